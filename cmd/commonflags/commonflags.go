@@ -19,15 +19,20 @@
 package commonflags
 
 import (
+	"fmt"
 	"path"
+	"strings"
 )
 
 const (
 	CcompilersDefault = "clang-5.0,gcc"
 	CcompilersUsage   = `comma-separated list of C compilers`
 
-	CformatterDefault = "clang-format-5.0"
-	CformatterUsage   = `C formatter`
+	CformatterDefault = ""
+	CformatterUsage   = `optional C formatter to run after the built-in one, e.g. "clang-format-5.0"`
+
+	CxxcompilersDefault = "clang++-5.0,g++"
+	CxxcompilersUsage   = `comma-separated list of C++ compilers, for testing the generated C++ wrappers`
 
 	FocusDefault = ""
 	FocusUsage   = `comma-separated list of tests or benchmarks (name prefixes) to focus on, e.g. "wuffs_gif_decode"`
@@ -44,6 +49,9 @@ const (
 	RepsMin     = 0
 	RepsMax     = 1000000
 	RepsUsage   = `the number of repetitions per benchmark`
+
+	SanitizeDefault = ""
+	SanitizeUsage   = `comma-separated list of sanitizers to build tests with: "address", "memory" or "undefined"`
 )
 
 // TODO: do IsAlphaNumericIsh and IsValidUsePath belong in a separate package,
@@ -58,7 +66,7 @@ const (
 func IsAlphaNumericIsh(s string) bool {
 	for i := 0; i < len(s); i++ {
 		c := s[i]
-		if c == ',' || c == '-' || c == '.' || c == '/' || ('0' <= c && c <= '9') || ('A' <= c && c <= 'Z') ||
+		if c == '+' || c == ',' || c == '-' || c == '.' || c == '/' || ('0' <= c && c <= '9') || ('A' <= c && c <= 'Z') ||
 			c == '_' || ('a' <= c && c <= 'z') {
 			continue
 		}
@@ -70,3 +78,31 @@ func IsAlphaNumericIsh(s string) bool {
 func IsValidUsePath(s string) bool {
 	return s == path.Clean(s) && s != "" && s[0] != '.' && s[0] != '/'
 }
+
+// ParseSanitizers parses a comma-separated list of sanitizers, as per the
+// -sanitize flag. An empty string means no sanitizers.
+//
+// The memory sanitizer cannot be combined with the address sanitizer.
+func ParseSanitizers(commaSeparated string) ([]string, error) {
+	if commaSeparated == "" {
+		return nil, nil
+	}
+	ret := []string(nil)
+	seen := map[string]bool{}
+	for _, s := range strings.Split(commaSeparated, ",") {
+		switch s {
+		case "address", "memory", "undefined":
+			// No-op.
+		default:
+			return nil, fmt.Errorf("bad -sanitize flag value %q: unknown sanitizer %q", commaSeparated, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			ret = append(ret, s)
+		}
+	}
+	if seen["address"] && seen["memory"] {
+		return nil, fmt.Errorf("bad -sanitize flag value %q: cannot combine address and memory", commaSeparated)
+	}
+	return ret, nil
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// genAmalgamation writes a single-file C library, wuffs.h, in the style of
+// the stb libraries (https://github.com/nothings/stb). It is the
+// concatenation, in dependency order, of the base code and each package's
+// generated C code, like what script/inline-c-relative-includes.go produces
+// for a test program that #include's several packages. Unlike that script's
+// output, the base header and implementation code appears once, not once per
+// package, the implementation code is behind an #ifdef WUFFS_IMPLEMENTATION,
+// and each package is behind an #if, so that users can strip unused packages.
+func genAmalgamation(dstDir string, srcDir string, filenames []string) error {
+	pkgs := map[string]*amalgamatedPackage{}
+	base := (*amalgamatedPackage)(nil)
+	for _, filename := range filenames {
+		p, err := splitGeneratedC(filepath.Join(srcDir, filename+".c"))
+		if err != nil {
+			return err
+		}
+		p.path = filename
+		pkgs[filename] = p
+		if base == nil {
+			base = p
+		}
+	}
+	if base == nil {
+		return fmt.Errorf("genlib: no packages to amalgamate")
+	}
+
+	ordered, err := sortAmalgamatedPackages(pkgs)
+	if err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString(amalgamationPrologue)
+
+	// Enable each selected package's dependencies. Visiting the packages in
+	// reverse dependency order means that this handles indirect dependencies.
+	out.WriteString("#if defined(WUFFS_CONFIG__MODULES)\n")
+	for i := len(ordered) - 1; i >= 0; i-- {
+		p := ordered[i]
+		if len(p.uses) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "#if defined(%s)\n", p.moduleMacro())
+		for _, u := range p.uses {
+			fmt.Fprintf(out, "#define %s\n", pkgs[u].moduleMacro())
+		}
+		out.WriteString("#endif\n")
+	}
+	out.WriteString("#endif  // defined(WUFFS_CONFIG__MODULES)\n\n")
+
+	out.WriteString(base.baseHeader)
+	out.WriteString("\n")
+	for _, p := range ordered {
+		p.writeSection(out, "HEADER", p.header)
+	}
+	out.WriteString("#endif  // WUFFS_INCLUDE_GUARD\n\n")
+
+	out.WriteString("#if defined(WUFFS_IMPLEMENTATION) && !defined(WUFFS_IMPLEMENTATION_GUARD)\n")
+	out.WriteString("#define WUFFS_IMPLEMENTATION_GUARD\n\n")
+	out.WriteString(base.baseImpl)
+	out.WriteString("\n")
+	for _, p := range ordered {
+		p.writeSection(out, "IMPLEMENTATION", p.impl)
+	}
+	out.WriteString("#endif  // WUFFS_IMPLEMENTATION\n")
+
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+	outFilename := filepath.Join(dstDir, "wuffs.h")
+	if err := ioutil.WriteFile(outFilename, out.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("genlib: %s\n", outFilename)
+	return nil
+}
+
+const amalgamationPrologue = `#ifndef WUFFS_INCLUDE_GUARD
+#define WUFFS_INCLUDE_GUARD
+
+// Code generated by "wuffs-c genlib -amalgamate". DO NOT EDIT.
+
+// This is a single-file version of the Wuffs C library. In exactly one C (or
+// C++) file, #define WUFFS_IMPLEMENTATION before #include'ing this file. In
+// every other file, just #include this file, which then acts as a regular
+// header file.
+//
+// By default, every package is enabled. To enable only some packages, and
+// their dependencies, #define WUFFS_CONFIG__MODULES and then one
+// WUFFS_CONFIG__MODULE__FOO macro per package, such as
+// WUFFS_CONFIG__MODULE__GIF for the std/gif package, before #include'ing this
+// file. Those macros should be the same for every #include of this file.
+
+`
+
+type amalgamatedPackage struct {
+	path       string
+	uses       []string
+	baseHeader string
+	baseImpl   string
+	header     string
+	impl       string
+}
+
+func (p *amalgamatedPackage) moduleMacro() string {
+	return "WUFFS_CONFIG__MODULE__" + strings.ToUpper(path.Base(p.path))
+}
+
+func (p *amalgamatedPackage) writeSection(out *bytes.Buffer, section string, code string) {
+	m := p.moduleMacro()
+	fmt.Fprintf(out, "// ---------------- BEGIN %s %q\n\n", section, p.path)
+	fmt.Fprintf(out, "#if !defined(WUFFS_CONFIG__MODULES) || defined(%s)\n\n", m)
+	out.WriteString(strings.TrimSpace(code))
+	fmt.Fprintf(out, "\n\n#endif  // !defined(WUFFS_CONFIG__MODULES) || defined(%s)\n\n", m)
+	fmt.Fprintf(out, "// ---------------- END   %s %q\n\n", section, p.path)
+}
+
+// splitGeneratedC splits a "wuffs-c gen" output file into the base header and
+// implementation code and the package-specific header and implementation
+// code. It also returns the packages used (i.e. depended on) by the package.
+func splitGeneratedC(filename string) (*amalgamatedPackage, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := string(src)
+	const headerEndsHere = "\n// C HEADER ENDS HERE.\n"
+	i := strings.Index(s, headerEndsHere)
+	if i < 0 {
+		return nil, fmt.Errorf("genlib: %s did not contain %q", filename, headerEndsHere)
+	}
+	p := &amalgamatedPackage{}
+
+	header, baseHeader, err := cutBlock(s[:i], "#ifndef WUFFS_BASE_HEADER_H\n", "#endif  // WUFFS_BASE_HEADER_H\n")
+	if err != nil {
+		return nil, fmt.Errorf("genlib: %s: %v", filename, err)
+	}
+	impl, baseImpl, err := cutBlock(s[i+len(headerEndsHere):], "#ifndef WUFFS_BASE_IMPL_H\n", "#endif  // WUFFS_BASE_IMPL_H\n")
+	if err != nil {
+		return nil, fmt.Errorf("genlib: %s: %v", filename, err)
+	}
+	p.baseHeader, p.baseImpl, p.impl = baseHeader, baseImpl, impl
+
+	// Remove the used packages' header code, as that code will appear in the
+	// amalgamation anyway. Use blocks nest: a used package's header code
+	// contains its own uses' header code. Only the top-level uses are direct
+	// dependencies.
+	const beginUse, endUse = "// ---------------- BEGIN USE ", "// ---------------- END   USE "
+	lines := strings.SplitAfter(header, "\n")
+	kept := []string(nil)
+	depth := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, beginUse):
+			if depth == 0 {
+				u := strings.Trim(trimmed[len(beginUse):], `"`)
+				p.uses = append(p.uses, u)
+			}
+			depth++
+		case strings.HasPrefix(trimmed, endUse):
+			if depth == 0 {
+				return nil, fmt.Errorf("genlib: %s: unmatched %q", filename, endUse)
+			}
+			depth--
+		case depth > 0:
+			// No-op.
+		case trimmed == "// Code generated by wuffs-c. DO NOT EDIT.":
+			// No-op. The amalgamation has its own "Code generated" comment.
+		default:
+			kept = append(kept, line)
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("genlib: %s: unterminated %q", filename, beginUse)
+	}
+	p.header = strings.Join(kept, "")
+	return p, nil
+}
+
+// cutBlock returns s with the block from begin to end (inclusive) removed, as
+// well as that block.
+func cutBlock(s string, begin string, end string) (remaining string, block string, err error) {
+	i := strings.Index(s, begin)
+	if i < 0 {
+		return "", "", fmt.Errorf("could not find %q", begin)
+	}
+	j := strings.Index(s[i:], end)
+	if j < 0 {
+		return "", "", fmt.Errorf("could not find %q", end)
+	}
+	j += i + len(end)
+	return s[:i] + s[j:], s[i:j], nil
+}
+
+// sortAmalgamatedPackages returns the packages in dependency order:
+// dependencies before their dependents, and otherwise sorted by path.
+func sortAmalgamatedPackages(pkgs map[string]*amalgamatedPackage) ([]*amalgamatedPackage, error) {
+	paths := make([]string, 0, len(pkgs))
+	for k := range pkgs {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+
+	ret := []*amalgamatedPackage(nil)
+	const (
+		unvisited = 0
+		visiting  = 1
+		visited   = 2
+	)
+	states := map[string]int{}
+	var visit func(string) error
+	visit = func(k string) error {
+		switch states[k] {
+		case visiting:
+			return fmt.Errorf("genlib: dependency cycle involving %q", k)
+		case visited:
+			return nil
+		}
+		states[k] = visiting
+		p := pkgs[k]
+		for _, u := range p.uses {
+			if pkgs[u] == nil {
+				return fmt.Errorf("genlib: %q uses %q, which is not being amalgamated", k, u)
+			}
+			if err := visit(u); err != nil {
+				return err
+			}
+		}
+		states[k] = visited
+		ret = append(ret, p)
+		return nil
+	}
+	for _, k := range paths {
+		if err := visit(k); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+// genCDir is the gen/c directory, relative to this package's directory.
+var genCDir = filepath.Join("..", "..", "gen", "c")
+
+// TestSplitGeneratedCNestedUses checks that a package's uses are only its
+// top-level "BEGIN USE" blocks, not the blocks nested inside them. For
+// example, std/ico uses std/png, whose header code contains std/zlib's, which
+// contains std/adler32's and std/deflate's.
+func TestSplitGeneratedCNestedUses(tt *testing.T) {
+	testCases := []struct {
+		pkg  string
+		want []string
+	}{
+		{"std/gzip", []string{"std/crc32", "std/deflate"}},
+		{"std/ico", []string{"std/bmp", "std/png"}},
+		{"std/png", []string{"std/crc32", "std/zlib"}},
+	}
+	for _, tc := range testCases {
+		p, err := splitGeneratedC(filepath.Join(genCDir, filepath.FromSlash(tc.pkg)+".c"))
+		if err != nil {
+			tt.Errorf("%s: %v", tc.pkg, err)
+			continue
+		}
+		if !reflect.DeepEqual(p.uses, tc.want) {
+			tt.Errorf("%s: uses: got %q, want %q", tc.pkg, p.uses, tc.want)
+		}
+		if strings.Contains(p.header, "// ---------------- END   USE ") {
+			tt.Errorf("%s: header contains a used package's header code", tc.pkg)
+		}
+	}
+}
+
+func TestSplitGeneratedCUnbalancedUses(tt *testing.T) {
+	const prefix = "" +
+		"#ifndef WUFFS_BASE_HEADER_H\n#endif  // WUFFS_BASE_HEADER_H\n"
+	const suffix = "" +
+		"\n// C HEADER ENDS HERE.\n" +
+		"#ifndef WUFFS_BASE_IMPL_H\n#endif  // WUFFS_BASE_IMPL_H\n"
+	dir, err := ioutil.TempDir("", "wuffs-c-amalgamate")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, body := range []string{
+		"// ---------------- BEGIN USE \"std/a\"\n",
+		"// ---------------- END   USE \"std/a\"\n",
+		"" +
+			"// ---------------- BEGIN USE \"std/a\"\n" +
+			"// ---------------- BEGIN USE \"std/b\"\n" +
+			"// ---------------- END   USE \"std/b\"\n",
+	} {
+		filename := filepath.Join(dir, "x.c")
+		if err := ioutil.WriteFile(filename, []byte(prefix+body+suffix), 0644); err != nil {
+			tt.Fatal(err)
+		}
+		if _, err := splitGeneratedC(filename); err == nil {
+			tt.Errorf("#%d: got nil error, want non-nil", i)
+		}
+	}
+}
+
+// TestAmalgamationCompiles amalgamates every std package's generated C code
+// and compiles the resultant wuffs.h, both with every package and with
+// WUFFS_CONFIG__MODULES and only one WUFFS_CONFIG__MODULE__FOO macro.
+func TestAmalgamationCompiles(tt *testing.T) {
+	cc := ""
+	for _, c := range strings.Split(cf.CcompilersDefault, ",") {
+		if _, err := exec.LookPath(c); err == nil {
+			cc = c
+			break
+		}
+	}
+	if cc == "" {
+		tt.Skipf("no C compiler (from %q) found", cf.CcompilersDefault)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(genCDir, "std", "*.c"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	filenames := []string(nil)
+	for _, m := range matches {
+		filenames = append(filenames, "std/"+strings.TrimSuffix(filepath.Base(m), ".c"))
+	}
+
+	dir, err := ioutil.TempDir("", "wuffs-c-amalgamate")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := genAmalgamation(dir, genCDir, filenames); err != nil {
+		tt.Fatal(err)
+	}
+	const program = "" +
+		"#define WUFFS_IMPLEMENTATION\n" +
+		"#include \"wuffs.h\"\n" +
+		"\n" +
+		"int main(int argc, char** argv) {\n" +
+		"  return 0;\n" +
+		"}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.c"), []byte(program), 0644); err != nil {
+		tt.Fatal(err)
+	}
+
+	configs := [][]string{nil}
+	for _, f := range filenames {
+		p := &amalgamatedPackage{path: f}
+		configs = append(configs, []string{"-DWUFFS_CONFIG__MODULES", "-D" + p.moduleMacro()})
+	}
+	for _, config := range configs {
+		args := []string{"-std=c99", "-Wall", "-Werror", "-fsyntax-only"}
+		args = append(args, config...)
+		args = append(args, filepath.Join(dir, "main.c"))
+		if out, err := exec.Command(cc, args...).CombinedOutput(); err != nil {
+			tt.Errorf("%s %q: %v\n%s", cc, config, err, out)
+		}
+	}
+}
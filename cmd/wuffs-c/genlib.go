@@ -27,9 +27,13 @@ import (
 
 func doGenlib(args []string) error {
 	flags := flag.FlagSet{}
+	amalgamateFlag := flags.Bool("amalgamate", false,
+		"whether to generate a single-file wuffs.h library instead of object files")
 	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
 	dstdirFlag := flags.String("dstdir", "", "directory containing the object files ")
 	srcdirFlag := flags.String("srcdir", "", "directory containing the C source files")
+	targetFlag := flags.String("target", "",
+		`the compilation target: empty for the host, or "wasm32" for a WebAssembly module`)
 	if err := flags.Parse(args); err != nil {
 		return err
 	}
@@ -42,12 +46,37 @@ func doGenlib(args []string) error {
 		return fmt.Errorf("empty -srcdir flag")
 	}
 
+	switch *targetFlag {
+	case "", "wasm32":
+		// No-op.
+	default:
+		return fmt.Errorf("bad -target flag value %q", *targetFlag)
+	}
+
+	if *amalgamateFlag {
+		if *targetFlag != "" {
+			return fmt.Errorf("the -target flag does not apply to -amalgamate")
+		}
+		return genAmalgamation(*dstdirFlag, *srcdirFlag, args)
+	}
+
 	for _, cc := range strings.Split(*ccompilersFlag, ",") {
 		cc = strings.TrimSpace(cc)
 		if cc == "" {
 			continue
 		}
 
+		if *targetFlag == "wasm32" {
+			outDir := filepath.Join(*dstdirFlag, cc+"-wasm32")
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return err
+			}
+			if err := genWasm32(outDir, *srcdirFlag, cc, args); err != nil {
+				return err
+			}
+			continue
+		}
+
 		for _, dynamism := range []string{"static", "dynamic"} {
 			outDir := filepath.Join(*dstdirFlag, cc+"-"+dynamism)
 			if err := os.MkdirAll(outDir, 0755); err != nil {
@@ -56,6 +56,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -660,6 +692,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -829,6 +911,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -927,6 +1048,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -938,19 +1104,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -990,16 +1171,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1053,6 +1244,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1062,18 +1257,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1185,4 +1387,15 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
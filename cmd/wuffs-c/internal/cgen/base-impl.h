@@ -20,6 +20,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -169,6 +176,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -511,6 +541,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -553,3 +603,54 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   o->private_impl.bounds[0] = mark;
   return ((wuffs_base__empty_struct){});
 }
+
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
@@ -71,6 +71,8 @@ func (g *gen) writeBuiltinCall(b *buffer, n *a.Expr, rp replacementPolicy, depth
 			return g.writeBuiltinIOReader(b, recv, method.Ident(), n.Args(), rp, depth)
 		case t.IDIOWriter:
 			return g.writeBuiltinIOWriter(b, recv, method.Ident(), n.Args(), rp, depth, n.BoundsCheckOptimized())
+		case t.IDRectIEU32:
+			return g.writeBuiltinRect(b, recv, method.Ident(), n.Args(), rp, depth)
 		case t.IDStatus:
 			return g.writeBuiltinStatus(b, recv, method.Ident(), n.Args(), rp, depth)
 		}
@@ -78,35 +80,40 @@ func (g *gen) writeBuiltinCall(b *buffer, n *a.Expr, rp replacementPolicy, depth
 	return errNoSuchBuiltin
 }
 
+// writeBuiltinRect writes a rect_ie_u32 getter, such as min_inclusive_x, as a
+// C struct field access. The setters are wuffs_base__rect_ie_u32__etc
+// functions, as if they were user-defined methods.
+func (g *gen) writeBuiltinRect(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	if len(args) != 0 {
+		return errNoSuchBuiltin
+	}
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	b.printf(".%s", method.Str(g.tm))
+	return nil
+}
+
 func (g *gen) writeBuiltinIO(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
 	switch method {
 	case t.IDAvailable:
-		p0, p1 := "", ""
-		// TODO: don't hard-code these.
-		switch recv.Str(g.tm) {
-		case "in.dst":
-			p0 = "iobounds1_dst"
-			p1 = "ioptr_dst"
-		case "in.src":
-			p0 = "iobounds1_src"
-			p1 = "ioptr_src"
-		case "w":
-			p0 = "iobounds1_w"
-			p1 = "ioptr_w"
-		}
-		if p0 == "" {
-			return fmt.Errorf(`TODO: cgen a "foo.available" expression`)
-		}
-		b.printf("((uint64_t)(%s - %s))", p0, p1)
+		name, err := ioName(g, recv)
+		if err != nil {
+			return err
+		}
+		b.printf("((uint64_t)(iobounds1_%s - ioptr_%s))", name, name)
 		return nil
 
 	case t.IDSet:
-		typ := "reader"
+		if recv.Operator() != 0 {
+			return fmt.Errorf("TODO: cgen a %q.set call", recv.Str(g.tm))
+		}
+		typ, name := "reader", recv.Ident().Str(g.tm)
 		if len(args) == 1 {
 			typ = "writer"
 		}
-		// TODO: don't hard-code v_w and u_w.
-		b.printf("wuffs_base__io_%s__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,", typ)
+		b.printf("wuffs_base__io_%s__set(&%s%s, &%s%s, &ioptr_%s, &iobounds1_%s,",
+			typ, vPrefix, name, uPrefix, name, name, name)
 		return g.writeArgs(b, args, rp, depth)
 
 	}
@@ -128,7 +135,7 @@ func (g *gen) writeBuiltinIOReader(b *buffer, recv *a.Expr, method t.ID, args []
 	case t.IDSinceMark:
 		b.printf("((wuffs_base__slice_u8){ "+
 			".ptr = %ssrc.private_impl.bounds[0], "+
-			".len = (size_t)(ioptr_src - %ssrc.private_impl.bounds[0]), })",
+			".len = (size_t)(ioptr_src - %ssrc.private_impl.bounds[0])})",
 			aPrefix, aPrefix)
 		return nil
 	}
@@ -136,39 +143,87 @@ func (g *gen) writeBuiltinIOReader(b *buffer, recv *a.Expr, method t.ID, args []
 	return g.writeBuiltinIO(b, recv, method, args, rp, depth)
 }
 
+// ioName returns the suffix of the C ioptr_etc and iobounds1_etc local
+// variables for an io_reader or io_writer receiver: "dst" for the "in.dst"
+// argument or "w" for a "w" local variable.
+func ioName(g *gen, recv *a.Expr) (string, error) {
+	switch recv.Operator() {
+	case 0:
+		return recv.Ident().Str(g.tm), nil
+	case t.IDDot:
+		if lhs := recv.LHS().Expr(); lhs.Operator() == 0 && lhs.Ident() == t.IDIn {
+			return recv.Ident().Str(g.tm), nil
+		}
+	}
+	return "", fmt.Errorf("TODO: cgen an I/O method call on %q", recv.Str(g.tm))
+}
+
 // TODO: remove bcoHack.
 func (g *gen) writeBuiltinIOWriter(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32, bcoHack bool) error {
 	// TODO: don't hard-code the recv being a_dst.
 	switch method {
 	case t.IDCopyFromHistory32:
+		argExprs := []buffer(nil)
+		for _, o := range args {
+			x := buffer(nil)
+			if err := g.writeExpr(&x, o.Arg().Value(), rp, depth); err != nil {
+				return err
+			}
+			argExprs = append(argExprs, x)
+		}
+
 		bco := ""
 		if bcoHack {
 			bco = "__bco"
+			if g.debugAsserts {
+				if len(argExprs) != 2 {
+					return fmt.Errorf("internal error: bad copy_from_history32 arguments")
+				}
+				// Check the bounds check optimization's preconditions. See
+				// the wuffs_base__io_writer__copy_from_history32__bco comment.
+				b.printf("(assert(((%s) > 0) && "+
+					"((uint64_t)(%s) <= (uint64_t)(ioptr_dst - %sdst.private_impl.bounds[0])) && "+
+					"((uint64_t)(%s) <= (uint64_t)(iobounds1_dst - ioptr_dst))), ",
+					argExprs[0], argExprs[0], aPrefix, argExprs[1])
+			}
 		}
 		b.printf("wuffs_base__io_writer__copy_from_history32%s("+
 			"&ioptr_dst, %sdst.private_impl.bounds[0], iobounds1_dst",
 			bco, aPrefix)
-		for _, o := range args {
+		for _, x := range argExprs {
 			b.writeb(',')
-			if err := g.writeExpr(b, o.Arg().Value(), rp, depth); err != nil {
-				return err
-			}
+			b.writex(x)
 		}
 		b.writeb(')')
+		if bcoHack && g.debugAsserts {
+			b.writeb(')')
+		}
 		return nil
 
 	case t.IDCopyFromReader32:
-		b.printf("wuffs_base__io_writer__copy_from_reader32(&ioptr_dst, iobounds1_dst,")
+		name, err := ioName(g, recv)
+		if err != nil {
+			return err
+		}
+		b.printf("wuffs_base__io_writer__copy_from_reader32(&ioptr_%s, iobounds1_%s,", name, name)
 		// TODO: don't assume that the first argument is "in.src".
 		b.printf("&ioptr_src, iobounds1_src,")
 		return g.writeArgs(b, args[1:], rp, depth)
 
 	case t.IDCopyFromSlice:
-		b.printf("wuffs_base__io_writer__copy_from_slice(&ioptr_dst, iobounds1_dst,")
+		name, err := ioName(g, recv)
+		if err != nil {
+			return err
+		}
+		b.printf("wuffs_base__io_writer__copy_from_slice(&ioptr_%s, iobounds1_%s,", name, name)
 		return g.writeArgs(b, args, rp, depth)
 
 	case t.IDCopyFromSlice32:
-		b.printf("wuffs_base__io_writer__copy_from_slice32(&ioptr_dst, iobounds1_dst,")
+		name, err := ioName(g, recv)
+		if err != nil {
+			return err
+		}
+		b.printf("wuffs_base__io_writer__copy_from_slice32(&ioptr_%s, iobounds1_%s,", name, name)
 		return g.writeArgs(b, args, rp, depth)
 
 	case t.IDSetMark:
@@ -182,7 +237,7 @@ func (g *gen) writeBuiltinIOWriter(b *buffer, recv *a.Expr, method t.ID, args []
 	case t.IDSinceMark:
 		b.printf("((wuffs_base__slice_u8){ "+
 			".ptr = %sdst.private_impl.bounds[0], "+
-			".len = (size_t)(ioptr_dst - %sdst.private_impl.bounds[0]), })",
+			".len = (size_t)(ioptr_dst - %sdst.private_impl.bounds[0])})",
 			aPrefix, aPrefix)
 		return nil
 	}
@@ -225,11 +280,9 @@ func (g *gen) writeBuiltinNumType(b *buffer, recv *a.Expr, method t.ID, args []*
 		return nil
 
 	case t.IDMax:
-		b.writes("wuffs_base__u")
-		if sz, err := g.sizeof(recv.MType()); err != nil {
+		b.writes("wuffs_base__")
+		if err := g.writeNumTypePrefix(b, recv.MType()); err != nil {
 			return err
-		} else {
-			b.printf("%d", 8*sz)
 		}
 		b.writes("__max(")
 		if err := g.writeExpr(b, recv, rp, depth); err != nil {
@@ -243,11 +296,9 @@ func (g *gen) writeBuiltinNumType(b *buffer, recv *a.Expr, method t.ID, args []*
 		return nil
 
 	case t.IDMin:
-		b.writes("wuffs_base__u")
-		if sz, err := g.sizeof(recv.MType()); err != nil {
+		b.writes("wuffs_base__")
+		if err := g.writeNumTypePrefix(b, recv.MType()); err != nil {
 			return err
-		} else {
-			b.printf("%d", 8*sz)
 		}
 		b.writes("__min(")
 		if err := g.writeExpr(b, recv, rp, depth); err != nil {
@@ -263,6 +314,20 @@ func (g *gen) writeBuiltinNumType(b *buffer, recv *a.Expr, method t.ID, args []*
 	return errNoSuchBuiltin
 }
 
+// writeNumTypePrefix writes the "u32" in "wuffs_base__u32__min", or the "i64"
+// in "wuffs_base__i64__max".
+func (g *gen) writeNumTypePrefix(b *buffer, typ *a.TypeExpr) error {
+	if qid := typ.QID(); typ.Decorator() == 0 && qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDI8, t.IDI16, t.IDI32, t.IDI64,
+			t.IDU8, t.IDU16, t.IDU32, t.IDU64:
+			b.writes(qid[1].Str(g.tm))
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported numeric type %q", typ.Str(g.tm))
+}
+
 func (g *gen) writeBuiltinSlice(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
 	switch method {
 	case t.IDCopyFromSlice:
@@ -282,6 +347,15 @@ func (g *gen) writeBuiltinSlice(b *buffer, recv *a.Expr, method t.ID, args []*a.
 		b.writes(".len))")
 		return nil
 
+	case t.IDPrefix:
+		// TODO: don't assume that the slice is a slice of base.u8.
+		b.writes("wuffs_base__slice_u8__prefix(")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writeb(',')
+		return g.writeArgs(b, args, rp, depth)
+
 	case t.IDSuffix:
 		// TODO: don't assume that the slice is a slice of base.u8.
 		b.writes("wuffs_base__slice_u8__suffix(")
@@ -391,6 +465,8 @@ func (g *gen) writeBuiltinCallSuspendibles(b *buffer, n *a.Expr, depth uint32) e
 			if !n.ProvenNotToSuspend() {
 				b.printf("if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) { goto short_read_src; }")
 				g.currFunk.shortReads = append(g.currFunk.shortReads, "src")
+			} else if g.debugAsserts {
+				b.printf("assert(ioptr_src < iobounds1_src);\n")
 			}
 
 			// TODO: watch for passing an array type to writeCTypeName? In C, an
@@ -430,7 +506,7 @@ func (g *gen) writeBuiltinCallSuspendibles(b *buffer, n *a.Expr, depth uint32) e
 		case t.IDReadU64LE:
 			return g.writeReadUXX(b, n, "src", 64, "le")
 
-		case t.IDSkip32:
+		case t.IDSkip32, t.IDSkip64:
 			g.currFunk.usesScratch = true
 			// TODO: don't hard-code [0], and allow recursive coroutines.
 			scratchName := fmt.Sprintf("self->private_impl.%s%s[0].scratch",
@@ -459,22 +535,57 @@ func (g *gen) writeBuiltinCallSuspendibles(b *buffer, n *a.Expr, depth uint32) e
 		}
 
 	} else {
+		name, err := ioName(g, recv)
+		if err != nil {
+			return err
+		}
 		switch method.Ident() {
 		case t.IDWriteU8:
 			if !n.ProvenNotToSuspend() {
-				b.printf("if (ioptr_dst == iobounds1_dst) { status = %sSUSPENSION_SHORT_WRITE;",
-					g.PKGPREFIX)
+				b.printf("if (ioptr_%s == iobounds1_%s) { status = %sSUSPENSION_SHORT_WRITE;",
+					name, name, g.PKGPREFIX)
 				b.writes("goto suspend;")
 				b.writes("}\n")
+			} else if g.debugAsserts {
+				b.printf("assert(ioptr_%s < iobounds1_%s);\n", name, name)
 			}
 
-			b.printf("*ioptr_dst++ = ")
+			b.printf("*ioptr_%s++ = ", name)
 			x := n.Args()[0].Arg().Value()
 			if err := g.writeExpr(b, x, replaceCallSuspendibles, depth); err != nil {
 				return err
 			}
 			b.writes(";\n")
 			return nil
+
+		case t.IDWriteU16BE:
+			return g.writeWriteUXX(b, n, name, 16, "be", depth)
+		case t.IDWriteU16LE:
+			return g.writeWriteUXX(b, n, name, 16, "le", depth)
+		case t.IDWriteU24BE:
+			return g.writeWriteUXX(b, n, name, 24, "be", depth)
+		case t.IDWriteU24LE:
+			return g.writeWriteUXX(b, n, name, 24, "le", depth)
+		case t.IDWriteU32BE:
+			return g.writeWriteUXX(b, n, name, 32, "be", depth)
+		case t.IDWriteU32LE:
+			return g.writeWriteUXX(b, n, name, 32, "le", depth)
+		case t.IDWriteU40BE:
+			return g.writeWriteUXX(b, n, name, 40, "be", depth)
+		case t.IDWriteU40LE:
+			return g.writeWriteUXX(b, n, name, 40, "le", depth)
+		case t.IDWriteU48BE:
+			return g.writeWriteUXX(b, n, name, 48, "be", depth)
+		case t.IDWriteU48LE:
+			return g.writeWriteUXX(b, n, name, 48, "le", depth)
+		case t.IDWriteU56BE:
+			return g.writeWriteUXX(b, n, name, 56, "be", depth)
+		case t.IDWriteU56LE:
+			return g.writeWriteUXX(b, n, name, 56, "le", depth)
+		case t.IDWriteU64BE:
+			return g.writeWriteUXX(b, n, name, 64, "be", depth)
+		case t.IDWriteU64LE:
+			return g.writeWriteUXX(b, n, name, 64, "le", depth)
 		}
 	}
 	return errNoSuchBuiltin
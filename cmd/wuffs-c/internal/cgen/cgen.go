@@ -42,6 +42,11 @@ import (
 var (
 	zero = big.NewInt(0)
 	one  = big.NewInt(1)
+
+	// maxInt64 is the largest constant that C accepts without a suffix. A
+	// larger (u64) constant needs a "u" suffix, otherwise C compilers warn
+	// that the "integer constant is so large that it is unsigned".
+	maxInt64 = big.NewInt((1 << 63) - 1)
 )
 
 // Prefixes are prepended to names to form a namespace and to avoid e.g.
@@ -66,6 +71,24 @@ const (
 func Do(args []string) error {
 	flags := flag.FlagSet{}
 	cformatterFlag := flags.String("cformatter", cf.CformatterDefault, cf.CformatterUsage)
+	cplusplusWrappersFlag := flags.Bool("cplusplus_wrappers", true,
+		`whether to write C++ wrapper classes, under "#ifdef __cplusplus", into the header`)
+	debugAssertsFlag := flags.Bool("debug_asserts", false,
+		`whether to re-check, with C assert calls, the facts that the Wuffs compiler proved`)
+	filenameLineCommentsFlag := flags.Bool("filename_line_comments", false,
+		`whether to print "// foo.wuffs:123" comments before each statement`)
+	jsModuleFlag := flags.String("js_module", "",
+		"the file to write a JavaScript module (wrapping a genlib -target=wasm32 module) to, or empty for none")
+	lineDirectivesFlag := flags.Bool("line_directives", false,
+		`whether to print "#line 123 \"foo.wuffs\"" directives before each statement`)
+	cgoLibraryFlag := flags.String("cgo_library", "",
+		"the genlib static library that the -cgo_package package links with")
+	cgoPackageFlag := flags.String("cgo_package", "",
+		"the file to write a cgo Go package (wrapping a genlib static library) to, or empty for none")
+	pythonModuleFlag := flags.String("python_module", "",
+		"the file to write a ctypes Python module (wrapping a genlib shared library) to, or empty for none")
+	sourceMapFlag := flags.String("source_map", "",
+		"the file to write a JSON source map (from C lines to Wuffs lines) to, or empty for none")
 
 	return generate.Do(&flags, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
 		if !cf.IsAlphaNumericIsh(*cformatterFlag) {
@@ -79,20 +102,60 @@ func Do(args []string) error {
 			tm:        tm,
 			checker:   c,
 			files:     files,
+
+			filenameLineComments: *filenameLineCommentsFlag,
+			lineMarkers:          *lineDirectivesFlag || (*sourceMapFlag != ""),
+			cplusplusWrappers:    *cplusplusWrappersFlag,
+			debugAsserts:         *debugAssertsFlag,
 		}
 		unformatted, err := g.generate()
 		if err != nil {
 			return nil, err
 		}
-		stdout := &bytes.Buffer{}
-		cmd := exec.Command(*cformatterFlag, "-style=Chromium")
-		cmd.Stdin = bytes.NewReader(unformatted)
-		cmd.Stdout = stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		if *cgoPackageFlag != "" {
+			if *cgoLibraryFlag == "" {
+				return nil, fmt.Errorf("the -cgo_package flag requires a -cgo_library flag")
+			}
+			if err := g.writeCgoPackage(*cgoPackageFlag, *cgoLibraryFlag); err != nil {
+				return nil, err
+			}
+		}
+		if *jsModuleFlag != "" {
+			if err := g.writeJSModule(*jsModuleFlag); err != nil {
+				return nil, err
+			}
+		}
+		if *pythonModuleFlag != "" {
+			if err := g.writePythonModule(*pythonModuleFlag); err != nil {
+				return nil, err
+			}
+		}
+		formatted, err := formatC(unformatted)
+		if err != nil {
 			return nil, err
 		}
-		return stdout.Bytes(), nil
+		if *cformatterFlag != "" {
+			stdout := &bytes.Buffer{}
+			cmd := exec.Command(*cformatterFlag, "-style=Chromium")
+			cmd.Stdin = bytes.NewReader(formatted)
+			cmd.Stdout = stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return nil, err
+			}
+			formatted = stdout.Bytes()
+		}
+		if !g.lineMarkers {
+			return formatted, nil
+		}
+
+		out, m := g.resolveLineMarkers(formatted, pkgName+".c", *lineDirectivesFlag)
+		if *sourceMapFlag != "" {
+			if err := m.writeFile(*sourceMapFlag); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
 	})
 }
 
@@ -177,6 +240,28 @@ type gen struct {
 	currFunk  funk
 	funks     map[t.QQID]funk
 	wuffsRoot string
+
+	// cplusplusWrappers is whether to write C++ wrapper classes into the
+	// generated header.
+	cplusplusWrappers bool
+
+	// debugAsserts is whether to write C assert calls for every assert, pre,
+	// inv and post condition, and for every index bound and bounds check
+	// optimization, that the Wuffs compiler proved (and would otherwise
+	// assume). It catches bugs in that compiler, not in the Wuffs code.
+	debugAsserts bool
+
+	// filenameLineComments is whether to print "// foo.wuffs:123\n" comments
+	// in the generated code.
+	filenameLineComments bool
+
+	// lineMarkers is whether to print "// wuffs-line-begin" and "//
+	// wuffs-line-end" comments around each statement, to be resolved (after
+	// any C formatting) into #line directives or a source map. lineSources
+	// lists the Wuffs file names that those comments refer to by index.
+	lineMarkers       bool
+	lineSources       []string
+	lineSourceIndexes map[string]int
 }
 
 func (g *gen) generate() ([]byte, error) {
@@ -226,7 +311,7 @@ func (g *gen) genHeader(b *buffer) error {
 	b.printf("#ifndef %s\n#define %s\n\n", includeGuard, includeGuard)
 
 	b.printf("// Code generated by wuffs-c. DO NOT EDIT.\n\n")
-	b.writes(baseHeader)
+	b.writeVerbatim(baseHeader)
 	b.writeb('\n')
 
 	b.writes("// ---------------- Use Declarations\n\n")
@@ -243,7 +328,7 @@ func (g *gen) genHeader(b *buffer) error {
 
 	b.printf("typedef int32_t %sstatus;\n\n", g.pkgPrefix)
 	pkgID := g.checker.PackageID()
-	b.printf("#define %spackageid %d // 0x%08X\n\n", g.pkgPrefix, pkgID, pkgID)
+	b.printf("#define %spackageid %d  // 0x%08X\n\n", g.pkgPrefix, pkgID, pkgID)
 
 	for i, z := range builtin.StatusList {
 		code := uint32(0)
@@ -251,7 +336,7 @@ func (g *gen) genHeader(b *buffer) error {
 			code |= 1 << 31
 		}
 		code |= uint32(i)
-		b.printf("#define %s %d // 0x%08X\n", strings.ToUpper(g.cName(z.String())), int32(code), code)
+		b.printf("#define %s %d  // 0x%08X\n", strings.ToUpper(g.cName(z.String())), int32(code), code)
 	}
 	b.writes("\n")
 
@@ -264,7 +349,7 @@ func (g *gen) genHeader(b *buffer) error {
 			code |= 1 << 31
 		}
 		code |= uint32(i)
-		b.printf("#define %s %d // 0x%08X\n", s.name, int32(code), code)
+		b.printf("#define %s %d  // 0x%08X\n", s.name, int32(code), code)
 	}
 	b.writes("\n")
 
@@ -292,19 +377,39 @@ func (g *gen) genHeader(b *buffer) error {
 		}
 	}
 
+	b.writes("// ---------------- Public Saved State Prototypes\n\n")
+	for _, n := range g.structList {
+		if n.Public() {
+			if err := g.writeStatePrototypes(b, n); err != nil {
+				return err
+			}
+		}
+	}
+
 	b.writes("// ---------------- Public Function Prototypes\n\n")
 	if err := g.forEachFunc(b, pubOnly, (*gen).writeFuncPrototype); err != nil {
 		return err
 	}
 
 	b.writes("\n#ifdef __cplusplus\n}  // extern \"C\"\n#endif\n\n")
+
+	if g.cplusplusWrappers {
+		if err := g.writeCPlusPlusWrappers(b); err != nil {
+			return err
+		}
+	}
+
 	b.printf("#endif  // %s\n\n", includeGuard)
 	return nil
 }
 
 func (g *gen) genImpl(b *buffer) error {
+	if g.debugAsserts {
+		b.writes("#include <assert.h>\n\n")
+	}
+
 	b.writes("#ifndef WUFFS_BASE_IMPL_H\n#define WUFFS_BASE_IMPL_H\n\n")
-	b.writes(baseImpl)
+	b.writeVerbatim(baseImpl)
 	b.writes("\n")
 	b.printf("static const char* wuffs_base__status__strings[%d] = {\n", len(builtin.StatusList))
 	for _, z := range builtin.StatusList {
@@ -354,6 +459,15 @@ func (g *gen) genImpl(b *buffer) error {
 		}
 	}
 
+	b.writes("// ---------------- Private Saved State Prototypes\n\n")
+	for _, n := range g.structList {
+		if !n.Public() {
+			if err := g.writeStatePrototypes(b, n); err != nil {
+				return err
+			}
+		}
+	}
+
 	b.writes("// ---------------- Private Function Prototypes\n\n")
 	if err := g.forEachFunc(b, priOnly, (*gen).writeFuncPrototype); err != nil {
 		return err
@@ -371,6 +485,13 @@ func (g *gen) genImpl(b *buffer) error {
 		return err
 	}
 
+	b.writes("// ---------------- Saved State Implementations\n\n")
+	for _, n := range g.structList {
+		if err := g.writeStateImpls(b, n); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -599,7 +720,7 @@ func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
 	}
 
 	b.printf("} private_impl;\n } %s%s;\n\n", g.pkgPrefix, structName)
-	return nil
+	return g.writeStateLengthMacro(b, n)
 }
 
 var (
@@ -642,13 +763,13 @@ func (g *gen) writeUse(b *buffer, n *a.Use) error {
 	if i := bytes.Index(hdr, wuffsBaseHeaderHStart); i < 0 {
 		return fmt.Errorf("use %q: previously generated header %q could not be inlined", useDirname, hdrFilename)
 	} else {
-		b.Write(hdr[:i])
+		b.writeVerbatim(string(hdr[:i]))
 		hdr = hdr[i+len(wuffsBaseHeaderHStart):]
 	}
 	if i := bytes.Index(hdr, wuffsBaseHeaderHEnd); i < 0 {
 		return fmt.Errorf("use %q: previously generated header %q could not be inlined", useDirname, hdrFilename)
 	} else {
-		b.Write(hdr[i+len(wuffsBaseHeaderHEnd):])
+		b.writeVerbatim(string(hdr[i+len(wuffsBaseHeaderHEnd):]))
 	}
 
 	b.writeb('\n')
@@ -678,13 +799,30 @@ func (g *gen) writeInitializerPrototype(b *buffer, n *a.Struct) error {
 		return err
 	}
 	b.writes(";\n\n")
+	g.writeSizeofSignature(b, n, n.Public())
+	b.writes(";\n\n")
 	return nil
 }
 
+// writeSizeofSignature writes the signature of the function that returns
+// sizeof the struct, for callers (such as other programming languages'
+// foreign function interfaces) that cannot see the C struct definition.
+func (g *gen) writeSizeofSignature(b *buffer, n *a.Struct, public bool) {
+	structName := n.QID().Str(g.tm)
+	if public {
+		writeComment(b, "", "sizeof__"+g.pkgPrefix+structName+" returns sizeof("+g.pkgPrefix+structName+
+			"), for callers that cannot see the struct definition, such as other "+
+			"programming languages' foreign function interfaces.")
+	}
+	b.printf("size_t sizeof__%s%s(void)", g.pkgPrefix, structName)
+}
+
 func (g *gen) writeInitializerImpl(b *buffer, n *a.Struct) error {
 	if !n.Suspendible() {
 		return nil
 	}
+	g.writeSizeofSignature(b, n, false)
+	b.printf("{\nreturn sizeof(%s%s);\n}\n\n", g.pkgPrefix, n.QID().Str(g.tm))
 	if err := g.writeInitializerSignature(b, n, false); err != nil {
 		return err
 	}
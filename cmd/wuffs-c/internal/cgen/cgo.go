@@ -0,0 +1,403 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/wuffs/lang/generate"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// The cgo package wraps the C API of a "wuffs genlib" static library, for Go
+// programs. Like the Python module, it is generated from the package's public
+// API information.
+//
+// Each public method that takes exactly one io_writer and one io_reader, such
+// as decoder.decode, becomes an io.ReadCloser type, such as Reader, whose Read
+// method loops over the C function's suspensions, filling the source buffer
+// from an underlying io.Reader as needed. Its struct's other public methods
+// with only numeric or boolean arguments, such as decoder.set_ignore_checksum,
+// become methods of that type. Error statuses are Go errors, of type Error.
+//
+// All of the C structs and buffers are allocated in C memory, so that no Go
+// pointers are passed to C. They are freed by the Close method or, failing
+// that, by a finalizer.
+
+// cgoParamTypes maps from numeric or boolean Wuffs types to their Go and cgo
+// type names.
+var cgoParamTypes = map[t.ID][2]string{
+	t.IDBool: {"bool", "C.bool"},
+	t.IDI8:   {"int8", "C.int8_t"},
+	t.IDI16:  {"int16", "C.int16_t"},
+	t.IDI32:  {"int32", "C.int32_t"},
+	t.IDI64:  {"int64", "C.int64_t"},
+	t.IDU8:   {"uint8", "C.uint8_t"},
+	t.IDU16:  {"uint16", "C.uint16_t"},
+	t.IDU32:  {"uint32", "C.uint32_t"},
+	t.IDU64:  {"uint64", "C.uint64_t"},
+}
+
+func cgoParamType(n *a.TypeExpr) ([2]string, bool) {
+	if n.Decorator() != 0 || n.QID()[0] != t.IDBase {
+		return [2]string{}, false
+	}
+	s, ok := cgoParamTypes[n.QID()[1]]
+	return s, ok
+}
+
+// cgoName converts a Wuffs name such as "set_ignore_checksum" or a status
+// message such as "bad Huffman code (over-subscribed)" to an exported Go
+// identifier such as "SetIgnoreChecksum" or "BadHuffmanCodeOverSubscribed".
+func cgoName(name string) string {
+	s := []byte(nil)
+	upper := true
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			if upper && ('a' <= c && c <= 'z') {
+				c -= 'a' - 'A'
+			}
+			s = append(s, c)
+			upper = false
+		} else {
+			upper = true
+		}
+	}
+	return string(s)
+}
+
+// isStreamFunc returns whether f is a public suspendible method that takes
+// exactly one io_writer and one io_reader, in that order.
+func isStreamFunc(f *a.Func) bool {
+	if !f.Public() || !f.Suspendible() {
+		return false
+	}
+	in := f.In().Fields()
+	return len(in) == 2 &&
+		isBaseType(in[0].Field().XType(), t.IDIOWriter) &&
+		isBaseType(in[1].Field().XType(), t.IDIOReader)
+}
+
+func isBaseType(n *a.TypeExpr, id t.ID) bool {
+	return n.Decorator() == 0 && n.QID() == t.QID{t.IDBase, id}
+}
+
+// writeCgoPackage writes a cgo Go package for the package to the named file,
+// linking with the named static library. It writes nothing if the package has
+// no stream funcs.
+func (g *gen) writeCgoPackage(filename string, library string) error {
+	hasStreamFuncs := false
+	g.forEachFunc(nil, pubOnly, func(g *gen, _ *buffer, f *a.Func) error {
+		if n := g.structMap[f.Receiver()]; n != nil && n.Public() && n.Suspendible() && isStreamFunc(f) {
+			hasStreamFuncs = true
+		}
+		return nil
+	})
+	if !hasStreamFuncs {
+		return nil
+	}
+
+	if g.wuffsRoot == "" {
+		var err error
+		g.wuffsRoot, err = generate.WuffsRoot()
+		if err != nil {
+			return err
+		}
+	}
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	includeDir, err := filepath.Rel(dir, filepath.Join(g.wuffsRoot, "gen", "h"))
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(library) {
+		if library, err = filepath.Abs(library); err != nil {
+			return err
+		}
+	}
+	if library, err = filepath.Rel(dir, library); err != nil {
+		return err
+	}
+
+	b := buffer(nil)
+	b.writes("// Code generated by wuffs-c. DO NOT EDIT.\n\n")
+	b.printf("// Package %s wraps, via cgo, the C API of the Wuffs %s package, as built\n", g.pkgName, g.pkgName)
+	b.writes("// into a static library by \"wuffs genlib\".\n")
+	b.printf("package %s\n\n", g.pkgName)
+	b.writes("/*\n")
+	b.printf("#cgo CFLAGS: -I${SRCDIR}/%s\n", filepath.ToSlash(includeDir))
+	b.printf("#cgo LDFLAGS: ${SRCDIR}/%s\n", filepath.ToSlash(library))
+	b.writes("#include <stdlib.h>\n")
+	b.printf("#include \"std/%s.h\"\n", g.pkgName)
+	b.writes("*/\nimport \"C\"\n\n")
+	b.writes("import (\n\"errors\"\n\"io\"\n\"runtime\"\n\"unsafe\"\n)\n\n")
+
+	g.writeCgoStatuses(&b)
+	b.writes(cgoStream)
+
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		if err := g.writeCgoStruct(&b, n); err != nil {
+			return err
+		}
+	}
+
+	formatted, err := format.Source(b)
+	if err != nil {
+		return fmt.Errorf("could not format the generated cgo code: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, formatted, 0644)
+}
+
+func (g *gen) writeCgoStatuses(b *buffer) {
+	b.writes("// ---------------- Status Codes\n\n")
+	b.printf("// Error is an error status returned by the %s package.\n", g.pkgName)
+	b.writes("type Error int32\n\n")
+	b.writes("func (e Error) Error() string {\n")
+	b.printf("return C.GoString(C.%sstatus__string(C.%sstatus(e)))\n", g.pkgPrefix, g.pkgPrefix)
+	b.writes("}\n\n")
+
+	b.writes("const (\n")
+	for _, s := range g.wrapperStatuses() {
+		if s.keyword == t.IDError {
+			b.printf("Err%s = Error(%d)\n", cgoName(strings.TrimPrefix(s.msg, g.pkgName+": ")), s.code)
+		}
+	}
+	b.writes(")\n\n")
+
+	b.writes("const (\n")
+	b.printf("statusOK = C.%sSTATUS_OK\n", g.PKGPREFIX)
+	b.printf("suspensionShortRead = C.%sSUSPENSION_SHORT_READ\n", g.PKGPREFIX)
+	b.printf("suspensionShortWrite = C.%sSUSPENSION_SHORT_WRITE\n", g.PKGPREFIX)
+	b.writes(")\n\n")
+}
+
+func (g *gen) writeCgoStruct(b *buffer, n *a.Struct) error {
+	structName := n.QID().Str(g.tm)
+	cName := g.pkgPrefix + structName
+
+	streamFuncs, otherFuncs := []*a.Func(nil), []*a.Func(nil)
+	err := g.forEachFunc(nil, pubOnly, func(g *gen, _ *buffer, f *a.Func) error {
+		if f.Receiver() != n.QID() {
+			return nil
+		}
+		if isStreamFunc(f) {
+			streamFuncs = append(streamFuncs, f)
+		} else if !f.Suspendible() {
+			otherFuncs = append(otherFuncs, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range streamFuncs {
+		fName := f.FuncName().Str(g.tm)
+		typeName := "Reader"
+		if structName != "decoder" || fName != "decode" {
+			typeName = cgoName(structName) + cgoName(fName) + "Reader"
+		}
+
+		b.printf("// ---------------- %s\n\n", typeName)
+		b.printf("// %s is an io.ReadCloser whose Read method returns the output of the\n", typeName)
+		b.printf("// %s function, given the underlying io.Reader as input.\n", g.funcCName(f))
+		b.printf("type %s struct {\ns stream\nc *C.%s\n}\n\n", typeName, cName)
+
+		b.printf("// New%s returns a new %s that reads from r.\n", typeName, typeName)
+		b.printf("func New%s(r io.Reader) *%s {\n", typeName, typeName)
+		b.printf("c := (*C.%s)(C.calloc(1, C.sizeof_%s))\n", cName, cName)
+		b.printf("C.%s__check_wuffs_version(c, C.sizeof_%s, C.WUFFS_VERSION)\n", cName, cName)
+		b.printf("z := &%s{c: c}\n", typeName)
+		b.writes("z.s.init(r, func(dst C.wuffs_base__io_writer, src C.wuffs_base__io_reader) int32 {\n")
+		b.printf("return int32(C.%s(c, dst, src))\n", g.funcCName(f))
+		b.writes("})\n")
+		b.printf("runtime.SetFinalizer(z, (*%s).Close)\n", typeName)
+		b.writes("return z\n}\n\n")
+
+		b.writes("// Read implements io.Reader.\n")
+		b.printf("func (z *%s) Read(p []byte) (int, error) {\nreturn z.s.read(p)\n}\n\n", typeName)
+
+		b.writes("// Close frees the underlying C memory. It does not close the underlying\n")
+		b.writes("// io.Reader.\n")
+		b.printf("func (z *%s) Close() error {\n", typeName)
+		b.writes("if z.c == nil {\nreturn nil\n}\n")
+		b.writes("runtime.SetFinalizer(z, nil)\n")
+		b.writes("C.free(unsafe.Pointer(z.c))\n")
+		b.writes("z.c = nil\n")
+		b.writes("z.s.close()\n")
+		b.writes("return nil\n}\n\n")
+
+		for _, o := range otherFuncs {
+			if err := g.writeCgoMethod(b, typeName, o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) writeCgoMethod(b *buffer, typeName string, f *a.Func) error {
+	params, args := []string(nil), []string{"z.c"}
+	for _, o := range f.In().Fields() {
+		o := o.Field()
+		typ, ok := cgoParamType(o.XType())
+		if !ok {
+			return nil
+		}
+		name := "a_" + o.Name().Str(g.tm)
+		params = append(params, name+" "+typ[0])
+		args = append(args, typ[1]+"("+name+")")
+	}
+	ret := ""
+	if out := f.Out().Fields(); len(out) == 1 {
+		typ, ok := cgoParamType(out[0].Field().XType())
+		if !ok {
+			return nil
+		}
+		ret = typ[0]
+	} else if len(out) != 0 {
+		return nil
+	}
+
+	fName := cgoName(f.FuncName().Str(g.tm))
+	call := "C." + g.funcCName(f) + "(" + strings.Join(args, ", ") + ")"
+	b.printf("// %s calls %s.\n// It panics if z is closed.\n", fName, g.funcCName(f))
+	b.printf("func (z *%s) %s(%s) %s {\n", typeName, fName, strings.Join(params, ", "), ret)
+	b.writes("if z.c == nil {\npanic(errClosed)\n}\n")
+	if ret == "" {
+		b.printf("%s\n", call)
+	} else {
+		b.printf("return %s(%s)\n", ret, call)
+	}
+	b.writes("}\n\n")
+	return nil
+}
+
+const cgoStream = `// ---------------- Streams
+
+// bufferSize is the size of each stream's source and destination buffers.
+const bufferSize = 65536
+
+var errClosed = errors.New("wuffs: use of closed reader")
+
+// stream calls a C function that takes an io_writer and an io_reader until
+// it completes, presenting its output as an io.Reader.
+type stream struct {
+	r    io.Reader
+	call func(dst C.wuffs_base__io_writer, src C.wuffs_base__io_reader) int32
+	dst  *C.wuffs_base__io_buffer
+	src  *C.wuffs_base__io_buffer
+	err  error
+}
+
+func (s *stream) init(r io.Reader, call func(dst C.wuffs_base__io_writer, src C.wuffs_base__io_reader) int32) {
+	s.r = r
+	s.call = call
+	s.dst = newIOBuffer()
+	s.src = newIOBuffer()
+}
+
+func (s *stream) close() {
+	freeIOBuffer(s.dst)
+	freeIOBuffer(s.src)
+	s.dst = nil
+	s.src = nil
+	s.err = errClosed
+}
+
+func (s *stream) read(p []byte) (int, error) {
+	for {
+		if s.dst == nil {
+			return 0, errClosed
+		}
+		if s.dst.ri < s.dst.wi {
+			n := copy(p, bufferBytes(s.dst)[s.dst.ri:s.dst.wi])
+			s.dst.ri += C.size_t(n)
+			return n, nil
+		}
+		if s.err != nil {
+			return 0, s.err
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+
+		s.dst.ri, s.dst.wi = 0, 0
+		switch status := s.call(C.wuffs_base__io_buffer__writer(s.dst), C.wuffs_base__io_buffer__reader(s.src)); status {
+		case statusOK:
+			s.err = io.EOF
+		case suspensionShortWrite:
+			// No-op. The next loop iteration drains s.dst.
+		case suspensionShortRead:
+			s.fill()
+		default:
+			s.err = Error(status)
+		}
+	}
+}
+
+// fill compacts s.src and then reads into it from s.r.
+func (s *stream) fill() {
+	if s.src.closed {
+		s.err = ErrUnexpectedEOF
+		return
+	}
+	buf := bufferBytes(s.src)
+	n := copy(buf, buf[s.src.ri:s.src.wi])
+	s.src.ri, s.src.wi = 0, C.size_t(n)
+
+	n, err := s.r.Read(buf[n:])
+	s.src.wi += C.size_t(n)
+	if err == io.EOF {
+		s.src.closed = true
+	} else if err != nil {
+		s.err = err
+	}
+}
+
+func newIOBuffer() *C.wuffs_base__io_buffer {
+	b := (*C.wuffs_base__io_buffer)(C.calloc(1, C.sizeof_wuffs_base__io_buffer))
+	b.ptr = (*C.uint8_t)(C.malloc(bufferSize))
+	b.len = bufferSize
+	return b
+}
+
+func freeIOBuffer(b *C.wuffs_base__io_buffer) {
+	C.free(unsafe.Pointer(b.ptr))
+	C.free(unsafe.Pointer(b))
+}
+
+// bufferBytes returns the C memory that b points to, as a Go slice.
+func bufferBytes(b *C.wuffs_base__io_buffer) []byte {
+	return (*[bufferSize]byte)(unsafe.Pointer(b.ptr))[:b.len:b.len]
+}
+
+`
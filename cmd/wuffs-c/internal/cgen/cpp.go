@@ -0,0 +1,236 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/wuffs/lang/builtin"
+
+	a "github.com/google/wuffs/lang/ast"
+)
+
+// cppGuard guards the C++ wrappers. Defining WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS
+// leaves C++ programs with just the C API.
+const cppGuard = "defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)"
+
+// writeCPlusPlusWrappers writes the C++ wrappers (a strongly typed status and
+// an RAII class per public struct) for the package's C API.
+//
+// The built-in C formatter does not understand C++, so the wrappers are
+// written pre-formatted, and passed through formatC verbatim.
+func (g *gen) writeCPlusPlusWrappers(b *buffer) error {
+	c := buffer(nil)
+	c.printf("#if %s\n\n", cppGuard)
+	c.printf("namespace wuffs {\nnamespace %s {\n\n", g.pkgName)
+
+	g.writeCPlusPlusStatus(&c)
+
+	for _, n := range g.structList {
+		if !n.Public() {
+			continue
+		}
+		if err := g.writeCPlusPlusStruct(&c, n); err != nil {
+			return err
+		}
+	}
+
+	c.printf("}  // namespace %s\n}  // namespace wuffs\n\n", g.pkgName)
+	c.printf("#endif  // %s\n", cppGuard)
+
+	b.writes("// ---------------- C++ Wrappers\n\n")
+	b.writeVerbatim(string(c))
+	b.writeb('\n')
+	return nil
+}
+
+func (g *gen) writeCPlusPlusStatus(c *buffer) {
+	typ := g.pkgPrefix + "status"
+	ok := g.PKGPREFIX + "STATUS_OK"
+
+	c.printf("// status is a strongly typed %s.\n", typ)
+	c.writes("class status {\n public:\n")
+	c.printf("  constexpr status() : repr_(%s) {}\n", ok)
+	c.printf("  constexpr explicit status(%s repr) : repr_(repr) {}\n\n", typ)
+	c.printf("  constexpr %s repr() const { return repr_; }\n", typ)
+	c.printf("  constexpr bool is_ok() const { return repr_ == %s; }\n", ok)
+	c.writes("  constexpr bool is_error() const { return repr_ < 0; }\n")
+	c.writes("  constexpr bool is_suspension() const { return repr_ > 0; }\n")
+	c.printf("  const char* message() const { return %sstatus__string(repr_); }\n\n", g.pkgPrefix)
+	c.writes("  constexpr bool operator==(status s) const { return repr_ == s.repr_; }\n")
+	c.writes("  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }\n\n")
+	c.printf(" private:\n  %s repr_;\n};\n\n", typ)
+
+	names := []string(nil)
+	for _, z := range builtin.StatusList {
+		names = append(names, strings.ToUpper(g.cName(z.String())))
+	}
+	for _, s := range g.statusList {
+		names = append(names, s.name)
+	}
+	for _, name := range names {
+		cppName := strings.ToLower(strings.TrimPrefix(name, g.PKGPREFIX))
+		writeCPlusPlusCall(c, 0, "constexpr status "+cppName+"(", []string{name}, ");")
+	}
+	c.writeb('\n')
+}
+
+func (g *gen) writeCPlusPlusStruct(c *buffer, n *a.Struct) error {
+	name := n.QID().Str(g.tm)
+	if cppKeywords[name] || name == "status" {
+		return fmt.Errorf("cannot use %q as a C++ class name", name)
+	}
+	cName := g.pkgPrefix + name
+
+	c.printf("// %s is an RAII wrapper for a %s.", name, cName)
+	if n.Suspendible() {
+		c.printf(" Its constructor\n// calls %s__check_wuffs_version.", cName)
+	}
+	c.printf("\nclass %s {\n public:\n", name)
+	if n.Suspendible() {
+		c.printf("  %s() : c_() {\n", name)
+		c.printf("    %s__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);\n", cName)
+		c.writes("  }\n\n")
+	} else {
+		c.printf("  %s() : c_() {}\n\n", name)
+	}
+	c.printf("  %s(const %s&) = delete;\n", name, name)
+	c.printf("  %s& operator=(const %s&) = delete;\n\n", name, name)
+	c.writes("  // c_struct returns the wrapped C struct, for passing to the C API.\n")
+	c.printf("  %s* c_struct() { return &c_; }\n", cName)
+
+	if n.Suspendible() {
+		c.writes("\n")
+		writeComment(c, "  ", "save_state and restore_state wrap "+cName+"__save_state and "+
+			cName+"__restore_state. A saved state is "+g.stateLengthMacro(n)+" bytes long.")
+		writeCPlusPlusCall(c, 2, "status save_state(", []string{"wuffs_base__slice_u8 dst"}, ") {")
+		writeCPlusPlusCall(c, 4, "return status("+cName+"__save_state(", []string{"&c_", "dst"}, "));")
+		c.writes("  }\n")
+		writeCPlusPlusCall(c, 2, "status restore_state(", []string{"wuffs_base__slice_u8 src"}, ") {")
+		writeCPlusPlusCall(c, 4, "return status("+cName+"__restore_state(", []string{"&c_", "src"}, "));")
+		c.writes("  }\n")
+	}
+
+	err := g.forEachFunc(nil, pubOnly, func(g *gen, _ *buffer, f *a.Func) error {
+		if f.Receiver() != n.QID() {
+			return nil
+		}
+		c.writeb('\n')
+		return g.writeCPlusPlusMethod(c, f)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.printf("\n private:\n  %s c_;\n};\n\n", cName)
+	return nil
+}
+
+func (g *gen) writeCPlusPlusMethod(c *buffer, f *a.Func) error {
+	ret := ""
+	if f.Suspendible() {
+		ret = "status"
+	} else if outFields := f.Out().Fields(); len(outFields) == 0 {
+		ret = "void"
+	} else if len(outFields) == 1 {
+		b := buffer(nil)
+		if err := g.writeCTypeName(&b, outFields[0].Field().XType(), "", ""); err != nil {
+			return err
+		}
+		ret = strings.TrimSpace(string(b))
+	} else {
+		return fmt.Errorf("TODO: multiple return values")
+	}
+
+	fName := f.FuncName().Str(g.tm)
+	if cppKeywords[fName] {
+		return fmt.Errorf("cannot use %q as a C++ method name", fName)
+	}
+
+	params, args := []string(nil), []string{"&c_"}
+	for _, o := range f.In().Fields() {
+		o := o.Field()
+		if oName := o.Name().Str(g.tm); cppKeywords[oName] {
+			return fmt.Errorf("cannot use %q as a C++ parameter name", oName)
+		}
+		b := buffer(nil)
+		if err := g.writeCTypeName(&b, o.XType(), "", o.Name().Str(g.tm)); err != nil {
+			return err
+		}
+		params = append(params, string(b))
+		args = append(args, o.Name().Str(g.tm))
+	}
+
+	call := g.funcCName(f) + "("
+	if f.Suspendible() {
+		call = "status(" + call
+	}
+	tail := ");"
+	if f.Suspendible() {
+		tail = "));"
+	}
+	if ret != "void" {
+		call = "return " + call
+	}
+
+	writeCPlusPlusCall(c, 2, ret+" "+fName+"(", params, ") {")
+	writeCPlusPlusCall(c, 4, call, args, tail)
+	c.writes("  }\n")
+	return nil
+}
+
+// writeCPlusPlusCall writes "head(arg0, arg1, etc)tail", where head ends with
+// the "(" and tail starts with the ")". If that does not fit within
+// formatMaxLineLength, the args are moved to the next line, one per line if
+// necessary, with formatContinuation spaces of extra indentation.
+func writeCPlusPlusCall(c *buffer, indent int, head string, args []string, tail string) {
+	prefix := strings.Repeat(" ", indent)
+	joined := strings.Join(args, ", ")
+	if line := prefix + head + joined + tail; len(line) <= formatMaxLineLength {
+		c.printf("%s\n", line)
+		return
+	}
+	c.printf("%s%s\n", prefix, head)
+	prefix += strings.Repeat(" ", formatContinuation)
+	if line := prefix + joined + tail; len(line) <= formatMaxLineLength {
+		c.printf("%s\n", line)
+		return
+	}
+	for i, arg := range args {
+		if i < len(args)-1 {
+			c.printf("%s%s,\n", prefix, arg)
+		} else {
+			c.printf("%s%s%s\n", prefix, arg, tail)
+		}
+	}
+}
+
+// cppKeywords are the C++ keywords that are not also C keywords. Wuffs
+// identifiers that clash with them cannot be used as C++ names.
+var cppKeywords = map[string]bool{
+	"alignas": true, "alignof": true, "and": true, "and_eq": true, "asm": true,
+	"bitand": true, "bitor": true, "bool": true, "catch": true, "class": true,
+	"compl": true, "constexpr": true, "const_cast": true, "decltype": true,
+	"delete": true, "dynamic_cast": true, "explicit": true, "export": true,
+	"false": true, "friend": true, "mutable": true, "namespace": true,
+	"new": true, "noexcept": true, "not": true, "not_eq": true, "nullptr": true,
+	"operator": true, "or": true, "or_eq": true, "private": true,
+	"protected": true, "public": true, "reinterpret_cast": true,
+	"static_assert": true, "static_cast": true, "template": true, "this": true,
+	"thread_local": true, "throw": true, "true": true, "try": true,
+	"typeid": true, "typename": true, "using": true, "virtual": true,
+	"wchar_t": true, "xor": true, "xor_eq": true,
+}
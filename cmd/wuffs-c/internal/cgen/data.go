@@ -22,7 +22,8 @@ const baseHeader = "" +
 	"" +
 	"// ---------------- Numeric Types\n\n// Flicks are a unit of time. One flick (frame-tick) is 1 / 705_600_000 of a\n// second. See https://github.com/OculusVR/Flicks\ntypedef int64_t wuffs_base__flicks;\n\n#define WUFFS_BASE__FLICKS_PER_SECOND ((uint64_t)705600000)\n#define WUFFS_BASE__FLICKS_PER_MILLISECOND ((uint64_t)705600)\n\n" +
 	"" +
-	"// --------\n\nstatic inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint8_t wuffs_base__u8__max(uint8_t x, uint8_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint16_t wuffs_base__u16__min(uint16_t x, uint16_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint16_t wuffs_base__u16__max(uint16_t x, uint16_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint32_t wuffs_base__u32__min(uint32_t x, uint32_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint32_t wuffs_base__u32__max(uint32_t x, uint32_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint64_t wuffs_base__u64__min(uint64_t x, uint64_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint64_t wuffs_base__u64__max(uint64_t x, uint64_t y) {\n  return x > y ? x : y;\n}\n\n" +
+	"// --------\n\nstatic inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint8_t wuffs_base__u8__max(uint8_t x, uint8_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint16_t wuffs_base__u16__min(uint16_t x, uint16_t y) {\n  return x " +
+	"< y ? x : y;\n}\n\nstatic inline uint16_t wuffs_base__u16__max(uint16_t x, uint16_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint32_t wuffs_base__u32__min(uint32_t x, uint32_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint32_t wuffs_base__u32__max(uint32_t x, uint32_t y) {\n  return x > y ? x : y;\n}\n\nstatic inline uint64_t wuffs_base__u64__min(uint64_t x, uint64_t y) {\n  return x < y ? x : y;\n}\n\nstatic inline uint64_t wuffs_base__u64__max(uint64_t x, uint64_t y) {\n  return x > y ? x : y;\n}\n\n" +
 	"" +
 	"// --------\n\n// Saturating arithmetic (sat_add, sat_sub) branchless bit-twiddling algorithms\n// are per https://locklessinc.com/articles/sat_arithmetic/\n//\n// It is important that the underlying types are unsigned integers, as signed\n// integer arithmetic overflow is undefined behavior in C.\n\nstatic inline uint8_t wuffs_base__u8__sat_add(uint8_t x, uint8_t y) {\n  uint8_t res = x + y;\n  res |= -(res < x);\n  return res;\n}\n\nstatic inline uint8_t wuffs_base__u8__sat_sub(uint8_t x, uint8_t y) {\n  uint8_t res = x - y;\n  res &= -(res <= x);\n  return res;\n}\n\nstatic inline uint16_t wuffs_base__u16__sat_add(uint16_t x, uint16_t y) {\n  uint16_t res = x + y;\n  res |= -(res < x);\n  return res;\n}\n\nstatic inline uint16_t wuffs_base__u16__sat_sub(uint16_t x, uint16_t y) {\n  uint16_t res = x - y;\n  res &= -(res <= x);\n  return res;\n}\n\nstatic inline uint32_t wuffs_base__u32__sat_add(uint32_t x, uint32_t y) {\n  uint32_t res = x + y;\n  res |= -(res < x);\n  return res;\n}\n\nstatic inline uint32_t wuffs_base__u32__sat_sub(uint32_t x" +
 	", uint32_t y) {\n  uint32_t res = x - y;\n  res &= -(res <= x);\n  return res;\n}\n\nstatic inline uint64_t wuffs_base__u64__sat_add(uint64_t x, uint64_t y) {\n  uint64_t res = x + y;\n  res |= -(res < x);\n  return res;\n}\n\nstatic inline uint64_t wuffs_base__u64__sat_sub(uint64_t x, uint64_t y) {\n  uint64_t res = x - y;\n  res &= -(res <= x);\n  return res;\n}\n\n" +
@@ -56,7 +57,9 @@ const baseHeader = "" +
 	"_ie_u32 r) {\n  return wuffs_base__u32__sat_sub(r.max_exclusive_y, r.min_inclusive_y);\n}\n\n" +
 	"" +
 	"// ---------------- I/O\n\n// wuffs_base__io_buffer is a 1-dimensional buffer (a pointer and length), plus\n// additional indexes into that buffer, plus an opened / closed flag.\n//\n// A value with all fields NULL or zero is a valid, empty buffer.\ntypedef struct {\n  uint8_t* ptr;  // Pointer.\n  size_t len;    // Length.\n  size_t wi;     // Write index. Invariant: wi <= len.\n  size_t ri;     // Read  index. Invariant: ri <= wi.\n  bool closed;   // No further writes are expected.\n} wuffs_base__io_buffer;\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__io_buffer* buf;\n    // The bounds values are typically NULL, when created by the Wuffs public\n    // API. NULL means that the callee substitutes the implicit bounds derived\n    // from buf.\n    uint8_t* bounds[2];\n  } private_impl;\n} wuffs_base__io_reader;\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/A" +
-	"BI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__io_buffer* buf;\n    // The bounds values are typically NULL, when created by the Wuffs public\n    // API. NULL means that the callee substitutes the implicit bounds derived\n    // from buf.\n    uint8_t* bounds[2];\n  } private_impl;\n} wuffs_base__io_writer;\n\nstatic inline wuffs_base__io_reader wuffs_base__io_buffer__reader(\n    wuffs_base__io_buffer* buf) {\n  wuffs_base__io_reader ret = ((wuffs_base__io_reader){});\n  ret.private_impl.buf = buf;\n  return ret;\n}\n\nstatic inline wuffs_base__io_writer wuffs_base__io_buffer__writer(\n    wuffs_base__io_buffer* buf) {\n  wuffs_base__io_writer ret = ((wuffs_base__io_writer){});\n  ret.private_impl.buf = buf;\n  return ret;\n}\n\n" +
+	"BI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__io_buffer* buf;\n    // The bounds values are typically NULL, when created by the Wuffs public\n    // API. NULL means that the callee substitutes the implicit bounds derived\n    // from buf.\n    uint8_t* bounds[2];\n  } private_impl;\n} wuffs_base__io_writer;\n\nstatic inline wuffs_base__io_reader wuffs_base__io_buffer__reader(\n    wuffs_base__io_buffer* buf) {\n  wuffs_base__io_reader ret = ((wuffs_base__io_reader){});\n  ret.private_impl.buf = buf;\n  return ret;\n}\n\nstatic inline wuffs_base__io_writer wuffs_base__io_buffer__writer(\n    wuffs_base__io_buffer* buf) {\n  wuffs_base__io_writer ret = ((wuffs_base__io_writer){});\n  ret.private_impl.buf = buf;\n  return ret;\n}\n\n#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)\n\nnamespace wuffs {\nnamespace base {\n\n// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from\n// a pointer and length, from an array or from any container (such as a\n// std" +
+	"::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has\n// data() and size() methods.\n//\n// By default, the buffer is empty, ready to be written to. Pass filled = true\n// for a buffer whose contents are ready to be read from. Pass closed = true if\n// no further writes are expected, e.g. if the contents are the complete input.\nclass io_buffer : public wuffs_base__io_buffer {\n public:\n  io_buffer() : wuffs_base__io_buffer() {}\n\n  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)\n      : wuffs_base__io_buffer() {\n    this->ptr = ptr;\n    this->len = len;\n    this->wi = filled ? len : 0;\n    this->closed = closed;\n  }\n\n  template <size_t N>\n  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)\n      : io_buffer(array, N, filled, closed) {}\n\n  template <typename Container>\n  io_buffer(Container& c, bool filled = false, bool closed = false)\n      : io_buffer(c.data(), c.size(), filled, closed) {}\n\n  wuffs_base__io_reader reader() { return wuffs_base" +
+	"__io_buffer__reader(this); }\n  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }\n\n  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].\n  uint8_t* reader_data() const { return this->ptr + this->ri; }\n  size_t reader_size() const { return this->wi - this->ri; }\n\n  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].\n  uint8_t* writer_data() const { return this->ptr + this->wi; }\n  size_t writer_size() const { return this->len - this->wi; }\n};\n\n}  // namespace base\n}  // namespace wuffs\n\n#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)\n\n" +
 	"" +
 	"// ---------------- Images\n\n// wuffs_base__pixel_format encodes the format of the bytes that constitute an\n// image frame's pixel data. Its bits:\n//  - bit        31  is reserved.\n//  - bits 30 .. 28 encodes color (and channel order, in terms of memory).\n//  - bits 27 .. 26 are reserved.\n//  - bits 25 .. 24 encodes transparency.\n//  - bit        23 indicates big-endian/MSB-first (as opposed to little/LSB).\n//  - bit        22 indicates floating point (as opposed to integer).\n//  - bits 21 .. 20 are the number of planes, minus 1. Zero means packed.\n//  - bits 19 .. 16 encodes the number of bits (depth) in an index value.\n//                  Zero means direct, not palette-indexed.\n//  - bits 15 .. 12 encodes the number of bits (depth) in the 3rd channel.\n//  - bits 11 ..  8 encodes the number of bits (depth) in the 2nd channel.\n//  - bits  7 ..  4 encodes the number of bits (depth) in the 1st channel.\n//  - bits  3 ..  0 encodes the number of bits (depth) in the 0th channel.\n//\n// The bit fields of a wuffs_base" +
 	"__pixel_format are not independent. For\n// example, the number of planes should not be greater than the number of\n// channels. Similarly, bits 15..4 are unused (and should be zero) if bits\n// 31..24 (color and transparency) together imply only 1 channel (gray, no\n// alpha) and floating point samples should mean a bit depth of 16, 32 or 64.\n//\n// Formats hold between 1 and 4 channels. For example: Y (1 channel: gray), YA\n// (2 channels: gray and alpha), BGR (3 channels: blue, green, red) or CMYK (4\n// channels: cyan, magenta, yellow, black).\n//\n// For direct formats with N > 1 channels, those channels can be laid out in\n// either 1 (packed) or N (planar) planes. For example, RGBA data is usually\n// packed, but YUV data is usually planar, due to chroma subsampling (for\n// details, see the wuffs_base__pixel_subsampling type). For indexed formats,\n// the palette (always 256 × 4 bytes) holds up to 4 packed bytes of color data\n// per index value, and there is only 1 plane (for the index). The distance\n// between s" +
@@ -66,37 +69,44 @@ const baseHeader = "" +
 	"L_DRM_FORMAT_RGB565.\n//\n// Different software libraries name their pixel formats (and especially their\n// channel order) either according to memory layout or as bits of a native\n// integer type like uint32_t. The two conventions differ because of a system's\n// endianness. As mentioned earlier, Wuffs pixel formats are always in memory\n// order. More detail of other software libraries' naming conventions is in the\n// Pixel Format Guide at https://afrantzis.github.io/pixel-format-guide/\n//\n// Do not manipulate these bits directly; they are private implementation\n// details. Use methods such as wuffs_base__pixel_format__num_planes instead.\ntypedef uint32_t wuffs_base__pixel_format;\n\n// Common 8-bit-depth pixel formats. This list is not exhaustive; not all valid\n// wuffs_base__pixel_format values are present.\n\n#define WUFFS_BASE__PIXEL_FORMAT__INVALID ((wuffs_base__pixel_format)0x00000000)\n\n#define WUFFS_BASE__PIXEL_FORMAT__A ((wuffs_base__pixel_format)0x02000008)\n\n#define WUFFS_BASE__PIXEL_FORMAT__Y ((wuffs_base_" +
 	"_pixel_format)0x10000008)\n#define WUFFS_BASE__PIXEL_FORMAT__YA_NONPREMUL \\\n  ((wuffs_base__pixel_format)0x12000008)\n#define WUFFS_BASE__PIXEL_FORMAT__YA_PREMUL \\\n  ((wuffs_base__pixel_format)0x13000008)\n\n#define WUFFS_BASE__PIXEL_FORMAT__BGR ((wuffs_base__pixel_format)0x20000888)\n#define WUFFS_BASE__PIXEL_FORMAT__BGRX ((wuffs_base__pixel_format)0x21008888)\n#define WUFFS_BASE__PIXEL_FORMAT__BGRX_INDEXED \\\n  ((wuffs_base__pixel_format)0x21088888)\n#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL \\\n  ((wuffs_base__pixel_format)0x22008888)\n#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL_INDEXED \\\n  ((wuffs_base__pixel_format)0x22088888)\n#define WUFFS_BASE__PIXEL_FORMAT__BGRA_PREMUL \\\n  ((wuffs_base__pixel_format)0x23008888)\n\n#define WUFFS_BASE__PIXEL_FORMAT__RGB ((wuffs_base__pixel_format)0x30000888)\n#define WUFFS_BASE__PIXEL_FORMAT__RGBX ((wuffs_base__pixel_format)0x31008888)\n#define WUFFS_BASE__PIXEL_FORMAT__RGBX_INDEXED \\\n  ((wuffs_base__pixel_format)0x31088888)\n#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL" +
 	" \\\n  ((wuffs_base__pixel_format)0x32008888)\n#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL_INDEXED \\\n  ((wuffs_base__pixel_format)0x32088888)\n#define WUFFS_BASE__PIXEL_FORMAT__RGBA_PREMUL \\\n  ((wuffs_base__pixel_format)0x33008888)\n\n#define WUFFS_BASE__PIXEL_FORMAT__YUV ((wuffs_base__pixel_format)0x40200888)\n#define WUFFS_BASE__PIXEL_FORMAT__YUVK ((wuffs_base__pixel_format)0x41308888)\n#define WUFFS_BASE__PIXEL_FORMAT__YUVA_NONPREMUL \\\n  ((wuffs_base__pixel_format)0x42308888)\n\n#define WUFFS_BASE__PIXEL_FORMAT__CMY ((wuffs_base__pixel_format)0x50200888)\n#define WUFFS_BASE__PIXEL_FORMAT__CMYK ((wuffs_base__pixel_format)0x51308888)\n\nstatic inline bool wuffs_base__pixel_format__is_valid(\n    wuffs_base__pixel_format f) {\n  return f != 0;\n}\n\nstatic inline bool wuffs_base__pixel_format__is_indexed(\n    wuffs_base__pixel_format f) {\n  return ((f >> 16) & 0x0F) != 0;\n}\n\n#define WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX 4\n\nstatic inline uint32_t wuffs_base__pixel_format__num_planes(\n    wuffs_base__pixel_format f) {\n  " +
-	"return f ? (((f >> 20) & 0x03) + 1) : 0;\n}\n\ntypedef struct {\n  wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];\n} wuffs_base__pixel_buffer;\n\n" +
+	"return f ? (((f >> 20) & 0x03) + 1) : 0;\n}\n\n// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per\n// pixel for a packed (single plane) pixel format. For indexed formats, this is\n// the number of bits per index value. It returns zero for invalid or planar\n// pixel formats.\nstatic inline uint32_t wuffs_base__pixel_format__bits_per_pixel(\n    wuffs_base__pixel_format f) {\n  static const uint32_t depths[16] = {\n      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,\n  };\n  if (!f || ((f >> 20) & 0x03)) {\n    return 0;\n  }\n  if ((f >> 16) & 0x0F) {\n    return depths[(f >> 16) & 0x0F];\n  }\n  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +\n         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];\n}\n\n// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits\n// per pixel in the p'th plane. For packed pixel formats, this is the same as\n// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel\n// formats, each plane holds one channel, so this is t" +
+	"hat channel's depth. It\n// returns zero if there is no p'th plane.\nstatic inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(\n    wuffs_base__pixel_format f,\n    uint32_t p) {\n  static const uint32_t depths[16] = {\n      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,\n  };\n  uint32_t n = wuffs_base__pixel_format__num_planes(f);\n  if (p >= n) {\n    return 0;\n  } else if (n == 1) {\n    return wuffs_base__pixel_format__bits_per_pixel(f);\n  }\n  return depths[(f >> (4 * p)) & 0x0F];\n}\n\ntypedef struct {\n  wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];\n} wuffs_base__pixel_buffer;\n\n" +
 	"" +
 	"// --------\n\n// wuffs_base__pixel_subsampling encodes the mapping of pixel space coordinates\n// (x, y) to pixel buffer indices (i, j). That mapping can differ for each\n// plane p. For a depth of 8 bits (1 byte), the p'th plane's sample starts at\n// (planes[p].ptr + (j * planes[p].stride) + i).\n//\n// For packed pixel formats, the mapping is trivial: i = x and j = y. For\n// planar pixel formats, the mapping can differ due to chroma subsampling. For\n// example, consider a three plane YUV pixel format with 4:2:2 subsampling. For\n// the luma (Y) channel, there is one sample for every pixel, but for the\n// chroma (U, V) channels, there is one sample for every two pixels: pairs of\n// horizontally adjacent pixels form one macropixel, i = x / 2 and j == y. In\n// general, for a given p:\n//  - i = (x + bias_x) >> shift_x.\n//  - j = (y + bias_y) >> shift_y.\n// where biases and shifts are in the range 0..3 and 0..2 respectively.\n//\n// In general, the biases will be zero after decoding an image. However, making\n// a sub-im" +
 	"age may change the bias, since the (x, y) coordinates are relative\n// to the sub-image's top-left origin, but the backing pixel buffers were\n// created relative to the original image's origin.\n//\n// For each plane p, each of those four numbers (biases and shifts) are encoded\n// in two bits, which combine to form an 8 bit unsigned integer:\n//\n//  e_p = (bias_x << 6) | (shift_x << 4) | (bias_y << 2) | (shift_y << 0)\n//\n// Those e_p values (e_0 for the first plane, e_1 for the second plane, etc)\n// combine to form a wuffs_base__pixel_subsampling value:\n//\n//  pixsub = (e_3 << 24) | (e_2 << 16) | (e_1 << 8) | (e_0 << 0)\n//\n// Do not manipulate these bits directly; they are private implementation\n// details. Use methods such as wuffs_base__pixel_subsampling__bias_x instead.\ntypedef uint32_t wuffs_base__pixel_subsampling;\n\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__NONE ((wuffs_base__pixel_subsampling)0)\n\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__444 \\\n  ((wuffs_base__pixel_subsampling)0x000000)\n#define WUFFS_BASE__PIXEL_" +
 	"SUBSAMPLING__440 \\\n  ((wuffs_base__pixel_subsampling)0x010100)\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__422 \\\n  ((wuffs_base__pixel_subsampling)0x101000)\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__420 \\\n  ((wuffs_base__pixel_subsampling)0x111100)\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__411 \\\n  ((wuffs_base__pixel_subsampling)0x202000)\n#define WUFFS_BASE__PIXEL_SUBSAMPLING__410 \\\n  ((wuffs_base__pixel_subsampling)0x212100)\n\nstatic inline uint32_t wuffs_base__pixel_subsampling__bias_x(\n    wuffs_base__pixel_subsampling s,\n    uint32_t plane) {\n  uint32_t shift = ((plane & 0x03) * 8) + 6;\n  return (s >> shift) & 0x03;\n}\n\nstatic inline uint32_t wuffs_base__pixel_subsampling__shift_x(\n    wuffs_base__pixel_subsampling s,\n    uint32_t plane) {\n  uint32_t shift = ((plane & 0x03) * 8) + 4;\n  return (s >> shift) & 0x03;\n}\n\nstatic inline uint32_t wuffs_base__pixel_subsampling__bias_y(\n    wuffs_base__pixel_subsampling s,\n    uint32_t plane) {\n  uint32_t shift = ((plane & 0x03) * 8) + 2;\n  return (s >> shift) & 0x03;\n}\n\nstatic" +
 	" inline uint32_t wuffs_base__pixel_subsampling__shift_y(\n    wuffs_base__pixel_subsampling s,\n    uint32_t plane) {\n  uint32_t shift = ((plane & 0x03) * 8) + 0;\n  return (s >> shift) & 0x03;\n}\n\n" +
 	"" +
-	"// --------\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__pixel_format pixfmt;\n    wuffs_base__pixel_subsampling pixsub;\n    uint32_t width;\n    uint32_t height;\n    uint32_t num_loops;\n  } private_impl;\n} wuffs_base__image_config;\n\n// TODO: Should this function return bool? An error type?\nstatic inline void wuffs_base__image_config__initialize(\n    wuffs_base__image_config* c,\n    wuffs_base__pixel_format pixfmt,\n    wuffs_base__pixel_subsampling pixsub,\n    uint32_t width,\n    uint32_t height,\n    uint32_t num_loops) {\n  if (!c) {\n    return;\n  }\n  if (pixfmt) {\n    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);\n    // TODO: handle things other than 1 byte per pixel.\n    if (wh <= ((uint64_t)SIZE_MAX)) {\n      c->private_impl.pixfmt = pixfmt;\n      c->private_impl.pixsub = pixsub;\n      c->private_impl.width = width;\n      c->private_impl.height = height;\n      c->private_imp" +
-	"l.num_loops = num_loops;\n      return;\n    }\n  }\n  *c = ((wuffs_base__image_config){});\n}\n\nstatic inline void wuffs_base__image_config__invalidate(\n    wuffs_base__image_config* c) {\n  if (c) {\n    *c = ((wuffs_base__image_config){});\n  }\n}\n\nstatic inline bool wuffs_base__image_config__is_valid(\n    wuffs_base__image_config* c) {\n  return c && c->private_impl.pixfmt;\n}\n\nstatic inline wuffs_base__pixel_format wuffs_base__image_config__pixel_format(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.pixfmt : 0;\n}\n\nstatic inline wuffs_base__pixel_subsampling\nwuffs_base__image_config__pixel_subsampling(wuffs_base__image_config* c) {\n  return c ? c->private_impl.pixsub : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__width(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.width : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__height(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.height : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__num_loops(\n" +
-	"    wuffs_base__image_config* c) {\n  return c ? c->private_impl.num_loops : 0;\n}\n\n// TODO: this is the right API for planar (not packed) pixbufs? Should it allow\n// decoding into a color model different from the format's intrinsic one? For\n// example, decoding a JPEG image straight to RGBA instead of to YCbCr?\nstatic inline size_t wuffs_base__image_config__pixbuf_size(\n    wuffs_base__image_config* c) {\n  if (c) {\n    uint64_t wh =\n        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);\n    // TODO: handle things other than 1 byte per pixel.\n    return (size_t)wh;\n  }\n  return 0;\n}\n\n" +
+	"// --------\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__pixel_format pixfmt;\n    wuffs_base__pixel_subsampling pixsub;\n    uint32_t width;\n    uint32_t height;\n    uint32_t num_loops;\n  } private_impl;\n} wuffs_base__image_config;\n\n// wuffs_base__image_config__plane_row_length returns the number of bytes per\n// row in the p'th plane. For planar pixel formats, the pixel subsampling\n// applies. It returns zero if there is no p'th plane.\nstatic inline uint64_t wuffs_base__image_config__plane_row_length(\n    wuffs_base__image_config* c,\n    uint32_t p) {\n  if (!c) {\n    return 0;\n  }\n  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(\n      c->private_impl.pixfmt, p);\n  uint64_t width = c->private_impl.width;\n  if ((width > 0) &&\n      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {\n    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(\n      " +
+	"                        c->private_impl.pixsub, p)) >>\n             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,\n                                                    p)) +\n            1;\n  }\n  return ((width * bpp) + 7) / 8;\n}\n\n// wuffs_base__image_config__plane_height returns the number of rows in the\n// p'th plane. For planar pixel formats, the pixel subsampling applies. It\n// returns zero if there is no p'th plane.\nstatic inline uint32_t wuffs_base__image_config__plane_height(\n    wuffs_base__image_config* c,\n    uint32_t p) {\n  if (!c || (p >= wuffs_base__pixel_format__num_planes(\n                      c->private_impl.pixfmt))) {\n    return 0;\n  }\n  uint64_t height = c->private_impl.height;\n  if ((height > 0) &&\n      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {\n    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(\n                                c->private_impl.pixsub, p)) >>\n              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub" +
+	",\n                                                     p)) +\n             1;\n  }\n  return (uint32_t)height;\n}\n\n// TODO: Should this function return bool? An error type?\nstatic inline void wuffs_base__image_config__initialize(\n    wuffs_base__image_config* c,\n    wuffs_base__pixel_format pixfmt,\n    wuffs_base__pixel_subsampling pixsub,\n    uint32_t width,\n    uint32_t height,\n    uint32_t num_loops) {\n  if (!c) {\n    return;\n  }\n  c->private_impl.pixfmt = pixfmt;\n  c->private_impl.pixsub = pixsub;\n  c->private_impl.width = width;\n  c->private_impl.height = height;\n  c->private_impl.num_loops = num_loops;\n\n  // Check that every plane has a non-zero bit depth, and that the total size\n  // of the planes does not overflow a size_t. The maximum row length in bytes\n  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the\n  // product of that and the height might.\n  uint64_t total = 0;\n  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);\n  uint32_t p;\n  for (p = 0; p < n; p++) {\n    if (!wuf" +
+	"fs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {\n      break;\n    }\n    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);\n    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);\n    if ((plane_height > 0) &&\n        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {\n      break;\n    }\n    total += row_length * plane_height;\n  }\n  if ((n == 0) || (p < n)) {\n    *c = ((wuffs_base__image_config){});\n  }\n}\n\nstatic inline void wuffs_base__image_config__invalidate(\n    wuffs_base__image_config* c) {\n  if (c) {\n    *c = ((wuffs_base__image_config){});\n  }\n}\n\nstatic inline bool wuffs_base__image_config__is_valid(\n    wuffs_base__image_config* c) {\n  return c && c->private_impl.pixfmt;\n}\n\nstatic inline wuffs_base__pixel_format wuffs_base__image_config__pixel_format(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.pixfmt : 0;\n}\n\nstatic inline wuffs_base__pixel_subsampling\nwuffs_base__image_config__pixel_subsampling(wuffs_base__image_config*" +
+	" c) {\n  return c ? c->private_impl.pixsub : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__width(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.width : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__height(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.height : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__num_loops(\n    wuffs_base__image_config* c) {\n  return c ? c->private_impl.num_loops : 0;\n}\n\n// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to\n// hold the image's pixel buffer: the sum of every plane's size, laid out\n// consecutively.\n//\n// TODO: should this allow decoding into a color model different from the\n// format's intrinsic one? For example, decoding a JPEG image straight to RGBA\n// instead of to YCbCr?\nstatic inline size_t wuffs_base__image_config__pixbuf_size(\n    wuffs_base__image_config* c) {\n  if (c) {\n    // wuffs_base__image_config__initialize checked that this doesn't\n    // overflow.\n    uint64_t total" +
+	" = 0;\n    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);\n    uint32_t p;\n    for (p = 0; p < n; p++) {\n      total += wuffs_base__image_config__plane_row_length(c, p) *\n               ((uint64_t)wuffs_base__image_config__plane_height(c, p));\n    }\n    return (size_t)total;\n  }\n  return 0;\n}\n\n" +
 	"" +
 	"// --------\n\n// wuffs_base__animation_disposal encodes, for an animated image, how to\n// dispose of a frame after displaying it:\n//  - None means to draw the next frame on top of this one.\n//  - Restore Background means to clear the frame's dirty rectangle to \"the\n//    background color\" (in practice, this means transparent black) before\n//    drawing the next frame.\n//  - Restore Previous means to undo the current frame, so that the next frame\n//    is drawn on top of the previous one.\ntypedef uint8_t wuffs_base__animation_disposal;\n\n#define WUFFS_BASE__ANIMATION_DISPOSAL__NONE ((wuffs_base__animation_disposal)0)\n#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_BACKGROUND \\\n  ((wuffs_base__animation_disposal)1)\n#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_PREVIOUS \\\n  ((wuffs_base__animation_disposal)2)\n\n" +
 	"" +
-	"// --------\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__image_config config;\n    uint32_t loop_count;  // 0-based count of the current loop.\n    wuffs_base__pixel_buffer pixbuf;\n    // TODO: color spaces.\n    wuffs_base__rect_ie_u32 dirty_rect;\n    wuffs_base__flicks duration;\n    bool blend;\n    wuffs_base__animation_disposal disposal;\n    bool palette_changed;\n    uint8_t palette[1024];\n  } private_impl;\n} wuffs_base__image_buffer;\n\nstatic inline void wuffs_base__image_buffer__set_from_pixbuf(\n    wuffs_base__image_buffer* b,\n    wuffs_base__image_config config,\n    wuffs_base__pixel_buffer pixbuf) {\n  if (!b) {\n    return;\n  }\n  *b = ((wuffs_base__image_buffer){});\n  b->private_impl.config = config;\n  b->private_impl.pixbuf = pixbuf;\n}\n\n// TODO: Should this function return bool? An error type?\nstatic inline void wuffs_base__image_buffer__set_from_slice(\n    wuffs_base__image_buff" +
-	"er* b,\n    wuffs_base__image_config config,\n    wuffs_base__slice_u8 pixbuf_memory) {\n  if (!b) {\n    return;\n  }\n  *b = ((wuffs_base__image_buffer){});\n  // TODO: don't assume 1 byte per pixel. Don't assume packed.\n  uint64_t wh = ((uint64_t)config.private_impl.width) *\n                ((uint64_t)config.private_impl.height);\n  if (wh > pixbuf_memory.len) {\n    return;\n  }\n  b->private_impl.config = config;\n  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];\n  tab->ptr = pixbuf_memory.ptr;\n  tab->width = config.private_impl.width;\n  tab->height = config.private_impl.height;\n  tab->stride = config.private_impl.width;\n}\n\n// The palette argument is ignored unless its length is exactly 1024.\nstatic inline void wuffs_base__image_buffer__update(\n    wuffs_base__image_buffer* b,\n    wuffs_base__rect_ie_u32 dirty_rect,\n    wuffs_base__flicks duration,\n    bool blend,\n    wuffs_base__animation_disposal disposal,\n    wuffs_base__slice_u8 palette) {\n  if (!b) {\n    return;\n  }\n\n  // Clip the dirty_rect to t" +
-	"he image bounds.\n  dirty_rect.max_exclusive_x = wuffs_base__u32__min(\n      dirty_rect.max_exclusive_x, b->private_impl.config.private_impl.width);\n  dirty_rect.max_exclusive_y = wuffs_base__u32__min(\n      dirty_rect.max_exclusive_y, b->private_impl.config.private_impl.height);\n  b->private_impl.dirty_rect = dirty_rect;\n\n  b->private_impl.duration = duration;\n  b->private_impl.blend = blend;\n  b->private_impl.disposal = disposal;\n  b->private_impl.palette_changed = palette.ptr && (palette.len == 1024);\n  if (b->private_impl.palette_changed) {\n    memmove(b->private_impl.palette, palette.ptr, 1024);\n  }\n}\n\n// wuffs_base__image_buffer__loop returns whether the image decoder should loop\n// back to the beginning of the animation, assuming that we've reached the end\n// of the encoded stream. If so, it increments b's count of the animation loops\n// played so far.\nstatic inline bool wuffs_base__image_buffer__loop(wuffs_base__image_buffer* b) {\n  if (!b) {\n    return false;\n  }\n  uint32_t n = b->private_impl.config." +
-	"private_impl.num_loops;\n  if (n == 0) {\n    return true;\n  }\n  if (b->private_impl.loop_count < n - 1) {\n    b->private_impl.loop_count++;\n    return true;\n  }\n  return false;\n}\n\n// wuffs_base__image_config returns the overall configuration for this frame.\nstatic inline wuffs_base__image_config* wuffs_base__image_buffer__image_config(\n    wuffs_base__image_buffer* b) {\n  return b ? &b->private_impl.config : NULL;\n}\n\n// wuffs_base__image_buffer__dirty_rect returns an upper bound for what part of\n// this frame's pixels differs from the previous frame.\nstatic inline wuffs_base__rect_ie_u32 wuffs_base__image_buffer__dirty_rect(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private_impl.dirty_rect : ((wuffs_base__rect_ie_u32){0});\n}\n\n// wuffs_base__image_buffer__duration returns the amount of time to display\n// this frame. Zero means to display forever - a still (non-animated) image.\nstatic inline wuffs_base__flicks wuffs_base__image_buffer__duration(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private" +
-	"_impl.duration : 0;\n}\n\n// wuffs_base__image_buffer__blend returns, for a transparent image, whether to\n// blend this frame with the existing canvas.\n//\n// In Porter-Duff compositing operator terminology, false means \"src\" and true\n// means \"src over dst\".\nstatic inline bool wuffs_base__image_buffer__blend(\n    wuffs_base__image_buffer* b) {\n  return b && b->private_impl.blend;\n}\n\n// wuffs_base__image_buffer__disposal returns, for an animated image, how to\n// dispose of this frame after displaying it.\nstatic inline wuffs_base__animation_disposal wuffs_base__image_buffer__disposal(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private_impl.disposal : 0;\n}\n\n// wuffs_base__image_buffer__palette_changed returns whether this frame's\n// palette differs from the previous frame. It is conservative and may return\n// false positives (but never false negatives).\nstatic inline bool wuffs_base__image_buffer__palette_changed(\n    wuffs_base__image_buffer* b) {\n  return b && b->private_impl.palette_changed;\n}\n\n// wuffs_" +
-	"base__image_buffer__palette returns the palette that the pixel data\n// can index. The backing array is inside b and has length 1024.\nstatic inline wuffs_base__slice_u8 wuffs_base__image_buffer__palette(\n    wuffs_base__image_buffer* b) {\n  return b ? ((wuffs_base__slice_u8){.ptr = b->private_impl.palette,\n                                     .len = 1024})\n           : ((wuffs_base__slice_u8){});\n}\n\nstatic inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(\n    wuffs_base__image_buffer* b,\n    uint32_t p) {\n  return (b && (p < WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX))\n             ? b->private_impl.pixbuf.planes[p]\n             : ((wuffs_base__table_u8){});\n}\n\n#endif  // WUFFS_BASE_HEADER_H\n" +
+	"// --------\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__image_config config;\n    uint32_t loop_count;  // 0-based count of the current loop.\n    wuffs_base__pixel_buffer pixbuf;\n    // TODO: color spaces.\n    wuffs_base__rect_ie_u32 dirty_rect;\n    wuffs_base__flicks duration;\n    bool blend;\n    wuffs_base__animation_disposal disposal;\n    bool palette_changed;\n    uint8_t palette[1024];\n  } private_impl;\n} wuffs_base__image_buffer;\n\nstatic inline void wuffs_base__image_buffer__set_from_pixbuf(\n    wuffs_base__image_buffer* b,\n    wuffs_base__image_config config,\n    wuffs_base__pixel_buffer pixbuf) {\n  if (!b) {\n    return;\n  }\n  *b = ((wuffs_base__image_buffer){});\n  b->private_impl.config = config;\n  b->private_impl.pixbuf = pixbuf;\n}\n\n// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its\n// pixel buffer. For planar pixel formats, the planes are laid out" +
+	"\n// consecutively, as per wuffs_base__image_config__pixbuf_size.\n//\n// TODO: Should this function return bool? An error type?\nstatic inline void wuffs_base__image_buffer__set_from_slice(\n    wuffs_base__image_buffer* b,\n    wuffs_base__image_config config,\n    wuffs_base__slice_u8 pixbuf_memory) {\n  if (!b) {\n    return;\n  }\n  *b = ((wuffs_base__image_buffer){});\n  if (!wuffs_base__image_config__is_valid(&config) ||\n      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {\n    return;\n  }\n  b->private_impl.config = config;\n  uint8_t* ptr = pixbuf_memory.ptr;\n  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);\n  uint32_t p;\n  for (p = 0; p < n; p++) {\n    size_t row_length =\n        (size_t)wuffs_base__image_config__plane_row_length(&config, p);\n    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);\n    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];\n    tab->ptr = ptr;\n    tab->width = row_length;\n    tab->height = plane_" +
+	"height;\n    tab->stride = row_length;\n    ptr += row_length * plane_height;\n  }\n}\n\n// The palette argument is ignored unless its length is exactly 1024.\nstatic inline void wuffs_base__image_buffer__update(\n    wuffs_base__image_buffer* b,\n    wuffs_base__rect_ie_u32 dirty_rect,\n    wuffs_base__flicks duration,\n    bool blend,\n    wuffs_base__animation_disposal disposal,\n    wuffs_base__slice_u8 palette) {\n  if (!b) {\n    return;\n  }\n\n  // Clip the dirty_rect to the image bounds.\n  dirty_rect.max_exclusive_x = wuffs_base__u32__min(\n      dirty_rect.max_exclusive_x, b->private_impl.config.private_impl.width);\n  dirty_rect.max_exclusive_y = wuffs_base__u32__min(\n      dirty_rect.max_exclusive_y, b->private_impl.config.private_impl.height);\n  b->private_impl.dirty_rect = dirty_rect;\n\n  b->private_impl.duration = duration;\n  b->private_impl.blend = blend;\n  b->private_impl.disposal = disposal;\n  b->private_impl.palette_changed = palette.ptr && (palette.len == 1024);\n  if (b->private_impl.palette_changed) {\n    mem" +
+	"move(b->private_impl.palette, palette.ptr, 1024);\n  }\n}\n\n// wuffs_base__image_buffer__loop returns whether the image decoder should loop\n// back to the beginning of the animation, assuming that we've reached the end\n// of the encoded stream. If so, it increments b's count of the animation loops\n// played so far.\nstatic inline bool wuffs_base__image_buffer__loop(wuffs_base__image_buffer* b) {\n  if (!b) {\n    return false;\n  }\n  uint32_t n = b->private_impl.config.private_impl.num_loops;\n  if (n == 0) {\n    return true;\n  }\n  if (b->private_impl.loop_count < n - 1) {\n    b->private_impl.loop_count++;\n    return true;\n  }\n  return false;\n}\n\n// wuffs_base__image_config returns the overall configuration for this frame.\nstatic inline wuffs_base__image_config* wuffs_base__image_buffer__image_config(\n    wuffs_base__image_buffer* b) {\n  return b ? &b->private_impl.config : NULL;\n}\n\n// wuffs_base__image_buffer__dirty_rect returns an upper bound for what part of\n// this frame's pixels differs from the previous frame.\ns" +
+	"tatic inline wuffs_base__rect_ie_u32 wuffs_base__image_buffer__dirty_rect(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private_impl.dirty_rect : ((wuffs_base__rect_ie_u32){0});\n}\n\n// wuffs_base__image_buffer__duration returns the amount of time to display\n// this frame. Zero means to display forever - a still (non-animated) image.\nstatic inline wuffs_base__flicks wuffs_base__image_buffer__duration(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private_impl.duration : 0;\n}\n\n// wuffs_base__image_buffer__blend returns, for a transparent image, whether to\n// blend this frame with the existing canvas.\n//\n// In Porter-Duff compositing operator terminology, false means \"src\" and true\n// means \"src over dst\".\nstatic inline bool wuffs_base__image_buffer__blend(\n    wuffs_base__image_buffer* b) {\n  return b && b->private_impl.blend;\n}\n\n// wuffs_base__image_buffer__disposal returns, for an animated image, how to\n// dispose of this frame after displaying it.\nstatic inline wuffs_base__animation_disposal wuffs_" +
+	"base__image_buffer__disposal(\n    wuffs_base__image_buffer* b) {\n  return b ? b->private_impl.disposal : 0;\n}\n\n// wuffs_base__image_buffer__palette_changed returns whether this frame's\n// palette differs from the previous frame. It is conservative and may return\n// false positives (but never false negatives).\nstatic inline bool wuffs_base__image_buffer__palette_changed(\n    wuffs_base__image_buffer* b) {\n  return b && b->private_impl.palette_changed;\n}\n\n// wuffs_base__image_buffer__palette returns the palette that the pixel data\n// can index. The backing array is inside b and has length 1024.\nstatic inline wuffs_base__slice_u8 wuffs_base__image_buffer__palette(\n    wuffs_base__image_buffer* b) {\n  return b ? ((wuffs_base__slice_u8){.ptr = b->private_impl.palette,\n                                     .len = 1024})\n           : ((wuffs_base__slice_u8){});\n}\n\nstatic inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(\n    wuffs_base__image_buffer* b,\n    uint32_t p) {\n  return (b && (p < WUFFS_BASE__PIXE" +
+	"L_FORMAT__NUM_PLANES_MAX))\n             ? b->private_impl.pixbuf.planes[p]\n             : ((wuffs_base__table_u8){});\n}\n\n" +
+	"" +
+	"// ---------------- Saved State\n\n// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the\n// start of every saved state, as written by a wuffs_foo__bar__save_state\n// function. The header holds a format version, a fingerprint of the generated\n// code, the total length (including the header) and a checksum of the rest.\n//\n// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total\n// length of its saved state.\n#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16\n\n#endif  // WUFFS_BASE_HEADER_H\n" +
 	""
 
 const baseImpl = "" +
-	"// Copyright 2017 The Wuffs Authors.\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//    https://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\nstatic inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {\n  return ((wuffs_base__empty_struct){});\n}\n\n#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)\n\n// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.\n// It's not foolproof, given C doesn't automatically zero memory before use,\n// but it should catch 99.99% of cases.\n//\n// Its (non-zero) value is arbitrary, base" +
-	"d on md5sum(\"wuffs\").\n#define WUFFS_BASE__MAGIC ((uint32_t)0x3CCB6C71)\n\n// Denote intentional fallthroughs for -Wimplicit-fallthrough.\n//\n// The order matters here. Clang also defines \"__GNUC__\".\n#if defined(__clang__) && __cplusplus >= 201103L\n#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]\n#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)\n#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))\n#else\n#define WUFFS_BASE__FALLTHROUGH\n#endif\n\n// Use switch cases for coroutine suspension points, similar to the technique\n// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html\n//\n// We use trivial macros instead of an explicit assignment and case statement\n// so that clang-format doesn't get confused by the unusual \"case\"s.\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \\\n  coro_susp_point = n;                            \\\n  WUFFS_BASE__FALLTHROUGH;                        \\\n  case n:;\n\n#define WUFFS_BASE__COROUTINE_" +
-	"SUSPENSION_POINT_MAYBE_SUSPEND(n) \\\n  if (status < 0) {                                             \\\n    goto exit;                                                  \\\n  } else if (status == 0) {                                     \\\n    goto ok;                                                    \\\n  }                                                             \\\n  coro_susp_point = n;                                          \\\n  goto suspend;                                                 \\\n  case n:;\n\n// Clang also defines \"__GNUC__\".\n#if defined(__GNUC__)\n#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))\n#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))\n#else\n#define WUFFS_BASE__LIKELY(expr) (expr)\n#define WUFFS_BASE__UNLIKELY(expr) (expr)\n#endif\n\n// Uncomment this #include for printf-debugging.\n// #include <stdio.h>\n\n// The helpers below are functions, instead of macros, because their arguments\n// can be an expression that we shouldn't evaluate more than once.\n//\n// They a" +
-	"re in base-impl.h and hence copy/pasted into every generated C file,\n// instead of being in some \"base.c\" file, since a design goal is that users of\n// the generated C code can often just #include a single .c file, such as\n// \"gif.c\", without having to additionally include or otherwise build and link\n// a \"base.c\" file.\n//\n// They are static, so that linking multiple wuffs .o files won't complain about\n// duplicate function definitions.\n//\n// They are explicitly marked inline, even if modern compilers don't use the\n// inline attribute to guide optimizations such as inlining, to avoid the\n// -Wunused-function warning, and we like to compile with -Wall -Werror.\n\n" +
+	"// Copyright 2017 The Wuffs Authors.\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//    https://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\nstatic inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {\n  return ((wuffs_base__empty_struct){});\n}\n\n#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)\n\n// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code\n// generated by \"wuffs-c gen -debug_asserts\" uses it to re-check, at run time,\n// the array and slice index bounds that the Wuffs compiler proved. That code\n// also #in" +
+	"clude's <assert.h>.\n#define WUFFS_BASE__DEBUG_INDEX(i, n) \\\n  (assert((uint64_t)(i) < (uint64_t)(n)), (i))\n\n// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.\n// It's not foolproof, given C doesn't automatically zero memory before use,\n// but it should catch 99.99% of cases.\n//\n// Its (non-zero) value is arbitrary, based on md5sum(\"wuffs\").\n#define WUFFS_BASE__MAGIC ((uint32_t)0x3CCB6C71)\n\n// Denote intentional fallthroughs for -Wimplicit-fallthrough.\n//\n// The order matters here. Clang also defines \"__GNUC__\".\n#if defined(__clang__) && __cplusplus >= 201103L\n#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]\n#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)\n#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))\n#else\n#define WUFFS_BASE__FALLTHROUGH\n#endif\n\n// Use switch cases for coroutine suspension points, similar to the technique\n// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html\n//\n// We use trivial macros instead of an explicit assignm" +
+	"ent and case statement\n// so that clang-format doesn't get confused by the unusual \"case\"s.\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \\\n  coro_susp_point = n;                            \\\n  WUFFS_BASE__FALLTHROUGH;                        \\\n  case n:;\n\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \\\n  if (status < 0) {                                             \\\n    goto exit;                                                  \\\n  } else if (status == 0) {                                     \\\n    goto ok;                                                    \\\n  }                                                             \\\n  coro_susp_point = n;                                          \\\n  goto suspend;                                                 \\\n  case n:;\n\n// Clang also defines \"__GNUC__\".\n#if defined(__GNUC__)\n#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))\n#define WUFFS_BASE__UNLIKELY(expr) (__builtin" +
+	"_expect(!!(expr), 0))\n#else\n#define WUFFS_BASE__LIKELY(expr) (expr)\n#define WUFFS_BASE__UNLIKELY(expr) (expr)\n#endif\n\n// Uncomment this #include for printf-debugging.\n// #include <stdio.h>\n\n// The helpers below are functions, instead of macros, because their arguments\n// can be an expression that we shouldn't evaluate more than once.\n//\n// They are in base-impl.h and hence copy/pasted into every generated C file,\n// instead of being in some \"base.c\" file, since a design goal is that users of\n// the generated C code can often just #include a single .c file, such as\n// \"gif.c\", without having to additionally include or otherwise build and link\n// a \"base.c\" file.\n//\n// They are static, so that linking multiple wuffs .o files won't complain about\n// duplicate function definitions.\n//\n// They are explicitly marked inline, even if modern compilers don't use the\n// inline attribute to guide optimizations such as inlining, to avoid the\n// -Wunused-function warning, and we like to compile with -Wall -Werror.\n\n" +
 	"" +
 	"// ---------------- Numeric Types\n\nstatic inline uint16_t wuffs_base__load_u16be(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);\n}\n\nstatic inline uint16_t wuffs_base__load_u16le(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);\n}\n\nstatic inline uint32_t wuffs_base__load_u24be(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 16) | ((uint32_t)(p[1]) << 8) |\n         ((uint32_t)(p[2]) << 0);\n}\n\nstatic inline uint32_t wuffs_base__load_u24le(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |\n         ((uint32_t)(p[2]) << 16);\n}\n\nstatic inline uint32_t wuffs_base__load_u32be(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |\n         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);\n}\n\nstatic inline uint32_t wuffs_base__load_u32le(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |\n         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);\n}\n\nstatic inline uint64_t wuffs_base__load_u40be(uint8_t" +
 	"* p) {\n  return ((uint64_t)(p[0]) << 32) | ((uint64_t)(p[1]) << 24) |\n         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 8) |\n         ((uint64_t)(p[4]) << 0);\n}\n\nstatic inline uint64_t wuffs_base__load_u40le(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |\n         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |\n         ((uint64_t)(p[4]) << 32);\n}\n\nstatic inline uint64_t wuffs_base__load_u48be(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 40) | ((uint64_t)(p[1]) << 32) |\n         ((uint64_t)(p[2]) << 24) | ((uint64_t)(p[3]) << 16) |\n         ((uint64_t)(p[4]) << 8) | ((uint64_t)(p[5]) << 0);\n}\n\nstatic inline uint64_t wuffs_base__load_u48le(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |\n         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |\n         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40);\n}\n\nstatic inline uint64_t wuffs_base__load_u56be(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 48) | ((uint64_t)(p[1]) << 40) |\n         ((u" +
 	"int64_t)(p[2]) << 32) | ((uint64_t)(p[3]) << 24) |\n         ((uint64_t)(p[4]) << 16) | ((uint64_t)(p[5]) << 8) |\n         ((uint64_t)(p[6]) << 0);\n}\n\nstatic inline uint64_t wuffs_base__load_u56le(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |\n         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |\n         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |\n         ((uint64_t)(p[6]) << 48);\n}\n\nstatic inline uint64_t wuffs_base__load_u64be(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 56) | ((uint64_t)(p[1]) << 48) |\n         ((uint64_t)(p[2]) << 40) | ((uint64_t)(p[3]) << 32) |\n         ((uint64_t)(p[4]) << 24) | ((uint64_t)(p[5]) << 16) |\n         ((uint64_t)(p[6]) << 8) | ((uint64_t)(p[7]) << 0);\n}\n\nstatic inline uint64_t wuffs_base__load_u64le(uint8_t* p) {\n  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |\n         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |\n         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |\n         ((uint64_t)(p[6]) << " +
-	"48) | ((uint64_t)(p[7]) << 56);\n}\n\n" +
+	"48) | ((uint64_t)(p[7]) << 56);\n}\n\nstatic inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {\n  p[0] = (uint8_t)(x >> 0);\n  p[1] = (uint8_t)(x >> 8);\n}\n\nstatic inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {\n  p[0] = (uint8_t)(x >> 0);\n  p[1] = (uint8_t)(x >> 8);\n  p[2] = (uint8_t)(x >> 16);\n  p[3] = (uint8_t)(x >> 24);\n}\n\nstatic inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {\n  p[0] = (uint8_t)(x >> 0);\n  p[1] = (uint8_t)(x >> 8);\n  p[2] = (uint8_t)(x >> 16);\n  p[3] = (uint8_t)(x >> 24);\n  p[4] = (uint8_t)(x >> 32);\n  p[5] = (uint8_t)(x >> 40);\n  p[6] = (uint8_t)(x >> 48);\n  p[7] = (uint8_t)(x >> 56);\n}\n\n" +
 	"" +
 	"// --------\n\nstatic inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {\n  *x = wuffs_base__u8__sat_add(*x, y);\n}\n\nstatic inline void wuffs_base__u8__sat_sub_indirect(uint8_t* x, uint8_t y) {\n  *x = wuffs_base__u8__sat_sub(*x, y);\n}\n\nstatic inline void wuffs_base__u16__sat_add_indirect(uint16_t* x, uint16_t y) {\n  *x = wuffs_base__u16__sat_add(*x, y);\n}\n\nstatic inline void wuffs_base__u16__sat_sub_indirect(uint16_t* x, uint16_t y) {\n  *x = wuffs_base__u16__sat_sub(*x, y);\n}\n\nstatic inline void wuffs_base__u32__sat_add_indirect(uint32_t* x, uint32_t y) {\n  *x = wuffs_base__u32__sat_add(*x, y);\n}\n\nstatic inline void wuffs_base__u32__sat_sub_indirect(uint32_t* x, uint32_t y) {\n  *x = wuffs_base__u32__sat_sub(*x, y);\n}\n\nstatic inline void wuffs_base__u64__sat_add_indirect(uint64_t* x, uint64_t y) {\n  *x = wuffs_base__u64__sat_add(*x, y);\n}\n\nstatic inline void wuffs_base__u64__sat_sub_indirect(uint64_t* x, uint64_t y) {\n  *x = wuffs_base__u64__sat_sub(*x, y);\n}\n\n" +
 	"" +
@@ -113,8 +123,13 @@ const baseImpl = "" +
 	"fs_base__io_writer__is_valid(wuffs_base__io_writer o) {\n  wuffs_base__io_buffer* buf = o.private_impl.buf;\n  // Note: if making this function public (i.e. moving it to base-header.h), it\n  // also needs to allow NULL (i.e. implicit, callee-calculated) bounds.\n  return buf ? ((buf->ptr <= o.private_impl.bounds[0]) &&\n                (o.private_impl.bounds[0] <= o.private_impl.bounds[1]) &&\n                (o.private_impl.bounds[1] <= buf->ptr + buf->len))\n             : ((o.private_impl.bounds[0] == NULL) &&\n                (o.private_impl.bounds[1] == NULL));\n}\n\nstatic inline uint32_t wuffs_base__io_writer__copy_from_history32(\n    uint8_t** ptr_ptr,\n    uint8_t* start,\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  if (!distance) {\n    return 0;\n  }\n  uint8_t* ptr = *ptr_ptr;\n  if ((size_t)(ptr - start) < (size_t)(distance)) {\n    return 0;\n  }\n  start = ptr - distance;\n  size_t n = end - ptr;\n  if ((size_t)(length) > n) {\n    length = n;\n  } else {\n    n = length;\n  }\n  // TODO: unrolling" +
 	" by 3 seems best for the std/deflate benchmarks, but that\n  // is mostly because 3 is the minimum length for the deflate format. This\n  // function implementation shouldn't overfit to that one format. Perhaps the\n  // copy_from_history32 Wuffs method should also take an unroll hint argument,\n  // and the cgen can look if that argument is the constant expression '3'.\n  //\n  // See also wuffs_base__io_writer__copy_from_history32__bco below.\n  //\n  // Alternatively, or additionally, have a sloppy_copy_from_history32 method\n  // that copies 8 bytes at a time, possibly writing more than length bytes?\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\n// wuffs_base__io_writer__copy_from_history32__bco is a Bounds Check Optimized\n// version of the wuffs_base__io_writer__copy_from_history32 function above.\n// The caller needs to prove that:\n//  - distance >  0\n//  - distance <= (*ptr_pt" +
 	"r - start)\n//  - length   <= (end      - *ptr_ptr)\nstatic inline uint32_t wuffs_base__io_writer__copy_from_history32__bco(\n    uint8_t** ptr_ptr,\n    uint8_t* start,\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  uint8_t* ptr = *ptr_ptr;\n  start = ptr - distance;\n  uint32_t n = length;\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\nstatic inline uint32_t wuffs_base__io_writer__copy_from_reader32(\n    uint8_t** ptr_ioptr_w,\n    uint8_t* iobounds1_w,\n    uint8_t** ptr_ioptr_r,\n    uint8_t* iobounds1_r,\n    uint32_t length) {\n  uint8_t* ioptr_w = *ptr_ioptr_w;\n  size_t n = length;\n  if (n > iobounds1_w - ioptr_w) {\n    n = iobounds1_w - ioptr_w;\n  }\n  uint8_t* ioptr_r = *ptr_ioptr_r;\n  if (n > iobounds1_r - ioptr_r) {\n    n = iobounds1_r - ioptr_r;\n  }\n  if (n > 0) {\n    memmove(ioptr_w, ioptr_r, n);\n    *ptr_ioptr_w += n;\n    *ptr_ioptr_r += n;\n  }\n  return " +
-	"n;\n}\n\nstatic inline uint64_t wuffs_base__io_writer__copy_from_slice(\n    uint8_t** ptr_ioptr_w,\n    uint8_t* iobounds1_w,\n    wuffs_base__slice_u8 src) {\n  uint8_t* ioptr_w = *ptr_ioptr_w;\n  size_t n = src.len;\n  if (n > iobounds1_w - ioptr_w) {\n    n = iobounds1_w - ioptr_w;\n  }\n  if (n > 0) {\n    memmove(ioptr_w, src.ptr, n);\n    *ptr_ioptr_w += n;\n  }\n  return n;\n}\n\nstatic inline uint32_t wuffs_base__io_writer__copy_from_slice32(\n    uint8_t** ptr_ioptr_w,\n    uint8_t* iobounds1_w,\n    wuffs_base__slice_u8 src,\n    uint32_t length) {\n  uint8_t* ioptr_w = *ptr_ioptr_w;\n  size_t n = src.len;\n  if (n > length) {\n    n = length;\n  }\n  if (n > iobounds1_w - ioptr_w) {\n    n = iobounds1_w - ioptr_w;\n  }\n  if (n > 0) {\n    memmove(ioptr_w, src.ptr, n);\n    *ptr_ioptr_w += n;\n  }\n  return n;\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(\n    wuffs_base__io_reader* o,\n    uint8_t* ioptr_r,\n    uint64_t limit) {\n  if (o && ((o->private_impl.bounds[1] - ioptr_r) > limit)) {\n    o->private" +
-	"_impl.bounds[1] = ioptr_r + limit;\n  }\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_reader__set_mark(\n    wuffs_base__io_reader* o,\n    uint8_t* mark) {\n  o->private_impl.bounds[0] = mark;\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_writer__set(\n    wuffs_base__io_writer* o,\n    wuffs_base__io_buffer* b,\n    uint8_t** ioptr1_ptr,\n    uint8_t** ioptr2_ptr,\n    wuffs_base__slice_u8 s) {\n  b->ptr = s.ptr;\n  b->len = s.len;\n  b->wi = 0;\n  b->ri = 0;\n  b->closed = false;\n  o->private_impl.buf = b;\n  o->private_impl.bounds[0] = s.ptr;\n  o->private_impl.bounds[1] = s.ptr + s.len;\n  *ioptr1_ptr = s.ptr;\n  *ioptr2_ptr = s.ptr + s.len;\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(\n    wuffs_base__io_writer* o,\n    uint8_t* mark) {\n  o->private_impl.bounds[0] = mark;\n  return ((wuffs_base__empty_struct){});\n}\n" +
+	"n;\n}\n\nstatic inline uint64_t wuffs_base__io_writer__copy_from_slice(\n    uint8_t** ptr_ioptr_w,\n    uint8_t* iobounds1_w,\n    wuffs_base__slice_u8 src) {\n  uint8_t* ioptr_w = *ptr_ioptr_w;\n  size_t n = src.len;\n  if (n > iobounds1_w - ioptr_w) {\n    n = iobounds1_w - ioptr_w;\n  }\n  if (n > 0) {\n    memmove(ioptr_w, src.ptr, n);\n    *ptr_ioptr_w += n;\n  }\n  return n;\n}\n\nstatic inline uint32_t wuffs_base__io_writer__copy_from_slice32(\n    uint8_t** ptr_ioptr_w,\n    uint8_t* iobounds1_w,\n    wuffs_base__slice_u8 src,\n    uint32_t length) {\n  uint8_t* ioptr_w = *ptr_ioptr_w;\n  size_t n = src.len;\n  if (n > length) {\n    n = length;\n  }\n  if (n > iobounds1_w - ioptr_w) {\n    n = iobounds1_w - ioptr_w;\n  }\n  if (n > 0) {\n    memmove(ioptr_w, src.ptr, n);\n    *ptr_ioptr_w += n;\n  }\n  return n;\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_reader__set(\n    wuffs_base__io_reader* o,\n    wuffs_base__io_buffer* b,\n    uint8_t** ioptr1_ptr,\n    uint8_t** ioptr2_ptr,\n    wuffs_base__slice_u8 s,\n    bool closed)" +
+	" {\n  b->ptr = s.ptr;\n  b->len = s.len;\n  b->wi = s.len;\n  b->ri = 0;\n  b->closed = closed;\n  o->private_impl.buf = b;\n  o->private_impl.bounds[0] = s.ptr;\n  o->private_impl.bounds[1] = s.ptr + s.len;\n  *ioptr1_ptr = s.ptr;\n  *ioptr2_ptr = s.ptr + s.len;\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(\n    wuffs_base__io_reader* o,\n    uint8_t* ioptr_r,\n    uint64_t limit) {\n  if (o && ((o->private_impl.bounds[1] - ioptr_r) > limit)) {\n    o->private_impl.bounds[1] = ioptr_r + limit;\n  }\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_reader__set_mark(\n    wuffs_base__io_reader* o,\n    uint8_t* mark) {\n  o->private_impl.bounds[0] = mark;\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_writer__set(\n    wuffs_base__io_writer* o,\n    wuffs_base__io_buffer* b,\n    uint8_t** ioptr1_ptr,\n    uint8_t** ioptr2_ptr,\n    wuffs_base__slice_u8 s) {\n  b->ptr" +
+	" = s.ptr;\n  b->len = s.len;\n  b->wi = 0;\n  b->ri = 0;\n  b->closed = false;\n  o->private_impl.buf = b;\n  o->private_impl.bounds[0] = s.ptr;\n  o->private_impl.bounds[1] = s.ptr + s.len;\n  *ioptr1_ptr = s.ptr;\n  *ioptr2_ptr = s.ptr + s.len;\n  return ((wuffs_base__empty_struct){});\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(\n    wuffs_base__io_writer* o,\n    uint8_t* mark) {\n  o->private_impl.bounds[0] = mark;\n  return ((wuffs_base__empty_struct){});\n}\n\n" +
+	"" +
+	"// ---------------- Saved State\n\n// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved\n// state's header. It changes whenever the saved state encoding does.\n#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)\n\n// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It\n// detects truncated or corrupted saved states. It is not a cryptographic hash.\nstatic inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,\n                                                         size_t n) {\n  uint32_t h = 2166136261u;\n  size_t i;\n  for (i = 0; i < n; i++) {\n    h = (h ^ p[i]) * 16777619u;\n  }\n  return h;\n}\n\n// wuffs_base__saved_state__write_header writes the header for a saved state of\n// length n (including the header) to p, after the rest of that saved state\n// has been written.\nstatic inline void wuffs_base__saved_state__write_header(uint8_t* p,\n                                                         uint32_t fingerprint,\n                                     " +
+	"                    uint32_t n) {\n  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);\n  wuffs_base__store_u32le(p + 4, fingerprint);\n  wuffs_base__store_u32le(p + 8, n);\n  wuffs_base__store_u32le(\n      p + 12,\n      wuffs_base__saved_state__checksum(\n          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,\n          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));\n}\n\n// wuffs_base__saved_state__check_header returns whether s holds a saved state\n// of length n (including the header) whose header matches fingerprint and\n// whose checksum is correct.\nstatic inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,\n                                                         uint32_t fingerprint,\n                                                         uint32_t n) {\n  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&\n         (wuffs_base__load_u32le(s.ptr + 0) ==\n          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&\n         (wuffs_base__load_u32le(s.ptr + 4) ==" +
+	" fingerprint) &&\n         (wuffs_base__load_u32le(s.ptr + 8) == n) &&\n         (wuffs_base__load_u32le(s.ptr + 12) ==\n          wuffs_base__saved_state__checksum(\n              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,\n              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));\n}\n" +
 	""
 
 type template_args_short_read struct {
@@ -47,6 +47,9 @@ func (g *gen) writeExpr(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32
 	if cv := n.ConstValue(); cv != nil {
 		if !n.MType().IsBool() {
 			b.writes(cv.String())
+			if cv.Cmp(maxInt64) > 0 {
+				b.writeb('u')
+			}
 		} else if cv.Cmp(zero) == 0 {
 			b.writes("false")
 		} else if cv.Cmp(one) == 0 {
@@ -129,17 +132,33 @@ func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, depth u
 
 	case t.IDOpenBracket:
 		// n is an index.
-		if err := g.writeExpr(b, n.LHS().Expr(), rp, depth); err != nil {
+		lhs := buffer(nil)
+		if err := g.writeExpr(&lhs, n.LHS().Expr(), rp, depth); err != nil {
 			return err
 		}
-		if lTyp := n.LHS().Expr().MType(); lTyp.IsSliceType() {
+		b.writex(lhs)
+		lTyp := n.LHS().Expr().MType()
+		if lTyp.IsSliceType() {
 			// TODO: don't assume that the slice is a slice of base.u8.
 			b.writes(".ptr")
 		}
 		b.writeb('[')
+		if g.debugAsserts {
+			// The Wuffs compiler proved that the index is in bounds.
+			b.writes("WUFFS_BASE__DEBUG_INDEX(")
+		}
 		if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
 			return err
 		}
+		if g.debugAsserts {
+			if lTyp.IsSliceType() {
+				b.printf(", %s.len)", lhs)
+			} else if lTyp.IsArrayType() {
+				b.printf(", %v)", lTyp.ArrayLength().ConstValue())
+			} else {
+				return fmt.Errorf("cannot index type %q", lTyp.Str(g.tm))
+			}
+		}
 		b.writeb(']')
 		return nil
 
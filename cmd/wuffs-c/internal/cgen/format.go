@@ -0,0 +1,869 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// The C code is generated without regard to layout, and then pretty-printed
+// by formatC: one statement per line, two-space indentation and lines wrapped
+// at 80 columns, roughly following clang-format's Chromium style. The output
+// depends only on the input, so that the generated code is byte-for-byte
+// identical across machines, regardless of which C formatter (if any) is
+// installed.
+//
+// formatC is not a general purpose C formatter. It understands only as much C
+// as this package generates. Hand-written C code, such as base-header.h, is
+// already formatted, and is passed through verbatim when it is bracketed by
+// format markers.
+const (
+	formatOffMarker = "// wuffs-format-off"
+	formatOnMarker  = "// wuffs-format-on"
+)
+
+const (
+	formatIndent         = 2
+	formatContinuation   = 4
+	formatMaxLineLength  = 80
+	formatTrailingPrefix = "  "
+)
+
+// writeVerbatim writes hand-written C code that formatC should not re-format.
+func (b *buffer) writeVerbatim(s string) {
+	if n := len(*b); n > 0 && (*b)[n-1] != '\n' {
+		b.writeb('\n')
+	}
+	b.writes(formatOffMarker)
+	b.writeb('\n')
+	b.writes(s)
+	if n := len(s); n > 0 && s[n-1] != '\n' {
+		b.writeb('\n')
+	}
+	b.writes(formatOnMarker)
+	b.writeb('\n')
+}
+
+// formatC pretty-prints generated C code.
+func formatC(src []byte) ([]byte, error) {
+	toks, err := lexC(src)
+	if err != nil {
+		return nil, err
+	}
+	f := &formatter{toks: toks}
+	if err := f.layout(); err != nil {
+		return nil, err
+	}
+	return f.render(), nil
+}
+
+type ctokenKind uint8
+
+const (
+	ctokenCode ctokenKind = iota // Identifiers, numbers, literals and punctuation.
+	ctokenComment
+	ctokenPreprocessor
+	ctokenVerbatim
+)
+
+type ctoken struct {
+	kind ctokenKind
+	text string
+	// nl is the number of newlines between the previous token and this one.
+	nl int
+}
+
+// cPunctuation lists the multi-byte C punctuation tokens, longest first.
+var cPunctuation = []string{
+	"<<=", ">>=", "...",
+	"->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "::",
+}
+
+func lexC(src []byte) ([]ctoken, error) {
+	toks := []ctoken(nil)
+	nl, lineStart := 0, true
+	for i := 0; i < len(src); {
+		c := src[i]
+		if c == '\n' {
+			nl, lineStart, i = nl+1, true, i+1
+			continue
+		} else if c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			i++
+			continue
+		}
+
+		kind, j := ctokenCode, i+1
+		switch {
+		case c == '#' && lineStart:
+			kind = ctokenPreprocessor
+			for ; j < len(src) && (src[j] != '\n' || src[j-1] == '\\'); j++ {
+			}
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			kind = ctokenComment
+			for ; j < len(src) && src[j] != '\n'; j++ {
+			}
+			if string(src[i:j]) == formatOffMarker {
+				k := bytes.Index(src[j:], []byte("\n"+formatOnMarker+"\n"))
+				if k < 0 {
+					return nil, fmt.Errorf("cgen: unmatched %q", formatOffMarker)
+				}
+				toks = append(toks, ctoken{ctokenVerbatim, string(src[j+1 : j+k+1]), nl})
+				// Leave i at the "\n" that ends the formatOnMarker line.
+				nl, lineStart, i = 0, false, j+k+1+len(formatOnMarker)
+				continue
+			}
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			kind = ctokenComment
+			k := bytes.Index(src[i+2:], []byte("*/"))
+			if k < 0 {
+				return nil, fmt.Errorf("cgen: unterminated C comment")
+			}
+			j = i + 2 + k + 2
+
+		case c == '"' || c == '\'':
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' {
+					j++
+				} else if src[j] == '\n' {
+					return nil, fmt.Errorf("cgen: unterminated C literal")
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("cgen: unterminated C literal")
+			}
+			j++
+
+		case isCIdentByte(c):
+			for ; j < len(src) && (isCIdentByte(src[j]) || (src[j] == '.' && isDigit(c))); j++ {
+			}
+
+		default:
+			for _, p := range cPunctuation {
+				if bytes.HasPrefix(src[i:], []byte(p)) {
+					j = i + len(p)
+					break
+				}
+			}
+		}
+
+		toks = append(toks, ctoken{kind, strings.TrimRight(string(src[i:j]), " \t"), nl})
+		nl, lineStart, i = 0, false, j
+	}
+	return toks, nil
+}
+
+func isCIdentByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// crole is a code token's syntactic role, as far as spacing and line breaking
+// are concerned.
+type crole uint8
+
+const (
+	croleNone      crole = iota
+	croleOperand         // An identifier, literal, ")", "]" or postfix "++".
+	croleTypeName        // A type name such as "uint8_t" or "wuffs_base__io_buffer".
+	croleTypeStar        // The "*" in "uint8_t* p".
+	croleKeyword         // A keyword such as "if" or "return".
+	croleOpen            // An opening "(", "[" or initializer "{".
+	croleUnary           // A prefix operator such as "!" or "&".
+	croleBinary          // An infix operator such as "+" or "=".
+	croleMember          // A "." or "->".
+	croleCastClose       // The ")" that ends a cast.
+	croleSeparator       // A "," or ";".
+)
+
+var cKeywords = map[string]bool{
+	"break": true, "case": true, "continue": true, "default": true, "do": true,
+	"else": true, "extern": true, "for": true, "goto": true, "if": true,
+	"inline": true, "return": true, "sizeof": true, "static": true,
+	"switch": true, "typedef": true, "while": true,
+}
+
+var cTypeKeywords = map[string]bool{
+	"bool": true, "char": true, "const": true, "double": true, "enum": true,
+	"float": true, "int": true, "long": true, "short": true, "signed": true,
+	"struct": true, "union": true, "unsigned": true, "void": true,
+	"volatile": true,
+}
+
+// isCTypeName returns whether s is (probably) the name of a type. Like this
+// package's naming conventions, it treats every lower-case "wuffs_" prefixed
+// identifier as a type name, even if it names a function or a table. That is
+// harmless: it only affects whether a following "*" is spaced as a pointer
+// declaration or as a multiplication, and generated code never multiplies
+// such identifiers.
+func isCTypeName(s string, prev string) bool {
+	return cTypeKeywords[s] || strings.HasSuffix(s, "_t") || strings.HasPrefix(s, "wuffs_") ||
+		prev == "struct" || prev == "union" || prev == "enum"
+}
+
+type ftok struct {
+	text  string
+	role  crole
+	space bool // Whether a space separates this token from the previous one.
+	// initOpen and initClose mark the braces of a declaration's top-level
+	// initializer list, such as a table's elements.
+	initOpen  bool
+	initClose bool
+}
+
+type flineKind uint8
+
+const (
+	flineCode flineKind = iota
+	flineRaw            // A comment or a preprocessor directive.
+	flineVerbatim
+)
+
+type fline struct {
+	kind   flineKind
+	indent int
+	blank  bool // Whether to precede this line by a blank line.
+	toks   []ftok
+	// text is the flineRaw or flineVerbatim text, or a flineCode line's
+	// trailing comment.
+	text string
+}
+
+type fblockKind uint8
+
+const (
+	fblockPlain fblockKind = iota
+	fblockSwitch
+	fblockExtern
+	// fblockRecord and fblockDo blocks are followed by more of the statement
+	// that started them: the "foo;" in "typedef struct { etc } foo;", or the
+	// "while (etc);" in "do { etc } while (etc);".
+	fblockRecord
+	fblockDo
+)
+
+type fblock struct {
+	kind       fblockKind
+	openIndent int
+	indent     int
+	inCase     bool
+	outer      fstmt
+}
+
+// fstmt is the state of the statement being formatted.
+type fstmt struct {
+	first string
+	n     int
+	// cont is whether the statement's first line has been written, e.g. when
+	// a comment interrupts the statement.
+	cont bool
+}
+
+type fparen struct {
+	open     string
+	n        int
+	cast     bool
+	initList bool
+}
+
+type formatter struct {
+	toks   []ctoken
+	lines  []fline
+	cur    fline
+	blocks []fblock
+	parens []fparen
+	stmt   fstmt
+
+	prevText string
+	prevRole crole
+
+	// blank is whether the current token was preceded by a blank line.
+	blank bool
+	// justOpened is whether the current block has no lines yet.
+	justOpened bool
+}
+
+func (f *formatter) layout() error {
+	for i := 0; i < len(f.toks); i++ {
+		tok := f.toks[i]
+		f.blank = tok.nl >= 2 && len(f.lines) > 0 && !f.justOpened
+
+		switch tok.kind {
+		case ctokenComment:
+			if n := len(f.lines) - 1; tok.nl == 0 && len(f.cur.toks) == 0 && n >= 0 &&
+				f.lines[n].kind == flineCode && f.lines[n].text == "" && strings.HasPrefix(tok.text, "//") {
+				// A trailing comment after a line that ended with a ";" or "{".
+				f.lines[n].text = tok.text
+				continue
+			}
+			if tok.nl == 0 && len(f.cur.toks) > 0 {
+				if strings.HasPrefix(tok.text, "//") {
+					f.cur.text = tok.text
+					f.flush()
+				} else {
+					f.cur.toks = append(f.cur.toks, ftok{text: tok.text, role: f.prevRole, space: true})
+				}
+				continue
+			}
+			f.flush()
+			f.lines = append(f.lines, fline{
+				kind:   flineRaw,
+				indent: f.lineIndent(),
+				blank:  f.blank,
+				text:   tok.text,
+			})
+			f.justOpened = false
+			continue
+
+		case ctokenPreprocessor, ctokenVerbatim:
+			f.flush()
+			kind := flineRaw
+			if tok.kind == ctokenVerbatim {
+				kind = flineVerbatim
+			}
+			f.lines = append(f.lines, fline{kind: kind, blank: f.blank, text: tok.text})
+			f.justOpened = false
+			continue
+		}
+
+		next := ctoken{}
+		if i+1 < len(f.toks) {
+			next = f.toks[i+1]
+		}
+		if err := f.code(tok.text, next); err != nil {
+			return err
+		}
+	}
+
+	f.flush()
+	if len(f.blocks) != 0 || len(f.parens) != 0 || f.stmt.n != 0 {
+		return fmt.Errorf("cgen: unbalanced C code")
+	}
+	return nil
+}
+
+func (f *formatter) code(s string, next ctoken) error {
+	switch s {
+	case "{":
+		if len(f.parens) > 0 || f.prevText == "return" || f.prevRole == croleBinary ||
+			f.prevRole == croleSeparator || f.prevRole == croleCastClose {
+			initList := len(f.parens) == 0 && f.prevText == "="
+			f.add(s, croleOpen, ftok{initOpen: initList})
+			f.parens = append(f.parens, fparen{open: s, initList: initList})
+			return nil
+		}
+		return f.openBlock()
+
+	case "}":
+		if n := len(f.parens); n > 0 {
+			p := f.parens[n-1]
+			if p.open != "{" {
+				return fmt.Errorf("cgen: unbalanced C code")
+			}
+			f.parens = f.parens[:n-1]
+			f.add(s, croleOperand, ftok{initClose: p.initList})
+			return nil
+		}
+		return f.closeBlock(next)
+
+	case "(", "[":
+		// A "(" could be a cast if it does not follow a function's name or a
+		// keyword like "if" or "sizeof".
+		cast := s == "(" && (f.prevRole != croleOperand && f.prevRole != croleTypeName &&
+			f.prevRole != croleTypeStar && f.prevRole != croleKeyword ||
+			f.prevText == "return" || f.prevText == "case")
+		f.add(s, croleOpen, ftok{})
+		f.parens = append(f.parens, fparen{open: s, cast: cast})
+		return nil
+
+	case ")", "]":
+		n := len(f.parens)
+		if n == 0 || (f.parens[n-1].open == "(") != (s == ")") {
+			return fmt.Errorf("cgen: unbalanced C code")
+		}
+		p := f.parens[n-1]
+		f.parens = f.parens[:n-1]
+		role := croleOperand
+		if p.cast && p.n > 0 {
+			role = croleCastClose
+		}
+		f.add(s, role, ftok{})
+		return nil
+
+	case ";":
+		f.add(s, croleSeparator, ftok{})
+		if len(f.parens) == 0 {
+			f.endStmt()
+		}
+		return nil
+
+	case ":":
+		if len(f.parens) != 0 {
+			break
+		}
+		if f.stmt.first == "case" || f.stmt.first == "default" {
+			b := f.top()
+			if b == nil {
+				return fmt.Errorf("cgen: case label outside of a block")
+			}
+			f.cur.indent = b.indent
+			f.add(s, croleSeparator, ftok{})
+			b.inCase = true
+			f.endStmt()
+			return nil
+		} else if f.stmt.n == 1 {
+			// A goto label is outdented by one level.
+			if f.cur.indent > 0 {
+				f.cur.indent--
+			}
+			f.add(s, croleSeparator, ftok{})
+			if next.kind != ctokenCode || next.text != ";" {
+				f.endStmt()
+			}
+			return nil
+		}
+	}
+
+	role := croleOperand
+	switch c := s[0]; {
+	case isCIdentByte(c) || c == '"' || c == '\'':
+		if cKeywords[s] {
+			role = croleKeyword
+		} else if isCTypeName(s, f.prevText) {
+			role = croleTypeName
+		}
+	case s == ",":
+		role = croleSeparator
+	case s == "." || s == "->":
+		role = croleMember
+	case s == "!" || s == "~":
+		role = croleUnary
+	case s == "*" && (f.prevRole == croleTypeName || f.prevRole == croleTypeStar):
+		role = croleTypeStar
+	case s == "*" || s == "&" || s == "+" || s == "-":
+		role = croleUnary
+		if f.prevRole == croleOperand {
+			role = croleBinary
+		}
+	case s == "++" || s == "--":
+		role = croleUnary
+		if f.prevRole == croleOperand {
+			role = croleOperand
+		}
+	default:
+		role = croleBinary
+	}
+	f.add(s, role, ftok{})
+	return nil
+}
+
+func (f *formatter) top() *fblock {
+	if n := len(f.blocks); n > 0 {
+		return &f.blocks[n-1]
+	}
+	return nil
+}
+
+func (f *formatter) openBlock() error {
+	kind := fblockPlain
+	switch f.stmt.first {
+	case "switch":
+		kind = fblockSwitch
+	case "extern":
+		kind = fblockExtern
+	case "typedef", "struct", "union", "enum":
+		kind = fblockRecord
+	case "do":
+		kind = fblockDo
+	}
+
+	f.add("{", croleOpen, ftok{})
+	indent := f.blockIndent()
+	f.flush()
+
+	b := fblock{
+		kind:       kind,
+		openIndent: indent,
+		indent:     indent + 1,
+		outer:      f.stmt,
+	}
+	if kind == fblockExtern {
+		b.indent = indent
+	}
+	f.blocks = append(f.blocks, b)
+	f.endStmt()
+	f.justOpened = true
+	return nil
+}
+
+func (f *formatter) closeBlock(next ctoken) error {
+	n := len(f.blocks)
+	if n == 0 {
+		return fmt.Errorf("cgen: unbalanced C code")
+	}
+	f.flush()
+	b := f.blocks[n-1]
+	f.blocks = f.blocks[:n-1]
+
+	f.stmt = fstmt{}
+	f.blank = false
+	f.add("}", croleOperand, ftok{})
+	f.cur.indent = b.openIndent
+	f.justOpened = false
+
+	switch {
+	case b.kind == fblockRecord || b.kind == fblockDo:
+		f.stmt = b.outer
+		f.stmt.n++
+	case next.kind == ctokenCode && (next.text == "else" || next.text == ";"):
+		f.stmt = fstmt{}
+	case next.kind == ctokenComment && next.nl == 0:
+		// The comment will be a trailing comment on this line.
+		f.stmt = fstmt{}
+		f.prevText, f.prevRole = "", croleNone
+	default:
+		f.endStmt()
+	}
+	return nil
+}
+
+// blockIndent returns the indentation of the current block's statements.
+func (f *formatter) blockIndent() int {
+	indent := 0
+	if b := f.top(); b != nil {
+		indent = b.indent
+		if b.inCase {
+			indent++
+		}
+	}
+	return indent
+}
+
+// lineIndent returns the indentation of the next line, which continues the
+// current statement if that statement has already started.
+func (f *formatter) lineIndent() int {
+	if f.stmt.n > 0 {
+		return f.blockIndent() + formatContinuation/formatIndent
+	}
+	return f.blockIndent()
+}
+
+func (f *formatter) add(s string, role crole, t ftok) {
+	if len(f.cur.toks) == 0 {
+		f.cur.indent = f.lineIndent()
+		f.cur.blank = f.blank
+		f.justOpened = false
+	}
+
+	if n := len(f.parens); n > 0 {
+		p := &f.parens[n-1]
+		p.n++
+		if role != croleTypeName && role != croleTypeStar && s != ")" {
+			p.cast = false
+		}
+	}
+	if f.stmt.n == 0 {
+		f.stmt.first = s
+	}
+	f.stmt.n++
+
+	t.text = s
+	t.role = role
+	t.space = len(f.cur.toks) > 0 && f.space(s, role)
+	f.cur.toks = append(f.cur.toks, t)
+	f.prevText, f.prevRole = s, role
+}
+
+// space returns whether to separate the previous token from the next one, s.
+func (f *formatter) space(s string, role crole) bool {
+	p, prev := f.prevRole, f.prevText
+	if glues(prev, s) {
+		return true
+	}
+	switch s {
+	case ")", "]", "}", ",", ";":
+		return false
+	case ":":
+		if role == croleSeparator {
+			return false
+		}
+	case "{":
+		// A compound literal, such as "(wuffs_base__slice_u8){etc}".
+		if prev == ")" && len(f.parens) > 0 {
+			return false
+		}
+	}
+	switch p {
+	case croleOpen, croleUnary, croleMember, croleCastClose:
+		return false
+	}
+
+	switch {
+	case s == "(":
+		return (p == croleKeyword && prev != "sizeof") || p == croleBinary || p == croleSeparator
+	case s == "[":
+		return false
+	case role == croleMember:
+		return p == croleSeparator
+	case role == croleTypeStar:
+		return false
+	case (s == "++" || s == "--") && role == croleOperand:
+		return false
+	}
+	return true
+}
+
+// glues returns whether writing x and y without a space between them would
+// lex as something other than the x and y tokens, such as "- -" becoming
+// "--".
+func glues(x string, y string) bool {
+	if x == "" || y == "" || isCIdentByte(x[len(x)-1]) || isCIdentByte(y[0]) {
+		return false
+	}
+	pair := x[len(x)-1:] + y[:1]
+	if pair == "//" || pair == "/*" {
+		return true
+	}
+	for _, p := range cPunctuation {
+		if strings.HasPrefix(p, pair) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *formatter) endStmt() {
+	f.flush()
+	f.stmt = fstmt{}
+	f.prevText, f.prevRole = "", croleNone
+}
+
+func (f *formatter) flush() {
+	if len(f.cur.toks) > 0 {
+		if f.stmt.n > 0 {
+			f.stmt.cont = true
+		}
+		f.lines = append(f.lines, f.cur)
+	}
+	f.cur = fline{}
+}
+
+func (f *formatter) render() []byte {
+	out := []byte(nil)
+	for _, l := range f.lines {
+		if l.blank && len(out) > 0 && !bytes.HasSuffix(out, []byte("\n\n")) {
+			out = append(out, '\n')
+		}
+		switch l.kind {
+		case flineRaw:
+			out = append(out, strings.Repeat(" ", l.indent*formatIndent)...)
+			out = append(out, l.text...)
+			out = append(out, '\n')
+		case flineVerbatim:
+			out = append(out, l.text...)
+		case flineCode:
+			out = l.appendCode(out)
+		}
+	}
+	return out
+}
+
+func (l *fline) appendCode(out []byte) []byte {
+	indent := l.indent * formatIndent
+	toks := l.toks
+
+	// A declaration's initializer list is written with its elements on lines
+	// of their own if it does not fit on one line or if it ends with a comma.
+	if i, j := initListBounds(toks); i >= 0 {
+		if toks[j-1].text == "," || codeLength(toks) > formatMaxLineLength-indent {
+			out = appendWrapped(out, toks[:i+1], indent, indent+formatContinuation, "")
+			body := append([]ftok(nil), toks[i+1:j]...)
+			body[0].space = false
+			out = appendWrapped(out, body, indent+formatContinuation, indent+formatContinuation, "")
+			tail := append([]ftok(nil), toks[j:]...)
+			tail[0].space = false
+			return appendWrapped(out, tail, indent, indent+formatContinuation, l.text)
+		}
+	}
+	return appendWrapped(out, toks, indent, indent+formatContinuation, l.text)
+}
+
+func initListBounds(toks []ftok) (i int, j int) {
+	for i = range toks {
+		if toks[i].initOpen {
+			for j = i + 1; j < len(toks); j++ {
+				if toks[j].initClose {
+					if j > i+1 {
+						return i, j
+					}
+					break
+				}
+			}
+			break
+		}
+	}
+	return -1, -1
+}
+
+func codeLength(toks []ftok) int {
+	n := 0
+	for i, t := range toks {
+		if i > 0 && t.space {
+			n++
+		}
+		n += len(t.text)
+	}
+	return n
+}
+
+// breakPenalties are the costs of wrapping a line after an operator (or
+// other token). Other things being equal, a line is wrapped after a lower
+// precedence or less deeply nested operator.
+var breakPenalties = map[string]int{
+	",": 2, "?": 4, ":": 4, "||": 6, "&&": 8, "|": 10, "^": 12, "&": 14,
+	"==": 16, "!=": 16, "<": 18, "<=": 18, ">": 18, ">=": 18, "<<": 20, ">>": 20,
+	"+": 22, "-": 22, "*": 24, "/": 24, "%": 24, "(": 30, "{": 30,
+}
+
+const (
+	breakPenaltyPerDepth      = 10
+	wrapCostPerLine           = 10
+	wrapCostPerOverflowColumn = 1000
+)
+
+// breakPenalty returns the cost of wrapping a line after toks[i], nested
+// depth levels deep, or -1 if the line cannot be wrapped there.
+func breakPenalty(toks []ftok, i int, depth int) int {
+	if i+1 >= len(toks) {
+		return -1
+	}
+	switch toks[i+1].text {
+	case ")", "]", "}", ",", ";":
+		return -1
+	}
+	t := toks[i]
+	if t.text != "," && t.text != "(" && t.text != "{" && t.role != croleBinary {
+		return -1
+	} else if t.role == croleOpen && toks[i+1].role == croleOpen {
+		// Don't leave a line ending with "((".
+		return -1
+	}
+	if p, ok := breakPenalties[t.text]; ok {
+		return depth*breakPenaltyPerDepth + p
+	}
+	// Assignment operators. Inside parentheses or braces, such as in a
+	// designated initializer, they bind tighter than a comma.
+	if depth > 0 {
+		return depth*breakPenaltyPerDepth + 26
+	}
+	return 0
+}
+
+// appendWrapped appends toks, wrapped at formatMaxLineLength columns, and
+// then the trailing comment, if any. The first line is indented by indent
+// spaces and any continuation lines by contIndent spaces.
+//
+// Where to wrap is chosen by dynamic programming, minimizing the sum of a
+// cost per line, the cost of each line's overflow (if any) and the cost of
+// each breakPenalty.
+func appendWrapped(out []byte, toks []ftok, indent int, contIndent int, comment string) []byte {
+	n := len(toks)
+
+	// pos[i] is the length of toks[:i] on a single line. penalties[i] is the
+	// cost of wrapping before toks[i], or -1 if that is not allowed.
+	pos := make([]int, n+1)
+	penalties := make([]int, n+1)
+	for i, depth := 0, 0; i < n; i++ {
+		pos[i+1] = pos[i] + len(toks[i].text)
+		if i > 0 && toks[i].space {
+			pos[i+1]++
+		}
+		switch toks[i].text {
+		case ")", "]", "}":
+			depth--
+		}
+		penalties[i+1] = breakPenalty(toks, i, depth)
+		if toks[i].role == croleOpen {
+			depth++
+		}
+	}
+
+	// costs[j] is the minimum cost of writing toks[:j] with a line break
+	// before toks[j], and prevs[j] is where the last line of that starts.
+	costs := make([]int, n+1)
+	prevs := make([]int, n+1)
+	for j := 1; j <= n; j++ {
+		costs[j] = -1
+		if j < n && penalties[j] < 0 {
+			continue
+		}
+		for i := j - 1; i >= 0; i-- {
+			if (i > 0 && penalties[i] < 0) || costs[i] < 0 {
+				continue
+			}
+			width := contIndent + pos[j] - pos[i]
+			if i == 0 {
+				width = indent + pos[j]
+			} else if toks[i].space {
+				width--
+			}
+			c := costs[i] + wrapCostPerLine
+			if width > formatMaxLineLength {
+				c += wrapCostPerOverflowColumn * (width - formatMaxLineLength)
+			}
+			if j < n {
+				c += penalties[j]
+			}
+			if costs[j] < 0 || c < costs[j] {
+				costs[j], prevs[j] = c, i
+			}
+		}
+	}
+
+	starts := []int(nil)
+	for j := n; j > 0; j = prevs[j] {
+		starts = append(starts, prevs[j])
+	}
+	for k := len(starts) - 1; k >= 0; k-- {
+		i, j := starts[k], n
+		if k > 0 {
+			j = starts[k-1]
+		}
+		if i == 0 {
+			out = append(out, strings.Repeat(" ", indent)...)
+		} else {
+			out = append(out, '\n')
+			out = append(out, strings.Repeat(" ", contIndent)...)
+		}
+		for m := i; m < j; m++ {
+			if m > i && toks[m].space {
+				out = append(out, ' ')
+			}
+			out = append(out, toks[m].text...)
+		}
+	}
+	if comment != "" {
+		out = append(out, formatTrailingPrefix...)
+		out = append(out, comment...)
+	}
+	return append(out, '\n')
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatC(tt *testing.T) {
+	testCases := []struct {
+		src  string
+		want string
+	}{{
+		"uint32_t f(foo_t *p,uint32_t x){if (!p) {return 0;}return x*2+ -x;}\n",
+		"" +
+			"uint32_t f(foo_t* p, uint32_t x) {\n" +
+			"  if (!p) {\n" +
+			"    return 0;\n" +
+			"  }\n" +
+			"  return x * 2 + -x;\n" +
+			"}\n",
+	}, {
+		"void g(){\nswitch (n) {\ncase 0: a = 1; break;\ndefault: a = 2;}\nexit:;\n}\n",
+		"" +
+			"void g() {\n" +
+			"  switch (n) {\n" +
+			"    case 0:\n" +
+			"      a = 1;\n" +
+			"      break;\n" +
+			"    default:\n" +
+			"      a = 2;\n" +
+			"  }\n" +
+			"exit:;\n" +
+			"}\n",
+	}, {
+		"typedef struct {\nuint8_t* ptr;\nsize_t len;\n} slice;\n\n\n\nint x = ( int )(- - y);\n",
+		"" +
+			"typedef struct {\n" +
+			"  uint8_t* ptr;\n" +
+			"  size_t len;\n" +
+			"} slice;\n" +
+			"\n" +
+			"int x = (int)(- -y);\n",
+	}, {
+		"if (x) {\n} else {\ns = ((slice){ .ptr = p, .len = n});\n}\n",
+		"" +
+			"if (x) {\n" +
+			"} else {\n" +
+			"  s = ((slice){.ptr = p, .len = n});\n" +
+			"}\n",
+	}, {
+		"static const uint8_t t[4] = {\n1,2,3,4,};\n",
+		"" +
+			"static const uint8_t t[4] = {\n" +
+			"    1, 2, 3, 4,\n" +
+			"};\n",
+	}, {
+		"#ifdef __cplusplus\nextern \"C\" {\n#endif\nint f(void);// Trailing.\n" +
+			"#ifdef __cplusplus\n}  // extern \"C\"\n#endif\n",
+		"" +
+			"#ifdef __cplusplus\n" +
+			"extern \"C\" {\n" +
+			"#endif\n" +
+			"int f(void);  // Trailing.\n" +
+			"#ifdef __cplusplus\n" +
+			"}  // extern \"C\"\n" +
+			"#endif\n",
+	}, {
+		"int a;\n" + formatOffMarker + "\n  int   b ;\n" + formatOnMarker + "\nint c;\n",
+		"int a;\n  int   b ;\nint c;\n",
+	}, {
+		"x = aaaaaaaaaaaaaaaaaaaa(bbbbbbbbbbbbbbbbbbbb, cccccccccccccccccccc) + dddddddddddddddddddd;\n",
+		"" +
+			"x = aaaaaaaaaaaaaaaaaaaa(bbbbbbbbbbbbbbbbbbbb,\n" +
+			"    cccccccccccccccccccc) + dddddddddddddddddddd;\n",
+	}}
+
+	for i, tc := range testCases {
+		got, err := formatC([]byte(tc.src))
+		if err != nil {
+			tt.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if string(got) != tc.want {
+			tt.Errorf("#%d:\ngot:\n%s\nwant:\n%s", i, got, tc.want)
+		}
+	}
+}
+
+func TestFormatCUnbalanced(tt *testing.T) {
+	for _, src := range []string{"int f() {\n", "}\n", "x = (y;\n", "x = y);\n"} {
+		if _, err := formatC([]byte(src)); err == nil {
+			tt.Errorf("%q: got nil error, want non-nil", src)
+		}
+	}
+}
+
+// TestFormatCIdempotent checks that re-formatting the generated code (other
+// than the hand-written code that formatC passes through verbatim) is a no-op.
+func TestFormatCIdempotent(tt *testing.T) {
+	const implementations = "// ---------------- Function Implementations\n"
+	for _, pkg := range []string{"deflate", "gif"} {
+		src, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "..", "gen", "c", "std", pkg+".c"))
+		if err != nil {
+			tt.Fatal(err)
+		}
+		s := string(src)
+		i := strings.Index(s, implementations)
+		if i < 0 {
+			tt.Fatalf("%s: no %q", pkg, implementations)
+		}
+		s = s[i:]
+		got, err := formatC([]byte(s))
+		if err != nil {
+			tt.Fatalf("%s: %v", pkg, err)
+		}
+		if string(got) != s {
+			tt.Errorf("%s: formatC was not idempotent", pkg)
+		}
+	}
+}
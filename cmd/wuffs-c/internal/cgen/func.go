@@ -173,6 +173,23 @@ func (g *gen) gatherFuncImpl(_ *buffer, n *a.Func) error {
 	return nil
 }
 
+// writeReturnZeroValue writes a return statement for the zero value of the
+// given type: 0 for numeric and boolean types and an empty struct literal for
+// slice types.
+func (g *gen) writeReturnZeroValue(b *buffer, typ *a.TypeExpr) error {
+	if !typ.IsSliceType() {
+		// TODO: don't assume that other return types are integers.
+		b.writes("return 0;")
+		return nil
+	}
+	b.writes("return ((")
+	if err := g.writeCTypeName(b, typ, "", ""); err != nil {
+		return err
+	}
+	b.writes("){});")
+	return nil
+}
+
 func (g *gen) writeFuncImplHeader(b *buffer) error {
 	// Check the previous status and the "self" arg.
 	if g.currFunk.public && !g.currFunk.astFunc.Receiver().IsZero() {
@@ -184,8 +201,9 @@ func (g *gen) writeFuncImplHeader(b *buffer) error {
 		} else if len(outFields) == 0 {
 			b.printf("return;")
 		} else if len(outFields) == 1 {
-			// TODO: don't assume that the return type is an integer.
-			b.printf("return 0;")
+			if err := g.writeReturnZeroValue(b, outFields[0].Field().XType()); err != nil {
+				return err
+			}
 		} else {
 			return fmt.Errorf("TODO: handle structured return types")
 		}
@@ -200,8 +218,9 @@ func (g *gen) writeFuncImplHeader(b *buffer) error {
 		} else if len(outFields) == 0 {
 			b.writes("return;")
 		} else if len(outFields) == 1 {
-			// TODO: don't assume that the return type is an integer.
-			b.writes("return 0;")
+			if err := g.writeReturnZeroValue(b, outFields[0].Field().XType()); err != nil {
+				return err
+			}
 		} else {
 			return fmt.Errorf("TODO: handle structured return types")
 		}
@@ -264,12 +283,16 @@ func (g *gen) writeFuncImplBodyResume(b *buffer) error {
 }
 
 func (g *gen) writeFuncImplBody(b *buffer) error {
+	asserts := g.currFunk.astFunc.Asserts()
+	if err := g.writeDebugAsserts(b, asserts, t.IDPre); err != nil {
+		return err
+	}
 	for _, o := range g.currFunk.astFunc.Body() {
 		if err := g.writeStatement(b, o, 0); err != nil {
 			return err
 		}
 	}
-	return nil
+	return g.writeDebugAsserts(b, asserts, t.IDPost)
 }
 
 func (g *gen) writeFuncImplBodySuspend(b *buffer) error {
@@ -0,0 +1,764 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// The JavaScript module wraps the exports of a "wuffs genlib -target=wasm32"
+// WebAssembly module, for browsers and for Node. It is an ES module, plus a
+// TypeScript declaration (.d.mts) file, generated from the package's public API
+// information, like the Python module.
+//
+// Every C struct and buffer lives in the WebAssembly module's linear memory,
+// allocated by the wuffs_wasm32__alloc function that the "wuffs-wasm32.c"
+// shim provides, and JavaScript objects hold pointers (numbers) into that
+// memory. Those objects have to be freed explicitly, by their free methods.
+// Methods that take exactly one io_writer and one io_reader also get an "All"
+// variant that takes and returns whole Uint8Arrays. 64-bit integers are
+// BigInts.
+
+// jsParam is a wrapped C function's parameter.
+type jsParam struct {
+	name string
+	// conv converts the JavaScript argument to the WebAssembly argument, with
+	// "%s" standing for the JavaScript argument.
+	conv string
+	// tsType is the TypeScript type, e.g. "number".
+	tsType string
+	// slice is whether the argument is a Uint8Array to copy into, and back out
+	// of, linear memory.
+	slice bool
+}
+
+// jsFunc is a wrapped C function.
+type jsFunc struct {
+	f      *a.Func
+	cName  string
+	params []jsParam
+	// result converts the WebAssembly result to the JavaScript result, with
+	// "%s" standing for the WebAssembly result. It is empty for no result.
+	result   string
+	tsResult string
+}
+
+// writeJSModule writes a JavaScript module for the package to the named file,
+// and its TypeScript declarations to the same name with a ".d.mts" suffix
+// instead of ".mjs".
+func (g *gen) writeJSModule(filename string) error {
+	m := wuffsVersionRegexp.FindStringSubmatch(baseHeader)
+	if m == nil {
+		return fmt.Errorf("could not find WUFFS_VERSION in the base header")
+	}
+
+	funcs := map[*a.Struct][]jsFunc{}
+	usesImages := false
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		err := g.forEachFunc(nil, pubOnly, func(g *gen, _ *buffer, f *a.Func) error {
+			if f.Receiver() != n.QID() {
+				return nil
+			}
+			if jf, ok := g.jsFunc(f); ok {
+				funcs[n] = append(funcs[n], jf)
+				for _, p := range jf.params {
+					usesImages = usesImages || p.tsType == "ImageConfig" || p.tsType == "ImageBuffer"
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	b, d := buffer(nil), buffer(nil)
+	b.writes("// Code generated by wuffs-c. DO NOT EDIT.\n\n")
+	b.printf("// Module wuffs_%s wraps the C API of the Wuffs %s package, as built into a\n", g.pkgName, g.pkgName)
+	b.writes("// WebAssembly module by \"wuffs genlib -target=wasm32\".\n\n")
+	b.printf("export const WUFFS_VERSION = %s;\n\n", m[1])
+	d.writes("// Code generated by wuffs-c. DO NOT EDIT.\n\n")
+	d.printf("export const WUFFS_VERSION: number;\n\n")
+
+	g.writeJSStatuses(&b, &d)
+
+	b.writes(strings.Replace(jsLibrary, "wuffs_PKG", "wuffs_"+g.pkgName, -1))
+	b.writes(jsIO)
+	d.writes(jsLibraryDeclarations)
+	if usesImages {
+		b.writes(jsImages)
+		d.writes(jsImagesDeclarations)
+	}
+
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		g.writeJSClass(&b, &d, n, funcs[n])
+	}
+
+	if !strings.HasSuffix(filename, ".mjs") {
+		return fmt.Errorf("JavaScript module filename %q does not end in \".mjs\"", filename)
+	}
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(strings.TrimSuffix(filename, ".mjs")+".d.mts", d, 0644)
+}
+
+func (g *gen) writeJSStatuses(b *buffer, d *buffer) {
+	statuses := g.wrapperStatuses()
+
+	b.writes("// ---------------- Status Codes\n\n")
+	d.writes("// ---------------- Status Codes\n\n")
+	for _, s := range statuses {
+		b.printf("export const %s = %d;\n", s.name, s.code)
+		d.printf("export const %s: number;\n", s.name)
+	}
+
+	b.printf("\n// WuffsError is an error status returned by the %s package. Its status\n", g.pkgName)
+	b.writes("// property holds its numeric code.\n")
+	b.writes("export class WuffsError extends Error {\n")
+	b.writes("  constructor(status) {\n")
+	b.writes("    super(statusString(status));\n")
+	b.writes("    this.name = new.target.name;\n")
+	b.writes("    this.status = status;\n")
+	b.writes("  }\n")
+	b.writes("}\n")
+	d.writes("\nexport class WuffsError extends Error {\n")
+	d.writes("  constructor(status: number);\n")
+	d.writes("  readonly status: number;\n")
+	d.writes("}\n")
+
+	errors := []string(nil)
+	for _, s := range statuses {
+		if s.keyword != t.IDError {
+			continue
+		}
+		className := errorClassName(s.name)
+		errors = append(errors, fmt.Sprintf("  [%s, %s],\n", s.name, className))
+		b.writes("\n")
+		writeComment(b, "", fmt.Sprintf("%s is the %q error.", className, s.msg))
+		b.printf("export class %s extends WuffsError {}\n", className)
+		d.printf("export class %s extends WuffsError {}\n", className)
+	}
+
+	b.writes("\nconst errors = new Map([\n")
+	for _, e := range errors {
+		b.writes(e)
+	}
+	b.writes("]);\n\n")
+
+	b.writes("function statusString(status) {\n")
+	b.printf("  const ptr = lib ? lib.%sstatus__string(status) : 0;\n", g.pkgPrefix)
+	b.writes("  return ptr ? cString(ptr) : `status ${status}`;\n")
+	b.writes("}\n\n")
+
+	b.writes("function check(status) {\n")
+	b.writes("  if (status < 0) {\n")
+	b.writes("    const E = errors.get(status) || WuffsError;\n")
+	b.writes("    throw new E(status);\n")
+	b.writes("  }\n")
+	b.writes("  return status;\n")
+	b.writes("}\n\n")
+	d.writes("\n")
+}
+
+// jsFunc returns how to wrap f, and false if it cannot be wrapped.
+func (g *gen) jsFunc(f *a.Func) (jsFunc, bool) {
+	jf := jsFunc{
+		f:        f,
+		cName:    g.funcCName(f),
+		tsResult: "void",
+	}
+	for _, o := range f.In().Fields() {
+		o := o.Field()
+		p, ok := jsParamType(o.XType())
+		if !ok {
+			return jsFunc{}, false
+		}
+		p.name = jsName(o.Name().Str(g.tm))
+		if jsKeywords[p.name] {
+			p.name += "_"
+		}
+		jf.params = append(jf.params, p)
+	}
+
+	if f.Suspendible() {
+		jf.result, jf.tsResult = "check(%s)", "number"
+	} else if outFields := f.Out().Fields(); len(outFields) == 1 {
+		n := outFields[0].Field().XType()
+		if n.Decorator() != 0 || n.QID()[0] != t.IDBase {
+			return jsFunc{}, false
+		}
+		switch n.QID()[1] {
+		case t.IDBool:
+			jf.result, jf.tsResult = "%s !== 0", "boolean"
+		case t.IDI8, t.IDI16, t.IDI32, t.IDU8, t.IDU16:
+			jf.result, jf.tsResult = "%s", "number"
+		case t.IDU32:
+			jf.result, jf.tsResult = "%s >>> 0", "number"
+		case t.IDI64:
+			jf.result, jf.tsResult = "%s", "bigint"
+		case t.IDU64:
+			jf.result, jf.tsResult = "BigInt.asUintN(64, %s)", "bigint"
+		default:
+			return jsFunc{}, false
+		}
+	} else if len(outFields) != 0 {
+		return jsFunc{}, false
+	}
+	return jf, true
+}
+
+func jsParamType(n *a.TypeExpr) (jsParam, bool) {
+	if n.IsSliceType() {
+		o := n.Inner()
+		if o.Decorator() == 0 && o.QID() == (t.QID{t.IDBase, t.IDU8}) && !o.IsRefined() {
+			return jsParam{conv: "%s", tsType: "Uint8Array", slice: true}, true
+		}
+		return jsParam{}, false
+	}
+	if n.Decorator() == t.IDPtr {
+		switch n.Inner().QID() {
+		case t.QID{t.IDBase, t.IDImageConfig}:
+			return jsParam{conv: "%s.ptr", tsType: "ImageConfig"}, true
+		case t.QID{t.IDBase, t.IDImageBuffer}:
+			return jsParam{conv: "%s.ptr", tsType: "ImageBuffer"}, true
+		}
+		return jsParam{}, false
+	}
+	if n.Decorator() != 0 {
+		return jsParam{}, false
+	}
+	qid := n.QID()
+	if qid[0] != t.IDBase {
+		return jsParam{}, false
+	}
+	switch qid[1] {
+	case t.IDBool:
+		return jsParam{conv: "%s ? 1 : 0", tsType: "boolean"}, true
+	case t.IDI8, t.IDI16, t.IDI32, t.IDU8, t.IDU16, t.IDU32:
+		return jsParam{conv: "%s", tsType: "number"}, true
+	case t.IDI64, t.IDU64:
+		return jsParam{conv: "BigInt(%s)", tsType: "bigint | number"}, true
+	case t.IDIOReader, t.IDIOWriter:
+		return jsParam{conv: "%s.handle()", tsType: "IOBuffer"}, true
+	}
+	return jsParam{}, false
+}
+
+// jsName converts a Wuffs name like "decode_config" to a JavaScript name like
+// "decodeConfig".
+func jsName(name string) string {
+	s := ""
+	for i, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		} else if i == 0 {
+			s += word
+		} else {
+			s += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return s
+}
+
+func (g *gen) writeJSClass(b *buffer, d *buffer, n *a.Struct, funcs []jsFunc) {
+	name := n.QID().Str(g.tm)
+	cName := g.pkgPrefix + name
+	className := wrapperClassName(name)
+
+	writeComment(b, "", fmt.Sprintf("%s wraps a %s. It must be freed, by calling free, "+
+		"when no longer needed.", className, cName))
+	b.printf("export class %s {\n", className)
+	b.writes("  constructor() {\n")
+	b.printf("    const n = library().sizeof__%s();\n", cName)
+	b.writes("    this.ptr = alloc(n);\n")
+	b.printf("    lib.%s__check_wuffs_version(this.ptr, n, WUFFS_VERSION);\n", cName)
+	b.writes("  }\n\n")
+	b.writes("  free() {\n")
+	b.writes("    if (this.ptr) {\n")
+	b.writes("      lib.wuffs_wasm32__free(this.ptr);\n")
+	b.writes("      this.ptr = 0;\n")
+	b.writes("    }\n")
+	b.writes("  }\n")
+	d.printf("\nexport class %s {\n", className)
+	d.writes("  constructor();\n")
+	d.writes("  free(): void;\n")
+
+	for _, jf := range funcs {
+		fName := jsName(jf.f.FuncName().Str(g.tm))
+		params, tsParams, args := []string(nil), []string(nil), []string{"this.ptr"}
+		for i, p := range jf.params {
+			params = append(params, p.name)
+			tsParams = append(tsParams, p.name+": "+p.tsType)
+			if p.slice {
+				args = append(args, fmt.Sprintf("s%d", i))
+			} else {
+				args = append(args, strings.Replace(p.conv, "%s", p.name, -1))
+			}
+		}
+		call := fmt.Sprintf("lib.%s(%s)", jf.cName, strings.Join(args, ", "))
+		// Wrap the call in a withSliceU8 closure per slice argument, innermost
+		// last.
+		for i := len(jf.params) - 1; i >= 0; i-- {
+			if p := jf.params[i]; p.slice {
+				call = fmt.Sprintf("withSliceU8(%s, (s%d) => %s)", p.name, i, call)
+			}
+		}
+		if jf.result != "" {
+			call = "return " + strings.Replace(jf.result, "%s", call, -1)
+		}
+
+		b.writes("\n")
+		if jf.f.Suspendible() {
+			writeComment(b, "  ", fmt.Sprintf("%s calls %s. It returns STATUS_OK or a "+
+				"suspension status, and throws a WuffsError for an error status.", fName, jf.cName))
+		} else {
+			writeComment(b, "  ", fmt.Sprintf("%s calls %s.", fName, jf.cName))
+		}
+		b.printf("  %s(%s) {\n", fName, strings.Join(params, ", "))
+		b.writes("    library();\n")
+		b.printf("    %s;\n", call)
+		b.writes("  }\n")
+		d.printf("  %s(%s): %s;\n", fName, strings.Join(tsParams, ", "), jf.tsResult)
+
+		if !jf.f.Suspendible() || len(jf.params) != 2 {
+			continue
+		}
+		dst, src := -1, -1
+		for i, o := range jf.f.In().Fields() {
+			switch o.Field().XType().QID()[1] {
+			case t.IDIOWriter:
+				dst = i
+			case t.IDIOReader:
+				src = i
+			}
+		}
+		if dst < 0 || src < 0 {
+			continue
+		}
+		bufs := []string{"", ""}
+		bufs[dst], bufs[src] = "w", "r"
+
+		b.writes("\n")
+		writeComment(b, "  ", fmt.Sprintf("%sAll calls %s until it completes, given all of the "+
+			"input, and returns all of the output. It throws a WuffsError for an error status.", fName, fName))
+		b.printf("  %sAll(src, bufferSize = 65536) {\n", fName)
+		b.printf("    return streamAll((w, r) => this.%s(%s), src, bufferSize);\n", fName, strings.Join(bufs, ", "))
+		b.writes("  }\n")
+		d.printf("  %sAll(src: Uint8Array, bufferSize?: number): Uint8Array;\n", fName)
+	}
+	b.writes("}\n\n")
+	d.writes("}\n")
+}
+
+// jsKeywords are JavaScript reserved words that are not obviously also Wuffs
+// keywords. Wuffs identifiers that clash with them get a trailing underscore.
+var jsKeywords = map[string]bool{
+	"await": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "enum": true, "export": true, "extends": true, "false": true,
+	"finally": true, "function": true, "import": true, "in": true,
+	"instanceof": true, "let": true, "new": true, "null": true, "super": true,
+	"switch": true, "this": true, "throw": true, "true": true, "try": true,
+	"typeof": true, "void": true, "with": true, "yield": true,
+}
+
+const jsLibrary = `// ---------------- Library
+
+let lib = null;
+
+// load instantiates the "wuffs genlib -target=wasm32" libwuffs.wasm module,
+// given as a WebAssembly.Instance, a WebAssembly.Module or the bytes of the
+// .wasm file, and returns the instance. It must be called, and its promise
+// resolved, before anything else in this module is used. Passing the same
+// instance to the modules of other packages lets them share linear memory.
+export async function load(source) {
+  let instance = source;
+  if (!(source instanceof WebAssembly.Instance)) {
+    const module = (source instanceof WebAssembly.Module) ?
+        source :
+        await WebAssembly.compile(source);
+    instance = await WebAssembly.instantiate(module, {});
+  }
+  lib = instance.exports;
+  return instance;
+}
+
+function library() {
+  if (!lib) {
+    throw new Error("wuffs_PKG: load has not been called");
+  }
+  return lib;
+}
+
+// The linear memory can grow, which detaches any previous ArrayBuffer, so
+// these views are re-created each time.
+function bytes(ptr, len) {
+  return new Uint8Array(lib.memory.buffer, ptr, len);
+}
+
+function view() {
+  return new DataView(lib.memory.buffer);
+}
+
+function alloc(n) {
+  const ptr = library().wuffs_wasm32__alloc(n);
+  if (!ptr) {
+    throw new RangeError("wuffs_PKG: out of memory");
+  }
+  return ptr;
+}
+
+function cString(ptr) {
+  const m = new Uint8Array(lib.memory.buffer);
+  let end = ptr;
+  while (m[end]) {
+    end++;
+  }
+  return new TextDecoder().decode(m.subarray(ptr, end));
+}
+
+`
+
+const jsIO = `// ---------------- I/O
+
+// An IOBuffer's C memory is a wuffs_base__io_buffer (ptr, len, wi, ri and
+// closed fields, at offsets 0, 4, 8, 12 and 16) followed, at offset 20, by a
+// wuffs_base__io_reader or wuffs_base__io_writer that points to it. The C
+// functions take those structs by value, which the wasm32 ABI passes as a
+// pointer. The "wuffs-wasm32.c" shim checks these offsets at compile time.
+const ioBufferStructSize = 32;
+const ioHandleOffset = 20;
+
+// IOBuffer is a wuffs_base__io_buffer and the memory that it points to. The C
+// code reads the bytes between the read and write indexes and writes the bytes
+// after the write index. It must be freed, by calling free, when no longer
+// needed.
+export class IOBuffer {
+  constructor(size = 65536) {
+    this.ptr = alloc(ioBufferStructSize);
+    this.size = size;
+    this.data = alloc(Math.max(size, 1));
+    const v = view();
+    v.setUint32(this.ptr + 0, this.data, true);
+    v.setUint32(this.ptr + 4, size, true);
+    v.setUint32(this.ptr + ioHandleOffset, this.ptr, true);
+  }
+
+  free() {
+    if (this.ptr) {
+      lib.wuffs_wasm32__free(this.data);
+      lib.wuffs_wasm32__free(this.ptr);
+      this.ptr = 0;
+      this.data = 0;
+    }
+  }
+
+  // closed is whether no further writes are expected.
+  get closed() {
+    return view().getUint8(this.ptr + 16) !== 0;
+  }
+
+  // close marks that no further writes are expected.
+  close() {
+    view().setUint8(this.ptr + 16, 1);
+  }
+
+  // readerLength returns the number of written but not yet read bytes.
+  readerLength() {
+    const v = view();
+    return v.getUint32(this.ptr + 8, true) - v.getUint32(this.ptr + 12, true);
+  }
+
+  // writerLength returns the number of bytes that can be written.
+  writerLength() {
+    return this.size - view().getUint32(this.ptr + 8, true);
+  }
+
+  // compact moves the not yet read bytes to the start of the buffer.
+  compact() {
+    const v = view();
+    const wi = v.getUint32(this.ptr + 8, true);
+    const ri = v.getUint32(this.ptr + 12, true);
+    if (ri > 0) {
+      bytes(this.data, this.size).copyWithin(0, ri, wi);
+      v.setUint32(this.ptr + 8, wi - ri, true);
+      v.setUint32(this.ptr + 12, 0, true);
+    }
+  }
+
+  // write compacts the buffer and then copies as much of the data Uint8Array
+  // as fits. It returns the number of bytes copied.
+  write(data) {
+    if (this.closed) {
+      throw new Error("write to a closed IOBuffer");
+    }
+    this.compact();
+    const n = Math.min(data.length, this.writerLength());
+    const wi = view().getUint32(this.ptr + 8, true);
+    bytes(this.data + wi, n).set(data.subarray(0, n));
+    view().setUint32(this.ptr + 8, wi + n, true);
+    return n;
+  }
+
+  // read returns, as a new Uint8Array, and marks as read, the not yet read
+  // bytes.
+  read() {
+    const v = view();
+    const wi = v.getUint32(this.ptr + 8, true);
+    const ri = v.getUint32(this.ptr + 12, true);
+    const out = bytes(this.data + ri, wi - ri).slice();
+    v.setUint32(this.ptr + 12, wi, true);
+    return out;
+  }
+
+  handle() {
+    return this.ptr + ioHandleOffset;
+  }
+}
+
+// withSliceU8 calls f with a pointer to a wuffs_base__slice_u8 that holds a
+// copy of the x Uint8Array, and copies any changes back into x afterwards.
+function withSliceU8(x, f) {
+  const n = x.length;
+  const s = alloc(8 + n);
+  try {
+    const v = view();
+    v.setUint32(s + 0, s + 8, true);
+    v.setUint32(s + 4, n, true);
+    bytes(s + 8, n).set(x);
+    const ret = f(s);
+    x.set(bytes(s + 8, n));
+    return ret;
+  } finally {
+    lib.wuffs_wasm32__free(s);
+  }
+}
+
+function streamAll(call, src, bufferSize) {
+  const r = new IOBuffer(src.length);
+  const w = new IOBuffer(bufferSize);
+  const chunks = [];
+  let length = 0;
+  try {
+    r.write(src);
+    r.close();
+    for (;;) {
+      const status = call(w, r);
+      const chunk = w.read();
+      chunks.push(chunk);
+      length += chunk.length;
+      w.compact();
+      if (status === SUSPENSION_SHORT_READ) {
+        throw new UnexpectedEofError(ERROR_UNEXPECTED_EOF);
+      } else if (status !== SUSPENSION_SHORT_WRITE) {
+        break;
+      }
+    }
+  } finally {
+    r.free();
+    w.free();
+  }
+  const out = new Uint8Array(length);
+  let i = 0;
+  for (const chunk of chunks) {
+    out.set(chunk, i);
+    i += chunk.length;
+  }
+  return out;
+}
+
+`
+
+const jsLibraryDeclarations = `// ---------------- Library
+
+export function load(
+    source: WebAssembly.Instance | WebAssembly.Module | BufferSource):
+    Promise<WebAssembly.Instance>;
+
+// ---------------- I/O
+
+export class IOBuffer {
+  constructor(size?: number);
+  free(): void;
+  readonly closed: boolean;
+  close(): void;
+  readerLength(): number;
+  writerLength(): number;
+  compact(): void;
+  write(data: Uint8Array): number;
+  read(): Uint8Array;
+}
+`
+
+const jsImages = `// ---------------- Images
+
+// ImageConfig is a wuffs_base__image_config. It must be freed, by calling
+// free, when no longer needed.
+export class ImageConfig {
+  constructor() {
+    this.ptr = alloc(library().wuffs_wasm32__sizeof_image_config());
+  }
+
+  free() {
+    if (this.ptr) {
+      lib.wuffs_wasm32__free(this.ptr);
+      this.ptr = 0;
+    }
+  }
+
+  get isValid() {
+    return lib.wuffs_wasm32__image_config__is_valid(this.ptr) !== 0;
+  }
+
+  get pixelFormat() {
+    return lib.wuffs_wasm32__image_config__pixel_format(this.ptr) >>> 0;
+  }
+
+  get width() {
+    return lib.wuffs_wasm32__image_config__width(this.ptr) >>> 0;
+  }
+
+  get height() {
+    return lib.wuffs_wasm32__image_config__height(this.ptr) >>> 0;
+  }
+
+  get numLoops() {
+    return lib.wuffs_wasm32__image_config__num_loops(this.ptr) >>> 0;
+  }
+
+  get pixbufSize() {
+    return lib.wuffs_wasm32__image_config__pixbuf_size(this.ptr) >>> 0;
+  }
+}
+
+// ImageBuffer is a wuffs_base__image_buffer and its pixel memory, sized for
+// the given ImageConfig. It must be freed, by calling free, when no longer
+// needed.
+export class ImageBuffer {
+  constructor(config) {
+    this.ptr = alloc(library().wuffs_wasm32__sizeof_image_buffer());
+    this.pixbufSize = config.pixbufSize;
+    this.pixbuf = alloc(Math.max(this.pixbufSize, 1));
+    lib.wuffs_wasm32__image_buffer__set_from_slice(
+        this.ptr, config.ptr, this.pixbuf, this.pixbufSize);
+  }
+
+  free() {
+    if (this.ptr) {
+      lib.wuffs_wasm32__free(this.pixbuf);
+      lib.wuffs_wasm32__free(this.ptr);
+      this.ptr = 0;
+      this.pixbuf = 0;
+    }
+  }
+
+  // pixels returns a copy of the pixel memory.
+  pixels() {
+    return bytes(this.pixbuf, this.pixbufSize).slice();
+  }
+
+  // palette returns a copy of the 1024 byte palette.
+  palette() {
+    return bytes(lib.wuffs_wasm32__image_buffer__palette(this.ptr), 1024)
+        .slice();
+  }
+
+  // loop returns whether the decoder should loop back to the beginning of the
+  // animation, incrementing the count of loops played so far if so.
+  loop() {
+    return lib.wuffs_wasm32__image_buffer__loop(this.ptr) !== 0;
+  }
+
+  // dirtyRect is the part of this frame that differs from the previous one.
+  get dirtyRect() {
+    const r = alloc(16);
+    try {
+      lib.wuffs_wasm32__image_buffer__dirty_rect(this.ptr, r);
+      const v = view();
+      return {
+        minInclusiveX: v.getUint32(r + 0, true),
+        minInclusiveY: v.getUint32(r + 4, true),
+        maxExclusiveX: v.getUint32(r + 8, true),
+        maxExclusiveY: v.getUint32(r + 12, true),
+      };
+    } finally {
+      lib.wuffs_wasm32__free(r);
+    }
+  }
+
+  // duration is how long, in flicks, to display this frame.
+  get duration() {
+    return lib.wuffs_wasm32__image_buffer__duration(this.ptr);
+  }
+
+  get blend() {
+    return lib.wuffs_wasm32__image_buffer__blend(this.ptr) !== 0;
+  }
+
+  get disposal() {
+    return lib.wuffs_wasm32__image_buffer__disposal(this.ptr);
+  }
+
+  get paletteChanged() {
+    return lib.wuffs_wasm32__image_buffer__palette_changed(this.ptr) !== 0;
+  }
+}
+
+`
+
+const jsImagesDeclarations = `
+// ---------------- Images
+
+export class ImageConfig {
+  constructor();
+  free(): void;
+  readonly isValid: boolean;
+  readonly pixelFormat: number;
+  readonly width: number;
+  readonly height: number;
+  readonly numLoops: number;
+  readonly pixbufSize: number;
+}
+
+export class ImageBuffer {
+  constructor(config: ImageConfig);
+  free(): void;
+  pixels(): Uint8Array;
+  palette(): Uint8Array;
+  loop(): boolean;
+  readonly dirtyRect: {
+    minInclusiveX: number,
+    minInclusiveY: number,
+    maxExclusiveX: number,
+    maxExclusiveY: number,
+  };
+  readonly duration: bigint;
+  readonly blend: boolean;
+  readonly disposal: number;
+  readonly paletteChanged: boolean;
+}
+`
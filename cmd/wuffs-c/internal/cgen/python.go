@@ -0,0 +1,556 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/google/wuffs/lang/builtin"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// The Python module wraps, via the standard library's ctypes module, the C API
+// of a "wuffs genlib" shared library. It is generated from the same public
+// API information (statuses, structs and funcs) as the C header, so that the
+// two cannot drift apart.
+//
+// Public suspendible structs become Python classes, whose methods call the
+// corresponding C functions. Error statuses are raised as exceptions, one
+// Python class per status, and I/O arguments are IOBuffer objects. Methods
+// that take exactly one io_writer and one io_reader also get a "_stream"
+// variant that loops over suspensions, reading from and writing to Python
+// file objects. Funcs with argument or return types that have no ctypes
+// equivalent (yet) are not wrapped.
+
+var wuffsVersionRegexp = regexp.MustCompile(`#define WUFFS_VERSION \(\(uint32_t\)(0x[0-9A-Fa-f]+)\)`)
+
+// pythonParam is a wrapped C function's parameter.
+type pythonParam struct {
+	name string
+	// ctype is the ctypes type, e.g. "ctypes.c_uint32".
+	ctype string
+	// conv converts the Python argument to the ctypes argument, with "%s"
+	// standing for the Python argument.
+	conv string
+}
+
+// pythonFunc is a wrapped C function.
+type pythonFunc struct {
+	f       *a.Func
+	cName   string
+	params  []pythonParam
+	restype string
+}
+
+// writePythonModule writes a ctypes Python module for the package to the
+// named file.
+func (g *gen) writePythonModule(filename string) error {
+	m := wuffsVersionRegexp.FindStringSubmatch(baseHeader)
+	if m == nil {
+		return fmt.Errorf("could not find WUFFS_VERSION in the base header")
+	}
+
+	funcs := map[*a.Struct][]pythonFunc{}
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		err := g.forEachFunc(nil, pubOnly, func(g *gen, _ *buffer, f *a.Func) error {
+			if f.Receiver() != n.QID() {
+				return nil
+			}
+			if pf, ok := g.pythonFunc(f); ok {
+				funcs[n] = append(funcs[n], pf)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	b := buffer(nil)
+	b.writes("# Code generated by wuffs-c. DO NOT EDIT.\n\n")
+	b.printf("\"\"\"Module wuffs_%s wraps, via ctypes, the C API of the Wuffs %s package,\n", g.pkgName, g.pkgName)
+	b.writes("as built into a shared library by \"wuffs genlib\".\n\"\"\"\n\n")
+	b.writes("import ctypes\nimport os\n\n")
+	b.printf("WUFFS_VERSION = %s\n\n", m[1])
+
+	g.writePythonStatuses(&b)
+
+	b.writes(pythonLibrary)
+	b.writes("\n\ndef _declare(lib):\n")
+	b.printf("    lib.%sstatus__string.argtypes = [ctypes.c_int32]\n", g.pkgPrefix)
+	b.printf("    lib.%sstatus__string.restype = ctypes.c_char_p\n", g.pkgPrefix)
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		cName := g.pkgPrefix + n.QID().Str(g.tm)
+		b.printf("    lib.sizeof__%s.argtypes = []\n", cName)
+		b.printf("    lib.sizeof__%s.restype = ctypes.c_size_t\n", cName)
+		b.printf("    lib.%s__check_wuffs_version.argtypes = [\n", cName)
+		b.writes("        ctypes.c_void_p, ctypes.c_size_t, ctypes.c_uint32]\n")
+		b.printf("    lib.%s__check_wuffs_version.restype = None\n", cName)
+		for _, pf := range funcs[n] {
+			argtypes := []string{"ctypes.c_void_p"}
+			for _, p := range pf.params {
+				argtypes = append(argtypes, p.ctype)
+			}
+			b.printf("    lib.%s.argtypes = [\n        %s]\n", pf.cName, strings.Join(argtypes, ", "))
+			b.printf("    lib.%s.restype = %s\n", pf.cName, pf.restype)
+		}
+	}
+	b.writes("\n\n")
+
+	b.writes(pythonIO)
+
+	for _, n := range g.structList {
+		if !n.Public() || !n.Suspendible() {
+			continue
+		}
+		g.writePythonClass(&b, n, funcs[n])
+	}
+
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// wrapperStatus is a status code, as seen by another programming language's
+// wrapper of the C API.
+type wrapperStatus struct {
+	name    string // e.g. "ERROR_BAD_CHECKSUM".
+	msg     string // e.g. "gzip: bad checksum".
+	keyword t.ID
+	code    int32
+}
+
+// wrapperStatuses returns the built-in and then the package's status codes.
+func (g *gen) wrapperStatuses() []wrapperStatus {
+	statuses := []wrapperStatus(nil)
+	for i, z := range builtin.StatusList {
+		code := uint32(i)
+		if z.Keyword == t.IDError {
+			code |= 1 << 31
+		}
+		statuses = append(statuses, wrapperStatus{
+			name:    strings.TrimPrefix(strings.ToUpper(g.cName(z.String())), g.PKGPREFIX),
+			msg:     z.Message,
+			keyword: z.Keyword,
+			code:    int32(code),
+		})
+	}
+	for i, s := range g.statusList {
+		code := g.checker.PackageID()<<statusCodeNamespaceShift | uint32(i)
+		if s.keyword == t.IDError {
+			code |= 1 << 31
+		}
+		statuses = append(statuses, wrapperStatus{
+			name:    strings.TrimPrefix(s.name, g.PKGPREFIX),
+			msg:     g.pkgName + ": " + s.msg,
+			keyword: s.keyword,
+			code:    int32(code),
+		})
+	}
+	return statuses
+}
+
+func (g *gen) writePythonStatuses(b *buffer) {
+	statuses := g.wrapperStatuses()
+
+	b.writes("# ---------------- Status Codes\n\n")
+	for _, s := range statuses {
+		b.printf("%s = %d\n", s.name, s.code)
+	}
+
+	b.writes("\n\nclass Error(Exception):\n")
+	b.printf("    \"\"\"Error is an error status returned by the %s package. The status\n", g.pkgName)
+	b.writes("    attribute holds its numeric code.\n    \"\"\"\n\n")
+	b.writes("    def __init__(self, status):\n")
+	b.writes("        Exception.__init__(self, _status_string(status))\n")
+	b.writes("        self.status = status\n")
+
+	errors := []string(nil)
+	for _, s := range statuses {
+		if s.keyword != t.IDError {
+			continue
+		}
+		className := errorClassName(s.name)
+		errors = append(errors, fmt.Sprintf("    %s: %s,\n", s.name, className))
+		b.printf("\n\nclass %s(Error):\n", className)
+		if doc := fmt.Sprintf("%s is the %q error.", className, s.msg); len(doc) <= 69 {
+			b.printf("    \"\"\"%s\"\"\"\n", doc)
+		} else {
+			b.printf("    \"\"\"%s is the\n    %q error.\n    \"\"\"\n", className, s.msg)
+		}
+	}
+
+	b.writes("\n\n_errors = {\n")
+	for _, e := range errors {
+		b.writes(e)
+	}
+	b.writes("}\n\n\n")
+
+	b.writes("def _status_string(status):\n")
+	b.printf("    s = _library().%sstatus__string(status)\n", g.pkgPrefix)
+	b.writes("    return s.decode(\"utf-8\") if s else \"status %d\" % status\n\n\n")
+
+	b.writes("def _check(status):\n")
+	b.writes("    if status < 0:\n")
+	b.writes("        raise _errors.get(status, Error)(status)\n")
+	b.writes("    return status\n\n\n")
+}
+
+// errorClassName converts a name like "ERROR_BAD_CHECKSUM" to a name
+// like "BadChecksumError".
+func errorClassName(name string) string {
+	s := ""
+	for _, word := range strings.Split(strings.TrimPrefix(name, "ERROR_"), "_") {
+		if word != "" {
+			s += word[:1] + strings.ToLower(word[1:])
+		}
+	}
+	return s + "Error"
+}
+
+// wrapperClassName converts a struct name like "ieee_hasher" to a class name
+// like "IeeeHasher".
+func wrapperClassName(name string) string {
+	s := ""
+	for _, word := range strings.Split(name, "_") {
+		if word != "" {
+			s += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return s
+}
+
+// pythonFunc returns how to wrap f, and false if it cannot be wrapped.
+func (g *gen) pythonFunc(f *a.Func) (pythonFunc, bool) {
+	pf := pythonFunc{
+		f:       f,
+		cName:   g.funcCName(f),
+		restype: "None",
+	}
+	for _, o := range f.In().Fields() {
+		o := o.Field()
+		p, ok := pythonParamType(o.XType())
+		if !ok {
+			return pythonFunc{}, false
+		}
+		p.name = o.Name().Str(g.tm)
+		if pythonKeywords[p.name] {
+			p.name += "_"
+		}
+		pf.params = append(pf.params, p)
+	}
+
+	if f.Suspendible() {
+		pf.restype = "ctypes.c_int32"
+	} else if outFields := f.Out().Fields(); len(outFields) == 1 {
+		p, ok := pythonParamType(outFields[0].Field().XType())
+		if !ok || p.conv != "%s" {
+			return pythonFunc{}, false
+		}
+		pf.restype = p.ctype
+	} else if len(outFields) != 0 {
+		return pythonFunc{}, false
+	}
+	return pf, true
+}
+
+func pythonParamType(n *a.TypeExpr) (pythonParam, bool) {
+	if n.IsSliceType() {
+		o := n.Inner()
+		if o.Decorator() == 0 && o.QID() == (t.QID{t.IDBase, t.IDU8}) && !o.IsRefined() {
+			return pythonParam{ctype: "_SliceU8", conv: "_slice_u8(%s)"}, true
+		}
+		return pythonParam{}, false
+	}
+	if n.Decorator() != 0 {
+		return pythonParam{}, false
+	}
+	qid := n.QID()
+	if qid[0] != t.IDBase {
+		return pythonParam{}, false
+	}
+	switch qid[1] {
+	case t.IDBool:
+		return pythonParam{ctype: "ctypes.c_bool", conv: "%s"}, true
+	case t.IDI8:
+		return pythonParam{ctype: "ctypes.c_int8", conv: "%s"}, true
+	case t.IDI16:
+		return pythonParam{ctype: "ctypes.c_int16", conv: "%s"}, true
+	case t.IDI32:
+		return pythonParam{ctype: "ctypes.c_int32", conv: "%s"}, true
+	case t.IDI64:
+		return pythonParam{ctype: "ctypes.c_int64", conv: "%s"}, true
+	case t.IDU8:
+		return pythonParam{ctype: "ctypes.c_uint8", conv: "%s"}, true
+	case t.IDU16:
+		return pythonParam{ctype: "ctypes.c_uint16", conv: "%s"}, true
+	case t.IDU32:
+		return pythonParam{ctype: "ctypes.c_uint32", conv: "%s"}, true
+	case t.IDU64:
+		return pythonParam{ctype: "ctypes.c_uint64", conv: "%s"}, true
+	case t.IDIOReader, t.IDIOWriter:
+		return pythonParam{ctype: "_IOHandle", conv: "%s._handle()"}, true
+	}
+	return pythonParam{}, false
+}
+
+func (g *gen) writePythonClass(b *buffer, n *a.Struct, funcs []pythonFunc) {
+	name := n.QID().Str(g.tm)
+	cName := g.pkgPrefix + name
+	className := wrapperClassName(name)
+
+	b.printf("\n\nclass %s(object):\n", className)
+	b.printf("    \"\"\"%s wraps a %s.\"\"\"\n\n", className, cName)
+	b.writes("    def __init__(self):\n")
+	b.writes("        lib = _library()\n")
+	b.printf("        n = lib.sizeof__%s()\n", cName)
+	b.writes("        self._c = ctypes.create_string_buffer(n)\n")
+	b.printf("        lib.%s__check_wuffs_version(\n", cName)
+	b.writes("            self._c, n, WUFFS_VERSION)\n")
+
+	for _, pf := range funcs {
+		fName := pf.f.FuncName().Str(g.tm)
+		params, args := []string{"self"}, []string{"self._c"}
+		for _, p := range pf.params {
+			params = append(params, p.name)
+			args = append(args, strings.Replace(p.conv, "%s", p.name, -1))
+		}
+		call := fmt.Sprintf("_lib.%s(%s)", pf.cName, strings.Join(args, ", "))
+		if len(call) > 60 {
+			call = fmt.Sprintf("_lib.%s(\n            %s)", pf.cName, strings.Join(args, ", "))
+		}
+
+		b.printf("\n    def %s(%s):\n", fName, strings.Join(params, ", "))
+		if pf.f.Suspendible() {
+			b.printf("        \"\"\"%s calls %s. It returns STATUS_OK or a\n", fName, pf.cName)
+			b.writes("        suspension status, and raises an Error for an error status.\n")
+			b.writes("        \"\"\"\n")
+			b.printf("        return _check(%s)\n", call)
+		} else {
+			b.printf("        \"\"\"%s calls %s.\"\"\"\n", fName, pf.cName)
+			if pf.restype == "None" {
+				b.printf("        %s\n", call)
+			} else {
+				b.printf("        return %s\n", call)
+			}
+		}
+
+		if !pf.f.Suspendible() || len(pf.params) != 2 {
+			continue
+		}
+		dst, src := -1, -1
+		for i, o := range pf.f.In().Fields() {
+			switch o.Field().XType().QID()[1] {
+			case t.IDIOWriter:
+				dst = i
+			case t.IDIOReader:
+				src = i
+			}
+		}
+		if dst < 0 || src < 0 {
+			continue
+		}
+		bufs := []string{"", ""}
+		bufs[dst], bufs[src] = "w", "r"
+
+		b.printf("\n    def %s_stream(self, dst, src, buffer_size=65536):\n", fName)
+		b.printf("        \"\"\"%s_stream calls %s until it completes, writing to the dst\n", fName, fName)
+		b.writes("        file object and reading from the src file object as needed. It\n")
+		b.writes("        returns STATUS_OK or any other suspension status, and raises an Error\n")
+		b.writes("        for an error status.\n")
+		b.writes("        \"\"\"\n")
+		b.printf("        return _stream(lambda w, r: self.%s(%s),\n", fName, strings.Join(bufs, ", "))
+		b.writes("                       dst, src, buffer_size)\n")
+	}
+}
+
+// pythonKeywords are Python keywords that are not obviously also Wuffs
+// keywords. Wuffs identifiers that clash with them get a trailing underscore.
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "as": true, "async": true,
+	"await": true, "class": true, "continue": true, "def": true, "del": true,
+	"elif": true, "except": true, "finally": true, "from": true,
+	"global": true, "import": true, "in": true, "is": true, "lambda": true,
+	"nonlocal": true, "not": true, "pass": true, "raise": true, "try": true,
+	"with": true, "yield": true,
+}
+
+const pythonLibrary = `# ---------------- Library
+
+_lib = None
+
+
+def load_library(filename=None):
+    """load_library loads the "wuffs genlib" shared library. If filename is
+    None, it is the WUFFS_LIBRARY environment variable or, if that is unset,
+    the libwuffs.so in a "foo-dynamic" sibling of this module's directory,
+    where foo is a C compiler. It is called implicitly, with no filename, if
+    needed.
+    """
+    global _lib
+    if filename is None:
+        filename = os.environ.get("WUFFS_LIBRARY")
+    if filename is None:
+        d = os.path.join(os.path.dirname(os.path.abspath(__file__)), os.pardir)
+        for name in sorted(os.listdir(d)):
+            f = os.path.join(d, name, "libwuffs.so")
+            if name.endswith("-dynamic") and os.path.exists(f):
+                filename = f
+                break
+        else:
+            raise OSError("could not find libwuffs.so; set WUFFS_LIBRARY")
+    lib = ctypes.CDLL(filename)
+    _declare(lib)
+    _lib = lib
+    return lib
+
+
+def _library():
+    if _lib is None:
+        load_library()
+    return _lib
+`
+
+const pythonIO = `# ---------------- I/O
+
+
+class _IOBufferStruct(ctypes.Structure):
+    _fields_ = [
+        ("ptr", ctypes.POINTER(ctypes.c_uint8)),
+        ("len", ctypes.c_size_t),
+        ("wi", ctypes.c_size_t),
+        ("ri", ctypes.c_size_t),
+        ("closed", ctypes.c_bool),
+    ]
+
+
+class _IOHandle(ctypes.Structure):
+    # wuffs_base__io_reader and wuffs_base__io_writer share this layout.
+    _fields_ = [
+        ("buf", ctypes.POINTER(_IOBufferStruct)),
+        ("bounds", ctypes.c_void_p * 2),
+    ]
+
+
+class _SliceU8(ctypes.Structure):
+    _fields_ = [
+        ("ptr", ctypes.POINTER(ctypes.c_uint8)),
+        ("len", ctypes.c_size_t),
+    ]
+
+
+def _slice_u8(x):
+    # A bytearray is passed without copying, so that the C code can write to
+    # it. Other bytes-like objects are copied.
+    if isinstance(x, bytearray):
+        a = (ctypes.c_uint8 * len(x)).from_buffer(x)
+    else:
+        a = (ctypes.c_uint8 * len(x)).from_buffer_copy(x)
+    s = _SliceU8(ctypes.cast(a, ctypes.POINTER(ctypes.c_uint8)), len(x))
+    s._keepalive = a
+    return s
+
+
+class IOBuffer(object):
+    """IOBuffer is a wuffs_base__io_buffer and the memory that it points to.
+    The C code reads the bytes between the read and write indexes and writes
+    the bytes after the write index.
+    """
+
+    def __init__(self, size=65536):
+        self._data = (ctypes.c_uint8 * size)()
+        self._c = _IOBufferStruct(
+            ctypes.cast(self._data, ctypes.POINTER(ctypes.c_uint8)), size)
+
+    @property
+    def closed(self):
+        """closed is whether no further writes are expected."""
+        return self._c.closed
+
+    def close(self):
+        """close marks that no further writes are expected."""
+        self._c.closed = True
+
+    def reader_length(self):
+        """reader_length is the number of written but not yet read bytes."""
+        return self._c.wi - self._c.ri
+
+    def writer_length(self):
+        """writer_length is the number of bytes that can be written."""
+        return self._c.len - self._c.wi
+
+    def compact(self):
+        """compact moves the not yet read bytes to the start of the buffer."""
+        c = self._c
+        if c.ri > 0:
+            n = c.wi - c.ri
+            addr = ctypes.addressof(self._data)
+            ctypes.memmove(addr, addr + c.ri, n)
+            c.wi = n
+            c.ri = 0
+
+    def write(self, data):
+        """write compacts the buffer and then copies as much of data as fits.
+        It returns the number of bytes copied.
+        """
+        if self._c.closed:
+            raise ValueError("write to a closed IOBuffer")
+        self.compact()
+        data = bytes(data[:self.writer_length()])
+        ctypes.memmove(ctypes.addressof(self._data) + self._c.wi, data,
+                       len(data))
+        self._c.wi += len(data)
+        return len(data)
+
+    def read(self):
+        """read returns, and marks as read, the not yet read bytes."""
+        c = self._c
+        data = ctypes.string_at(ctypes.addressof(self._data) + c.ri,
+                                c.wi - c.ri)
+        c.ri = c.wi
+        return data
+
+    def _handle(self):
+        return _IOHandle(ctypes.pointer(self._c))
+
+
+def _stream(call, dst, src, buffer_size):
+    w = IOBuffer(buffer_size)
+    r = IOBuffer(buffer_size)
+    while True:
+        status = call(w, r)
+        dst.write(w.read())
+        w.compact()
+        if status == SUSPENSION_SHORT_READ:
+            if r.closed:
+                raise UnexpectedEofError(ERROR_UNEXPECTED_EOF)
+            r.compact()
+            data = src.read(r.writer_length())
+            if data:
+                r.write(data)
+            else:
+                r.close()
+        elif status != SUSPENSION_SHORT_WRITE:
+            return status
+`
@@ -0,0 +1,197 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Line markers are comments, not #line directives, when generating the code.
+// The C formatter can move lines around, so the #line directives' line
+// numbers (for returning to the C file) and the source map's C line numbers
+// can only be calculated after formatting.
+//
+// A marker is "// wuffs-line-begin 0:123", meaning line 123 of the first
+// lineSources file. Unlike a file name, the "0" index keeps the comment short
+// enough for the C formatter to not re-flow it. Begin and end markers nest,
+// as Wuffs statements nest.
+const (
+	lineBeginMarker = "// wuffs-line-begin"
+	lineEndMarker   = "// wuffs-line-end"
+)
+
+// writeLineMarker writes a marker on a line of its own. Some statements' C
+// code does not end with a "\n".
+func writeLineMarker(b *buffer, marker string) {
+	if n := len(*b); n > 0 && (*b)[n-1] != '\n' {
+		b.writeb('\n')
+	}
+	b.writes(marker)
+	b.writeb('\n')
+}
+
+func (g *gen) lineSourceIndex(filename string) int {
+	if i, ok := g.lineSourceIndexes[filename]; ok {
+		return i
+	}
+	if g.lineSourceIndexes == nil {
+		g.lineSourceIndexes = map[string]int{}
+	}
+	i := len(g.lineSources)
+	g.lineSources = append(g.lineSources, filename)
+	g.lineSourceIndexes[filename] = i
+	return i
+}
+
+// sourceMap maps ranges of lines in the generated C file to lines in the
+// Wuffs source files.
+type sourceMap struct {
+	Version  int             `json:"version"`
+	File     string          `json:"file"`
+	Sources  []string        `json:"sources"`
+	Mappings []sourceMapping `json:"mappings"`
+}
+
+// sourceMapping says that the C lines in the inclusive range [CFirstLine ..
+// CLastLine] implement line Line of Sources[Source]. Line numbers are 1-based.
+type sourceMapping struct {
+	CFirstLine int `json:"c_first_line"`
+	CLastLine  int `json:"c_last_line"`
+	Source     int `json:"source"`
+	Line       int `json:"line"`
+}
+
+func (m *sourceMap) writeFile(filename string) error {
+	j, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(j, '\n'), 0644)
+}
+
+type sourceLocation struct {
+	source int // An index into lineSources, or -1 for the C file.
+	line   int
+}
+
+// resolveLineMarkers removes the line markers from the formatted C code,
+// returning that code and its source map. If directives is true, it also
+// inserts #line directives, so that each C line's presumed location (e.g. for
+// the C compiler's warnings, a debugger or a coverage tool) is that of the
+// innermost enclosing Wuffs statement, or of the C file itself outside of any
+// statement. cFilename is the name used for the latter.
+func (g *gen) resolveLineMarkers(formatted []byte, cFilename string, directives bool) ([]byte, *sourceMap) {
+	m := &sourceMap{
+		Version: 1,
+		File:    cFilename,
+		Sources: g.lineSources,
+	}
+	if m.Sources == nil {
+		m.Sources = []string{}
+	}
+	m.Mappings = []sourceMapping{}
+
+	out := &bytes.Buffer{}
+	outLine := 0
+	stack := []sourceLocation(nil)
+	// presumed is what the C compiler will consider the next line's location
+	// to be, given the #line directives written so far.
+	presumed := sourceLocation{-1, 1}
+	continuation := false
+
+	for _, line := range strings.SplitAfter(string(formatted), "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, lineBeginMarker) {
+			stack = append(stack, parseLineMarker(trimmed[len(lineBeginMarker):]))
+			continue
+		} else if trimmed == lineEndMarker {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		want := sourceLocation{-1, 0}
+		if len(stack) > 0 {
+			want = stack[len(stack)-1]
+		}
+
+		// A #line directive cannot split a backslash-continued line.
+		if directives && !continuation {
+			if want.source < 0 {
+				// Return to the C file. Writing the directive will take up
+				// one line, so this line will be outLine + 2.
+				want.line = outLine + 2
+				if presumed.source < 0 && presumed.line == outLine+1 {
+					want.line = outLine + 1
+				}
+			}
+			if presumed != want {
+				filename := cFilename
+				if want.source >= 0 {
+					filename = g.lineSources[want.source]
+				}
+				fmt.Fprintf(out, "#line %d %s\n", want.line, strconv.Quote(filename))
+				outLine++
+				presumed = want
+			}
+		}
+		presumed.line++
+		continuation = strings.HasSuffix(strings.TrimRight(line, "\n"), "\\")
+
+		out.WriteString(line)
+		outLine++
+
+		if want.source < 0 {
+			continue
+		}
+		if n := len(m.Mappings); n > 0 {
+			if x := &m.Mappings[n-1]; x.CLastLine == outLine-1 && x.Source == want.source && x.Line == want.line {
+				x.CLastLine = outLine
+				continue
+			}
+		}
+		m.Mappings = append(m.Mappings, sourceMapping{
+			CFirstLine: outLine,
+			CLastLine:  outLine,
+			Source:     want.source,
+			Line:       want.line,
+		})
+	}
+	return out.Bytes(), m
+}
+
+// parseLineMarker parses the " 0:123" that follows lineBeginMarker.
+func parseLineMarker(s string) sourceLocation {
+	s = strings.TrimSpace(s)
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return sourceLocation{-1, 0}
+	}
+	source, err0 := strconv.Atoi(s[:i])
+	line, err1 := strconv.Atoi(s[i+1:])
+	if err0 != nil || err1 != nil {
+		return sourceLocation{-1, 0}
+	}
+	return sourceLocation{source, line}
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLineMarker(tt *testing.T) {
+	testCases := []struct {
+		s    string
+		want sourceLocation
+	}{
+		{" 0:123", sourceLocation{0, 123}},
+		{"1:2", sourceLocation{1, 2}},
+		{"  2:34  ", sourceLocation{2, 34}},
+		{"", sourceLocation{-1, 0}},
+		{" 0", sourceLocation{-1, 0}},
+		{" x:1", sourceLocation{-1, 0}},
+		{" 0:y", sourceLocation{-1, 0}},
+		{" 0:", sourceLocation{-1, 0}},
+	}
+
+	for i, tc := range testCases {
+		if got := parseLineMarker(tc.s); got != tc.want {
+			tt.Errorf("#%d: %q: got %v, want %v", i, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestResolveLineMarkers(tt *testing.T) {
+	testCases := []struct {
+		src          string
+		directives   bool
+		want         string
+		wantMappings []sourceMapping
+	}{{
+		// No markers.
+		"int a;\nint b;\n",
+		true,
+		"int a;\nint b;\n",
+		[]sourceMapping{},
+	}, {
+		// Nested markers, with #line directives. Returning to the C file
+		// names the line after the directive.
+		"" +
+			"int f() {\n" +
+			"  // wuffs-line-begin 0:10\n" +
+			"  x = 1;\n" +
+			"  // wuffs-line-begin 1:20\n" +
+			"  y = 2;\n" +
+			"  // wuffs-line-end\n" +
+			"  z = 3;\n" +
+			"  // wuffs-line-end\n" +
+			"}\n",
+		true,
+		"" +
+			"int f() {\n" +
+			"#line 10 \"a.wuffs\"\n" +
+			"  x = 1;\n" +
+			"#line 20 \"b.wuffs\"\n" +
+			"  y = 2;\n" +
+			"#line 10 \"a.wuffs\"\n" +
+			"  z = 3;\n" +
+			"#line 9 \"x.c\"\n" +
+			"}\n",
+		[]sourceMapping{
+			{CFirstLine: 3, CLastLine: 3, Source: 0, Line: 10},
+			{CFirstLine: 5, CLastLine: 5, Source: 1, Line: 20},
+			{CFirstLine: 7, CLastLine: 7, Source: 0, Line: 10},
+		},
+	}, {
+		// Nested markers, without #line directives.
+		"" +
+			"int f() {\n" +
+			"  // wuffs-line-begin 0:10\n" +
+			"  x = 1;\n" +
+			"  // wuffs-line-begin 1:20\n" +
+			"  y = 2;\n" +
+			"  // wuffs-line-end\n" +
+			"  z = 3;\n" +
+			"  // wuffs-line-end\n" +
+			"}\n",
+		false,
+		"int f() {\n  x = 1;\n  y = 2;\n  z = 3;\n}\n",
+		[]sourceMapping{
+			{CFirstLine: 2, CLastLine: 2, Source: 0, Line: 10},
+			{CFirstLine: 3, CLastLine: 3, Source: 1, Line: 20},
+			{CFirstLine: 4, CLastLine: 4, Source: 0, Line: 10},
+		},
+	}, {
+		// Consecutive lines of one statement share a mapping.
+		"" +
+			"// wuffs-line-begin 0:3\n" +
+			"a;\n" +
+			"b;\n" +
+			"// wuffs-line-end\n",
+		false,
+		"a;\nb;\n",
+		[]sourceMapping{
+			{CFirstLine: 1, CLastLine: 2, Source: 0, Line: 3},
+		},
+	}, {
+		// With #line directives, each of a statement's lines is presumed to
+		// be the statement's line, so each needs its own directive.
+		"" +
+			"// wuffs-line-begin 0:3\n" +
+			"a;\n" +
+			"b;\n" +
+			"// wuffs-line-end\n",
+		true,
+		"#line 3 \"a.wuffs\"\na;\n#line 3 \"a.wuffs\"\nb;\n",
+		[]sourceMapping{
+			{CFirstLine: 2, CLastLine: 2, Source: 0, Line: 3},
+			{CFirstLine: 4, CLastLine: 4, Source: 0, Line: 3},
+		},
+	}, {
+		// A #line directive cannot split a backslash-continued line, even
+		// where the location changes.
+		"" +
+			"// wuffs-line-begin 0:5\n" +
+			"a = b + \\\n" +
+			"// wuffs-line-end\n" +
+			"c;\n" +
+			"int d;\n",
+		true,
+		"" +
+			"#line 5 \"a.wuffs\"\n" +
+			"a = b + \\\n" +
+			"c;\n" +
+			"#line 5 \"x.c\"\n" +
+			"int d;\n",
+		[]sourceMapping{
+			{CFirstLine: 2, CLastLine: 2, Source: 0, Line: 5},
+		},
+	}, {
+		// An unmatched end marker is ignored.
+		"// wuffs-line-end\nint a;\n",
+		true,
+		"int a;\n",
+		[]sourceMapping{},
+	}, {
+		// A begin marker at the end of the file, without a final "\n".
+		"int a;\n// wuffs-line-begin 0:7",
+		true,
+		"int a;\n",
+		[]sourceMapping{},
+	}, {
+		// A marked line at the end of the file, without a final "\n".
+		"// wuffs-line-begin 1:7\nx;",
+		true,
+		"#line 7 \"b.wuffs\"\nx;",
+		[]sourceMapping{
+			{CFirstLine: 2, CLastLine: 2, Source: 1, Line: 7},
+		},
+	}}
+
+	for i, tc := range testCases {
+		g := &gen{lineSources: []string{"a.wuffs", "b.wuffs"}}
+		got, m := g.resolveLineMarkers([]byte(tc.src), "x.c", tc.directives)
+		if string(got) != tc.want {
+			tt.Errorf("#%d:\ngot:\n%s\nwant:\n%s", i, got, tc.want)
+		}
+		if !reflect.DeepEqual(m.Mappings, tc.wantMappings) {
+			tt.Errorf("#%d: mappings: got %+v, want %+v", i, m.Mappings, tc.wantMappings)
+		}
+		if m.File != "x.c" || !reflect.DeepEqual(m.Sources, g.lineSources) {
+			tt.Errorf("#%d: file and sources: got %q %q", i, m.File, m.Sources)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// This file generates the save_state and restore_state functions, which
+// serialize a struct's private_impl, including the c_ prefixed coroutine state
+// of any suspended method (see writeResumeSuspend in var.go), to and from a
+// byte slice. The encoding is a WUFFS_BASE__SAVED_STATE__HEADER_LENGTH byte
+// header and then each value in the order that writeStruct declares it:
+//  - bools and 8 bit integers are 1 byte.
+//  - other integers and status codes are 2, 4 or 8 bytes, little-endian.
+//  - arrays are their elements, in order.
+//  - sub-structs are their own saved state, header and all.
+
+// maxStateArrayDepth is an arbitrary implementation restriction.
+const maxStateArrayDepth = 4
+
+func (g *gen) stateLengthMacro(n *a.Struct) string {
+	return g.PKGPREFIX + strings.ToUpper(n.QID().Str(g.tm)) + "__STATE_LENGTH"
+}
+
+// stateLength returns the length of typ's saved state, as a number of bytes
+// plus the sum of zero or more C macros, for sub-structs.
+func (g *gen) stateLength(typ *a.TypeExpr) (uint64, []string, error) {
+	if typ.IsArrayType() {
+		n, macros, err := g.stateLength(typ.Inner())
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(macros) != 0 {
+			return 0, nil, fmt.Errorf("TODO: save the state of arrays of structs")
+		}
+		return n * typ.ArrayLength().ConstValue().Uint64(), nil, nil
+	}
+	if typ.Decorator() != 0 {
+		return 0, nil, fmt.Errorf("cannot save the state of a %q", typ.Str(g.tm))
+	}
+
+	qid := typ.QID()
+	if qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDBool, t.IDI8, t.IDU8:
+			return 1, nil, nil
+		case t.IDI16, t.IDU16:
+			return 2, nil, nil
+		case t.IDI32, t.IDU32, t.IDStatus:
+			return 4, nil, nil
+		case t.IDI64, t.IDU64:
+			return 8, nil, nil
+		}
+		if qid[1].Str(g.tm) == "rect_ie_u32" {
+			return 16, nil, nil
+		}
+	} else if qid[1] == t.IDStatus {
+		return 4, nil, nil
+	} else if s := g.structMap[qid]; (s != nil) && s.Suspendible() {
+		return 0, []string{g.stateLengthMacro(s)}, nil
+	} else if qid[0] != 0 {
+		// Structs from used packages generate their own STATE_LENGTH macros.
+		otherPkg := g.tm.ByID(qid[0])
+		return 0, []string{"WUFFS_" + strings.ToUpper(otherPkg) + "__" +
+			strings.ToUpper(qid[1].Str(g.tm)) + "__STATE_LENGTH"}, nil
+	}
+	return 0, nil, fmt.Errorf("cannot save the state of a %q", typ.Str(g.tm))
+}
+
+// forEachStateValue calls f for each value in n's saved state, in order. The
+// lhs passed to f is relative to "self->private_impl.". Fields are checked
+// against their refinements when restoring, but coroutine locals are not, as
+// they may be uninitialized (in the C sense) at a suspension point.
+func (g *gen) forEachStateValue(n *a.Struct,
+	f func(lhs string, typ *a.TypeExpr, checkRefinement bool, maxValue uint32) error) error {
+
+	if err := f("status", nil, false, 0); err != nil {
+		return err
+	}
+	for _, o := range n.Fields() {
+		o := o.Field()
+		if err := f(fPrefix+o.Name().Str(g.tm), o.XType(), true, 0); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc {
+				continue
+			}
+			o := tld.Func()
+			if o.Receiver() != n.QID() || !o.Suspendible() {
+				continue
+			}
+			k := g.funks[o.QQID()]
+			if k.coroSuspPoint == 0 && !k.usesScratch {
+				continue
+			}
+			c := fmt.Sprintf("%s%s[0].", cPrefix, o.FuncName().Str(g.tm))
+			if k.coroSuspPoint != 0 {
+				if err := f(c+"coro_susp_point", nil, false, k.coroSuspPoint); err != nil {
+					return err
+				}
+				err := g.visitVars(nil, o.Body(), 0, func(g *gen, b *buffer, v *a.Var) error {
+					if v.XType().HasPointers() || v.IterateVariable() {
+						return nil
+					}
+					return f(c+vPrefix+v.Name().Str(g.tm), v.XType(), false, 0)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if k.usesScratch {
+				if err := f(c+"scratch", nil, false, 0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeStateLengthMacro writes the STATE_LENGTH macro for n.
+func (g *gen) writeStateLengthMacro(b *buffer, n *a.Struct) error {
+	if !n.Suspendible() {
+		return nil
+	}
+	total, macros := uint64(0), []string(nil)
+	err := g.forEachStateValue(n, func(lhs string, typ *a.TypeExpr, _ bool, maxValue uint32) error {
+		if typ == nil {
+			if strings.HasSuffix(lhs, "scratch") {
+				total += 8
+			} else {
+				total += 4
+			}
+			return nil
+		}
+		l, m, err := g.stateLength(typ)
+		total += l
+		macros = append(macros, m...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if total > 0xFFFFFFFF {
+		return fmt.Errorf("saved state for %q is too long", n.QID().Str(g.tm))
+	}
+
+	writeComment(b, "", g.stateLengthMacro(n)+" is the length of a "+
+		g.pkgPrefix+n.QID().Str(g.tm)+"'s saved state.")
+	b.printf("#define %s (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + %d", g.stateLengthMacro(n), total)
+	for _, m := range macros {
+		b.printf(" + %s", m)
+	}
+	b.writes(")\n\n")
+	return nil
+}
+
+// stateFingerprint hashes n's layout and the Wuffs code of its methods. A
+// saved state can only be restored by code with the same fingerprint, as the
+// coro_susp_point values are only meaningful to the code that saved them.
+func (g *gen) stateFingerprint(n *a.Struct) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s.%s\n", g.pkgName, n.QID().Str(g.tm))
+	for _, o := range n.Fields() {
+		o := o.Field()
+		fmt.Fprintf(h, "field %s %s\n", o.Name().Str(g.tm), o.XType().Str(g.tm))
+	}
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc || tld.Func().Receiver() != n.QID() {
+				continue
+			}
+			o := tld.Func()
+			fmt.Fprintf(h, "func %s %d\n", o.FuncName().Str(g.tm), g.funks[o.QQID()].coroSuspPoint)
+			for _, p := range o.Body() {
+				p.Walk(func(q *a.Node) error {
+					g.fingerprintNode(h, q)
+					return nil
+				})
+			}
+		}
+	}
+	return h.Sum32()
+}
+
+func (g *gen) fingerprintNode(w io.Writer, n *a.Node) {
+	fmt.Fprintf(w, "%s", n.Kind())
+	switch n.Kind() {
+	case a.KArg:
+		fmt.Fprintf(w, " %s", n.Arg().Name().Str(g.tm))
+	case a.KAssert:
+		fmt.Fprintf(w, " %s", n.Assert().Keyword().Str(g.tm))
+	case a.KAssign:
+		fmt.Fprintf(w, " %s", n.Assign().Operator().Str(g.tm))
+	case a.KExpr:
+		fmt.Fprintf(w, " %s %s", n.Expr().Operator().Str(g.tm), n.Expr().Ident().Str(g.tm))
+	case a.KIterate:
+		fmt.Fprintf(w, " %s %s", n.Iterate().Length().Str(g.tm), n.Iterate().Unroll().Str(g.tm))
+	case a.KJump:
+		fmt.Fprintf(w, " %s %s", n.Jump().Keyword().Str(g.tm), n.Jump().Label().Str(g.tm))
+	case a.KRet:
+		fmt.Fprintf(w, " %s", n.Ret().Keyword().Str(g.tm))
+	case a.KTypeExpr:
+		fmt.Fprintf(w, " %s", n.TypeExpr().Str(g.tm))
+	case a.KVar:
+		fmt.Fprintf(w, " %s", n.Var().Name().Str(g.tm))
+	case a.KWhile:
+		fmt.Fprintf(w, " %s", n.While().Label().Str(g.tm))
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (g *gen) writeStateSignature(b *buffer, n *a.Struct, restoring bool, comments bool) {
+	cName := g.pkgPrefix + n.QID().Str(g.tm)
+	if comments && !restoring {
+		writeComment(b, "", cName+"__save_state writes self's state, including that of any "+
+			"suspended coroutine, to the first "+g.stateLengthMacro(n)+" bytes of dst.")
+		b.writes("//\n")
+		writeComment(b, "", "The saved state can be restored, by "+cName+"__restore_state, "+
+			"into a different struct, possibly in a different process, as long as it runs "+
+			"code generated from the same Wuffs source by the same Wuffs compiler.")
+	} else if comments && restoring {
+		writeComment(b, "", cName+"__restore_state sets self's state to that saved in src, "+
+			"which must be exactly "+g.stateLengthMacro(n)+" bytes long. The self argument "+
+			"must have been initialized by "+cName+"__check_wuffs_version.")
+		b.writes("//\n")
+		writeComment(b, "", "Restoring checks the saved state's header, including a checksum, "+
+			"before modifying self. It then checks each value against its type, such as bools "+
+			"being 0 or 1. If those later checks fail, self is left with a sticky error status. "+
+			"The checks reject mismatched or corrupted saved states, but they cannot re-prove "+
+			"every fact that the Wuffs compiler proved, so saved states should only be "+
+			"restored from trusted sources.")
+	}
+
+	if !n.Public() {
+		b.writes("static ")
+	}
+	if restoring {
+		b.printf("%sstatus %s__restore_state(%s *self, wuffs_base__slice_u8 a_src)", g.pkgPrefix, cName, cName)
+	} else {
+		b.printf("%sstatus %s__save_state(%s *self, wuffs_base__slice_u8 a_dst)", g.pkgPrefix, cName, cName)
+	}
+}
+
+func (g *gen) writeStatePrototypes(b *buffer, n *a.Struct) error {
+	if !n.Suspendible() {
+		return nil
+	}
+	for _, restoring := range []bool{false, true} {
+		g.writeStateSignature(b, n, restoring, n.Public())
+		b.writes(";\n\n")
+	}
+	return nil
+}
+
+func (g *gen) writeStateImpls(b *buffer, n *a.Struct) error {
+	if !n.Suspendible() {
+		return nil
+	}
+	structName := n.QID().Str(g.tm)
+	macro := g.stateLengthMacro(n)
+	fingerprint := g.stateFingerprint(n)
+
+	for _, restoring := range []bool{false, true} {
+		body, usesBadState, arrayDepth := buffer(nil), false, 0
+		err := g.forEachStateValue(n, func(lhs string, typ *a.TypeExpr, checkRefinement bool, maxValue uint32) error {
+			lhs = "self->private_impl." + lhs
+			if typ == nil {
+				if strings.HasSuffix(lhs, "scratch") {
+					return g.writeStateScalar(&body, lhs, "uint64_t", 8, restoring)
+				}
+				if lhs == "self->private_impl.status" {
+					return g.writeStateScalar(&body, lhs, g.pkgPrefix+"status", 4, restoring)
+				}
+				if !restoring || (maxValue == 0) {
+					return g.writeStateScalar(&body, lhs, "uint32_t", 4, restoring)
+				}
+				body.printf("%s = wuffs_base__load_u32le(p);\n", lhs)
+				body.printf("if (%s > %d) { goto bad_state; }\n", lhs, maxValue)
+				body.writes("p += 4;\n")
+				usesBadState = true
+				return nil
+			}
+			u, d, err := g.writeStateValue(&body, lhs, typ, checkRefinement, restoring, 0)
+			usesBadState = usesBadState || u
+			if arrayDepth < d {
+				arrayDepth = d
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if !restoring {
+			b.printf("// -------- func %s.save_state\n\n", structName)
+			g.writeStateSignature(b, n, false, false)
+			b.writes(" {\n")
+			b.printf("if (!self) { return %sERROR_BAD_RECEIVER; }\n", g.PKGPREFIX)
+			b.printf("if (self->private_impl.magic != WUFFS_BASE__MAGIC) {\n")
+			b.printf("return %sERROR_CHECK_WUFFS_VERSION_NOT_CALLED;\n", g.PKGPREFIX)
+			b.printf("}\n")
+			b.printf("if (a_dst.len < %s) { return %sERROR_BAD_ARGUMENT; }\n", macro, g.PKGPREFIX)
+		} else {
+			b.printf("// -------- func %s.restore_state\n\n", structName)
+			g.writeStateSignature(b, n, true, false)
+			b.writes(" {\n")
+			b.printf("if (!self) { return %sERROR_BAD_RECEIVER; }\n", g.PKGPREFIX)
+			b.printf("if (self->private_impl.magic != WUFFS_BASE__MAGIC) {\n")
+			b.printf("self->private_impl.status = %sERROR_CHECK_WUFFS_VERSION_NOT_CALLED;\n", g.PKGPREFIX)
+			b.printf("return self->private_impl.status;\n")
+			b.printf("}\n")
+			b.printf("if (!wuffs_base__saved_state__check_header(a_src, 0x%08X, %s)) {\n", fingerprint, macro)
+			b.printf("return %sERROR_BAD_ARGUMENT;\n", g.PKGPREFIX)
+			b.printf("}\n")
+		}
+		b.printf("uint8_t* p = a_%s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;\n",
+			map[bool]string{false: "dst", true: "src"}[restoring])
+		for i := 0; i < arrayDepth; i++ {
+			b.printf("size_t i%d;\n", i)
+		}
+		b.writeb('\n')
+		b.writex(body)
+		b.writes("WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);\n")
+
+		if !restoring {
+			b.printf("wuffs_base__saved_state__write_header(a_dst.ptr, 0x%08X, %s);\n", fingerprint, macro)
+			b.printf("return %sSTATUS_OK;\n", g.PKGPREFIX)
+		} else {
+			b.printf("return %sSTATUS_OK;\n", g.PKGPREFIX)
+			if usesBadState {
+				b.writes("\nbad_state:\n")
+				b.printf("self->private_impl.status = %sERROR_BAD_ARGUMENT;\n", g.PKGPREFIX)
+				b.printf("return self->private_impl.status;\n")
+			}
+		}
+		b.writes("}\n\n")
+	}
+	return nil
+}
+
+// writeStateScalar writes the C code to save or restore the integer lhs,
+// whose C type is cType, as size little-endian bytes.
+func (g *gen) writeStateScalar(b *buffer, lhs string, cType string, size uint32, restoring bool) error {
+	if restoring {
+		if size == 1 {
+			b.printf("%s = (%s)(p[0]);\n", lhs, cType)
+		} else {
+			b.printf("%s = (%s)(wuffs_base__load_u%dle(p));\n", lhs, cType, 8*size)
+		}
+	} else {
+		if size == 1 {
+			b.printf("p[0] = (uint8_t)(%s);\n", lhs)
+		} else {
+			b.printf("wuffs_base__store_u%dle(p, (uint%d_t)(%s));\n", 8*size, 8*size, lhs)
+		}
+	}
+	b.printf("p += %d;\n", size)
+	return nil
+}
+
+// writeStateValue writes the C code to save or restore lhs, of type typ. It
+// returns whether that code can "goto bad_state" and the depth of any nested
+// "for" loops over arrays.
+func (g *gen) writeStateValue(b *buffer, lhs string, typ *a.TypeExpr,
+	checkRefinement bool, restoring bool, arrayDepth int) (usesBadState bool, maxArrayDepth int, err error) {
+
+	if typ.IsArrayType() {
+		if arrayDepth == maxStateArrayDepth {
+			return false, 0, fmt.Errorf("cannot save the state of %q: array depth too large", lhs)
+		}
+		i := fmt.Sprintf("i%d", arrayDepth)
+		b.printf("for (%s = 0; %s < %v; %s++) {\n", i, i, typ.ArrayLength().ConstValue(), i)
+		usesBadState, maxArrayDepth, err = g.writeStateValue(
+			b, lhs+"["+i+"]", typ.Inner(), checkRefinement, restoring, arrayDepth+1)
+		b.writes("}\n")
+		return usesBadState, maxArrayDepth, err
+	}
+
+	length, macros, err := g.stateLength(typ)
+	if err != nil {
+		return false, 0, err
+	}
+
+	// Sub-structs.
+	if len(macros) != 0 {
+		qid := typ.QID()
+		prefix := g.packagePrefix(qid)
+		method := "save_state"
+		if restoring {
+			method = "restore_state"
+		}
+		b.writes("{\n")
+		b.printf("int32_t z = %s%s__%s(&%s, ((wuffs_base__slice_u8){.ptr = p, .len = %s}));\n",
+			prefix, qid[1].Str(g.tm), method, lhs, macros[0])
+		if restoring {
+			b.writes("if (z) { self->private_impl.status = z; return z; }\n")
+		} else {
+			b.writes("if (z) { return z; }\n")
+		}
+		b.printf("p += %s;\n", macros[0])
+		b.writes("}\n")
+		return false, arrayDepth, nil
+	}
+
+	qid := typ.QID()
+	if (qid[0] == t.IDBase) && (qid[1].Str(g.tm) == "rect_ie_u32") {
+		for _, s := range []string{"min_inclusive_x", "min_inclusive_y", "max_exclusive_x", "max_exclusive_y"} {
+			g.writeStateScalar(b, lhs+"."+s, "uint32_t", 4, restoring)
+		}
+		return false, arrayDepth, nil
+	}
+
+	if qid[1] == t.IDBool {
+		if restoring {
+			b.printf("if (p[0] > 1) { goto bad_state; }\n")
+			b.printf("%s = p[0];\n", lhs)
+			b.writes("p += 1;\n")
+			return true, arrayDepth, nil
+		}
+		b.printf("p[0] = %s ? 1 : 0;\n", lhs)
+		b.writes("p += 1;\n")
+		return false, arrayDepth, nil
+	}
+
+	cType := buffer(nil)
+	if err := g.writeCTypeName(&cType, typ.Unrefined(), "", ""); err != nil {
+		return false, 0, err
+	}
+	if err := g.writeStateScalar(b, lhs, strings.TrimSpace(string(cType)), uint32(length), restoring); err != nil {
+		return false, 0, err
+	}
+	if !restoring || !checkRefinement || !typ.IsRefined() {
+		return false, arrayDepth, nil
+	}
+
+	// Only the upper bound is checked. A lower bound might not hold for a
+	// zero-initialized struct, before its first method call.
+	m := typ.Max()
+	if m == nil {
+		return false, arrayDepth, nil
+	}
+	b.printf("if (%s > %v) { goto bad_state; }\n", lhs, m.ConstValue())
+	return true, arrayDepth, nil
+}
+
+// writeComment writes text as "// " prefixed lines, after the given indent,
+// word-wrapped to fit within formatMaxLineLength where possible.
+func writeComment(b *buffer, indent string, text string) {
+	line := indent + "//"
+	for _, word := range strings.Fields(text) {
+		if (len(line) > len(indent)+2) && (len(line)+1+len(word) > formatMaxLineLength) {
+			b.printf("%s\n", line)
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	b.printf("%s\n", line)
+}
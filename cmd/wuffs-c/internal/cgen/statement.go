@@ -23,12 +23,6 @@ import (
 	t "github.com/google/wuffs/lang/token"
 )
 
-// genFilenameLineComments is whether to print "// foo.wuffs:123\n" comments in
-// the generated code. This can be useful for debugging, although it is not
-// enabled by default as it can lead to many spurious changes in the generated
-// C code (due to line numbers changing) when editing Wuffs code.
-const genFilenameLineComments = false
-
 func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 	if depth > a.MaxBodyDepth {
 		return fmt.Errorf("body recursion depth too large")
@@ -36,8 +30,8 @@ func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 	depth++
 
 	if n.Kind() == a.KAssert {
-		// Assertions only apply at compile-time.
-		return nil
+		// Assertions only apply at compile-time, other than in debug mode.
+		return g.writeDebugAsserts(b, []*a.Node{n}, t.IDAssert)
 	}
 
 	mightIntroduceTemporaries := false
@@ -58,7 +52,18 @@ func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 		defer b.writes("}\n")
 	}
 
-	if genFilenameLineComments {
+	// The "// foo.wuffs:123\n" comments can be useful for debugging, and the
+	// wuffs tool's coverage reports use them to map C lines back to Wuffs
+	// lines, but they are not enabled by default as they can lead to many
+	// spurious changes in the generated C code (due to line numbers changing)
+	// when editing Wuffs code.
+	if g.lineMarkers {
+		filename, line := n.Raw().FilenameLine()
+		writeLineMarker(b, fmt.Sprintf("%s %d:%d", lineBeginMarker, g.lineSourceIndex(filename), line))
+		defer writeLineMarker(b, lineEndMarker)
+	}
+
+	if g.filenameLineComments {
 		filename, line := n.Raw().FilenameLine()
 		if i := strings.LastIndexByte(filename, '/'); i >= 0 {
 			filename = filename[i+1:]
@@ -325,6 +330,13 @@ func (g *gen) writeStatementJump(b *buffer, n *a.Jump, depth uint32) error {
 func (g *gen) writeStatementRet(b *buffer, n *a.Ret, depth uint32) error {
 	retExpr := n.Value()
 
+	// A successful return is a function exit, where the post conditions hold.
+	if (retExpr == nil) || !g.currFunk.suspendible {
+		if err := g.writeDebugAsserts(b, g.currFunk.astFunc.Asserts(), t.IDPost); err != nil {
+			return err
+		}
+	}
+
 	if g.currFunk.suspendible {
 		b.writes("status = ")
 		retKeyword := t.IDStatus
@@ -410,6 +422,8 @@ func (g *gen) writeStatementVar(b *buffer, n *a.Var, depth uint32) error {
 				s = "writer"
 			}
 			b.printf("((wuffs_base__io_%s){})", s)
+		} else if qid := nTyp.QID(); (qid[0] == t.IDBase) && (qid[1].Str(g.tm) == "rect_ie_u32") {
+			b.printf("((wuffs_base__rect_ie_u32){})")
 		} else {
 			b.writeb('0')
 		}
@@ -428,6 +442,11 @@ func (g *gen) writeStatementWhile(b *buffer, n *a.While, depth uint32) error {
 		}
 		b.printf("label_%d_continue:;\n", jt)
 	}
+	// The pre and inv conditions hold on entry and on every continue, explicit
+	// or implicit. The inv and post conditions hold on exit.
+	if err := g.writeDebugAsserts(b, n.Asserts(), t.IDPre, t.IDInv); err != nil {
+		return err
+	}
 	condition := buffer(nil)
 	if err := g.writeExpr(&condition, n.Condition(), replaceCallSuspendibles, 0); err != nil {
 		return err
@@ -439,6 +458,9 @@ func (g *gen) writeStatementWhile(b *buffer, n *a.While, depth uint32) error {
 			return err
 		}
 	}
+	if err := g.writeDebugAsserts(b, n.Asserts(), t.IDPre, t.IDInv); err != nil {
+		return err
+	}
 	b.writes("}\n")
 	if n.HasBreak() {
 		jt, err := g.currFunk.jumpTarget(n)
@@ -447,6 +469,30 @@ func (g *gen) writeStatementWhile(b *buffer, n *a.While, depth uint32) error {
 		}
 		b.printf("label_%d_break:;\n", jt)
 	}
+	return g.writeDebugAsserts(b, n.Asserts(), t.IDInv, t.IDPost)
+}
+
+// writeDebugAsserts writes a C assert call for each of the asserts whose
+// keyword is one of keywords, if in debug mode. Otherwise, it is a no-op.
+func (g *gen) writeDebugAsserts(b *buffer, asserts []*a.Node, keywords ...t.ID) error {
+	if !g.debugAsserts {
+		return nil
+	}
+	for _, o := range asserts {
+		o := o.Assert()
+		match := false
+		for _, k := range keywords {
+			match = match || (o.Keyword() == k)
+		}
+		if !match {
+			continue
+		}
+		condition := buffer(nil)
+		if err := g.writeExpr(&condition, o.Condition(), replaceCallSuspendibles, 0); err != nil {
+			return err
+		}
+		b.printf("assert(%s);\n", trimParens(condition))
+	}
 	return nil
 }
 
@@ -683,3 +729,43 @@ func trimParens(b []byte) []byte {
 	}
 	return b
 }
+
+// writeWriteUXX writes a size-bit big-endian or little-endian number, one byte
+// at a time. The number of bytes written so far is held in the coroutine's
+// scratch space, so that resuming after a short write continues where it left
+// off. The value being written is re-evaluated when resuming, which is fine,
+// as the coroutine's local variables are restored before then.
+func (g *gen) writeWriteUXX(b *buffer, n *a.Expr, name string, size uint32, endianness string, depth uint32) error {
+	if (size&7 != 0) || (size < 16) || (size > 64) {
+		return fmt.Errorf("internal error: bad writeWriteUXX size %d", size)
+	}
+	if endianness != "be" && endianness != "le" {
+		return fmt.Errorf("internal error: bad writeWriteUXX endianness %q", endianness)
+	}
+
+	g.currFunk.usesScratch = true
+	// TODO: don't hard-code [0], and allow recursive coroutines.
+	scratchName := fmt.Sprintf("self->private_impl.%s%s[0].scratch",
+		cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+
+	b.printf("%s = 0;\n", scratchName)
+	if err := g.writeCoroSuspPoint(b, false); err != nil {
+		return err
+	}
+	b.printf("while (%s < %d) {", scratchName, size/8)
+	b.printf("if (ioptr_%s == iobounds1_%s) { status = %sSUSPENSION_SHORT_WRITE; goto suspend; }",
+		name, name, g.PKGPREFIX)
+	b.printf("*ioptr_%s++ = (uint8_t)(((uint64_t)(", name)
+	if err := g.writeExpr(b, n.Args()[0].Arg().Value(), replaceCallSuspendibles, depth); err != nil {
+		return err
+	}
+	switch endianness {
+	case "be":
+		b.printf(")) >> (%d - (8 * %s)));", size-8, scratchName)
+	case "le":
+		b.printf(")) >> (8 * %s));", scratchName)
+	}
+	b.printf("%s++;", scratchName)
+	b.writes("}\n")
+	return nil
+}
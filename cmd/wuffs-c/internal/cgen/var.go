@@ -86,8 +86,12 @@ func (g *gen) writeLoadDerivedVar(b *buffer, hack string, name t.ID, typ *a.Type
 		name = g.tm.ByName("src")
 	}
 	// TODO: also remove this hack.
-	if hack == "w" {
-		b.printf("ioptr_w = %sw.ptr + %sw.wi;\n", uPrefix, uPrefix)
+	if hack != "" {
+		i0 := "wi"
+		if typ.QID()[1] == t.IDIOReader {
+			i0 = "ri"
+		}
+		b.printf("ioptr_%s = %s%s.ptr + %s%s.%s;\n", hack, uPrefix, hack, uPrefix, hack, i0)
 		return nil
 	}
 
@@ -147,8 +151,12 @@ func (g *gen) writeSaveDerivedVar(b *buffer, hack string, name t.ID, typ *a.Type
 		name = g.tm.ByName("src")
 	}
 	// TODO: also remove this hack.
-	if hack == "w" {
-		b.printf("%sw.wi = ioptr_w - %sw.ptr;\n", uPrefix, uPrefix)
+	if hack != "" {
+		i0 := "wi"
+		if typ.QID()[1] == t.IDIOReader {
+			i0 = "ri"
+		}
+		b.printf("%s%s.%s = ioptr_%s - %s%s.ptr;\n", uPrefix, hack, i0, hack, uPrefix, hack)
 		return nil
 	}
 
@@ -185,10 +193,11 @@ func (g *gen) writeLoadExprDerivedVars(b *buffer, n *a.Expr) error {
 		o := o.Arg()
 		// TODO: don't hard-code these.
 		hack := ""
-		if s := o.Value().Str(g.tm); s != "in.dst" && s != "in.src" && s != "w" {
+		if v := o.Value(); (v.Operator() == 0) && v.MType().IsIOType() {
+			// An io_bind'ed local variable, such as "w".
+			hack = v.Ident().Str(g.tm)
+		} else if s := v.Str(g.tm); s != "in.dst" && s != "in.src" {
 			continue
-		} else if s == "w" {
-			hack = "w"
 		}
 		if err := g.writeLoadDerivedVar(b, hack, o.Name(), o.Value().MType(), false); err != nil {
 			return err
@@ -208,10 +217,11 @@ func (g *gen) writeSaveExprDerivedVars(b *buffer, n *a.Expr) error {
 		o := o.Arg()
 		// TODO: don't hard-code these.
 		hack := ""
-		if s := o.Value().Str(g.tm); s != "in.dst" && s != "in.src" && s != "w" {
+		if v := o.Value(); (v.Operator() == 0) && v.MType().IsIOType() {
+			// An io_bind'ed local variable, such as "w".
+			hack = v.Ident().Str(g.tm)
+		} else if s := v.Str(g.tm); s != "in.dst" && s != "in.src" {
 			continue
-		} else if s == "w" {
-			hack = "w"
 		}
 		if err := g.writeSaveDerivedVar(b, hack, o.Name(), o.Value().MType()); err != nil {
 			return err
@@ -16,8 +16,10 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -33,10 +35,14 @@ func doTest(args []string) error  { return doBenchTest(args, false) }
 func doBenchTest(args []string, bench bool) error {
 	flags := flag.FlagSet{}
 	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
+	cxxcompilersFlag := flags.String("cxxcompilers", cf.CxxcompilersDefault, cf.CxxcompilersUsage)
+	coverFlag := flags.String("cover", "",
+		"the directory to write coverage (gcov) data to, or empty to not measure coverage")
 	focusFlag := flags.String("focus", cf.FocusDefault, cf.FocusUsage)
 	iterscaleFlag := flags.Int("iterscale", cf.IterscaleDefault, cf.IterscaleUsage)
 	mimicFlag := flags.Bool("mimic", cf.MimicDefault, cf.MimicUsage)
 	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
+	sanitizeFlag := flags.String("sanitize", cf.SanitizeDefault, cf.SanitizeUsage)
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -45,6 +51,9 @@ func doBenchTest(args []string, bench bool) error {
 	if !cf.IsAlphaNumericIsh(*ccompilersFlag) {
 		return fmt.Errorf("bad -ccompilers flag value %q", *ccompilersFlag)
 	}
+	if !cf.IsAlphaNumericIsh(*cxxcompilersFlag) {
+		return fmt.Errorf("bad -cxxcompilers flag value %q", *cxxcompilersFlag)
+	}
 	if !cf.IsAlphaNumericIsh(*focusFlag) {
 		return fmt.Errorf("bad -focus flag value %q", *focusFlag)
 	}
@@ -56,13 +65,24 @@ func doBenchTest(args []string, bench bool) error {
 		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]",
 			*repsFlag, cf.RepsMin, cf.RepsMax)
 	}
+	sanitizers, err := cf.ParseSanitizers(*sanitizeFlag)
+	if err != nil {
+		return err
+	}
+	if bench && len(sanitizers) > 0 {
+		return fmt.Errorf("the -sanitize flag only applies to tests, not benchmarks")
+	}
+	if bench && *coverFlag != "" {
+		return fmt.Errorf("the -cover flag only applies to tests, not benchmarks")
+	}
 
 	args = flags.Args()
 
 	failed := false
 	for _, arg := range args {
 		f, err := doBenchTest1(arg, bench,
-			*ccompilersFlag, *focusFlag, *iterscaleFlag, *mimicFlag, *repsFlag)
+			*ccompilersFlag, *cxxcompilersFlag, *focusFlag, *iterscaleFlag, *mimicFlag, *repsFlag,
+			sanitizers, *coverFlag)
 		if err != nil {
 			return err
 		}
@@ -78,8 +98,8 @@ func doBenchTest(args []string, bench bool) error {
 	return nil
 }
 
-func doBenchTest1(filename string, bench bool, ccompilers string, focus string,
-	iterscale int, mimic bool, reps int) (failed bool, err error) {
+func doBenchTest1(filename string, bench bool, ccompilers string, cxxcompilers string, focus string,
+	iterscale int, mimic bool, reps int, sanitizers []string, cover string) (failed bool, err error) {
 
 	workDir, err := ioutil.TempDir("", "wuffs-c")
 	if err != nil {
@@ -88,6 +108,13 @@ func doBenchTest1(filename string, bench bool, ccompilers string, focus string,
 	defer os.RemoveAll(workDir)
 
 	in := filename + ".c"
+	if cover != "" {
+		// The coverage data records the source file names as given to the C
+		// compiler, and gcov needs to find them from a different directory.
+		if in, err = filepath.Abs(in); err != nil {
+			return false, err
+		}
+	}
 	out := filepath.Join(workDir, "a.out")
 
 	ccArgs := []string(nil)
@@ -97,6 +124,19 @@ func doBenchTest1(filename string, bench bool, ccompilers string, focus string,
 		// TODO: set these flags even if we pass -O3.
 		ccArgs = append(ccArgs, "-Wall", "-Werror")
 	}
+	sanitizeMemory := false
+	if len(sanitizers) > 0 {
+		// Sanitizer findings should abort the test program, with a useful stack
+		// trace, instead of being printed and then carrying on.
+		ccArgs = append(ccArgs, "-fsanitize="+strings.Join(sanitizers, ","),
+			"-fno-sanitize-recover=all", "-fno-omit-frame-pointer", "-g")
+		for _, s := range sanitizers {
+			sanitizeMemory = sanitizeMemory || (s == "memory")
+		}
+	}
+	if cover != "" {
+		ccArgs = append(ccArgs, "--coverage")
+	}
 	ccArgs = append(ccArgs, "-std=c99", "-o", out, in)
 	if mimic {
 		extra, err := findWuffsMimicCflags(in)
@@ -111,11 +151,16 @@ func doBenchTest1(filename string, bench bool, ccompilers string, focus string,
 		if cc == "" {
 			continue
 		}
+		if _, err := exec.LookPath(cc); err != nil {
+			fmt.Printf("%s: skipping %s: not found\n", filename, cc)
+			continue
+		}
+		if sanitizeMemory && !strings.Contains(filepath.Base(cc), "clang") {
+			fmt.Printf("%s: skipping %s: -sanitize=memory requires clang\n", filename, cc)
+			continue
+		}
 
-		ccCmd := exec.Command(cc, ccArgs...)
-		ccCmd.Stdout = os.Stdout
-		ccCmd.Stderr = os.Stderr
-		if err := ccCmd.Run(); err != nil {
+		if err := runCompiler(cc, ccArgs); err != nil {
 			return false, err
 		}
 
@@ -129,21 +174,251 @@ func doBenchTest1(filename string, bench bool, ccompilers string, focus string,
 		if focus != "" {
 			outArgs = append(outArgs, fmt.Sprintf("-focus=%s", focus))
 		}
-		outCmd := exec.Command(out, outArgs...)
-		outCmd.Stdout = os.Stdout
-		outCmd.Stderr = os.Stderr
-		outCmd.Dir = filepath.Dir(filename)
-		if err := outCmd.Run(); err == nil {
-			// No-op.
-		} else if _, ok := err.(*exec.ExitError); ok {
-			failed = true
-		} else {
+		f, err := runTestProgram(filename, cc, out, outArgs, len(sanitizers) > 0)
+		if err != nil {
+			return false, err
+		}
+		failed = failed || f
+
+		if cover != "" {
+			dstDir := filepath.Join(cover, filepath.Base(filename), filepath.Base(cc))
+			if err := runGcov(dstDir, workDir, cc); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// The C++ test programs check the C++ wrappers in the generated headers.
+	// They aren't benchmarks, and coverage is measured by the C programs.
+	if !bench && cover == "" {
+		f, err := doTestCPlusPlus(filename, workDir, cxxcompilers, focus, sanitizers)
+		if err != nil {
 			return false, err
 		}
+		failed = failed || f
 	}
 	return failed, nil
 }
 
+// doTestCPlusPlus builds and runs the filename+".cc" test program, if it
+// exists, with each of the cxxcompilers. That program is linked against the
+// C implementations of the generated "gen/h/etc.h" headers that it includes,
+// built from the corresponding "gen/c/etc.c" files by the same compiler.
+func doTestCPlusPlus(filename string, workDir string, cxxcompilers string, focus string,
+	sanitizers []string) (failed bool, err error) {
+
+	in := filename + ".cc"
+	if _, err := os.Stat(in); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	cFilenames, err := findWuffsImplementations(in)
+	if err != nil {
+		return false, err
+	}
+	out := filepath.Join(workDir, "a.out")
+
+	ccArgs := []string{"-Wall", "-Werror"}
+	sanitizeMemory := false
+	if len(sanitizers) > 0 {
+		ccArgs = append(ccArgs, "-fsanitize="+strings.Join(sanitizers, ","),
+			"-fno-sanitize-recover=all", "-fno-omit-frame-pointer", "-g")
+		for _, s := range sanitizers {
+			sanitizeMemory = sanitizeMemory || (s == "memory")
+		}
+	}
+
+	outArgs := []string(nil)
+	if focus != "" {
+		outArgs = append(outArgs, fmt.Sprintf("-focus=%s", focus))
+	}
+
+	for _, cxx := range strings.Split(cxxcompilers, ",") {
+		cxx = strings.TrimSpace(cxx)
+		if cxx == "" {
+			continue
+		}
+		if _, err := exec.LookPath(cxx); err != nil {
+			fmt.Printf("%s: skipping %s: not found\n", filename, cxx)
+			continue
+		}
+		if sanitizeMemory && !strings.Contains(filepath.Base(cxx), "clang") {
+			fmt.Printf("%s: skipping %s: -sanitize=memory requires clang\n", filename, cxx)
+			continue
+		}
+
+		objs := []string(nil)
+		for _, c := range cFilenames {
+			obj := filepath.Join(workDir, strings.TrimSuffix(filepath.Base(c), ".c")+".o")
+			args := append(ccArgs[:len(ccArgs):len(ccArgs)], "-x", "c", "-std=c99", "-c", "-o", obj, c)
+			if err := runCompiler(cxx, args); err != nil {
+				return false, err
+			}
+			objs = append(objs, obj)
+		}
+		args := append(ccArgs[:len(ccArgs):len(ccArgs)], "-std=c++11", "-o", out, in)
+		if err := runCompiler(cxx, append(args, objs...)); err != nil {
+			return false, err
+		}
+
+		f, err := runTestProgram(filename, cxx, out, outArgs, len(sanitizers) > 0)
+		if err != nil {
+			return false, err
+		}
+		failed = failed || f
+	}
+	return failed, nil
+}
+
+func runCompiler(cc string, args []string) error {
+	cmd := exec.Command(cc, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runTestProgram runs the out program, built by the cc compiler from the
+// filename test, returning whether it (or, if sanitized, any sanitizer)
+// reported a failure.
+func runTestProgram(filename string, cc string, out string, outArgs []string,
+	sanitized bool) (failed bool, err error) {
+
+	outCmd := exec.Command(out, outArgs...)
+	outCmd.Stdout = os.Stdout
+	outCmd.Stderr = os.Stderr
+	outCmd.Dir = filepath.Dir(filename)
+	stderr := &bytes.Buffer{}
+	if sanitized {
+		outCmd.Env = sanitizerEnv()
+		outCmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+	}
+	if err := outCmd.Run(); err == nil {
+		// No-op.
+	} else if _, ok := err.(*exec.ExitError); ok {
+		failed = true
+	} else {
+		return false, err
+	}
+	if summaries := sanitizerSummaries(stderr.Bytes()); len(summaries) > 0 {
+		failed = true
+		for _, summary := range summaries {
+			fmt.Printf("%-16s%-8sFAIL %s\n", filename, cc, summary)
+		}
+	}
+	return failed, nil
+}
+
+// runGcov converts the coverage data in workDir, from running a program built
+// with the cc compiler's --coverage flag, to text .gcov files in dstDir. It
+// then removes that coverage data, so that workDir can be re-used for the
+// next compiler.
+func runGcov(dstDir string, workDir string, cc string) error {
+	gcdas, err := filepath.Glob(filepath.Join(workDir, "*.gcda"))
+	if err != nil {
+		return err
+	}
+	if len(gcdas) > 0 {
+		if err := os.MkdirAll(dstDir, 0755); err != nil {
+			return err
+		}
+		// Match the gcov tool to the compiler, e.g. "gcc-7" uses "gcov-7" and
+		// "clang-5.0" uses "llvm-cov-5.0 gcov".
+		command, args := "gcov", []string(nil)
+		if base := filepath.Base(cc); strings.HasPrefix(base, "clang") {
+			command, args = "llvm-cov"+strings.TrimPrefix(base, "clang"), []string{"gcov"}
+		} else if strings.HasPrefix(base, "gcc") {
+			command = "gcov" + strings.TrimPrefix(base, "gcc")
+		}
+		args = append(args, "-p")
+		args = append(args, gcdas...)
+		cmd := exec.Command(command, args...)
+		cmd.Stdout = ioutil.Discard
+		cmd.Stderr = os.Stderr
+		cmd.Dir = dstDir
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range []string{"*.gcda", "*.gcno"} {
+		matches, err := filepath.Glob(filepath.Join(workDir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			if err := os.Remove(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sanitizerEnv returns the environment for running a sanitizer-instrumented
+// test program. Any options already set by the user take priority.
+func sanitizerEnv() []string {
+	env := os.Environ()
+	for _, kv := range [...][2]string{
+		{"ASAN_OPTIONS", "abort_on_error=0:detect_leaks=1"},
+		{"MSAN_OPTIONS", "halt_on_error=1:print_stacktrace=1"},
+		{"UBSAN_OPTIONS", "halt_on_error=1:print_stacktrace=1"},
+	} {
+		if _, ok := os.LookupEnv(kv[0]); !ok {
+			env = append(env, kv[0]+"="+kv[1])
+		}
+	}
+	return env
+}
+
+// sanitizerSummaries returns the one-line summaries that the sanitizer
+// runtimes print to stderr after finding a problem: "SUMMARY: FooSanitizer:
+// etc" for ASan and MSan, and "file.c:1:2: runtime error: etc" for UBSan.
+func sanitizerSummaries(stderr []byte) (ret []string) {
+	s := bufio.NewScanner(bytes.NewReader(stderr))
+	for s.Scan() {
+		t := strings.TrimSpace(s.Text())
+		if (strings.HasPrefix(t, "SUMMARY: ") && strings.Contains(t, "Sanitizer")) ||
+			strings.Contains(t, ": runtime error: ") {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// findWuffsImplementations returns the "gen/c/etc.c" implementation files
+// for the `#include "path/to/gen/h/etc.h"` lines in the filename C++ program.
+// Relative paths are resolved relative to filename's directory.
+func findWuffsImplementations(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ret := []string(nil)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		t := strings.TrimSpace(s.Text())
+		const prefix = "#include \""
+		if !strings.HasPrefix(t, prefix) || !strings.HasSuffix(t, ".h\"") {
+			continue
+		}
+		t = filepath.FromSlash(t[len(prefix) : len(t)-1])
+		sep := string(filepath.Separator)
+		i := strings.LastIndex(t, sep+"gen"+sep+"h"+sep)
+		if i < 0 {
+			continue
+		}
+		t = t[:i] + sep + "gen" + sep + "c" + sep + t[i+len("/gen/h/"):len(t)-len(".h")] + ".c"
+		if !filepath.IsAbs(t) {
+			t = filepath.Join(filepath.Dir(filename), t)
+		}
+		ret = append(ret, t)
+	}
+	return ret, s.Err()
+}
+
 func findWuffsMimicCflags(filename string) ([]string, error) {
 	f, err := os.Open(filename)
 	if err != nil {
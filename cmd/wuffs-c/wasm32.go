@@ -0,0 +1,338 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// genWasm32 compiles the C files into a single libwuffs.wasm WebAssembly
+// module, using the C compiler's (e.g. clang's) wasm32 target directly, with
+// no Emscripten or other C library. The generated C code needs only memcpy,
+// memmove and memset from a C library, and those, plus a memory allocator and
+// some accessors for the base package's inline functions, are supplied by a
+// "wuffs-wasm32.c" shim. Every non-static function, such as every public
+// function of every package, is exported.
+func genWasm32(outDir string, inDir string, cc string, filenames []string) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("genlib: no packages to compile")
+	}
+	base, err := splitGeneratedC(filepath.Join(inDir, filenames[0]+".c"))
+	if err != nil {
+		return err
+	}
+
+	includeDir := filepath.Join(outDir, "include")
+	if err := os.MkdirAll(includeDir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(includeDir, "string.h"), []byte(wasm32StringH), 0644); err != nil {
+		return err
+	}
+	shim := filepath.Join(outDir, "wuffs-wasm32.c")
+	src := wasm32ShimPrologue + strings.TrimSpace(base.baseHeader) + "\n\n" + wasm32ShimImpl
+	if err := ioutil.WriteFile(shim, []byte(src), 0644); err != nil {
+		return err
+	}
+
+	objs := []string(nil)
+	compile := func(in string, out string, extraArgs ...string) error {
+		args := []string{"--target=wasm32", "-O3", "-std=c99", "-ffreestanding", "-nostdlib",
+			"-isystem", includeDir}
+		args = append(args, extraArgs...)
+		args = append(args, "-c", "-o", out, in)
+		if err := runGenlibCommand(cc, args); err != nil {
+			return err
+		}
+		fmt.Printf("genlib: %s\n", out)
+		objs = append(objs, out)
+		return nil
+	}
+
+	for _, filename := range filenames {
+		in := filepath.Join(inDir, filename+".c")
+		if err := compile(in, genlibOutFilename(outDir, "static", filename)); err != nil {
+			return err
+		}
+	}
+	// The shim implements memcpy and friends, so it must not be compiled into
+	// calls to itself.
+	if err := compile(shim, filepath.Join(outDir, "wuffs-wasm32.o"), "-fno-builtin"); err != nil {
+		return err
+	}
+
+	out := filepath.Join(outDir, "libwuffs.wasm")
+	args := []string{"--target=wasm32", "-nostdlib",
+		"-Wl,--no-entry", "-Wl,--export-dynamic", "-Wl,-z,stack-size=1048576", "-o", out}
+	args = append(args, objs...)
+	if err := runGenlibCommand(cc, args); err != nil {
+		return err
+	}
+	fmt.Printf("genlib: %s\n", out)
+	return nil
+}
+
+func runGenlibCommand(name string, args []string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+const wasm32StringH = `// Code generated by "wuffs-c genlib -target=wasm32". DO NOT EDIT.
+
+// This is the subset of the C library's <string.h> that the generated C code
+// uses. The implementations are in wuffs-wasm32.c.
+//
+// It also defines __WORDSIZE, which a C library's <stdint.h> would otherwise
+// provide, for the base package's word size check.
+
+#ifndef WUFFS_WASM32_STRING_H
+#define WUFFS_WASM32_STRING_H
+
+#include <stddef.h>
+
+#ifndef __WORDSIZE
+#define __WORDSIZE 32
+#endif
+
+void* memcpy(void* dst, const void* src, size_t n);
+void* memmove(void* dst, const void* src, size_t n);
+void* memset(void* dst, int c, size_t n);
+
+#endif  // WUFFS_WASM32_STRING_H
+`
+
+const wasm32ShimPrologue = `// Code generated by "wuffs-c genlib -target=wasm32". DO NOT EDIT.
+
+// This file supplies what the generated C code needs from a C library, plus a
+// memory allocator and accessors for the base package's inline functions, for
+// the JavaScript wrappers of a libwuffs.wasm WebAssembly module.
+
+`
+
+const wasm32ShimImpl = `
+
+#include <stddef.h>
+
+// ---------------- C Library
+
+void* memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)dst;
+  const uint8_t* s = (const uint8_t*)src;
+  while (n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+void* memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)dst;
+  const uint8_t* s = (const uint8_t*)src;
+  if (d < s) {
+    while (n--) {
+      *d++ = *s++;
+    }
+  } else if (d > s) {
+    d += n;
+    s += n;
+    while (n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+void* memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)dst;
+  while (n--) {
+    *d++ = (uint8_t)c;
+  }
+  return dst;
+}
+
+// ---------------- Memory Allocation
+
+// The allocator is a first-fit free list of blocks, each preceded by a header
+// holding its size. Memory is never returned to the host, as WebAssembly
+// cannot shrink its linear memory, but freed blocks are re-used. Allocated
+// memory is zeroed, as the check_wuffs_version initializers require.
+
+#define WUFFS_WASM32__PAGE_SIZE 65536
+#define WUFFS_WASM32__ALIGN 16
+
+typedef struct wuffs_wasm32__block_struct {
+  size_t size;  // Including this header.
+  struct wuffs_wasm32__block_struct* next;
+  uint8_t padding[WUFFS_WASM32__ALIGN - sizeof(size_t) - sizeof(void*)];
+} wuffs_wasm32__block;
+
+extern uint8_t __heap_base;
+
+static uint8_t* wuffs_wasm32__heap_ptr = NULL;
+static uint8_t* wuffs_wasm32__heap_end = NULL;
+static wuffs_wasm32__block* wuffs_wasm32__free_list = NULL;
+
+void* wuffs_wasm32__alloc(size_t n) {
+  if (n > (SIZE_MAX / 2)) {
+    return NULL;
+  }
+  size_t size = sizeof(wuffs_wasm32__block) +
+                ((n + WUFFS_WASM32__ALIGN - 1) & ~(WUFFS_WASM32__ALIGN - 1));
+
+  wuffs_wasm32__block** p = &wuffs_wasm32__free_list;
+  for (; *p; p = &(*p)->next) {
+    if ((*p)->size >= size) {
+      wuffs_wasm32__block* b = *p;
+      *p = b->next;
+      b->next = NULL;
+      memset(b + 1, 0, b->size - sizeof(wuffs_wasm32__block));
+      return b + 1;
+    }
+  }
+
+  if (!wuffs_wasm32__heap_ptr) {
+    uintptr_t base = (uintptr_t)(&__heap_base);
+    base = (base + WUFFS_WASM32__ALIGN - 1) & ~(WUFFS_WASM32__ALIGN - 1);
+    wuffs_wasm32__heap_ptr = (uint8_t*)base;
+    wuffs_wasm32__heap_end = (uint8_t*)(__builtin_wasm_memory_size(0) *
+                                        WUFFS_WASM32__PAGE_SIZE);
+  }
+  size_t avail = (size_t)(wuffs_wasm32__heap_end - wuffs_wasm32__heap_ptr);
+  if (avail < size) {
+    size_t pages =
+        (size - avail + WUFFS_WASM32__PAGE_SIZE - 1) / WUFFS_WASM32__PAGE_SIZE;
+    if (__builtin_wasm_memory_grow(0, pages) == ((size_t)-1)) {
+      return NULL;
+    }
+    wuffs_wasm32__heap_end += pages * WUFFS_WASM32__PAGE_SIZE;
+  }
+  // Fresh memory from the host is already zeroed.
+  wuffs_wasm32__block* b = (wuffs_wasm32__block*)wuffs_wasm32__heap_ptr;
+  wuffs_wasm32__heap_ptr += size;
+  b->size = size;
+  b->next = NULL;
+  return b + 1;
+}
+
+void wuffs_wasm32__free(void* ptr) {
+  if (ptr) {
+    wuffs_wasm32__block* b = ((wuffs_wasm32__block*)ptr) - 1;
+    b->next = wuffs_wasm32__free_list;
+    wuffs_wasm32__free_list = b;
+  }
+}
+
+// ---------------- Layout Checks
+
+// The JavaScript wrappers' IOBuffer class assumes these offsets.
+
+typedef struct {
+  wuffs_base__io_buffer buf;
+  wuffs_base__io_reader handle;
+} wuffs_wasm32__io_buffer_and_handle;
+
+typedef char wuffs_wasm32__layout_check
+    [((sizeof(wuffs_wasm32__io_buffer_and_handle) == 32) &&
+      (offsetof(wuffs_base__io_buffer, ptr) == 0) &&
+      (offsetof(wuffs_base__io_buffer, len) == 4) &&
+      (offsetof(wuffs_base__io_buffer, wi) == 8) &&
+      (offsetof(wuffs_base__io_buffer, ri) == 12) &&
+      (offsetof(wuffs_base__io_buffer, closed) == 16) &&
+      (offsetof(wuffs_wasm32__io_buffer_and_handle, handle) == 20) &&
+      (sizeof(wuffs_base__io_writer) == sizeof(wuffs_base__io_reader)))
+         ? 1
+         : -1];
+
+// ---------------- Images
+
+size_t wuffs_wasm32__sizeof_image_config(void) {
+  return sizeof(wuffs_base__image_config);
+}
+
+size_t wuffs_wasm32__sizeof_image_buffer(void) {
+  return sizeof(wuffs_base__image_buffer);
+}
+
+bool wuffs_wasm32__image_config__is_valid(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__is_valid(c);
+}
+
+uint32_t wuffs_wasm32__image_config__pixel_format(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__pixel_format(c);
+}
+
+uint32_t wuffs_wasm32__image_config__width(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__width(c);
+}
+
+uint32_t wuffs_wasm32__image_config__height(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__height(c);
+}
+
+uint32_t wuffs_wasm32__image_config__num_loops(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__num_loops(c);
+}
+
+size_t wuffs_wasm32__image_config__pixbuf_size(wuffs_base__image_config* c) {
+  return wuffs_base__image_config__pixbuf_size(c);
+}
+
+void wuffs_wasm32__image_buffer__set_from_slice(wuffs_base__image_buffer* b,
+                                                wuffs_base__image_config* c,
+                                                uint8_t* ptr,
+                                                size_t len) {
+  if (c) {
+    wuffs_base__image_buffer__set_from_slice(
+        b, *c, ((wuffs_base__slice_u8){.ptr = ptr, .len = len}));
+  }
+}
+
+bool wuffs_wasm32__image_buffer__loop(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__loop(b);
+}
+
+void wuffs_wasm32__image_buffer__dirty_rect(wuffs_base__image_buffer* b,
+                                            wuffs_base__rect_ie_u32* dst) {
+  if (dst) {
+    *dst = wuffs_base__image_buffer__dirty_rect(b);
+  }
+}
+
+int64_t wuffs_wasm32__image_buffer__duration(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__duration(b);
+}
+
+bool wuffs_wasm32__image_buffer__blend(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__blend(b);
+}
+
+uint8_t wuffs_wasm32__image_buffer__disposal(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__disposal(b);
+}
+
+bool wuffs_wasm32__image_buffer__palette_changed(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__palette_changed(b);
+}
+
+uint8_t* wuffs_wasm32__image_buffer__palette(wuffs_base__image_buffer* b) {
+  return wuffs_base__image_buffer__palette(b).ptr;
+}
+`
@@ -0,0 +1,432 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+var errNoSuchBuiltin = errors.New("gogen: internal error: no such built-in")
+
+func (g *gen) writeBuiltinCall(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	if n.Operator() != t.IDOpenParen {
+		return errNoSuchBuiltin
+	}
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+
+	switch recvTyp.Decorator() {
+	case 0:
+		// No-op.
+	case t.IDPtr:
+		if recvTyp.Inner().Decorator() != 0 || recvTyp.Inner().QID()[0] != t.IDBase {
+			return errNoSuchBuiltin
+		}
+		return g.writeBuiltinMethod(b, recv, method.Ident(), n.Args(), rp, depth)
+	case t.IDSlice:
+		return g.writeBuiltinSlice(b, recv, method.Ident(), n.Args(), rp, depth)
+	case t.IDTable:
+		return g.writeBuiltinTable(b, recv, method.Ident(), n.Args(), rp, depth)
+	default:
+		return errNoSuchBuiltin
+	}
+
+	qid := recvTyp.QID()
+	if qid[0] != t.IDBase {
+		return errNoSuchBuiltin
+	}
+	rp &^= statementContext
+
+	if qid[1].IsNumType() {
+		return g.writeBuiltinNumType(b, recv, method.Ident(), n.Args(), rp, depth)
+	}
+	switch qid[1] {
+	case t.IDIOReader, t.IDIOWriter:
+		return g.writeBuiltinIO(b, recv, method.Ident(), n.Args(), rp, depth, n.BoundsCheckOptimized())
+	case t.IDRectIEU32:
+		if len(n.Args()) == 0 {
+			// A getter, such as min_inclusive_x, is a struct field access.
+			if err := g.writeExpr(b, recv, rp, depth); err != nil {
+				return err
+			}
+			b.printf(".%s", goName(method.Ident().Str(g.tm), true))
+			return nil
+		}
+	case t.IDStatus:
+		return g.writeBuiltinStatus(b, recv, method.Ident(), n.Args(), rp, depth)
+	}
+	return g.writeBuiltinMethod(b, recv, method.Ident(), n.Args(), rp, depth)
+}
+
+// writeBuiltinMethod writes a call to the lib/go/base method of the same
+// (camel-cased) name, such as "ImageConfig.Initialize" or
+// "RectIEU32.SetMinInclusiveX".
+func (g *gen) writeBuiltinMethod(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	b.printf(".%s(", goName(method.Str(g.tm), true))
+	return g.writeArgs(b, args, rp, depth)
+}
+
+// TODO: remove bcoHack.
+func (g *gen) writeBuiltinIO(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32, bcoHack bool) error {
+	switch method {
+	case t.IDSet:
+		if recv.Operator() != 0 {
+			return fmt.Errorf("TODO: gogen a %q expression", recv.Str(g.tm))
+		}
+		name := recv.Ident().Str(g.tm)
+		b.printf("%s%s.Set(&%s%s, ", vPrefix, name, uPrefix, name)
+		return g.writeArgs(b, args, rp, depth)
+
+	case t.IDCopyFromHistory32:
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		if bcoHack {
+			b.writes(".CopyFromHistory32Fast(")
+		} else {
+			b.writes(".CopyFromHistory32(")
+		}
+		return g.writeArgs(b, args, rp, depth)
+
+	case t.IDCopyFromReader32:
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".CopyFromReader32(&")
+		return g.writeArgs(b, args, rp, depth)
+
+	case t.IDAvailable, t.IDCopyFromSlice, t.IDCopyFromSlice32,
+		t.IDSetLimit, t.IDSetMark, t.IDSinceMark:
+		return g.writeBuiltinMethod(b, recv, method, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinNumType(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	if iBits := intBits(recv.MType().QID()); iBits != 0 {
+		switch method {
+		case t.IDMax, t.IDMin:
+			fn := "Max"
+			if method == t.IDMin {
+				fn = "Min"
+			}
+			b.printf("base.I%d%s(", iBits, fn)
+			if err := g.writeExpr(b, recv, rp, depth); err != nil {
+				return err
+			}
+			b.writes(", ")
+			return g.writeArgs(b, args, rp, depth)
+		}
+		return errNoSuchBuiltin
+	}
+
+	uBits := uintBits(recv.MType().QID())
+	if uBits == 0 {
+		return fmt.Errorf("unsupported receiver type %q", recv.MType().Str(g.tm))
+	}
+
+	switch method {
+	case t.IDLowBits:
+		// "recv.low_bits(n:etc)" in Go is "((recv) & ((uintN(1) << (n)) - 1))".
+		b.writes("((")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(") & ((uint%d(1) << (", uBits)
+		if err := g.writeExpr(b, args[0].Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(")) - 1))")
+		return nil
+
+	case t.IDHighBits:
+		// "recv.high_bits(n:etc)" in Go is "((recv) >> (N - (n)))".
+		b.writes("((")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(") >> (%d - (", uBits)
+		if err := g.writeExpr(b, args[0].Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(")))")
+		return nil
+
+	case t.IDMax, t.IDMin:
+		fn := "Max"
+		if method == t.IDMin {
+			fn = "Min"
+		}
+		b.printf("base.U%d%s(", uBits, fn)
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		return g.writeArgs(b, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinSlice(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	switch method {
+	case t.IDCopyFromSlice:
+		// TODO: don't assume that the slice is a slice of base.u8.
+		statement := rp&statementContext != 0
+		rp &^= statementContext
+		if !statement {
+			b.writes("uint64(")
+		}
+		b.writes("copy(")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		if err := g.writeArgs(b, args, rp, depth); err != nil {
+			return err
+		}
+		if !statement {
+			b.writes(")")
+		}
+		return nil
+
+	case t.IDLength:
+		b.writes("uint64(len(")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes("))")
+		return nil
+
+	case t.IDPrefix, t.IDSuffix:
+		// TODO: don't assume that the slice is a slice of base.u8.
+		if method == t.IDPrefix {
+			b.writes("base.SliceU8Prefix(")
+		} else {
+			b.writes("base.SliceU8Suffix(")
+		}
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		return g.writeArgs(b, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinStatus(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	switch method {
+	case t.IDIsError:
+		b.writes("base.IsError(")
+	case t.IDIsOK:
+		b.writes("(")
+	case t.IDIsSuspension:
+		b.writes("base.IsSuspension(")
+	default:
+		return fmt.Errorf("gogen: internal error for writeBuiltinStatus")
+	}
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	if method == t.IDIsOK {
+		b.writes(" == nil")
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeBuiltinTable(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	rp &^= statementContext
+	field := ""
+
+	switch method {
+	case t.IDHeight:
+		field = "Height"
+	case t.IDStride:
+		field = "Stride"
+	case t.IDWidth:
+		field = "Width"
+
+	case t.IDRow:
+		// TODO: don't assume that the table is a table of base.u8.
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".Row(")
+		return g.writeArgs(b, args, rp, depth)
+	}
+
+	if field != "" {
+		b.writes("uint64(")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(".%s)", field)
+		return nil
+	}
+
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeArgs(b *buffer, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	for i, o := range args {
+		if i > 0 {
+			b.writes(", ")
+		}
+		if err := g.writeExpr(b, o.Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+	}
+	b.writes(")")
+	return nil
+}
+
+func (g *gen) writeBuiltinCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
+	// TODO: also handle (or reject??) t.IDTry.
+	if n.Operator() != t.IDOpenParen {
+		return errNoSuchBuiltin
+	}
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+	if !recvTyp.IsIOType() {
+		return errNoSuchBuiltin
+	}
+	recvName := buffer(nil)
+	if err := g.writeExpr(&recvName, recv, replaceNothing, depth); err != nil {
+		return err
+	}
+
+	if recvTyp.QID()[1] == t.IDIOReader {
+		switch method.Ident() {
+		case t.IDUnreadU8:
+			g.currFunk.hasGotoExit = true
+			b.printf("if status = %s.UnreadU8(); status != nil {\ngoto exit\n}\n", recvName)
+			return nil
+
+		case t.IDReadU8:
+			typ := buffer(nil)
+			if err := g.writeGoTypeName(&typ, n.MType()); err != nil {
+				return err
+			}
+			temp, err := g.newTemp(string(typ))
+			if err != nil {
+				return err
+			}
+			if n.ProvenNotToSuspend() {
+				b.printf("%s%d = %s.ReadU8Fast()\n", tPrefix, temp, recvName)
+				return nil
+			}
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("if %s%d, status = %s.ReadU8(); status != nil {\ngoto suspend\n}\n",
+				tPrefix, temp, recvName)
+			return nil
+
+		case t.IDReadU16BE, t.IDReadU16LE, t.IDReadU24BE, t.IDReadU24LE,
+			t.IDReadU32BE, t.IDReadU32LE, t.IDReadU40BE, t.IDReadU40LE,
+			t.IDReadU48BE, t.IDReadU48LE, t.IDReadU56BE, t.IDReadU56LE,
+			t.IDReadU64BE, t.IDReadU64LE:
+			typ := buffer(nil)
+			if err := g.writeGoTypeName(&typ, n.MType()); err != nil {
+				return err
+			}
+			temp, err := g.newTemp(string(typ))
+			if err != nil {
+				return err
+			}
+			// A partially complete read is held in the coroutine's scratch
+			// space, so that resuming continues where it left off.
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = 0\n", scratchName)
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			// For example, "read_u32le" becomes "ReadU32LE".
+			goMethod := goName(method.Ident().Str(g.tm), true)
+			goMethod = goMethod[:len(goMethod)-2] + strings.ToUpper(goMethod[len(goMethod)-2:])
+			b.printf("if %s%d, status = %s.%s(&%s); status != nil {\ngoto suspend\n}\n",
+				tPrefix, temp, recvName, goMethod, scratchName)
+			return nil
+
+		case t.IDSkip32, t.IDSkip64:
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = uint64(", scratchName)
+			x := n.Args()[0].Arg().Value()
+			if err := g.writeExpr(b, x, replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			b.writes(")\n")
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("if status = %s.Skip(&%s); status != nil {\ngoto suspend\n}\n", recvName, scratchName)
+			return nil
+		}
+
+	} else {
+		switch method.Ident() {
+		case t.IDWriteU8:
+			x := buffer(nil)
+			if err := g.writeExpr(&x, n.Args()[0].Arg().Value(), replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			if n.ProvenNotToSuspend() {
+				b.printf("%s.WriteU8Fast(%s)\n", recvName, x)
+				return nil
+			}
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("if status = %s.WriteU8(%s); status != nil {\ngoto suspend\n}\n", recvName, x)
+			return nil
+
+		case t.IDWriteU16BE, t.IDWriteU16LE, t.IDWriteU24BE, t.IDWriteU24LE,
+			t.IDWriteU32BE, t.IDWriteU32LE, t.IDWriteU40BE, t.IDWriteU40LE,
+			t.IDWriteU48BE, t.IDWriteU48LE, t.IDWriteU56BE, t.IDWriteU56LE,
+			t.IDWriteU64BE, t.IDWriteU64LE:
+			x := buffer(nil)
+			if err := g.writeExpr(&x, n.Args()[0].Arg().Value(), replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			// The number of bytes written so far is held in the coroutine's
+			// scratch space, so that resuming continues where it left off.
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = 0\n", scratchName)
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			// For example, "write_u32le" becomes "WriteU32LE".
+			goMethod := goName(method.Ident().Str(g.tm), true)
+			goMethod = goMethod[:len(goMethod)-2] + strings.ToUpper(goMethod[len(goMethod)-2:])
+			b.printf("if status = %s.%s(&%s, %s); status != nil {\ngoto suspend\n}\n",
+				recvName, goMethod, scratchName, x)
+			return nil
+		}
+	}
+	return errNoSuchBuiltin
+}
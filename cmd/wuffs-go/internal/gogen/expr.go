@@ -0,0 +1,443 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogen
+
+import (
+	"fmt"
+
+	"github.com/google/wuffs/lang/builtin"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+type replacementPolicy uint32
+
+const (
+	replaceNothing          = replacementPolicy(0)
+	replaceCallSuspendibles = replacementPolicy(1)
+
+	// statementContext means that the expression is the whole of an
+	// expression statement. Some Go expressions, such as conversions, are
+	// not valid statements.
+	statementContext = replacementPolicy(2)
+)
+
+func (g *gen) writeExpr(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	if depth > a.MaxExprDepth {
+		return fmt.Errorf("expression recursion depth too large")
+	}
+	depth++
+
+	if rp&replaceCallSuspendibles != 0 && n.CallSuspendible() {
+		if g.currFunk.tempR >= g.currFunk.tempW {
+			return fmt.Errorf("internal error: temporary variable count out of sync")
+		}
+		b.printf("%s%d", tPrefix, g.currFunk.tempR)
+		g.currFunk.tempR++
+		return nil
+	}
+
+	if cv := n.ConstValue(); cv != nil {
+		if !n.MType().IsBool() {
+			b.writes(cv.String())
+		} else if cv.Cmp(zero) == 0 {
+			b.writes("false")
+		} else if cv.Cmp(one) == 0 {
+			b.writes("true")
+		} else {
+			return fmt.Errorf("%v has type bool but constant value %v is neither 0 or 1", n.Str(g.tm), cv)
+		}
+		return nil
+	}
+
+	switch op := n.Operator(); {
+	case op.IsXUnaryOp():
+		return g.writeExprUnaryOp(b, n, rp&^statementContext, depth)
+	case op.IsXBinaryOp():
+		return g.writeExprBinaryOp(b, n, rp&^statementContext, depth)
+	case op.IsXAssociativeOp():
+		return g.writeExprAssociativeOp(b, n, rp&^statementContext, depth)
+	}
+	return g.writeExprOther(b, n, rp, depth)
+}
+
+func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	switch n.Operator() {
+	case 0:
+		if id1 := n.Ident(); id1 == t.IDThis {
+			b.writes("self")
+		} else if n.GlobalIdent() {
+			b.writes(g.constName(t.QID{0, id1}))
+		} else {
+			b.writes(vPrefix)
+			b.writes(id1.Str(g.tm))
+		}
+		return nil
+
+	case t.IDOpenParen:
+		// n is a function call.
+		if err := g.writeBuiltinCall(b, n, rp, depth); err != errNoSuchBuiltin {
+			return err
+		}
+
+		if n.LHS().Expr().Ident() == t.IDReset {
+			if rp&statementContext == 0 {
+				return fmt.Errorf("TODO: a reset method call %q as an expression", n.Str(g.tm))
+			}
+			method := n.LHS().Expr()
+			recv := method.LHS().Expr()
+			recvTyp := recv.MType()
+			if recvTyp.Decorator() == t.IDPtr {
+				recvTyp = recvTyp.Inner()
+			}
+			if recvTyp.Decorator() != 0 {
+				return fmt.Errorf("cannot generate reset method call %q for receiver type %q",
+					n.Str(g.tm), recv.MType().Str(g.tm))
+			}
+			// Assigning the zero value resets the struct, including its
+			// coroutine state.
+			if err := g.writeExpr(b, recv, rp&^statementContext, depth); err != nil {
+				return err
+			}
+			b.printf(" = %s{}", g.structName(recvTyp.QID()))
+			return nil
+		}
+
+		return g.writeExprUserDefinedCall(b, n, rp&^statementContext, depth)
+
+	case t.IDOpenBracket:
+		// n is an index.
+		if err := g.writeExpr(b, n.LHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writeb('[')
+		if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writeb(']')
+		return nil
+
+	case t.IDColon:
+		// n is a slice.
+		if err := g.writeExpr(b, n.LHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writeb('[')
+		if mhs := n.MHS().Expr(); mhs != nil {
+			if err := g.writeExpr(b, mhs, rp, depth); err != nil {
+				return err
+			}
+		}
+		b.writeb(':')
+		if rhs := n.RHS().Expr(); rhs != nil {
+			if err := g.writeExpr(b, rhs, rp, depth); err != nil {
+				return err
+			}
+		}
+		b.writeb(']')
+		return nil
+
+	case t.IDDot:
+		lhs := n.LHS().Expr()
+		if lhs.Ident() == t.IDIn {
+			b.writes(aPrefix)
+			b.writes(n.Ident().Str(g.tm))
+			return nil
+		}
+
+		if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+			return err
+		}
+		b.writes("." + fPrefix)
+		b.writes(n.Ident().Str(g.tm))
+		return nil
+
+	case t.IDError, t.IDStatus, t.IDSuspension:
+		status := g.statusMap[n.StatusQID()]
+		if status.name == "" {
+			msg, _ := t.Unescape(n.Ident().Str(g.tm))
+			z := builtin.StatusMap[msg]
+			if z.Message == "" {
+				return fmt.Errorf("no status code for %q", msg)
+			}
+			status.name = builtinStatusName(z)
+		}
+		b.writes(status.name)
+		return nil
+	}
+	return fmt.Errorf("unrecognized token (0x%X) for writeExprOther", n.Operator())
+}
+
+func (g *gen) writeExprUnaryOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	opName := goOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writes(opName)
+	return g.writeExpr(b, n.RHS().Expr(), rp, depth)
+}
+
+func (g *gen) writeExprBinaryOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	switch op {
+	case t.IDXBinaryTildeSatPlus, t.IDXBinaryTildeSatMinus:
+		uBits := uintBits(n.MType().QID())
+		if uBits == 0 {
+			return fmt.Errorf("unsupported tilde-operator type %q", n.MType().Str(g.tm))
+		}
+		uOp := "Add"
+		if op != t.IDXBinaryTildeSatPlus {
+			uOp = "Sub"
+		}
+		b.printf("base.U%dSat%s(", uBits, uOp)
+		if err := g.writeExpr(b, n.LHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writeb(')')
+		return nil
+
+	case t.IDXBinaryAs:
+		return g.writeExprAs(b, n.LHS().Expr(), n.RHS().TypeExpr(), rp, depth)
+	}
+
+	opName := goOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writeb('(')
+	lhs := n.LHS().Expr()
+	if (op == t.IDXBinaryShiftL || op == t.IDXBinaryShiftR) &&
+		(lhs.ConstValue() != nil) && !n.MType().IsIdeal() {
+		// In Go, the untyped constant on the left of a non-constant shift
+		// takes its type from the context, which might not be n's type.
+		if err := g.writeGoTypeName(b, n.MType()); err != nil {
+			return err
+		}
+		b.writeb('(')
+		if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+			return err
+		}
+		b.writeb(')')
+	} else if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+		return err
+	}
+	b.writes(opName)
+	if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
+		return err
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprAs(b *buffer, lhs *a.Expr, rhs *a.TypeExpr, rp replacementPolicy, depth uint32) error {
+	if err := g.writeGoTypeName(b, rhs); err != nil {
+		return err
+	}
+	b.writeb('(')
+	if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+		return err
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprAssociativeOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	opName := goOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writeb('(')
+	for i, o := range n.Args() {
+		if i != 0 {
+			b.writes(opName)
+		}
+		if err := g.writeExpr(b, o.Expr(), rp, depth); err != nil {
+			return err
+		}
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprUserDefinedCall(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+	if recvTyp.Decorator() == t.IDPtr {
+		recvTyp = recvTyp.Inner()
+	}
+	if recvTyp.Decorator() != 0 {
+		return fmt.Errorf("cannot generate user-defined method call %q for receiver type %q",
+			n.Str(g.tm), recv.MType().Str(g.tm))
+	}
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	b.printf(".%s(", g.methodGoName(recvTyp.QID(), method.Ident()))
+	return g.writeArgs(b, n.Args(), rp, depth)
+}
+
+func (g *gen) writeGoTypeName(b *buffer, n *a.TypeExpr) error {
+	switch n.Decorator() {
+	case 0:
+		// No-op.
+	case t.IDPtr:
+		b.writeb('*')
+		return g.writeGoTypeName(b, n.Inner())
+	case t.IDArray:
+		b.printf("[%v]", n.ArrayLength().ConstValue())
+		return g.writeGoTypeName(b, n.Inner())
+	case t.IDSlice, t.IDTable:
+		// TODO: fix this, allow slices of all types, not just of base.u8's.
+		o := n.Inner()
+		if o.Decorator() != 0 || o.QID() != (t.QID{t.IDBase, t.IDU8}) || o.IsRefined() {
+			return fmt.Errorf("cannot convert Wuffs type %q to Go", n.Str(g.tm))
+		}
+		if n.Decorator() == t.IDSlice {
+			b.writes("[]byte")
+		} else {
+			b.writes("base.TableU8")
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot convert Wuffs type %q to Go", n.Str(g.tm))
+	}
+
+	if qid := n.QID(); qid[0] == t.IDBase {
+		if key := qid[1]; key < t.ID(len(goTypeNames)) {
+			if s := goTypeNames[key]; s != "" {
+				b.writes(s)
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot convert Wuffs type %q to Go", n.Str(g.tm))
+	}
+	b.writes(g.structName(n.QID()))
+	return nil
+}
+
+func (g *gen) writeZeroValue(b *buffer, n *a.TypeExpr) error {
+	switch n.Decorator() {
+	case t.IDPtr, t.IDSlice:
+		b.writes("nil")
+		return nil
+	case t.IDArray, t.IDTable:
+		// No-op.
+	default:
+		switch n.QID() {
+		case t.QID{t.IDBase, t.IDBool}:
+			b.writes("false")
+			return nil
+		case t.QID{t.IDBase, t.IDStatus}:
+			b.writes("nil")
+			return nil
+		}
+		if n.IsNumType() {
+			b.writes("0")
+			return nil
+		}
+	}
+	if err := g.writeGoTypeName(b, n); err != nil {
+		return err
+	}
+	b.writes("{}")
+	return nil
+}
+
+var goTypeNames = [...]string{
+	t.IDI8:          "int8",
+	t.IDI16:         "int16",
+	t.IDI32:         "int32",
+	t.IDI64:         "int64",
+	t.IDU8:          "uint8",
+	t.IDU16:         "uint16",
+	t.IDU32:         "uint32",
+	t.IDU64:         "uint64",
+	t.IDBool:        "bool",
+	t.IDEmptyStruct: "struct{}",
+	t.IDRectIEU32:   "base.RectIEU32",
+	t.IDRectIIU32:   "base.RectIIU32",
+	t.IDImageBuffer: "base.ImageBuffer",
+	t.IDImageConfig: "base.ImageConfig",
+	t.IDIOReader:    "base.IOReader",
+	t.IDIOWriter:    "base.IOWriter",
+	t.IDStatus:      "error",
+}
+
+func goOpName(x t.ID) string {
+	if x < t.ID(len(goOpNames)) {
+		return goOpNames[x]
+	}
+	return ""
+}
+
+var goOpNames = [...]string{
+	t.IDEq:              " = ",
+	t.IDPlusEq:          " += ",
+	t.IDMinusEq:         " -= ",
+	t.IDStarEq:          " *= ",
+	t.IDSlashEq:         " /= ",
+	t.IDShiftLEq:        " <<= ",
+	t.IDShiftREq:        " >>= ",
+	t.IDAmpEq:           " &= ",
+	t.IDPipeEq:          " |= ",
+	t.IDHatEq:           " ^= ",
+	t.IDPercentEq:       " %= ",
+	t.IDTildeModPlusEq:  " += ",
+	t.IDTildeModMinusEq: " -= ",
+
+	t.IDXUnaryPlus:  "+",
+	t.IDXUnaryMinus: "-",
+	t.IDXUnaryNot:   "!",
+
+	t.IDXBinaryPlus:          " + ",
+	t.IDXBinaryMinus:         " - ",
+	t.IDXBinaryStar:          " * ",
+	t.IDXBinarySlash:         " / ",
+	t.IDXBinaryShiftL:        " << ",
+	t.IDXBinaryShiftR:        " >> ",
+	t.IDXBinaryAmp:           " & ",
+	t.IDXBinaryPipe:          " | ",
+	t.IDXBinaryHat:           " ^ ",
+	t.IDXBinaryPercent:       " % ",
+	t.IDXBinaryTildeModPlus:  " + ",
+	t.IDXBinaryTildeModMinus: " - ",
+	t.IDXBinaryNotEq:         " != ",
+	t.IDXBinaryLessThan:      " < ",
+	t.IDXBinaryLessEq:        " <= ",
+	t.IDXBinaryEqEq:          " == ",
+	t.IDXBinaryGreaterEq:     " >= ",
+	t.IDXBinaryGreaterThan:   " > ",
+	t.IDXBinaryAnd:           " && ",
+	t.IDXBinaryOr:            " || ",
+
+	t.IDXAssociativePlus: " + ",
+	t.IDXAssociativeStar: " * ",
+	t.IDXAssociativeAmp:  " & ",
+	t.IDXAssociativePipe: " | ",
+	t.IDXAssociativeHat:  " ^ ",
+	t.IDXAssociativeAnd:  " && ",
+	t.IDXAssociativeOr:   " || ",
+}
@@ -0,0 +1,416 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogen
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+type funk struct {
+	bHeader buffer
+	bBody   buffer
+	bFooter buffer
+
+	astFunc       *a.Func
+	goName        string
+	jumpTargets   map[a.Loop]uint32
+	coroSuspPoint uint32
+	ioBinds       uint32
+	tempW         uint32
+	tempR         uint32
+	tempTypes     []string
+	public        bool
+	suspendible   bool
+	usesScratch   bool
+	hasGotoOK     bool
+	hasGotoExit   bool
+
+	// pointMark is the offset, in the buffer of hoisted suspendible calls for
+	// the current statement, of the code after the most recent coroutine
+	// suspension point. See writeCoroSuspPoint.
+	pointMark int
+
+	// hasPoints is whether a previous pass over this function found any
+	// coroutine suspension points. pointRanges, from that previous pass, maps
+	// statements (and if-conditions) to the inclusive range of the suspension
+	// points that they contain. newPointRanges is being built by this pass.
+	hasPoints      bool
+	pointRanges    map[*a.Node][2]uint32
+	newPointRanges map[*a.Node][2]uint32
+}
+
+func (k *funk) jumpTarget(n a.Loop) (uint32, error) {
+	if k.jumpTargets == nil {
+		k.jumpTargets = map[a.Loop]uint32{}
+	}
+	if jt, ok := k.jumpTargets[n]; ok {
+		return jt, nil
+	}
+	jt := uint32(len(k.jumpTargets))
+	if jt == 1000000 {
+		return 0, fmt.Errorf("too many jump targets")
+	}
+	k.jumpTargets[n] = jt
+	return jt, nil
+}
+
+func (k *funk) coroName(g *gen) string {
+	return "self." + cPrefix + k.astFunc.FuncName().Str(g.tm)
+}
+
+func (g *gen) funcGoName(n *a.Func) string {
+	return goName(n.FuncName().Str(g.tm), n.Public())
+}
+
+// methodGoName returns the Go name of the method called funcName whose
+// receiver has the given type. Methods of other packages' structs are always
+// public.
+func (g *gen) methodGoName(recv t.QID, funcName t.ID) string {
+	if recv[0] != 0 {
+		return goName(funcName.Str(g.tm), true)
+	}
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc {
+				continue
+			}
+			if f := tld.Func(); f.Receiver() == recv && f.FuncName() == funcName {
+				return g.funcGoName(f)
+			}
+		}
+	}
+	return goName(funcName.Str(g.tm), true)
+}
+
+func (g *gen) receiverIsSuspendible(n *a.Func) bool {
+	s := g.structMap[n.Receiver()]
+	return s != nil && s.Suspendible()
+}
+
+func (g *gen) writeFuncSignature(b *buffer, n *a.Func) error {
+	b.writes("func ")
+	if r := n.Receiver(); !r.IsZero() {
+		b.printf("(self *%s) ", g.structName(r))
+	}
+	b.writes(g.funcGoName(n))
+	b.writeb('(')
+	for i, o := range n.In().Fields() {
+		if i > 0 {
+			b.writes(", ")
+		}
+		o := o.Field()
+		b.printf("%s%s ", aPrefix, o.Name().Str(g.tm))
+		if err := g.writeGoTypeName(b, o.XType()); err != nil {
+			return err
+		}
+	}
+	b.writes(") ")
+
+	// TODO: write n's return values.
+	if n.Suspendible() {
+		b.writes("(status error) ")
+	} else if outFields := n.Out().Fields(); len(outFields) == 0 {
+		// No-op.
+	} else if len(outFields) == 1 {
+		if err := g.writeGoTypeName(b, outFields[0].Field().XType()); err != nil {
+			return err
+		}
+		b.writeb(' ')
+	} else {
+		return fmt.Errorf("TODO: multiple return values")
+	}
+	return nil
+}
+
+func (g *gen) writeFuncImpl(b *buffer, n *a.Func) error {
+	k := g.funks[n.QQID()]
+
+	b.printf("// -------- func %s\n\n", n.QQID().Str(g.tm))
+	if err := g.writeFuncSignature(b, n); err != nil {
+		return err
+	}
+	b.writes("{\n")
+	b.writex(k.bHeader)
+	b.writex(k.bBody)
+	b.writex(k.bFooter)
+	b.writes("}\n\n")
+	return nil
+}
+
+func (g *gen) gatherFuncImpl(_ *buffer, n *a.Func) error {
+	if n.Receiver().IsZero() {
+		return fmt.Errorf("TODO: free-standing functions")
+	}
+
+	// Generate the function twice. The first pass numbers the coroutine
+	// suspension points, so that the second pass knows which statements can
+	// be resumed into.
+	pointRanges := map[*a.Node][2]uint32(nil)
+	hasPoints := false
+	for pass := 0; pass < 2; pass++ {
+		g.currFunk = funk{
+			astFunc:        n,
+			goName:         g.funcGoName(n),
+			public:         n.Public(),
+			suspendible:    n.Suspendible(),
+			hasPoints:      hasPoints,
+			pointRanges:    pointRanges,
+			newPointRanges: map[*a.Node][2]uint32{},
+		}
+
+		if err := g.writeFuncImplBody(&g.currFunk.bBody); err != nil {
+			return err
+		}
+		if err := g.writeFuncImplFooter(&g.currFunk.bFooter); err != nil {
+			return err
+		}
+		if err := g.writeFuncImplHeader(&g.currFunk.bHeader); err != nil {
+			return err
+		}
+
+		if g.currFunk.tempW != g.currFunk.tempR {
+			return fmt.Errorf("internal error: temporary variable count out of sync")
+		}
+		hasPoints = g.currFunk.coroSuspPoint > 0
+		pointRanges = g.currFunk.newPointRanges
+	}
+	g.funks[n.QQID()] = g.currFunk
+	return nil
+}
+
+func (g *gen) writeFuncImplHeader(b *buffer) error {
+	n := g.currFunk.astFunc
+	outFields := n.Out().Fields()
+	zeroReturn := "return"
+	if len(outFields) == 1 {
+		zero := buffer(nil)
+		if err := g.writeZeroValue(&zero, outFields[0].Field().XType()); err != nil {
+			return err
+		}
+		zeroReturn = "return " + string(zero)
+	} else if len(outFields) > 1 {
+		return fmt.Errorf("TODO: multiple return values")
+	}
+
+	// Check the previous status.
+	if g.currFunk.public && g.receiverIsSuspendible(n) {
+		b.writes("if base.IsError(self.status) {\n")
+		if g.currFunk.suspendible {
+			b.writes("return self.status\n")
+		} else {
+			b.printf("%s\n", zeroReturn)
+		}
+		b.writes("}\n")
+	}
+
+	// For public functions, check (at runtime) the other args for bounds and
+	// nil-ness. For private functions, those checks are done at compile time.
+	if g.currFunk.public {
+		if err := g.writeFuncImplArgChecks(b, n, zeroReturn); err != nil {
+			return err
+		}
+		for _, o := range n.In().Fields() {
+			if o := o.Field(); o.XType().Decorator() == 0 && o.XType().IsIOType() {
+				b.printf("%s%s.Derive()\n", aPrefix, o.Name().Str(g.tm))
+			}
+		}
+	}
+	b.writes("\n")
+
+	// Generate the local variables.
+	decls := buffer(nil)
+	if err := g.writeVars(&decls, n.Body(), false); err != nil {
+		return err
+	}
+	for i, typ := range g.currFunk.tempTypes {
+		decls.printf("%s%d %s\n", tPrefix, i, typ)
+	}
+	if len(decls) > 0 {
+		b.writes("var (\n")
+		b.writex(decls)
+		b.writes(")\n")
+		if err := g.writeUnusedVars(b); err != nil {
+			return err
+		}
+		b.writes("\n")
+	}
+
+	if g.currFunk.suspendible && g.currFunk.hasPoints {
+		coroName := g.currFunk.coroName(g)
+		b.printf("r := %s.coroSuspPoint\n", coroName)
+		b.writes("csp := uint32(0)\n")
+		restore := buffer(nil)
+		if err := g.writeResumeSuspend(&restore, n.Body(), false); err != nil {
+			return err
+		}
+		if len(restore) > 0 {
+			b.writes("if r != 0 {\n")
+			b.writex(restore)
+			b.writes("}\n")
+		}
+		b.writes("\n")
+	}
+	return nil
+}
+
+// writeUnusedVars writes "_ = v_x" for every local variable that is assigned
+// to but never read, as the Go compiler rejects such variables.
+func (g *gen) writeUnusedVars(b *buffer) error {
+	body := append(append(buffer(nil), g.currFunk.bBody...), g.currFunk.bFooter...)
+	return g.visitVars(b, g.currFunk.astFunc.Body(), 0, func(g *gen, b *buffer, n *a.Var) error {
+		if n.IterateVariable() {
+			return nil
+		}
+		names := []string{vPrefix + n.Name().Str(g.tm)}
+		if n.XType().IsIOType() {
+			names = append(names, uPrefix+n.Name().Str(g.tm))
+		}
+		for _, name := range names {
+			all := regexp.MustCompile(`\b`+name+`\b`).FindAllIndex(body, -1)
+			assigns := regexp.MustCompile(`(?m)^`+name+` = `).FindAllIndex(body, -1)
+			if len(all) <= len(assigns) {
+				b.printf("_ = %s\n", name)
+			}
+		}
+		return nil
+	})
+}
+
+func (g *gen) writeFuncImplBody(b *buffer) error {
+	return g.writeBlock(b, g.currFunk.astFunc.Body(), 0)
+}
+
+func (g *gen) writeFuncImplFooter(b *buffer) error {
+	if !g.currFunk.suspendible {
+		return nil
+	}
+	if g.currFunk.hasPoints {
+		// We've reached the end of the function body. Reset the coroutine
+		// suspension point so that the next call to this function starts at
+		// the top.
+		coroName := g.currFunk.coroName(g)
+		b.writes("\n")
+		if g.currFunk.hasGotoOK {
+			b.writes("ok:\n")
+		}
+		b.printf("%s.coroSuspPoint = 0\n", coroName)
+		b.writes("goto exit\n\n")
+
+		b.writes("suspend:\n")
+		b.printf("%s.coroSuspPoint = csp\n", coroName)
+		if err := g.writeResumeSuspend(b, g.currFunk.astFunc.Body(), true); err != nil {
+			return err
+		}
+		b.writes("\n")
+		g.currFunk.hasGotoExit = true
+	}
+
+	if g.currFunk.hasGotoExit {
+		b.writes("exit:\n")
+	}
+	if g.currFunk.public {
+		b.writes("self.status = status\n")
+	}
+	b.writes("return status\n")
+	return nil
+}
+
+func (g *gen) writeFuncImplArgChecks(b *buffer, n *a.Func, zeroReturn string) error {
+	checks := []string(nil)
+
+	for _, o := range n.In().Fields() {
+		o := o.Field()
+		oTyp := o.XType()
+		if oTyp.Decorator() != t.IDPtr && !oTyp.IsRefined() {
+			// TODO: Also check elements, for array-typed arguments.
+			continue
+		}
+
+		switch {
+		case oTyp.Decorator() == t.IDPtr:
+			checks = append(checks, fmt.Sprintf("%s%s == nil", aPrefix, o.Name().Str(g.tm)))
+
+		case oTyp.IsRefined():
+			bounds := [2]*big.Int{}
+			for i, bound := range oTyp.Bounds() {
+				if bound != nil {
+					if cv := bound.ConstValue(); cv != nil {
+						bounds[i] = cv
+					}
+				}
+			}
+			if qid := oTyp.QID(); qid[0] == t.IDBase {
+				if key := qid[1]; key < t.ID(len(numTypeBounds)) {
+					ntb := numTypeBounds[key]
+					for i := 0; i < 2; i++ {
+						if bounds[i] != nil && ntb[i] != nil && bounds[i].Cmp(ntb[i]) == 0 {
+							bounds[i] = nil
+							continue
+						}
+					}
+				}
+			}
+			for i, bound := range bounds {
+				if bound != nil {
+					op := '<'
+					if i != 0 {
+						op = '>'
+					}
+					checks = append(checks, fmt.Sprintf("%s%s %c %s", aPrefix, o.Name().Str(g.tm), op, bound))
+				}
+			}
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	b.writes("if ")
+	for i, c := range checks {
+		if i != 0 {
+			b.writes(" || ")
+		}
+		b.writes(c)
+	}
+	b.writes(" {\n")
+	if g.receiverIsSuspendible(n) {
+		b.writes("self.status = base.ErrBadArgument\n")
+	}
+	if g.currFunk.suspendible {
+		b.writes("return base.ErrBadArgument\n")
+	} else {
+		b.printf("%s\n", zeroReturn)
+	}
+	b.writes("}\n")
+	return nil
+}
+
+var numTypeBounds = [...][2]*big.Int{
+	t.IDI8:   {big.NewInt(-1 << 7), big.NewInt(1<<7 - 1)},
+	t.IDI16:  {big.NewInt(-1 << 15), big.NewInt(1<<15 - 1)},
+	t.IDI32:  {big.NewInt(-1 << 31), big.NewInt(1<<31 - 1)},
+	t.IDI64:  {big.NewInt(-1 << 63), big.NewInt(1<<63 - 1)},
+	t.IDU8:   {zero, big.NewInt(0).SetUint64(1<<8 - 1)},
+	t.IDU16:  {zero, big.NewInt(0).SetUint64(1<<16 - 1)},
+	t.IDU32:  {zero, big.NewInt(0).SetUint64(1<<32 - 1)},
+	t.IDU64:  {zero, big.NewInt(0).SetUint64(1<<64 - 1)},
+	t.IDBool: {zero, one},
+}
@@ -0,0 +1,549 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gogen transpiles Wuffs programs to Go programs.
+//
+// The generated Go code depends on the runtime support library in
+// lib/go/base, the Go equivalent of the C code's base-header.h and
+// base-impl.h.
+package gogen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"math/big"
+	"os"
+	"path"
+
+	"github.com/google/wuffs/lang/builtin"
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+var (
+	zero = big.NewInt(0)
+	one  = big.NewInt(1)
+)
+
+// Prefixes are prepended to names to form a namespace and to avoid e.g.
+// "type" being a valid Wuffs variable name but not a valid Go one.
+const (
+	aPrefix = "a_" // Function argument.
+	cPrefix = "c_" // Coroutine state.
+	fPrefix = "f_" // Struct field.
+	iPrefix = "i_" // Iterate variable.
+	oPrefix = "o_" // Temporary io_bind variable.
+	tPrefix = "t_" // Temporary local variable.
+	uPrefix = "u_" // Derived from a local variable.
+	vPrefix = "v_" // Local variable.
+)
+
+const (
+	baseImportPath = "github.com/google/wuffs/lib/go/base"
+	genImportPath  = "github.com/google/wuffs/gen/go/"
+)
+
+// Do transpiles a Wuffs program to a Go program.
+//
+// The arguments list the source Wuffs files. If no arguments are given, it
+// reads from stdin.
+//
+// The generated program is written to stdout.
+func Do(args []string) error {
+	flags := flag.FlagSet{}
+	return generate.Do(&flags, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		g := &gen{
+			pkgName: pkgName,
+			tm:      tm,
+			checker: c,
+			files:   files,
+		}
+		unformatted, err := g.generate()
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(unformatted)
+		if err != nil {
+			os.Stderr.Write(unformatted)
+			return nil, fmt.Errorf("wuffs-go: could not format the generated code: %v", err)
+		}
+		return formatted, nil
+	})
+}
+
+type visibility uint32
+
+const (
+	bothPubPri = visibility(iota)
+	pubOnly
+	priOnly
+)
+
+const (
+	maxIOBinds        = 100
+	maxIOBindInFields = 100
+	maxTemp           = 10000
+)
+
+type status struct {
+	name    string
+	msg     string
+	keyword t.ID
+}
+
+type buffer []byte
+
+func (b *buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func (b *buffer) printf(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+func (b *buffer) writeb(x byte)                             { *b = append(*b, x) }
+func (b *buffer) writes(s string)                           { *b = append(*b, s...) }
+func (b *buffer) writex(s []byte)                           { *b = append(*b, s...) }
+
+type gen struct {
+	pkgName string // e.g. "jpeg"
+
+	tm      *t.Map
+	checker *check.Checker
+	files   []*a.File
+
+	statusList []status
+	statusMap  map[t.QID]status
+	structList []*a.Struct
+	structMap  map[t.QID]*a.Struct
+	usesList   []string
+	usesMap    map[string]struct{}
+
+	currFunk funk
+	funks    map[t.QQID]funk
+}
+
+func (g *gen) generate() ([]byte, error) {
+	b := new(buffer)
+
+	g.statusMap = map[t.QID]status{}
+	if err := g.forEachStatus(b, bothPubPri, (*gen).gatherStatuses); err != nil {
+		return nil, err
+	}
+
+	// Make a topologically sorted list of structs.
+	unsortedStructs := []*a.Struct(nil)
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() == a.KStruct {
+				unsortedStructs = append(unsortedStructs, tld.Struct())
+			}
+		}
+	}
+	var ok bool
+	g.structList, ok = a.TopologicalSortStructs(unsortedStructs)
+	if !ok {
+		return nil, fmt.Errorf("cyclical struct definitions")
+	}
+	g.structMap = map[t.QID]*a.Struct{}
+	for _, n := range g.structList {
+		g.structMap[n.QID()] = n
+	}
+
+	g.funks = map[t.QQID]funk{}
+	if err := g.forEachFunc(nil, bothPubPri, (*gen).gatherFuncImpl); err != nil {
+		return nil, err
+	}
+
+	// Generate the body first, so that we know whether the base package is
+	// used, before generating the imports.
+	body := new(buffer)
+	if err := g.genBody(body); err != nil {
+		return nil, err
+	}
+
+	b.printf("// Code generated by wuffs-go. DO NOT EDIT.\n\n")
+	b.printf("package %s\n\n", g.pkgName)
+	if err := g.forEachUse(b, (*gen).gatherUse); err != nil {
+		return nil, err
+	}
+	b.writes("import (\n")
+	if bytes.Contains(*body, []byte("base.")) {
+		b.printf("%q\n", baseImportPath)
+	}
+	if len(g.usesList) > 0 {
+		b.writes("\n")
+		for _, u := range g.usesList {
+			b.printf("%q\n", genImportPath+u)
+		}
+	}
+	b.writes(")\n\n")
+	b.writex(*body)
+	return *b, nil
+}
+
+func (g *gen) genBody(b *buffer) error {
+	if len(g.statusList) > 0 {
+		b.writes("// ---------------- Status Codes\n\n")
+		b.writes("var (\n")
+		for _, s := range g.statusList {
+			constructor := "NewSuspension"
+			if s.keyword == t.IDError {
+				constructor = "NewError"
+			}
+			b.printf("%s = base.%s(%q)\n", s.name, constructor, g.pkgName+": "+s.msg)
+		}
+		b.writes(")\n\n")
+	}
+
+	b.writes("// ---------------- Public Consts\n\n")
+	if err := g.forEachConst(b, pubOnly, (*gen).writeConst); err != nil {
+		return err
+	}
+
+	b.writes("// ---------------- Structs\n\n")
+	for _, n := range g.structList {
+		if err := g.writeStruct(b, n); err != nil {
+			return err
+		}
+	}
+
+	b.writes("// ---------------- Private Consts\n\n")
+	if err := g.forEachConst(b, priOnly, (*gen).writeConst); err != nil {
+		return err
+	}
+
+	b.writes("// ---------------- Function Implementations\n\n")
+	return g.forEachFunc(b, bothPubPri, (*gen).writeFuncImpl)
+}
+
+func (g *gen) forEachConst(b *buffer, v visibility, f func(*gen, *buffer, *a.Const) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KConst ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Const()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachFunc(b *buffer, v visibility, f func(*gen, *buffer, *a.Func) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Func()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachStatus(b *buffer, v visibility, f func(*gen, *buffer, *a.Status) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStatus ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Status()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachUse(b *buffer, f func(*gen, *buffer, *a.Use) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KUse {
+				continue
+			}
+			if err := f(g, b, tld.Use()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goName converts a Wuffs name such as "decode_frame" or a status message
+// such as "bad Huffman code (over-subscribed)" to a Go identifier such as
+// "DecodeFrame" or "BadHuffmanCodeOverSubscribed". If public is false, the
+// first letter is lower-cased, so that the identifier is unexported.
+func goName(name string, public bool) string {
+	s := []byte(nil)
+	upper := true
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			if upper && ('a' <= c && c <= 'z') {
+				c -= 'a' - 'A'
+			}
+			s = append(s, c)
+			upper = false
+		} else {
+			upper = true
+		}
+	}
+	if !public && len(s) > 0 {
+		if c := s[0]; 'A' <= c && c <= 'Z' {
+			// Lower-case the whole leading acronym, such as the "TODO" in
+			// "TODOUnsupportedInterlacing", but not the "U".
+			i := 1
+			for ; i < len(s) && 'A' <= s[i] && s[i] <= 'Z'; i++ {
+			}
+			if i > 1 && i < len(s) {
+				i--
+			}
+			for j := 0; j < i; j++ {
+				s[j] += 'a' - 'A'
+			}
+		}
+	}
+	return string(s)
+}
+
+func statusName(keyword t.ID, msg string, public bool) string {
+	prefix := "suspension "
+	if keyword == t.IDError {
+		prefix = "err "
+	}
+	return goName(prefix+msg, public)
+}
+
+func intBits(qid t.QID) uint32 {
+	if qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDI8:
+			return 8
+		case t.IDI16:
+			return 16
+		case t.IDI32:
+			return 32
+		case t.IDI64:
+			return 64
+		}
+	}
+	return 0
+}
+
+func uintBits(qid t.QID) uint32 {
+	if qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDU8:
+			return 8
+		case t.IDU16:
+			return 16
+		case t.IDU32:
+			return 32
+		case t.IDU64:
+			return 64
+		}
+	}
+	return 0
+}
+
+func (g *gen) gatherStatuses(b *buffer, n *a.Status) error {
+	raw := n.QID()[1].Str(g.tm)
+	msg, ok := t.Unescape(raw)
+	if !ok {
+		return fmt.Errorf("bad status message %q", raw)
+	}
+	s := status{
+		name:    statusName(n.Keyword(), msg, n.Public()),
+		msg:     msg,
+		keyword: n.Keyword(),
+	}
+	g.statusList = append(g.statusList, s)
+	g.statusMap[n.QID()] = s
+	return nil
+}
+
+func (g *gen) gatherUse(b *buffer, n *a.Use) error {
+	useDirname := g.tm.ByID(n.Path())
+	useDirname, _ = t.Unescape(useDirname)
+
+	if g.usesMap == nil {
+		g.usesMap = map[string]struct{}{}
+	} else if _, ok := g.usesMap[useDirname]; ok {
+		return nil
+	}
+	g.usesList = append(g.usesList, useDirname)
+	g.usesMap[useDirname] = struct{}{}
+	return nil
+}
+
+func (g *gen) constName(n t.QID) string {
+	if c := g.constDecl(n); c != nil && c.Public() {
+		return goName(n[1].Str(g.tm), true)
+	}
+	return n[1].Str(g.tm)
+}
+
+func (g *gen) constDecl(qid t.QID) *a.Const {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() == a.KConst && tld.Const().QID()[1] == qid[1] {
+				return tld.Const()
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) writeConst(b *buffer, n *a.Const) error {
+	name := g.constName(n.QID())
+	if n.XType().IsArrayType() {
+		// Go does not have constant arrays, so use a variable instead.
+		b.printf("var %s = ", name)
+		if err := g.writeConstList(b, n.XType(), n.Value()); err != nil {
+			return err
+		}
+	} else {
+		b.printf("const %s ", name)
+		if err := g.writeGoTypeName(b, n.XType()); err != nil {
+			return err
+		}
+		b.writes(" = ")
+		if err := g.writeConstList(b, n.XType(), n.Value()); err != nil {
+			return err
+		}
+	}
+	b.writes("\n\n")
+	return nil
+}
+
+func (g *gen) writeConstList(b *buffer, typ *a.TypeExpr, n *a.Expr) error {
+	if n.Operator() == t.IDDollar {
+		if err := g.writeGoTypeName(b, typ); err != nil {
+			return err
+		}
+		b.writes("{\n")
+		for _, o := range n.Args() {
+			if err := g.writeConstList(b, typ.Inner(), o.Expr()); err != nil {
+				return err
+			}
+			b.writes(",\n")
+		}
+		b.writeb('}')
+	} else if cv := n.ConstValue(); cv != nil {
+		if typ.IsBool() {
+			b.writes(fmt.Sprint(cv.Cmp(zero) != 0))
+		} else if typ.IsUnsignedInteger() || typ.IsRefined() {
+			b.printf("0x%X", cv)
+		} else {
+			b.writes(cv.String())
+		}
+	} else {
+		return fmt.Errorf("invalid const value %q", n.Str(g.tm))
+	}
+	return nil
+}
+
+func (g *gen) structName(qid t.QID) string {
+	name := qid[1].Str(g.tm)
+	if qid[0] != 0 && qid[0] != t.IDBase {
+		// A struct from a used package must be public.
+		return g.tm.ByID(qid[0]) + "." + goName(name, true)
+	}
+	if n := g.structMap[qid]; n != nil && !n.Public() {
+		return goName(name, false)
+	}
+	return goName(name, true)
+}
+
+func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
+	structName := g.structName(n.QID())
+	if n.Public() {
+		b.printf("// %s holds the state of a %s.%s. Its zero value is ready to use.\n",
+			structName, g.pkgName, n.QID()[1].Str(g.tm))
+	}
+	b.printf("type %s struct {\n", structName)
+	if n.Suspendible() {
+		b.writes("status error\n\n")
+	}
+
+	for _, o := range n.Fields() {
+		o := o.Field()
+		b.printf("%s%s ", fPrefix, o.Name().Str(g.tm))
+		if err := g.writeGoTypeName(b, o.XType()); err != nil {
+			return err
+		}
+		b.writeb('\n')
+	}
+
+	if n.Suspendible() {
+		for _, file := range g.files {
+			for _, tld := range file.TopLevelDecls() {
+				if tld.Kind() != a.KFunc {
+					continue
+				}
+				o := tld.Func()
+				if o.Receiver() != n.QID() || !o.Suspendible() {
+					continue
+				}
+				k := g.funks[o.QQID()]
+				if k.coroSuspPoint == 0 && !k.usesScratch {
+					continue
+				}
+				b.printf("\n%s%s struct {\n", cPrefix, o.FuncName().Str(g.tm))
+				if k.coroSuspPoint != 0 {
+					b.writes("coroSuspPoint uint32\n")
+					if err := g.writeVars(b, o.Body(), true); err != nil {
+						return err
+					}
+				}
+				if k.usesScratch {
+					b.writes("scratch uint64\n")
+				}
+				b.writes("}\n")
+			}
+		}
+	}
+
+	b.writes("}\n\n")
+	return nil
+}
+
+// builtinStatusName returns the lib/go/base name of a built-in status, such
+// as "base.ErrBadArgument", or "nil" for the "ok" status.
+func builtinStatusName(z builtin.Status) string {
+	if z.Keyword == 0 {
+		return "nil"
+	}
+	return "base." + statusName(z.Keyword, z.Message, true)
+}
+
+func (g *gen) packageName(qid t.QID) string {
+	if qid[0] != 0 && qid[0] != t.IDBase {
+		return path.Base(g.tm.ByID(qid[0]))
+	}
+	return ""
+}
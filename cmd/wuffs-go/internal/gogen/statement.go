@@ -0,0 +1,702 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogen
+
+// Go has no equivalent of C's switch-into-the-middle-of-a-loop trick (Duff's
+// device), which the C code uses to implement coroutines. Instead, the Go
+// code guards every statement of a resumable block by whether it is being
+// resumed into, based on the "r" variable, the coroutine suspension point to
+// resume at, or zero if not resuming:
+//
+//	if r == 0 {
+//		// Statements without any suspension points.
+//	}
+//	if r == 0 || (3 <= r && r <= 5) {
+//		// A statement containing suspension points 3, 4 and 5.
+//	}
+//
+// Reaching a suspension point while resuming sets r to zero, and execution
+// carries on from there as normal.
+
+import (
+	"fmt"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// blockRange returns the inclusive range of the coroutine suspension points
+// in block, as numbered by the previous pass.
+func (g *gen) blockRange(block []*a.Node) (rng [2]uint32, ok bool) {
+	for _, o := range block {
+		if r, rOK := g.currFunk.pointRanges[o]; rOK {
+			if !ok {
+				rng, ok = r, true
+			} else {
+				if rng[0] > r[0] {
+					rng[0] = r[0]
+				}
+				if rng[1] < r[1] {
+					rng[1] = r[1]
+				}
+			}
+		}
+	}
+	return rng, ok
+}
+
+func rangeCondition(rng [2]uint32) string {
+	if rng[0] == rng[1] {
+		return fmt.Sprintf("r == %d", rng[0])
+	}
+	return fmt.Sprintf("(%d <= r && r <= %d)", rng[0], rng[1])
+}
+
+func (g *gen) writeBlock(b *buffer, block []*a.Node, depth uint32) error {
+	if _, resumable := g.blockRange(block); !resumable {
+		for _, o := range block {
+			if err := g.writeStatement(b, o, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	inGroup := false
+	for _, o := range block {
+		if o.Kind() == a.KAssert {
+			continue
+		}
+		rng, ok := g.currFunk.pointRanges[o]
+		if !ok {
+			if !inGroup {
+				b.writes("if r == 0 {\n")
+				inGroup = true
+			}
+			if err := g.writeStatement(b, o, depth); err != nil {
+				return err
+			}
+			continue
+		}
+		if inGroup {
+			b.writes("}\n")
+			inGroup = false
+		}
+		b.printf("if r == 0 || %s {\n", rangeCondition(rng))
+		if err := g.writeStatement(b, o, depth); err != nil {
+			return err
+		}
+		b.writes("}\n")
+	}
+	if inGroup {
+		b.writes("}\n")
+	}
+	return nil
+}
+
+func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
+	if depth > a.MaxBodyDepth {
+		return fmt.Errorf("body recursion depth too large")
+	}
+	depth++
+
+	if n.Kind() == a.KAssert {
+		// Assertions only apply at compile-time.
+		return nil
+	}
+
+	lo := g.currFunk.coroSuspPoint + 1
+	if err := g.writeStatement1(b, n, depth); err != nil {
+		return err
+	}
+	if hi := g.currFunk.coroSuspPoint; lo <= hi {
+		g.currFunk.newPointRanges[n] = [2]uint32{lo, hi}
+	}
+	return nil
+}
+
+func (g *gen) writeStatement1(b *buffer, n *a.Node, depth uint32) error {
+	switch n.Kind() {
+	case a.KAssign:
+		return g.writeStatementAssign(b, n.Assign(), depth)
+	case a.KExpr:
+		return g.writeStatementExpr(b, n.Expr(), depth)
+	case a.KIOBind:
+		return g.writeStatementIOBind(b, n.IOBind(), depth)
+	case a.KIf:
+		return g.writeStatementIf(b, n.If(), depth)
+	case a.KIterate:
+		return g.writeStatementIterate(b, n.Iterate(), depth)
+	case a.KJump:
+		return g.writeStatementJump(b, n.Jump(), depth)
+	case a.KRet:
+		return g.writeStatementRet(b, n.Ret(), depth)
+	case a.KVar:
+		return g.writeStatementVar(b, n.Var(), depth)
+	case a.KWhile:
+		return g.writeStatementWhile(b, n.While(), depth)
+	}
+	return fmt.Errorf("unrecognized ast.Kind (%s) for writeStatement", n.Kind())
+}
+
+func (g *gen) writeStatementAssign(b *buffer, n *a.Assign, depth uint32) error {
+	if err := g.writeSuspendibles(b, n.LHS(), depth); err != nil {
+		return err
+	}
+	if err := g.writeSuspendibles(b, n.RHS(), depth); err != nil {
+		return err
+	}
+
+	op := n.Operator()
+	switch op {
+	case t.IDTildeSatPlusEq, t.IDTildeSatMinusEq:
+		uBits := uintBits(n.LHS().MType().QID())
+		if uBits == 0 {
+			return fmt.Errorf("unsupported tilde-operator type %q", n.LHS().MType().Str(g.tm))
+		}
+		uOp := "Add"
+		if op != t.IDTildeSatPlusEq {
+			uOp = "Sub"
+		}
+		lhs := buffer(nil)
+		if err := g.writeExpr(&lhs, n.LHS(), replaceCallSuspendibles, depth); err != nil {
+			return err
+		}
+		b.printf("%s = base.U%dSat%s(%s, ", lhs, uBits, uOp, lhs)
+		if err := g.writeExpr(b, n.RHS(), replaceCallSuspendibles, depth); err != nil {
+			return err
+		}
+		b.writes(")\n")
+		return nil
+	}
+
+	opName := goOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+	if err := g.writeExpr(b, n.LHS(), replaceCallSuspendibles, depth); err != nil {
+		return err
+	}
+	b.writes(opName)
+	if err := g.writeExpr(b, n.RHS(), replaceCallSuspendibles, depth); err != nil {
+		return err
+	}
+	b.writes("\n")
+	return nil
+}
+
+func (g *gen) writeStatementExpr(b *buffer, n *a.Expr, depth uint32) error {
+	if err := g.writeSuspendibles(b, n, depth); err != nil {
+		return err
+	}
+	if n.CallSuspendible() {
+		return nil
+	}
+	if err := g.writeExpr(b, n, replaceCallSuspendibles|statementContext, depth); err != nil {
+		return err
+	}
+	b.writes("\n")
+	return nil
+}
+
+func (g *gen) writeStatementIOBind(b *buffer, n *a.IOBind, depth uint32) error {
+	inFields := n.InFields()
+
+	if g.currFunk.ioBinds > maxIOBinds || len(inFields) > maxIOBindInFields {
+		return fmt.Errorf("too many temporary variables required")
+	}
+	ioBindNum := g.currFunk.ioBinds
+	g.currFunk.ioBinds++
+
+	if _, ok := g.blockRange(n.Body()); ok {
+		return fmt.Errorf("TODO: suspendible calls inside io_bind")
+	}
+
+	b.writes("{\n")
+	for i := 0; i < len(inFields); i++ {
+		e := inFields[i].Expr()
+		prefix := vPrefix
+		if e.Operator() != 0 {
+			prefix = aPrefix
+		}
+		name := e.Ident().Str(g.tm)
+		b.printf("%s%d_%s%s := %s%s\n", oPrefix, ioBindNum, prefix, name, prefix, name)
+	}
+
+	if err := g.writeBlock(b, n.Body(), depth); err != nil {
+		return err
+	}
+
+	for i := len(inFields) - 1; i >= 0; i-- {
+		e := inFields[i].Expr()
+		prefix := vPrefix
+		if e.Operator() != 0 {
+			prefix = aPrefix
+		}
+		name := e.Ident().Str(g.tm)
+		b.printf("%s%s = %s%d_%s%s\n", prefix, name, oPrefix, ioBindNum, prefix, name)
+	}
+	b.writes("}\n")
+	return nil
+}
+
+func (g *gen) writeStatementIf(b *buffer, n *a.If, depth uint32) error {
+	// If any part of the if-else chain can be resumed into, then every
+	// condition is only evaluated when not resuming, and a branch is entered
+	// (regardless of its condition) when resuming into that branch.
+	resumable := false
+	for o := n; o != nil; o = o.ElseIf() {
+		if _, ok := g.currFunk.pointRanges[o.Condition().Node()]; ok {
+			resumable = true
+		}
+		if _, ok := g.blockRange(o.BodyIfTrue()); ok {
+			resumable = true
+		}
+	}
+
+	nCloseCurly := 1
+	for first := true; ; first = false {
+		cond := n.Condition()
+		if cond.Suspendible() {
+			if !first {
+				b.writes("{\n")
+				const maxCloseCurly = 1000
+				if nCloseCurly == maxCloseCurly {
+					return fmt.Errorf("too many nested if's")
+				}
+				nCloseCurly++
+			}
+
+			lo := g.currFunk.coroSuspPoint + 1
+			hoisted := buffer(nil)
+			if err := g.writeSuspendibles(&hoisted, cond, depth); err != nil {
+				return err
+			}
+			if hi := g.currFunk.coroSuspPoint; lo <= hi {
+				g.currFunk.newPointRanges[cond.Node()] = [2]uint32{lo, hi}
+			}
+			if rng, ok := g.currFunk.pointRanges[cond.Node()]; ok {
+				b.printf("if r == 0 || %s {\n", rangeCondition(rng))
+				b.writex(hoisted)
+				b.writes("}\n")
+			} else if resumable {
+				b.writes("if r == 0 {\n")
+				b.writex(hoisted)
+				b.writes("}\n")
+			} else {
+				b.writex(hoisted)
+			}
+		}
+
+		condition := buffer(nil)
+		if err := g.writeExpr(&condition, cond, replaceCallSuspendibles, 0); err != nil {
+			return err
+		}
+		if !resumable {
+			b.printf("if %s {\n", trimParens(condition))
+		} else if rng, ok := g.blockRange(n.BodyIfTrue()); ok {
+			b.printf("if %s || (r == 0 && %s) {\n", rangeCondition(rng), condition)
+		} else {
+			b.printf("if r == 0 && %s {\n", condition)
+		}
+		if err := g.writeBlock(b, n.BodyIfTrue(), depth); err != nil {
+			return err
+		}
+		if bif := n.BodyIfFalse(); len(bif) > 0 {
+			b.writes("} else {\n")
+			if err := g.writeBlock(b, bif, depth); err != nil {
+				return err
+			}
+			break
+		}
+		n = n.ElseIf()
+		if n == nil {
+			break
+		}
+		b.writes("} else ")
+	}
+	for ; nCloseCurly > 0; nCloseCurly-- {
+		b.writes("}\n")
+	}
+	return nil
+}
+
+func (g *gen) writeStatementIterate(b *buffer, n *a.Iterate, depth uint32) error {
+	vars := n.Variables()
+	if len(vars) == 0 {
+		return nil
+	}
+	if len(vars) != 1 {
+		return fmt.Errorf("TODO: iterate over more than one variable")
+	}
+	if n.HasBreak() || n.HasContinue() {
+		return fmt.Errorf("TODO: break or continue inside iterate")
+	}
+	v := vars[0].Var()
+	name := v.Name().Str(g.tm)
+	b.writes("{\n")
+
+	// TODO: don't assume that the slice is a slice of base.u8.
+	b.printf("%sslice_%s := ", iPrefix, name)
+	if err := g.writeExpr(b, v.Value(), replaceCallSuspendibles, 0); err != nil {
+		return err
+	}
+	b.writes("\n")
+	b.printf("var %s%s []byte\n", vPrefix, name)
+	b.printf("%s%s := 0\n", iPrefix, name)
+
+	round := uint32(0)
+	for ; n != nil; n = n.ElseIterate() {
+		if _, ok := g.blockRange(n.Body()); ok {
+			return fmt.Errorf("TODO: suspendible calls inside iterate")
+		}
+		length := n.Length().SmallPowerOf2Value()
+		unroll := n.Unroll().SmallPowerOf2Value()
+		for {
+			if err := g.writeIterateRound(b, name, n.Body(), round, depth, length, unroll); err != nil {
+				return err
+			}
+			round++
+
+			if unroll == 1 {
+				break
+			}
+			unroll = 1
+		}
+	}
+
+	b.writes("}\n")
+	return nil
+}
+
+func (g *gen) writeIterateRound(b *buffer, name string, body []*a.Node, round uint32, depth uint32, length int, unroll int) error {
+	b.printf("%send%d_%s := (len(%sslice_%s) / %d) * %d\n",
+		iPrefix, round, name, iPrefix, name, length*unroll, length*unroll)
+	b.printf("for %s%s < %send%d_%s {\n", iPrefix, name, iPrefix, round, name)
+	for i := 0; i < unroll; i++ {
+		b.printf("%s%s = %sslice_%s[%s%s : %s%s+%d]\n",
+			vPrefix, name, iPrefix, name, iPrefix, name, iPrefix, name, length)
+		if err := g.writeBlock(b, body, depth); err != nil {
+			return err
+		}
+		b.printf("%s%s += %d\n", iPrefix, name, length)
+	}
+	b.writes("}\n")
+	return nil
+}
+
+func (g *gen) writeStatementJump(b *buffer, n *a.Jump, depth uint32) error {
+	jt, err := g.currFunk.jumpTarget(n.JumpTarget())
+	if err != nil {
+		return err
+	}
+	keyword := "continue"
+	if n.Keyword() == t.IDBreak {
+		keyword = "break"
+	}
+	b.printf("%s label_%d\n", keyword, jt)
+	return nil
+}
+
+func (g *gen) writeStatementRet(b *buffer, n *a.Ret, depth uint32) error {
+	retExpr := n.Value()
+
+	if g.currFunk.suspendible {
+		if retExpr != nil && retExpr.Suspendible() {
+			return fmt.Errorf("TODO: return a suspendible call")
+		}
+		b.writes("status = ")
+		retKeyword := t.IDStatus
+		if retExpr == nil {
+			b.writes("nil")
+		} else {
+			retKeyword = retExpr.Operator()
+			if err := g.writeExpr(b, retExpr, replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+		}
+		b.writes("\n")
+
+		if n.Keyword() == t.IDYield {
+			return g.writeYield(b, retKeyword)
+		}
+
+		switch retKeyword {
+		case t.IDError:
+			g.currFunk.hasGotoExit = true
+			b.writes("goto exit\n")
+		case t.IDStatus:
+			b.writes(g.gotoOK())
+		default:
+			g.currFunk.hasGotoExit = true
+			b.printf("if status == nil {\n%s}\n", g.gotoOK())
+			b.writes("if base.IsSuspension(status) {\nstatus = base.ErrCannotReturnASuspension\n}\n")
+			b.writes("goto exit\n")
+		}
+		return nil
+	}
+
+	b.writes("return")
+	if len(g.currFunk.astFunc.Out().Fields()) == 0 {
+		if retExpr != nil {
+			return fmt.Errorf("return expression %q incompatible with empty return type", retExpr.Str(g.tm))
+		}
+	} else if retExpr == nil {
+		// TODO: should a bare "return" imply "return out"?
+		return fmt.Errorf("empty return expression incompatible with non-empty return type")
+	} else {
+		b.writeb(' ')
+		if err := g.writeExpr(b, retExpr, replaceCallSuspendibles, depth); err != nil {
+			return err
+		}
+	}
+	b.writes("\n")
+	return nil
+}
+
+// gotoOK returns the code to finish the function call successfully. Without
+// any coroutine suspension points, there is no coroutine state to reset, and
+// the "ok" label is not needed.
+func (g *gen) gotoOK() string {
+	if g.currFunk.hasPoints {
+		g.currFunk.hasGotoOK = true
+		return "goto ok\n"
+	}
+	g.currFunk.hasGotoExit = true
+	return "goto exit\n"
+}
+
+// writeYield writes a coroutine suspension point that, unlike the others, is
+// reached after (not before) suspending: yielding a suspension suspends the
+// coroutine, and resuming carries on after the yield statement. Resuming also
+// clears the yielded status, so that it isn't mistaken for the resumed call's
+// result.
+func (g *gen) writeYield(b *buffer, retKeyword t.ID) error {
+	k, err := g.nextCoroSuspPoint()
+	if err != nil {
+		return err
+	}
+	b.printf("if r == %d {\nr = 0\nstatus = nil\n} else {\n", k)
+	switch retKeyword {
+	case t.IDError:
+		g.currFunk.hasGotoExit = true
+		b.writes("goto exit\n")
+	case t.IDSuspension:
+		b.printf("csp = %d\ngoto suspend\n", k)
+	default:
+		g.currFunk.hasGotoExit = true
+		b.writes("if base.IsError(status) {\ngoto exit\n}\n")
+		b.printf("if status != nil {\ncsp = %d\ngoto suspend\n}\n", k)
+	}
+	b.writes("}\n")
+	return nil
+}
+
+func (g *gen) writeStatementVar(b *buffer, n *a.Var, depth uint32) error {
+	if v := n.Value(); v != nil {
+		if err := g.writeSuspendibles(b, v, depth); err != nil {
+			return err
+		}
+	}
+	b.printf("%s%s = ", vPrefix, n.Name().Str(g.tm))
+	if v := n.Value(); v != nil {
+		if n.XType().IsArrayType() {
+			return fmt.Errorf("TODO: array initializers for non-zero default values")
+		}
+		if err := g.writeExpr(b, v, replaceCallSuspendibles, 0); err != nil {
+			return err
+		}
+	} else if err := g.writeZeroValue(b, n.XType()); err != nil {
+		return err
+	}
+	b.writes("\n")
+	return nil
+}
+
+func (g *gen) writeStatementWhile(b *buffer, n *a.While, depth uint32) error {
+	if n.Condition().Suspendible() {
+		return fmt.Errorf("TODO: suspendible calls inside a while condition")
+	}
+
+	if n.HasBreak() || n.HasContinue() {
+		jt, err := g.currFunk.jumpTarget(n)
+		if err != nil {
+			return err
+		}
+		b.printf("label_%d:\n", jt)
+	}
+
+	condition := buffer(nil)
+	if cv := n.Condition().ConstValue(); cv != nil && cv.Cmp(one) == 0 {
+		// No-op. A "while true" loop is a Go "for" loop without a condition.
+	} else if err := g.writeExpr(&condition, n.Condition(), replaceCallSuspendibles, 0); err != nil {
+		return err
+	}
+
+	_, resumable := g.blockRange(n.Body())
+	switch {
+	case len(condition) == 0:
+		b.writes("for {\n")
+	case resumable:
+		b.printf("for r != 0 || %s {\n", condition)
+	default:
+		b.printf("for %s {\n", trimParens(condition))
+	}
+	if err := g.writeBlock(b, n.Body(), depth); err != nil {
+		return err
+	}
+	b.writes("}\n")
+	return nil
+}
+
+// nextCoroSuspPoint allocates the next coroutine suspension point number.
+func (g *gen) nextCoroSuspPoint() (uint32, error) {
+	const maxCoroSuspPoint = 0xFFFFFFFF
+	g.currFunk.coroSuspPoint++
+	if g.currFunk.coroSuspPoint == maxCoroSuspPoint {
+		return 0, fmt.Errorf("too many coroutine suspension points required")
+	}
+	return g.currFunk.coroSuspPoint, nil
+}
+
+// writeCoroSuspPoint writes a coroutine suspension point. Resuming at that
+// point re-executes the (hoisted suspendible call) code that follows it, but
+// not the code since the previous point, which is wrapped in an "if r == 0"
+// block. b holds the hoisted code for the current statement.
+func (g *gen) writeCoroSuspPoint(b *buffer) error {
+	k, err := g.nextCoroSuspPoint()
+	if err != nil {
+		return err
+	}
+	if since := (*b)[g.currFunk.pointMark:]; len(since) > 0 {
+		wrapped := append(buffer("if r == 0 {\n"), since...)
+		wrapped = append(wrapped, "}\n"...)
+		*b = append((*b)[:g.currFunk.pointMark], wrapped...)
+	}
+	b.printf("if r == %d {\nr = 0\n}\ncsp = %d\n", k, k)
+	g.currFunk.pointMark = len(*b)
+	return nil
+}
+
+func (g *gen) writeSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
+	if !n.Suspendible() {
+		return nil
+	}
+	hoisted := buffer(nil)
+	g.currFunk.pointMark = 0
+	if err := g.writeCallSuspendibles(&hoisted, n, depth); err != nil {
+		return err
+	}
+	b.writex(hoisted)
+	return nil
+}
+
+// mightActuallySuspend is the absence of ProvenNotToSuspend. A "try" call
+// never suspends the caller, as its status is returned, not acted upon.
+func (g *gen) mightActuallySuspend(n *a.Expr) bool {
+	return n.CallSuspendible() && !n.ProvenNotToSuspend() && n.Operator() != t.IDTry
+}
+
+func (g *gen) writeCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
+	if depth > a.MaxExprDepth {
+		return fmt.Errorf("expression recursion depth too large")
+	}
+	depth++
+
+	// The evaluation order for suspendible calls (which can have side effects)
+	// is important here: LHS, MHS, RHS, Args and finally the node itself.
+	if !n.CallSuspendible() {
+		for _, o := range n.Node().Raw().SubNodes() {
+			if o != nil && o.Kind() == a.KExpr {
+				if err := g.writeCallSuspendibles(b, o.Expr(), depth); err != nil {
+					return err
+				}
+			}
+		}
+		for _, o := range n.Args() {
+			if o != nil && o.Kind() == a.KExpr {
+				if err := g.writeCallSuspendibles(b, o.Expr(), depth); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := g.writeBuiltinCallSuspendibles(b, n, depth); err != errNoSuchBuiltin {
+		return err
+	}
+
+	if n.Operator() == t.IDTry {
+		temp, err := g.newTemp("error")
+		if err != nil {
+			return err
+		}
+		b.printf("%s%d = ", tPrefix, temp)
+		if err := g.writeExprUserDefinedCall(b, n, replaceNothing, depth); err != nil {
+			return err
+		}
+		b.writes("\n")
+		return nil
+	}
+
+	label := "exit"
+	if g.mightActuallySuspend(n) {
+		if err := g.writeCoroSuspPoint(b); err != nil {
+			return err
+		}
+		label = "suspend"
+	} else {
+		g.currFunk.hasGotoExit = true
+	}
+	b.writes("if status = ")
+	if err := g.writeExprUserDefinedCall(b, n, replaceNothing, depth); err != nil {
+		return err
+	}
+	b.printf("; status != nil {\ngoto %s\n}\n", label)
+	return nil
+}
+
+// newTemp allocates a temporary variable, of the given Go type, that is read
+// by a later writeExpr call.
+func (g *gen) newTemp(goType string) (uint32, error) {
+	if g.currFunk.tempW > maxTemp {
+		return 0, fmt.Errorf("too many temporary variables required")
+	}
+	temp := g.currFunk.tempW
+	g.currFunk.tempW++
+	g.currFunk.tempTypes = append(g.currFunk.tempTypes, goType)
+	return temp, nil
+}
+
+func trimParens(b []byte) []byte {
+	if len(b) > 1 && b[0] == '(' && b[len(b)-1] == ')' {
+		// Only trim if the outer parentheses match each other.
+		depth := 0
+		for i, c := range b {
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 && i != len(b)-1 {
+					return b
+				}
+			}
+		}
+		return b[1 : len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+func doBench(args []string) error { return doBenchTest(args, true) }
+func doTest(args []string) error  { return doBenchTest(args, false) }
+
+func doBenchTest(args []string, bench bool) error {
+	flags := flag.FlagSet{}
+	focusFlag := flags.String("focus", cf.FocusDefault, cf.FocusUsage)
+	iterscaleFlag := flags.Int("iterscale", cf.IterscaleDefault, cf.IterscaleUsage)
+	mimicFlag := flags.Bool("mimic", cf.MimicDefault, cf.MimicUsage)
+	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !cf.IsAlphaNumericIsh(*focusFlag) {
+		return fmt.Errorf("bad -focus flag value %q", *focusFlag)
+	}
+	if *iterscaleFlag < cf.IterscaleMin || cf.IterscaleMax < *iterscaleFlag {
+		return fmt.Errorf("bad -iterscale flag value %d, outside the range [%d..%d]",
+			*iterscaleFlag, cf.IterscaleMin, cf.IterscaleMax)
+	}
+	if *repsFlag < cf.RepsMin || cf.RepsMax < *repsFlag {
+		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]",
+			*repsFlag, cf.RepsMin, cf.RepsMax)
+	}
+
+	args = flags.Args()
+
+	failed := false
+	for _, arg := range args {
+		f, err := doBenchTest1(arg, bench, *focusFlag, *iterscaleFlag, *mimicFlag, *repsFlag)
+		if err != nil {
+			return err
+		}
+		failed = failed || f
+	}
+	if failed {
+		s := "tests"
+		if bench {
+			s = "benchmarks"
+		}
+		return fmt.Errorf("%s: some %s failed", os.Args[0], s)
+	}
+	return nil
+}
+
+// doBenchTest1 runs "go test" on the dirname directory, such as
+// "test/go/std/gif", which holds that package's _test.go files.
+//
+// The Go tests always compare Wuffs' output with the Go standard library's,
+// as the latter is always available. The -mimic flag only affects which
+// benchmarks are run: those named BenchmarkWuffsEtc and, if mimic is true,
+// those named BenchmarkMimicEtc.
+func doBenchTest1(dirname string, bench bool, focus string, iterscale int, mimic bool, reps int) (failed bool, err error) {
+	if _, err := os.Stat(dirname); os.IsNotExist(err) {
+		// Not every package has Go tests.
+		return false, nil
+	}
+
+	goArgs := []string{"test"}
+	names := focusRegexp(focus)
+	if bench {
+		kinds := "Wuffs"
+		if mimic {
+			kinds = "(Wuffs|Mimic)"
+		}
+		goArgs = append(goArgs,
+			"-run=^$",
+			fmt.Sprintf("-bench=^Benchmark%s%s", kinds, names),
+			"-benchtime="+benchtime(iterscale),
+			"-count="+fmt.Sprint(reps),
+			"-cpu=1",
+		)
+		fmt.Printf("# %s\n# %s\n#\n", packageName(dirname), runtime.Version())
+	} else if names != "" {
+		goArgs = append(goArgs, fmt.Sprintf("-run=^Test%s", names))
+	}
+
+	cmd := exec.Command("go", goArgs...)
+	cmd.Dir = dirname
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err == nil {
+		// No-op.
+	} else if _, ok := err.(*exec.ExitError); ok {
+		return true, nil
+	} else {
+		return false, err
+	}
+	return false, nil
+}
+
+// focusRegexp converts a comma-separated list of test or benchmark name
+// prefixes to a regular expression that matches what follows the "TestFoo"
+// or "BenchmarkFoo" prefix of a Go test function's name.
+func focusRegexp(focus string) string {
+	alts := []string(nil)
+	for _, s := range strings.Split(focus, ",") {
+		s = strings.TrimPrefix(s, "Benchmark")
+		s = strings.TrimPrefix(s, "Test")
+		s = strings.TrimPrefix(s, "Wuffs")
+		s = strings.TrimPrefix(s, "Mimic")
+		if s != "" {
+			alts = append(alts, regexp.QuoteMeta(s))
+		}
+	}
+	if len(alts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(alts, "|") + ")"
+}
+
+// benchtime scales the Go benchmarks' default target running time of 1
+// second, in the same way that the C benchmarks scale their number of
+// iterations, with 100 being the default.
+func benchtime(iterscale int) string {
+	if iterscale <= 0 {
+		return "1x"
+	}
+	return fmt.Sprintf("%dms", 10*iterscale)
+}
+
+func packageName(dirname string) string {
+	dirname = filepath.ToSlash(dirname)
+	if i := strings.Index(dirname, "/test/go/"); i >= 0 {
+		return dirname[i+len("/test/go/"):]
+	}
+	return filepath.Base(dirname)
+}
@@ -0,0 +1,400 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsgen
+
+import (
+	"errors"
+	"fmt"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+var errNoSuchBuiltin = errors.New("rsgen: internal error: no such built-in")
+
+func (g *gen) writeBuiltinCall(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	if n.Operator() != t.IDOpenParen {
+		return errNoSuchBuiltin
+	}
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+
+	switch recvTyp.Decorator() {
+	case 0:
+		// No-op.
+	case t.IDPtr:
+		if recvTyp.Inner().Decorator() != 0 || recvTyp.Inner().QID()[0] != t.IDBase {
+			return errNoSuchBuiltin
+		}
+		return g.writeBuiltinMethod(b, recv, method.Ident(), n.Args(), rp&^statementContext, depth)
+	case t.IDSlice:
+		return g.writeBuiltinSlice(b, recv, method.Ident(), n.Args(), rp&^statementContext, depth)
+	case t.IDTable:
+		return g.writeBuiltinTable(b, recv, method.Ident(), n.Args(), rp&^statementContext, depth)
+	default:
+		return errNoSuchBuiltin
+	}
+
+	qid := recvTyp.QID()
+	if qid[0] != t.IDBase {
+		return errNoSuchBuiltin
+	}
+	rp &^= statementContext
+
+	if qid[1].IsNumType() {
+		return g.writeBuiltinNumType(b, recv, method.Ident(), n.Args(), rp, depth)
+	}
+	switch qid[1] {
+	case t.IDIOReader, t.IDIOWriter:
+		return g.writeBuiltinIO(b, recv, method.Ident(), n.Args(), rp, depth, n.BoundsCheckOptimized())
+	case t.IDRectIEU32:
+		if len(n.Args()) == 0 {
+			// A getter, such as min_inclusive_x, is a struct field access.
+			if err := g.writeExpr(b, recv, rp, depth); err != nil {
+				return err
+			}
+			b.printf(".%s", method.Ident().Str(g.tm))
+			return nil
+		}
+	case t.IDStatus:
+		return g.writeBuiltinStatus(b, recv, method.Ident(), n.Args(), rp, depth)
+	}
+	return g.writeBuiltinMethod(b, recv, method.Ident(), n.Args(), rp, depth)
+}
+
+// writeBuiltinMethod writes a call to the lib/rs/wuffs_base method of the
+// same name, such as "ImageConfig::initialize" or
+// "RectIeU32::set_min_inclusive_x".
+func (g *gen) writeBuiltinMethod(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	name := method.Str(g.tm)
+	if name == "palette" {
+		// ImageBuffer::palette returns a &[u8], for Rust callers. The
+		// generated code wants a wuffs_base::SliceU8.
+		name = "palette_slice"
+	}
+	b.printf(".%s(", methodName(name))
+	return g.writeArgs(b, args, rp, depth)
+}
+
+// TODO: remove bcoHack.
+func (g *gen) writeBuiltinIO(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32, bcoHack bool) error {
+	switch method {
+	case t.IDSet:
+		if recv.Operator() != 0 {
+			return fmt.Errorf("TODO: rsgen a %q expression", recv.Str(g.tm))
+		}
+		// The local variable u_foo, declared in the same scope as v_foo,
+		// outlives the v_foo reader or writer.
+		name := recv.Ident().Str(g.tm)
+		b.printf("unsafe { %s%s.set(&mut %s%s, ", vPrefix, name, uPrefix, name)
+		if err := g.writeArgs(b, args, rp, depth); err != nil {
+			return err
+		}
+		b.writes(" }")
+		return nil
+
+	case t.IDCopyFromHistory32:
+		if !bcoHack {
+			return g.writeBuiltinMethod(b, recv, method, args, rp, depth)
+		}
+		b.writes("unsafe { ")
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".copy_from_history32_fast(")
+		if err := g.writeArgs(b, args, rp, depth); err != nil {
+			return err
+		}
+		b.writes(" }")
+		return nil
+
+	case t.IDCopyFromReader32:
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".copy_from_reader32(&mut ")
+		return g.writeArgs(b, args, rp, depth)
+
+	case t.IDAvailable, t.IDCopyFromSlice, t.IDCopyFromSlice32,
+		t.IDSetLimit, t.IDSetMark, t.IDSinceMark:
+		return g.writeBuiltinMethod(b, recv, method, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinNumType(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	if iBits := intBits(recv.MType().QID()); iBits != 0 {
+		switch method {
+		case t.IDMax, t.IDMin:
+			fn := "max"
+			if method == t.IDMin {
+				fn = "min"
+			}
+			b.printf("i%d::%s(", iBits, fn)
+			if err := g.writeExpr(b, recv, rp, depth); err != nil {
+				return err
+			}
+			b.writes(", ")
+			return g.writeArgs(b, args, rp, depth)
+		}
+		return errNoSuchBuiltin
+	}
+
+	uBits := uintBits(recv.MType().QID())
+	if uBits == 0 {
+		return fmt.Errorf("unsupported receiver type %q", recv.MType().Str(g.tm))
+	}
+
+	// The low_bits and high_bits methods' n argument can equal the number of
+	// bits in the receiver's type, and shifting by that many bits overflows
+	// in Rust. checked_shr returns None, instead of overflowing, and None
+	// means a zero result.
+	switch method {
+	case t.IDLowBits:
+		// "recv.low_bits(n:etc)" in Rust is
+		// "(recv & uN::MAX.checked_shr(N - (n as u32)).unwrap_or(0))".
+		b.writeb('(')
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(" & u%d::MAX.checked_shr(%d - (", uBits, uBits)
+		if err := g.writeExpr(b, args[0].Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(" as u32)).unwrap_or(0))")
+		return nil
+
+	case t.IDHighBits:
+		// "recv.high_bits(n:etc)" in Rust is
+		// "uN::checked_shr(recv, N - (n as u32)).unwrap_or(0)".
+		b.printf("u%d::checked_shr(", uBits)
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(", %d - (", uBits)
+		if err := g.writeExpr(b, args[0].Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(" as u32)).unwrap_or(0)")
+		return nil
+
+	case t.IDMax, t.IDMin:
+		fn := "max"
+		if method == t.IDMin {
+			fn = "min"
+		}
+		b.printf("u%d::%s(", uBits, fn)
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		return g.writeArgs(b, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinSlice(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	switch method {
+	case t.IDCopyFromSlice, t.IDPrefix, t.IDSuffix:
+		// TODO: don't assume that the slice is a slice of base.u8.
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(".%s(", method.Str(g.tm))
+		return g.writeArgs(b, args, rp, depth)
+
+	case t.IDLength:
+		b.writeb('(')
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".len() as u64)")
+		return nil
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeBuiltinStatus(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	switch method {
+	case t.IDIsError, t.IDIsOK, t.IDIsSuspension:
+		// No-op.
+	default:
+		return fmt.Errorf("rsgen: internal error for writeBuiltinStatus")
+	}
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	b.printf(".%s()", method.Str(g.tm))
+	return nil
+}
+
+func (g *gen) writeBuiltinTable(b *buffer, recv *a.Expr, method t.ID, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	switch method {
+	case t.IDHeight, t.IDStride, t.IDWidth:
+		b.writeb('(')
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.printf(".%s as u64)", method.Str(g.tm))
+		return nil
+
+	case t.IDRow:
+		// TODO: don't assume that the table is a table of base.u8.
+		if err := g.writeExpr(b, recv, rp, depth); err != nil {
+			return err
+		}
+		b.writes(".row(")
+		return g.writeArgs(b, args, rp, depth)
+	}
+	return errNoSuchBuiltin
+}
+
+func (g *gen) writeArgs(b *buffer, args []*a.Node, rp replacementPolicy, depth uint32) error {
+	for i, o := range args {
+		if i > 0 {
+			b.writes(", ")
+		}
+		if err := g.writeExpr(b, o.Arg().Value(), rp, depth); err != nil {
+			return err
+		}
+	}
+	b.writes(")")
+	return nil
+}
+
+func (g *gen) writeBuiltinCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
+	// TODO: also handle (or reject??) t.IDTry.
+	if n.Operator() != t.IDOpenParen {
+		return errNoSuchBuiltin
+	}
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+	if !recvTyp.IsIOType() {
+		return errNoSuchBuiltin
+	}
+	recvName := buffer(nil)
+	if err := g.writeExpr(&recvName, recv, replaceNothing, depth); err != nil {
+		return err
+	}
+
+	if recvTyp.QID()[1] == t.IDIOReader {
+		switch method.Ident() {
+		case t.IDUnreadU8:
+			g.currFunk.hasGotoExit = true
+			b.printf("status = %s.unread_u8();\nif !status.is_ok() {\nbreak 'exit;\n}\n", recvName)
+			return nil
+
+		case t.IDReadU8:
+			temp, err := g.newTemp("u8", "0")
+			if err != nil {
+				return err
+			}
+			if n.ProvenNotToSuspend() {
+				b.printf("%s%d = unsafe { %s.read_u8_fast() };\n", tPrefix, temp, recvName)
+				return nil
+			}
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("status = %s.read_u8(&mut %s%d);\nif !status.is_ok() {\nbreak 'suspend;\n}\n",
+				recvName, tPrefix, temp)
+			return nil
+
+		case t.IDReadU16BE, t.IDReadU16LE, t.IDReadU24BE, t.IDReadU24LE,
+			t.IDReadU32BE, t.IDReadU32LE, t.IDReadU40BE, t.IDReadU40LE,
+			t.IDReadU48BE, t.IDReadU48LE, t.IDReadU56BE, t.IDReadU56LE,
+			t.IDReadU64BE, t.IDReadU64LE:
+			typ := buffer(nil)
+			if err := g.writeRsTypeName(&typ, n.MType()); err != nil {
+				return err
+			}
+			temp, err := g.newTemp(string(typ), "0")
+			if err != nil {
+				return err
+			}
+			// A partially complete read is held in the coroutine's scratch
+			// space, so that resuming continues where it left off.
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = 0;\n", scratchName)
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("status = %s.%s(&mut %s%d, &mut %s);\nif !status.is_ok() {\nbreak 'suspend;\n}\n",
+				recvName, method.Ident().Str(g.tm), tPrefix, temp, scratchName)
+			return nil
+
+		case t.IDSkip32, t.IDSkip64:
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = (", scratchName)
+			x := n.Args()[0].Arg().Value()
+			if err := g.writeExpr(b, x, replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			b.writes(" as u64);\n")
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("status = %s.skip(&mut %s);\nif !status.is_ok() {\nbreak 'suspend;\n}\n",
+				recvName, scratchName)
+			return nil
+		}
+
+	} else {
+		switch method.Ident() {
+		case t.IDWriteU8:
+			x := buffer(nil)
+			if err := g.writeExpr(&x, n.Args()[0].Arg().Value(), replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			if n.ProvenNotToSuspend() {
+				b.printf("unsafe { %s.write_u8_fast(%s) };\n", recvName, x)
+				return nil
+			}
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("status = %s.write_u8(%s);\nif !status.is_ok() {\nbreak 'suspend;\n}\n", recvName, x)
+			return nil
+
+		case t.IDWriteU16BE, t.IDWriteU16LE, t.IDWriteU24BE, t.IDWriteU24LE,
+			t.IDWriteU32BE, t.IDWriteU32LE, t.IDWriteU40BE, t.IDWriteU40LE,
+			t.IDWriteU48BE, t.IDWriteU48LE, t.IDWriteU56BE, t.IDWriteU56LE,
+			t.IDWriteU64BE, t.IDWriteU64LE:
+			x := buffer(nil)
+			if err := g.writeExpr(&x, n.Args()[0].Arg().Value(), replaceCallSuspendibles, depth); err != nil {
+				return err
+			}
+			// The number of bytes written so far is held in the coroutine's
+			// scratch space, so that resuming continues where it left off.
+			g.currFunk.usesScratch = true
+			scratchName := g.currFunk.coroName(g) + ".scratch"
+			b.printf("%s = 0;\n", scratchName)
+			if err := g.writeCoroSuspPoint(b); err != nil {
+				return err
+			}
+			b.printf("status = %s.%s(%s, &mut %s);\nif !status.is_ok() {\nbreak 'suspend;\n}\n",
+				recvName, method.Ident().Str(g.tm), x, scratchName)
+			return nil
+		}
+	}
+	return errNoSuchBuiltin
+}
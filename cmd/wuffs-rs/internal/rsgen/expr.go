@@ -0,0 +1,510 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsgen
+
+import (
+	"fmt"
+
+	"github.com/google/wuffs/lang/builtin"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+type replacementPolicy uint32
+
+const (
+	replaceNothing          = replacementPolicy(0)
+	replaceCallSuspendibles = replacementPolicy(1)
+
+	// statementContext means that the expression is the whole of an
+	// expression statement.
+	statementContext = replacementPolicy(2)
+
+	// lhsContext means that the expression is the left hand side of an
+	// assignment, so that array and slice elements are mutably borrowed.
+	lhsContext = replacementPolicy(4)
+)
+
+func (g *gen) writeExpr(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	if depth > a.MaxExprDepth {
+		return fmt.Errorf("expression recursion depth too large")
+	}
+	depth++
+
+	if rp&replaceCallSuspendibles != 0 && n.CallSuspendible() {
+		if g.currFunk.tempR >= g.currFunk.tempW {
+			return fmt.Errorf("internal error: temporary variable count out of sync")
+		}
+		b.printf("%s%d", tPrefix, g.currFunk.tempR)
+		g.currFunk.tempR++
+		return nil
+	}
+
+	if cv := n.ConstValue(); cv != nil {
+		if !n.MType().IsBool() {
+			b.writes(cv.String())
+		} else if cv.Cmp(zero) == 0 {
+			b.writes("false")
+		} else if cv.Cmp(one) == 0 {
+			b.writes("true")
+		} else {
+			return fmt.Errorf("%v has type bool but constant value %v is neither 0 or 1", n.Str(g.tm), cv)
+		}
+		return nil
+	}
+
+	switch op := n.Operator(); {
+	case op.IsXUnaryOp():
+		return g.writeExprUnaryOp(b, n, rp&^(statementContext|lhsContext), depth)
+	case op.IsXBinaryOp():
+		return g.writeExprBinaryOp(b, n, rp&^(statementContext|lhsContext), depth)
+	case op.IsXAssociativeOp():
+		return g.writeExprAssociativeOp(b, n, rp&^(statementContext|lhsContext), depth)
+	}
+	return g.writeExprOther(b, n, rp, depth)
+}
+
+func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	switch n.Operator() {
+	case 0:
+		if id1 := n.Ident(); id1 == t.IDThis {
+			b.writes("self")
+		} else if n.GlobalIdent() {
+			b.writes(g.constName(t.QID{0, id1}))
+		} else {
+			b.writes(vPrefix)
+			b.writes(id1.Str(g.tm))
+		}
+		return nil
+
+	case t.IDOpenParen:
+		// n is a function call.
+		if err := g.writeBuiltinCall(b, n, rp&^lhsContext, depth); err != errNoSuchBuiltin {
+			return err
+		}
+
+		if n.LHS().Expr().Ident() == t.IDReset {
+			if rp&statementContext == 0 {
+				return fmt.Errorf("TODO: a reset method call %q as an expression", n.Str(g.tm))
+			}
+			method := n.LHS().Expr()
+			recv := method.LHS().Expr()
+			recvTyp := recv.MType()
+			if recvTyp.Decorator() == t.IDPtr {
+				recvTyp = recvTyp.Inner()
+			}
+			if recvTyp.Decorator() != 0 {
+				return fmt.Errorf("cannot generate reset method call %q for receiver type %q",
+					n.Str(g.tm), recv.MType().Str(g.tm))
+			}
+			// Assigning the default value resets the struct, including its
+			// coroutine state.
+			if recv.MType().Decorator() == t.IDPtr {
+				b.writeb('*')
+			}
+			if err := g.writeExpr(b, recv, rp&^statementContext, depth); err != nil {
+				return err
+			}
+			b.printf(" = %s::default()", g.structName(recvTyp.QID()))
+			return nil
+		}
+
+		return g.writeExprUserDefinedCall(b, n, rp&^(statementContext|lhsContext), depth)
+
+	case t.IDOpenBracket:
+		// n is an index. The Wuffs compiler has proved that the index is in
+		// bounds, so skip the Rust run time bounds check. Indexing into a
+		// nested array, such as "a[i][j]", becomes a single unsafe block:
+		// "*unsafe { a.get_unchecked(i as usize).get_unchecked(j as usize) }".
+		getter := ".get_unchecked("
+		if rp&lhsContext != 0 {
+			getter = ".get_unchecked_mut("
+		}
+		rp &^= statementContext | lhsContext
+		indexes := []*a.Expr(nil)
+		for n.Operator() == t.IDOpenBracket {
+			indexes = append(indexes, n.RHS().Expr())
+			n = n.LHS().Expr()
+		}
+		b.writes("(*unsafe { ")
+		if err := g.writeExpr(b, n, rp, depth); err != nil {
+			return err
+		}
+		for i := len(indexes) - 1; i >= 0; i-- {
+			b.writes(getter)
+			if err := g.writeExpr(b, indexes[i], rp, depth); err != nil {
+				return err
+			}
+			b.writes(" as usize)")
+		}
+		b.writes(" })")
+		return nil
+
+	case t.IDColon:
+		// n is a slice.
+		rp &^= statementContext | lhsContext
+		lhs := n.LHS().Expr()
+		mhs, rhs := n.MHS().Expr(), n.RHS().Expr()
+		if lhs.MType().IsArrayType() {
+			// TODO: don't assume that the array is an array of base.u8.
+			b.writes("unsafe { wuffs_base::SliceU8::from_array(&mut ")
+		} else if mhs != nil || rhs != nil {
+			b.writes("unsafe { ")
+		}
+		if lhs.MType().IsArrayType() {
+			// Slicing an array borrows it mutably.
+			if err := g.writeExpr(b, lhs, rp|lhsContext, depth); err != nil {
+				return err
+			}
+			b.writeb(')')
+		} else if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+			return err
+		}
+		switch {
+		case mhs != nil && rhs != nil:
+			b.writes(".subslice_ij(")
+		case mhs != nil:
+			b.writes(".subslice_i(")
+		case rhs != nil:
+			b.writes(".subslice_j(")
+		}
+		if mhs != nil {
+			if err := g.writeExpr(b, mhs, rp, depth); err != nil {
+				return err
+			}
+			b.writes(" as usize")
+		}
+		if mhs != nil && rhs != nil {
+			b.writes(", ")
+		}
+		if rhs != nil {
+			if err := g.writeExpr(b, rhs, rp, depth); err != nil {
+				return err
+			}
+			b.writes(" as usize")
+		}
+		if mhs != nil || rhs != nil {
+			b.writeb(')')
+		}
+		if lhs.MType().IsArrayType() || mhs != nil || rhs != nil {
+			b.writes(" }")
+		}
+		return nil
+
+	case t.IDDot:
+		lhs := n.LHS().Expr()
+		if lhs.Ident() == t.IDIn {
+			b.writes(aPrefix)
+			b.writes(n.Ident().Str(g.tm))
+			return nil
+		}
+
+		if err := g.writeExpr(b, lhs, rp&^(statementContext|lhsContext), depth); err != nil {
+			return err
+		}
+		b.writes("." + fPrefix)
+		b.writes(n.Ident().Str(g.tm))
+		return nil
+
+	case t.IDError, t.IDStatus, t.IDSuspension:
+		status := g.statusMap[n.StatusQID()]
+		if status.name == "" {
+			msg, _ := t.Unescape(n.Ident().Str(g.tm))
+			z := builtin.StatusMap[msg]
+			if z.Message == "" {
+				return fmt.Errorf("no status code for %q", msg)
+			}
+			status.name = builtinStatusName(z)
+		}
+		b.writes(status.name)
+		return nil
+	}
+	return fmt.Errorf("unrecognized token (0x%X) for writeExprOther", n.Operator())
+}
+
+func (g *gen) writeExprUnaryOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	opName := rsOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writes(opName)
+	return g.writeExpr(b, n.RHS().Expr(), rp, depth)
+}
+
+func (g *gen) writeExprBinaryOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	switch op {
+	case t.IDXBinaryTildeSatPlus, t.IDXBinaryTildeSatMinus,
+		t.IDXBinaryTildeModPlus, t.IDXBinaryTildeModMinus:
+		uBits := uintBits(n.MType().QID())
+		if uBits == 0 {
+			return fmt.Errorf("unsupported tilde-operator type %q", n.MType().Str(g.tm))
+		}
+		b.printf("u%d::%s(", uBits, tildeMethodNames[op])
+		if err := g.writeExpr(b, n.LHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writes(", ")
+		if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
+			return err
+		}
+		b.writeb(')')
+		return nil
+
+	case t.IDXBinaryAs:
+		return g.writeExprAs(b, n.LHS().Expr(), n.RHS().TypeExpr(), rp, depth)
+	}
+
+	opName := rsOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writeb('(')
+	lhs := n.LHS().Expr()
+	if (op == t.IDXBinaryShiftL || op == t.IDXBinaryShiftR) &&
+		(lhs.ConstValue() != nil) && !n.MType().IsIdeal() {
+		// Rust cannot infer the type of an integer literal on the left of a
+		// shift, so give it an explicit suffix.
+		b.writes(lhs.ConstValue().String())
+		if err := g.writeRsTypeName(b, n.MType()); err != nil {
+			return err
+		}
+	} else if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+		return err
+	}
+	b.writes(opName)
+	if err := g.writeExpr(b, n.RHS().Expr(), rp, depth); err != nil {
+		return err
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprAs(b *buffer, lhs *a.Expr, rhs *a.TypeExpr, rp replacementPolicy, depth uint32) error {
+	if cv := lhs.ConstValue(); cv != nil && rhs.IsNumType() {
+		// An integer literal, by itself, would default to i32, which might
+		// overflow. Give it the target type's suffix instead.
+		b.writes(cv.String())
+		return g.writeRsTypeName(b, rhs)
+	}
+	b.writeb('(')
+	if err := g.writeExpr(b, lhs, rp, depth); err != nil {
+		return err
+	}
+	b.writes(" as ")
+	if err := g.writeRsTypeName(b, rhs); err != nil {
+		return err
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprAssociativeOp(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	op := n.Operator()
+	opName := rsOpName(op)
+	if opName == "" {
+		return fmt.Errorf("unrecognized operator %q", op.AmbiguousForm().Str(g.tm))
+	}
+
+	b.writeb('(')
+	for i, o := range n.Args() {
+		if i != 0 {
+			b.writes(opName)
+		}
+		if err := g.writeExpr(b, o.Expr(), rp, depth); err != nil {
+			return err
+		}
+	}
+	b.writeb(')')
+	return nil
+}
+
+func (g *gen) writeExprUserDefinedCall(b *buffer, n *a.Expr, rp replacementPolicy, depth uint32) error {
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+	if recvTyp.Decorator() == t.IDPtr {
+		recvTyp = recvTyp.Inner()
+	}
+	if recvTyp.Decorator() != 0 {
+		return fmt.Errorf("cannot generate user-defined method call %q for receiver type %q",
+			n.Str(g.tm), recv.MType().Str(g.tm))
+	}
+	if err := g.writeExpr(b, recv, rp, depth); err != nil {
+		return err
+	}
+	b.printf(".%s(", methodName(method.Ident().Str(g.tm)))
+	return g.writeArgs(b, n.Args(), rp, depth)
+}
+
+func (g *gen) writeRsTypeName(b *buffer, n *a.TypeExpr) error {
+	switch n.Decorator() {
+	case 0:
+		// No-op.
+	case t.IDPtr:
+		b.writes("&mut ")
+		return g.writeRsTypeName(b, n.Inner())
+	case t.IDArray:
+		b.writeb('[')
+		if err := g.writeRsTypeName(b, n.Inner()); err != nil {
+			return err
+		}
+		b.printf("; %v]", n.ArrayLength().ConstValue())
+		return nil
+	case t.IDSlice, t.IDTable:
+		// TODO: fix this, allow slices of all types, not just of base.u8's.
+		o := n.Inner()
+		if o.Decorator() != 0 || o.QID() != (t.QID{t.IDBase, t.IDU8}) || o.IsRefined() {
+			return fmt.Errorf("cannot convert Wuffs type %q to Rust", n.Str(g.tm))
+		}
+		if n.Decorator() == t.IDSlice {
+			b.writes("wuffs_base::SliceU8")
+		} else {
+			b.writes("wuffs_base::TableU8")
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot convert Wuffs type %q to Rust", n.Str(g.tm))
+	}
+
+	if qid := n.QID(); qid[0] == t.IDBase {
+		if key := qid[1]; key < t.ID(len(rsTypeNames)) {
+			if s := rsTypeNames[key]; s != "" {
+				b.writes(s)
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot convert Wuffs type %q to Rust", n.Str(g.tm))
+	}
+	b.writes(g.structName(n.QID()))
+	return nil
+}
+
+func (g *gen) writeZeroValue(b *buffer, n *a.TypeExpr) error {
+	switch n.Decorator() {
+	case t.IDPtr:
+		return fmt.Errorf("cannot generate a zero value for Wuffs type %q", n.Str(g.tm))
+	case t.IDArray:
+		inner := buffer(nil)
+		if err := g.writeZeroValue(&inner, n.Inner()); err != nil {
+			return err
+		}
+		if o := n.Inner(); o.IsNumType() || o.IsBool() || o.IsArrayType() {
+			b.printf("[%s; %v]", inner, n.ArrayLength().ConstValue())
+		} else {
+			// Rust's "[x; N]" syntax requires that x's type is Copy.
+			b.printf("core::array::from_fn(|_| %s)", inner)
+		}
+		return nil
+	case t.IDSlice, t.IDTable:
+		// No-op.
+	default:
+		switch n.QID() {
+		case t.QID{t.IDBase, t.IDBool}:
+			b.writes("false")
+			return nil
+		case t.QID{t.IDBase, t.IDStatus}:
+			b.writes("wuffs_base::Status::Ok")
+			return nil
+		}
+		if n.IsNumType() {
+			b.writes("0")
+			return nil
+		}
+	}
+	if err := g.writeRsTypeName(b, n); err != nil {
+		return err
+	}
+	b.writes("::default()")
+	return nil
+}
+
+var rsTypeNames = [...]string{
+	t.IDI8:          "i8",
+	t.IDI16:         "i16",
+	t.IDI32:         "i32",
+	t.IDI64:         "i64",
+	t.IDU8:          "u8",
+	t.IDU16:         "u16",
+	t.IDU32:         "u32",
+	t.IDU64:         "u64",
+	t.IDBool:        "bool",
+	t.IDEmptyStruct: "()",
+	t.IDRectIEU32:   "wuffs_base::RectIeU32",
+	t.IDRectIIU32:   "wuffs_base::RectIiU32",
+	t.IDImageBuffer: "wuffs_base::ImageBuffer",
+	t.IDImageConfig: "wuffs_base::ImageConfig",
+	t.IDIOReader:    "wuffs_base::IoReader",
+	t.IDIOWriter:    "wuffs_base::IoWriter",
+	t.IDStatus:      "wuffs_base::Status",
+}
+
+func rsOpName(x t.ID) string {
+	if x < t.ID(len(rsOpNames)) {
+		return rsOpNames[x]
+	}
+	return ""
+}
+
+// rsOpNames excludes the tilde operators, which are method calls such as
+// "wrapping_add" in Rust. See tildeMethodNames.
+var rsOpNames = [...]string{
+	t.IDEq:        " = ",
+	t.IDPlusEq:    " += ",
+	t.IDMinusEq:   " -= ",
+	t.IDStarEq:    " *= ",
+	t.IDSlashEq:   " /= ",
+	t.IDShiftLEq:  " <<= ",
+	t.IDShiftREq:  " >>= ",
+	t.IDAmpEq:     " &= ",
+	t.IDPipeEq:    " |= ",
+	t.IDHatEq:     " ^= ",
+	t.IDPercentEq: " %= ",
+
+	t.IDXUnaryPlus:  "+",
+	t.IDXUnaryMinus: "-",
+	t.IDXUnaryNot:   "!",
+
+	t.IDXBinaryPlus:        " + ",
+	t.IDXBinaryMinus:       " - ",
+	t.IDXBinaryStar:        " * ",
+	t.IDXBinarySlash:       " / ",
+	t.IDXBinaryShiftL:      " << ",
+	t.IDXBinaryShiftR:      " >> ",
+	t.IDXBinaryAmp:         " & ",
+	t.IDXBinaryPipe:        " | ",
+	t.IDXBinaryHat:         " ^ ",
+	t.IDXBinaryPercent:     " % ",
+	t.IDXBinaryNotEq:       " != ",
+	t.IDXBinaryLessThan:    " < ",
+	t.IDXBinaryLessEq:      " <= ",
+	t.IDXBinaryEqEq:        " == ",
+	t.IDXBinaryGreaterEq:   " >= ",
+	t.IDXBinaryGreaterThan: " > ",
+	t.IDXBinaryAnd:         " && ",
+	t.IDXBinaryOr:          " || ",
+
+	t.IDXAssociativePlus: " + ",
+	t.IDXAssociativeStar: " * ",
+	t.IDXAssociativeAmp:  " & ",
+	t.IDXAssociativePipe: " | ",
+	t.IDXAssociativeHat:  " ^ ",
+	t.IDXAssociativeAnd:  " && ",
+	t.IDXAssociativeOr:   " || ",
+}
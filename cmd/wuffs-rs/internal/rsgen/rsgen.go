@@ -0,0 +1,666 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rsgen transpiles Wuffs programs to Rust programs.
+//
+// Each Wuffs package becomes a Rust crate, named e.g. "wuffs_std_gif", that
+// depends on the wuffs_base runtime support library in lib/rs/wuffs_base, the
+// Rust equivalent of the C code's base-header.h and base-impl.h.
+//
+// The generated Rust code mostly follows the generated Go code (see the
+// cmd/wuffs-go/internal/gogen package) but, as the Wuffs checker has already
+// proved that every array and slice access is within bounds, those accesses
+// skip Rust's run time bounds checks, inside unsafe blocks.
+package rsgen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/google/wuffs/lang/builtin"
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+var (
+	zero = big.NewInt(0)
+	one  = big.NewInt(1)
+)
+
+// Prefixes are prepended to names to form a namespace and to avoid e.g.
+// "type" being a valid Wuffs variable name but not a valid Rust one.
+const (
+	aPrefix = "a_" // Function argument.
+	cPrefix = "c_" // Coroutine state.
+	fPrefix = "f_" // Struct field.
+	iPrefix = "i_" // Iterate variable.
+	oPrefix = "o_" // Temporary io_bind variable.
+	tPrefix = "t_" // Temporary local variable.
+	uPrefix = "u_" // Derived from a local variable.
+	vPrefix = "v_" // Local variable.
+)
+
+// CargoManifestEndsHere separates the two parts of the "wuffs-rs gen" output:
+// the crate's Cargo.toml manifest and its src/lib.rs source code.
+const CargoManifestEndsHere = "\n# CARGO MANIFEST ENDS HERE.\n\n"
+
+// Do transpiles a Wuffs program to a Rust program.
+//
+// The arguments list the source Wuffs files. If no arguments are given, it
+// reads from stdin.
+//
+// The generated crate's Cargo.toml and src/lib.rs are written to stdout,
+// separated by CargoManifestEndsHere.
+func Do(args []string) error {
+	flags := flag.FlagSet{}
+	packageDirnameFlag := flags.String("package_dirname", "",
+		`the package's directory, relative to the Wuffs root, e.g. "std/gif"`)
+	rsformatterFlag := flags.String("rsformatter", "rustfmt", "Rust formatter")
+
+	return generate.Do(&flags, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		if !cf.IsAlphaNumericIsh(*rsformatterFlag) {
+			return nil, fmt.Errorf("bad -rsformatter flag value %q", *rsformatterFlag)
+		}
+		dirname := *packageDirnameFlag
+		if dirname == "" {
+			dirname = "std/" + pkgName
+		}
+		if path.Base(dirname) != pkgName || path.IsAbs(dirname) || path.Clean(dirname) != dirname ||
+			strings.HasPrefix(dirname, "../") {
+			return nil, fmt.Errorf("bad -package_dirname flag value %q", dirname)
+		}
+
+		g := &gen{
+			pkgName:    pkgName,
+			pkgDirname: dirname,
+			tm:         tm,
+			checker:    c,
+			files:      files,
+		}
+		unformatted, err := g.generate()
+		if err != nil {
+			return nil, err
+		}
+		stdout := &bytes.Buffer{}
+		cmd := exec.Command(*rsformatterFlag, "--edition", "2021")
+		cmd.Stdin = bytes.NewReader(unformatted)
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			os.Stderr.Write(unformatted)
+			return nil, fmt.Errorf("wuffs-rs: could not format the generated code: %v", err)
+		}
+
+		manifest, err := g.cargoManifest()
+		if err != nil {
+			return nil, err
+		}
+		return append(append(manifest, CargoManifestEndsHere...), stdout.Bytes()...), nil
+	})
+}
+
+// CrateName returns the Rust crate name, such as "wuffs_std_gif", for a Wuffs
+// package directory, such as "std/gif".
+func CrateName(dirname string) string {
+	return "wuffs_" + strings.Replace(dirname, "/", "_", -1)
+}
+
+type visibility uint32
+
+const (
+	bothPubPri = visibility(iota)
+	pubOnly
+	priOnly
+)
+
+const (
+	maxIOBinds        = 100
+	maxIOBindInFields = 100
+	maxTemp           = 10000
+)
+
+type status struct {
+	name    string
+	msg     string
+	keyword t.ID
+}
+
+type buffer []byte
+
+func (b *buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func (b *buffer) printf(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+func (b *buffer) writeb(x byte)                             { *b = append(*b, x) }
+func (b *buffer) writes(s string)                           { *b = append(*b, s...) }
+func (b *buffer) writex(s []byte)                           { *b = append(*b, s...) }
+
+type gen struct {
+	pkgName    string // e.g. "jpeg"
+	pkgDirname string // e.g. "std/jpeg"
+
+	tm      *t.Map
+	checker *check.Checker
+	files   []*a.File
+
+	statusList []status
+	statusMap  map[t.QID]status
+	structList []*a.Struct
+	structMap  map[t.QID]*a.Struct
+	usesList   []string
+	usesMap    map[string]struct{}
+
+	currFunk funk
+	funks    map[t.QQID]funk
+}
+
+func (g *gen) generate() ([]byte, error) {
+	b := new(buffer)
+
+	g.statusMap = map[t.QID]status{}
+	if err := g.forEachStatus(b, bothPubPri, (*gen).gatherStatuses); err != nil {
+		return nil, err
+	}
+
+	// Make a topologically sorted list of structs.
+	unsortedStructs := []*a.Struct(nil)
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() == a.KStruct {
+				unsortedStructs = append(unsortedStructs, tld.Struct())
+			}
+		}
+	}
+	var ok bool
+	g.structList, ok = a.TopologicalSortStructs(unsortedStructs)
+	if !ok {
+		return nil, fmt.Errorf("cyclical struct definitions")
+	}
+	g.structMap = map[t.QID]*a.Struct{}
+	for _, n := range g.structList {
+		g.structMap[n.QID()] = n
+	}
+
+	g.funks = map[t.QQID]funk{}
+	if err := g.forEachFunc(nil, bothPubPri, (*gen).gatherFuncImpl); err != nil {
+		return nil, err
+	}
+	if err := g.forEachUse(b, (*gen).gatherUse); err != nil {
+		return nil, err
+	}
+
+	b.printf("// Code generated by wuffs-rs. DO NOT EDIT.\n\n")
+	b.printf("//! Package %s is the Rust translation of the Wuffs %s package.\n", g.pkgName, g.pkgDirname)
+	b.writes("//!\n")
+	b.writes("//! # Safety\n")
+	b.writes("//!\n")
+	b.writes("//! The Wuffs compiler has proved that every array or slice index, and every\n")
+	b.writes("//! fast path I/O call, in this crate is in bounds. The unsafe blocks below\n")
+	b.writes("//! skip the corresponding Rust run time checks, and are justified by those\n")
+	b.writes("//! proofs, not by local reasoning.\n\n")
+	// The generated code, like the generated C code, does not try to avoid
+	// e.g. unused variables or unnecessary parentheses.
+	b.writes("#![no_std]\n")
+	b.writes("#![allow(dead_code, non_camel_case_types, non_snake_case, non_upper_case_globals)]\n")
+	b.writes("#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]\n")
+	b.writes("#![allow(unused_parens, unused_unsafe, unused_variables)]\n\n")
+	for _, u := range g.usesList {
+		b.printf("use %s as %s;\n", CrateName(u), path.Base(u))
+	}
+	if len(g.usesList) > 0 {
+		b.writes("\n")
+	}
+
+	if len(g.statusList) > 0 {
+		b.writes("// ---------------- Status Codes\n\n")
+		for _, s := range g.statusList {
+			variant := "Suspension"
+			if s.keyword == t.IDError {
+				variant = "Error"
+			}
+			b.printf("pub const %s: wuffs_base::Status = wuffs_base::Status::%s(%q);\n",
+				s.name, variant, g.pkgName+": "+s.msg)
+		}
+		b.writes("\n")
+	}
+
+	b.writes("// ---------------- Public Consts\n\n")
+	if err := g.forEachConst(b, pubOnly, (*gen).writeConst); err != nil {
+		return nil, err
+	}
+
+	b.writes("// ---------------- Structs\n\n")
+	for _, n := range g.structList {
+		if err := g.writeStruct(b, n); err != nil {
+			return nil, err
+		}
+	}
+
+	b.writes("// ---------------- Private Consts\n\n")
+	if err := g.forEachConst(b, priOnly, (*gen).writeConst); err != nil {
+		return nil, err
+	}
+
+	b.writes("// ---------------- Function Implementations\n\n")
+	if err := g.forEachFunc(b, bothPubPri, (*gen).writeFuncImpl); err != nil {
+		return nil, err
+	}
+	return *b, nil
+}
+
+// cargoManifest returns the crate's Cargo.toml. The crate lives in the
+// gen/rs/std/foo directory, and depends (by relative paths) on the
+// lib/rs/wuffs_base crate and on the crates of the packages that it uses.
+func (g *gen) cargoManifest() ([]byte, error) {
+	crateDir := path.Join("gen", "rs", g.pkgDirname)
+	rel := func(dst string) string {
+		up := strings.Repeat("../", strings.Count(crateDir, "/")+1)
+		return up + dst
+	}
+
+	b := new(buffer)
+	b.writes("# Code generated by wuffs-rs. DO NOT EDIT.\n\n")
+	b.writes("[package]\n")
+	b.printf("name    = %q\n", CrateName(g.pkgDirname))
+	b.writes("version = \"0.0.1\"\n")
+	b.writes("authors = [\"The Wuffs Authors\"]\n")
+	b.writes("edition = \"2021\"\n")
+	b.writes("license = \"Apache-2.0\"\n\n")
+	b.writes("[lib]\n")
+	b.writes("path = \"src/lib.rs\"\n\n")
+	b.writes("[dependencies]\n")
+	b.printf("wuffs_base = { path = %q }\n", rel("lib/rs/wuffs_base"))
+	for _, u := range g.usesList {
+		b.printf("%s = { path = %q }\n", CrateName(u), rel(path.Join("gen", "rs", u)))
+	}
+	return *b, nil
+}
+
+func (g *gen) forEachConst(b *buffer, v visibility, f func(*gen, *buffer, *a.Const) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KConst ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Const()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachFunc(b *buffer, v visibility, f func(*gen, *buffer, *a.Func) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Func()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachStatus(b *buffer, v visibility, f func(*gen, *buffer, *a.Status) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStatus ||
+				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				continue
+			}
+			if err := f(g, b, tld.Status()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *gen) forEachUse(b *buffer, f func(*gen, *buffer, *a.Use) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KUse {
+				continue
+			}
+			if err := f(g, b, tld.Use()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// camelName converts a Wuffs name such as "decode_frame" to a Rust type name
+// such as "DecodeFrame".
+func camelName(name string) string {
+	s := []byte(nil)
+	upper := true
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			if upper && ('a' <= c && c <= 'z') {
+				c -= 'a' - 'A'
+			}
+			s = append(s, c)
+			upper = false
+		} else {
+			upper = true
+		}
+	}
+	return string(s)
+}
+
+// upperSnakeName converts a Wuffs name such as "ieee_table" or a status
+// message such as "bad Huffman code (over-subscribed)" to a Rust constant name
+// such as "IEEE_TABLE" or "BAD_HUFFMAN_CODE_OVER_SUBSCRIBED".
+func upperSnakeName(name string) string {
+	s := []byte(nil)
+	underscore := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			if underscore && len(s) > 0 {
+				s = append(s, '_')
+			}
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			s = append(s, c)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+	return string(s)
+}
+
+// rsKeywords are the Rust keywords that are valid Wuffs function names. Such
+// names are written as raw identifiers, e.g. "r#loop".
+var rsKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "box": true, "break": true,
+	"const": true, "continue": true, "crate": true, "dyn": true, "else": true,
+	"enum": true, "extern": true, "false": true, "fn": true, "for": true,
+	"if": true, "impl": true, "in": true, "let": true, "loop": true,
+	"match": true, "mod": true, "move": true, "mut": true, "pub": true,
+	"ref": true, "return": true, "static": true, "struct": true, "trait": true,
+	"true": true, "type": true, "unsafe": true, "use": true, "where": true,
+	"while": true, "yield": true,
+}
+
+// methodName returns the Rust name of a Wuffs method, which is the Wuffs name
+// unless that is a Rust keyword.
+func methodName(name string) string {
+	if rsKeywords[name] {
+		return "r#" + name
+	}
+	return name
+}
+
+func statusName(keyword t.ID, msg string) string {
+	prefix := "suspension "
+	if keyword == t.IDError {
+		prefix = "error "
+	}
+	return upperSnakeName(prefix + msg)
+}
+
+func intBits(qid t.QID) uint32 {
+	if qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDI8:
+			return 8
+		case t.IDI16:
+			return 16
+		case t.IDI32:
+			return 32
+		case t.IDI64:
+			return 64
+		}
+	}
+	return 0
+}
+
+func uintBits(qid t.QID) uint32 {
+	if qid[0] == t.IDBase {
+		switch qid[1] {
+		case t.IDU8:
+			return 8
+		case t.IDU16:
+			return 16
+		case t.IDU32:
+			return 32
+		case t.IDU64:
+			return 64
+		}
+	}
+	return 0
+}
+
+func (g *gen) gatherStatuses(b *buffer, n *a.Status) error {
+	raw := n.QID()[1].Str(g.tm)
+	msg, ok := t.Unescape(raw)
+	if !ok {
+		return fmt.Errorf("bad status message %q", raw)
+	}
+	s := status{
+		name:    statusName(n.Keyword(), msg),
+		msg:     msg,
+		keyword: n.Keyword(),
+	}
+	g.statusList = append(g.statusList, s)
+	g.statusMap[n.QID()] = s
+	return nil
+}
+
+func (g *gen) gatherUse(b *buffer, n *a.Use) error {
+	useDirname := g.tm.ByID(n.Path())
+	useDirname, _ = t.Unescape(useDirname)
+
+	if g.usesMap == nil {
+		g.usesMap = map[string]struct{}{}
+	} else if _, ok := g.usesMap[useDirname]; ok {
+		return nil
+	}
+	g.usesList = append(g.usesList, useDirname)
+	g.usesMap[useDirname] = struct{}{}
+	return nil
+}
+
+func (g *gen) constName(n t.QID) string {
+	return upperSnakeName(n[1].Str(g.tm))
+}
+
+func (g *gen) writeConst(b *buffer, n *a.Const) error {
+	pub := ""
+	if n.Public() {
+		pub = "pub "
+	}
+	// Rust constants are inlined at every use, so use a static for arrays.
+	keyword := "const"
+	if n.XType().IsArrayType() {
+		keyword = "static"
+	}
+	b.printf("%s%s %s: ", pub, keyword, g.constName(n.QID()))
+	if err := g.writeRsTypeName(b, n.XType()); err != nil {
+		return err
+	}
+	b.writes(" = ")
+	if err := g.writeConstList(b, n.XType(), n.Value()); err != nil {
+		return err
+	}
+	b.writes(";\n\n")
+	return nil
+}
+
+func (g *gen) writeConstList(b *buffer, typ *a.TypeExpr, n *a.Expr) error {
+	if n.Operator() == t.IDDollar {
+		b.writes("[\n")
+		for _, o := range n.Args() {
+			if err := g.writeConstList(b, typ.Inner(), o.Expr()); err != nil {
+				return err
+			}
+			b.writes(",\n")
+		}
+		b.writeb(']')
+	} else if cv := n.ConstValue(); cv != nil {
+		if typ.IsBool() {
+			b.writes(fmt.Sprint(cv.Cmp(zero) != 0))
+		} else if typ.IsUnsignedInteger() || typ.IsRefined() {
+			b.printf("0x%X", cv)
+		} else {
+			b.writes(cv.String())
+		}
+	} else {
+		return fmt.Errorf("invalid const value %q", n.Str(g.tm))
+	}
+	return nil
+}
+
+func (g *gen) structName(qid t.QID) string {
+	name := camelName(qid[1].Str(g.tm))
+	if qid[0] != 0 && qid[0] != t.IDBase {
+		return g.tm.ByID(qid[0]) + "::" + name
+	}
+	return name
+}
+
+func (g *gen) coroStructName(n *a.Func) string {
+	return g.structName(n.Receiver()) + camelName(n.FuncName().Str(g.tm)) + "Coro"
+}
+
+// field is a Rust struct field: its name, type and default value.
+type field struct {
+	name string
+	typ  string
+	zero string
+}
+
+func (g *gen) newField(name string, typ *a.TypeExpr) (field, error) {
+	t, z := buffer(nil), buffer(nil)
+	if err := g.writeRsTypeName(&t, typ); err != nil {
+		return field{}, err
+	}
+	if err := g.writeZeroValue(&z, typ); err != nil {
+		return field{}, err
+	}
+	return field{name: name, typ: string(t), zero: string(z)}, nil
+}
+
+// writeStructDef writes a Rust struct definition and its Default impl. Wuffs
+// structs can hold arrays that are too long for #[derive(Default)].
+func writeStructDef(b *buffer, pub string, name string, fields []field) {
+	b.printf("%sstruct %s {\n", pub, name)
+	for _, f := range fields {
+		b.printf("%s: %s,\n", f.name, f.typ)
+	}
+	b.writes("}\n\n")
+
+	b.printf("impl Default for %s {\n", name)
+	b.printf("fn default() -> Self {\n%s {\n", name)
+	for _, f := range fields {
+		b.printf("%s: %s,\n", f.name, f.zero)
+	}
+	b.writes("}\n}\n}\n\n")
+}
+
+func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
+	structName := g.structName(n.QID())
+	fields := []field(nil)
+	if n.Suspendible() {
+		fields = append(fields, field{"status", "wuffs_base::Status", "wuffs_base::Status::Ok"})
+	}
+	for _, o := range n.Fields() {
+		o := o.Field()
+		f, err := g.newField(fPrefix+o.Name().Str(g.tm), o.XType())
+		if err != nil {
+			return err
+		}
+		fields = append(fields, f)
+	}
+
+	coroStructs := buffer(nil)
+	if n.Suspendible() {
+		for _, file := range g.files {
+			for _, tld := range file.TopLevelDecls() {
+				if tld.Kind() != a.KFunc {
+					continue
+				}
+				o := tld.Func()
+				if o.Receiver() != n.QID() || !o.Suspendible() {
+					continue
+				}
+				k := g.funks[o.QQID()]
+				if k.coroSuspPoint == 0 && !k.usesScratch {
+					continue
+				}
+				coroFields := []field(nil)
+				if k.coroSuspPoint != 0 {
+					coroFields = append(coroFields, field{"coro_susp_point", "u32", "0"})
+					vars, err := g.coroVars(o.Body())
+					if err != nil {
+						return err
+					}
+					coroFields = append(coroFields, vars...)
+				}
+				if k.usesScratch {
+					coroFields = append(coroFields, field{"scratch", "u64", "0"})
+				}
+				coroName := g.coroStructName(o)
+				fields = append(fields, field{cPrefix + o.FuncName().Str(g.tm), coroName, coroName + "::default()"})
+				writeStructDef(&coroStructs, "", coroName, coroFields)
+			}
+		}
+	}
+
+	if n.Public() {
+		b.printf("/// %s holds the state of a %s.%s. Its default value is ready to use.\n",
+			structName, g.pkgName, n.QID()[1].Str(g.tm))
+		writeStructDef(b, "pub ", structName, fields)
+	} else {
+		writeStructDef(b, "", structName, fields)
+	}
+	b.writex(coroStructs)
+	return nil
+}
+
+// builtinStatusName returns the lib/rs/wuffs_base name of a built-in status,
+// such as "wuffs_base::ERROR_BAD_ARGUMENT", or "wuffs_base::Status::Ok" for
+// the "ok" status.
+func builtinStatusName(z builtin.Status) string {
+	if z.Keyword == 0 {
+		return "wuffs_base::Status::Ok"
+	}
+	return "wuffs_base::" + statusName(z.Keyword, z.Message)
+}
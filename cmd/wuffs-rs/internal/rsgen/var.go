@@ -0,0 +1,122 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsgen
+
+import (
+	"fmt"
+
+	a "github.com/google/wuffs/lang/ast"
+)
+
+func (g *gen) visitVars(b *buffer, block []*a.Node, depth uint32, f func(*gen, *buffer, *a.Var) error) error {
+	if depth > a.MaxBodyDepth {
+		return fmt.Errorf("body recursion depth too large")
+	}
+	depth++
+
+	for _, o := range block {
+		switch o.Kind() {
+		case a.KIf:
+			for o := o.If(); o != nil; o = o.ElseIf() {
+				if err := g.visitVars(b, o.BodyIfTrue(), depth, f); err != nil {
+					return err
+				}
+				if err := g.visitVars(b, o.BodyIfFalse(), depth, f); err != nil {
+					return err
+				}
+			}
+
+		case a.KIOBind:
+			if err := g.visitVars(b, o.IOBind().Body(), depth, f); err != nil {
+				return err
+			}
+
+		case a.KIterate:
+			if err := g.visitVars(b, o.Iterate().Variables(), depth, f); err != nil {
+				return err
+			}
+			if err := g.visitVars(b, o.Iterate().Body(), depth, f); err != nil {
+				return err
+			}
+
+		case a.KVar:
+			if err := f(g, b, o.Var()); err != nil {
+				return err
+			}
+
+		case a.KWhile:
+			if err := g.visitVars(b, o.While().Body(), depth, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeResumeSuspend writes the code to restore (or, if suspend is true, to
+// save) the local variables from (or to) the coroutine state. Like the C code,
+// variables of pointer-like types (slices, tables, I/O readers and writers)
+// are not saved, and are reset to their zero value when resuming.
+func (g *gen) writeResumeSuspend(b *buffer, block []*a.Node, suspend bool) error {
+	return g.visitVars(b, block, 0, func(g *gen, b *buffer, n *a.Var) error {
+		if n.IterateVariable() || n.XType().HasPointers() {
+			return nil
+		}
+		local := vPrefix + n.Name().Str(g.tm)
+		saved := g.currFunk.coroName(g) + "." + local
+		if suspend {
+			b.printf("%s = %s;\n", saved, local)
+		} else {
+			b.printf("%s = %s;\n", local, saved)
+		}
+		return nil
+	})
+}
+
+// writeVars declares the local variables.
+func (g *gen) writeVars(b *buffer, block []*a.Node) error {
+	return g.visitVars(b, block, 0, func(g *gen, b *buffer, n *a.Var) error {
+		if n.IterateVariable() {
+			return nil
+		}
+		name := n.Name().Str(g.tm)
+		f, err := g.newField(vPrefix+name, n.XType())
+		if err != nil {
+			return err
+		}
+		b.printf("let mut %s: %s = %s;\n", f.name, f.typ, f.zero)
+		if n.XType().IsIOType() {
+			b.printf("let mut %s%s = wuffs_base::IoBuffer::default();\n", uPrefix, name)
+		}
+		return nil
+	})
+}
+
+// coroVars returns the fields of the coroutine state struct, which are the
+// non-pointer local variables.
+func (g *gen) coroVars(block []*a.Node) (fields []field, err error) {
+	err = g.visitVars(nil, block, 0, func(g *gen, b *buffer, n *a.Var) error {
+		if n.IterateVariable() || n.XType().HasPointers() {
+			return nil
+		}
+		f, err := g.newField(vPrefix+n.Name().Str(g.tm), n.XType())
+		if err != nil {
+			return err
+		}
+		fields = append(fields, f)
+		return nil
+	})
+	return fields, err
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+func doBench(args []string) error { return doBenchTest(args, true) }
+func doTest(args []string) error  { return doBenchTest(args, false) }
+
+func doBenchTest(args []string, bench bool) error {
+	flags := flag.FlagSet{}
+	focusFlag := flags.String("focus", cf.FocusDefault, cf.FocusUsage)
+	iterscaleFlag := flags.Int("iterscale", cf.IterscaleDefault, cf.IterscaleUsage)
+	mimicFlag := flags.Bool("mimic", cf.MimicDefault, cf.MimicUsage)
+	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !cf.IsAlphaNumericIsh(*focusFlag) {
+		return fmt.Errorf("bad -focus flag value %q", *focusFlag)
+	}
+	if *iterscaleFlag < cf.IterscaleMin || cf.IterscaleMax < *iterscaleFlag {
+		return fmt.Errorf("bad -iterscale flag value %d, outside the range [%d..%d]",
+			*iterscaleFlag, cf.IterscaleMin, cf.IterscaleMax)
+	}
+	if *repsFlag < cf.RepsMin || cf.RepsMax < *repsFlag {
+		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]",
+			*repsFlag, cf.RepsMin, cf.RepsMax)
+	}
+
+	args = flags.Args()
+
+	failed := false
+	for _, arg := range args {
+		f, err := doBenchTest1(arg, bench, *focusFlag, *iterscaleFlag, *mimicFlag, *repsFlag)
+		if err != nil {
+			return err
+		}
+		failed = failed || f
+	}
+	if failed {
+		s := "tests"
+		if bench {
+			s = "benchmarks"
+		}
+		return fmt.Errorf("%s: some %s failed", os.Args[0], s)
+	}
+	return nil
+}
+
+// doBenchTest1 runs "cargo test" on the dirname directory, such as
+// "test/rs/std/gif", which holds that package's test crate.
+//
+// The Rust tests compare Wuffs' output with golden files in test/data. The
+// benchmarks are also cargo tests, named bench_wuffs_etc and marked
+// #[ignore], so that they only run (in release mode) for "wuffs-rs bench".
+// There are no mimic benchmarks, as the Rust standard library has no
+// compression or image codecs, so the -mimic flag has no effect.
+func doBenchTest1(dirname string, bench bool, focus string, iterscale int, mimic bool, reps int) (failed bool, err error) {
+	if _, err := os.Stat(dirname); os.IsNotExist(err) {
+		// Not every package has Rust tests.
+		return false, nil
+	}
+
+	cargoArgs := []string{"test", "--offline", "--quiet"}
+	testArgs := []string{"--test-threads=1"}
+	if bench {
+		cargoArgs = append(cargoArgs, "--release")
+		testArgs = append(testArgs, "--ignored", "--nocapture")
+		version, err := rustcVersion()
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("# %s\n# %s\n#\n", packageName(dirname), version)
+	}
+	testArgs = append(testArgs, focusNames(focus)...)
+
+	cmd := exec.Command("cargo", append(append(cargoArgs, "--"), testArgs...)...)
+	cmd.Dir = dirname
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("WUFFS_BENCH_ITERSCALE=%d", iterscale),
+		fmt.Sprintf("WUFFS_BENCH_REPS=%d", reps),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if bench {
+		cmd.Stdout = &benchWriter{}
+	}
+	if err := cmd.Run(); err == nil {
+		// No-op.
+	} else if _, ok := err.(*exec.ExitError); ok {
+		return true, nil
+	} else {
+		return false, err
+	}
+	return false, nil
+}
+
+// focusNames converts a comma-separated list of test or benchmark names,
+// such as "test_wuffs_gif_decode_animated_small" or
+// "Benchmarkwuffs_gif_decode_10k", to the Rust test harness' name filters.
+// Those filters match substrings, and the Rust tests' names, unlike the C
+// tests' names, do not repeat the package name, so only the part after the
+// package name is kept for tests.
+func focusNames(focus string) []string {
+	names := []string(nil)
+	for _, s := range strings.Split(focus, ",") {
+		s = strings.TrimPrefix(s, "Benchmark")
+		s = strings.TrimPrefix(s, "bench_")
+		if t := strings.TrimPrefix(s, "test_wuffs_"); t != s {
+			// Drop the package name, such as the "gif_" in "gif_decode_etc".
+			if i := strings.IndexByte(t, '_'); i >= 0 {
+				t = t[i+1:]
+			}
+			s = t
+		}
+		s = strings.TrimPrefix(s, "test_")
+		if s != "" {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// benchWriter passes the benchmark lines, those starting with "#" or
+// "Benchmark", through to stdout, and everything else that "cargo test"
+// prints, such as the number of tests run, to stderr. This keeps stdout in the
+// same format as the C and Go benchmarks, for tools like benchstat.
+//
+// The test harness prints a "." after each test finishes (cargo's --quiet
+// flag is also passed to the harness) without a trailing "\n", so that those
+// dots can prefix the next test's first line.
+type benchWriter struct {
+	buf []byte
+}
+
+func (w *benchWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimLeft(w.buf[:i+1], ".")
+		w.buf = w.buf[i+1:]
+		if bytes.HasPrefix(line, []byte("#")) || bytes.HasPrefix(line, []byte("Benchmark")) {
+			os.Stdout.Write(line)
+		} else {
+			os.Stderr.Write(line)
+		}
+	}
+	return len(p), nil
+}
+
+func rustcVersion() (string, error) {
+	out, err := exec.Command("rustc", "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(out)), nil
+}
+
+func packageName(dirname string) string {
+	dirname = filepath.ToSlash(dirname)
+	if i := strings.Index(dirname, "/test/rs/"); i >= 0 {
+		return dirname[i+len("/test/rs/"):]
+	}
+	return filepath.Base(dirname)
+}
@@ -0,0 +1,195 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/wuffs/cmd/wuffs/internal/cover"
+)
+
+// coverHelper measures the test programs' coverage of the Wuffs code.
+//
+// The checked-in generated C code doesn't say which Wuffs line each C line
+// came from, so the C code is re-generated with "// foo.wuffs:123" comments
+// into a temporary directory, a copy of the relevant parts of the Wuffs root
+// directory. The test programs, copied alongside, #include that C code
+// instead. The test data is symlinked, not copied.
+//
+// The same mechanism also tests C code generated with other "wuffs-c gen"
+// flags, such as "-debug_asserts", without touching the checked-in code.
+type coverHelper struct {
+	wuffsRoot string
+	tmpRoot   string
+	gh        genHelper
+}
+
+func newCoverHelper(wuffsRoot string, cformatter string, cgenArgs []string) (*coverHelper, error) {
+	tmpRoot, err := ioutil.TempDir("", "wuffs-cover")
+	if err != nil {
+		return nil, err
+	}
+	h := &coverHelper{
+		wuffsRoot: wuffsRoot,
+		tmpRoot:   tmpRoot,
+		gh: genHelper{
+			wuffsRoot:  wuffsRoot,
+			langs:      []string{"c"},
+			cformatter: cformatter,
+			outRoot:    tmpRoot,
+			cgenArgs:   cgenArgs,
+			progress:   ioutil.Discard,
+		},
+	}
+	if err := h.copyTests(); err != nil {
+		h.close()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *coverHelper) close() {
+	os.RemoveAll(h.tmpRoot)
+}
+
+// gcovDir is where "wuffs-c test -cover" writes its .gcov files.
+func (h *coverHelper) gcovDir() string {
+	return filepath.Join(h.tmpRoot, "gcov")
+}
+
+func (h *coverHelper) copyTests() error {
+	if err := os.MkdirAll(filepath.Join(h.tmpRoot, "test"), 0755); err != nil {
+		return err
+	}
+	if err := os.Symlink(filepath.Join(h.wuffsRoot, "test", "data"),
+		filepath.Join(h.tmpRoot, "test", "data")); err != nil {
+		return err
+	}
+
+	srcRoot := filepath.Join(h.wuffsRoot, "test", "c")
+	return filepath.Walk(srcRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(h.wuffsRoot, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(h.tmpRoot, rel)
+		if info.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(dst, src, 0644)
+	})
+}
+
+// gen generates the C code, with "// foo.wuffs:123" comments, for the
+// packages under test and their dependencies.
+//
+// A test program can also #include packages that its package doesn't use,
+// such as test/c/std/bmp.c including std/png to check the decoded pixels, so
+// every std package is generated too.
+func (h *coverHelper) gen(dirname string, recursive bool) error {
+	if err := h.gh.gen(dirname, recursive); err != nil {
+		return err
+	}
+	return h.gh.gen("std", true)
+}
+
+// profile folds the .gcov files back onto the Wuffs source files.
+func (h *coverHelper) profile() (*cover.Profile, error) {
+	genC := filepath.Join(h.tmpRoot, "gen", "c") + string(filepath.Separator)
+	resolve := func(cFilename string) (string, bool) {
+		cFilename = filepath.Clean(cFilename)
+		if !strings.HasPrefix(cFilename, genC) || !strings.HasSuffix(cFilename, ".c") {
+			return "", false
+		}
+		return filepath.ToSlash(strings.TrimSuffix(cFilename[len(genC):], ".c")), true
+	}
+
+	p := &cover.Profile{}
+	err := filepath.Walk(h.gcovDir(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// No test program produced any coverage data.
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".gcov") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return p.AddGcov(f, resolve)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// report writes a coverage summary to w and, if coverdir is non-empty,
+// writes annotated coverage.txt and coverage.html files to that directory.
+func (h *coverHelper) report(coverdir string, w io.Writer) error {
+	p, err := h.profile()
+	if err != nil {
+		return err
+	}
+	if err := p.WriteSummary(w); err != nil {
+		return err
+	}
+	if coverdir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(coverdir, 0755); err != nil {
+		return err
+	}
+	for _, x := range []struct {
+		filename string
+		write    func(io.Writer, string) error
+	}{
+		{"coverage.txt", p.WriteText},
+		{"coverage.html", p.WriteHTML},
+	} {
+		filename := filepath.Join(coverdir, x.filename)
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		err = x.write(f, h.wuffsRoot)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "cover wrote:   ", filename)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -39,9 +40,13 @@ func doGenlib(wuffsRoot string, args []string) error { return doGenGenlib(wuffsR
 
 func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	amalgamateFlag := flags.Bool("amalgamate", amalgamateDefault, amalgamateUsage)
+	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
+	cgoFlag := flags.Bool("cgo", cgoDefault, cgoUsage)
 	cformatterFlag := flags.String("cformatter", cf.CformatterDefault, cf.CformatterUsage)
 	langsFlag := flags.String("langs", langsDefault, langsUsage)
 	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	targetFlag := flags.String("target", targetDefault, targetUsage)
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -53,6 +58,23 @@ func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 	if err != nil {
 		return err
 	}
+	if *amalgamateFlag && !genlib {
+		return fmt.Errorf("the -amalgamate flag only applies to genlib")
+	}
+	if !cf.IsAlphaNumericIsh(*ccompilersFlag) {
+		return fmt.Errorf("bad -ccompilers flag value %q", *ccompilersFlag)
+	}
+	if *cgoFlag && (!genlib || *amalgamateFlag) {
+		return fmt.Errorf("the -cgo flag only applies to genlib without -amalgamate")
+	}
+	if *targetFlag != "" {
+		if *targetFlag != "wasm32" {
+			return fmt.Errorf("bad -target flag value %q", *targetFlag)
+		}
+		if !genlib || *amalgamateFlag || *cgoFlag {
+			return fmt.Errorf("the -target flag only applies to genlib without -amalgamate or -cgo")
+		}
+	}
 	args = flags.Args()
 	if len(args) == 0 {
 		args = []string{"std/..."}
@@ -63,6 +85,16 @@ func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 		langs:       langs,
 		cformatter:  *cformatterFlag,
 		skipgendeps: *skipgendepsFlag,
+
+		pythonModules: genlib && !*amalgamateFlag && (*targetFlag == ""),
+		jsModules:     genlib && (*targetFlag == "wasm32"),
+	}
+	if *cgoFlag {
+		cc := strings.TrimSpace(strings.Split(*ccompilersFlag, ",")[0])
+		if cc == "" {
+			return fmt.Errorf("the -cgo flag requires a non-empty -ccompilers flag")
+		}
+		h.cgoLibrary = filepath.Join(wuffsRoot, "gen", "lib", "c", cc+"-static", "libwuffs.a")
 	}
 
 	for _, arg := range args {
@@ -79,7 +111,7 @@ func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 	}
 
 	if genlib {
-		return h.genlibAffected()
+		return h.genlibAffected(*amalgamateFlag, *ccompilersFlag, *targetFlag)
 	}
 	return nil
 }
@@ -90,6 +122,27 @@ type genHelper struct {
 	cformatter  string
 	skipgendeps bool
 
+	// outRoot, if non-empty, is where to write the generated files, instead
+	// of under wuffsRoot. cgenArgs are extra arguments for "wuffs-c gen".
+	outRoot  string
+	cgenArgs []string
+
+	// pythonModules is whether to also write, for each C package, a ctypes
+	// Python module that wraps the genlib shared library.
+	pythonModules bool
+
+	// jsModules is whether to also write, for each C package, a JavaScript
+	// module that wraps the genlib WebAssembly module.
+	jsModules bool
+
+	// cgoLibrary, if non-empty, is the genlib static library that the cgo Go
+	// packages, written for each C package, link with.
+	cgoLibrary string
+
+	// progress is where to print "gen wrote" messages. A nil value means
+	// os.Stdout.
+	progress io.Writer
+
 	affected []string
 	seen     map[string]struct{}
 	tm       t.Map
@@ -154,6 +207,30 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 		cmdArgs := []string{"gen", "-package_name", packageName}
 		if lang == "c" {
 			cmdArgs = append(cmdArgs, fmt.Sprintf("-cformatter=%s", h.cformatter))
+			cmdArgs = append(cmdArgs, h.cgenArgs...)
+			if h.pythonModules {
+				pyFilename := filepath.Join(h.wuffsRoot, "gen", "lib", "c", "python", "wuffs_"+packageName+".py")
+				if err := os.MkdirAll(filepath.Dir(pyFilename), 0755); err != nil {
+					return err
+				}
+				cmdArgs = append(cmdArgs, "-python_module", pyFilename)
+				defer h.printProgress("gen wrote:     ", pyFilename)
+			}
+			if h.jsModules {
+				jsFilename := filepath.Join(h.wuffsRoot, "gen", "lib", "c", "js", "wuffs_"+packageName+".mjs")
+				if err := os.MkdirAll(filepath.Dir(jsFilename), 0755); err != nil {
+					return err
+				}
+				cmdArgs = append(cmdArgs, "-js_module", jsFilename)
+				defer h.printProgress("gen wrote:     ", jsFilename)
+			}
+			if h.cgoLibrary != "" {
+				cgoFilename := filepath.Join(h.wuffsRoot, "gen", "lib", "c", "cgo",
+					filepath.FromSlash(dirname), packageName+".go")
+				cmdArgs = append(cmdArgs, "-cgo_package", cgoFilename, "-cgo_library", h.cgoLibrary)
+			}
+		} else if lang == "rs" {
+			cmdArgs = append(cmdArgs, "-package_dirname", dirname)
 		}
 		cmdArgs = append(cmdArgs, qualifiedFilenames...)
 		stdout := &bytes.Buffer{}
@@ -170,6 +247,23 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 			return err
 		}
 		out := stdout.Bytes()
+
+		// Special-case the "rs" generator, which writes a Cargo.toml file and
+		// a src/lib.rs file.
+		if lang == "rs" {
+			i := bytes.Index(out, rsCargoManifestEndsHere)
+			if i < 0 {
+				return fmt.Errorf("%s: output did not contain %q", command, rsCargoManifestEndsHere)
+			}
+			if err := h.genFile(dirname, "cargo", out[:i+1]); err != nil {
+				return err
+			}
+			if err := h.genFile(dirname, "rs", out[i+len(rsCargoManifestEndsHere):]); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := h.genFile(dirname, lang, out); err != nil {
 			return err
 		}
@@ -195,7 +289,10 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 	return nil
 }
 
-var cHeaderEndsHere = []byte("\n// C HEADER ENDS HERE.\n\n")
+var (
+	cHeaderEndsHere         = []byte("\n// C HEADER ENDS HERE.\n\n")
+	rsCargoManifestEndsHere = []byte("\n# CARGO MANIFEST ENDS HERE.\n\n")
+)
 
 func (h *genHelper) genDirDependencies(qualifiedFilenames []string) error {
 	files, err := generate.ParseFiles(&h.tm, qualifiedFilenames, nil)
@@ -218,9 +315,24 @@ func (h *genHelper) genDirDependencies(qualifiedFilenames []string) error {
 }
 
 func (h *genHelper) genFile(dirname string, lang string, out []byte) error {
-	outFilename := filepath.Join(h.wuffsRoot, "gen", lang, filepath.FromSlash(dirname)+"."+lang)
+	outRoot := h.outRoot
+	if outRoot == "" {
+		outRoot = h.wuffsRoot
+	}
+	outFilename := filepath.Join(outRoot, "gen", lang, filepath.FromSlash(dirname)+"."+lang)
+	if lang == "go" {
+		// Go packages are directories, not files, so that e.g. the generated
+		// std/gif code can import "github.com/google/wuffs/gen/go/std/lzw".
+		outFilename = filepath.Join(outRoot, "gen", lang, filepath.FromSlash(dirname), path.Base(dirname)+".go")
+	} else if lang == "rs" {
+		// Rust packages are crates, such as gen/rs/std/gif, whose Cargo.toml
+		// (written as the "cargo" pseudo-language) refers to src/lib.rs.
+		outFilename = filepath.Join(outRoot, "gen", lang, filepath.FromSlash(dirname), "src", "lib.rs")
+	} else if lang == "cargo" {
+		outFilename = filepath.Join(outRoot, "gen", "rs", filepath.FromSlash(dirname), "Cargo.toml")
+	}
 	if existing, err := ioutil.ReadFile(outFilename); err == nil && bytes.Equal(existing, out) {
-		fmt.Println("gen unchanged: ", outFilename)
+		h.printProgress("gen unchanged: ", outFilename)
 		return nil
 	}
 	if err := os.MkdirAll(filepath.Dir(outFilename), 0755); err != nil {
@@ -229,10 +341,18 @@ func (h *genHelper) genFile(dirname string, lang string, out []byte) error {
 	if err := ioutil.WriteFile(outFilename, out, 0644); err != nil {
 		return err
 	}
-	fmt.Println("gen wrote:     ", outFilename)
+	h.printProgress("gen wrote:     ", outFilename)
 	return nil
 }
 
+func (h *genHelper) printProgress(a ...interface{}) {
+	w := h.progress
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintln(w, a...)
+}
+
 func (h *genHelper) genWuffs(dirname string, qualifiedFilenames []string) error {
 	files, err := generate.ParseFiles(&h.tm, qualifiedFilenames, &parse.Options{
 		AllowDoubleUnderscoreNames: true,
@@ -240,95 +360,26 @@ func (h *genHelper) genWuffs(dirname string, qualifiedFilenames []string) error
 	if err != nil {
 		return err
 	}
-	pkgIDNode := (*a.PackageID)(nil)
-	for _, f := range files {
-		for _, n := range f.TopLevelDecls() {
-			if n.Kind() == a.KPackageID {
-				pkgIDNode = n.PackageID()
-			}
-		}
-	}
-	if pkgIDNode == nil {
-		return fmt.Errorf("missing packageid declaration")
-	}
-	pkgIDStr, ok := t.Unescape(pkgIDNode.ID().Str(&h.tm))
-	if !ok {
-		return fmt.Errorf("invalid packageid declaration")
-	}
-
-	out := &bytes.Buffer{}
-	fmt.Fprintf(out, "// Code generated by running \"wuffs gen\". DO NOT EDIT.\n\n")
-	fmt.Fprintf(out, "packageid %q\n\n", pkgIDStr)
-
-	for _, f := range files {
-		for _, n := range f.TopLevelDecls() {
-			switch n.Kind() {
-			case a.KConst:
-				n := n.Const()
-				if !n.Public() {
-					continue
-				}
-				return fmt.Errorf("TODO: genWuffs for consts")
-
-			case a.KFunc:
-				n := n.Func()
-				if !n.Public() {
-					continue
-				}
-				effect := ""
-				if n.Suspendible() {
-					effect = "?"
-				} else if n.Impure() {
-					effect = "!"
-				}
-				if n.Receiver().IsZero() {
-					return fmt.Errorf("TODO: genWuffs for a free-standing function")
-				}
-				// TODO: look at n.Asserts().
-				fmt.Fprintf(out, "pub func %s.%s%s(", n.Receiver().Str(&h.tm), n.FuncName().Str(&h.tm), effect)
-				for i, param := range [2]*a.Struct{n.In(), n.Out()} {
-					if i > 0 {
-						fmt.Fprintf(out, ")(")
-					}
-					for j, field := range param.Fields() {
-						field := field.Field()
-						if j > 0 {
-							fmt.Fprintf(out, ", ")
-						}
-						// TODO: what happens if the XType is from another
-						// package?
-						fmt.Fprintf(out, "%s %s", field.Name().Str(&h.tm), field.XType().Str(&h.tm))
-					}
-				}
-				fmt.Fprintf(out, ") { }\n")
-
-			case a.KStatus:
-				n := n.Status()
-				if !n.Public() {
-					continue
-				}
-				fmt.Fprintf(out, "pub %s %s\n", n.Keyword().Str(&h.tm), n.QID().Str(&h.tm))
-
-			case a.KStruct:
-				n := n.Struct()
-				if !n.Public() {
-					continue
-				}
-				effect := ""
-				if n.Suspendible() {
-					effect = "?"
-				}
-				fmt.Fprintf(out, "pub struct %s%s()\n", n.QID().Str(&h.tm), effect)
-			}
-		}
+	out, err := generate.Declarations(&h.tm, files)
+	if err != nil {
+		return err
 	}
-	return h.genFile(dirname, "wuffs", out.Bytes())
+	return h.genFile(dirname, "wuffs", out)
 }
 
-func (h *genHelper) genlibAffected() error {
+func (h *genHelper) genlibAffected(amalgamate bool, ccompilers string, target string) error {
 	for _, lang := range h.langs {
 		command := "wuffs-" + lang
 		args := []string{"genlib"}
+		if amalgamate {
+			args = append(args, "-amalgamate")
+		}
+		if lang == "c" {
+			args = append(args, "-ccompilers", ccompilers)
+			if target != "" {
+				args = append(args, "-target", target)
+			}
+		}
 		args = append(args, "-dstdir", filepath.Join(h.wuffsRoot, "gen", "lib", lang))
 		args = append(args, "-srcdir", filepath.Join(h.wuffsRoot, "gen", lang))
 		args = append(args, h.affected...)
@@ -0,0 +1,375 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchstat parses, aggregates and compares the output of the test
+// programs' "-bench" mode.
+//
+// Those programs (see test/c/testlib/testlib.c) print one line per benchmark
+// per repetition, in the Go benchmark format:
+//
+//	Benchmarkwuffs_gif_decode_10k/gcc	    5000	  237456 ns/op	  41.342 MB/s
+//
+// along with "#" comment lines, the first of which names the package.
+package benchstat
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample is one repetition of one benchmark.
+type Sample struct {
+	Iters   uint64  `json:"iters"`
+	NsPerOp float64 `json:"ns_per_op"`
+	MBPerS  float64 `json:"mb_per_s,omitempty"`
+}
+
+// Benchmark is the aggregation of all repetitions of a benchmark, for a given
+// package, name and C compiler.
+type Benchmark struct {
+	Package string   `json:"package"`
+	Name    string   `json:"name"`
+	CC      string   `json:"cc"`
+	Samples []Sample `json:"samples"`
+
+	// The fields below are derived from Samples. They are re-calculated by
+	// Finish, and are recorded in the JSON output only for the benefit of
+	// human readers and other tools.
+
+	MeanNsPerOp float64 `json:"mean_ns_per_op"`
+	CI95NsPerOp float64 `json:"ci95_ns_per_op"`
+	MeanMBPerS  float64 `json:"mean_mb_per_s,omitempty"`
+}
+
+// Key returns a string that uniquely identifies b within a Set.
+func (b *Benchmark) Key() string {
+	return b.Package + "\x00" + b.Name + "\x00" + b.CC
+}
+
+// FullName returns the Go benchmark format name, such as
+// "Benchmarkwuffs_gif_decode_10k/gcc".
+func (b *Benchmark) FullName() string {
+	return "Benchmark" + b.Name + "/" + b.CC
+}
+
+// NsPerOps returns the ns/op values of b's samples.
+func (b *Benchmark) NsPerOps() []float64 {
+	ret := make([]float64, len(b.Samples))
+	for i, s := range b.Samples {
+		ret[i] = s.NsPerOp
+	}
+	return ret
+}
+
+func (b *Benchmark) finish() {
+	b.MeanNsPerOp, b.CI95NsPerOp = MeanCI95(b.NsPerOps())
+	mbps := []float64(nil)
+	for _, s := range b.Samples {
+		if s.MBPerS != 0 {
+			mbps = append(mbps, s.MBPerS)
+		}
+	}
+	b.MeanMBPerS, _ = MeanCI95(mbps)
+}
+
+// Set is a collection of benchmarks, typically from one "wuffs bench" run.
+type Set struct {
+	Benchmarks []*Benchmark `json:"benchmarks"`
+
+	m map[string]*Benchmark
+}
+
+// Lookup returns the benchmark with the given package, name and C compiler,
+// or nil if there is no such benchmark.
+func (s *Set) Lookup(pkg string, name string, cc string) *Benchmark {
+	s.index()
+	return s.m[pkg+"\x00"+name+"\x00"+cc]
+}
+
+func (s *Set) index() {
+	if s.m != nil {
+		return
+	}
+	s.m = map[string]*Benchmark{}
+	for _, b := range s.Benchmarks {
+		s.m[b.Key()] = b
+	}
+}
+
+func (s *Set) add(pkg string, name string, cc string, sample Sample) {
+	s.index()
+	key := pkg + "\x00" + name + "\x00" + cc
+	b := s.m[key]
+	if b == nil {
+		b = &Benchmark{
+			Package: pkg,
+			Name:    name,
+			CC:      cc,
+		}
+		s.m[key] = b
+		s.Benchmarks = append(s.Benchmarks, b)
+	}
+	b.Samples = append(b.Samples, sample)
+}
+
+// Parse adds the benchmark lines in r to s. Lines that are not benchmark
+// lines, including warm up lines, are ignored except for the "# std/foo"
+// package name line.
+func (s *Set) Parse(r io.Reader) error {
+	pkg := ""
+	expectPackage := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			// The package name is the first of a group of comment lines. Any
+			// later comment lines in that group, such as the "# gcc version
+			// 7.2.0" line, are not package names.
+			if expectPackage {
+				pkg = strings.TrimSpace(line[1:])
+				expectPackage = false
+			}
+			continue
+		}
+		expectPackage = true
+		if !strings.HasPrefix(line, "Benchmark") {
+			continue
+		}
+		name, cc, sample, err := parseLine(line)
+		if err != nil {
+			return err
+		}
+		s.add(pkg, name, cc, sample)
+	}
+	return scanner.Err()
+}
+
+func parseLine(line string) (name string, cc string, sample Sample, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[3] != "ns/op" {
+		return "", "", Sample{}, fmt.Errorf("benchstat: invalid benchmark line %q", line)
+	}
+	name = fields[0][len("Benchmark"):]
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name, cc = name[:i], name[i+1:]
+	}
+	if sample.Iters, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return "", "", Sample{}, fmt.Errorf("benchstat: invalid benchmark line %q: %v", line, err)
+	}
+	if sample.NsPerOp, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return "", "", Sample{}, fmt.Errorf("benchstat: invalid benchmark line %q: %v", line, err)
+	}
+	if len(fields) >= 6 && fields[5] == "MB/s" {
+		if sample.MBPerS, err = strconv.ParseFloat(fields[4], 64); err != nil {
+			return "", "", Sample{}, fmt.Errorf("benchstat: invalid benchmark line %q: %v", line, err)
+		}
+	}
+	return name, cc, sample, nil
+}
+
+// Finish sorts s's benchmarks and re-calculates their derived fields.
+func (s *Set) Finish() {
+	sort.SliceStable(s.Benchmarks, func(i, j int) bool {
+		bi, bj := s.Benchmarks[i], s.Benchmarks[j]
+		if bi.Package != bj.Package {
+			return bi.Package < bj.Package
+		}
+		if bi.Name != bj.Name {
+			return bi.Name < bj.Name
+		}
+		return bi.CC < bj.CC
+	})
+	for _, b := range s.Benchmarks {
+		b.finish()
+	}
+}
+
+// WriteJSON writes s, in JSON format, to w.
+func (s *Set) WriteJSON(w io.Writer) error {
+	s.Finish()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s)
+}
+
+// ReadJSON reads a Set, in JSON format, from r.
+func ReadJSON(r io.Reader) (*Set, error) {
+	s := &Set{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	for _, b := range s.Benchmarks {
+		if b == nil {
+			return nil, fmt.Errorf("benchstat: invalid JSON: null benchmark")
+		}
+	}
+	s.Finish()
+	return s, nil
+}
+
+// WriteGoBench writes s in the Go benchmark format, suitable as input to the
+// golang.org/x/perf/cmd/benchstat tool. Each package's lines are preceded by
+// a "pkg:" line, and each benchmark's samples are grouped together.
+func (s *Set) WriteGoBench(w io.Writer) error {
+	s.Finish()
+	pkg := "\x00"
+	for _, b := range s.Benchmarks {
+		if b.Package != pkg {
+			pkg = b.Package
+			if _, err := fmt.Fprintf(w, "pkg: %s\n", pkg); err != nil {
+				return err
+			}
+		}
+		for _, x := range b.Samples {
+			if _, err := fmt.Fprintf(w, "%s\t%8d\t%8.0f ns/op", b.FullName(), x.Iters, x.NsPerOp); err != nil {
+				return err
+			}
+			if x.MBPerS != 0 {
+				if _, err := fmt.Fprintf(w, "\t%12.3f MB/s", x.MBPerS); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Comparison is the result of comparing an old and new benchmark.
+type Comparison struct {
+	Old *Benchmark
+	New *Benchmark
+
+	// Delta is the relative change in mean ns/op, e.g. +0.05 means that New
+	// is 5% slower than Old.
+	Delta float64
+	// P is the p-value of Welch's t-test on the two samples' ns/op values.
+	P float64
+	// Regression is whether New is significantly slower than Old: P is less
+	// than the alpha given to Compare, and Delta is more than the threshold.
+	Regression bool
+}
+
+// String returns a one line human readable summary of c.
+func (c *Comparison) String() string {
+	flag := ""
+	if c.Regression {
+		flag = "  REGRESSION"
+	}
+	return fmt.Sprintf("%-48s %12.0f ±%3.0f%%  %12.0f ±%3.0f%%  %+7.2f%%  (p=%.3f)%s",
+		c.New.FullName(),
+		c.Old.MeanNsPerOp, percent(c.Old.CI95NsPerOp, c.Old.MeanNsPerOp),
+		c.New.MeanNsPerOp, percent(c.New.CI95NsPerOp, c.New.MeanNsPerOp),
+		100*c.Delta, c.P, flag)
+}
+
+func percent(x float64, y float64) float64 {
+	if y == 0 {
+		return 0
+	}
+	return 100 * x / y
+}
+
+func compare(old *Benchmark, new *Benchmark, alpha float64, threshold float64) *Comparison {
+	c := &Comparison{
+		Old: old,
+		New: new,
+		P:   WelchTTest(old.NsPerOps(), new.NsPerOps()),
+	}
+	if old.MeanNsPerOp != 0 {
+		c.Delta = new.MeanNsPerOp/old.MeanNsPerOp - 1
+	}
+	c.Regression = (c.P < alpha) && (c.Delta > threshold)
+	return c
+}
+
+// CompareBaseline compares every benchmark in s with the benchmark of the same
+// package, name and C compiler in baseline. Benchmarks that are in only one
+// of the two sets are skipped.
+//
+// alpha is the significance level, e.g. 0.05. threshold is the minimum
+// relative slow-down, e.g. 0.05 for 5%, for a significant change to count as
+// a regression.
+func (s *Set) CompareBaseline(baseline *Set, alpha float64, threshold float64) []*Comparison {
+	s.Finish()
+	baseline.Finish()
+	ret := []*Comparison(nil)
+	for _, b := range s.Benchmarks {
+		if old := baseline.Lookup(b.Package, b.Name, b.CC); old != nil {
+			ret = append(ret, compare(old, b, alpha, threshold))
+		}
+	}
+	return ret
+}
+
+// CompareMimic compares every "wuffs_foo" benchmark in s with the "mimic_foo"
+// benchmark (if any) of the same package and C compiler, also in s. For each
+// Comparison, Old is the mimic benchmark and New is the Wuffs benchmark, so
+// that a Regression means that Wuffs is significantly slower than the mimic
+// library.
+func (s *Set) CompareMimic(alpha float64, threshold float64) []*Comparison {
+	s.Finish()
+	ret := []*Comparison(nil)
+	for _, b := range s.Benchmarks {
+		if !strings.HasPrefix(b.Name, "wuffs_") {
+			continue
+		}
+		mimicName := "mimic_" + b.Name[len("wuffs_"):]
+		if old := s.Lookup(b.Package, mimicName, b.CC); old != nil {
+			ret = append(ret, compare(old, b, alpha, threshold))
+		}
+	}
+	return ret
+}
+
+// MeanCI95 returns the mean of xs and the half-width of its 95% confidence
+// interval, based on Student's t-distribution.
+func MeanCI95(xs []float64) (mean float64, ci95 float64) {
+	n := len(xs)
+	if n == 0 {
+		return 0, 0
+	}
+	mean, variance := meanVariance(xs)
+	if n == 1 {
+		return mean, 0
+	}
+	return mean, studentT975(float64(n-1)) * math.Sqrt(variance/float64(n))
+}
+
+// meanVariance returns the mean and the (unbiased) sample variance of xs.
+func meanVariance(xs []float64) (mean float64, variance float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) == 1 {
+		return mean, 0
+	}
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	variance /= float64(len(xs) - 1)
+	return mean, variance
+}
@@ -0,0 +1,212 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchstat
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const benchOutput = `# std/adler32
+# gcc version 7.2.0
+#
+# The output format, including the "Benchmark" prefixes, is compatible with the
+# https://godoc.org/golang.org/x/perf/cmd/benchstat tool. To install it, first
+# install Go, then run "go get golang.org/x/perf/cmd/benchstat".
+# (warm up) wuffs_adler32_10k/gcc	       0.034324 seconds
+# (warm up) mimic_adler32_10k/gcc	       0.033897 seconds
+# 2 benchmarks, 1+3 reps per benchmark, iterscale=5
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5200 ns/op	    2130.288 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4000 ns/op	    2178.321 MB/s
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5300 ns/op	    2339.389 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4100 ns/op	    2324.059 MB/s
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5250 ns/op	    2252.522 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4050 ns/op	    2338.339 MB/s
+# std/crc32
+# clang version 5.0.0
+Benchmarkwuffs_crc32_ieee_10k/clang	    1000	   10000 ns/op
+`
+
+func TestParse(tt *testing.T) {
+	s := &Set{}
+	if err := s.Parse(strings.NewReader(benchOutput)); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+	s.Finish()
+	if got, want := len(s.Benchmarks), 3; got != want {
+		tt.Fatalf("len(Benchmarks): got %d, want %d", got, want)
+	}
+
+	b := s.Lookup("std/adler32", "wuffs_adler32_10k", "gcc")
+	if b == nil {
+		tt.Fatalf("Lookup: got nil")
+	}
+	if got, want := len(b.Samples), 3; got != want {
+		tt.Fatalf("len(Samples): got %d, want %d", got, want)
+	}
+	if got, want := b.MeanNsPerOp, 5250.0; got != want {
+		tt.Fatalf("MeanNsPerOp: got %v, want %v", got, want)
+	}
+	// The sample standard deviation is 50, so the 95% CI half-width is
+	// 4.303 * 50 / sqrt(3), where 4.303 is the t-distribution critical value
+	// for 2 degrees of freedom.
+	if got, want := b.CI95NsPerOp, 4.3027*50/math.Sqrt(3); math.Abs(got-want) > 0.01 {
+		tt.Fatalf("CI95NsPerOp: got %v, want %v", got, want)
+	}
+
+	c := s.Lookup("std/crc32", "wuffs_crc32_ieee_10k", "clang")
+	if c == nil {
+		tt.Fatalf("Lookup: got nil")
+	}
+	if got, want := c.Samples[0], (Sample{Iters: 1000, NsPerOp: 10000}); got != want {
+		tt.Fatalf("Samples[0]: got %v, want %v", got, want)
+	}
+}
+
+func TestParseInvalid(tt *testing.T) {
+	s := &Set{}
+	if err := s.Parse(strings.NewReader("Benchmarkfoo/gcc\tbar\n")); err == nil {
+		tt.Fatalf("Parse: got nil error, want non-nil")
+	}
+}
+
+func TestJSONRoundTrip(tt *testing.T) {
+	s := &Set{}
+	if err := s.Parse(strings.NewReader(benchOutput)); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := s.WriteJSON(buf); err != nil {
+		tt.Fatalf("WriteJSON: %v", err)
+	}
+	t, err := ReadJSON(buf)
+	if err != nil {
+		tt.Fatalf("ReadJSON: %v", err)
+	}
+	if got, want := len(t.Benchmarks), len(s.Benchmarks); got != want {
+		tt.Fatalf("len(Benchmarks): got %d, want %d", got, want)
+	}
+	for i, b := range t.Benchmarks {
+		if got, want := b.Key(), s.Benchmarks[i].Key(); got != want {
+			tt.Fatalf("Key #%d: got %q, want %q", i, got, want)
+		}
+		if got, want := b.MeanNsPerOp, s.Benchmarks[i].MeanNsPerOp; got != want {
+			tt.Fatalf("MeanNsPerOp #%d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestWriteGoBench(tt *testing.T) {
+	s := &Set{}
+	if err := s.Parse(strings.NewReader(benchOutput)); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := s.WriteGoBench(buf); err != nil {
+		tt.Fatalf("WriteGoBench: %v", err)
+	}
+
+	// Writing and re-parsing should give the same samples.
+	t := &Set{}
+	if err := t.Parse(strings.NewReader(strings.Replace(buf.String(), "pkg: ", "# ", -1))); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+	t.Finish()
+	for i, b := range t.Benchmarks {
+		if got, want := len(b.Samples), len(s.Benchmarks[i].Samples); got != want {
+			tt.Fatalf("len(Samples) #%d: got %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestStudentT975(tt *testing.T) {
+	// Critical values from a standard statistics table.
+	testCases := []struct {
+		df   float64
+		want float64
+	}{
+		{1, 12.706},
+		{2, 4.303},
+		{4, 2.776},
+		{10, 2.228},
+		{30, 2.042},
+		{1000, 1.962},
+	}
+	for _, tc := range testCases {
+		if got := studentT975(tc.df); math.Abs(got-tc.want) > 0.001 {
+			tt.Errorf("df=%v: got %v, want %v", tc.df, got, tc.want)
+		}
+	}
+}
+
+func TestWelchTTest(tt *testing.T) {
+	same := []float64{100, 101, 99, 100, 102}
+	if p := WelchTTest(same, same); p < 0.99 {
+		tt.Errorf("same: got p=%v, want ~1", p)
+	}
+	slower := []float64{120, 121, 119, 120, 122}
+	if p := WelchTTest(same, slower); p > 0.001 {
+		tt.Errorf("slower: got p=%v, want ~0", p)
+	}
+	noisy := []float64{50, 150, 100, 90, 110}
+	if p := WelchTTest(same, noisy); p < 0.5 {
+		tt.Errorf("noisy: got p=%v, want > 0.5", p)
+	}
+	if p := WelchTTest(same, []float64{200}); p != 1 {
+		tt.Errorf("too few samples: got p=%v, want 1", p)
+	}
+}
+
+func TestCompare(tt *testing.T) {
+	s := &Set{}
+	if err := s.Parse(strings.NewReader(benchOutput)); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+
+	// Comparing against the mimic library: Wuffs is about 30% slower in this
+	// fake data.
+	cs := s.CompareMimic(0.05, 0.05)
+	if len(cs) != 1 {
+		tt.Fatalf("CompareMimic: got %d comparisons, want 1", len(cs))
+	}
+	if c := cs[0]; !c.Regression || c.New.Name != "wuffs_adler32_10k" || c.Old.Name != "mimic_adler32_10k" {
+		tt.Fatalf("CompareMimic: got %v", c)
+	}
+
+	// Comparing against itself should not find any regressions.
+	for _, c := range s.CompareBaseline(s, 0.05, 0.05) {
+		if c.Regression {
+			tt.Fatalf("CompareBaseline: got a regression: %v", c)
+		}
+	}
+
+	// Comparing against a faster baseline should find a regression.
+	baseline := &Set{}
+	fast := strings.Replace(benchOutput, "    5", "    4", -1)
+	if err := baseline.Parse(strings.NewReader(fast)); err != nil {
+		tt.Fatalf("Parse: %v", err)
+	}
+	n := 0
+	for _, c := range s.CompareBaseline(baseline, 0.05, 0.05) {
+		if c.Regression {
+			n++
+		}
+	}
+	if n != 1 {
+		tt.Fatalf("CompareBaseline: got %d regressions, want 1", n)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchstat
+
+import (
+	"math"
+)
+
+// WelchTTest returns the two-tailed p-value of Welch's t-test, testing the
+// null hypothesis that xs and ys have equal means. It returns 1 if there are
+// too few samples (fewer than 2 of either) to say otherwise.
+func WelchTTest(xs []float64, ys []float64) float64 {
+	if len(xs) < 2 || len(ys) < 2 {
+		return 1
+	}
+	mx, vx := meanVariance(xs)
+	my, vy := meanVariance(ys)
+	nx, ny := float64(len(xs)), float64(len(ys))
+	sx, sy := vx/nx, vy/ny
+	if sx+sy == 0 {
+		// Both samples are constant. The means are either exactly equal or
+		// exactly different.
+		if mx == my {
+			return 1
+		}
+		return 0
+	}
+	t := (mx - my) / math.Sqrt(sx+sy)
+	// The Welch–Satterthwaite equation.
+	df := (sx + sy) * (sx + sy) / (sx*sx/(nx-1) + sy*sy/(ny-1))
+	return 2 * studentTSF(math.Abs(t), df)
+}
+
+// studentTSF returns the survival function, 1 - CDF(t), for Student's
+// t-distribution with df degrees of freedom, for non-negative t.
+func studentTSF(t float64, df float64) float64 {
+	return 0.5 * regIncBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// studentT975 returns the 97.5th percentile of Student's t-distribution with
+// df degrees of freedom: the multiplier for a two-sided 95% confidence
+// interval.
+func studentT975(df float64) float64 {
+	// Bisect, since studentTSF is monotonically decreasing. The 97.5th
+	// percentile is 12.71 for df == 1, and decreases towards 1.96 as df grows.
+	lo, hi := 0.0, 64.0
+	for i := 0; i < 64; i++ {
+		mid := (lo + hi) / 2
+		if studentTSF(mid, df) > 0.025 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regIncBeta returns the regularized incomplete beta function I_x(a, b).
+//
+// It follows "Numerical Recipes in C", section 6.4, evaluating a continued
+// fraction by the modified Lentz's method.
+func regIncBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges rapidly for x < (a+1)/(a+b+2).
+	// Otherwise, use the symmetry relation I_x(a, b) = 1 - I_(1-x)(b, a).
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-15
+		tiny          = 1e-300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		// The even step.
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// The odd step.
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,292 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cover maps code coverage data for generated C code back onto the
+// Wuffs source code.
+//
+// The C code must have been generated with "wuffs-c gen
+// -filename_line_comments", so that each statement's C code is preceded by a
+// "// foo.wuffs:123" comment. The coverage data is in gcov's text format, as
+// produced by "gcov" or "llvm-cov gcov", which includes the C source code and
+// therefore those comments.
+//
+// A Wuffs line's count is the sum of the counts of the first executable C
+// line after each of that Wuffs line's comments. Summing handles the C code
+// for a Wuffs statement being duplicated, such as for iterate loops, and
+// handles multiple test programs or C compilers.
+package cover
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is the coverage of a Wuffs source file.
+type File struct {
+	// Name is the slash-separated file name, relative to the Wuffs root
+	// directory, such as "std/gif/decode_gif.wuffs".
+	Name string
+	// Counts maps line numbers to execution counts. Lines without code, such
+	// as comments or declarations, are not in the map.
+	Counts map[int]uint64
+}
+
+// Covered returns the number of lines with a non-zero count and the number
+// of lines in f.Counts.
+func (f *File) Covered() (covered int, total int) {
+	for _, c := range f.Counts {
+		if c != 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Counts)
+}
+
+// Profile is the coverage of a set of Wuffs source files.
+type Profile struct {
+	Files map[string]*File
+}
+
+func (p *Profile) file(name string) *File {
+	if p.Files == nil {
+		p.Files = map[string]*File{}
+	}
+	f := p.Files[name]
+	if f == nil {
+		f = &File{Name: name, Counts: map[int]uint64{}}
+		p.Files[name] = f
+	}
+	return f
+}
+
+// sortedFiles returns p's files, sorted by name.
+func (p *Profile) sortedFiles() []*File {
+	ret := make([]*File, 0, len(p.Files))
+	for _, f := range p.Files {
+		ret = append(ret, f)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+var filenameLineComment = regexp.MustCompile(`^\s*// ([A-Za-z0-9_\-]+\.wuffs):([0-9]+)$`)
+
+// AddGcov adds the counts from a .gcov file, the coverage of one C source
+// file. The resolve function maps that C file's name to the slash-separated
+// directory, such as "std/gif", that holds its Wuffs source files. If resolve
+// returns false, the C file is not Wuffs-generated code and is ignored.
+func (p *Profile) AddGcov(r io.Reader, resolve func(cFilename string) (wuffsDir string, ok bool)) error {
+	wuffsDir, ok := "", false
+	pendingName, pendingLine := "", 0
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		// Each line looks like "count:lineNumber:source". Skip any other
+		// lines, such as the per-function summaries that some gcov versions
+		// can print.
+		fields := strings.SplitN(s.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		count, lineNumber, source := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]), fields[2]
+
+		if lineNumber == "0" {
+			if strings.HasPrefix(source, "Source:") {
+				wuffsDir, ok = resolve(source[len("Source:"):])
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if m := filenameLineComment.FindStringSubmatch(source); m != nil {
+			line, err := strconv.Atoi(m[2])
+			if err != nil {
+				return fmt.Errorf("cover: bad line number in %q", source)
+			}
+			pendingName, pendingLine = wuffsDir+"/"+m[1], line
+			continue
+		}
+		if strings.HasPrefix(source, "}") {
+			// The end of a C function.
+			pendingName = ""
+			continue
+		}
+		if pendingName == "" || count == "-" {
+			continue
+		}
+
+		n := uint64(0)
+		if count != "#####" && count != "=====" {
+			x, err := strconv.ParseUint(strings.TrimSuffix(count, "*"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cover: bad gcov count %q", count)
+			}
+			n = x
+		}
+		p.file(pendingName).Counts[pendingLine] += n
+		pendingName = ""
+	}
+	return s.Err()
+}
+
+// WriteSummary writes each file's percentage of covered lines, and a total.
+func (p *Profile) WriteSummary(w io.Writer) error {
+	allCovered, allTotal := 0, 0
+	for _, f := range p.sortedFiles() {
+		covered, total := f.Covered()
+		allCovered += covered
+		allTotal += total
+		if _, err := fmt.Fprintf(w, "%-40s %s\n", f.Name, percent(covered, total)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%-40s %s\n", "total", percent(allCovered, allTotal))
+	return err
+}
+
+func percent(covered int, total int) string {
+	if total == 0 {
+		return "no lines"
+	}
+	return fmt.Sprintf("%5.1f%% of %d lines", 100*float64(covered)/float64(total), total)
+}
+
+// line is a Wuffs source line, annotated with its coverage.
+type line struct {
+	Number  int
+	Count   string
+	Class   string
+	Source  string
+	HasCode bool
+}
+
+func (p *Profile) annotate(wuffsRoot string, f *File) ([]line, error) {
+	src, err := ioutil.ReadFile(filepath.Join(wuffsRoot, filepath.FromSlash(f.Name)))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(src), "\n"), "\n")
+	ret := make([]line, len(lines))
+	for i, s := range lines {
+		l := line{Number: i + 1, Count: "-", Class: "none", Source: s}
+		if c, ok := f.Counts[i+1]; ok {
+			l.HasCode = true
+			if c == 0 {
+				l.Count, l.Class = "#####", "miss"
+			} else {
+				l.Count, l.Class = strconv.FormatUint(c, 10), "hit"
+			}
+		}
+		ret[i] = l
+	}
+	return ret, nil
+}
+
+// WriteText writes each file's source code, annotated gcov-style with each
+// line's count: "-" for lines without code, and "#####" for lines never run.
+// The Wuffs source files are read from under wuffsRoot.
+func (p *Profile) WriteText(w io.Writer, wuffsRoot string) error {
+	for _, f := range p.sortedFiles() {
+		lines, err := p.annotate(wuffsRoot, f)
+		if err != nil {
+			return err
+		}
+		covered, total := f.Covered()
+		if _, err := fmt.Fprintf(w, "# %s: %s\n", f.Name, percent(covered, total)); err != nil {
+			return err
+		}
+		for _, l := range lines {
+			if _, err := fmt.Fprintf(w, "%9s:%5d:%s\n", l.Count, l.Number, l.Source); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteHTML writes a single HTML page that shows each file's source code,
+// with lines colored by whether they were run. The Wuffs source files are
+// read from under wuffsRoot.
+func (p *Profile) WriteHTML(w io.Writer, wuffsRoot string) error {
+	type htmlFile struct {
+		Index   int
+		Name    string
+		Summary string
+		Lines   []line
+	}
+	data := []htmlFile(nil)
+	for i, f := range p.sortedFiles() {
+		lines, err := p.annotate(wuffsRoot, f)
+		if err != nil {
+			return err
+		}
+		covered, total := f.Covered()
+		data = append(data, htmlFile{
+			Index:   i,
+			Name:    f.Name,
+			Summary: percent(covered, total),
+			Lines:   lines,
+		})
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Wuffs coverage</title>
+<style>
+body { background: white; color: black; font-family: sans-serif; }
+pre { margin: 0; }
+.count { color: gray; text-align: right; padding-right: 1em; }
+.num { color: gray; text-align: right; padding-right: 1em; }
+.hit { background: #c0f0c0; }
+.miss { background: #f0c0c0; }
+.file { display: none; }
+table { border-collapse: collapse; font-family: monospace; white-space: pre; }
+</style>
+</head>
+<body>
+<select id="files" onchange="show(this.value)">
+{{range .}}<option value="file{{.Index}}">{{.Name}} ({{.Summary}})</option>
+{{end}}</select>
+{{range .}}<div class="file" id="file{{.Index}}">
+<table>
+{{range .Lines}}<tr class="{{.Class}}"><td class="count">{{if .HasCode}}{{.Count}}{{end}}</td><td class="num">{{.Number}}</td><td>{{.Source}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}<script>
+function show(id) {
+  var files = document.getElementsByClassName("file");
+  for (var i = 0; i < files.length; i++) {
+    files[i].style.display = (files[i].id === id) ? "block" : "none";
+  }
+}
+show(document.getElementById("files").value);
+</script>
+</body>
+</html>
+`))
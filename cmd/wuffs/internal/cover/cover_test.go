@@ -0,0 +1,149 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cover
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gcovOutput = `        -:    0:Source:/root/gen/c/std/foo.c
+        -:    0:Graph:/tmp/a-foo.gcno
+        -:    1:// Code generated by wuffs-c. DO NOT EDIT.
+        2:    2:void wuffs_foo__decoder__decode(int* x) {
+        -:    3:  // decode_foo.wuffs:10
+        2:    4:  if (*x > 0) {
+        -:    5:    // decode_foo.wuffs:11
+        1:    6:    *x = 1;
+        -:    7:  } else {
+        -:    8:    // decode_foo.wuffs:13
+        1*:    9:    *x = 2;
+        -:   10:  }
+        -:   11:  // decode_foo.wuffs:15
+    #####:   12:  if (*x > 9) {
+        -:   13:    // decode_foo.wuffs:16
+    #####:   14:    *x = 3;
+        -:   15:  }
+        -:   16:  // decode_foo.wuffs:17
+        -:   17:  // decode_foo.wuffs:18
+        2:   18:  return;
+        -:   19:  // decode_foo.wuffs:19
+        -:   20:}
+        2:   21:void suspend() {
+        -:   22:}
+`
+
+func resolve(cFilename string) (string, bool) {
+	if cFilename == "/root/gen/c/std/foo.c" {
+		return "std/foo", true
+	}
+	return "", false
+}
+
+func TestAddGcov(tt *testing.T) {
+	p := &Profile{}
+	if err := p.AddGcov(strings.NewReader(gcovOutput), resolve); err != nil {
+		tt.Fatalf("AddGcov: %v", err)
+	}
+	f := p.Files["std/foo/decode_foo.wuffs"]
+	if f == nil {
+		tt.Fatalf("Files: got %v", p.Files)
+	}
+	want := map[int]uint64{10: 2, 11: 1, 13: 1, 15: 0, 16: 0, 18: 2}
+	if !reflect.DeepEqual(f.Counts, want) {
+		tt.Fatalf("Counts:\ngot  %v\nwant %v", f.Counts, want)
+	}
+	if covered, total := f.Covered(); covered != 4 || total != 6 {
+		tt.Fatalf("Covered: got %d, %d, want 4, 6", covered, total)
+	}
+
+	// Adding the same data again should sum the counts.
+	if err := p.AddGcov(strings.NewReader(gcovOutput), resolve); err != nil {
+		tt.Fatalf("AddGcov: %v", err)
+	}
+	if got, want := f.Counts[10], uint64(4); got != want {
+		tt.Fatalf("Counts[10]: got %d, want %d", got, want)
+	}
+
+	// Non-Wuffs C files should be ignored.
+	q := &Profile{}
+	other := strings.Replace(gcovOutput, "/root/gen/c/std/foo.c", "/root/test/c/std/foo.c", 1)
+	if err := q.AddGcov(strings.NewReader(other), resolve); err != nil {
+		tt.Fatalf("AddGcov: %v", err)
+	}
+	if len(q.Files) != 0 {
+		tt.Fatalf("Files: got %v, want none", q.Files)
+	}
+}
+
+func TestWrite(tt *testing.T) {
+	wuffsRoot, err := ioutil.TempDir("", "wuffs-cover-test")
+	if err != nil {
+		tt.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(wuffsRoot)
+	if err := os.MkdirAll(filepath.Join(wuffsRoot, "std", "foo"), 0755); err != nil {
+		tt.Fatalf("MkdirAll: %v", err)
+	}
+	src := "// Comment.\nx = 1\ny = <2>\n"
+	if err := ioutil.WriteFile(filepath.Join(wuffsRoot, "std", "foo", "a.wuffs"), []byte(src), 0644); err != nil {
+		tt.Fatalf("WriteFile: %v", err)
+	}
+
+	p := &Profile{}
+	f := p.file("std/foo/a.wuffs")
+	f.Counts[2] = 7
+	f.Counts[3] = 0
+
+	buf := &bytes.Buffer{}
+	if err := p.WriteSummary(buf); err != nil {
+		tt.Fatalf("WriteSummary: %v", err)
+	}
+	if got, want := buf.String(), ""+
+		"std/foo/a.wuffs                           50.0% of 2 lines\n"+
+		"total                                     50.0% of 2 lines\n"; got != want {
+		tt.Fatalf("WriteSummary:\ngot  %q\nwant %q", got, want)
+	}
+
+	buf.Reset()
+	if err := p.WriteText(buf, wuffsRoot); err != nil {
+		tt.Fatalf("WriteText: %v", err)
+	}
+	if got, want := buf.String(), ""+
+		"# std/foo/a.wuffs:  50.0% of 2 lines\n"+
+		"        -:    1:// Comment.\n"+
+		"        7:    2:x = 1\n"+
+		"    #####:    3:y = <2>\n"; got != want {
+		tt.Fatalf("WriteText:\ngot  %q\nwant %q", got, want)
+	}
+
+	buf.Reset()
+	if err := p.WriteHTML(buf, wuffsRoot); err != nil {
+		tt.Fatalf("WriteHTML: %v", err)
+	}
+	for _, want := range []string{
+		`<tr class="hit"><td class="count">7</td><td class="num">2</td><td>x = 1</td></tr>`,
+		`<td>y = &lt;2&gt;</td>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			tt.Fatalf("WriteHTML: output does not contain %q", want)
+		}
+	}
+}
@@ -107,6 +107,27 @@ func listDir(wuffsRoot string, dirname string, returnSubdirs bool) (filenames []
 }
 
 const (
+	amalgamateDefault = false
+	amalgamateUsage   = `whether genlib should generate a single-file library (e.g. wuffs.h) instead of object files`
+
+	cgoDefault = false
+	cgoUsage   = `whether genlib should also generate cgo Go packages that wrap the first -ccompilers compiler's static library`
+
+	coverDefault = false
+	coverUsage   = `whether to measure the tests' coverage of the Wuffs code (C only)`
+
+	debugAssertsDefault = false
+	debugAssertsUsage   = `whether to test C code generated with "wuffs-c gen -debug_asserts", re-checking the compiler's proofs at run time`
+
+	coverdirDefault = ""
+	coverdirUsage   = `directory to write annotated coverage.txt and coverage.html files to`
+
+	baselineDefault = ""
+	baselineUsage   = `JSON file (from a previous "-format=json" run) to compare benchmarks against`
+
+	formatDefault = "text"
+	formatUsage   = `benchmark output format: "text", "json" or "gobench"`
+
 	langsDefault = "c"
 	langsUsage   = `comma-separated list of target languages (file extensions), e.g. "c,go,rs"`
 
@@ -115,6 +136,14 @@ const (
 
 	skipgendepsDefault = false
 	skipgendepsUsage   = `whether to skip automatically generating packages' dependencies`
+
+	targetDefault = ""
+	targetUsage   = `genlib compilation target: empty for the host, or "wasm32" for a WebAssembly module and JavaScript wrappers`
+
+	thresholdDefault = 5
+	thresholdMin     = 0
+	thresholdMax     = 1000
+	thresholdUsage   = `the minimum slow-down (as a percentage) for a benchmark change to be a regression`
 )
 
 func parseLangs(commaSeparated string) ([]string, error) {
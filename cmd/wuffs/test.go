@@ -15,30 +15,45 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/google/wuffs/cmd/wuffs/internal/benchstat"
+
 	cf "github.com/google/wuffs/cmd/commonflags"
 )
 
+// benchAlpha is the significance level for flagging a benchmark regression.
+const benchAlpha = 0.05
+
 func doBench(wuffsRoot string, args []string) error { return doBenchTest(wuffsRoot, args, true) }
 func doTest(wuffsRoot string, args []string) error  { return doBenchTest(wuffsRoot, args, false) }
 
 func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	baselineFlag := flags.String("baseline", baselineDefault, baselineUsage)
 	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
 	cformatterFlag := flags.String("cformatter", cf.CformatterDefault, cf.CformatterUsage)
+	cxxcompilersFlag := flags.String("cxxcompilers", cf.CxxcompilersDefault, cf.CxxcompilersUsage)
+	coverFlag := flags.Bool("cover", coverDefault, coverUsage)
+	coverdirFlag := flags.String("coverdir", coverdirDefault, coverdirUsage)
+	debugAssertsFlag := flags.Bool("debug_asserts", debugAssertsDefault, debugAssertsUsage)
 	focusFlag := flags.String("focus", cf.FocusDefault, cf.FocusUsage)
+	formatFlag := flags.String("format", formatDefault, formatUsage)
 	iterscaleFlag := flags.Int("iterscale", cf.IterscaleDefault, cf.IterscaleUsage)
 	langsFlag := flags.String("langs", langsDefault, langsUsage)
 	mimicFlag := flags.Bool("mimic", cf.MimicDefault, cf.MimicUsage)
 	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
+	sanitizeFlag := flags.String("sanitize", cf.SanitizeDefault, cf.SanitizeUsage)
 	skipgenFlag := flags.Bool("skipgen", skipgenDefault, skipgenUsage)
 	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	thresholdFlag := flags.Float64("threshold", thresholdDefault, thresholdUsage)
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -54,6 +69,9 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	if !cf.IsAlphaNumericIsh(*cformatterFlag) {
 		return fmt.Errorf("bad -cformatter flag value %q", *cformatterFlag)
 	}
+	if !cf.IsAlphaNumericIsh(*cxxcompilersFlag) {
+		return fmt.Errorf("bad -cxxcompilers flag value %q", *cxxcompilersFlag)
+	}
 	if !cf.IsAlphaNumericIsh(*focusFlag) {
 		return fmt.Errorf("bad -focus flag value %q", *focusFlag)
 	}
@@ -65,6 +83,48 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]",
 			*repsFlag, cf.RepsMin, cf.RepsMax)
 	}
+	switch *formatFlag {
+	case "text", "json", "gobench":
+		// No-op.
+	default:
+		return fmt.Errorf("bad -format flag value %q", *formatFlag)
+	}
+	if *thresholdFlag < thresholdMin || thresholdMax < *thresholdFlag {
+		return fmt.Errorf("bad -threshold flag value %g, outside the range [%d..%d]",
+			*thresholdFlag, thresholdMin, thresholdMax)
+	}
+	if !bench && (*formatFlag != formatDefault || *baselineFlag != baselineDefault) {
+		return fmt.Errorf("the -format and -baseline flags only apply to benchmarks")
+	}
+	sanitizers, err := cf.ParseSanitizers(*sanitizeFlag)
+	if err != nil {
+		return err
+	}
+	if bench && len(sanitizers) > 0 {
+		return fmt.Errorf("the -sanitize flag only applies to tests, not benchmarks")
+	}
+	if bench && (*coverFlag || *coverdirFlag != coverdirDefault) {
+		return fmt.Errorf("the -cover and -coverdir flags only apply to tests, not benchmarks")
+	}
+	if bench && *debugAssertsFlag {
+		return fmt.Errorf("the -debug_asserts flag only applies to tests, not benchmarks")
+	}
+	if *coverdirFlag != coverdirDefault && !*coverFlag {
+		return fmt.Errorf("the -coverdir flag requires the -cover flag")
+	}
+
+	baseline := (*benchstat.Set)(nil)
+	if *baselineFlag != "" {
+		f, err := os.Open(*baselineFlag)
+		if err != nil {
+			return err
+		}
+		baseline, err = benchstat.ReadJSON(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("bad -baseline file %q: %v", *baselineFlag, err)
+		}
+	}
 
 	args = flags.Args()
 	if len(args) == 0 {
@@ -88,10 +148,55 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	}
 
 	h := testHelper{
-		wuffsRoot:  wuffsRoot,
-		langs:      langs,
-		cmdArgs:    cmdArgs,
-		ccompilers: *ccompilersFlag,
+		wuffsRoot:    wuffsRoot,
+		langs:        langs,
+		cmdArgs:      cmdArgs,
+		ccompilers:   *ccompilersFlag,
+		cxxcompilers: *cxxcompilersFlag,
+		sanitize:     strings.Join(sanitizers, ","),
+	}
+
+	// Structured output and regression comparison (against a baseline or the
+	// mimic libraries) need to capture, not just pass through, the benchmark
+	// programs' output. Only the plain text format also echoes that output
+	// (and the "gen" progress) to stdout.
+	progress := io.Writer(os.Stdout)
+	if bench && (*formatFlag != "text" || baseline != nil || *mimicFlag) {
+		h.results = &benchstat.Set{}
+		h.echo = *formatFlag == "text"
+		if !h.echo {
+			progress = os.Stderr
+		}
+	}
+
+	ch := (*coverHelper)(nil)
+	if *coverFlag || *debugAssertsFlag {
+		hasC := false
+		for _, lang := range langs {
+			hasC = hasC || (lang == "c")
+		}
+		cgenArgs := []string(nil)
+		if *coverFlag {
+			if !hasC {
+				return fmt.Errorf("the -cover flag requires the c language")
+			}
+			cgenArgs = append(cgenArgs, "-filename_line_comments")
+		}
+		if *debugAssertsFlag {
+			if !hasC {
+				return fmt.Errorf("the -debug_asserts flag requires the c language")
+			}
+			cgenArgs = append(cgenArgs, "-debug_asserts")
+		}
+		ch, err = newCoverHelper(wuffsRoot, *cformatterFlag, cgenArgs)
+		if err != nil {
+			return err
+		}
+		defer ch.close()
+		h.testRoot = ch.tmpRoot
+		if *coverFlag {
+			h.cover = ch.gcovDir()
+		}
 	}
 
 	failed := false
@@ -108,11 +213,17 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 				langs:       langs,
 				cformatter:  *cformatterFlag,
 				skipgendeps: *skipgendepsFlag,
+				progress:    progress,
 			}
 			if err := gh.gen(arg, recursive); err != nil {
 				return err
 			}
 		}
+		if ch != nil {
+			if err := ch.gen(arg, recursive); err != nil {
+				return err
+			}
+		}
 
 		// Proceed with benching / testing the generated code.
 		f, err := h.benchTest(arg, recursive)
@@ -121,6 +232,23 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 		}
 		failed = failed || f
 	}
+
+	if h.results != nil {
+		regressed, err := h.report(*formatFlag, baseline, *mimicFlag, *thresholdFlag/100, progress)
+		if err != nil {
+			return err
+		}
+		if regressed && !failed {
+			return fmt.Errorf("wuffs bench: some benchmarks regressed")
+		}
+	}
+
+	if *coverFlag {
+		if err := ch.report(*coverdirFlag, progress); err != nil {
+			return err
+		}
+	}
+
 	if failed {
 		s0, s1 := "test", "tests"
 		if bench {
@@ -132,10 +260,22 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 }
 
 type testHelper struct {
-	wuffsRoot  string
-	langs      []string
-	cmdArgs    []string
-	ccompilers string
+	wuffsRoot    string
+	langs        []string
+	cmdArgs      []string
+	ccompilers   string
+	cxxcompilers string
+	sanitize     string
+
+	// testRoot, if non-empty, is where to find the test programs, instead of
+	// under wuffsRoot. cover, if non-empty, is where to write coverage data.
+	testRoot string
+	cover    string
+
+	// results, if non-nil, collects the benchmark programs' output. echo is
+	// whether that output is also written to stdout.
+	results *benchstat.Set
+	echo    bool
 }
 
 func (h *testHelper) benchTest(dirname string, recursive bool) (failed bool, err error) {
@@ -173,11 +313,30 @@ func (h *testHelper) benchTestDir(dirname string) (failed bool, err error) {
 		args = append(args, h.cmdArgs...)
 		if lang == "c" {
 			args = append(args, fmt.Sprintf("-ccompilers=%s", h.ccompilers))
+			args = append(args, fmt.Sprintf("-cxxcompilers=%s", h.cxxcompilers))
+			if h.sanitize != "" {
+				args = append(args, fmt.Sprintf("-sanitize=%s", h.sanitize))
+			}
+			if h.cover != "" {
+				args = append(args, fmt.Sprintf("-cover=%s", h.cover))
+			}
+		}
+		testRoot := h.testRoot
+		if testRoot == "" {
+			testRoot = h.wuffsRoot
 		}
-		args = append(args, filepath.Join(h.wuffsRoot, "test", lang, filepath.FromSlash(dirname)))
+		args = append(args, filepath.Join(testRoot, "test", lang, filepath.FromSlash(dirname)))
 		cmd := exec.Command(command, args...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
+		stdout := &bytes.Buffer{}
+		if h.results != nil {
+			if h.echo {
+				cmd.Stdout = io.MultiWriter(os.Stdout, stdout)
+			} else {
+				cmd.Stdout = stdout
+			}
+		}
 		if err := cmd.Run(); err == nil {
 			// No-op.
 		} else if _, ok := err.(*exec.ExitError); ok {
@@ -185,6 +344,64 @@ func (h *testHelper) benchTestDir(dirname string) (failed bool, err error) {
 		} else {
 			return false, err
 		}
+		if h.results != nil {
+			if err := h.results.Parse(stdout); err != nil {
+				return false, err
+			}
+		}
 	}
 	return failed, nil
 }
+
+type benchSection struct {
+	title       string
+	oldColumn   string
+	comparisons []*benchstat.Comparison
+}
+
+// report writes the collected benchmark results in the given format and, if
+// there is a baseline, compares the results against it. It returns whether
+// any benchmark regressed.
+//
+// If mimic is true, with or without a baseline, the Wuffs benchmarks are also
+// compared against their mimic library counterparts in the same run. Those
+// comparisons are also reported as regressions if Wuffs is significantly
+// slower than the mimic library.
+func (h *testHelper) report(format string, baseline *benchstat.Set, mimic bool,
+	threshold float64, w io.Writer) (regressed bool, err error) {
+
+	switch format {
+	case "json":
+		err = h.results.WriteJSON(os.Stdout)
+	case "gobench":
+		err = h.results.WriteGoBench(os.Stdout)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sections := []benchSection(nil)
+	if baseline != nil {
+		sections = append(sections, benchSection{
+			"baseline", "baseline ns/op", h.results.CompareBaseline(baseline, benchAlpha, threshold),
+		})
+	}
+	if mimic {
+		sections = append(sections, benchSection{
+			"mimic libraries", "mimic ns/op", h.results.CompareMimic(benchAlpha, threshold),
+		})
+	}
+
+	for _, s := range sections {
+		fmt.Fprintf(w, "# Compared to %s (alpha=%g, threshold=%g%%):\n", s.title, benchAlpha, 100*threshold)
+		fmt.Fprintf(w, "# %-46s %19s  %19s  %8s\n", "name", s.oldColumn, "ns/op", "delta")
+		if len(s.comparisons) == 0 {
+			fmt.Fprintf(w, "# (no matching benchmarks)\n")
+		}
+		for _, c := range s.comparisons {
+			fmt.Fprintf(w, "%s\n", c)
+			regressed = regressed || c.Regression
+		}
+	}
+	return regressed, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/wuffs/cmd/wuffs/internal/benchstat"
+)
+
+// benchOutput has a Wuffs benchmark that is significantly slower than its
+// mimic library counterpart.
+const benchOutput = `# std/adler32
+# gcc version 7.2.0
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5200 ns/op	    2130.288 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4000 ns/op	    2178.321 MB/s
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5300 ns/op	    2339.389 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4100 ns/op	    2324.059 MB/s
+Benchmarkwuffs_adler32_10k/gcc	    7500	    5250 ns/op	    2252.522 MB/s
+Benchmarkmimic_adler32_10k/gcc	    7500	    4050 ns/op	    2338.339 MB/s
+`
+
+// TestReportMimicWithoutBaseline checks that the mimic libraries are compared
+// against, and regressions reported, even without a baseline.
+func TestReportMimicWithoutBaseline(tt *testing.T) {
+	testCases := []struct {
+		mimic         bool
+		wantRegressed bool
+		wantSection   bool
+	}{
+		{false, false, false},
+		{true, true, true},
+	}
+
+	for _, tc := range testCases {
+		h := &testHelper{results: &benchstat.Set{}}
+		if err := h.results.Parse(strings.NewReader(benchOutput)); err != nil {
+			tt.Fatalf("Parse: %v", err)
+		}
+		w := &bytes.Buffer{}
+		regressed, err := h.report("text", nil, tc.mimic, 0.05, w)
+		if err != nil {
+			tt.Errorf("mimic=%t: report: %v", tc.mimic, err)
+			continue
+		}
+		if regressed != tc.wantRegressed {
+			tt.Errorf("mimic=%t: regressed: got %t, want %t", tc.mimic, regressed, tc.wantRegressed)
+		}
+		out := w.String()
+		if got := strings.Contains(out, "# Compared to mimic libraries"); got != tc.wantSection {
+			tt.Errorf("mimic=%t: mimic section: got %t, want %t\n%s", tc.mimic, got, tc.wantSection, out)
+		}
+		if strings.Contains(out, "# Compared to baseline") {
+			tt.Errorf("mimic=%t: got a baseline section\n%s", tc.mimic, out)
+		}
+		if tc.mimic && !strings.Contains(out, "wuffs_adler32_10k") {
+			tt.Errorf("mimic=%t: no wuffs_adler32_10k comparison\n%s", tc.mimic, out)
+		}
+	}
+}
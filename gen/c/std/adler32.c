@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,9 +1390,20 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1210,24 +1423,21 @@ typedef int32_t wuffs_adler32__status;
 
 #define wuffs_adler32__packageid 681002  // 0x000A642A
 
-#define WUFFS_ADLER32__STATUS_OK 0                            // 0x00000000
-#define WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_ADLER32__STATUS_OK 0  // 0x00000000
+#define WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_ADLER32__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_ADLER32__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                   // 0x80000006
+#define WUFFS_ADLER32__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_ADLER32__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
 #define WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
-#define WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES -2147483640      // 0x80000008
-#define WUFFS_ADLER32__ERROR_UNEXPECTED_EOF -2147483639         // 0x80000009
-#define WUFFS_ADLER32__SUSPENSION_SHORT_READ 10                 // 0x0000000A
-#define WUFFS_ADLER32__SUSPENSION_SHORT_WRITE 11                // 0x0000000B
-#define WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION \
-  -2147483636                                                   // 0x8000000C
+#define WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_ADLER32__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_ADLER32__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_ADLER32__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
 #define WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
-#define WUFFS_ADLER32__SUSPENSION_END_OF_DATA 14                // 0x0000000E
+#define WUFFS_ADLER32__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
 bool wuffs_adler32__status__is_error(wuffs_adler32__status s);
 
@@ -1251,10 +1461,13 @@ typedef struct {
 
     uint32_t f_state;
     bool f_started;
-
   } private_impl;
 } wuffs_adler32__hasher;
 
+// WUFFS_ADLER32__HASHER__STATE_LENGTH is the length of a
+// wuffs_adler32__hasher's saved state.
+#define WUFFS_ADLER32__HASHER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 9)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_adler32__hasher__check_wuffs_version is an initializer function.
@@ -1263,18 +1476,135 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_adler32__hasher__check_wuffs_version(wuffs_adler32__hasher* self,
-                                                size_t sizeof_star_self,
-                                                uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_adler32__hasher returns sizeof(wuffs_adler32__hasher), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_adler32__hasher(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_adler32__hasher__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ADLER32__HASHER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_adler32__hasher__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_adler32__status wuffs_adler32__hasher__save_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_adler32__hasher__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_adler32__hasher__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_adler32__status wuffs_adler32__hasher__restore_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
-                                       wuffs_base__slice_u8 a_x);
+    wuffs_base__slice_u8 a_x);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace adler32 {
+
+// status is a strongly typed wuffs_adler32__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_ADLER32__STATUS_OK) {}
+  constexpr explicit status(wuffs_adler32__status repr) : repr_(repr) {}
+
+  constexpr wuffs_adler32__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_ADLER32__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_adler32__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_adler32__status repr_;
+};
+
+constexpr status status_ok(WUFFS_ADLER32__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_ADLER32__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_ADLER32__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_ADLER32__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_ADLER32__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_ADLER32__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_ADLER32__SUSPENSION_END_OF_DATA);
+
+// hasher is an RAII wrapper for a wuffs_adler32__hasher. Its constructor
+// calls wuffs_adler32__hasher__check_wuffs_version.
+class hasher {
+ public:
+  hasher() : c_() {
+    wuffs_adler32__hasher__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  hasher(const hasher&) = delete;
+  hasher& operator=(const hasher&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_adler32__hasher* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_adler32__hasher__save_state and
+  // wuffs_adler32__hasher__restore_state. A saved state is
+  // WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_adler32__hasher__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_adler32__hasher__restore_state(&c_, src));
+  }
+
+  uint32_t update(wuffs_base__slice_u8 x) {
+    return wuffs_adler32__hasher__update(&c_, x);
+  }
+
+ private:
+  wuffs_adler32__hasher c_;
+};
+
+}  // namespace adler32
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_ADLER32_H
 
 // C HEADER ENDS HERE.
@@ -1302,6 +1632,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -1451,6 +1788,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -1793,6 +2153,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -1836,22 +2216,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -1885,13 +2306,18 @@ const char* wuffs_adler32__status__string(wuffs_adler32__status s) {
 
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_adler32__hasher(void) {
+  return sizeof(wuffs_adler32__hasher);
+}
+
 void wuffs_adler32__hasher__check_wuffs_version(wuffs_adler32__hasher* self,
-                                                size_t sizeof_star_self,
-                                                uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -1916,7 +2342,7 @@ void wuffs_adler32__hasher__check_wuffs_version(wuffs_adler32__hasher* self,
 // -------- func hasher.update
 
 uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
-                                       wuffs_base__slice_u8 a_x) {
+    wuffs_base__slice_u8 a_x) {
   if (!self) {
     return 0;
   }
@@ -1990,3 +2416,68 @@ uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
   self->private_impl.f_state = (((v_s2 & 65535) << 16) | (v_s1 & 65535));
   return self->private_impl.f_state;
 }
+
+// ---------------- Saved State Implementations
+
+// -------- func hasher.save_state
+
+wuffs_adler32__status wuffs_adler32__hasher__save_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_ADLER32__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_ADLER32__HASHER__STATE_LENGTH) {
+    return WUFFS_ADLER32__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_state));
+  p += 4;
+  p[0] = self->private_impl.f_started ? 1 : 0;
+  p += 1;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x5ED7E687,
+      WUFFS_ADLER32__HASHER__STATE_LENGTH);
+  return WUFFS_ADLER32__STATUS_OK;
+}
+
+// -------- func hasher.restore_state
+
+wuffs_adler32__status wuffs_adler32__hasher__restore_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_ADLER32__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x5ED7E687,
+      WUFFS_ADLER32__HASHER__STATE_LENGTH)) {
+    return WUFFS_ADLER32__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  self->private_impl.status =
+      (wuffs_adler32__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_state = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_started = p[0];
+  p += 1;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_ADLER32__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_ADLER32__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
+    }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
     }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,9 +1390,20 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1210,54 +1423,40 @@ typedef int32_t wuffs_deflate__status;
 
 #define wuffs_deflate__packageid 848533  // 0x000CF295
 
-#define WUFFS_DEFLATE__STATUS_OK 0                            // 0x00000000
-#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_DEFLATE__STATUS_OK 0  // 0x00000000
+#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                   // 0x80000006
+#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
 #define WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
-#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640      // 0x80000008
-#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639         // 0x80000009
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10                 // 0x0000000A
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11                // 0x0000000B
-#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION \
-  -2147483636                                                   // 0x8000000C
+#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
 #define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
-#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14                // 0x0000000E
-
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED \
-  -1278585856  // 0xB3CA5400
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED \
-  -1278585855  // 0xB3CA5401
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT \
-  -1278585854  // 0xB3CA5402
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION \
-  -1278585853                                              // 0xB3CA5403
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED -1278585856  // 0xB3CA5400
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED -1278585855  // 0xB3CA5401
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT -1278585854  // 0xB3CA5402
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION -1278585853  // 0xB3CA5403
 #define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE -1278585852  // 0xB3CA5404
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH \
-  -1278585851                                                     // 0xB3CA5405
-#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850                // 0xB3CA5406
-#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849             // 0xB3CA5407
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH -1278585851  // 0xB3CA5405
+#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850  // 0xB3CA5406
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849  // 0xB3CA5407
 #define WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT -1278585848  // 0xB3CA5408
-#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT \
-  -1278585847  // 0xB3CA5409
-#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH \
-  -1278585846  // 0xB3CA540A
-#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE \
-  -1278585845                                              // 0xB3CA540B
+#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT -1278585847  // 0xB3CA5409
+#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH -1278585846  // 0xB3CA540A
+#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE -1278585845  // 0xB3CA540B
 #define WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES -1278585844  // 0xB3CA540C
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE \
-  -1278585843  // 0xB3CA540D
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK \
-  -1278585842  // 0xB3CA540E
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE \
-  -1278585841  // 0xB3CA540F
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS \
-  -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE -1278585843  // 0xB3CA540D
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK -1278585842  // 0xB3CA540E
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE -1278585841  // 0xB3CA540F
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE -1278585839  // 0xB3CA5411
 
 bool wuffs_deflate__status__is_error(wuffs_deflate__status s);
 
@@ -1291,8 +1490,6 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       wuffs_deflate__status v_z;
-      uint64_t v_n_copied;
-      uint32_t v_already_full;
     } c_decode[1];
     struct {
       uint32_t coro_susp_point;
@@ -1343,6 +1540,102 @@ typedef struct {
   } private_impl;
 } wuffs_deflate__decoder;
 
+// WUFFS_DEFLATE__DECODER__STATE_LENGTH is the length of a
+// wuffs_deflate__decoder's saved state.
+#define WUFFS_DEFLATE__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 43138)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_level;
+    bool f_level_set;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_window[65536];
+    uint32_t f_w_pos;
+    uint32_t f_w_end;
+    uint32_t f_block_start;
+    uint16_t f_head[32768];
+    uint16_t f_prev[32768];
+    uint32_t f_ins_pos;
+    uint32_t f_tokens[16384];
+    uint32_t f_n_tokens;
+    uint32_t f_freqs[3][288];
+    uint8_t f_lens[3][288];
+    uint16_t f_codes[3][288];
+    uint32_t f_n_lcodes;
+    uint32_t f_n_dcodes;
+    uint32_t f_n_clcodes;
+    uint16_t f_clseq[320];
+    uint32_t f_n_clseq;
+    uint32_t f_hsort_keys[512];
+    uint16_t f_hsort_syms[512];
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_window[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_stored;
+      bool v_fixed;
+      uint64_t v_n_extra_bits;
+      uint64_t v_fixed_cost;
+      uint64_t v_dynamic_cost;
+      uint64_t v_n_bytes;
+      uint64_t v_stored_cost;
+    } c_write_block[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_start;
+      uint32_t v_end;
+      uint32_t v_remaining;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint32_t v_new_start;
+      uint64_t scratch;
+    } c_write_stored[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_symbol;
+      uint32_t v_n_extra_bits;
+    } c_write_dynamic_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_t;
+      uint32_t v_length;
+      uint32_t v_lc;
+      uint32_t v_d;
+      uint32_t v_dc;
+      uint32_t v_magic;
+    } c_write_tokens[1];
+  } private_impl;
+} wuffs_deflate__encoder;
+
+// WUFFS_DEFLATE__ENCODER__STATE_LENGTH is the length of a
+// wuffs_deflate__encoder's saved state.
+#define WUFFS_DEFLATE__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 272109)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_deflate__decoder__check_wuffs_version is an initializer function.
@@ -1351,20 +1644,255 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
-                                                 size_t sizeof_star_self,
-                                                 uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__decoder returns sizeof(wuffs_deflate__decoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__decoder(void);
+
+// wuffs_deflate__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_deflate__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_deflate__encoder__check_wuffs_version(wuffs_deflate__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__encoder returns sizeof(wuffs_deflate__encoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_deflate__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__decoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__decoder__save_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__decoder__restore_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_src);
+
+// wuffs_deflate__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__encoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__encoder__save_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__encoder__restore_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 wuffs_deflate__status wuffs_deflate__decoder__decode(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+    wuffs_base__slice_u8 a_hist);
+
+void wuffs_deflate__encoder__set_level(wuffs_deflate__encoder* self,
+    uint32_t a_level);
+
+wuffs_deflate__status wuffs_deflate__encoder__encode(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace deflate {
+
+// status is a strongly typed wuffs_deflate__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_DEFLATE__STATUS_OK) {}
+  constexpr explicit status(wuffs_deflate__status repr) : repr_(repr) {}
+
+  constexpr wuffs_deflate__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_DEFLATE__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_deflate__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_deflate__status repr_;
+};
+
+constexpr status status_ok(WUFFS_DEFLATE__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_DEFLATE__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_DEFLATE__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_DEFLATE__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_DEFLATE__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_huffman_code_over_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_under_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_length_count(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT);
+constexpr status error_bad_huffman_code_length_repetition(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION);
+constexpr status error_bad_huffman_code(WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE);
+constexpr status error_bad_huffman_minimum_code_length(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH);
+constexpr status error_bad_block(WUFFS_DEFLATE__ERROR_BAD_BLOCK);
+constexpr status error_bad_distance(WUFFS_DEFLATE__ERROR_BAD_DISTANCE);
+constexpr status error_bad_distance_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT);
+constexpr status error_bad_literal_length_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT);
+constexpr status error_inconsistent_stored_block_length(
+    WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH);
+constexpr status error_missing_end_of_block_code(
+    WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE);
+constexpr status error_no_huffman_codes(WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES);
+constexpr status error_internal_error_inconsistent_huffman_decoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE);
+constexpr status error_internal_error_inconsistent_huffman_end_of_block(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK);
+constexpr status error_internal_error_inconsistent_distance(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE);
+constexpr status error_internal_error_inconsistent_n_bits(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS);
+constexpr status error_internal_error_inconsistent_encoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE);
+
+// decoder is an RAII wrapper for a wuffs_deflate__decoder. Its constructor
+// calls wuffs_deflate__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_deflate__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__decoder__save_state and
+  // wuffs_deflate__decoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__decoder__restore_state(&c_, src));
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__decoder__decode(&c_, dst, src));
+  }
+
+  void add_history(wuffs_base__slice_u8 hist) {
+    wuffs_deflate__decoder__add_history(&c_, hist);
+  }
+
+ private:
+  wuffs_deflate__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_deflate__encoder. Its constructor
+// calls wuffs_deflate__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_deflate__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__encoder__save_state and
+  // wuffs_deflate__encoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_deflate__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_deflate__encoder c_;
+};
+
+}  // namespace deflate
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_DEFLATE_H
 
 // C HEADER ENDS HERE.
@@ -1392,6 +1920,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -1541,6 +2076,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -1883,6 +2441,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -1926,22 +2504,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -1952,24 +2571,21 @@ bool wuffs_deflate__status__is_error(wuffs_deflate__status s) {
   return s < 0;
 }
 
-const char* wuffs_deflate__status__strings[17] = {
+const char* wuffs_deflate__status__strings[18] = {
     "deflate: bad Huffman code (over-subscribed)",
     "deflate: bad Huffman code (under-subscribed)",
     "deflate: bad Huffman code length count",
-    "deflate: bad Huffman code length repetition",
-    "deflate: bad Huffman code",
-    "deflate: bad Huffman minimum code length",
-    "deflate: bad block",
-    "deflate: bad distance",
-    "deflate: bad distance code count",
+    "deflate: bad Huffman code length repetition", "deflate: bad Huffman code",
+    "deflate: bad Huffman minimum code length", "deflate: bad block",
+    "deflate: bad distance", "deflate: bad distance code count",
     "deflate: bad literal/length code count",
     "deflate: inconsistent stored block length",
-    "deflate: missing end-of-block code",
-    "deflate: no Huffman codes",
+    "deflate: missing end-of-block code", "deflate: no Huffman codes",
     "deflate: internal error: inconsistent Huffman decoder state",
     "deflate: internal error: inconsistent Huffman end_of_block",
     "deflate: internal error: inconsistent distance",
     "deflate: internal error: inconsistent n_bits",
+    "deflate: internal error: inconsistent encoder state",
 };
 
 const char* wuffs_deflate__status__string(wuffs_deflate__status s) {
@@ -1982,7 +2598,7 @@ const char* wuffs_deflate__status__string(wuffs_deflate__status s) {
       break;
     case wuffs_deflate__packageid:
       a = wuffs_deflate__status__strings;
-      n = 17;
+      n = 18;
       break;
   }
   uint32_t i = s & 0xFF;
@@ -1996,22 +2612,22 @@ static const uint8_t wuffs_deflate__code_order[19] = {
 };
 
 static const uint8_t wuffs_deflate__reverse8[256] = {
-    0,  128, 64, 192, 32, 160, 96,  224, 16, 144, 80, 208, 48, 176, 112, 240,
-    8,  136, 72, 200, 40, 168, 104, 232, 24, 152, 88, 216, 56, 184, 120, 248,
-    4,  132, 68, 196, 36, 164, 100, 228, 20, 148, 84, 212, 52, 180, 116, 244,
-    12, 140, 76, 204, 44, 172, 108, 236, 28, 156, 92, 220, 60, 188, 124, 252,
-    2,  130, 66, 194, 34, 162, 98,  226, 18, 146, 82, 210, 50, 178, 114, 242,
-    10, 138, 74, 202, 42, 170, 106, 234, 26, 154, 90, 218, 58, 186, 122, 250,
-    6,  134, 70, 198, 38, 166, 102, 230, 22, 150, 86, 214, 54, 182, 118, 246,
-    14, 142, 78, 206, 46, 174, 110, 238, 30, 158, 94, 222, 62, 190, 126, 254,
-    1,  129, 65, 193, 33, 161, 97,  225, 17, 145, 81, 209, 49, 177, 113, 241,
-    9,  137, 73, 201, 41, 169, 105, 233, 25, 153, 89, 217, 57, 185, 121, 249,
-    5,  133, 69, 197, 37, 165, 101, 229, 21, 149, 85, 213, 53, 181, 117, 245,
-    13, 141, 77, 205, 45, 173, 109, 237, 29, 157, 93, 221, 61, 189, 125, 253,
-    3,  131, 67, 195, 35, 163, 99,  227, 19, 147, 83, 211, 51, 179, 115, 243,
-    11, 139, 75, 203, 43, 171, 107, 235, 27, 155, 91, 219, 59, 187, 123, 251,
-    7,  135, 71, 199, 39, 167, 103, 231, 23, 151, 87, 215, 55, 183, 119, 247,
-    15, 143, 79, 207, 47, 175, 111, 239, 31, 159, 95, 223, 63, 191, 127, 255,
+    0, 128, 64, 192, 32, 160, 96, 224, 16, 144, 80, 208, 48, 176, 112, 240, 8,
+    136, 72, 200, 40, 168, 104, 232, 24, 152, 88, 216, 56, 184, 120, 248, 4,
+    132, 68, 196, 36, 164, 100, 228, 20, 148, 84, 212, 52, 180, 116, 244, 12,
+    140, 76, 204, 44, 172, 108, 236, 28, 156, 92, 220, 60, 188, 124, 252, 2,
+    130, 66, 194, 34, 162, 98, 226, 18, 146, 82, 210, 50, 178, 114, 242, 10,
+    138, 74, 202, 42, 170, 106, 234, 26, 154, 90, 218, 58, 186, 122, 250, 6,
+    134, 70, 198, 38, 166, 102, 230, 22, 150, 86, 214, 54, 182, 118, 246, 14,
+    142, 78, 206, 46, 174, 110, 238, 30, 158, 94, 222, 62, 190, 126, 254, 1,
+    129, 65, 193, 33, 161, 97, 225, 17, 145, 81, 209, 49, 177, 113, 241, 9, 137,
+    73, 201, 41, 169, 105, 233, 25, 153, 89, 217, 57, 185, 121, 249, 5, 133, 69,
+    197, 37, 165, 101, 229, 21, 149, 85, 213, 53, 181, 117, 245, 13, 141, 77,
+    205, 45, 173, 109, 237, 29, 157, 93, 221, 61, 189, 125, 253, 3, 131, 67,
+    195, 35, 163, 99, 227, 19, 147, 83, 211, 51, 179, 115, 243, 11, 139, 75,
+    203, 43, 171, 107, 235, 27, 155, 91, 219, 59, 187, 123, 251, 7, 135, 71,
+    199, 39, 167, 103, 231, 23, 151, 87, 215, 55, 183, 119, 247, 15, 143, 79,
+    207, 47, 175, 111, 239, 31, 159, 95, 223, 63, 191, 127, 255,
 };
 
 static const uint32_t wuffs_deflate__lcode_magic_numbers[32] = {
@@ -2020,7 +2636,7 @@ static const uint32_t wuffs_deflate__lcode_magic_numbers[32] = {
     1073746720, 1073747744, 1073748768, 1073749792, 1073750832, 1073752880,
     1073754928, 1073756976, 1073759040, 1073763136, 1073767232, 1073771328,
     1073775440, 1073783632, 1073791824, 1073800016, 1073807872, 134217728,
-    134217728,  134217728,
+    134217728, 134217728,
 };
 
 static const uint32_t wuffs_deflate__dcode_magic_numbers[32] = {
@@ -2029,52 +2645,196 @@ static const uint32_t wuffs_deflate__dcode_magic_numbers[32] = {
     1073758288, 1073766480, 1073774688, 1073791072, 1073807472, 1073840240,
     1073873024, 1073938560, 1074004112, 1074135184, 1074266272, 1074528416,
     1074790576, 1075314864, 1075839168, 1076887744, 1077936336, 1080033488,
-    134217728,  134217728,
+    134217728, 134217728,
+};
+
+static const uint8_t wuffs_deflate__length_codes[256] = {
+    0, 1, 2, 3, 4, 5, 6, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 12, 12, 13, 13,
+    13, 13, 14, 14, 14, 14, 15, 15, 15, 15, 16, 16, 16, 16, 16, 16, 16, 16, 17,
+    17, 17, 17, 17, 17, 17, 17, 18, 18, 18, 18, 18, 18, 18, 18, 19, 19, 19, 19,
+    19, 19, 19, 19, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
+    20, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 21, 22, 22,
+    22, 22, 22, 22, 22, 22, 22, 22, 22, 22, 22, 22, 22, 22, 23, 23, 23, 23, 23,
+    23, 23, 23, 23, 23, 23, 23, 23, 23, 23, 23, 24, 24, 24, 24, 24, 24, 24, 24,
+    24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24,
+    24, 24, 24, 24, 24, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25,
+    25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25, 26,
+    26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+    26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 27, 27, 27, 27, 27, 27, 27,
+    27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27,
+    27, 27, 27, 27, 27, 28,
+};
+
+static const uint8_t wuffs_deflate__distance_codes[512] = {
+    0, 1, 2, 3, 4, 4, 5, 5, 6, 6, 6, 6, 7, 7, 7, 7, 8, 8, 8, 8, 8, 8, 8, 8, 9,
+    9, 9, 9, 9, 9, 9, 9, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10,
+    10, 10, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 12,
+    12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12,
+    12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 13, 13, 13, 13, 13, 13, 13,
+    13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
+    13, 13, 13, 13, 13, 13, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14,
+    14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14,
+    14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14,
+    14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 14, 15, 15, 15, 15, 15, 15,
+    15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+    15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+    15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+    15, 0, 0, 16, 17, 18, 18, 19, 19, 20, 20, 20, 20, 21, 21, 21, 21, 22, 22,
+    22, 22, 22, 22, 22, 22, 23, 23, 23, 23, 23, 23, 23, 23, 24, 24, 24, 24, 24,
+    24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 25, 25, 25, 25, 25, 25, 25, 25,
+    25, 25, 25, 25, 25, 25, 25, 25, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+    26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+    26, 26, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27,
+    27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 27, 28, 28, 28, 28,
+    28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+    28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+    28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+    28, 28, 28, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29,
+    29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29,
+    29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29, 29,
+    29, 29, 29, 29, 29, 29, 29, 29, 29, 29,
+};
+
+static const uint32_t wuffs_deflate__level_max_chains[10] = {
+    0, 4, 8, 32, 16, 32, 128, 256, 1024, 4096,
+};
+
+static const uint32_t wuffs_deflate__level_nice_lengths[10] = {
+    0, 8, 16, 32, 16, 32, 128, 128, 258, 258,
+};
+
+static const uint32_t wuffs_deflate__level_lazy_lengths[10] = {
+    0, 0, 0, 0, 4, 16, 16, 32, 128, 258,
 };
 
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_blocks(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_uncompressed(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 static wuffs_deflate__status wuffs_deflate__decoder__init_fixed_huffman(
     wuffs_deflate__decoder* self);
 
 static wuffs_deflate__status wuffs_deflate__decoder__init_dynamic_huffman(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_deflate__decoder* self, wuffs_base__io_reader a_src);
 
 static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
-    wuffs_deflate__decoder* self,
-    uint32_t a_which,
-    uint32_t a_n_codes0,
-    uint32_t a_n_codes1,
-    uint32_t a_base_symbol);
+    wuffs_deflate__decoder* self, uint32_t a_which, uint32_t a_n_codes0,
+    uint32_t a_n_codes1, uint32_t a_base_symbol);
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_fast(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
+static wuffs_deflate__status wuffs_deflate__encoder__fill_window(
+    wuffs_deflate__encoder* self, wuffs_base__io_reader a_src);
+
+static void wuffs_deflate__encoder__slide(wuffs_deflate__encoder* self);
+
+static void wuffs_deflate__encoder__tokenize(wuffs_deflate__encoder* self);
+
+static void wuffs_deflate__encoder__add_token(wuffs_deflate__encoder* self,
+    uint32_t a_t);
+
+static void wuffs_deflate__encoder__insert(wuffs_deflate__encoder* self,
+    uint32_t a_up_to);
+
+static uint32_t wuffs_deflate__encoder__find_match(wuffs_deflate__encoder* self,
+    uint32_t a_p);
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_block(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    uint32_t a_final);
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_stored(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    uint32_t a_final);
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_bits(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst, uint32_t a_x,
+    uint32_t a_n);
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_dynamic_header(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst);
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_tokens(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst);
+
+static uint32_t wuffs_deflate__encoder__dcode(wuffs_deflate__encoder* self,
+    uint32_t a_d);
+
+static uint64_t wuffs_deflate__encoder__count_frequencies(
+    wuffs_deflate__encoder* self);
+
+static uint64_t wuffs_deflate__encoder__fixed_cost(
+    wuffs_deflate__encoder* self);
+
+static void wuffs_deflate__encoder__init_fixed_huffman(
+    wuffs_deflate__encoder* self);
+
+static uint64_t wuffs_deflate__encoder__build_dynamic_huffman(
+    wuffs_deflate__encoder* self);
+
+static void wuffs_deflate__encoder__build_clseq(wuffs_deflate__encoder* self);
+
+static uint8_t wuffs_deflate__encoder__code_length(wuffs_deflate__encoder* self,
+    uint32_t a_i);
+
+static void wuffs_deflate__encoder__ensure_two_symbols(
+    wuffs_deflate__encoder* self, uint32_t a_which, uint32_t a_n);
+
+static void wuffs_deflate__encoder__build_huffman(wuffs_deflate__encoder* self,
+    uint32_t a_which, uint32_t a_n, uint32_t a_max_length);
+
+static void wuffs_deflate__encoder__build_codes(wuffs_deflate__encoder* self,
+    uint32_t a_which, uint32_t a_n);
+
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_deflate__decoder(void) {
+  return sizeof(wuffs_deflate__decoder);
+}
+
 void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
-                                                 size_t sizeof_star_self,
-                                                 uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+}
+
+size_t sizeof__wuffs_deflate__encoder(void) {
+  return sizeof(wuffs_deflate__encoder);
+}
+
+void wuffs_deflate__encoder__check_wuffs_version(wuffs_deflate__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -2099,8 +2859,7 @@ void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
 // -------- func decoder.decode
 
 wuffs_deflate__status wuffs_deflate__decoder__decode(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
@@ -2115,9 +2874,6 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
   wuffs_deflate__status v_z;
-  wuffs_base__slice_u8 v_written;
-  uint64_t v_n_copied;
-  uint32_t v_already_full;
 
   uint8_t* ioptr_dst = NULL;
   uint8_t* iobounds0orig_dst = NULL;
@@ -2141,11 +2897,7 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
   uint32_t coro_susp_point = self->private_impl.c_decode[0].coro_susp_point;
   if (coro_susp_point) {
     v_z = self->private_impl.c_decode[0].v_z;
-    v_written = ((wuffs_base__slice_u8){});
-    v_n_copied = self->private_impl.c_decode[0].v_n_copied;
-    v_already_full = self->private_impl.c_decode[0].v_already_full;
   } else {
-    v_written = ((wuffs_base__slice_u8){});
   }
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
@@ -2172,42 +2924,9 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
         }
         goto exit;
       }
-      v_written = ((wuffs_base__slice_u8){
-          .ptr = a_dst.private_impl.bounds[0],
-          .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-      });
-      if (((uint64_t)(v_written.len)) >= 32768) {
-        v_written = wuffs_base__slice_u8__suffix(v_written, 32768);
-        wuffs_base__slice_u8__copy_from_slice(
-            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                    .len = 32768}),
-            v_written);
-        self->private_impl.f_history_index = 32768;
-      } else {
-        v_n_copied = wuffs_base__slice_u8__copy_from_slice(
-            wuffs_base__slice_u8__subslice_i(
-                ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                        .len = 32768}),
-                (self->private_impl.f_history_index & 32767)),
-            v_written);
-        if (v_n_copied < ((uint64_t)(v_written.len))) {
-          v_written = wuffs_base__slice_u8__subslice_i(v_written, v_n_copied);
-          v_n_copied = wuffs_base__slice_u8__copy_from_slice(
-              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                      .len = 32768}),
-              v_written);
-          self->private_impl.f_history_index =
-              (((uint32_t)((v_n_copied & 32767))) + 32768);
-        } else {
-          v_already_full = 0;
-          if (self->private_impl.f_history_index >= 32768) {
-            v_already_full = 32768;
-          }
-          self->private_impl.f_history_index =
-              ((self->private_impl.f_history_index & 32767) +
-               ((uint32_t)((v_n_copied & 32767))) + v_already_full);
-        }
-      }
+      wuffs_deflate__decoder__add_history(self,
+          ((wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+          .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}));
       status = v_z;
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
     }
@@ -2222,8 +2941,6 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
 suspend:
   self->private_impl.c_decode[0].coro_susp_point = coro_susp_point;
   self->private_impl.c_decode[0].v_z = v_z;
-  self->private_impl.c_decode[0].v_n_copied = v_n_copied;
-  self->private_impl.c_decode[0].v_already_full = v_already_full;
 
   goto exit;
 exit:
@@ -2235,11 +2952,57 @@ exit:
   return status;
 }
 
+// -------- func decoder.add_history
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+    wuffs_base__slice_u8 a_hist) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  uint64_t v_n_copied;
+  uint32_t v_already_full;
+
+  if (((uint64_t)(a_hist.len)) >= 32768) {
+    a_hist = wuffs_base__slice_u8__suffix(a_hist, 32768);
+    wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){
+        .ptr = self->private_impl.f_history, .len = 32768}), a_hist);
+    self->private_impl.f_history_index = 32768;
+  } else {
+    v_n_copied = wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){
+        .ptr = self->private_impl.f_history, .len = 32768}),
+        (self->private_impl.f_history_index & 32767)), a_hist);
+    if (v_n_copied < ((uint64_t)(a_hist.len))) {
+      a_hist = wuffs_base__slice_u8__subslice_i(a_hist, v_n_copied);
+      v_n_copied =
+          wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){
+          .ptr = self->private_impl.f_history, .len = 32768}), a_hist);
+      self->private_impl.f_history_index =
+          (((uint32_t)((v_n_copied & 32767))) + 32768);
+    } else {
+      v_already_full = 0;
+      if (self->private_impl.f_history_index >= 32768) {
+        v_already_full = 32768;
+      }
+      self->private_impl.f_history_index =
+          ((self->private_impl.f_history_index & 32767) +
+          ((uint32_t)((v_n_copied & 32767))) + v_already_full);
+    }
+  }
+}
+
 // -------- func decoder.decode_blocks
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_blocks(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
@@ -2393,8 +3156,7 @@ short_read_src:
 // -------- func decoder.decode_uncompressed
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_uncompressed(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
@@ -2488,8 +3250,8 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_uncompressed(
     }
     v_length = ((v_length) & ((1 << (16)) - 1));
     while (true) {
-      v_n_copied = wuffs_base__io_writer__copy_from_reader32(
-          &ioptr_dst, iobounds1_dst, &ioptr_src, iobounds1_src, v_length);
+      v_n_copied = wuffs_base__io_writer__copy_from_reader32(&ioptr_dst,
+          iobounds1_dst, &ioptr_src, iobounds1_src, v_length);
       if (v_length <= v_n_copied) {
         status = WUFFS_DEFLATE__STATUS_OK;
         goto ok;
@@ -2604,8 +3366,7 @@ exit:
 // -------- func decoder.init_dynamic_huffman
 
 static wuffs_deflate__status wuffs_deflate__decoder__init_dynamic_huffman(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_deflate__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
   uint32_t v_bits;
@@ -2813,7 +3574,7 @@ static wuffs_deflate__status wuffs_deflate__decoder__init_dynamic_huffman(
     }
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
     status = wuffs_deflate__decoder__init_huff(self, 1, v_n_lit,
-                                               (v_n_lit + v_n_dist), 0);
+        (v_n_lit + v_n_dist), 0);
     if (status) {
       goto suspend;
     }
@@ -2864,11 +3625,8 @@ short_read_src:
 // -------- func decoder.init_huff
 
 static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
-    wuffs_deflate__decoder* self,
-    uint32_t a_which,
-    uint32_t a_n_codes0,
-    uint32_t a_n_codes1,
-    uint32_t a_base_symbol) {
+    wuffs_deflate__decoder* self, uint32_t a_which, uint32_t a_n_codes0,
+    uint32_t a_n_codes1, uint32_t a_base_symbol) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
   uint16_t v_counts[16];
@@ -2929,8 +3687,11 @@ static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
     v_i += 1;
   }
   if (v_remaining != 0) {
-    status = WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
-    goto exit;
+    if ((a_which != 1) || (v_counts[1] != 1) || (v_remaining != 16384)) {
+      status = WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
+      goto exit;
+    }
+    self->private_impl.f_huffs[1][1] = 134217728;
   }
   memset(v_offsets, 0, sizeof(v_offsets));
   v_n_symbols = 0;
@@ -3081,7 +3842,7 @@ label_1_break:;
         v_next_top = (v_top + (((uint32_t)(1)) << v_tmp));
         v_redirect_key =
             (((uint32_t)(wuffs_deflate__reverse8[(v_redirect_key >> 1)])) |
-             ((v_redirect_key & 1) << 8));
+            ((v_redirect_key & 1) << 8));
         self->private_impl.f_huffs[a_which][v_redirect_key] =
             (268435465 | (v_top << 8) | (v_tmp << 4));
       }
@@ -3093,7 +3854,7 @@ label_1_break:;
     }
     v_counts[v_prev_cl] -= 1;
     v_reversed_key = (((uint32_t)(wuffs_deflate__reverse8[(v_key >> 1)])) |
-                      ((v_key & 1) << 8));
+        ((v_key & 1) << 8));
     v_reversed_key >>= (9 - v_cl);
     v_symbol = ((uint32_t)(v_symbols[v_i]));
     if (v_symbol == 256) {
@@ -3121,9 +3882,8 @@ label_1_break:;
             WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
         goto exit;
       }
-      self->private_impl
-          .f_huffs[a_which][(v_top + ((v_high_bits | v_reversed_key) & 511))] =
-          v_value;
+      self->private_impl.f_huffs[a_which][(
+          v_top + ((v_high_bits | v_reversed_key) & 511))] = v_value;
     }
     v_i += 1;
     if (v_i >= v_n_symbols) {
@@ -3145,8 +3905,7 @@ exit:
 // -------- func decoder.decode_huffman_fast
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_fast(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
@@ -3211,7 +3970,7 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_fast(
   v_dmask = ((((uint32_t)(1)) << self->private_impl.f_n_huffs_bits[1]) - 1);
 label_0_continue:;
   while ((((uint64_t)(iobounds1_dst - ioptr_dst)) >= 258) &&
-         (((uint64_t)(iobounds1_src - ioptr_src)) >= 12)) {
+      (((uint64_t)(iobounds1_src - ioptr_src)) >= 12)) {
     if (v_n_bits < 15) {
       {
         uint8_t t_0 = *ioptr_src++;
@@ -3257,8 +4016,8 @@ label_0_continue:;
             WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
         goto exit;
       }
-      v_table_entry = self->private_impl
-                          .f_huffs[0][(v_redir_top + (v_bits & v_redir_mask))];
+      v_table_entry = self->private_impl.f_huffs[0][(
+          v_redir_top + (v_bits & v_redir_mask))];
       v_table_entry_n_bits = (v_table_entry & 15);
       v_bits >>= v_table_entry_n_bits;
       v_n_bits -= v_table_entry_n_bits;
@@ -3307,7 +4066,7 @@ label_0_continue:;
       }
       v_length =
           ((v_length + ((v_bits) & ((1 << (v_table_entry_n_bits)) - 1))) &
-           32767);
+          32767);
       v_bits >>= v_table_entry_n_bits;
       v_n_bits -= v_table_entry_n_bits;
     } else {
@@ -3355,8 +4114,8 @@ label_0_continue:;
             WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
         goto exit;
       }
-      v_table_entry = self->private_impl
-                          .f_huffs[1][(v_redir_top + (v_bits & v_redir_mask))];
+      v_table_entry = self->private_impl.f_huffs[1][(
+          v_redir_top + (v_bits & v_redir_mask))];
       v_table_entry_n_bits = (v_table_entry & 15);
       v_bits >>= v_table_entry_n_bits;
       v_n_bits -= v_table_entry_n_bits;
@@ -3388,28 +4147,20 @@ label_0_continue:;
       }
       v_dist_minus_1 =
           ((v_dist_minus_1 + ((v_bits) & ((1 << (v_table_entry_n_bits)) - 1))) &
-           32767);
+          32767);
       v_bits >>= v_table_entry_n_bits;
       v_n_bits -= v_table_entry_n_bits;
     }
     v_n_copied = 0;
     while (true) {
-      if (((uint64_t)((v_dist_minus_1 + 1))) >
-          ((uint64_t)(
-              ((wuffs_base__slice_u8){
-                   .ptr = a_dst.private_impl.bounds[0],
-                   .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-               })
-                  .len))) {
+      if (((uint64_t)((v_dist_minus_1 + 1))) > ((
+          uint64_t)(((wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+          .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}).len))) {
         v_hlen = 0;
-        v_hdist = ((uint32_t)(
-            (((uint64_t)((v_dist_minus_1 + 1))) -
-             ((uint64_t)(
-                 ((wuffs_base__slice_u8){
-                      .ptr = a_dst.private_impl.bounds[0],
-                      .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-                  })
-                     .len)))));
+        v_hdist =
+            ((uint32_t)((((uint64_t)((v_dist_minus_1 + 1))) - ((uint64_t)(((
+            wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0], .len =
+            (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}).len)))));
         if (v_length > v_hdist) {
           v_length -= v_hdist;
           v_hlen = v_hdist;
@@ -3428,42 +4179,38 @@ label_0_continue:;
         }
         v_hdist = (self->private_impl.f_history_index - v_hdist);
         while (true) {
-          v_n_copied = wuffs_base__io_writer__copy_from_slice32(
-              &ioptr_dst, iobounds1_dst,
-              wuffs_base__slice_u8__subslice_i(
-                  ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                          .len = 32768}),
-                  (v_hdist & 32767)),
+          v_n_copied = wuffs_base__io_writer__copy_from_slice32(&ioptr_dst,
+              iobounds1_dst,
+              wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){.ptr =
+              self->private_impl.f_history, .len = 32768}), (v_hdist & 32767)),
               v_hlen);
           if (v_hlen <= v_n_copied) {
             goto label_1_break;
           }
           v_hlen -= v_n_copied;
-          wuffs_base__io_writer__copy_from_slice32(
-              &ioptr_dst, iobounds1_dst,
+          wuffs_base__io_writer__copy_from_slice32(&ioptr_dst, iobounds1_dst,
               ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                      .len = 32768}),
-              v_hlen);
+              .len = 32768}), v_hlen);
           goto label_1_break;
         }
       label_1_break:;
         if (v_length == 0) {
           goto label_0_continue;
         }
-        if (((uint64_t)((v_dist_minus_1 + 1))) >
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = a_dst.private_impl.bounds[0],
-                     .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-                 })
-                    .len))) {
+        if (((uint64_t)((v_dist_minus_1 + 1))) > ((uint64_t)(((
+            wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+            .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}).len))) {
           status = WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE;
           goto exit;
         }
       }
-      wuffs_base__io_writer__copy_from_history32__bco(
-          &ioptr_dst, a_dst.private_impl.bounds[0], iobounds1_dst,
-          (v_dist_minus_1 + 1), v_length);
+      if (((uint64_t)(v_length)) > ((uint64_t)(iobounds1_dst - ioptr_dst))) {
+        status = WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE;
+        goto exit;
+      }
+      wuffs_base__io_writer__copy_from_history32__bco(&ioptr_dst,
+          a_dst.private_impl.bounds[0], iobounds1_dst, (v_dist_minus_1 + 1),
+          v_length);
       goto label_2_break;
     }
   label_2_break:;
@@ -3499,8 +4246,7 @@ exit:
 // -------- func decoder.decode_huffman_slow
 
 static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src) {
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
@@ -3627,9 +4373,8 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
                 WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
             goto exit;
           }
-          v_table_entry =
-              self->private_impl
-                  .f_huffs[0][(v_redir_top + (v_bits & v_redir_mask))];
+          v_table_entry = self->private_impl.f_huffs[0][(
+              v_redir_top + (v_bits & v_redir_mask))];
           v_table_entry_n_bits = (v_table_entry & 15);
           if (v_n_bits >= v_table_entry_n_bits) {
             v_bits >>= v_table_entry_n_bits;
@@ -3695,7 +4440,7 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
         }
         v_length =
             ((v_length + ((v_bits) & ((1 << (v_table_entry_n_bits)) - 1))) &
-             32767);
+            32767);
         v_bits >>= v_table_entry_n_bits;
         v_n_bits -= v_table_entry_n_bits;
       }
@@ -3727,9 +4472,8 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
                 WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
             goto exit;
           }
-          v_table_entry =
-              self->private_impl
-                  .f_huffs[1][(v_redir_top + (v_bits & v_redir_mask))];
+          v_table_entry = self->private_impl.f_huffs[1][(
+              v_redir_top + (v_bits & v_redir_mask))];
           v_table_entry_n_bits = (v_table_entry & 15);
           if (v_n_bits >= v_table_entry_n_bits) {
             v_bits >>= v_table_entry_n_bits;
@@ -3772,29 +4516,20 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
           v_n_bits += 8;
         }
         v_dist_minus_1 = ((v_dist_minus_1 +
-                           ((v_bits) & ((1 << (v_table_entry_n_bits)) - 1))) &
-                          32767);
+            ((v_bits) & ((1 << (v_table_entry_n_bits)) - 1))) & 32767);
         v_bits >>= v_table_entry_n_bits;
         v_n_bits -= v_table_entry_n_bits;
       }
       v_n_copied = 0;
       while (true) {
-        if (((uint64_t)((v_dist_minus_1 + 1))) >
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = a_dst.private_impl.bounds[0],
-                     .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-                 })
-                    .len))) {
+        if (((uint64_t)((v_dist_minus_1 + 1))) > ((uint64_t)(((
+            wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+            .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}).len))) {
           v_hlen = 0;
-          v_hdist = ((uint32_t)(
-              (((uint64_t)((v_dist_minus_1 + 1))) -
-               ((uint64_t)(((wuffs_base__slice_u8){
-                                .ptr = a_dst.private_impl.bounds[0],
-                                .len = (size_t)(ioptr_dst -
-                                                a_dst.private_impl.bounds[0]),
-                            })
-                               .len)))));
+          v_hdist =
+              ((uint32_t)((((uint64_t)((v_dist_minus_1 + 1))) - ((uint64_t)(((
+              wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0], .len =
+              (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}).len)))));
           if (v_length > v_hdist) {
             v_length -= v_hdist;
             v_hlen = v_hdist;
@@ -3808,13 +4543,10 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
           }
           v_hdist = (self->private_impl.f_history_index - v_hdist);
           while (true) {
-            v_n_copied = wuffs_base__io_writer__copy_from_slice32(
-                &ioptr_dst, iobounds1_dst,
-                wuffs_base__slice_u8__subslice_i(
-                    ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                            .len = 32768}),
-                    (v_hdist & 32767)),
-                v_hlen);
+            v_n_copied = wuffs_base__io_writer__copy_from_slice32(&ioptr_dst,
+                iobounds1_dst, wuffs_base__slice_u8__subslice_i(((
+                wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
+                .len = 32768}), (v_hdist & 32767)), v_hlen);
             if (v_hlen <= v_n_copied) {
               v_hlen = 0;
               goto label_5_break;
@@ -3832,13 +4564,10 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
         label_5_break:;
           if (v_hlen > 0) {
             while (true) {
-              v_n_copied = wuffs_base__io_writer__copy_from_slice32(
-                  &ioptr_dst, iobounds1_dst,
-                  wuffs_base__slice_u8__subslice_i(
-                      ((wuffs_base__slice_u8){
-                          .ptr = self->private_impl.f_history, .len = 32768}),
-                      (v_hdist & 32767)),
-                  v_hlen);
+              v_n_copied = wuffs_base__io_writer__copy_from_slice32(&ioptr_dst,
+                  iobounds1_dst, wuffs_base__slice_u8__subslice_i(((
+                  wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
+                  .len = 32768}), (v_hdist & 32767)), v_hlen);
               if (v_hlen <= v_n_copied) {
                 v_hlen = 0;
                 goto label_6_break;
@@ -3854,9 +4583,9 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
             goto label_0_continue;
           }
         }
-        v_n_copied = wuffs_base__io_writer__copy_from_history32(
-            &ioptr_dst, a_dst.private_impl.bounds[0], iobounds1_dst,
-            (v_dist_minus_1 + 1), v_length);
+        v_n_copied = wuffs_base__io_writer__copy_from_history32(&ioptr_dst,
+            a_dst.private_impl.bounds[0], iobounds1_dst, (v_dist_minus_1 + 1),
+            v_length);
         if (v_length <= v_n_copied) {
           v_length = 0;
           goto label_7_break;
@@ -3919,3 +4648,2379 @@ short_read_src:
   status = WUFFS_DEFLATE__SUSPENSION_SHORT_READ;
   goto suspend;
 }
+
+// -------- func encoder.set_level
+
+void wuffs_deflate__encoder__set_level(wuffs_deflate__encoder* self,
+    uint32_t a_level) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  self->private_impl.f_level = wuffs_base__u32__min(a_level, 9);
+  self->private_impl.f_level_set = true;
+}
+
+// -------- func encoder.encode
+
+wuffs_deflate__status wuffs_deflate__encoder__encode(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  wuffs_deflate__status v_z;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_z = self->private_impl.c_encode[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (!self->private_impl.f_level_set) {
+      self->private_impl.f_level_set = true;
+      self->private_impl.f_level = 6;
+    }
+  label_0_continue:;
+    while (true) {
+      if (!self->private_impl.f_eof) {
+        {
+          wuffs_deflate__status t_0 =
+              wuffs_deflate__encoder__fill_window(self, a_src);
+          v_z = t_0;
+        }
+        if (v_z < 0) {
+          self->private_impl.f_eof = true;
+        } else if (v_z > 0) {
+          status = v_z;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+          goto label_0_continue;
+        }
+      }
+      wuffs_deflate__encoder__tokenize(self);
+      if (self->private_impl.f_eof &&
+          (self->private_impl.f_w_pos >= self->private_impl.f_w_end)) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status = wuffs_deflate__encoder__write_block(self, a_dst, 1);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+        if (self->private_impl.f_n_bits > 0) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+          if (ioptr_dst == iobounds1_dst) {
+            status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+            goto suspend;
+          }
+          *ioptr_dst++ = ((uint8_t)((self->private_impl.f_bits & 255)));
+          self->private_impl.f_bits = 0;
+          self->private_impl.f_n_bits = 0;
+        }
+        status = WUFFS_DEFLATE__STATUS_OK;
+        goto ok;
+      }
+      if (self->private_impl.f_n_tokens < 16383) {
+        if (self->private_impl.f_n_tokens > 0) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          if (a_dst.private_impl.buf) {
+            a_dst.private_impl.buf->wi =
+                ioptr_dst - a_dst.private_impl.buf->ptr;
+          }
+          status = wuffs_deflate__encoder__write_block(self, a_dst, 0);
+          if (a_dst.private_impl.buf) {
+            ioptr_dst =
+                a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+          }
+          if (status) {
+            goto suspend;
+          }
+        }
+        wuffs_deflate__encoder__slide(self);
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status = wuffs_deflate__encoder__write_block(self, a_dst, 0);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func encoder.fill_window
+
+static wuffs_deflate__status wuffs_deflate__encoder__fill_window(
+    wuffs_deflate__encoder* self, wuffs_base__io_reader a_src) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  wuffs_base__io_writer v_w;
+  wuffs_base__io_buffer u_w;
+  uint8_t* ioptr_w = NULL;
+  uint8_t* iobounds1_w = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_w);
+  uint8_t v_c;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_fill_window[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_w = ((wuffs_base__io_writer){});
+    v_c = self->private_impl.c_fill_window[0].v_c;
+  } else {
+    v_w = ((wuffs_base__io_writer){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+  label_0_continue:;
+    while (self->private_impl.f_w_end < 65536) {
+      if (((uint64_t)(iobounds1_src - ioptr_src)) > 0) {
+        v_w = ((wuffs_base__io_writer){});
+        {
+          wuffs_base__io_reader o_0_a_src = a_src;
+          wuffs_base__io_writer o_0_v_w = v_w;
+          uint8_t* o_0_ioptr_v_w = ioptr_w;
+          uint8_t* o_0_iobounds1_v_w = iobounds1_w;
+          wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+              wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){
+              .ptr = self->private_impl.f_window, .len = 65536}),
+              self->private_impl.f_w_end));
+          wuffs_base__io_writer__copy_from_reader32(&ioptr_w, iobounds1_w,
+              &ioptr_src, iobounds1_src, 65536);
+          self->private_impl.f_w_end =
+              (65536 - ((uint32_t)(wuffs_base__u64__min(((uint64_t)(
+              iobounds1_w - ioptr_w)), 65536))));
+          v_w = o_0_v_w;
+          ioptr_w = o_0_ioptr_v_w;
+          iobounds1_w = o_0_iobounds1_v_w;
+          a_src = o_0_a_src;
+        }
+        goto label_0_continue;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_0 = *ioptr_src++;
+        v_c = t_0;
+      }
+      if (self->private_impl.f_w_end < 65536) {
+        self->private_impl.f_window[self->private_impl.f_w_end] = v_c;
+        self->private_impl.f_w_end += 1;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_window[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_window[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_window[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_DEFLATE__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func encoder.slide
+
+static void wuffs_deflate__encoder__slide(wuffs_deflate__encoder* self) {
+  uint32_t v_i;
+
+  v_i = 0;
+  while (v_i < 32768) {
+    self->private_impl.f_window[v_i] =
+        self->private_impl.f_window[(v_i + 32768)];
+    wuffs_base__u16__sat_sub_indirect(&self->private_impl.f_head[v_i], 32768);
+    wuffs_base__u16__sat_sub_indirect(&self->private_impl.f_prev[v_i], 32768);
+    v_i += 1;
+  }
+  wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_w_pos, 32768);
+  wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_w_end, 32768);
+  wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_block_start, 32768);
+  wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_ins_pos, 32768);
+}
+
+// -------- func encoder.tokenize
+
+static void wuffs_deflate__encoder__tokenize(wuffs_deflate__encoder* self) {
+  uint32_t v_limit;
+  uint32_t v_lazy_length;
+  uint32_t v_p;
+  uint32_t v_m;
+  uint32_t v_m_length;
+  uint32_t v_m2;
+  uint32_t v_q;
+
+  v_limit = self->private_impl.f_w_end;
+  if (!self->private_impl.f_eof) {
+    v_limit = wuffs_base__u32__sat_sub(self->private_impl.f_w_end, 262);
+  }
+  v_lazy_length = wuffs_deflate__level_lazy_lengths[self->private_impl.f_level];
+  v_p = 0;
+  v_m = 0;
+  v_m_length = 0;
+label_0_continue:;
+  while ((self->private_impl.f_w_pos < v_limit) &&
+      (self->private_impl.f_n_tokens < 16383)) {
+    if (self->private_impl.f_w_pos >= 65536) {
+      return;
+    }
+    v_p = self->private_impl.f_w_pos;
+    if (self->private_impl.f_level == 0) {
+      wuffs_deflate__encoder__add_token(self,
+          ((uint32_t)(self->private_impl.f_window[v_p])));
+      self->private_impl.f_w_pos = (v_p + 1);
+      goto label_0_continue;
+    }
+    v_m = wuffs_deflate__encoder__find_match(self, v_p);
+    v_m_length = (v_m & 511);
+    while ((v_m_length >= 3) && (v_m_length < v_lazy_length) &&
+        (self->private_impl.f_n_tokens < 16382)) {
+      if ((v_p + 1) >= v_limit) {
+        goto label_1_break;
+      }
+      v_m2 = wuffs_deflate__encoder__find_match(self, (v_p + 1));
+      if ((v_m2 & 511) <= v_m_length) {
+        goto label_1_break;
+      }
+      wuffs_deflate__encoder__add_token(self,
+          ((uint32_t)(self->private_impl.f_window[v_p])));
+      v_p = (v_p + 1);
+      v_m = v_m2;
+      v_m_length = (v_m & 511);
+    }
+  label_1_break:;
+    if (v_m_length >= 3) {
+      wuffs_deflate__encoder__add_token(self, v_m);
+      v_q = (v_p + v_m_length);
+      self->private_impl.f_w_pos =
+          wuffs_base__u32__min(v_q, self->private_impl.f_w_end);
+    } else {
+      wuffs_deflate__encoder__add_token(self,
+          ((uint32_t)(self->private_impl.f_window[v_p])));
+      self->private_impl.f_w_pos = (v_p + 1);
+    }
+  }
+}
+
+// -------- func encoder.add_token
+
+static void wuffs_deflate__encoder__add_token(wuffs_deflate__encoder* self,
+    uint32_t a_t) {
+  if (self->private_impl.f_n_tokens < 16384) {
+    self->private_impl.f_tokens[self->private_impl.f_n_tokens] = a_t;
+    self->private_impl.f_n_tokens += 1;
+  }
+}
+
+// -------- func encoder.insert
+
+static void wuffs_deflate__encoder__insert(wuffs_deflate__encoder* self,
+    uint32_t a_up_to) {
+  uint32_t v_h;
+
+  while (self->private_impl.f_ins_pos < a_up_to) {
+    if ((self->private_impl.f_ins_pos + 2) >= self->private_impl.f_w_end) {
+      self->private_impl.f_ins_pos = a_up_to;
+      return;
+    }
+    v_h = (((((uint32_t)(self->private_impl.f_window[(
+        self->private_impl.f_ins_pos & 65535)])) << 10) ^ (((uint32_t)(
+        self->private_impl.f_window[((self->private_impl.f_ins_pos + 1) &
+        65535)])) << 5) ^ ((uint32_t)(self->private_impl.f_window[((
+        self->private_impl.f_ins_pos + 2) & 65535)]))) & 32767);
+    self->private_impl.f_prev[(self->private_impl.f_ins_pos & 32767)] =
+        self->private_impl.f_head[v_h];
+    self->private_impl.f_head[v_h] = ((uint16_t)(self->private_impl.f_ins_pos));
+    self->private_impl.f_ins_pos += 1;
+  }
+}
+
+// -------- func encoder.find_match
+
+static uint32_t wuffs_deflate__encoder__find_match(wuffs_deflate__encoder* self,
+    uint32_t a_p) {
+  uint32_t v_remaining;
+  uint32_t v_max_length;
+  uint32_t v_h;
+  uint32_t v_candidate;
+  uint32_t v_chain;
+  uint32_t v_nice_length;
+  uint32_t v_best_length;
+  uint32_t v_best_distance;
+  uint32_t v_distance;
+  uint32_t v_n;
+  uint32_t v_next;
+
+  wuffs_deflate__encoder__insert(self, a_p);
+  v_remaining = wuffs_base__u32__sat_sub(self->private_impl.f_w_end, a_p);
+  if (v_remaining < 3) {
+    wuffs_deflate__encoder__insert(self, (a_p + 1));
+    return 0;
+  }
+  v_max_length = wuffs_base__u32__min(v_remaining, 258);
+  v_h = (((((uint32_t)(self->private_impl.f_window[a_p])) << 10) ^
+      (((uint32_t)(self->private_impl.f_window[((a_p + 1) & 65535)])) << 5) ^
+      ((uint32_t)(self->private_impl.f_window[((a_p + 2) & 65535)]))) & 32767);
+  v_candidate = ((uint32_t)(self->private_impl.f_head[v_h]));
+  v_chain = wuffs_deflate__level_max_chains[self->private_impl.f_level];
+  v_nice_length = wuffs_deflate__level_nice_lengths[self->private_impl.f_level];
+  v_best_length = 2;
+  v_best_distance = 0;
+  v_distance = 0;
+  v_n = 0;
+  v_next = 0;
+  while ((v_candidate > 0) && (v_chain > 0)) {
+    if (a_p <= v_candidate) {
+      goto label_0_break;
+    } else if ((a_p - v_candidate) > 32768) {
+      goto label_0_break;
+    }
+    v_distance = (a_p - v_candidate);
+    if (self->private_impl.f_window[((v_candidate + v_best_length) & 65535)] ==
+        self->private_impl.f_window[((a_p + v_best_length) & 65535)]) {
+      v_n = 0;
+      while (v_n < v_max_length) {
+        if (self->private_impl.f_window[((v_candidate + v_n) & 65535)] !=
+            self->private_impl.f_window[((a_p + v_n) & 65535)]) {
+          goto label_1_break;
+        }
+        v_n += 1;
+      }
+    label_1_break:;
+      if (v_n > v_best_length) {
+        v_best_length = v_n;
+        v_best_distance = v_distance;
+        if ((v_n >= v_nice_length) || (v_n >= v_max_length)) {
+          goto label_0_break;
+        }
+      }
+    }
+    v_next = ((uint32_t)(self->private_impl.f_prev[(v_candidate & 32767)]));
+    if (v_next >= v_candidate) {
+      goto label_0_break;
+    }
+    v_candidate = v_next;
+    wuffs_base__u32__sat_sub_indirect(&v_chain, 1);
+  }
+label_0_break:;
+  wuffs_deflate__encoder__insert(self, (a_p + 1));
+  if ((v_best_length < 3) ||
+      ((v_best_length == 3) && (v_best_distance > 4096))) {
+    return 0;
+  }
+  return ((v_best_distance << 16) | v_best_length);
+}
+
+// -------- func encoder.write_block
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_block(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    uint32_t a_final) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  bool v_stored;
+  bool v_fixed;
+  uint64_t v_n_extra_bits;
+  uint64_t v_fixed_cost;
+  uint64_t v_dynamic_cost;
+  uint64_t v_n_bytes;
+  uint64_t v_stored_cost;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_write_block[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_stored = self->private_impl.c_write_block[0].v_stored;
+    v_fixed = self->private_impl.c_write_block[0].v_fixed;
+    v_n_extra_bits = self->private_impl.c_write_block[0].v_n_extra_bits;
+    v_fixed_cost = self->private_impl.c_write_block[0].v_fixed_cost;
+    v_dynamic_cost = self->private_impl.c_write_block[0].v_dynamic_cost;
+    v_n_bytes = self->private_impl.c_write_block[0].v_n_bytes;
+    v_stored_cost = self->private_impl.c_write_block[0].v_stored_cost;
+  } else {
+    v_stored = false;
+    v_fixed = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_stored = (self->private_impl.f_level == 0);
+    v_fixed = 0;
+    if (!v_stored) {
+      v_n_extra_bits = wuffs_deflate__encoder__count_frequencies(self);
+      v_fixed_cost =
+          (wuffs_deflate__encoder__fixed_cost(self) + v_n_extra_bits);
+      v_dynamic_cost = (
+          wuffs_deflate__encoder__build_dynamic_huffman(self) + v_n_extra_bits);
+      v_n_bytes =
+          ((uint64_t)(wuffs_base__u32__sat_sub(self->private_impl.f_w_pos,
+          self->private_impl.f_block_start)));
+      v_stored_cost = (8 * (v_n_bytes + 5 + (5 * (v_n_bytes / 65535))));
+      v_stored = ((v_stored_cost <= v_fixed_cost) &&
+          (v_stored_cost <= v_dynamic_cost));
+      v_fixed = (v_fixed_cost <= v_dynamic_cost);
+    }
+    if (v_stored) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_deflate__encoder__write_stored(self, a_dst, a_final);
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      if (v_fixed) {
+        wuffs_deflate__encoder__init_fixed_huffman(self);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status =
+            wuffs_deflate__encoder__write_bits(self, a_dst, (a_final | 2), 3);
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status =
+            wuffs_deflate__encoder__write_bits(self, a_dst, (a_final | 4), 3);
+        if (status) {
+          goto suspend;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_deflate__encoder__write_dynamic_header(self, a_dst);
+        if (status) {
+          goto suspend;
+        }
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_deflate__encoder__write_tokens(self, a_dst);
+      if (status) {
+        goto suspend;
+      }
+    }
+    self->private_impl.f_n_tokens = 0;
+    self->private_impl.f_block_start = self->private_impl.f_w_pos;
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_block[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_block[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_block[0].v_stored = v_stored;
+  self->private_impl.c_write_block[0].v_fixed = v_fixed;
+  self->private_impl.c_write_block[0].v_n_extra_bits = v_n_extra_bits;
+  self->private_impl.c_write_block[0].v_fixed_cost = v_fixed_cost;
+  self->private_impl.c_write_block[0].v_dynamic_cost = v_dynamic_cost;
+  self->private_impl.c_write_block[0].v_n_bytes = v_n_bytes;
+  self->private_impl.c_write_block[0].v_stored_cost = v_stored_cost;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func encoder.write_stored
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_stored(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    uint32_t a_final) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  uint32_t v_start;
+  uint32_t v_end;
+  uint32_t v_remaining;
+  uint32_t v_length;
+  uint32_t v_n_copied;
+  uint32_t v_new_start;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_write_stored[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_start = self->private_impl.c_write_stored[0].v_start;
+    v_end = self->private_impl.c_write_stored[0].v_end;
+    v_remaining = self->private_impl.c_write_stored[0].v_remaining;
+    v_length = self->private_impl.c_write_stored[0].v_length;
+    v_n_copied = self->private_impl.c_write_stored[0].v_n_copied;
+    v_new_start = self->private_impl.c_write_stored[0].v_new_start;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_start = self->private_impl.f_block_start;
+    v_end = self->private_impl.f_w_pos;
+    v_remaining = 0;
+    v_length = 0;
+    v_n_copied = 0;
+    v_new_start = 0;
+    while (true) {
+      v_remaining = wuffs_base__u32__sat_sub(v_end, v_start);
+      v_length = wuffs_base__u32__min(v_remaining, 65535);
+      if ((v_start + v_length) < v_end) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status = wuffs_deflate__encoder__write_bits(self, a_dst, 0, 3);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status = wuffs_deflate__encoder__write_bits(self, a_dst, a_final, 3);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+      }
+      if (self->private_impl.f_n_bits > 0) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        if (ioptr_dst == iobounds1_dst) {
+          status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+          goto suspend;
+        }
+        *ioptr_dst++ = ((uint8_t)((self->private_impl.f_bits & 255)));
+        self->private_impl.f_bits = 0;
+        self->private_impl.f_n_bits = 0;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      self->private_impl.c_write_stored[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      while (self->private_impl.c_write_stored[0].scratch < 2) {
+        if (ioptr_dst == iobounds1_dst) {
+          status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+          goto suspend;
+        }
+        *ioptr_dst++ = (uint8_t)(((uint64_t)(((uint16_t)(v_length)))) >>
+            (8 * self->private_impl.c_write_stored[0].scratch));
+        self->private_impl.c_write_stored[0].scratch++;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      self->private_impl.c_write_stored[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      while (self->private_impl.c_write_stored[0].scratch < 2) {
+        if (ioptr_dst == iobounds1_dst) {
+          status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+          goto suspend;
+        }
+        *ioptr_dst++ =
+            (uint8_t)(((uint64_t)(((uint16_t)((65535 - v_length))))) >>
+            (8 * self->private_impl.c_write_stored[0].scratch));
+        self->private_impl.c_write_stored[0].scratch++;
+      }
+      while (v_length > 0) {
+        v_n_copied =
+            wuffs_base__io_writer__copy_from_slice32(&ioptr_dst, iobounds1_dst,
+            wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){.ptr =
+            self->private_impl.f_window, .len = 65536}), v_start), v_length);
+        v_new_start = (v_start + v_n_copied);
+        if (v_new_start > v_end) {
+          status =
+              WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE;
+          goto exit;
+        }
+        v_start = v_new_start;
+        if (v_length <= v_n_copied) {
+          goto label_0_break;
+        }
+        v_length -= v_n_copied;
+        status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(8);
+      }
+    label_0_break:;
+      if (v_start >= v_end) {
+        goto label_1_break;
+      }
+    }
+  label_1_break:;
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_stored[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_stored[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_stored[0].v_start = v_start;
+  self->private_impl.c_write_stored[0].v_end = v_end;
+  self->private_impl.c_write_stored[0].v_remaining = v_remaining;
+  self->private_impl.c_write_stored[0].v_length = v_length;
+  self->private_impl.c_write_stored[0].v_n_copied = v_n_copied;
+  self->private_impl.c_write_stored[0].v_new_start = v_new_start;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.write_bits
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_bits(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst, uint32_t a_x,
+    uint32_t a_n) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  uint32_t v_n_bits;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_write_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n_bits = self->private_impl.c_write_bits[0].v_n_bits;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_bits |= (a_x << self->private_impl.f_n_bits);
+    v_n_bits = (self->private_impl.f_n_bits + a_n);
+    while (v_n_bits >= 8) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = ((uint8_t)((self->private_impl.f_bits & 255)));
+      self->private_impl.f_bits >>= 8;
+      v_n_bits -= 8;
+    }
+    self->private_impl.f_n_bits = v_n_bits;
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_bits[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_bits[0].v_n_bits = v_n_bits;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.write_dynamic_header
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_dynamic_header(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  uint32_t v_i;
+  uint32_t v_symbol;
+  uint32_t v_n_extra_bits;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_write_dynamic_header[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_i = self->private_impl.c_write_dynamic_header[0].v_i;
+    v_symbol = self->private_impl.c_write_dynamic_header[0].v_symbol;
+    v_n_extra_bits =
+        self->private_impl.c_write_dynamic_header[0].v_n_extra_bits;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_deflate__encoder__write_bits(self, a_dst,
+        wuffs_base__u32__sat_sub(self->private_impl.f_n_lcodes, 257), 5);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_deflate__encoder__write_bits(self, a_dst,
+        wuffs_base__u32__sat_sub(self->private_impl.f_n_dcodes, 1), 5);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_deflate__encoder__write_bits(self, a_dst,
+        wuffs_base__u32__sat_sub(self->private_impl.f_n_clcodes, 4), 4);
+    if (status) {
+      goto suspend;
+    }
+    v_i = 0;
+    while (v_i < self->private_impl.f_n_clcodes) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_deflate__encoder__write_bits(self, a_dst, ((uint32_t)((
+          self->private_impl.f_lens[2][wuffs_deflate__code_order[v_i]] & 7))),
+          3);
+      if (status) {
+        goto suspend;
+      }
+      v_i += 1;
+    }
+    v_symbol = 0;
+    v_n_extra_bits = 0;
+    v_i = 0;
+    while (v_i < self->private_impl.f_n_clseq) {
+      v_symbol = ((uint32_t)((self->private_impl.f_clseq[v_i] & 255)));
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_deflate__encoder__write_bits(self, a_dst,
+          ((uint32_t)(self->private_impl.f_codes[2][v_symbol])),
+          ((uint32_t)(self->private_impl.f_lens[2][v_symbol])));
+      if (status) {
+        goto suspend;
+      }
+      v_n_extra_bits = 0;
+      if (v_symbol == 16) {
+        v_n_extra_bits = 2;
+      } else if (v_symbol == 17) {
+        v_n_extra_bits = 3;
+      } else if (v_symbol == 18) {
+        v_n_extra_bits = 7;
+      }
+      if (v_n_extra_bits > 0) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((uint32_t)((self->private_impl.f_clseq[v_i] >> 8))),
+            v_n_extra_bits);
+        if (status) {
+          goto suspend;
+        }
+      }
+      v_i += 1;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_dynamic_header[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_dynamic_header[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_write_dynamic_header[0].v_i = v_i;
+  self->private_impl.c_write_dynamic_header[0].v_symbol = v_symbol;
+  self->private_impl.c_write_dynamic_header[0].v_n_extra_bits = v_n_extra_bits;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func encoder.write_tokens
+
+static wuffs_deflate__status wuffs_deflate__encoder__write_tokens(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst) {
+  wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
+
+  uint32_t v_i;
+  uint32_t v_t;
+  uint32_t v_length;
+  uint32_t v_lc;
+  uint32_t v_d;
+  uint32_t v_dc;
+  uint32_t v_magic;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_write_tokens[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_i = self->private_impl.c_write_tokens[0].v_i;
+    v_t = self->private_impl.c_write_tokens[0].v_t;
+    v_length = self->private_impl.c_write_tokens[0].v_length;
+    v_lc = self->private_impl.c_write_tokens[0].v_lc;
+    v_d = self->private_impl.c_write_tokens[0].v_d;
+    v_dc = self->private_impl.c_write_tokens[0].v_dc;
+    v_magic = self->private_impl.c_write_tokens[0].v_magic;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_i = 0;
+    v_t = 0;
+    v_length = 0;
+    v_lc = 0;
+    v_d = 0;
+    v_dc = 0;
+    v_magic = 0;
+    while (v_i < self->private_impl.f_n_tokens) {
+      v_t = self->private_impl.f_tokens[v_i];
+      if ((v_t >> 16) == 0) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((uint32_t)(self->private_impl.f_codes[0][(v_t &255)])),
+            ((uint32_t)(self->private_impl.f_lens[0][(v_t &255)])));
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        v_length = (v_t &511);
+        v_lc =
+            ((uint32_t)(wuffs_deflate__length_codes[((v_length - 3) & 255)]));
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((uint32_t)(self->private_impl.f_codes[0][(257 + v_lc)])),
+            ((uint32_t)(self->private_impl.f_lens[0][(257 + v_lc)])));
+        if (status) {
+          goto suspend;
+        }
+        v_magic = wuffs_deflate__lcode_magic_numbers[v_lc];
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((v_length - ((v_magic >> 8) & 511)) & 31), ((v_magic >> 4) & 15));
+        if (status) {
+          goto suspend;
+        }
+        v_d = (((v_t >> 16) - 1) & 32767);
+        v_dc = wuffs_deflate__encoder__dcode(self, v_d);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((uint32_t)(self->private_impl.f_codes[1][v_dc])),
+            ((uint32_t)(self->private_impl.f_lens[1][v_dc])));
+        if (status) {
+          goto suspend;
+        }
+        v_magic = wuffs_deflate__dcode_magic_numbers[v_dc];
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        status = wuffs_deflate__encoder__write_bits(self, a_dst,
+            ((v_d - ((v_magic >> 8) & 32767)) & 8191), ((v_magic >> 4) & 15));
+        if (status) {
+          goto suspend;
+        }
+      }
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    status = wuffs_deflate__encoder__write_bits(self, a_dst,
+        ((uint32_t)(self->private_impl.f_codes[0][256])),
+        ((uint32_t)(self->private_impl.f_lens[0][256])));
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_tokens[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_tokens[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_tokens[0].v_i = v_i;
+  self->private_impl.c_write_tokens[0].v_t = v_t;
+  self->private_impl.c_write_tokens[0].v_length = v_length;
+  self->private_impl.c_write_tokens[0].v_lc = v_lc;
+  self->private_impl.c_write_tokens[0].v_d = v_d;
+  self->private_impl.c_write_tokens[0].v_dc = v_dc;
+  self->private_impl.c_write_tokens[0].v_magic = v_magic;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func encoder.dcode
+
+static uint32_t wuffs_deflate__encoder__dcode(wuffs_deflate__encoder* self,
+    uint32_t a_d) {
+  if (a_d < 256) {
+    return ((uint32_t)(wuffs_deflate__distance_codes[a_d]));
+  }
+  return ((uint32_t)(wuffs_deflate__distance_codes[(256 + (a_d >> 7))]));
+}
+
+// -------- func encoder.count_frequencies
+
+static uint64_t wuffs_deflate__encoder__count_frequencies(
+    wuffs_deflate__encoder* self) {
+  uint32_t v_i;
+  uint64_t v_n_extra_bits;
+  uint32_t v_t;
+  uint32_t v_lc;
+  uint32_t v_dc;
+
+  v_i = 0;
+  while (v_i < 288) {
+    self->private_impl.f_freqs[0][v_i] = 0;
+    self->private_impl.f_freqs[1][v_i] = 0;
+    v_i += 1;
+  }
+  v_n_extra_bits = 0;
+  v_t = 0;
+  v_lc = 0;
+  v_dc = 0;
+  v_i = 0;
+  while (v_i < self->private_impl.f_n_tokens) {
+    v_t = self->private_impl.f_tokens[v_i];
+    if ((v_t >> 16) == 0) {
+      self->private_impl.f_freqs[0][(v_t &255)] += 1;
+    } else {
+      v_lc =
+          ((uint32_t)(wuffs_deflate__length_codes[(((v_t &511) - 3) & 255)]));
+      self->private_impl.f_freqs[0][(257 + v_lc)] += 1;
+      v_n_extra_bits +=
+          ((uint64_t)(((wuffs_deflate__lcode_magic_numbers[v_lc] >> 4) & 15)));
+      v_dc = wuffs_deflate__encoder__dcode(self, (((v_t >> 16) - 1) & 32767));
+      self->private_impl.f_freqs[1][v_dc] += 1;
+      v_n_extra_bits +=
+          ((uint64_t)(((wuffs_deflate__dcode_magic_numbers[v_dc] >> 4) & 15)));
+    }
+    v_i += 1;
+  }
+  self->private_impl.f_freqs[0][256] = 1;
+  return v_n_extra_bits;
+}
+
+// -------- func encoder.fixed_cost
+
+static uint64_t wuffs_deflate__encoder__fixed_cost(
+    wuffs_deflate__encoder* self) {
+  uint64_t v_cost;
+  uint32_t v_i;
+  uint64_t v_n;
+
+  v_cost = 0;
+  v_i = 0;
+  while (v_i < 288) {
+    v_n = 8;
+    if (v_i < 144) {
+      v_n = 8;
+    } else if (v_i < 256) {
+      v_n = 9;
+    } else if (v_i < 280) {
+      v_n = 7;
+    }
+    v_cost += (((uint64_t)(self->private_impl.f_freqs[0][v_i])) * v_n);
+    v_cost += (((uint64_t)(self->private_impl.f_freqs[1][v_i])) * 5);
+    v_i += 1;
+  }
+  return v_cost;
+}
+
+// -------- func encoder.init_fixed_huffman
+
+static void wuffs_deflate__encoder__init_fixed_huffman(
+    wuffs_deflate__encoder* self) {
+  uint32_t v_i;
+
+  v_i = 0;
+  while (v_i < 288) {
+    if (v_i < 144) {
+      self->private_impl.f_lens[0][v_i] = 8;
+    } else if (v_i < 256) {
+      self->private_impl.f_lens[0][v_i] = 9;
+    } else if (v_i < 280) {
+      self->private_impl.f_lens[0][v_i] = 7;
+    } else {
+      self->private_impl.f_lens[0][v_i] = 8;
+    }
+    v_i += 1;
+  }
+  v_i = 0;
+  while (v_i < 32) {
+    self->private_impl.f_lens[1][v_i] = 5;
+    v_i += 1;
+  }
+  wuffs_deflate__encoder__build_codes(self, 0, 288);
+  wuffs_deflate__encoder__build_codes(self, 1, 32);
+}
+
+// -------- func encoder.build_dynamic_huffman
+
+static uint64_t wuffs_deflate__encoder__build_dynamic_huffman(
+    wuffs_deflate__encoder* self) {
+  uint64_t v_cost;
+  uint32_t v_i;
+  uint32_t v_symbol;
+
+  wuffs_deflate__encoder__ensure_two_symbols(self, 0, 286);
+  wuffs_deflate__encoder__ensure_two_symbols(self, 1, 30);
+  wuffs_deflate__encoder__build_huffman(self, 0, 286, 15);
+  wuffs_deflate__encoder__build_huffman(self, 1, 30, 15);
+  v_cost = 0;
+  v_i = 0;
+  while (v_i < 286) {
+    v_cost += (((uint64_t)(self->private_impl.f_freqs[0][v_i])) *
+        ((uint64_t)(self->private_impl.f_lens[0][v_i])));
+    if (v_i < 30) {
+      v_cost += (((uint64_t)(self->private_impl.f_freqs[1][v_i])) *
+          ((uint64_t)(self->private_impl.f_lens[1][v_i])));
+    }
+    v_i += 1;
+  }
+  self->private_impl.f_n_lcodes = 286;
+  while (self->private_impl.f_n_lcodes > 257) {
+    if (self->private_impl.f_lens[0][(self->private_impl.f_n_lcodes - 1)] !=
+        0) {
+      goto label_0_break;
+    }
+    self->private_impl.f_n_lcodes -= 1;
+  }
+label_0_break:;
+  self->private_impl.f_n_dcodes = 30;
+  while (self->private_impl.f_n_dcodes > 1) {
+    if (self->private_impl.f_lens[1][(self->private_impl.f_n_dcodes - 1)] !=
+        0) {
+      goto label_1_break;
+    }
+    self->private_impl.f_n_dcodes -= 1;
+  }
+label_1_break:;
+  wuffs_deflate__encoder__build_clseq(self);
+  wuffs_deflate__encoder__ensure_two_symbols(self, 2, 19);
+  wuffs_deflate__encoder__build_huffman(self, 2, 19, 7);
+  self->private_impl.f_n_clcodes = 19;
+  while (self->private_impl.f_n_clcodes > 4) {
+    if (self->private_impl.f_lens[2][wuffs_deflate__code_order[(
+        self->private_impl.f_n_clcodes - 1)]] != 0) {
+      goto label_2_break;
+    }
+    self->private_impl.f_n_clcodes -= 1;
+  }
+label_2_break:;
+  v_cost += (14 + (3 * ((uint64_t)(self->private_impl.f_n_clcodes))));
+  v_symbol = 0;
+  v_i = 0;
+  while (v_i < self->private_impl.f_n_clseq) {
+    v_symbol = ((uint32_t)((self->private_impl.f_clseq[v_i] & 255)));
+    v_cost += ((uint64_t)(self->private_impl.f_lens[2][v_symbol]));
+    if (v_symbol == 16) {
+      v_cost += 2;
+    } else if (v_symbol == 17) {
+      v_cost += 3;
+    } else if (v_symbol == 18) {
+      v_cost += 7;
+    }
+    v_i += 1;
+  }
+  return v_cost;
+}
+
+// -------- func encoder.build_clseq
+
+static void wuffs_deflate__encoder__build_clseq(wuffs_deflate__encoder* self) {
+  uint32_t v_i;
+  uint32_t v_total;
+  uint8_t v_cl;
+  uint32_t v_prev_cl;
+  uint32_t v_run;
+  uint32_t v_symbol;
+  uint32_t v_extra;
+  uint32_t v_rep;
+
+  v_i = 0;
+  while (v_i < 19) {
+    self->private_impl.f_freqs[2][v_i] = 0;
+    v_i += 1;
+  }
+  self->private_impl.f_n_clseq = 0;
+  v_total = (self->private_impl.f_n_lcodes + self->private_impl.f_n_dcodes);
+  v_cl = 0;
+  v_prev_cl = 255;
+  v_run = 0;
+  v_symbol = 0;
+  v_extra = 0;
+  v_rep = 0;
+  v_i = 0;
+  while (v_i < v_total) {
+    v_cl = wuffs_deflate__encoder__code_length(self, v_i);
+    v_run = 1;
+    while (v_run < 138) {
+      if ((v_i + v_run) >= v_total) {
+        goto label_0_break;
+      }
+      if (wuffs_deflate__encoder__code_length(self, (v_i + v_run)) != v_cl) {
+        goto label_0_break;
+      }
+      v_run += 1;
+    }
+  label_0_break:;
+    v_symbol = ((uint32_t)(v_cl));
+    v_extra = 0;
+    if (v_cl == 0) {
+      if (v_run >= 11) {
+        v_symbol = 18;
+        v_extra = (v_run - 11);
+      } else if (v_run >= 3) {
+        v_symbol = 17;
+        v_extra = (v_run - 3);
+      } else {
+        v_run = 1;
+      }
+    } else if ((((uint32_t)(v_cl)) == v_prev_cl) && (v_run >= 3)) {
+      v_symbol = 16;
+      v_rep = wuffs_base__u32__sat_sub(wuffs_base__u32__min(v_run, 6), 3);
+      v_extra = v_rep;
+      v_run = (v_rep + 3);
+    } else {
+      v_run = 1;
+    }
+    v_prev_cl = ((uint32_t)(v_cl));
+    v_i += v_run;
+    if (self->private_impl.f_n_clseq >= 320) {
+      return;
+    }
+    self->private_impl.f_clseq[self->private_impl.f_n_clseq] =
+        ((uint16_t)((v_symbol | (v_extra << 8))));
+    self->private_impl.f_n_clseq += 1;
+    self->private_impl.f_freqs[2][v_symbol] += 1;
+  }
+}
+
+// -------- func encoder.code_length
+
+static uint8_t wuffs_deflate__encoder__code_length(wuffs_deflate__encoder* self,
+    uint32_t a_i) {
+  uint32_t v_j;
+
+  if (a_i < self->private_impl.f_n_lcodes) {
+    return self->private_impl.f_lens[0][a_i];
+  }
+  v_j = (a_i - self->private_impl.f_n_lcodes);
+  if (v_j < self->private_impl.f_n_dcodes) {
+    return self->private_impl.f_lens[1][v_j];
+  }
+  return 0;
+}
+
+// -------- func encoder.ensure_two_symbols
+
+static void wuffs_deflate__encoder__ensure_two_symbols(
+    wuffs_deflate__encoder* self, uint32_t a_which, uint32_t a_n) {
+  uint32_t v_n_used;
+  uint32_t v_i;
+
+  v_n_used = 0;
+  v_i = 0;
+  while (v_i < a_n) {
+    if ((self->private_impl.f_freqs[a_which][v_i] > 0) && (v_n_used < 2)) {
+      v_n_used += 1;
+    }
+    v_i += 1;
+  }
+  v_i = 0;
+  while ((v_i < 2) && (v_n_used < 2)) {
+    if (self->private_impl.f_freqs[a_which][v_i] == 0) {
+      self->private_impl.f_freqs[a_which][v_i] = 1;
+      v_n_used += 1;
+    }
+    v_i += 1;
+  }
+}
+
+// -------- func encoder.build_huffman
+
+static void wuffs_deflate__encoder__build_huffman(wuffs_deflate__encoder* self,
+    uint32_t a_which, uint32_t a_n, uint32_t a_max_length) {
+  uint32_t v_n_used;
+  uint32_t v_f;
+  uint32_t v_j;
+  uint32_t v_i;
+  uint32_t v_root;
+  uint32_t v_leaf;
+  uint32_t v_next;
+  uint32_t v_n_used_minus_1;
+  uint32_t v_n_used_minus_2;
+  uint32_t v_avbl;
+  uint32_t v_used;
+  uint32_t v_depth;
+  uint32_t v_counts[16];
+  uint64_t v_total;
+  uint32_t v_length;
+  uint32_t v_count;
+
+  v_n_used = 0;
+  v_f = 0;
+  v_j = 0;
+  v_i = 0;
+  while (v_i < a_n) {
+    self->private_impl.f_lens[a_which][v_i] = 0;
+    v_f = self->private_impl.f_freqs[a_which][v_i];
+    if (v_f > 0) {
+      if (v_n_used >= 288) {
+        return;
+      }
+      v_j = v_n_used;
+      while (v_j > 0) {
+        if (self->private_impl.f_hsort_keys[(v_j - 1)] <= v_f) {
+          goto label_0_break;
+        }
+        self->private_impl.f_hsort_keys[v_j] =
+            self->private_impl.f_hsort_keys[(v_j - 1)];
+        self->private_impl.f_hsort_syms[v_j] =
+            self->private_impl.f_hsort_syms[(v_j - 1)];
+        v_j -= 1;
+      }
+    label_0_break:;
+      self->private_impl.f_hsort_keys[v_j] = v_f;
+      self->private_impl.f_hsort_syms[v_j] = ((uint16_t)(v_i));
+      v_n_used += 1;
+    }
+    v_i += 1;
+  }
+  if (v_n_used < 2) {
+    if (v_n_used == 1) {
+      self->private_impl.f_lens[a_which][self->private_impl.f_hsort_syms[0]] =
+          1;
+    }
+    return;
+  }
+  self->private_impl.f_hsort_keys[0] += self->private_impl.f_hsort_keys[1];
+  v_root = 0;
+  v_leaf = 2;
+  v_next = 1;
+  v_n_used_minus_1 = (v_n_used - 1);
+  v_n_used_minus_2 = (v_n_used - 2);
+  while (v_next < v_n_used_minus_1) {
+    if ((v_leaf >= v_n_used) || (self->private_impl.f_hsort_keys[(v_root &
+        511)] < self->private_impl.f_hsort_keys[(v_leaf & 511)])) {
+      self->private_impl.f_hsort_keys[(v_next & 511)] =
+          self->private_impl.f_hsort_keys[(v_root & 511)];
+      self->private_impl.f_hsort_keys[(v_root & 511)] = v_next;
+      v_root += 1;
+    } else {
+      self->private_impl.f_hsort_keys[(v_next & 511)] =
+          self->private_impl.f_hsort_keys[(v_leaf & 511)];
+      v_leaf += 1;
+    }
+    if ((v_leaf >= v_n_used) ||
+        ((v_root < v_next) && (self->private_impl.f_hsort_keys[(v_root & 511)] <
+        self->private_impl.f_hsort_keys[(v_leaf & 511)]))) {
+      self->private_impl.f_hsort_keys[(v_next & 511)] +=
+          self->private_impl.f_hsort_keys[(v_root & 511)];
+      self->private_impl.f_hsort_keys[(v_root & 511)] = v_next;
+      v_root += 1;
+    } else {
+      self->private_impl.f_hsort_keys[(v_next & 511)] +=
+          self->private_impl.f_hsort_keys[(v_leaf & 511)];
+      v_leaf += 1;
+    }
+    v_next += 1;
+  }
+  self->private_impl.f_hsort_keys[v_n_used_minus_2] = 0;
+  v_next = v_n_used_minus_2;
+  while (v_next > 0) {
+    v_next -= 1;
+    self->private_impl.f_hsort_keys[(v_next & 511)] =
+        (self->private_impl.f_hsort_keys[(
+        self->private_impl.f_hsort_keys[(v_next & 511)] & 511)] + 1);
+  }
+  v_avbl = 1;
+  v_used = 0;
+  v_depth = 0;
+  v_root = v_n_used_minus_1;
+  v_next = v_n_used;
+  while ((v_avbl > 0) && (v_next > 0)) {
+    while (v_root > 0) {
+      if (self->private_impl.f_hsort_keys[((v_root - 1) & 511)] != v_depth) {
+        goto label_1_break;
+      }
+      v_used += 1;
+      v_root -= 1;
+    }
+  label_1_break:;
+    while ((v_avbl > v_used) && (v_next > 0)) {
+      self->private_impl.f_hsort_keys[((v_next - 1) & 511)] = v_depth;
+      v_next -= 1;
+      wuffs_base__u32__sat_sub_indirect(&v_avbl, 1);
+    }
+    v_avbl = ((v_used & 511) * 2);
+    v_depth += 1;
+    v_used = 0;
+  }
+  memset(v_counts, 0, sizeof(v_counts));
+  v_i = 0;
+  while (v_i < v_n_used) {
+    v_depth = self->private_impl.f_hsort_keys[v_i];
+    if (v_depth > a_max_length) {
+      v_depth = a_max_length;
+    }
+    v_counts[(v_depth & 15)] += 1;
+    v_i += 1;
+  }
+  v_total = 0;
+  v_length = 1;
+  while (a_max_length >= v_length) {
+    v_total += (((uint64_t)(v_counts[v_length])) << (a_max_length - v_length));
+    if (v_length >= 15) {
+      goto label_2_break;
+    }
+    v_length += 1;
+  }
+label_2_break:;
+  while (v_total > (((uint64_t)(1)) << a_max_length)) {
+    if (v_counts[a_max_length] > 0) {
+      v_counts[a_max_length] -= 1;
+    }
+    v_length = a_max_length;
+    while (v_length > 1) {
+      v_length -= 1;
+      if (v_counts[v_length] > 0) {
+        v_counts[v_length] -= 1;
+        v_counts[((v_length + 1) & 15)] += 2;
+        goto label_3_break;
+      }
+    }
+  label_3_break:;
+    wuffs_base__u64__sat_sub_indirect(&v_total, 1);
+  }
+  v_i = 0;
+  v_length = a_max_length;
+  v_count = 0;
+  while (v_length > 0) {
+    v_count = v_counts[v_length];
+    while (v_count > 0) {
+      if (v_i >= v_n_used) {
+        goto label_4_break;
+      }
+      self->private_impl.f_lens[a_which][self->private_impl.f_hsort_syms[v_i]] =
+          ((uint8_t)(v_length));
+      v_i += 1;
+      v_count -= 1;
+    }
+  label_4_break:;
+    v_length -= 1;
+  }
+  wuffs_deflate__encoder__build_codes(self, a_which, a_n);
+}
+
+// -------- func encoder.build_codes
+
+static void wuffs_deflate__encoder__build_codes(wuffs_deflate__encoder* self,
+    uint32_t a_which, uint32_t a_n) {
+  uint32_t v_counts[16];
+  uint32_t v_i;
+  uint32_t v_next_codes[16];
+  uint32_t v_code;
+  uint32_t v_length;
+  uint32_t v_c;
+  uint32_t v_r;
+
+  memset(v_counts, 0, sizeof(v_counts));
+  v_i = 0;
+  while (v_i < a_n) {
+    v_counts[self->private_impl.f_lens[a_which][v_i]] += 1;
+    v_i += 1;
+  }
+  v_counts[0] = 0;
+  memset(v_next_codes, 0, sizeof(v_next_codes));
+  v_code = 0;
+  v_length = 0;
+  while (v_length < 15) {
+    v_code = (((v_code + v_counts[v_length]) & 32767) << 1);
+    v_next_codes[(v_length + 1)] = v_code;
+    v_length += 1;
+  }
+  v_c = 0;
+  v_r = 0;
+  v_i = 0;
+  while (v_i < a_n) {
+    v_length = ((uint32_t)(self->private_impl.f_lens[a_which][v_i]));
+    if (v_length > 0) {
+      v_c = (v_next_codes[v_length] & 65535);
+      v_next_codes[v_length] += 1;
+      v_r = ((((uint32_t)(wuffs_deflate__reverse8[(v_c & 255)])) << 8) |
+          ((uint32_t)(wuffs_deflate__reverse8[(v_c >> 8)])));
+      self->private_impl.f_codes[a_which][v_i] =
+          ((uint16_t)((v_r >> (16 - v_length))));
+    } else {
+      self->private_impl.f_codes[a_which][v_i] = 0;
+    }
+    v_i += 1;
+  }
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_deflate__status wuffs_deflate__decoder__save_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_DEFLATE__DECODER__STATE_LENGTH) {
+    return WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_bits));
+  p += 4;
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1234; i1++) {
+      wuffs_base__store_u32le(p,
+          (uint32_t)(self->private_impl.f_huffs[i0][i1]));
+      p += 4;
+    }
+  }
+  for (i0 = 0; i0 < 2; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_n_huffs_bits[i0]));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 32768; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_history[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_history_index));
+  p += 4;
+  for (i0 = 0; i0 < 320; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_code_lengths[i0]);
+    p += 1;
+  }
+  p[0] = self->private_impl.f_end_of_block ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_blocks[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_blocks[0].v_final));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_blocks[0].v_type));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_uncompressed[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_uncompressed[0].v_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_uncompressed[0].v_n_copied));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_uncompressed[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_fixed_huffman[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_fixed_huffman[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_n_lit));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_n_dist));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_n_clen));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_mask));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_table_entry));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_init_dynamic_huffman[0].v_table_entry_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_n_extra_bits));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_init_dynamic_huffman[0].v_rep_symbol);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_init_dynamic_huffman[0].v_rep_count));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_table_entry));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_huffman_slow[0].v_table_entry_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_lmask));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_dmask));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_redir_top));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_redir_mask));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_dist_minus_1));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_n_copied));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_hlen));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_huffman_slow[0].v_hdist));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x3509FC1D,
+      WUFFS_DEFLATE__DECODER__STATE_LENGTH);
+  return WUFFS_DEFLATE__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_deflate__status wuffs_deflate__decoder__restore_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x3509FC1D,
+      WUFFS_DEFLATE__DECODER__STATE_LENGTH)) {
+    return WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  self->private_impl.status =
+      (wuffs_deflate__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_n_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1234; i1++) {
+      self->private_impl.f_huffs[i0][i1] =
+          (uint32_t)(wuffs_base__load_u32le(p));
+      p += 4;
+    }
+  }
+  for (i0 = 0; i0 < 2; i0++) {
+    self->private_impl.f_n_huffs_bits[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+    if (self->private_impl.f_n_huffs_bits[i0] > 9) {
+      goto bad_state;
+    }
+  }
+  for (i0 = 0; i0 < 32768; i0++) {
+    self->private_impl.f_history[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_history_index = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  for (i0 = 0; i0 < 320; i0++) {
+    self->private_impl.f_code_lengths[i0] = (uint8_t)(p[0]);
+    p += 1;
+    if (self->private_impl.f_code_lengths[i0] > 15) {
+      goto bad_state;
+    }
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_end_of_block = p[0];
+  p += 1;
+  self->private_impl.c_decode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode[0].v_z =
+      (wuffs_deflate__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_blocks[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_blocks[0].coro_susp_point > 6) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_blocks[0].v_final =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_blocks[0].v_type =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_uncompressed[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_uncompressed[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_uncompressed[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_uncompressed[0].v_n_copied =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_uncompressed[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_init_fixed_huffman[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_init_fixed_huffman[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_init_fixed_huffman[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_init_dynamic_huffman[0].coro_susp_point > 7) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_n_lit =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_n_dist =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_n_clen =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_mask =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_table_entry =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_table_entry_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_n_extra_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_init_dynamic_huffman[0].v_rep_symbol = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_init_dynamic_huffman[0].v_rep_count =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_huffman_slow[0].coro_susp_point > 11) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_table_entry =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_table_entry_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_lmask =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_dmask =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_redir_top =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_redir_mask =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_dist_minus_1 =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_n_copied =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_hlen =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_huffman_slow[0].v_hdist =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_DEFLATE__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
+
+// -------- func encoder.save_state
+
+wuffs_deflate__status wuffs_deflate__encoder__save_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_DEFLATE__ENCODER__STATE_LENGTH) {
+    return WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_level));
+  p += 4;
+  p[0] = self->private_impl.f_level_set ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_eof ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_bits));
+  p += 4;
+  for (i0 = 0; i0 < 65536; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_window[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_w_pos));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_w_end));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_block_start));
+  p += 4;
+  for (i0 = 0; i0 < 32768; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_head[i0]));
+    p += 2;
+  }
+  for (i0 = 0; i0 < 32768; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_prev[i0]));
+    p += 2;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_ins_pos));
+  p += 4;
+  for (i0 = 0; i0 < 16384; i0++) {
+    wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_tokens[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_tokens));
+  p += 4;
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      wuffs_base__store_u32le(p,
+          (uint32_t)(self->private_impl.f_freqs[i0][i1]));
+      p += 4;
+    }
+  }
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      p[0] = (uint8_t)(self->private_impl.f_lens[i0][i1]);
+      p += 1;
+    }
+  }
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      wuffs_base__store_u16le(p,
+          (uint16_t)(self->private_impl.f_codes[i0][i1]));
+      p += 2;
+    }
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_lcodes));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_dcodes));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_clcodes));
+  p += 4;
+  for (i0 = 0; i0 < 320; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_clseq[i0]));
+    p += 2;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_clseq));
+  p += 4;
+  for (i0 = 0; i0 < 512; i0++) {
+    wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_hsort_keys[i0]));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 512; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_hsort_syms[i0]));
+    p += 2;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_window[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_fill_window[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_block[0].coro_susp_point));
+  p += 4;
+  p[0] = self->private_impl.c_write_block[0].v_stored ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.c_write_block[0].v_fixed ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_n_extra_bits));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_fixed_cost));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_dynamic_cost));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_n_bytes));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_stored_cost));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_start));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_end));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_remaining));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_n_copied));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_stored[0].v_new_start));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_stored[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_bits[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_bits[0].v_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_dynamic_header[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_dynamic_header[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_dynamic_header[0].v_symbol));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_dynamic_header[0].v_n_extra_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_t));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_lc));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_d));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_dc));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_tokens[0].v_magic));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xD1BC3769,
+      WUFFS_DEFLATE__ENCODER__STATE_LENGTH);
+  return WUFFS_DEFLATE__STATUS_OK;
+}
+
+// -------- func encoder.restore_state
+
+wuffs_deflate__status wuffs_deflate__encoder__restore_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_DEFLATE__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xD1BC3769,
+      WUFFS_DEFLATE__ENCODER__STATE_LENGTH)) {
+    return WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  self->private_impl.status =
+      (wuffs_deflate__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_level = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_level > 9) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_level_set = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_eof = p[0];
+  p += 1;
+  self->private_impl.f_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_n_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_bits > 7) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 65536; i0++) {
+    self->private_impl.f_window[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_w_pos = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_w_pos > 65536) {
+    goto bad_state;
+  }
+  self->private_impl.f_w_end = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_w_end > 65536) {
+    goto bad_state;
+  }
+  self->private_impl.f_block_start = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_block_start > 65536) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 32768; i0++) {
+    self->private_impl.f_head[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+  }
+  for (i0 = 0; i0 < 32768; i0++) {
+    self->private_impl.f_prev[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+  }
+  self->private_impl.f_ins_pos = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_ins_pos > 65536) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 16384; i0++) {
+    self->private_impl.f_tokens[i0] = (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  self->private_impl.f_n_tokens = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_tokens > 16384) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      self->private_impl.f_freqs[i0][i1] =
+          (uint32_t)(wuffs_base__load_u32le(p));
+      p += 4;
+    }
+  }
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      self->private_impl.f_lens[i0][i1] = (uint8_t)(p[0]);
+      p += 1;
+      if (self->private_impl.f_lens[i0][i1] > 15) {
+        goto bad_state;
+      }
+    }
+  }
+  for (i0 = 0; i0 < 3; i0++) {
+    for (i1 = 0; i1 < 288; i1++) {
+      self->private_impl.f_codes[i0][i1] =
+          (uint16_t)(wuffs_base__load_u16le(p));
+      p += 2;
+    }
+  }
+  self->private_impl.f_n_lcodes = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_lcodes > 286) {
+    goto bad_state;
+  }
+  self->private_impl.f_n_dcodes = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_dcodes > 30) {
+    goto bad_state;
+  }
+  self->private_impl.f_n_clcodes = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_clcodes > 19) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 320; i0++) {
+    self->private_impl.f_clseq[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+  }
+  self->private_impl.f_n_clseq = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_clseq > 320) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 512; i0++) {
+    self->private_impl.f_hsort_keys[i0] = (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 512; i0++) {
+    self->private_impl.f_hsort_syms[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+    if (self->private_impl.f_hsort_syms[i0] > 287) {
+      goto bad_state;
+    }
+  }
+  self->private_impl.c_encode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode[0].v_z =
+      (wuffs_deflate__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_fill_window[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_window[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_window[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_write_block[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_block[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_write_block[0].v_stored = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_write_block[0].v_fixed = p[0];
+  p += 1;
+  self->private_impl.c_write_block[0].v_n_extra_bits =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_block[0].v_fixed_cost =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_block[0].v_dynamic_cost =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_block[0].v_n_bytes =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_block[0].v_stored_cost =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_stored[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_stored[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_stored[0].v_start =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].v_end =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].v_remaining =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].v_n_copied =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].v_new_start =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_stored[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_bits[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_bits[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_bits[0].v_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_dynamic_header[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_dynamic_header[0].coro_susp_point > 6) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_dynamic_header[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_dynamic_header[0].v_symbol =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_dynamic_header[0].v_n_extra_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_tokens[0].coro_susp_point > 6) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_t =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_lc =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_d =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_dc =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_tokens[0].v_magic =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_DEFLATE__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_DEFLATE__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
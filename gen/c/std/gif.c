@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,18 +1390,30 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
-  // ---------------- BEGIN USE "std/lzw"
+// ---------------- BEGIN USE "std/lzw"
 
 #ifndef WUFFS_LZW_H
 #define WUFFS_LZW_H
 
-  // Code generated by wuffs-c. DO NOT EDIT.
+// Code generated by wuffs-c. DO NOT EDIT.
+
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1219,26 +1433,25 @@ typedef int32_t wuffs_lzw__status;
 
 #define wuffs_lzw__packageid 1316776  // 0x001417A8
 
-#define WUFFS_LZW__STATUS_OK 0                            // 0x00000000
-#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_LZW__STATUS_OK 0  // 0x00000000
+#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                    // 0x80000006
-#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_LZW__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_LZW__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
-#define WUFFS_LZW__ERROR_BAD_CODE -799105024               // 0xD05EA000
+#define WUFFS_LZW__ERROR_BAD_CODE -799105024  // 0xD05EA000
 #define WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN -799105023  // 0xD05EA001
+#define WUFFS_LZW__ERROR_BAD_LITERAL -799105022  // 0xD05EA002
 
 bool wuffs_lzw__status__is_error(wuffs_lzw__status s);
 
@@ -1261,6 +1474,7 @@ typedef struct {
     uint32_t magic;
 
     uint32_t f_literal_width;
+    bool f_tiff_mode;
     uint8_t f_stack[4096];
     uint8_t f_suffixes[4096];
     uint16_t f_prefixes[4096];
@@ -1268,6 +1482,7 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       uint32_t v_literal_width;
+      bool v_tiff_mode;
       uint32_t v_clear_code;
       uint32_t v_end_code;
       uint32_t v_save_code;
@@ -1276,6 +1491,7 @@ typedef struct {
       uint32_t v_bits;
       uint32_t v_n_bits;
       uint32_t v_code;
+      uint32_t v_msb_bits;
       uint32_t v_s;
       uint32_t v_c;
       uint64_t v_n_copied;
@@ -1283,6 +1499,61 @@ typedef struct {
   } private_impl;
 } wuffs_lzw__decoder;
 
+// WUFFS_LZW__DECODER__STATE_LENGTH is the length of a wuffs_lzw__decoder's
+// saved state.
+#define WUFFS_LZW__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 16454)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_lzw__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_lzw__status status;
+    uint32_t magic;
+
+    uint32_t f_literal_width;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_buf[4096];
+    uint32_t f_buf_ri;
+    uint32_t f_buf_wi;
+    uint32_t f_htable[16384];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_literal_width;
+      uint32_t v_clear_code;
+      uint32_t v_end_code;
+      uint32_t v_hi;
+      uint32_t v_width;
+      uint32_t v_code;
+      bool v_has_code;
+      uint32_t v_literal;
+      uint32_t v_key;
+      uint32_t v_h;
+      uint32_t v_entry;
+      wuffs_lzw__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_buf[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_code[1];
+  } private_impl;
+} wuffs_lzw__encoder;
+
+// WUFFS_LZW__ENCODER__STATE_LENGTH is the length of a wuffs_lzw__encoder's
+// saved state.
+#define WUFFS_LZW__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 69719)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_lzw__decoder__check_wuffs_version is an initializer function.
@@ -1291,22 +1562,229 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__decoder returns sizeof(wuffs_lzw__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__decoder(void);
+
+// wuffs_lzw__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_lzw__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_lzw__encoder__check_wuffs_version(wuffs_lzw__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__encoder returns sizeof(wuffs_lzw__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_lzw__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__decoder__save_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__decoder__restore_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_lzw__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__encoder__save_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__encoder__restore_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
-                                           uint32_t a_lw);
+    uint32_t a_lw);
+
+void wuffs_lzw__decoder__set_tiff_mode(wuffs_lzw__decoder* self, bool a_m);
 
 wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
-                                             wuffs_base__io_writer a_dst,
-                                             wuffs_base__io_reader a_src);
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_lzw__encoder__set_literal_width(wuffs_lzw__encoder* self,
+    uint32_t a_lw);
+
+wuffs_lzw__status wuffs_lzw__encoder__encode(wuffs_lzw__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace lzw {
+
+// status is a strongly typed wuffs_lzw__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_LZW__STATUS_OK) {}
+  constexpr explicit status(wuffs_lzw__status repr) : repr_(repr) {}
+
+  constexpr wuffs_lzw__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_LZW__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_lzw__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_lzw__status repr_;
+};
+
+constexpr status status_ok(WUFFS_LZW__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_LZW__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_LZW__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_LZW__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_LZW__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_LZW__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_LZW__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_LZW__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_LZW__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_LZW__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_code(WUFFS_LZW__ERROR_BAD_CODE);
+constexpr status error_cyclical_prefix_chain(
+    WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN);
+constexpr status error_bad_literal(WUFFS_LZW__ERROR_BAD_LITERAL);
+
+// decoder is an RAII wrapper for a wuffs_lzw__decoder. Its constructor
+// calls wuffs_lzw__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_lzw__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__decoder__save_state and
+  // wuffs_lzw__decoder__restore_state. A saved state is
+  // WUFFS_LZW__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__decoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__decoder__set_literal_width(&c_, lw);
+  }
+
+  void set_tiff_mode(bool m) {
+    wuffs_lzw__decoder__set_tiff_mode(&c_, m);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_lzw__encoder. Its constructor
+// calls wuffs_lzw__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_lzw__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__encoder__save_state and
+  // wuffs_lzw__encoder__restore_state. A saved state is
+  // WUFFS_LZW__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__encoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__encoder__set_literal_width(&c_, lw);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__encoder c_;
+};
+
+}  // namespace lzw
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_LZW_H
 
 // ---------------- END   USE "std/lzw"
@@ -1329,34 +1807,32 @@ typedef int32_t wuffs_gif__status;
 
 #define wuffs_gif__packageid 1017222  // 0x000F8586
 
-#define WUFFS_GIF__STATUS_OK 0                            // 0x00000000
-#define WUFFS_GIF__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_GIF__STATUS_OK 0  // 0x00000000
+#define WUFFS_GIF__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_GIF__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_GIF__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_GIF__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                    // 0x80000006
-#define WUFFS_GIF__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_GIF__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_GIF__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_GIF__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_GIF__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_GIF__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_GIF__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_GIF__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_GIF__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_GIF__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_GIF__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_GIF__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_GIF__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_GIF__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
-
-#define WUFFS_GIF__ERROR_BAD_BLOCK -1105848320              // 0xBE161800
-#define WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL -1105848319    // 0xBE161801
-#define WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL -1105848318    // 0xBE161802
-#define WUFFS_GIF__ERROR_BAD_HEADER -1105848317             // 0xBE161803
-#define WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH -1105848316      // 0xBE161804
+#define WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_GIF__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_GIF__ERROR_BAD_BLOCK -1105848320  // 0xBE161800
+#define WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL -1105848319  // 0xBE161801
+#define WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL -1105848318  // 0xBE161802
+#define WUFFS_GIF__ERROR_BAD_HEADER -1105848317  // 0xBE161803
+#define WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH -1105848316  // 0xBE161804
 #define WUFFS_GIF__ERROR_NOT_ENOUGH_PIXEL_DATA -1105848315  // 0xBE161805
-#define WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA -1105848314    // 0xBE161806
-#define WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI \
-  -1105848313                                                      // 0xBE161807
-#define WUFFS_GIF__ERROR_TODO_UNSUPPORTED_INTERLACING -1105848312  // 0xBE161808
+#define WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA -1105848314  // 0xBE161806
+#define WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI -1105848313  // 0xBE161807
+#define WUFFS_GIF__ERROR_UNSUPPORTED_IMAGE_DIMENSIONS -1105848312  // 0xBE161808
+#define WUFFS_GIF__ERROR_UNSUPPORTED_PIXEL_FORMAT -1105848311  // 0xBE161809
 
 bool wuffs_gif__status__is_error(wuffs_gif__status s);
 
@@ -1385,7 +1861,7 @@ typedef struct {
     bool f_previous_lzw_decode_ended_abruptly;
     bool f_previous_use_global_palette;
     uint8_t f_background_color_index;
-    bool f_interlace;
+    uint8_t f_interlace;
     bool f_seen_num_loops;
     uint32_t f_num_loops;
     bool f_seen_graphic_control;
@@ -1398,6 +1874,7 @@ typedef struct {
     uint32_t f_dst_y;
     uint32_t f_dst_x0;
     uint32_t f_dst_x1;
+    uint32_t f_dst_y0;
     uint32_t f_dst_y1;
     uint32_t f_uncompressed_ri;
     uint32_t f_uncompressed_wi;
@@ -1473,6 +1950,102 @@ typedef struct {
   } private_impl;
 } wuffs_gif__decoder;
 
+// WUFFS_GIF__DECODER__STATE_LENGTH is the length of a wuffs_gif__decoder's
+// saved state.
+#define WUFFS_GIF__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 6396 + WUFFS_LZW__DECODER__STATE_LENGTH)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_gif__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_gif__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint32_t f_num_loops;
+    uint8_t f_call_sequence;
+    uint32_t f_frame_x0;
+    uint32_t f_frame_y0;
+    uint32_t f_frame_x1;
+    uint32_t f_frame_y1;
+    uint32_t f_src_x;
+    uint32_t f_src_y;
+    uint32_t f_palette_bits;
+    uint32_t f_global_palette_bits;
+    uint8_t f_palettes[2][1024];
+    uint8_t f_block[255];
+    uint32_t f_block_ri;
+    uint32_t f_block_wi;
+    wuffs_lzw__encoder f_lzw;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_width;
+      uint32_t v_height;
+      uint64_t scratch;
+    } c_encode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_encode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_encode_trailer[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_encode_lsd[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+    } c_encode_palette[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_loops;
+      uint32_t v_i;
+      uint64_t scratch;
+    } c_encode_ae[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_flags;
+      uint8_t v_disposal;
+      uint8_t v_transparent_index;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+      uint64_t v_centiseconds;
+      uint64_t v_duration;
+      uint64_t scratch;
+    } c_encode_gc[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_use_global_palette;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+      uint64_t scratch;
+    } c_encode_id[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_last_row;
+      wuffs_gif__status v_z;
+    } c_encode_pixels[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint32_t v_new_ri;
+    } c_write_block[1];
+  } private_impl;
+} wuffs_gif__encoder;
+
+// WUFFS_GIF__ENCODER__STATE_LENGTH is the length of a wuffs_gif__encoder's
+// saved state.
+#define WUFFS_GIF__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 2517 + WUFFS_LZW__ENCODER__STATE_LENGTH)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_gif__decoder__check_wuffs_version is an initializer function.
@@ -1481,55 +2054,287 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_gif__decoder__check_wuffs_version(wuffs_gif__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gif__decoder returns sizeof(wuffs_gif__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gif__decoder(void);
+
+// wuffs_gif__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_gif__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_gif__encoder__check_wuffs_version(wuffs_gif__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gif__encoder returns sizeof(wuffs_gif__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gif__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_gif__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GIF__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gif__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gif__status wuffs_gif__decoder__save_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gif__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GIF__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gif__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gif__status wuffs_gif__decoder__restore_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_gif__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GIF__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gif__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gif__status wuffs_gif__encoder__save_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gif__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GIF__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gif__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gif__status wuffs_gif__encoder__restore_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
-wuffs_gif__status wuffs_gif__decoder__decode_config(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_config* a_dst,
-    wuffs_base__io_reader a_src);
+wuffs_gif__status wuffs_gif__decoder__decode_config(wuffs_gif__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src);
 
-wuffs_gif__status wuffs_gif__decoder__decode_frame(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_dst,
-    wuffs_base__io_reader a_src);
+wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src);
 
 wuffs_gif__status wuffs_gif__decoder__decode_up_to_id_part1(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_config(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_config* a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_frame(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_buffer* a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_trailer(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
-#endif  // WUFFS_GIF_H
+// ---------------- C++ Wrappers
 
-// C HEADER ENDS HERE.
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
 
-#ifndef WUFFS_BASE_IMPL_H
-#define WUFFS_BASE_IMPL_H
+namespace wuffs {
+namespace gif {
 
-// Copyright 2017 The Wuffs Authors.
-//
-// Licensed under the Apache License, Version 2.0 (the "License");
-// you may not use this file except in compliance with the License.
-// You may obtain a copy of the License at
-//
-//    https://www.apache.org/licenses/LICENSE-2.0
-//
-// Unless required by applicable law or agreed to in writing, software
-// distributed under the License is distributed on an "AS IS" BASIS,
-// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-// See the License for the specific language governing permissions and
-// limitations under the License.
+// status is a strongly typed wuffs_gif__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_GIF__STATUS_OK) {}
+  constexpr explicit status(wuffs_gif__status repr) : repr_(repr) {}
 
-static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
-  return ((wuffs_base__empty_struct){});
-}
+  constexpr wuffs_gif__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_GIF__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_gif__status__string(repr_); }
 
-#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_gif__status repr_;
+};
+
+constexpr status status_ok(WUFFS_GIF__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_GIF__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_GIF__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_GIF__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_GIF__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_GIF__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_GIF__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_GIF__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_GIF__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_GIF__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_GIF__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_GIF__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_block(WUFFS_GIF__ERROR_BAD_BLOCK);
+constexpr status error_bad_extension_label(
+    WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL);
+constexpr status error_bad_graphic_control(
+    WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL);
+constexpr status error_bad_header(WUFFS_GIF__ERROR_BAD_HEADER);
+constexpr status error_bad_literal_width(WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH);
+constexpr status error_not_enough_pixel_data(
+    WUFFS_GIF__ERROR_NOT_ENOUGH_PIXEL_DATA);
+constexpr status error_too_much_pixel_data(
+    WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA);
+constexpr status error_internal_error_inconsistent_ri_wi(
+    WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI);
+constexpr status error_unsupported_image_dimensions(
+    WUFFS_GIF__ERROR_UNSUPPORTED_IMAGE_DIMENSIONS);
+constexpr status error_unsupported_pixel_format(
+    WUFFS_GIF__ERROR_UNSUPPORTED_PIXEL_FORMAT);
+
+// decoder is an RAII wrapper for a wuffs_gif__decoder. Its constructor
+// calls wuffs_gif__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_gif__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gif__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gif__decoder__save_state and
+  // wuffs_gif__decoder__restore_state. A saved state is
+  // WUFFS_GIF__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gif__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gif__decoder__restore_state(&c_, src));
+  }
+
+  status decode_config(
+      wuffs_base__image_config* dst, wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_config(&c_, dst, src));
+  }
+
+  status decode_frame(
+      wuffs_base__image_buffer* dst, wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_frame(&c_, dst, src));
+  }
+
+  status decode_up_to_id_part1(wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_up_to_id_part1(&c_, src));
+  }
+
+ private:
+  wuffs_gif__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_gif__encoder. Its constructor
+// calls wuffs_gif__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_gif__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gif__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gif__encoder__save_state and
+  // wuffs_gif__encoder__restore_state. A saved state is
+  // WUFFS_GIF__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gif__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gif__encoder__restore_state(&c_, src));
+  }
+
+  status encode_config(
+      wuffs_base__io_writer dst, wuffs_base__image_config* src) {
+    return status(wuffs_gif__encoder__encode_config(&c_, dst, src));
+  }
+
+  status encode_frame(
+      wuffs_base__io_writer dst, wuffs_base__image_buffer* src) {
+    return status(wuffs_gif__encoder__encode_frame(&c_, dst, src));
+  }
+
+  status encode_trailer(wuffs_base__io_writer dst) {
+    return status(wuffs_gif__encoder__encode_trailer(&c_, dst));
+  }
+
+ private:
+  wuffs_gif__encoder c_;
+};
+
+}  // namespace gif
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_GIF_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
+  return ((wuffs_base__empty_struct){});
+}
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
 
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
@@ -1680,6 +2485,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -2022,6 +2850,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -2065,22 +2913,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -2091,16 +2980,11 @@ bool wuffs_gif__status__is_error(wuffs_gif__status s) {
   return s < 0;
 }
 
-const char* wuffs_gif__status__strings[9] = {
-    "gif: bad block",
-    "gif: bad extension label",
-    "gif: bad graphic control",
-    "gif: bad header",
-    "gif: bad literal width",
-    "gif: not enough pixel data",
-    "gif: too much pixel data",
-    "gif: internal error: inconsistent ri/wi",
-    "gif: TODO: unsupported interlacing",
+const char* wuffs_gif__status__strings[10] = {
+    "gif: bad block", "gif: bad extension label", "gif: bad graphic control",
+    "gif: bad header", "gif: bad literal width", "gif: not enough pixel data",
+    "gif: too much pixel data", "gif: internal error: inconsistent ri/wi",
+    "gif: unsupported image dimensions", "gif: unsupported pixel format",
 };
 
 const char* wuffs_gif__status__string(wuffs_gif__status s) {
@@ -2113,7 +2997,7 @@ const char* wuffs_gif__status__string(wuffs_gif__status s) {
       break;
     case wuffs_gif__packageid:
       a = wuffs_gif__status__strings;
-      n = 9;
+      n = 10;
       break;
     case wuffs_lzw__packageid:
       return wuffs_lzw__status__string(s);
@@ -2132,52 +3016,83 @@ static const uint8_t wuffs_gif__netscape2dot0[11] = {
     78, 69, 84, 83, 67, 65, 80, 69, 50, 46, 48,
 };
 
+static const uint32_t wuffs_gif__interlace_start[5] = {
+    4294967295, 1, 2, 4, 0,
+};
+
+static const uint8_t wuffs_gif__interlace_delta[5] = {
+    1, 2, 4, 8, 8,
+};
+
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
 static wuffs_gif__status wuffs_gif__decoder__decode_header(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__decode_lsd(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__decode_extension(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__skip_blocks(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
 
-static wuffs_gif__status wuffs_gif__decoder__decode_ae(
-    wuffs_gif__decoder* self,
+static wuffs_gif__status wuffs_gif__decoder__decode_ae(wuffs_gif__decoder* self,
     wuffs_base__io_reader a_src);
 
-static wuffs_gif__status wuffs_gif__decoder__decode_gc(
-    wuffs_gif__decoder* self,
+static wuffs_gif__status wuffs_gif__decoder__decode_gc(wuffs_gif__decoder* self,
     wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id_part0(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_dst,
+    wuffs_gif__decoder* self, wuffs_base__image_buffer* a_dst,
     wuffs_base__io_reader a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__copy_to_image_buffer(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_ib);
+    wuffs_gif__decoder* self, wuffs_base__image_buffer* a_ib);
+
+static void wuffs_gif__decoder__advance_dst_y(wuffs_gif__decoder* self);
+
+static void wuffs_gif__encoder__prepare_frame(wuffs_gif__encoder* self,
+    wuffs_base__image_buffer* a_src);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_lsd(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_palette(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_ae(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_gc(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_buffer* a_src);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_id(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst);
+
+static wuffs_gif__status wuffs_gif__encoder__encode_pixels(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__image_buffer* a_src);
+
+static wuffs_gif__status wuffs_gif__encoder__write_block(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst);
 
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_gif__decoder(void) {
+  return sizeof(wuffs_gif__decoder);
+}
+
 void wuffs_gif__decoder__check_wuffs_version(wuffs_gif__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -2196,18 +3111,42 @@ void wuffs_gif__decoder__check_wuffs_version(wuffs_gif__decoder* self,
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
   wuffs_lzw__decoder__check_wuffs_version(&self->private_impl.f_lzw,
-                                          sizeof(self->private_impl.f_lzw),
-                                          WUFFS_VERSION);
+      sizeof(self->private_impl.f_lzw), WUFFS_VERSION);
+}
+
+size_t sizeof__wuffs_gif__encoder(void) {
+  return sizeof(wuffs_gif__encoder);
+}
+
+void wuffs_gif__encoder__check_wuffs_version(wuffs_gif__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  wuffs_lzw__encoder__check_wuffs_version(&self->private_impl.f_lzw,
+      sizeof(self->private_impl.f_lzw), WUFFS_VERSION);
 }
 
 // ---------------- Function Implementations
 
 // -------- func decoder.decode_config
 
-wuffs_gif__status wuffs_gif__decoder__decode_config(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_config* a_dst,
-    wuffs_base__io_reader a_src) {
+wuffs_gif__status wuffs_gif__decoder__decode_config(wuffs_gif__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_GIF__ERROR_BAD_RECEIVER;
   }
@@ -2257,9 +3196,8 @@ wuffs_gif__status wuffs_gif__decoder__decode_config(
     if (self->private_impl.f_seen_num_loops) {
       v_num_loops = self->private_impl.f_num_loops;
     }
-    wuffs_base__image_config__initialize(
-        a_dst, 570984584, 0, self->private_impl.f_width,
-        self->private_impl.f_height, v_num_loops);
+    wuffs_base__image_config__initialize(a_dst, 570984584, 0,
+        self->private_impl.f_width, self->private_impl.f_height, v_num_loops);
     self->private_impl.f_call_sequence = 1;
 
     goto ok;
@@ -2281,10 +3219,8 @@ exit:
 
 // -------- func decoder.decode_frame
 
-wuffs_gif__status wuffs_gif__decoder__decode_frame(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_dst,
-    wuffs_base__io_reader a_src) {
+wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_GIF__ERROR_BAD_RECEIVER;
   }
@@ -2354,8 +3290,7 @@ exit:
 // -------- func decoder.decode_up_to_id_part1
 
 wuffs_gif__status wuffs_gif__decoder__decode_up_to_id_part1(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_GIF__ERROR_BAD_RECEIVER;
   }
@@ -2471,8 +3406,7 @@ short_read_src:
 // -------- func decoder.decode_header
 
 static wuffs_gif__status wuffs_gif__decoder__decode_header(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint8_t v_c[6];
@@ -2555,8 +3489,7 @@ short_read_src:
 // -------- func decoder.decode_lsd
 
 static wuffs_gif__status wuffs_gif__decoder__decode_lsd(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint8_t v_c[7];
@@ -2700,8 +3633,7 @@ short_read_src:
 // -------- func decoder.decode_extension
 
 static wuffs_gif__status wuffs_gif__decoder__decode_extension(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint8_t v_label;
@@ -2811,8 +3743,7 @@ short_read_src:
 // -------- func decoder.skip_blocks
 
 static wuffs_gif__status wuffs_gif__decoder__skip_blocks(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint8_t v_block_size;
@@ -2898,8 +3829,7 @@ short_read_src:
 
 // -------- func decoder.decode_ae
 
-static wuffs_gif__status wuffs_gif__decoder__decode_ae(
-    wuffs_gif__decoder* self,
+static wuffs_gif__status wuffs_gif__decoder__decode_ae(wuffs_gif__decoder* self,
     wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
@@ -3111,8 +4041,7 @@ short_read_src:
 
 // -------- func decoder.decode_gc
 
-static wuffs_gif__status wuffs_gif__decoder__decode_gc(
-    wuffs_gif__decoder* self,
+static wuffs_gif__status wuffs_gif__decoder__decode_gc(wuffs_gif__decoder* self,
     wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
@@ -3250,8 +4179,7 @@ short_read_src:
 // -------- func decoder.decode_id_part0
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id_part0(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src) {
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint32_t v_frame_x;
@@ -3346,6 +4274,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part0(
     self->private_impl.f_dst_x = v_frame_x;
     self->private_impl.f_dst_y = v_frame_y;
     self->private_impl.f_dst_x0 = v_frame_x;
+    self->private_impl.f_dst_y0 = v_frame_y;
     {
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
       uint16_t t_5;
@@ -3447,8 +4376,7 @@ short_read_src:
 // -------- func decoder.decode_id_part1
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_dst,
+    wuffs_gif__decoder* self, wuffs_base__image_buffer* a_dst,
     wuffs_base__io_reader a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
@@ -3516,7 +4444,11 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
       uint8_t t_0 = *ioptr_src++;
       v_flags = t_0;
     }
-    self->private_impl.f_interlace = ((v_flags & 64) != 0);
+    if ((v_flags & 64) != 0) {
+      self->private_impl.f_interlace = 4;
+    } else {
+      self->private_impl.f_interlace = 0;
+    }
     v_use_local_palette = ((v_flags & 128) != 0);
     if (v_use_local_palette) {
       v_num_palette_entries = (((uint32_t)(1)) << (1 + (v_flags & 7)));
@@ -3571,10 +4503,9 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
     }
     if (self->private_impl.f_previous_lzw_decode_ended_abruptly) {
       (memset(&self->private_impl.f_lzw, 0, sizeof((wuffs_lzw__decoder){})),
-       wuffs_lzw__decoder__check_wuffs_version(&self->private_impl.f_lzw,
-                                               sizeof((wuffs_lzw__decoder){}),
-                                               WUFFS_VERSION),
-       wuffs_base__return_empty_struct());
+          wuffs_lzw__decoder__check_wuffs_version(&self->private_impl.f_lzw,
+          sizeof((wuffs_lzw__decoder){}), WUFFS_VERSION),
+          wuffs_base__return_empty_struct());
     }
     {
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
@@ -3589,7 +4520,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
       goto exit;
     }
     wuffs_lzw__decoder__set_literal_width(&self->private_impl.f_lzw,
-                                          ((uint32_t)(v_lw)));
+        ((uint32_t)(v_lw)));
     self->private_impl.f_previous_lzw_decode_ended_abruptly = true;
     while (true) {
       {
@@ -3612,12 +4543,10 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
           wuffs_base__io_writer o_0_v_w = v_w;
           uint8_t* o_0_ioptr_v_w = ioptr_w;
           uint8_t* o_0_iobounds1_v_w = iobounds1_w;
-          wuffs_base__io_writer__set(
-              &v_w, &u_w, &ioptr_w, &iobounds1_w,
-              wuffs_base__slice_u8__subslice_i(
-                  ((wuffs_base__slice_u8){
-                      .ptr = self->private_impl.f_uncompressed, .len = 4096}),
-                  self->private_impl.f_uncompressed_wi));
+          wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+              wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){
+              .ptr = self->private_impl.f_uncompressed, .len = 4096}),
+              self->private_impl.f_uncompressed_wi));
           wuffs_base__io_reader__set_limit(&a_src, ioptr_src, v_block_size);
           wuffs_base__io_reader__set_mark(&a_src, ioptr_src);
           {
@@ -3626,8 +4555,9 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
               a_src.private_impl.buf->ri =
                   ioptr_src - a_src.private_impl.buf->ptr;
             }
-            wuffs_gif__status t_5 = wuffs_lzw__decoder__decode(
-                &self->private_impl.f_lzw, v_w, a_src);
+            wuffs_gif__status t_5 =
+                wuffs_lzw__decoder__decode(&self->private_impl.f_lzw, v_w,
+                a_src);
             ioptr_w = u_w.ptr + u_w.wi;
             if (a_src.private_impl.buf) {
               ioptr_src =
@@ -3636,16 +4566,11 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
             v_z = t_5;
           }
           self->private_impl.f_uncompressed_wi =
-              (4096 - ((uint32_t)(wuffs_base__u64__min(
-                          ((uint64_t)(iobounds1_w - ioptr_w)), 4096))));
-          wuffs_base__u64__sat_sub_indirect(
-              &v_block_size,
-              ((uint64_t)(((wuffs_base__slice_u8){
-                               .ptr = a_src.private_impl.bounds[0],
-                               .len = (size_t)(ioptr_src -
-                                               a_src.private_impl.bounds[0]),
-                           })
-                              .len)));
+              (4096 - ((uint32_t)(wuffs_base__u64__min(((uint64_t)(
+              iobounds1_w - ioptr_w)), 4096))));
+          wuffs_base__u64__sat_sub_indirect(&v_block_size, ((uint64_t)(((
+              wuffs_base__slice_u8){.ptr = a_src.private_impl.bounds[0], .len =
+              (size_t)(ioptr_src - a_src.private_impl.bounds[0])}).len)));
           v_w = o_0_v_w;
           ioptr_w = o_0_ioptr_v_w;
           iobounds1_w = o_0_iobounds1_v_w;
@@ -3682,16 +4607,17 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id_part1(
     }
     v_palette = ((wuffs_base__slice_u8){});
     if (v_use_local_palette) {
-      v_palette = ((wuffs_base__slice_u8){
-          .ptr = self->private_impl.f_palettes[1], .len = 1024});
+      v_palette =
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palettes[1],
+          .len = 1024});
     } else if (!self->private_impl.f_previous_use_global_palette) {
-      v_palette = ((wuffs_base__slice_u8){
-          .ptr = self->private_impl.f_palettes[0], .len = 1024});
+      v_palette =
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palettes[0],
+          .len = 1024});
     }
     wuffs_base__image_buffer__update(a_dst, self->private_impl.f_frame_rect,
-                                     self->private_impl.f_gc_duration, true,
-                                     self->private_impl.f_gc_disposal,
-                                     v_palette);
+        self->private_impl.f_gc_duration, true,
+        self->private_impl.f_gc_disposal, v_palette);
     self->private_impl.f_previous_use_global_palette = !v_use_local_palette;
     self->private_impl.f_seen_graphic_control = false;
     self->private_impl.f_gc_has_transparent_index = false;
@@ -3739,8 +4665,7 @@ short_read_src:
 // -------- func decoder.copy_to_image_buffer
 
 static wuffs_gif__status wuffs_gif__decoder__copy_to_image_buffer(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_ib) {
+    wuffs_gif__decoder* self, wuffs_base__image_buffer* a_ib) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   wuffs_base__slice_u8 v_dst;
@@ -3756,36 +4681,29 @@ static wuffs_gif__status wuffs_gif__decoder__copy_to_image_buffer(
   v_tab = wuffs_base__image_buffer__plane(a_ib, 0);
 label_0_continue:;
   while (self->private_impl.f_uncompressed_wi >
-         self->private_impl.f_uncompressed_ri) {
-    v_src = wuffs_base__slice_u8__subslice_ij(
-        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_uncompressed,
-                                .len = 4096}),
+      self->private_impl.f_uncompressed_ri) {
+    v_src = wuffs_base__slice_u8__subslice_ij(((wuffs_base__slice_u8){
+        .ptr = self->private_impl.f_uncompressed, .len = 4096}),
         self->private_impl.f_uncompressed_ri,
         self->private_impl.f_uncompressed_wi);
     if (self->private_impl.f_dst_y >= self->private_impl.f_dst_y1) {
       status = WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA;
       goto exit;
     }
-    if (self->private_impl.f_interlace) {
-      status = WUFFS_GIF__ERROR_TODO_UNSUPPORTED_INTERLACING;
-      goto exit;
-    } else {
-      v_dst = wuffs_base__table_u8__row(v_tab, self->private_impl.f_dst_y);
-    }
+    v_dst = wuffs_base__table_u8__row(v_tab, self->private_impl.f_dst_y);
     if (((uint64_t)(self->private_impl.f_dst_x)) < ((uint64_t)(v_dst.len))) {
       if ((((uint64_t)(self->private_impl.f_dst_x)) <=
-           ((uint64_t)(self->private_impl.f_dst_x1))) &&
-          (((uint64_t)(self->private_impl.f_dst_x1)) <=
-           ((uint64_t)(v_dst.len)))) {
-        v_dst = wuffs_base__slice_u8__subslice_ij(
-            v_dst, ((uint64_t)(self->private_impl.f_dst_x)),
+          ((uint64_t)(self->private_impl.f_dst_x1))) && (((uint64_t)(
+          self->private_impl.f_dst_x1)) <= ((uint64_t)(v_dst.len)))) {
+        v_dst = wuffs_base__slice_u8__subslice_ij(v_dst,
+            ((uint64_t)(self->private_impl.f_dst_x)),
             ((uint64_t)(self->private_impl.f_dst_x1)));
       } else {
-        v_dst = wuffs_base__slice_u8__subslice_i(
-            v_dst, ((uint64_t)(self->private_impl.f_dst_x)));
+        v_dst = wuffs_base__slice_u8__subslice_i(v_dst,
+            ((uint64_t)(self->private_impl.f_dst_x)));
       }
-      v_n = ((uint32_t)(
-          (wuffs_base__slice_u8__copy_from_slice(v_dst, v_src) & 4294967295)));
+      v_n = ((uint32_t)((wuffs_base__slice_u8__copy_from_slice(v_dst,
+          v_src) & 4294967295)));
       v_new_ri =
           wuffs_base__u32__sat_add(self->private_impl.f_uncompressed_ri, v_n);
       self->private_impl.f_uncompressed_ri =
@@ -3794,27 +4712,27 @@ label_0_continue:;
     }
     if (self->private_impl.f_dst_x1 <= self->private_impl.f_dst_x) {
       self->private_impl.f_dst_x = self->private_impl.f_dst_x0;
-      self->private_impl.f_dst_y += 1;
+      wuffs_gif__decoder__advance_dst_y(self);
       goto label_0_continue;
     }
     if (self->private_impl.f_uncompressed_wi ==
         self->private_impl.f_uncompressed_ri) {
       goto label_0_break;
     } else if (self->private_impl.f_uncompressed_wi <
-               self->private_impl.f_uncompressed_ri) {
+        self->private_impl.f_uncompressed_ri) {
       status = WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI;
       goto exit;
     }
     v_n = (self->private_impl.f_dst_x1 - self->private_impl.f_dst_x);
     v_n = wuffs_base__u32__min(v_n, (self->private_impl.f_uncompressed_wi -
-                                     self->private_impl.f_uncompressed_ri));
+        self->private_impl.f_uncompressed_ri));
     v_new_ri =
         wuffs_base__u32__sat_add(self->private_impl.f_uncompressed_ri, v_n);
     self->private_impl.f_uncompressed_ri = wuffs_base__u32__min(v_new_ri, 4096);
     wuffs_base__u32__sat_add_indirect(&self->private_impl.f_dst_x, v_n);
     if (self->private_impl.f_dst_x1 <= self->private_impl.f_dst_x) {
       self->private_impl.f_dst_x = self->private_impl.f_dst_x0;
-      self->private_impl.f_dst_y += 1;
+      wuffs_gif__decoder__advance_dst_y(self);
       goto label_0_continue;
     }
     if (self->private_impl.f_uncompressed_ri !=
@@ -3831,3 +4749,2215 @@ label_0_break:;
 exit:
   return status;
 }
+
+// -------- func decoder.advance_dst_y
+
+static void wuffs_gif__decoder__advance_dst_y(wuffs_gif__decoder* self) {
+  self->private_impl.f_dst_y +=
+      ((uint32_t)(wuffs_gif__interlace_delta[self->private_impl.f_interlace]));
+  while ((self->private_impl.f_interlace > 1) &&
+      (self->private_impl.f_dst_y >= self->private_impl.f_dst_y1)) {
+    self->private_impl.f_interlace -= 1;
+    self->private_impl.f_dst_y = (self->private_impl.f_dst_y0 +
+        wuffs_gif__interlace_start[self->private_impl.f_interlace]);
+  }
+}
+
+// -------- func encoder.encode_config
+
+wuffs_gif__status wuffs_gif__encoder__encode_config(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_config* a_src) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_src) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint32_t v_width;
+  uint32_t v_height;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_encode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_width = self->private_impl.c_encode_config[0].v_width;
+    v_height = self->private_impl.c_encode_config[0].v_height;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    v_width = wuffs_base__image_config__width(a_src);
+    v_height = wuffs_base__image_config__height(a_src);
+    if ((v_width > 65535) || (v_height > 65535)) {
+      status = WUFFS_GIF__ERROR_UNSUPPORTED_IMAGE_DIMENSIONS;
+      goto exit;
+    }
+    self->private_impl.f_width = v_width;
+    self->private_impl.f_height = v_height;
+    self->private_impl.f_num_loops = wuffs_base__image_config__num_loops(a_src);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode_config[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode_config[0].scratch < 6) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(78380036274529)) >>
+          (40 - (8 * self->private_impl.c_encode_config[0].scratch)));
+      self->private_impl.c_encode_config[0].scratch++;
+    }
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_config[0].v_width = v_width;
+  self->private_impl.c_encode_config[0].v_height = v_height;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func encoder.encode_frame
+
+wuffs_gif__status wuffs_gif__encoder__encode_frame(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_buffer* a_src) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_src) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  wuffs_base__table_u8 v_tab;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_encode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_tab = ((wuffs_base__table_u8){});
+  } else {
+    v_tab = ((wuffs_base__table_u8){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if ((self->private_impl.f_call_sequence == 0) ||
+        (self->private_impl.f_call_sequence >= 3)) {
+      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    v_tab = wuffs_base__image_buffer__plane(a_src, 0);
+    if (((uint64_t)(v_tab.width)) != ((uint64_t)(self->private_impl.f_width))) {
+      status = WUFFS_GIF__ERROR_UNSUPPORTED_PIXEL_FORMAT;
+      goto exit;
+    }
+    wuffs_gif__encoder__prepare_frame(self, a_src);
+    if (self->private_impl.f_call_sequence == 1) {
+      self->private_impl.f_global_palette_bits =
+          self->private_impl.f_palette_bits;
+      wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){
+          .ptr = self->private_impl.f_palettes[0], .len = 1024}),
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palettes[1],
+          .len = 1024}));
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_gif__encoder__encode_lsd(self, a_dst);
+      if (status) {
+        goto suspend;
+      }
+      if (self->private_impl.f_num_loops != 1) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_gif__encoder__encode_ae(self, a_dst);
+        if (status) {
+          goto suspend;
+        }
+      }
+      self->private_impl.f_call_sequence = 2;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_gif__encoder__encode_gc(self, a_dst, a_src);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    status = wuffs_gif__encoder__encode_id(self, a_dst);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    status = wuffs_gif__encoder__encode_pixels(self, a_dst, a_src);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_frame[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func encoder.encode_trailer
+
+wuffs_gif__status wuffs_gif__encoder__encode_trailer(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_encode_trailer[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if ((self->private_impl.f_call_sequence == 0) ||
+        (self->private_impl.f_call_sequence >= 3)) {
+      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    if (self->private_impl.f_call_sequence == 1) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (a_dst.private_impl.buf) {
+        a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+      }
+      status = wuffs_gif__encoder__encode_lsd(self, a_dst);
+      if (a_dst.private_impl.buf) {
+        ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+      }
+      if (status) {
+        goto suspend;
+      }
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 59;
+    self->private_impl.f_call_sequence = 3;
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_trailer[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_trailer[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func encoder.prepare_frame
+
+static void wuffs_gif__encoder__prepare_frame(wuffs_gif__encoder* self,
+    wuffs_base__image_buffer* a_src) {
+  wuffs_base__table_u8 v_tab;
+  uint8_t v_max_index;
+  wuffs_base__slice_u8 v_row;
+  uint32_t v_y;
+  uint32_t v_bits;
+
+  v_tab = wuffs_base__image_buffer__plane(a_src, 0);
+  self->private_impl.f_frame_x1 =
+      wuffs_base__u32__min(wuffs_base__image_buffer__dirty_rect(
+      a_src).max_exclusive_x, self->private_impl.f_width);
+  self->private_impl.f_frame_y1 =
+      wuffs_base__u32__min(wuffs_base__image_buffer__dirty_rect(
+      a_src).max_exclusive_y, self->private_impl.f_height);
+  self->private_impl.f_frame_y1 =
+      wuffs_base__u32__min(self->private_impl.f_frame_y1,
+      ((uint32_t)(wuffs_base__u64__min(((uint64_t)(v_tab.height)), 65535))));
+  self->private_impl.f_frame_x0 =
+      wuffs_base__u32__min(wuffs_base__image_buffer__dirty_rect(
+      a_src).min_inclusive_x, self->private_impl.f_frame_x1);
+  self->private_impl.f_frame_y0 =
+      wuffs_base__u32__min(wuffs_base__image_buffer__dirty_rect(
+      a_src).min_inclusive_y, self->private_impl.f_frame_y1);
+  self->private_impl.f_src_x = self->private_impl.f_frame_x0;
+  self->private_impl.f_src_y = self->private_impl.f_frame_y0;
+  v_max_index = 0;
+  v_row = ((wuffs_base__slice_u8){});
+  v_y = self->private_impl.f_frame_y0;
+  while (v_y < self->private_impl.f_frame_y1) {
+    v_row = wuffs_base__table_u8__row(v_tab, v_y);
+    if ((((uint64_t)(self->private_impl.f_frame_x0)) <=
+        ((uint64_t)(self->private_impl.f_frame_x1))) && (((uint64_t)(
+        self->private_impl.f_frame_x1)) <= ((uint64_t)(v_row.len)))) {
+      v_row = wuffs_base__slice_u8__subslice_ij(v_row,
+          ((uint64_t)(self->private_impl.f_frame_x0)),
+          ((uint64_t)(self->private_impl.f_frame_x1)));
+    }
+    {
+      wuffs_base__slice_u8 i_slice_p = v_row;
+      wuffs_base__slice_u8 v_p = i_slice_p;
+      v_p.len = 1;
+      uint8_t* i_end0_p = i_slice_p.ptr + (i_slice_p.len / 1) * 1;
+      while (v_p.ptr < i_end0_p) {
+        v_max_index = wuffs_base__u8__max(v_max_index, v_p.ptr[0]);
+        v_p.ptr += 1;
+      }
+    }
+    wuffs_base__u32__sat_add_indirect(&v_y, 1);
+  }
+  v_bits = 1;
+  while ((v_bits < 8) &&
+      ((((uint32_t)(1)) << v_bits) <= ((uint32_t)(v_max_index)))) {
+    v_bits += 1;
+  }
+  self->private_impl.f_palette_bits = v_bits;
+  wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){
+      .ptr = self->private_impl.f_palettes[1], .len = 1024}),
+      wuffs_base__image_buffer__palette(a_src));
+}
+
+// -------- func encoder.encode_lsd
+
+static wuffs_gif__status wuffs_gif__encoder__encode_lsd(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode_lsd[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode_lsd[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode_lsd[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(self->private_impl.f_width)))) >>
+          (8 * self->private_impl.c_encode_lsd[0].scratch));
+      self->private_impl.c_encode_lsd[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    self->private_impl.c_encode_lsd[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    while (self->private_impl.c_encode_lsd[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(self->private_impl.f_height)))) >>
+          (8 * self->private_impl.c_encode_lsd[0].scratch));
+      self->private_impl.c_encode_lsd[0].scratch++;
+    }
+    if (self->private_impl.f_global_palette_bits == 0) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      self->private_impl.c_encode_lsd[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      while (self->private_impl.c_encode_lsd[0].scratch < 3) {
+        if (ioptr_dst == iobounds1_dst) {
+          status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+          goto suspend;
+        }
+        *ioptr_dst++ = (uint8_t)(((uint64_t)(0)) >>
+            (16 - (8 * self->private_impl.c_encode_lsd[0].scratch)));
+        self->private_impl.c_encode_lsd[0].scratch++;
+      }
+      status = WUFFS_GIF__STATUS_OK;
+      goto ok;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = (240 |
+        ((uint8_t)(((self->private_impl.f_global_palette_bits - 1) & 7))));
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    self->private_impl.c_encode_lsd[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+    while (self->private_impl.c_encode_lsd[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(0)) >>
+          (8 - (8 * self->private_impl.c_encode_lsd[0].scratch)));
+      self->private_impl.c_encode_lsd[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+    if (a_dst.private_impl.buf) {
+      a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+    }
+    status = wuffs_gif__encoder__encode_palette(self, a_dst);
+    if (a_dst.private_impl.buf) {
+      ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    }
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_lsd[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_lsd[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.encode_palette
+
+static wuffs_gif__status wuffs_gif__encoder__encode_palette(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint32_t v_num_palette_entries;
+  uint32_t v_i;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_encode_palette[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_num_palette_entries =
+        self->private_impl.c_encode_palette[0].v_num_palette_entries;
+    v_i = self->private_impl.c_encode_palette[0].v_i;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_num_palette_entries =
+        (((uint32_t)(1)) << self->private_impl.f_palette_bits);
+    v_i = 0;
+    while (v_i < v_num_palette_entries) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = self->private_impl.f_palettes[1][((4 * v_i) + 2)];
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = self->private_impl.f_palettes[1][((4 * v_i) + 1)];
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = self->private_impl.f_palettes[1][((4 * v_i) + 0)];
+      v_i += 1;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_palette[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_palette[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_palette[0].v_num_palette_entries =
+      v_num_palette_entries;
+  self->private_impl.c_encode_palette[0].v_i = v_i;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.encode_ae
+
+static wuffs_gif__status wuffs_gif__encoder__encode_ae(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint32_t v_num_loops;
+  uint32_t v_i;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode_ae[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_num_loops = self->private_impl.c_encode_ae[0].v_num_loops;
+    v_i = self->private_impl.c_encode_ae[0].v_i;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_num_loops = self->private_impl.f_num_loops;
+    if (v_num_loops > 0) {
+      v_num_loops -= 1;
+      v_num_loops = wuffs_base__u32__min(v_num_loops, 65535);
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode_ae[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode_ae[0].scratch < 3) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(2227979)) >>
+          (16 - (8 * self->private_impl.c_encode_ae[0].scratch)));
+      self->private_impl.c_encode_ae[0].scratch++;
+    }
+    v_i = 0;
+    while (v_i < 11) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = wuffs_gif__netscape2dot0[v_i];
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    self->private_impl.c_encode_ae[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    while (self->private_impl.c_encode_ae[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(769)) >>
+          (8 - (8 * self->private_impl.c_encode_ae[0].scratch)));
+      self->private_impl.c_encode_ae[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    self->private_impl.c_encode_ae[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    while (self->private_impl.c_encode_ae[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)((v_num_loops & 65535))))) >>
+          (8 * self->private_impl.c_encode_ae[0].scratch));
+      self->private_impl.c_encode_ae[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_ae[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_ae[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_ae[0].v_num_loops = v_num_loops;
+  self->private_impl.c_encode_ae[0].v_i = v_i;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.encode_gc
+
+static wuffs_gif__status wuffs_gif__encoder__encode_gc(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_buffer* a_src) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t v_flags;
+  uint8_t v_disposal;
+  uint8_t v_transparent_index;
+  uint32_t v_num_palette_entries;
+  uint32_t v_i;
+  uint64_t v_centiseconds;
+  uint64_t v_duration;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode_gc[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_flags = self->private_impl.c_encode_gc[0].v_flags;
+    v_disposal = self->private_impl.c_encode_gc[0].v_disposal;
+    v_transparent_index = self->private_impl.c_encode_gc[0].v_transparent_index;
+    v_num_palette_entries =
+        self->private_impl.c_encode_gc[0].v_num_palette_entries;
+    v_i = self->private_impl.c_encode_gc[0].v_i;
+    v_centiseconds = self->private_impl.c_encode_gc[0].v_centiseconds;
+    v_duration = self->private_impl.c_encode_gc[0].v_duration;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_flags = 0;
+    v_disposal = wuffs_base__image_buffer__disposal(a_src);
+    if (v_disposal == 1) {
+      v_flags = 8;
+    } else if (v_disposal == 2) {
+      v_flags = 12;
+    }
+    v_transparent_index = 0;
+    v_num_palette_entries =
+        (((uint32_t)(1)) << self->private_impl.f_palette_bits);
+    v_i = 0;
+    while (v_i < v_num_palette_entries) {
+      if (self->private_impl.f_palettes[1][((4 * v_i) + 3)] == 0) {
+        v_flags |= 1;
+        v_transparent_index = ((uint8_t)((v_i & 255)));
+        goto label_0_break;
+      }
+      v_i += 1;
+    }
+  label_0_break:;
+    v_centiseconds =
+        (wuffs_base__u64__sat_add(wuffs_base__image_buffer__duration(a_src),
+        3528000) / 7056000);
+    v_duration = wuffs_base__u64__min(v_centiseconds, 65535);
+    if ((v_flags == 0) && (v_duration == 0)) {
+      status = WUFFS_GIF__STATUS_OK;
+      goto ok;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode_gc[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode_gc[0].scratch < 3) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(2226436)) >>
+          (16 - (8 * self->private_impl.c_encode_gc[0].scratch)));
+      self->private_impl.c_encode_gc[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = v_flags;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    self->private_impl.c_encode_gc[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    while (self->private_impl.c_encode_gc[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(((uint16_t)(v_duration)))) >>
+          (8 * self->private_impl.c_encode_gc[0].scratch));
+      self->private_impl.c_encode_gc[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = v_transparent_index;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_gc[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_gc[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_gc[0].v_flags = v_flags;
+  self->private_impl.c_encode_gc[0].v_disposal = v_disposal;
+  self->private_impl.c_encode_gc[0].v_transparent_index = v_transparent_index;
+  self->private_impl.c_encode_gc[0].v_num_palette_entries =
+      v_num_palette_entries;
+  self->private_impl.c_encode_gc[0].v_i = v_i;
+  self->private_impl.c_encode_gc[0].v_centiseconds = v_centiseconds;
+  self->private_impl.c_encode_gc[0].v_duration = v_duration;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.encode_id
+
+static wuffs_gif__status wuffs_gif__encoder__encode_id(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  bool v_use_global_palette;
+  uint32_t v_num_palette_entries;
+  uint32_t v_i;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode_id[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_use_global_palette =
+        self->private_impl.c_encode_id[0].v_use_global_palette;
+    v_num_palette_entries =
+        self->private_impl.c_encode_id[0].v_num_palette_entries;
+    v_i = self->private_impl.c_encode_id[0].v_i;
+  } else {
+    v_use_global_palette = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_use_global_palette = (self->private_impl.f_palette_bits <=
+        self->private_impl.f_global_palette_bits);
+    if (v_use_global_palette) {
+      v_num_palette_entries =
+          (((uint32_t)(1)) << self->private_impl.f_palette_bits);
+      v_i = 0;
+      while (v_i < v_num_palette_entries) {
+        if ((self->private_impl.f_palettes[0][((4 * v_i) + 0)] !=
+            self->private_impl.f_palettes[1][((4 * v_i) + 0)]) ||
+            (self->private_impl.f_palettes[0][((4 * v_i) + 1)] !=
+            self->private_impl.f_palettes[1][((4 * v_i) + 1)]) ||
+            (self->private_impl.f_palettes[0][((4 * v_i) + 2)] !=
+            self->private_impl.f_palettes[1][((4 * v_i) + 2)])) {
+          v_use_global_palette = false;
+          goto label_0_break;
+        }
+        v_i += 1;
+      }
+    label_0_break:;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 44;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    self->private_impl.c_encode_id[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    while (self->private_impl.c_encode_id[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(self->private_impl.f_frame_x0)))) >>
+          (8 * self->private_impl.c_encode_id[0].scratch));
+      self->private_impl.c_encode_id[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    self->private_impl.c_encode_id[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    while (self->private_impl.c_encode_id[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(self->private_impl.f_frame_y0)))) >>
+          (8 * self->private_impl.c_encode_id[0].scratch));
+      self->private_impl.c_encode_id[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    self->private_impl.c_encode_id[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    while (self->private_impl.c_encode_id[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(((self->private_impl.f_frame_x1 -
+          self->private_impl.f_frame_x0) & 65535))))) >>
+          (8 * self->private_impl.c_encode_id[0].scratch));
+      self->private_impl.c_encode_id[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    self->private_impl.c_encode_id[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+    while (self->private_impl.c_encode_id[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (uint8_t)(((uint64_t)(((uint16_t)(((self->private_impl.f_frame_y1 -
+          self->private_impl.f_frame_y0) & 65535))))) >>
+          (8 * self->private_impl.c_encode_id[0].scratch));
+      self->private_impl.c_encode_id[0].scratch++;
+    }
+    if (v_use_global_palette) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = 0;
+    } else {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ =
+          (128 | ((uint8_t)(((self->private_impl.f_palette_bits - 1) & 7))));
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+      if (a_dst.private_impl.buf) {
+        a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+      }
+      status = wuffs_gif__encoder__encode_palette(self, a_dst);
+      if (a_dst.private_impl.buf) {
+        ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+      }
+      if (status) {
+        goto suspend;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_id[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_id[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_id[0].v_use_global_palette = v_use_global_palette;
+  self->private_impl.c_encode_id[0].v_num_palette_entries =
+      v_num_palette_entries;
+  self->private_impl.c_encode_id[0].v_i = v_i;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.encode_pixels
+
+static wuffs_gif__status wuffs_gif__encoder__encode_pixels(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__image_buffer* a_src) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  wuffs_base__table_u8 v_tab;
+  wuffs_base__slice_u8 v_row;
+  bool v_last_row;
+  wuffs_base__io_reader v_r;
+  wuffs_base__io_buffer u_r;
+  uint8_t* ioptr_r = NULL;
+  uint8_t* iobounds1_r = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_r);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_r);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_r);
+  wuffs_base__io_writer v_w;
+  wuffs_base__io_buffer u_w;
+  uint8_t* ioptr_w = NULL;
+  uint8_t* iobounds1_w = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_w);
+  wuffs_gif__status v_z;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_encode_pixels[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_tab = ((wuffs_base__table_u8){});
+    v_row = ((wuffs_base__slice_u8){});
+    v_last_row = self->private_impl.c_encode_pixels[0].v_last_row;
+    v_r = ((wuffs_base__io_reader){});
+    v_w = ((wuffs_base__io_writer){});
+    v_z = self->private_impl.c_encode_pixels[0].v_z;
+  } else {
+    v_tab = ((wuffs_base__table_u8){});
+    v_row = ((wuffs_base__slice_u8){});
+    v_last_row = false;
+    v_r = ((wuffs_base__io_reader){});
+    v_w = ((wuffs_base__io_writer){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_palette_bits > 2) {
+      wuffs_lzw__encoder__set_literal_width(&self->private_impl.f_lzw,
+          self->private_impl.f_palette_bits);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = ((uint8_t)(self->private_impl.f_palette_bits));
+    } else {
+      wuffs_lzw__encoder__set_literal_width(&self->private_impl.f_lzw, 2);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = 2;
+    }
+    while (true) {
+      v_tab = wuffs_base__image_buffer__plane(a_src, 0);
+      v_row = ((wuffs_base__slice_u8){});
+      if (self->private_impl.f_src_y < self->private_impl.f_frame_y1) {
+        v_row = wuffs_base__table_u8__row(v_tab, self->private_impl.f_src_y);
+        if ((((uint64_t)(self->private_impl.f_src_x)) <=
+            ((uint64_t)(self->private_impl.f_frame_x1))) && (((uint64_t)(
+            self->private_impl.f_frame_x1)) <= ((uint64_t)(v_row.len)))) {
+          v_row = wuffs_base__slice_u8__subslice_ij(v_row,
+              ((uint64_t)(self->private_impl.f_src_x)),
+              ((uint64_t)(self->private_impl.f_frame_x1)));
+        } else {
+          v_row = wuffs_base__slice_u8__subslice_j(v_row, 0);
+        }
+      }
+      v_last_row = (self->private_impl.f_frame_y1 <=
+          wuffs_base__u32__sat_add(self->private_impl.f_src_y, 1));
+      v_r = ((wuffs_base__io_reader){});
+      v_w = ((wuffs_base__io_writer){});
+      {
+        wuffs_base__io_reader o_0_v_r = v_r;
+        uint8_t* o_0_ioptr_v_r = ioptr_r;
+        uint8_t* o_0_iobounds1_v_r = iobounds1_r;
+        wuffs_base__io_writer o_0_v_w = v_w;
+        uint8_t* o_0_ioptr_v_w = ioptr_w;
+        uint8_t* o_0_iobounds1_v_w = iobounds1_w;
+        wuffs_base__io_reader__set(&v_r, &u_r, &ioptr_r, &iobounds1_r, v_row,
+            v_last_row);
+        wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+            wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){
+            .ptr = self->private_impl.f_block, .len = 255}),
+            self->private_impl.f_block_wi));
+        {
+          u_w.wi = ioptr_w - u_w.ptr;
+          u_r.ri = ioptr_r - u_r.ptr;
+          wuffs_gif__status t_0 =
+              wuffs_lzw__encoder__encode(&self->private_impl.f_lzw, v_w, v_r);
+          ioptr_w = u_w.ptr + u_w.wi;
+          ioptr_r = u_r.ptr + u_r.ri;
+          v_z = t_0;
+        }
+        wuffs_base__u32__sat_add_indirect(&self->private_impl.f_src_x,
+            ((uint32_t)((wuffs_base__u64__sat_sub(((uint64_t)(v_row.len)),
+            ((uint64_t)(iobounds1_r - ioptr_r))) & 4294967295))));
+        self->private_impl.f_block_wi = (255 - ((uint32_t)(
+            wuffs_base__u64__min(((uint64_t)(iobounds1_w - ioptr_w)), 255))));
+        v_w = o_0_v_w;
+        ioptr_w = o_0_ioptr_v_w;
+        iobounds1_w = o_0_iobounds1_v_w;
+        v_r = o_0_v_r;
+        ioptr_r = o_0_ioptr_v_r;
+        iobounds1_r = o_0_iobounds1_v_r;
+      }
+      if (v_z == 0) {
+        goto label_0_break;
+      } else if (v_z == WUFFS_GIF__SUSPENSION_SHORT_READ) {
+        self->private_impl.f_src_x = self->private_impl.f_frame_x0;
+        wuffs_base__u32__sat_add_indirect(&self->private_impl.f_src_y, 1);
+      } else if (v_z == WUFFS_GIF__SUSPENSION_SHORT_WRITE) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status = wuffs_gif__encoder__write_block(self, a_dst);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        status = v_z;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(4);
+      }
+    }
+  label_0_break:;
+    if (self->private_impl.f_block_wi > 0) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      if (a_dst.private_impl.buf) {
+        a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+      }
+      status = wuffs_gif__encoder__write_block(self, a_dst);
+      if (a_dst.private_impl.buf) {
+        ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+      }
+      if (status) {
+        goto suspend;
+      }
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode_pixels[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode_pixels[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode_pixels[0].v_last_row = v_last_row;
+  self->private_impl.c_encode_pixels[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func encoder.write_block
+
+static wuffs_gif__status wuffs_gif__encoder__write_block(
+    wuffs_gif__encoder* self, wuffs_base__io_writer a_dst) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint64_t v_n;
+  uint32_t v_new_ri;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_write_block[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_write_block[0].v_n;
+    v_new_ri = self->private_impl.c_write_block[0].v_new_ri;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = ((uint8_t)(self->private_impl.f_block_wi));
+    while (self->private_impl.f_block_ri < self->private_impl.f_block_wi) {
+      v_n = wuffs_base__io_writer__copy_from_slice(&ioptr_dst, iobounds1_dst,
+          wuffs_base__slice_u8__subslice_ij(((wuffs_base__slice_u8){
+          .ptr = self->private_impl.f_block, .len = 255}),
+          self->private_impl.f_block_ri, self->private_impl.f_block_wi));
+      v_new_ri = wuffs_base__u32__sat_add(self->private_impl.f_block_ri,
+          ((uint32_t)(wuffs_base__u64__min(v_n, 255))));
+      self->private_impl.f_block_ri = wuffs_base__u32__min(v_new_ri, 255);
+      if (self->private_impl.f_block_ri < self->private_impl.f_block_wi) {
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+      }
+    }
+    self->private_impl.f_block_ri = 0;
+    self->private_impl.f_block_wi = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_block[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_block[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_block[0].v_n = v_n;
+  self->private_impl.c_write_block[0].v_new_ri = v_new_ri;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_gif__status wuffs_gif__decoder__save_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_GIF__DECODER__STATE_LENGTH) {
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_height));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_call_sequence);
+  p += 1;
+  p[0] = self->private_impl.f_end_of_data ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_previous_lzw_decode_ended_abruptly ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_previous_use_global_palette ? 1 : 0;
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.f_background_color_index);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.f_interlace);
+  p += 1;
+  p[0] = self->private_impl.f_seen_num_loops ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_num_loops));
+  p += 4;
+  p[0] = self->private_impl.f_seen_graphic_control ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_gc_has_transparent_index ? 1 : 0;
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.f_gc_transparent_index);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.f_gc_disposal);
+  p += 1;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_gc_duration));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.min_inclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.min_inclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.max_exclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.max_exclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_x));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_y));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_x0));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_x1));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_y0));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dst_y1));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_uncompressed_ri));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_uncompressed_wi));
+  p += 4;
+  for (i0 = 0; i0 < 4096; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_uncompressed[i0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1024; i1++) {
+      p[0] = (uint8_t)(self->private_impl.f_palettes[i0][i1]);
+      p += 1;
+    }
+  }
+  {
+    int32_t z = wuffs_lzw__decoder__save_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__DECODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_LZW__DECODER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_num_loops));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_up_to_id_part1[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_up_to_id_part1[0].v_block_type);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_header[0].coro_susp_point));
+  p += 4;
+  for (i0 = 0; i0 < 6; i0++) {
+    p[0] = (uint8_t)(self->private_impl.c_decode_header[0].v_c[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_header[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_lsd[0].coro_susp_point));
+  p += 4;
+  for (i0 = 0; i0 < 7; i0++) {
+    p[0] = (uint8_t)(self->private_impl.c_decode_lsd[0].v_c[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_lsd[0].v_i));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_lsd[0].v_flags);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_lsd[0].v_num_palette_entries));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_lsd[0].v_argb));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_lsd[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_extension[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_extension[0].v_label);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_skip_blocks[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_skip_blocks[0].v_block_size);
+  p += 1;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_skip_blocks[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_ae[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_ae[0].v_c);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.c_decode_ae[0].v_block_size);
+  p += 1;
+  p[0] = self->private_impl.c_decode_ae[0].v_not_animexts ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.c_decode_ae[0].v_not_netscape ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_ae[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_gc[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_gc[0].v_flags);
+  p += 1;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_gc[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part0[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part0[0].v_frame_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part0[0].v_frame_y));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_id_part0[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part1[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_id_part1[0].v_flags);
+  p += 1;
+  p[0] = self->private_impl.c_decode_id_part1[0].v_use_local_palette ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_id_part1[0].v_num_palette_entries));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part1[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part1[0].v_argb));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_id_part1[0].v_lw);
+  p += 1;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_id_part1[0].v_block_size));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_id_part1[0].v_z));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_id_part1[0].scratch));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x7C743A98,
+      WUFFS_GIF__DECODER__STATE_LENGTH);
+  return WUFFS_GIF__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_gif__status wuffs_gif__decoder__restore_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x7C743A98,
+      WUFFS_GIF__DECODER__STATE_LENGTH)) {
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  self->private_impl.status = (wuffs_gif__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_call_sequence = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_end_of_data = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_previous_lzw_decode_ended_abruptly = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_previous_use_global_palette = p[0];
+  p += 1;
+  self->private_impl.f_background_color_index = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_interlace = (uint8_t)(p[0]);
+  p += 1;
+  if (self->private_impl.f_interlace > 4) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_seen_num_loops = p[0];
+  p += 1;
+  self->private_impl.f_num_loops = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_seen_graphic_control = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_gc_has_transparent_index = p[0];
+  p += 1;
+  self->private_impl.f_gc_transparent_index = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_gc_disposal = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_gc_duration = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  if (self->private_impl.f_gc_duration > 462414960000) {
+    goto bad_state;
+  }
+  self->private_impl.f_frame_rect.min_inclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.min_inclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.max_exclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.max_exclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_x = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_y = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_x0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_x1 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_y0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dst_y1 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_uncompressed_ri = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_uncompressed_ri > 4096) {
+    goto bad_state;
+  }
+  self->private_impl.f_uncompressed_wi = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_uncompressed_wi > 4096) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    self->private_impl.f_uncompressed[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1024; i1++) {
+      self->private_impl.f_palettes[i0][i1] = (uint8_t)(p[0]);
+      p += 1;
+    }
+  }
+  {
+    int32_t z = wuffs_lzw__decoder__restore_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__DECODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_LZW__DECODER__STATE_LENGTH;
+  }
+  self->private_impl.c_decode_config[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_config[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_config[0].v_num_loops =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_frame[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_up_to_id_part1[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_up_to_id_part1[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_up_to_id_part1[0].v_block_type = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_header[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_header[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  for (i0 = 0; i0 < 6; i0++) {
+    self->private_impl.c_decode_header[0].v_c[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.c_decode_header[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_lsd[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_lsd[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  for (i0 = 0; i0 < 7; i0++) {
+    self->private_impl.c_decode_lsd[0].v_c[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.c_decode_lsd[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_lsd[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_lsd[0].v_num_palette_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_lsd[0].v_argb =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_lsd[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_extension[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_extension[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_extension[0].v_label = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_skip_blocks[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_skip_blocks[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_skip_blocks[0].v_block_size = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_skip_blocks[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_ae[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_ae[0].coro_susp_point > 13) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_ae[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_ae[0].v_block_size = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_decode_ae[0].v_not_animexts = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_decode_ae[0].v_not_netscape = p[0];
+  p += 1;
+  self->private_impl.c_decode_ae[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_gc[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_gc[0].coro_susp_point > 6) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_gc[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_gc[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_id_part0[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_id_part0[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_id_part0[0].v_frame_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part0[0].v_frame_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part0[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_id_part1[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_id_part1[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_id_part1[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_decode_id_part1[0].v_use_local_palette = p[0];
+  p += 1;
+  self->private_impl.c_decode_id_part1[0].v_num_palette_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part1[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part1[0].v_argb =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part1[0].v_lw = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_id_part1[0].v_block_size =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_id_part1[0].v_z =
+      (wuffs_gif__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_id_part1[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_GIF__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
+
+// -------- func encoder.save_state
+
+wuffs_gif__status wuffs_gif__encoder__save_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_GIF__ENCODER__STATE_LENGTH) {
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_height));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_num_loops));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_call_sequence);
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_x0));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_y0));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_x1));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_y1));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_src_x));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_src_y));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_palette_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_global_palette_bits));
+  p += 4;
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1024; i1++) {
+      p[0] = (uint8_t)(self->private_impl.f_palettes[i0][i1]);
+      p += 1;
+    }
+  }
+  for (i0 = 0; i0 < 255; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_block[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_block_ri));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_block_wi));
+  p += 4;
+  {
+    int32_t z = wuffs_lzw__encoder__save_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__ENCODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_LZW__ENCODER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_config[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_config[0].v_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_config[0].v_height));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_config[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_frame[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_trailer[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_lsd[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_lsd[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_palette[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_palette[0].v_num_palette_entries));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_palette[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_ae[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_ae[0].v_num_loops));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode_ae[0].v_i));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_ae[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_gc[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_encode_gc[0].v_flags);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.c_encode_gc[0].v_disposal);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.c_encode_gc[0].v_transparent_index);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_gc[0].v_num_palette_entries));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode_gc[0].v_i));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_gc[0].v_centiseconds));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_gc[0].v_duration));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_gc[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_id[0].coro_susp_point));
+  p += 4;
+  p[0] = self->private_impl.c_encode_id[0].v_use_global_palette ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_id[0].v_num_palette_entries));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode_id[0].v_i));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode_id[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_pixels[0].coro_susp_point));
+  p += 4;
+  p[0] = self->private_impl.c_encode_pixels[0].v_last_row ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode_pixels[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_block[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_write_block[0].v_n));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_block[0].v_new_ri));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x06FD7C19,
+      WUFFS_GIF__ENCODER__STATE_LENGTH);
+  return WUFFS_GIF__STATUS_OK;
+}
+
+// -------- func encoder.restore_state
+
+wuffs_gif__status wuffs_gif__encoder__restore_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x06FD7C19,
+      WUFFS_GIF__ENCODER__STATE_LENGTH)) {
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+  size_t i1;
+
+  self->private_impl.status = (wuffs_gif__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_width > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_height > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_num_loops = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_call_sequence = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_frame_x0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_frame_x0 > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_frame_y0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_frame_y0 > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_frame_x1 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_frame_x1 > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_frame_y1 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_frame_y1 > 65535) {
+    goto bad_state;
+  }
+  self->private_impl.f_src_x = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_src_y = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_palette_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_palette_bits > 8) {
+    goto bad_state;
+  }
+  self->private_impl.f_global_palette_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_global_palette_bits > 8) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 2; i0++) {
+    for (i1 = 0; i1 < 1024; i1++) {
+      self->private_impl.f_palettes[i0][i1] = (uint8_t)(p[0]);
+      p += 1;
+    }
+  }
+  for (i0 = 0; i0 < 255; i0++) {
+    self->private_impl.f_block[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_block_ri = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_block_ri > 255) {
+    goto bad_state;
+  }
+  self->private_impl.f_block_wi = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_block_wi > 255) {
+    goto bad_state;
+  }
+  {
+    int32_t z = wuffs_lzw__encoder__restore_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__ENCODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_LZW__ENCODER__STATE_LENGTH;
+  }
+  self->private_impl.c_encode_config[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_config[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_config[0].v_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_config[0].v_height =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_config[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_frame[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_frame[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_trailer[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_trailer[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_lsd[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_lsd[0].coro_susp_point > 10) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_lsd[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_palette[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_palette[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_palette[0].v_num_palette_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_palette[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_ae[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_ae[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_ae[0].v_num_loops =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_ae[0].v_i = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_ae[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_gc[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_gc[0].coro_susp_point > 7) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode_gc[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_encode_gc[0].v_disposal = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_encode_gc[0].v_transparent_index = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_encode_gc[0].v_num_palette_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_gc[0].v_i = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_gc[0].v_centiseconds =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_gc[0].v_duration =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_gc[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_id[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_id[0].coro_susp_point > 12) {
+    goto bad_state;
+  }
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_encode_id[0].v_use_global_palette = p[0];
+  p += 1;
+  self->private_impl.c_encode_id[0].v_num_palette_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_id[0].v_i = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode_id[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_encode_pixels[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode_pixels[0].coro_susp_point > 6) {
+    goto bad_state;
+  }
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_encode_pixels[0].v_last_row = p[0];
+  p += 1;
+  self->private_impl.c_encode_pixels[0].v_z =
+      (wuffs_gif__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_write_block[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_block[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_block[0].v_n =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_write_block[0].v_new_ri =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_GIF__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,18 +1390,30 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
-  // ---------------- BEGIN USE "std/crc32"
+// ---------------- BEGIN USE "std/crc32"
 
 #ifndef WUFFS_CRC32_H
 #define WUFFS_CRC32_H
 
-  // Code generated by wuffs-c. DO NOT EDIT.
+// Code generated by wuffs-c. DO NOT EDIT.
+
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1219,23 +1433,21 @@ typedef int32_t wuffs_crc32__status;
 
 #define wuffs_crc32__packageid 810620  // 0x000C5E7C
 
-#define WUFFS_CRC32__STATUS_OK 0                            // 0x00000000
-#define WUFFS_CRC32__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_CRC32__STATUS_OK 0  // 0x00000000
+#define WUFFS_CRC32__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_CRC32__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_CRC32__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_CRC32__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                      // 0x80000006
-#define WUFFS_CRC32__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_CRC32__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_CRC32__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_CRC32__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_CRC32__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_CRC32__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_CRC32__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_CRC32__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_CRC32__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_CRC32__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_CRC32__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_CRC32__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_CRC32__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_CRC32__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_CRC32__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_CRC32__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_CRC32__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
 bool wuffs_crc32__status__is_error(wuffs_crc32__status s);
 
@@ -1258,10 +1470,13 @@ typedef struct {
     uint32_t magic;
 
     uint32_t f_state;
-
   } private_impl;
 } wuffs_crc32__ieee_hasher;
 
+// WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH is the length of a
+// wuffs_crc32__ieee_hasher's saved state.
+#define WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 8)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_crc32__ieee_hasher__check_wuffs_version is an initializer function.
@@ -1270,19 +1485,134 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_crc32__ieee_hasher__check_wuffs_version(
-    wuffs_crc32__ieee_hasher* self,
-    size_t sizeof_star_self,
+    wuffs_crc32__ieee_hasher* self, size_t sizeof_star_self,
     uint32_t wuffs_version);
 
+// sizeof__wuffs_crc32__ieee_hasher returns sizeof(wuffs_crc32__ieee_hasher),
+// for callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_crc32__ieee_hasher(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_crc32__ieee_hasher__save_state writes self's state, including that of
+// any suspended coroutine, to the first WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH
+// bytes of dst.
+//
+// The saved state can be restored, by wuffs_crc32__ieee_hasher__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_crc32__status wuffs_crc32__ieee_hasher__save_state(
+    wuffs_crc32__ieee_hasher* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_crc32__ieee_hasher__restore_state sets self's state to that saved in
+// src, which must be exactly WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH bytes long.
+// The self argument must have been initialized by
+// wuffs_crc32__ieee_hasher__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_crc32__status wuffs_crc32__ieee_hasher__restore_state(
+    wuffs_crc32__ieee_hasher* self, wuffs_base__slice_u8 a_src);
+
 // ---------------- Public Function Prototypes
 
 uint32_t wuffs_crc32__ieee_hasher__update(wuffs_crc32__ieee_hasher* self,
-                                          wuffs_base__slice_u8 a_x);
+    wuffs_base__slice_u8 a_x);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace crc32 {
+
+// status is a strongly typed wuffs_crc32__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_CRC32__STATUS_OK) {}
+  constexpr explicit status(wuffs_crc32__status repr) : repr_(repr) {}
+
+  constexpr wuffs_crc32__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_CRC32__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_crc32__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_crc32__status repr_;
+};
+
+constexpr status status_ok(WUFFS_CRC32__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_CRC32__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_CRC32__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_CRC32__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_CRC32__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_CRC32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_CRC32__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_CRC32__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_CRC32__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_CRC32__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_CRC32__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_CRC32__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_CRC32__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_CRC32__SUSPENSION_END_OF_DATA);
+
+// ieee_hasher is an RAII wrapper for a wuffs_crc32__ieee_hasher. Its constructor
+// calls wuffs_crc32__ieee_hasher__check_wuffs_version.
+class ieee_hasher {
+ public:
+  ieee_hasher() : c_() {
+    wuffs_crc32__ieee_hasher__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  ieee_hasher(const ieee_hasher&) = delete;
+  ieee_hasher& operator=(const ieee_hasher&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_crc32__ieee_hasher* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_crc32__ieee_hasher__save_state and
+  // wuffs_crc32__ieee_hasher__restore_state. A saved state is
+  // WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_crc32__ieee_hasher__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_crc32__ieee_hasher__restore_state(&c_, src));
+  }
+
+  uint32_t update(wuffs_base__slice_u8 x) {
+    return wuffs_crc32__ieee_hasher__update(&c_, x);
+  }
+
+ private:
+  wuffs_crc32__ieee_hasher c_;
+};
+
+}  // namespace crc32
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_CRC32_H
 
 // ---------------- END   USE "std/crc32"
@@ -1294,6 +1624,7 @@ uint32_t wuffs_crc32__ieee_hasher__update(wuffs_crc32__ieee_hasher* self,
 
 // Code generated by wuffs-c. DO NOT EDIT.
 
+
 // ---------------- Use Declarations
 
 #ifdef __cplusplus
@@ -1314,54 +1645,40 @@ typedef int32_t wuffs_deflate__status;
 
 #define wuffs_deflate__packageid 848533  // 0x000CF295
 
-#define WUFFS_DEFLATE__STATUS_OK 0                            // 0x00000000
-#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_DEFLATE__STATUS_OK 0  // 0x00000000
+#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                   // 0x80000006
+#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
 #define WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
-#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640      // 0x80000008
-#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639         // 0x80000009
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10                 // 0x0000000A
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11                // 0x0000000B
-#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION \
-  -2147483636                                                   // 0x8000000C
+#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
 #define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
-#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14                // 0x0000000E
-
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED \
-  -1278585856  // 0xB3CA5400
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED \
-  -1278585855  // 0xB3CA5401
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT \
-  -1278585854  // 0xB3CA5402
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION \
-  -1278585853                                              // 0xB3CA5403
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED -1278585856  // 0xB3CA5400
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED -1278585855  // 0xB3CA5401
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT -1278585854  // 0xB3CA5402
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION -1278585853  // 0xB3CA5403
 #define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE -1278585852  // 0xB3CA5404
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH \
-  -1278585851                                                     // 0xB3CA5405
-#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850                // 0xB3CA5406
-#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849             // 0xB3CA5407
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH -1278585851  // 0xB3CA5405
+#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850  // 0xB3CA5406
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849  // 0xB3CA5407
 #define WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT -1278585848  // 0xB3CA5408
-#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT \
-  -1278585847  // 0xB3CA5409
-#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH \
-  -1278585846  // 0xB3CA540A
-#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE \
-  -1278585845                                              // 0xB3CA540B
+#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT -1278585847  // 0xB3CA5409
+#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH -1278585846  // 0xB3CA540A
+#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE -1278585845  // 0xB3CA540B
 #define WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES -1278585844  // 0xB3CA540C
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE \
-  -1278585843  // 0xB3CA540D
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK \
-  -1278585842  // 0xB3CA540E
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE \
-  -1278585841  // 0xB3CA540F
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS \
-  -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE -1278585843  // 0xB3CA540D
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK -1278585842  // 0xB3CA540E
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE -1278585841  // 0xB3CA540F
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE -1278585839  // 0xB3CA5411
 
 bool wuffs_deflate__status__is_error(wuffs_deflate__status s);
 
@@ -1395,8 +1712,6 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       wuffs_deflate__status v_z;
-      uint64_t v_n_copied;
-      uint32_t v_already_full;
     } c_decode[1];
     struct {
       uint32_t coro_susp_point;
@@ -1447,6 +1762,102 @@ typedef struct {
   } private_impl;
 } wuffs_deflate__decoder;
 
+// WUFFS_DEFLATE__DECODER__STATE_LENGTH is the length of a
+// wuffs_deflate__decoder's saved state.
+#define WUFFS_DEFLATE__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 43138)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_level;
+    bool f_level_set;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_window[65536];
+    uint32_t f_w_pos;
+    uint32_t f_w_end;
+    uint32_t f_block_start;
+    uint16_t f_head[32768];
+    uint16_t f_prev[32768];
+    uint32_t f_ins_pos;
+    uint32_t f_tokens[16384];
+    uint32_t f_n_tokens;
+    uint32_t f_freqs[3][288];
+    uint8_t f_lens[3][288];
+    uint16_t f_codes[3][288];
+    uint32_t f_n_lcodes;
+    uint32_t f_n_dcodes;
+    uint32_t f_n_clcodes;
+    uint16_t f_clseq[320];
+    uint32_t f_n_clseq;
+    uint32_t f_hsort_keys[512];
+    uint16_t f_hsort_syms[512];
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_window[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_stored;
+      bool v_fixed;
+      uint64_t v_n_extra_bits;
+      uint64_t v_fixed_cost;
+      uint64_t v_dynamic_cost;
+      uint64_t v_n_bytes;
+      uint64_t v_stored_cost;
+    } c_write_block[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_start;
+      uint32_t v_end;
+      uint32_t v_remaining;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint32_t v_new_start;
+      uint64_t scratch;
+    } c_write_stored[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_symbol;
+      uint32_t v_n_extra_bits;
+    } c_write_dynamic_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_t;
+      uint32_t v_length;
+      uint32_t v_lc;
+      uint32_t v_d;
+      uint32_t v_dc;
+      uint32_t v_magic;
+    } c_write_tokens[1];
+  } private_impl;
+} wuffs_deflate__encoder;
+
+// WUFFS_DEFLATE__ENCODER__STATE_LENGTH is the length of a
+// wuffs_deflate__encoder's saved state.
+#define WUFFS_DEFLATE__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 272109)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_deflate__decoder__check_wuffs_version is an initializer function.
@@ -1455,20 +1866,255 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
-                                                 size_t sizeof_star_self,
-                                                 uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__decoder returns sizeof(wuffs_deflate__decoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__decoder(void);
+
+// wuffs_deflate__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_deflate__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_deflate__encoder__check_wuffs_version(wuffs_deflate__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__encoder returns sizeof(wuffs_deflate__encoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_deflate__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__decoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__decoder__save_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__decoder__restore_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_src);
+
+// wuffs_deflate__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__encoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__encoder__save_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__encoder__restore_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 wuffs_deflate__status wuffs_deflate__decoder__decode(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+    wuffs_base__slice_u8 a_hist);
+
+void wuffs_deflate__encoder__set_level(wuffs_deflate__encoder* self,
+    uint32_t a_level);
+
+wuffs_deflate__status wuffs_deflate__encoder__encode(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace deflate {
+
+// status is a strongly typed wuffs_deflate__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_DEFLATE__STATUS_OK) {}
+  constexpr explicit status(wuffs_deflate__status repr) : repr_(repr) {}
+
+  constexpr wuffs_deflate__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_DEFLATE__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_deflate__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_deflate__status repr_;
+};
+
+constexpr status status_ok(WUFFS_DEFLATE__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_DEFLATE__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_DEFLATE__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_DEFLATE__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_DEFLATE__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_huffman_code_over_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_under_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_length_count(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT);
+constexpr status error_bad_huffman_code_length_repetition(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION);
+constexpr status error_bad_huffman_code(WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE);
+constexpr status error_bad_huffman_minimum_code_length(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH);
+constexpr status error_bad_block(WUFFS_DEFLATE__ERROR_BAD_BLOCK);
+constexpr status error_bad_distance(WUFFS_DEFLATE__ERROR_BAD_DISTANCE);
+constexpr status error_bad_distance_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT);
+constexpr status error_bad_literal_length_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT);
+constexpr status error_inconsistent_stored_block_length(
+    WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH);
+constexpr status error_missing_end_of_block_code(
+    WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE);
+constexpr status error_no_huffman_codes(WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES);
+constexpr status error_internal_error_inconsistent_huffman_decoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE);
+constexpr status error_internal_error_inconsistent_huffman_end_of_block(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK);
+constexpr status error_internal_error_inconsistent_distance(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE);
+constexpr status error_internal_error_inconsistent_n_bits(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS);
+constexpr status error_internal_error_inconsistent_encoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE);
+
+// decoder is an RAII wrapper for a wuffs_deflate__decoder. Its constructor
+// calls wuffs_deflate__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_deflate__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__decoder__save_state and
+  // wuffs_deflate__decoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__decoder__restore_state(&c_, src));
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__decoder__decode(&c_, dst, src));
+  }
+
+  void add_history(wuffs_base__slice_u8 hist) {
+    wuffs_deflate__decoder__add_history(&c_, hist);
+  }
+
+ private:
+  wuffs_deflate__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_deflate__encoder. Its constructor
+// calls wuffs_deflate__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_deflate__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__encoder__save_state and
+  // wuffs_deflate__encoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_deflate__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_deflate__encoder c_;
+};
+
+}  // namespace deflate
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_DEFLATE_H
 
 // ---------------- END   USE "std/deflate"
@@ -1491,28 +2137,27 @@ typedef int32_t wuffs_gzip__status;
 
 #define wuffs_gzip__packageid 1041911  // 0x000FE5F7
 
-#define WUFFS_GZIP__STATUS_OK 0                            // 0x00000000
-#define WUFFS_GZIP__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_GZIP__STATUS_OK 0  // 0x00000000
+#define WUFFS_GZIP__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_GZIP__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_GZIP__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_GZIP__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                     // 0x80000006
-#define WUFFS_GZIP__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_GZIP__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_GZIP__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_GZIP__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_GZIP__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_GZIP__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_GZIP__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_GZIP__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_GZIP__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_GZIP__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_GZIP__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_GZIP__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_GZIP__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_GZIP__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_GZIP__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_GZIP__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_GZIP__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
-#define WUFFS_GZIP__ERROR_BAD_CHECKSUM -1080566784            // 0xBF97DC00
+#define WUFFS_GZIP__ERROR_BAD_CHECKSUM -1080566784  // 0xBF97DC00
 #define WUFFS_GZIP__ERROR_BAD_COMPRESSION_METHOD -1080566783  // 0xBF97DC01
-#define WUFFS_GZIP__ERROR_BAD_ENCODING_FLAGS -1080566782      // 0xBF97DC02
-#define WUFFS_GZIP__ERROR_BAD_HEADER -1080566781              // 0xBF97DC03
+#define WUFFS_GZIP__ERROR_BAD_ENCODING_FLAGS -1080566782  // 0xBF97DC02
+#define WUFFS_GZIP__ERROR_BAD_HEADER -1080566781  // 0xBF97DC03
+#define WUFFS_GZIP__ERROR_BAD_HEADER_CHECKSUM -1080566780  // 0xBF97DC04
 
 bool wuffs_gzip__status__is_error(wuffs_gzip__status s);
 
@@ -1537,22 +2182,80 @@ typedef struct {
     wuffs_deflate__decoder f_flate;
     wuffs_crc32__ieee_hasher f_checksum;
     bool f_ignore_checksum;
+    bool f_multi_member;
+    uint32_t f_mtime;
+    uint8_t f_os;
+    uint8_t f_fname[255];
+    uint32_t f_fname_length;
+    uint8_t f_fcomment[1024];
+    uint32_t f_fcomment_length;
+    wuffs_crc32__ieee_hasher f_header_hasher;
+    uint32_t f_header_checksum;
+    bool f_hash_header;
+    uint8_t f_hbuf[4];
 
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_gzip__status v_z;
+    } c_decode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_peek_u8[1];
     struct {
       uint32_t coro_susp_point;
       uint8_t v_flags;
+      uint8_t v_xfl;
       uint8_t v_c;
+      bool v_truncated;
       uint16_t v_xlen;
+      uint16_t v_header_checksum_want;
       uint32_t v_checksum_got;
       uint32_t v_decoded_length_got;
       wuffs_gzip__status v_z;
       uint32_t v_checksum_want;
       uint32_t v_decoded_length_want;
       uint64_t scratch;
-    } c_decode[1];
+    } c_decode_member[1];
   } private_impl;
 } wuffs_gzip__decoder;
 
+// WUFFS_GZIP__DECODER__STATE_LENGTH is the length of a wuffs_gzip__decoder's
+// saved state.
+#define WUFFS_GZIP__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 1360 + WUFFS_DEFLATE__DECODER__STATE_LENGTH + WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH + WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_gzip__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_gzip__status status;
+    uint32_t magic;
+
+    wuffs_deflate__encoder f_flate;
+    wuffs_crc32__ieee_hasher f_checksum;
+    uint32_t f_level;
+    bool f_level_set;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_xfl;
+      uint32_t v_checksum;
+      uint32_t v_isize;
+      wuffs_gzip__status v_z;
+      uint64_t scratch;
+    } c_encode[1];
+  } private_impl;
+} wuffs_gzip__encoder;
+
+// WUFFS_GZIP__ENCODER__STATE_LENGTH is the length of a wuffs_gzip__encoder's
+// saved state.
+#define WUFFS_GZIP__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 34 + WUFFS_DEFLATE__ENCODER__STATE_LENGTH + WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_gzip__decoder__check_wuffs_version is an initializer function.
@@ -1561,30 +2264,265 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_gzip__decoder__check_wuffs_version(wuffs_gzip__decoder* self,
-                                              size_t sizeof_star_self,
-                                              uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gzip__decoder returns sizeof(wuffs_gzip__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gzip__decoder(void);
+
+// wuffs_gzip__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_gzip__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_gzip__encoder__check_wuffs_version(wuffs_gzip__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gzip__encoder returns sizeof(wuffs_gzip__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gzip__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_gzip__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GZIP__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gzip__decoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gzip__status wuffs_gzip__decoder__save_state(wuffs_gzip__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gzip__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GZIP__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gzip__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gzip__status wuffs_gzip__decoder__restore_state(wuffs_gzip__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_gzip__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GZIP__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gzip__encoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gzip__status wuffs_gzip__encoder__save_state(wuffs_gzip__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gzip__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GZIP__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gzip__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gzip__status wuffs_gzip__encoder__restore_state(wuffs_gzip__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 void wuffs_gzip__decoder__set_ignore_checksum(wuffs_gzip__decoder* self,
-                                              bool a_ic);
+    bool a_ic);
+
+void wuffs_gzip__decoder__set_multi_member(wuffs_gzip__decoder* self,
+    bool a_mm);
+
+uint32_t wuffs_gzip__decoder__modification_time(wuffs_gzip__decoder* self);
+
+uint8_t wuffs_gzip__decoder__operating_system(wuffs_gzip__decoder* self);
+
+wuffs_base__slice_u8 wuffs_gzip__decoder__file_name(wuffs_gzip__decoder* self);
+
+wuffs_base__slice_u8 wuffs_gzip__decoder__comment(wuffs_gzip__decoder* self);
 
 wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
-                                               wuffs_base__io_writer a_dst,
-                                               wuffs_base__io_reader a_src);
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_gzip__encoder__set_level(wuffs_gzip__encoder* self,
+    uint32_t a_level);
+
+wuffs_gzip__status wuffs_gzip__encoder__encode(wuffs_gzip__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
-#endif  // WUFFS_GZIP_H
+// ---------------- C++ Wrappers
 
-// C HEADER ENDS HERE.
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
 
-#ifndef WUFFS_BASE_IMPL_H
-#define WUFFS_BASE_IMPL_H
+namespace wuffs {
+namespace gzip {
 
-// Copyright 2017 The Wuffs Authors.
+// status is a strongly typed wuffs_gzip__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_GZIP__STATUS_OK) {}
+  constexpr explicit status(wuffs_gzip__status repr) : repr_(repr) {}
+
+  constexpr wuffs_gzip__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_GZIP__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_gzip__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_gzip__status repr_;
+};
+
+constexpr status status_ok(WUFFS_GZIP__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_GZIP__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_GZIP__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_GZIP__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_GZIP__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_GZIP__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_GZIP__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_GZIP__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_GZIP__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_GZIP__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_GZIP__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_GZIP__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_GZIP__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_checksum(WUFFS_GZIP__ERROR_BAD_CHECKSUM);
+constexpr status error_bad_compression_method(
+    WUFFS_GZIP__ERROR_BAD_COMPRESSION_METHOD);
+constexpr status error_bad_encoding_flags(WUFFS_GZIP__ERROR_BAD_ENCODING_FLAGS);
+constexpr status error_bad_header(WUFFS_GZIP__ERROR_BAD_HEADER);
+constexpr status error_bad_header_checksum(
+    WUFFS_GZIP__ERROR_BAD_HEADER_CHECKSUM);
+
+// decoder is an RAII wrapper for a wuffs_gzip__decoder. Its constructor
+// calls wuffs_gzip__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_gzip__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gzip__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gzip__decoder__save_state and
+  // wuffs_gzip__decoder__restore_state. A saved state is
+  // WUFFS_GZIP__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gzip__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gzip__decoder__restore_state(&c_, src));
+  }
+
+  void set_ignore_checksum(bool ic) {
+    wuffs_gzip__decoder__set_ignore_checksum(&c_, ic);
+  }
+
+  void set_multi_member(bool mm) {
+    wuffs_gzip__decoder__set_multi_member(&c_, mm);
+  }
+
+  uint32_t modification_time() {
+    return wuffs_gzip__decoder__modification_time(&c_);
+  }
+
+  uint8_t operating_system() {
+    return wuffs_gzip__decoder__operating_system(&c_);
+  }
+
+  wuffs_base__slice_u8 file_name() {
+    return wuffs_gzip__decoder__file_name(&c_);
+  }
+
+  wuffs_base__slice_u8 comment() {
+    return wuffs_gzip__decoder__comment(&c_);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_gzip__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_gzip__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_gzip__encoder. Its constructor
+// calls wuffs_gzip__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_gzip__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gzip__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gzip__encoder__save_state and
+  // wuffs_gzip__encoder__restore_state. A saved state is
+  // WUFFS_GZIP__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gzip__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gzip__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_gzip__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_gzip__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_gzip__encoder c_;
+};
+
+}  // namespace gzip
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_GZIP_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -1604,6 +2542,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -1753,6 +2698,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -2095,6 +3063,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -2138,22 +3126,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -2164,11 +3193,9 @@ bool wuffs_gzip__status__is_error(wuffs_gzip__status s) {
   return s < 0;
 }
 
-const char* wuffs_gzip__status__strings[4] = {
-    "gzip: bad checksum",
-    "gzip: bad compression method",
-    "gzip: bad encoding flags",
-    "gzip: bad header",
+const char* wuffs_gzip__status__strings[5] = {
+    "gzip: bad checksum", "gzip: bad compression method",
+    "gzip: bad encoding flags", "gzip: bad header", "gzip: bad header checksum",
 };
 
 const char* wuffs_gzip__status__string(wuffs_gzip__status s) {
@@ -2181,7 +3208,7 @@ const char* wuffs_gzip__status__string(wuffs_gzip__status s) {
       break;
     case wuffs_gzip__packageid:
       a = wuffs_gzip__status__strings;
-      n = 4;
+      n = 5;
       break;
     case wuffs_crc32__packageid:
       return wuffs_crc32__status__string(s);
@@ -2196,13 +3223,28 @@ const char* wuffs_gzip__status__string(wuffs_gzip__status s) {
 
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
+static wuffs_gzip__status wuffs_gzip__decoder__peek_u8(
+    wuffs_gzip__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_gzip__status wuffs_gzip__decoder__decode_member(
+    wuffs_gzip__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+static void wuffs_gzip__decoder__hash(wuffs_gzip__decoder* self, uint32_t a_x,
+    uint32_t a_n);
+
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_gzip__decoder(void) {
+  return sizeof(wuffs_gzip__decoder);
+}
+
 void wuffs_gzip__decoder__check_wuffs_version(wuffs_gzip__decoder* self,
-                                              size_t sizeof_star_self,
-                                              uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -2220,12 +3262,42 @@ void wuffs_gzip__decoder__check_wuffs_version(wuffs_gzip__decoder* self,
     return;
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
-  wuffs_deflate__decoder__check_wuffs_version(
-      &self->private_impl.f_flate, sizeof(self->private_impl.f_flate),
-      WUFFS_VERSION);
+  wuffs_deflate__decoder__check_wuffs_version(&self->private_impl.f_flate,
+      sizeof(self->private_impl.f_flate), WUFFS_VERSION);
+  wuffs_crc32__ieee_hasher__check_wuffs_version(&self->private_impl.f_checksum,
+      sizeof(self->private_impl.f_checksum), WUFFS_VERSION);
   wuffs_crc32__ieee_hasher__check_wuffs_version(
-      &self->private_impl.f_checksum, sizeof(self->private_impl.f_checksum),
-      WUFFS_VERSION);
+      &self->private_impl.f_header_hasher,
+      sizeof(self->private_impl.f_header_hasher), WUFFS_VERSION);
+}
+
+size_t sizeof__wuffs_gzip__encoder(void) {
+  return sizeof(wuffs_gzip__encoder);
+}
+
+void wuffs_gzip__encoder__check_wuffs_version(wuffs_gzip__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_GZIP__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_GZIP__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  wuffs_deflate__encoder__check_wuffs_version(&self->private_impl.f_flate,
+      sizeof(self->private_impl.f_flate), WUFFS_VERSION);
+  wuffs_crc32__ieee_hasher__check_wuffs_version(&self->private_impl.f_checksum,
+      sizeof(self->private_impl.f_checksum), WUFFS_VERSION);
 }
 
 // ---------------- Function Implementations
@@ -2233,7 +3305,7 @@ void wuffs_gzip__decoder__check_wuffs_version(wuffs_gzip__decoder* self,
 // -------- func decoder.set_ignore_checksum
 
 void wuffs_gzip__decoder__set_ignore_checksum(wuffs_gzip__decoder* self,
-                                              bool a_ic) {
+    bool a_ic) {
   if (!self) {
     return;
   }
@@ -2248,11 +3320,100 @@ void wuffs_gzip__decoder__set_ignore_checksum(wuffs_gzip__decoder* self,
   self->private_impl.f_ignore_checksum = a_ic;
 }
 
+// -------- func decoder.set_multi_member
+
+void wuffs_gzip__decoder__set_multi_member(wuffs_gzip__decoder* self,
+    bool a_mm) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  self->private_impl.f_multi_member = a_mm;
+}
+
+// -------- func decoder.modification_time
+
+uint32_t wuffs_gzip__decoder__modification_time(wuffs_gzip__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return self->private_impl.f_mtime;
+}
+
+// -------- func decoder.operating_system
+
+uint8_t wuffs_gzip__decoder__operating_system(wuffs_gzip__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return self->private_impl.f_os;
+}
+
+// -------- func decoder.file_name
+
+wuffs_base__slice_u8 wuffs_gzip__decoder__file_name(wuffs_gzip__decoder* self) {
+  if (!self) {
+    return ((wuffs_base__slice_u8){});
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return ((wuffs_base__slice_u8){});
+  }
+
+  return wuffs_base__slice_u8__subslice_j(((wuffs_base__slice_u8){
+      .ptr = self->private_impl.f_fname, .len = 255}),
+      self->private_impl.f_fname_length);
+}
+
+// -------- func decoder.comment
+
+wuffs_base__slice_u8 wuffs_gzip__decoder__comment(wuffs_gzip__decoder* self) {
+  if (!self) {
+    return ((wuffs_base__slice_u8){});
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return ((wuffs_base__slice_u8){});
+  }
+
+  return wuffs_base__slice_u8__subslice_j(((wuffs_base__slice_u8){
+      .ptr = self->private_impl.f_fcomment, .len = 1024}),
+      self->private_impl.f_fcomment_length);
+}
+
 // -------- func decoder.decode
 
 wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
-                                               wuffs_base__io_writer a_dst,
-                                               wuffs_base__io_reader a_src) {
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_GZIP__ERROR_BAD_RECEIVER;
   }
@@ -2265,33 +3426,78 @@ wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
   }
   wuffs_gzip__status status = WUFFS_GZIP__STATUS_OK;
 
-  uint8_t v_flags;
-  uint8_t v_c;
-  uint16_t v_xlen;
-  uint32_t v_checksum_got;
-  uint32_t v_decoded_length_got;
   wuffs_gzip__status v_z;
-  uint32_t v_checksum_want;
-  uint32_t v_decoded_length_want;
 
-  uint8_t* ioptr_dst = NULL;
-  uint8_t* iobounds0orig_dst = NULL;
-  uint8_t* iobounds1_dst = NULL;
-  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
-  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
-  if (a_dst.private_impl.buf) {
-    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
-    if (!a_dst.private_impl.bounds[0]) {
-      a_dst.private_impl.bounds[0] = ioptr_dst;
-      a_dst.private_impl.bounds[1] =
-          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
-    }
-    if (a_dst.private_impl.buf->closed) {
-      a_dst.private_impl.bounds[1] = ioptr_dst;
+  uint32_t coro_susp_point = self->private_impl.c_decode[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_z = self->private_impl.c_decode[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    while (true) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_gzip__decoder__decode_member(self, a_dst, a_src);
+      if (status) {
+        goto suspend;
+      }
+      if (!self->private_impl.f_multi_member) {
+        status = WUFFS_GZIP__STATUS_OK;
+        goto ok;
+      }
+      while (true) {
+        {
+          wuffs_gzip__status t_0 = wuffs_gzip__decoder__peek_u8(self, a_src);
+          v_z = t_0;
+        }
+        if (v_z == 0) {
+          goto label_0_break;
+        } else if (v_z < 0) {
+          status = WUFFS_GZIP__STATUS_OK;
+          goto ok;
+        }
+        status = v_z;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+      }
+    label_0_break:;
+      (memset(&self->private_impl.f_flate, 0,
+          sizeof((wuffs_deflate__decoder){})),
+          wuffs_deflate__decoder__check_wuffs_version(
+          &self->private_impl.f_flate, sizeof((wuffs_deflate__decoder){}),
+          WUFFS_VERSION), wuffs_base__return_empty_struct());
+      (memset(&self->private_impl.f_checksum, 0,
+          sizeof((wuffs_crc32__ieee_hasher){})),
+          wuffs_crc32__ieee_hasher__check_wuffs_version(
+          &self->private_impl.f_checksum, sizeof((wuffs_crc32__ieee_hasher){}),
+          WUFFS_VERSION), wuffs_base__return_empty_struct());
     }
-    iobounds0orig_dst = a_dst.private_impl.bounds[0];
-    iobounds1_dst = a_dst.private_impl.bounds[1];
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode[0].coro_susp_point = 0;
+    goto exit;
   }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode[0].v_z = v_z;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func decoder.peek_u8
+
+static wuffs_gzip__status wuffs_gzip__decoder__peek_u8(
+    wuffs_gzip__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_gzip__status status = WUFFS_GZIP__STATUS_OK;
+
+  uint8_t v_c;
+
   uint8_t* ioptr_src = NULL;
   uint8_t* iobounds0orig_src = NULL;
   uint8_t* iobounds1_src = NULL;
@@ -2308,38 +3514,160 @@ wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
     iobounds1_src = a_src.private_impl.bounds[1];
   }
 
-  uint32_t coro_susp_point = self->private_impl.c_decode[0].coro_susp_point;
+  uint32_t coro_susp_point = self->private_impl.c_peek_u8[0].coro_susp_point;
   if (coro_susp_point) {
-    v_flags = self->private_impl.c_decode[0].v_flags;
-    v_c = self->private_impl.c_decode[0].v_c;
-    v_xlen = self->private_impl.c_decode[0].v_xlen;
-    v_checksum_got = self->private_impl.c_decode[0].v_checksum_got;
-    v_decoded_length_got = self->private_impl.c_decode[0].v_decoded_length_got;
-    v_z = self->private_impl.c_decode[0].v_z;
-    v_checksum_want = self->private_impl.c_decode[0].v_checksum_want;
-    v_decoded_length_want =
-        self->private_impl.c_decode[0].v_decoded_length_want;
+    v_c = self->private_impl.c_peek_u8[0].v_c;
   } else {
   }
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
-    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
-    if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
-      goto short_read_src;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_0 = *ioptr_src++;
+      v_c = t_0;
     }
-    uint8_t t_0 = *ioptr_src++;
-    if (t_0 != 31) {
-      status = WUFFS_GZIP__ERROR_BAD_HEADER;
+    if (ioptr_src == iobounds0orig_src) {
+      status = WUFFS_GZIP__ERROR_INVALID_I_O_OPERATION;
       goto exit;
     }
-    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
-    if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
-      goto short_read_src;
-    }
-    uint8_t t_1 = *ioptr_src++;
-    if (t_1 != 139) {
-      status = WUFFS_GZIP__ERROR_BAD_HEADER;
+    ioptr_src--;
+
+    goto ok;
+  ok:
+    self->private_impl.c_peek_u8[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_peek_u8[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_peek_u8[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_GZIP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_GZIP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_member
+
+static wuffs_gzip__status wuffs_gzip__decoder__decode_member(
+    wuffs_gzip__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src) {
+  wuffs_gzip__status status = WUFFS_GZIP__STATUS_OK;
+
+  uint8_t v_flags;
+  uint8_t v_xfl;
+  uint8_t v_c;
+  bool v_truncated;
+  uint16_t v_xlen;
+  uint16_t v_header_checksum_want;
+  uint32_t v_checksum_got;
+  uint32_t v_decoded_length_got;
+  wuffs_gzip__status v_z;
+  uint32_t v_checksum_want;
+  uint32_t v_decoded_length_want;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_member[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_flags = self->private_impl.c_decode_member[0].v_flags;
+    v_xfl = self->private_impl.c_decode_member[0].v_xfl;
+    v_c = self->private_impl.c_decode_member[0].v_c;
+    v_truncated = self->private_impl.c_decode_member[0].v_truncated;
+    v_xlen = self->private_impl.c_decode_member[0].v_xlen;
+    v_header_checksum_want =
+        self->private_impl.c_decode_member[0].v_header_checksum_want;
+    v_checksum_got = self->private_impl.c_decode_member[0].v_checksum_got;
+    v_decoded_length_got =
+        self->private_impl.c_decode_member[0].v_decoded_length_got;
+    v_z = self->private_impl.c_decode_member[0].v_z;
+    v_checksum_want = self->private_impl.c_decode_member[0].v_checksum_want;
+    v_decoded_length_want =
+        self->private_impl.c_decode_member[0].v_decoded_length_want;
+  } else {
+    v_truncated = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_mtime = 0;
+    self->private_impl.f_os = 0;
+    self->private_impl.f_fname_length = 0;
+    self->private_impl.f_fcomment_length = 0;
+    self->private_impl.f_header_checksum = 0;
+    (memset(&self->private_impl.f_header_hasher, 0,
+        sizeof((wuffs_crc32__ieee_hasher){})),
+        wuffs_crc32__ieee_hasher__check_wuffs_version(
+        &self->private_impl.f_header_hasher,
+        sizeof((wuffs_crc32__ieee_hasher){}), WUFFS_VERSION),
+        wuffs_base__return_empty_struct());
+    self->private_impl.f_hash_header = false;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+      goto short_read_src;
+    }
+    uint8_t t_0 = *ioptr_src++;
+    if (t_0 != 31) {
+      status = WUFFS_GZIP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+      goto short_read_src;
+    }
+    uint8_t t_1 = *ioptr_src++;
+    if (t_1 != 139) {
+      status = WUFFS_GZIP__ERROR_BAD_HEADER;
       goto exit;
     }
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
@@ -2359,101 +3687,230 @@ wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
       uint8_t t_3 = *ioptr_src++;
       v_flags = t_3;
     }
-    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
-    self->private_impl.c_decode[0].scratch = 6;
-    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
-    if (self->private_impl.c_decode[0].scratch > iobounds1_src - ioptr_src) {
-      self->private_impl.c_decode[0].scratch -= iobounds1_src - ioptr_src;
-      ioptr_src = iobounds1_src;
-      goto short_read_src;
+    if ((v_flags & 224) != 0) {
+      status = WUFFS_GZIP__ERROR_BAD_ENCODING_FLAGS;
+      goto exit;
+    }
+    self->private_impl.f_hash_header = ((v_flags & 2) != 0);
+    wuffs_gzip__decoder__hash(self, 559903, 3);
+    wuffs_gzip__decoder__hash(self, ((uint32_t)(v_flags)), 1);
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      uint32_t t_5;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_5 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_decode_member[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_decode_member[0].scratch;
+          uint32_t t_4 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_4;
+          if (t_4 == 24) {
+            t_5 = *scratch;
+            break;
+          }
+          t_4 += 8;
+          *scratch |= ((uint64_t)(t_4)) << 56;
+        }
+      }
+      self->private_impl.f_mtime = t_5;
+    }
+    wuffs_gzip__decoder__hash(self, self->private_impl.f_mtime, 4);
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_6 = *ioptr_src++;
+      v_xfl = t_6;
     }
-    ioptr_src += self->private_impl.c_decode[0].scratch;
+    wuffs_gzip__decoder__hash(self, ((uint32_t)(v_xfl)), 1);
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_7 = *ioptr_src++;
+      self->private_impl.f_os = t_7;
+    }
+    wuffs_gzip__decoder__hash(self, ((uint32_t)(self->private_impl.f_os)), 1);
     v_c = 0;
+    v_truncated = 0;
     if ((v_flags & 4) != 0) {
       {
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
-        uint16_t t_5;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+        uint16_t t_9;
         if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 2)) {
-          t_5 = wuffs_base__load_u16le(ioptr_src);
+          t_9 = wuffs_base__load_u16le(ioptr_src);
           ioptr_src += 2;
         } else {
-          self->private_impl.c_decode[0].scratch = 0;
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+          self->private_impl.c_decode_member[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
           while (true) {
             if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
               goto short_read_src;
             }
-            uint64_t* scratch = &self->private_impl.c_decode[0].scratch;
-            uint32_t t_4 = *scratch >> 56;
+            uint64_t* scratch = &self->private_impl.c_decode_member[0].scratch;
+            uint32_t t_8 = *scratch >> 56;
             *scratch <<= 8;
             *scratch >>= 8;
-            *scratch |= ((uint64_t)(*ioptr_src++)) << t_4;
-            if (t_4 == 8) {
-              t_5 = *scratch;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_8;
+            if (t_8 == 8) {
+              t_9 = *scratch;
               break;
             }
-            t_4 += 8;
-            *scratch |= ((uint64_t)(t_4)) << 56;
+            t_8 += 8;
+            *scratch |= ((uint64_t)(t_8)) << 56;
           }
         }
-        v_xlen = t_5;
+        v_xlen = t_9;
       }
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
-      self->private_impl.c_decode[0].scratch = ((uint32_t)(v_xlen));
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
-      if (self->private_impl.c_decode[0].scratch > iobounds1_src - ioptr_src) {
-        self->private_impl.c_decode[0].scratch -= iobounds1_src - ioptr_src;
-        ioptr_src = iobounds1_src;
-        goto short_read_src;
+      wuffs_gzip__decoder__hash(self, ((uint32_t)(v_xlen)), 2);
+      if (self->private_impl.f_hash_header) {
+        while (v_xlen > 0) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_10 = *ioptr_src++;
+            v_c = t_10;
+          }
+          wuffs_gzip__decoder__hash(self, ((uint32_t)(v_c)), 1);
+          v_xlen -= 1;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+        self->private_impl.c_decode_member[0].scratch = ((uint32_t)(v_xlen));
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+        if (self->private_impl.c_decode_member[0].scratch >
+            iobounds1_src - ioptr_src) {
+          self->private_impl.c_decode_member[0].scratch -=
+              iobounds1_src - ioptr_src;
+          ioptr_src = iobounds1_src;
+          goto short_read_src;
+        }
+        ioptr_src += self->private_impl.c_decode_member[0].scratch;
       }
-      ioptr_src += self->private_impl.c_decode[0].scratch;
     }
     if ((v_flags & 8) != 0) {
+    label_0_continue:;
       while (true) {
         {
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
           if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
             goto short_read_src;
           }
-          uint8_t t_6 = *ioptr_src++;
-          v_c = t_6;
+          uint8_t t_11 = *ioptr_src++;
+          v_c = t_11;
         }
+        wuffs_gzip__decoder__hash(self, ((uint32_t)(v_c)), 1);
         if (v_c == 0) {
           goto label_0_break;
+        } else if (v_truncated) {
+          goto label_0_continue;
+        } else if (v_c < 128) {
+          if (self->private_impl.f_fname_length >= 255) {
+            v_truncated = true;
+            goto label_0_continue;
+          }
+          self->private_impl.f_fname[self->private_impl.f_fname_length] = v_c;
+          self->private_impl.f_fname_length += 1;
+        } else {
+          if (self->private_impl.f_fname_length >= 254) {
+            v_truncated = true;
+            goto label_0_continue;
+          }
+          self->private_impl.f_fname[self->private_impl.f_fname_length] =
+              (192 | (v_c >> 6));
+          self->private_impl.f_fname[(self->private_impl.f_fname_length + 1)] =
+              (128 | (v_c & 63));
+          self->private_impl.f_fname_length += 2;
         }
       }
     label_0_break:;
     }
     if ((v_flags & 16) != 0) {
+      v_truncated = false;
+    label_1_continue:;
       while (true) {
         {
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
           if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
             goto short_read_src;
           }
-          uint8_t t_7 = *ioptr_src++;
-          v_c = t_7;
+          uint8_t t_12 = *ioptr_src++;
+          v_c = t_12;
         }
+        wuffs_gzip__decoder__hash(self, ((uint32_t)(v_c)), 1);
         if (v_c == 0) {
           goto label_1_break;
+        } else if (v_truncated) {
+          goto label_1_continue;
+        } else if (v_c < 128) {
+          if (self->private_impl.f_fcomment_length >= 1024) {
+            v_truncated = true;
+            goto label_1_continue;
+          }
+          self->private_impl.f_fcomment[self->private_impl.f_fcomment_length] =
+              v_c;
+          self->private_impl.f_fcomment_length += 1;
+        } else {
+          if (self->private_impl.f_fcomment_length >= 1023) {
+            v_truncated = true;
+            goto label_1_continue;
+          }
+          self->private_impl.f_fcomment[self->private_impl.f_fcomment_length] =
+              (192 | (v_c >> 6));
+          self->private_impl.f_fcomment[(
+              self->private_impl.f_fcomment_length + 1)] = (128 | (v_c & 63));
+          self->private_impl.f_fcomment_length += 2;
         }
       }
     label_1_break:;
     }
     if ((v_flags & 2) != 0) {
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
-      self->private_impl.c_decode[0].scratch = 2;
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
-      if (self->private_impl.c_decode[0].scratch > iobounds1_src - ioptr_src) {
-        self->private_impl.c_decode[0].scratch -= iobounds1_src - ioptr_src;
-        ioptr_src = iobounds1_src;
-        goto short_read_src;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+        uint16_t t_14;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 2)) {
+          t_14 = wuffs_base__load_u16le(ioptr_src);
+          ioptr_src += 2;
+        } else {
+          self->private_impl.c_decode_member[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_decode_member[0].scratch;
+            uint32_t t_13 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_13;
+            if (t_13 == 8) {
+              t_14 = *scratch;
+              break;
+            }
+            t_13 += 8;
+            *scratch |= ((uint64_t)(t_13)) << 56;
+          }
+        }
+        v_header_checksum_want = t_14;
+      }
+      if (!self->private_impl.f_ignore_checksum &&
+          ((self->private_impl.f_header_checksum & 65535) !=
+          ((uint32_t)(v_header_checksum_want)))) {
+        status = WUFFS_GZIP__ERROR_BAD_HEADER_CHECKSUM;
+        goto exit;
       }
-      ioptr_src += self->private_impl.c_decode[0].scratch;
-    }
-    if ((v_flags & 224) != 0) {
-      status = WUFFS_GZIP__ERROR_BAD_ENCODING_FLAGS;
-      goto exit;
     }
     v_checksum_got = 0;
     v_decoded_length_got = 0;
@@ -2466,119 +3923,119 @@ wuffs_gzip__status wuffs_gzip__decoder__decode(wuffs_gzip__decoder* self,
         if (a_src.private_impl.buf) {
           a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
         }
-        wuffs_gzip__status t_8 = wuffs_deflate__decoder__decode(
-            &self->private_impl.f_flate, a_dst, a_src);
+        wuffs_gzip__status t_15 =
+            wuffs_deflate__decoder__decode(&self->private_impl.f_flate, a_dst,
+            a_src);
         if (a_dst.private_impl.buf) {
           ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
         }
         if (a_src.private_impl.buf) {
           ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
         }
-        v_z = t_8;
+        v_z = t_15;
       }
       if (!self->private_impl.f_ignore_checksum) {
-        v_checksum_got = wuffs_crc32__ieee_hasher__update(
-            &self->private_impl.f_checksum,
-            ((wuffs_base__slice_u8){
-                .ptr = a_dst.private_impl.bounds[0],
-                .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-            }));
-        v_decoded_length_got += ((uint32_t)(
-            (((uint64_t)(
-                 ((wuffs_base__slice_u8){
-                      .ptr = a_dst.private_impl.bounds[0],
-                      .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-                  })
-                     .len)) &
-             4294967295)));
+        v_checksum_got =
+            wuffs_crc32__ieee_hasher__update(&self->private_impl.f_checksum,
+            ((wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+            .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}));
+        v_decoded_length_got +=
+            ((uint32_t)((((uint64_t)(((wuffs_base__slice_u8){
+            .ptr = a_dst.private_impl.bounds[0], .len = (size_t)(
+            ioptr_dst - a_dst.private_impl.bounds[0])}).len)) & 4294967295)));
       }
       if (v_z == 0) {
         goto label_2_break;
       }
       status = v_z;
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(15);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(18);
     }
   label_2_break:;
     {
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
-      uint32_t t_10;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(19);
+      uint32_t t_17;
       if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
-        t_10 = wuffs_base__load_u32le(ioptr_src);
+        t_17 = wuffs_base__load_u32le(ioptr_src);
         ioptr_src += 4;
       } else {
-        self->private_impl.c_decode[0].scratch = 0;
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+        self->private_impl.c_decode_member[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(20);
         while (true) {
           if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
             goto short_read_src;
           }
-          uint64_t* scratch = &self->private_impl.c_decode[0].scratch;
-          uint32_t t_9 = *scratch >> 56;
+          uint64_t* scratch = &self->private_impl.c_decode_member[0].scratch;
+          uint32_t t_16 = *scratch >> 56;
           *scratch <<= 8;
           *scratch >>= 8;
-          *scratch |= ((uint64_t)(*ioptr_src++)) << t_9;
-          if (t_9 == 24) {
-            t_10 = *scratch;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_16;
+          if (t_16 == 24) {
+            t_17 = *scratch;
             break;
           }
-          t_9 += 8;
-          *scratch |= ((uint64_t)(t_9)) << 56;
+          t_16 += 8;
+          *scratch |= ((uint64_t)(t_16)) << 56;
         }
       }
-      v_checksum_want = t_10;
+      v_checksum_want = t_17;
     }
     {
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(18);
-      uint32_t t_12;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(21);
+      uint32_t t_19;
       if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
-        t_12 = wuffs_base__load_u32le(ioptr_src);
+        t_19 = wuffs_base__load_u32le(ioptr_src);
         ioptr_src += 4;
       } else {
-        self->private_impl.c_decode[0].scratch = 0;
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(19);
+        self->private_impl.c_decode_member[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(22);
         while (true) {
           if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
             goto short_read_src;
           }
-          uint64_t* scratch = &self->private_impl.c_decode[0].scratch;
-          uint32_t t_11 = *scratch >> 56;
+          uint64_t* scratch = &self->private_impl.c_decode_member[0].scratch;
+          uint32_t t_18 = *scratch >> 56;
           *scratch <<= 8;
           *scratch >>= 8;
-          *scratch |= ((uint64_t)(*ioptr_src++)) << t_11;
-          if (t_11 == 24) {
-            t_12 = *scratch;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_18;
+          if (t_18 == 24) {
+            t_19 = *scratch;
             break;
           }
-          t_11 += 8;
-          *scratch |= ((uint64_t)(t_11)) << 56;
+          t_18 += 8;
+          *scratch |= ((uint64_t)(t_18)) << 56;
         }
       }
-      v_decoded_length_want = t_12;
+      v_decoded_length_want = t_19;
     }
-    if (!self->private_impl.f_ignore_checksum &&
-        ((v_checksum_got != v_checksum_want) ||
-         (v_decoded_length_got != v_decoded_length_want))) {
+    if (!self->private_impl.f_ignore_checksum && ((v_checksum_got !=
+        v_checksum_want) || (v_decoded_length_got != v_decoded_length_want))) {
       status = WUFFS_GZIP__ERROR_BAD_CHECKSUM;
       goto exit;
     }
 
     goto ok;
   ok:
-    self->private_impl.c_decode[0].coro_susp_point = 0;
+    self->private_impl.c_decode_member[0].coro_susp_point = 0;
     goto exit;
   }
 
   goto suspend;
 suspend:
-  self->private_impl.c_decode[0].coro_susp_point = coro_susp_point;
-  self->private_impl.c_decode[0].v_flags = v_flags;
-  self->private_impl.c_decode[0].v_c = v_c;
-  self->private_impl.c_decode[0].v_xlen = v_xlen;
-  self->private_impl.c_decode[0].v_checksum_got = v_checksum_got;
-  self->private_impl.c_decode[0].v_decoded_length_got = v_decoded_length_got;
-  self->private_impl.c_decode[0].v_z = v_z;
-  self->private_impl.c_decode[0].v_checksum_want = v_checksum_want;
-  self->private_impl.c_decode[0].v_decoded_length_want = v_decoded_length_want;
+  self->private_impl.c_decode_member[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_member[0].v_flags = v_flags;
+  self->private_impl.c_decode_member[0].v_xfl = v_xfl;
+  self->private_impl.c_decode_member[0].v_c = v_c;
+  self->private_impl.c_decode_member[0].v_truncated = v_truncated;
+  self->private_impl.c_decode_member[0].v_xlen = v_xlen;
+  self->private_impl.c_decode_member[0].v_header_checksum_want =
+      v_header_checksum_want;
+  self->private_impl.c_decode_member[0].v_checksum_got = v_checksum_got;
+  self->private_impl.c_decode_member[0].v_decoded_length_got =
+      v_decoded_length_got;
+  self->private_impl.c_decode_member[0].v_z = v_z;
+  self->private_impl.c_decode_member[0].v_checksum_want = v_checksum_want;
+  self->private_impl.c_decode_member[0].v_decoded_length_want =
+      v_decoded_length_want;
 
   goto exit;
 exit:
@@ -2589,7 +4046,6 @@ exit:
     a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
   }
 
-  self->private_impl.status = status;
   return status;
 
 short_read_src:
@@ -2600,3 +4056,668 @@ short_read_src:
   status = WUFFS_GZIP__SUSPENSION_SHORT_READ;
   goto suspend;
 }
+
+// -------- func decoder.hash
+
+static void wuffs_gzip__decoder__hash(wuffs_gzip__decoder* self, uint32_t a_x,
+    uint32_t a_n) {
+  if (!self->private_impl.f_hash_header) {
+    return;
+  }
+  self->private_impl.f_hbuf[0] = ((uint8_t)(((a_x >> 0) & 255)));
+  self->private_impl.f_hbuf[1] = ((uint8_t)(((a_x >> 8) & 255)));
+  self->private_impl.f_hbuf[2] = ((uint8_t)(((a_x >> 16) & 255)));
+  self->private_impl.f_hbuf[3] = ((uint8_t)(((a_x >> 24) & 255)));
+  self->private_impl.f_header_checksum =
+      wuffs_crc32__ieee_hasher__update(&self->private_impl.f_header_hasher,
+      wuffs_base__slice_u8__subslice_j(((wuffs_base__slice_u8){
+      .ptr = self->private_impl.f_hbuf, .len = 4}), a_n));
+}
+
+// -------- func encoder.set_level
+
+void wuffs_gzip__encoder__set_level(wuffs_gzip__encoder* self,
+    uint32_t a_level) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  wuffs_deflate__encoder__set_level(&self->private_impl.f_flate, a_level);
+  self->private_impl.f_level = wuffs_base__u32__min(a_level, 9);
+  self->private_impl.f_level_set = true;
+}
+
+// -------- func encoder.encode
+
+wuffs_gzip__status wuffs_gzip__encoder__encode(wuffs_gzip__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_GZIP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_gzip__status status = WUFFS_GZIP__STATUS_OK;
+
+  uint8_t v_xfl;
+  uint32_t v_checksum;
+  uint32_t v_isize;
+  wuffs_gzip__status v_z;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_xfl = self->private_impl.c_encode[0].v_xfl;
+    v_checksum = self->private_impl.c_encode[0].v_checksum;
+    v_isize = self->private_impl.c_encode[0].v_isize;
+    v_z = self->private_impl.c_encode[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (!self->private_impl.f_level_set) {
+      self->private_impl.f_level_set = true;
+      self->private_impl.f_level = 6;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode[0].scratch < 4) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(559903)) >>
+          (8 * self->private_impl.c_encode[0].scratch));
+      self->private_impl.c_encode[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    while (self->private_impl.c_encode[0].scratch < 4) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(0)) >>
+          (8 * self->private_impl.c_encode[0].scratch));
+      self->private_impl.c_encode[0].scratch++;
+    }
+    v_xfl = 0;
+    if (self->private_impl.f_level == 9) {
+      v_xfl = 2;
+    } else if (self->private_impl.f_level < 2) {
+      v_xfl = 4;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = v_xfl;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    if (ioptr_dst == iobounds1_dst) {
+      status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+      goto suspend;
+    }
+    *ioptr_dst++ = 255;
+    v_checksum = 0;
+    v_isize = 0;
+    while (true) {
+      wuffs_base__io_reader__set_mark(&a_src, ioptr_src);
+      {
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        if (a_src.private_impl.buf) {
+          a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+        }
+        wuffs_gzip__status t_0 =
+            wuffs_deflate__encoder__encode(&self->private_impl.f_flate, a_dst,
+            a_src);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (a_src.private_impl.buf) {
+          ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+        }
+        v_z = t_0;
+      }
+      v_checksum =
+          wuffs_crc32__ieee_hasher__update(&self->private_impl.f_checksum,
+          ((wuffs_base__slice_u8){.ptr = a_src.private_impl.bounds[0],
+          .len = (size_t)(ioptr_src - a_src.private_impl.bounds[0])}));
+      v_isize += ((uint32_t)((((uint64_t)(((wuffs_base__slice_u8){
+          .ptr = a_src.private_impl.bounds[0], .len = (size_t)(
+          ioptr_src - a_src.private_impl.bounds[0])}).len)) & 4294967295)));
+      if (v_z == 0) {
+        goto label_0_break;
+      }
+      status = v_z;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(7);
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+    while (self->private_impl.c_encode[0].scratch < 4) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(v_checksum)) >>
+          (8 * self->private_impl.c_encode[0].scratch));
+      self->private_impl.c_encode[0].scratch++;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+    while (self->private_impl.c_encode[0].scratch < 4) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_GZIP__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(v_isize)) >>
+          (8 * self->private_impl.c_encode[0].scratch));
+      self->private_impl.c_encode[0].scratch++;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode[0].v_xfl = v_xfl;
+  self->private_impl.c_encode[0].v_checksum = v_checksum;
+  self->private_impl.c_encode[0].v_isize = v_isize;
+  self->private_impl.c_encode[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_gzip__status wuffs_gzip__decoder__save_state(wuffs_gzip__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_GZIP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_GZIP__DECODER__STATE_LENGTH) {
+    return WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  {
+    int32_t z = wuffs_deflate__decoder__save_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__DECODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_DEFLATE__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_crc32__ieee_hasher__save_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  p[0] = self->private_impl.f_ignore_checksum ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_multi_member ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_mtime));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_os);
+  p += 1;
+  for (i0 = 0; i0 < 255; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_fname[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_fname_length));
+  p += 4;
+  for (i0 = 0; i0 < 1024; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_fcomment[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_fcomment_length));
+  p += 4;
+  {
+    int32_t z = wuffs_crc32__ieee_hasher__save_state(
+        &self->private_impl.f_header_hasher, ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_header_checksum));
+  p += 4;
+  p[0] = self->private_impl.f_hash_header ? 1 : 0;
+  p += 1;
+  for (i0 = 0; i0 < 4; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_hbuf[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_peek_u8[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_peek_u8[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_member[0].v_flags);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.c_decode_member[0].v_xfl);
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.c_decode_member[0].v_c);
+  p += 1;
+  p[0] = self->private_impl.c_decode_member[0].v_truncated ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u16le(p,
+      (uint16_t)(self->private_impl.c_decode_member[0].v_xlen));
+  p += 2;
+  wuffs_base__store_u16le(p,
+      (uint16_t)(self->private_impl.c_decode_member[0].v_header_checksum_want));
+  p += 2;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].v_checksum_got));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].v_decoded_length_got));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].v_checksum_want));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_member[0].v_decoded_length_want));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_member[0].scratch));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xD21BEBE7,
+      WUFFS_GZIP__DECODER__STATE_LENGTH);
+  return WUFFS_GZIP__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_gzip__status wuffs_gzip__decoder__restore_state(wuffs_gzip__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_GZIP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xD21BEBE7,
+      WUFFS_GZIP__DECODER__STATE_LENGTH)) {
+    return WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  self->private_impl.status = (wuffs_gzip__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  {
+    int32_t z =
+        wuffs_deflate__decoder__restore_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__DECODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_DEFLATE__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_crc32__ieee_hasher__restore_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_ignore_checksum = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_multi_member = p[0];
+  p += 1;
+  self->private_impl.f_mtime = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_os = (uint8_t)(p[0]);
+  p += 1;
+  for (i0 = 0; i0 < 255; i0++) {
+    self->private_impl.f_fname[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_fname_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_fname_length > 255) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 1024; i0++) {
+    self->private_impl.f_fcomment[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_fcomment_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_fcomment_length > 1024) {
+    goto bad_state;
+  }
+  {
+    int32_t z = wuffs_crc32__ieee_hasher__restore_state(
+        &self->private_impl.f_header_hasher, ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  self->private_impl.f_header_checksum = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_hash_header = p[0];
+  p += 1;
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_hbuf[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.c_decode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode[0].v_z =
+      (wuffs_gzip__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_peek_u8[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_peek_u8[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_peek_u8[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_member[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_member[0].coro_susp_point > 22) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_member[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_member[0].v_xfl = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_member[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_decode_member[0].v_truncated = p[0];
+  p += 1;
+  self->private_impl.c_decode_member[0].v_xlen =
+      (uint16_t)(wuffs_base__load_u16le(p));
+  p += 2;
+  self->private_impl.c_decode_member[0].v_header_checksum_want =
+      (uint16_t)(wuffs_base__load_u16le(p));
+  p += 2;
+  self->private_impl.c_decode_member[0].v_checksum_got =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_member[0].v_decoded_length_got =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_member[0].v_z =
+      (wuffs_gzip__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_member[0].v_checksum_want =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_member[0].v_decoded_length_want =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_member[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_GZIP__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
+
+// -------- func encoder.save_state
+
+wuffs_gzip__status wuffs_gzip__encoder__save_state(wuffs_gzip__encoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_GZIP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_GZIP__ENCODER__STATE_LENGTH) {
+    return WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  {
+    int32_t z = wuffs_deflate__encoder__save_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__ENCODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_DEFLATE__ENCODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_crc32__ieee_hasher__save_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_level));
+  p += 4;
+  p[0] = self->private_impl.f_level_set ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_encode[0].v_xfl);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_checksum));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_isize));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_z));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode[0].scratch));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xE4A4355D,
+      WUFFS_GZIP__ENCODER__STATE_LENGTH);
+  return WUFFS_GZIP__STATUS_OK;
+}
+
+// -------- func encoder.restore_state
+
+wuffs_gzip__status wuffs_gzip__encoder__restore_state(wuffs_gzip__encoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_GZIP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_GZIP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xE4A4355D,
+      WUFFS_GZIP__ENCODER__STATE_LENGTH)) {
+    return WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  self->private_impl.status = (wuffs_gzip__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  {
+    int32_t z =
+        wuffs_deflate__encoder__restore_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__ENCODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_DEFLATE__ENCODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_crc32__ieee_hasher__restore_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  }
+  self->private_impl.f_level = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_level > 9) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_level_set = p[0];
+  p += 1;
+  self->private_impl.c_encode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode[0].coro_susp_point > 11) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode[0].v_xfl = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_encode[0].v_checksum =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_isize =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_z =
+      (wuffs_gzip__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_GZIP__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_GZIP__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,9 +1390,20 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1210,26 +1423,25 @@ typedef int32_t wuffs_lzw__status;
 
 #define wuffs_lzw__packageid 1316776  // 0x001417A8
 
-#define WUFFS_LZW__STATUS_OK 0                            // 0x00000000
-#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_LZW__STATUS_OK 0  // 0x00000000
+#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                    // 0x80000006
-#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_LZW__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_LZW__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
-#define WUFFS_LZW__ERROR_BAD_CODE -799105024               // 0xD05EA000
+#define WUFFS_LZW__ERROR_BAD_CODE -799105024  // 0xD05EA000
 #define WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN -799105023  // 0xD05EA001
+#define WUFFS_LZW__ERROR_BAD_LITERAL -799105022  // 0xD05EA002
 
 bool wuffs_lzw__status__is_error(wuffs_lzw__status s);
 
@@ -1252,6 +1464,7 @@ typedef struct {
     uint32_t magic;
 
     uint32_t f_literal_width;
+    bool f_tiff_mode;
     uint8_t f_stack[4096];
     uint8_t f_suffixes[4096];
     uint16_t f_prefixes[4096];
@@ -1259,6 +1472,7 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       uint32_t v_literal_width;
+      bool v_tiff_mode;
       uint32_t v_clear_code;
       uint32_t v_end_code;
       uint32_t v_save_code;
@@ -1267,6 +1481,7 @@ typedef struct {
       uint32_t v_bits;
       uint32_t v_n_bits;
       uint32_t v_code;
+      uint32_t v_msb_bits;
       uint32_t v_s;
       uint32_t v_c;
       uint64_t v_n_copied;
@@ -1274,6 +1489,61 @@ typedef struct {
   } private_impl;
 } wuffs_lzw__decoder;
 
+// WUFFS_LZW__DECODER__STATE_LENGTH is the length of a wuffs_lzw__decoder's
+// saved state.
+#define WUFFS_LZW__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 16454)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_lzw__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_lzw__status status;
+    uint32_t magic;
+
+    uint32_t f_literal_width;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_buf[4096];
+    uint32_t f_buf_ri;
+    uint32_t f_buf_wi;
+    uint32_t f_htable[16384];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_literal_width;
+      uint32_t v_clear_code;
+      uint32_t v_end_code;
+      uint32_t v_hi;
+      uint32_t v_width;
+      uint32_t v_code;
+      bool v_has_code;
+      uint32_t v_literal;
+      uint32_t v_key;
+      uint32_t v_h;
+      uint32_t v_entry;
+      wuffs_lzw__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_buf[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_code[1];
+  } private_impl;
+} wuffs_lzw__encoder;
+
+// WUFFS_LZW__ENCODER__STATE_LENGTH is the length of a wuffs_lzw__encoder's
+// saved state.
+#define WUFFS_LZW__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 69719)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_lzw__decoder__check_wuffs_version is an initializer function.
@@ -1282,22 +1552,229 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__decoder returns sizeof(wuffs_lzw__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__decoder(void);
+
+// wuffs_lzw__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_lzw__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_lzw__encoder__check_wuffs_version(wuffs_lzw__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__encoder returns sizeof(wuffs_lzw__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_lzw__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__decoder__save_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__decoder__restore_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_lzw__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__encoder__save_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__encoder__restore_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
-                                           uint32_t a_lw);
+    uint32_t a_lw);
+
+void wuffs_lzw__decoder__set_tiff_mode(wuffs_lzw__decoder* self, bool a_m);
 
 wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
-                                             wuffs_base__io_writer a_dst,
-                                             wuffs_base__io_reader a_src);
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_lzw__encoder__set_literal_width(wuffs_lzw__encoder* self,
+    uint32_t a_lw);
+
+wuffs_lzw__status wuffs_lzw__encoder__encode(wuffs_lzw__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace lzw {
+
+// status is a strongly typed wuffs_lzw__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_LZW__STATUS_OK) {}
+  constexpr explicit status(wuffs_lzw__status repr) : repr_(repr) {}
+
+  constexpr wuffs_lzw__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_LZW__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_lzw__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_lzw__status repr_;
+};
+
+constexpr status status_ok(WUFFS_LZW__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_LZW__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_LZW__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_LZW__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_LZW__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_LZW__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_LZW__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_LZW__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_LZW__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_LZW__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_code(WUFFS_LZW__ERROR_BAD_CODE);
+constexpr status error_cyclical_prefix_chain(
+    WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN);
+constexpr status error_bad_literal(WUFFS_LZW__ERROR_BAD_LITERAL);
+
+// decoder is an RAII wrapper for a wuffs_lzw__decoder. Its constructor
+// calls wuffs_lzw__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_lzw__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__decoder__save_state and
+  // wuffs_lzw__decoder__restore_state. A saved state is
+  // WUFFS_LZW__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__decoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__decoder__set_literal_width(&c_, lw);
+  }
+
+  void set_tiff_mode(bool m) {
+    wuffs_lzw__decoder__set_tiff_mode(&c_, m);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_lzw__encoder. Its constructor
+// calls wuffs_lzw__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_lzw__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__encoder__save_state and
+  // wuffs_lzw__encoder__restore_state. A saved state is
+  // WUFFS_LZW__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__encoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__encoder__set_literal_width(&c_, lw);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__encoder c_;
+};
+
+}  // namespace lzw
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_LZW_H
 
 // C HEADER ENDS HERE.
@@ -1325,6 +1802,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -1474,6 +1958,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -1816,6 +2323,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -1859,22 +2386,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -1885,9 +2453,8 @@ bool wuffs_lzw__status__is_error(wuffs_lzw__status s) {
   return s < 0;
 }
 
-const char* wuffs_lzw__status__strings[2] = {
-    "lzw: bad code",
-    "lzw: cyclical prefix chain",
+const char* wuffs_lzw__status__strings[3] = {
+    "lzw: bad code", "lzw: cyclical prefix chain", "lzw: bad literal",
 };
 
 const char* wuffs_lzw__status__string(wuffs_lzw__status s) {
@@ -1900,7 +2467,7 @@ const char* wuffs_lzw__status__string(wuffs_lzw__status s) {
       break;
     case wuffs_lzw__packageid:
       a = wuffs_lzw__status__strings;
-      n = 2;
+      n = 3;
       break;
   }
   uint32_t i = s & 0xFF;
@@ -1911,13 +2478,52 @@ const char* wuffs_lzw__status__string(wuffs_lzw__status s) {
 
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
+static wuffs_lzw__status wuffs_lzw__encoder__fill_buf(wuffs_lzw__encoder* self,
+    wuffs_base__io_reader a_src);
+
+static void wuffs_lzw__encoder__clear_table(wuffs_lzw__encoder* self);
+
+static wuffs_lzw__status wuffs_lzw__encoder__write_code(
+    wuffs_lzw__encoder* self, wuffs_base__io_writer a_dst, uint32_t a_code,
+    uint32_t a_width);
+
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_lzw__decoder(void) {
+  return sizeof(wuffs_lzw__decoder);
+}
+
 void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_LZW__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+}
+
+size_t sizeof__wuffs_lzw__encoder(void) {
+  return sizeof(wuffs_lzw__encoder);
+}
+
+void wuffs_lzw__encoder__check_wuffs_version(wuffs_lzw__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -1942,7 +2548,7 @@ void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
 // -------- func decoder.set_literal_width
 
 void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
-                                           uint32_t a_lw) {
+    uint32_t a_lw) {
   if (!self) {
     return;
   }
@@ -1960,11 +2566,26 @@ void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
   self->private_impl.f_literal_width = a_lw;
 }
 
+// -------- func decoder.set_tiff_mode
+
+void wuffs_lzw__decoder__set_tiff_mode(wuffs_lzw__decoder* self, bool a_m) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  self->private_impl.f_tiff_mode = a_m;
+}
+
 // -------- func decoder.decode
 
 wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
-                                             wuffs_base__io_writer a_dst,
-                                             wuffs_base__io_reader a_src) {
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_LZW__ERROR_BAD_RECEIVER;
   }
@@ -1977,6 +2598,7 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
   wuffs_lzw__status status = WUFFS_LZW__STATUS_OK;
 
   uint32_t v_literal_width;
+  bool v_tiff_mode;
   uint32_t v_clear_code;
   uint32_t v_end_code;
   uint32_t v_save_code;
@@ -1985,6 +2607,7 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
   uint32_t v_bits;
   uint32_t v_n_bits;
   uint32_t v_code;
+  uint32_t v_msb_bits;
   uint32_t v_s;
   uint32_t v_c;
   wuffs_base__slice_u8 v_expansion;
@@ -2027,6 +2650,7 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
   uint32_t coro_susp_point = self->private_impl.c_decode[0].coro_susp_point;
   if (coro_susp_point) {
     v_literal_width = self->private_impl.c_decode[0].v_literal_width;
+    v_tiff_mode = self->private_impl.c_decode[0].v_tiff_mode;
     v_clear_code = self->private_impl.c_decode[0].v_clear_code;
     v_end_code = self->private_impl.c_decode[0].v_end_code;
     v_save_code = self->private_impl.c_decode[0].v_save_code;
@@ -2035,20 +2659,24 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
     v_bits = self->private_impl.c_decode[0].v_bits;
     v_n_bits = self->private_impl.c_decode[0].v_n_bits;
     v_code = self->private_impl.c_decode[0].v_code;
+    v_msb_bits = self->private_impl.c_decode[0].v_msb_bits;
     v_s = self->private_impl.c_decode[0].v_s;
     v_c = self->private_impl.c_decode[0].v_c;
     v_expansion = ((wuffs_base__slice_u8){});
     v_n_copied = self->private_impl.c_decode[0].v_n_copied;
   } else {
+    v_tiff_mode = false;
     v_expansion = ((wuffs_base__slice_u8){});
   }
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
     v_literal_width = 8;
-    if (self->private_impl.f_literal_width >= 2) {
+    if ((self->private_impl.f_literal_width >= 2) &&
+        !self->private_impl.f_tiff_mode) {
       v_literal_width = self->private_impl.f_literal_width;
     }
+    v_tiff_mode = self->private_impl.f_tiff_mode;
     v_clear_code = (((uint32_t)(1)) << v_literal_width);
     v_end_code = (v_clear_code + 1);
     v_save_code = v_end_code;
@@ -2059,21 +2687,38 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
   label_0_continue:;
     while (true) {
       while (v_n_bits < v_width) {
-        {
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
-          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
-            goto short_read_src;
+        if (v_tiff_mode) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_0 = *ioptr_src++;
+            v_bits = (((v_bits & 4095) << 8) | ((uint32_t)(t_0)));
+          }
+        } else {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_1 = *ioptr_src++;
+            v_bits |= (((uint32_t)(t_1)) << v_n_bits);
           }
-          uint8_t t_0 = *ioptr_src++;
-          v_bits |= (((uint32_t)(t_0)) << v_n_bits);
         }
         v_n_bits += 8;
       }
-      v_code = ((v_bits) & ((1 << (v_width)) - 1));
-      v_bits >>= v_width;
+      v_code = 0;
+      if (v_tiff_mode) {
+        v_msb_bits = (v_bits >> (v_n_bits - v_width));
+        v_code = ((v_msb_bits) & ((1 << (v_width)) - 1));
+      } else {
+        v_code = ((v_bits) & ((1 << (v_width)) - 1));
+        v_bits >>= v_width;
+      }
       v_n_bits -= v_width;
       if (v_code < v_clear_code) {
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
         if (ioptr_dst == iobounds1_dst) {
           status = WUFFS_LZW__SUSPENSION_SHORT_WRITE;
           goto suspend;
@@ -2113,18 +2758,17 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
           self->private_impl.f_stack[4095] = ((uint8_t)(v_c));
         }
         while (true) {
-          v_expansion = wuffs_base__slice_u8__subslice_i(
-              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_stack,
-                                      .len = 4096}),
-              v_s);
-          v_n_copied = wuffs_base__io_writer__copy_from_slice(
-              &ioptr_dst, iobounds1_dst, v_expansion);
+          v_expansion =
+              wuffs_base__slice_u8__subslice_i(((wuffs_base__slice_u8){
+              .ptr = self->private_impl.f_stack, .len = 4096}), v_s);
+          v_n_copied = wuffs_base__io_writer__copy_from_slice(&ioptr_dst,
+              iobounds1_dst, v_expansion);
           if (v_n_copied == ((uint64_t)(v_expansion.len))) {
             goto label_1_break;
           }
           v_s = ((v_s + ((uint32_t)((v_n_copied & 4095)))) & 4095);
           status = WUFFS_LZW__SUSPENSION_SHORT_WRITE;
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(4);
         }
       label_1_break:;
         if (v_save_code <= 4095) {
@@ -2138,7 +2782,13 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
       }
       if (v_save_code <= 4095) {
         v_save_code += 1;
-        if ((v_save_code == (((uint32_t)(1)) << v_width)) && (v_width < 12)) {
+        if (v_tiff_mode) {
+          if (((v_save_code + 1) == (((uint32_t)(1)) << v_width)) &&
+              (v_width < 12)) {
+            v_width += 1;
+          }
+        } else if ((v_save_code == (((uint32_t)(1)) << v_width)) &&
+            (v_width < 12)) {
           v_width += 1;
         }
       }
@@ -2155,6 +2805,7 @@ wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
 suspend:
   self->private_impl.c_decode[0].coro_susp_point = coro_susp_point;
   self->private_impl.c_decode[0].v_literal_width = v_literal_width;
+  self->private_impl.c_decode[0].v_tiff_mode = v_tiff_mode;
   self->private_impl.c_decode[0].v_clear_code = v_clear_code;
   self->private_impl.c_decode[0].v_end_code = v_end_code;
   self->private_impl.c_decode[0].v_save_code = v_save_code;
@@ -2163,6 +2814,7 @@ suspend:
   self->private_impl.c_decode[0].v_bits = v_bits;
   self->private_impl.c_decode[0].v_n_bits = v_n_bits;
   self->private_impl.c_decode[0].v_code = v_code;
+  self->private_impl.c_decode[0].v_msb_bits = v_msb_bits;
   self->private_impl.c_decode[0].v_s = v_s;
   self->private_impl.c_decode[0].v_c = v_c;
   self->private_impl.c_decode[0].v_n_copied = v_n_copied;
@@ -2187,3 +2839,862 @@ short_read_src:
   status = WUFFS_LZW__SUSPENSION_SHORT_READ;
   goto suspend;
 }
+
+// -------- func encoder.set_literal_width
+
+void wuffs_lzw__encoder__set_literal_width(wuffs_lzw__encoder* self,
+    uint32_t a_lw) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+  if (a_lw < 2 || a_lw > 8) {
+    self->private_impl.status = WUFFS_LZW__ERROR_BAD_ARGUMENT;
+    return;
+  }
+
+  self->private_impl.f_literal_width = a_lw;
+}
+
+// -------- func encoder.encode
+
+wuffs_lzw__status wuffs_lzw__encoder__encode(wuffs_lzw__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_LZW__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_lzw__status status = WUFFS_LZW__STATUS_OK;
+
+  uint32_t v_literal_width;
+  uint32_t v_clear_code;
+  uint32_t v_end_code;
+  uint32_t v_hi;
+  uint32_t v_width;
+  uint32_t v_code;
+  bool v_has_code;
+  uint32_t v_literal;
+  uint32_t v_key;
+  uint32_t v_h;
+  uint32_t v_entry;
+  wuffs_lzw__status v_z;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_literal_width = self->private_impl.c_encode[0].v_literal_width;
+    v_clear_code = self->private_impl.c_encode[0].v_clear_code;
+    v_end_code = self->private_impl.c_encode[0].v_end_code;
+    v_hi = self->private_impl.c_encode[0].v_hi;
+    v_width = self->private_impl.c_encode[0].v_width;
+    v_code = self->private_impl.c_encode[0].v_code;
+    v_has_code = self->private_impl.c_encode[0].v_has_code;
+    v_literal = self->private_impl.c_encode[0].v_literal;
+    v_key = self->private_impl.c_encode[0].v_key;
+    v_h = self->private_impl.c_encode[0].v_h;
+    v_entry = self->private_impl.c_encode[0].v_entry;
+    v_z = self->private_impl.c_encode[0].v_z;
+  } else {
+    v_has_code = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_literal_width = 8;
+    if (self->private_impl.f_literal_width >= 2) {
+      v_literal_width = self->private_impl.f_literal_width;
+    }
+    v_clear_code = (((uint32_t)(1)) << v_literal_width);
+    v_end_code = (v_clear_code + 1);
+    v_hi = v_end_code;
+    v_width = (v_literal_width + 1);
+    v_code = 0;
+    v_has_code = 0;
+    v_literal = 0;
+    v_key = 0;
+    v_h = 0;
+    v_entry = 0;
+    self->private_impl.f_eof = false;
+    self->private_impl.f_bits = 0;
+    self->private_impl.f_n_bits = 0;
+    self->private_impl.f_buf_ri = 0;
+    self->private_impl.f_buf_wi = 0;
+    wuffs_lzw__encoder__clear_table(self);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (a_dst.private_impl.buf) {
+      a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+    }
+    status = wuffs_lzw__encoder__write_code(self, a_dst, v_clear_code, v_width);
+    if (a_dst.private_impl.buf) {
+      ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    }
+    if (status) {
+      goto suspend;
+    }
+  label_0_continue:;
+    while (true) {
+      if (self->private_impl.f_buf_ri >= self->private_impl.f_buf_wi) {
+        if (self->private_impl.f_eof) {
+          goto label_0_break;
+        }
+        {
+          wuffs_lzw__status t_0 = wuffs_lzw__encoder__fill_buf(self, a_src);
+          v_z = t_0;
+        }
+        if (v_z < 0) {
+          self->private_impl.f_eof = true;
+        } else if (v_z > 0) {
+          status = v_z;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+        }
+        goto label_0_continue;
+      }
+      v_literal =
+          ((uint32_t)(self->private_impl.f_buf[self->private_impl.f_buf_ri]));
+      self->private_impl.f_buf_ri += 1;
+      if (v_literal >= v_clear_code) {
+        status = WUFFS_LZW__ERROR_BAD_LITERAL;
+        goto exit;
+      }
+      if (!v_has_code) {
+        v_code = v_literal;
+        v_has_code = true;
+        goto label_0_continue;
+      }
+      v_key = ((v_code << 8) | v_literal);
+      v_h = (((v_key >> 12) ^ v_key) & 16383);
+      while (true) {
+        v_entry = self->private_impl.f_htable[v_h];
+        if (v_entry == 0) {
+          goto label_1_break;
+        }
+        if ((v_entry >> 12) == v_key) {
+          v_code = (v_entry & 4095);
+          goto label_0_continue;
+        }
+        v_h = ((v_h + 1) & 16383);
+      }
+    label_1_break:;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (a_dst.private_impl.buf) {
+        a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+      }
+      status = wuffs_lzw__encoder__write_code(self, a_dst, v_code, v_width);
+      if (a_dst.private_impl.buf) {
+        ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+      }
+      if (status) {
+        goto suspend;
+      }
+      v_code = v_literal;
+      if (v_hi >= 4094) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status =
+            wuffs_lzw__encoder__write_code(self, a_dst, v_clear_code, v_width);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+        v_hi = v_end_code;
+        v_width = (v_literal_width + 1);
+        wuffs_lzw__encoder__clear_table(self);
+        goto label_0_continue;
+      }
+      v_hi += 1;
+      if ((v_hi == (((uint32_t)(1)) << v_width)) && (v_width < 12)) {
+        v_width += 1;
+      }
+      self->private_impl.f_htable[v_h] = ((v_key << 12) | v_hi);
+    }
+  label_0_break:;
+    if (v_has_code) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      if (a_dst.private_impl.buf) {
+        a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+      }
+      status = wuffs_lzw__encoder__write_code(self, a_dst, v_code, v_width);
+      if (a_dst.private_impl.buf) {
+        ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+      }
+      if (status) {
+        goto suspend;
+      }
+      if (v_hi >= 4094) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        status =
+            wuffs_lzw__encoder__write_code(self, a_dst, v_clear_code, v_width);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (status) {
+          goto suspend;
+        }
+        v_width = (v_literal_width + 1);
+      } else {
+        v_hi += 1;
+        if ((v_hi == (((uint32_t)(1)) << v_width)) && (v_width < 12)) {
+          v_width += 1;
+        }
+      }
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    if (a_dst.private_impl.buf) {
+      a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+    }
+    status = wuffs_lzw__encoder__write_code(self, a_dst, v_end_code, v_width);
+    if (a_dst.private_impl.buf) {
+      ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    }
+    if (status) {
+      goto suspend;
+    }
+    if (self->private_impl.f_n_bits > 0) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_LZW__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = ((uint8_t)((self->private_impl.f_bits & 255)));
+      self->private_impl.f_bits = 0;
+      self->private_impl.f_n_bits = 0;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode[0].v_literal_width = v_literal_width;
+  self->private_impl.c_encode[0].v_clear_code = v_clear_code;
+  self->private_impl.c_encode[0].v_end_code = v_end_code;
+  self->private_impl.c_encode[0].v_hi = v_hi;
+  self->private_impl.c_encode[0].v_width = v_width;
+  self->private_impl.c_encode[0].v_code = v_code;
+  self->private_impl.c_encode[0].v_has_code = v_has_code;
+  self->private_impl.c_encode[0].v_literal = v_literal;
+  self->private_impl.c_encode[0].v_key = v_key;
+  self->private_impl.c_encode[0].v_h = v_h;
+  self->private_impl.c_encode[0].v_entry = v_entry;
+  self->private_impl.c_encode[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func encoder.fill_buf
+
+static wuffs_lzw__status wuffs_lzw__encoder__fill_buf(wuffs_lzw__encoder* self,
+    wuffs_base__io_reader a_src) {
+  wuffs_lzw__status status = WUFFS_LZW__STATUS_OK;
+
+  wuffs_base__io_writer v_w;
+  wuffs_base__io_buffer u_w;
+  uint8_t* ioptr_w = NULL;
+  uint8_t* iobounds1_w = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_w);
+  uint8_t v_c;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_fill_buf[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_w = ((wuffs_base__io_writer){});
+    v_c = self->private_impl.c_fill_buf[0].v_c;
+  } else {
+    v_w = ((wuffs_base__io_writer){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_buf_ri = 0;
+    self->private_impl.f_buf_wi = 0;
+    if (((uint64_t)(iobounds1_src - ioptr_src)) > 0) {
+      v_w = ((wuffs_base__io_writer){});
+      {
+        wuffs_base__io_reader o_0_a_src = a_src;
+        wuffs_base__io_writer o_0_v_w = v_w;
+        uint8_t* o_0_ioptr_v_w = ioptr_w;
+        uint8_t* o_0_iobounds1_v_w = iobounds1_w;
+        wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_buf,
+            .len = 4096}));
+        wuffs_base__io_writer__copy_from_reader32(&ioptr_w, iobounds1_w,
+            &ioptr_src, iobounds1_src, 4096);
+        self->private_impl.f_buf_wi = (4096 - ((uint32_t)(
+            wuffs_base__u64__min(((uint64_t)(iobounds1_w - ioptr_w)), 4096))));
+        v_w = o_0_v_w;
+        ioptr_w = o_0_ioptr_v_w;
+        iobounds1_w = o_0_iobounds1_v_w;
+        a_src = o_0_a_src;
+      }
+      status = WUFFS_LZW__STATUS_OK;
+      goto ok;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_0 = *ioptr_src++;
+      v_c = t_0;
+    }
+    self->private_impl.f_buf[0] = v_c;
+    self->private_impl.f_buf_wi = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_buf[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_buf[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_buf[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_LZW__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_LZW__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func encoder.clear_table
+
+static void wuffs_lzw__encoder__clear_table(wuffs_lzw__encoder* self) {
+  uint32_t v_i;
+
+  v_i = 0;
+  while (v_i < 16384) {
+    self->private_impl.f_htable[v_i] = 0;
+    v_i += 1;
+  }
+}
+
+// -------- func encoder.write_code
+
+static wuffs_lzw__status wuffs_lzw__encoder__write_code(
+    wuffs_lzw__encoder* self, wuffs_base__io_writer a_dst, uint32_t a_code,
+    uint32_t a_width) {
+  wuffs_lzw__status status = WUFFS_LZW__STATUS_OK;
+
+  uint32_t v_n_bits;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_write_code[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n_bits = self->private_impl.c_write_code[0].v_n_bits;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n_bits = (self->private_impl.f_n_bits + a_width);
+    self->private_impl.f_bits |= (a_code << self->private_impl.f_n_bits);
+    while (v_n_bits >= 8) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_LZW__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = ((uint8_t)((self->private_impl.f_bits & 255)));
+      self->private_impl.f_bits >>= 8;
+      v_n_bits -= 8;
+    }
+    self->private_impl.f_n_bits = v_n_bits;
+
+    goto ok;
+  ok:
+    self->private_impl.c_write_code[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_write_code[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_write_code[0].v_n_bits = v_n_bits;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_lzw__status wuffs_lzw__decoder__save_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_LZW__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_LZW__DECODER__STATE_LENGTH) {
+    return WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_literal_width));
+  p += 4;
+  p[0] = self->private_impl.f_tiff_mode ? 1 : 0;
+  p += 1;
+  for (i0 = 0; i0 < 4096; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_stack[i0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_suffixes[i0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_prefixes[i0]));
+    p += 2;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_literal_width));
+  p += 4;
+  p[0] = self->private_impl.c_decode[0].v_tiff_mode ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_clear_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_end_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_save_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_prev_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_msb_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_s));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_c));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode[0].v_n_copied));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xAA388D54,
+      WUFFS_LZW__DECODER__STATE_LENGTH);
+  return WUFFS_LZW__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_lzw__status wuffs_lzw__decoder__restore_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_LZW__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xAA388D54,
+      WUFFS_LZW__DECODER__STATE_LENGTH)) {
+    return WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  self->private_impl.status = (wuffs_lzw__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_literal_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_literal_width > 8) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_tiff_mode = p[0];
+  p += 1;
+  for (i0 = 0; i0 < 4096; i0++) {
+    self->private_impl.f_stack[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    self->private_impl.f_suffixes[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    self->private_impl.f_prefixes[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+    if (self->private_impl.f_prefixes[i0] > 4095) {
+      goto bad_state;
+    }
+  }
+  self->private_impl.c_decode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode[0].v_literal_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_decode[0].v_tiff_mode = p[0];
+  p += 1;
+  self->private_impl.c_decode[0].v_clear_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_end_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_save_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_prev_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_code = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_msb_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_s = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_c = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_n_copied =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_LZW__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
+
+// -------- func encoder.save_state
+
+wuffs_lzw__status wuffs_lzw__encoder__save_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_LZW__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_LZW__ENCODER__STATE_LENGTH) {
+    return WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_literal_width));
+  p += 4;
+  p[0] = self->private_impl.f_eof ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_bits));
+  p += 4;
+  for (i0 = 0; i0 < 4096; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_buf[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_buf_ri));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_buf_wi));
+  p += 4;
+  for (i0 = 0; i0 < 16384; i0++) {
+    wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_htable[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_literal_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_clear_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_end_code));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_hi));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_code));
+  p += 4;
+  p[0] = self->private_impl.c_encode[0].v_has_code ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_literal));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_key));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_h));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_entry));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_buf[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_fill_buf[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_code[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_write_code[0].v_n_bits));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x66C6B773,
+      WUFFS_LZW__ENCODER__STATE_LENGTH);
+  return WUFFS_LZW__STATUS_OK;
+}
+
+// -------- func encoder.restore_state
+
+wuffs_lzw__status wuffs_lzw__encoder__restore_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_LZW__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x66C6B773,
+      WUFFS_LZW__ENCODER__STATE_LENGTH)) {
+    return WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  self->private_impl.status = (wuffs_lzw__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_literal_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_literal_width > 8) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_eof = p[0];
+  p += 1;
+  self->private_impl.f_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_n_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_bits > 7) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 4096; i0++) {
+    self->private_impl.f_buf[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_buf_ri = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_buf_ri > 4096) {
+    goto bad_state;
+  }
+  self->private_impl.f_buf_wi = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_buf_wi > 4096) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 16384; i0++) {
+    self->private_impl.f_htable[i0] = (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  self->private_impl.c_encode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode[0].v_literal_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_clear_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_end_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_hi = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_code = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_encode[0].v_has_code = p[0];
+  p += 1;
+  self->private_impl.c_encode[0].v_literal =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_key = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_h = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_entry =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_z =
+      (wuffs_lzw__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_fill_buf[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_buf[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_buf[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_write_code[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_write_code[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_write_code[0].v_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_LZW__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_LZW__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
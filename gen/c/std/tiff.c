@@ -0,0 +1,6784 @@
+#ifndef WUFFS_TIFF_H
+#define WUFFS_TIFF_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+#ifndef WUFFS_BASE_HEADER_H
+#define WUFFS_BASE_HEADER_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <stdbool.h>
+#include <stdint.h>
+#include <string.h>
+
+// Wuffs assumes that:
+//  - converting a uint32_t to a size_t will never overflow.
+//  - converting a size_t to a uint64_t will never overflow.
+#if (__WORDSIZE != 32) && (__WORDSIZE != 64)
+#error "Wuffs requires a word size of either 32 or 64 bits"
+#endif
+
+// WUFFS_VERSION is the major.minor version number as a uint32_t. The major
+// number is the high 16 bits. The minor number is the low 16 bits.
+//
+// The intention is to bump the version number at least on every API / ABI
+// backwards incompatible change.
+//
+// For now, the API and ABI are simply unstable and can change at any time.
+//
+// TODO: don't hard code this in base-header.h.
+#define WUFFS_VERSION ((uint32_t)0x00001)
+
+// wuffs_base__empty_struct is used when a Wuffs function returns an empty
+// struct. In C, if a function f returns void, you can't say "x = f()", but in
+// Wuffs, if a function g returns empty, you can say "y = g()".
+typedef struct {
+} wuffs_base__empty_struct;
+
+// ---------------- Numeric Types
+
+// Flicks are a unit of time. One flick (frame-tick) is 1 / 705_600_000 of a
+// second. See https://github.com/OculusVR/Flicks
+typedef int64_t wuffs_base__flicks;
+
+#define WUFFS_BASE__FLICKS_PER_SECOND ((uint64_t)705600000)
+#define WUFFS_BASE__FLICKS_PER_MILLISECOND ((uint64_t)705600)
+
+// --------
+
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint8_t wuffs_base__u8__max(uint8_t x, uint8_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint16_t wuffs_base__u16__min(uint16_t x, uint16_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint16_t wuffs_base__u16__max(uint16_t x, uint16_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint32_t wuffs_base__u32__min(uint32_t x, uint32_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint32_t wuffs_base__u32__max(uint32_t x, uint32_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint64_t wuffs_base__u64__min(uint64_t x, uint64_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint64_t wuffs_base__u64__max(uint64_t x, uint64_t y) {
+  return x > y ? x : y;
+}
+
+// --------
+
+// Saturating arithmetic (sat_add, sat_sub) branchless bit-twiddling algorithms
+// are per https://locklessinc.com/articles/sat_arithmetic/
+//
+// It is important that the underlying types are unsigned integers, as signed
+// integer arithmetic overflow is undefined behavior in C.
+
+static inline uint8_t wuffs_base__u8__sat_add(uint8_t x, uint8_t y) {
+  uint8_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint8_t wuffs_base__u8__sat_sub(uint8_t x, uint8_t y) {
+  uint8_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint16_t wuffs_base__u16__sat_add(uint16_t x, uint16_t y) {
+  uint16_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint16_t wuffs_base__u16__sat_sub(uint16_t x, uint16_t y) {
+  uint16_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint32_t wuffs_base__u32__sat_add(uint32_t x, uint32_t y) {
+  uint32_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint32_t wuffs_base__u32__sat_sub(uint32_t x, uint32_t y) {
+  uint32_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint64_t wuffs_base__u64__sat_add(uint64_t x, uint64_t y) {
+  uint64_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint64_t wuffs_base__u64__sat_sub(uint64_t x, uint64_t y) {
+  uint64_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+// --------
+
+// Clang also defines "__GNUC__".
+
+static inline uint16_t wuffs_base__u16__byte_swapped(uint16_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap16(x);
+#else
+  return (x >> 8) | (x << 8);
+#endif
+}
+
+static inline uint32_t wuffs_base__u32__byte_swapped(uint32_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap32(x);
+#else
+  static const uint32_t mask8 = 0x00FF00FF;
+  x = ((x >> 8) & mask8) | ((x & mask8) << 8);
+  return (x >> 16) | (x << 16);
+#endif
+}
+
+static inline uint64_t wuffs_base__u64__byte_swapped(uint64_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap64(x);
+#else
+  static const uint64_t mask8 = 0x00FF00FF00FF00FF;
+  static const uint64_t mask16 = 0x0000FFFF0000FFFF;
+  x = ((x >> 8) & mask8) | ((x & mask8) << 8);
+  x = ((x >> 16) & mask16) | ((x & mask16) << 16);
+  return (x >> 32) | (x << 32);
+#endif
+}
+
+// ---------------- Slices and Tables
+
+// WUFFS_BASE__SLICE is a 1-dimensional buffer.
+//
+// A value with all fields NULL or zero is a valid, empty slice.
+#define WUFFS_BASE__SLICE(T) \
+  struct {                   \
+    T* ptr;                  \
+    size_t len;              \
+  }
+
+// WUFFS_BASE__TABLE is a 2-dimensional buffer.
+//
+// A value with all fields NULL or zero is a valid, empty table.
+#define WUFFS_BASE__TABLE(T) \
+  struct {                   \
+    T* ptr;                  \
+    size_t width;            \
+    size_t height;           \
+    size_t stride;           \
+  }
+
+typedef WUFFS_BASE__SLICE(uint8_t) wuffs_base__slice_u8;
+typedef WUFFS_BASE__SLICE(uint16_t) wuffs_base__slice_u16;
+typedef WUFFS_BASE__SLICE(uint32_t) wuffs_base__slice_u32;
+typedef WUFFS_BASE__SLICE(uint64_t) wuffs_base__slice_u64;
+
+typedef WUFFS_BASE__TABLE(uint8_t) wuffs_base__table_u8;
+typedef WUFFS_BASE__TABLE(uint16_t) wuffs_base__table_u16;
+typedef WUFFS_BASE__TABLE(uint32_t) wuffs_base__table_u32;
+typedef WUFFS_BASE__TABLE(uint64_t) wuffs_base__table_u64;
+
+// ---------------- Ranges and Rects
+
+// Ranges are either inclusive ("range_ii") or exclusive ("range_ie") on the
+// high end. Both the "ii" and "ie" flavors are useful in practice.
+//
+// The "ei" and "ee" flavors also exist in theory, but aren't widely used. In
+// Wuffs, the low end is always inclusive.
+//
+// The "ii" (closed interval) flavor is useful when refining e.g. "the set of
+// all uint32_t values" to a contiguous subset: "uint32_t values in the closed
+// interval [M, N]", for uint32_t values M and N. An unrefined type (in other
+// words, the set of all uint32_t values) is not representable in the "ie"
+// flavor because if N equals ((1<<32) - 1) then (N + 1) will overflow.
+//
+// On the other hand, the "ie" (half-open interval) flavor is recommended by
+// Dijkstra's "Why numbering should start at zero" at
+// http://www.cs.utexas.edu/users/EWD/ewd08xx/EWD831.PDF and a further
+// discussion of motivating rationale is at
+// https://www.quora.com/Why-are-Python-ranges-half-open-exclusive-instead-of-closed-inclusive
+//
+// For example, with "ie", the number of elements in "uint32_t values in the
+// half-open interval [M, N)" is equal to max(0, N-M). Furthermore, that number
+// of elements (in one dimension, a length, in two dimensions, a width or
+// height) is itself representable as a uint32_t without overflow, again for
+// uint32_t values M and N. In the contrasting "ii" flavor, the length of the
+// closed interval [0, (1<<32) - 1] is 1<<32, which cannot be represented as a
+// uint32_t. In Wuffs, because of this potential overflow, the "ie" flavor has
+// length / width / height methods, but the "ii" flavor does not.
+//
+// It is valid for min > max (for range_ii) or for min >= max (for range_ie),
+// in which case the range is empty. There are multiple representations of an
+// empty range.
+
+typedef struct {
+  uint32_t min_inclusive;
+  uint32_t max_inclusive;
+} wuffs_base__range_ii_u32;
+
+static inline bool wuffs_base__range_ii_u32__is_empty(
+    wuffs_base__range_ii_u32 r) {
+  return r.min_inclusive > r.max_inclusive;
+}
+
+static inline bool wuffs_base__range_ii_u32__equals(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_inclusive == s.max_inclusive) ||
+         (wuffs_base__range_ii_u32__is_empty(r) &&
+          wuffs_base__range_ii_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ii_u32__contains(
+    wuffs_base__range_ii_u32 r,
+    uint32_t x) {
+  return (r.min_inclusive <= x) && (x <= r.max_inclusive);
+}
+
+static inline wuffs_base__range_ii_u32 wuffs_base__range_ii_u32__intersection(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  r.min_inclusive = wuffs_base__u32__max(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u32__min(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ii_u32 wuffs_base__range_ii_u32__union(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  if (wuffs_base__range_ii_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ii_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u32__min(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u32__max(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+// --------
+
+typedef struct {
+  uint32_t min_inclusive;
+  uint32_t max_exclusive;
+} wuffs_base__range_ie_u32;
+
+static inline bool wuffs_base__range_ie_u32__is_empty(
+    wuffs_base__range_ie_u32 r) {
+  return r.min_inclusive >= r.max_exclusive;
+}
+
+static inline bool wuffs_base__range_ie_u32__equals(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_exclusive == s.max_exclusive) ||
+         (wuffs_base__range_ie_u32__is_empty(r) &&
+          wuffs_base__range_ie_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ie_u32__contains(
+    wuffs_base__range_ie_u32 r,
+    uint32_t x) {
+  return (r.min_inclusive <= x) && (x < r.max_exclusive);
+}
+
+static inline wuffs_base__range_ie_u32 wuffs_base__range_ie_u32__intersection(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  r.min_inclusive = wuffs_base__u32__max(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u32__min(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ie_u32 wuffs_base__range_ie_u32__union(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  if (wuffs_base__range_ie_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ie_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u32__min(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u32__max(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline uint32_t wuffs_base__range_ie_u32__length(
+    wuffs_base__range_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive, r.min_inclusive);
+}
+
+// --------
+
+typedef struct {
+  uint64_t min_inclusive;
+  uint64_t max_inclusive;
+} wuffs_base__range_ii_u64;
+
+static inline bool wuffs_base__range_ii_u64__is_empty(
+    wuffs_base__range_ii_u64 r) {
+  return r.min_inclusive > r.max_inclusive;
+}
+
+static inline bool wuffs_base__range_ii_u64__equals(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_inclusive == s.max_inclusive) ||
+         (wuffs_base__range_ii_u64__is_empty(r) &&
+          wuffs_base__range_ii_u64__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ii_u64__contains(
+    wuffs_base__range_ii_u64 r,
+    uint64_t x) {
+  return (r.min_inclusive <= x) && (x <= r.max_inclusive);
+}
+
+static inline wuffs_base__range_ii_u64 wuffs_base__range_ii_u64__intersection(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  r.min_inclusive = wuffs_base__u64__max(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u64__min(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ii_u64 wuffs_base__range_ii_u64__union(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  if (wuffs_base__range_ii_u64__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ii_u64__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u64__min(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u64__max(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+// --------
+
+typedef struct {
+  uint64_t min_inclusive;
+  uint64_t max_exclusive;
+} wuffs_base__range_ie_u64;
+
+static inline bool wuffs_base__range_ie_u64__is_empty(
+    wuffs_base__range_ie_u64 r) {
+  return r.min_inclusive >= r.max_exclusive;
+}
+
+static inline bool wuffs_base__range_ie_u64__equals(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_exclusive == s.max_exclusive) ||
+         (wuffs_base__range_ie_u64__is_empty(r) &&
+          wuffs_base__range_ie_u64__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ie_u64__contains(
+    wuffs_base__range_ie_u64 r,
+    uint64_t x) {
+  return (r.min_inclusive <= x) && (x < r.max_exclusive);
+}
+
+static inline wuffs_base__range_ie_u64 wuffs_base__range_ie_u64__intersection(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  r.min_inclusive = wuffs_base__u64__max(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u64__min(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ie_u64 wuffs_base__range_ie_u64__union(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  if (wuffs_base__range_ie_u64__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ie_u64__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u64__min(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u64__max(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline uint64_t wuffs_base__range_ie_u64__length(
+    wuffs_base__range_ie_u64 r) {
+  return wuffs_base__u64__sat_sub(r.max_exclusive, r.min_inclusive);
+}
+
+// --------
+
+// wuffs_base__rect_ii_u32 is a rectangle (a 2-dimensional range) on the
+// integer grid. The "ii" means that the bounds are inclusive on the low end
+// and inclusive on the high end. It contains all points (x, y) such that
+// ((min_inclusive_x <= x) && (x <= max_inclusive_x)) and likewise for y.
+//
+// It is valid for min > max, in which case the rectangle is empty. There are
+// multiple representations of an empty rectangle.
+//
+// The X and Y axes increase right and down.
+typedef struct {
+  uint32_t min_inclusive_x;
+  uint32_t min_inclusive_y;
+  uint32_t max_inclusive_x;
+  uint32_t max_inclusive_y;
+} wuffs_base__rect_ii_u32;
+
+static inline bool wuffs_base__rect_ii_u32__is_empty(
+    wuffs_base__rect_ii_u32 r) {
+  return (r.min_inclusive_x > r.max_inclusive_x) ||
+         (r.min_inclusive_y > r.max_inclusive_y);
+}
+
+static inline bool wuffs_base__rect_ii_u32__equals(wuffs_base__rect_ii_u32 r,
+                                                   wuffs_base__rect_ii_u32 s) {
+  return (r.min_inclusive_x == s.min_inclusive_x &&
+          r.min_inclusive_y == s.min_inclusive_y &&
+          r.max_inclusive_x == s.max_inclusive_x &&
+          r.max_inclusive_y == s.max_inclusive_y) ||
+         (wuffs_base__rect_ii_u32__is_empty(r) &&
+          wuffs_base__rect_ii_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__rect_ii_u32__contains(wuffs_base__rect_ii_u32 r,
+                                                     uint32_t x,
+                                                     uint32_t y) {
+  return (r.min_inclusive_x <= x) && (x <= r.max_inclusive_x) &&
+         (r.min_inclusive_y <= y) && (y <= r.max_inclusive_y);
+}
+
+static inline wuffs_base__rect_ii_u32 wuffs_base__rect_ii_u32__intersection(
+    wuffs_base__rect_ii_u32 r,
+    wuffs_base__rect_ii_u32 s) {
+  r.min_inclusive_x =
+      wuffs_base__u32__max(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__max(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_inclusive_x =
+      wuffs_base__u32__min(r.max_inclusive_x, s.max_inclusive_x);
+  r.max_inclusive_y =
+      wuffs_base__u32__min(r.max_inclusive_y, s.max_inclusive_y);
+  return r;
+}
+
+static inline wuffs_base__rect_ii_u32 wuffs_base__rect_ii_u32__union(
+    wuffs_base__rect_ii_u32 r,
+    wuffs_base__rect_ii_u32 s) {
+  if (wuffs_base__rect_ii_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__rect_ii_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive_x =
+      wuffs_base__u32__min(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__min(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_inclusive_x =
+      wuffs_base__u32__max(r.max_inclusive_x, s.max_inclusive_x);
+  r.max_inclusive_y =
+      wuffs_base__u32__max(r.max_inclusive_y, s.max_inclusive_y);
+  return r;
+}
+
+// --------
+
+// wuffs_base__rect_ie_u32 is a rectangle (a 2-dimensional range) on the
+// integer grid. The "ie" means that the bounds are inclusive on the low end
+// and exclusive on the high end. It contains all points (x, y) such that
+// ((min_inclusive_x <= x) && (x < max_exclusive_x)) and likewise for y.
+//
+// It is valid for min >= max, in which case the rectangle is empty. There are
+// multiple representations of an empty rectangle, including a value with all
+// fields zero.
+//
+// The X and Y axes increase right and down.
+typedef struct {
+  uint32_t min_inclusive_x;
+  uint32_t min_inclusive_y;
+  uint32_t max_exclusive_x;
+  uint32_t max_exclusive_y;
+} wuffs_base__rect_ie_u32;
+
+static inline bool wuffs_base__rect_ie_u32__is_empty(
+    wuffs_base__rect_ie_u32 r) {
+  return (r.min_inclusive_x >= r.max_exclusive_x) ||
+         (r.min_inclusive_y >= r.max_exclusive_y);
+}
+
+static inline bool wuffs_base__rect_ie_u32__equals(wuffs_base__rect_ie_u32 r,
+                                                   wuffs_base__rect_ie_u32 s) {
+  return (r.min_inclusive_x == s.min_inclusive_x &&
+          r.min_inclusive_y == s.min_inclusive_y &&
+          r.max_exclusive_x == s.max_exclusive_x &&
+          r.max_exclusive_y == s.max_exclusive_y) ||
+         (wuffs_base__rect_ie_u32__is_empty(r) &&
+          wuffs_base__rect_ie_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__rect_ie_u32__contains(wuffs_base__rect_ie_u32 r,
+                                                     uint32_t x,
+                                                     uint32_t y) {
+  return (r.min_inclusive_x <= x) && (x < r.max_exclusive_x) &&
+         (r.min_inclusive_y <= y) && (y < r.max_exclusive_y);
+}
+
+static inline wuffs_base__rect_ie_u32 wuffs_base__rect_ie_u32__intersection(
+    wuffs_base__rect_ie_u32 r,
+    wuffs_base__rect_ie_u32 s) {
+  r.min_inclusive_x =
+      wuffs_base__u32__max(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__max(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_exclusive_x =
+      wuffs_base__u32__min(r.max_exclusive_x, s.max_exclusive_x);
+  r.max_exclusive_y =
+      wuffs_base__u32__min(r.max_exclusive_y, s.max_exclusive_y);
+  return r;
+}
+
+static inline wuffs_base__rect_ie_u32 wuffs_base__rect_ie_u32__union(
+    wuffs_base__rect_ie_u32 r,
+    wuffs_base__rect_ie_u32 s) {
+  if (wuffs_base__rect_ie_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__rect_ie_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive_x =
+      wuffs_base__u32__min(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__min(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_exclusive_x =
+      wuffs_base__u32__max(r.max_exclusive_x, s.max_exclusive_x);
+  r.max_exclusive_y =
+      wuffs_base__u32__max(r.max_exclusive_y, s.max_exclusive_y);
+  return r;
+}
+
+static inline uint32_t wuffs_base__rect_ie_u32__width(
+    wuffs_base__rect_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive_x, r.min_inclusive_x);
+}
+
+static inline uint32_t wuffs_base__rect_ie_u32__height(
+    wuffs_base__rect_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive_y, r.min_inclusive_y);
+}
+
+// ---------------- I/O
+
+// wuffs_base__io_buffer is a 1-dimensional buffer (a pointer and length), plus
+// additional indexes into that buffer, plus an opened / closed flag.
+//
+// A value with all fields NULL or zero is a valid, empty buffer.
+typedef struct {
+  uint8_t* ptr;  // Pointer.
+  size_t len;    // Length.
+  size_t wi;     // Write index. Invariant: wi <= len.
+  size_t ri;     // Read  index. Invariant: ri <= wi.
+  bool closed;   // No further writes are expected.
+} wuffs_base__io_buffer;
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__io_buffer* buf;
+    // The bounds values are typically NULL, when created by the Wuffs public
+    // API. NULL means that the callee substitutes the implicit bounds derived
+    // from buf.
+    uint8_t* bounds[2];
+  } private_impl;
+} wuffs_base__io_reader;
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__io_buffer* buf;
+    // The bounds values are typically NULL, when created by the Wuffs public
+    // API. NULL means that the callee substitutes the implicit bounds derived
+    // from buf.
+    uint8_t* bounds[2];
+  } private_impl;
+} wuffs_base__io_writer;
+
+static inline wuffs_base__io_reader wuffs_base__io_buffer__reader(
+    wuffs_base__io_buffer* buf) {
+  wuffs_base__io_reader ret = ((wuffs_base__io_reader){});
+  ret.private_impl.buf = buf;
+  return ret;
+}
+
+static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
+    wuffs_base__io_buffer* buf) {
+  wuffs_base__io_writer ret = ((wuffs_base__io_writer){});
+  ret.private_impl.buf = buf;
+  return ret;
+}
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+// ---------------- Images
+
+// wuffs_base__pixel_format encodes the format of the bytes that constitute an
+// image frame's pixel data. Its bits:
+//  - bit        31  is reserved.
+//  - bits 30 .. 28 encodes color (and channel order, in terms of memory).
+//  - bits 27 .. 26 are reserved.
+//  - bits 25 .. 24 encodes transparency.
+//  - bit        23 indicates big-endian/MSB-first (as opposed to little/LSB).
+//  - bit        22 indicates floating point (as opposed to integer).
+//  - bits 21 .. 20 are the number of planes, minus 1. Zero means packed.
+//  - bits 19 .. 16 encodes the number of bits (depth) in an index value.
+//                  Zero means direct, not palette-indexed.
+//  - bits 15 .. 12 encodes the number of bits (depth) in the 3rd channel.
+//  - bits 11 ..  8 encodes the number of bits (depth) in the 2nd channel.
+//  - bits  7 ..  4 encodes the number of bits (depth) in the 1st channel.
+//  - bits  3 ..  0 encodes the number of bits (depth) in the 0th channel.
+//
+// The bit fields of a wuffs_base__pixel_format are not independent. For
+// example, the number of planes should not be greater than the number of
+// channels. Similarly, bits 15..4 are unused (and should be zero) if bits
+// 31..24 (color and transparency) together imply only 1 channel (gray, no
+// alpha) and floating point samples should mean a bit depth of 16, 32 or 64.
+//
+// Formats hold between 1 and 4 channels. For example: Y (1 channel: gray), YA
+// (2 channels: gray and alpha), BGR (3 channels: blue, green, red) or CMYK (4
+// channels: cyan, magenta, yellow, black).
+//
+// For direct formats with N > 1 channels, those channels can be laid out in
+// either 1 (packed) or N (planar) planes. For example, RGBA data is usually
+// packed, but YUV data is usually planar, due to chroma subsampling (for
+// details, see the wuffs_base__pixel_subsampling type). For indexed formats,
+// the palette (always 256 × 4 bytes) holds up to 4 packed bytes of color data
+// per index value, and there is only 1 plane (for the index). The distance
+// between successive palette elements is always 4 bytes.
+//
+// The color field is encoded in 3 bits:
+//  - 0 means                 A (Alpha).
+//  - 1 means   Y       or   YA (Gray, Alpha).
+//  - 2 means BGR, BGRX or BGRA (Blue, Green, Red, X-padding or Alpha).
+//  - 3 means RGB, RGBX or RGBA (Red, Green, Blue, X-padding or Alpha).
+//  - 4 means YUV       or YUVA (Luma, Chroma-blue, Chroma-red, Alpha).
+//  - 5 means CMY       or CMYK (Cyan, Magenta, Yellow, Black).
+//  - all other values are reserved.
+//
+// In Wuffs, channels are given in memory order, regardless of endianness,
+// since the C type for the pixel data is an array of bytes, not an array of
+// uint32_t. For example, packed BGRA with 8 bits per channel means that the
+// bytes in memory are always Blue, Green, Red then Alpha. On big-endian
+// systems, that is the uint32_t 0xBBGGRRAA. On little-endian, 0xAARRGGBB.
+//
+// When the color field (3 bits) encodes multiple options, the transparency
+// field (2 bits) distinguishes them:
+//  - 0 means fully opaque, no extra channels
+//  - 1 means fully opaque, one extra channel (X or K, padding or black).
+//  - 2 means one extra alpha channel, other channels are non-premultiplied.
+//  - 3 means one extra alpha channel, other channels are     premultiplied.
+//
+// The zero wuffs_base__pixel_format value is an invalid pixel format, as it is
+// invalid to combine the zero color (alpha only) with the zero transparency.
+//
+// Bit depth is encoded in 4 bits:
+//  -  0 means the channel or index is unused.
+//  -  x means a bit depth of  x, for x in the range 1..8.
+//  -  9 means a bit depth of 10.
+//  - 10 means a bit depth of 12.
+//  - 11 means a bit depth of 16.
+//  - 12 means a bit depth of 24.
+//  - 13 means a bit depth of 32.
+//  - 14 means a bit depth of 48.
+//  - 15 means a bit depth of 64.
+//
+// For example, wuffs_base__pixel_format 0x3280BBBB is a natural format for
+// decoding a PNG image - network byte order (also known as big-endian),
+// packed, non-premultiplied alpha - that happens to be 16-bit-depth truecolor
+// with alpha (RGBA). In memory order:
+//
+//  ptr+0  ptr+1  ptr+2  ptr+3  ptr+4  ptr+5  ptr+6  ptr+7
+//  Rhi    Rlo    Ghi    Glo    Bhi    Blo    Ahi    Alo
+//
+// For example, the value wuffs_base__pixel_format 0x20000565 means BGR with no
+// alpha or padding, 5/6/5 bits for blue/green/red, packed 2 bytes per pixel,
+// laid out LSB-first in memory order:
+//
+//  ptr+0...........  ptr+1...........
+//  MSB          LSB  MSB          LSB
+//  G₂G₁G₀B₄B₃B₂B₁B₀  R₄R₃R₂R₁R₀G₅G₄G₃
+//
+// On little-endian systems (but not big-endian), this Wuffs pixel format value
+// (0x20000565) corresponds to the Cairo library's CAIRO_FORMAT_RGB16_565, the
+// SDL2 (Simple DirectMedia Layer 2) library's SDL_PIXELFORMAT_RGB565 and the
+// Skia library's kRGB_565_SkColorType. Note BGR in Wuffs versus RGB in the
+// other libraries.
+//
+// Regardless of endianness, this Wuffs pixel format value (0x20000565)
+// corresponds to the V4L2 (Video For Linux 2) library's V4L2_PIX_FMT_RGB565
+// and the Wayland-DRM library's WL_DRM_FORMAT_RGB565.
+//
+// Different software libraries name their pixel formats (and especially their
+// channel order) either according to memory layout or as bits of a native
+// integer type like uint32_t. The two conventions differ because of a system's
+// endianness. As mentioned earlier, Wuffs pixel formats are always in memory
+// order. More detail of other software libraries' naming conventions is in the
+// Pixel Format Guide at https://afrantzis.github.io/pixel-format-guide/
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_base__pixel_format__num_planes instead.
+typedef uint32_t wuffs_base__pixel_format;
+
+// Common 8-bit-depth pixel formats. This list is not exhaustive; not all valid
+// wuffs_base__pixel_format values are present.
+
+#define WUFFS_BASE__PIXEL_FORMAT__INVALID ((wuffs_base__pixel_format)0x00000000)
+
+#define WUFFS_BASE__PIXEL_FORMAT__A ((wuffs_base__pixel_format)0x02000008)
+
+#define WUFFS_BASE__PIXEL_FORMAT__Y ((wuffs_base__pixel_format)0x10000008)
+#define WUFFS_BASE__PIXEL_FORMAT__YA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x12000008)
+#define WUFFS_BASE__PIXEL_FORMAT__YA_PREMUL \
+  ((wuffs_base__pixel_format)0x13000008)
+
+#define WUFFS_BASE__PIXEL_FORMAT__BGR ((wuffs_base__pixel_format)0x20000888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRX ((wuffs_base__pixel_format)0x21008888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRX_INDEXED \
+  ((wuffs_base__pixel_format)0x21088888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x22008888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL_INDEXED \
+  ((wuffs_base__pixel_format)0x22088888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_PREMUL \
+  ((wuffs_base__pixel_format)0x23008888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__RGB ((wuffs_base__pixel_format)0x30000888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBX ((wuffs_base__pixel_format)0x31008888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBX_INDEXED \
+  ((wuffs_base__pixel_format)0x31088888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x32008888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL_INDEXED \
+  ((wuffs_base__pixel_format)0x32088888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_PREMUL \
+  ((wuffs_base__pixel_format)0x33008888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__YUV ((wuffs_base__pixel_format)0x40200888)
+#define WUFFS_BASE__PIXEL_FORMAT__YUVK ((wuffs_base__pixel_format)0x41308888)
+#define WUFFS_BASE__PIXEL_FORMAT__YUVA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x42308888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__CMY ((wuffs_base__pixel_format)0x50200888)
+#define WUFFS_BASE__PIXEL_FORMAT__CMYK ((wuffs_base__pixel_format)0x51308888)
+
+static inline bool wuffs_base__pixel_format__is_valid(
+    wuffs_base__pixel_format f) {
+  return f != 0;
+}
+
+static inline bool wuffs_base__pixel_format__is_indexed(
+    wuffs_base__pixel_format f) {
+  return ((f >> 16) & 0x0F) != 0;
+}
+
+#define WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX 4
+
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    wuffs_base__pixel_format f) {
+  return f ? (((f >> 20) & 0x03) + 1) : 0;
+}
+
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
+typedef struct {
+  wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
+} wuffs_base__pixel_buffer;
+
+// --------
+
+// wuffs_base__pixel_subsampling encodes the mapping of pixel space coordinates
+// (x, y) to pixel buffer indices (i, j). That mapping can differ for each
+// plane p. For a depth of 8 bits (1 byte), the p'th plane's sample starts at
+// (planes[p].ptr + (j * planes[p].stride) + i).
+//
+// For packed pixel formats, the mapping is trivial: i = x and j = y. For
+// planar pixel formats, the mapping can differ due to chroma subsampling. For
+// example, consider a three plane YUV pixel format with 4:2:2 subsampling. For
+// the luma (Y) channel, there is one sample for every pixel, but for the
+// chroma (U, V) channels, there is one sample for every two pixels: pairs of
+// horizontally adjacent pixels form one macropixel, i = x / 2 and j == y. In
+// general, for a given p:
+//  - i = (x + bias_x) >> shift_x.
+//  - j = (y + bias_y) >> shift_y.
+// where biases and shifts are in the range 0..3 and 0..2 respectively.
+//
+// In general, the biases will be zero after decoding an image. However, making
+// a sub-image may change the bias, since the (x, y) coordinates are relative
+// to the sub-image's top-left origin, but the backing pixel buffers were
+// created relative to the original image's origin.
+//
+// For each plane p, each of those four numbers (biases and shifts) are encoded
+// in two bits, which combine to form an 8 bit unsigned integer:
+//
+//  e_p = (bias_x << 6) | (shift_x << 4) | (bias_y << 2) | (shift_y << 0)
+//
+// Those e_p values (e_0 for the first plane, e_1 for the second plane, etc)
+// combine to form a wuffs_base__pixel_subsampling value:
+//
+//  pixsub = (e_3 << 24) | (e_2 << 16) | (e_1 << 8) | (e_0 << 0)
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_base__pixel_subsampling__bias_x instead.
+typedef uint32_t wuffs_base__pixel_subsampling;
+
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__NONE ((wuffs_base__pixel_subsampling)0)
+
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__444 \
+  ((wuffs_base__pixel_subsampling)0x000000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__440 \
+  ((wuffs_base__pixel_subsampling)0x010100)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__422 \
+  ((wuffs_base__pixel_subsampling)0x101000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__420 \
+  ((wuffs_base__pixel_subsampling)0x111100)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__411 \
+  ((wuffs_base__pixel_subsampling)0x202000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__410 \
+  ((wuffs_base__pixel_subsampling)0x212100)
+
+static inline uint32_t wuffs_base__pixel_subsampling__bias_x(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 6;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__shift_x(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 4;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__bias_y(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 2;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__shift_y(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 0;
+  return (s >> shift) & 0x03;
+}
+
+// --------
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__pixel_format pixfmt;
+    wuffs_base__pixel_subsampling pixsub;
+    uint32_t width;
+    uint32_t height;
+    uint32_t num_loops;
+  } private_impl;
+} wuffs_base__image_config;
+
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
+// TODO: Should this function return bool? An error type?
+static inline void wuffs_base__image_config__initialize(
+    wuffs_base__image_config* c,
+    wuffs_base__pixel_format pixfmt,
+    wuffs_base__pixel_subsampling pixsub,
+    uint32_t width,
+    uint32_t height,
+    uint32_t num_loops) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
+    }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline void wuffs_base__image_config__invalidate(
+    wuffs_base__image_config* c) {
+  if (c) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline bool wuffs_base__image_config__is_valid(
+    wuffs_base__image_config* c) {
+  return c && c->private_impl.pixfmt;
+}
+
+static inline wuffs_base__pixel_format wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.pixfmt : 0;
+}
+
+static inline wuffs_base__pixel_subsampling
+wuffs_base__image_config__pixel_subsampling(wuffs_base__image_config* c) {
+  return c ? c->private_impl.pixsub : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__width(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.width : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__height(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.height : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__num_loops(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.num_loops : 0;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  if (c) {
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
+  }
+  return 0;
+}
+
+// --------
+
+// wuffs_base__animation_disposal encodes, for an animated image, how to
+// dispose of a frame after displaying it:
+//  - None means to draw the next frame on top of this one.
+//  - Restore Background means to clear the frame's dirty rectangle to "the
+//    background color" (in practice, this means transparent black) before
+//    drawing the next frame.
+//  - Restore Previous means to undo the current frame, so that the next frame
+//    is drawn on top of the previous one.
+typedef uint8_t wuffs_base__animation_disposal;
+
+#define WUFFS_BASE__ANIMATION_DISPOSAL__NONE ((wuffs_base__animation_disposal)0)
+#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_BACKGROUND \
+  ((wuffs_base__animation_disposal)1)
+#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_PREVIOUS \
+  ((wuffs_base__animation_disposal)2)
+
+// --------
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__image_config config;
+    uint32_t loop_count;  // 0-based count of the current loop.
+    wuffs_base__pixel_buffer pixbuf;
+    // TODO: color spaces.
+    wuffs_base__rect_ie_u32 dirty_rect;
+    wuffs_base__flicks duration;
+    bool blend;
+    wuffs_base__animation_disposal disposal;
+    bool palette_changed;
+    uint8_t palette[1024];
+  } private_impl;
+} wuffs_base__image_buffer;
+
+static inline void wuffs_base__image_buffer__set_from_pixbuf(
+    wuffs_base__image_buffer* b,
+    wuffs_base__image_config config,
+    wuffs_base__pixel_buffer pixbuf) {
+  if (!b) {
+    return;
+  }
+  *b = ((wuffs_base__image_buffer){});
+  b->private_impl.config = config;
+  b->private_impl.pixbuf = pixbuf;
+}
+
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
+// TODO: Should this function return bool? An error type?
+static inline void wuffs_base__image_buffer__set_from_slice(
+    wuffs_base__image_buffer* b,
+    wuffs_base__image_config config,
+    wuffs_base__slice_u8 pixbuf_memory) {
+  if (!b) {
+    return;
+  }
+  *b = ((wuffs_base__image_buffer){});
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
+    return;
+  }
+  b->private_impl.config = config;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
+}
+
+// The palette argument is ignored unless its length is exactly 1024.
+static inline void wuffs_base__image_buffer__update(
+    wuffs_base__image_buffer* b,
+    wuffs_base__rect_ie_u32 dirty_rect,
+    wuffs_base__flicks duration,
+    bool blend,
+    wuffs_base__animation_disposal disposal,
+    wuffs_base__slice_u8 palette) {
+  if (!b) {
+    return;
+  }
+
+  // Clip the dirty_rect to the image bounds.
+  dirty_rect.max_exclusive_x = wuffs_base__u32__min(
+      dirty_rect.max_exclusive_x, b->private_impl.config.private_impl.width);
+  dirty_rect.max_exclusive_y = wuffs_base__u32__min(
+      dirty_rect.max_exclusive_y, b->private_impl.config.private_impl.height);
+  b->private_impl.dirty_rect = dirty_rect;
+
+  b->private_impl.duration = duration;
+  b->private_impl.blend = blend;
+  b->private_impl.disposal = disposal;
+  b->private_impl.palette_changed = palette.ptr && (palette.len == 1024);
+  if (b->private_impl.palette_changed) {
+    memmove(b->private_impl.palette, palette.ptr, 1024);
+  }
+}
+
+// wuffs_base__image_buffer__loop returns whether the image decoder should loop
+// back to the beginning of the animation, assuming that we've reached the end
+// of the encoded stream. If so, it increments b's count of the animation loops
+// played so far.
+static inline bool wuffs_base__image_buffer__loop(wuffs_base__image_buffer* b) {
+  if (!b) {
+    return false;
+  }
+  uint32_t n = b->private_impl.config.private_impl.num_loops;
+  if (n == 0) {
+    return true;
+  }
+  if (b->private_impl.loop_count < n - 1) {
+    b->private_impl.loop_count++;
+    return true;
+  }
+  return false;
+}
+
+// wuffs_base__image_config returns the overall configuration for this frame.
+static inline wuffs_base__image_config* wuffs_base__image_buffer__image_config(
+    wuffs_base__image_buffer* b) {
+  return b ? &b->private_impl.config : NULL;
+}
+
+// wuffs_base__image_buffer__dirty_rect returns an upper bound for what part of
+// this frame's pixels differs from the previous frame.
+static inline wuffs_base__rect_ie_u32 wuffs_base__image_buffer__dirty_rect(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.dirty_rect : ((wuffs_base__rect_ie_u32){0});
+}
+
+// wuffs_base__image_buffer__duration returns the amount of time to display
+// this frame. Zero means to display forever - a still (non-animated) image.
+static inline wuffs_base__flicks wuffs_base__image_buffer__duration(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.duration : 0;
+}
+
+// wuffs_base__image_buffer__blend returns, for a transparent image, whether to
+// blend this frame with the existing canvas.
+//
+// In Porter-Duff compositing operator terminology, false means "src" and true
+// means "src over dst".
+static inline bool wuffs_base__image_buffer__blend(
+    wuffs_base__image_buffer* b) {
+  return b && b->private_impl.blend;
+}
+
+// wuffs_base__image_buffer__disposal returns, for an animated image, how to
+// dispose of this frame after displaying it.
+static inline wuffs_base__animation_disposal wuffs_base__image_buffer__disposal(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.disposal : 0;
+}
+
+// wuffs_base__image_buffer__palette_changed returns whether this frame's
+// palette differs from the previous frame. It is conservative and may return
+// false positives (but never false negatives).
+static inline bool wuffs_base__image_buffer__palette_changed(
+    wuffs_base__image_buffer* b) {
+  return b && b->private_impl.palette_changed;
+}
+
+// wuffs_base__image_buffer__palette returns the palette that the pixel data
+// can index. The backing array is inside b and has length 1024.
+static inline wuffs_base__slice_u8 wuffs_base__image_buffer__palette(
+    wuffs_base__image_buffer* b) {
+  return b ? ((wuffs_base__slice_u8){.ptr = b->private_impl.palette,
+                                     .len = 1024})
+           : ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
+    wuffs_base__image_buffer* b,
+    uint32_t p) {
+  return (b && (p < WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX))
+             ? b->private_impl.pixbuf.planes[p]
+             : ((wuffs_base__table_u8){});
+}
+
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
+#endif  // WUFFS_BASE_HEADER_H
+
+// ---------------- Use Declarations
+
+// ---------------- BEGIN USE "std/lzw"
+
+#ifndef WUFFS_LZW_H
+#define WUFFS_LZW_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+
+// ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_lzw__status__is_error instead.
+typedef int32_t wuffs_lzw__status;
+
+#define wuffs_lzw__packageid 1316776  // 0x001417A8
+
+#define WUFFS_LZW__STATUS_OK 0  // 0x00000000
+#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_LZW__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_LZW__ERROR_BAD_CODE -799105024  // 0xD05EA000
+#define WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN -799105023  // 0xD05EA001
+#define WUFFS_LZW__ERROR_BAD_LITERAL -799105022  // 0xD05EA002
+
+bool wuffs_lzw__status__is_error(wuffs_lzw__status s);
+
+const char* wuffs_lzw__status__string(wuffs_lzw__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_lzw__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_lzw__status status;
+    uint32_t magic;
+
+    uint32_t f_literal_width;
+    bool f_tiff_mode;
+    uint8_t f_stack[4096];
+    uint8_t f_suffixes[4096];
+    uint16_t f_prefixes[4096];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_literal_width;
+      bool v_tiff_mode;
+      uint32_t v_clear_code;
+      uint32_t v_end_code;
+      uint32_t v_save_code;
+      uint32_t v_prev_code;
+      uint32_t v_width;
+      uint32_t v_bits;
+      uint32_t v_n_bits;
+      uint32_t v_code;
+      uint32_t v_msb_bits;
+      uint32_t v_s;
+      uint32_t v_c;
+      uint64_t v_n_copied;
+    } c_decode[1];
+  } private_impl;
+} wuffs_lzw__decoder;
+
+// WUFFS_LZW__DECODER__STATE_LENGTH is the length of a wuffs_lzw__decoder's
+// saved state.
+#define WUFFS_LZW__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 16454)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_lzw__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_lzw__status status;
+    uint32_t magic;
+
+    uint32_t f_literal_width;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_buf[4096];
+    uint32_t f_buf_ri;
+    uint32_t f_buf_wi;
+    uint32_t f_htable[16384];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_literal_width;
+      uint32_t v_clear_code;
+      uint32_t v_end_code;
+      uint32_t v_hi;
+      uint32_t v_width;
+      uint32_t v_code;
+      bool v_has_code;
+      uint32_t v_literal;
+      uint32_t v_key;
+      uint32_t v_h;
+      uint32_t v_entry;
+      wuffs_lzw__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_buf[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_code[1];
+  } private_impl;
+} wuffs_lzw__encoder;
+
+// WUFFS_LZW__ENCODER__STATE_LENGTH is the length of a wuffs_lzw__encoder's
+// saved state.
+#define WUFFS_LZW__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 69719)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_lzw__decoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_lzw__decoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__decoder returns sizeof(wuffs_lzw__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__decoder(void);
+
+// wuffs_lzw__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_lzw__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_lzw__encoder__check_wuffs_version(wuffs_lzw__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__encoder returns sizeof(wuffs_lzw__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_lzw__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__decoder__save_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__decoder__restore_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_lzw__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__encoder__save_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__encoder__restore_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
+    uint32_t a_lw);
+
+void wuffs_lzw__decoder__set_tiff_mode(wuffs_lzw__decoder* self, bool a_m);
+
+wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_lzw__encoder__set_literal_width(wuffs_lzw__encoder* self,
+    uint32_t a_lw);
+
+wuffs_lzw__status wuffs_lzw__encoder__encode(wuffs_lzw__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace lzw {
+
+// status is a strongly typed wuffs_lzw__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_LZW__STATUS_OK) {}
+  constexpr explicit status(wuffs_lzw__status repr) : repr_(repr) {}
+
+  constexpr wuffs_lzw__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_LZW__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_lzw__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_lzw__status repr_;
+};
+
+constexpr status status_ok(WUFFS_LZW__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_LZW__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_LZW__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_LZW__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_LZW__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_LZW__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_LZW__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_LZW__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_LZW__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_LZW__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_code(WUFFS_LZW__ERROR_BAD_CODE);
+constexpr status error_cyclical_prefix_chain(
+    WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN);
+constexpr status error_bad_literal(WUFFS_LZW__ERROR_BAD_LITERAL);
+
+// decoder is an RAII wrapper for a wuffs_lzw__decoder. Its constructor
+// calls wuffs_lzw__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_lzw__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__decoder__save_state and
+  // wuffs_lzw__decoder__restore_state. A saved state is
+  // WUFFS_LZW__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__decoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__decoder__set_literal_width(&c_, lw);
+  }
+
+  void set_tiff_mode(bool m) {
+    wuffs_lzw__decoder__set_tiff_mode(&c_, m);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_lzw__encoder. Its constructor
+// calls wuffs_lzw__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_lzw__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__encoder__save_state and
+  // wuffs_lzw__encoder__restore_state. A saved state is
+  // WUFFS_LZW__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__encoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__encoder__set_literal_width(&c_, lw);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__encoder c_;
+};
+
+}  // namespace lzw
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_LZW_H
+
+// ---------------- END   USE "std/lzw"
+
+// ---------------- BEGIN USE "std/zlib"
+
+#ifndef WUFFS_ZLIB_H
+#define WUFFS_ZLIB_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+
+// ---------------- Use Declarations
+
+// ---------------- BEGIN USE "std/adler32"
+
+#ifndef WUFFS_ADLER32_H
+#define WUFFS_ADLER32_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+
+// ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_adler32__status__is_error instead.
+typedef int32_t wuffs_adler32__status;
+
+#define wuffs_adler32__packageid 681002  // 0x000A642A
+
+#define WUFFS_ADLER32__STATUS_OK 0  // 0x00000000
+#define WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_ADLER32__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_ADLER32__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_ADLER32__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_ADLER32__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_ADLER32__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_ADLER32__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+bool wuffs_adler32__status__is_error(wuffs_adler32__status s);
+
+const char* wuffs_adler32__status__string(wuffs_adler32__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_adler32__hasher__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_adler32__status status;
+    uint32_t magic;
+
+    uint32_t f_state;
+    bool f_started;
+  } private_impl;
+} wuffs_adler32__hasher;
+
+// WUFFS_ADLER32__HASHER__STATE_LENGTH is the length of a
+// wuffs_adler32__hasher's saved state.
+#define WUFFS_ADLER32__HASHER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 9)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_adler32__hasher__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_adler32__hasher__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_adler32__hasher__check_wuffs_version(wuffs_adler32__hasher* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_adler32__hasher returns sizeof(wuffs_adler32__hasher), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_adler32__hasher(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_adler32__hasher__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ADLER32__HASHER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_adler32__hasher__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_adler32__status wuffs_adler32__hasher__save_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_adler32__hasher__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_adler32__hasher__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_adler32__status wuffs_adler32__hasher__restore_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
+    wuffs_base__slice_u8 a_x);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace adler32 {
+
+// status is a strongly typed wuffs_adler32__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_ADLER32__STATUS_OK) {}
+  constexpr explicit status(wuffs_adler32__status repr) : repr_(repr) {}
+
+  constexpr wuffs_adler32__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_ADLER32__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_adler32__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_adler32__status repr_;
+};
+
+constexpr status status_ok(WUFFS_ADLER32__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_ADLER32__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_ADLER32__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_ADLER32__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_ADLER32__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_ADLER32__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_ADLER32__SUSPENSION_END_OF_DATA);
+
+// hasher is an RAII wrapper for a wuffs_adler32__hasher. Its constructor
+// calls wuffs_adler32__hasher__check_wuffs_version.
+class hasher {
+ public:
+  hasher() : c_() {
+    wuffs_adler32__hasher__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  hasher(const hasher&) = delete;
+  hasher& operator=(const hasher&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_adler32__hasher* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_adler32__hasher__save_state and
+  // wuffs_adler32__hasher__restore_state. A saved state is
+  // WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_adler32__hasher__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_adler32__hasher__restore_state(&c_, src));
+  }
+
+  uint32_t update(wuffs_base__slice_u8 x) {
+    return wuffs_adler32__hasher__update(&c_, x);
+  }
+
+ private:
+  wuffs_adler32__hasher c_;
+};
+
+}  // namespace adler32
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_ADLER32_H
+
+// ---------------- END   USE "std/adler32"
+
+// ---------------- BEGIN USE "std/deflate"
+
+#ifndef WUFFS_DEFLATE_H
+#define WUFFS_DEFLATE_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+
+// ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_deflate__status__is_error instead.
+typedef int32_t wuffs_deflate__status;
+
+#define wuffs_deflate__packageid 848533  // 0x000CF295
+
+#define WUFFS_DEFLATE__STATUS_OK 0  // 0x00000000
+#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED -1278585856  // 0xB3CA5400
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED -1278585855  // 0xB3CA5401
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT -1278585854  // 0xB3CA5402
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION -1278585853  // 0xB3CA5403
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE -1278585852  // 0xB3CA5404
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH -1278585851  // 0xB3CA5405
+#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850  // 0xB3CA5406
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849  // 0xB3CA5407
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT -1278585848  // 0xB3CA5408
+#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT -1278585847  // 0xB3CA5409
+#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH -1278585846  // 0xB3CA540A
+#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE -1278585845  // 0xB3CA540B
+#define WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES -1278585844  // 0xB3CA540C
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE -1278585843  // 0xB3CA540D
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK -1278585842  // 0xB3CA540E
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE -1278585841  // 0xB3CA540F
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE -1278585839  // 0xB3CA5411
+
+bool wuffs_deflate__status__is_error(wuffs_deflate__status s);
+
+const char* wuffs_deflate__status__string(wuffs_deflate__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint32_t f_huffs[2][1234];
+    uint32_t f_n_huffs_bits[2];
+    uint8_t f_history[32768];
+    uint32_t f_history_index;
+    uint8_t f_code_lengths[320];
+    bool f_end_of_block;
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_decode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_final;
+      uint32_t v_type;
+    } c_decode_blocks[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint64_t scratch;
+    } c_decode_uncompressed[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+    } c_init_fixed_huffman[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_bits;
+      uint32_t v_n_bits;
+      uint32_t v_n_lit;
+      uint32_t v_n_dist;
+      uint32_t v_n_clen;
+      uint32_t v_i;
+      uint32_t v_mask;
+      uint32_t v_table_entry;
+      uint32_t v_table_entry_n_bits;
+      uint32_t v_n_extra_bits;
+      uint8_t v_rep_symbol;
+      uint32_t v_rep_count;
+    } c_init_dynamic_huffman[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_bits;
+      uint32_t v_n_bits;
+      uint32_t v_table_entry;
+      uint32_t v_table_entry_n_bits;
+      uint32_t v_lmask;
+      uint32_t v_dmask;
+      uint32_t v_redir_top;
+      uint32_t v_redir_mask;
+      uint32_t v_length;
+      uint32_t v_dist_minus_1;
+      uint32_t v_n_copied;
+      uint32_t v_hlen;
+      uint32_t v_hdist;
+    } c_decode_huffman_slow[1];
+  } private_impl;
+} wuffs_deflate__decoder;
+
+// WUFFS_DEFLATE__DECODER__STATE_LENGTH is the length of a
+// wuffs_deflate__decoder's saved state.
+#define WUFFS_DEFLATE__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 43138)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_level;
+    bool f_level_set;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_window[65536];
+    uint32_t f_w_pos;
+    uint32_t f_w_end;
+    uint32_t f_block_start;
+    uint16_t f_head[32768];
+    uint16_t f_prev[32768];
+    uint32_t f_ins_pos;
+    uint32_t f_tokens[16384];
+    uint32_t f_n_tokens;
+    uint32_t f_freqs[3][288];
+    uint8_t f_lens[3][288];
+    uint16_t f_codes[3][288];
+    uint32_t f_n_lcodes;
+    uint32_t f_n_dcodes;
+    uint32_t f_n_clcodes;
+    uint16_t f_clseq[320];
+    uint32_t f_n_clseq;
+    uint32_t f_hsort_keys[512];
+    uint16_t f_hsort_syms[512];
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_window[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_stored;
+      bool v_fixed;
+      uint64_t v_n_extra_bits;
+      uint64_t v_fixed_cost;
+      uint64_t v_dynamic_cost;
+      uint64_t v_n_bytes;
+      uint64_t v_stored_cost;
+    } c_write_block[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_start;
+      uint32_t v_end;
+      uint32_t v_remaining;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint32_t v_new_start;
+      uint64_t scratch;
+    } c_write_stored[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_symbol;
+      uint32_t v_n_extra_bits;
+    } c_write_dynamic_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_t;
+      uint32_t v_length;
+      uint32_t v_lc;
+      uint32_t v_d;
+      uint32_t v_dc;
+      uint32_t v_magic;
+    } c_write_tokens[1];
+  } private_impl;
+} wuffs_deflate__encoder;
+
+// WUFFS_DEFLATE__ENCODER__STATE_LENGTH is the length of a
+// wuffs_deflate__encoder's saved state.
+#define WUFFS_DEFLATE__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 272109)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_deflate__decoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_deflate__decoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__decoder returns sizeof(wuffs_deflate__decoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__decoder(void);
+
+// wuffs_deflate__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_deflate__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_deflate__encoder__check_wuffs_version(wuffs_deflate__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__encoder returns sizeof(wuffs_deflate__encoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_deflate__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__decoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__decoder__save_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__decoder__restore_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_src);
+
+// wuffs_deflate__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__encoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__encoder__save_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__encoder__restore_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+wuffs_deflate__status wuffs_deflate__decoder__decode(
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+    wuffs_base__slice_u8 a_hist);
+
+void wuffs_deflate__encoder__set_level(wuffs_deflate__encoder* self,
+    uint32_t a_level);
+
+wuffs_deflate__status wuffs_deflate__encoder__encode(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace deflate {
+
+// status is a strongly typed wuffs_deflate__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_DEFLATE__STATUS_OK) {}
+  constexpr explicit status(wuffs_deflate__status repr) : repr_(repr) {}
+
+  constexpr wuffs_deflate__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_DEFLATE__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_deflate__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_deflate__status repr_;
+};
+
+constexpr status status_ok(WUFFS_DEFLATE__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_DEFLATE__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_DEFLATE__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_DEFLATE__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_DEFLATE__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_huffman_code_over_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_under_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_length_count(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT);
+constexpr status error_bad_huffman_code_length_repetition(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION);
+constexpr status error_bad_huffman_code(WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE);
+constexpr status error_bad_huffman_minimum_code_length(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH);
+constexpr status error_bad_block(WUFFS_DEFLATE__ERROR_BAD_BLOCK);
+constexpr status error_bad_distance(WUFFS_DEFLATE__ERROR_BAD_DISTANCE);
+constexpr status error_bad_distance_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT);
+constexpr status error_bad_literal_length_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT);
+constexpr status error_inconsistent_stored_block_length(
+    WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH);
+constexpr status error_missing_end_of_block_code(
+    WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE);
+constexpr status error_no_huffman_codes(WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES);
+constexpr status error_internal_error_inconsistent_huffman_decoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE);
+constexpr status error_internal_error_inconsistent_huffman_end_of_block(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK);
+constexpr status error_internal_error_inconsistent_distance(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE);
+constexpr status error_internal_error_inconsistent_n_bits(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS);
+constexpr status error_internal_error_inconsistent_encoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE);
+
+// decoder is an RAII wrapper for a wuffs_deflate__decoder. Its constructor
+// calls wuffs_deflate__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_deflate__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__decoder__save_state and
+  // wuffs_deflate__decoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__decoder__restore_state(&c_, src));
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__decoder__decode(&c_, dst, src));
+  }
+
+  void add_history(wuffs_base__slice_u8 hist) {
+    wuffs_deflate__decoder__add_history(&c_, hist);
+  }
+
+ private:
+  wuffs_deflate__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_deflate__encoder. Its constructor
+// calls wuffs_deflate__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_deflate__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__encoder__save_state and
+  // wuffs_deflate__encoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_deflate__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_deflate__encoder c_;
+};
+
+}  // namespace deflate
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_DEFLATE_H
+
+// ---------------- END   USE "std/deflate"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_zlib__status__is_error instead.
+typedef int32_t wuffs_zlib__status;
+
+#define wuffs_zlib__packageid 2064249  // 0x001F7F79
+
+#define WUFFS_ZLIB__STATUS_OK 0  // 0x00000000
+#define WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_ZLIB__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_ZLIB__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_ZLIB__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_ZLIB__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_ZLIB__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_ZLIB__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_ZLIB__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_ZLIB__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_ZLIB__ERROR_BAD_CHECKSUM -33692672  // 0xFDFDE400
+#define WUFFS_ZLIB__ERROR_BAD_COMPRESSION_METHOD -33692671  // 0xFDFDE401
+#define WUFFS_ZLIB__ERROR_BAD_COMPRESSION_WINDOW_SIZE -33692670  // 0xFDFDE402
+#define WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK -33692669  // 0xFDFDE403
+#define WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY -33692668  // 0xFDFDE404
+#define WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED 2113790981  // 0x7DFDE405
+
+bool wuffs_zlib__status__is_error(wuffs_zlib__status s);
+
+const char* wuffs_zlib__status__string(wuffs_zlib__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_zlib__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_zlib__status status;
+    uint32_t magic;
+
+    wuffs_deflate__decoder f_flate;
+    wuffs_adler32__hasher f_checksum;
+    bool f_ignore_checksum;
+    wuffs_adler32__hasher f_dict_id_hasher;
+    uint32_t f_dict_id_got;
+    uint32_t f_dict_id_want;
+    bool f_waiting_for_dictionary;
+    bool f_got_dictionary;
+    bool f_bad_call_sequence;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint16_t v_x;
+      uint32_t v_checksum_got;
+      wuffs_zlib__status v_z;
+      uint32_t v_checksum_want;
+      uint64_t scratch;
+    } c_decode[1];
+  } private_impl;
+} wuffs_zlib__decoder;
+
+// WUFFS_ZLIB__DECODER__STATE_LENGTH is the length of a wuffs_zlib__decoder's
+// saved state.
+#define WUFFS_ZLIB__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 42 + WUFFS_DEFLATE__DECODER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_zlib__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_zlib__status status;
+    uint32_t magic;
+
+    wuffs_deflate__encoder f_flate;
+    wuffs_adler32__hasher f_checksum;
+    uint32_t f_level;
+    bool f_level_set;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint16_t v_x;
+      uint32_t v_checksum;
+      wuffs_zlib__status v_z;
+      uint64_t scratch;
+    } c_encode[1];
+  } private_impl;
+} wuffs_zlib__encoder;
+
+// WUFFS_ZLIB__ENCODER__STATE_LENGTH is the length of a wuffs_zlib__encoder's
+// saved state.
+#define WUFFS_ZLIB__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 31 + WUFFS_DEFLATE__ENCODER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_zlib__decoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_zlib__decoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_zlib__decoder__check_wuffs_version(wuffs_zlib__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_zlib__decoder returns sizeof(wuffs_zlib__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_zlib__decoder(void);
+
+// wuffs_zlib__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_zlib__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_zlib__encoder__check_wuffs_version(wuffs_zlib__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_zlib__encoder returns sizeof(wuffs_zlib__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_zlib__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_zlib__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ZLIB__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_zlib__decoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_zlib__status wuffs_zlib__decoder__save_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_zlib__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ZLIB__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_zlib__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_zlib__status wuffs_zlib__decoder__restore_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_zlib__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_zlib__encoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_zlib__status wuffs_zlib__encoder__save_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_zlib__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_zlib__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_zlib__status wuffs_zlib__encoder__restore_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_zlib__decoder__set_ignore_checksum(wuffs_zlib__decoder* self,
+    bool a_ic);
+
+uint32_t wuffs_zlib__decoder__dictionary_id(wuffs_zlib__decoder* self);
+
+void wuffs_zlib__decoder__set_dictionary(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dict);
+
+wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_zlib__encoder__set_level(wuffs_zlib__encoder* self,
+    uint32_t a_level);
+
+wuffs_zlib__status wuffs_zlib__encoder__encode(wuffs_zlib__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace zlib {
+
+// status is a strongly typed wuffs_zlib__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_ZLIB__STATUS_OK) {}
+  constexpr explicit status(wuffs_zlib__status repr) : repr_(repr) {}
+
+  constexpr wuffs_zlib__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_ZLIB__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_zlib__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_zlib__status repr_;
+};
+
+constexpr status status_ok(WUFFS_ZLIB__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_ZLIB__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_ZLIB__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_ZLIB__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_ZLIB__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_ZLIB__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_ZLIB__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_ZLIB__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_ZLIB__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_checksum(WUFFS_ZLIB__ERROR_BAD_CHECKSUM);
+constexpr status error_bad_compression_method(
+    WUFFS_ZLIB__ERROR_BAD_COMPRESSION_METHOD);
+constexpr status error_bad_compression_window_size(
+    WUFFS_ZLIB__ERROR_BAD_COMPRESSION_WINDOW_SIZE);
+constexpr status error_bad_parity_check(WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK);
+constexpr status error_incorrect_dictionary(
+    WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY);
+constexpr status suspension_dictionary_required(
+    WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED);
+
+// decoder is an RAII wrapper for a wuffs_zlib__decoder. Its constructor
+// calls wuffs_zlib__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_zlib__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_zlib__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_zlib__decoder__save_state and
+  // wuffs_zlib__decoder__restore_state. A saved state is
+  // WUFFS_ZLIB__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_zlib__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_zlib__decoder__restore_state(&c_, src));
+  }
+
+  void set_ignore_checksum(bool ic) {
+    wuffs_zlib__decoder__set_ignore_checksum(&c_, ic);
+  }
+
+  uint32_t dictionary_id() {
+    return wuffs_zlib__decoder__dictionary_id(&c_);
+  }
+
+  void set_dictionary(wuffs_base__slice_u8 dict) {
+    wuffs_zlib__decoder__set_dictionary(&c_, dict);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_zlib__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_zlib__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_zlib__encoder. Its constructor
+// calls wuffs_zlib__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_zlib__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_zlib__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_zlib__encoder__save_state and
+  // wuffs_zlib__encoder__restore_state. A saved state is
+  // WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_zlib__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_zlib__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_zlib__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_zlib__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_zlib__encoder c_;
+};
+
+}  // namespace zlib
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_ZLIB_H
+
+// ---------------- END   USE "std/zlib"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_tiff__status__is_error instead.
+typedef int32_t wuffs_tiff__status;
+
+#define wuffs_tiff__packageid 1730575  // 0x001A680F
+
+#define WUFFS_TIFF__STATUS_OK 0  // 0x00000000
+#define WUFFS_TIFF__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_TIFF__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_TIFF__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_TIFF__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_TIFF__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_TIFF__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_TIFF__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_TIFF__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_TIFF__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_TIFF__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_TIFF__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_TIFF__ERROR_BAD_HEADER -375374848  // 0xE9A03C00
+#define WUFFS_TIFF__ERROR_BAD_IFD_ENTRY -375374847  // 0xE9A03C01
+#define WUFFS_TIFF__ERROR_BAD_WORKBUF_LENGTH -375374846  // 0xE9A03C02
+#define WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA -375374845  // 0xE9A03C03
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_BIT_DEPTH -375374844  // 0xE9A03C04
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_COMPRESSION -375374843  // 0xE9A03C05
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_PHOTOMETRIC_INTERPRETATION -375374842  // 0xE9A03C06
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_PLANAR_CONFIGURATION -375374841  // 0xE9A03C07
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_PREDICTOR -375374840  // 0xE9A03C08
+#define WUFFS_TIFF__ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL -375374839  // 0xE9A03C09
+#define WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_BIGTIFF -375374838  // 0xE9A03C0A
+#define WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH -375374837  // 0xE9A03C0B
+#define WUFFS_TIFF__SUSPENSION_SEEK_REQUIRED 1772108812  // 0x69A03C0C
+
+bool wuffs_tiff__status__is_error(wuffs_tiff__status s);
+
+const char* wuffs_tiff__status__string(wuffs_tiff__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_tiff__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_tiff__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint8_t f_call_sequence;
+    bool f_big_endian;
+    uint64_t f_pos;
+    uint64_t f_seek_pos;
+    uint32_t f_value;
+    uint32_t f_raw_width;
+    uint32_t f_raw_height;
+    uint32_t f_raw_bits_per_sample;
+    uint32_t f_raw_compression;
+    uint32_t f_raw_photometric;
+    uint32_t f_raw_samples_per_pixel;
+    uint32_t f_raw_rows_per_strip;
+    uint32_t f_raw_planar_configuration;
+    uint32_t f_raw_predictor;
+    uint32_t f_raw_tile_width;
+    uint32_t f_raw_tile_height;
+    uint32_t f_raw_extra_samples;
+    uint32_t f_bits_per_sample;
+    uint32_t f_samples_per_pixel;
+    uint32_t f_compression;
+    uint32_t f_photometric;
+    bool f_predictor;
+    uint32_t f_alpha;
+    bool f_is_tiled;
+    uint32_t f_chunk_width;
+    uint32_t f_chunk_height;
+    uint32_t f_chunks_across;
+    uint32_t f_num_chunks;
+    uint32_t f_chunk_index;
+    uint64_t f_chunk_remaining;
+    uint32_t f_array_types[4];
+    uint32_t f_array_counts[4];
+    uint32_t f_array_values[4];
+    uint32_t f_packbits_literal;
+    uint32_t f_packbits_run;
+    uint8_t f_packbits_byte;
+    uint32_t f_row_length;
+    uint32_t f_row_wi;
+    uint8_t f_row[32772];
+    uint8_t f_palette[1024];
+    wuffs_lzw__decoder f_lzw;
+    wuffs_zlib__decoder f_zlib;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_magic;
+      uint32_t v_num_entries;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_tag;
+      uint32_t v_field_type;
+      uint32_t v_count;
+      uint32_t v_raw;
+      uint32_t v_which;
+      uint32_t v_v;
+      uint64_t scratch;
+    } c_read_entry[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_field_type;
+      uint32_t v_count;
+      uint32_t v_raw;
+      uint32_t v_n;
+      bool v_is_inline;
+      uint32_t v_offset;
+      uint32_t v_i;
+      uint32_t v_v;
+      uint32_t v_c;
+      uint32_t v_index;
+    } c_read_array[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_read_u16[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_read_u32[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_seek[1];
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_base__rect_ie_u32 v_dirty_rect;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_c;
+      uint32_t v_offset;
+      uint64_t v_x0;
+      uint64_t v_y0;
+      uint64_t v_y;
+      uint64_t v_y_end;
+    } c_decode_chunk[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_fill_row[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint8_t v_c;
+    } c_fill_row_none[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_row_packbits[1];
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_tiff__status v_z;
+      uint64_t v_n;
+      wuffs_tiff__status v_lzw_status;
+      wuffs_tiff__status v_zlib_status;
+    } c_fill_row_lzw_or_deflate[1];
+  } private_impl;
+} wuffs_tiff__decoder;
+
+// WUFFS_TIFF__DECODER__STATE_LENGTH is the length of a wuffs_tiff__decoder's
+// saved state.
+#define WUFFS_TIFF__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 34236 + WUFFS_LZW__DECODER__STATE_LENGTH + WUFFS_ZLIB__DECODER__STATE_LENGTH)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_tiff__decoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_tiff__decoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_tiff__decoder__check_wuffs_version(wuffs_tiff__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_tiff__decoder returns sizeof(wuffs_tiff__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_tiff__decoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_tiff__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_TIFF__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_tiff__decoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_tiff__status wuffs_tiff__decoder__save_state(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_tiff__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_TIFF__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_tiff__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_tiff__status wuffs_tiff__decoder__restore_state(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+uint64_t wuffs_tiff__decoder__seek_position(wuffs_tiff__decoder* self);
+
+uint64_t wuffs_tiff__decoder__workbuf_len(wuffs_tiff__decoder* self);
+
+wuffs_tiff__status wuffs_tiff__decoder__decode_config(wuffs_tiff__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src);
+
+wuffs_tiff__status wuffs_tiff__decoder__decode_frame(wuffs_tiff__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace tiff {
+
+// status is a strongly typed wuffs_tiff__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_TIFF__STATUS_OK) {}
+  constexpr explicit status(wuffs_tiff__status repr) : repr_(repr) {}
+
+  constexpr wuffs_tiff__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_TIFF__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_tiff__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_tiff__status repr_;
+};
+
+constexpr status status_ok(WUFFS_TIFF__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_TIFF__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_TIFF__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_TIFF__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_TIFF__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_TIFF__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_TIFF__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_TIFF__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_TIFF__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_TIFF__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_TIFF__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_TIFF__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_header(WUFFS_TIFF__ERROR_BAD_HEADER);
+constexpr status error_bad_ifd_entry(WUFFS_TIFF__ERROR_BAD_IFD_ENTRY);
+constexpr status error_bad_workbuf_length(WUFFS_TIFF__ERROR_BAD_WORKBUF_LENGTH);
+constexpr status error_not_enough_pixel_data(
+    WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA);
+constexpr status error_unsupported_bit_depth(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_BIT_DEPTH);
+constexpr status error_unsupported_compression(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_COMPRESSION);
+constexpr status error_unsupported_photometric_interpretation(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_PHOTOMETRIC_INTERPRETATION);
+constexpr status error_unsupported_planar_configuration(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_PLANAR_CONFIGURATION);
+constexpr status error_unsupported_predictor(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_PREDICTOR);
+constexpr status error_unsupported_samples_per_pixel(
+    WUFFS_TIFF__ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL);
+constexpr status error_todo_unsupported_bigtiff(
+    WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_BIGTIFF);
+constexpr status error_todo_unsupported_image_width(
+    WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH);
+constexpr status suspension_seek_required(WUFFS_TIFF__SUSPENSION_SEEK_REQUIRED);
+
+// decoder is an RAII wrapper for a wuffs_tiff__decoder. Its constructor
+// calls wuffs_tiff__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_tiff__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_tiff__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_tiff__decoder__save_state and
+  // wuffs_tiff__decoder__restore_state. A saved state is
+  // WUFFS_TIFF__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_tiff__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_tiff__decoder__restore_state(&c_, src));
+  }
+
+  uint64_t seek_position() {
+    return wuffs_tiff__decoder__seek_position(&c_);
+  }
+
+  uint64_t workbuf_len() {
+    return wuffs_tiff__decoder__workbuf_len(&c_);
+  }
+
+  status decode_config(
+      wuffs_base__image_config* dst, wuffs_base__io_reader src) {
+    return status(wuffs_tiff__decoder__decode_config(&c_, dst, src));
+  }
+
+  status decode_frame(
+      wuffs_base__image_buffer* dst,
+      wuffs_base__io_reader src,
+      wuffs_base__slice_u8 workbuf) {
+    return status(wuffs_tiff__decoder__decode_frame(&c_, dst, src, workbuf));
+  }
+
+ private:
+  wuffs_tiff__decoder c_;
+};
+
+}  // namespace tiff
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_TIFF_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
+  return ((wuffs_base__empty_struct){});
+}
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
+// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
+// It's not foolproof, given C doesn't automatically zero memory before use,
+// but it should catch 99.99% of cases.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("wuffs").
+#define WUFFS_BASE__MAGIC ((uint32_t)0x3CCB6C71)
+
+// Denote intentional fallthroughs for -Wimplicit-fallthrough.
+//
+// The order matters here. Clang also defines "__GNUC__".
+#if defined(__clang__) && __cplusplus >= 201103L
+#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]
+#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)
+#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))
+#else
+#define WUFFS_BASE__FALLTHROUGH
+#endif
+
+// Use switch cases for coroutine suspension points, similar to the technique
+// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html
+//
+// We use trivial macros instead of an explicit assignment and case statement
+// so that clang-format doesn't get confused by the unusual "case"s.
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \
+  coro_susp_point = n;                            \
+  WUFFS_BASE__FALLTHROUGH;                        \
+  case n:;
+
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \
+  if (status < 0) {                                             \
+    goto exit;                                                  \
+  } else if (status == 0) {                                     \
+    goto ok;                                                    \
+  }                                                             \
+  coro_susp_point = n;                                          \
+  goto suspend;                                                 \
+  case n:;
+
+// Clang also defines "__GNUC__".
+#if defined(__GNUC__)
+#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))
+#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))
+#else
+#define WUFFS_BASE__LIKELY(expr) (expr)
+#define WUFFS_BASE__UNLIKELY(expr) (expr)
+#endif
+
+// Uncomment this #include for printf-debugging.
+// #include <stdio.h>
+
+// The helpers below are functions, instead of macros, because their arguments
+// can be an expression that we shouldn't evaluate more than once.
+//
+// They are in base-impl.h and hence copy/pasted into every generated C file,
+// instead of being in some "base.c" file, since a design goal is that users of
+// the generated C code can often just #include a single .c file, such as
+// "gif.c", without having to additionally include or otherwise build and link
+// a "base.c" file.
+//
+// They are static, so that linking multiple wuffs .o files won't complain about
+// duplicate function definitions.
+//
+// They are explicitly marked inline, even if modern compilers don't use the
+// inline attribute to guide optimizations such as inlining, to avoid the
+// -Wunused-function warning, and we like to compile with -Wall -Werror.
+
+// ---------------- Numeric Types
+
+static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
+}
+
+static inline uint16_t wuffs_base__load_u16le(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);
+}
+
+static inline uint32_t wuffs_base__load_u24be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 16) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u24le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16);
+}
+
+static inline uint32_t wuffs_base__load_u32be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |
+         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u32le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);
+}
+
+static inline uint64_t wuffs_base__load_u40be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 32) | ((uint64_t)(p[1]) << 24) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 8) |
+         ((uint64_t)(p[4]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u40le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32);
+}
+
+static inline uint64_t wuffs_base__load_u48be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 40) | ((uint64_t)(p[1]) << 32) |
+         ((uint64_t)(p[2]) << 24) | ((uint64_t)(p[3]) << 16) |
+         ((uint64_t)(p[4]) << 8) | ((uint64_t)(p[5]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u48le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40);
+}
+
+static inline uint64_t wuffs_base__load_u56be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 48) | ((uint64_t)(p[1]) << 40) |
+         ((uint64_t)(p[2]) << 32) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 16) | ((uint64_t)(p[5]) << 8) |
+         ((uint64_t)(p[6]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u56le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |
+         ((uint64_t)(p[6]) << 48);
+}
+
+static inline uint64_t wuffs_base__load_u64be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 56) | ((uint64_t)(p[1]) << 48) |
+         ((uint64_t)(p[2]) << 40) | ((uint64_t)(p[3]) << 32) |
+         ((uint64_t)(p[4]) << 24) | ((uint64_t)(p[5]) << 16) |
+         ((uint64_t)(p[6]) << 8) | ((uint64_t)(p[7]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |
+         ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
+}
+
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
+// --------
+
+static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
+  *x = wuffs_base__u8__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u8__sat_sub_indirect(uint8_t* x, uint8_t y) {
+  *x = wuffs_base__u8__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u16__sat_add_indirect(uint16_t* x, uint16_t y) {
+  *x = wuffs_base__u16__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u16__sat_sub_indirect(uint16_t* x, uint16_t y) {
+  *x = wuffs_base__u16__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u32__sat_add_indirect(uint32_t* x, uint32_t y) {
+  *x = wuffs_base__u32__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u32__sat_sub_indirect(uint32_t* x, uint32_t y) {
+  *x = wuffs_base__u32__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u64__sat_add_indirect(uint64_t* x, uint64_t y) {
+  *x = wuffs_base__u64__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u64__sat_sub_indirect(uint64_t* x, uint64_t y) {
+  *x = wuffs_base__u64__sat_sub(*x, y);
+}
+
+// ---------------- Slices and Tables
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(
+    wuffs_base__slice_u8 s,
+    uint64_t i) {
+  if ((i <= SIZE_MAX) && (i <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = s.len - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(
+    wuffs_base__slice_u8 s,
+    uint64_t j) {
+  if ((j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(
+    wuffs_base__slice_u8 s,
+    uint64_t i,
+    uint64_t j) {
+  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = j - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__suffix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.ptr += (uint64_t)(s.len) - up_to;
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
+// length) where length is the minimum of dst.len and src.len.
+//
+// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
+// slice) is valid and results in a no-op.
+static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src) {
+  size_t length = dst.len < src.len ? dst.len : src.len;
+  if (length > 0) {
+    memmove(dst.ptr, src.ptr, length);
+  }
+  return length;
+}
+
+// --------
+
+static inline wuffs_base__slice_u8 wuffs_base__table_u8__row(
+    wuffs_base__table_u8 t,
+    uint32_t y) {
+  if (y < t.height) {
+    return ((wuffs_base__slice_u8){
+        .ptr = t.ptr + (t.stride * y),
+        .len = t.width,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// ---------------- Ranges and Rects
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_min_inclusive_x(wuffs_base__rect_ii_u32* r,
+                                             uint32_t x) {
+  r->min_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_min_inclusive_y(wuffs_base__rect_ii_u32* r,
+                                             uint32_t y) {
+  r->min_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_max_inclusive_x(wuffs_base__rect_ii_u32* r,
+                                             uint32_t x) {
+  r->max_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_max_inclusive_y(wuffs_base__rect_ii_u32* r,
+                                             uint32_t y) {
+  r->max_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+// --------
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_min_inclusive_x(wuffs_base__rect_ie_u32* r,
+                                             uint32_t x) {
+  r->min_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_min_inclusive_y(wuffs_base__rect_ie_u32* r,
+                                             uint32_t y) {
+  r->min_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_max_exclusive_x(wuffs_base__rect_ie_u32* r,
+                                             uint32_t x) {
+  r->max_exclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_max_exclusive_y(wuffs_base__rect_ie_u32* r,
+                                             uint32_t y) {
+  r->max_exclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+// ---------------- I/O
+
+static inline bool wuffs_base__io_buffer__is_valid(wuffs_base__io_buffer buf) {
+  return (buf.ptr || (buf.len == 0)) && (buf.len >= buf.wi) &&
+         (buf.wi >= buf.ri);
+}
+
+static inline bool wuffs_base__io_reader__is_eof(wuffs_base__io_reader o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  return buf && buf->closed && (buf->ptr + buf->wi == o.private_impl.bounds[1]);
+}
+
+static inline bool wuffs_base__io_reader__is_valid(wuffs_base__io_reader o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  // Note: if making this function public (i.e. moving it to base-header.h), it
+  // also needs to allow NULL (i.e. implicit, callee-calculated) bounds.
+  return buf ? ((buf->ptr <= o.private_impl.bounds[0]) &&
+                (o.private_impl.bounds[0] <= o.private_impl.bounds[1]) &&
+                (o.private_impl.bounds[1] <= buf->ptr + buf->len))
+             : ((o.private_impl.bounds[0] == NULL) &&
+                (o.private_impl.bounds[1] == NULL));
+}
+
+static inline bool wuffs_base__io_writer__is_valid(wuffs_base__io_writer o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  // Note: if making this function public (i.e. moving it to base-header.h), it
+  // also needs to allow NULL (i.e. implicit, callee-calculated) bounds.
+  return buf ? ((buf->ptr <= o.private_impl.bounds[0]) &&
+                (o.private_impl.bounds[0] <= o.private_impl.bounds[1]) &&
+                (o.private_impl.bounds[1] <= buf->ptr + buf->len))
+             : ((o.private_impl.bounds[0] == NULL) &&
+                (o.private_impl.bounds[1] == NULL));
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_history32(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  if (!distance) {
+    return 0;
+  }
+  uint8_t* ptr = *ptr_ptr;
+  if ((size_t)(ptr - start) < (size_t)(distance)) {
+    return 0;
+  }
+  start = ptr - distance;
+  size_t n = end - ptr;
+  if ((size_t)(length) > n) {
+    length = n;
+  } else {
+    n = length;
+  }
+  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that
+  // is mostly because 3 is the minimum length for the deflate format. This
+  // function implementation shouldn't overfit to that one format. Perhaps the
+  // copy_from_history32 Wuffs method should also take an unroll hint argument,
+  // and the cgen can look if that argument is the constant expression '3'.
+  //
+  // See also wuffs_base__io_writer__copy_from_history32__bco below.
+  //
+  // Alternatively, or additionally, have a sloppy_copy_from_history32 method
+  // that copies 8 bytes at a time, possibly writing more than length bytes?
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+// wuffs_base__io_writer__copy_from_history32__bco is a Bounds Check Optimized
+// version of the wuffs_base__io_writer__copy_from_history32 function above.
+// The caller needs to prove that:
+//  - distance >  0
+//  - distance <= (*ptr_ptr - start)
+//  - length   <= (end      - *ptr_ptr)
+static inline uint32_t wuffs_base__io_writer__copy_from_history32__bco(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  uint8_t* ptr = *ptr_ptr;
+  start = ptr - distance;
+  uint32_t n = length;
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_reader32(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    uint8_t** ptr_ioptr_r,
+    uint8_t* iobounds1_r,
+    uint32_t length) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = length;
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  uint8_t* ioptr_r = *ptr_ioptr_r;
+  if (n > iobounds1_r - ioptr_r) {
+    n = iobounds1_r - ioptr_r;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, ioptr_r, n);
+    *ptr_ioptr_w += n;
+    *ptr_ioptr_r += n;
+  }
+  return n;
+}
+
+static inline uint64_t wuffs_base__io_writer__copy_from_slice(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    wuffs_base__slice_u8 src) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = src.len;
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, src.ptr, n);
+    *ptr_ioptr_w += n;
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    wuffs_base__slice_u8 src,
+    uint32_t length) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = src.len;
+  if (n > length) {
+    n = length;
+  }
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, src.ptr, n);
+    *ptr_ioptr_w += n;
+  }
+  return n;
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
+    wuffs_base__io_reader* o,
+    uint8_t* ioptr_r,
+    uint64_t limit) {
+  if (o && ((o->private_impl.bounds[1] - ioptr_r) > limit)) {
+    o->private_impl.bounds[1] = ioptr_r + limit;
+  }
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set_mark(
+    wuffs_base__io_reader* o,
+    uint8_t* mark) {
+  o->private_impl.bounds[0] = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_writer__set(
+    wuffs_base__io_writer* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = 0;
+  b->ri = 0;
+  b->closed = false;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
+    wuffs_base__io_writer* o,
+    uint8_t* mark) {
+  o->private_impl.bounds[0] = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+static const char* wuffs_base__status__strings[15] = {
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
+};
+
+#endif  // WUFFS_BASE_IMPL_H
+
+// ---------------- Status Codes Implementations
+
+bool wuffs_tiff__status__is_error(wuffs_tiff__status s) {
+  return s < 0;
+}
+
+const char* wuffs_tiff__status__strings[13] = {
+    "tiff: bad header", "tiff: bad IFD entry", "tiff: bad workbuf length",
+    "tiff: not enough pixel data", "tiff: unsupported bit depth",
+    "tiff: unsupported compression",
+    "tiff: unsupported photometric interpretation",
+    "tiff: unsupported planar configuration", "tiff: unsupported predictor",
+    "tiff: unsupported samples per pixel", "tiff: TODO: unsupported BigTIFF",
+    "tiff: TODO: unsupported image width", "tiff: seek required",
+};
+
+const char* wuffs_tiff__status__string(wuffs_tiff__status s) {
+  const char** a = NULL;
+  uint32_t n = 0;
+  switch ((s >> 10) & 0x1FFFFF) {
+    case 0:
+      a = wuffs_base__status__strings;
+      n = 15;
+      break;
+    case wuffs_tiff__packageid:
+      a = wuffs_tiff__status__strings;
+      n = 13;
+      break;
+    case wuffs_lzw__packageid:
+      return wuffs_lzw__status__string(s);
+    case wuffs_zlib__packageid:
+      return wuffs_zlib__status__string(s);
+  }
+  uint32_t i = s & 0xFF;
+  return i < n ? a[i] : "unknown status";
+}
+
+// ---------------- Private Consts
+
+static const uint32_t wuffs_tiff__tag_image_width = 256;
+
+static const uint32_t wuffs_tiff__tag_image_length = 257;
+
+static const uint32_t wuffs_tiff__tag_bits_per_sample = 258;
+
+static const uint32_t wuffs_tiff__tag_compression = 259;
+
+static const uint32_t wuffs_tiff__tag_photometric_interpretation = 262;
+
+static const uint32_t wuffs_tiff__tag_strip_offsets = 273;
+
+static const uint32_t wuffs_tiff__tag_samples_per_pixel = 277;
+
+static const uint32_t wuffs_tiff__tag_rows_per_strip = 278;
+
+static const uint32_t wuffs_tiff__tag_strip_byte_counts = 279;
+
+static const uint32_t wuffs_tiff__tag_planar_configuration = 284;
+
+static const uint32_t wuffs_tiff__tag_predictor = 317;
+
+static const uint32_t wuffs_tiff__tag_color_map = 320;
+
+static const uint32_t wuffs_tiff__tag_tile_width = 322;
+
+static const uint32_t wuffs_tiff__tag_tile_length = 323;
+
+static const uint32_t wuffs_tiff__tag_tile_offsets = 324;
+
+static const uint32_t wuffs_tiff__tag_tile_byte_counts = 325;
+
+static const uint32_t wuffs_tiff__tag_extra_samples = 338;
+
+static const uint32_t wuffs_tiff__field_type_short = 3;
+
+static const uint32_t wuffs_tiff__field_type_long = 4;
+
+static const uint32_t wuffs_tiff__compression_none = 1;
+
+static const uint32_t wuffs_tiff__compression_lzw = 5;
+
+static const uint32_t wuffs_tiff__compression_deflate = 8;
+
+static const uint32_t wuffs_tiff__compression_packbits = 32773;
+
+static const uint32_t wuffs_tiff__compression_old_deflate = 32946;
+
+static const uint32_t wuffs_tiff__photometric_white_is_zero = 0;
+
+static const uint32_t wuffs_tiff__photometric_black_is_zero = 1;
+
+static const uint32_t wuffs_tiff__photometric_rgb = 2;
+
+static const uint32_t wuffs_tiff__photometric_palette = 3;
+
+static const uint32_t wuffs_tiff__extra_samples_associated_alpha = 1;
+
+static const uint32_t wuffs_tiff__extra_samples_unassociated_alpha = 2;
+
+static const uint32_t wuffs_tiff__which_offsets = 0;
+
+static const uint32_t wuffs_tiff__which_byte_counts = 1;
+
+static const uint32_t wuffs_tiff__which_bits_per_sample = 2;
+
+static const uint32_t wuffs_tiff__which_color_map = 3;
+
+// ---------------- Private Initializer Prototypes
+
+// ---------------- Private Saved State Prototypes
+
+// ---------------- Private Function Prototypes
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_entry(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static uint32_t wuffs_tiff__decoder__inline_element(wuffs_tiff__decoder* self,
+    uint32_t a_raw, uint32_t a_field_type, uint32_t a_i);
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_array(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which);
+
+static wuffs_tiff__status wuffs_tiff__decoder__configure(
+    wuffs_tiff__decoder* self);
+
+static uint64_t wuffs_tiff__decoder__div_round_up(wuffs_tiff__decoder* self,
+    uint64_t a_a, uint64_t a_b);
+
+static void wuffs_tiff__decoder__set_gray_palette(wuffs_tiff__decoder* self);
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_u16(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_u32(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_tiff__status wuffs_tiff__decoder__seek(wuffs_tiff__decoder* self,
+    wuffs_base__io_reader a_src, uint64_t a_pos);
+
+static wuffs_tiff__status wuffs_tiff__decoder__decode_chunk(
+    wuffs_tiff__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_tiff__decoder__set_chunk_value(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_i,
+    uint32_t a_v);
+
+static uint32_t wuffs_tiff__decoder__chunk_value(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_i);
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_none(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_packbits(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_lzw_or_deflate(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src);
+
+static void wuffs_tiff__decoder__unpredict_row(wuffs_tiff__decoder* self);
+
+static void wuffs_tiff__decoder__swizzle_row(wuffs_tiff__decoder* self,
+    wuffs_base__image_buffer* a_dst, uint64_t a_x0, uint64_t a_y);
+
+static uint8_t wuffs_tiff__decoder__unpremultiply(wuffs_tiff__decoder* self,
+    uint32_t a_v, uint32_t a_a);
+
+// ---------------- Initializer Implementations
+
+size_t sizeof__wuffs_tiff__decoder(void) {
+  return sizeof(wuffs_tiff__decoder);
+}
+
+void wuffs_tiff__decoder__check_wuffs_version(wuffs_tiff__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_TIFF__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_TIFF__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  wuffs_lzw__decoder__check_wuffs_version(&self->private_impl.f_lzw,
+      sizeof(self->private_impl.f_lzw), WUFFS_VERSION);
+  wuffs_zlib__decoder__check_wuffs_version(&self->private_impl.f_zlib,
+      sizeof(self->private_impl.f_zlib), WUFFS_VERSION);
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.seek_position
+
+uint64_t wuffs_tiff__decoder__seek_position(wuffs_tiff__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return self->private_impl.f_seek_pos;
+}
+
+// -------- func decoder.workbuf_len
+
+uint64_t wuffs_tiff__decoder__workbuf_len(wuffs_tiff__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return (((uint64_t)(self->private_impl.f_num_chunks)) * 8);
+}
+
+// -------- func decoder.decode_config
+
+wuffs_tiff__status wuffs_tiff__decoder__decode_config(wuffs_tiff__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_TIFF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+    return WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t v_magic;
+  uint32_t v_num_entries;
+  wuffs_base__slice_u8 v_workbuf;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_magic = self->private_impl.c_decode_config[0].v_magic;
+    v_num_entries = self->private_impl.c_decode_config[0].v_num_entries;
+    v_workbuf = ((wuffs_base__slice_u8){});
+  } else {
+    v_workbuf = ((wuffs_base__slice_u8){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint32_t t_1;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_1 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+          uint32_t t_0 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_0;
+          if (t_0 == 24) {
+            t_1 = *scratch;
+            break;
+          }
+          t_0 += 8;
+          *scratch |= ((uint64_t)(t_0)) << 56;
+        }
+      }
+      v_magic = t_1;
+    }
+    if (v_magic == 2771273) {
+      self->private_impl.f_big_endian = false;
+    } else if (v_magic == 704662861) {
+      self->private_impl.f_big_endian = true;
+    } else if ((v_magic == 2836809) || (v_magic == 721440077)) {
+      status = WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_BIGTIFF;
+      goto exit;
+    } else {
+      status = WUFFS_TIFF__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_pos = 4;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__read_u32(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    if (self->private_impl.f_value < 8) {
+      status = WUFFS_TIFF__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__seek(self, a_src,
+        ((uint64_t)(self->private_impl.f_value)));
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    self->private_impl.f_raw_bits_per_sample = 1;
+    self->private_impl.f_raw_compression = wuffs_tiff__compression_none;
+    self->private_impl.f_raw_photometric = 4294967295;
+    self->private_impl.f_raw_samples_per_pixel = 1;
+    self->private_impl.f_raw_rows_per_strip = 4294967295;
+    self->private_impl.f_raw_planar_configuration = 1;
+    self->private_impl.f_raw_predictor = 1;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__read_u16(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    v_num_entries = self->private_impl.f_value;
+    if (v_num_entries == 0) {
+      status = WUFFS_TIFF__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    while (v_num_entries > 0) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      if (a_src.private_impl.buf) {
+        a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+      }
+      status = wuffs_tiff__decoder__read_entry(self, a_src);
+      if (a_src.private_impl.buf) {
+        ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      v_num_entries -= 1;
+    }
+    if ((self->private_impl.f_raw_samples_per_pixel == 0) ||
+        (self->private_impl.f_raw_samples_per_pixel > 4)) {
+      status = WUFFS_TIFF__ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+      goto exit;
+    }
+    self->private_impl.f_samples_per_pixel =
+        self->private_impl.f_raw_samples_per_pixel;
+    v_workbuf = ((wuffs_base__slice_u8){});
+    if (self->private_impl.f_array_counts[wuffs_tiff__which_bits_per_sample] >
+        0) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      if (a_src.private_impl.buf) {
+        a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+      }
+      status = wuffs_tiff__decoder__read_array(self, a_src, v_workbuf,
+          wuffs_tiff__which_bits_per_sample);
+      if (a_src.private_impl.buf) {
+        ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    status = wuffs_tiff__decoder__configure(self);
+    if (status) {
+      goto suspend;
+    }
+    if (self->private_impl.f_photometric == wuffs_tiff__photometric_palette) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      if (a_src.private_impl.buf) {
+        a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+      }
+      status = wuffs_tiff__decoder__read_array(self, a_src, v_workbuf,
+          wuffs_tiff__which_color_map);
+      if (a_src.private_impl.buf) {
+        ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      wuffs_tiff__decoder__set_gray_palette(self);
+    }
+    if ((self->private_impl.f_array_counts[wuffs_tiff__which_offsets] <
+        self->private_impl.f_num_chunks) ||
+        (self->private_impl.f_array_counts[wuffs_tiff__which_byte_counts] <
+        self->private_impl.f_num_chunks)) {
+      status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+      goto exit;
+    }
+    wuffs_base__image_config__initialize(a_dst, 570460296, 0,
+        self->private_impl.f_width, self->private_impl.f_height, 1);
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_config[0].v_magic = v_magic;
+  self->private_impl.c_decode_config[0].v_num_entries = v_num_entries;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.read_entry
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_entry(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t v_tag;
+  uint32_t v_field_type;
+  uint32_t v_count;
+  uint32_t v_raw;
+  uint32_t v_which;
+  uint32_t v_v;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_read_entry[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_tag = self->private_impl.c_read_entry[0].v_tag;
+    v_field_type = self->private_impl.c_read_entry[0].v_field_type;
+    v_count = self->private_impl.c_read_entry[0].v_count;
+    v_raw = self->private_impl.c_read_entry[0].v_raw;
+    v_which = self->private_impl.c_read_entry[0].v_which;
+    v_v = self->private_impl.c_read_entry[0].v_v;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__read_u16(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    v_tag = self->private_impl.f_value;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__read_u16(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    v_field_type = self->private_impl.f_value;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_tiff__decoder__read_u32(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    v_count = self->private_impl.f_value;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      uint32_t t_1;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_1 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_read_entry[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_read_entry[0].scratch;
+          uint32_t t_0 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_0;
+          if (t_0 == 24) {
+            t_1 = *scratch;
+            break;
+          }
+          t_0 += 8;
+          *scratch |= ((uint64_t)(t_0)) << 56;
+        }
+      }
+      v_raw = t_1;
+    }
+    wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 4);
+    if ((v_tag == wuffs_tiff__tag_bits_per_sample) ||
+        (v_tag == wuffs_tiff__tag_strip_offsets) ||
+        (v_tag == wuffs_tiff__tag_strip_byte_counts) || (v_tag ==
+        wuffs_tiff__tag_color_map) || (v_tag == wuffs_tiff__tag_tile_offsets) ||
+        (v_tag == wuffs_tiff__tag_tile_byte_counts)) {
+      if (((v_field_type != wuffs_tiff__field_type_short) &&
+          (v_field_type != wuffs_tiff__field_type_long)) || (v_count == 0)) {
+        status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+        goto exit;
+      }
+      v_which = wuffs_tiff__which_bits_per_sample;
+      if ((v_tag == wuffs_tiff__tag_strip_offsets) ||
+          (v_tag == wuffs_tiff__tag_tile_offsets)) {
+        v_which = wuffs_tiff__which_offsets;
+      } else if ((v_tag == wuffs_tiff__tag_strip_byte_counts) ||
+          (v_tag == wuffs_tiff__tag_tile_byte_counts)) {
+        v_which = wuffs_tiff__which_byte_counts;
+      } else if (v_tag == wuffs_tiff__tag_color_map) {
+        v_which = wuffs_tiff__which_color_map;
+      }
+      self->private_impl.f_array_types[v_which] = v_field_type;
+      self->private_impl.f_array_counts[v_which] = v_count;
+      self->private_impl.f_array_values[v_which] = v_raw;
+      if ((v_tag == wuffs_tiff__tag_tile_offsets) ||
+          (v_tag == wuffs_tiff__tag_tile_byte_counts)) {
+        self->private_impl.f_is_tiled = true;
+      }
+      status = WUFFS_TIFF__STATUS_OK;
+      goto ok;
+    }
+    if ((v_tag != wuffs_tiff__tag_image_width) &&
+        (v_tag != wuffs_tiff__tag_image_length) &&
+        (v_tag != wuffs_tiff__tag_compression) &&
+        (v_tag != wuffs_tiff__tag_photometric_interpretation) &&
+        (v_tag != wuffs_tiff__tag_samples_per_pixel) &&
+        (v_tag != wuffs_tiff__tag_rows_per_strip) &&
+        (v_tag != wuffs_tiff__tag_planar_configuration) && (v_tag !=
+        wuffs_tiff__tag_predictor) && (v_tag != wuffs_tiff__tag_tile_width) &&
+        (v_tag != wuffs_tiff__tag_tile_length) &&
+        (v_tag != wuffs_tiff__tag_extra_samples)) {
+      status = WUFFS_TIFF__STATUS_OK;
+      goto ok;
+    }
+    if (((v_field_type != wuffs_tiff__field_type_short) &&
+        (v_field_type != wuffs_tiff__field_type_long)) || (v_count != 1)) {
+      status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+      goto exit;
+    }
+    v_v = wuffs_tiff__decoder__inline_element(self, v_raw, v_field_type, 0);
+    if (v_tag == wuffs_tiff__tag_image_width) {
+      self->private_impl.f_raw_width = v_v;
+    } else if (v_tag == wuffs_tiff__tag_image_length) {
+      self->private_impl.f_raw_height = v_v;
+    } else if (v_tag == wuffs_tiff__tag_compression) {
+      self->private_impl.f_raw_compression = v_v;
+    } else if (v_tag == wuffs_tiff__tag_photometric_interpretation) {
+      self->private_impl.f_raw_photometric = v_v;
+    } else if (v_tag == wuffs_tiff__tag_samples_per_pixel) {
+      self->private_impl.f_raw_samples_per_pixel = v_v;
+    } else if (v_tag == wuffs_tiff__tag_rows_per_strip) {
+      self->private_impl.f_raw_rows_per_strip = v_v;
+    } else if (v_tag == wuffs_tiff__tag_planar_configuration) {
+      self->private_impl.f_raw_planar_configuration = v_v;
+    } else if (v_tag == wuffs_tiff__tag_predictor) {
+      self->private_impl.f_raw_predictor = v_v;
+    } else if (v_tag == wuffs_tiff__tag_tile_width) {
+      self->private_impl.f_raw_tile_width = v_v;
+      self->private_impl.f_is_tiled = true;
+    } else if (v_tag == wuffs_tiff__tag_tile_length) {
+      self->private_impl.f_raw_tile_height = v_v;
+      self->private_impl.f_is_tiled = true;
+    } else if (v_tag == wuffs_tiff__tag_extra_samples) {
+      self->private_impl.f_raw_extra_samples = v_v;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_entry[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_entry[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_read_entry[0].v_tag = v_tag;
+  self->private_impl.c_read_entry[0].v_field_type = v_field_type;
+  self->private_impl.c_read_entry[0].v_count = v_count;
+  self->private_impl.c_read_entry[0].v_raw = v_raw;
+  self->private_impl.c_read_entry[0].v_which = v_which;
+  self->private_impl.c_read_entry[0].v_v = v_v;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.inline_element
+
+static uint32_t wuffs_tiff__decoder__inline_element(wuffs_tiff__decoder* self,
+    uint32_t a_raw, uint32_t a_field_type, uint32_t a_i) {
+  uint32_t v_x;
+
+  v_x = 0;
+  if (a_field_type == wuffs_tiff__field_type_long) {
+    v_x = a_raw;
+    if (self->private_impl.f_big_endian) {
+      v_x = (((v_x & 255) << 24) | ((v_x & 65280) << 8) | ((v_x >> 8) & 65280) |
+          (v_x >> 24));
+    }
+    return v_x;
+  }
+  v_x = (a_raw >> (16 * a_i));
+  if (self->private_impl.f_big_endian) {
+    return (((v_x & 255) << 8) | ((v_x >> 8) & 255));
+  }
+  return (v_x & 65535);
+}
+
+// -------- func decoder.read_array
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_array(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t v_field_type;
+  uint32_t v_count;
+  uint32_t v_raw;
+  uint32_t v_n;
+  bool v_is_inline;
+  uint32_t v_offset;
+  uint32_t v_i;
+  uint32_t v_v;
+  uint32_t v_c;
+  uint32_t v_index;
+
+  uint32_t coro_susp_point = self->private_impl.c_read_array[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_field_type = self->private_impl.c_read_array[0].v_field_type;
+    v_count = self->private_impl.c_read_array[0].v_count;
+    v_raw = self->private_impl.c_read_array[0].v_raw;
+    v_n = self->private_impl.c_read_array[0].v_n;
+    v_is_inline = self->private_impl.c_read_array[0].v_is_inline;
+    v_offset = self->private_impl.c_read_array[0].v_offset;
+    v_i = self->private_impl.c_read_array[0].v_i;
+    v_v = self->private_impl.c_read_array[0].v_v;
+    v_c = self->private_impl.c_read_array[0].v_c;
+    v_index = self->private_impl.c_read_array[0].v_index;
+  } else {
+    v_is_inline = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_field_type = self->private_impl.f_array_types[a_which];
+    v_count = self->private_impl.f_array_counts[a_which];
+    v_raw = self->private_impl.f_array_values[a_which];
+    v_n = v_count;
+    if (a_which == wuffs_tiff__which_bits_per_sample) {
+      if (v_count != self->private_impl.f_samples_per_pixel) {
+        status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+        goto exit;
+      }
+    } else if (a_which == wuffs_tiff__which_color_map) {
+      if (v_count !=
+          (((uint32_t)(3)) << self->private_impl.f_bits_per_sample)) {
+        status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+        goto exit;
+      }
+    } else {
+      v_n = wuffs_base__u32__min(v_count, self->private_impl.f_num_chunks);
+    }
+    v_is_inline = (v_count <= 1);
+    if (v_field_type == wuffs_tiff__field_type_short) {
+      v_is_inline = (v_count <= 2);
+    }
+    if (!v_is_inline) {
+      v_offset = wuffs_tiff__decoder__inline_element(self, v_raw,
+          wuffs_tiff__field_type_long, 0);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_tiff__decoder__seek(self, a_src, ((uint64_t)(v_offset)));
+      if (status) {
+        goto suspend;
+      }
+    }
+    v_i = 0;
+    v_v = 0;
+    v_c = 0;
+    v_index = 0;
+    while (v_i < v_n) {
+      if (v_is_inline) {
+        v_v = wuffs_tiff__decoder__inline_element(self, v_raw, v_field_type,
+            (v_i & 1));
+      } else if (v_field_type == wuffs_tiff__field_type_short) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_tiff__decoder__read_u16(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_v = self->private_impl.f_value;
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_tiff__decoder__read_u32(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_v = self->private_impl.f_value;
+      }
+      if (a_which == wuffs_tiff__which_offsets) {
+        wuffs_tiff__decoder__set_chunk_value(self, a_workbuf,
+            wuffs_tiff__which_offsets, v_i, v_v);
+      } else if (a_which == wuffs_tiff__which_byte_counts) {
+        wuffs_tiff__decoder__set_chunk_value(self, a_workbuf,
+            wuffs_tiff__which_byte_counts, v_i, v_v);
+      } else if (a_which == wuffs_tiff__which_bits_per_sample) {
+        if (v_i == 0) {
+          self->private_impl.f_raw_bits_per_sample = v_v;
+        } else if (v_v != self->private_impl.f_raw_bits_per_sample) {
+          status = WUFFS_TIFF__ERROR_UNSUPPORTED_BIT_DEPTH;
+          goto exit;
+        }
+      } else {
+        v_c = 2;
+        v_index = v_i;
+        if (v_index >= (v_count / 3)) {
+          v_c = 1;
+          wuffs_base__u32__sat_sub_indirect(&v_index, (v_count / 3));
+          if (v_index >= (v_count / 3)) {
+            v_c = 0;
+            wuffs_base__u32__sat_sub_indirect(&v_index, (v_count / 3));
+          }
+        }
+        if (v_index < 256) {
+          self->private_impl.f_palette[((4 * v_index) + v_c)] =
+              ((uint8_t)(((v_v >> 8) & 255)));
+          self->private_impl.f_palette[((4 * v_index) + 3)] = 255;
+        }
+      }
+      wuffs_base__u32__sat_add_indirect(&v_i, 1);
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_array[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_array[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_read_array[0].v_field_type = v_field_type;
+  self->private_impl.c_read_array[0].v_count = v_count;
+  self->private_impl.c_read_array[0].v_raw = v_raw;
+  self->private_impl.c_read_array[0].v_n = v_n;
+  self->private_impl.c_read_array[0].v_is_inline = v_is_inline;
+  self->private_impl.c_read_array[0].v_offset = v_offset;
+  self->private_impl.c_read_array[0].v_i = v_i;
+  self->private_impl.c_read_array[0].v_v = v_v;
+  self->private_impl.c_read_array[0].v_c = v_c;
+  self->private_impl.c_read_array[0].v_index = v_index;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.configure
+
+static wuffs_tiff__status wuffs_tiff__decoder__configure(
+    wuffs_tiff__decoder* self) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t v_spp;
+  uint32_t v_bps;
+  uint32_t v_num_colors;
+  uint32_t v_compression;
+  uint64_t v_chunks_across;
+  uint64_t v_chunks_down;
+  uint64_t v_across;
+  uint64_t v_down;
+  uint64_t v_num_chunks;
+  uint64_t v_row_length;
+
+  if ((self->private_impl.f_raw_width == 0) || (self->private_impl.f_raw_width >
+      2147483647) || (self->private_impl.f_raw_height == 0) ||
+      (self->private_impl.f_raw_height > 2147483647)) {
+    status = WUFFS_TIFF__ERROR_BAD_HEADER;
+    goto exit;
+  }
+  self->private_impl.f_width = self->private_impl.f_raw_width;
+  self->private_impl.f_height = self->private_impl.f_raw_height;
+  v_spp = self->private_impl.f_samples_per_pixel;
+  v_bps = self->private_impl.f_raw_bits_per_sample;
+  v_num_colors = 1;
+  if (self->private_impl.f_raw_photometric == wuffs_tiff__photometric_rgb) {
+    v_num_colors = 3;
+  } else if ((self->private_impl.f_raw_photometric !=
+      wuffs_tiff__photometric_white_is_zero) &&
+      (self->private_impl.f_raw_photometric !=
+      wuffs_tiff__photometric_black_is_zero) &&
+      (self->private_impl.f_raw_photometric !=
+      wuffs_tiff__photometric_palette)) {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_PHOTOMETRIC_INTERPRETATION;
+    goto exit;
+  }
+  self->private_impl.f_photometric =
+      wuffs_base__u32__min(self->private_impl.f_raw_photometric, 3);
+  self->private_impl.f_alpha = 0;
+  if (v_spp == (v_num_colors + 1)) {
+    if (self->private_impl.f_raw_extra_samples ==
+        wuffs_tiff__extra_samples_associated_alpha) {
+      self->private_impl.f_alpha = 1;
+    } else if (self->private_impl.f_raw_extra_samples ==
+        wuffs_tiff__extra_samples_unassociated_alpha) {
+      self->private_impl.f_alpha = 2;
+    }
+    if ((self->private_impl.f_alpha != 0) &&
+        (self->private_impl.f_photometric == wuffs_tiff__photometric_palette)) {
+      status = WUFFS_TIFF__ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+      goto exit;
+    }
+  } else if (v_spp != v_num_colors) {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+    goto exit;
+  }
+  if ((v_spp > 1) && (self->private_impl.f_raw_planar_configuration != 1)) {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_PLANAR_CONFIGURATION;
+    goto exit;
+  }
+  if (v_bps == 8) {
+    self->private_impl.f_bits_per_sample = 8;
+  } else if ((v_spp == 1) && ((v_bps == 1) || (v_bps == 2) || (v_bps == 4))) {
+    self->private_impl.f_bits_per_sample = wuffs_base__u32__min(v_bps, 4);
+  } else {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_BIT_DEPTH;
+    goto exit;
+  }
+  v_compression = self->private_impl.f_raw_compression;
+  if (v_compression == wuffs_tiff__compression_old_deflate) {
+    v_compression = wuffs_tiff__compression_deflate;
+  }
+  if ((v_compression != wuffs_tiff__compression_none) &&
+      (v_compression != wuffs_tiff__compression_lzw) &&
+      (v_compression != wuffs_tiff__compression_deflate) &&
+      (v_compression != wuffs_tiff__compression_packbits)) {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_COMPRESSION;
+    goto exit;
+  }
+  self->private_impl.f_compression = v_compression;
+  if (self->private_impl.f_raw_predictor == 2) {
+    if (self->private_impl.f_bits_per_sample != 8) {
+      status = WUFFS_TIFF__ERROR_UNSUPPORTED_PREDICTOR;
+      goto exit;
+    }
+    self->private_impl.f_predictor = true;
+  } else if (self->private_impl.f_raw_predictor != 1) {
+    status = WUFFS_TIFF__ERROR_UNSUPPORTED_PREDICTOR;
+    goto exit;
+  }
+  v_chunks_across = 1;
+  v_chunks_down = 0;
+  if (self->private_impl.f_is_tiled) {
+    if ((self->private_impl.f_raw_tile_width == 0) ||
+        (self->private_impl.f_raw_tile_width > 2147483647) ||
+        (self->private_impl.f_raw_tile_height == 0) ||
+        (self->private_impl.f_raw_tile_height > 2147483647)) {
+      status = WUFFS_TIFF__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_chunk_width = self->private_impl.f_raw_tile_width;
+    self->private_impl.f_chunk_height = self->private_impl.f_raw_tile_height;
+    v_chunks_across = wuffs_tiff__decoder__div_round_up(self,
+        ((uint64_t)(self->private_impl.f_width)),
+        ((uint64_t)(self->private_impl.f_chunk_width)));
+  } else {
+    if (self->private_impl.f_raw_rows_per_strip == 0) {
+      status = WUFFS_TIFF__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_chunk_width = self->private_impl.f_width;
+    self->private_impl.f_chunk_height =
+        wuffs_base__u32__min(self->private_impl.f_raw_rows_per_strip,
+        self->private_impl.f_height);
+  }
+  v_chunks_down = wuffs_tiff__decoder__div_round_up(self,
+      ((uint64_t)(self->private_impl.f_height)),
+      ((uint64_t)(self->private_impl.f_chunk_height)));
+  if ((v_chunks_across > 2147483647) || (v_chunks_down > 2147483647)) {
+    status = WUFFS_TIFF__ERROR_BAD_HEADER;
+    goto exit;
+  }
+  v_across = v_chunks_across;
+  v_down = v_chunks_down;
+  v_num_chunks = (v_across * v_down);
+  if (v_num_chunks > 4294967295) {
+    status = WUFFS_TIFF__ERROR_BAD_IFD_ENTRY;
+    goto exit;
+  }
+  self->private_impl.f_chunks_across = ((uint32_t)(v_across));
+  self->private_impl.f_num_chunks = ((uint32_t)(v_num_chunks));
+  v_row_length = wuffs_tiff__decoder__div_round_up(self,
+      (((uint64_t)(self->private_impl.f_chunk_width)) *
+      ((uint64_t)((v_spp * self->private_impl.f_bits_per_sample)))), 8);
+  if (v_row_length > 32768) {
+    status = WUFFS_TIFF__ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH;
+    goto exit;
+  }
+  self->private_impl.f_row_length = ((uint32_t)(v_row_length));
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.div_round_up
+
+static uint64_t wuffs_tiff__decoder__div_round_up(wuffs_tiff__decoder* self,
+    uint64_t a_a, uint64_t a_b) {
+  if (a_b == 0) {
+    return 0;
+  }
+  return (((a_a + a_b) - 1) / a_b);
+}
+
+// -------- func decoder.set_gray_palette
+
+static void wuffs_tiff__decoder__set_gray_palette(wuffs_tiff__decoder* self) {
+  uint32_t v_max;
+  uint32_t v_scale;
+  uint32_t v_i;
+  uint32_t v_g;
+
+  v_max = ((((uint32_t)(1)) << self->private_impl.f_bits_per_sample) - 1);
+  v_scale = 255;
+  if (v_max > 0) {
+    v_scale = (255 / v_max);
+  }
+  v_i = 0;
+  v_g = 0;
+  while (v_i <= v_max) {
+    v_g = v_i;
+    if (self->private_impl.f_photometric ==
+        wuffs_tiff__photometric_white_is_zero) {
+      v_g = wuffs_base__u32__sat_sub(v_max, v_i);
+    }
+    v_g = ((v_g * v_scale) & 255);
+    self->private_impl.f_palette[((4 * v_i) + 0)] = ((uint8_t)(v_g));
+    self->private_impl.f_palette[((4 * v_i) + 1)] = ((uint8_t)(v_g));
+    self->private_impl.f_palette[((4 * v_i) + 2)] = ((uint8_t)(v_g));
+    self->private_impl.f_palette[((4 * v_i) + 3)] = 255;
+    v_i += 1;
+  }
+}
+
+// -------- func decoder.read_u16
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_u16(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_read_u16[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_big_endian) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        uint16_t t_1;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 2)) {
+          t_1 = wuffs_base__load_u16be(ioptr_src);
+          ioptr_src += 2;
+        } else {
+          self->private_impl.c_read_u16[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_read_u16[0].scratch;
+            uint32_t t_0 = *scratch & 0xFF;
+            *scratch >>= 8;
+            *scratch <<= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << (56 - t_0);
+            if (t_0 == 8) {
+              t_1 = *scratch >> (64 - 16);
+              break;
+            }
+            t_0 += 8;
+            *scratch |= ((uint64_t)(t_0));
+          }
+        }
+        self->private_impl.f_value = ((uint32_t)(t_1));
+      }
+    } else {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        uint16_t t_3;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 2)) {
+          t_3 = wuffs_base__load_u16le(ioptr_src);
+          ioptr_src += 2;
+        } else {
+          self->private_impl.c_read_u16[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_read_u16[0].scratch;
+            uint32_t t_2 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_2;
+            if (t_2 == 8) {
+              t_3 = *scratch;
+              break;
+            }
+            t_2 += 8;
+            *scratch |= ((uint64_t)(t_2)) << 56;
+          }
+        }
+        self->private_impl.f_value = ((uint32_t)(t_3));
+      }
+    }
+    wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 2);
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_u16[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_u16[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.read_u32
+
+static wuffs_tiff__status wuffs_tiff__decoder__read_u32(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_read_u32[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_big_endian) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        uint32_t t_1;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+          t_1 = wuffs_base__load_u32be(ioptr_src);
+          ioptr_src += 4;
+        } else {
+          self->private_impl.c_read_u32[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_read_u32[0].scratch;
+            uint32_t t_0 = *scratch & 0xFF;
+            *scratch >>= 8;
+            *scratch <<= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << (56 - t_0);
+            if (t_0 == 24) {
+              t_1 = *scratch >> (64 - 32);
+              break;
+            }
+            t_0 += 8;
+            *scratch |= ((uint64_t)(t_0));
+          }
+        }
+        self->private_impl.f_value = t_1;
+      }
+    } else {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        uint32_t t_3;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+          t_3 = wuffs_base__load_u32le(ioptr_src);
+          ioptr_src += 4;
+        } else {
+          self->private_impl.c_read_u32[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_read_u32[0].scratch;
+            uint32_t t_2 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_2;
+            if (t_2 == 24) {
+              t_3 = *scratch;
+              break;
+            }
+            t_2 += 8;
+            *scratch |= ((uint64_t)(t_2)) << 56;
+          }
+        }
+        self->private_impl.f_value = t_3;
+      }
+    }
+    wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 4);
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_u32[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_u32[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.seek
+
+static wuffs_tiff__status wuffs_tiff__decoder__seek(wuffs_tiff__decoder* self,
+    wuffs_base__io_reader a_src, uint64_t a_pos) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_seek[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (a_pos >= self->private_impl.f_pos) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      self->private_impl.c_seek[0].scratch = (a_pos - self->private_impl.f_pos);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      if (self->private_impl.c_seek[0].scratch > iobounds1_src - ioptr_src) {
+        self->private_impl.c_seek[0].scratch -= iobounds1_src - ioptr_src;
+        ioptr_src = iobounds1_src;
+        goto short_read_src;
+      }
+      ioptr_src += self->private_impl.c_seek[0].scratch;
+    } else {
+      self->private_impl.f_seek_pos = a_pos;
+      status = WUFFS_TIFF__SUSPENSION_SEEK_REQUIRED;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+    }
+    self->private_impl.f_pos = a_pos;
+
+    goto ok;
+  ok:
+    self->private_impl.c_seek[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_seek[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_frame
+
+wuffs_tiff__status wuffs_tiff__decoder__decode_frame(wuffs_tiff__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf) {
+  if (!self) {
+    return WUFFS_TIFF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+    return WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  wuffs_base__slice_u8 v_palette;
+  wuffs_base__rect_ie_u32 v_dirty_rect;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_palette = ((wuffs_base__slice_u8){});
+    v_dirty_rect = self->private_impl.c_decode_frame[0].v_dirty_rect;
+  } else {
+    v_palette = ((wuffs_base__slice_u8){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence == 0) {
+      status = WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    } else if (self->private_impl.f_call_sequence == 2) {
+      while (true) {
+        status = WUFFS_TIFF__SUSPENSION_END_OF_DATA;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+      }
+    }
+    if (((uint64_t)(a_workbuf.len)) <
+        (((uint64_t)(self->private_impl.f_num_chunks)) * 8)) {
+      status = WUFFS_TIFF__ERROR_BAD_WORKBUF_LENGTH;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_tiff__decoder__read_array(self, a_src, a_workbuf,
+        wuffs_tiff__which_offsets);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_tiff__decoder__read_array(self, a_src, a_workbuf,
+        wuffs_tiff__which_byte_counts);
+    if (status) {
+      goto suspend;
+    }
+    while (self->private_impl.f_chunk_index < self->private_impl.f_num_chunks) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_tiff__decoder__decode_chunk(self, a_dst, a_src, a_workbuf);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_base__u32__sat_add_indirect(&self->private_impl.f_chunk_index, 1);
+    }
+    v_palette = ((wuffs_base__slice_u8){});
+    v_dirty_rect = ((wuffs_base__rect_ie_u32){});
+    wuffs_base__rect_ie_u32__set_max_exclusive_x(&v_dirty_rect,
+        self->private_impl.f_width);
+    wuffs_base__rect_ie_u32__set_max_exclusive_y(&v_dirty_rect,
+        self->private_impl.f_height);
+    wuffs_base__image_buffer__update(a_dst, v_dirty_rect, 0, false, 0,
+        v_palette);
+    self->private_impl.f_call_sequence = 2;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame[0].v_dirty_rect = v_dirty_rect;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+// -------- func decoder.decode_chunk
+
+static wuffs_tiff__status wuffs_tiff__decoder__decode_chunk(
+    wuffs_tiff__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t v_c;
+  uint32_t v_offset;
+  uint64_t v_x0;
+  uint64_t v_y0;
+  uint64_t v_y;
+  uint64_t v_y_end;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_chunk[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_chunk[0].v_c;
+    v_offset = self->private_impl.c_decode_chunk[0].v_offset;
+    v_x0 = self->private_impl.c_decode_chunk[0].v_x0;
+    v_y0 = self->private_impl.c_decode_chunk[0].v_y0;
+    v_y = self->private_impl.c_decode_chunk[0].v_y;
+    v_y_end = self->private_impl.c_decode_chunk[0].v_y_end;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = self->private_impl.f_chunk_index;
+    v_offset = wuffs_tiff__decoder__chunk_value(self, a_workbuf,
+        wuffs_tiff__which_offsets, v_c);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_tiff__decoder__seek(self, a_src, ((uint64_t)(v_offset)));
+    if (status) {
+      goto suspend;
+    }
+    self->private_impl.f_chunk_remaining =
+        ((uint64_t)(wuffs_tiff__decoder__chunk_value(self, a_workbuf,
+        wuffs_tiff__which_byte_counts, v_c)));
+    v_x0 = 0;
+    v_y0 = 0;
+    if (self->private_impl.f_chunks_across > 0) {
+      v_x0 = (((uint64_t)((v_c % self->private_impl.f_chunks_across))) *
+          ((uint64_t)(self->private_impl.f_chunk_width)));
+      v_y0 = (((uint64_t)((v_c / self->private_impl.f_chunks_across))) *
+          ((uint64_t)(self->private_impl.f_chunk_height)));
+    }
+    if (self->private_impl.f_compression == wuffs_tiff__compression_lzw) {
+      (memset(&self->private_impl.f_lzw, 0, sizeof((wuffs_lzw__decoder){})),
+          wuffs_lzw__decoder__check_wuffs_version(&self->private_impl.f_lzw,
+          sizeof((wuffs_lzw__decoder){}), WUFFS_VERSION),
+          wuffs_base__return_empty_struct());
+      wuffs_lzw__decoder__set_tiff_mode(&self->private_impl.f_lzw, true);
+    } else if (self->private_impl.f_compression ==
+        wuffs_tiff__compression_deflate) {
+      (memset(&self->private_impl.f_zlib, 0, sizeof((wuffs_zlib__decoder){})),
+          wuffs_zlib__decoder__check_wuffs_version(&self->private_impl.f_zlib,
+          sizeof((wuffs_zlib__decoder){}), WUFFS_VERSION),
+          wuffs_base__return_empty_struct());
+    }
+    self->private_impl.f_packbits_literal = 0;
+    self->private_impl.f_packbits_run = 0;
+    v_y = v_y0;
+    v_y_end = (v_y0 + ((uint64_t)(self->private_impl.f_chunk_height)));
+    if (v_y_end > ((uint64_t)(self->private_impl.f_height))) {
+      v_y_end = ((uint64_t)(self->private_impl.f_height));
+    }
+    while (v_y < v_y_end) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_tiff__decoder__fill_row(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      if (self->private_impl.f_predictor) {
+        wuffs_tiff__decoder__unpredict_row(self);
+      }
+      wuffs_tiff__decoder__swizzle_row(self, a_dst, v_x0, v_y);
+      v_y += 1;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_chunk[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_chunk[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_chunk[0].v_c = v_c;
+  self->private_impl.c_decode_chunk[0].v_offset = v_offset;
+  self->private_impl.c_decode_chunk[0].v_x0 = v_x0;
+  self->private_impl.c_decode_chunk[0].v_y0 = v_y0;
+  self->private_impl.c_decode_chunk[0].v_y = v_y;
+  self->private_impl.c_decode_chunk[0].v_y_end = v_y_end;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.set_chunk_value
+
+static void wuffs_tiff__decoder__set_chunk_value(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_i,
+    uint32_t a_v) {
+  uint64_t v_o;
+  uint8_t v_b[4];
+
+  v_o = ((((uint64_t)(a_i)) * 8) + ((uint64_t)((a_which * 4))));
+  memset(v_b, 0, sizeof(v_b));
+  v_b[0] = ((uint8_t)(((a_v >> 0) & 255)));
+  v_b[1] = ((uint8_t)(((a_v >> 8) & 255)));
+  v_b[2] = ((uint8_t)(((a_v >> 16) & 255)));
+  v_b[3] = ((uint8_t)(((a_v >> 24) & 255)));
+  if (v_o < ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(wuffs_base__slice_u8__subslice_i(
+        a_workbuf, v_o), ((wuffs_base__slice_u8){.ptr = v_b, .len = 4}));
+  }
+}
+
+// -------- func decoder.chunk_value
+
+static uint32_t wuffs_tiff__decoder__chunk_value(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_i) {
+  uint64_t v_o;
+  uint8_t v_b[4];
+
+  v_o = ((((uint64_t)(a_i)) * 8) + ((uint64_t)((a_which * 4))));
+  memset(v_b, 0, sizeof(v_b));
+  if (v_o < ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){.ptr = v_b,
+        .len = 4}), wuffs_base__slice_u8__subslice_i(a_workbuf, v_o));
+  }
+  return ((((uint32_t)(v_b[0])) << 0) | (((uint32_t)(v_b[1])) << 8) |
+      (((uint32_t)(v_b[2])) << 16) | (((uint32_t)(v_b[3])) << 24));
+}
+
+// -------- func decoder.fill_row
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint32_t coro_susp_point = self->private_impl.c_fill_row[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_row_wi = 0;
+    if (self->private_impl.f_compression == wuffs_tiff__compression_none) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_tiff__decoder__fill_row_none(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+    } else if (self->private_impl.f_compression ==
+        wuffs_tiff__compression_packbits) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_tiff__decoder__fill_row_packbits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_tiff__decoder__fill_row_lzw_or_deflate(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_row[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_row[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.fill_row_none
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_none(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint64_t v_n;
+  uint8_t v_c;
+  wuffs_base__io_writer v_w;
+  wuffs_base__io_buffer u_w;
+  uint8_t* ioptr_w = NULL;
+  uint8_t* iobounds1_w = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_w);
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_fill_row_none[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_fill_row_none[0].v_n;
+    v_c = self->private_impl.c_fill_row_none[0].v_c;
+    v_w = ((wuffs_base__io_writer){});
+  } else {
+    v_w = ((wuffs_base__io_writer){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n = 0;
+  label_0_continue:;
+    while (self->private_impl.f_row_wi < self->private_impl.f_row_length) {
+      if (self->private_impl.f_chunk_remaining == 0) {
+        status = WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA;
+        goto exit;
+      }
+      if (((uint64_t)(iobounds1_src - ioptr_src)) == 0) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_0 = *ioptr_src++;
+          v_c = t_0;
+        }
+        wuffs_base__u64__sat_sub_indirect(&self->private_impl.f_chunk_remaining,
+            1);
+        wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 1);
+        self->private_impl.f_row[self->private_impl.f_row_wi] = v_c;
+        self->private_impl.f_row_wi += 1;
+        goto label_0_continue;
+      }
+      v_w = ((wuffs_base__io_writer){});
+      {
+        wuffs_base__io_reader o_0_a_src = a_src;
+        wuffs_base__io_writer o_0_v_w = v_w;
+        uint8_t* o_0_ioptr_v_w = ioptr_w;
+        uint8_t* o_0_iobounds1_v_w = iobounds1_w;
+        wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+            wuffs_base__slice_u8__subslice_ij(((wuffs_base__slice_u8){
+            .ptr = self->private_impl.f_row, .len = 32772}),
+            self->private_impl.f_row_wi, self->private_impl.f_row_length));
+        wuffs_base__io_reader__set_limit(&a_src, ioptr_src,
+            self->private_impl.f_chunk_remaining);
+        wuffs_base__io_reader__set_mark(&a_src, ioptr_src);
+        wuffs_base__io_writer__copy_from_reader32(&ioptr_w, iobounds1_w,
+            &ioptr_src, iobounds1_src, 32768);
+        v_n = ((uint64_t)(((wuffs_base__slice_u8){
+            .ptr = a_src.private_impl.bounds[0],
+            .len = (size_t)(ioptr_src - a_src.private_impl.bounds[0])}).len));
+        wuffs_base__u64__sat_sub_indirect(&self->private_impl.f_chunk_remaining,
+            v_n);
+        wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, v_n);
+        self->private_impl.f_row_wi =
+            wuffs_base__u32__sat_sub(self->private_impl.f_row_length,
+            ((uint32_t)(
+            wuffs_base__u64__min(((uint64_t)(iobounds1_w - ioptr_w)), 32768))));
+        v_w = o_0_v_w;
+        ioptr_w = o_0_ioptr_v_w;
+        iobounds1_w = o_0_iobounds1_v_w;
+        a_src = o_0_a_src;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_row_none[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_row_none[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_row_none[0].v_n = v_n;
+  self->private_impl.c_fill_row_none[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.fill_row_packbits
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_packbits(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  uint8_t v_c;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_fill_row_packbits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_fill_row_packbits[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+  label_0_continue:;
+    while (self->private_impl.f_row_wi < self->private_impl.f_row_length) {
+      if (self->private_impl.f_packbits_run > 0) {
+        self->private_impl.f_row[self->private_impl.f_row_wi] =
+            self->private_impl.f_packbits_byte;
+        self->private_impl.f_row_wi += 1;
+        self->private_impl.f_packbits_run -= 1;
+        goto label_0_continue;
+      }
+      if (self->private_impl.f_chunk_remaining == 0) {
+        status = WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA;
+        goto exit;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_0 = *ioptr_src++;
+        v_c = t_0;
+      }
+      wuffs_base__u64__sat_sub_indirect(&self->private_impl.f_chunk_remaining,
+          1);
+      wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 1);
+      if (self->private_impl.f_packbits_literal > 0) {
+        self->private_impl.f_row[self->private_impl.f_row_wi] = v_c;
+        self->private_impl.f_row_wi += 1;
+        self->private_impl.f_packbits_literal -= 1;
+      } else if (v_c < 128) {
+        self->private_impl.f_packbits_literal = (((uint32_t)(v_c)) + 1);
+      } else if (v_c > 128) {
+        self->private_impl.f_packbits_run = (257 - ((uint32_t)(v_c)));
+        if (self->private_impl.f_chunk_remaining == 0) {
+          status = WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA;
+          goto exit;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_1 = *ioptr_src++;
+          self->private_impl.f_packbits_byte = t_1;
+        }
+        wuffs_base__u64__sat_sub_indirect(&self->private_impl.f_chunk_remaining,
+            1);
+        wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, 1);
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_row_packbits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_row_packbits[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_row_packbits[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_TIFF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_TIFF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.fill_row_lzw_or_deflate
+
+static wuffs_tiff__status wuffs_tiff__decoder__fill_row_lzw_or_deflate(
+    wuffs_tiff__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_tiff__status status = WUFFS_TIFF__STATUS_OK;
+
+  wuffs_tiff__status v_z;
+  uint64_t v_n;
+  wuffs_base__io_writer v_w;
+  wuffs_base__io_buffer u_w;
+  uint8_t* ioptr_w = NULL;
+  uint8_t* iobounds1_w = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(u_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(ioptr_w);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_w);
+  wuffs_tiff__status v_lzw_status;
+  wuffs_tiff__status v_zlib_status;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_z = self->private_impl.c_fill_row_lzw_or_deflate[0].v_z;
+    v_n = self->private_impl.c_fill_row_lzw_or_deflate[0].v_n;
+    v_w = ((wuffs_base__io_writer){});
+    v_lzw_status = self->private_impl.c_fill_row_lzw_or_deflate[0].v_lzw_status;
+    v_zlib_status =
+        self->private_impl.c_fill_row_lzw_or_deflate[0].v_zlib_status;
+  } else {
+    v_w = ((wuffs_base__io_writer){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_z = 0;
+    v_n = 0;
+  label_0_continue:;
+    while (true) {
+      if (self->private_impl.f_row_wi >= self->private_impl.f_row_length) {
+        status = WUFFS_TIFF__STATUS_OK;
+        goto ok;
+      }
+      v_w = ((wuffs_base__io_writer){});
+      {
+        wuffs_base__io_reader o_0_a_src = a_src;
+        wuffs_base__io_writer o_0_v_w = v_w;
+        uint8_t* o_0_ioptr_v_w = ioptr_w;
+        uint8_t* o_0_iobounds1_v_w = iobounds1_w;
+        wuffs_base__io_writer__set(&v_w, &u_w, &ioptr_w, &iobounds1_w,
+            wuffs_base__slice_u8__subslice_ij(((wuffs_base__slice_u8){
+            .ptr = self->private_impl.f_row, .len = 32772}),
+            self->private_impl.f_row_wi, self->private_impl.f_row_length));
+        wuffs_base__io_reader__set_limit(&a_src, ioptr_src,
+            self->private_impl.f_chunk_remaining);
+        wuffs_base__io_reader__set_mark(&a_src, ioptr_src);
+        if (self->private_impl.f_compression == wuffs_tiff__compression_lzw) {
+          {
+            u_w.wi = ioptr_w - u_w.ptr;
+            if (a_src.private_impl.buf) {
+              a_src.private_impl.buf->ri =
+                  ioptr_src - a_src.private_impl.buf->ptr;
+            }
+            wuffs_tiff__status t_0 =
+                wuffs_lzw__decoder__decode(&self->private_impl.f_lzw, v_w,
+                a_src);
+            ioptr_w = u_w.ptr + u_w.wi;
+            if (a_src.private_impl.buf) {
+              ioptr_src =
+                  a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+            }
+            v_lzw_status = t_0;
+          }
+          v_z = v_lzw_status;
+        } else {
+          {
+            u_w.wi = ioptr_w - u_w.ptr;
+            if (a_src.private_impl.buf) {
+              a_src.private_impl.buf->ri =
+                  ioptr_src - a_src.private_impl.buf->ptr;
+            }
+            wuffs_tiff__status t_1 =
+                wuffs_zlib__decoder__decode(&self->private_impl.f_zlib, v_w,
+                a_src);
+            ioptr_w = u_w.ptr + u_w.wi;
+            if (a_src.private_impl.buf) {
+              ioptr_src =
+                  a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+            }
+            v_zlib_status = t_1;
+          }
+          v_z = v_zlib_status;
+        }
+        v_n = ((uint64_t)(((wuffs_base__slice_u8){
+            .ptr = a_src.private_impl.bounds[0],
+            .len = (size_t)(ioptr_src - a_src.private_impl.bounds[0])}).len));
+        wuffs_base__u64__sat_sub_indirect(&self->private_impl.f_chunk_remaining,
+            v_n);
+        wuffs_base__u64__sat_add_indirect(&self->private_impl.f_pos, v_n);
+        self->private_impl.f_row_wi =
+            wuffs_base__u32__sat_sub(self->private_impl.f_row_length,
+            ((uint32_t)(
+            wuffs_base__u64__min(((uint64_t)(iobounds1_w - ioptr_w)), 32768))));
+        v_w = o_0_v_w;
+        ioptr_w = o_0_ioptr_v_w;
+        iobounds1_w = o_0_iobounds1_v_w;
+        a_src = o_0_a_src;
+      }
+      if (self->private_impl.f_row_wi >= self->private_impl.f_row_length) {
+        status = WUFFS_TIFF__STATUS_OK;
+        goto ok;
+      } else if ((v_z == 0) || ((self->private_impl.f_chunk_remaining == 0) &&
+          (v_z == WUFFS_TIFF__SUSPENSION_SHORT_READ))) {
+        status = WUFFS_TIFF__ERROR_NOT_ENOUGH_PIXEL_DATA;
+        goto exit;
+      } else if (v_z == WUFFS_TIFF__SUSPENSION_SHORT_WRITE) {
+        goto label_0_continue;
+      }
+      status = v_z;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_z = v_z;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_n = v_n;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_lzw_status = v_lzw_status;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_zlib_status = v_zlib_status;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+}
+
+// -------- func decoder.unpredict_row
+
+static void wuffs_tiff__decoder__unpredict_row(wuffs_tiff__decoder* self) {
+  uint32_t v_spp;
+  uint32_t v_n;
+  uint32_t v_i;
+
+  v_spp = self->private_impl.f_samples_per_pixel;
+  v_n = wuffs_base__u32__sat_sub(self->private_impl.f_row_length, v_spp);
+  v_i = 0;
+  while (v_i < v_n) {
+    self->private_impl.f_row[(v_i + v_spp)] += self->private_impl.f_row[v_i];
+    v_i += 1;
+  }
+}
+
+// -------- func decoder.swizzle_row
+
+static void wuffs_tiff__decoder__swizzle_row(wuffs_tiff__decoder* self,
+    wuffs_base__image_buffer* a_dst, uint64_t a_x0, uint64_t a_y) {
+  wuffs_base__table_u8 v_tab;
+  uint32_t v_y;
+  wuffs_base__slice_u8 v_dst;
+  uint32_t v_n;
+  uint32_t v_spp;
+  uint32_t v_bps;
+  uint32_t v_mask;
+  uint32_t v_shift;
+  uint32_t v_o;
+  uint64_t v_x;
+  uint64_t v_x_end;
+  uint32_t v_p;
+  uint32_t v_a;
+  uint8_t v_pixel[4];
+  uint64_t v_x4;
+
+  v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+  if (a_y >= ((uint64_t)(self->private_impl.f_height))) {
+    return;
+  } else if (a_y >= 2147483647) {
+    return;
+  }
+  v_y = ((uint32_t)(a_y));
+  v_dst = wuffs_base__table_u8__row(v_tab, v_y);
+  v_n = self->private_impl.f_row_length;
+  v_spp = self->private_impl.f_samples_per_pixel;
+  v_bps = self->private_impl.f_bits_per_sample;
+  v_mask = ((((uint32_t)(1)) << v_bps) - 1);
+  v_shift = (8 - v_bps);
+  v_o = 0;
+  v_x = a_x0;
+  v_x_end = (a_x0 + ((uint64_t)(self->private_impl.f_chunk_width)));
+  v_p = 0;
+  v_a = 0;
+  memset(v_pixel, 0, sizeof(v_pixel));
+  v_x4 = 0;
+  if (v_x_end > ((uint64_t)(self->private_impl.f_width))) {
+    v_x_end = ((uint64_t)(self->private_impl.f_width));
+  }
+  while ((v_x < v_x_end) && (v_o < v_n)) {
+    if (self->private_impl.f_photometric == wuffs_tiff__photometric_rgb) {
+      v_pixel[0] = self->private_impl.f_row[(v_o + 2)];
+      v_pixel[1] = self->private_impl.f_row[(v_o + 1)];
+      v_pixel[2] = self->private_impl.f_row[(v_o + 0)];
+      v_pixel[3] = 255;
+      if (self->private_impl.f_alpha != 0) {
+        v_a = ((uint32_t)(self->private_impl.f_row[(v_o + 3)]));
+        v_pixel[3] = ((uint8_t)(v_a));
+        if (self->private_impl.f_alpha ==
+            wuffs_tiff__extra_samples_associated_alpha) {
+          v_pixel[0] = wuffs_tiff__decoder__unpremultiply(self,
+              ((uint32_t)(self->private_impl.f_row[(v_o + 2)])), v_a);
+          v_pixel[1] = wuffs_tiff__decoder__unpremultiply(self,
+              ((uint32_t)(self->private_impl.f_row[(v_o + 1)])), v_a);
+          v_pixel[2] = wuffs_tiff__decoder__unpremultiply(self,
+              ((uint32_t)(self->private_impl.f_row[(v_o + 0)])), v_a);
+        }
+      }
+      v_o += v_spp;
+    } else {
+      v_p = ((((uint32_t)(self->private_impl.f_row[v_o])) >> v_shift) & v_mask);
+      v_pixel[0] = self->private_impl.f_palette[((4 * v_p) + 0)];
+      v_pixel[1] = self->private_impl.f_palette[((4 * v_p) + 1)];
+      v_pixel[2] = self->private_impl.f_palette[((4 * v_p) + 2)];
+      v_pixel[3] = self->private_impl.f_palette[((4 * v_p) + 3)];
+      if (v_bps == 8) {
+        if (self->private_impl.f_alpha != 0) {
+          v_pixel[3] = self->private_impl.f_row[(v_o + 1)];
+        }
+        v_o += v_spp;
+      } else if (v_shift >= v_bps) {
+        v_shift -= v_bps;
+      } else {
+        v_shift = (8 - v_bps);
+        v_o += 1;
+      }
+    }
+    v_x4 = (wuffs_base__u64__min(v_x, 2147483647) * 4);
+    if (v_x4 < ((uint64_t)(v_dst.len))) {
+      wuffs_base__slice_u8__copy_from_slice(wuffs_base__slice_u8__subslice_i(
+          v_dst, v_x4), ((wuffs_base__slice_u8){.ptr = v_pixel, .len = 4}));
+    }
+    v_x += 1;
+  }
+}
+
+// -------- func decoder.unpremultiply
+
+static uint8_t wuffs_tiff__decoder__unpremultiply(wuffs_tiff__decoder* self,
+    uint32_t a_v, uint32_t a_a) {
+  uint32_t v_x;
+
+  if (a_a == 0) {
+    return 0;
+  }
+  v_x = (((a_v * 255) + (a_a / 2)) / a_a);
+  if (v_x > 255) {
+    return 255;
+  }
+  return ((uint8_t)(v_x));
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_tiff__status wuffs_tiff__decoder__save_state(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_TIFF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_TIFF__DECODER__STATE_LENGTH) {
+    return WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_height));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_call_sequence);
+  p += 1;
+  p[0] = self->private_impl.f_big_endian ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_pos));
+  p += 8;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_seek_pos));
+  p += 8;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_value));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_height));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_raw_bits_per_sample));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_compression));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_photometric));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_raw_samples_per_pixel));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_raw_rows_per_strip));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_raw_planar_configuration));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_predictor));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_tile_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_raw_tile_height));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_raw_extra_samples));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_bits_per_sample));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_samples_per_pixel));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_compression));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_photometric));
+  p += 4;
+  p[0] = self->private_impl.f_predictor ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_alpha));
+  p += 4;
+  p[0] = self->private_impl.f_is_tiled ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunk_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunk_height));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunks_across));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_num_chunks));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunk_index));
+  p += 4;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_chunk_remaining));
+  p += 8;
+  for (i0 = 0; i0 < 4; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_array_types[i0]));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_array_counts[i0]));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_array_values[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_packbits_literal));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_packbits_run));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_packbits_byte);
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_row_length));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_row_wi));
+  p += 4;
+  for (i0 = 0; i0 < 32772; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_row[i0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 1024; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_palette[i0]);
+    p += 1;
+  }
+  {
+    int32_t z = wuffs_lzw__decoder__save_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__DECODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_LZW__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z = wuffs_zlib__decoder__save_state(&self->private_impl.f_zlib,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ZLIB__DECODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_ZLIB__DECODER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_magic));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_num_entries));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_config[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_tag));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_field_type));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_count));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_raw));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_which));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_entry[0].v_v));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_read_entry[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_field_type));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_count));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_raw));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_n));
+  p += 4;
+  p[0] = self->private_impl.c_read_array[0].v_is_inline ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_offset));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_v));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_c));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_array[0].v_index));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_u16[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_read_u16[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_u32[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_read_u32[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_seek[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.c_seek[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_frame[0].v_dirty_rect.min_inclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_frame[0].v_dirty_rect.min_inclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_frame[0].v_dirty_rect.max_exclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_decode_frame[0].v_dirty_rect.max_exclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_chunk[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_chunk[0].v_c));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_chunk[0].v_offset));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_chunk[0].v_x0));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_chunk[0].v_y0));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_chunk[0].v_y));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_chunk[0].v_y_end));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_row[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_row_none[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_fill_row_none[0].v_n));
+  p += 8;
+  p[0] = (uint8_t)(self->private_impl.c_fill_row_none[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_row_packbits[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_fill_row_packbits[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_row_lzw_or_deflate[0].v_z));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_fill_row_lzw_or_deflate[0].v_n));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_row_lzw_or_deflate[0].v_lzw_status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_fill_row_lzw_or_deflate[0].v_zlib_status));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x2A219818,
+      WUFFS_TIFF__DECODER__STATE_LENGTH);
+  return WUFFS_TIFF__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_tiff__status wuffs_tiff__decoder__restore_state(wuffs_tiff__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_TIFF__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_TIFF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x2A219818,
+      WUFFS_TIFF__DECODER__STATE_LENGTH)) {
+    return WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  self->private_impl.status = (wuffs_tiff__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_width > 2147483647) {
+    goto bad_state;
+  }
+  self->private_impl.f_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_height > 2147483647) {
+    goto bad_state;
+  }
+  self->private_impl.f_call_sequence = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_big_endian = p[0];
+  p += 1;
+  self->private_impl.f_pos = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.f_seek_pos = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.f_value = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_bits_per_sample =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_compression = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_photometric = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_samples_per_pixel =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_rows_per_strip =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_planar_configuration =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_predictor = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_tile_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_tile_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_raw_extra_samples =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_bits_per_sample = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_bits_per_sample > 8) {
+    goto bad_state;
+  }
+  self->private_impl.f_samples_per_pixel =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_samples_per_pixel > 4) {
+    goto bad_state;
+  }
+  self->private_impl.f_compression = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_photometric = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_photometric > 3) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_predictor = p[0];
+  p += 1;
+  self->private_impl.f_alpha = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_alpha > 2) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_is_tiled = p[0];
+  p += 1;
+  self->private_impl.f_chunk_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_chunk_width > 2147483647) {
+    goto bad_state;
+  }
+  self->private_impl.f_chunk_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_chunk_height > 2147483647) {
+    goto bad_state;
+  }
+  self->private_impl.f_chunks_across = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_chunks_across > 2147483647) {
+    goto bad_state;
+  }
+  self->private_impl.f_num_chunks = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_chunk_index = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_chunk_remaining = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  if (self->private_impl.f_chunk_remaining > 4294967295) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_array_types[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_array_counts[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_array_values[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  self->private_impl.f_packbits_literal = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_packbits_literal > 128) {
+    goto bad_state;
+  }
+  self->private_impl.f_packbits_run = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_packbits_run > 128) {
+    goto bad_state;
+  }
+  self->private_impl.f_packbits_byte = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_row_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_row_length > 32768) {
+    goto bad_state;
+  }
+  self->private_impl.f_row_wi = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_row_wi > 32768) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 32772; i0++) {
+    self->private_impl.f_row[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 1024; i0++) {
+    self->private_impl.f_palette[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  {
+    int32_t z = wuffs_lzw__decoder__restore_state(&self->private_impl.f_lzw,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_LZW__DECODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_LZW__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z = wuffs_zlib__decoder__restore_state(&self->private_impl.f_zlib,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ZLIB__DECODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_ZLIB__DECODER__STATE_LENGTH;
+  }
+  self->private_impl.c_decode_config[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_config[0].coro_susp_point > 9) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_config[0].v_magic =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].v_num_entries =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_read_entry[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_entry[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_entry[0].v_tag =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].v_field_type =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].v_count =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].v_raw =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].v_which =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].v_v =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_entry[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_read_array[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_array[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_array[0].v_field_type =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_count =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_raw =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_n =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.c_read_array[0].v_is_inline = p[0];
+  p += 1;
+  self->private_impl.c_read_array[0].v_offset =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_v =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_c =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_array[0].v_index =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_u16[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_u16[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_u16[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_read_u32[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_u32[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_u32[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_seek[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_seek[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_seek[0].scratch = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_frame[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_frame[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_frame[0].v_dirty_rect.min_inclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame[0].v_dirty_rect.min_inclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame[0].v_dirty_rect.max_exclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame[0].v_dirty_rect.max_exclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_chunk[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_chunk[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_chunk[0].v_c =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_chunk[0].v_offset =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_chunk[0].v_x0 =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_chunk[0].v_y0 =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_chunk[0].v_y =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_chunk[0].v_y_end =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_fill_row[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_row[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_row_none[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_row_none[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_row_none[0].v_n =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_fill_row_none[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_fill_row_packbits[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_row_packbits[0].coro_susp_point > 2) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_row_packbits[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_row_lzw_or_deflate[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_z =
+      (wuffs_tiff__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_n =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_lzw_status =
+      (wuffs_tiff__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_fill_row_lzw_or_deflate[0].v_zlib_status =
+      (wuffs_tiff__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_TIFF__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -0,0 +1,6801 @@
+#ifndef WUFFS_WEBP_H
+#define WUFFS_WEBP_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+#ifndef WUFFS_BASE_HEADER_H
+#define WUFFS_BASE_HEADER_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <stdbool.h>
+#include <stdint.h>
+#include <string.h>
+
+// Wuffs assumes that:
+//  - converting a uint32_t to a size_t will never overflow.
+//  - converting a size_t to a uint64_t will never overflow.
+#if (__WORDSIZE != 32) && (__WORDSIZE != 64)
+#error "Wuffs requires a word size of either 32 or 64 bits"
+#endif
+
+// WUFFS_VERSION is the major.minor version number as a uint32_t. The major
+// number is the high 16 bits. The minor number is the low 16 bits.
+//
+// The intention is to bump the version number at least on every API / ABI
+// backwards incompatible change.
+//
+// For now, the API and ABI are simply unstable and can change at any time.
+//
+// TODO: don't hard code this in base-header.h.
+#define WUFFS_VERSION ((uint32_t)0x00001)
+
+// wuffs_base__empty_struct is used when a Wuffs function returns an empty
+// struct. In C, if a function f returns void, you can't say "x = f()", but in
+// Wuffs, if a function g returns empty, you can say "y = g()".
+typedef struct {
+} wuffs_base__empty_struct;
+
+// ---------------- Numeric Types
+
+// Flicks are a unit of time. One flick (frame-tick) is 1 / 705_600_000 of a
+// second. See https://github.com/OculusVR/Flicks
+typedef int64_t wuffs_base__flicks;
+
+#define WUFFS_BASE__FLICKS_PER_SECOND ((uint64_t)705600000)
+#define WUFFS_BASE__FLICKS_PER_MILLISECOND ((uint64_t)705600)
+
+// --------
+
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint8_t wuffs_base__u8__max(uint8_t x, uint8_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint16_t wuffs_base__u16__min(uint16_t x, uint16_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint16_t wuffs_base__u16__max(uint16_t x, uint16_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint32_t wuffs_base__u32__min(uint32_t x, uint32_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint32_t wuffs_base__u32__max(uint32_t x, uint32_t y) {
+  return x > y ? x : y;
+}
+
+static inline uint64_t wuffs_base__u64__min(uint64_t x, uint64_t y) {
+  return x < y ? x : y;
+}
+
+static inline uint64_t wuffs_base__u64__max(uint64_t x, uint64_t y) {
+  return x > y ? x : y;
+}
+
+// --------
+
+// Saturating arithmetic (sat_add, sat_sub) branchless bit-twiddling algorithms
+// are per https://locklessinc.com/articles/sat_arithmetic/
+//
+// It is important that the underlying types are unsigned integers, as signed
+// integer arithmetic overflow is undefined behavior in C.
+
+static inline uint8_t wuffs_base__u8__sat_add(uint8_t x, uint8_t y) {
+  uint8_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint8_t wuffs_base__u8__sat_sub(uint8_t x, uint8_t y) {
+  uint8_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint16_t wuffs_base__u16__sat_add(uint16_t x, uint16_t y) {
+  uint16_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint16_t wuffs_base__u16__sat_sub(uint16_t x, uint16_t y) {
+  uint16_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint32_t wuffs_base__u32__sat_add(uint32_t x, uint32_t y) {
+  uint32_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint32_t wuffs_base__u32__sat_sub(uint32_t x, uint32_t y) {
+  uint32_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+static inline uint64_t wuffs_base__u64__sat_add(uint64_t x, uint64_t y) {
+  uint64_t res = x + y;
+  res |= -(res < x);
+  return res;
+}
+
+static inline uint64_t wuffs_base__u64__sat_sub(uint64_t x, uint64_t y) {
+  uint64_t res = x - y;
+  res &= -(res <= x);
+  return res;
+}
+
+// --------
+
+// Clang also defines "__GNUC__".
+
+static inline uint16_t wuffs_base__u16__byte_swapped(uint16_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap16(x);
+#else
+  return (x >> 8) | (x << 8);
+#endif
+}
+
+static inline uint32_t wuffs_base__u32__byte_swapped(uint32_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap32(x);
+#else
+  static const uint32_t mask8 = 0x00FF00FF;
+  x = ((x >> 8) & mask8) | ((x & mask8) << 8);
+  return (x >> 16) | (x << 16);
+#endif
+}
+
+static inline uint64_t wuffs_base__u64__byte_swapped(uint64_t x) {
+#if defined(__GNUC__)
+  return __builtin_bswap64(x);
+#else
+  static const uint64_t mask8 = 0x00FF00FF00FF00FF;
+  static const uint64_t mask16 = 0x0000FFFF0000FFFF;
+  x = ((x >> 8) & mask8) | ((x & mask8) << 8);
+  x = ((x >> 16) & mask16) | ((x & mask16) << 16);
+  return (x >> 32) | (x << 32);
+#endif
+}
+
+// ---------------- Slices and Tables
+
+// WUFFS_BASE__SLICE is a 1-dimensional buffer.
+//
+// A value with all fields NULL or zero is a valid, empty slice.
+#define WUFFS_BASE__SLICE(T) \
+  struct {                   \
+    T* ptr;                  \
+    size_t len;              \
+  }
+
+// WUFFS_BASE__TABLE is a 2-dimensional buffer.
+//
+// A value with all fields NULL or zero is a valid, empty table.
+#define WUFFS_BASE__TABLE(T) \
+  struct {                   \
+    T* ptr;                  \
+    size_t width;            \
+    size_t height;           \
+    size_t stride;           \
+  }
+
+typedef WUFFS_BASE__SLICE(uint8_t) wuffs_base__slice_u8;
+typedef WUFFS_BASE__SLICE(uint16_t) wuffs_base__slice_u16;
+typedef WUFFS_BASE__SLICE(uint32_t) wuffs_base__slice_u32;
+typedef WUFFS_BASE__SLICE(uint64_t) wuffs_base__slice_u64;
+
+typedef WUFFS_BASE__TABLE(uint8_t) wuffs_base__table_u8;
+typedef WUFFS_BASE__TABLE(uint16_t) wuffs_base__table_u16;
+typedef WUFFS_BASE__TABLE(uint32_t) wuffs_base__table_u32;
+typedef WUFFS_BASE__TABLE(uint64_t) wuffs_base__table_u64;
+
+// ---------------- Ranges and Rects
+
+// Ranges are either inclusive ("range_ii") or exclusive ("range_ie") on the
+// high end. Both the "ii" and "ie" flavors are useful in practice.
+//
+// The "ei" and "ee" flavors also exist in theory, but aren't widely used. In
+// Wuffs, the low end is always inclusive.
+//
+// The "ii" (closed interval) flavor is useful when refining e.g. "the set of
+// all uint32_t values" to a contiguous subset: "uint32_t values in the closed
+// interval [M, N]", for uint32_t values M and N. An unrefined type (in other
+// words, the set of all uint32_t values) is not representable in the "ie"
+// flavor because if N equals ((1<<32) - 1) then (N + 1) will overflow.
+//
+// On the other hand, the "ie" (half-open interval) flavor is recommended by
+// Dijkstra's "Why numbering should start at zero" at
+// http://www.cs.utexas.edu/users/EWD/ewd08xx/EWD831.PDF and a further
+// discussion of motivating rationale is at
+// https://www.quora.com/Why-are-Python-ranges-half-open-exclusive-instead-of-closed-inclusive
+//
+// For example, with "ie", the number of elements in "uint32_t values in the
+// half-open interval [M, N)" is equal to max(0, N-M). Furthermore, that number
+// of elements (in one dimension, a length, in two dimensions, a width or
+// height) is itself representable as a uint32_t without overflow, again for
+// uint32_t values M and N. In the contrasting "ii" flavor, the length of the
+// closed interval [0, (1<<32) - 1] is 1<<32, which cannot be represented as a
+// uint32_t. In Wuffs, because of this potential overflow, the "ie" flavor has
+// length / width / height methods, but the "ii" flavor does not.
+//
+// It is valid for min > max (for range_ii) or for min >= max (for range_ie),
+// in which case the range is empty. There are multiple representations of an
+// empty range.
+
+typedef struct {
+  uint32_t min_inclusive;
+  uint32_t max_inclusive;
+} wuffs_base__range_ii_u32;
+
+static inline bool wuffs_base__range_ii_u32__is_empty(
+    wuffs_base__range_ii_u32 r) {
+  return r.min_inclusive > r.max_inclusive;
+}
+
+static inline bool wuffs_base__range_ii_u32__equals(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_inclusive == s.max_inclusive) ||
+         (wuffs_base__range_ii_u32__is_empty(r) &&
+          wuffs_base__range_ii_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ii_u32__contains(
+    wuffs_base__range_ii_u32 r,
+    uint32_t x) {
+  return (r.min_inclusive <= x) && (x <= r.max_inclusive);
+}
+
+static inline wuffs_base__range_ii_u32 wuffs_base__range_ii_u32__intersection(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  r.min_inclusive = wuffs_base__u32__max(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u32__min(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ii_u32 wuffs_base__range_ii_u32__union(
+    wuffs_base__range_ii_u32 r,
+    wuffs_base__range_ii_u32 s) {
+  if (wuffs_base__range_ii_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ii_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u32__min(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u32__max(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+// --------
+
+typedef struct {
+  uint32_t min_inclusive;
+  uint32_t max_exclusive;
+} wuffs_base__range_ie_u32;
+
+static inline bool wuffs_base__range_ie_u32__is_empty(
+    wuffs_base__range_ie_u32 r) {
+  return r.min_inclusive >= r.max_exclusive;
+}
+
+static inline bool wuffs_base__range_ie_u32__equals(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_exclusive == s.max_exclusive) ||
+         (wuffs_base__range_ie_u32__is_empty(r) &&
+          wuffs_base__range_ie_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ie_u32__contains(
+    wuffs_base__range_ie_u32 r,
+    uint32_t x) {
+  return (r.min_inclusive <= x) && (x < r.max_exclusive);
+}
+
+static inline wuffs_base__range_ie_u32 wuffs_base__range_ie_u32__intersection(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  r.min_inclusive = wuffs_base__u32__max(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u32__min(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ie_u32 wuffs_base__range_ie_u32__union(
+    wuffs_base__range_ie_u32 r,
+    wuffs_base__range_ie_u32 s) {
+  if (wuffs_base__range_ie_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ie_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u32__min(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u32__max(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline uint32_t wuffs_base__range_ie_u32__length(
+    wuffs_base__range_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive, r.min_inclusive);
+}
+
+// --------
+
+typedef struct {
+  uint64_t min_inclusive;
+  uint64_t max_inclusive;
+} wuffs_base__range_ii_u64;
+
+static inline bool wuffs_base__range_ii_u64__is_empty(
+    wuffs_base__range_ii_u64 r) {
+  return r.min_inclusive > r.max_inclusive;
+}
+
+static inline bool wuffs_base__range_ii_u64__equals(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_inclusive == s.max_inclusive) ||
+         (wuffs_base__range_ii_u64__is_empty(r) &&
+          wuffs_base__range_ii_u64__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ii_u64__contains(
+    wuffs_base__range_ii_u64 r,
+    uint64_t x) {
+  return (r.min_inclusive <= x) && (x <= r.max_inclusive);
+}
+
+static inline wuffs_base__range_ii_u64 wuffs_base__range_ii_u64__intersection(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  r.min_inclusive = wuffs_base__u64__max(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u64__min(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ii_u64 wuffs_base__range_ii_u64__union(
+    wuffs_base__range_ii_u64 r,
+    wuffs_base__range_ii_u64 s) {
+  if (wuffs_base__range_ii_u64__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ii_u64__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u64__min(r.min_inclusive, s.min_inclusive);
+  r.max_inclusive = wuffs_base__u64__max(r.max_inclusive, s.max_inclusive);
+  return r;
+}
+
+// --------
+
+typedef struct {
+  uint64_t min_inclusive;
+  uint64_t max_exclusive;
+} wuffs_base__range_ie_u64;
+
+static inline bool wuffs_base__range_ie_u64__is_empty(
+    wuffs_base__range_ie_u64 r) {
+  return r.min_inclusive >= r.max_exclusive;
+}
+
+static inline bool wuffs_base__range_ie_u64__equals(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  return (r.min_inclusive == s.min_inclusive &&
+          r.max_exclusive == s.max_exclusive) ||
+         (wuffs_base__range_ie_u64__is_empty(r) &&
+          wuffs_base__range_ie_u64__is_empty(s));
+}
+
+static inline bool wuffs_base__range_ie_u64__contains(
+    wuffs_base__range_ie_u64 r,
+    uint64_t x) {
+  return (r.min_inclusive <= x) && (x < r.max_exclusive);
+}
+
+static inline wuffs_base__range_ie_u64 wuffs_base__range_ie_u64__intersection(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  r.min_inclusive = wuffs_base__u64__max(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u64__min(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline wuffs_base__range_ie_u64 wuffs_base__range_ie_u64__union(
+    wuffs_base__range_ie_u64 r,
+    wuffs_base__range_ie_u64 s) {
+  if (wuffs_base__range_ie_u64__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__range_ie_u64__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive = wuffs_base__u64__min(r.min_inclusive, s.min_inclusive);
+  r.max_exclusive = wuffs_base__u64__max(r.max_exclusive, s.max_exclusive);
+  return r;
+}
+
+static inline uint64_t wuffs_base__range_ie_u64__length(
+    wuffs_base__range_ie_u64 r) {
+  return wuffs_base__u64__sat_sub(r.max_exclusive, r.min_inclusive);
+}
+
+// --------
+
+// wuffs_base__rect_ii_u32 is a rectangle (a 2-dimensional range) on the
+// integer grid. The "ii" means that the bounds are inclusive on the low end
+// and inclusive on the high end. It contains all points (x, y) such that
+// ((min_inclusive_x <= x) && (x <= max_inclusive_x)) and likewise for y.
+//
+// It is valid for min > max, in which case the rectangle is empty. There are
+// multiple representations of an empty rectangle.
+//
+// The X and Y axes increase right and down.
+typedef struct {
+  uint32_t min_inclusive_x;
+  uint32_t min_inclusive_y;
+  uint32_t max_inclusive_x;
+  uint32_t max_inclusive_y;
+} wuffs_base__rect_ii_u32;
+
+static inline bool wuffs_base__rect_ii_u32__is_empty(
+    wuffs_base__rect_ii_u32 r) {
+  return (r.min_inclusive_x > r.max_inclusive_x) ||
+         (r.min_inclusive_y > r.max_inclusive_y);
+}
+
+static inline bool wuffs_base__rect_ii_u32__equals(wuffs_base__rect_ii_u32 r,
+                                                   wuffs_base__rect_ii_u32 s) {
+  return (r.min_inclusive_x == s.min_inclusive_x &&
+          r.min_inclusive_y == s.min_inclusive_y &&
+          r.max_inclusive_x == s.max_inclusive_x &&
+          r.max_inclusive_y == s.max_inclusive_y) ||
+         (wuffs_base__rect_ii_u32__is_empty(r) &&
+          wuffs_base__rect_ii_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__rect_ii_u32__contains(wuffs_base__rect_ii_u32 r,
+                                                     uint32_t x,
+                                                     uint32_t y) {
+  return (r.min_inclusive_x <= x) && (x <= r.max_inclusive_x) &&
+         (r.min_inclusive_y <= y) && (y <= r.max_inclusive_y);
+}
+
+static inline wuffs_base__rect_ii_u32 wuffs_base__rect_ii_u32__intersection(
+    wuffs_base__rect_ii_u32 r,
+    wuffs_base__rect_ii_u32 s) {
+  r.min_inclusive_x =
+      wuffs_base__u32__max(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__max(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_inclusive_x =
+      wuffs_base__u32__min(r.max_inclusive_x, s.max_inclusive_x);
+  r.max_inclusive_y =
+      wuffs_base__u32__min(r.max_inclusive_y, s.max_inclusive_y);
+  return r;
+}
+
+static inline wuffs_base__rect_ii_u32 wuffs_base__rect_ii_u32__union(
+    wuffs_base__rect_ii_u32 r,
+    wuffs_base__rect_ii_u32 s) {
+  if (wuffs_base__rect_ii_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__rect_ii_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive_x =
+      wuffs_base__u32__min(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__min(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_inclusive_x =
+      wuffs_base__u32__max(r.max_inclusive_x, s.max_inclusive_x);
+  r.max_inclusive_y =
+      wuffs_base__u32__max(r.max_inclusive_y, s.max_inclusive_y);
+  return r;
+}
+
+// --------
+
+// wuffs_base__rect_ie_u32 is a rectangle (a 2-dimensional range) on the
+// integer grid. The "ie" means that the bounds are inclusive on the low end
+// and exclusive on the high end. It contains all points (x, y) such that
+// ((min_inclusive_x <= x) && (x < max_exclusive_x)) and likewise for y.
+//
+// It is valid for min >= max, in which case the rectangle is empty. There are
+// multiple representations of an empty rectangle, including a value with all
+// fields zero.
+//
+// The X and Y axes increase right and down.
+typedef struct {
+  uint32_t min_inclusive_x;
+  uint32_t min_inclusive_y;
+  uint32_t max_exclusive_x;
+  uint32_t max_exclusive_y;
+} wuffs_base__rect_ie_u32;
+
+static inline bool wuffs_base__rect_ie_u32__is_empty(
+    wuffs_base__rect_ie_u32 r) {
+  return (r.min_inclusive_x >= r.max_exclusive_x) ||
+         (r.min_inclusive_y >= r.max_exclusive_y);
+}
+
+static inline bool wuffs_base__rect_ie_u32__equals(wuffs_base__rect_ie_u32 r,
+                                                   wuffs_base__rect_ie_u32 s) {
+  return (r.min_inclusive_x == s.min_inclusive_x &&
+          r.min_inclusive_y == s.min_inclusive_y &&
+          r.max_exclusive_x == s.max_exclusive_x &&
+          r.max_exclusive_y == s.max_exclusive_y) ||
+         (wuffs_base__rect_ie_u32__is_empty(r) &&
+          wuffs_base__rect_ie_u32__is_empty(s));
+}
+
+static inline bool wuffs_base__rect_ie_u32__contains(wuffs_base__rect_ie_u32 r,
+                                                     uint32_t x,
+                                                     uint32_t y) {
+  return (r.min_inclusive_x <= x) && (x < r.max_exclusive_x) &&
+         (r.min_inclusive_y <= y) && (y < r.max_exclusive_y);
+}
+
+static inline wuffs_base__rect_ie_u32 wuffs_base__rect_ie_u32__intersection(
+    wuffs_base__rect_ie_u32 r,
+    wuffs_base__rect_ie_u32 s) {
+  r.min_inclusive_x =
+      wuffs_base__u32__max(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__max(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_exclusive_x =
+      wuffs_base__u32__min(r.max_exclusive_x, s.max_exclusive_x);
+  r.max_exclusive_y =
+      wuffs_base__u32__min(r.max_exclusive_y, s.max_exclusive_y);
+  return r;
+}
+
+static inline wuffs_base__rect_ie_u32 wuffs_base__rect_ie_u32__union(
+    wuffs_base__rect_ie_u32 r,
+    wuffs_base__rect_ie_u32 s) {
+  if (wuffs_base__rect_ie_u32__is_empty(r)) {
+    return s;
+  }
+  if (wuffs_base__rect_ie_u32__is_empty(s)) {
+    return r;
+  }
+  r.min_inclusive_x =
+      wuffs_base__u32__min(r.min_inclusive_x, s.min_inclusive_x);
+  r.min_inclusive_y =
+      wuffs_base__u32__min(r.min_inclusive_y, s.min_inclusive_y);
+  r.max_exclusive_x =
+      wuffs_base__u32__max(r.max_exclusive_x, s.max_exclusive_x);
+  r.max_exclusive_y =
+      wuffs_base__u32__max(r.max_exclusive_y, s.max_exclusive_y);
+  return r;
+}
+
+static inline uint32_t wuffs_base__rect_ie_u32__width(
+    wuffs_base__rect_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive_x, r.min_inclusive_x);
+}
+
+static inline uint32_t wuffs_base__rect_ie_u32__height(
+    wuffs_base__rect_ie_u32 r) {
+  return wuffs_base__u32__sat_sub(r.max_exclusive_y, r.min_inclusive_y);
+}
+
+// ---------------- I/O
+
+// wuffs_base__io_buffer is a 1-dimensional buffer (a pointer and length), plus
+// additional indexes into that buffer, plus an opened / closed flag.
+//
+// A value with all fields NULL or zero is a valid, empty buffer.
+typedef struct {
+  uint8_t* ptr;  // Pointer.
+  size_t len;    // Length.
+  size_t wi;     // Write index. Invariant: wi <= len.
+  size_t ri;     // Read  index. Invariant: ri <= wi.
+  bool closed;   // No further writes are expected.
+} wuffs_base__io_buffer;
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__io_buffer* buf;
+    // The bounds values are typically NULL, when created by the Wuffs public
+    // API. NULL means that the callee substitutes the implicit bounds derived
+    // from buf.
+    uint8_t* bounds[2];
+  } private_impl;
+} wuffs_base__io_reader;
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__io_buffer* buf;
+    // The bounds values are typically NULL, when created by the Wuffs public
+    // API. NULL means that the callee substitutes the implicit bounds derived
+    // from buf.
+    uint8_t* bounds[2];
+  } private_impl;
+} wuffs_base__io_writer;
+
+static inline wuffs_base__io_reader wuffs_base__io_buffer__reader(
+    wuffs_base__io_buffer* buf) {
+  wuffs_base__io_reader ret = ((wuffs_base__io_reader){});
+  ret.private_impl.buf = buf;
+  return ret;
+}
+
+static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
+    wuffs_base__io_buffer* buf) {
+  wuffs_base__io_writer ret = ((wuffs_base__io_writer){});
+  ret.private_impl.buf = buf;
+  return ret;
+}
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+// ---------------- Images
+
+// wuffs_base__pixel_format encodes the format of the bytes that constitute an
+// image frame's pixel data. Its bits:
+//  - bit        31  is reserved.
+//  - bits 30 .. 28 encodes color (and channel order, in terms of memory).
+//  - bits 27 .. 26 are reserved.
+//  - bits 25 .. 24 encodes transparency.
+//  - bit        23 indicates big-endian/MSB-first (as opposed to little/LSB).
+//  - bit        22 indicates floating point (as opposed to integer).
+//  - bits 21 .. 20 are the number of planes, minus 1. Zero means packed.
+//  - bits 19 .. 16 encodes the number of bits (depth) in an index value.
+//                  Zero means direct, not palette-indexed.
+//  - bits 15 .. 12 encodes the number of bits (depth) in the 3rd channel.
+//  - bits 11 ..  8 encodes the number of bits (depth) in the 2nd channel.
+//  - bits  7 ..  4 encodes the number of bits (depth) in the 1st channel.
+//  - bits  3 ..  0 encodes the number of bits (depth) in the 0th channel.
+//
+// The bit fields of a wuffs_base__pixel_format are not independent. For
+// example, the number of planes should not be greater than the number of
+// channels. Similarly, bits 15..4 are unused (and should be zero) if bits
+// 31..24 (color and transparency) together imply only 1 channel (gray, no
+// alpha) and floating point samples should mean a bit depth of 16, 32 or 64.
+//
+// Formats hold between 1 and 4 channels. For example: Y (1 channel: gray), YA
+// (2 channels: gray and alpha), BGR (3 channels: blue, green, red) or CMYK (4
+// channels: cyan, magenta, yellow, black).
+//
+// For direct formats with N > 1 channels, those channels can be laid out in
+// either 1 (packed) or N (planar) planes. For example, RGBA data is usually
+// packed, but YUV data is usually planar, due to chroma subsampling (for
+// details, see the wuffs_base__pixel_subsampling type). For indexed formats,
+// the palette (always 256 × 4 bytes) holds up to 4 packed bytes of color data
+// per index value, and there is only 1 plane (for the index). The distance
+// between successive palette elements is always 4 bytes.
+//
+// The color field is encoded in 3 bits:
+//  - 0 means                 A (Alpha).
+//  - 1 means   Y       or   YA (Gray, Alpha).
+//  - 2 means BGR, BGRX or BGRA (Blue, Green, Red, X-padding or Alpha).
+//  - 3 means RGB, RGBX or RGBA (Red, Green, Blue, X-padding or Alpha).
+//  - 4 means YUV       or YUVA (Luma, Chroma-blue, Chroma-red, Alpha).
+//  - 5 means CMY       or CMYK (Cyan, Magenta, Yellow, Black).
+//  - all other values are reserved.
+//
+// In Wuffs, channels are given in memory order, regardless of endianness,
+// since the C type for the pixel data is an array of bytes, not an array of
+// uint32_t. For example, packed BGRA with 8 bits per channel means that the
+// bytes in memory are always Blue, Green, Red then Alpha. On big-endian
+// systems, that is the uint32_t 0xBBGGRRAA. On little-endian, 0xAARRGGBB.
+//
+// When the color field (3 bits) encodes multiple options, the transparency
+// field (2 bits) distinguishes them:
+//  - 0 means fully opaque, no extra channels
+//  - 1 means fully opaque, one extra channel (X or K, padding or black).
+//  - 2 means one extra alpha channel, other channels are non-premultiplied.
+//  - 3 means one extra alpha channel, other channels are     premultiplied.
+//
+// The zero wuffs_base__pixel_format value is an invalid pixel format, as it is
+// invalid to combine the zero color (alpha only) with the zero transparency.
+//
+// Bit depth is encoded in 4 bits:
+//  -  0 means the channel or index is unused.
+//  -  x means a bit depth of  x, for x in the range 1..8.
+//  -  9 means a bit depth of 10.
+//  - 10 means a bit depth of 12.
+//  - 11 means a bit depth of 16.
+//  - 12 means a bit depth of 24.
+//  - 13 means a bit depth of 32.
+//  - 14 means a bit depth of 48.
+//  - 15 means a bit depth of 64.
+//
+// For example, wuffs_base__pixel_format 0x3280BBBB is a natural format for
+// decoding a PNG image - network byte order (also known as big-endian),
+// packed, non-premultiplied alpha - that happens to be 16-bit-depth truecolor
+// with alpha (RGBA). In memory order:
+//
+//  ptr+0  ptr+1  ptr+2  ptr+3  ptr+4  ptr+5  ptr+6  ptr+7
+//  Rhi    Rlo    Ghi    Glo    Bhi    Blo    Ahi    Alo
+//
+// For example, the value wuffs_base__pixel_format 0x20000565 means BGR with no
+// alpha or padding, 5/6/5 bits for blue/green/red, packed 2 bytes per pixel,
+// laid out LSB-first in memory order:
+//
+//  ptr+0...........  ptr+1...........
+//  MSB          LSB  MSB          LSB
+//  G₂G₁G₀B₄B₃B₂B₁B₀  R₄R₃R₂R₁R₀G₅G₄G₃
+//
+// On little-endian systems (but not big-endian), this Wuffs pixel format value
+// (0x20000565) corresponds to the Cairo library's CAIRO_FORMAT_RGB16_565, the
+// SDL2 (Simple DirectMedia Layer 2) library's SDL_PIXELFORMAT_RGB565 and the
+// Skia library's kRGB_565_SkColorType. Note BGR in Wuffs versus RGB in the
+// other libraries.
+//
+// Regardless of endianness, this Wuffs pixel format value (0x20000565)
+// corresponds to the V4L2 (Video For Linux 2) library's V4L2_PIX_FMT_RGB565
+// and the Wayland-DRM library's WL_DRM_FORMAT_RGB565.
+//
+// Different software libraries name their pixel formats (and especially their
+// channel order) either according to memory layout or as bits of a native
+// integer type like uint32_t. The two conventions differ because of a system's
+// endianness. As mentioned earlier, Wuffs pixel formats are always in memory
+// order. More detail of other software libraries' naming conventions is in the
+// Pixel Format Guide at https://afrantzis.github.io/pixel-format-guide/
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_base__pixel_format__num_planes instead.
+typedef uint32_t wuffs_base__pixel_format;
+
+// Common 8-bit-depth pixel formats. This list is not exhaustive; not all valid
+// wuffs_base__pixel_format values are present.
+
+#define WUFFS_BASE__PIXEL_FORMAT__INVALID ((wuffs_base__pixel_format)0x00000000)
+
+#define WUFFS_BASE__PIXEL_FORMAT__A ((wuffs_base__pixel_format)0x02000008)
+
+#define WUFFS_BASE__PIXEL_FORMAT__Y ((wuffs_base__pixel_format)0x10000008)
+#define WUFFS_BASE__PIXEL_FORMAT__YA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x12000008)
+#define WUFFS_BASE__PIXEL_FORMAT__YA_PREMUL \
+  ((wuffs_base__pixel_format)0x13000008)
+
+#define WUFFS_BASE__PIXEL_FORMAT__BGR ((wuffs_base__pixel_format)0x20000888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRX ((wuffs_base__pixel_format)0x21008888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRX_INDEXED \
+  ((wuffs_base__pixel_format)0x21088888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x22008888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL_INDEXED \
+  ((wuffs_base__pixel_format)0x22088888)
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA_PREMUL \
+  ((wuffs_base__pixel_format)0x23008888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__RGB ((wuffs_base__pixel_format)0x30000888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBX ((wuffs_base__pixel_format)0x31008888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBX_INDEXED \
+  ((wuffs_base__pixel_format)0x31088888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x32008888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_NONPREMUL_INDEXED \
+  ((wuffs_base__pixel_format)0x32088888)
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA_PREMUL \
+  ((wuffs_base__pixel_format)0x33008888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__YUV ((wuffs_base__pixel_format)0x40200888)
+#define WUFFS_BASE__PIXEL_FORMAT__YUVK ((wuffs_base__pixel_format)0x41308888)
+#define WUFFS_BASE__PIXEL_FORMAT__YUVA_NONPREMUL \
+  ((wuffs_base__pixel_format)0x42308888)
+
+#define WUFFS_BASE__PIXEL_FORMAT__CMY ((wuffs_base__pixel_format)0x50200888)
+#define WUFFS_BASE__PIXEL_FORMAT__CMYK ((wuffs_base__pixel_format)0x51308888)
+
+static inline bool wuffs_base__pixel_format__is_valid(
+    wuffs_base__pixel_format f) {
+  return f != 0;
+}
+
+static inline bool wuffs_base__pixel_format__is_indexed(
+    wuffs_base__pixel_format f) {
+  return ((f >> 16) & 0x0F) != 0;
+}
+
+#define WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX 4
+
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    wuffs_base__pixel_format f) {
+  return f ? (((f >> 20) & 0x03) + 1) : 0;
+}
+
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
+typedef struct {
+  wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
+} wuffs_base__pixel_buffer;
+
+// --------
+
+// wuffs_base__pixel_subsampling encodes the mapping of pixel space coordinates
+// (x, y) to pixel buffer indices (i, j). That mapping can differ for each
+// plane p. For a depth of 8 bits (1 byte), the p'th plane's sample starts at
+// (planes[p].ptr + (j * planes[p].stride) + i).
+//
+// For packed pixel formats, the mapping is trivial: i = x and j = y. For
+// planar pixel formats, the mapping can differ due to chroma subsampling. For
+// example, consider a three plane YUV pixel format with 4:2:2 subsampling. For
+// the luma (Y) channel, there is one sample for every pixel, but for the
+// chroma (U, V) channels, there is one sample for every two pixels: pairs of
+// horizontally adjacent pixels form one macropixel, i = x / 2 and j == y. In
+// general, for a given p:
+//  - i = (x + bias_x) >> shift_x.
+//  - j = (y + bias_y) >> shift_y.
+// where biases and shifts are in the range 0..3 and 0..2 respectively.
+//
+// In general, the biases will be zero after decoding an image. However, making
+// a sub-image may change the bias, since the (x, y) coordinates are relative
+// to the sub-image's top-left origin, but the backing pixel buffers were
+// created relative to the original image's origin.
+//
+// For each plane p, each of those four numbers (biases and shifts) are encoded
+// in two bits, which combine to form an 8 bit unsigned integer:
+//
+//  e_p = (bias_x << 6) | (shift_x << 4) | (bias_y << 2) | (shift_y << 0)
+//
+// Those e_p values (e_0 for the first plane, e_1 for the second plane, etc)
+// combine to form a wuffs_base__pixel_subsampling value:
+//
+//  pixsub = (e_3 << 24) | (e_2 << 16) | (e_1 << 8) | (e_0 << 0)
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_base__pixel_subsampling__bias_x instead.
+typedef uint32_t wuffs_base__pixel_subsampling;
+
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__NONE ((wuffs_base__pixel_subsampling)0)
+
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__444 \
+  ((wuffs_base__pixel_subsampling)0x000000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__440 \
+  ((wuffs_base__pixel_subsampling)0x010100)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__422 \
+  ((wuffs_base__pixel_subsampling)0x101000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__420 \
+  ((wuffs_base__pixel_subsampling)0x111100)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__411 \
+  ((wuffs_base__pixel_subsampling)0x202000)
+#define WUFFS_BASE__PIXEL_SUBSAMPLING__410 \
+  ((wuffs_base__pixel_subsampling)0x212100)
+
+static inline uint32_t wuffs_base__pixel_subsampling__bias_x(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 6;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__shift_x(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 4;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__bias_y(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 2;
+  return (s >> shift) & 0x03;
+}
+
+static inline uint32_t wuffs_base__pixel_subsampling__shift_y(
+    wuffs_base__pixel_subsampling s,
+    uint32_t plane) {
+  uint32_t shift = ((plane & 0x03) * 8) + 0;
+  return (s >> shift) & 0x03;
+}
+
+// --------
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__pixel_format pixfmt;
+    wuffs_base__pixel_subsampling pixsub;
+    uint32_t width;
+    uint32_t height;
+    uint32_t num_loops;
+  } private_impl;
+} wuffs_base__image_config;
+
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
+// TODO: Should this function return bool? An error type?
+static inline void wuffs_base__image_config__initialize(
+    wuffs_base__image_config* c,
+    wuffs_base__pixel_format pixfmt,
+    wuffs_base__pixel_subsampling pixsub,
+    uint32_t width,
+    uint32_t height,
+    uint32_t num_loops) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
+    }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline void wuffs_base__image_config__invalidate(
+    wuffs_base__image_config* c) {
+  if (c) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline bool wuffs_base__image_config__is_valid(
+    wuffs_base__image_config* c) {
+  return c && c->private_impl.pixfmt;
+}
+
+static inline wuffs_base__pixel_format wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.pixfmt : 0;
+}
+
+static inline wuffs_base__pixel_subsampling
+wuffs_base__image_config__pixel_subsampling(wuffs_base__image_config* c) {
+  return c ? c->private_impl.pixsub : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__width(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.width : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__height(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.height : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__num_loops(
+    wuffs_base__image_config* c) {
+  return c ? c->private_impl.num_loops : 0;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  if (c) {
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
+  }
+  return 0;
+}
+
+// --------
+
+// wuffs_base__animation_disposal encodes, for an animated image, how to
+// dispose of a frame after displaying it:
+//  - None means to draw the next frame on top of this one.
+//  - Restore Background means to clear the frame's dirty rectangle to "the
+//    background color" (in practice, this means transparent black) before
+//    drawing the next frame.
+//  - Restore Previous means to undo the current frame, so that the next frame
+//    is drawn on top of the previous one.
+typedef uint8_t wuffs_base__animation_disposal;
+
+#define WUFFS_BASE__ANIMATION_DISPOSAL__NONE ((wuffs_base__animation_disposal)0)
+#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_BACKGROUND \
+  ((wuffs_base__animation_disposal)1)
+#define WUFFS_BASE__ANIMATION_DISPOSAL__RESTORE_PREVIOUS \
+  ((wuffs_base__animation_disposal)2)
+
+// --------
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__image_config config;
+    uint32_t loop_count;  // 0-based count of the current loop.
+    wuffs_base__pixel_buffer pixbuf;
+    // TODO: color spaces.
+    wuffs_base__rect_ie_u32 dirty_rect;
+    wuffs_base__flicks duration;
+    bool blend;
+    wuffs_base__animation_disposal disposal;
+    bool palette_changed;
+    uint8_t palette[1024];
+  } private_impl;
+} wuffs_base__image_buffer;
+
+static inline void wuffs_base__image_buffer__set_from_pixbuf(
+    wuffs_base__image_buffer* b,
+    wuffs_base__image_config config,
+    wuffs_base__pixel_buffer pixbuf) {
+  if (!b) {
+    return;
+  }
+  *b = ((wuffs_base__image_buffer){});
+  b->private_impl.config = config;
+  b->private_impl.pixbuf = pixbuf;
+}
+
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
+// TODO: Should this function return bool? An error type?
+static inline void wuffs_base__image_buffer__set_from_slice(
+    wuffs_base__image_buffer* b,
+    wuffs_base__image_config config,
+    wuffs_base__slice_u8 pixbuf_memory) {
+  if (!b) {
+    return;
+  }
+  *b = ((wuffs_base__image_buffer){});
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
+    return;
+  }
+  b->private_impl.config = config;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
+}
+
+// The palette argument is ignored unless its length is exactly 1024.
+static inline void wuffs_base__image_buffer__update(
+    wuffs_base__image_buffer* b,
+    wuffs_base__rect_ie_u32 dirty_rect,
+    wuffs_base__flicks duration,
+    bool blend,
+    wuffs_base__animation_disposal disposal,
+    wuffs_base__slice_u8 palette) {
+  if (!b) {
+    return;
+  }
+
+  // Clip the dirty_rect to the image bounds.
+  dirty_rect.max_exclusive_x = wuffs_base__u32__min(
+      dirty_rect.max_exclusive_x, b->private_impl.config.private_impl.width);
+  dirty_rect.max_exclusive_y = wuffs_base__u32__min(
+      dirty_rect.max_exclusive_y, b->private_impl.config.private_impl.height);
+  b->private_impl.dirty_rect = dirty_rect;
+
+  b->private_impl.duration = duration;
+  b->private_impl.blend = blend;
+  b->private_impl.disposal = disposal;
+  b->private_impl.palette_changed = palette.ptr && (palette.len == 1024);
+  if (b->private_impl.palette_changed) {
+    memmove(b->private_impl.palette, palette.ptr, 1024);
+  }
+}
+
+// wuffs_base__image_buffer__loop returns whether the image decoder should loop
+// back to the beginning of the animation, assuming that we've reached the end
+// of the encoded stream. If so, it increments b's count of the animation loops
+// played so far.
+static inline bool wuffs_base__image_buffer__loop(wuffs_base__image_buffer* b) {
+  if (!b) {
+    return false;
+  }
+  uint32_t n = b->private_impl.config.private_impl.num_loops;
+  if (n == 0) {
+    return true;
+  }
+  if (b->private_impl.loop_count < n - 1) {
+    b->private_impl.loop_count++;
+    return true;
+  }
+  return false;
+}
+
+// wuffs_base__image_config returns the overall configuration for this frame.
+static inline wuffs_base__image_config* wuffs_base__image_buffer__image_config(
+    wuffs_base__image_buffer* b) {
+  return b ? &b->private_impl.config : NULL;
+}
+
+// wuffs_base__image_buffer__dirty_rect returns an upper bound for what part of
+// this frame's pixels differs from the previous frame.
+static inline wuffs_base__rect_ie_u32 wuffs_base__image_buffer__dirty_rect(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.dirty_rect : ((wuffs_base__rect_ie_u32){0});
+}
+
+// wuffs_base__image_buffer__duration returns the amount of time to display
+// this frame. Zero means to display forever - a still (non-animated) image.
+static inline wuffs_base__flicks wuffs_base__image_buffer__duration(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.duration : 0;
+}
+
+// wuffs_base__image_buffer__blend returns, for a transparent image, whether to
+// blend this frame with the existing canvas.
+//
+// In Porter-Duff compositing operator terminology, false means "src" and true
+// means "src over dst".
+static inline bool wuffs_base__image_buffer__blend(
+    wuffs_base__image_buffer* b) {
+  return b && b->private_impl.blend;
+}
+
+// wuffs_base__image_buffer__disposal returns, for an animated image, how to
+// dispose of this frame after displaying it.
+static inline wuffs_base__animation_disposal wuffs_base__image_buffer__disposal(
+    wuffs_base__image_buffer* b) {
+  return b ? b->private_impl.disposal : 0;
+}
+
+// wuffs_base__image_buffer__palette_changed returns whether this frame's
+// palette differs from the previous frame. It is conservative and may return
+// false positives (but never false negatives).
+static inline bool wuffs_base__image_buffer__palette_changed(
+    wuffs_base__image_buffer* b) {
+  return b && b->private_impl.palette_changed;
+}
+
+// wuffs_base__image_buffer__palette returns the palette that the pixel data
+// can index. The backing array is inside b and has length 1024.
+static inline wuffs_base__slice_u8 wuffs_base__image_buffer__palette(
+    wuffs_base__image_buffer* b) {
+  return b ? ((wuffs_base__slice_u8){.ptr = b->private_impl.palette,
+                                     .len = 1024})
+           : ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
+    wuffs_base__image_buffer* b,
+    uint32_t p) {
+  return (b && (p < WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX))
+             ? b->private_impl.pixbuf.planes[p]
+             : ((wuffs_base__table_u8){});
+}
+
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
+#endif  // WUFFS_BASE_HEADER_H
+
+// ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values. Its bits:
+//  - bit        31 (the sign bit) indicates unrecoverable-ness: an error.
+//  - bits 30 .. 10 are the packageid: a namespace.
+//  - bits  9 ..  8 are reserved.
+//  - bits  7 ..  0 are a package-namespaced numeric code.
+//
+// Do not manipulate these bits directly; they are private implementation
+// details. Use methods such as wuffs_webp__status__is_error instead.
+typedef int32_t wuffs_webp__status;
+
+#define wuffs_webp__packageid 1889273  // 0x001CD3F9
+
+#define WUFFS_WEBP__STATUS_OK 0  // 0x00000000
+#define WUFFS_WEBP__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
+#define WUFFS_WEBP__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
+#define WUFFS_WEBP__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_WEBP__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_WEBP__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_WEBP__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_WEBP__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_WEBP__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_WEBP__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_WEBP__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
+#define WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_WEBP__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED -212868096  // 0xF34FE400
+#define WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED -212868095  // 0xF34FE401
+#define WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE -212868094  // 0xF34FE402
+#define WUFFS_WEBP__ERROR_BAD_BACK_REFERENCE -212868093  // 0xF34FE403
+#define WUFFS_WEBP__ERROR_BAD_COLOR_CACHE_SIZE -212868092  // 0xF34FE404
+#define WUFFS_WEBP__ERROR_BAD_HEADER -212868091  // 0xF34FE405
+#define WUFFS_WEBP__ERROR_BAD_TRANSFORM -212868090  // 0xF34FE406
+#define WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH -212868089  // 0xF34FE407
+#define WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE -212868088  // 0xF34FE408
+#define WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE -212868087  // 0xF34FE409
+
+bool wuffs_webp__status__is_error(wuffs_webp__status s);
+
+const char* wuffs_webp__status__string(wuffs_webp__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_webp__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_webp__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint8_t f_call_sequence;
+    bool f_end_of_data;
+    bool f_is_animated;
+    uint32_t f_num_loops;
+    uint32_t f_riff_length;
+    uint32_t f_chunk_type;
+    uint32_t f_chunk_length;
+    uint32_t f_anmf_length;
+    wuffs_base__rect_ie_u32 f_frame_rect;
+    uint32_t f_frame_x0;
+    uint32_t f_frame_y0;
+    uint64_t f_frame_duration;
+    bool f_frame_blend;
+    uint8_t f_frame_disposal;
+    uint32_t f_vp8l_width;
+    uint32_t f_vp8l_height;
+    uint64_t f_bits;
+    uint32_t f_n_bits;
+    uint32_t f_num_transforms;
+    uint32_t f_seen_transforms;
+    uint32_t f_transform_types[4];
+    uint32_t f_transform_widths[4];
+    uint32_t f_predictor_bits;
+    uint32_t f_predictor_width;
+    uint32_t f_color_bits;
+    uint32_t f_color_width;
+    uint32_t f_palette_width_bits;
+    uint32_t f_meta_bits;
+    uint32_t f_meta_width;
+    uint32_t f_cache_bits;
+    uint8_t f_huff_table[5232];
+    uint32_t f_max_groups;
+    uint16_t f_group_map[65536];
+    uint8_t f_code_lengths[2328];
+    uint32_t f_color_cache[2048];
+    uint32_t f_sub_image_max_pixels;
+    uint32_t f_palette[256];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_riff_length;
+      uint32_t v_max_width;
+      uint32_t v_max_height;
+      uint32_t v_sub_width;
+      uint32_t v_sub_height;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_workbuf_len;
+      uint64_t scratch;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_length;
+      uint64_t v_padded_length;
+      uint64_t scratch;
+    } c_next_chunk[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_x0;
+      uint32_t v_y0;
+      uint32_t v_w;
+      uint32_t v_h;
+      uint32_t v_x1;
+      uint32_t v_y1;
+      uint8_t v_flags;
+      uint32_t v_chunk_type;
+      uint32_t v_length;
+      uint64_t v_padded_length;
+      uint64_t scratch;
+    } c_decode_anmf_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_c;
+      uint64_t scratch;
+    } c_decode_vp8l_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_width;
+      uint32_t v_height;
+      uint32_t v_t;
+      uint32_t v_b;
+      uint32_t v_w;
+      uint32_t v_h;
+      uint32_t v_num_colors;
+      uint32_t v_i;
+    } c_decode_vp8l[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_cache_bits;
+      uint32_t v_num_groups;
+      uint32_t v_b;
+      uint32_t v_w;
+      uint32_t v_h;
+      uint32_t v_i;
+      uint32_t v_n;
+      uint32_t v_g;
+    } c_decode_frame_image[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_sub_image[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_c;
+    } c_read_color_cache_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_green_symbols;
+      uint32_t v_g;
+      uint32_t v_next;
+      uint32_t v_position;
+      uint64_t v_offset;
+    } c_read_huffman_groups[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_num_symbols;
+      uint32_t v_simple_symbol;
+      uint32_t v_num_code_lengths;
+      uint64_t v_code_length_t;
+      uint32_t v_max_symbol;
+      uint32_t v_length_n_bits;
+      uint8_t v_prev_code_length;
+      uint32_t v_symbol;
+      uint32_t v_code;
+      uint32_t v_repeat;
+      uint8_t v_value;
+    } c_read_huffman_code[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_pixels;
+      uint32_t v_p;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint64_t v_group;
+      uint32_t v_code;
+      uint32_t v_argb;
+      uint32_t v_length;
+      uint32_t v_dist_code;
+      uint32_t v_dist;
+      uint32_t v_q;
+      uint32_t v_qx;
+      uint32_t v_qy;
+      uint32_t v_i;
+      uint32_t v_num_cache_codes;
+    } c_decode_pixels[1];
+  } private_impl;
+} wuffs_webp__decoder;
+
+// WUFFS_WEBP__DECODER__STATE_LENGTH is the length of a wuffs_webp__decoder's
+// saved state.
+#define WUFFS_WEBP__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 148397)
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_webp__decoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_webp__decoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_webp__decoder__check_wuffs_version(wuffs_webp__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_webp__decoder returns sizeof(wuffs_webp__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_webp__decoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_webp__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_WEBP__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_webp__decoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_webp__status wuffs_webp__decoder__save_state(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_webp__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_WEBP__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_webp__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_webp__status wuffs_webp__decoder__restore_state(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// ---------------- Public Function Prototypes
+
+uint64_t wuffs_webp__decoder__workbuf_len(wuffs_webp__decoder* self);
+
+wuffs_webp__status wuffs_webp__decoder__decode_config(wuffs_webp__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src);
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace webp {
+
+// status is a strongly typed wuffs_webp__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_WEBP__STATUS_OK) {}
+  constexpr explicit status(wuffs_webp__status repr) : repr_(repr) {}
+
+  constexpr wuffs_webp__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_WEBP__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_webp__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_webp__status repr_;
+};
+
+constexpr status status_ok(WUFFS_WEBP__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_WEBP__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_WEBP__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_WEBP__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_WEBP__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_WEBP__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_WEBP__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_WEBP__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_WEBP__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_WEBP__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_WEBP__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_WEBP__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_huffman_code_over_subscribed(
+    WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_under_subscribed(
+    WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED);
+constexpr status error_bad_huffman_code(WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE);
+constexpr status error_bad_back_reference(WUFFS_WEBP__ERROR_BAD_BACK_REFERENCE);
+constexpr status error_bad_color_cache_size(
+    WUFFS_WEBP__ERROR_BAD_COLOR_CACHE_SIZE);
+constexpr status error_bad_header(WUFFS_WEBP__ERROR_BAD_HEADER);
+constexpr status error_bad_transform(WUFFS_WEBP__ERROR_BAD_TRANSFORM);
+constexpr status error_bad_workbuf_length(WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH);
+constexpr status error_todo_unsupported_lossy_image(
+    WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE);
+constexpr status error_internal_error_inconsistent_huffman_decoder_state(
+    WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE);
+
+// decoder is an RAII wrapper for a wuffs_webp__decoder. Its constructor
+// calls wuffs_webp__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_webp__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_webp__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_webp__decoder__save_state and
+  // wuffs_webp__decoder__restore_state. A saved state is
+  // WUFFS_WEBP__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_webp__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_webp__decoder__restore_state(&c_, src));
+  }
+
+  uint64_t workbuf_len() {
+    return wuffs_webp__decoder__workbuf_len(&c_);
+  }
+
+  status decode_config(
+      wuffs_base__image_config* dst, wuffs_base__io_reader src) {
+    return status(wuffs_webp__decoder__decode_config(&c_, dst, src));
+  }
+
+  status decode_frame(
+      wuffs_base__image_buffer* dst,
+      wuffs_base__io_reader src,
+      wuffs_base__slice_u8 workbuf) {
+    return status(wuffs_webp__decoder__decode_frame(&c_, dst, src, workbuf));
+  }
+
+ private:
+  wuffs_webp__decoder c_;
+};
+
+}  // namespace webp
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+#endif  // WUFFS_WEBP_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
+  return ((wuffs_base__empty_struct){});
+}
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
+// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
+// It's not foolproof, given C doesn't automatically zero memory before use,
+// but it should catch 99.99% of cases.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("wuffs").
+#define WUFFS_BASE__MAGIC ((uint32_t)0x3CCB6C71)
+
+// Denote intentional fallthroughs for -Wimplicit-fallthrough.
+//
+// The order matters here. Clang also defines "__GNUC__".
+#if defined(__clang__) && __cplusplus >= 201103L
+#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]
+#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)
+#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))
+#else
+#define WUFFS_BASE__FALLTHROUGH
+#endif
+
+// Use switch cases for coroutine suspension points, similar to the technique
+// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html
+//
+// We use trivial macros instead of an explicit assignment and case statement
+// so that clang-format doesn't get confused by the unusual "case"s.
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \
+  coro_susp_point = n;                            \
+  WUFFS_BASE__FALLTHROUGH;                        \
+  case n:;
+
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \
+  if (status < 0) {                                             \
+    goto exit;                                                  \
+  } else if (status == 0) {                                     \
+    goto ok;                                                    \
+  }                                                             \
+  coro_susp_point = n;                                          \
+  goto suspend;                                                 \
+  case n:;
+
+// Clang also defines "__GNUC__".
+#if defined(__GNUC__)
+#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))
+#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))
+#else
+#define WUFFS_BASE__LIKELY(expr) (expr)
+#define WUFFS_BASE__UNLIKELY(expr) (expr)
+#endif
+
+// Uncomment this #include for printf-debugging.
+// #include <stdio.h>
+
+// The helpers below are functions, instead of macros, because their arguments
+// can be an expression that we shouldn't evaluate more than once.
+//
+// They are in base-impl.h and hence copy/pasted into every generated C file,
+// instead of being in some "base.c" file, since a design goal is that users of
+// the generated C code can often just #include a single .c file, such as
+// "gif.c", without having to additionally include or otherwise build and link
+// a "base.c" file.
+//
+// They are static, so that linking multiple wuffs .o files won't complain about
+// duplicate function definitions.
+//
+// They are explicitly marked inline, even if modern compilers don't use the
+// inline attribute to guide optimizations such as inlining, to avoid the
+// -Wunused-function warning, and we like to compile with -Wall -Werror.
+
+// ---------------- Numeric Types
+
+static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
+}
+
+static inline uint16_t wuffs_base__load_u16le(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);
+}
+
+static inline uint32_t wuffs_base__load_u24be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 16) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u24le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16);
+}
+
+static inline uint32_t wuffs_base__load_u32be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |
+         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u32le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);
+}
+
+static inline uint64_t wuffs_base__load_u40be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 32) | ((uint64_t)(p[1]) << 24) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 8) |
+         ((uint64_t)(p[4]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u40le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32);
+}
+
+static inline uint64_t wuffs_base__load_u48be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 40) | ((uint64_t)(p[1]) << 32) |
+         ((uint64_t)(p[2]) << 24) | ((uint64_t)(p[3]) << 16) |
+         ((uint64_t)(p[4]) << 8) | ((uint64_t)(p[5]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u48le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40);
+}
+
+static inline uint64_t wuffs_base__load_u56be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 48) | ((uint64_t)(p[1]) << 40) |
+         ((uint64_t)(p[2]) << 32) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 16) | ((uint64_t)(p[5]) << 8) |
+         ((uint64_t)(p[6]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u56le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |
+         ((uint64_t)(p[6]) << 48);
+}
+
+static inline uint64_t wuffs_base__load_u64be(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 56) | ((uint64_t)(p[1]) << 48) |
+         ((uint64_t)(p[2]) << 40) | ((uint64_t)(p[3]) << 32) |
+         ((uint64_t)(p[4]) << 24) | ((uint64_t)(p[5]) << 16) |
+         ((uint64_t)(p[6]) << 8) | ((uint64_t)(p[7]) << 0);
+}
+
+static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
+  return ((uint64_t)(p[0]) << 0) | ((uint64_t)(p[1]) << 8) |
+         ((uint64_t)(p[2]) << 16) | ((uint64_t)(p[3]) << 24) |
+         ((uint64_t)(p[4]) << 32) | ((uint64_t)(p[5]) << 40) |
+         ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
+}
+
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
+// --------
+
+static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
+  *x = wuffs_base__u8__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u8__sat_sub_indirect(uint8_t* x, uint8_t y) {
+  *x = wuffs_base__u8__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u16__sat_add_indirect(uint16_t* x, uint16_t y) {
+  *x = wuffs_base__u16__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u16__sat_sub_indirect(uint16_t* x, uint16_t y) {
+  *x = wuffs_base__u16__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u32__sat_add_indirect(uint32_t* x, uint32_t y) {
+  *x = wuffs_base__u32__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u32__sat_sub_indirect(uint32_t* x, uint32_t y) {
+  *x = wuffs_base__u32__sat_sub(*x, y);
+}
+
+static inline void wuffs_base__u64__sat_add_indirect(uint64_t* x, uint64_t y) {
+  *x = wuffs_base__u64__sat_add(*x, y);
+}
+
+static inline void wuffs_base__u64__sat_sub_indirect(uint64_t* x, uint64_t y) {
+  *x = wuffs_base__u64__sat_sub(*x, y);
+}
+
+// ---------------- Slices and Tables
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(
+    wuffs_base__slice_u8 s,
+    uint64_t i) {
+  if ((i <= SIZE_MAX) && (i <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = s.len - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(
+    wuffs_base__slice_u8 s,
+    uint64_t j) {
+  if ((j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(
+    wuffs_base__slice_u8 s,
+    uint64_t i,
+    uint64_t j) {
+  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = j - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__suffix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.ptr += (uint64_t)(s.len) - up_to;
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
+// length) where length is the minimum of dst.len and src.len.
+//
+// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
+// slice) is valid and results in a no-op.
+static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src) {
+  size_t length = dst.len < src.len ? dst.len : src.len;
+  if (length > 0) {
+    memmove(dst.ptr, src.ptr, length);
+  }
+  return length;
+}
+
+// --------
+
+static inline wuffs_base__slice_u8 wuffs_base__table_u8__row(
+    wuffs_base__table_u8 t,
+    uint32_t y) {
+  if (y < t.height) {
+    return ((wuffs_base__slice_u8){
+        .ptr = t.ptr + (t.stride * y),
+        .len = t.width,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// ---------------- Ranges and Rects
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_min_inclusive_x(wuffs_base__rect_ii_u32* r,
+                                             uint32_t x) {
+  r->min_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_min_inclusive_y(wuffs_base__rect_ii_u32* r,
+                                             uint32_t y) {
+  r->min_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_max_inclusive_x(wuffs_base__rect_ii_u32* r,
+                                             uint32_t x) {
+  r->max_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ii_u32__set_max_inclusive_y(wuffs_base__rect_ii_u32* r,
+                                             uint32_t y) {
+  r->max_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+// --------
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_min_inclusive_x(wuffs_base__rect_ie_u32* r,
+                                             uint32_t x) {
+  r->min_inclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_min_inclusive_y(wuffs_base__rect_ie_u32* r,
+                                             uint32_t y) {
+  r->min_inclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_max_exclusive_x(wuffs_base__rect_ie_u32* r,
+                                             uint32_t x) {
+  r->max_exclusive_x = x;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct
+wuffs_base__rect_ie_u32__set_max_exclusive_y(wuffs_base__rect_ie_u32* r,
+                                             uint32_t y) {
+  r->max_exclusive_y = y;
+  return ((wuffs_base__empty_struct){});
+}
+
+// ---------------- I/O
+
+static inline bool wuffs_base__io_buffer__is_valid(wuffs_base__io_buffer buf) {
+  return (buf.ptr || (buf.len == 0)) && (buf.len >= buf.wi) &&
+         (buf.wi >= buf.ri);
+}
+
+static inline bool wuffs_base__io_reader__is_eof(wuffs_base__io_reader o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  return buf && buf->closed && (buf->ptr + buf->wi == o.private_impl.bounds[1]);
+}
+
+static inline bool wuffs_base__io_reader__is_valid(wuffs_base__io_reader o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  // Note: if making this function public (i.e. moving it to base-header.h), it
+  // also needs to allow NULL (i.e. implicit, callee-calculated) bounds.
+  return buf ? ((buf->ptr <= o.private_impl.bounds[0]) &&
+                (o.private_impl.bounds[0] <= o.private_impl.bounds[1]) &&
+                (o.private_impl.bounds[1] <= buf->ptr + buf->len))
+             : ((o.private_impl.bounds[0] == NULL) &&
+                (o.private_impl.bounds[1] == NULL));
+}
+
+static inline bool wuffs_base__io_writer__is_valid(wuffs_base__io_writer o) {
+  wuffs_base__io_buffer* buf = o.private_impl.buf;
+  // Note: if making this function public (i.e. moving it to base-header.h), it
+  // also needs to allow NULL (i.e. implicit, callee-calculated) bounds.
+  return buf ? ((buf->ptr <= o.private_impl.bounds[0]) &&
+                (o.private_impl.bounds[0] <= o.private_impl.bounds[1]) &&
+                (o.private_impl.bounds[1] <= buf->ptr + buf->len))
+             : ((o.private_impl.bounds[0] == NULL) &&
+                (o.private_impl.bounds[1] == NULL));
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_history32(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  if (!distance) {
+    return 0;
+  }
+  uint8_t* ptr = *ptr_ptr;
+  if ((size_t)(ptr - start) < (size_t)(distance)) {
+    return 0;
+  }
+  start = ptr - distance;
+  size_t n = end - ptr;
+  if ((size_t)(length) > n) {
+    length = n;
+  } else {
+    n = length;
+  }
+  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that
+  // is mostly because 3 is the minimum length for the deflate format. This
+  // function implementation shouldn't overfit to that one format. Perhaps the
+  // copy_from_history32 Wuffs method should also take an unroll hint argument,
+  // and the cgen can look if that argument is the constant expression '3'.
+  //
+  // See also wuffs_base__io_writer__copy_from_history32__bco below.
+  //
+  // Alternatively, or additionally, have a sloppy_copy_from_history32 method
+  // that copies 8 bytes at a time, possibly writing more than length bytes?
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+// wuffs_base__io_writer__copy_from_history32__bco is a Bounds Check Optimized
+// version of the wuffs_base__io_writer__copy_from_history32 function above.
+// The caller needs to prove that:
+//  - distance >  0
+//  - distance <= (*ptr_ptr - start)
+//  - length   <= (end      - *ptr_ptr)
+static inline uint32_t wuffs_base__io_writer__copy_from_history32__bco(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  uint8_t* ptr = *ptr_ptr;
+  start = ptr - distance;
+  uint32_t n = length;
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_reader32(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    uint8_t** ptr_ioptr_r,
+    uint8_t* iobounds1_r,
+    uint32_t length) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = length;
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  uint8_t* ioptr_r = *ptr_ioptr_r;
+  if (n > iobounds1_r - ioptr_r) {
+    n = iobounds1_r - ioptr_r;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, ioptr_r, n);
+    *ptr_ioptr_w += n;
+    *ptr_ioptr_r += n;
+  }
+  return n;
+}
+
+static inline uint64_t wuffs_base__io_writer__copy_from_slice(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    wuffs_base__slice_u8 src) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = src.len;
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, src.ptr, n);
+    *ptr_ioptr_w += n;
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
+    uint8_t** ptr_ioptr_w,
+    uint8_t* iobounds1_w,
+    wuffs_base__slice_u8 src,
+    uint32_t length) {
+  uint8_t* ioptr_w = *ptr_ioptr_w;
+  size_t n = src.len;
+  if (n > length) {
+    n = length;
+  }
+  if (n > iobounds1_w - ioptr_w) {
+    n = iobounds1_w - ioptr_w;
+  }
+  if (n > 0) {
+    memmove(ioptr_w, src.ptr, n);
+    *ptr_ioptr_w += n;
+  }
+  return n;
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
+    wuffs_base__io_reader* o,
+    uint8_t* ioptr_r,
+    uint64_t limit) {
+  if (o && ((o->private_impl.bounds[1] - ioptr_r) > limit)) {
+    o->private_impl.bounds[1] = ioptr_r + limit;
+  }
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set_mark(
+    wuffs_base__io_reader* o,
+    uint8_t* mark) {
+  o->private_impl.bounds[0] = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_writer__set(
+    wuffs_base__io_writer* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = 0;
+  b->ri = 0;
+  b->closed = false;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
+static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
+    wuffs_base__io_writer* o,
+    uint8_t* mark) {
+  o->private_impl.bounds[0] = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+static const char* wuffs_base__status__strings[15] = {
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
+};
+
+#endif  // WUFFS_BASE_IMPL_H
+
+// ---------------- Status Codes Implementations
+
+bool wuffs_webp__status__is_error(wuffs_webp__status s) {
+  return s < 0;
+}
+
+const char* wuffs_webp__status__strings[10] = {
+    "webp: bad Huffman code (over-subscribed)",
+    "webp: bad Huffman code (under-subscribed)", "webp: bad Huffman code",
+    "webp: bad back-reference", "webp: bad color cache size",
+    "webp: bad header", "webp: bad transform", "webp: bad workbuf length",
+    "webp: TODO: unsupported lossy image",
+    "webp: internal error: inconsistent Huffman decoder state",
+};
+
+const char* wuffs_webp__status__string(wuffs_webp__status s) {
+  const char** a = NULL;
+  uint32_t n = 0;
+  switch ((s >> 10) & 0x1FFFFF) {
+    case 0:
+      a = wuffs_base__status__strings;
+      n = 15;
+      break;
+    case wuffs_webp__packageid:
+      a = wuffs_webp__status__strings;
+      n = 10;
+      break;
+  }
+  uint32_t i = s & 0xFF;
+  return i < n ? a[i] : "unknown status";
+}
+
+// ---------------- Private Consts
+
+static const uint32_t wuffs_webp__fourcc_riff = 1179011410;
+
+static const uint32_t wuffs_webp__fourcc_webp = 1346520407;
+
+static const uint32_t wuffs_webp__fourcc_alph = 1213221953;
+
+static const uint32_t wuffs_webp__fourcc_anim = 1296649793;
+
+static const uint32_t wuffs_webp__fourcc_anmf = 1179471425;
+
+static const uint32_t wuffs_webp__fourcc_vp8 = 540561494;
+
+static const uint32_t wuffs_webp__fourcc_vp8l = 1278758998;
+
+static const uint32_t wuffs_webp__fourcc_vp8x = 1480085590;
+
+static const uint32_t wuffs_webp__transform_predictor = 0;
+
+static const uint32_t wuffs_webp__transform_color = 1;
+
+static const uint32_t wuffs_webp__transform_subtract_green = 2;
+
+static const uint32_t wuffs_webp__transform_color_indexing = 3;
+
+static const uint32_t wuffs_webp__which_frame = 0;
+
+static const uint32_t wuffs_webp__which_predictor = 1;
+
+static const uint32_t wuffs_webp__which_color = 2;
+
+static const uint32_t wuffs_webp__which_meta = 3;
+
+static const uint32_t wuffs_webp__which_palette = 4;
+
+static const uint64_t wuffs_webp__table_header_size = 576;
+
+static const uint64_t wuffs_webp__table_offset_red = 5232;
+
+static const uint64_t wuffs_webp__table_offset_blue = 6320;
+
+static const uint64_t wuffs_webp__table_offset_alpha = 7408;
+
+static const uint64_t wuffs_webp__table_offset_distance = 8496;
+
+static const uint64_t wuffs_webp__group_size = 9152;
+
+static const uint64_t wuffs_webp__flicks_per_millisecond = 705600;
+
+static const uint8_t wuffs_webp__code_length_code_order[19] = {
+    17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+};
+
+static const uint8_t wuffs_webp__distance_map[120] = {
+    24, 7, 23, 25, 40, 6, 39, 41, 22, 26, 38, 42, 56, 5, 55, 57, 21, 27, 54, 58,
+    37, 43, 72, 4, 71, 73, 20, 28, 53, 59, 70, 74, 36, 44, 88, 69, 75, 52, 60,
+    3, 87, 89, 19, 29, 86, 90, 35, 45, 68, 76, 85, 91, 51, 61, 104, 2, 103, 105,
+    18, 30, 102, 106, 34, 46, 84, 92, 67, 77, 101, 107, 50, 62, 120, 1, 119,
+    121, 83, 93, 17, 31, 100, 108, 66, 78, 118, 122, 33, 47, 117, 123, 49, 63,
+    99, 109, 82, 94, 0, 116, 124, 65, 79, 16, 32, 98, 110, 48, 115, 125, 81, 95,
+    64, 114, 126, 97, 111, 80, 113, 127, 96, 112,
+};
+
+// ---------------- Private Initializer Prototypes
+
+// ---------------- Private Saved State Prototypes
+
+// ---------------- Private Function Prototypes
+
+static uint64_t wuffs_webp__decoder__groups_offset(wuffs_webp__decoder* self);
+
+static uint64_t wuffs_webp__decoder__code_length_table_offset(
+    wuffs_webp__decoder* self);
+
+static wuffs_webp__status wuffs_webp__decoder__next_chunk(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_anmf_header(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_vp8l_header(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_vp8l(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf);
+
+static uint32_t wuffs_webp__decoder__div_round_up(wuffs_webp__decoder* self,
+    uint32_t a_n, uint32_t a_b);
+
+static void wuffs_webp__decoder__decode_palette(wuffs_webp__decoder* self,
+    uint32_t a_n);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_frame_image(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_width, uint32_t a_height);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_sub_image(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_width, uint32_t a_height);
+
+static wuffs_webp__status wuffs_webp__decoder__read_color_cache_bits(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__read_huffman_groups(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_n);
+
+static wuffs_webp__status wuffs_webp__decoder__read_huffman_code(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t, uint32_t a_n);
+
+static wuffs_webp__status wuffs_webp__decoder__build_huffman(
+    wuffs_webp__decoder* self, wuffs_base__slice_u8 a_workbuf, uint64_t a_t,
+    uint32_t a_n);
+
+static void wuffs_webp__decoder__set_table_value(wuffs_webp__decoder* self,
+    uint32_t a_i, uint32_t a_v);
+
+static void wuffs_webp__decoder__copy_table(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t, uint32_t a_n);
+
+static uint32_t wuffs_webp__decoder__load_table_value(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_o);
+
+static wuffs_webp__status wuffs_webp__decoder__fill_bits(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src);
+
+static uint32_t wuffs_webp__decoder__take_bits(wuffs_webp__decoder* self,
+    uint32_t a_n);
+
+static uint32_t wuffs_webp__decoder__decode_huffman(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t);
+
+static uint32_t wuffs_webp__decoder__decode_lz77_value(
+    wuffs_webp__decoder* self, uint32_t a_p);
+
+static uint32_t wuffs_webp__decoder__distance(wuffs_webp__decoder* self,
+    uint32_t a_code, uint32_t a_width);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_pixels(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_width, uint32_t a_height);
+
+static uint64_t wuffs_webp__decoder__group_offset(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_x,
+    uint32_t a_y);
+
+static void wuffs_webp__decoder__put_pixel(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_x, uint32_t a_y, uint32_t a_p,
+    uint32_t a_argb);
+
+static uint32_t wuffs_webp__decoder__get_pixel(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_x, uint32_t a_y, uint32_t a_p);
+
+static uint32_t wuffs_webp__decoder__load_sub_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint64_t a_i);
+
+static void wuffs_webp__decoder__store_sub_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint64_t a_i,
+    uint32_t a_argb);
+
+static uint32_t wuffs_webp__decoder__load_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_row, uint32_t a_x);
+
+static void wuffs_webp__decoder__store_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_row, uint32_t a_x, uint32_t a_argb);
+
+static uint32_t wuffs_webp__decoder__add_pixels(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b);
+
+static void wuffs_webp__decoder__apply_predictor_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_width, uint32_t a_height);
+
+static uint32_t wuffs_webp__decoder__predict(wuffs_webp__decoder* self,
+    uint32_t a_mode, uint32_t a_l, uint32_t a_t, uint32_t a_tr, uint32_t a_tl);
+
+static uint32_t wuffs_webp__decoder__average2(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__select_nearer(wuffs_webp__decoder* self,
+    uint32_t a_l, uint32_t a_t, uint32_t a_tl);
+
+static uint32_t wuffs_webp__decoder__abs_diff(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_full(
+    wuffs_webp__decoder* self, uint32_t a_a, uint32_t a_b, uint32_t a_c);
+
+static uint32_t wuffs_webp__decoder__clamp_full(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b, uint32_t a_c);
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_half(
+    wuffs_webp__decoder* self, uint32_t a_a, uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__clamp_half(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b);
+
+static void wuffs_webp__decoder__apply_color_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_width, uint32_t a_height);
+
+static uint32_t wuffs_webp__decoder__color_transform_delta(
+    wuffs_webp__decoder* self, uint32_t a_t, uint32_t a_c);
+
+static void wuffs_webp__decoder__apply_subtract_green_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    uint32_t a_width, uint32_t a_height);
+
+static void wuffs_webp__decoder__apply_color_indexing_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    uint32_t a_width, uint32_t a_height);
+
+// ---------------- Initializer Implementations
+
+size_t sizeof__wuffs_webp__decoder(void) {
+  return sizeof(wuffs_webp__decoder);
+}
+
+void wuffs_webp__decoder__check_wuffs_version(wuffs_webp__decoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.workbuf_len
+
+uint64_t wuffs_webp__decoder__workbuf_len(wuffs_webp__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return (wuffs_webp__decoder__code_length_table_offset(self) +
+      wuffs_webp__table_header_size);
+}
+
+// -------- func decoder.groups_offset
+
+static uint64_t wuffs_webp__decoder__groups_offset(wuffs_webp__decoder* self) {
+  return (((uint64_t)(self->private_impl.f_sub_image_max_pixels)) * 12);
+}
+
+// -------- func decoder.code_length_table_offset
+
+static uint64_t wuffs_webp__decoder__code_length_table_offset(
+    wuffs_webp__decoder* self) {
+  return (wuffs_webp__decoder__groups_offset(self) + (((uint64_t)((
+      self->private_impl.f_max_groups + 1))) * wuffs_webp__group_size));
+}
+
+// -------- func decoder.decode_config
+
+wuffs_webp__status wuffs_webp__decoder__decode_config(wuffs_webp__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_riff_length;
+  uint32_t v_max_width;
+  uint32_t v_max_height;
+  uint32_t v_sub_width;
+  uint32_t v_sub_height;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_riff_length = self->private_impl.c_decode_config[0].v_riff_length;
+    v_max_width = self->private_impl.c_decode_config[0].v_max_width;
+    v_max_height = self->private_impl.c_decode_config[0].v_max_height;
+    v_sub_width = self->private_impl.c_decode_config[0].v_sub_width;
+    v_sub_height = self->private_impl.c_decode_config[0].v_sub_height;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    uint32_t t_1;
+    if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+      t_1 = wuffs_base__load_u32le(ioptr_src);
+      ioptr_src += 4;
+    } else {
+      self->private_impl.c_decode_config[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+          goto short_read_src;
+        }
+        uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+        uint32_t t_0 = *scratch >> 56;
+        *scratch <<= 8;
+        *scratch >>= 8;
+        *scratch |= ((uint64_t)(*ioptr_src++)) << t_0;
+        if (t_0 == 24) {
+          t_1 = *scratch;
+          break;
+        }
+        t_0 += 8;
+        *scratch |= ((uint64_t)(t_0)) << 56;
+      }
+    }
+    if (t_1 != wuffs_webp__fourcc_riff) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint32_t t_3;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_3 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+          uint32_t t_2 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_2;
+          if (t_2 == 24) {
+            t_3 = *scratch;
+            break;
+          }
+          t_2 += 8;
+          *scratch |= ((uint64_t)(t_2)) << 56;
+        }
+      }
+      v_riff_length = t_3;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    uint32_t t_5;
+    if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+      t_5 = wuffs_base__load_u32le(ioptr_src);
+      ioptr_src += 4;
+    } else {
+      self->private_impl.c_decode_config[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+          goto short_read_src;
+        }
+        uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+        uint32_t t_4 = *scratch >> 56;
+        *scratch <<= 8;
+        *scratch >>= 8;
+        *scratch |= ((uint64_t)(*ioptr_src++)) << t_4;
+        if (t_4 == 24) {
+          t_5 = *scratch;
+          break;
+        }
+        t_4 += 8;
+        *scratch |= ((uint64_t)(t_4)) << 56;
+      }
+    }
+    if (t_5 != wuffs_webp__fourcc_webp) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    if (v_riff_length < 4) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_riff_length = (v_riff_length - 4);
+    self->private_impl.f_num_loops = 1;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_webp__decoder__next_chunk(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8l) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (a_src.private_impl.buf) {
+        a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+      }
+      status = wuffs_webp__decoder__decode_vp8l_header(self, a_src);
+      if (a_src.private_impl.buf) {
+        ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      self->private_impl.f_width = self->private_impl.f_vp8l_width;
+      self->private_impl.f_height = self->private_impl.f_vp8l_height;
+    } else if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8x) {
+      if (self->private_impl.f_chunk_length < 10) {
+        status = WUFFS_WEBP__ERROR_BAD_HEADER;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      self->private_impl.c_decode_config[0].scratch = 4;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+      if (self->private_impl.c_decode_config[0].scratch >
+          iobounds1_src - ioptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            iobounds1_src - ioptr_src;
+        ioptr_src = iobounds1_src;
+        goto short_read_src;
+      }
+      ioptr_src += self->private_impl.c_decode_config[0].scratch;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+        uint32_t t_7;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+          t_7 = wuffs_base__load_u24le(ioptr_src);
+          ioptr_src += 3;
+        } else {
+          self->private_impl.c_decode_config[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+            uint32_t t_6 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_6;
+            if (t_6 == 16) {
+              t_7 = *scratch;
+              break;
+            }
+            t_6 += 8;
+            *scratch |= ((uint64_t)(t_6)) << 56;
+          }
+        }
+        self->private_impl.f_width = (t_7 + 1);
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+        uint32_t t_9;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+          t_9 = wuffs_base__load_u24le(ioptr_src);
+          ioptr_src += 3;
+        } else {
+          self->private_impl.c_decode_config[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_decode_config[0].scratch;
+            uint32_t t_8 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_8;
+            if (t_8 == 16) {
+              t_9 = *scratch;
+              break;
+            }
+            t_8 += 8;
+            *scratch |= ((uint64_t)(t_8)) << 56;
+          }
+        }
+        self->private_impl.f_height = (t_9 + 1);
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+      self->private_impl.c_decode_config[0].scratch =
+          wuffs_base__u32__sat_sub(self->private_impl.f_chunk_length, 10);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+      if (self->private_impl.c_decode_config[0].scratch >
+          iobounds1_src - ioptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            iobounds1_src - ioptr_src;
+        ioptr_src = iobounds1_src;
+        goto short_read_src;
+      }
+      ioptr_src += self->private_impl.c_decode_config[0].scratch;
+      while (true) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+        if (a_src.private_impl.buf) {
+          a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+        }
+        status = wuffs_webp__decoder__next_chunk(self, a_src);
+        if (a_src.private_impl.buf) {
+          ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+        }
+        if (status) {
+          goto suspend;
+        }
+        if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_anim) {
+          if (self->private_impl.f_chunk_length < 6) {
+            status = WUFFS_WEBP__ERROR_BAD_HEADER;
+            goto exit;
+          }
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(18);
+          self->private_impl.c_decode_config[0].scratch = 4;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(19);
+          if (self->private_impl.c_decode_config[0].scratch >
+              iobounds1_src - ioptr_src) {
+            self->private_impl.c_decode_config[0].scratch -=
+                iobounds1_src - ioptr_src;
+            ioptr_src = iobounds1_src;
+            goto short_read_src;
+          }
+          ioptr_src += self->private_impl.c_decode_config[0].scratch;
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(20);
+            uint16_t t_11;
+            if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 2)) {
+              t_11 = wuffs_base__load_u16le(ioptr_src);
+              ioptr_src += 2;
+            } else {
+              self->private_impl.c_decode_config[0].scratch = 0;
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(21);
+              while (true) {
+                if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+                  goto short_read_src;
+                }
+                uint64_t* scratch =
+                    &self->private_impl.c_decode_config[0].scratch;
+                uint32_t t_10 = *scratch >> 56;
+                *scratch <<= 8;
+                *scratch >>= 8;
+                *scratch |= ((uint64_t)(*ioptr_src++)) << t_10;
+                if (t_10 == 8) {
+                  t_11 = *scratch;
+                  break;
+                }
+                t_10 += 8;
+                *scratch |= ((uint64_t)(t_10)) << 56;
+              }
+            }
+            self->private_impl.f_num_loops = ((uint32_t)(t_11));
+          }
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(22);
+          self->private_impl.c_decode_config[0].scratch =
+              wuffs_base__u32__sat_sub(self->private_impl.f_chunk_length, 6);
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(23);
+          if (self->private_impl.c_decode_config[0].scratch >
+              iobounds1_src - ioptr_src) {
+            self->private_impl.c_decode_config[0].scratch -=
+                iobounds1_src - ioptr_src;
+            ioptr_src = iobounds1_src;
+            goto short_read_src;
+          }
+          ioptr_src += self->private_impl.c_decode_config[0].scratch;
+        } else if ((self->private_impl.f_chunk_type ==
+            wuffs_webp__fourcc_anmf) ||
+            (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8l) ||
+            (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8) ||
+            (self->private_impl.f_chunk_type == wuffs_webp__fourcc_alph)) {
+          goto label_0_break;
+        } else if (self->private_impl.f_chunk_type == 0) {
+          status = WUFFS_WEBP__ERROR_BAD_HEADER;
+          goto exit;
+        } else {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(24);
+          self->private_impl.c_decode_config[0].scratch =
+              self->private_impl.f_chunk_length;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(25);
+          if (self->private_impl.c_decode_config[0].scratch >
+              iobounds1_src - ioptr_src) {
+            self->private_impl.c_decode_config[0].scratch -=
+                iobounds1_src - ioptr_src;
+            ioptr_src = iobounds1_src;
+            goto short_read_src;
+          }
+          ioptr_src += self->private_impl.c_decode_config[0].scratch;
+        }
+      }
+    label_0_break:;
+      if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_anmf) {
+        self->private_impl.f_is_animated = true;
+      } else if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8l) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(26);
+        if (a_src.private_impl.buf) {
+          a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+        }
+        status = wuffs_webp__decoder__decode_vp8l_header(self, a_src);
+        if (a_src.private_impl.buf) {
+          ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+        }
+        if (status) {
+          goto suspend;
+        }
+        if ((self->private_impl.f_width != self->private_impl.f_vp8l_width) ||
+            (self->private_impl.f_height != self->private_impl.f_vp8l_height)) {
+          status = WUFFS_WEBP__ERROR_BAD_HEADER;
+          goto exit;
+        }
+      } else {
+        status = WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+        goto exit;
+      }
+    } else if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_vp8) {
+      status = WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+      goto exit;
+    } else {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    v_max_width = 16384;
+    if (self->private_impl.f_width < 16384) {
+      v_max_width = self->private_impl.f_width;
+    }
+    v_max_height = 16384;
+    if (self->private_impl.f_height < 16384) {
+      v_max_height = self->private_impl.f_height;
+    }
+    v_sub_width = ((v_max_width + 3) >> 2);
+    v_sub_height = ((v_max_height + 3) >> 2);
+    self->private_impl.f_sub_image_max_pixels = (v_sub_width * v_sub_height);
+    self->private_impl.f_max_groups = 65536;
+    if (self->private_impl.f_sub_image_max_pixels < 65536) {
+      self->private_impl.f_max_groups =
+          self->private_impl.f_sub_image_max_pixels;
+    }
+    wuffs_base__image_config__initialize(a_dst, 570460296, 0,
+        self->private_impl.f_width, self->private_impl.f_height,
+        self->private_impl.f_num_loops);
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_config[0].v_riff_length = v_riff_length;
+  self->private_impl.c_decode_config[0].v_max_width = v_max_width;
+  self->private_impl.c_decode_config[0].v_max_height = v_max_height;
+  self->private_impl.c_decode_config[0].v_sub_width = v_sub_width;
+  self->private_impl.c_decode_config[0].v_sub_height = v_sub_height;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_frame
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint64_t v_workbuf_len;
+  wuffs_base__slice_u8 v_palette;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_workbuf_len = self->private_impl.c_decode_frame[0].v_workbuf_len;
+    v_palette = ((wuffs_base__slice_u8){});
+  } else {
+    v_palette = ((wuffs_base__slice_u8){});
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence == 0) {
+      status = WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    if (!self->private_impl.f_end_of_data) {
+      if (self->private_impl.f_call_sequence == 1) {
+        self->private_impl.f_call_sequence = 2;
+      } else if (self->private_impl.f_is_animated) {
+        while (true) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (a_src.private_impl.buf) {
+            a_src.private_impl.buf->ri =
+                ioptr_src - a_src.private_impl.buf->ptr;
+          }
+          status = wuffs_webp__decoder__next_chunk(self, a_src);
+          if (a_src.private_impl.buf) {
+            ioptr_src =
+                a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+          }
+          if (status) {
+            goto suspend;
+          }
+          if (self->private_impl.f_chunk_type == wuffs_webp__fourcc_anmf) {
+            goto label_0_break;
+          } else if (self->private_impl.f_chunk_type == 0) {
+            self->private_impl.f_end_of_data = true;
+            goto label_0_break;
+          }
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          self->private_impl.c_decode_frame[0].scratch =
+              self->private_impl.f_chunk_length;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+          if (self->private_impl.c_decode_frame[0].scratch >
+              iobounds1_src - ioptr_src) {
+            self->private_impl.c_decode_frame[0].scratch -=
+                iobounds1_src - ioptr_src;
+            ioptr_src = iobounds1_src;
+            goto short_read_src;
+          }
+          ioptr_src += self->private_impl.c_decode_frame[0].scratch;
+        }
+      label_0_break:;
+      } else {
+        self->private_impl.f_end_of_data = true;
+      }
+    }
+    if (self->private_impl.f_end_of_data) {
+      while (true) {
+        status = WUFFS_WEBP__SUSPENSION_END_OF_DATA;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(4);
+      }
+    }
+    v_workbuf_len = (wuffs_webp__decoder__code_length_table_offset(self) +
+        wuffs_webp__table_header_size);
+    if (((uint64_t)(a_workbuf.len)) < v_workbuf_len) {
+      status = WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH;
+      goto exit;
+    }
+    if (self->private_impl.f_is_animated) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      if (a_src.private_impl.buf) {
+        a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+      }
+      status = wuffs_webp__decoder__decode_anmf_header(self, a_src);
+      if (a_src.private_impl.buf) {
+        ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      wuffs_base__rect_ie_u32__set_max_exclusive_x(
+          &self->private_impl.f_frame_rect, self->private_impl.f_width);
+      wuffs_base__rect_ie_u32__set_max_exclusive_y(
+          &self->private_impl.f_frame_rect, self->private_impl.f_height);
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_webp__decoder__decode_vp8l(self, a_dst, a_src, a_workbuf);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    self->private_impl.c_decode_frame[0].scratch =
+        (((uint64_t)(self->private_impl.f_chunk_length)) +
+        ((uint64_t)(self->private_impl.f_anmf_length)));
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    if (self->private_impl.c_decode_frame[0].scratch >
+        iobounds1_src - ioptr_src) {
+      self->private_impl.c_decode_frame[0].scratch -= iobounds1_src - ioptr_src;
+      ioptr_src = iobounds1_src;
+      goto short_read_src;
+    }
+    ioptr_src += self->private_impl.c_decode_frame[0].scratch;
+    self->private_impl.f_chunk_length = 0;
+    self->private_impl.f_anmf_length = 0;
+    v_palette = ((wuffs_base__slice_u8){});
+    wuffs_base__image_buffer__update(a_dst, self->private_impl.f_frame_rect,
+        self->private_impl.f_frame_duration, self->private_impl.f_frame_blend,
+        self->private_impl.f_frame_disposal, v_palette);
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame[0].v_workbuf_len = v_workbuf_len;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.next_chunk
+
+static wuffs_webp__status wuffs_webp__decoder__next_chunk(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_length;
+  uint64_t v_padded_length;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_next_chunk[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_length = self->private_impl.c_next_chunk[0].v_length;
+    v_padded_length = self->private_impl.c_next_chunk[0].v_padded_length;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_riff_length == 0) {
+      self->private_impl.f_chunk_type = 0;
+      self->private_impl.f_chunk_length = 0;
+      status = WUFFS_WEBP__STATUS_OK;
+      goto ok;
+    } else if (self->private_impl.f_riff_length < 8) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint32_t t_1;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_1 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_next_chunk[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_next_chunk[0].scratch;
+          uint32_t t_0 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_0;
+          if (t_0 == 24) {
+            t_1 = *scratch;
+            break;
+          }
+          t_0 += 8;
+          *scratch |= ((uint64_t)(t_0)) << 56;
+        }
+      }
+      self->private_impl.f_chunk_type = t_1;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint32_t t_3;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_3 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_next_chunk[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch = &self->private_impl.c_next_chunk[0].scratch;
+          uint32_t t_2 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_2;
+          if (t_2 == 24) {
+            t_3 = *scratch;
+            break;
+          }
+          t_2 += 8;
+          *scratch |= ((uint64_t)(t_2)) << 56;
+        }
+      }
+      v_length = t_3;
+    }
+    v_padded_length = (((uint64_t)(v_length)) + ((uint64_t)((v_length & 1))));
+    if ((((uint64_t)(self->private_impl.f_riff_length)) < 8) ||
+        (v_padded_length >
+        (((uint64_t)(self->private_impl.f_riff_length)) - 8))) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_chunk_length =
+        ((uint32_t)((v_padded_length & 4294967295)));
+    self->private_impl.f_riff_length =
+        wuffs_base__u32__sat_sub(self->private_impl.f_riff_length,
+        wuffs_base__u32__sat_add(self->private_impl.f_chunk_length, 8));
+
+    goto ok;
+  ok:
+    self->private_impl.c_next_chunk[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_next_chunk[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_next_chunk[0].v_length = v_length;
+  self->private_impl.c_next_chunk[0].v_padded_length = v_padded_length;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_anmf_header
+
+static wuffs_webp__status wuffs_webp__decoder__decode_anmf_header(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_x0;
+  uint32_t v_y0;
+  uint32_t v_w;
+  uint32_t v_h;
+  uint32_t v_x1;
+  uint32_t v_y1;
+  uint8_t v_flags;
+  uint32_t v_chunk_type;
+  uint32_t v_length;
+  uint64_t v_padded_length;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_anmf_header[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_x0 = self->private_impl.c_decode_anmf_header[0].v_x0;
+    v_y0 = self->private_impl.c_decode_anmf_header[0].v_y0;
+    v_w = self->private_impl.c_decode_anmf_header[0].v_w;
+    v_h = self->private_impl.c_decode_anmf_header[0].v_h;
+    v_x1 = self->private_impl.c_decode_anmf_header[0].v_x1;
+    v_y1 = self->private_impl.c_decode_anmf_header[0].v_y1;
+    v_flags = self->private_impl.c_decode_anmf_header[0].v_flags;
+    v_chunk_type = self->private_impl.c_decode_anmf_header[0].v_chunk_type;
+    v_length = self->private_impl.c_decode_anmf_header[0].v_length;
+    v_padded_length =
+        self->private_impl.c_decode_anmf_header[0].v_padded_length;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_chunk_length < 16) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_anmf_length = (self->private_impl.f_chunk_length - 16);
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint32_t t_1;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+        t_1 = wuffs_base__load_u24le(ioptr_src);
+        ioptr_src += 3;
+      } else {
+        self->private_impl.c_decode_anmf_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_anmf_header[0].scratch;
+          uint32_t t_0 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_0;
+          if (t_0 == 16) {
+            t_1 = *scratch;
+            break;
+          }
+          t_0 += 8;
+          *scratch |= ((uint64_t)(t_0)) << 56;
+        }
+      }
+      v_x0 = (t_1 * 2);
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint32_t t_3;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+        t_3 = wuffs_base__load_u24le(ioptr_src);
+        ioptr_src += 3;
+      } else {
+        self->private_impl.c_decode_anmf_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_anmf_header[0].scratch;
+          uint32_t t_2 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_2;
+          if (t_2 == 16) {
+            t_3 = *scratch;
+            break;
+          }
+          t_2 += 8;
+          *scratch |= ((uint64_t)(t_2)) << 56;
+        }
+      }
+      v_y0 = (t_3 * 2);
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      uint32_t t_5;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+        t_5 = wuffs_base__load_u24le(ioptr_src);
+        ioptr_src += 3;
+      } else {
+        self->private_impl.c_decode_anmf_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_anmf_header[0].scratch;
+          uint32_t t_4 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_4;
+          if (t_4 == 16) {
+            t_5 = *scratch;
+            break;
+          }
+          t_4 += 8;
+          *scratch |= ((uint64_t)(t_4)) << 56;
+        }
+      }
+      v_w = (t_5 + 1);
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      uint32_t t_7;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+        t_7 = wuffs_base__load_u24le(ioptr_src);
+        ioptr_src += 3;
+      } else {
+        self->private_impl.c_decode_anmf_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_anmf_header[0].scratch;
+          uint32_t t_6 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_6;
+          if (t_6 == 16) {
+            t_7 = *scratch;
+            break;
+          }
+          t_6 += 8;
+          *scratch |= ((uint64_t)(t_6)) << 56;
+        }
+      }
+      v_h = (t_7 + 1);
+    }
+    v_x1 = (v_x0 + v_w);
+    v_y1 = (v_y0 + v_h);
+    if ((v_x1 > self->private_impl.f_width) ||
+        (v_y1 > self->private_impl.f_height)) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    wuffs_base__rect_ie_u32__set_min_inclusive_x(
+        &self->private_impl.f_frame_rect, v_x0);
+    wuffs_base__rect_ie_u32__set_min_inclusive_y(
+        &self->private_impl.f_frame_rect, v_y0);
+    wuffs_base__rect_ie_u32__set_max_exclusive_x(
+        &self->private_impl.f_frame_rect, v_x1);
+    wuffs_base__rect_ie_u32__set_max_exclusive_y(
+        &self->private_impl.f_frame_rect, v_y1);
+    self->private_impl.f_frame_x0 = v_x0;
+    self->private_impl.f_frame_y0 = v_y0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      uint32_t t_9;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 3)) {
+        t_9 = wuffs_base__load_u24le(ioptr_src);
+        ioptr_src += 3;
+      } else {
+        self->private_impl.c_decode_anmf_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_anmf_header[0].scratch;
+          uint32_t t_8 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_8;
+          if (t_8 == 16) {
+            t_9 = *scratch;
+            break;
+          }
+          t_8 += 8;
+          *scratch |= ((uint64_t)(t_8)) << 56;
+        }
+      }
+      self->private_impl.f_frame_duration =
+          (((uint64_t)(t_9)) * wuffs_webp__flicks_per_millisecond);
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+      if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_10 = *ioptr_src++;
+      v_flags = t_10;
+    }
+    self->private_impl.f_frame_blend = ((v_flags & 2) == 0);
+    if ((v_flags & 1) != 0) {
+      self->private_impl.f_frame_disposal = 1;
+    } else {
+      self->private_impl.f_frame_disposal = 0;
+    }
+    while (true) {
+      if (self->private_impl.f_anmf_length < 8) {
+        status = WUFFS_WEBP__ERROR_BAD_HEADER;
+        goto exit;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+        uint32_t t_12;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+          t_12 = wuffs_base__load_u32le(ioptr_src);
+          ioptr_src += 4;
+        } else {
+          self->private_impl.c_decode_anmf_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch =
+                &self->private_impl.c_decode_anmf_header[0].scratch;
+            uint32_t t_11 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_11;
+            if (t_11 == 24) {
+              t_12 = *scratch;
+              break;
+            }
+            t_11 += 8;
+            *scratch |= ((uint64_t)(t_11)) << 56;
+          }
+        }
+        v_chunk_type = t_12;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+        uint32_t t_14;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+          t_14 = wuffs_base__load_u32le(ioptr_src);
+          ioptr_src += 4;
+        } else {
+          self->private_impl.c_decode_anmf_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch =
+                &self->private_impl.c_decode_anmf_header[0].scratch;
+            uint32_t t_13 = *scratch >> 56;
+            *scratch <<= 8;
+            *scratch >>= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << t_13;
+            if (t_13 == 24) {
+              t_14 = *scratch;
+              break;
+            }
+            t_13 += 8;
+            *scratch |= ((uint64_t)(t_13)) << 56;
+          }
+        }
+        v_length = t_14;
+      }
+      v_padded_length = (((uint64_t)(v_length)) + ((uint64_t)((v_length & 1))));
+      if ((((uint64_t)(self->private_impl.f_anmf_length)) < 8) ||
+          (v_padded_length >
+          (((uint64_t)(self->private_impl.f_anmf_length)) - 8))) {
+        status = WUFFS_WEBP__ERROR_BAD_HEADER;
+        goto exit;
+      }
+      self->private_impl.f_chunk_type = v_chunk_type;
+      self->private_impl.f_chunk_length =
+          ((uint32_t)((v_padded_length & 4294967295)));
+      self->private_impl.f_anmf_length =
+          wuffs_base__u32__sat_sub(self->private_impl.f_anmf_length,
+          wuffs_base__u32__sat_add(self->private_impl.f_chunk_length, 8));
+      if (v_chunk_type == wuffs_webp__fourcc_vp8l) {
+        goto label_0_break;
+      } else if ((v_chunk_type == wuffs_webp__fourcc_vp8) ||
+          (v_chunk_type == wuffs_webp__fourcc_alph)) {
+        status = WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+      self->private_impl.c_decode_anmf_header[0].scratch =
+          self->private_impl.f_chunk_length;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+      if (self->private_impl.c_decode_anmf_header[0].scratch >
+          iobounds1_src - ioptr_src) {
+        self->private_impl.c_decode_anmf_header[0].scratch -=
+            iobounds1_src - ioptr_src;
+        ioptr_src = iobounds1_src;
+        goto short_read_src;
+      }
+      ioptr_src += self->private_impl.c_decode_anmf_header[0].scratch;
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(18);
+    if (a_src.private_impl.buf) {
+      a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+    }
+    status = wuffs_webp__decoder__decode_vp8l_header(self, a_src);
+    if (a_src.private_impl.buf) {
+      ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    }
+    if (status) {
+      goto suspend;
+    }
+    if ((v_w != self->private_impl.f_vp8l_width) ||
+        (v_h != self->private_impl.f_vp8l_height)) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_anmf_header[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_anmf_header[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_anmf_header[0].v_x0 = v_x0;
+  self->private_impl.c_decode_anmf_header[0].v_y0 = v_y0;
+  self->private_impl.c_decode_anmf_header[0].v_w = v_w;
+  self->private_impl.c_decode_anmf_header[0].v_h = v_h;
+  self->private_impl.c_decode_anmf_header[0].v_x1 = v_x1;
+  self->private_impl.c_decode_anmf_header[0].v_y1 = v_y1;
+  self->private_impl.c_decode_anmf_header[0].v_flags = v_flags;
+  self->private_impl.c_decode_anmf_header[0].v_chunk_type = v_chunk_type;
+  self->private_impl.c_decode_anmf_header[0].v_length = v_length;
+  self->private_impl.c_decode_anmf_header[0].v_padded_length = v_padded_length;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_vp8l_header
+
+static wuffs_webp__status wuffs_webp__decoder__decode_vp8l_header(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_c;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_vp8l_header[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_vp8l_header[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_chunk_length < 5) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+      goto short_read_src;
+    }
+    uint8_t t_0 = *ioptr_src++;
+    if (t_0 != 47) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      uint32_t t_2;
+      if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+        t_2 = wuffs_base__load_u32le(ioptr_src);
+        ioptr_src += 4;
+      } else {
+        self->private_impl.c_decode_vp8l_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint64_t* scratch =
+              &self->private_impl.c_decode_vp8l_header[0].scratch;
+          uint32_t t_1 = *scratch >> 56;
+          *scratch <<= 8;
+          *scratch >>= 8;
+          *scratch |= ((uint64_t)(*ioptr_src++)) << t_1;
+          if (t_1 == 24) {
+            t_2 = *scratch;
+            break;
+          }
+          t_1 += 8;
+          *scratch |= ((uint64_t)(t_1)) << 56;
+        }
+      }
+      v_c = t_2;
+    }
+    if ((v_c >> 29) != 0) {
+      status = WUFFS_WEBP__ERROR_BAD_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_vp8l_width = ((v_c & 16383) + 1);
+    self->private_impl.f_vp8l_height = (((v_c >> 14) & 16383) + 1);
+    wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_chunk_length, 5);
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_vp8l_header[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_vp8l_header[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_vp8l_header[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.decode_vp8l
+
+static wuffs_webp__status wuffs_webp__decoder__decode_vp8l(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_width;
+  uint32_t v_height;
+  uint32_t v_t;
+  uint32_t v_b;
+  uint32_t v_w;
+  uint32_t v_h;
+  uint32_t v_num_colors;
+  uint32_t v_i;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_vp8l[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_width = self->private_impl.c_decode_vp8l[0].v_width;
+    v_height = self->private_impl.c_decode_vp8l[0].v_height;
+    v_t = self->private_impl.c_decode_vp8l[0].v_t;
+    v_b = self->private_impl.c_decode_vp8l[0].v_b;
+    v_w = self->private_impl.c_decode_vp8l[0].v_w;
+    v_h = self->private_impl.c_decode_vp8l[0].v_h;
+    v_num_colors = self->private_impl.c_decode_vp8l[0].v_num_colors;
+    v_i = self->private_impl.c_decode_vp8l[0].v_i;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_bits = 0;
+    self->private_impl.f_n_bits = 0;
+    self->private_impl.f_num_transforms = 0;
+    self->private_impl.f_seen_transforms = 0;
+    self->private_impl.f_meta_bits = 0;
+    v_width = self->private_impl.f_vp8l_width;
+    v_height = self->private_impl.f_vp8l_height;
+    v_t = 0;
+    v_b = 0;
+    v_w = 0;
+    v_h = 0;
+    v_num_colors = 0;
+    v_i = 0;
+    while (true) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      if (wuffs_webp__decoder__take_bits(self, 1) == 0) {
+        goto label_0_break;
+      }
+      v_t = (wuffs_webp__decoder__take_bits(self, 2) & 3);
+      if (((self->private_impl.f_seen_transforms >> v_t) & 1) != 0) {
+        status = WUFFS_WEBP__ERROR_BAD_TRANSFORM;
+        goto exit;
+      }
+      self->private_impl.f_seen_transforms |= (((uint32_t)(1)) << v_t);
+      v_i = self->private_impl.f_num_transforms;
+      if (v_i >= 4) {
+        status =
+            WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+        goto exit;
+      }
+      self->private_impl.f_transform_types[v_i] = v_t;
+      self->private_impl.f_transform_widths[v_i] = v_width;
+      self->private_impl.f_num_transforms = (v_i + 1);
+      if ((v_t == wuffs_webp__transform_predictor) ||
+          (v_t == wuffs_webp__transform_color)) {
+        v_b = ((wuffs_webp__decoder__take_bits(self, 3) & 7) + 2);
+        v_w = wuffs_webp__decoder__div_round_up(self, v_width, v_b);
+        v_h = wuffs_webp__decoder__div_round_up(self, v_height, v_b);
+        if (v_t == wuffs_webp__transform_predictor) {
+          self->private_impl.f_predictor_bits = v_b;
+          self->private_impl.f_predictor_width = v_w;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          status = wuffs_webp__decoder__decode_sub_image(self, a_dst, a_src,
+              a_workbuf, wuffs_webp__which_predictor, v_w, v_h);
+          if (status) {
+            goto suspend;
+          }
+        } else {
+          self->private_impl.f_color_bits = v_b;
+          self->private_impl.f_color_width = v_w;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+          status = wuffs_webp__decoder__decode_sub_image(self, a_dst, a_src,
+              a_workbuf, wuffs_webp__which_color, v_w, v_h);
+          if (status) {
+            goto suspend;
+          }
+        }
+      } else if (v_t == wuffs_webp__transform_color_indexing) {
+        v_num_colors = ((wuffs_webp__decoder__take_bits(self, 8) & 255) + 1);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_webp__decoder__decode_sub_image(self, a_dst, a_src,
+            a_workbuf, wuffs_webp__which_palette, v_num_colors, 1);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_palette(self, v_num_colors);
+        if (v_num_colors <= 2) {
+          self->private_impl.f_palette_width_bits = 3;
+        } else if (v_num_colors <= 4) {
+          self->private_impl.f_palette_width_bits = 2;
+        } else if (v_num_colors <= 16) {
+          self->private_impl.f_palette_width_bits = 1;
+        } else {
+          self->private_impl.f_palette_width_bits = 0;
+        }
+        v_width = wuffs_webp__decoder__div_round_up(self, v_width,
+            self->private_impl.f_palette_width_bits);
+      }
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    status = wuffs_webp__decoder__decode_frame_image(self, a_dst, a_src,
+        a_workbuf, v_width, v_height);
+    if (status) {
+      goto suspend;
+    }
+    v_i = self->private_impl.f_num_transforms;
+    while (v_i > 0) {
+      v_t = self->private_impl.f_transform_types[(v_i - 1)];
+      v_w = self->private_impl.f_transform_widths[(v_i - 1)];
+      v_i -= 1;
+      if (v_t == wuffs_webp__transform_predictor) {
+        wuffs_webp__decoder__apply_predictor_transform(self, a_dst, a_workbuf,
+            v_w, v_height);
+      } else if (v_t == wuffs_webp__transform_color) {
+        wuffs_webp__decoder__apply_color_transform(self, a_dst, a_workbuf, v_w,
+            v_height);
+      } else if (v_t == wuffs_webp__transform_subtract_green) {
+        wuffs_webp__decoder__apply_subtract_green_transform(self, a_dst, v_w,
+            v_height);
+      } else {
+        wuffs_webp__decoder__apply_color_indexing_transform(self, a_dst, v_w,
+            v_height);
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_vp8l[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_vp8l[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_vp8l[0].v_width = v_width;
+  self->private_impl.c_decode_vp8l[0].v_height = v_height;
+  self->private_impl.c_decode_vp8l[0].v_t = v_t;
+  self->private_impl.c_decode_vp8l[0].v_b = v_b;
+  self->private_impl.c_decode_vp8l[0].v_w = v_w;
+  self->private_impl.c_decode_vp8l[0].v_h = v_h;
+  self->private_impl.c_decode_vp8l[0].v_num_colors = v_num_colors;
+  self->private_impl.c_decode_vp8l[0].v_i = v_i;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.div_round_up
+
+static uint32_t wuffs_webp__decoder__div_round_up(wuffs_webp__decoder* self,
+    uint32_t a_n, uint32_t a_b) {
+  return ((a_n + ((((uint32_t)(1)) << a_b) - 1)) >> a_b);
+}
+
+// -------- func decoder.decode_palette
+
+static void wuffs_webp__decoder__decode_palette(wuffs_webp__decoder* self,
+    uint32_t a_n) {
+  uint32_t v_i;
+
+  v_i = 1;
+  while (v_i < a_n) {
+    self->private_impl.f_palette[v_i] =
+        wuffs_webp__decoder__add_pixels(self, self->private_impl.f_palette[v_i],
+        self->private_impl.f_palette[(v_i - 1)]);
+    v_i += 1;
+  }
+  while (v_i < 256) {
+    self->private_impl.f_palette[v_i] = 0;
+    v_i += 1;
+  }
+}
+
+// -------- func decoder.decode_frame_image
+
+static wuffs_webp__status wuffs_webp__decoder__decode_frame_image(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_width, uint32_t a_height) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_cache_bits;
+  uint32_t v_num_groups;
+  uint32_t v_b;
+  uint32_t v_w;
+  uint32_t v_h;
+  uint32_t v_i;
+  uint32_t v_n;
+  uint32_t v_g;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame_image[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_cache_bits = self->private_impl.c_decode_frame_image[0].v_cache_bits;
+    v_num_groups = self->private_impl.c_decode_frame_image[0].v_num_groups;
+    v_b = self->private_impl.c_decode_frame_image[0].v_b;
+    v_w = self->private_impl.c_decode_frame_image[0].v_w;
+    v_h = self->private_impl.c_decode_frame_image[0].v_h;
+    v_i = self->private_impl.c_decode_frame_image[0].v_i;
+    v_n = self->private_impl.c_decode_frame_image[0].v_n;
+    v_g = self->private_impl.c_decode_frame_image[0].v_g;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__read_color_cache_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    v_cache_bits = self->private_impl.f_cache_bits;
+    v_num_groups = 1;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    if (wuffs_webp__decoder__take_bits(self, 1) != 0) {
+      v_b = ((wuffs_webp__decoder__take_bits(self, 3) & 7) + 2);
+      v_w = wuffs_webp__decoder__div_round_up(self, a_width, v_b);
+      v_h = wuffs_webp__decoder__div_round_up(self, a_height, v_b);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_webp__decoder__decode_sub_image(self, a_dst, a_src,
+          a_workbuf, wuffs_webp__which_meta, v_w, v_h);
+      if (status) {
+        goto suspend;
+      }
+      v_i = 0;
+      while (v_i < 65536) {
+        self->private_impl.f_group_map[v_i] = 0;
+        v_i += 1;
+      }
+      v_n = (v_w * v_h);
+      v_i = 0;
+      while (v_i < v_n) {
+        v_g = ((wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+            wuffs_webp__which_meta, ((uint64_t)(v_i))) >> 8) & 65535);
+        self->private_impl.f_group_map[v_g] = 1;
+        if (v_num_groups <= v_g) {
+          v_num_groups = (v_g + 1);
+        }
+        v_i += 1;
+      }
+      self->private_impl.f_meta_bits = v_b;
+      self->private_impl.f_meta_width = v_w;
+    }
+    self->private_impl.f_cache_bits = v_cache_bits;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    status = wuffs_webp__decoder__read_huffman_groups(self, a_src, a_workbuf,
+        v_num_groups);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    status = wuffs_webp__decoder__decode_pixels(self, a_dst, a_src, a_workbuf,
+        wuffs_webp__which_frame, a_width, a_height);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame_image[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame_image[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame_image[0].v_cache_bits = v_cache_bits;
+  self->private_impl.c_decode_frame_image[0].v_num_groups = v_num_groups;
+  self->private_impl.c_decode_frame_image[0].v_b = v_b;
+  self->private_impl.c_decode_frame_image[0].v_w = v_w;
+  self->private_impl.c_decode_frame_image[0].v_h = v_h;
+  self->private_impl.c_decode_frame_image[0].v_i = v_i;
+  self->private_impl.c_decode_frame_image[0].v_n = v_n;
+  self->private_impl.c_decode_frame_image[0].v_g = v_g;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.decode_sub_image
+
+static wuffs_webp__status wuffs_webp__decoder__decode_sub_image(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_width, uint32_t a_height) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_sub_image[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__read_color_cache_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status =
+        wuffs_webp__decoder__read_huffman_groups(self, a_src, a_workbuf, 1);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_webp__decoder__decode_pixels(self, a_dst, a_src, a_workbuf,
+        a_which, a_width, a_height);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_sub_image[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_sub_image[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.read_color_cache_bits
+
+static wuffs_webp__status wuffs_webp__decoder__read_color_cache_bits(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_c;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_read_color_cache_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_read_color_cache_bits[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    self->private_impl.f_cache_bits = 0;
+    if (wuffs_webp__decoder__take_bits(self, 1) != 0) {
+      v_c = (wuffs_webp__decoder__take_bits(self, 4) & 15);
+      if ((v_c < 1) || (11 < v_c)) {
+        status = WUFFS_WEBP__ERROR_BAD_COLOR_CACHE_SIZE;
+        goto exit;
+      }
+      self->private_impl.f_cache_bits = v_c;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_color_cache_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_color_cache_bits[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_read_color_cache_bits[0].v_c = v_c;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.read_huffman_groups
+
+static wuffs_webp__status wuffs_webp__decoder__read_huffman_groups(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_n) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_num_green_symbols;
+  uint32_t v_g;
+  uint32_t v_next;
+  uint32_t v_position;
+  uint64_t v_offset;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_read_huffman_groups[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_num_green_symbols =
+        self->private_impl.c_read_huffman_groups[0].v_num_green_symbols;
+    v_g = self->private_impl.c_read_huffman_groups[0].v_g;
+    v_next = self->private_impl.c_read_huffman_groups[0].v_next;
+    v_position = self->private_impl.c_read_huffman_groups[0].v_position;
+    v_offset = self->private_impl.c_read_huffman_groups[0].v_offset;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_num_green_symbols = 280;
+    if (self->private_impl.f_cache_bits > 0) {
+      v_num_green_symbols +=
+          (((uint32_t)(1)) << self->private_impl.f_cache_bits);
+    }
+    v_g = 0;
+    v_next = 0;
+    v_position = 0;
+    v_offset = 0;
+    while (v_g < a_n) {
+      v_position = 0;
+      if (self->private_impl.f_meta_bits > 0) {
+        if (self->private_impl.f_group_map[v_g] == 0) {
+          v_position = self->private_impl.f_max_groups;
+        } else if (v_next < self->private_impl.f_max_groups) {
+          v_position = v_next;
+          self->private_impl.f_group_map[v_g] = ((uint16_t)(v_next));
+          v_next += 1;
+        } else {
+          status =
+              WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+          goto exit;
+        }
+      }
+      v_offset = (wuffs_webp__decoder__groups_offset(self) +
+          (((uint64_t)(v_position)) * wuffs_webp__group_size));
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__read_huffman_code(self, a_src, a_workbuf,
+          v_offset, v_num_green_symbols);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_webp__decoder__read_huffman_code(self, a_src, a_workbuf,
+          (v_offset + wuffs_webp__table_offset_red), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_webp__decoder__read_huffman_code(self, a_src, a_workbuf,
+          (v_offset + wuffs_webp__table_offset_blue), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_webp__decoder__read_huffman_code(self, a_src, a_workbuf,
+          (v_offset + wuffs_webp__table_offset_alpha), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_webp__decoder__read_huffman_code(self, a_src, a_workbuf,
+          (v_offset + wuffs_webp__table_offset_distance), 40);
+      if (status) {
+        goto suspend;
+      }
+      v_g += 1;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_huffman_groups[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_huffman_groups[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_read_huffman_groups[0].v_num_green_symbols =
+      v_num_green_symbols;
+  self->private_impl.c_read_huffman_groups[0].v_g = v_g;
+  self->private_impl.c_read_huffman_groups[0].v_next = v_next;
+  self->private_impl.c_read_huffman_groups[0].v_position = v_position;
+  self->private_impl.c_read_huffman_groups[0].v_offset = v_offset;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.read_huffman_code
+
+static wuffs_webp__status wuffs_webp__decoder__read_huffman_code(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t, uint32_t a_n) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_i;
+  uint32_t v_num_symbols;
+  uint32_t v_simple_symbol;
+  uint32_t v_num_code_lengths;
+  uint64_t v_code_length_t;
+  uint32_t v_max_symbol;
+  uint32_t v_length_n_bits;
+  uint8_t v_prev_code_length;
+  uint32_t v_symbol;
+  uint32_t v_code;
+  uint32_t v_repeat;
+  uint8_t v_value;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_read_huffman_code[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_i = self->private_impl.c_read_huffman_code[0].v_i;
+    v_num_symbols = self->private_impl.c_read_huffman_code[0].v_num_symbols;
+    v_simple_symbol = self->private_impl.c_read_huffman_code[0].v_simple_symbol;
+    v_num_code_lengths =
+        self->private_impl.c_read_huffman_code[0].v_num_code_lengths;
+    v_code_length_t = self->private_impl.c_read_huffman_code[0].v_code_length_t;
+    v_max_symbol = self->private_impl.c_read_huffman_code[0].v_max_symbol;
+    v_length_n_bits = self->private_impl.c_read_huffman_code[0].v_length_n_bits;
+    v_prev_code_length =
+        self->private_impl.c_read_huffman_code[0].v_prev_code_length;
+    v_symbol = self->private_impl.c_read_huffman_code[0].v_symbol;
+    v_code = self->private_impl.c_read_huffman_code[0].v_code;
+    v_repeat = self->private_impl.c_read_huffman_code[0].v_repeat;
+    v_value = self->private_impl.c_read_huffman_code[0].v_value;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_i = 0;
+    while (v_i < a_n) {
+      self->private_impl.f_code_lengths[v_i] = 0;
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    if (wuffs_webp__decoder__take_bits(self, 1) != 0) {
+      v_num_symbols = ((wuffs_webp__decoder__take_bits(self, 1) & 1) + 1);
+      v_simple_symbol = 0;
+      if (wuffs_webp__decoder__take_bits(self, 1) != 0) {
+        v_simple_symbol = wuffs_webp__decoder__take_bits(self, 8);
+      } else {
+        v_simple_symbol = wuffs_webp__decoder__take_bits(self, 1);
+      }
+      if (v_simple_symbol < a_n) {
+        self->private_impl.f_code_lengths[v_simple_symbol] = 1;
+      }
+      if (v_num_symbols == 2) {
+        v_simple_symbol = wuffs_webp__decoder__take_bits(self, 8);
+        if (v_simple_symbol < a_n) {
+          self->private_impl.f_code_lengths[v_simple_symbol] = 1;
+        }
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_webp__decoder__build_huffman(self, a_workbuf, a_t, a_n);
+      if (status) {
+        goto suspend;
+      }
+      status = WUFFS_WEBP__STATUS_OK;
+      goto ok;
+    }
+    v_num_code_lengths = ((wuffs_webp__decoder__take_bits(self, 4) & 15) + 4);
+    v_i = 0;
+    while (v_i < v_num_code_lengths) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      self->private_impl.f_code_lengths[wuffs_webp__code_length_code_order[v_i]] =
+          ((uint8_t)((wuffs_webp__decoder__take_bits(self, 3) & 7)));
+      v_i += 1;
+    }
+    while (v_i < 19) {
+      self->private_impl.f_code_lengths[wuffs_webp__code_length_code_order[v_i]] =
+          0;
+      v_i += 1;
+    }
+    v_code_length_t = wuffs_webp__decoder__code_length_table_offset(self);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    status = wuffs_webp__decoder__build_huffman(self, a_workbuf,
+        v_code_length_t, 19);
+    if (status) {
+      goto suspend;
+    }
+    v_max_symbol = a_n;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    if (wuffs_webp__decoder__take_bits(self, 1) != 0) {
+      v_length_n_bits =
+          ((2 * (wuffs_webp__decoder__take_bits(self, 3) & 7)) + 2);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      v_max_symbol =
+          (2 + (wuffs_webp__decoder__take_bits(self, v_length_n_bits) & 65535));
+      if (v_max_symbol > a_n) {
+        status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+        goto exit;
+      }
+    }
+    v_i = 0;
+    while (v_i < a_n) {
+      self->private_impl.f_code_lengths[v_i] = 0;
+      v_i += 1;
+    }
+    v_prev_code_length = 8;
+    v_symbol = 0;
+    v_code = 0;
+    v_repeat = 0;
+    v_value = 0;
+  label_0_continue:;
+    while (v_symbol < a_n) {
+      if (v_max_symbol == 0) {
+        goto label_0_break;
+      }
+      v_max_symbol -= 1;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      v_code =
+          wuffs_webp__decoder__decode_huffman(self, a_workbuf, v_code_length_t);
+      if (v_code < 16) {
+        self->private_impl.f_code_lengths[v_symbol] = ((uint8_t)(v_code));
+        v_symbol += 1;
+        if (v_code != 0) {
+          v_prev_code_length = ((uint8_t)(v_code));
+        }
+        goto label_0_continue;
+      }
+      if (v_code == 16) {
+        v_repeat = ((wuffs_webp__decoder__take_bits(self, 2) & 3) + 3);
+        v_value = v_prev_code_length;
+      } else if (v_code == 17) {
+        v_repeat = ((wuffs_webp__decoder__take_bits(self, 3) & 7) + 3);
+        v_value = 0;
+      } else if (v_code == 18) {
+        v_repeat = ((wuffs_webp__decoder__take_bits(self, 7) & 127) + 11);
+        v_value = 0;
+      } else {
+        status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+        goto exit;
+      }
+      while (v_repeat > 0) {
+        if (v_symbol >= a_n) {
+          status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+          goto exit;
+        }
+        self->private_impl.f_code_lengths[v_symbol] = v_value;
+        v_symbol += 1;
+        v_repeat -= 1;
+      }
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+    status = wuffs_webp__decoder__build_huffman(self, a_workbuf, a_t, a_n);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_huffman_code[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_huffman_code[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_read_huffman_code[0].v_i = v_i;
+  self->private_impl.c_read_huffman_code[0].v_num_symbols = v_num_symbols;
+  self->private_impl.c_read_huffman_code[0].v_simple_symbol = v_simple_symbol;
+  self->private_impl.c_read_huffman_code[0].v_num_code_lengths =
+      v_num_code_lengths;
+  self->private_impl.c_read_huffman_code[0].v_code_length_t = v_code_length_t;
+  self->private_impl.c_read_huffman_code[0].v_max_symbol = v_max_symbol;
+  self->private_impl.c_read_huffman_code[0].v_length_n_bits = v_length_n_bits;
+  self->private_impl.c_read_huffman_code[0].v_prev_code_length =
+      v_prev_code_length;
+  self->private_impl.c_read_huffman_code[0].v_symbol = v_symbol;
+  self->private_impl.c_read_huffman_code[0].v_code = v_code;
+  self->private_impl.c_read_huffman_code[0].v_repeat = v_repeat;
+  self->private_impl.c_read_huffman_code[0].v_value = v_value;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.build_huffman
+
+static wuffs_webp__status wuffs_webp__decoder__build_huffman(
+    wuffs_webp__decoder* self, wuffs_base__slice_u8 a_workbuf, uint64_t a_t,
+    uint32_t a_n) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_counts[16];
+  uint32_t v_num_symbols;
+  uint32_t v_last_symbol;
+  uint32_t v_i;
+  uint32_t v_length;
+  uint32_t v_remaining;
+  uint32_t v_l;
+  uint32_t v_next_codes[16];
+  uint32_t v_deltas[16];
+  uint32_t v_code;
+  uint32_t v_vals_length;
+  uint32_t v_c;
+  uint32_t v_maxcode;
+  uint32_t v_v;
+  uint32_t v_reversed;
+  uint32_t v_j;
+  uint32_t v_unused_bits;
+  uint32_t v_key;
+  uint32_t v_index;
+
+  memset(v_counts, 0, sizeof(v_counts));
+  v_num_symbols = 0;
+  v_last_symbol = 0;
+  v_i = 0;
+  v_length = 0;
+  v_i = 0;
+  while (v_i < a_n) {
+    v_length = ((uint32_t)(self->private_impl.f_code_lengths[v_i]));
+    if (v_length != 0) {
+      if ((v_counts[v_length] >= 2328) || (v_num_symbols >= 2328)) {
+        status =
+            WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+        goto exit;
+      }
+      v_counts[v_length] += 1;
+      v_num_symbols += 1;
+      v_last_symbol = v_i;
+    }
+    v_i += 1;
+  }
+  if (v_num_symbols == 0) {
+    status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+    goto exit;
+  } else if (v_num_symbols == 1) {
+    v_i = 0;
+    while (v_i < 256) {
+      wuffs_webp__decoder__set_table_value(self, v_i, v_last_symbol);
+      v_i += 1;
+    }
+    wuffs_webp__decoder__copy_table(self, a_workbuf, a_t, 0);
+    status = WUFFS_WEBP__STATUS_OK;
+    goto ok;
+  }
+  v_i = 0;
+  while (v_i < 256) {
+    wuffs_webp__decoder__set_table_value(self, v_i, 65535);
+    v_i += 1;
+  }
+  v_remaining = 1;
+  v_l = 1;
+  while (v_l <= 15) {
+    if (v_remaining > 1073741824) {
+      status =
+          WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+      goto exit;
+    }
+    v_remaining <<= 1;
+    if (v_remaining < v_counts[v_l]) {
+      status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED;
+      goto exit;
+    }
+    v_remaining -= v_counts[v_l];
+    v_l += 1;
+  }
+  if (v_remaining != 0) {
+    status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
+    goto exit;
+  }
+  memset(v_next_codes, 0, sizeof(v_next_codes));
+  memset(v_deltas, 0, sizeof(v_deltas));
+  v_code = 0;
+  v_vals_length = 0;
+  v_l = 1;
+  while (v_l <= 15) {
+    v_c = ((v_code + v_counts[(v_l - 1)]) << 1);
+    if (v_c > 32768) {
+      status =
+          WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+      goto exit;
+    }
+    v_code = v_c;
+    v_next_codes[v_l] = v_code;
+    v_maxcode = (v_code + v_counts[v_l]);
+    if (v_maxcode > 32768) {
+      status =
+          WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+      goto exit;
+    }
+    wuffs_webp__decoder__set_table_value(self, (256 + v_l), v_maxcode);
+    if (v_l >= 9) {
+      v_deltas[v_l] = ((v_vals_length - v_code) & 65535);
+      wuffs_webp__decoder__set_table_value(self, (272 + v_l), v_deltas[v_l]);
+      v_v = (v_vals_length + v_counts[v_l]);
+      if (v_v > a_n) {
+        status =
+            WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+        goto exit;
+      }
+      v_vals_length = v_v;
+    }
+    v_l += 1;
+  }
+  v_reversed = 0;
+  v_j = 0;
+  v_unused_bits = 0;
+  v_key = 0;
+  v_index = 0;
+  v_i = 0;
+label_0_continue:;
+  while (v_i < a_n) {
+    v_length = ((uint32_t)(self->private_impl.f_code_lengths[v_i]));
+    if (v_length == 0) {
+      v_i += 1;
+      goto label_0_continue;
+    }
+    v_code = v_next_codes[v_length];
+    if (v_code >= 32768) {
+      status =
+          WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+      goto exit;
+    }
+    v_next_codes[v_length] = (v_code + 1);
+    if (v_length <= 8) {
+      v_unused_bits = (8 - v_length);
+      v_reversed = 0;
+      v_j = 0;
+      while (v_j < 8) {
+        v_reversed = (((v_reversed << 1) | ((v_code >> v_j) & 1)) & 255);
+        v_j += 1;
+      }
+      v_key = (v_reversed >> v_unused_bits);
+      while (v_key < 256) {
+        wuffs_webp__decoder__set_table_value(self, v_key,
+            ((v_length << 12) | v_i));
+        v_key += (((uint32_t)(1)) << v_length);
+      }
+    } else {
+      v_index = ((v_code + v_deltas[v_length]) & 65535);
+      if (v_index >= 2328) {
+        status =
+            WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+        goto exit;
+      }
+      wuffs_webp__decoder__set_table_value(self, (288 + v_index), v_i);
+    }
+    v_i += 1;
+  }
+  wuffs_webp__decoder__copy_table(self, a_workbuf, a_t, v_vals_length);
+
+  goto ok;
+ok:
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.set_table_value
+
+static void wuffs_webp__decoder__set_table_value(wuffs_webp__decoder* self,
+    uint32_t a_i, uint32_t a_v) {
+  self->private_impl.f_huff_table[(2 * a_i)] = ((uint8_t)((a_v & 255)));
+  self->private_impl.f_huff_table[((2 * a_i) + 1)] = ((uint8_t)((a_v >> 8)));
+}
+
+// -------- func decoder.copy_table
+
+static void wuffs_webp__decoder__copy_table(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t, uint32_t a_n) {
+  if (a_t <= ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(a_workbuf, a_t),
+        wuffs_base__slice_u8__subslice_j(((wuffs_base__slice_u8){.ptr =
+        self->private_impl.f_huff_table, .len = 5232}), (576 + (2 * a_n))));
+  }
+}
+
+// -------- func decoder.load_table_value
+
+static uint32_t wuffs_webp__decoder__load_table_value(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_o) {
+  uint8_t v_value[2];
+
+  memset(v_value, 0, sizeof(v_value));
+  if (a_o < ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){.ptr =
+        v_value, .len = 2}), wuffs_base__slice_u8__subslice_i(a_workbuf, a_o));
+  }
+  return (((uint32_t)(v_value[0])) | (((uint32_t)(v_value[1])) << 8));
+}
+
+// -------- func decoder.fill_bits
+
+static wuffs_webp__status wuffs_webp__decoder__fill_bits(
+    wuffs_webp__decoder* self, wuffs_base__io_reader a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint8_t v_c;
+
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_fill_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_fill_bits[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    while (self->private_impl.f_n_bits < 32) {
+      v_c = 0;
+      if (self->private_impl.f_chunk_length > 0) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_0 = *ioptr_src++;
+          v_c = t_0;
+        }
+        wuffs_base__u32__sat_sub_indirect(&self->private_impl.f_chunk_length,
+            1);
+      }
+      self->private_impl.f_bits |=
+          (((uint64_t)(v_c)) << self->private_impl.f_n_bits);
+      self->private_impl.f_n_bits += 8;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_bits[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_bits[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  return status;
+
+short_read_src:
+  if (wuffs_base__io_reader__is_eof(a_src)) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+// -------- func decoder.take_bits
+
+static uint32_t wuffs_webp__decoder__take_bits(wuffs_webp__decoder* self,
+    uint32_t a_n) {
+  uint64_t v_v;
+
+  if (self->private_impl.f_n_bits < a_n) {
+    return 0;
+  }
+  v_v = ((self->private_impl.f_bits) & ((1 << (a_n)) - 1));
+  self->private_impl.f_bits >>= a_n;
+  self->private_impl.f_n_bits -= a_n;
+  return ((uint32_t)((v_v & 4294967295)));
+}
+
+// -------- func decoder.decode_huffman
+
+static uint32_t wuffs_webp__decoder__decode_huffman(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint64_t a_t) {
+  uint32_t v_entry;
+  uint32_t v_n;
+  uint64_t v_bits;
+  uint32_t v_code;
+  uint32_t v_index;
+
+  v_entry = wuffs_webp__decoder__load_table_value(self, a_workbuf,
+      (a_t +((self->private_impl.f_bits & 255) * 2)));
+  v_n = 0;
+  if (v_entry != 65535) {
+    v_n = (v_entry >> 12);
+    if (self->private_impl.f_n_bits < v_n) {
+      return 65535;
+    }
+    self->private_impl.f_bits >>= v_n;
+    self->private_impl.f_n_bits -= v_n;
+    return (v_entry & 4095);
+  }
+  v_bits = self->private_impl.f_bits;
+  v_code = 0;
+  v_index = 0;
+  v_n = 1;
+  while (v_n <= 15) {
+    v_code = (((v_code << 1) | ((uint32_t)((v_bits & 1)))) & 32767);
+    v_bits >>= 1;
+    if ((v_n >= 9) && (v_code < wuffs_webp__decoder__load_table_value(self,
+        a_workbuf, (a_t +512 + ((uint64_t)((v_n * 2))))))) {
+      if (self->private_impl.f_n_bits < v_n) {
+        return 65535;
+      }
+      self->private_impl.f_bits >>= v_n;
+      self->private_impl.f_n_bits -= v_n;
+      v_index = ((v_code + wuffs_webp__decoder__load_table_value(self,
+          a_workbuf, (a_t +544 + ((uint64_t)((v_n * 2)))))) & 65535);
+      return wuffs_webp__decoder__load_table_value(self, a_workbuf,
+          (a_t +576 + ((uint64_t)((v_index * 2)))));
+    }
+    v_n += 1;
+  }
+  return 65535;
+}
+
+// -------- func decoder.decode_lz77_value
+
+static uint32_t wuffs_webp__decoder__decode_lz77_value(
+    wuffs_webp__decoder* self, uint32_t a_p) {
+  uint32_t v_extra_bits;
+  uint32_t v_offset;
+  uint32_t v_extra;
+
+  if (a_p < 4) {
+    return (a_p + 1);
+  }
+  v_extra_bits = ((a_p - 2) >> 1);
+  v_offset = ((2 + (a_p & 1)) << v_extra_bits);
+  v_extra = (wuffs_webp__decoder__take_bits(self, v_extra_bits) & 262143);
+  return (v_offset + v_extra + 1);
+}
+
+// -------- func decoder.distance
+
+static uint32_t wuffs_webp__decoder__distance(wuffs_webp__decoder* self,
+    uint32_t a_code, uint32_t a_width) {
+  uint32_t v_m;
+  uint32_t v_d;
+
+  if (a_code > 120) {
+    return (a_code - 120);
+  } else if (a_code < 1) {
+    return 1;
+  }
+  v_m = ((uint32_t)(wuffs_webp__distance_map[(a_code - 1)]));
+  v_d = (((v_m >> 4) * a_width) + 8);
+  if (v_d <= (v_m & 15)) {
+    return 1;
+  }
+  return (v_d - (v_m & 15));
+}
+
+// -------- func decoder.decode_pixels
+
+static wuffs_webp__status wuffs_webp__decoder__decode_pixels(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__io_reader a_src, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_width, uint32_t a_height) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_num_pixels;
+  uint32_t v_p;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint64_t v_group;
+  uint32_t v_code;
+  uint32_t v_argb;
+  uint32_t v_length;
+  uint32_t v_dist_code;
+  uint32_t v_dist;
+  uint32_t v_q;
+  uint32_t v_qx;
+  uint32_t v_qy;
+  uint32_t v_i;
+  uint32_t v_num_cache_codes;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_pixels[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_num_pixels = self->private_impl.c_decode_pixels[0].v_num_pixels;
+    v_p = self->private_impl.c_decode_pixels[0].v_p;
+    v_x = self->private_impl.c_decode_pixels[0].v_x;
+    v_y = self->private_impl.c_decode_pixels[0].v_y;
+    v_group = self->private_impl.c_decode_pixels[0].v_group;
+    v_code = self->private_impl.c_decode_pixels[0].v_code;
+    v_argb = self->private_impl.c_decode_pixels[0].v_argb;
+    v_length = self->private_impl.c_decode_pixels[0].v_length;
+    v_dist_code = self->private_impl.c_decode_pixels[0].v_dist_code;
+    v_dist = self->private_impl.c_decode_pixels[0].v_dist;
+    v_q = self->private_impl.c_decode_pixels[0].v_q;
+    v_qx = self->private_impl.c_decode_pixels[0].v_qx;
+    v_qy = self->private_impl.c_decode_pixels[0].v_qy;
+    v_i = self->private_impl.c_decode_pixels[0].v_i;
+    v_num_cache_codes = self->private_impl.c_decode_pixels[0].v_num_cache_codes;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_num_pixels = (a_width * a_height);
+    v_p = 0;
+    v_x = 0;
+    v_y = 0;
+    v_group = 0;
+    v_code = 0;
+    v_argb = 0;
+    v_length = 0;
+    v_dist_code = 0;
+    v_dist = 0;
+    v_q = 0;
+    v_qx = 0;
+    v_qy = 0;
+    v_i = 0;
+    while (v_i < 2048) {
+      self->private_impl.f_color_cache[v_i] = 0;
+      v_i += 1;
+    }
+    v_num_cache_codes = 0;
+    if (self->private_impl.f_cache_bits > 0) {
+      v_num_cache_codes = (((uint32_t)(1)) << self->private_impl.f_cache_bits);
+    }
+    while (v_p < v_num_pixels) {
+      v_group =
+          wuffs_webp__decoder__group_offset(self, a_workbuf, a_which, v_x, v_y);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      v_code = wuffs_webp__decoder__decode_huffman(self, a_workbuf, v_group);
+      if (v_code < 256) {
+        v_argb = (v_code << 8);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_argb |= ((wuffs_webp__decoder__decode_huffman(self, a_workbuf,
+            (v_group + wuffs_webp__table_offset_red)) & 255) << 16);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_argb |= ((wuffs_webp__decoder__decode_huffman(self, a_workbuf,
+            (v_group + wuffs_webp__table_offset_blue)) & 255) << 0);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_argb |= ((wuffs_webp__decoder__decode_huffman(self, a_workbuf,
+            (v_group + wuffs_webp__table_offset_alpha)) & 255) << 24);
+        wuffs_webp__decoder__put_pixel(self, a_dst, a_workbuf, a_which, v_x,
+            v_y, v_p, v_argb);
+        wuffs_base__u32__sat_add_indirect(&v_p, 1);
+        wuffs_base__u32__sat_add_indirect(&v_x, 1);
+        if (v_x >= a_width) {
+          v_x = 0;
+          wuffs_base__u32__sat_add_indirect(&v_y, 1);
+        }
+      } else if (v_code < 280) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_length = wuffs_webp__decoder__decode_lz77_value(self, (v_code - 256));
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_code = wuffs_webp__decoder__decode_huffman(self, a_workbuf,
+            (v_group + wuffs_webp__table_offset_distance));
+        if (v_code >= 40) {
+          status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+          goto exit;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        v_dist_code = wuffs_webp__decoder__decode_lz77_value(self, v_code);
+        v_dist = wuffs_webp__decoder__distance(self, v_dist_code, a_width);
+        if ((v_dist > v_p) ||
+            (v_length > wuffs_base__u32__sat_sub(v_num_pixels, v_p))) {
+          status = WUFFS_WEBP__ERROR_BAD_BACK_REFERENCE;
+          goto exit;
+        }
+        v_q = wuffs_base__u32__sat_sub(v_p, v_dist);
+        v_qx = 0;
+        v_qy = 0;
+        if (a_width > 0) {
+          v_qx = (v_q % a_width);
+          v_qy = (v_q / a_width);
+        }
+        while (v_length > 0) {
+          v_argb = wuffs_webp__decoder__get_pixel(self, a_dst, a_workbuf,
+              a_which, v_qx, v_qy, v_q);
+          wuffs_webp__decoder__put_pixel(self, a_dst, a_workbuf, a_which, v_x,
+              v_y, v_p, v_argb);
+          wuffs_base__u32__sat_add_indirect(&v_p, 1);
+          wuffs_base__u32__sat_add_indirect(&v_x, 1);
+          if (v_x >= a_width) {
+            v_x = 0;
+            wuffs_base__u32__sat_add_indirect(&v_y, 1);
+          }
+          wuffs_base__u32__sat_add_indirect(&v_q, 1);
+          wuffs_base__u32__sat_add_indirect(&v_qx, 1);
+          if (v_qx >= a_width) {
+            v_qx = 0;
+            wuffs_base__u32__sat_add_indirect(&v_qy, 1);
+          }
+          v_length -= 1;
+        }
+      } else if ((v_code - 280) < v_num_cache_codes) {
+        v_argb = self->private_impl.f_color_cache[((v_code - 280) & 2047)];
+        wuffs_webp__decoder__put_pixel(self, a_dst, a_workbuf, a_which, v_x,
+            v_y, v_p, v_argb);
+        wuffs_base__u32__sat_add_indirect(&v_p, 1);
+        wuffs_base__u32__sat_add_indirect(&v_x, 1);
+        if (v_x >= a_width) {
+          v_x = 0;
+          wuffs_base__u32__sat_add_indirect(&v_y, 1);
+        }
+      } else {
+        status = WUFFS_WEBP__ERROR_BAD_HUFFMAN_CODE;
+        goto exit;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_pixels[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_pixels[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_pixels[0].v_num_pixels = v_num_pixels;
+  self->private_impl.c_decode_pixels[0].v_p = v_p;
+  self->private_impl.c_decode_pixels[0].v_x = v_x;
+  self->private_impl.c_decode_pixels[0].v_y = v_y;
+  self->private_impl.c_decode_pixels[0].v_group = v_group;
+  self->private_impl.c_decode_pixels[0].v_code = v_code;
+  self->private_impl.c_decode_pixels[0].v_argb = v_argb;
+  self->private_impl.c_decode_pixels[0].v_length = v_length;
+  self->private_impl.c_decode_pixels[0].v_dist_code = v_dist_code;
+  self->private_impl.c_decode_pixels[0].v_dist = v_dist;
+  self->private_impl.c_decode_pixels[0].v_q = v_q;
+  self->private_impl.c_decode_pixels[0].v_qx = v_qx;
+  self->private_impl.c_decode_pixels[0].v_qy = v_qy;
+  self->private_impl.c_decode_pixels[0].v_i = v_i;
+  self->private_impl.c_decode_pixels[0].v_num_cache_codes = v_num_cache_codes;
+
+  goto exit;
+exit:
+  return status;
+}
+
+// -------- func decoder.group_offset
+
+static uint64_t wuffs_webp__decoder__group_offset(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint32_t a_x,
+    uint32_t a_y) {
+  uint64_t v_i;
+  uint32_t v_g;
+
+  if ((a_which != wuffs_webp__which_frame) ||
+      (self->private_impl.f_meta_bits == 0)) {
+    return wuffs_webp__decoder__groups_offset(self);
+  }
+  v_i = ((((uint64_t)((a_y >> self->private_impl.f_meta_bits))) *
+      ((uint64_t)(self->private_impl.f_meta_width))) +
+      ((uint64_t)((a_x >> self->private_impl.f_meta_bits))));
+  v_g = ((wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+      wuffs_webp__which_meta, v_i) >> 8) & 65535);
+  return (wuffs_webp__decoder__groups_offset(self) + (((uint64_t)(
+      self->private_impl.f_group_map[v_g])) * wuffs_webp__group_size));
+}
+
+// -------- func decoder.put_pixel
+
+static void wuffs_webp__decoder__put_pixel(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_x, uint32_t a_y, uint32_t a_p,
+    uint32_t a_argb) {
+  wuffs_base__table_u8 v_tab;
+  uint64_t v_h;
+
+  if (a_which == wuffs_webp__which_frame) {
+    v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+    wuffs_webp__decoder__store_pixel(self,
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + a_y)),
+        (self->private_impl.f_frame_x0 + a_x), a_argb);
+  } else if (a_which == wuffs_webp__which_predictor) {
+    wuffs_webp__decoder__store_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_predictor, ((uint64_t)(a_p)), a_argb);
+  } else if (a_which == wuffs_webp__which_color) {
+    wuffs_webp__decoder__store_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_color, ((uint64_t)(a_p)), a_argb);
+  } else if (a_which == wuffs_webp__which_meta) {
+    wuffs_webp__decoder__store_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_meta, ((uint64_t)(a_p)), a_argb);
+  } else {
+    self->private_impl.f_palette[(a_p & 255)] = a_argb;
+  }
+  if (self->private_impl.f_cache_bits > 0) {
+    v_h = ((((uint64_t)(a_argb)) * 506832829) & 4294967295);
+    self->private_impl.f_color_cache[((v_h >>
+        (32 - self->private_impl.f_cache_bits)) & 2047)] = a_argb;
+  }
+}
+
+// -------- func decoder.get_pixel
+
+static uint32_t wuffs_webp__decoder__get_pixel(wuffs_webp__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__slice_u8 a_workbuf,
+    uint32_t a_which, uint32_t a_x, uint32_t a_y, uint32_t a_p) {
+  wuffs_base__table_u8 v_tab;
+
+  if (a_which == wuffs_webp__which_frame) {
+    v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+    return wuffs_webp__decoder__load_pixel(self,
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + a_y)),
+        (self->private_impl.f_frame_x0 + a_x));
+  } else if (a_which == wuffs_webp__which_predictor) {
+    return wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_predictor, ((uint64_t)(a_p)));
+  } else if (a_which == wuffs_webp__which_color) {
+    return wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_color, ((uint64_t)(a_p)));
+  } else if (a_which == wuffs_webp__which_meta) {
+    return wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+        wuffs_webp__which_meta, ((uint64_t)(a_p)));
+  }
+  return self->private_impl.f_palette[(a_p & 255)];
+}
+
+// -------- func decoder.load_sub_pixel
+
+static uint32_t wuffs_webp__decoder__load_sub_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint64_t a_i) {
+  uint64_t v_n;
+  uint64_t v_offset;
+
+  v_n = ((uint64_t)(self->private_impl.f_sub_image_max_pixels));
+  if (a_i >= v_n) {
+    return 0;
+  }
+  v_offset = (((uint64_t)((a_which - 1))) * v_n * 4);
+  if (v_offset > ((uint64_t)(a_workbuf.len))) {
+    return 0;
+  }
+  return wuffs_webp__decoder__load_pixel(self,
+      wuffs_base__slice_u8__subslice_i(a_workbuf, v_offset), ((uint32_t)(a_i)));
+}
+
+// -------- func decoder.store_sub_pixel
+
+static void wuffs_webp__decoder__store_sub_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_which, uint64_t a_i,
+    uint32_t a_argb) {
+  uint64_t v_n;
+  uint64_t v_offset;
+
+  v_n = ((uint64_t)(self->private_impl.f_sub_image_max_pixels));
+  if (a_i >= v_n) {
+    return;
+  }
+  v_offset = (((uint64_t)((a_which - 1))) * v_n * 4);
+  if (v_offset > ((uint64_t)(a_workbuf.len))) {
+    return;
+  }
+  wuffs_webp__decoder__store_pixel(self,
+      wuffs_base__slice_u8__subslice_i(a_workbuf, v_offset), ((uint32_t)(a_i)),
+      a_argb);
+}
+
+// -------- func decoder.load_pixel
+
+static uint32_t wuffs_webp__decoder__load_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_row, uint32_t a_x) {
+  uint64_t v_x4;
+  uint8_t v_pixel[4];
+
+  v_x4 = (((uint64_t)(a_x)) * 4);
+  memset(v_pixel, 0, sizeof(v_pixel));
+  if (v_x4 < ((uint64_t)(a_row.len))) {
+    wuffs_base__slice_u8__copy_from_slice(((wuffs_base__slice_u8){.ptr =
+        v_pixel, .len = 4}), wuffs_base__slice_u8__subslice_i(a_row, v_x4));
+  }
+  return ((((uint32_t)(v_pixel[0])) << 0) | (((uint32_t)(v_pixel[1])) << 8) |
+      (((uint32_t)(v_pixel[2])) << 16) | (((uint32_t)(v_pixel[3])) << 24));
+}
+
+// -------- func decoder.store_pixel
+
+static void wuffs_webp__decoder__store_pixel(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_row, uint32_t a_x, uint32_t a_argb) {
+  uint64_t v_x4;
+  uint8_t v_pixel[4];
+
+  v_x4 = (((uint64_t)(a_x)) * 4);
+  memset(v_pixel, 0, sizeof(v_pixel));
+  v_pixel[0] = ((uint8_t)(((a_argb >> 0) & 255)));
+  v_pixel[1] = ((uint8_t)(((a_argb >> 8) & 255)));
+  v_pixel[2] = ((uint8_t)(((a_argb >> 16) & 255)));
+  v_pixel[3] = ((uint8_t)(((a_argb >> 24) & 255)));
+  if (v_x4 < ((uint64_t)(a_row.len))) {
+    wuffs_base__slice_u8__copy_from_slice(wuffs_base__slice_u8__subslice_i(
+        a_row, v_x4), ((wuffs_base__slice_u8){.ptr = v_pixel, .len = 4}));
+  }
+}
+
+// -------- func decoder.add_pixels
+
+static uint32_t wuffs_webp__decoder__add_pixels(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b) {
+  return ((((a_a & 4278255360) + (a_b & 4278255360)) & 4278255360) |
+      (((a_a & 16711935) + (a_b & 16711935)) & 16711935));
+}
+
+// -------- func decoder.apply_predictor_transform
+
+static void wuffs_webp__decoder__apply_predictor_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_width, uint32_t a_height) {
+  wuffs_base__table_u8 v_tab;
+  wuffs_base__slice_u8 v_curr;
+  wuffs_base__slice_u8 v_prev;
+  uint32_t v_x0;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_l;
+  uint32_t v_t;
+  uint32_t v_tr;
+  uint32_t v_tl;
+  uint32_t v_prediction;
+  uint32_t v_mode;
+  uint32_t v_bits;
+  uint64_t v_i;
+
+  v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+  v_curr = ((wuffs_base__slice_u8){});
+  v_prev = ((wuffs_base__slice_u8){});
+  v_x0 = self->private_impl.f_frame_x0;
+  v_x = 0;
+  v_y = 0;
+  v_l = 0;
+  v_t = 0;
+  v_tr = 0;
+  v_tl = 0;
+  v_prediction = 0;
+  v_mode = 0;
+  v_bits = self->private_impl.f_predictor_bits;
+  v_i = 0;
+  while (v_y < a_height) {
+    v_curr =
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + v_y));
+    v_x = 0;
+    while (v_x < a_width) {
+      if (v_y == 0) {
+        if (v_x == 0) {
+          v_prediction = 4278190080;
+        } else {
+          v_prediction = v_l;
+        }
+      } else if (v_x == 0) {
+        v_prediction = wuffs_webp__decoder__load_pixel(self, v_prev, v_x0);
+      } else {
+        v_i = ((((uint64_t)((v_y >> v_bits))) *
+            ((uint64_t)(self->private_impl.f_predictor_width))) +
+            ((uint64_t)((v_x >> v_bits))));
+        v_mode = ((wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+            wuffs_webp__which_predictor, v_i) >> 8) & 15);
+        v_t = wuffs_webp__decoder__load_pixel(self, v_prev, (v_x0 + v_x));
+        v_tl =
+            wuffs_webp__decoder__load_pixel(self, v_prev, (v_x0 + (v_x - 1)));
+        if (wuffs_base__u32__sat_add(v_x, 1) < a_width) {
+          v_tr = wuffs_webp__decoder__load_pixel(self, v_prev,
+              (v_x0 + wuffs_base__u32__sat_add(v_x, 1)));
+        } else {
+          v_tr = wuffs_webp__decoder__load_pixel(self, v_curr, v_x0);
+        }
+        v_prediction =
+            wuffs_webp__decoder__predict(self, v_mode, v_l, v_t, v_tr, v_tl);
+      }
+      v_l = wuffs_webp__decoder__load_pixel(self, v_curr, (v_x0 + v_x));
+      v_l = wuffs_webp__decoder__add_pixels(self, v_l, v_prediction);
+      wuffs_webp__decoder__store_pixel(self, v_curr, (v_x0 + v_x), v_l);
+      wuffs_base__u32__sat_add_indirect(&v_x, 1);
+    }
+    v_prev = v_curr;
+    wuffs_base__u32__sat_add_indirect(&v_y, 1);
+  }
+}
+
+// -------- func decoder.predict
+
+static uint32_t wuffs_webp__decoder__predict(wuffs_webp__decoder* self,
+    uint32_t a_mode, uint32_t a_l, uint32_t a_t, uint32_t a_tr, uint32_t a_tl) {
+  uint32_t v_p;
+  uint32_t v_q;
+
+  v_p = 0;
+  v_q = 0;
+  if (a_mode == 1) {
+    return a_l;
+  } else if (a_mode == 2) {
+    return a_t;
+  } else if (a_mode == 3) {
+    return a_tr;
+  } else if (a_mode == 4) {
+    return a_tl;
+  } else if (a_mode == 5) {
+    v_p = wuffs_webp__decoder__average2(self, a_l, a_tr);
+    return wuffs_webp__decoder__average2(self, v_p, a_t);
+  } else if (a_mode == 6) {
+    return wuffs_webp__decoder__average2(self, a_l, a_tl);
+  } else if (a_mode == 7) {
+    return wuffs_webp__decoder__average2(self, a_l, a_t);
+  } else if (a_mode == 8) {
+    return wuffs_webp__decoder__average2(self, a_tl, a_t);
+  } else if (a_mode == 9) {
+    return wuffs_webp__decoder__average2(self, a_t, a_tr);
+  } else if (a_mode == 10) {
+    v_p = wuffs_webp__decoder__average2(self, a_l, a_tl);
+    v_q = wuffs_webp__decoder__average2(self, a_t, a_tr);
+    return wuffs_webp__decoder__average2(self, v_p, v_q);
+  } else if (a_mode == 11) {
+    return wuffs_webp__decoder__select_nearer(self, a_l, a_t, a_tl);
+  } else if (a_mode == 12) {
+    return wuffs_webp__decoder__clamp_add_subtract_full(self, a_l, a_t, a_tl);
+  } else if (a_mode == 13) {
+    v_p = wuffs_webp__decoder__average2(self, a_l, a_t);
+    return wuffs_webp__decoder__clamp_add_subtract_half(self, v_p, a_tl);
+  }
+  return 4278190080;
+}
+
+// -------- func decoder.average2
+
+static uint32_t wuffs_webp__decoder__average2(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b) {
+  return ((((a_a ^ a_b) & 4278124286) >> 1) + (a_a & a_b));
+}
+
+// -------- func decoder.select_nearer
+
+static uint32_t wuffs_webp__decoder__select_nearer(wuffs_webp__decoder* self,
+    uint32_t a_l, uint32_t a_t, uint32_t a_tl) {
+  uint32_t v_distance_to_l;
+  uint32_t v_distance_to_t;
+
+  v_distance_to_l = (wuffs_webp__decoder__abs_diff(self, ((a_t >> 0) & 255),
+      ((a_tl >> 0) & 255)) + wuffs_webp__decoder__abs_diff(self,
+      ((a_t >> 8) & 255), ((a_tl >> 8) & 255)) +
+      wuffs_webp__decoder__abs_diff(self, ((a_t >> 16) & 255),
+      ((a_tl >> 16) & 255)) + wuffs_webp__decoder__abs_diff(self,
+      ((a_t >> 24) & 255), ((a_tl >> 24) & 255)));
+  v_distance_to_t = (wuffs_webp__decoder__abs_diff(self, ((a_l >> 0) & 255),
+      ((a_tl >> 0) & 255)) + wuffs_webp__decoder__abs_diff(self,
+      ((a_l >> 8) & 255), ((a_tl >> 8) & 255)) +
+      wuffs_webp__decoder__abs_diff(self, ((a_l >> 16) & 255),
+      ((a_tl >> 16) & 255)) + wuffs_webp__decoder__abs_diff(self,
+      ((a_l >> 24) & 255), ((a_tl >> 24) & 255)));
+  if (v_distance_to_l < v_distance_to_t) {
+    return a_l;
+  }
+  return a_t;
+}
+
+// -------- func decoder.abs_diff
+
+static uint32_t wuffs_webp__decoder__abs_diff(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b) {
+  if (a_a >= a_b) {
+    return (a_a - a_b);
+  }
+  return wuffs_base__u32__sat_sub(a_b, a_a);
+}
+
+// -------- func decoder.clamp_add_subtract_full
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_full(
+    wuffs_webp__decoder* self, uint32_t a_a, uint32_t a_b, uint32_t a_c) {
+  return ((wuffs_webp__decoder__clamp_full(self, ((a_a >> 0) & 255),
+      ((a_b >> 0) & 255), ((a_c >> 0) & 255)) << 0) |
+      (wuffs_webp__decoder__clamp_full(self, ((a_a >> 8) & 255), ((a_b >> 8) &
+      255), ((a_c >> 8) & 255)) << 8) | (wuffs_webp__decoder__clamp_full(self,
+      ((a_a >> 16) & 255), ((a_b >> 16) & 255), ((a_c >> 16) & 255)) << 16) |
+      (wuffs_webp__decoder__clamp_full(self, ((a_a >> 24) & 255),
+      ((a_b >> 24) & 255), ((a_c >> 24) & 255)) << 24));
+}
+
+// -------- func decoder.clamp_full
+
+static uint32_t wuffs_webp__decoder__clamp_full(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b, uint32_t a_c) {
+  uint32_t v_v;
+
+  v_v = (a_a + a_b);
+  if (v_v <= a_c) {
+    return 0;
+  }
+  v_v -= a_c;
+  if (v_v > 255) {
+    return 255;
+  }
+  return v_v;
+}
+
+// -------- func decoder.clamp_add_subtract_half
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_half(
+    wuffs_webp__decoder* self, uint32_t a_a, uint32_t a_b) {
+  return ((wuffs_webp__decoder__clamp_half(self, ((a_a >> 0) & 255),
+      ((a_b >> 0) & 255)) << 0) | (wuffs_webp__decoder__clamp_half(self,
+      ((a_a >> 8) & 255), ((a_b >> 8) & 255)) << 8) |
+      (wuffs_webp__decoder__clamp_half(self, ((a_a >> 16) & 255),
+      ((a_b >> 16) & 255)) << 16) | (wuffs_webp__decoder__clamp_half(self,
+      ((a_a >> 24) & 255), ((a_b >> 24) & 255)) << 24));
+}
+
+// -------- func decoder.clamp_half
+
+static uint32_t wuffs_webp__decoder__clamp_half(wuffs_webp__decoder* self,
+    uint32_t a_a, uint32_t a_b) {
+  uint32_t v_d;
+
+  v_d = 0;
+  if (a_b >= a_a) {
+    v_d = ((a_b - a_a) / 2);
+    if (v_d >= a_a) {
+      return 0;
+    }
+    return (a_a - v_d);
+  }
+  v_d = (wuffs_base__u32__sat_sub(a_a, a_b) / 2);
+  if (v_d > (255 - a_a)) {
+    return 255;
+  }
+  return (a_a + v_d);
+}
+
+// -------- func decoder.apply_color_transform
+
+static void wuffs_webp__decoder__apply_color_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    wuffs_base__slice_u8 a_workbuf, uint32_t a_width, uint32_t a_height) {
+  wuffs_base__table_u8 v_tab;
+  wuffs_base__slice_u8 v_row;
+  uint32_t v_x0;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_bits;
+  uint64_t v_i;
+  uint32_t v_m;
+  uint32_t v_argb;
+  uint32_t v_g;
+  uint32_t v_r;
+  uint32_t v_b;
+
+  v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+  v_row = ((wuffs_base__slice_u8){});
+  v_x0 = self->private_impl.f_frame_x0;
+  v_x = 0;
+  v_y = 0;
+  v_bits = self->private_impl.f_color_bits;
+  v_i = 0;
+  v_m = 0;
+  v_argb = 0;
+  v_g = 0;
+  v_r = 0;
+  v_b = 0;
+  while (v_y < a_height) {
+    v_row =
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + v_y));
+    v_x = 0;
+    while (v_x < a_width) {
+      v_i = ((((uint64_t)((v_y >> v_bits))) * ((uint64_t)(
+          self->private_impl.f_color_width))) + ((uint64_t)((v_x >> v_bits))));
+      v_m = wuffs_webp__decoder__load_sub_pixel(self, a_workbuf,
+          wuffs_webp__which_color, v_i);
+      v_argb = wuffs_webp__decoder__load_pixel(self, v_row, (v_x0 + v_x));
+      v_g = ((v_argb >> 8) & 255);
+      v_r = (((v_argb >> 16) + wuffs_webp__decoder__color_transform_delta(self,
+          ((v_m >> 0) & 255), v_g)) & 255);
+      v_b =
+          (((v_argb + wuffs_webp__decoder__color_transform_delta(self, ((v_m >>
+          8) & 255), v_g)) + wuffs_webp__decoder__color_transform_delta(self,
+          ((v_m >> 16) & 255), v_r)) & 255);
+      v_argb = ((v_argb & 4278255360) | (v_r << 16) | v_b);
+      wuffs_webp__decoder__store_pixel(self, v_row, (v_x0 + v_x), v_argb);
+      wuffs_base__u32__sat_add_indirect(&v_x, 1);
+    }
+    wuffs_base__u32__sat_add_indirect(&v_y, 1);
+  }
+}
+
+// -------- func decoder.color_transform_delta
+
+static uint32_t wuffs_webp__decoder__color_transform_delta(
+    wuffs_webp__decoder* self, uint32_t a_t, uint32_t a_c) {
+  uint32_t v_t_magnitude;
+  uint32_t v_c_magnitude;
+  bool v_negative;
+  uint32_t v_product;
+
+  v_t_magnitude = 0;
+  v_c_magnitude = 0;
+  v_negative = 0;
+  if (a_t < 128) {
+    v_t_magnitude = a_t;
+  } else {
+    v_t_magnitude = (256 - a_t);
+    v_negative = !v_negative;
+  }
+  if (a_c < 128) {
+    v_c_magnitude = a_c;
+  } else {
+    v_c_magnitude = (256 - a_c);
+    v_negative = !v_negative;
+  }
+  v_product = (v_t_magnitude * v_c_magnitude);
+  if (v_negative) {
+    return (0 - ((v_product + 31) >> 5));
+  }
+  return (v_product >> 5);
+}
+
+// -------- func decoder.apply_subtract_green_transform
+
+static void wuffs_webp__decoder__apply_subtract_green_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    uint32_t a_width, uint32_t a_height) {
+  wuffs_base__table_u8 v_tab;
+  wuffs_base__slice_u8 v_row;
+  uint32_t v_x0;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_argb;
+  uint32_t v_g;
+
+  v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+  v_row = ((wuffs_base__slice_u8){});
+  v_x0 = self->private_impl.f_frame_x0;
+  v_x = 0;
+  v_y = 0;
+  v_argb = 0;
+  v_g = 0;
+  while (v_y < a_height) {
+    v_row =
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + v_y));
+    v_x = 0;
+    while (v_x < a_width) {
+      v_argb = wuffs_webp__decoder__load_pixel(self, v_row, (v_x0 + v_x));
+      v_g = ((v_argb >> 8) & 255);
+      v_argb =
+          wuffs_webp__decoder__add_pixels(self, v_argb, ((v_g << 16) | v_g));
+      wuffs_webp__decoder__store_pixel(self, v_row, (v_x0 + v_x), v_argb);
+      wuffs_base__u32__sat_add_indirect(&v_x, 1);
+    }
+    wuffs_base__u32__sat_add_indirect(&v_y, 1);
+  }
+}
+
+// -------- func decoder.apply_color_indexing_transform
+
+static void wuffs_webp__decoder__apply_color_indexing_transform(
+    wuffs_webp__decoder* self, wuffs_base__image_buffer* a_dst,
+    uint32_t a_width, uint32_t a_height) {
+  wuffs_base__table_u8 v_tab;
+  wuffs_base__slice_u8 v_row;
+  uint32_t v_x0;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_width_bits;
+  uint32_t v_bits_per_index;
+  uint32_t v_index_mask;
+  uint32_t v_g;
+  uint32_t v_shift;
+
+  v_tab = wuffs_base__image_buffer__plane(a_dst, 0);
+  v_row = ((wuffs_base__slice_u8){});
+  v_x0 = self->private_impl.f_frame_x0;
+  v_x = 0;
+  v_y = 0;
+  v_width_bits = self->private_impl.f_palette_width_bits;
+  v_bits_per_index = (((uint32_t)(8)) >> v_width_bits);
+  v_index_mask = ((((uint32_t)(1)) << v_bits_per_index) - 1);
+  v_g = 0;
+  v_shift = 0;
+  while (v_y < a_height) {
+    v_row =
+        wuffs_base__table_u8__row(v_tab, (self->private_impl.f_frame_y0 + v_y));
+    v_x = a_width;
+    while (v_x > 0) {
+      v_x -= 1;
+      v_g = ((wuffs_webp__decoder__load_pixel(self, v_row,
+          (v_x0 + (v_x >> v_width_bits))) >> 8) & 255);
+      v_shift =
+          (v_bits_per_index * (v_x & ((((uint32_t)(1)) << v_width_bits) - 1)));
+      wuffs_webp__decoder__store_pixel(self, v_row, (v_x0 + v_x),
+          self->private_impl.f_palette[((v_g >> (v_shift & 7)) &
+          v_index_mask)]);
+    }
+    wuffs_base__u32__sat_add_indirect(&v_y, 1);
+  }
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_webp__status wuffs_webp__decoder__save_state(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_WEBP__DECODER__STATE_LENGTH) {
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_height));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.f_call_sequence);
+  p += 1;
+  p[0] = self->private_impl.f_end_of_data ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_is_animated ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_num_loops));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_riff_length));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunk_type));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_chunk_length));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_anmf_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.min_inclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.min_inclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.max_exclusive_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_frame_rect.max_exclusive_y));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_x0));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_frame_y0));
+  p += 4;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_frame_duration));
+  p += 8;
+  p[0] = self->private_impl.f_frame_blend ? 1 : 0;
+  p += 1;
+  p[0] = (uint8_t)(self->private_impl.f_frame_disposal);
+  p += 1;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_vp8l_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_vp8l_height));
+  p += 4;
+  wuffs_base__store_u64le(p, (uint64_t)(self->private_impl.f_bits));
+  p += 8;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_n_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_num_transforms));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_seen_transforms));
+  p += 4;
+  for (i0 = 0; i0 < 4; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_transform_types[i0]));
+    p += 4;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_transform_widths[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_predictor_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_predictor_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_color_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_color_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_palette_width_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_meta_bits));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_meta_width));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_cache_bits));
+  p += 4;
+  for (i0 = 0; i0 < 5232; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_huff_table[i0]);
+    p += 1;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_max_groups));
+  p += 4;
+  for (i0 = 0; i0 < 65536; i0++) {
+    wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.f_group_map[i0]));
+    p += 2;
+  }
+  for (i0 = 0; i0 < 2328; i0++) {
+    p[0] = (uint8_t)(self->private_impl.f_code_lengths[i0]);
+    p += 1;
+  }
+  for (i0 = 0; i0 < 2048; i0++) {
+    wuffs_base__store_u32le(p,
+        (uint32_t)(self->private_impl.f_color_cache[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.f_sub_image_max_pixels));
+  p += 4;
+  for (i0 = 0; i0 < 256; i0++) {
+    wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_palette[i0]));
+    p += 4;
+  }
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_riff_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_max_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_max_height));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_sub_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_config[0].v_sub_height));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_config[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_frame[0].v_workbuf_len));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_frame[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_next_chunk[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_next_chunk[0].v_length));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_next_chunk[0].v_padded_length));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_next_chunk[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_x0));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_y0));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_w));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_h));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_x1));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_y1));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_decode_anmf_header[0].v_flags);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_chunk_type));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_anmf_header[0].v_length));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_anmf_header[0].v_padded_length));
+  p += 8;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_anmf_header[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l_header[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l_header[0].v_c));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_vp8l_header[0].scratch));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_width));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_height));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_t));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_b));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_w));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_h));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_num_colors));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_vp8l[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_cache_bits));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_num_groups));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_b));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_w));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_h));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_n));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_frame_image[0].v_g));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_sub_image[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_read_color_cache_bits[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_color_cache_bits[0].v_c));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_groups[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(
+      self->private_impl.c_read_huffman_groups[0].v_num_green_symbols));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_groups[0].v_g));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_groups[0].v_next));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_groups[0].v_position));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_read_huffman_groups[0].v_offset));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_num_symbols));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_simple_symbol));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_num_code_lengths));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_read_huffman_code[0].v_code_length_t));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_max_symbol));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_length_n_bits));
+  p += 4;
+  p[0] =
+      (uint8_t)(self->private_impl.c_read_huffman_code[0].v_prev_code_length);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_symbol));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_read_huffman_code[0].v_repeat));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_read_huffman_code[0].v_value);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_fill_bits[0].coro_susp_point));
+  p += 4;
+  p[0] = (uint8_t)(self->private_impl.c_fill_bits[0].v_c);
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_num_pixels));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_p));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_x));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_y));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode_pixels[0].v_group));
+  p += 8;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_argb));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_length));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_dist_code));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_dist));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_q));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_qx));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_qy));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_i));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode_pixels[0].v_num_cache_codes));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0x7E41A06D,
+      WUFFS_WEBP__DECODER__STATE_LENGTH);
+  return WUFFS_WEBP__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_webp__status wuffs_webp__decoder__restore_state(wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_WEBP__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0x7E41A06D,
+      WUFFS_WEBP__DECODER__STATE_LENGTH)) {
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+  size_t i0;
+
+  self->private_impl.status = (wuffs_webp__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_call_sequence = (uint8_t)(p[0]);
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_end_of_data = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_is_animated = p[0];
+  p += 1;
+  self->private_impl.f_num_loops = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_riff_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_chunk_type = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_chunk_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_anmf_length = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.min_inclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.min_inclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.max_exclusive_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_rect.max_exclusive_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_x0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_y0 = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_frame_duration = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  if (self->private_impl.f_frame_duration > 11838002904000) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_frame_blend = p[0];
+  p += 1;
+  self->private_impl.f_frame_disposal = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.f_vp8l_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_vp8l_width > 16384) {
+    goto bad_state;
+  }
+  self->private_impl.f_vp8l_height = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_vp8l_height > 16384) {
+    goto bad_state;
+  }
+  self->private_impl.f_bits = (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.f_n_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_n_bits > 64) {
+    goto bad_state;
+  }
+  self->private_impl.f_num_transforms = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_num_transforms > 4) {
+    goto bad_state;
+  }
+  self->private_impl.f_seen_transforms = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_seen_transforms > 15) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_transform_types[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+    if (self->private_impl.f_transform_types[i0] > 3) {
+      goto bad_state;
+    }
+  }
+  for (i0 = 0; i0 < 4; i0++) {
+    self->private_impl.f_transform_widths[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+    if (self->private_impl.f_transform_widths[i0] > 16384) {
+      goto bad_state;
+    }
+  }
+  self->private_impl.f_predictor_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_predictor_bits > 9) {
+    goto bad_state;
+  }
+  self->private_impl.f_predictor_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_predictor_width > 16384) {
+    goto bad_state;
+  }
+  self->private_impl.f_color_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_color_bits > 9) {
+    goto bad_state;
+  }
+  self->private_impl.f_color_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_color_width > 16384) {
+    goto bad_state;
+  }
+  self->private_impl.f_palette_width_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_palette_width_bits > 3) {
+    goto bad_state;
+  }
+  self->private_impl.f_meta_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_meta_bits > 9) {
+    goto bad_state;
+  }
+  self->private_impl.f_meta_width = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_meta_width > 16384) {
+    goto bad_state;
+  }
+  self->private_impl.f_cache_bits = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_cache_bits > 11) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 5232; i0++) {
+    self->private_impl.f_huff_table[i0] = (uint8_t)(p[0]);
+    p += 1;
+  }
+  self->private_impl.f_max_groups = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_max_groups > 65536) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 65536; i0++) {
+    self->private_impl.f_group_map[i0] = (uint16_t)(wuffs_base__load_u16le(p));
+    p += 2;
+  }
+  for (i0 = 0; i0 < 2328; i0++) {
+    self->private_impl.f_code_lengths[i0] = (uint8_t)(p[0]);
+    p += 1;
+    if (self->private_impl.f_code_lengths[i0] > 15) {
+      goto bad_state;
+    }
+  }
+  for (i0 = 0; i0 < 2048; i0++) {
+    self->private_impl.f_color_cache[i0] =
+        (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  self->private_impl.f_sub_image_max_pixels =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_sub_image_max_pixels > 16777216) {
+    goto bad_state;
+  }
+  for (i0 = 0; i0 < 256; i0++) {
+    self->private_impl.f_palette[i0] = (uint32_t)(wuffs_base__load_u32le(p));
+    p += 4;
+  }
+  self->private_impl.c_decode_config[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_config[0].coro_susp_point > 26) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_config[0].v_riff_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].v_max_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].v_max_height =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].v_sub_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].v_sub_height =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_config[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_frame[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_frame[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_frame[0].v_workbuf_len =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_frame[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_next_chunk[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_next_chunk[0].coro_susp_point > 4) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_next_chunk[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_next_chunk[0].v_padded_length =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_next_chunk[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_anmf_header[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_anmf_header[0].coro_susp_point > 18) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_x0 =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_y0 =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_w =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_h =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_x1 =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_y1 =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_flags = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_anmf_header[0].v_chunk_type =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_anmf_header[0].v_padded_length =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_anmf_header[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_vp8l_header[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_vp8l_header[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_vp8l_header[0].v_c =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l_header[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_vp8l[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_vp8l[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_width =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_height =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_t =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_b =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_w =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_h =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_num_colors =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_vp8l[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_frame_image[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_cache_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_num_groups =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_b =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_w =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_h =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_n =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_frame_image[0].v_g =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_sub_image[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_sub_image[0].coro_susp_point > 3) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_color_cache_bits[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_color_cache_bits[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_color_cache_bits[0].v_c =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_huffman_groups[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].v_num_green_symbols =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].v_g =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].v_next =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].v_position =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_groups[0].v_offset =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_read_huffman_code[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_read_huffman_code[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_num_symbols =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_simple_symbol =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_num_code_lengths =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_code_length_t =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_read_huffman_code[0].v_max_symbol =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_length_n_bits =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_prev_code_length =
+      (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_read_huffman_code[0].v_symbol =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_repeat =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_read_huffman_code[0].v_value = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_fill_bits[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_fill_bits[0].coro_susp_point > 1) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_fill_bits[0].v_c = (uint8_t)(p[0]);
+  p += 1;
+  self->private_impl.c_decode_pixels[0].coro_susp_point =
+      wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode_pixels[0].coro_susp_point > 7) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_num_pixels =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_p =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_x =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_y =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_group =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  self->private_impl.c_decode_pixels[0].v_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_argb =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_length =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_dist_code =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_dist =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_q =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_qx =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_qy =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_i =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode_pixels[0].v_num_cache_codes =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_WEBP__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
     }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
+    }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,18 +1390,30 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
-  // ---------------- BEGIN USE "std/adler32"
+// ---------------- BEGIN USE "std/adler32"
 
 #ifndef WUFFS_ADLER32_H
 #define WUFFS_ADLER32_H
 
-  // Code generated by wuffs-c. DO NOT EDIT.
+// Code generated by wuffs-c. DO NOT EDIT.
+
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1219,24 +1433,21 @@ typedef int32_t wuffs_adler32__status;
 
 #define wuffs_adler32__packageid 681002  // 0x000A642A
 
-#define WUFFS_ADLER32__STATUS_OK 0                            // 0x00000000
-#define WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_ADLER32__STATUS_OK 0  // 0x00000000
+#define WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_ADLER32__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_ADLER32__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                   // 0x80000006
+#define WUFFS_ADLER32__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_ADLER32__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
 #define WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
-#define WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES -2147483640      // 0x80000008
-#define WUFFS_ADLER32__ERROR_UNEXPECTED_EOF -2147483639         // 0x80000009
-#define WUFFS_ADLER32__SUSPENSION_SHORT_READ 10                 // 0x0000000A
-#define WUFFS_ADLER32__SUSPENSION_SHORT_WRITE 11                // 0x0000000B
-#define WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION \
-  -2147483636                                                   // 0x8000000C
+#define WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_ADLER32__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_ADLER32__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_ADLER32__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
 #define WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
-#define WUFFS_ADLER32__SUSPENSION_END_OF_DATA 14                // 0x0000000E
+#define WUFFS_ADLER32__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
 bool wuffs_adler32__status__is_error(wuffs_adler32__status s);
 
@@ -1260,10 +1471,13 @@ typedef struct {
 
     uint32_t f_state;
     bool f_started;
-
   } private_impl;
 } wuffs_adler32__hasher;
 
+// WUFFS_ADLER32__HASHER__STATE_LENGTH is the length of a
+// wuffs_adler32__hasher's saved state.
+#define WUFFS_ADLER32__HASHER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 9)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_adler32__hasher__check_wuffs_version is an initializer function.
@@ -1272,18 +1486,135 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_adler32__hasher__check_wuffs_version(wuffs_adler32__hasher* self,
-                                                size_t sizeof_star_self,
-                                                uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_adler32__hasher returns sizeof(wuffs_adler32__hasher), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_adler32__hasher(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_adler32__hasher__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ADLER32__HASHER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_adler32__hasher__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_adler32__status wuffs_adler32__hasher__save_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_adler32__hasher__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_adler32__hasher__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_adler32__status wuffs_adler32__hasher__restore_state(
+    wuffs_adler32__hasher* self, wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
-                                       wuffs_base__slice_u8 a_x);
+    wuffs_base__slice_u8 a_x);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace adler32 {
+
+// status is a strongly typed wuffs_adler32__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_ADLER32__STATUS_OK) {}
+  constexpr explicit status(wuffs_adler32__status repr) : repr_(repr) {}
+
+  constexpr wuffs_adler32__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_ADLER32__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_adler32__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_adler32__status repr_;
+};
+
+constexpr status status_ok(WUFFS_ADLER32__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_ADLER32__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_ADLER32__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_ADLER32__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_ADLER32__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_ADLER32__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_ADLER32__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_ADLER32__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_ADLER32__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_ADLER32__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_ADLER32__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_ADLER32__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_ADLER32__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_ADLER32__SUSPENSION_END_OF_DATA);
+
+// hasher is an RAII wrapper for a wuffs_adler32__hasher. Its constructor
+// calls wuffs_adler32__hasher__check_wuffs_version.
+class hasher {
+ public:
+  hasher() : c_() {
+    wuffs_adler32__hasher__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  hasher(const hasher&) = delete;
+  hasher& operator=(const hasher&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_adler32__hasher* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_adler32__hasher__save_state and
+  // wuffs_adler32__hasher__restore_state. A saved state is
+  // WUFFS_ADLER32__HASHER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_adler32__hasher__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_adler32__hasher__restore_state(&c_, src));
+  }
+
+  uint32_t update(wuffs_base__slice_u8 x) {
+    return wuffs_adler32__hasher__update(&c_, x);
+  }
+
+ private:
+  wuffs_adler32__hasher c_;
+};
+
+}  // namespace adler32
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_ADLER32_H
 
 // ---------------- END   USE "std/adler32"
@@ -1295,6 +1626,7 @@ uint32_t wuffs_adler32__hasher__update(wuffs_adler32__hasher* self,
 
 // Code generated by wuffs-c. DO NOT EDIT.
 
+
 // ---------------- Use Declarations
 
 #ifdef __cplusplus
@@ -1315,54 +1647,40 @@ typedef int32_t wuffs_deflate__status;
 
 #define wuffs_deflate__packageid 848533  // 0x000CF295
 
-#define WUFFS_DEFLATE__STATUS_OK 0                            // 0x00000000
-#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_DEFLATE__STATUS_OK 0  // 0x00000000
+#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                   // 0x80000006
+#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
 #define WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
-#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640      // 0x80000008
-#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639         // 0x80000009
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10                 // 0x0000000A
-#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11                // 0x0000000B
-#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION \
-  -2147483636                                                   // 0x8000000C
+#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
+#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
 #define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
-#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14                // 0x0000000E
-
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED \
-  -1278585856  // 0xB3CA5400
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED \
-  -1278585855  // 0xB3CA5401
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT \
-  -1278585854  // 0xB3CA5402
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION \
-  -1278585853                                              // 0xB3CA5403
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED -1278585856  // 0xB3CA5400
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED -1278585855  // 0xB3CA5401
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT -1278585854  // 0xB3CA5402
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION -1278585853  // 0xB3CA5403
 #define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE -1278585852  // 0xB3CA5404
-#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH \
-  -1278585851                                                     // 0xB3CA5405
-#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850                // 0xB3CA5406
-#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849             // 0xB3CA5407
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH -1278585851  // 0xB3CA5405
+#define WUFFS_DEFLATE__ERROR_BAD_BLOCK -1278585850  // 0xB3CA5406
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585849  // 0xB3CA5407
 #define WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT -1278585848  // 0xB3CA5408
-#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT \
-  -1278585847  // 0xB3CA5409
-#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH \
-  -1278585846  // 0xB3CA540A
-#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE \
-  -1278585845                                              // 0xB3CA540B
+#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT -1278585847  // 0xB3CA5409
+#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH -1278585846  // 0xB3CA540A
+#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE -1278585845  // 0xB3CA540B
 #define WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES -1278585844  // 0xB3CA540C
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE \
-  -1278585843  // 0xB3CA540D
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK \
-  -1278585842  // 0xB3CA540E
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE \
-  -1278585841  // 0xB3CA540F
-#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS \
-  -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE -1278585843  // 0xB3CA540D
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK -1278585842  // 0xB3CA540E
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE -1278585841  // 0xB3CA540F
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS -1278585840  // 0xB3CA5410
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE -1278585839  // 0xB3CA5411
 
 bool wuffs_deflate__status__is_error(wuffs_deflate__status s);
 
@@ -1396,8 +1714,6 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       wuffs_deflate__status v_z;
-      uint64_t v_n_copied;
-      uint32_t v_already_full;
     } c_decode[1];
     struct {
       uint32_t coro_susp_point;
@@ -1448,6 +1764,102 @@ typedef struct {
   } private_impl;
 } wuffs_deflate__decoder;
 
+// WUFFS_DEFLATE__DECODER__STATE_LENGTH is the length of a
+// wuffs_deflate__decoder's saved state.
+#define WUFFS_DEFLATE__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 43138)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_level;
+    bool f_level_set;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_window[65536];
+    uint32_t f_w_pos;
+    uint32_t f_w_end;
+    uint32_t f_block_start;
+    uint16_t f_head[32768];
+    uint16_t f_prev[32768];
+    uint32_t f_ins_pos;
+    uint32_t f_tokens[16384];
+    uint32_t f_n_tokens;
+    uint32_t f_freqs[3][288];
+    uint8_t f_lens[3][288];
+    uint16_t f_codes[3][288];
+    uint32_t f_n_lcodes;
+    uint32_t f_n_dcodes;
+    uint32_t f_n_clcodes;
+    uint16_t f_clseq[320];
+    uint32_t f_n_clseq;
+    uint32_t f_hsort_keys[512];
+    uint16_t f_hsort_syms[512];
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_window[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_stored;
+      bool v_fixed;
+      uint64_t v_n_extra_bits;
+      uint64_t v_fixed_cost;
+      uint64_t v_dynamic_cost;
+      uint64_t v_n_bytes;
+      uint64_t v_stored_cost;
+    } c_write_block[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_start;
+      uint32_t v_end;
+      uint32_t v_remaining;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint32_t v_new_start;
+      uint64_t scratch;
+    } c_write_stored[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_symbol;
+      uint32_t v_n_extra_bits;
+    } c_write_dynamic_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_t;
+      uint32_t v_length;
+      uint32_t v_lc;
+      uint32_t v_d;
+      uint32_t v_dc;
+      uint32_t v_magic;
+    } c_write_tokens[1];
+  } private_impl;
+} wuffs_deflate__encoder;
+
+// WUFFS_DEFLATE__ENCODER__STATE_LENGTH is the length of a
+// wuffs_deflate__encoder's saved state.
+#define WUFFS_DEFLATE__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 272109)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_deflate__decoder__check_wuffs_version is an initializer function.
@@ -1456,20 +1868,255 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_deflate__decoder__check_wuffs_version(wuffs_deflate__decoder* self,
-                                                 size_t sizeof_star_self,
-                                                 uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__decoder returns sizeof(wuffs_deflate__decoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__decoder(void);
+
+// wuffs_deflate__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_deflate__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_deflate__encoder__check_wuffs_version(wuffs_deflate__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_deflate__encoder returns sizeof(wuffs_deflate__encoder), for
+// callers that cannot see the struct definition, such as other programming
+// languages' foreign function interfaces.
+size_t sizeof__wuffs_deflate__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_deflate__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__decoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__decoder__save_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__decoder__restore_state(
+    wuffs_deflate__decoder* self, wuffs_base__slice_u8 a_src);
+
+// wuffs_deflate__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes
+// of dst.
+//
+// The saved state can be restored, by wuffs_deflate__encoder__restore_state,
+// into a different struct, possibly in a different process, as long as it runs
+// code generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_deflate__status wuffs_deflate__encoder__save_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_dst);
+
+// wuffs_deflate__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long. The
+// self argument must have been initialized by
+// wuffs_deflate__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_deflate__status wuffs_deflate__encoder__restore_state(
+    wuffs_deflate__encoder* self, wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 wuffs_deflate__status wuffs_deflate__decoder__decode(
-    wuffs_deflate__decoder* self,
-    wuffs_base__io_writer a_dst,
+    wuffs_deflate__decoder* self, wuffs_base__io_writer a_dst,
+    wuffs_base__io_reader a_src);
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+    wuffs_base__slice_u8 a_hist);
+
+void wuffs_deflate__encoder__set_level(wuffs_deflate__encoder* self,
+    uint32_t a_level);
+
+wuffs_deflate__status wuffs_deflate__encoder__encode(
+    wuffs_deflate__encoder* self, wuffs_base__io_writer a_dst,
     wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace deflate {
+
+// status is a strongly typed wuffs_deflate__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_DEFLATE__STATUS_OK) {}
+  constexpr explicit status(wuffs_deflate__status repr) : repr_(repr) {}
+
+  constexpr wuffs_deflate__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_DEFLATE__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_deflate__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_deflate__status repr_;
+};
+
+constexpr status status_ok(WUFFS_DEFLATE__STATUS_OK);
+constexpr status error_bad_wuffs_version(
+    WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_DEFLATE__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_DEFLATE__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_DEFLATE__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_DEFLATE__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(
+    WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_DEFLATE__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_DEFLATE__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_huffman_code_over_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_under_subscribed(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED);
+constexpr status error_bad_huffman_code_length_count(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT);
+constexpr status error_bad_huffman_code_length_repetition(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION);
+constexpr status error_bad_huffman_code(WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE);
+constexpr status error_bad_huffman_minimum_code_length(
+    WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH);
+constexpr status error_bad_block(WUFFS_DEFLATE__ERROR_BAD_BLOCK);
+constexpr status error_bad_distance(WUFFS_DEFLATE__ERROR_BAD_DISTANCE);
+constexpr status error_bad_distance_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT);
+constexpr status error_bad_literal_length_code_count(
+    WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT);
+constexpr status error_inconsistent_stored_block_length(
+    WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH);
+constexpr status error_missing_end_of_block_code(
+    WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE);
+constexpr status error_no_huffman_codes(WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES);
+constexpr status error_internal_error_inconsistent_huffman_decoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE);
+constexpr status error_internal_error_inconsistent_huffman_end_of_block(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK);
+constexpr status error_internal_error_inconsistent_distance(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE);
+constexpr status error_internal_error_inconsistent_n_bits(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS);
+constexpr status error_internal_error_inconsistent_encoder_state(
+    WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE);
+
+// decoder is an RAII wrapper for a wuffs_deflate__decoder. Its constructor
+// calls wuffs_deflate__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_deflate__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__decoder__save_state and
+  // wuffs_deflate__decoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__decoder__restore_state(&c_, src));
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__decoder__decode(&c_, dst, src));
+  }
+
+  void add_history(wuffs_base__slice_u8 hist) {
+    wuffs_deflate__decoder__add_history(&c_, hist);
+  }
+
+ private:
+  wuffs_deflate__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_deflate__encoder. Its constructor
+// calls wuffs_deflate__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_deflate__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_deflate__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_deflate__encoder__save_state and
+  // wuffs_deflate__encoder__restore_state. A saved state is
+  // WUFFS_DEFLATE__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_deflate__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_deflate__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_deflate__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_deflate__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_deflate__encoder c_;
+};
+
+}  // namespace deflate
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_DEFLATE_H
 
 // ---------------- END   USE "std/deflate"
@@ -1492,30 +2139,28 @@ typedef int32_t wuffs_zlib__status;
 
 #define wuffs_zlib__packageid 2064249  // 0x001F7F79
 
-#define WUFFS_ZLIB__STATUS_OK 0                            // 0x00000000
-#define WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_ZLIB__STATUS_OK 0  // 0x00000000
+#define WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_ZLIB__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_ZLIB__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_ZLIB__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                     // 0x80000006
-#define WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_ZLIB__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_ZLIB__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_ZLIB__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_ZLIB__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_ZLIB__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_ZLIB__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_ZLIB__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_ZLIB__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_ZLIB__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_ZLIB__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_ZLIB__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
-#define WUFFS_ZLIB__ERROR_BAD_CHECKSUM -33692672                 // 0xFDFDE400
-#define WUFFS_ZLIB__ERROR_BAD_COMPRESSION_METHOD -33692671       // 0xFDFDE401
+#define WUFFS_ZLIB__ERROR_BAD_CHECKSUM -33692672  // 0xFDFDE400
+#define WUFFS_ZLIB__ERROR_BAD_COMPRESSION_METHOD -33692671  // 0xFDFDE401
 #define WUFFS_ZLIB__ERROR_BAD_COMPRESSION_WINDOW_SIZE -33692670  // 0xFDFDE402
-#define WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK -33692669             // 0xFDFDE403
-#define WUFFS_ZLIB__ERROR_TODO_UNSUPPORTED_PRESET_DICTIONARY \
-  -33692668  // 0xFDFDE404
+#define WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK -33692669  // 0xFDFDE403
+#define WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY -33692668  // 0xFDFDE404
+#define WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED 2113790981  // 0x7DFDE405
 
 bool wuffs_zlib__status__is_error(wuffs_zlib__status s);
 
@@ -1540,6 +2185,12 @@ typedef struct {
     wuffs_deflate__decoder f_flate;
     wuffs_adler32__hasher f_checksum;
     bool f_ignore_checksum;
+    wuffs_adler32__hasher f_dict_id_hasher;
+    uint32_t f_dict_id_got;
+    uint32_t f_dict_id_want;
+    bool f_waiting_for_dictionary;
+    bool f_got_dictionary;
+    bool f_bad_call_sequence;
 
     struct {
       uint32_t coro_susp_point;
@@ -1552,6 +2203,41 @@ typedef struct {
   } private_impl;
 } wuffs_zlib__decoder;
 
+// WUFFS_ZLIB__DECODER__STATE_LENGTH is the length of a wuffs_zlib__decoder's
+// saved state.
+#define WUFFS_ZLIB__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 42 + WUFFS_DEFLATE__DECODER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_zlib__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_zlib__status status;
+    uint32_t magic;
+
+    wuffs_deflate__encoder f_flate;
+    wuffs_adler32__hasher f_checksum;
+    uint32_t f_level;
+    bool f_level_set;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint16_t v_x;
+      uint32_t v_checksum;
+      wuffs_zlib__status v_z;
+      uint64_t scratch;
+    } c_encode[1];
+  } private_impl;
+} wuffs_zlib__encoder;
+
+// WUFFS_ZLIB__ENCODER__STATE_LENGTH is the length of a wuffs_zlib__encoder's
+// saved state.
+#define WUFFS_ZLIB__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 31 + WUFFS_DEFLATE__ENCODER__STATE_LENGTH + WUFFS_ADLER32__HASHER__STATE_LENGTH)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_zlib__decoder__check_wuffs_version is an initializer function.
@@ -1560,22 +2246,242 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_zlib__decoder__check_wuffs_version(wuffs_zlib__decoder* self,
-                                              size_t sizeof_star_self,
-                                              uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_zlib__decoder returns sizeof(wuffs_zlib__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_zlib__decoder(void);
+
+// wuffs_zlib__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_zlib__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_zlib__encoder__check_wuffs_version(wuffs_zlib__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_zlib__encoder returns sizeof(wuffs_zlib__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_zlib__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_zlib__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ZLIB__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_zlib__decoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_zlib__status wuffs_zlib__decoder__save_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_zlib__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ZLIB__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_zlib__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_zlib__status wuffs_zlib__decoder__restore_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_zlib__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_zlib__encoder__restore_state, into
+// a different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_zlib__status wuffs_zlib__encoder__save_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_zlib__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_zlib__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_zlib__status wuffs_zlib__encoder__restore_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 void wuffs_zlib__decoder__set_ignore_checksum(wuffs_zlib__decoder* self,
-                                              bool a_ic);
+    bool a_ic);
+
+uint32_t wuffs_zlib__decoder__dictionary_id(wuffs_zlib__decoder* self);
+
+void wuffs_zlib__decoder__set_dictionary(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dict);
 
 wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
-                                               wuffs_base__io_writer a_dst,
-                                               wuffs_base__io_reader a_src);
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_zlib__encoder__set_level(wuffs_zlib__encoder* self,
+    uint32_t a_level);
+
+wuffs_zlib__status wuffs_zlib__encoder__encode(wuffs_zlib__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace zlib {
+
+// status is a strongly typed wuffs_zlib__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_ZLIB__STATUS_OK) {}
+  constexpr explicit status(wuffs_zlib__status repr) : repr_(repr) {}
+
+  constexpr wuffs_zlib__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_ZLIB__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_zlib__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_zlib__status repr_;
+};
+
+constexpr status status_ok(WUFFS_ZLIB__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_ZLIB__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_ZLIB__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_ZLIB__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_ZLIB__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_ZLIB__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_ZLIB__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_ZLIB__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_ZLIB__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_checksum(WUFFS_ZLIB__ERROR_BAD_CHECKSUM);
+constexpr status error_bad_compression_method(
+    WUFFS_ZLIB__ERROR_BAD_COMPRESSION_METHOD);
+constexpr status error_bad_compression_window_size(
+    WUFFS_ZLIB__ERROR_BAD_COMPRESSION_WINDOW_SIZE);
+constexpr status error_bad_parity_check(WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK);
+constexpr status error_incorrect_dictionary(
+    WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY);
+constexpr status suspension_dictionary_required(
+    WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED);
+
+// decoder is an RAII wrapper for a wuffs_zlib__decoder. Its constructor
+// calls wuffs_zlib__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_zlib__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_zlib__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_zlib__decoder__save_state and
+  // wuffs_zlib__decoder__restore_state. A saved state is
+  // WUFFS_ZLIB__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_zlib__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_zlib__decoder__restore_state(&c_, src));
+  }
+
+  void set_ignore_checksum(bool ic) {
+    wuffs_zlib__decoder__set_ignore_checksum(&c_, ic);
+  }
+
+  uint32_t dictionary_id() {
+    return wuffs_zlib__decoder__dictionary_id(&c_);
+  }
+
+  void set_dictionary(wuffs_base__slice_u8 dict) {
+    wuffs_zlib__decoder__set_dictionary(&c_, dict);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_zlib__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_zlib__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_zlib__encoder. Its constructor
+// calls wuffs_zlib__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_zlib__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_zlib__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_zlib__encoder__save_state and
+  // wuffs_zlib__encoder__restore_state. A saved state is
+  // WUFFS_ZLIB__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_zlib__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_zlib__encoder__restore_state(&c_, src));
+  }
+
+  void set_level(uint32_t level) {
+    wuffs_zlib__encoder__set_level(&c_, level);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_zlib__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_zlib__encoder c_;
+};
+
+}  // namespace zlib
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_ZLIB_H
 
 // C HEADER ENDS HERE.
@@ -1603,6 +2509,13 @@ static inline wuffs_base__empty_struct wuffs_base__return_empty_struct() {
 
 #define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
 
+// WUFFS_BASE__DEBUG_INDEX(i, n) is i, after asserting that i < n. Code
+// generated by "wuffs-c gen -debug_asserts" uses it to re-check, at run time,
+// the array and slice index bounds that the Wuffs compiler proved. That code
+// also #include's <assert.h>.
+#define WUFFS_BASE__DEBUG_INDEX(i, n) \
+  (assert((uint64_t)(i) < (uint64_t)(n)), (i))
+
 // WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
 // It's not foolproof, given C doesn't automatically zero memory before use,
 // but it should catch 99.99% of cases.
@@ -1752,6 +2665,29 @@ static inline uint64_t wuffs_base__load_u64le(uint8_t* p) {
          ((uint64_t)(p[6]) << 48) | ((uint64_t)(p[7]) << 56);
 }
 
+static inline void wuffs_base__store_u16le(uint8_t* p, uint16_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+}
+
+static inline void wuffs_base__store_u32le(uint8_t* p, uint32_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+}
+
+static inline void wuffs_base__store_u64le(uint8_t* p, uint64_t x) {
+  p[0] = (uint8_t)(x >> 0);
+  p[1] = (uint8_t)(x >> 8);
+  p[2] = (uint8_t)(x >> 16);
+  p[3] = (uint8_t)(x >> 24);
+  p[4] = (uint8_t)(x >> 32);
+  p[5] = (uint8_t)(x >> 40);
+  p[6] = (uint8_t)(x >> 48);
+  p[7] = (uint8_t)(x >> 56);
+}
+
 // --------
 
 static inline void wuffs_base__u8__sat_add_indirect(uint8_t* x, uint8_t y) {
@@ -2094,6 +3030,26 @@ static inline uint32_t wuffs_base__io_writer__copy_from_slice32(
   return n;
 }
 
+static inline wuffs_base__empty_struct wuffs_base__io_reader__set(
+    wuffs_base__io_reader* o,
+    wuffs_base__io_buffer* b,
+    uint8_t** ioptr1_ptr,
+    uint8_t** ioptr2_ptr,
+    wuffs_base__slice_u8 s,
+    bool closed) {
+  b->ptr = s.ptr;
+  b->len = s.len;
+  b->wi = s.len;
+  b->ri = 0;
+  b->closed = closed;
+  o->private_impl.buf = b;
+  o->private_impl.bounds[0] = s.ptr;
+  o->private_impl.bounds[1] = s.ptr + s.len;
+  *ioptr1_ptr = s.ptr;
+  *ioptr2_ptr = s.ptr + s.len;
+  return ((wuffs_base__empty_struct){});
+}
+
 static inline wuffs_base__empty_struct wuffs_base__io_reader__set_limit(
     wuffs_base__io_reader* o,
     uint8_t* ioptr_r,
@@ -2137,22 +3093,63 @@ static inline wuffs_base__empty_struct wuffs_base__io_writer__set_mark(
   return ((wuffs_base__empty_struct){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__FORMAT_VERSION is the first field of a saved
+// state's header. It changes whenever the saved state encoding does.
+#define WUFFS_BASE__SAVED_STATE__FORMAT_VERSION ((uint32_t)0x00000001)
+
+// wuffs_base__saved_state__checksum is the 32-bit FNV-1a hash of p[0:n]. It
+// detects truncated or corrupted saved states. It is not a cryptographic hash.
+static inline uint32_t wuffs_base__saved_state__checksum(uint8_t* p,
+                                                         size_t n) {
+  uint32_t h = 2166136261u;
+  size_t i;
+  for (i = 0; i < n; i++) {
+    h = (h ^ p[i]) * 16777619u;
+  }
+  return h;
+}
+
+// wuffs_base__saved_state__write_header writes the header for a saved state of
+// length n (including the header) to p, after the rest of that saved state
+// has been written.
+static inline void wuffs_base__saved_state__write_header(uint8_t* p,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  wuffs_base__store_u32le(p + 0, WUFFS_BASE__SAVED_STATE__FORMAT_VERSION);
+  wuffs_base__store_u32le(p + 4, fingerprint);
+  wuffs_base__store_u32le(p + 8, n);
+  wuffs_base__store_u32le(
+      p + 12,
+      wuffs_base__saved_state__checksum(
+          p + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+          n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
+// wuffs_base__saved_state__check_header returns whether s holds a saved state
+// of length n (including the header) whose header matches fingerprint and
+// whose checksum is correct.
+static inline bool wuffs_base__saved_state__check_header(wuffs_base__slice_u8 s,
+                                                         uint32_t fingerprint,
+                                                         uint32_t n) {
+  return (s.len == n) && (n >= WUFFS_BASE__SAVED_STATE__HEADER_LENGTH) &&
+         (wuffs_base__load_u32le(s.ptr + 0) ==
+          WUFFS_BASE__SAVED_STATE__FORMAT_VERSION) &&
+         (wuffs_base__load_u32le(s.ptr + 4) == fingerprint) &&
+         (wuffs_base__load_u32le(s.ptr + 8) == n) &&
+         (wuffs_base__load_u32le(s.ptr + 12) ==
+          wuffs_base__saved_state__checksum(
+              s.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+              n - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+}
+
 static const char* wuffs_base__status__strings[15] = {
-    "ok",
-    "bad wuffs version",
-    "bad sizeof receiver",
-    "bad receiver",
-    "bad argument",
-    "check_wuffs_version not called",
-    "check_wuffs_version called twice",
-    "invalid I/O operation",
-    "closed for writes",
-    "unexpected EOF",
-    "short read",
-    "short write",
-    "cannot return a suspension",
-    "invalid call sequence",
-    "end of data",
+    "ok", "bad wuffs version", "bad sizeof receiver", "bad receiver",
+    "bad argument", "check_wuffs_version not called",
+    "check_wuffs_version called twice", "invalid I/O operation",
+    "closed for writes", "unexpected EOF", "short read", "short write",
+    "cannot return a suspension", "invalid call sequence", "end of data",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -2163,12 +3160,10 @@ bool wuffs_zlib__status__is_error(wuffs_zlib__status s) {
   return s < 0;
 }
 
-const char* wuffs_zlib__status__strings[5] = {
-    "zlib: bad checksum",
-    "zlib: bad compression method",
-    "zlib: bad compression window size",
-    "zlib: bad parity check",
-    "zlib: TODO: unsupported preset dictionary",
+const char* wuffs_zlib__status__strings[6] = {
+    "zlib: bad checksum", "zlib: bad compression method",
+    "zlib: bad compression window size", "zlib: bad parity check",
+    "zlib: incorrect dictionary", "zlib: dictionary required",
 };
 
 const char* wuffs_zlib__status__string(wuffs_zlib__status s) {
@@ -2181,7 +3176,7 @@ const char* wuffs_zlib__status__string(wuffs_zlib__status s) {
       break;
     case wuffs_zlib__packageid:
       a = wuffs_zlib__status__strings;
-      n = 5;
+      n = 6;
       break;
     case wuffs_adler32__packageid:
       return wuffs_adler32__status__string(s);
@@ -2196,13 +3191,18 @@ const char* wuffs_zlib__status__string(wuffs_zlib__status s) {
 
 // ---------------- Private Initializer Prototypes
 
+// ---------------- Private Saved State Prototypes
+
 // ---------------- Private Function Prototypes
 
 // ---------------- Initializer Implementations
 
+size_t sizeof__wuffs_zlib__decoder(void) {
+  return sizeof(wuffs_zlib__decoder);
+}
+
 void wuffs_zlib__decoder__check_wuffs_version(wuffs_zlib__decoder* self,
-                                              size_t sizeof_star_self,
-                                              uint32_t wuffs_version) {
+    size_t sizeof_star_self, uint32_t wuffs_version) {
   if (!self) {
     return;
   }
@@ -2220,12 +3220,42 @@ void wuffs_zlib__decoder__check_wuffs_version(wuffs_zlib__decoder* self,
     return;
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
-  wuffs_deflate__decoder__check_wuffs_version(
-      &self->private_impl.f_flate, sizeof(self->private_impl.f_flate),
-      WUFFS_VERSION);
+  wuffs_deflate__decoder__check_wuffs_version(&self->private_impl.f_flate,
+      sizeof(self->private_impl.f_flate), WUFFS_VERSION);
+  wuffs_adler32__hasher__check_wuffs_version(&self->private_impl.f_checksum,
+      sizeof(self->private_impl.f_checksum), WUFFS_VERSION);
   wuffs_adler32__hasher__check_wuffs_version(
-      &self->private_impl.f_checksum, sizeof(self->private_impl.f_checksum),
-      WUFFS_VERSION);
+      &self->private_impl.f_dict_id_hasher,
+      sizeof(self->private_impl.f_dict_id_hasher), WUFFS_VERSION);
+}
+
+size_t sizeof__wuffs_zlib__encoder(void) {
+  return sizeof(wuffs_zlib__encoder);
+}
+
+void wuffs_zlib__encoder__check_wuffs_version(wuffs_zlib__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version) {
+  if (!self) {
+    return;
+  }
+  if (sizeof(*self) != sizeof_star_self) {
+    self->private_impl.status = WUFFS_ZLIB__ERROR_BAD_SIZEOF_RECEIVER;
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (self->private_impl.magic != 0) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE;
+    return;
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  wuffs_deflate__encoder__check_wuffs_version(&self->private_impl.f_flate,
+      sizeof(self->private_impl.f_flate), WUFFS_VERSION);
+  wuffs_adler32__hasher__check_wuffs_version(&self->private_impl.f_checksum,
+      sizeof(self->private_impl.f_checksum), WUFFS_VERSION);
 }
 
 // ---------------- Function Implementations
@@ -2233,7 +3263,7 @@ void wuffs_zlib__decoder__check_wuffs_version(wuffs_zlib__decoder* self,
 // -------- func decoder.set_ignore_checksum
 
 void wuffs_zlib__decoder__set_ignore_checksum(wuffs_zlib__decoder* self,
-                                              bool a_ic) {
+    bool a_ic) {
   if (!self) {
     return;
   }
@@ -2248,11 +3278,54 @@ void wuffs_zlib__decoder__set_ignore_checksum(wuffs_zlib__decoder* self,
   self->private_impl.f_ignore_checksum = a_ic;
 }
 
+// -------- func decoder.dictionary_id
+
+uint32_t wuffs_zlib__decoder__dictionary_id(wuffs_zlib__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return self->private_impl.f_dict_id_want;
+}
+
+// -------- func decoder.set_dictionary
+
+void wuffs_zlib__decoder__set_dictionary(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dict) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  if (!self->private_impl.f_waiting_for_dictionary) {
+    self->private_impl.f_bad_call_sequence = true;
+    return;
+  }
+  self->private_impl.f_waiting_for_dictionary = false;
+  self->private_impl.f_got_dictionary = true;
+  self->private_impl.f_dict_id_got =
+      wuffs_adler32__hasher__update(&self->private_impl.f_dict_id_hasher,
+      a_dict);
+  wuffs_deflate__decoder__add_history(&self->private_impl.f_flate, a_dict);
+}
+
 // -------- func decoder.decode
 
 wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
-                                               wuffs_base__io_writer a_dst,
-                                               wuffs_base__io_reader a_src) {
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
   if (!self) {
     return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
   }
@@ -2315,6 +3388,10 @@ wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
+    if (self->private_impl.f_bad_call_sequence) {
+      status = WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
     {
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
       uint16_t t_1;
@@ -2351,14 +3428,53 @@ wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
       status = WUFFS_ZLIB__ERROR_BAD_COMPRESSION_WINDOW_SIZE;
       goto exit;
     }
-    if ((v_x & 32) != 0) {
-      status = WUFFS_ZLIB__ERROR_TODO_UNSUPPORTED_PRESET_DICTIONARY;
-      goto exit;
-    }
     if ((v_x % 31) != 0) {
       status = WUFFS_ZLIB__ERROR_BAD_PARITY_CHECK;
       goto exit;
     }
+    if ((v_x & 32) != 0) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        uint32_t t_3;
+        if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
+          t_3 = wuffs_base__load_u32be(ioptr_src);
+          ioptr_src += 4;
+        } else {
+          self->private_impl.c_decode[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
+              goto short_read_src;
+            }
+            uint64_t* scratch = &self->private_impl.c_decode[0].scratch;
+            uint32_t t_2 = *scratch & 0xFF;
+            *scratch >>= 8;
+            *scratch <<= 8;
+            *scratch |= ((uint64_t)(*ioptr_src++)) << (56 - t_2);
+            if (t_2 == 24) {
+              t_3 = *scratch >> (64 - 32);
+              break;
+            }
+            t_2 += 8;
+            *scratch |= ((uint64_t)(t_2));
+          }
+        }
+        self->private_impl.f_dict_id_want = t_3;
+      }
+      self->private_impl.f_waiting_for_dictionary = true;
+      while (!self->private_impl.f_got_dictionary) {
+        status = WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(5);
+      }
+      if (self->private_impl.f_bad_call_sequence) {
+        status = WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE;
+        goto exit;
+      } else if (self->private_impl.f_dict_id_got !=
+          self->private_impl.f_dict_id_want) {
+        status = WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY;
+        goto exit;
+      }
+    }
     v_checksum_got = 0;
     while (true) {
       wuffs_base__io_writer__set_mark(&a_dst, ioptr_dst);
@@ -2369,58 +3485,57 @@ wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
         if (a_src.private_impl.buf) {
           a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
         }
-        wuffs_zlib__status t_2 = wuffs_deflate__decoder__decode(
-            &self->private_impl.f_flate, a_dst, a_src);
+        wuffs_zlib__status t_4 =
+            wuffs_deflate__decoder__decode(&self->private_impl.f_flate, a_dst,
+            a_src);
         if (a_dst.private_impl.buf) {
           ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
         }
         if (a_src.private_impl.buf) {
           ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
         }
-        v_z = t_2;
+        v_z = t_4;
       }
       if (!self->private_impl.f_ignore_checksum) {
-        v_checksum_got = wuffs_adler32__hasher__update(
-            &self->private_impl.f_checksum,
-            ((wuffs_base__slice_u8){
-                .ptr = a_dst.private_impl.bounds[0],
-                .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0]),
-            }));
+        v_checksum_got =
+            wuffs_adler32__hasher__update(&self->private_impl.f_checksum,
+            ((wuffs_base__slice_u8){.ptr = a_dst.private_impl.bounds[0],
+            .len = (size_t)(ioptr_dst - a_dst.private_impl.bounds[0])}));
       }
       if (v_z == 0) {
         goto label_0_break;
       }
       status = v_z;
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(6);
     }
   label_0_break:;
     {
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
-      uint32_t t_4;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      uint32_t t_6;
       if (WUFFS_BASE__LIKELY(iobounds1_src - ioptr_src >= 4)) {
-        t_4 = wuffs_base__load_u32be(ioptr_src);
+        t_6 = wuffs_base__load_u32be(ioptr_src);
         ioptr_src += 4;
       } else {
         self->private_impl.c_decode[0].scratch = 0;
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
         while (true) {
           if (WUFFS_BASE__UNLIKELY(ioptr_src == iobounds1_src)) {
             goto short_read_src;
           }
           uint64_t* scratch = &self->private_impl.c_decode[0].scratch;
-          uint32_t t_3 = *scratch & 0xFF;
+          uint32_t t_5 = *scratch & 0xFF;
           *scratch >>= 8;
           *scratch <<= 8;
-          *scratch |= ((uint64_t)(*ioptr_src++)) << (56 - t_3);
-          if (t_3 == 24) {
-            t_4 = *scratch >> (64 - 32);
+          *scratch |= ((uint64_t)(*ioptr_src++)) << (56 - t_5);
+          if (t_5 == 24) {
+            t_6 = *scratch >> (64 - 32);
             break;
           }
-          t_3 += 8;
-          *scratch |= ((uint64_t)(t_3));
+          t_5 += 8;
+          *scratch |= ((uint64_t)(t_5));
         }
       }
-      v_checksum_want = t_4;
+      v_checksum_want = t_6;
     }
     if (!self->private_impl.f_ignore_checksum &&
         (v_checksum_got != v_checksum_want)) {
@@ -2462,3 +3577,501 @@ short_read_src:
   status = WUFFS_ZLIB__SUSPENSION_SHORT_READ;
   goto suspend;
 }
+
+// -------- func encoder.set_level
+
+void wuffs_zlib__encoder__set_level(wuffs_zlib__encoder* self,
+    uint32_t a_level) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  wuffs_deflate__encoder__set_level(&self->private_impl.f_flate, a_level);
+  self->private_impl.f_level = wuffs_base__u32__min(a_level, 9);
+  self->private_impl.f_level_set = true;
+}
+
+// -------- func encoder.encode
+
+wuffs_zlib__status wuffs_zlib__encoder__encode(wuffs_zlib__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src) {
+  if (!self) {
+    return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_zlib__status status = WUFFS_ZLIB__STATUS_OK;
+
+  uint16_t v_x;
+  uint32_t v_checksum;
+  wuffs_zlib__status v_z;
+
+  uint8_t* ioptr_dst = NULL;
+  uint8_t* iobounds0orig_dst = NULL;
+  uint8_t* iobounds1_dst = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_dst);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_dst);
+  if (a_dst.private_impl.buf) {
+    ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+    if (!a_dst.private_impl.bounds[0]) {
+      a_dst.private_impl.bounds[0] = ioptr_dst;
+      a_dst.private_impl.bounds[1] =
+          a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->len;
+    }
+    if (a_dst.private_impl.buf->closed) {
+      a_dst.private_impl.bounds[1] = ioptr_dst;
+    }
+    iobounds0orig_dst = a_dst.private_impl.bounds[0];
+    iobounds1_dst = a_dst.private_impl.bounds[1];
+  }
+  uint8_t* ioptr_src = NULL;
+  uint8_t* iobounds0orig_src = NULL;
+  uint8_t* iobounds1_src = NULL;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds0orig_src);
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(iobounds1_src);
+  if (a_src.private_impl.buf) {
+    ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+    if (!a_src.private_impl.bounds[0]) {
+      a_src.private_impl.bounds[0] = ioptr_src;
+      a_src.private_impl.bounds[1] =
+          a_src.private_impl.buf->ptr + a_src.private_impl.buf->wi;
+    }
+    iobounds0orig_src = a_src.private_impl.bounds[0];
+    iobounds1_src = a_src.private_impl.bounds[1];
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_encode[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_x = self->private_impl.c_encode[0].v_x;
+    v_checksum = self->private_impl.c_encode[0].v_checksum;
+    v_z = self->private_impl.c_encode[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (!self->private_impl.f_level_set) {
+      self->private_impl.f_level_set = true;
+      self->private_impl.f_level = 6;
+    }
+    v_x = 30876;
+    if (self->private_impl.f_level < 2) {
+      v_x = 30721;
+    } else if (self->private_impl.f_level < 6) {
+      v_x = 30814;
+    } else if (self->private_impl.f_level > 6) {
+      v_x = 30938;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    while (self->private_impl.c_encode[0].scratch < 2) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_ZLIB__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(v_x)) >>
+          (8 - (8 * self->private_impl.c_encode[0].scratch)));
+      self->private_impl.c_encode[0].scratch++;
+    }
+    v_checksum = 0;
+    while (true) {
+      wuffs_base__io_reader__set_mark(&a_src, ioptr_src);
+      {
+        if (a_dst.private_impl.buf) {
+          a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+        }
+        if (a_src.private_impl.buf) {
+          a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+        }
+        wuffs_zlib__status t_0 =
+            wuffs_deflate__encoder__encode(&self->private_impl.f_flate, a_dst,
+            a_src);
+        if (a_dst.private_impl.buf) {
+          ioptr_dst = a_dst.private_impl.buf->ptr + a_dst.private_impl.buf->wi;
+        }
+        if (a_src.private_impl.buf) {
+          ioptr_src = a_src.private_impl.buf->ptr + a_src.private_impl.buf->ri;
+        }
+        v_z = t_0;
+      }
+      v_checksum = wuffs_adler32__hasher__update(&self->private_impl.f_checksum,
+          ((wuffs_base__slice_u8){.ptr = a_src.private_impl.bounds[0],
+          .len = (size_t)(ioptr_src - a_src.private_impl.bounds[0])}));
+      if (v_z == 0) {
+        goto label_0_break;
+      }
+      status = v_z;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    self->private_impl.c_encode[0].scratch = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    while (self->private_impl.c_encode[0].scratch < 4) {
+      if (ioptr_dst == iobounds1_dst) {
+        status = WUFFS_ZLIB__SUSPENSION_SHORT_WRITE;
+        goto suspend;
+      }
+      *ioptr_dst++ = (uint8_t)(((uint64_t)(v_checksum)) >>
+          (24 - (8 * self->private_impl.c_encode[0].scratch)));
+      self->private_impl.c_encode[0].scratch++;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_encode[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_encode[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_encode[0].v_x = v_x;
+  self->private_impl.c_encode[0].v_checksum = v_checksum;
+  self->private_impl.c_encode[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_dst.private_impl.buf) {
+    a_dst.private_impl.buf->wi = ioptr_dst - a_dst.private_impl.buf->ptr;
+  }
+  if (a_src.private_impl.buf) {
+    a_src.private_impl.buf->ri = ioptr_src - a_src.private_impl.buf->ptr;
+  }
+
+  self->private_impl.status = status;
+  return status;
+}
+
+// ---------------- Saved State Implementations
+
+// -------- func decoder.save_state
+
+wuffs_zlib__status wuffs_zlib__decoder__save_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_ZLIB__DECODER__STATE_LENGTH) {
+    return WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  {
+    int32_t z = wuffs_deflate__decoder__save_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__DECODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_DEFLATE__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_adler32__hasher__save_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  p[0] = self->private_impl.f_ignore_checksum ? 1 : 0;
+  p += 1;
+  {
+    int32_t z =
+        wuffs_adler32__hasher__save_state(&self->private_impl.f_dict_id_hasher,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dict_id_got));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_dict_id_want));
+  p += 4;
+  p[0] = self->private_impl.f_waiting_for_dictionary ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_got_dictionary ? 1 : 0;
+  p += 1;
+  p[0] = self->private_impl.f_bad_call_sequence ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.c_decode[0].v_x));
+  p += 2;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_checksum_got));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_decode[0].v_z));
+  p += 4;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_decode[0].v_checksum_want));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_decode[0].scratch));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xEFF7E86A,
+      WUFFS_ZLIB__DECODER__STATE_LENGTH);
+  return WUFFS_ZLIB__STATUS_OK;
+}
+
+// -------- func decoder.restore_state
+
+wuffs_zlib__status wuffs_zlib__decoder__restore_state(wuffs_zlib__decoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xEFF7E86A,
+      WUFFS_ZLIB__DECODER__STATE_LENGTH)) {
+    return WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  self->private_impl.status = (wuffs_zlib__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  {
+    int32_t z =
+        wuffs_deflate__decoder__restore_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__DECODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_DEFLATE__DECODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_adler32__hasher__restore_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_ignore_checksum = p[0];
+  p += 1;
+  {
+    int32_t z = wuffs_adler32__hasher__restore_state(
+        &self->private_impl.f_dict_id_hasher, ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  self->private_impl.f_dict_id_got = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.f_dict_id_want = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_waiting_for_dictionary = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_got_dictionary = p[0];
+  p += 1;
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_bad_call_sequence = p[0];
+  p += 1;
+  self->private_impl.c_decode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_decode[0].coro_susp_point > 8) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_decode[0].v_x = (uint16_t)(wuffs_base__load_u16le(p));
+  p += 2;
+  self->private_impl.c_decode[0].v_checksum_got =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_z =
+      (wuffs_zlib__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].v_checksum_want =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_decode[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_ZLIB__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
+
+// -------- func encoder.save_state
+
+wuffs_zlib__status wuffs_zlib__encoder__save_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_dst) {
+  if (!self) {
+    return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    return WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+  }
+  if (a_dst.len < WUFFS_ZLIB__ENCODER__STATE_LENGTH) {
+    return WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_dst.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.status));
+  p += 4;
+  {
+    int32_t z = wuffs_deflate__encoder__save_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__ENCODER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_DEFLATE__ENCODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_adler32__hasher__save_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.f_level));
+  p += 4;
+  p[0] = self->private_impl.f_level_set ? 1 : 0;
+  p += 1;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].coro_susp_point));
+  p += 4;
+  wuffs_base__store_u16le(p, (uint16_t)(self->private_impl.c_encode[0].v_x));
+  p += 2;
+  wuffs_base__store_u32le(p,
+      (uint32_t)(self->private_impl.c_encode[0].v_checksum));
+  p += 4;
+  wuffs_base__store_u32le(p, (uint32_t)(self->private_impl.c_encode[0].v_z));
+  p += 4;
+  wuffs_base__store_u64le(p,
+      (uint64_t)(self->private_impl.c_encode[0].scratch));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  wuffs_base__saved_state__write_header(a_dst.ptr, 0xCBF443B1,
+      WUFFS_ZLIB__ENCODER__STATE_LENGTH);
+  return WUFFS_ZLIB__STATUS_OK;
+}
+
+// -------- func encoder.restore_state
+
+wuffs_zlib__status wuffs_zlib__encoder__restore_state(wuffs_zlib__encoder* self,
+    wuffs_base__slice_u8 a_src) {
+  if (!self) {
+    return WUFFS_ZLIB__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status =
+        WUFFS_ZLIB__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED;
+    return self->private_impl.status;
+  }
+  if (!wuffs_base__saved_state__check_header(a_src, 0xCBF443B1,
+      WUFFS_ZLIB__ENCODER__STATE_LENGTH)) {
+    return WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  }
+  uint8_t* p = a_src.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH;
+
+  self->private_impl.status = (wuffs_zlib__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  {
+    int32_t z =
+        wuffs_deflate__encoder__restore_state(&self->private_impl.f_flate,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_DEFLATE__ENCODER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_DEFLATE__ENCODER__STATE_LENGTH;
+  }
+  {
+    int32_t z =
+        wuffs_adler32__hasher__restore_state(&self->private_impl.f_checksum,
+        ((wuffs_base__slice_u8){.ptr = p,
+        .len = WUFFS_ADLER32__HASHER__STATE_LENGTH}));
+    if (z) {
+      self->private_impl.status = z;
+      return z;
+    }
+    p += WUFFS_ADLER32__HASHER__STATE_LENGTH;
+  }
+  self->private_impl.f_level = (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  if (self->private_impl.f_level > 9) {
+    goto bad_state;
+  }
+  if (p[0] > 1) {
+    goto bad_state;
+  }
+  self->private_impl.f_level_set = p[0];
+  p += 1;
+  self->private_impl.c_encode[0].coro_susp_point = wuffs_base__load_u32le(p);
+  if (self->private_impl.c_encode[0].coro_susp_point > 5) {
+    goto bad_state;
+  }
+  p += 4;
+  self->private_impl.c_encode[0].v_x = (uint16_t)(wuffs_base__load_u16le(p));
+  p += 2;
+  self->private_impl.c_encode[0].v_checksum =
+      (uint32_t)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].v_z =
+      (wuffs_zlib__status)(wuffs_base__load_u32le(p));
+  p += 4;
+  self->private_impl.c_encode[0].scratch =
+      (uint64_t)(wuffs_base__load_u64le(p));
+  p += 8;
+  WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(p);
+  return WUFFS_ZLIB__STATUS_OK;
+
+bad_state:
+  self->private_impl.status = WUFFS_ZLIB__ERROR_BAD_ARGUMENT;
+  return self->private_impl.status;
+}
@@ -0,0 +1,103 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package adler32
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Hasher holds the state of a adler32.hasher. Its zero value is ready to use.
+type Hasher struct {
+	status error
+
+	f_state   uint32
+	f_started bool
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func hasher.update
+
+func (self *Hasher) Update(a_x []byte) uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	var (
+		v_s1        uint32
+		v_s2        uint32
+		v_remaining []byte
+	)
+
+	if !self.f_started {
+		self.f_started = true
+		self.f_state = 1
+	}
+	v_s1 = ((self.f_state) & ((uint32(1) << (16)) - 1))
+	v_s2 = ((self.f_state) >> (32 - (16)))
+	for uint64(len(a_x)) > 0 {
+		v_remaining = nil
+		if uint64(len(a_x)) > 5552 {
+			v_remaining = a_x[5552:]
+			a_x = a_x[:5552]
+		}
+		{
+			i_slice_p := a_x
+			var v_p []byte
+			i_p := 0
+			i_end0_p := (len(i_slice_p) / 8) * 8
+			for i_p < i_end0_p {
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+			}
+			i_end1_p := (len(i_slice_p) / 1) * 1
+			for i_p < i_end1_p {
+				v_p = i_slice_p[i_p : i_p+1]
+				v_s1 += uint32(v_p[0])
+				v_s2 += v_s1
+				i_p += 1
+			}
+		}
+		v_s1 %= 65521
+		v_s2 %= 65521
+		a_x = v_remaining
+	}
+	self.f_state = (((v_s2 & 65535) << 16) | (v_s1 & 65535))
+	return self.f_state
+}
@@ -0,0 +1,1275 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package bmp
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadHeader                 = base.NewError("bmp: bad header")
+	ErrBadPalette                = base.NewError("bmp: bad palette")
+	ErrUnsupportedBitDepth       = base.NewError("bmp: unsupported bit depth")
+	ErrUnsupportedCompression    = base.NewError("bmp: unsupported compression")
+	ErrUnsupportedHeaderSize     = base.NewError("bmp: unsupported header size")
+	ErrTODOUnsupportedImageWidth = base.NewError("bmp: TODO: unsupported image width")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a bmp.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width             uint32
+	f_height            uint32
+	f_call_sequence     uint8
+	f_ico_mode          bool
+	f_top_down          bool
+	f_bits_per_pixel    uint32
+	f_compression       uint32
+	f_pixel_data_offset uint32
+	f_channel_masks     [4]uint32
+	f_channel_shifts    [4]uint32
+	f_channel_num_bits  [4]uint32
+	f_row_length        uint32
+	f_row_wi            uint32
+	f_dst_y             uint32
+	f_row               [32772]uint8
+	f_palette           [1024]uint8
+
+	c_decode_config struct {
+		coroSuspPoint    uint32
+		v_num_read       uint64
+		v_header_size    uint32
+		v_width          uint32
+		v_height         uint32
+		v_bits_per_pixel uint32
+		v_compression    uint32
+		v_num_colors     uint32
+		v_num_masks      uint32
+		v_i              uint32
+		v_num_entries    uint32
+		v_row_length     uint64
+		scratch          uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+		v_dirty_rect  base.RectIEU32
+	}
+
+	c_read_row struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_decode_rle struct {
+		coroSuspPoint uint32
+		v_rle4        bool
+		v_x           uint32
+		v_a           uint32
+		v_b           uint8
+		v_c           uint8
+		v_n           uint32
+		v_num_bytes   uint32
+		scratch       uint64
+	}
+
+	c_decode_and_mask struct {
+		coroSuspPoint uint32
+		v_row_length  uint64
+	}
+}
+
+// ---------------- Private Consts
+
+const compression_rgb uint32 = 0x0
+
+const compression_rle8 uint32 = 0x1
+
+const compression_rle4 uint32 = 0x2
+
+const compression_bitfields uint32 = 0x3
+
+const compression_alpha_bitfields uint32 = 0x6
+
+var rgba_to_bgra = [4]uint8{
+	0x2,
+	0x1,
+	0x0,
+	0x3,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ico_mode
+
+func (self *Decoder) SetIcoMode(a_m bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_ico_mode = a_m
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_num_read       uint64
+		v_header_size    uint32
+		v_width          uint32
+		v_height         uint32
+		v_bits_per_pixel uint32
+		v_compression    uint32
+		v_num_colors     uint32
+		v_num_masks      uint32
+		v_i              uint32
+		v_num_entries    uint32
+		v_row_length     uint64
+		t_0              uint16
+		t_1              uint32
+		t_2              uint32
+		t_3              uint32
+		t_4              uint32
+		t_5              uint16
+		t_6              uint16
+		t_7              uint32
+		t_8              uint32
+		t_9              uint32
+		t_10             uint8
+		t_11             uint8
+		t_12             uint8
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_read = self.c_decode_config.v_num_read
+		v_header_size = self.c_decode_config.v_header_size
+		v_width = self.c_decode_config.v_width
+		v_height = self.c_decode_config.v_height
+		v_bits_per_pixel = self.c_decode_config.v_bits_per_pixel
+		v_compression = self.c_decode_config.v_compression
+		v_num_colors = self.c_decode_config.v_num_colors
+		v_num_masks = self.c_decode_config.v_num_masks
+		v_i = self.c_decode_config.v_i
+		v_num_entries = self.c_decode_config.v_num_entries
+		v_row_length = self.c_decode_config.v_row_length
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+		v_num_read = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+		if (1 <= r && r <= 3) || (r == 0 && !self.f_ico_mode) {
+			if r == 0 || r == 1 {
+				if r == 0 || r == 1 {
+					if r == 0 {
+						self.c_decode_config.scratch = 0
+					}
+					if r == 1 {
+						r = 0
+					}
+					csp = 1
+					if t_0, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+						goto suspend
+					}
+				}
+				if r == 0 && (t_0 != 19778) {
+					status = ErrBadHeader
+					goto exit
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(8)
+				}
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 3 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if t_1, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				self.f_pixel_data_offset = t_1
+			}
+			if r == 0 {
+				v_num_read = 14
+			}
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_2, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_header_size = t_2
+	}
+	if r == 0 {
+		if (v_header_size != 40) && (v_header_size != 52) && (v_header_size != 56) && (v_header_size != 108) && (v_header_size != 124) {
+			status = ErrUnsupportedHeaderSize
+			goto exit
+		}
+		v_num_read = base.U64SatAdd(v_num_read, uint64(v_header_size))
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_3, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_width = t_3
+	}
+	if r == 0 {
+		if v_width > 2147483647 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_width = v_width
+	}
+	if r == 0 || r == 6 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if t_4, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_height = t_4
+	}
+	if r == 0 {
+		if v_height == 2147483648 {
+			status = ErrBadHeader
+			goto exit
+		} else if v_height > 2147483648 {
+			self.f_top_down = true
+			v_height = ((4294967295 - v_height) + 1)
+		}
+		if self.f_ico_mode {
+			v_height /= 2
+		}
+		if v_height > 2147483647 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_height = v_height
+	}
+	if r == 0 || r == 7 {
+		if r == 0 || r == 7 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 7 {
+				r = 0
+			}
+			csp = 7
+			if t_5, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_5 != 1) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 8 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 8 {
+			r = 0
+		}
+		csp = 8
+		if t_6, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_bits_per_pixel = uint32(t_6)
+	}
+	if r == 0 || r == 9 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 9 {
+			r = 0
+		}
+		csp = 9
+		if t_7, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_compression = t_7
+	}
+	if r == 0 {
+		self.f_compression = v_compression
+	}
+	if r == 0 || r == 10 {
+		if r == 0 {
+			self.c_decode_config.scratch = uint64(12)
+		}
+		if r == 10 {
+			r = 0
+		}
+		csp = 10
+		if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 11 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 11 {
+			r = 0
+		}
+		csp = 11
+		if t_8, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_num_colors = t_8
+	}
+	if r == 0 || r == 12 {
+		if r == 0 {
+			self.c_decode_config.scratch = uint64(4)
+		}
+		if r == 12 {
+			r = 0
+		}
+		csp = 12
+		if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_num_masks = 0
+		if v_header_size >= 56 {
+			v_num_masks = 4
+		} else if v_header_size >= 52 {
+			v_num_masks = 3
+		} else if v_compression == compression_bitfields {
+			v_num_masks = 3
+			v_num_read = base.U64SatAdd(v_num_read, 12)
+		} else if v_compression == compression_alpha_bitfields {
+			v_num_masks = 4
+			v_num_read = base.U64SatAdd(v_num_read, 16)
+		}
+		v_i = 0
+	}
+	if r == 0 || r == 13 {
+		for r != 0 || (v_i < v_num_masks) {
+			if r == 0 || r == 13 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 13 {
+					r = 0
+				}
+				csp = 13
+				if t_9, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				self.f_channel_masks[rgba_to_bgra[v_i]] = t_9
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 || r == 14 {
+		if r == 14 || (r == 0 && (v_header_size > 40)) {
+			if r == 0 || r == 14 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(base.U32SatSub((v_header_size - 40), (4 * v_num_masks)))
+				}
+				if r == 14 {
+					r = 0
+				}
+				csp = 14
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+		} else if r == 0 && (v_num_masks < 4) {
+			self.f_channel_masks[3] = 0
+		}
+	}
+	if r == 0 {
+		if v_compression == compression_rgb {
+			if v_bits_per_pixel == 16 {
+				self.f_channel_masks[0] = 31
+				self.f_channel_masks[1] = 992
+				self.f_channel_masks[2] = 31744
+				self.f_channel_masks[3] = 0
+			} else if v_bits_per_pixel == 32 {
+				self.f_channel_masks[0] = 255
+				self.f_channel_masks[1] = 65280
+				self.f_channel_masks[2] = 16711680
+				if self.f_ico_mode {
+					self.f_channel_masks[3] = 4278190080
+				} else {
+					self.f_channel_masks[3] = 0
+				}
+			} else if (v_bits_per_pixel != 1) && (v_bits_per_pixel != 4) && (v_bits_per_pixel != 8) && (v_bits_per_pixel != 24) {
+				status = ErrUnsupportedBitDepth
+				goto exit
+			}
+		} else if v_compression == compression_rle8 {
+			if v_bits_per_pixel != 8 {
+				status = ErrUnsupportedBitDepth
+				goto exit
+			}
+		} else if v_compression == compression_rle4 {
+			if v_bits_per_pixel != 4 {
+				status = ErrUnsupportedBitDepth
+				goto exit
+			}
+		} else if (v_compression == compression_bitfields) || (v_compression == compression_alpha_bitfields) {
+			if (v_bits_per_pixel != 16) && (v_bits_per_pixel != 32) {
+				status = ErrUnsupportedBitDepth
+				goto exit
+			}
+		} else {
+			status = ErrUnsupportedCompression
+			goto exit
+		}
+		self.f_bits_per_pixel = base.U32Min(v_bits_per_pixel, 32)
+		self.setChannelShifts(0)
+		self.setChannelShifts(1)
+		self.setChannelShifts(2)
+		self.setChannelShifts(3)
+	}
+	if r == 0 || (15 <= r && r <= 19) {
+		if (15 <= r && r <= 18) || (r == 0 && (v_bits_per_pixel <= 8)) {
+			if r == 0 {
+				if v_num_colors == 0 {
+					v_num_colors = (uint32(1) << base.U32Min(self.f_bits_per_pixel, 8))
+				} else if v_num_colors > 256 {
+					status = ErrBadPalette
+					goto exit
+				}
+				v_num_entries = base.U32Min(v_num_colors, 256)
+				v_i = 0
+			}
+			if r == 0 || (15 <= r && r <= 18) {
+				for r != 0 || (v_i < v_num_entries) {
+					if r == 0 || r == 15 {
+						if r == 15 {
+							r = 0
+						}
+						csp = 15
+						if t_10, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_palette[((4 * v_i) + 0)] = t_10
+					}
+					if r == 0 || r == 16 {
+						if r == 16 {
+							r = 0
+						}
+						csp = 16
+						if t_11, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_palette[((4 * v_i) + 1)] = t_11
+					}
+					if r == 0 || r == 17 {
+						if r == 17 {
+							r = 0
+						}
+						csp = 17
+						if t_12, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_palette[((4 * v_i) + 2)] = t_12
+					}
+					if r == 0 {
+						self.f_palette[((4 * v_i) + 3)] = 255
+					}
+					if r == 0 || r == 18 {
+						if r == 0 {
+							self.c_decode_config.scratch = uint64(1)
+						}
+						if r == 18 {
+							r = 0
+						}
+						csp = 18
+						if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_i += 1
+					}
+				}
+			}
+			if r == 0 {
+				for v_i < 256 {
+					self.f_palette[((4 * v_i) + 0)] = 0
+					self.f_palette[((4 * v_i) + 1)] = 0
+					self.f_palette[((4 * v_i) + 2)] = 0
+					self.f_palette[((4 * v_i) + 3)] = 255
+					v_i += 1
+				}
+			}
+		} else if r == 19 || (r == 0 && (v_num_colors > 0)) {
+			if r == 0 {
+				if (v_num_colors > 256) || self.f_ico_mode {
+					status = ErrBadPalette
+					goto exit
+				}
+			}
+			if r == 0 || r == 19 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64((4 * v_num_colors))
+				}
+				if r == 19 {
+					r = 0
+				}
+				csp = 19
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_num_read = base.U64SatAdd(v_num_read, (4 * uint64(v_num_colors)))
+	}
+	if r == 0 || r == 20 {
+		if r == 20 || (r == 0 && !self.f_ico_mode) {
+			if r == 0 {
+				if uint64(self.f_pixel_data_offset) < v_num_read {
+					status = ErrBadHeader
+					goto exit
+				}
+			}
+			if r == 0 || r == 20 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64((uint64(self.f_pixel_data_offset) - v_num_read))
+				}
+				if r == 20 {
+					r = 0
+				}
+				csp = 20
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_row_length = ((((uint64(v_width) * uint64(self.f_bits_per_pixel)) + 31) >> 5) * 4)
+		if (v_compression != compression_rle8) && (v_compression != compression_rle4) && (v_row_length > 32768) {
+			status = ErrTODOUnsupportedImageWidth
+			goto exit
+		}
+		self.f_row_length = uint32(base.U64Min(v_row_length, 32768))
+		a_dst.Initialize(570460296, 0, self.f_width, self.f_height, 1)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_num_read = v_num_read
+	self.c_decode_config.v_header_size = v_header_size
+	self.c_decode_config.v_width = v_width
+	self.c_decode_config.v_height = v_height
+	self.c_decode_config.v_bits_per_pixel = v_bits_per_pixel
+	self.c_decode_config.v_compression = v_compression
+	self.c_decode_config.v_num_colors = v_num_colors
+	self.c_decode_config.v_num_masks = v_num_masks
+	self.c_decode_config.v_i = v_i
+	self.c_decode_config.v_num_entries = v_num_entries
+	self.c_decode_config.v_row_length = v_row_length
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_palette    []byte
+		v_dirty_rect base.RectIEU32
+	)
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_dirty_rect = self.c_decode_frame.v_dirty_rect
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 && (self.f_call_sequence == 0) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		} else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+			if r == 0 || r == 1 {
+				for {
+					if r == 0 || r == 1 {
+						status = base.SuspensionEndOfData
+						if r == 1 {
+							r = 0
+							status = nil
+						} else {
+							csp = 1
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		if r == 2 || (r == 0 && ((self.f_compression == compression_rle8) || (self.f_compression == compression_rle4))) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.decodeRle(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 3 {
+				for r != 0 || (self.f_dst_y < self.f_height) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.readRow(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						self.swizzleRow(a_dst)
+						self.f_dst_y = base.U32SatAdd(self.f_dst_y, 1)
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 || (r == 0 && self.f_ico_mode) {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.decodeAndMask(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_palette = nil
+		v_dirty_rect = base.RectIEU32{}
+		v_dirty_rect.SetMaxExclusiveX(self.f_width)
+		v_dirty_rect.SetMaxExclusiveY(self.f_height)
+		a_dst.Update(v_dirty_rect, 0, false, 0, v_palette)
+		self.f_call_sequence = 2
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+	self.c_decode_frame.v_dirty_rect = v_dirty_rect
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.set_channel_shifts
+
+func (self *Decoder) setChannelShifts(a_c uint32) {
+
+	var (
+		v_mask     uint32
+		v_shift    uint32
+		v_num_bits uint32
+	)
+
+	v_mask = self.f_channel_masks[a_c]
+	v_shift = 0
+	v_num_bits = 0
+	if v_mask != 0 {
+		for ((v_mask & 1) == 0) && (v_shift < 31) {
+			v_mask >>= 1
+			v_shift += 1
+		}
+		for (v_mask != 0) && (v_num_bits < 32) {
+			v_mask >>= 1
+			v_num_bits += 1
+		}
+	}
+	self.f_channel_shifts[a_c] = v_shift
+	self.f_channel_num_bits[a_c] = v_num_bits
+}
+
+// -------- func decoder.read_row
+
+func (self *Decoder) readRow(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		v_w base.IOWriter
+		u_w base.IOBuffer
+		t_0 uint8
+	)
+
+	r := self.c_read_row.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_read_row.v_c
+	}
+
+	if r == 0 || r == 1 {
+	label_0:
+		for r != 0 || (self.f_row_wi < self.f_row_length) {
+			if r == 0 || r == 1 {
+				if r == 1 || (r == 0 && (a_src.Available() == 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_0
+					}
+					if r == 0 {
+						self.f_row[self.f_row_wi] = v_c
+						self.f_row_wi += 1
+						continue label_0
+					}
+				}
+			}
+			if r == 0 {
+				v_w = base.IOWriter{}
+				{
+					o_0_a_src := a_src
+					o_0_v_w := v_w
+					v_w.Set(&u_w, self.f_row[self.f_row_wi:self.f_row_length])
+					v_w.CopyFromReader32(&a_src, 32768)
+					self.f_row_wi = base.U32SatSub(self.f_row_length, uint32(base.U64Min(v_w.Available(), 32768)))
+					v_w = o_0_v_w
+					a_src = o_0_a_src
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_row_wi = 0
+	}
+
+	self.c_read_row.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_row.coroSuspPoint = csp
+	self.c_read_row.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.swizzle_row
+
+func (self *Decoder) swizzleRow(a_dst *base.ImageBuffer) {
+
+	var (
+		v_tab            base.TableU8
+		v_dst            []byte
+		v_n              uint32
+		v_bits_per_pixel uint32
+		v_mask           uint32
+		v_shift          uint32
+		v_o              uint32
+		v_x              uint32
+		v_p              uint32
+		v_v              uint32
+		v_pixel          [4]uint8
+		v_x4             uint64
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_dst = v_tab.Row(self.fileRowToY(self.f_dst_y))
+	v_n = self.f_row_length
+	v_bits_per_pixel = self.f_bits_per_pixel
+	v_mask = ((uint32(1) << base.U32Min(v_bits_per_pixel, 8)) - 1)
+	v_shift = (8 - base.U32Min(v_bits_per_pixel, 8))
+	v_o = 0
+	v_x = 0
+	v_p = 0
+	v_v = 0
+	v_pixel = [4]uint8{}
+	v_x4 = 0
+	for (v_x < self.f_width) && (v_o < v_n) {
+		if v_bits_per_pixel <= 8 {
+			v_p = ((uint32(self.f_row[v_o]) >> v_shift) & v_mask)
+			v_pixel[0] = self.f_palette[((4 * v_p) + 0)]
+			v_pixel[1] = self.f_palette[((4 * v_p) + 1)]
+			v_pixel[2] = self.f_palette[((4 * v_p) + 2)]
+			v_pixel[3] = self.f_palette[((4 * v_p) + 3)]
+			if v_shift >= v_bits_per_pixel {
+				v_shift -= v_bits_per_pixel
+			} else {
+				v_shift = (8 - base.U32Min(v_bits_per_pixel, 8))
+				v_o += 1
+			}
+		} else if v_bits_per_pixel == 24 {
+			v_pixel[0] = self.f_row[(v_o + 0)]
+			v_pixel[1] = self.f_row[(v_o + 1)]
+			v_pixel[2] = self.f_row[(v_o + 2)]
+			v_pixel[3] = 255
+			v_o += 3
+		} else {
+			if v_bits_per_pixel == 16 {
+				v_v = ((uint32(self.f_row[(v_o+0)]) << 0) | (uint32(self.f_row[(v_o+1)]) << 8))
+				v_o += 2
+			} else {
+				v_v = ((uint32(self.f_row[(v_o+0)]) << 0) | (uint32(self.f_row[(v_o+1)]) << 8) | (uint32(self.f_row[(v_o+2)]) << 16) | (uint32(self.f_row[(v_o+3)]) << 24))
+				v_o += 4
+			}
+			v_pixel[0] = self.extractChannel(v_v, 0)
+			v_pixel[1] = self.extractChannel(v_v, 1)
+			v_pixel[2] = self.extractChannel(v_v, 2)
+			v_pixel[3] = self.extractChannel(v_v, 3)
+		}
+		v_x4 = (uint64(v_x) * 4)
+		if v_x4 < uint64(len(v_dst)) {
+			copy(v_dst[v_x4:], v_pixel[:])
+		}
+		v_x += 1
+	}
+}
+
+// -------- func decoder.extract_channel
+
+func (self *Decoder) extractChannel(a_v uint32, a_c uint32) uint8 {
+
+	var (
+		v_num_bits uint32
+		v_x        uint32
+		v_nb       uint32
+		v_y        uint32
+		v_n        uint32
+	)
+
+	v_num_bits = self.f_channel_num_bits[a_c]
+	if v_num_bits == 0 {
+		if a_c == 3 {
+			return 255
+		}
+		return 0
+	}
+	v_x = ((a_v & self.f_channel_masks[a_c]) >> self.f_channel_shifts[a_c])
+	if v_num_bits >= 8 {
+		return uint8(((v_x >> (v_num_bits - 8)) & 255))
+	}
+	v_nb = base.U32Min(v_num_bits, 7)
+	v_y = 0
+	v_n = 0
+	for v_n < 8 {
+		v_y = (((v_y << v_nb) | v_x) & 16383)
+		v_n += v_nb
+	}
+	return uint8(((v_y >> (v_n - 8)) & 255))
+}
+
+// -------- func decoder.decode_rle
+
+func (self *Decoder) decodeRle(a_dst *base.ImageBuffer, a_src base.IOReader) (status error) {
+
+	var (
+		v_rle4      bool
+		v_x         uint32
+		v_a         uint32
+		v_b         uint8
+		v_c         uint8
+		v_n         uint32
+		v_num_bytes uint32
+		t_0         uint8
+		t_1         uint8
+		t_2         uint8
+		t_3         uint8
+		t_4         uint8
+	)
+
+	r := self.c_decode_rle.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_rle4 = self.c_decode_rle.v_rle4
+		v_x = self.c_decode_rle.v_x
+		v_a = self.c_decode_rle.v_a
+		v_b = self.c_decode_rle.v_b
+		v_c = self.c_decode_rle.v_c
+		v_n = self.c_decode_rle.v_n
+		v_num_bytes = self.c_decode_rle.v_num_bytes
+	}
+
+	if r == 0 {
+		self.clearImage(a_dst)
+		v_rle4 = (self.f_compression == compression_rle4)
+		v_x = 0
+		v_a = 0
+		v_b = 0
+		v_c = 0
+		v_n = 0
+		v_num_bytes = 0
+	}
+	if r == 0 || (1 <= r && r <= 6) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_a = uint32(t_0)
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_b = t_1
+			}
+			if r == 0 || (3 <= r && r <= 6) {
+				if r == 0 && (v_a > 0) {
+					v_n = 0
+					for v_n < v_a {
+						if v_rle4 && ((v_n & 1) == 0) {
+							v_c = (v_b >> 4)
+						} else if v_rle4 {
+							v_c = (v_b & 15)
+						} else {
+							v_c = v_b
+						}
+						self.writeIndex(a_dst, v_x, v_c)
+						v_x = base.U32SatAdd(v_x, 1)
+						v_n += 1
+					}
+				} else if r == 0 && (v_b == 0) {
+					v_x = 0
+					self.f_dst_y = base.U32SatAdd(self.f_dst_y, 1)
+				} else if r == 0 && (v_b == 1) {
+					break label_0
+				} else if (3 <= r && r <= 4) || (r == 0 && (v_b == 2)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if t_2, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_x = base.U32SatAdd(v_x, uint32(t_2))
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if t_3, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_dst_y = base.U32SatAdd(self.f_dst_y, uint32(t_3))
+					}
+				} else {
+					if r == 0 {
+						v_a = uint32(v_b)
+						if v_rle4 {
+							v_num_bytes = ((v_a + 1) >> 1)
+						} else {
+							v_num_bytes = v_a
+						}
+						v_n = 0
+					}
+					if r == 0 || r == 5 {
+						for r != 0 || (v_n < v_a) {
+							if r == 0 || r == 5 {
+								if r == 5 || (r == 0 && (!v_rle4 || ((v_n & 1) == 0))) {
+									if r == 0 || r == 5 {
+										if r == 5 {
+											r = 0
+										}
+										csp = 5
+										if t_4, status = a_src.ReadU8(); status != nil {
+											goto suspend
+										}
+										v_b = t_4
+									}
+								}
+							}
+							if r == 0 {
+								if v_rle4 && ((v_n & 1) == 0) {
+									v_c = (v_b >> 4)
+								} else if v_rle4 {
+									v_c = (v_b & 15)
+								} else {
+									v_c = v_b
+								}
+								self.writeIndex(a_dst, v_x, v_c)
+								v_x = base.U32SatAdd(v_x, 1)
+								v_n += 1
+							}
+						}
+					}
+					if r == 0 || r == 6 {
+						if r == 6 || (r == 0 && ((v_num_bytes & 1) != 0)) {
+							if r == 0 || r == 6 {
+								if r == 0 {
+									self.c_decode_rle.scratch = uint64(1)
+								}
+								if r == 6 {
+									r = 0
+								}
+								csp = 6
+								if status = a_src.Skip(&self.c_decode_rle.scratch); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	self.c_decode_rle.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_rle.coroSuspPoint = csp
+	self.c_decode_rle.v_rle4 = v_rle4
+	self.c_decode_rle.v_x = v_x
+	self.c_decode_rle.v_a = v_a
+	self.c_decode_rle.v_b = v_b
+	self.c_decode_rle.v_c = v_c
+	self.c_decode_rle.v_n = v_n
+	self.c_decode_rle.v_num_bytes = v_num_bytes
+
+exit:
+	return status
+}
+
+// -------- func decoder.clear_image
+
+func (self *Decoder) clearImage(a_dst *base.ImageBuffer) {
+
+	var (
+		v_tab base.TableU8
+		v_y   uint32
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_y = 0
+	for v_y < self.f_height {
+		{
+			i_slice_p := v_tab.Row(v_y)
+			var v_p []byte
+			i_p := 0
+			i_end0_p := (len(i_slice_p) / 1) * 1
+			for i_p < i_end0_p {
+				v_p = i_slice_p[i_p : i_p+1]
+				v_p[0] = 0
+				i_p += 1
+			}
+		}
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+}
+
+// -------- func decoder.write_index
+
+func (self *Decoder) writeIndex(a_dst *base.ImageBuffer, a_x uint32, a_index uint8) {
+
+	var (
+		v_tab   base.TableU8
+		v_dst   []byte
+		v_x4    uint64
+		v_p     uint32
+		v_pixel [4]uint8
+	)
+
+	if (a_x >= self.f_width) || (self.f_dst_y >= self.f_height) {
+		return
+	}
+	v_tab = a_dst.Plane(0)
+	v_dst = v_tab.Row(self.fileRowToY(self.f_dst_y))
+	v_x4 = (uint64(a_x) * 4)
+	v_p = uint32(a_index)
+	v_pixel = [4]uint8{}
+	v_pixel[0] = self.f_palette[((4 * v_p) + 0)]
+	v_pixel[1] = self.f_palette[((4 * v_p) + 1)]
+	v_pixel[2] = self.f_palette[((4 * v_p) + 2)]
+	v_pixel[3] = self.f_palette[((4 * v_p) + 3)]
+	if v_x4 < uint64(len(v_dst)) {
+		copy(v_dst[v_x4:], v_pixel[:])
+	}
+}
+
+// -------- func decoder.decode_and_mask
+
+func (self *Decoder) decodeAndMask(a_dst *base.ImageBuffer, a_src base.IOReader) (status error) {
+
+	var (
+		v_row_length uint64
+	)
+
+	r := self.c_decode_and_mask.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_row_length = self.c_decode_and_mask.v_row_length
+	}
+
+	if r == 0 {
+		v_row_length = (((uint64(self.f_width) + 31) >> 5) * 4)
+		if v_row_length > 32768 {
+			status = ErrTODOUnsupportedImageWidth
+			goto exit
+		}
+		self.f_row_length = uint32(base.U64Min(v_row_length, 32768))
+		self.f_dst_y = 0
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (self.f_dst_y < self.f_height) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.readRow(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if self.f_bits_per_pixel < 32 {
+					self.applyAndMaskRow(a_dst)
+				}
+				self.f_dst_y = base.U32SatAdd(self.f_dst_y, 1)
+			}
+		}
+	}
+
+	self.c_decode_and_mask.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_and_mask.coroSuspPoint = csp
+	self.c_decode_and_mask.v_row_length = v_row_length
+
+exit:
+	return status
+}
+
+// -------- func decoder.apply_and_mask_row
+
+func (self *Decoder) applyAndMaskRow(a_dst *base.ImageBuffer) {
+
+	var (
+		v_tab    base.TableU8
+		v_dst    []byte
+		v_n      uint32
+		v_zeroes [4]uint8
+		v_x      uint32
+		v_x4     uint64
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_dst = v_tab.Row(self.fileRowToY(self.f_dst_y))
+	v_n = self.f_row_length
+	v_zeroes = [4]uint8{}
+	v_x = 0
+	v_x4 = 0
+	for (v_x < self.f_width) && ((v_x >> 3) < v_n) {
+		if ((self.f_row[(v_x>>3)] >> (7 - (v_x & 7))) & 1) != 0 {
+			v_x4 = (uint64(v_x) * 4)
+			if v_x4 < uint64(len(v_dst)) {
+				copy(v_dst[v_x4:], v_zeroes[:])
+			}
+		}
+		v_x += 1
+	}
+}
+
+// -------- func decoder.file_row_to_y
+
+func (self *Decoder) fileRowToY(a_r uint32) uint32 {
+
+	if self.f_top_down || (a_r >= self.f_height) {
+		return a_r
+	}
+	return ((self.f_height - 1) - a_r)
+}
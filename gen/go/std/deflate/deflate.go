@@ -0,0 +1,4486 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package deflate
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadHuffmanCodeOverSubscribed                 = base.NewError("deflate: bad Huffman code (over-subscribed)")
+	ErrBadHuffmanCodeUnderSubscribed                = base.NewError("deflate: bad Huffman code (under-subscribed)")
+	ErrBadHuffmanCodeLengthCount                    = base.NewError("deflate: bad Huffman code length count")
+	ErrBadHuffmanCodeLengthRepetition               = base.NewError("deflate: bad Huffman code length repetition")
+	ErrBadHuffmanCode                               = base.NewError("deflate: bad Huffman code")
+	ErrBadHuffmanMinimumCodeLength                  = base.NewError("deflate: bad Huffman minimum code length")
+	ErrBadBlock                                     = base.NewError("deflate: bad block")
+	ErrBadDistance                                  = base.NewError("deflate: bad distance")
+	ErrBadDistanceCodeCount                         = base.NewError("deflate: bad distance code count")
+	ErrBadLiteralLengthCodeCount                    = base.NewError("deflate: bad literal/length code count")
+	ErrInconsistentStoredBlockLength                = base.NewError("deflate: inconsistent stored block length")
+	ErrMissingEndOfBlockCode                        = base.NewError("deflate: missing end-of-block code")
+	ErrNoHuffmanCodes                               = base.NewError("deflate: no Huffman codes")
+	errInternalErrorInconsistentHuffmanDecoderState = base.NewError("deflate: internal error: inconsistent Huffman decoder state")
+	errInternalErrorInconsistentHuffmanEndOfBlock   = base.NewError("deflate: internal error: inconsistent Huffman end_of_block")
+	errInternalErrorInconsistentDistance            = base.NewError("deflate: internal error: inconsistent distance")
+	errInternalErrorInconsistentNBits               = base.NewError("deflate: internal error: inconsistent n_bits")
+	errInternalErrorInconsistentEncoderState        = base.NewError("deflate: internal error: inconsistent encoder state")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a deflate.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_bits          uint32
+	f_n_bits        uint32
+	f_huffs         [2][1234]uint32
+	f_n_huffs_bits  [2]uint32
+	f_history       [32768]uint8
+	f_history_index uint32
+	f_code_lengths  [320]uint8
+	f_end_of_block  bool
+
+	c_decode struct {
+		coroSuspPoint uint32
+		v_z           error
+	}
+
+	c_decode_blocks struct {
+		coroSuspPoint uint32
+		v_final       uint32
+		v_type        uint32
+	}
+
+	c_decode_uncompressed struct {
+		coroSuspPoint uint32
+		v_length      uint32
+		v_n_copied    uint32
+		scratch       uint64
+	}
+
+	c_init_fixed_huffman struct {
+		coroSuspPoint uint32
+		v_i           uint32
+	}
+
+	c_init_dynamic_huffman struct {
+		coroSuspPoint        uint32
+		v_bits               uint32
+		v_n_bits             uint32
+		v_n_lit              uint32
+		v_n_dist             uint32
+		v_n_clen             uint32
+		v_i                  uint32
+		v_mask               uint32
+		v_table_entry        uint32
+		v_table_entry_n_bits uint32
+		v_n_extra_bits       uint32
+		v_rep_symbol         uint8
+		v_rep_count          uint32
+	}
+
+	c_decode_huffman_slow struct {
+		coroSuspPoint        uint32
+		v_bits               uint32
+		v_n_bits             uint32
+		v_table_entry        uint32
+		v_table_entry_n_bits uint32
+		v_lmask              uint32
+		v_dmask              uint32
+		v_redir_top          uint32
+		v_redir_mask         uint32
+		v_length             uint32
+		v_dist_minus_1       uint32
+		v_n_copied           uint32
+		v_hlen               uint32
+		v_hdist              uint32
+	}
+}
+
+// Encoder holds the state of a deflate.encoder. Its zero value is ready to use.
+type Encoder struct {
+	status error
+
+	f_level       uint32
+	f_level_set   bool
+	f_eof         bool
+	f_bits        uint32
+	f_n_bits      uint32
+	f_window      [65536]uint8
+	f_w_pos       uint32
+	f_w_end       uint32
+	f_block_start uint32
+	f_head        [32768]uint16
+	f_prev        [32768]uint16
+	f_ins_pos     uint32
+	f_tokens      [16384]uint32
+	f_n_tokens    uint32
+	f_freqs       [3][288]uint32
+	f_lens        [3][288]uint8
+	f_codes       [3][288]uint16
+	f_n_lcodes    uint32
+	f_n_dcodes    uint32
+	f_n_clcodes   uint32
+	f_clseq       [320]uint16
+	f_n_clseq     uint32
+	f_hsort_keys  [512]uint32
+	f_hsort_syms  [512]uint16
+
+	c_encode struct {
+		coroSuspPoint uint32
+		v_z           error
+	}
+
+	c_fill_window struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_write_block struct {
+		coroSuspPoint  uint32
+		v_stored       bool
+		v_fixed        bool
+		v_n_extra_bits uint64
+		v_fixed_cost   uint64
+		v_dynamic_cost uint64
+		v_n_bytes      uint64
+		v_stored_cost  uint64
+	}
+
+	c_write_stored struct {
+		coroSuspPoint uint32
+		v_start       uint32
+		v_end         uint32
+		v_remaining   uint32
+		v_length      uint32
+		v_n_copied    uint32
+		v_new_start   uint32
+		scratch       uint64
+	}
+
+	c_write_bits struct {
+		coroSuspPoint uint32
+		v_n_bits      uint32
+	}
+
+	c_write_dynamic_header struct {
+		coroSuspPoint  uint32
+		v_i            uint32
+		v_symbol       uint32
+		v_n_extra_bits uint32
+	}
+
+	c_write_tokens struct {
+		coroSuspPoint uint32
+		v_i           uint32
+		v_t           uint32
+		v_length      uint32
+		v_lc          uint32
+		v_d           uint32
+		v_dc          uint32
+		v_magic       uint32
+	}
+}
+
+// ---------------- Private Consts
+
+var code_order = [19]uint8{
+	0x10,
+	0x11,
+	0x12,
+	0x0,
+	0x8,
+	0x7,
+	0x9,
+	0x6,
+	0xA,
+	0x5,
+	0xB,
+	0x4,
+	0xC,
+	0x3,
+	0xD,
+	0x2,
+	0xE,
+	0x1,
+	0xF,
+}
+
+var reverse8 = [256]uint8{
+	0x0,
+	0x80,
+	0x40,
+	0xC0,
+	0x20,
+	0xA0,
+	0x60,
+	0xE0,
+	0x10,
+	0x90,
+	0x50,
+	0xD0,
+	0x30,
+	0xB0,
+	0x70,
+	0xF0,
+	0x8,
+	0x88,
+	0x48,
+	0xC8,
+	0x28,
+	0xA8,
+	0x68,
+	0xE8,
+	0x18,
+	0x98,
+	0x58,
+	0xD8,
+	0x38,
+	0xB8,
+	0x78,
+	0xF8,
+	0x4,
+	0x84,
+	0x44,
+	0xC4,
+	0x24,
+	0xA4,
+	0x64,
+	0xE4,
+	0x14,
+	0x94,
+	0x54,
+	0xD4,
+	0x34,
+	0xB4,
+	0x74,
+	0xF4,
+	0xC,
+	0x8C,
+	0x4C,
+	0xCC,
+	0x2C,
+	0xAC,
+	0x6C,
+	0xEC,
+	0x1C,
+	0x9C,
+	0x5C,
+	0xDC,
+	0x3C,
+	0xBC,
+	0x7C,
+	0xFC,
+	0x2,
+	0x82,
+	0x42,
+	0xC2,
+	0x22,
+	0xA2,
+	0x62,
+	0xE2,
+	0x12,
+	0x92,
+	0x52,
+	0xD2,
+	0x32,
+	0xB2,
+	0x72,
+	0xF2,
+	0xA,
+	0x8A,
+	0x4A,
+	0xCA,
+	0x2A,
+	0xAA,
+	0x6A,
+	0xEA,
+	0x1A,
+	0x9A,
+	0x5A,
+	0xDA,
+	0x3A,
+	0xBA,
+	0x7A,
+	0xFA,
+	0x6,
+	0x86,
+	0x46,
+	0xC6,
+	0x26,
+	0xA6,
+	0x66,
+	0xE6,
+	0x16,
+	0x96,
+	0x56,
+	0xD6,
+	0x36,
+	0xB6,
+	0x76,
+	0xF6,
+	0xE,
+	0x8E,
+	0x4E,
+	0xCE,
+	0x2E,
+	0xAE,
+	0x6E,
+	0xEE,
+	0x1E,
+	0x9E,
+	0x5E,
+	0xDE,
+	0x3E,
+	0xBE,
+	0x7E,
+	0xFE,
+	0x1,
+	0x81,
+	0x41,
+	0xC1,
+	0x21,
+	0xA1,
+	0x61,
+	0xE1,
+	0x11,
+	0x91,
+	0x51,
+	0xD1,
+	0x31,
+	0xB1,
+	0x71,
+	0xF1,
+	0x9,
+	0x89,
+	0x49,
+	0xC9,
+	0x29,
+	0xA9,
+	0x69,
+	0xE9,
+	0x19,
+	0x99,
+	0x59,
+	0xD9,
+	0x39,
+	0xB9,
+	0x79,
+	0xF9,
+	0x5,
+	0x85,
+	0x45,
+	0xC5,
+	0x25,
+	0xA5,
+	0x65,
+	0xE5,
+	0x15,
+	0x95,
+	0x55,
+	0xD5,
+	0x35,
+	0xB5,
+	0x75,
+	0xF5,
+	0xD,
+	0x8D,
+	0x4D,
+	0xCD,
+	0x2D,
+	0xAD,
+	0x6D,
+	0xED,
+	0x1D,
+	0x9D,
+	0x5D,
+	0xDD,
+	0x3D,
+	0xBD,
+	0x7D,
+	0xFD,
+	0x3,
+	0x83,
+	0x43,
+	0xC3,
+	0x23,
+	0xA3,
+	0x63,
+	0xE3,
+	0x13,
+	0x93,
+	0x53,
+	0xD3,
+	0x33,
+	0xB3,
+	0x73,
+	0xF3,
+	0xB,
+	0x8B,
+	0x4B,
+	0xCB,
+	0x2B,
+	0xAB,
+	0x6B,
+	0xEB,
+	0x1B,
+	0x9B,
+	0x5B,
+	0xDB,
+	0x3B,
+	0xBB,
+	0x7B,
+	0xFB,
+	0x7,
+	0x87,
+	0x47,
+	0xC7,
+	0x27,
+	0xA7,
+	0x67,
+	0xE7,
+	0x17,
+	0x97,
+	0x57,
+	0xD7,
+	0x37,
+	0xB7,
+	0x77,
+	0xF7,
+	0xF,
+	0x8F,
+	0x4F,
+	0xCF,
+	0x2F,
+	0xAF,
+	0x6F,
+	0xEF,
+	0x1F,
+	0x9F,
+	0x5F,
+	0xDF,
+	0x3F,
+	0xBF,
+	0x7F,
+	0xFF,
+}
+
+var lcode_magic_numbers = [32]uint32{
+	0x40000300,
+	0x40000400,
+	0x40000500,
+	0x40000600,
+	0x40000700,
+	0x40000800,
+	0x40000900,
+	0x40000A00,
+	0x40000B10,
+	0x40000D10,
+	0x40000F10,
+	0x40001110,
+	0x40001320,
+	0x40001720,
+	0x40001B20,
+	0x40001F20,
+	0x40002330,
+	0x40002B30,
+	0x40003330,
+	0x40003B30,
+	0x40004340,
+	0x40005340,
+	0x40006340,
+	0x40007340,
+	0x40008350,
+	0x4000A350,
+	0x4000C350,
+	0x4000E350,
+	0x40010200,
+	0x8000000,
+	0x8000000,
+	0x8000000,
+}
+
+var dcode_magic_numbers = [32]uint32{
+	0x40000000,
+	0x40000100,
+	0x40000200,
+	0x40000300,
+	0x40000410,
+	0x40000610,
+	0x40000820,
+	0x40000C20,
+	0x40001030,
+	0x40001830,
+	0x40002040,
+	0x40003040,
+	0x40004050,
+	0x40006050,
+	0x40008060,
+	0x4000C060,
+	0x40010070,
+	0x40018070,
+	0x40020080,
+	0x40030080,
+	0x40040090,
+	0x40060090,
+	0x400800A0,
+	0x400C00A0,
+	0x401000B0,
+	0x401800B0,
+	0x402000C0,
+	0x403000C0,
+	0x404000D0,
+	0x406000D0,
+	0x8000000,
+	0x8000000,
+}
+
+var length_codes = [256]uint8{
+	0x0,
+	0x1,
+	0x2,
+	0x3,
+	0x4,
+	0x5,
+	0x6,
+	0x7,
+	0x8,
+	0x8,
+	0x9,
+	0x9,
+	0xA,
+	0xA,
+	0xB,
+	0xB,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0x10,
+	0x10,
+	0x10,
+	0x10,
+	0x10,
+	0x10,
+	0x10,
+	0x10,
+	0x11,
+	0x11,
+	0x11,
+	0x11,
+	0x11,
+	0x11,
+	0x11,
+	0x11,
+	0x12,
+	0x12,
+	0x12,
+	0x12,
+	0x12,
+	0x12,
+	0x12,
+	0x12,
+	0x13,
+	0x13,
+	0x13,
+	0x13,
+	0x13,
+	0x13,
+	0x13,
+	0x13,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1C,
+}
+
+var distance_codes = [512]uint8{
+	0x0,
+	0x1,
+	0x2,
+	0x3,
+	0x4,
+	0x4,
+	0x5,
+	0x5,
+	0x6,
+	0x6,
+	0x6,
+	0x6,
+	0x7,
+	0x7,
+	0x7,
+	0x7,
+	0x8,
+	0x8,
+	0x8,
+	0x8,
+	0x8,
+	0x8,
+	0x8,
+	0x8,
+	0x9,
+	0x9,
+	0x9,
+	0x9,
+	0x9,
+	0x9,
+	0x9,
+	0x9,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xA,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xB,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xC,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xD,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xE,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0xF,
+	0x0,
+	0x0,
+	0x10,
+	0x11,
+	0x12,
+	0x12,
+	0x13,
+	0x13,
+	0x14,
+	0x14,
+	0x14,
+	0x14,
+	0x15,
+	0x15,
+	0x15,
+	0x15,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x16,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x17,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x18,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x19,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1A,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1B,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1C,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+	0x1D,
+}
+
+var level_max_chains = [10]uint32{
+	0x0,
+	0x4,
+	0x8,
+	0x20,
+	0x10,
+	0x20,
+	0x80,
+	0x100,
+	0x400,
+	0x1000,
+}
+
+var level_nice_lengths = [10]uint32{
+	0x0,
+	0x8,
+	0x10,
+	0x20,
+	0x10,
+	0x20,
+	0x80,
+	0x80,
+	0x102,
+	0x102,
+}
+
+var level_lazy_lengths = [10]uint32{
+	0x0,
+	0x0,
+	0x0,
+	0x0,
+	0x4,
+	0x10,
+	0x10,
+	0x20,
+	0x80,
+	0x102,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.decode
+
+func (self *Decoder) Decode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_z error
+		t_0 error
+	)
+
+	r := self.c_decode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_z = self.c_decode.v_z
+	}
+
+	if r == 0 || r == 1 {
+		for {
+			if r == 0 {
+				a_dst.SetMark()
+				t_0 = self.decodeBlocks(a_dst, a_src)
+				v_z = t_0
+				if !base.IsSuspension(v_z) {
+					status = v_z
+					if status == nil {
+						goto ok
+					}
+					if base.IsSuspension(status) {
+						status = base.ErrCannotReturnASuspension
+					}
+					goto exit
+				}
+				self.AddHistory(a_dst.SinceMark())
+			}
+			if r == 0 || r == 1 {
+				status = v_z
+				if r == 1 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 1
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_decode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode.coroSuspPoint = csp
+	self.c_decode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.add_history
+
+func (self *Decoder) AddHistory(a_hist []byte) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	var (
+		v_n_copied     uint64
+		v_already_full uint32
+	)
+
+	if uint64(len(a_hist)) >= 32768 {
+		a_hist = base.SliceU8Suffix(a_hist, 32768)
+		copy(self.f_history[:], a_hist)
+		self.f_history_index = 32768
+	} else {
+		v_n_copied = uint64(copy(self.f_history[(self.f_history_index&32767):], a_hist))
+		if v_n_copied < uint64(len(a_hist)) {
+			a_hist = a_hist[v_n_copied:]
+			v_n_copied = uint64(copy(self.f_history[:], a_hist))
+			self.f_history_index = (uint32((v_n_copied & 32767)) + 32768)
+		} else {
+			v_already_full = 0
+			if self.f_history_index >= 32768 {
+				v_already_full = 32768
+			}
+			self.f_history_index = ((self.f_history_index & 32767) + uint32((v_n_copied & 32767)) + v_already_full)
+		}
+	}
+}
+
+// -------- func decoder.decode_blocks
+
+func (self *Decoder) decodeBlocks(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+
+	var (
+		v_final uint32
+		v_type  uint32
+		t_0     uint8
+	)
+
+	r := self.c_decode_blocks.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_final = self.c_decode_blocks.v_final
+		v_type = self.c_decode_blocks.v_type
+	}
+
+	if r == 0 {
+		v_final = 0
+	}
+	if r == 0 || (1 <= r && r <= 6) {
+	label_0:
+		for r != 0 || (v_final == 0) {
+			if r == 0 || r == 1 {
+				for r != 0 || (self.f_n_bits < 3) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_bits |= (uint32(t_0) << self.f_n_bits)
+					}
+					if r == 0 {
+						self.f_n_bits += 8
+					}
+				}
+			}
+			if r == 0 {
+				v_final = (self.f_bits & 1)
+				v_type = ((self.f_bits >> 1) & 3)
+				self.f_bits >>= 3
+				self.f_n_bits -= 3
+			}
+			if r == 0 || (2 <= r && r <= 4) {
+				if r == 2 || (r == 0 && (v_type == 0)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.decodeUncompressed(a_dst, a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						continue label_0
+					}
+				} else if r == 3 || (r == 0 && (v_type == 1)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.initFixedHuffman(); status != nil {
+							goto suspend
+						}
+					}
+				} else if r == 4 || (r == 0 && (v_type == 2)) {
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.initDynamicHuffman(a_src); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					status = ErrBadBlock
+					goto exit
+				}
+			}
+			if r == 0 {
+				self.f_end_of_block = false
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.decodeHuffmanFast(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if self.f_end_of_block {
+					continue label_0
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = self.decodeHuffmanSlow(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if self.f_end_of_block {
+					continue label_0
+				}
+				status = errInternalErrorInconsistentHuffmanEndOfBlock
+				goto exit
+			}
+		}
+	}
+
+	self.c_decode_blocks.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_blocks.coroSuspPoint = csp
+	self.c_decode_blocks.v_final = v_final
+	self.c_decode_blocks.v_type = v_type
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_uncompressed
+
+func (self *Decoder) decodeUncompressed(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+
+	var (
+		v_length   uint32
+		v_n_copied uint32
+		t_0        uint32
+	)
+
+	r := self.c_decode_uncompressed.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_decode_uncompressed.v_length
+		v_n_copied = self.c_decode_uncompressed.v_n_copied
+	}
+
+	if r == 0 {
+		if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+			status = errInternalErrorInconsistentNBits
+			goto exit
+		}
+		self.f_n_bits = 0
+		self.f_bits = 0
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_uncompressed.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU32LE(&self.c_decode_uncompressed.scratch); status != nil {
+			goto suspend
+		}
+		v_length = t_0
+	}
+	if r == 0 {
+		if (((v_length) & ((uint32(1) << (16)) - 1)) + ((v_length) >> (32 - (16)))) != 65535 {
+			status = ErrInconsistentStoredBlockLength
+			goto exit
+		}
+		v_length = ((v_length) & ((uint32(1) << (16)) - 1))
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		for {
+			if r == 0 {
+				v_n_copied = a_dst.CopyFromReader32(&a_src, v_length)
+				if v_length <= v_n_copied {
+					status = nil
+					goto ok
+				}
+				v_length -= v_n_copied
+			}
+			if r == 0 || (2 <= r && r <= 3) {
+				if r == 2 || (r == 0 && (a_dst.Available() == 0)) {
+					if r == 0 || r == 2 {
+						status = base.SuspensionShortWrite
+						if r == 2 {
+							r = 0
+							status = nil
+						} else {
+							csp = 2
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 || r == 3 {
+						status = base.SuspensionShortRead
+						if r == 3 {
+							r = 0
+							status = nil
+						} else {
+							csp = 3
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_decode_uncompressed.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_uncompressed.coroSuspPoint = csp
+	self.c_decode_uncompressed.v_length = v_length
+	self.c_decode_uncompressed.v_n_copied = v_n_copied
+
+exit:
+	return status
+}
+
+// -------- func decoder.init_fixed_huffman
+
+func (self *Decoder) initFixedHuffman() (status error) {
+
+	var (
+		v_i uint32
+	)
+
+	r := self.c_init_fixed_huffman.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_i = self.c_init_fixed_huffman.v_i
+	}
+
+	if r == 0 {
+		v_i = 0
+		for v_i < 144 {
+			self.f_code_lengths[v_i] = 8
+			v_i += 1
+		}
+		for v_i < 256 {
+			self.f_code_lengths[v_i] = 9
+			v_i += 1
+		}
+		for v_i < 280 {
+			self.f_code_lengths[v_i] = 7
+			v_i += 1
+		}
+		for v_i < 288 {
+			self.f_code_lengths[v_i] = 8
+			v_i += 1
+		}
+		for v_i < 320 {
+			self.f_code_lengths[v_i] = 5
+			v_i += 1
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.initHuff(0, 0, 288, 257); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.initHuff(1, 288, 320, 0); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_init_fixed_huffman.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_init_fixed_huffman.coroSuspPoint = csp
+	self.c_init_fixed_huffman.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.init_dynamic_huffman
+
+func (self *Decoder) initDynamicHuffman(a_src base.IOReader) (status error) {
+
+	var (
+		v_bits               uint32
+		v_n_bits             uint32
+		v_n_lit              uint32
+		v_n_dist             uint32
+		v_n_clen             uint32
+		v_i                  uint32
+		v_mask               uint32
+		v_table_entry        uint32
+		v_table_entry_n_bits uint32
+		v_n_extra_bits       uint32
+		v_rep_symbol         uint8
+		v_rep_count          uint32
+		t_0                  uint8
+		t_1                  uint8
+		t_2                  uint8
+		t_3                  uint8
+	)
+
+	r := self.c_init_dynamic_huffman.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_bits = self.c_init_dynamic_huffman.v_bits
+		v_n_bits = self.c_init_dynamic_huffman.v_n_bits
+		v_n_lit = self.c_init_dynamic_huffman.v_n_lit
+		v_n_dist = self.c_init_dynamic_huffman.v_n_dist
+		v_n_clen = self.c_init_dynamic_huffman.v_n_clen
+		v_i = self.c_init_dynamic_huffman.v_i
+		v_mask = self.c_init_dynamic_huffman.v_mask
+		v_table_entry = self.c_init_dynamic_huffman.v_table_entry
+		v_table_entry_n_bits = self.c_init_dynamic_huffman.v_table_entry_n_bits
+		v_n_extra_bits = self.c_init_dynamic_huffman.v_n_extra_bits
+		v_rep_symbol = self.c_init_dynamic_huffman.v_rep_symbol
+		v_rep_count = self.c_init_dynamic_huffman.v_rep_count
+	}
+
+	if r == 0 {
+		v_bits = self.f_bits
+		v_n_bits = self.f_n_bits
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_n_bits < 14) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_bits |= (uint32(t_0) << v_n_bits)
+			}
+			if r == 0 {
+				v_n_bits += 8
+			}
+		}
+	}
+	if r == 0 {
+		v_n_lit = (((v_bits) & ((uint32(1) << (5)) - 1)) + 257)
+		if v_n_lit > 286 {
+			status = ErrBadLiteralLengthCodeCount
+			goto exit
+		}
+		v_bits >>= 5
+		v_n_dist = (((v_bits) & ((uint32(1) << (5)) - 1)) + 1)
+		if v_n_dist > 30 {
+			status = ErrBadDistanceCodeCount
+			goto exit
+		}
+		v_bits >>= 5
+		v_n_clen = (((v_bits) & ((uint32(1) << (4)) - 1)) + 4)
+		v_bits >>= 4
+		v_n_bits -= 14
+		v_i = 0
+	}
+	if r == 0 || r == 2 {
+		for r != 0 || (v_i < v_n_clen) {
+			if r == 0 || r == 2 {
+				for r != 0 || (v_n_bits < 3) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if t_1, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_bits |= (uint32(t_1) << v_n_bits)
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 {
+				self.f_code_lengths[code_order[v_i]] = uint8((v_bits & 7))
+				v_bits >>= 3
+				v_n_bits -= 3
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		for v_i < 19 {
+			self.f_code_lengths[code_order[v_i]] = 0
+			v_i += 1
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.initHuff(0, 0, 19, 4095); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_mask = ((uint32(1) << self.f_n_huffs_bits[0]) - 1)
+		v_i = 0
+	}
+	if r == 0 || (4 <= r && r <= 5) {
+	label_0:
+		for r != 0 || (v_i < (v_n_lit + v_n_dist)) {
+			if r == 0 {
+				v_table_entry = 0
+			}
+			if r == 0 || r == 4 {
+			label_1:
+				for {
+					if r == 0 {
+						v_table_entry = self.f_huffs[0][(v_bits & v_mask)]
+						v_table_entry_n_bits = (v_table_entry & 15)
+						if v_n_bits >= v_table_entry_n_bits {
+							v_bits >>= v_table_entry_n_bits
+							v_n_bits -= v_table_entry_n_bits
+							break label_1
+						}
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if t_2, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_bits |= (uint32(t_2) << v_n_bits)
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 {
+				if (v_table_entry >> 24) != 128 {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+				v_table_entry = ((v_table_entry >> 8) & 255)
+				if v_table_entry < 16 {
+					self.f_code_lengths[v_i] = uint8(v_table_entry)
+					v_i += 1
+					continue label_0
+				}
+				v_n_extra_bits = 0
+				v_rep_symbol = 0
+				v_rep_count = 0
+				if v_table_entry == 16 {
+					v_n_extra_bits = 2
+					if v_i <= 0 {
+						status = ErrBadHuffmanCodeLengthRepetition
+						goto exit
+					}
+					v_rep_symbol = self.f_code_lengths[(v_i - 1)]
+					v_rep_count = 3
+				} else if v_table_entry == 17 {
+					v_n_extra_bits = 3
+					v_rep_symbol = 0
+					v_rep_count = 3
+				} else if v_table_entry == 18 {
+					v_n_extra_bits = 7
+					v_rep_symbol = 0
+					v_rep_count = 11
+				} else {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+			}
+			if r == 0 || r == 5 {
+				for r != 0 || (v_n_bits < v_n_extra_bits) {
+					if r == 0 || r == 5 {
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if t_3, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_bits |= (uint32(t_3) << v_n_bits)
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 {
+				v_rep_count += ((v_bits) & ((uint32(1) << (v_n_extra_bits)) - 1))
+				v_bits >>= v_n_extra_bits
+				v_n_bits -= v_n_extra_bits
+				for v_rep_count > 0 {
+					if v_i >= (v_n_lit + v_n_dist) {
+						status = ErrBadHuffmanCodeLengthCount
+						goto exit
+					}
+					self.f_code_lengths[v_i] = v_rep_symbol
+					v_i += 1
+					v_rep_count -= 1
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if v_i != (v_n_lit + v_n_dist) {
+			status = ErrBadHuffmanCodeLengthCount
+			goto exit
+		}
+		if self.f_code_lengths[256] == 0 {
+			status = ErrMissingEndOfBlockCode
+			goto exit
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = self.initHuff(0, 0, v_n_lit, 257); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 7 {
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if status = self.initHuff(1, v_n_lit, (v_n_lit + v_n_dist), 0); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_bits = v_bits
+		self.f_n_bits = v_n_bits
+	}
+
+	self.c_init_dynamic_huffman.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_init_dynamic_huffman.coroSuspPoint = csp
+	self.c_init_dynamic_huffman.v_bits = v_bits
+	self.c_init_dynamic_huffman.v_n_bits = v_n_bits
+	self.c_init_dynamic_huffman.v_n_lit = v_n_lit
+	self.c_init_dynamic_huffman.v_n_dist = v_n_dist
+	self.c_init_dynamic_huffman.v_n_clen = v_n_clen
+	self.c_init_dynamic_huffman.v_i = v_i
+	self.c_init_dynamic_huffman.v_mask = v_mask
+	self.c_init_dynamic_huffman.v_table_entry = v_table_entry
+	self.c_init_dynamic_huffman.v_table_entry_n_bits = v_table_entry_n_bits
+	self.c_init_dynamic_huffman.v_n_extra_bits = v_n_extra_bits
+	self.c_init_dynamic_huffman.v_rep_symbol = v_rep_symbol
+	self.c_init_dynamic_huffman.v_rep_count = v_rep_count
+
+exit:
+	return status
+}
+
+// -------- func decoder.init_huff
+
+func (self *Decoder) initHuff(a_which uint32, a_n_codes0 uint32, a_n_codes1 uint32, a_base_symbol uint32) (status error) {
+
+	var (
+		v_counts            [16]uint16
+		v_i                 uint32
+		v_remaining         uint32
+		v_offsets           [16]uint16
+		v_n_symbols         uint32
+		v_count             uint32
+		v_symbols           [320]uint16
+		v_min_cl            uint32
+		v_max_cl            uint32
+		v_initial_high_bits uint32
+		v_prev_cl           uint32
+		v_prev_redirect_key uint32
+		v_top               uint32
+		v_next_top          uint32
+		v_code              uint32
+		v_key               uint32
+		v_value             uint32
+		v_cl                uint32
+		v_tmp               uint32
+		v_redirect_key      uint32
+		v_j                 uint32
+		v_reversed_key      uint32
+		v_symbol            uint32
+		v_high_bits         uint32
+		v_delta             uint32
+	)
+
+	v_counts = [16]uint16{}
+	v_i = a_n_codes0
+	for v_i < a_n_codes1 {
+		if v_counts[self.f_code_lengths[v_i]] >= 320 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_counts[self.f_code_lengths[v_i]] += 1
+		v_i += 1
+	}
+	if (uint32(v_counts[0]) + a_n_codes0) == a_n_codes1 {
+		status = ErrNoHuffmanCodes
+		goto exit
+	}
+	v_remaining = 1
+	v_i = 1
+	for v_i <= 15 {
+		if v_remaining > 1073741824 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_remaining <<= 1
+		if v_remaining < uint32(v_counts[v_i]) {
+			status = ErrBadHuffmanCodeOverSubscribed
+			goto exit
+		}
+		v_remaining -= uint32(v_counts[v_i])
+		v_i += 1
+	}
+	if v_remaining != 0 {
+		if (a_which != 1) || (v_counts[1] != 1) || (v_remaining != 16384) {
+			status = ErrBadHuffmanCodeUnderSubscribed
+			goto exit
+		}
+		self.f_huffs[1][1] = 134217728
+	}
+	v_offsets = [16]uint16{}
+	v_n_symbols = 0
+	v_i = 1
+	for v_i <= 15 {
+		v_offsets[v_i] = uint16(v_n_symbols)
+		v_count = uint32(v_counts[v_i])
+		if v_n_symbols > (320 - v_count) {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_n_symbols = (v_n_symbols + v_count)
+		v_i += 1
+	}
+	if v_n_symbols > 288 {
+		status = errInternalErrorInconsistentHuffmanDecoderState
+		goto exit
+	}
+	v_symbols = [320]uint16{}
+	v_i = a_n_codes0
+	for v_i < a_n_codes1 {
+		if v_i < a_n_codes0 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		if self.f_code_lengths[v_i] != 0 {
+			if v_offsets[self.f_code_lengths[v_i]] >= 320 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			v_symbols[v_offsets[self.f_code_lengths[v_i]]] = uint16((v_i - a_n_codes0))
+			v_offsets[self.f_code_lengths[v_i]] += 1
+		}
+		v_i += 1
+	}
+	v_min_cl = 1
+label_0:
+	for {
+		if v_counts[v_min_cl] != 0 {
+			break label_0
+		}
+		if v_min_cl >= 9 {
+			status = ErrBadHuffmanMinimumCodeLength
+			goto exit
+		}
+		v_min_cl += 1
+	}
+	v_max_cl = 15
+label_1:
+	for {
+		if v_counts[v_max_cl] != 0 {
+			break label_1
+		}
+		if v_max_cl <= 1 {
+			status = ErrNoHuffmanCodes
+			goto exit
+		}
+		v_max_cl -= 1
+	}
+	if v_max_cl <= 9 {
+		self.f_n_huffs_bits[a_which] = v_max_cl
+	} else {
+		self.f_n_huffs_bits[a_which] = 9
+	}
+	v_i = 0
+	if (v_n_symbols != uint32(v_offsets[v_max_cl])) || (v_n_symbols != uint32(v_offsets[15])) {
+		status = errInternalErrorInconsistentHuffmanDecoderState
+		goto exit
+	}
+	if (a_n_codes0 + uint32(v_symbols[0])) >= 320 {
+		status = errInternalErrorInconsistentHuffmanDecoderState
+		goto exit
+	}
+	v_initial_high_bits = 512
+	if v_max_cl < 9 {
+		v_initial_high_bits = (uint32(1) << v_max_cl)
+	}
+	v_prev_cl = uint32(self.f_code_lengths[(a_n_codes0 + uint32(v_symbols[0]))])
+	v_prev_redirect_key = 4294967295
+	v_top = 0
+	v_next_top = 512
+	v_code = 0
+	v_key = 0
+	v_value = 0
+label_2:
+	for {
+		if (a_n_codes0 + uint32(v_symbols[v_i])) >= 320 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_cl = uint32(self.f_code_lengths[(a_n_codes0 + uint32(v_symbols[v_i]))])
+		if v_cl > v_prev_cl {
+			v_code <<= (v_cl - v_prev_cl)
+			if v_code >= 32768 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+		}
+		v_prev_cl = v_cl
+		v_key = v_code
+		if v_cl > 9 {
+			v_tmp = (v_cl - 9)
+			v_cl = v_tmp
+			v_redirect_key = ((v_key >> v_tmp) & 511)
+			v_key = ((v_key) & ((uint32(1) << (v_tmp)) - 1))
+			if v_prev_redirect_key != v_redirect_key {
+				v_prev_redirect_key = v_redirect_key
+				v_remaining = (uint32(1) << v_cl)
+				v_j = v_prev_cl
+			label_3:
+				for v_j <= 15 {
+					if v_remaining <= uint32(v_counts[v_j]) {
+						break label_3
+					}
+					v_remaining -= uint32(v_counts[v_j])
+					if v_remaining > 1073741824 {
+						status = errInternalErrorInconsistentHuffmanDecoderState
+						goto exit
+					}
+					v_remaining <<= 1
+					v_j += 1
+				}
+				if (v_j <= 9) || (15 < v_j) {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+				v_tmp = (v_j - 9)
+				v_initial_high_bits = (uint32(1) << v_tmp)
+				v_top = v_next_top
+				if (v_top + (uint32(1) << v_tmp)) > 1234 {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+				v_next_top = (v_top + (uint32(1) << v_tmp))
+				v_redirect_key = (uint32(reverse8[(v_redirect_key>>1)]) | ((v_redirect_key & 1) << 8))
+				self.f_huffs[a_which][v_redirect_key] = (268435465 | (v_top << 8) | (v_tmp << 4))
+			}
+		}
+		if (v_key >= 512) || (v_counts[v_prev_cl] <= 0) {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_counts[v_prev_cl] -= 1
+		v_reversed_key = (uint32(reverse8[(v_key>>1)]) | ((v_key & 1) << 8))
+		v_reversed_key >>= (9 - v_cl)
+		v_symbol = uint32(v_symbols[v_i])
+		if v_symbol == 256 {
+			v_value = (536870912 | v_cl)
+		} else if (v_symbol < 256) && (a_which == 0) {
+			v_value = (2147483648 | (v_symbol << 8) | v_cl)
+		} else if v_symbol >= a_base_symbol {
+			v_symbol -= a_base_symbol
+			if a_which == 0 {
+				v_value = (lcode_magic_numbers[(v_symbol&31)] | v_cl)
+			} else {
+				v_value = (dcode_magic_numbers[(v_symbol&31)] | v_cl)
+			}
+		} else {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_high_bits = v_initial_high_bits
+		v_delta = (uint32(1) << v_cl)
+		for v_high_bits >= v_delta {
+			v_high_bits -= v_delta
+			if (v_top + ((v_high_bits | v_reversed_key) & 511)) >= 1234 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			self.f_huffs[a_which][(v_top + ((v_high_bits | v_reversed_key) & 511))] = v_value
+		}
+		v_i += 1
+		if v_i >= v_n_symbols {
+			break label_2
+		}
+		v_code += 1
+		if v_code >= 32768 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+	}
+exit:
+	return status
+}
+
+// -------- func decoder.decode_huffman_fast
+
+func (self *Decoder) decodeHuffmanFast(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+
+	var (
+		v_bits               uint32
+		v_n_bits             uint32
+		v_table_entry        uint32
+		v_table_entry_n_bits uint32
+		v_lmask              uint32
+		v_dmask              uint32
+		v_redir_top          uint32
+		v_redir_mask         uint32
+		v_length             uint32
+		v_dist_minus_1       uint32
+		v_n_copied           uint32
+		v_hlen               uint32
+		v_hdist              uint32
+		t_0                  uint8
+		t_1                  uint8
+		t_2                  uint8
+		t_3                  uint8
+		t_4                  uint8
+		t_5                  uint8
+		t_6                  uint8
+		t_7                  uint8
+		t_8                  uint8
+		t_9                  uint8
+		t_10                 uint8
+		t_11                 uint8
+	)
+
+	if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+		status = errInternalErrorInconsistentNBits
+		goto exit
+	}
+	v_bits = self.f_bits
+	v_n_bits = self.f_n_bits
+	v_table_entry = 0
+	v_table_entry_n_bits = 0
+	v_lmask = ((uint32(1) << self.f_n_huffs_bits[0]) - 1)
+	v_dmask = ((uint32(1) << self.f_n_huffs_bits[1]) - 1)
+label_0:
+	for (a_dst.Available() >= 258) && (a_src.Available() >= 12) {
+		if v_n_bits < 15 {
+			t_0 = a_src.ReadU8Fast()
+			v_bits |= (uint32(t_0) << v_n_bits)
+			v_n_bits += 8
+			t_1 = a_src.ReadU8Fast()
+			v_bits |= (uint32(t_1) << v_n_bits)
+			v_n_bits += 8
+		} else {
+		}
+		v_table_entry = self.f_huffs[0][(v_bits & v_lmask)]
+		v_table_entry_n_bits = (v_table_entry & 15)
+		v_bits >>= v_table_entry_n_bits
+		v_n_bits -= v_table_entry_n_bits
+		if (v_table_entry >> 31) != 0 {
+			a_dst.WriteU8Fast(uint8(((v_table_entry >> 8) & 255)))
+			continue label_0
+		} else if (v_table_entry >> 30) != 0 {
+		} else if (v_table_entry >> 29) != 0 {
+			self.f_end_of_block = true
+			break label_0
+		} else if (v_table_entry >> 28) != 0 {
+			if v_n_bits < 15 {
+				t_2 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_2) << v_n_bits)
+				v_n_bits += 8
+				t_3 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_3) << v_n_bits)
+				v_n_bits += 8
+			} else {
+			}
+			v_redir_top = ((v_table_entry >> 8) & 65535)
+			v_redir_mask = ((uint32(1) << ((v_table_entry >> 4) & 15)) - 1)
+			if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			v_table_entry = self.f_huffs[0][(v_redir_top + (v_bits & v_redir_mask))]
+			v_table_entry_n_bits = (v_table_entry & 15)
+			v_bits >>= v_table_entry_n_bits
+			v_n_bits -= v_table_entry_n_bits
+			if (v_table_entry >> 31) != 0 {
+				a_dst.WriteU8Fast(uint8(((v_table_entry >> 8) & 255)))
+				continue label_0
+			} else if (v_table_entry >> 30) != 0 {
+			} else if (v_table_entry >> 29) != 0 {
+				self.f_end_of_block = true
+				break label_0
+			} else if (v_table_entry >> 28) != 0 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			} else if (v_table_entry >> 27) != 0 {
+				status = ErrBadHuffmanCode
+				goto exit
+			} else {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+		} else if (v_table_entry >> 27) != 0 {
+			status = ErrBadHuffmanCode
+			goto exit
+		} else {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_length = ((v_table_entry >> 8) & 32767)
+		v_table_entry_n_bits = ((v_table_entry >> 4) & 15)
+		if v_table_entry_n_bits > 0 {
+			if v_n_bits < 15 {
+				t_4 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_4) << v_n_bits)
+				v_n_bits += 8
+				t_5 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_5) << v_n_bits)
+				v_n_bits += 8
+			} else {
+			}
+			v_length = ((v_length + ((v_bits) & ((uint32(1) << (v_table_entry_n_bits)) - 1))) & 32767)
+			v_bits >>= v_table_entry_n_bits
+			v_n_bits -= v_table_entry_n_bits
+		} else {
+		}
+		if v_length > 258 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		if v_n_bits < 15 {
+			t_6 = a_src.ReadU8Fast()
+			v_bits |= (uint32(t_6) << v_n_bits)
+			v_n_bits += 8
+			t_7 = a_src.ReadU8Fast()
+			v_bits |= (uint32(t_7) << v_n_bits)
+			v_n_bits += 8
+		} else {
+		}
+		v_table_entry = self.f_huffs[1][(v_bits & v_dmask)]
+		v_table_entry_n_bits = (v_table_entry & 15)
+		v_bits >>= v_table_entry_n_bits
+		v_n_bits -= v_table_entry_n_bits
+		if (v_table_entry >> 28) == 1 {
+			if v_n_bits < 15 {
+				t_8 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_8) << v_n_bits)
+				v_n_bits += 8
+				t_9 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_9) << v_n_bits)
+				v_n_bits += 8
+			} else {
+			}
+			v_redir_top = ((v_table_entry >> 8) & 65535)
+			v_redir_mask = ((uint32(1) << ((v_table_entry >> 4) & 15)) - 1)
+			if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			v_table_entry = self.f_huffs[1][(v_redir_top + (v_bits & v_redir_mask))]
+			v_table_entry_n_bits = (v_table_entry & 15)
+			v_bits >>= v_table_entry_n_bits
+			v_n_bits -= v_table_entry_n_bits
+		} else {
+		}
+		if (v_table_entry >> 24) != 64 {
+			if (v_table_entry >> 24) == 8 {
+				status = ErrBadHuffmanCode
+				goto exit
+			}
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_dist_minus_1 = ((v_table_entry >> 8) & 32767)
+		v_table_entry_n_bits = ((v_table_entry >> 4) & 15)
+		if v_table_entry_n_bits > 0 {
+			if v_n_bits < 15 {
+				t_10 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_10) << v_n_bits)
+				v_n_bits += 8
+				t_11 = a_src.ReadU8Fast()
+				v_bits |= (uint32(t_11) << v_n_bits)
+				v_n_bits += 8
+			}
+			v_dist_minus_1 = ((v_dist_minus_1 + ((v_bits) & ((uint32(1) << (v_table_entry_n_bits)) - 1))) & 32767)
+			v_bits >>= v_table_entry_n_bits
+			v_n_bits -= v_table_entry_n_bits
+		}
+		v_n_copied = 0
+	label_1:
+		for {
+			if uint64((v_dist_minus_1 + 1)) > uint64(len(a_dst.SinceMark())) {
+				v_hlen = 0
+				v_hdist = uint32((uint64((v_dist_minus_1 + 1)) - uint64(len(a_dst.SinceMark()))))
+				if v_length > v_hdist {
+					v_length -= v_hdist
+					v_hlen = v_hdist
+					if v_length > 258 {
+						status = errInternalErrorInconsistentHuffmanDecoderState
+						goto exit
+					}
+				} else {
+					v_hlen = v_length
+					v_length = 0
+				}
+				if self.f_history_index < v_hdist {
+					status = ErrBadDistance
+					goto exit
+				}
+				v_hdist = (self.f_history_index - v_hdist)
+			label_2:
+				for {
+					v_n_copied = a_dst.CopyFromSlice32(self.f_history[(v_hdist&32767):], v_hlen)
+					if v_hlen <= v_n_copied {
+						break label_2
+					}
+					v_hlen -= v_n_copied
+					a_dst.CopyFromSlice32(self.f_history[:], v_hlen)
+					break label_2
+				}
+				if v_length == 0 {
+					continue label_0
+				}
+				if uint64((v_dist_minus_1 + 1)) > uint64(len(a_dst.SinceMark())) {
+					status = errInternalErrorInconsistentDistance
+					goto exit
+				}
+			}
+			if uint64(v_length) > a_dst.Available() {
+				status = errInternalErrorInconsistentDistance
+				goto exit
+			}
+			a_dst.CopyFromHistory32Fast((v_dist_minus_1 + 1), v_length)
+			break label_1
+		}
+	}
+	for v_n_bits >= 8 {
+		v_n_bits -= 8
+		if status = a_src.UnreadU8(); status != nil {
+			goto exit
+		}
+	}
+	self.f_bits = (v_bits & ((uint32(1) << v_n_bits) - 1))
+	self.f_n_bits = v_n_bits
+	if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+		status = errInternalErrorInconsistentNBits
+		goto exit
+	}
+exit:
+	return status
+}
+
+// -------- func decoder.decode_huffman_slow
+
+func (self *Decoder) decodeHuffmanSlow(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+
+	var (
+		v_bits               uint32
+		v_n_bits             uint32
+		v_table_entry        uint32
+		v_table_entry_n_bits uint32
+		v_lmask              uint32
+		v_dmask              uint32
+		v_redir_top          uint32
+		v_redir_mask         uint32
+		v_length             uint32
+		v_dist_minus_1       uint32
+		v_n_copied           uint32
+		v_hlen               uint32
+		v_hdist              uint32
+		t_0                  uint8
+		t_1                  uint8
+		t_2                  uint8
+		t_3                  uint8
+		t_4                  uint8
+		t_5                  uint8
+	)
+
+	r := self.c_decode_huffman_slow.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_bits = self.c_decode_huffman_slow.v_bits
+		v_n_bits = self.c_decode_huffman_slow.v_n_bits
+		v_table_entry = self.c_decode_huffman_slow.v_table_entry
+		v_table_entry_n_bits = self.c_decode_huffman_slow.v_table_entry_n_bits
+		v_lmask = self.c_decode_huffman_slow.v_lmask
+		v_dmask = self.c_decode_huffman_slow.v_dmask
+		v_redir_top = self.c_decode_huffman_slow.v_redir_top
+		v_redir_mask = self.c_decode_huffman_slow.v_redir_mask
+		v_length = self.c_decode_huffman_slow.v_length
+		v_dist_minus_1 = self.c_decode_huffman_slow.v_dist_minus_1
+		v_n_copied = self.c_decode_huffman_slow.v_n_copied
+		v_hlen = self.c_decode_huffman_slow.v_hlen
+		v_hdist = self.c_decode_huffman_slow.v_hdist
+	}
+
+	if r == 0 {
+		if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+			status = errInternalErrorInconsistentNBits
+			goto exit
+		}
+		v_bits = self.f_bits
+		v_n_bits = self.f_n_bits
+		v_table_entry = 0
+		v_table_entry_n_bits = 0
+		v_lmask = ((uint32(1) << self.f_n_huffs_bits[0]) - 1)
+		v_dmask = ((uint32(1) << self.f_n_huffs_bits[1]) - 1)
+	}
+	if r == 0 || (1 <= r && r <= 11) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+			label_1:
+				for {
+					if r == 0 {
+						v_table_entry = self.f_huffs[0][(v_bits & v_lmask)]
+						v_table_entry_n_bits = (v_table_entry & 15)
+						if v_n_bits >= v_table_entry_n_bits {
+							v_bits >>= v_table_entry_n_bits
+							v_n_bits -= v_table_entry_n_bits
+							break label_1
+						}
+					}
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_bits |= (uint32(t_0) << v_n_bits)
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 || (2 <= r && r <= 4) {
+				if r == 2 || (r == 0 && ((v_table_entry >> 31) != 0)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = a_dst.WriteU8(uint8(((v_table_entry >> 8) & 255))); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						continue label_0
+					}
+				} else if r == 0 && ((v_table_entry >> 30) != 0) {
+				} else if r == 0 && ((v_table_entry >> 29) != 0) {
+					self.f_end_of_block = true
+					break label_0
+				} else if (3 <= r && r <= 4) || (r == 0 && ((v_table_entry >> 28) != 0)) {
+					if r == 0 {
+						v_redir_top = ((v_table_entry >> 8) & 65535)
+						v_redir_mask = ((uint32(1) << ((v_table_entry >> 4) & 15)) - 1)
+					}
+					if r == 0 || r == 3 {
+					label_2:
+						for {
+							if r == 0 {
+								if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+									status = errInternalErrorInconsistentHuffmanDecoderState
+									goto exit
+								}
+								v_table_entry = self.f_huffs[0][(v_redir_top + (v_bits & v_redir_mask))]
+								v_table_entry_n_bits = (v_table_entry & 15)
+								if v_n_bits >= v_table_entry_n_bits {
+									v_bits >>= v_table_entry_n_bits
+									v_n_bits -= v_table_entry_n_bits
+									break label_2
+								}
+							}
+							if r == 0 || r == 3 {
+								if r == 3 {
+									r = 0
+								}
+								csp = 3
+								if t_1, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits |= (uint32(t_1) << v_n_bits)
+							}
+							if r == 0 {
+								v_n_bits += 8
+							}
+						}
+					}
+					if r == 0 || r == 4 {
+						if r == 4 || (r == 0 && ((v_table_entry >> 31) != 0)) {
+							if r == 0 || r == 4 {
+								if r == 4 {
+									r = 0
+								}
+								csp = 4
+								if status = a_dst.WriteU8(uint8(((v_table_entry >> 8) & 255))); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								continue label_0
+							}
+						} else if r == 0 && ((v_table_entry >> 30) != 0) {
+						} else if r == 0 && ((v_table_entry >> 29) != 0) {
+							self.f_end_of_block = true
+							break label_0
+						} else if r == 0 && ((v_table_entry >> 28) != 0) {
+							status = errInternalErrorInconsistentHuffmanDecoderState
+							goto exit
+						} else if r == 0 && ((v_table_entry >> 27) != 0) {
+							status = ErrBadHuffmanCode
+							goto exit
+						} else {
+							status = errInternalErrorInconsistentHuffmanDecoderState
+							goto exit
+						}
+					}
+				} else if r == 0 && ((v_table_entry >> 27) != 0) {
+					status = ErrBadHuffmanCode
+					goto exit
+				} else {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+			}
+			if r == 0 {
+				v_length = ((v_table_entry >> 8) & 32767)
+				v_table_entry_n_bits = ((v_table_entry >> 4) & 15)
+			}
+			if r == 0 || r == 5 {
+				if r == 5 || (r == 0 && (v_table_entry_n_bits > 0)) {
+					if r == 0 || r == 5 {
+						for r != 0 || (v_n_bits < v_table_entry_n_bits) {
+							if r == 0 || r == 5 {
+								if r == 5 {
+									r = 0
+								}
+								csp = 5
+								if t_2, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits |= (uint32(t_2) << v_n_bits)
+							}
+							if r == 0 {
+								v_n_bits += 8
+							}
+						}
+					}
+					if r == 0 {
+						v_length = ((v_length + ((v_bits) & ((uint32(1) << (v_table_entry_n_bits)) - 1))) & 32767)
+						v_bits >>= v_table_entry_n_bits
+						v_n_bits -= v_table_entry_n_bits
+					}
+				}
+			}
+			if r == 0 || r == 6 {
+			label_3:
+				for {
+					if r == 0 {
+						v_table_entry = self.f_huffs[1][(v_bits & v_dmask)]
+						v_table_entry_n_bits = (v_table_entry & 15)
+						if v_n_bits >= v_table_entry_n_bits {
+							v_bits >>= v_table_entry_n_bits
+							v_n_bits -= v_table_entry_n_bits
+							break label_3
+						}
+					}
+					if r == 0 || r == 6 {
+						if r == 6 {
+							r = 0
+						}
+						csp = 6
+						if t_3, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_bits |= (uint32(t_3) << v_n_bits)
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 || r == 7 {
+				if r == 7 || (r == 0 && ((v_table_entry >> 28) == 1)) {
+					if r == 0 {
+						v_redir_top = ((v_table_entry >> 8) & 65535)
+						v_redir_mask = ((uint32(1) << ((v_table_entry >> 4) & 15)) - 1)
+					}
+					if r == 0 || r == 7 {
+					label_4:
+						for {
+							if r == 0 {
+								if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+									status = errInternalErrorInconsistentHuffmanDecoderState
+									goto exit
+								}
+								v_table_entry = self.f_huffs[1][(v_redir_top + (v_bits & v_redir_mask))]
+								v_table_entry_n_bits = (v_table_entry & 15)
+								if v_n_bits >= v_table_entry_n_bits {
+									v_bits >>= v_table_entry_n_bits
+									v_n_bits -= v_table_entry_n_bits
+									break label_4
+								}
+							}
+							if r == 0 || r == 7 {
+								if r == 7 {
+									r = 0
+								}
+								csp = 7
+								if t_4, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits |= (uint32(t_4) << v_n_bits)
+							}
+							if r == 0 {
+								v_n_bits += 8
+							}
+						}
+					}
+				}
+			}
+			if r == 0 {
+				if (v_table_entry >> 24) != 64 {
+					if (v_table_entry >> 24) == 8 {
+						status = ErrBadHuffmanCode
+						goto exit
+					}
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+				v_dist_minus_1 = ((v_table_entry >> 8) & 32767)
+				v_table_entry_n_bits = ((v_table_entry >> 4) & 15)
+			}
+			if r == 0 || r == 8 {
+				if r == 8 || (r == 0 && (v_table_entry_n_bits > 0)) {
+					if r == 0 || r == 8 {
+						for r != 0 || (v_n_bits < v_table_entry_n_bits) {
+							if r == 0 || r == 8 {
+								if r == 8 {
+									r = 0
+								}
+								csp = 8
+								if t_5, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits |= (uint32(t_5) << v_n_bits)
+							}
+							if r == 0 {
+								v_n_bits += 8
+							}
+						}
+					}
+					if r == 0 {
+						v_dist_minus_1 = ((v_dist_minus_1 + ((v_bits) & ((uint32(1) << (v_table_entry_n_bits)) - 1))) & 32767)
+						v_bits >>= v_table_entry_n_bits
+						v_n_bits -= v_table_entry_n_bits
+					}
+				}
+			}
+			if r == 0 {
+				v_n_copied = 0
+			}
+			if r == 0 || (9 <= r && r <= 11) {
+			label_5:
+				for {
+					if r == 0 || (9 <= r && r <= 10) {
+						if (9 <= r && r <= 10) || (r == 0 && (uint64((v_dist_minus_1 + 1)) > uint64(len(a_dst.SinceMark())))) {
+							if r == 0 {
+								v_hlen = 0
+								v_hdist = uint32((uint64((v_dist_minus_1 + 1)) - uint64(len(a_dst.SinceMark()))))
+								if v_length > v_hdist {
+									v_length -= v_hdist
+									v_hlen = v_hdist
+								} else {
+									v_hlen = v_length
+									v_length = 0
+								}
+								if self.f_history_index < v_hdist {
+									status = ErrBadDistance
+									goto exit
+								}
+								v_hdist = (self.f_history_index - v_hdist)
+							}
+							if r == 0 || r == 9 {
+							label_6:
+								for {
+									if r == 0 {
+										v_n_copied = a_dst.CopyFromSlice32(self.f_history[(v_hdist&32767):], v_hlen)
+										if v_hlen <= v_n_copied {
+											v_hlen = 0
+											break label_6
+										}
+										if v_n_copied > 0 {
+											v_hlen -= v_n_copied
+											v_hdist = ((v_hdist + v_n_copied) & 32767)
+											if v_hdist == 0 {
+												break label_6
+											}
+										}
+									}
+									if r == 0 || r == 9 {
+										status = base.SuspensionShortWrite
+										if r == 9 {
+											r = 0
+											status = nil
+										} else {
+											csp = 9
+											goto suspend
+										}
+									}
+								}
+							}
+							if r == 0 || r == 10 {
+								if r == 10 || (r == 0 && (v_hlen > 0)) {
+									if r == 0 || r == 10 {
+									label_7:
+										for {
+											if r == 0 {
+												v_n_copied = a_dst.CopyFromSlice32(self.f_history[(v_hdist&32767):], v_hlen)
+												if v_hlen <= v_n_copied {
+													v_hlen = 0
+													break label_7
+												}
+												v_hlen -= v_n_copied
+												v_hdist += v_n_copied
+											}
+											if r == 0 || r == 10 {
+												status = base.SuspensionShortWrite
+												if r == 10 {
+													r = 0
+													status = nil
+												} else {
+													csp = 10
+													goto suspend
+												}
+											}
+										}
+									}
+								}
+							}
+							if r == 0 {
+								if v_length == 0 {
+									continue label_0
+								}
+							}
+						}
+					}
+					if r == 0 {
+						v_n_copied = a_dst.CopyFromHistory32((v_dist_minus_1 + 1), v_length)
+						if v_length <= v_n_copied {
+							v_length = 0
+							break label_5
+						}
+						v_length -= v_n_copied
+					}
+					if r == 0 || r == 11 {
+						status = base.SuspensionShortWrite
+						if r == 11 {
+							r = 0
+							status = nil
+						} else {
+							csp = 11
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_bits = v_bits
+		self.f_n_bits = v_n_bits
+		if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+			status = errInternalErrorInconsistentNBits
+			goto exit
+		}
+	}
+
+	self.c_decode_huffman_slow.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_huffman_slow.coroSuspPoint = csp
+	self.c_decode_huffman_slow.v_bits = v_bits
+	self.c_decode_huffman_slow.v_n_bits = v_n_bits
+	self.c_decode_huffman_slow.v_table_entry = v_table_entry
+	self.c_decode_huffman_slow.v_table_entry_n_bits = v_table_entry_n_bits
+	self.c_decode_huffman_slow.v_lmask = v_lmask
+	self.c_decode_huffman_slow.v_dmask = v_dmask
+	self.c_decode_huffman_slow.v_redir_top = v_redir_top
+	self.c_decode_huffman_slow.v_redir_mask = v_redir_mask
+	self.c_decode_huffman_slow.v_length = v_length
+	self.c_decode_huffman_slow.v_dist_minus_1 = v_dist_minus_1
+	self.c_decode_huffman_slow.v_n_copied = v_n_copied
+	self.c_decode_huffman_slow.v_hlen = v_hlen
+	self.c_decode_huffman_slow.v_hdist = v_hdist
+
+exit:
+	return status
+}
+
+// -------- func encoder.set_level
+
+func (self *Encoder) SetLevel(a_level uint32) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_level = base.U32Min(a_level, 9)
+	self.f_level_set = true
+}
+
+// -------- func encoder.encode
+
+func (self *Encoder) Encode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_z error
+		t_0 error
+	)
+
+	r := self.c_encode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_z = self.c_encode.v_z
+	}
+
+	if r == 0 {
+		if !self.f_level_set {
+			self.f_level_set = true
+			self.f_level = 6
+		}
+	}
+	if r == 0 || (1 <= r && r <= 5) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 || (r == 0 && !self.f_eof) {
+					if r == 0 {
+						t_0 = self.fillWindow(a_src)
+						v_z = t_0
+					}
+					if r == 0 || r == 1 {
+						if r == 0 && base.IsError(v_z) {
+							self.f_eof = true
+						} else if r == 1 || (r == 0 && base.IsSuspension(v_z)) {
+							if r == 0 || r == 1 {
+								status = v_z
+								if r == 1 {
+									r = 0
+									status = nil
+								} else {
+									if base.IsError(status) {
+										goto exit
+									}
+									if status != nil {
+										csp = 1
+										goto suspend
+									}
+								}
+							}
+							if r == 0 {
+								continue label_0
+							}
+						}
+					}
+				}
+			}
+			if r == 0 {
+				self.tokenize()
+			}
+			if r == 0 || (2 <= r && r <= 3) {
+				if (2 <= r && r <= 3) || (r == 0 && (self.f_eof && (self.f_w_pos >= self.f_w_end))) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.writeBlock(a_dst, 1); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 3 {
+						if r == 3 || (r == 0 && (self.f_n_bits > 0)) {
+							if r == 0 || r == 3 {
+								if r == 3 {
+									r = 0
+								}
+								csp = 3
+								if status = a_dst.WriteU8(uint8((self.f_bits & 255))); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								self.f_bits = 0
+								self.f_n_bits = 0
+							}
+						}
+					}
+					if r == 0 {
+						status = nil
+						goto ok
+					}
+				}
+			}
+			if r == 0 || (4 <= r && r <= 5) {
+				if r == 4 || (r == 0 && (self.f_n_tokens < 16383)) {
+					if r == 0 || r == 4 {
+						if r == 4 || (r == 0 && (self.f_n_tokens > 0)) {
+							if r == 0 || r == 4 {
+								if r == 4 {
+									r = 0
+								}
+								csp = 4
+								if status = self.writeBlock(a_dst, 0); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+					if r == 0 {
+						self.slide()
+					}
+				} else {
+					if r == 0 || r == 5 {
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if status = self.writeBlock(a_dst, 0); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_encode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode.coroSuspPoint = csp
+	self.c_encode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.fill_window
+
+func (self *Encoder) fillWindow(a_src base.IOReader) (status error) {
+
+	var (
+		v_w base.IOWriter
+		u_w base.IOBuffer
+		v_c uint8
+		t_0 uint8
+	)
+
+	r := self.c_fill_window.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_fill_window.v_c
+	}
+
+	if r == 0 || r == 1 {
+	label_0:
+		for r != 0 || (self.f_w_end < 65536) {
+			if r == 0 {
+				if a_src.Available() > 0 {
+					v_w = base.IOWriter{}
+					{
+						o_0_a_src := a_src
+						o_0_v_w := v_w
+						v_w.Set(&u_w, self.f_window[self.f_w_end:])
+						v_w.CopyFromReader32(&a_src, 65536)
+						self.f_w_end = (65536 - uint32(base.U64Min(v_w.Available(), 65536)))
+						v_w = o_0_v_w
+						a_src = o_0_a_src
+					}
+					continue label_0
+				}
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c = t_0
+			}
+			if r == 0 {
+				if self.f_w_end < 65536 {
+					self.f_window[self.f_w_end] = v_c
+					self.f_w_end += 1
+				}
+			}
+		}
+	}
+
+	self.c_fill_window.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_window.coroSuspPoint = csp
+	self.c_fill_window.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func encoder.slide
+
+func (self *Encoder) slide() {
+
+	var (
+		v_i uint32
+	)
+
+	v_i = 0
+	for v_i < 32768 {
+		self.f_window[v_i] = self.f_window[(v_i + 32768)]
+		self.f_head[v_i] = base.U16SatSub(self.f_head[v_i], 32768)
+		self.f_prev[v_i] = base.U16SatSub(self.f_prev[v_i], 32768)
+		v_i += 1
+	}
+	self.f_w_pos = base.U32SatSub(self.f_w_pos, 32768)
+	self.f_w_end = base.U32SatSub(self.f_w_end, 32768)
+	self.f_block_start = base.U32SatSub(self.f_block_start, 32768)
+	self.f_ins_pos = base.U32SatSub(self.f_ins_pos, 32768)
+}
+
+// -------- func encoder.tokenize
+
+func (self *Encoder) tokenize() {
+
+	var (
+		v_limit       uint32
+		v_lazy_length uint32
+		v_p           uint32
+		v_m           uint32
+		v_m_length    uint32
+		v_m2          uint32
+		v_q           uint32
+	)
+
+	v_limit = self.f_w_end
+	if !self.f_eof {
+		v_limit = base.U32SatSub(self.f_w_end, 262)
+	}
+	v_lazy_length = level_lazy_lengths[self.f_level]
+	v_p = 0
+	v_m = 0
+	v_m_length = 0
+label_0:
+	for (self.f_w_pos < v_limit) && (self.f_n_tokens < 16383) {
+		if self.f_w_pos >= 65536 {
+			return
+		}
+		v_p = self.f_w_pos
+		if self.f_level == 0 {
+			self.addToken(uint32(self.f_window[v_p]))
+			self.f_w_pos = (v_p + 1)
+			continue label_0
+		}
+		v_m = self.findMatch(v_p)
+		v_m_length = (v_m & 511)
+	label_1:
+		for (v_m_length >= 3) && (v_m_length < v_lazy_length) && (self.f_n_tokens < 16382) {
+			if (v_p + 1) >= v_limit {
+				break label_1
+			}
+			v_m2 = self.findMatch((v_p + 1))
+			if (v_m2 & 511) <= v_m_length {
+				break label_1
+			}
+			self.addToken(uint32(self.f_window[v_p]))
+			v_p = (v_p + 1)
+			v_m = v_m2
+			v_m_length = (v_m & 511)
+		}
+		if v_m_length >= 3 {
+			self.addToken(v_m)
+			v_q = (v_p + v_m_length)
+			self.f_w_pos = base.U32Min(v_q, self.f_w_end)
+		} else {
+			self.addToken(uint32(self.f_window[v_p]))
+			self.f_w_pos = (v_p + 1)
+		}
+	}
+}
+
+// -------- func encoder.add_token
+
+func (self *Encoder) addToken(a_t uint32) {
+
+	if self.f_n_tokens < 16384 {
+		self.f_tokens[self.f_n_tokens] = a_t
+		self.f_n_tokens += 1
+	}
+}
+
+// -------- func encoder.insert
+
+func (self *Encoder) insert(a_up_to uint32) {
+
+	var (
+		v_h uint32
+	)
+
+	for self.f_ins_pos < a_up_to {
+		if (self.f_ins_pos + 2) >= self.f_w_end {
+			self.f_ins_pos = a_up_to
+			return
+		}
+		v_h = (((uint32(self.f_window[(self.f_ins_pos&65535)]) << 10) ^ (uint32(self.f_window[((self.f_ins_pos+1)&65535)]) << 5) ^ uint32(self.f_window[((self.f_ins_pos+2)&65535)])) & 32767)
+		self.f_prev[(self.f_ins_pos & 32767)] = self.f_head[v_h]
+		self.f_head[v_h] = uint16(self.f_ins_pos)
+		self.f_ins_pos += 1
+	}
+}
+
+// -------- func encoder.find_match
+
+func (self *Encoder) findMatch(a_p uint32) uint32 {
+
+	var (
+		v_remaining     uint32
+		v_max_length    uint32
+		v_h             uint32
+		v_candidate     uint32
+		v_chain         uint32
+		v_nice_length   uint32
+		v_best_length   uint32
+		v_best_distance uint32
+		v_distance      uint32
+		v_n             uint32
+		v_next          uint32
+	)
+
+	self.insert(a_p)
+	v_remaining = base.U32SatSub(self.f_w_end, a_p)
+	if v_remaining < 3 {
+		self.insert((a_p + 1))
+		return 0
+	}
+	v_max_length = base.U32Min(v_remaining, 258)
+	v_h = (((uint32(self.f_window[a_p]) << 10) ^ (uint32(self.f_window[((a_p+1)&65535)]) << 5) ^ uint32(self.f_window[((a_p+2)&65535)])) & 32767)
+	v_candidate = uint32(self.f_head[v_h])
+	v_chain = level_max_chains[self.f_level]
+	v_nice_length = level_nice_lengths[self.f_level]
+	v_best_length = 2
+	v_best_distance = 0
+	v_distance = 0
+	v_n = 0
+	v_next = 0
+label_0:
+	for (v_candidate > 0) && (v_chain > 0) {
+		if a_p <= v_candidate {
+			break label_0
+		} else if (a_p - v_candidate) > 32768 {
+			break label_0
+		}
+		v_distance = (a_p - v_candidate)
+		if self.f_window[((v_candidate+v_best_length)&65535)] == self.f_window[((a_p+v_best_length)&65535)] {
+			v_n = 0
+		label_1:
+			for v_n < v_max_length {
+				if self.f_window[((v_candidate+v_n)&65535)] != self.f_window[((a_p+v_n)&65535)] {
+					break label_1
+				}
+				v_n += 1
+			}
+			if v_n > v_best_length {
+				v_best_length = v_n
+				v_best_distance = v_distance
+				if (v_n >= v_nice_length) || (v_n >= v_max_length) {
+					break label_0
+				}
+			}
+		}
+		v_next = uint32(self.f_prev[(v_candidate & 32767)])
+		if v_next >= v_candidate {
+			break label_0
+		}
+		v_candidate = v_next
+		v_chain = base.U32SatSub(v_chain, 1)
+	}
+	self.insert((a_p + 1))
+	if (v_best_length < 3) || ((v_best_length == 3) && (v_best_distance > 4096)) {
+		return 0
+	}
+	return ((v_best_distance << 16) | v_best_length)
+}
+
+// -------- func encoder.write_block
+
+func (self *Encoder) writeBlock(a_dst base.IOWriter, a_final uint32) (status error) {
+
+	var (
+		v_stored       bool
+		v_fixed        bool
+		v_n_extra_bits uint64
+		v_fixed_cost   uint64
+		v_dynamic_cost uint64
+		v_n_bytes      uint64
+		v_stored_cost  uint64
+	)
+
+	r := self.c_write_block.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_stored = self.c_write_block.v_stored
+		v_fixed = self.c_write_block.v_fixed
+		v_n_extra_bits = self.c_write_block.v_n_extra_bits
+		v_fixed_cost = self.c_write_block.v_fixed_cost
+		v_dynamic_cost = self.c_write_block.v_dynamic_cost
+		v_n_bytes = self.c_write_block.v_n_bytes
+		v_stored_cost = self.c_write_block.v_stored_cost
+	}
+
+	if r == 0 {
+		v_stored = (self.f_level == 0)
+		v_fixed = false
+		if !v_stored {
+			v_n_extra_bits = self.countFrequencies()
+			v_fixed_cost = (self.fixedCost() + v_n_extra_bits)
+			v_dynamic_cost = (self.buildDynamicHuffman() + v_n_extra_bits)
+			v_n_bytes = uint64(base.U32SatSub(self.f_w_pos, self.f_block_start))
+			v_stored_cost = (8 * (v_n_bytes + 5 + (5 * (v_n_bytes / 65535))))
+			v_stored = ((v_stored_cost <= v_fixed_cost) && (v_stored_cost <= v_dynamic_cost))
+			v_fixed = (v_fixed_cost <= v_dynamic_cost)
+		}
+	}
+	if r == 0 || (1 <= r && r <= 5) {
+		if r == 1 || (r == 0 && v_stored) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.writeStored(a_dst, a_final); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || (2 <= r && r <= 4) {
+				if r == 2 || (r == 0 && v_fixed) {
+					if r == 0 {
+						self.initFixedHuffman()
+					}
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.writeBits(a_dst, (a_final | 2), 3); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.writeBits(a_dst, (a_final | 4), 3); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.writeDynamicHeader(a_dst); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.writeTokens(a_dst); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_n_tokens = 0
+		self.f_block_start = self.f_w_pos
+	}
+
+	self.c_write_block.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_block.coroSuspPoint = csp
+	self.c_write_block.v_stored = v_stored
+	self.c_write_block.v_fixed = v_fixed
+	self.c_write_block.v_n_extra_bits = v_n_extra_bits
+	self.c_write_block.v_fixed_cost = v_fixed_cost
+	self.c_write_block.v_dynamic_cost = v_dynamic_cost
+	self.c_write_block.v_n_bytes = v_n_bytes
+	self.c_write_block.v_stored_cost = v_stored_cost
+
+exit:
+	return status
+}
+
+// -------- func encoder.write_stored
+
+func (self *Encoder) writeStored(a_dst base.IOWriter, a_final uint32) (status error) {
+
+	var (
+		v_start     uint32
+		v_end       uint32
+		v_remaining uint32
+		v_length    uint32
+		v_n_copied  uint32
+		v_new_start uint32
+	)
+
+	r := self.c_write_stored.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_start = self.c_write_stored.v_start
+		v_end = self.c_write_stored.v_end
+		v_remaining = self.c_write_stored.v_remaining
+		v_length = self.c_write_stored.v_length
+		v_n_copied = self.c_write_stored.v_n_copied
+		v_new_start = self.c_write_stored.v_new_start
+	}
+
+	if r == 0 {
+		v_start = self.f_block_start
+		v_end = self.f_w_pos
+		v_remaining = 0
+		v_length = 0
+		v_n_copied = 0
+		v_new_start = 0
+	}
+	if r == 0 || (1 <= r && r <= 6) {
+	label_0:
+		for {
+			if r == 0 {
+				v_remaining = base.U32SatSub(v_end, v_start)
+				v_length = base.U32Min(v_remaining, 65535)
+			}
+			if r == 0 || (1 <= r && r <= 2) {
+				if r == 1 || (r == 0 && ((v_start + v_length) < v_end)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if status = self.writeBits(a_dst, 0, 3); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.writeBits(a_dst, a_final, 3); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 || r == 3 {
+				if r == 3 || (r == 0 && (self.f_n_bits > 0)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = a_dst.WriteU8(uint8((self.f_bits & 255))); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						self.f_bits = 0
+						self.f_n_bits = 0
+					}
+				}
+			}
+			if r == 0 || r == 4 {
+				if r == 0 {
+					self.c_write_stored.scratch = 0
+				}
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = a_dst.WriteU16LE(&self.c_write_stored.scratch, uint16(v_length)); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 0 {
+					self.c_write_stored.scratch = 0
+				}
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = a_dst.WriteU16LE(&self.c_write_stored.scratch, uint16((65535 - v_length))); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 6 {
+			label_1:
+				for r != 0 || (v_length > 0) {
+					if r == 0 {
+						v_n_copied = a_dst.CopyFromSlice32(self.f_window[v_start:], v_length)
+						v_new_start = (v_start + v_n_copied)
+						if v_new_start > v_end {
+							status = errInternalErrorInconsistentEncoderState
+							goto exit
+						}
+						v_start = v_new_start
+						if v_length <= v_n_copied {
+							break label_1
+						}
+						v_length -= v_n_copied
+					}
+					if r == 0 || r == 6 {
+						status = base.SuspensionShortWrite
+						if r == 6 {
+							r = 0
+							status = nil
+						} else {
+							csp = 6
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 {
+				if v_start >= v_end {
+					break label_0
+				}
+			}
+		}
+	}
+
+	self.c_write_stored.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_stored.coroSuspPoint = csp
+	self.c_write_stored.v_start = v_start
+	self.c_write_stored.v_end = v_end
+	self.c_write_stored.v_remaining = v_remaining
+	self.c_write_stored.v_length = v_length
+	self.c_write_stored.v_n_copied = v_n_copied
+	self.c_write_stored.v_new_start = v_new_start
+
+exit:
+	return status
+}
+
+// -------- func encoder.write_bits
+
+func (self *Encoder) writeBits(a_dst base.IOWriter, a_x uint32, a_n uint32) (status error) {
+
+	var (
+		v_n_bits uint32
+	)
+
+	r := self.c_write_bits.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_n_bits = self.c_write_bits.v_n_bits
+	}
+
+	if r == 0 {
+		self.f_bits |= (a_x << self.f_n_bits)
+		v_n_bits = (self.f_n_bits + a_n)
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_n_bits >= 8) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = a_dst.WriteU8(uint8((self.f_bits & 255))); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_bits >>= 8
+				v_n_bits -= 8
+			}
+		}
+	}
+	if r == 0 {
+		self.f_n_bits = v_n_bits
+	}
+
+	self.c_write_bits.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_bits.coroSuspPoint = csp
+	self.c_write_bits.v_n_bits = v_n_bits
+
+exit:
+	return status
+}
+
+// -------- func encoder.write_dynamic_header
+
+func (self *Encoder) writeDynamicHeader(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_i            uint32
+		v_symbol       uint32
+		v_n_extra_bits uint32
+	)
+
+	r := self.c_write_dynamic_header.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_i = self.c_write_dynamic_header.v_i
+		v_symbol = self.c_write_dynamic_header.v_symbol
+		v_n_extra_bits = self.c_write_dynamic_header.v_n_extra_bits
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.writeBits(a_dst, base.U32SatSub(self.f_n_lcodes, 257), 5); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.writeBits(a_dst, base.U32SatSub(self.f_n_dcodes, 1), 5); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.writeBits(a_dst, base.U32SatSub(self.f_n_clcodes, 4), 4); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_i = 0
+	}
+	if r == 0 || r == 4 {
+		for r != 0 || (v_i < self.f_n_clcodes) {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.writeBits(a_dst, uint32((self.f_lens[2][code_order[v_i]] & 7)), 3); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		v_symbol = 0
+		v_n_extra_bits = 0
+		v_i = 0
+	}
+	if r == 0 || (5 <= r && r <= 6) {
+		for r != 0 || (v_i < self.f_n_clseq) {
+			if r == 0 {
+				v_symbol = uint32((self.f_clseq[v_i] & 255))
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.writeBits(a_dst, uint32(self.f_codes[2][v_symbol]), uint32(self.f_lens[2][v_symbol])); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_n_extra_bits = 0
+				if v_symbol == 16 {
+					v_n_extra_bits = 2
+				} else if v_symbol == 17 {
+					v_n_extra_bits = 3
+				} else if v_symbol == 18 {
+					v_n_extra_bits = 7
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 6 || (r == 0 && (v_n_extra_bits > 0)) {
+					if r == 0 || r == 6 {
+						if r == 6 {
+							r = 0
+						}
+						csp = 6
+						if status = self.writeBits(a_dst, uint32((self.f_clseq[v_i] >> 8)), v_n_extra_bits); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+
+	self.c_write_dynamic_header.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_dynamic_header.coroSuspPoint = csp
+	self.c_write_dynamic_header.v_i = v_i
+	self.c_write_dynamic_header.v_symbol = v_symbol
+	self.c_write_dynamic_header.v_n_extra_bits = v_n_extra_bits
+
+exit:
+	return status
+}
+
+// -------- func encoder.write_tokens
+
+func (self *Encoder) writeTokens(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_i      uint32
+		v_t      uint32
+		v_length uint32
+		v_lc     uint32
+		v_d      uint32
+		v_dc     uint32
+		v_magic  uint32
+	)
+
+	r := self.c_write_tokens.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_i = self.c_write_tokens.v_i
+		v_t = self.c_write_tokens.v_t
+		v_length = self.c_write_tokens.v_length
+		v_lc = self.c_write_tokens.v_lc
+		v_d = self.c_write_tokens.v_d
+		v_dc = self.c_write_tokens.v_dc
+		v_magic = self.c_write_tokens.v_magic
+	}
+
+	if r == 0 {
+		v_i = 0
+		v_t = 0
+		v_length = 0
+		v_lc = 0
+		v_d = 0
+		v_dc = 0
+		v_magic = 0
+	}
+	if r == 0 || (1 <= r && r <= 5) {
+		for r != 0 || (v_i < self.f_n_tokens) {
+			if r == 0 {
+				v_t = self.f_tokens[v_i]
+			}
+			if r == 0 || (1 <= r && r <= 5) {
+				if r == 1 || (r == 0 && ((v_t >> 16) == 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if status = self.writeBits(a_dst, uint32(self.f_codes[0][(v_t&255)]), uint32(self.f_lens[0][(v_t&255)])); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 {
+						v_length = (v_t & 511)
+						v_lc = uint32(length_codes[((v_length - 3) & 255)])
+					}
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.writeBits(a_dst, uint32(self.f_codes[0][(257+v_lc)]), uint32(self.f_lens[0][(257+v_lc)])); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_magic = lcode_magic_numbers[v_lc]
+					}
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.writeBits(a_dst, ((v_length - ((v_magic >> 8) & 511)) & 31), ((v_magic >> 4) & 15)); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_d = (((v_t >> 16) - 1) & 32767)
+						v_dc = self.dcode(v_d)
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.writeBits(a_dst, uint32(self.f_codes[1][v_dc]), uint32(self.f_lens[1][v_dc])); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_magic = dcode_magic_numbers[v_dc]
+					}
+					if r == 0 || r == 5 {
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if status = self.writeBits(a_dst, ((v_d - ((v_magic >> 8) & 32767)) & 8191), ((v_magic >> 4) & 15)); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = self.writeBits(a_dst, uint32(self.f_codes[0][256]), uint32(self.f_lens[0][256])); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_write_tokens.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_tokens.coroSuspPoint = csp
+	self.c_write_tokens.v_i = v_i
+	self.c_write_tokens.v_t = v_t
+	self.c_write_tokens.v_length = v_length
+	self.c_write_tokens.v_lc = v_lc
+	self.c_write_tokens.v_d = v_d
+	self.c_write_tokens.v_dc = v_dc
+	self.c_write_tokens.v_magic = v_magic
+
+exit:
+	return status
+}
+
+// -------- func encoder.dcode
+
+func (self *Encoder) dcode(a_d uint32) uint32 {
+
+	if a_d < 256 {
+		return uint32(distance_codes[a_d])
+	}
+	return uint32(distance_codes[(256 + (a_d >> 7))])
+}
+
+// -------- func encoder.count_frequencies
+
+func (self *Encoder) countFrequencies() uint64 {
+
+	var (
+		v_i            uint32
+		v_n_extra_bits uint64
+		v_t            uint32
+		v_lc           uint32
+		v_dc           uint32
+	)
+
+	v_i = 0
+	for v_i < 288 {
+		self.f_freqs[0][v_i] = 0
+		self.f_freqs[1][v_i] = 0
+		v_i += 1
+	}
+	v_n_extra_bits = 0
+	v_t = 0
+	v_lc = 0
+	v_dc = 0
+	v_i = 0
+	for v_i < self.f_n_tokens {
+		v_t = self.f_tokens[v_i]
+		if (v_t >> 16) == 0 {
+			self.f_freqs[0][(v_t & 255)] += 1
+		} else {
+			v_lc = uint32(length_codes[(((v_t & 511) - 3) & 255)])
+			self.f_freqs[0][(257 + v_lc)] += 1
+			v_n_extra_bits += uint64(((lcode_magic_numbers[v_lc] >> 4) & 15))
+			v_dc = self.dcode((((v_t >> 16) - 1) & 32767))
+			self.f_freqs[1][v_dc] += 1
+			v_n_extra_bits += uint64(((dcode_magic_numbers[v_dc] >> 4) & 15))
+		}
+		v_i += 1
+	}
+	self.f_freqs[0][256] = 1
+	return v_n_extra_bits
+}
+
+// -------- func encoder.fixed_cost
+
+func (self *Encoder) fixedCost() uint64 {
+
+	var (
+		v_cost uint64
+		v_i    uint32
+		v_n    uint64
+	)
+
+	v_cost = 0
+	v_i = 0
+	for v_i < 288 {
+		v_n = 8
+		if v_i < 144 {
+			v_n = 8
+		} else if v_i < 256 {
+			v_n = 9
+		} else if v_i < 280 {
+			v_n = 7
+		}
+		v_cost += (uint64(self.f_freqs[0][v_i]) * v_n)
+		v_cost += (uint64(self.f_freqs[1][v_i]) * 5)
+		v_i += 1
+	}
+	return v_cost
+}
+
+// -------- func encoder.init_fixed_huffman
+
+func (self *Encoder) initFixedHuffman() {
+
+	var (
+		v_i uint32
+	)
+
+	v_i = 0
+	for v_i < 288 {
+		if v_i < 144 {
+			self.f_lens[0][v_i] = 8
+		} else if v_i < 256 {
+			self.f_lens[0][v_i] = 9
+		} else if v_i < 280 {
+			self.f_lens[0][v_i] = 7
+		} else {
+			self.f_lens[0][v_i] = 8
+		}
+		v_i += 1
+	}
+	v_i = 0
+	for v_i < 32 {
+		self.f_lens[1][v_i] = 5
+		v_i += 1
+	}
+	self.buildCodes(0, 288)
+	self.buildCodes(1, 32)
+}
+
+// -------- func encoder.build_dynamic_huffman
+
+func (self *Encoder) buildDynamicHuffman() uint64 {
+
+	var (
+		v_cost   uint64
+		v_i      uint32
+		v_symbol uint32
+	)
+
+	self.ensureTwoSymbols(0, 286)
+	self.ensureTwoSymbols(1, 30)
+	self.buildHuffman(0, 286, 15)
+	self.buildHuffman(1, 30, 15)
+	v_cost = 0
+	v_i = 0
+	for v_i < 286 {
+		v_cost += (uint64(self.f_freqs[0][v_i]) * uint64(self.f_lens[0][v_i]))
+		if v_i < 30 {
+			v_cost += (uint64(self.f_freqs[1][v_i]) * uint64(self.f_lens[1][v_i]))
+		}
+		v_i += 1
+	}
+	self.f_n_lcodes = 286
+label_0:
+	for self.f_n_lcodes > 257 {
+		if self.f_lens[0][(self.f_n_lcodes-1)] != 0 {
+			break label_0
+		}
+		self.f_n_lcodes -= 1
+	}
+	self.f_n_dcodes = 30
+label_1:
+	for self.f_n_dcodes > 1 {
+		if self.f_lens[1][(self.f_n_dcodes-1)] != 0 {
+			break label_1
+		}
+		self.f_n_dcodes -= 1
+	}
+	self.buildClseq()
+	self.ensureTwoSymbols(2, 19)
+	self.buildHuffman(2, 19, 7)
+	self.f_n_clcodes = 19
+label_2:
+	for self.f_n_clcodes > 4 {
+		if self.f_lens[2][code_order[(self.f_n_clcodes-1)]] != 0 {
+			break label_2
+		}
+		self.f_n_clcodes -= 1
+	}
+	v_cost += (14 + (3 * uint64(self.f_n_clcodes)))
+	v_symbol = 0
+	v_i = 0
+	for v_i < self.f_n_clseq {
+		v_symbol = uint32((self.f_clseq[v_i] & 255))
+		v_cost += uint64(self.f_lens[2][v_symbol])
+		if v_symbol == 16 {
+			v_cost += 2
+		} else if v_symbol == 17 {
+			v_cost += 3
+		} else if v_symbol == 18 {
+			v_cost += 7
+		}
+		v_i += 1
+	}
+	return v_cost
+}
+
+// -------- func encoder.build_clseq
+
+func (self *Encoder) buildClseq() {
+
+	var (
+		v_i       uint32
+		v_total   uint32
+		v_cl      uint8
+		v_prev_cl uint32
+		v_run     uint32
+		v_symbol  uint32
+		v_extra   uint32
+		v_rep     uint32
+	)
+
+	v_i = 0
+	for v_i < 19 {
+		self.f_freqs[2][v_i] = 0
+		v_i += 1
+	}
+	self.f_n_clseq = 0
+	v_total = (self.f_n_lcodes + self.f_n_dcodes)
+	v_cl = 0
+	v_prev_cl = 255
+	v_run = 0
+	v_symbol = 0
+	v_extra = 0
+	v_rep = 0
+	v_i = 0
+	for v_i < v_total {
+		v_cl = self.codeLength(v_i)
+		v_run = 1
+	label_0:
+		for v_run < 138 {
+			if (v_i + v_run) >= v_total {
+				break label_0
+			}
+			if self.codeLength((v_i + v_run)) != v_cl {
+				break label_0
+			}
+			v_run += 1
+		}
+		v_symbol = uint32(v_cl)
+		v_extra = 0
+		if v_cl == 0 {
+			if v_run >= 11 {
+				v_symbol = 18
+				v_extra = (v_run - 11)
+			} else if v_run >= 3 {
+				v_symbol = 17
+				v_extra = (v_run - 3)
+			} else {
+				v_run = 1
+			}
+		} else if (uint32(v_cl) == v_prev_cl) && (v_run >= 3) {
+			v_symbol = 16
+			v_rep = base.U32SatSub(base.U32Min(v_run, 6), 3)
+			v_extra = v_rep
+			v_run = (v_rep + 3)
+		} else {
+			v_run = 1
+		}
+		v_prev_cl = uint32(v_cl)
+		v_i += v_run
+		if self.f_n_clseq >= 320 {
+			return
+		}
+		self.f_clseq[self.f_n_clseq] = uint16((v_symbol | (v_extra << 8)))
+		self.f_n_clseq += 1
+		self.f_freqs[2][v_symbol] += 1
+	}
+}
+
+// -------- func encoder.code_length
+
+func (self *Encoder) codeLength(a_i uint32) uint8 {
+
+	var (
+		v_j uint32
+	)
+
+	if a_i < self.f_n_lcodes {
+		return self.f_lens[0][a_i]
+	}
+	v_j = (a_i - self.f_n_lcodes)
+	if v_j < self.f_n_dcodes {
+		return self.f_lens[1][v_j]
+	}
+	return 0
+}
+
+// -------- func encoder.ensure_two_symbols
+
+func (self *Encoder) ensureTwoSymbols(a_which uint32, a_n uint32) {
+
+	var (
+		v_n_used uint32
+		v_i      uint32
+	)
+
+	v_n_used = 0
+	v_i = 0
+	for v_i < a_n {
+		if (self.f_freqs[a_which][v_i] > 0) && (v_n_used < 2) {
+			v_n_used += 1
+		}
+		v_i += 1
+	}
+	v_i = 0
+	for (v_i < 2) && (v_n_used < 2) {
+		if self.f_freqs[a_which][v_i] == 0 {
+			self.f_freqs[a_which][v_i] = 1
+			v_n_used += 1
+		}
+		v_i += 1
+	}
+}
+
+// -------- func encoder.build_huffman
+
+func (self *Encoder) buildHuffman(a_which uint32, a_n uint32, a_max_length uint32) {
+
+	var (
+		v_n_used         uint32
+		v_f              uint32
+		v_j              uint32
+		v_i              uint32
+		v_root           uint32
+		v_leaf           uint32
+		v_next           uint32
+		v_n_used_minus_1 uint32
+		v_n_used_minus_2 uint32
+		v_avbl           uint32
+		v_used           uint32
+		v_depth          uint32
+		v_counts         [16]uint32
+		v_total          uint64
+		v_length         uint32
+		v_count          uint32
+	)
+
+	v_n_used = 0
+	v_f = 0
+	v_j = 0
+	v_i = 0
+	for v_i < a_n {
+		self.f_lens[a_which][v_i] = 0
+		v_f = self.f_freqs[a_which][v_i]
+		if v_f > 0 {
+			if v_n_used >= 288 {
+				return
+			}
+			v_j = v_n_used
+		label_0:
+			for v_j > 0 {
+				if self.f_hsort_keys[(v_j-1)] <= v_f {
+					break label_0
+				}
+				self.f_hsort_keys[v_j] = self.f_hsort_keys[(v_j - 1)]
+				self.f_hsort_syms[v_j] = self.f_hsort_syms[(v_j - 1)]
+				v_j -= 1
+			}
+			self.f_hsort_keys[v_j] = v_f
+			self.f_hsort_syms[v_j] = uint16(v_i)
+			v_n_used += 1
+		}
+		v_i += 1
+	}
+	if v_n_used < 2 {
+		if v_n_used == 1 {
+			self.f_lens[a_which][self.f_hsort_syms[0]] = 1
+		}
+		return
+	}
+	self.f_hsort_keys[0] += self.f_hsort_keys[1]
+	v_root = 0
+	v_leaf = 2
+	v_next = 1
+	v_n_used_minus_1 = (v_n_used - 1)
+	v_n_used_minus_2 = (v_n_used - 2)
+	for v_next < v_n_used_minus_1 {
+		if (v_leaf >= v_n_used) || (self.f_hsort_keys[(v_root&511)] < self.f_hsort_keys[(v_leaf&511)]) {
+			self.f_hsort_keys[(v_next & 511)] = self.f_hsort_keys[(v_root & 511)]
+			self.f_hsort_keys[(v_root & 511)] = v_next
+			v_root += 1
+		} else {
+			self.f_hsort_keys[(v_next & 511)] = self.f_hsort_keys[(v_leaf & 511)]
+			v_leaf += 1
+		}
+		if (v_leaf >= v_n_used) || ((v_root < v_next) && (self.f_hsort_keys[(v_root&511)] < self.f_hsort_keys[(v_leaf&511)])) {
+			self.f_hsort_keys[(v_next & 511)] += self.f_hsort_keys[(v_root & 511)]
+			self.f_hsort_keys[(v_root & 511)] = v_next
+			v_root += 1
+		} else {
+			self.f_hsort_keys[(v_next & 511)] += self.f_hsort_keys[(v_leaf & 511)]
+			v_leaf += 1
+		}
+		v_next += 1
+	}
+	self.f_hsort_keys[v_n_used_minus_2] = 0
+	v_next = v_n_used_minus_2
+	for v_next > 0 {
+		v_next -= 1
+		self.f_hsort_keys[(v_next & 511)] = (self.f_hsort_keys[(self.f_hsort_keys[(v_next&511)]&511)] + 1)
+	}
+	v_avbl = 1
+	v_used = 0
+	v_depth = 0
+	v_root = v_n_used_minus_1
+	v_next = v_n_used
+	for (v_avbl > 0) && (v_next > 0) {
+	label_1:
+		for v_root > 0 {
+			if self.f_hsort_keys[((v_root-1)&511)] != v_depth {
+				break label_1
+			}
+			v_used += 1
+			v_root -= 1
+		}
+		for (v_avbl > v_used) && (v_next > 0) {
+			self.f_hsort_keys[((v_next - 1) & 511)] = v_depth
+			v_next -= 1
+			v_avbl = base.U32SatSub(v_avbl, 1)
+		}
+		v_avbl = ((v_used & 511) * 2)
+		v_depth += 1
+		v_used = 0
+	}
+	v_counts = [16]uint32{}
+	v_i = 0
+	for v_i < v_n_used {
+		v_depth = self.f_hsort_keys[v_i]
+		if v_depth > a_max_length {
+			v_depth = a_max_length
+		}
+		v_counts[(v_depth & 15)] += 1
+		v_i += 1
+	}
+	v_total = 0
+	v_length = 1
+label_2:
+	for a_max_length >= v_length {
+		v_total += (uint64(v_counts[v_length]) << (a_max_length - v_length))
+		if v_length >= 15 {
+			break label_2
+		}
+		v_length += 1
+	}
+	for v_total > (uint64(1) << a_max_length) {
+		if v_counts[a_max_length] > 0 {
+			v_counts[a_max_length] -= 1
+		}
+		v_length = a_max_length
+	label_3:
+		for v_length > 1 {
+			v_length -= 1
+			if v_counts[v_length] > 0 {
+				v_counts[v_length] -= 1
+				v_counts[((v_length + 1) & 15)] += 2
+				break label_3
+			}
+		}
+		v_total = base.U64SatSub(v_total, 1)
+	}
+	v_i = 0
+	v_length = a_max_length
+	v_count = 0
+	for v_length > 0 {
+		v_count = v_counts[v_length]
+	label_4:
+		for v_count > 0 {
+			if v_i >= v_n_used {
+				break label_4
+			}
+			self.f_lens[a_which][self.f_hsort_syms[v_i]] = uint8(v_length)
+			v_i += 1
+			v_count -= 1
+		}
+		v_length -= 1
+	}
+	self.buildCodes(a_which, a_n)
+}
+
+// -------- func encoder.build_codes
+
+func (self *Encoder) buildCodes(a_which uint32, a_n uint32) {
+
+	var (
+		v_counts     [16]uint32
+		v_i          uint32
+		v_next_codes [16]uint32
+		v_code       uint32
+		v_length     uint32
+		v_c          uint32
+		v_r          uint32
+	)
+
+	v_counts = [16]uint32{}
+	v_i = 0
+	for v_i < a_n {
+		v_counts[self.f_lens[a_which][v_i]] += 1
+		v_i += 1
+	}
+	v_counts[0] = 0
+	v_next_codes = [16]uint32{}
+	v_code = 0
+	v_length = 0
+	for v_length < 15 {
+		v_code = (((v_code + v_counts[v_length]) & 32767) << 1)
+		v_next_codes[(v_length + 1)] = v_code
+		v_length += 1
+	}
+	v_c = 0
+	v_r = 0
+	v_i = 0
+	for v_i < a_n {
+		v_length = uint32(self.f_lens[a_which][v_i])
+		if v_length > 0 {
+			v_c = (v_next_codes[v_length] & 65535)
+			v_next_codes[v_length] += 1
+			v_r = ((uint32(reverse8[(v_c&255)]) << 8) | uint32(reverse8[(v_c>>8)]))
+			self.f_codes[a_which][v_i] = uint16((v_r >> (16 - v_length)))
+		} else {
+			self.f_codes[a_which][v_i] = 0
+		}
+		v_i += 1
+	}
+}
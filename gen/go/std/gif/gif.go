@@ -0,0 +1,2565 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package gif
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/lzw"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadBlock                      = base.NewError("gif: bad block")
+	ErrBadExtensionLabel             = base.NewError("gif: bad extension label")
+	ErrBadGraphicControl             = base.NewError("gif: bad graphic control")
+	ErrBadHeader                     = base.NewError("gif: bad header")
+	ErrBadLiteralWidth               = base.NewError("gif: bad literal width")
+	ErrNotEnoughPixelData            = base.NewError("gif: not enough pixel data")
+	ErrTooMuchPixelData              = base.NewError("gif: too much pixel data")
+	errInternalErrorInconsistentRiWi = base.NewError("gif: internal error: inconsistent ri/wi")
+	ErrUnsupportedImageDimensions    = base.NewError("gif: unsupported image dimensions")
+	ErrUnsupportedPixelFormat        = base.NewError("gif: unsupported pixel format")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a gif.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width                              uint32
+	f_height                             uint32
+	f_call_sequence                      uint8
+	f_end_of_data                        bool
+	f_previous_lzw_decode_ended_abruptly bool
+	f_previous_use_global_palette        bool
+	f_background_color_index             uint8
+	f_interlace                          uint8
+	f_seen_num_loops                     bool
+	f_num_loops                          uint32
+	f_seen_graphic_control               bool
+	f_gc_has_transparent_index           bool
+	f_gc_transparent_index               uint8
+	f_gc_disposal                        uint8
+	f_gc_duration                        uint64
+	f_frame_rect                         base.RectIEU32
+	f_dst_x                              uint32
+	f_dst_y                              uint32
+	f_dst_x0                             uint32
+	f_dst_x1                             uint32
+	f_dst_y0                             uint32
+	f_dst_y1                             uint32
+	f_uncompressed_ri                    uint32
+	f_uncompressed_wi                    uint32
+	f_uncompressed                       [4096]uint8
+	f_palettes                           [2][1024]uint8
+	f_lzw                                lzw.Decoder
+
+	c_decode_config struct {
+		coroSuspPoint uint32
+		v_num_loops   uint32
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+	}
+
+	c_decode_up_to_id_part1 struct {
+		coroSuspPoint uint32
+		v_block_type  uint8
+	}
+
+	c_decode_header struct {
+		coroSuspPoint uint32
+		v_c           [6]uint8
+		v_i           uint32
+	}
+
+	c_decode_lsd struct {
+		coroSuspPoint         uint32
+		v_c                   [7]uint8
+		v_i                   uint32
+		v_flags               uint8
+		v_num_palette_entries uint32
+		v_argb                uint32
+		scratch               uint64
+	}
+
+	c_decode_extension struct {
+		coroSuspPoint uint32
+		v_label       uint8
+	}
+
+	c_skip_blocks struct {
+		coroSuspPoint uint32
+		v_block_size  uint8
+		scratch       uint64
+	}
+
+	c_decode_ae struct {
+		coroSuspPoint  uint32
+		v_c            uint8
+		v_block_size   uint8
+		v_not_animexts bool
+		v_not_netscape bool
+		scratch        uint64
+	}
+
+	c_decode_gc struct {
+		coroSuspPoint uint32
+		v_flags       uint8
+		scratch       uint64
+	}
+
+	c_decode_id_part0 struct {
+		coroSuspPoint uint32
+		v_frame_x     uint32
+		v_frame_y     uint32
+		scratch       uint64
+	}
+
+	c_decode_id_part1 struct {
+		coroSuspPoint         uint32
+		v_flags               uint8
+		v_use_local_palette   bool
+		v_num_palette_entries uint32
+		v_i                   uint32
+		v_argb                uint32
+		v_lw                  uint8
+		v_block_size          uint64
+		v_z                   error
+		scratch               uint64
+	}
+}
+
+// Encoder holds the state of a gif.encoder. Its zero value is ready to use.
+type Encoder struct {
+	status error
+
+	f_width               uint32
+	f_height              uint32
+	f_num_loops           uint32
+	f_call_sequence       uint8
+	f_frame_x0            uint32
+	f_frame_y0            uint32
+	f_frame_x1            uint32
+	f_frame_y1            uint32
+	f_src_x               uint32
+	f_src_y               uint32
+	f_palette_bits        uint32
+	f_global_palette_bits uint32
+	f_palettes            [2][1024]uint8
+	f_block               [255]uint8
+	f_block_ri            uint32
+	f_block_wi            uint32
+	f_lzw                 lzw.Encoder
+
+	c_encode_config struct {
+		coroSuspPoint uint32
+		v_width       uint32
+		v_height      uint32
+		scratch       uint64
+	}
+
+	c_encode_frame struct {
+		coroSuspPoint uint32
+	}
+
+	c_encode_trailer struct {
+		coroSuspPoint uint32
+	}
+
+	c_encode_lsd struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_encode_palette struct {
+		coroSuspPoint         uint32
+		v_num_palette_entries uint32
+		v_i                   uint32
+	}
+
+	c_encode_ae struct {
+		coroSuspPoint uint32
+		v_num_loops   uint32
+		v_i           uint32
+		scratch       uint64
+	}
+
+	c_encode_gc struct {
+		coroSuspPoint         uint32
+		v_flags               uint8
+		v_disposal            uint8
+		v_transparent_index   uint8
+		v_num_palette_entries uint32
+		v_i                   uint32
+		v_centiseconds        uint64
+		v_duration            uint64
+		scratch               uint64
+	}
+
+	c_encode_id struct {
+		coroSuspPoint         uint32
+		v_use_global_palette  bool
+		v_num_palette_entries uint32
+		v_i                   uint32
+		scratch               uint64
+	}
+
+	c_encode_pixels struct {
+		coroSuspPoint uint32
+		v_last_row    bool
+		v_z           error
+	}
+
+	c_write_block struct {
+		coroSuspPoint uint32
+		v_n           uint64
+		v_new_ri      uint32
+	}
+}
+
+// ---------------- Private Consts
+
+var animexts1dot0 = [11]uint8{
+	0x41,
+	0x4E,
+	0x49,
+	0x4D,
+	0x45,
+	0x58,
+	0x54,
+	0x53,
+	0x31,
+	0x2E,
+	0x30,
+}
+
+var netscape2dot0 = [11]uint8{
+	0x4E,
+	0x45,
+	0x54,
+	0x53,
+	0x43,
+	0x41,
+	0x50,
+	0x45,
+	0x32,
+	0x2E,
+	0x30,
+}
+
+var interlace_start = [5]uint32{
+	0xFFFFFFFF,
+	0x1,
+	0x2,
+	0x4,
+	0x0,
+}
+
+var interlace_delta = [5]uint8{
+	0x1,
+	0x2,
+	0x4,
+	0x8,
+	0x8,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_num_loops uint32
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_loops = self.c_decode_config.v_num_loops
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.decodeHeader(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.decodeLsd(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.DecodeUpToIdPart1(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_num_loops = 1
+		if self.f_seen_num_loops {
+			v_num_loops = self.f_num_loops
+		}
+		a_dst.Initialize(570984584, 0, self.f_width, self.f_height, v_num_loops)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_num_loops = v_num_loops
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		if self.f_call_sequence == 0 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 || (r == 0 && !self.f_end_of_data) {
+			if r == 0 || r == 1 {
+				if self.f_call_sequence == 1 {
+					self.f_call_sequence = 2
+				} else {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if status = self.DecodeUpToIdPart1(a_src); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 || (r == 0 && self.f_end_of_data) {
+			if r == 0 || r == 2 {
+				for {
+					if r == 0 || r == 2 {
+						status = base.SuspensionEndOfData
+						if r == 2 {
+							r = 0
+							status = nil
+						} else {
+							csp = 2
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.decodeIdPart1(a_dst, a_src); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_up_to_id_part1
+
+func (self *Decoder) DecodeUpToIdPart1(a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_src.Derive()
+
+	var (
+		v_block_type uint8
+		t_0          uint8
+	)
+
+	r := self.c_decode_up_to_id_part1.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_block_type = self.c_decode_up_to_id_part1.v_block_type
+	}
+
+	if r == 0 || (1 <= r && r <= 3) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_block_type = t_0
+			}
+			if r == 0 || (2 <= r && r <= 3) {
+				if r == 2 || (r == 0 && (v_block_type == 33)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.decodeExtension(a_src); status != nil {
+							goto suspend
+						}
+					}
+				} else if r == 3 || (r == 0 && (v_block_type == 44)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.decodeIdPart0(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				} else if r == 0 && (v_block_type == 59) {
+					self.f_end_of_data = true
+					break label_0
+				} else {
+					status = ErrBadBlock
+					goto exit
+				}
+			}
+		}
+	}
+
+	self.c_decode_up_to_id_part1.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_up_to_id_part1.coroSuspPoint = csp
+	self.c_decode_up_to_id_part1.v_block_type = v_block_type
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_header
+
+func (self *Decoder) decodeHeader(a_src base.IOReader) (status error) {
+
+	var (
+		v_c [6]uint8
+		v_i uint32
+		t_0 uint8
+	)
+
+	r := self.c_decode_header.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_header.v_c
+		v_i = self.c_decode_header.v_i
+	}
+
+	if r == 0 {
+		v_c = [6]uint8{}
+		v_i = 0
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_i < 6) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c[v_i] = t_0
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		if (v_c[0] != 71) || (v_c[1] != 73) || (v_c[2] != 70) || (v_c[3] != 56) || ((v_c[4] != 55) && (v_c[4] != 57)) || (v_c[5] != 97) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+
+	self.c_decode_header.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_header.coroSuspPoint = csp
+	self.c_decode_header.v_c = v_c
+	self.c_decode_header.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_lsd
+
+func (self *Decoder) decodeLsd(a_src base.IOReader) (status error) {
+
+	var (
+		v_c                   [7]uint8
+		v_i                   uint32
+		v_flags               uint8
+		v_num_palette_entries uint32
+		v_argb                uint32
+		t_0                   uint8
+		t_1                   uint32
+	)
+
+	r := self.c_decode_lsd.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_lsd.v_c
+		v_i = self.c_decode_lsd.v_i
+		v_flags = self.c_decode_lsd.v_flags
+		v_num_palette_entries = self.c_decode_lsd.v_num_palette_entries
+		v_argb = self.c_decode_lsd.v_argb
+	}
+
+	if r == 0 {
+		v_c = [7]uint8{}
+		v_i = 0
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_i < 7) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c[v_i] = t_0
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		self.f_width = (uint32(v_c[0]) | (uint32(v_c[1]) << 8))
+		self.f_height = (uint32(v_c[2]) | (uint32(v_c[3]) << 8))
+		v_flags = v_c[4]
+		self.f_background_color_index = v_c[5]
+	}
+	if r == 0 || r == 2 {
+		if r == 2 || (r == 0 && ((v_flags & 128) != 0)) {
+			if r == 0 {
+				v_num_palette_entries = (uint32(1) << (1 + (v_flags & 7)))
+				v_i = 0
+			}
+			if r == 0 || r == 2 {
+				for r != 0 || (v_i < v_num_palette_entries) {
+					if r == 0 || r == 2 {
+						if r == 0 {
+							self.c_decode_lsd.scratch = 0
+						}
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if t_1, status = a_src.ReadU24BE(&self.c_decode_lsd.scratch); status != nil {
+							goto suspend
+						}
+						v_argb = (t_1 | 4278190080)
+					}
+					if r == 0 {
+						self.f_palettes[0][((4 * v_i) + 0)] = uint8(((v_argb >> 0) & 255))
+						self.f_palettes[0][((4 * v_i) + 1)] = uint8(((v_argb >> 8) & 255))
+						self.f_palettes[0][((4 * v_i) + 2)] = uint8(((v_argb >> 16) & 255))
+						self.f_palettes[0][((4 * v_i) + 3)] = uint8(((v_argb >> 24) & 255))
+						v_i += 1
+					}
+				}
+			}
+			if r == 0 {
+				for v_i < 256 {
+					self.f_palettes[0][((4 * v_i) + 0)] = 0
+					self.f_palettes[0][((4 * v_i) + 1)] = 0
+					self.f_palettes[0][((4 * v_i) + 2)] = 0
+					self.f_palettes[0][((4 * v_i) + 3)] = 0
+					v_i += 1
+				}
+			}
+		}
+	}
+
+	self.c_decode_lsd.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_lsd.coroSuspPoint = csp
+	self.c_decode_lsd.v_c = v_c
+	self.c_decode_lsd.v_i = v_i
+	self.c_decode_lsd.v_flags = v_flags
+	self.c_decode_lsd.v_num_palette_entries = v_num_palette_entries
+	self.c_decode_lsd.v_argb = v_argb
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_extension
+
+func (self *Decoder) decodeExtension(a_src base.IOReader) (status error) {
+
+	var (
+		v_label uint8
+		t_0     uint8
+	)
+
+	r := self.c_decode_extension.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_label = self.c_decode_extension.v_label
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_label = t_0
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		if r == 2 || (r == 0 && (v_label == 249)) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.decodeGc(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				status = nil
+				goto ok
+			}
+		} else if r == 3 || (r == 0 && (v_label == 255)) {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.decodeAe(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				status = nil
+				goto ok
+			}
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.skipBlocks(a_src); status != nil {
+			goto suspend
+		}
+	}
+
+ok:
+	self.c_decode_extension.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_extension.coroSuspPoint = csp
+	self.c_decode_extension.v_label = v_label
+
+exit:
+	return status
+}
+
+// -------- func decoder.skip_blocks
+
+func (self *Decoder) skipBlocks(a_src base.IOReader) (status error) {
+
+	var (
+		v_block_size uint8
+		t_0          uint8
+	)
+
+	r := self.c_skip_blocks.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_block_size = self.c_skip_blocks.v_block_size
+	}
+
+	if r == 0 || (1 <= r && r <= 2) {
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_block_size = t_0
+			}
+			if r == 0 {
+				if v_block_size == 0 {
+					status = nil
+					goto ok
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 0 {
+					self.c_skip_blocks.scratch = uint64(uint32(v_block_size))
+				}
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = a_src.Skip(&self.c_skip_blocks.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_skip_blocks.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_skip_blocks.coroSuspPoint = csp
+	self.c_skip_blocks.v_block_size = v_block_size
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_ae
+
+func (self *Decoder) decodeAe(a_src base.IOReader) (status error) {
+
+	var (
+		v_c            uint8
+		v_block_size   uint8
+		v_not_animexts bool
+		v_not_netscape bool
+		t_0            uint8
+		t_1            uint8
+		t_2            uint8
+		t_3            uint8
+		t_4            uint16
+	)
+
+	r := self.c_decode_ae.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_ae.v_c
+		v_block_size = self.c_decode_ae.v_block_size
+		v_not_animexts = self.c_decode_ae.v_not_animexts
+		v_not_netscape = self.c_decode_ae.v_not_netscape
+	}
+
+	if r == 0 || (1 <= r && r <= 8) {
+	label_0:
+		for {
+			if r == 0 {
+				v_c = 0
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_block_size = t_0
+			}
+			if r == 0 {
+				if v_block_size == 0 {
+					status = nil
+					goto ok
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 || (r == 0 && (v_block_size != 11)) {
+					if r == 0 || r == 2 {
+						if r == 0 {
+							self.c_decode_ae.scratch = uint64(uint32(v_block_size))
+						}
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = a_src.Skip(&self.c_decode_ae.scratch); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				}
+			}
+			if r == 0 {
+				v_not_animexts = false
+				v_not_netscape = false
+				v_block_size = 0
+			}
+			if r == 0 || r == 3 {
+				for r != 0 || (v_block_size < 11) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if t_1, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_1
+					}
+					if r == 0 {
+						v_not_animexts = (v_not_animexts || (v_c != animexts1dot0[v_block_size]))
+						v_not_netscape = (v_not_netscape || (v_c != netscape2dot0[v_block_size]))
+						v_block_size += 1
+					}
+				}
+			}
+			if r == 0 {
+				if v_not_animexts && v_not_netscape {
+					break label_0
+				}
+			}
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if t_2, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_block_size = t_2
+			}
+			if r == 0 || r == 5 {
+				if r == 5 || (r == 0 && (v_block_size != 3)) {
+					if r == 0 || r == 5 {
+						if r == 0 {
+							self.c_decode_ae.scratch = uint64(uint32(v_block_size))
+						}
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if status = a_src.Skip(&self.c_decode_ae.scratch); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if t_3, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c = t_3
+			}
+			if r == 0 || r == 7 {
+				if r == 7 || (r == 0 && (v_c != 1)) {
+					if r == 0 || r == 7 {
+						if r == 0 {
+							self.c_decode_ae.scratch = uint64(2)
+						}
+						if r == 7 {
+							r = 0
+						}
+						csp = 7
+						if status = a_src.Skip(&self.c_decode_ae.scratch); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				}
+			}
+			if r == 0 || r == 8 {
+				if r == 0 {
+					self.c_decode_ae.scratch = 0
+				}
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_4, status = a_src.ReadU16LE(&self.c_decode_ae.scratch); status != nil {
+					goto suspend
+				}
+				self.f_num_loops = uint32(t_4)
+			}
+			if r == 0 {
+				self.f_seen_num_loops = true
+				if (0 < self.f_num_loops) && (self.f_num_loops <= 65535) {
+					self.f_num_loops += 1
+				}
+				break label_0
+			}
+		}
+	}
+	if r == 0 || r == 9 {
+		if r == 9 {
+			r = 0
+		}
+		csp = 9
+		if status = self.skipBlocks(a_src); status != nil {
+			goto suspend
+		}
+	}
+
+ok:
+	self.c_decode_ae.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_ae.coroSuspPoint = csp
+	self.c_decode_ae.v_c = v_c
+	self.c_decode_ae.v_block_size = v_block_size
+	self.c_decode_ae.v_not_animexts = v_not_animexts
+	self.c_decode_ae.v_not_netscape = v_not_netscape
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_gc
+
+func (self *Decoder) decodeGc(a_src base.IOReader) (status error) {
+
+	var (
+		v_flags uint8
+		t_0     uint8
+		t_1     uint8
+		t_2     uint16
+		t_3     uint8
+		t_4     uint8
+	)
+
+	r := self.c_decode_gc.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_flags = self.c_decode_gc.v_flags
+	}
+
+	if r == 0 {
+		if self.f_seen_graphic_control {
+			status = ErrBadGraphicControl
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 4) {
+			status = ErrBadGraphicControl
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_flags = t_1
+	}
+	if r == 0 {
+		self.f_gc_has_transparent_index = ((v_flags & 1) != 0)
+		v_flags = ((v_flags >> 2) & 7)
+		if v_flags == 2 {
+			self.f_gc_disposal = 1
+		} else if (v_flags == 3) || (v_flags == 4) {
+			self.f_gc_disposal = 2
+		} else {
+			self.f_gc_disposal = 0
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_decode_gc.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU16LE(&self.c_decode_gc.scratch); status != nil {
+			goto suspend
+		}
+		self.f_gc_duration = (uint64(t_2) * 7056000)
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_3, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		self.f_gc_transparent_index = t_3
+	}
+	if r == 0 || r == 5 {
+		if r == 0 || r == 5 {
+			if r == 5 {
+				r = 0
+			}
+			csp = 5
+			if t_4, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_4 != 0) {
+			status = ErrBadGraphicControl
+			goto exit
+		}
+	}
+	if r == 0 {
+		self.f_seen_graphic_control = true
+	}
+
+	self.c_decode_gc.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_gc.coroSuspPoint = csp
+	self.c_decode_gc.v_flags = v_flags
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_id_part0
+
+func (self *Decoder) decodeIdPart0(a_src base.IOReader) (status error) {
+
+	var (
+		v_frame_x uint32
+		v_frame_y uint32
+		t_0       uint16
+		t_1       uint16
+		t_2       uint16
+		t_3       uint16
+	)
+
+	r := self.c_decode_id_part0.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_frame_x = self.c_decode_id_part0.v_frame_x
+		v_frame_y = self.c_decode_id_part0.v_frame_y
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_id_part0.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16LE(&self.c_decode_id_part0.scratch); status != nil {
+			goto suspend
+		}
+		v_frame_x = uint32(t_0)
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_id_part0.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU16LE(&self.c_decode_id_part0.scratch); status != nil {
+			goto suspend
+		}
+		v_frame_y = uint32(t_1)
+	}
+	if r == 0 {
+		self.f_frame_rect.SetMinInclusiveX(v_frame_x)
+		self.f_frame_rect.SetMinInclusiveY(v_frame_y)
+		self.f_dst_x = v_frame_x
+		self.f_dst_y = v_frame_y
+		self.f_dst_x0 = v_frame_x
+		self.f_dst_y0 = v_frame_y
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_decode_id_part0.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU16LE(&self.c_decode_id_part0.scratch); status != nil {
+			goto suspend
+		}
+		v_frame_x += uint32(t_2)
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_decode_id_part0.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_3, status = a_src.ReadU16LE(&self.c_decode_id_part0.scratch); status != nil {
+			goto suspend
+		}
+		v_frame_y += uint32(t_3)
+	}
+	if r == 0 {
+		self.f_frame_rect.SetMaxExclusiveX(v_frame_x)
+		self.f_frame_rect.SetMaxExclusiveY(v_frame_y)
+		self.f_dst_x1 = v_frame_x
+		self.f_dst_y1 = v_frame_y
+		if self.f_call_sequence == 0 {
+			self.f_width = base.U32Max(self.f_width, v_frame_x)
+			self.f_height = base.U32Max(self.f_height, v_frame_y)
+		}
+	}
+
+	self.c_decode_id_part0.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_id_part0.coroSuspPoint = csp
+	self.c_decode_id_part0.v_frame_x = v_frame_x
+	self.c_decode_id_part0.v_frame_y = v_frame_y
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_id_part1
+
+func (self *Decoder) decodeIdPart1(a_dst *base.ImageBuffer, a_src base.IOReader) (status error) {
+
+	var (
+		v_flags               uint8
+		v_use_local_palette   bool
+		v_num_palette_entries uint32
+		v_i                   uint32
+		v_argb                uint32
+		v_lw                  uint8
+		v_block_size          uint64
+		v_w                   base.IOWriter
+		u_w                   base.IOBuffer
+		v_z                   error
+		v_palette             []byte
+		t_0                   uint8
+		t_1                   uint32
+		t_2                   uint8
+		t_3                   uint8
+		t_4                   error
+	)
+
+	r := self.c_decode_id_part1.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_flags = self.c_decode_id_part1.v_flags
+		v_use_local_palette = self.c_decode_id_part1.v_use_local_palette
+		v_num_palette_entries = self.c_decode_id_part1.v_num_palette_entries
+		v_i = self.c_decode_id_part1.v_i
+		v_argb = self.c_decode_id_part1.v_argb
+		v_lw = self.c_decode_id_part1.v_lw
+		v_block_size = self.c_decode_id_part1.v_block_size
+		v_z = self.c_decode_id_part1.v_z
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_flags = t_0
+	}
+	if r == 0 {
+		if (v_flags & 64) != 0 {
+			self.f_interlace = 4
+		} else {
+			self.f_interlace = 0
+		}
+		v_use_local_palette = ((v_flags & 128) != 0)
+	}
+	if r == 0 || r == 2 {
+		if r == 2 || (r == 0 && v_use_local_palette) {
+			if r == 0 {
+				v_num_palette_entries = (uint32(1) << (1 + (v_flags & 7)))
+				v_i = 0
+			}
+			if r == 0 || r == 2 {
+				for r != 0 || (v_i < v_num_palette_entries) {
+					if r == 0 || r == 2 {
+						if r == 0 {
+							self.c_decode_id_part1.scratch = 0
+						}
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if t_1, status = a_src.ReadU24BE(&self.c_decode_id_part1.scratch); status != nil {
+							goto suspend
+						}
+						v_argb = (t_1 | 4278190080)
+					}
+					if r == 0 {
+						self.f_palettes[1][((4 * v_i) + 0)] = uint8(((v_argb >> 0) & 255))
+						self.f_palettes[1][((4 * v_i) + 1)] = uint8(((v_argb >> 8) & 255))
+						self.f_palettes[1][((4 * v_i) + 2)] = uint8(((v_argb >> 16) & 255))
+						self.f_palettes[1][((4 * v_i) + 3)] = uint8(((v_argb >> 24) & 255))
+						v_i += 1
+					}
+				}
+			}
+			if r == 0 {
+				for v_i < 256 {
+					self.f_palettes[1][((4 * v_i) + 0)] = 0
+					self.f_palettes[1][((4 * v_i) + 1)] = 0
+					self.f_palettes[1][((4 * v_i) + 2)] = 0
+					self.f_palettes[1][((4 * v_i) + 3)] = 0
+					v_i += 1
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if self.f_previous_lzw_decode_ended_abruptly {
+			self.f_lzw = lzw.Decoder{}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_lw = t_2
+	}
+	if r == 0 {
+		if (v_lw < 2) || (8 < v_lw) {
+			status = ErrBadLiteralWidth
+			goto exit
+		}
+		self.f_lzw.SetLiteralWidth(uint32(v_lw))
+		self.f_previous_lzw_decode_ended_abruptly = true
+	}
+	if r == 0 || (4 <= r && r <= 6) {
+	label_0:
+		for {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if t_3, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_block_size = uint64(t_3)
+			}
+			if r == 0 {
+				if v_block_size == 0 {
+					break label_0
+				}
+				self.f_previous_lzw_decode_ended_abruptly = true
+			}
+			if r == 0 || (5 <= r && r <= 6) {
+			label_1:
+				for {
+					if r == 0 {
+						v_w = base.IOWriter{}
+						{
+							o_0_a_src := a_src
+							o_0_v_w := v_w
+							v_w.Set(&u_w, self.f_uncompressed[self.f_uncompressed_wi:])
+							a_src.SetLimit(v_block_size)
+							a_src.SetMark()
+							t_4 = self.f_lzw.Decode(v_w, a_src)
+							v_z = t_4
+							self.f_uncompressed_wi = (4096 - uint32(base.U64Min(v_w.Available(), 4096)))
+							v_block_size = base.U64SatSub(v_block_size, uint64(len(a_src.SinceMark())))
+							v_w = o_0_v_w
+							a_src = o_0_a_src
+						}
+					}
+					if r == 0 || r == 5 {
+						if r == 5 || (r == 0 && ((v_z == nil) || (v_z == base.SuspensionShortWrite))) {
+							if r == 0 || r == 5 {
+								if r == 5 {
+									r = 0
+								}
+								csp = 5
+								if status = self.copyToImageBuffer(a_dst); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								if v_z == base.SuspensionShortWrite {
+									continue label_1
+								}
+								self.f_previous_lzw_decode_ended_abruptly = false
+								break label_1
+							}
+						}
+					}
+					if r == 0 {
+						if (v_block_size == 0) && (v_z == base.SuspensionShortRead) {
+							break label_1
+						}
+					}
+					if r == 0 || r == 6 {
+						status = v_z
+						if r == 6 {
+							r = 0
+							status = nil
+						} else {
+							if base.IsError(status) {
+								goto exit
+							}
+							if status != nil {
+								csp = 6
+								goto suspend
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 7 {
+		if r == 7 || (r == 0 && (self.f_uncompressed_ri != self.f_uncompressed_wi)) {
+			if r == 0 || r == 7 {
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if status = self.copyToImageBuffer(a_dst); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_palette = nil
+		if v_use_local_palette {
+			v_palette = self.f_palettes[1][:]
+		} else if !self.f_previous_use_global_palette {
+			v_palette = self.f_palettes[0][:]
+		}
+		a_dst.Update(self.f_frame_rect, self.f_gc_duration, true, self.f_gc_disposal, v_palette)
+		self.f_previous_use_global_palette = !v_use_local_palette
+		self.f_seen_graphic_control = false
+		self.f_gc_has_transparent_index = false
+		self.f_gc_transparent_index = 0
+		self.f_gc_disposal = 0
+		self.f_gc_duration = 0
+	}
+
+	self.c_decode_id_part1.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_id_part1.coroSuspPoint = csp
+	self.c_decode_id_part1.v_flags = v_flags
+	self.c_decode_id_part1.v_use_local_palette = v_use_local_palette
+	self.c_decode_id_part1.v_num_palette_entries = v_num_palette_entries
+	self.c_decode_id_part1.v_i = v_i
+	self.c_decode_id_part1.v_argb = v_argb
+	self.c_decode_id_part1.v_lw = v_lw
+	self.c_decode_id_part1.v_block_size = v_block_size
+	self.c_decode_id_part1.v_z = v_z
+
+exit:
+	return status
+}
+
+// -------- func decoder.copy_to_image_buffer
+
+func (self *Decoder) copyToImageBuffer(a_ib *base.ImageBuffer) (status error) {
+
+	var (
+		v_dst    []byte
+		v_src    []byte
+		v_n      uint32
+		v_new_ri uint32
+		v_tab    base.TableU8
+	)
+
+	v_dst = nil
+	v_src = nil
+	v_n = 0
+	v_new_ri = 0
+	v_tab = a_ib.Plane(0)
+label_0:
+	for self.f_uncompressed_wi > self.f_uncompressed_ri {
+		v_src = self.f_uncompressed[self.f_uncompressed_ri:self.f_uncompressed_wi]
+		if self.f_dst_y >= self.f_dst_y1 {
+			status = ErrTooMuchPixelData
+			goto exit
+		}
+		v_dst = v_tab.Row(self.f_dst_y)
+		if uint64(self.f_dst_x) < uint64(len(v_dst)) {
+			if (uint64(self.f_dst_x) <= uint64(self.f_dst_x1)) && (uint64(self.f_dst_x1) <= uint64(len(v_dst))) {
+				v_dst = v_dst[uint64(self.f_dst_x):uint64(self.f_dst_x1)]
+			} else {
+				v_dst = v_dst[uint64(self.f_dst_x):]
+			}
+			v_n = uint32((uint64(copy(v_dst, v_src)) & 4294967295))
+			v_new_ri = base.U32SatAdd(self.f_uncompressed_ri, v_n)
+			self.f_uncompressed_ri = base.U32Min(v_new_ri, 4096)
+			self.f_dst_x = base.U32SatAdd(self.f_dst_x, v_n)
+		}
+		if self.f_dst_x1 <= self.f_dst_x {
+			self.f_dst_x = self.f_dst_x0
+			self.advanceDstY()
+			continue label_0
+		}
+		if self.f_uncompressed_wi == self.f_uncompressed_ri {
+			break label_0
+		} else if self.f_uncompressed_wi < self.f_uncompressed_ri {
+			status = errInternalErrorInconsistentRiWi
+			goto exit
+		}
+		v_n = (self.f_dst_x1 - self.f_dst_x)
+		v_n = base.U32Min(v_n, (self.f_uncompressed_wi - self.f_uncompressed_ri))
+		v_new_ri = base.U32SatAdd(self.f_uncompressed_ri, v_n)
+		self.f_uncompressed_ri = base.U32Min(v_new_ri, 4096)
+		self.f_dst_x = base.U32SatAdd(self.f_dst_x, v_n)
+		if self.f_dst_x1 <= self.f_dst_x {
+			self.f_dst_x = self.f_dst_x0
+			self.advanceDstY()
+			continue label_0
+		}
+		if self.f_uncompressed_ri != self.f_uncompressed_wi {
+			status = errInternalErrorInconsistentRiWi
+			goto exit
+		}
+		break label_0
+	}
+	self.f_uncompressed_ri = 0
+	self.f_uncompressed_wi = 0
+exit:
+	return status
+}
+
+// -------- func decoder.advance_dst_y
+
+func (self *Decoder) advanceDstY() {
+
+	self.f_dst_y += uint32(interlace_delta[self.f_interlace])
+	for (self.f_interlace > 1) && (self.f_dst_y >= self.f_dst_y1) {
+		self.f_interlace -= 1
+		self.f_dst_y = (self.f_dst_y0 + interlace_start[self.f_interlace])
+	}
+}
+
+// -------- func encoder.encode_config
+
+func (self *Encoder) EncodeConfig(a_dst base.IOWriter, a_src *base.ImageConfig) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_src == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_dst.Derive()
+
+	var (
+		v_width  uint32
+		v_height uint32
+	)
+
+	r := self.c_encode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_width = self.c_encode_config.v_width
+		v_height = self.c_encode_config.v_height
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+		v_width = a_src.Width()
+		v_height = a_src.Height()
+		if (v_width > 65535) || (v_height > 65535) {
+			status = ErrUnsupportedImageDimensions
+			goto exit
+		}
+		self.f_width = v_width
+		self.f_height = v_height
+		self.f_num_loops = a_src.NumLoops()
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode_config.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU48BE(&self.c_encode_config.scratch, 78380036274529); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_call_sequence = 1
+	}
+
+	self.c_encode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_config.coroSuspPoint = csp
+	self.c_encode_config.v_width = v_width
+	self.c_encode_config.v_height = v_height
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.encode_frame
+
+func (self *Encoder) EncodeFrame(a_dst base.IOWriter, a_src *base.ImageBuffer) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_src == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_dst.Derive()
+
+	var (
+		v_tab base.TableU8
+	)
+
+	r := self.c_encode_frame.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		if (self.f_call_sequence == 0) || (self.f_call_sequence >= 3) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+		v_tab = a_src.Plane(0)
+		if uint64(v_tab.Width) != uint64(self.f_width) {
+			status = ErrUnsupportedPixelFormat
+			goto exit
+		}
+		self.prepareFrame(a_src)
+	}
+	if r == 0 || (1 <= r && r <= 2) {
+		if (1 <= r && r <= 2) || (r == 0 && (self.f_call_sequence == 1)) {
+			if r == 0 {
+				self.f_global_palette_bits = self.f_palette_bits
+				copy(self.f_palettes[0][:], self.f_palettes[1][:])
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.encodeLsd(a_dst); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 || (r == 0 && (self.f_num_loops != 1)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.encodeAe(a_dst); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 {
+				self.f_call_sequence = 2
+			}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.encodeGc(a_dst, a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.encodeId(a_dst); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.encodePixels(a_dst, a_src); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_encode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_frame.coroSuspPoint = csp
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.encode_trailer
+
+func (self *Encoder) EncodeTrailer(a_dst base.IOWriter) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+
+	r := self.c_encode_trailer.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		if (self.f_call_sequence == 0) || (self.f_call_sequence >= 3) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 || (r == 0 && (self.f_call_sequence == 1)) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.encodeLsd(a_dst); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = a_dst.WriteU8(59); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_call_sequence = 3
+	}
+
+	self.c_encode_trailer.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_trailer.coroSuspPoint = csp
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.prepare_frame
+
+func (self *Encoder) prepareFrame(a_src *base.ImageBuffer) {
+
+	var (
+		v_tab       base.TableU8
+		v_max_index uint8
+		v_row       []byte
+		v_y         uint32
+		v_bits      uint32
+	)
+
+	v_tab = a_src.Plane(0)
+	self.f_frame_x1 = base.U32Min(a_src.DirtyRect().MaxExclusiveX, self.f_width)
+	self.f_frame_y1 = base.U32Min(a_src.DirtyRect().MaxExclusiveY, self.f_height)
+	self.f_frame_y1 = base.U32Min(self.f_frame_y1, uint32(base.U64Min(uint64(v_tab.Height), 65535)))
+	self.f_frame_x0 = base.U32Min(a_src.DirtyRect().MinInclusiveX, self.f_frame_x1)
+	self.f_frame_y0 = base.U32Min(a_src.DirtyRect().MinInclusiveY, self.f_frame_y1)
+	self.f_src_x = self.f_frame_x0
+	self.f_src_y = self.f_frame_y0
+	v_max_index = 0
+	v_row = nil
+	v_y = self.f_frame_y0
+	for v_y < self.f_frame_y1 {
+		v_row = v_tab.Row(v_y)
+		if (uint64(self.f_frame_x0) <= uint64(self.f_frame_x1)) && (uint64(self.f_frame_x1) <= uint64(len(v_row))) {
+			v_row = v_row[uint64(self.f_frame_x0):uint64(self.f_frame_x1)]
+		}
+		{
+			i_slice_p := v_row
+			var v_p []byte
+			i_p := 0
+			i_end0_p := (len(i_slice_p) / 1) * 1
+			for i_p < i_end0_p {
+				v_p = i_slice_p[i_p : i_p+1]
+				v_max_index = base.U8Max(v_max_index, v_p[0])
+				i_p += 1
+			}
+		}
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+	v_bits = 1
+	for (v_bits < 8) && ((uint32(1) << v_bits) <= uint32(v_max_index)) {
+		v_bits += 1
+	}
+	self.f_palette_bits = v_bits
+	copy(self.f_palettes[1][:], a_src.Palette())
+}
+
+// -------- func encoder.encode_lsd
+
+func (self *Encoder) encodeLsd(a_dst base.IOWriter) (status error) {
+
+	r := self.c_encode_lsd.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode_lsd.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU16LE(&self.c_encode_lsd.scratch, uint16(self.f_width)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_encode_lsd.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = a_dst.WriteU16LE(&self.c_encode_lsd.scratch, uint16(self.f_height)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 || (r == 0 && (self.f_global_palette_bits == 0)) {
+			if r == 0 || r == 3 {
+				if r == 0 {
+					self.c_encode_lsd.scratch = 0
+				}
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = a_dst.WriteU24BE(&self.c_encode_lsd.scratch, 0); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				status = nil
+				goto ok
+			}
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = a_dst.WriteU8((240 | uint8(((self.f_global_palette_bits - 1) & 7)))); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_encode_lsd.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = a_dst.WriteU16BE(&self.c_encode_lsd.scratch, 0); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = self.encodePalette(a_dst); status != nil {
+			goto suspend
+		}
+	}
+
+ok:
+	self.c_encode_lsd.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_lsd.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func encoder.encode_palette
+
+func (self *Encoder) encodePalette(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_num_palette_entries uint32
+		v_i                   uint32
+	)
+
+	r := self.c_encode_palette.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_palette_entries = self.c_encode_palette.v_num_palette_entries
+		v_i = self.c_encode_palette.v_i
+	}
+
+	if r == 0 {
+		v_num_palette_entries = (uint32(1) << self.f_palette_bits)
+		v_i = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+		for r != 0 || (v_i < v_num_palette_entries) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = a_dst.WriteU8(self.f_palettes[1][((4 * v_i) + 2)]); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = a_dst.WriteU8(self.f_palettes[1][((4 * v_i) + 1)]); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = a_dst.WriteU8(self.f_palettes[1][((4 * v_i) + 0)]); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+
+	self.c_encode_palette.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_palette.coroSuspPoint = csp
+	self.c_encode_palette.v_num_palette_entries = v_num_palette_entries
+	self.c_encode_palette.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func encoder.encode_ae
+
+func (self *Encoder) encodeAe(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_num_loops uint32
+		v_i         uint32
+	)
+
+	r := self.c_encode_ae.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_loops = self.c_encode_ae.v_num_loops
+		v_i = self.c_encode_ae.v_i
+	}
+
+	if r == 0 {
+		v_num_loops = self.f_num_loops
+		if v_num_loops > 0 {
+			v_num_loops -= 1
+			v_num_loops = base.U32Min(v_num_loops, 65535)
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode_ae.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU24BE(&self.c_encode_ae.scratch, 2227979); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_i = 0
+	}
+	if r == 0 || r == 2 {
+		for r != 0 || (v_i < 11) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = a_dst.WriteU8(netscape2dot0[v_i]); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_encode_ae.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = a_dst.WriteU16BE(&self.c_encode_ae.scratch, 769); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_encode_ae.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = a_dst.WriteU16LE(&self.c_encode_ae.scratch, uint16((v_num_loops & 65535))); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = a_dst.WriteU8(0); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_encode_ae.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_ae.coroSuspPoint = csp
+	self.c_encode_ae.v_num_loops = v_num_loops
+	self.c_encode_ae.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func encoder.encode_gc
+
+func (self *Encoder) encodeGc(a_dst base.IOWriter, a_src *base.ImageBuffer) (status error) {
+
+	var (
+		v_flags               uint8
+		v_disposal            uint8
+		v_transparent_index   uint8
+		v_num_palette_entries uint32
+		v_i                   uint32
+		v_centiseconds        uint64
+		v_duration            uint64
+	)
+
+	r := self.c_encode_gc.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_flags = self.c_encode_gc.v_flags
+		v_disposal = self.c_encode_gc.v_disposal
+		v_transparent_index = self.c_encode_gc.v_transparent_index
+		v_num_palette_entries = self.c_encode_gc.v_num_palette_entries
+		v_i = self.c_encode_gc.v_i
+		v_centiseconds = self.c_encode_gc.v_centiseconds
+		v_duration = self.c_encode_gc.v_duration
+	}
+
+	if r == 0 {
+		v_flags = 0
+		v_disposal = a_src.Disposal()
+		if v_disposal == 1 {
+			v_flags = 8
+		} else if v_disposal == 2 {
+			v_flags = 12
+		}
+		v_transparent_index = 0
+		v_num_palette_entries = (uint32(1) << self.f_palette_bits)
+		v_i = 0
+	label_0:
+		for v_i < v_num_palette_entries {
+			if self.f_palettes[1][((4*v_i)+3)] == 0 {
+				v_flags |= 1
+				v_transparent_index = uint8((v_i & 255))
+				break label_0
+			}
+			v_i += 1
+		}
+		v_centiseconds = (base.U64SatAdd(a_src.Duration(), 3528000) / 7056000)
+		v_duration = base.U64Min(v_centiseconds, 65535)
+		if (v_flags == 0) && (v_duration == 0) {
+			status = nil
+			goto ok
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode_gc.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU24BE(&self.c_encode_gc.scratch, 2226436); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = a_dst.WriteU8(v_flags); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_encode_gc.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = a_dst.WriteU16LE(&self.c_encode_gc.scratch, uint16(v_duration)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = a_dst.WriteU8(v_transparent_index); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = a_dst.WriteU8(0); status != nil {
+			goto suspend
+		}
+	}
+
+ok:
+	self.c_encode_gc.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_gc.coroSuspPoint = csp
+	self.c_encode_gc.v_flags = v_flags
+	self.c_encode_gc.v_disposal = v_disposal
+	self.c_encode_gc.v_transparent_index = v_transparent_index
+	self.c_encode_gc.v_num_palette_entries = v_num_palette_entries
+	self.c_encode_gc.v_i = v_i
+	self.c_encode_gc.v_centiseconds = v_centiseconds
+	self.c_encode_gc.v_duration = v_duration
+
+exit:
+	return status
+}
+
+// -------- func encoder.encode_id
+
+func (self *Encoder) encodeId(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_use_global_palette  bool
+		v_num_palette_entries uint32
+		v_i                   uint32
+	)
+
+	r := self.c_encode_id.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_use_global_palette = self.c_encode_id.v_use_global_palette
+		v_num_palette_entries = self.c_encode_id.v_num_palette_entries
+		v_i = self.c_encode_id.v_i
+	}
+
+	if r == 0 {
+		v_use_global_palette = (self.f_palette_bits <= self.f_global_palette_bits)
+		if v_use_global_palette {
+			v_num_palette_entries = (uint32(1) << self.f_palette_bits)
+			v_i = 0
+		label_0:
+			for v_i < v_num_palette_entries {
+				if (self.f_palettes[0][((4*v_i)+0)] != self.f_palettes[1][((4*v_i)+0)]) || (self.f_palettes[0][((4*v_i)+1)] != self.f_palettes[1][((4*v_i)+1)]) || (self.f_palettes[0][((4*v_i)+2)] != self.f_palettes[1][((4*v_i)+2)]) {
+					v_use_global_palette = false
+					break label_0
+				}
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU8(44); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_encode_id.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = a_dst.WriteU16LE(&self.c_encode_id.scratch, uint16(self.f_frame_x0)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_encode_id.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = a_dst.WriteU16LE(&self.c_encode_id.scratch, uint16(self.f_frame_y0)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_encode_id.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = a_dst.WriteU16LE(&self.c_encode_id.scratch, uint16(((self.f_frame_x1 - self.f_frame_x0) & 65535))); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_encode_id.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = a_dst.WriteU16LE(&self.c_encode_id.scratch, uint16(((self.f_frame_y1 - self.f_frame_y0) & 65535))); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || (6 <= r && r <= 8) {
+		if r == 6 || (r == 0 && v_use_global_palette) {
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = a_dst.WriteU8(0); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 7 {
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if status = a_dst.WriteU8((128 | uint8(((self.f_palette_bits - 1) & 7)))); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 8 {
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if status = self.encodePalette(a_dst); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+	self.c_encode_id.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_id.coroSuspPoint = csp
+	self.c_encode_id.v_use_global_palette = v_use_global_palette
+	self.c_encode_id.v_num_palette_entries = v_num_palette_entries
+	self.c_encode_id.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func encoder.encode_pixels
+
+func (self *Encoder) encodePixels(a_dst base.IOWriter, a_src *base.ImageBuffer) (status error) {
+
+	var (
+		v_tab      base.TableU8
+		v_row      []byte
+		v_last_row bool
+		v_r        base.IOReader
+		u_r        base.IOBuffer
+		v_w        base.IOWriter
+		u_w        base.IOBuffer
+		v_z        error
+		t_0        error
+	)
+
+	r := self.c_encode_pixels.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_last_row = self.c_encode_pixels.v_last_row
+		v_z = self.c_encode_pixels.v_z
+	}
+
+	if r == 0 || (1 <= r && r <= 2) {
+		if r == 1 || (r == 0 && (self.f_palette_bits > 2)) {
+			if r == 0 {
+				self.f_lzw.SetLiteralWidth(self.f_palette_bits)
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = a_dst.WriteU8(uint8(self.f_palette_bits)); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 {
+				self.f_lzw.SetLiteralWidth(2)
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = a_dst.WriteU8(2); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 || (3 <= r && r <= 4) {
+	label_0:
+		for {
+			if r == 0 {
+				v_tab = a_src.Plane(0)
+				v_row = nil
+				if self.f_src_y < self.f_frame_y1 {
+					v_row = v_tab.Row(self.f_src_y)
+					if (uint64(self.f_src_x) <= uint64(self.f_frame_x1)) && (uint64(self.f_frame_x1) <= uint64(len(v_row))) {
+						v_row = v_row[uint64(self.f_src_x):uint64(self.f_frame_x1)]
+					} else {
+						v_row = v_row[:0]
+					}
+				}
+				v_last_row = (self.f_frame_y1 <= base.U32SatAdd(self.f_src_y, 1))
+				v_r = base.IOReader{}
+				v_w = base.IOWriter{}
+				{
+					o_0_v_r := v_r
+					o_0_v_w := v_w
+					v_r.Set(&u_r, v_row, v_last_row)
+					v_w.Set(&u_w, self.f_block[self.f_block_wi:])
+					t_0 = self.f_lzw.Encode(v_w, v_r)
+					v_z = t_0
+					self.f_src_x = base.U32SatAdd(self.f_src_x, uint32((base.U64SatSub(uint64(len(v_row)), v_r.Available()) & 4294967295)))
+					self.f_block_wi = (255 - uint32(base.U64Min(v_w.Available(), 255)))
+					v_w = o_0_v_w
+					v_r = o_0_v_r
+				}
+			}
+			if r == 0 || (3 <= r && r <= 4) {
+				if r == 0 && (v_z == nil) {
+					break label_0
+				} else if r == 0 && (v_z == base.SuspensionShortRead) {
+					self.f_src_x = self.f_frame_x0
+					self.f_src_y = base.U32SatAdd(self.f_src_y, 1)
+				} else if r == 3 || (r == 0 && (v_z == base.SuspensionShortWrite)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.writeBlock(a_dst); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 || r == 4 {
+						status = v_z
+						if r == 4 {
+							r = 0
+							status = nil
+						} else {
+							if base.IsError(status) {
+								goto exit
+							}
+							if status != nil {
+								csp = 4
+								goto suspend
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 || (r == 0 && (self.f_block_wi > 0)) {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.writeBlock(a_dst); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = a_dst.WriteU8(0); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_encode_pixels.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode_pixels.coroSuspPoint = csp
+	self.c_encode_pixels.v_last_row = v_last_row
+	self.c_encode_pixels.v_z = v_z
+
+exit:
+	return status
+}
+
+// -------- func encoder.write_block
+
+func (self *Encoder) writeBlock(a_dst base.IOWriter) (status error) {
+
+	var (
+		v_n      uint64
+		v_new_ri uint32
+	)
+
+	r := self.c_write_block.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_n = self.c_write_block.v_n
+		v_new_ri = self.c_write_block.v_new_ri
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU8(uint8(self.f_block_wi)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		for r != 0 || (self.f_block_ri < self.f_block_wi) {
+			if r == 0 {
+				v_n = a_dst.CopyFromSlice(self.f_block[self.f_block_ri:self.f_block_wi])
+				v_new_ri = base.U32SatAdd(self.f_block_ri, uint32(base.U64Min(v_n, 255)))
+				self.f_block_ri = base.U32Min(v_new_ri, 255)
+			}
+			if r == 0 || r == 2 {
+				if r == 2 || (r == 0 && (self.f_block_ri < self.f_block_wi)) {
+					if r == 0 || r == 2 {
+						status = base.SuspensionShortWrite
+						if r == 2 {
+							r = 0
+							status = nil
+						} else {
+							csp = 2
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_block_ri = 0
+		self.f_block_wi = 0
+	}
+
+	self.c_write_block.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_block.coroSuspPoint = csp
+	self.c_write_block.v_n = v_n
+	self.c_write_block.v_new_ri = v_new_ri
+
+exit:
+	return status
+}
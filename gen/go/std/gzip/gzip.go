@@ -0,0 +1,885 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package gzip
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/crc32"
+	"github.com/google/wuffs/gen/go/std/deflate"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadChecksum          = base.NewError("gzip: bad checksum")
+	ErrBadCompressionMethod = base.NewError("gzip: bad compression method")
+	ErrBadEncodingFlags     = base.NewError("gzip: bad encoding flags")
+	ErrBadHeader            = base.NewError("gzip: bad header")
+	ErrBadHeaderChecksum    = base.NewError("gzip: bad header checksum")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a gzip.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_flate           deflate.Decoder
+	f_checksum        crc32.IeeeHasher
+	f_ignore_checksum bool
+	f_multi_member    bool
+	f_mtime           uint32
+	f_os              uint8
+	f_fname           [255]uint8
+	f_fname_length    uint32
+	f_fcomment        [1024]uint8
+	f_fcomment_length uint32
+	f_header_hasher   crc32.IeeeHasher
+	f_header_checksum uint32
+	f_hash_header     bool
+	f_hbuf            [4]uint8
+
+	c_decode struct {
+		coroSuspPoint uint32
+		v_z           error
+	}
+
+	c_peek_u8 struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_decode_member struct {
+		coroSuspPoint          uint32
+		v_flags                uint8
+		v_xfl                  uint8
+		v_c                    uint8
+		v_truncated            bool
+		v_xlen                 uint16
+		v_header_checksum_want uint16
+		v_checksum_got         uint32
+		v_decoded_length_got   uint32
+		v_z                    error
+		v_checksum_want        uint32
+		v_decoded_length_want  uint32
+		scratch                uint64
+	}
+}
+
+// Encoder holds the state of a gzip.encoder. Its zero value is ready to use.
+type Encoder struct {
+	status error
+
+	f_flate     deflate.Encoder
+	f_checksum  crc32.IeeeHasher
+	f_level     uint32
+	f_level_set bool
+
+	c_encode struct {
+		coroSuspPoint uint32
+		v_xfl         uint8
+		v_checksum    uint32
+		v_isize       uint32
+		v_z           error
+		scratch       uint64
+	}
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+func (self *Decoder) SetIgnoreChecksum(a_ic bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_ignore_checksum = a_ic
+}
+
+// -------- func decoder.set_multi_member
+
+func (self *Decoder) SetMultiMember(a_mm bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_multi_member = a_mm
+}
+
+// -------- func decoder.modification_time
+
+func (self *Decoder) ModificationTime() uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_mtime
+}
+
+// -------- func decoder.operating_system
+
+func (self *Decoder) OperatingSystem() uint8 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_os
+}
+
+// -------- func decoder.file_name
+
+func (self *Decoder) FileName() []byte {
+	if base.IsError(self.status) {
+		return nil
+	}
+
+	return self.f_fname[:self.f_fname_length]
+}
+
+// -------- func decoder.comment
+
+func (self *Decoder) Comment() []byte {
+	if base.IsError(self.status) {
+		return nil
+	}
+
+	return self.f_fcomment[:self.f_fcomment_length]
+}
+
+// -------- func decoder.decode
+
+func (self *Decoder) Decode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_z error
+		t_0 error
+	)
+
+	r := self.c_decode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_z = self.c_decode.v_z
+	}
+
+	if r == 0 || (1 <= r && r <= 2) {
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.decodeMember(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if !self.f_multi_member {
+					status = nil
+					goto ok
+				}
+			}
+			if r == 0 || r == 2 {
+			label_0:
+				for {
+					if r == 0 {
+						t_0 = self.peekU8(a_src)
+						v_z = t_0
+						if v_z == nil {
+							break label_0
+						} else if base.IsError(v_z) {
+							status = nil
+							goto ok
+						}
+					}
+					if r == 0 || r == 2 {
+						status = v_z
+						if r == 2 {
+							r = 0
+							status = nil
+						} else {
+							if base.IsError(status) {
+								goto exit
+							}
+							if status != nil {
+								csp = 2
+								goto suspend
+							}
+						}
+					}
+				}
+			}
+			if r == 0 {
+				self.f_flate = deflate.Decoder{}
+				self.f_checksum = crc32.IeeeHasher{}
+			}
+		}
+	}
+
+ok:
+	self.c_decode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode.coroSuspPoint = csp
+	self.c_decode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.peek_u8
+
+func (self *Decoder) peekU8(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		t_0 uint8
+	)
+
+	r := self.c_peek_u8.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_peek_u8.v_c
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_c = t_0
+	}
+	if r == 0 {
+		if status = a_src.UnreadU8(); status != nil {
+			goto exit
+		}
+	}
+
+	self.c_peek_u8.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_peek_u8.coroSuspPoint = csp
+	self.c_peek_u8.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_member
+
+func (self *Decoder) decodeMember(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+
+	var (
+		v_flags                uint8
+		v_xfl                  uint8
+		v_c                    uint8
+		v_truncated            bool
+		v_xlen                 uint16
+		v_header_checksum_want uint16
+		v_checksum_got         uint32
+		v_decoded_length_got   uint32
+		v_z                    error
+		v_checksum_want        uint32
+		v_decoded_length_want  uint32
+		t_0                    uint8
+		t_1                    uint8
+		t_2                    uint8
+		t_3                    uint8
+		t_4                    uint32
+		t_5                    uint8
+		t_6                    uint8
+		t_7                    uint16
+		t_8                    uint8
+		t_9                    uint8
+		t_10                   uint8
+		t_11                   uint16
+		t_12                   error
+		t_13                   uint32
+		t_14                   uint32
+	)
+
+	r := self.c_decode_member.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_flags = self.c_decode_member.v_flags
+		v_xfl = self.c_decode_member.v_xfl
+		v_c = self.c_decode_member.v_c
+		v_truncated = self.c_decode_member.v_truncated
+		v_xlen = self.c_decode_member.v_xlen
+		v_header_checksum_want = self.c_decode_member.v_header_checksum_want
+		v_checksum_got = self.c_decode_member.v_checksum_got
+		v_decoded_length_got = self.c_decode_member.v_decoded_length_got
+		v_z = self.c_decode_member.v_z
+		v_checksum_want = self.c_decode_member.v_checksum_want
+		v_decoded_length_want = self.c_decode_member.v_decoded_length_want
+	}
+
+	if r == 0 {
+		self.f_mtime = 0
+		self.f_os = 0
+		self.f_fname_length = 0
+		self.f_fcomment_length = 0
+		self.f_header_checksum = 0
+		self.f_header_hasher = crc32.IeeeHasher{}
+		self.f_hash_header = false
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 31) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 || r == 2 {
+			if r == 2 {
+				r = 0
+			}
+			csp = 2
+			if t_1, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_1 != 139) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 || r == 3 {
+			if r == 3 {
+				r = 0
+			}
+			csp = 3
+			if t_2, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_2 != 8) {
+			status = ErrBadCompressionMethod
+			goto exit
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_3, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_flags = t_3
+	}
+	if r == 0 {
+		if (v_flags & 224) != 0 {
+			status = ErrBadEncodingFlags
+			goto exit
+		}
+		self.f_hash_header = ((v_flags & 2) != 0)
+		self.hash(559903, 3)
+		self.hash(uint32(v_flags), 1)
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_decode_member.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_4, status = a_src.ReadU32LE(&self.c_decode_member.scratch); status != nil {
+			goto suspend
+		}
+		self.f_mtime = t_4
+	}
+	if r == 0 {
+		self.hash(self.f_mtime, 4)
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if t_5, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_xfl = t_5
+	}
+	if r == 0 {
+		self.hash(uint32(v_xfl), 1)
+	}
+	if r == 0 || r == 7 {
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if t_6, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		self.f_os = t_6
+	}
+	if r == 0 {
+		self.hash(uint32(self.f_os), 1)
+		v_c = 0
+		v_truncated = false
+	}
+	if r == 0 || (8 <= r && r <= 10) {
+		if (8 <= r && r <= 10) || (r == 0 && ((v_flags & 4) != 0)) {
+			if r == 0 || r == 8 {
+				if r == 0 {
+					self.c_decode_member.scratch = 0
+				}
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_7, status = a_src.ReadU16LE(&self.c_decode_member.scratch); status != nil {
+					goto suspend
+				}
+				v_xlen = t_7
+			}
+			if r == 0 {
+				self.hash(uint32(v_xlen), 2)
+			}
+			if r == 0 || (9 <= r && r <= 10) {
+				if r == 9 || (r == 0 && self.f_hash_header) {
+					if r == 0 || r == 9 {
+						for r != 0 || (v_xlen > 0) {
+							if r == 0 || r == 9 {
+								if r == 9 {
+									r = 0
+								}
+								csp = 9
+								if t_8, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_c = t_8
+							}
+							if r == 0 {
+								self.hash(uint32(v_c), 1)
+								v_xlen -= 1
+							}
+						}
+					}
+				} else {
+					if r == 0 || r == 10 {
+						if r == 0 {
+							self.c_decode_member.scratch = uint64(uint32(v_xlen))
+						}
+						if r == 10 {
+							r = 0
+						}
+						csp = 10
+						if status = a_src.Skip(&self.c_decode_member.scratch); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 11 {
+		if r == 11 || (r == 0 && ((v_flags & 8) != 0)) {
+			if r == 0 || r == 11 {
+			label_0:
+				for {
+					if r == 0 || r == 11 {
+						if r == 11 {
+							r = 0
+						}
+						csp = 11
+						if t_9, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_9
+					}
+					if r == 0 {
+						self.hash(uint32(v_c), 1)
+						if v_c == 0 {
+							break label_0
+						} else if v_truncated {
+							continue label_0
+						} else if v_c < 128 {
+							if self.f_fname_length >= 255 {
+								v_truncated = true
+								continue label_0
+							}
+							self.f_fname[self.f_fname_length] = v_c
+							self.f_fname_length += 1
+						} else {
+							if self.f_fname_length >= 254 {
+								v_truncated = true
+								continue label_0
+							}
+							self.f_fname[self.f_fname_length] = (192 | (v_c >> 6))
+							self.f_fname[(self.f_fname_length + 1)] = (128 | (v_c & 63))
+							self.f_fname_length += 2
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 12 {
+		if r == 12 || (r == 0 && ((v_flags & 16) != 0)) {
+			if r == 0 {
+				v_truncated = false
+			}
+			if r == 0 || r == 12 {
+			label_1:
+				for {
+					if r == 0 || r == 12 {
+						if r == 12 {
+							r = 0
+						}
+						csp = 12
+						if t_10, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_10
+					}
+					if r == 0 {
+						self.hash(uint32(v_c), 1)
+						if v_c == 0 {
+							break label_1
+						} else if v_truncated {
+							continue label_1
+						} else if v_c < 128 {
+							if self.f_fcomment_length >= 1024 {
+								v_truncated = true
+								continue label_1
+							}
+							self.f_fcomment[self.f_fcomment_length] = v_c
+							self.f_fcomment_length += 1
+						} else {
+							if self.f_fcomment_length >= 1023 {
+								v_truncated = true
+								continue label_1
+							}
+							self.f_fcomment[self.f_fcomment_length] = (192 | (v_c >> 6))
+							self.f_fcomment[(self.f_fcomment_length + 1)] = (128 | (v_c & 63))
+							self.f_fcomment_length += 2
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 13 {
+		if r == 13 || (r == 0 && ((v_flags & 2) != 0)) {
+			if r == 0 || r == 13 {
+				if r == 0 {
+					self.c_decode_member.scratch = 0
+				}
+				if r == 13 {
+					r = 0
+				}
+				csp = 13
+				if t_11, status = a_src.ReadU16LE(&self.c_decode_member.scratch); status != nil {
+					goto suspend
+				}
+				v_header_checksum_want = t_11
+			}
+			if r == 0 {
+				if !self.f_ignore_checksum && ((self.f_header_checksum & 65535) != uint32(v_header_checksum_want)) {
+					status = ErrBadHeaderChecksum
+					goto exit
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_checksum_got = 0
+		v_decoded_length_got = 0
+	}
+	if r == 0 || r == 14 {
+	label_2:
+		for {
+			if r == 0 {
+				a_dst.SetMark()
+				t_12 = self.f_flate.Decode(a_dst, a_src)
+				v_z = t_12
+				if !self.f_ignore_checksum {
+					v_checksum_got = self.f_checksum.Update(a_dst.SinceMark())
+					v_decoded_length_got += uint32((uint64(len(a_dst.SinceMark())) & 4294967295))
+				}
+				if v_z == nil {
+					break label_2
+				}
+			}
+			if r == 0 || r == 14 {
+				status = v_z
+				if r == 14 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 14
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 15 {
+		if r == 0 {
+			self.c_decode_member.scratch = 0
+		}
+		if r == 15 {
+			r = 0
+		}
+		csp = 15
+		if t_13, status = a_src.ReadU32LE(&self.c_decode_member.scratch); status != nil {
+			goto suspend
+		}
+		v_checksum_want = t_13
+	}
+	if r == 0 || r == 16 {
+		if r == 0 {
+			self.c_decode_member.scratch = 0
+		}
+		if r == 16 {
+			r = 0
+		}
+		csp = 16
+		if t_14, status = a_src.ReadU32LE(&self.c_decode_member.scratch); status != nil {
+			goto suspend
+		}
+		v_decoded_length_want = t_14
+	}
+	if r == 0 {
+		if !self.f_ignore_checksum && ((v_checksum_got != v_checksum_want) || (v_decoded_length_got != v_decoded_length_want)) {
+			status = ErrBadChecksum
+			goto exit
+		}
+	}
+
+	self.c_decode_member.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_member.coroSuspPoint = csp
+	self.c_decode_member.v_flags = v_flags
+	self.c_decode_member.v_xfl = v_xfl
+	self.c_decode_member.v_c = v_c
+	self.c_decode_member.v_truncated = v_truncated
+	self.c_decode_member.v_xlen = v_xlen
+	self.c_decode_member.v_header_checksum_want = v_header_checksum_want
+	self.c_decode_member.v_checksum_got = v_checksum_got
+	self.c_decode_member.v_decoded_length_got = v_decoded_length_got
+	self.c_decode_member.v_z = v_z
+	self.c_decode_member.v_checksum_want = v_checksum_want
+	self.c_decode_member.v_decoded_length_want = v_decoded_length_want
+
+exit:
+	return status
+}
+
+// -------- func decoder.hash
+
+func (self *Decoder) hash(a_x uint32, a_n uint32) {
+
+	if !self.f_hash_header {
+		return
+	}
+	self.f_hbuf[0] = uint8(((a_x >> 0) & 255))
+	self.f_hbuf[1] = uint8(((a_x >> 8) & 255))
+	self.f_hbuf[2] = uint8(((a_x >> 16) & 255))
+	self.f_hbuf[3] = uint8(((a_x >> 24) & 255))
+	self.f_header_checksum = self.f_header_hasher.Update(self.f_hbuf[:a_n])
+}
+
+// -------- func encoder.set_level
+
+func (self *Encoder) SetLevel(a_level uint32) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_flate.SetLevel(a_level)
+	self.f_level = base.U32Min(a_level, 9)
+	self.f_level_set = true
+}
+
+// -------- func encoder.encode
+
+func (self *Encoder) Encode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_xfl      uint8
+		v_checksum uint32
+		v_isize    uint32
+		v_z        error
+		t_0        error
+	)
+
+	r := self.c_encode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_xfl = self.c_encode.v_xfl
+		v_checksum = self.c_encode.v_checksum
+		v_isize = self.c_encode.v_isize
+		v_z = self.c_encode.v_z
+	}
+
+	if r == 0 {
+		if !self.f_level_set {
+			self.f_level_set = true
+			self.f_level = 6
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU32LE(&self.c_encode.scratch, 559903); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = a_dst.WriteU32LE(&self.c_encode.scratch, 0); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_xfl = 0
+		if self.f_level == 9 {
+			v_xfl = 2
+		} else if self.f_level < 2 {
+			v_xfl = 4
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = a_dst.WriteU8(v_xfl); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = a_dst.WriteU8(255); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_checksum = 0
+		v_isize = 0
+	}
+	if r == 0 || r == 5 {
+	label_0:
+		for {
+			if r == 0 {
+				a_src.SetMark()
+				t_0 = self.f_flate.Encode(a_dst, a_src)
+				v_z = t_0
+				v_checksum = self.f_checksum.Update(a_src.SinceMark())
+				v_isize += uint32((uint64(len(a_src.SinceMark())) & 4294967295))
+				if v_z == nil {
+					break label_0
+				}
+			}
+			if r == 0 || r == 5 {
+				status = v_z
+				if r == 5 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 5
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = a_dst.WriteU32LE(&self.c_encode.scratch, v_checksum); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 7 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if status = a_dst.WriteU32LE(&self.c_encode.scratch, v_isize); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_encode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode.coroSuspPoint = csp
+	self.c_encode.v_xfl = v_xfl
+	self.c_encode.v_checksum = v_checksum
+	self.c_encode.v_isize = v_isize
+	self.c_encode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
@@ -0,0 +1,529 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package ico
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/bmp"
+	"github.com/google/wuffs/gen/go/std/png"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadHeader = base.NewError("ico: bad header")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a ico.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_call_sequence       uint8
+	f_is_cur              bool
+	f_num_sub_images      uint32
+	f_best_width          uint32
+	f_best_height         uint32
+	f_best_bits_per_pixel uint32
+	f_best_offset         uint32
+	f_best_is_png         bool
+	f_best_hotspot_x      uint32
+	f_best_hotspot_y      uint32
+	f_bmp                 bmp.Decoder
+	f_png                 png.Decoder
+
+	c_decode_config struct {
+		coroSuspPoint    uint32
+		v_file_type      uint16
+		v_num_images     uint32
+		v_i              uint32
+		v_width          uint32
+		v_height         uint32
+		v_x              uint32
+		v_y              uint32
+		v_bits_per_pixel uint32
+		v_offset         uint32
+		v_num_read       uint64
+		v_c              uint8
+		scratch          uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+	}
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.is_cursor
+
+func (self *Decoder) IsCursor() bool {
+	if base.IsError(self.status) {
+		return false
+	}
+
+	return self.f_is_cur
+}
+
+// -------- func decoder.hotspot_x
+
+func (self *Decoder) HotspotX() uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_best_hotspot_x
+}
+
+// -------- func decoder.hotspot_y
+
+func (self *Decoder) HotspotY() uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_best_hotspot_y
+}
+
+// -------- func decoder.num_images
+
+func (self *Decoder) NumImages() uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_num_sub_images
+}
+
+// -------- func decoder.workbuf_len
+
+func (self *Decoder) WorkbufLen() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	if self.f_best_is_png {
+		return self.f_png.WorkbufLen()
+	}
+	return 0
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_file_type      uint16
+		v_num_images     uint32
+		v_i              uint32
+		v_width          uint32
+		v_height         uint32
+		v_x              uint32
+		v_y              uint32
+		v_bits_per_pixel uint32
+		v_offset         uint32
+		v_num_read       uint64
+		v_c              uint8
+		t_0              uint16
+		t_1              uint16
+		t_2              uint16
+		t_3              uint8
+		t_4              uint8
+		t_5              uint16
+		t_6              uint16
+		t_7              uint32
+		t_8              uint8
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_file_type = self.c_decode_config.v_file_type
+		v_num_images = self.c_decode_config.v_num_images
+		v_i = self.c_decode_config.v_i
+		v_width = self.c_decode_config.v_width
+		v_height = self.c_decode_config.v_height
+		v_x = self.c_decode_config.v_x
+		v_y = self.c_decode_config.v_y
+		v_bits_per_pixel = self.c_decode_config.v_bits_per_pixel
+		v_offset = self.c_decode_config.v_offset
+		v_num_read = self.c_decode_config.v_num_read
+		v_c = self.c_decode_config.v_c
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 0) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_file_type = t_1
+	}
+	if r == 0 {
+		if (v_file_type != 1) && (v_file_type != 2) {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_is_cur = (v_file_type == 2)
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_num_images = uint32(t_2)
+	}
+	if r == 0 {
+		if v_num_images == 0 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_num_sub_images = v_num_images
+		v_i = 0
+		v_width = 0
+		v_height = 0
+		v_x = 0
+		v_y = 0
+		v_bits_per_pixel = 0
+		v_offset = 0
+	}
+	if r == 0 || (4 <= r && r <= 10) {
+		for r != 0 || (v_i < v_num_images) {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if t_3, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_width = uint32(t_3)
+			}
+			if r == 0 {
+				if v_width == 0 {
+					v_width = 256
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if t_4, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_height = uint32(t_4)
+			}
+			if r == 0 {
+				if v_height == 0 {
+					v_height = 256
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(2)
+				}
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 7 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if t_5, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				v_x = uint32(t_5)
+			}
+			if r == 0 || r == 8 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_6, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				v_y = uint32(t_6)
+			}
+			if r == 0 || r == 9 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(4)
+				}
+				if r == 9 {
+					r = 0
+				}
+				csp = 9
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 10 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 10 {
+					r = 0
+				}
+				csp = 10
+				if t_7, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				v_offset = t_7
+			}
+			if r == 0 {
+				v_bits_per_pixel = v_y
+				if self.f_is_cur {
+					v_bits_per_pixel = 0
+				}
+				if (v_i == 0) || self.isBetter(v_width, v_height, v_bits_per_pixel) {
+					self.f_best_width = v_width
+					self.f_best_height = v_height
+					self.f_best_bits_per_pixel = v_bits_per_pixel
+					self.f_best_offset = v_offset
+					if self.f_is_cur {
+						self.f_best_hotspot_x = v_x
+						self.f_best_hotspot_y = v_y
+					}
+				}
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		v_num_read = (6 + (16 * uint64(v_num_images)))
+		if uint64(self.f_best_offset) < v_num_read {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 11 {
+		if r == 0 {
+			self.c_decode_config.scratch = uint64((uint64(self.f_best_offset) - v_num_read))
+		}
+		if r == 11 {
+			r = 0
+		}
+		csp = 11
+		if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 12 {
+		if r == 12 {
+			r = 0
+		}
+		csp = 12
+		if t_8, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_c = t_8
+	}
+	if r == 0 {
+		if status = a_src.UnreadU8(); status != nil {
+			goto exit
+		}
+		self.f_best_is_png = (v_c == 137)
+	}
+	if r == 0 || (13 <= r && r <= 14) {
+		if r == 13 || (r == 0 && self.f_best_is_png) {
+			if r == 0 || r == 13 {
+				if r == 13 {
+					r = 0
+				}
+				csp = 13
+				if status = self.f_png.DecodeConfig(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 {
+				self.f_bmp.SetIcoMode(true)
+			}
+			if r == 0 || r == 14 {
+				if r == 14 {
+					r = 0
+				}
+				csp = 14
+				if status = self.f_bmp.DecodeConfig(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_file_type = v_file_type
+	self.c_decode_config.v_num_images = v_num_images
+	self.c_decode_config.v_i = v_i
+	self.c_decode_config.v_width = v_width
+	self.c_decode_config.v_height = v_height
+	self.c_decode_config.v_x = v_x
+	self.c_decode_config.v_y = v_y
+	self.c_decode_config.v_bits_per_pixel = v_bits_per_pixel
+	self.c_decode_config.v_offset = v_offset
+	self.c_decode_config.v_num_read = v_num_read
+	self.c_decode_config.v_c = v_c
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || r == 1 {
+		if r == 0 && (self.f_call_sequence == 0) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		} else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+			if r == 0 || r == 1 {
+				for {
+					if r == 0 || r == 1 {
+						status = base.SuspensionEndOfData
+						if r == 1 {
+							r = 0
+							status = nil
+						} else {
+							csp = 1
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		if r == 2 || (r == 0 && self.f_best_is_png) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.f_png.DecodeFrame(a_dst, a_src, a_workbuf); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.f_bmp.DecodeFrame(a_dst, a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_call_sequence = 2
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.is_better
+
+func (self *Decoder) isBetter(a_width uint32, a_height uint32, a_bits_per_pixel uint32) bool {
+
+	var (
+		v_area      uint32
+		v_best_area uint32
+	)
+
+	v_area = (a_width * a_height)
+	v_best_area = (self.f_best_width * self.f_best_height)
+	if v_area != v_best_area {
+		return (v_area > v_best_area)
+	}
+	return (a_bits_per_pixel > self.f_best_bits_per_pixel)
+}
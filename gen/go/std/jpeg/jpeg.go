@@ -0,0 +1,3027 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package jpeg
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadDHTMarker                      = base.NewError("jpeg: bad DHT marker")
+	ErrBadDQTMarker                      = base.NewError("jpeg: bad DQT marker")
+	ErrBadDRIMarker                      = base.NewError("jpeg: bad DRI marker")
+	ErrBadHuffmanCode                    = base.NewError("jpeg: bad Huffman code")
+	ErrBadRSTMarker                      = base.NewError("jpeg: bad RST marker")
+	ErrBadSOFMarker                      = base.NewError("jpeg: bad SOF marker")
+	ErrBadSOSMarker                      = base.NewError("jpeg: bad SOS marker")
+	ErrBadHeader                         = base.NewError("jpeg: bad header")
+	ErrBadMarker                         = base.NewError("jpeg: bad marker")
+	ErrMissingHuffmanTable               = base.NewError("jpeg: missing Huffman table")
+	ErrMissingQuantizationTable          = base.NewError("jpeg: missing quantization table")
+	ErrUnsupportedCodingProcess          = base.NewError("jpeg: unsupported coding process")
+	ErrTODOUnsupportedArithmeticCoding   = base.NewError("jpeg: TODO: unsupported arithmetic coding")
+	ErrTODOUnsupportedNumberOfComponents = base.NewError("jpeg: TODO: unsupported number of components")
+	ErrTODOUnsupportedPrecision          = base.NewError("jpeg: TODO: unsupported precision")
+	ErrTODOUnsupportedSubsampling        = base.NewError("jpeg: TODO: unsupported subsampling")
+	ErrBadWorkbufLength                  = base.NewError("jpeg: bad workbuf length")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a jpeg.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width                 uint32
+	f_height                uint32
+	f_call_sequence         uint8
+	f_marker                uint8
+	f_marker_pending        uint8
+	f_progressive           bool
+	f_num_components        uint32
+	f_max_h                 uint32
+	f_max_v                 uint32
+	f_mcus_across           uint32
+	f_mcus_down             uint32
+	f_comp_id               [3]uint8
+	f_comp_h                [3]uint32
+	f_comp_v                [3]uint32
+	f_comp_tq               [3]uint8
+	f_comp_width_in_blocks  [3]uint32
+	f_comp_height_in_blocks [3]uint32
+	f_comp_stride           [3]uint32
+	f_comp_offset           [3]uint64
+	f_num_blocks            uint64
+	f_comp_quant_latched    [3]bool
+	f_comp_quant            [3][64]uint16
+	f_scan_num_components   uint32
+	f_scan_comp             [3]uint32
+	f_scan_td               [3]uint32
+	f_scan_ta               [3]uint32
+	f_ss                    uint32
+	f_se                    uint32
+	f_ah                    uint32
+	f_al                    uint32
+	f_dc_pred               [3]uint16
+	f_eobrun                uint32
+	f_restart_interval      uint32
+	f_restarts_to_go        uint32
+	f_next_restart          uint32
+	f_bits                  uint32
+	f_n_bits                uint32
+	f_quant_defined         [4]bool
+	f_quant                 [4][64]uint16
+	f_huff_defined          [8]bool
+	f_huff_lut              [8][256]uint16
+	f_huff_maxcode          [8][17]uint32
+	f_huff_mincode          [8][17]uint32
+	f_huff_valptr           [8][17]uint32
+	f_huff_vals             [8][256]uint8
+	f_huff_counts           [17]uint32
+	f_block                 [64]uint16
+	f_workspace             [64]int64
+	f_pixels                [64]uint8
+	f_coef_bytes            [128]uint8
+
+	c_decode_config struct {
+		coroSuspPoint uint32
+		v_m           uint8
+		v_pixfmt      uint32
+		v_pixsub      uint32
+		v_e           uint32
+		scratch       uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+		v_j           uint64
+		v_m           uint8
+		v_dirty_rect  base.RectIEU32
+	}
+
+	c_read_marker struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_decode_misc_marker struct {
+		coroSuspPoint uint32
+		v_m           uint8
+		v_length      uint32
+		scratch       uint64
+	}
+
+	c_decode_sof struct {
+		coroSuspPoint    uint32
+		v_length         uint32
+		v_height         uint32
+		v_width          uint32
+		v_n              uint32
+		v_num_components uint32
+		v_max_h          uint32
+		v_max_v          uint32
+		v_i              uint32
+		v_hv             uint8
+		v_h              uint32
+		v_v              uint32
+		v_tq             uint8
+		v_mcu_width      uint32
+		v_mcu_height     uint32
+		v_mcus_across    uint32
+		v_mcus_down      uint32
+		v_num_blocks     uint64
+		v_cw             uint32
+		v_ch             uint32
+		scratch          uint64
+	}
+
+	c_decode_dht struct {
+		coroSuspPoint uint32
+		v_length      uint32
+		v_n           uint32
+		v_tc_th       uint8
+		v_t           uint32
+		v_total       uint32
+		v_i           uint32
+		v_num_symbols uint32
+		scratch       uint64
+	}
+
+	c_decode_dqt struct {
+		coroSuspPoint uint32
+		v_length      uint32
+		v_n           uint32
+		v_pq_tq       uint8
+		v_pq          uint8
+		v_tq          uint8
+		v_i           uint32
+		scratch       uint64
+	}
+
+	c_decode_dri struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_decode_sos struct {
+		coroSuspPoint    uint32
+		v_length         uint32
+		v_n              uint32
+		v_num_components uint32
+		v_i              uint32
+		v_id             uint8
+		v_td_ta          uint8
+		v_j              uint32
+		v_ss             uint32
+		v_se             uint32
+		v_ah_al          uint8
+		v_ah             uint32
+		v_al             uint32
+		v_c              uint32
+		v_k              uint32
+		scratch          uint64
+	}
+
+	c_decode_scan struct {
+		coroSuspPoint uint32
+		v_mcus_x      uint32
+		v_mcus_y      uint32
+		v_my          uint32
+		v_mx          uint32
+		v_i           uint32
+		v_c           uint32
+		v_h           uint32
+		v_v           uint32
+		v_y           uint32
+		v_x           uint32
+	}
+
+	c_process_restart struct {
+		coroSuspPoint uint32
+	}
+
+	c_fill_bits struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_decode_block struct {
+		coroSuspPoint uint32
+		v_c           uint32
+		v_i           uint32
+		v_index       uint64
+		v_offset      uint64
+	}
+
+	c_decode_dc_first struct {
+		coroSuspPoint uint32
+		v_c           uint32
+		v_n           uint32
+		v_v           uint32
+		v_coef        uint32
+	}
+
+	c_decode_dc_refine struct {
+		coroSuspPoint uint32
+	}
+
+	c_decode_ac_first struct {
+		coroSuspPoint uint32
+		v_t           uint32
+		v_k           uint32
+		v_rs          uint32
+		v_r           uint32
+		v_n           uint32
+		v_v           uint32
+		v_coef        uint32
+		v_eobrun      uint32
+	}
+
+	c_decode_ac_refine struct {
+		coroSuspPoint uint32
+		v_t           uint32
+		v_p1          uint32
+		v_k           uint32
+		v_v           uint16
+		v_z           uint32
+		v_rs          uint32
+		v_r           uint32
+		v_n           uint32
+		v_eobrun      uint32
+		v_i           uint32
+	}
+
+	c_refine_coefficient struct {
+		coroSuspPoint uint32
+		v_v           uint16
+	}
+}
+
+// ---------------- Private Consts
+
+var zigzag = [80]uint8{
+	0x0,
+	0x1,
+	0x8,
+	0x10,
+	0x9,
+	0x2,
+	0x3,
+	0xA,
+	0x11,
+	0x18,
+	0x20,
+	0x19,
+	0x12,
+	0xB,
+	0x4,
+	0x5,
+	0xC,
+	0x13,
+	0x1A,
+	0x21,
+	0x28,
+	0x30,
+	0x29,
+	0x22,
+	0x1B,
+	0x14,
+	0xD,
+	0x6,
+	0x7,
+	0xE,
+	0x15,
+	0x1C,
+	0x23,
+	0x2A,
+	0x31,
+	0x38,
+	0x39,
+	0x32,
+	0x2B,
+	0x24,
+	0x1D,
+	0x16,
+	0xF,
+	0x17,
+	0x1E,
+	0x25,
+	0x2C,
+	0x33,
+	0x3A,
+	0x3B,
+	0x34,
+	0x2D,
+	0x26,
+	0x1F,
+	0x27,
+	0x2E,
+	0x35,
+	0x3C,
+	0x3D,
+	0x36,
+	0x2F,
+	0x37,
+	0x3E,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+	0x3F,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.workbuf_len
+
+func (self *Decoder) WorkbufLen() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	if self.f_progressive {
+		return (self.f_num_blocks * 128)
+	}
+	return 0
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_m      uint8
+		v_pixfmt uint32
+		v_pixsub uint32
+		v_e      uint32
+		t_0      uint16
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_m = self.c_decode_config.v_m
+		v_pixfmt = self.c_decode_config.v_pixfmt
+		v_pixsub = self.c_decode_config.v_pixsub
+		v_e = self.c_decode_config.v_e
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU16BE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 65496) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || (2 <= r && r <= 4) {
+	label_0:
+		for {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.readMarker(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_m = self.f_marker
+			}
+			if r == 0 || r == 3 {
+				if r == 3 || (r == 0 && ((v_m == 192) || (v_m == 193) || (v_m == 194))) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.decodeSof(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				} else if r == 0 && ((v_m == 195) || ((v_m >= 197) && (v_m <= 200))) {
+					status = ErrUnsupportedCodingProcess
+					goto exit
+				} else if r == 0 && ((v_m >= 201) && (v_m <= 207) && (v_m != 204)) {
+					status = ErrTODOUnsupportedArithmeticCoding
+					goto exit
+				} else if r == 0 && ((v_m == 216) || (v_m == 217) || (v_m == 218)) {
+					status = ErrBadMarker
+					goto exit
+				}
+			}
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.decodeMiscMarker(a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_pixfmt = 268435464
+		v_pixsub = 0
+		if self.f_num_components == 3 {
+			v_pixfmt = 1075841160
+			if (self.f_comp_h[0] != self.f_comp_h[1]) || (self.f_comp_v[0] != self.f_comp_v[1]) {
+				v_e = ((base.U32SatSub(base.U32Min(self.f_comp_h[0], 2), 1) << 4) | base.U32SatSub(base.U32Min(self.f_comp_v[0], 2), 1))
+				v_pixsub = ((v_e << 16) | (v_e << 8))
+			}
+		}
+		a_dst.Initialize(v_pixfmt, v_pixsub, self.f_width, self.f_height, 1)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_m = v_m
+	self.c_decode_config.v_pixfmt = v_pixfmt
+	self.c_decode_config.v_pixsub = v_pixsub
+	self.c_decode_config.v_e = v_e
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_coefs      []byte
+		v_j          uint64
+		v_m          uint8
+		v_palette    []byte
+		v_dirty_rect base.RectIEU32
+	)
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_j = self.c_decode_frame.v_j
+		v_m = self.c_decode_frame.v_m
+		v_dirty_rect = self.c_decode_frame.v_dirty_rect
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 && (self.f_call_sequence == 0) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		} else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+			if r == 0 || r == 1 {
+				for {
+					if r == 0 || r == 1 {
+						status = base.SuspensionEndOfData
+						if r == 1 {
+							r = 0
+							status = nil
+						} else {
+							csp = 1
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if self.f_progressive {
+			if uint64(len(a_workbuf)) < (self.f_num_blocks * 128) {
+				status = ErrBadWorkbufLength
+				goto exit
+			}
+			v_coefs = base.SliceU8Prefix(a_workbuf, (self.f_num_blocks * 128))
+			v_j = 0
+			for v_j < uint64(len(v_coefs)) {
+				v_coefs[v_j] = 0
+				v_j += 1
+			}
+		}
+	}
+	if r == 0 || (2 <= r && r <= 5) {
+	label_0:
+		for {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.readMarker(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_m = self.f_marker
+			}
+			if r == 0 || (3 <= r && r <= 5) {
+				if (3 <= r && r <= 4) || (r == 0 && (v_m == 218)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.decodeSos(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.decodeScan(a_dst, a_src, a_workbuf); status != nil {
+							goto suspend
+						}
+					}
+				} else if r == 0 && (v_m == 217) {
+					break label_0
+				} else if r == 0 && (((v_m >= 192) && (v_m <= 207) && (v_m != 196) && (v_m != 204)) || (v_m == 216)) {
+					status = ErrBadMarker
+					goto exit
+				} else {
+					if r == 0 || r == 5 {
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if status = self.decodeMiscMarker(a_src); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if self.f_progressive {
+			self.writeProgressiveBlocks(a_dst, a_workbuf)
+		}
+		v_palette = nil
+		v_dirty_rect = base.RectIEU32{}
+		v_dirty_rect.SetMaxExclusiveX(self.f_width)
+		v_dirty_rect.SetMaxExclusiveY(self.f_height)
+		a_dst.Update(v_dirty_rect, 0, false, 0, v_palette)
+		self.f_call_sequence = 2
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+	self.c_decode_frame.v_j = v_j
+	self.c_decode_frame.v_m = v_m
+	self.c_decode_frame.v_dirty_rect = v_dirty_rect
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.read_marker
+
+func (self *Decoder) readMarker(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		t_0 uint8
+		t_1 uint8
+		t_2 uint8
+	)
+
+	r := self.c_read_marker.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_read_marker.v_c
+	}
+
+	if r == 0 {
+		if self.f_marker_pending != 0 {
+			self.f_marker = self.f_marker_pending
+			self.f_marker_pending = 0
+			status = nil
+			goto ok
+		}
+		v_c = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c = t_0
+			}
+			if r == 0 {
+				if v_c != 255 {
+					continue label_0
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c = t_1
+			}
+			if r == 0 || r == 3 {
+				for r != 0 || (v_c == 255) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if t_2, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_2
+					}
+				}
+			}
+			if r == 0 {
+				if v_c != 0 {
+					break label_0
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_marker = v_c
+	}
+
+ok:
+	self.c_read_marker.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_marker.coroSuspPoint = csp
+	self.c_read_marker.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_misc_marker
+
+func (self *Decoder) decodeMiscMarker(a_src base.IOReader) (status error) {
+
+	var (
+		v_m      uint8
+		v_length uint32
+		t_0      uint16
+	)
+
+	r := self.c_decode_misc_marker.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_m = self.c_decode_misc_marker.v_m
+		v_length = self.c_decode_misc_marker.v_length
+	}
+
+	if r == 0 {
+		v_m = self.f_marker
+	}
+	if r == 0 || (1 <= r && r <= 5) {
+		if r == 1 || (r == 0 && (v_m == 196)) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.decodeDht(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else if r == 2 || (r == 0 && (v_m == 219)) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.decodeDqt(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else if r == 3 || (r == 0 && (v_m == 221)) {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.decodeDri(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else if r == 0 && ((v_m == 1) || ((v_m >= 208) && (v_m <= 215))) {
+		} else {
+			if r == 0 || r == 4 {
+				if r == 0 {
+					self.c_decode_misc_marker.scratch = 0
+				}
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if t_0, status = a_src.ReadU16BE(&self.c_decode_misc_marker.scratch); status != nil {
+					goto suspend
+				}
+				v_length = uint32(t_0)
+			}
+			if r == 0 {
+				if v_length < 2 {
+					status = ErrBadMarker
+					goto exit
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 0 {
+					self.c_decode_misc_marker.scratch = uint64((v_length - 2))
+				}
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = a_src.Skip(&self.c_decode_misc_marker.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+	self.c_decode_misc_marker.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_misc_marker.coroSuspPoint = csp
+	self.c_decode_misc_marker.v_m = v_m
+	self.c_decode_misc_marker.v_length = v_length
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_sof
+
+func (self *Decoder) decodeSof(a_src base.IOReader) (status error) {
+
+	var (
+		v_length         uint32
+		v_height         uint32
+		v_width          uint32
+		v_n              uint32
+		v_num_components uint32
+		v_max_h          uint32
+		v_max_v          uint32
+		v_i              uint32
+		v_hv             uint8
+		v_h              uint32
+		v_v              uint32
+		v_tq             uint8
+		v_mcu_width      uint32
+		v_mcu_height     uint32
+		v_mcus_across    uint32
+		v_mcus_down      uint32
+		v_num_blocks     uint64
+		v_cw             uint32
+		v_ch             uint32
+		t_0              uint16
+		t_1              uint8
+		t_2              uint16
+		t_3              uint16
+		t_4              uint8
+		t_5              uint8
+		t_6              uint8
+		t_7              uint8
+	)
+
+	r := self.c_decode_sof.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_decode_sof.v_length
+		v_height = self.c_decode_sof.v_height
+		v_width = self.c_decode_sof.v_width
+		v_n = self.c_decode_sof.v_n
+		v_num_components = self.c_decode_sof.v_num_components
+		v_max_h = self.c_decode_sof.v_max_h
+		v_max_v = self.c_decode_sof.v_max_v
+		v_i = self.c_decode_sof.v_i
+		v_hv = self.c_decode_sof.v_hv
+		v_h = self.c_decode_sof.v_h
+		v_v = self.c_decode_sof.v_v
+		v_tq = self.c_decode_sof.v_tq
+		v_mcu_width = self.c_decode_sof.v_mcu_width
+		v_mcu_height = self.c_decode_sof.v_mcu_height
+		v_mcus_across = self.c_decode_sof.v_mcus_across
+		v_mcus_down = self.c_decode_sof.v_mcus_down
+		v_num_blocks = self.c_decode_sof.v_num_blocks
+		v_cw = self.c_decode_sof.v_cw
+		v_ch = self.c_decode_sof.v_ch
+	}
+
+	if r == 0 {
+		self.f_progressive = (self.f_marker == 194)
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_sof.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16BE(&self.c_decode_sof.scratch); status != nil {
+			goto suspend
+		}
+		v_length = uint32(t_0)
+	}
+	if r == 0 || r == 2 {
+		if r == 0 || r == 2 {
+			if r == 2 {
+				r = 0
+			}
+			csp = 2
+			if t_1, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_1 != 8) {
+			status = ErrTODOUnsupportedPrecision
+			goto exit
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_decode_sof.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU16BE(&self.c_decode_sof.scratch); status != nil {
+			goto suspend
+		}
+		v_height = uint32(t_2)
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_decode_sof.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_3, status = a_src.ReadU16BE(&self.c_decode_sof.scratch); status != nil {
+			goto suspend
+		}
+		v_width = uint32(t_3)
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_4, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_n = uint32(t_4)
+	}
+	if r == 0 {
+		if (v_width == 0) || (v_height == 0) {
+			status = ErrBadSOFMarker
+			goto exit
+		} else if (v_n == 2) || (v_n == 4) {
+			status = ErrTODOUnsupportedNumberOfComponents
+			goto exit
+		} else if ((v_n != 1) && (v_n != 3)) || (v_length != (8 + (3 * v_n))) {
+			status = ErrBadSOFMarker
+			goto exit
+		}
+		self.f_width = v_width
+		self.f_height = v_height
+		self.f_num_components = base.U32Min(v_n, 3)
+		v_num_components = self.f_num_components
+		v_max_h = 1
+		v_max_v = 1
+		v_i = 0
+	}
+	if r == 0 || (6 <= r && r <= 8) {
+		for r != 0 || (v_i < v_num_components) {
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if t_5, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				self.f_comp_id[v_i] = t_5
+			}
+			if r == 0 || r == 7 {
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if t_6, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_hv = t_6
+			}
+			if r == 0 {
+				v_h = uint32((v_hv >> 4))
+				v_v = uint32((v_hv & 15))
+			}
+			if r == 0 || r == 8 {
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_7, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_tq = t_7
+			}
+			if r == 0 {
+				if (v_h < 1) || (v_h > 4) || (v_v < 1) || (v_v > 4) || (v_tq > 3) {
+					status = ErrBadSOFMarker
+					goto exit
+				}
+				self.f_comp_h[v_i] = v_h
+				self.f_comp_v[v_i] = v_v
+				self.f_comp_tq[v_i] = v_tq
+				self.f_comp_quant_latched[v_i] = false
+				v_max_h = base.U32Max(v_max_h, v_h)
+				v_max_v = base.U32Max(v_max_v, v_v)
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		self.f_max_h = v_max_h
+		self.f_max_v = v_max_v
+		if v_num_components == 3 {
+			if (self.f_comp_h[1] != self.f_comp_h[2]) || (self.f_comp_v[1] != self.f_comp_v[2]) {
+				status = ErrTODOUnsupportedSubsampling
+				goto exit
+			} else if (self.f_comp_h[0] != self.f_comp_h[1]) || (self.f_comp_v[0] != self.f_comp_v[1]) {
+				if (self.f_comp_h[1] != 1) || (self.f_comp_v[1] != 1) || (self.f_comp_h[0] > 2) || (self.f_comp_v[0] > 2) {
+					status = ErrTODOUnsupportedSubsampling
+					goto exit
+				}
+			}
+		}
+		v_mcu_width = (8 * base.U32Max(v_max_h, 1))
+		v_mcu_height = (8 * base.U32Max(v_max_v, 1))
+		v_mcus_across = ((v_width + (v_mcu_width - 1)) / v_mcu_width)
+		v_mcus_down = ((v_height + (v_mcu_height - 1)) / v_mcu_height)
+		self.f_mcus_across = base.U32Min(v_mcus_across, 8192)
+		self.f_mcus_down = base.U32Min(v_mcus_down, 8192)
+		v_num_blocks = 0
+		v_i = 0
+		for v_i < v_num_components {
+			v_cw = (((v_width * self.f_comp_h[v_i]) + (base.U32Max(v_max_h, 1) - 1)) / base.U32Max(v_max_h, 1))
+			v_ch = (((v_height * self.f_comp_v[v_i]) + (base.U32Max(v_max_v, 1) - 1)) / base.U32Max(v_max_v, 1))
+			self.f_comp_width_in_blocks[v_i] = ((base.U32Min(v_cw, 65535) + 7) / 8)
+			self.f_comp_height_in_blocks[v_i] = ((base.U32Min(v_ch, 65535) + 7) / 8)
+			self.f_comp_stride[v_i] = (self.f_mcus_across * self.f_comp_h[v_i])
+			self.f_comp_offset[v_i] = base.U64Min(v_num_blocks, 2147483648)
+			v_num_blocks = (base.U64Min(v_num_blocks, 2147483648) + (uint64((self.f_mcus_across * self.f_comp_h[v_i])) * uint64((self.f_mcus_down * self.f_comp_v[v_i]))))
+			v_i += 1
+		}
+		self.f_num_blocks = v_num_blocks
+	}
+
+	self.c_decode_sof.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_sof.coroSuspPoint = csp
+	self.c_decode_sof.v_length = v_length
+	self.c_decode_sof.v_height = v_height
+	self.c_decode_sof.v_width = v_width
+	self.c_decode_sof.v_n = v_n
+	self.c_decode_sof.v_num_components = v_num_components
+	self.c_decode_sof.v_max_h = v_max_h
+	self.c_decode_sof.v_max_v = v_max_v
+	self.c_decode_sof.v_i = v_i
+	self.c_decode_sof.v_hv = v_hv
+	self.c_decode_sof.v_h = v_h
+	self.c_decode_sof.v_v = v_v
+	self.c_decode_sof.v_tq = v_tq
+	self.c_decode_sof.v_mcu_width = v_mcu_width
+	self.c_decode_sof.v_mcu_height = v_mcu_height
+	self.c_decode_sof.v_mcus_across = v_mcus_across
+	self.c_decode_sof.v_mcus_down = v_mcus_down
+	self.c_decode_sof.v_num_blocks = v_num_blocks
+	self.c_decode_sof.v_cw = v_cw
+	self.c_decode_sof.v_ch = v_ch
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_dht
+
+func (self *Decoder) decodeDht(a_src base.IOReader) (status error) {
+
+	var (
+		v_length      uint32
+		v_n           uint32
+		v_tc_th       uint8
+		v_t           uint32
+		v_total       uint32
+		v_i           uint32
+		v_num_symbols uint32
+		t_0           uint16
+		t_1           uint8
+		t_2           uint8
+		t_3           uint8
+	)
+
+	r := self.c_decode_dht.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_decode_dht.v_length
+		v_n = self.c_decode_dht.v_n
+		v_tc_th = self.c_decode_dht.v_tc_th
+		v_t = self.c_decode_dht.v_t
+		v_total = self.c_decode_dht.v_total
+		v_i = self.c_decode_dht.v_i
+		v_num_symbols = self.c_decode_dht.v_num_symbols
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_dht.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16BE(&self.c_decode_dht.scratch); status != nil {
+			goto suspend
+		}
+		v_length = uint32(t_0)
+	}
+	if r == 0 {
+		if v_length < 2 {
+			status = ErrBadDHTMarker
+			goto exit
+		}
+		v_n = (v_length - 2)
+	}
+	if r == 0 || (2 <= r && r <= 5) {
+		for r != 0 || (v_n > 0) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_tc_th = t_1
+			}
+			if r == 0 {
+				if ((v_tc_th >> 4) > 1) || ((v_tc_th & 15) > 3) || (v_n < 17) {
+					status = ErrBadDHTMarker
+					goto exit
+				}
+				v_t = uint32(((((v_tc_th >> 4) & 1) << 2) | (v_tc_th & 3)))
+				v_n -= 17
+				v_total = 0
+				v_i = 1
+			}
+			if r == 0 || r == 3 {
+				for r != 0 || (v_i < 17) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if t_2, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_huff_counts[v_i] = uint32(t_2)
+					}
+					if r == 0 {
+						v_total = (base.U32Min(v_total, 65535) + self.f_huff_counts[v_i])
+						v_i += 1
+					}
+				}
+			}
+			if r == 0 {
+				if (v_total > 256) || (v_n < v_total) {
+					status = ErrBadDHTMarker
+					goto exit
+				}
+				v_n -= v_total
+				v_num_symbols = v_total
+				v_i = 0
+			}
+			if r == 0 || r == 4 {
+				for r != 0 || (v_i < v_num_symbols) {
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if t_3, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_huff_vals[v_t][v_i] = t_3
+					}
+					if r == 0 {
+						v_i += 1
+					}
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.buildHuffmanTable(v_t); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+	self.c_decode_dht.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_dht.coroSuspPoint = csp
+	self.c_decode_dht.v_length = v_length
+	self.c_decode_dht.v_n = v_n
+	self.c_decode_dht.v_tc_th = v_tc_th
+	self.c_decode_dht.v_t = v_t
+	self.c_decode_dht.v_total = v_total
+	self.c_decode_dht.v_i = v_i
+	self.c_decode_dht.v_num_symbols = v_num_symbols
+
+exit:
+	return status
+}
+
+// -------- func decoder.build_huffman_table
+
+func (self *Decoder) buildHuffmanTable(a_t uint32) (status error) {
+
+	var (
+		v_i         uint32
+		v_code      uint32
+		v_k         uint32
+		v_length    uint32
+		v_count     uint32
+		v_max_code  uint32
+		v_j         uint32
+		v_entry     uint16
+		v_shift     uint32
+		v_lo        uint32
+		v_hi        uint32
+		v_next_code uint32
+	)
+
+	v_i = 0
+	for v_i < 256 {
+		self.f_huff_lut[a_t][v_i] = 0
+		v_i += 1
+	}
+	v_code = 0
+	v_k = 0
+	v_length = 1
+	for v_length < 17 {
+		v_count = self.f_huff_counts[v_length]
+		v_max_code = ((uint32(1) << v_length) - 1)
+		if (v_code + v_count) > v_max_code {
+			status = ErrBadDHTMarker
+			goto exit
+		}
+		self.f_huff_valptr[a_t][v_length] = v_k
+		self.f_huff_mincode[a_t][v_length] = v_code
+		self.f_huff_maxcode[a_t][v_length] = 0
+		if v_count > 0 {
+			self.f_huff_maxcode[a_t][v_length] = (v_code + v_count)
+		}
+		v_j = 0
+		for v_j < v_count {
+			if v_length <= 8 {
+				v_entry = uint16(((v_length << 8) | uint32(self.f_huff_vals[a_t][v_k])))
+				v_shift = (8 - v_length)
+				v_lo = (((v_code + v_j) & 255) << v_shift)
+				v_hi = ((((v_code + v_j) & 255) + 1) << v_shift)
+				for (v_lo < v_hi) && (v_lo < 256) {
+					self.f_huff_lut[a_t][v_lo] = v_entry
+					v_lo += 1
+				}
+			}
+			if v_k < 255 {
+				v_k += 1
+			}
+			v_j += 1
+		}
+		v_next_code = ((v_code + v_count) << 1)
+		v_code = base.U32Min(v_next_code, 65535)
+		v_length += 1
+	}
+	self.f_huff_defined[a_t] = true
+exit:
+	return status
+}
+
+// -------- func decoder.decode_dqt
+
+func (self *Decoder) decodeDqt(a_src base.IOReader) (status error) {
+
+	var (
+		v_length uint32
+		v_n      uint32
+		v_pq_tq  uint8
+		v_pq     uint8
+		v_tq     uint8
+		v_i      uint32
+		t_0      uint16
+		t_1      uint8
+		t_2      uint8
+		t_3      uint16
+	)
+
+	r := self.c_decode_dqt.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_decode_dqt.v_length
+		v_n = self.c_decode_dqt.v_n
+		v_pq_tq = self.c_decode_dqt.v_pq_tq
+		v_pq = self.c_decode_dqt.v_pq
+		v_tq = self.c_decode_dqt.v_tq
+		v_i = self.c_decode_dqt.v_i
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_dqt.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16BE(&self.c_decode_dqt.scratch); status != nil {
+			goto suspend
+		}
+		v_length = uint32(t_0)
+	}
+	if r == 0 {
+		if v_length < 2 {
+			status = ErrBadDQTMarker
+			goto exit
+		}
+		v_n = (v_length - 2)
+	}
+	if r == 0 || (2 <= r && r <= 4) {
+		for r != 0 || (v_n > 0) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_pq_tq = t_1
+			}
+			if r == 0 {
+				v_n -= 1
+				if (v_pq_tq & 236) != 0 {
+					status = ErrBadDQTMarker
+					goto exit
+				}
+				v_pq = ((v_pq_tq >> 4) & 1)
+				v_tq = (v_pq_tq & 3)
+				v_i = 0
+			}
+			if r == 0 || (3 <= r && r <= 4) {
+				if r == 3 || (r == 0 && (v_pq == 0)) {
+					if r == 0 {
+						if v_n < 64 {
+							status = ErrBadDQTMarker
+							goto exit
+						}
+						v_n -= 64
+					}
+					if r == 0 || r == 3 {
+						for r != 0 || (v_i < 64) {
+							if r == 0 || r == 3 {
+								if r == 3 {
+									r = 0
+								}
+								csp = 3
+								if t_2, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								self.f_quant[v_tq][zigzag[v_i]] = uint16(t_2)
+							}
+							if r == 0 {
+								v_i += 1
+							}
+						}
+					}
+				} else {
+					if r == 0 {
+						if v_n < 128 {
+							status = ErrBadDQTMarker
+							goto exit
+						}
+						v_n -= 128
+					}
+					if r == 0 || r == 4 {
+						for r != 0 || (v_i < 64) {
+							if r == 0 || r == 4 {
+								if r == 0 {
+									self.c_decode_dqt.scratch = 0
+								}
+								if r == 4 {
+									r = 0
+								}
+								csp = 4
+								if t_3, status = a_src.ReadU16BE(&self.c_decode_dqt.scratch); status != nil {
+									goto suspend
+								}
+								self.f_quant[v_tq][zigzag[v_i]] = t_3
+							}
+							if r == 0 {
+								v_i += 1
+							}
+						}
+					}
+				}
+			}
+			if r == 0 {
+				self.f_quant_defined[v_tq] = true
+			}
+		}
+	}
+
+	self.c_decode_dqt.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_dqt.coroSuspPoint = csp
+	self.c_decode_dqt.v_length = v_length
+	self.c_decode_dqt.v_n = v_n
+	self.c_decode_dqt.v_pq_tq = v_pq_tq
+	self.c_decode_dqt.v_pq = v_pq
+	self.c_decode_dqt.v_tq = v_tq
+	self.c_decode_dqt.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_dri
+
+func (self *Decoder) decodeDri(a_src base.IOReader) (status error) {
+
+	var (
+		t_0 uint16
+		t_1 uint16
+	)
+
+	r := self.c_decode_dri.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 0 {
+				self.c_decode_dri.scratch = 0
+			}
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU16BE(&self.c_decode_dri.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 4) {
+			status = ErrBadDRIMarker
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_dri.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU16BE(&self.c_decode_dri.scratch); status != nil {
+			goto suspend
+		}
+		self.f_restart_interval = uint32(t_1)
+	}
+
+	self.c_decode_dri.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_dri.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_sos
+
+func (self *Decoder) decodeSos(a_src base.IOReader) (status error) {
+
+	var (
+		v_length         uint32
+		v_n              uint32
+		v_num_components uint32
+		v_i              uint32
+		v_id             uint8
+		v_td_ta          uint8
+		v_j              uint32
+		v_ss             uint32
+		v_se             uint32
+		v_ah_al          uint8
+		v_ah             uint32
+		v_al             uint32
+		v_c              uint32
+		v_k              uint32
+		t_0              uint16
+		t_1              uint8
+		t_2              uint8
+		t_3              uint8
+		t_4              uint8
+		t_5              uint8
+		t_6              uint8
+	)
+
+	r := self.c_decode_sos.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_decode_sos.v_length
+		v_n = self.c_decode_sos.v_n
+		v_num_components = self.c_decode_sos.v_num_components
+		v_i = self.c_decode_sos.v_i
+		v_id = self.c_decode_sos.v_id
+		v_td_ta = self.c_decode_sos.v_td_ta
+		v_j = self.c_decode_sos.v_j
+		v_ss = self.c_decode_sos.v_ss
+		v_se = self.c_decode_sos.v_se
+		v_ah_al = self.c_decode_sos.v_ah_al
+		v_ah = self.c_decode_sos.v_ah
+		v_al = self.c_decode_sos.v_al
+		v_c = self.c_decode_sos.v_c
+		v_k = self.c_decode_sos.v_k
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_sos.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16BE(&self.c_decode_sos.scratch); status != nil {
+			goto suspend
+		}
+		v_length = uint32(t_0)
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_n = uint32(t_1)
+	}
+	if r == 0 {
+		if (v_n < 1) || (v_n > self.f_num_components) || (v_length != (6 + (2 * v_n))) {
+			status = ErrBadSOSMarker
+			goto exit
+		}
+		v_num_components = base.U32Min(v_n, 3)
+		self.f_scan_num_components = v_num_components
+		v_i = 0
+	}
+	if r == 0 || (3 <= r && r <= 4) {
+		for r != 0 || (v_i < v_num_components) {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if t_2, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_id = t_2
+			}
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if t_3, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_td_ta = t_3
+			}
+			if r == 0 {
+				if ((v_td_ta >> 4) > 3) || ((v_td_ta & 15) > 3) {
+					status = ErrBadSOSMarker
+					goto exit
+				}
+				self.f_scan_td[v_i] = uint32((v_td_ta >> 4))
+				self.f_scan_ta[v_i] = uint32((v_td_ta & 15))
+				v_j = 0
+			label_0:
+				for {
+					if (v_j >= self.f_num_components) || (v_j >= 3) {
+						status = ErrBadSOSMarker
+						goto exit
+					}
+					if self.f_comp_id[v_j] == v_id {
+						break label_0
+					}
+					v_j += 1
+				}
+				self.f_scan_comp[v_i] = base.U32Min(v_j, 2)
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_4, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_ss = uint32(t_4)
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if t_5, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_se = uint32(t_5)
+	}
+	if r == 0 || r == 7 {
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if t_6, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_ah_al = t_6
+	}
+	if r == 0 {
+		v_ah = uint32((v_ah_al >> 4))
+		v_al = uint32((v_ah_al & 15))
+		if self.f_progressive {
+			if (v_ss > 63) || (v_se > 63) || (v_ss > v_se) || (v_ah > 13) || (v_al > 13) || ((v_ss == 0) && (v_se != 0)) || ((v_ss != 0) && (v_num_components != 1)) {
+				status = ErrBadSOSMarker
+				goto exit
+			}
+			self.f_ss = v_ss
+			self.f_se = v_se
+			self.f_ah = v_ah
+			self.f_al = v_al
+		} else {
+			self.f_ss = 0
+			self.f_se = 63
+			self.f_ah = 0
+			self.f_al = 0
+		}
+		v_i = 0
+		for v_i < v_num_components {
+			v_c = self.f_scan_comp[v_i]
+			if !self.f_comp_quant_latched[v_c] {
+				if !self.f_quant_defined[self.f_comp_tq[v_c]] {
+					status = ErrMissingQuantizationTable
+					goto exit
+				}
+				v_k = 0
+				for v_k < 64 {
+					self.f_comp_quant[v_c][v_k] = self.f_quant[self.f_comp_tq[v_c]][v_k]
+					v_k += 1
+				}
+				self.f_comp_quant_latched[v_c] = true
+			}
+			if (self.f_ss == 0) && (self.f_ah == 0) && !self.f_huff_defined[self.f_scan_td[v_i]] {
+				status = ErrMissingHuffmanTable
+				goto exit
+			}
+			if (self.f_se > 0) && !self.f_huff_defined[(4+self.f_scan_ta[v_i])] {
+				status = ErrMissingHuffmanTable
+				goto exit
+			}
+			v_i += 1
+		}
+	}
+
+	self.c_decode_sos.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_sos.coroSuspPoint = csp
+	self.c_decode_sos.v_length = v_length
+	self.c_decode_sos.v_n = v_n
+	self.c_decode_sos.v_num_components = v_num_components
+	self.c_decode_sos.v_i = v_i
+	self.c_decode_sos.v_id = v_id
+	self.c_decode_sos.v_td_ta = v_td_ta
+	self.c_decode_sos.v_j = v_j
+	self.c_decode_sos.v_ss = v_ss
+	self.c_decode_sos.v_se = v_se
+	self.c_decode_sos.v_ah_al = v_ah_al
+	self.c_decode_sos.v_ah = v_ah
+	self.c_decode_sos.v_al = v_al
+	self.c_decode_sos.v_c = v_c
+	self.c_decode_sos.v_k = v_k
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_scan
+
+func (self *Decoder) decodeScan(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+
+	var (
+		v_mcus_x uint32
+		v_mcus_y uint32
+		v_my     uint32
+		v_mx     uint32
+		v_i      uint32
+		v_c      uint32
+		v_h      uint32
+		v_v      uint32
+		v_y      uint32
+		v_x      uint32
+	)
+
+	r := self.c_decode_scan.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_mcus_x = self.c_decode_scan.v_mcus_x
+		v_mcus_y = self.c_decode_scan.v_mcus_y
+		v_my = self.c_decode_scan.v_my
+		v_mx = self.c_decode_scan.v_mx
+		v_i = self.c_decode_scan.v_i
+		v_c = self.c_decode_scan.v_c
+		v_h = self.c_decode_scan.v_h
+		v_v = self.c_decode_scan.v_v
+		v_y = self.c_decode_scan.v_y
+		v_x = self.c_decode_scan.v_x
+	}
+
+	if r == 0 {
+		self.f_bits = 0
+		self.f_n_bits = 0
+		self.f_marker_pending = 0
+		self.f_eobrun = 0
+		self.f_dc_pred[0] = 0
+		self.f_dc_pred[1] = 0
+		self.f_dc_pred[2] = 0
+		self.f_restarts_to_go = self.f_restart_interval
+		self.f_next_restart = 0
+		v_mcus_x = self.f_mcus_across
+		v_mcus_y = self.f_mcus_down
+		if self.f_scan_num_components == 1 {
+			v_mcus_x = self.f_comp_width_in_blocks[self.f_scan_comp[0]]
+			v_mcus_y = self.f_comp_height_in_blocks[self.f_scan_comp[0]]
+		}
+		v_my = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+		for r != 0 || (v_my < v_mcus_y) {
+			if r == 0 {
+				v_mx = 0
+			}
+			if r == 0 || (1 <= r && r <= 3) {
+				for r != 0 || (v_mx < v_mcus_x) {
+					if r == 0 || r == 1 {
+						if r == 1 || (r == 0 && (self.f_restart_interval > 0)) {
+							if r == 0 || r == 1 {
+								if r == 1 || (r == 0 && (self.f_restarts_to_go == 0)) {
+									if r == 0 || r == 1 {
+										if r == 1 {
+											r = 0
+										}
+										csp = 1
+										if status = self.processRestart(a_src); status != nil {
+											goto suspend
+										}
+									}
+								}
+							}
+							if r == 0 {
+								self.f_restarts_to_go = base.U32SatSub(self.f_restarts_to_go, 1)
+							}
+						}
+					}
+					if r == 0 || (2 <= r && r <= 3) {
+						if r == 2 || (r == 0 && (self.f_scan_num_components == 1)) {
+							if r == 0 || r == 2 {
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if status = self.decodeBlock(a_dst, a_src, a_workbuf, 0, v_mx, v_my); status != nil {
+									goto suspend
+								}
+							}
+						} else {
+							if r == 0 {
+								v_i = 0
+							}
+							if r == 0 || r == 3 {
+								for r != 0 || (v_i < self.f_scan_num_components) {
+									if r == 0 {
+										v_c = self.f_scan_comp[v_i]
+										v_h = self.f_comp_h[v_c]
+										v_v = self.f_comp_v[v_c]
+										v_y = 0
+									}
+									if r == 0 || r == 3 {
+										for r != 0 || (v_y < v_v) {
+											if r == 0 {
+												v_x = 0
+											}
+											if r == 0 || r == 3 {
+												for r != 0 || (v_x < v_h) {
+													if r == 0 || r == 3 {
+														if r == 3 {
+															r = 0
+														}
+														csp = 3
+														if status = self.decodeBlock(a_dst, a_src, a_workbuf, v_i, ((v_mx * v_h) + v_x), ((v_my * v_v) + v_y)); status != nil {
+															goto suspend
+														}
+													}
+													if r == 0 {
+														v_x += 1
+													}
+												}
+											}
+											if r == 0 {
+												v_y += 1
+											}
+										}
+									}
+									if r == 0 {
+										v_i += 1
+									}
+								}
+							}
+						}
+					}
+					if r == 0 {
+						v_mx += 1
+					}
+				}
+			}
+			if r == 0 {
+				v_my += 1
+			}
+		}
+	}
+
+	self.c_decode_scan.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_scan.coroSuspPoint = csp
+	self.c_decode_scan.v_mcus_x = v_mcus_x
+	self.c_decode_scan.v_mcus_y = v_mcus_y
+	self.c_decode_scan.v_my = v_my
+	self.c_decode_scan.v_mx = v_mx
+	self.c_decode_scan.v_i = v_i
+	self.c_decode_scan.v_c = v_c
+	self.c_decode_scan.v_h = v_h
+	self.c_decode_scan.v_v = v_v
+	self.c_decode_scan.v_y = v_y
+	self.c_decode_scan.v_x = v_x
+
+exit:
+	return status
+}
+
+// -------- func decoder.process_restart
+
+func (self *Decoder) processRestart(a_src base.IOReader) (status error) {
+
+	r := self.c_process_restart.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		self.f_bits = 0
+		self.f_n_bits = 0
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.readMarker(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if uint32(self.f_marker) != (208 + self.f_next_restart) {
+			status = ErrBadRSTMarker
+			goto exit
+		}
+		self.f_next_restart = ((self.f_next_restart + 1) & 7)
+		self.f_restarts_to_go = self.f_restart_interval
+		self.f_eobrun = 0
+		self.f_dc_pred[0] = 0
+		self.f_dc_pred[1] = 0
+		self.f_dc_pred[2] = 0
+	}
+
+	self.c_process_restart.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_process_restart.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.fill_bits
+
+func (self *Decoder) fillBits(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		t_0 uint8
+		t_1 uint8
+		t_2 uint8
+	)
+
+	r := self.c_fill_bits.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_fill_bits.v_c
+	}
+
+	if r == 0 {
+		v_c = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+		for r != 0 || (self.f_n_bits <= 24) {
+			if r == 0 {
+				v_c = 0
+			}
+			if r == 0 || (1 <= r && r <= 3) {
+				if (1 <= r && r <= 3) || (r == 0 && (self.f_marker_pending == 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_0
+					}
+					if r == 0 || (2 <= r && r <= 3) {
+						if (2 <= r && r <= 3) || (r == 0 && (v_c == 255)) {
+							if r == 0 || r == 2 {
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if t_1, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_c = t_1
+							}
+							if r == 0 || r == 3 {
+								for r != 0 || (v_c == 255) {
+									if r == 0 || r == 3 {
+										if r == 3 {
+											r = 0
+										}
+										csp = 3
+										if t_2, status = a_src.ReadU8(); status != nil {
+											goto suspend
+										}
+										v_c = t_2
+									}
+								}
+							}
+							if r == 0 {
+								if v_c == 0 {
+									v_c = 255
+								} else {
+									self.f_marker_pending = v_c
+									v_c = 0
+								}
+							}
+						}
+					}
+				}
+			}
+			if r == 0 {
+				self.f_bits = (((self.f_bits & 16777215) << 8) | uint32(v_c))
+				self.f_n_bits += 8
+			}
+		}
+	}
+
+	self.c_fill_bits.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_bits.coroSuspPoint = csp
+	self.c_fill_bits.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.take_bits
+
+func (self *Decoder) takeBits(a_n uint32) uint32 {
+
+	var (
+		v_v uint32
+	)
+
+	if (a_n == 0) || (self.f_n_bits < a_n) {
+		return 0
+	}
+	self.f_n_bits -= a_n
+	v_v = (self.f_bits >> self.f_n_bits)
+	return ((v_v) & ((uint32(1) << (a_n)) - 1))
+}
+
+// -------- func decoder.decode_huffman
+
+func (self *Decoder) decodeHuffman(a_t uint32) uint32 {
+
+	var (
+		v_n_bits uint32
+		v_entry  uint32
+		v_length uint32
+		v_code   uint32
+	)
+
+	v_n_bits = self.f_n_bits
+	if v_n_bits < 16 {
+		return 256
+	}
+	v_entry = uint32(self.f_huff_lut[a_t][((self.f_bits >> (v_n_bits - 8)) & 255)])
+	if v_entry != 0 {
+		self.f_n_bits = base.U32SatSub(v_n_bits, (v_entry >> 8))
+		return (v_entry & 255)
+	}
+	v_length = 9
+	for v_length < 17 {
+		v_code = (self.f_bits >> (v_n_bits - v_length))
+		v_code = ((v_code) & ((uint32(1) << (v_length)) - 1))
+		if v_code < self.f_huff_maxcode[a_t][v_length] {
+			self.f_n_bits = (v_n_bits - v_length)
+			return uint32(self.f_huff_vals[a_t][(((self.f_huff_valptr[a_t][v_length] + v_code) - self.f_huff_mincode[a_t][v_length]) & 255)])
+		}
+		v_length += 1
+	}
+	return 256
+}
+
+// -------- func decoder.extend
+
+func (self *Decoder) extend(a_v uint32, a_n uint32) uint16 {
+
+	var (
+		v_v uint32
+	)
+
+	if a_n == 0 {
+		return 0
+	}
+	v_v = a_v
+	if (v_v >> (a_n - 1)) == 0 {
+		v_v = ((v_v - (uint32(1) << a_n)) + 1)
+	}
+	return uint16(((v_v) & ((uint32(1) << (16)) - 1)))
+}
+
+// -------- func decoder.decode_block
+
+func (self *Decoder) decodeBlock(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte, a_s uint32, a_bx uint32, a_by uint32) (status error) {
+
+	var (
+		v_c      uint32
+		v_i      uint32
+		v_index  uint64
+		v_offset uint64
+	)
+
+	r := self.c_decode_block.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_block.v_c
+		v_i = self.c_decode_block.v_i
+		v_index = self.c_decode_block.v_index
+		v_offset = self.c_decode_block.v_offset
+	}
+
+	if r == 0 {
+		v_c = self.f_scan_comp[a_s]
+	}
+	if r == 0 || (1 <= r && r <= 2) {
+		if (1 <= r && r <= 2) || (r == 0 && !self.f_progressive) {
+			if r == 0 {
+				v_i = 0
+				for v_i < 64 {
+					self.f_block[v_i] = 0
+					v_i += 1
+				}
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.decodeDcFirst(a_src, a_s); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.decodeAcFirst(a_src, a_s); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.writeBlock(a_dst, v_c, a_bx, a_by)
+				status = nil
+				goto ok
+			}
+		}
+	}
+	if r == 0 {
+		v_index = (self.f_comp_offset[v_c] + (uint64(a_by) * uint64(self.f_comp_stride[v_c])) + uint64(a_bx))
+		v_offset = (v_index * 128)
+		if uint64(len(a_workbuf)) < v_offset {
+			status = ErrBadWorkbufLength
+			goto exit
+		} else if (uint64(len(a_workbuf)) - v_offset) < 128 {
+			status = ErrBadWorkbufLength
+			goto exit
+		}
+		self.loadCoefs(a_workbuf[v_offset:])
+	}
+	if r == 0 || (3 <= r && r <= 6) {
+		if (3 <= r && r <= 4) || (r == 0 && (self.f_ss == 0)) {
+			if r == 0 || (3 <= r && r <= 4) {
+				if r == 3 || (r == 0 && (self.f_ah == 0)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.decodeDcFirst(a_src, a_s); status != nil {
+							goto suspend
+						}
+					}
+				} else {
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.decodeDcRefine(a_src); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		} else if r == 5 || (r == 0 && (self.f_ah == 0)) {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.decodeAcFirst(a_src, a_s); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = self.decodeAcRefine(a_src, a_s); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if v_offset > uint64(len(a_workbuf)) {
+			status = ErrBadWorkbufLength
+			goto exit
+		}
+		self.storeCoefs(a_workbuf[v_offset:])
+	}
+
+ok:
+	self.c_decode_block.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_block.coroSuspPoint = csp
+	self.c_decode_block.v_c = v_c
+	self.c_decode_block.v_i = v_i
+	self.c_decode_block.v_index = v_index
+	self.c_decode_block.v_offset = v_offset
+
+exit:
+	return status
+}
+
+// -------- func decoder.load_coefs
+
+func (self *Decoder) loadCoefs(a_coefs []byte) {
+
+	var (
+		v_i uint32
+	)
+
+	copy(self.f_coef_bytes[:], a_coefs)
+	v_i = 0
+	for v_i < 64 {
+		self.f_block[v_i] = (uint16(self.f_coef_bytes[(2*v_i)]) | (uint16(self.f_coef_bytes[((2*v_i)+1)]) << 8))
+		v_i += 1
+	}
+}
+
+// -------- func decoder.store_coefs
+
+func (self *Decoder) storeCoefs(a_coefs []byte) {
+
+	var (
+		v_i uint32
+	)
+
+	v_i = 0
+	for v_i < 64 {
+		self.f_coef_bytes[(2 * v_i)] = uint8((self.f_block[v_i] & 255))
+		self.f_coef_bytes[((2 * v_i) + 1)] = uint8((self.f_block[v_i] >> 8))
+		v_i += 1
+	}
+	copy(a_coefs, self.f_coef_bytes[:])
+}
+
+// -------- func decoder.decode_dc_first
+
+func (self *Decoder) decodeDcFirst(a_src base.IOReader, a_s uint32) (status error) {
+
+	var (
+		v_c    uint32
+		v_n    uint32
+		v_v    uint32
+		v_coef uint32
+	)
+
+	r := self.c_decode_dc_first.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_dc_first.v_c
+		v_n = self.c_decode_dc_first.v_n
+		v_v = self.c_decode_dc_first.v_v
+		v_coef = self.c_decode_dc_first.v_coef
+	}
+
+	if r == 0 {
+		v_c = self.f_scan_comp[a_s]
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_n = self.decodeHuffman(self.f_scan_td[a_s])
+		if v_n > 15 {
+			status = ErrBadHuffmanCode
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_v = self.takeBits(v_n)
+		self.f_dc_pred[v_c] += self.extend(v_v, v_n)
+		v_coef = (uint32(self.f_dc_pred[v_c]) << self.f_al)
+		self.f_block[0] = uint16(((v_coef) & ((uint32(1) << (16)) - 1)))
+	}
+
+	self.c_decode_dc_first.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_dc_first.coroSuspPoint = csp
+	self.c_decode_dc_first.v_c = v_c
+	self.c_decode_dc_first.v_n = v_n
+	self.c_decode_dc_first.v_v = v_v
+	self.c_decode_dc_first.v_coef = v_coef
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_dc_refine
+
+func (self *Decoder) decodeDcRefine(a_src base.IOReader) (status error) {
+
+	r := self.c_decode_dc_refine.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if self.takeBits(1) != 0 {
+			self.f_block[0] |= uint16((uint32(1) << self.f_al))
+		}
+	}
+
+	self.c_decode_dc_refine.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_dc_refine.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_ac_first
+
+func (self *Decoder) decodeAcFirst(a_src base.IOReader, a_s uint32) (status error) {
+
+	var (
+		v_t      uint32
+		v_k      uint32
+		v_rs     uint32
+		v_r      uint32
+		v_n      uint32
+		v_v      uint32
+		v_coef   uint32
+		v_eobrun uint32
+	)
+
+	r := self.c_decode_ac_first.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_t = self.c_decode_ac_first.v_t
+		v_k = self.c_decode_ac_first.v_k
+		v_rs = self.c_decode_ac_first.v_rs
+		v_r = self.c_decode_ac_first.v_r
+		v_n = self.c_decode_ac_first.v_n
+		v_v = self.c_decode_ac_first.v_v
+		v_coef = self.c_decode_ac_first.v_coef
+		v_eobrun = self.c_decode_ac_first.v_eobrun
+	}
+
+	if r == 0 {
+		if self.f_eobrun > 0 {
+			self.f_eobrun -= 1
+			status = nil
+			goto ok
+		}
+		v_t = (4 + self.f_scan_ta[a_s])
+		v_k = base.U32Max(self.f_ss, 1)
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+	label_0:
+		for r != 0 || (v_k <= self.f_se) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_rs = self.decodeHuffman(v_t)
+				if v_rs > 255 {
+					status = ErrBadHuffmanCode
+					goto exit
+				}
+				v_r = (v_rs >> 4)
+				v_n = (v_rs & 15)
+			}
+			if r == 0 || (2 <= r && r <= 3) {
+				if r == 2 || (r == 0 && (v_n != 0)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_v = self.takeBits(v_n)
+						v_coef = (uint32(self.extend(v_v, v_n)) << self.f_al)
+						self.f_block[zigzag[(v_k+v_r)]] = uint16(((v_coef) & ((uint32(1) << (16)) - 1)))
+						v_k += (v_r + 1)
+					}
+				} else if r == 0 && (v_r == 15) {
+					v_k += 16
+				} else {
+					if r == 0 || r == 3 {
+						if r == 3 || (r == 0 && ((v_r > 0) && self.f_progressive)) {
+							if r == 0 || r == 3 {
+								if r == 3 {
+									r = 0
+								}
+								csp = 3
+								if status = self.fillBits(a_src); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								v_eobrun = (((uint32(1) << v_r) - 1) + self.takeBits(v_r))
+								self.f_eobrun = base.U32Min(v_eobrun, 32767)
+							}
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_decode_ac_first.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_ac_first.coroSuspPoint = csp
+	self.c_decode_ac_first.v_t = v_t
+	self.c_decode_ac_first.v_k = v_k
+	self.c_decode_ac_first.v_rs = v_rs
+	self.c_decode_ac_first.v_r = v_r
+	self.c_decode_ac_first.v_n = v_n
+	self.c_decode_ac_first.v_v = v_v
+	self.c_decode_ac_first.v_coef = v_coef
+	self.c_decode_ac_first.v_eobrun = v_eobrun
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_ac_refine
+
+func (self *Decoder) decodeAcRefine(a_src base.IOReader, a_s uint32) (status error) {
+
+	var (
+		v_t      uint32
+		v_p1     uint32
+		v_k      uint32
+		v_v      uint16
+		v_z      uint32
+		v_rs     uint32
+		v_r      uint32
+		v_n      uint32
+		v_eobrun uint32
+		v_i      uint32
+	)
+
+	r := self.c_decode_ac_refine.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_t = self.c_decode_ac_refine.v_t
+		v_p1 = self.c_decode_ac_refine.v_p1
+		v_k = self.c_decode_ac_refine.v_k
+		v_v = self.c_decode_ac_refine.v_v
+		v_z = self.c_decode_ac_refine.v_z
+		v_rs = self.c_decode_ac_refine.v_rs
+		v_r = self.c_decode_ac_refine.v_r
+		v_n = self.c_decode_ac_refine.v_n
+		v_eobrun = self.c_decode_ac_refine.v_eobrun
+		v_i = self.c_decode_ac_refine.v_i
+	}
+
+	if r == 0 {
+		v_t = (4 + self.f_scan_ta[a_s])
+		v_p1 = (uint32(1) << self.f_al)
+		v_k = self.f_ss
+		v_v = 0
+		v_z = 0
+	}
+	if r == 0 || (1 <= r && r <= 4) {
+		if (1 <= r && r <= 4) || (r == 0 && (self.f_eobrun == 0)) {
+			if r == 0 || (1 <= r && r <= 4) {
+			label_0:
+				for r != 0 || (v_k <= self.f_se) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_rs = self.decodeHuffman(v_t)
+						if v_rs > 255 {
+							status = ErrBadHuffmanCode
+							goto exit
+						}
+						v_r = (v_rs >> 4)
+						v_n = (v_rs & 15)
+						v_v = 0
+					}
+					if r == 0 || (2 <= r && r <= 3) {
+						if r == 2 || (r == 0 && (v_n != 0)) {
+							if r == 0 || r == 2 {
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if status = self.fillBits(a_src); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								if self.takeBits(1) != 0 {
+									v_v = uint16(v_p1)
+								} else {
+									v_v = (uint16(0) - uint16(v_p1))
+								}
+							}
+						} else if r == 3 || (r == 0 && (v_r != 15)) {
+							if r == 0 {
+								self.f_eobrun = (uint32(1) << v_r)
+							}
+							if r == 0 || r == 3 {
+								if r == 3 || (r == 0 && (v_r > 0)) {
+									if r == 0 || r == 3 {
+										if r == 3 {
+											r = 0
+										}
+										csp = 3
+										if status = self.fillBits(a_src); status != nil {
+											goto suspend
+										}
+									}
+									if r == 0 {
+										v_eobrun = (self.f_eobrun + self.takeBits(v_r))
+										self.f_eobrun = base.U32Min(v_eobrun, 32767)
+									}
+								}
+							}
+							if r == 0 {
+								break label_0
+							}
+						}
+					}
+					if r == 0 {
+						v_z = v_r
+					}
+					if r == 0 || r == 4 {
+					label_1:
+						for r != 0 || (v_k <= self.f_se) {
+							if r == 0 {
+								v_i = uint32(zigzag[v_k])
+							}
+							if r == 0 || r == 4 {
+								if r == 4 || (r == 0 && (self.f_block[v_i] != 0)) {
+									if r == 0 || r == 4 {
+										if r == 4 {
+											r = 0
+										}
+										csp = 4
+										if status = self.refineCoefficient(a_src, v_i, v_p1); status != nil {
+											goto suspend
+										}
+									}
+								} else if r == 0 && (v_z == 0) {
+									break label_1
+								} else {
+									v_z -= 1
+								}
+							}
+							if r == 0 {
+								v_k += 1
+							}
+						}
+					}
+					if r == 0 {
+						if v_v != 0 {
+							self.f_block[zigzag[v_k]] = v_v
+						}
+						v_k += 1
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 || (r == 0 && (self.f_eobrun > 0)) {
+			if r == 0 || r == 5 {
+				for r != 0 || (v_k <= self.f_se) {
+					if r == 0 || r == 5 {
+						if r == 5 || (r == 0 && (self.f_block[zigzag[v_k]] != 0)) {
+							if r == 0 || r == 5 {
+								if r == 5 {
+									r = 0
+								}
+								csp = 5
+								if status = self.refineCoefficient(a_src, uint32(zigzag[v_k]), v_p1); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+					if r == 0 {
+						v_k += 1
+					}
+				}
+			}
+			if r == 0 {
+				self.f_eobrun = base.U32SatSub(self.f_eobrun, 1)
+			}
+		}
+	}
+
+	self.c_decode_ac_refine.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_ac_refine.coroSuspPoint = csp
+	self.c_decode_ac_refine.v_t = v_t
+	self.c_decode_ac_refine.v_p1 = v_p1
+	self.c_decode_ac_refine.v_k = v_k
+	self.c_decode_ac_refine.v_v = v_v
+	self.c_decode_ac_refine.v_z = v_z
+	self.c_decode_ac_refine.v_rs = v_rs
+	self.c_decode_ac_refine.v_r = v_r
+	self.c_decode_ac_refine.v_n = v_n
+	self.c_decode_ac_refine.v_eobrun = v_eobrun
+	self.c_decode_ac_refine.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.refine_coefficient
+
+func (self *Decoder) refineCoefficient(a_src base.IOReader, a_i uint32, a_p1 uint32) (status error) {
+
+	var (
+		v_v uint16
+	)
+
+	r := self.c_refine_coefficient.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_v = self.c_refine_coefficient.v_v
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if self.takeBits(1) == 0 {
+			status = nil
+			goto ok
+		}
+		v_v = self.f_block[a_i]
+		if (uint32(v_v) & a_p1) != 0 {
+			status = nil
+			goto ok
+		}
+		if (v_v >> 15) == 0 {
+			self.f_block[a_i] = (v_v + uint16(a_p1))
+		} else {
+			self.f_block[a_i] = (v_v - uint16(a_p1))
+		}
+	}
+
+ok:
+	self.c_refine_coefficient.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_refine_coefficient.coroSuspPoint = csp
+	self.c_refine_coefficient.v_v = v_v
+
+exit:
+	return status
+}
+
+// -------- func decoder.write_progressive_blocks
+
+func (self *Decoder) writeProgressiveBlocks(a_dst *base.ImageBuffer, a_workbuf []byte) {
+
+	var (
+		v_c      uint32
+		v_by     uint32
+		v_bx     uint32
+		v_index  uint64
+		v_offset uint64
+	)
+
+	v_c = 0
+	for v_c < self.f_num_components {
+		v_by = 0
+		for v_by < self.f_comp_height_in_blocks[v_c] {
+			v_bx = 0
+			for v_bx < self.f_comp_width_in_blocks[v_c] {
+				v_index = (self.f_comp_offset[v_c] + (uint64(v_by) * uint64(self.f_comp_stride[v_c])) + uint64(v_bx))
+				v_offset = (v_index * 128)
+				if v_offset < uint64(len(a_workbuf)) {
+					self.loadCoefs(a_workbuf[v_offset:])
+					self.writeBlock(a_dst, v_c, v_bx, v_by)
+				}
+				v_bx += 1
+			}
+			v_by += 1
+		}
+		v_c += 1
+	}
+}
+
+// -------- func decoder.write_block
+
+func (self *Decoder) writeBlock(a_dst *base.ImageBuffer, a_c uint32, a_bx uint32, a_by uint32) {
+
+	var (
+		v_tab base.TableU8
+		v_x0  uint64
+		v_y   uint32
+		v_py  uint32
+		v_row []byte
+	)
+
+	self.idctColumns(a_c)
+	self.idctRows()
+	v_tab = a_dst.Plane(a_c)
+	v_x0 = (uint64(a_bx) * 8)
+	v_y = 0
+label_0:
+	for v_y < 8 {
+		v_py = ((a_by * 8) + v_y)
+		if uint64(v_py) >= uint64(v_tab.Height) {
+			break label_0
+		}
+		v_row = v_tab.Row(v_py)
+		if v_x0 < uint64(len(v_row)) {
+			v_row = v_row[v_x0:]
+			if uint64(len(v_row)) > 8 {
+				v_row = v_row[:8]
+			}
+			copy(v_row, self.f_pixels[(8*v_y):])
+		}
+		v_y += 1
+	}
+}
+
+// -------- func decoder.idct_columns
+
+func (self *Decoder) idctColumns(a_c uint32) {
+
+	var (
+		v_in0 int64
+		v_in1 int64
+		v_in2 int64
+		v_in3 int64
+		v_in4 int64
+		v_in5 int64
+		v_in6 int64
+		v_in7 int64
+		v_e   int64
+		v_e0  int64
+		v_e1  int64
+		v_e2  int64
+		v_e3  int64
+		v_e10 int64
+		v_e11 int64
+		v_e12 int64
+		v_e13 int64
+		v_z1  int64
+		v_z2  int64
+		v_z3  int64
+		v_z4  int64
+		v_z5  int64
+		v_o0  int64
+		v_o1  int64
+		v_o2  int64
+		v_o3  int64
+		v_x   uint32
+	)
+
+	v_in0 = 0
+	v_in1 = 0
+	v_in2 = 0
+	v_in3 = 0
+	v_in4 = 0
+	v_in5 = 0
+	v_in6 = 0
+	v_in7 = 0
+	v_e = 0
+	v_e0 = 0
+	v_e1 = 0
+	v_e2 = 0
+	v_e3 = 0
+	v_e10 = 0
+	v_e11 = 0
+	v_e12 = 0
+	v_e13 = 0
+	v_z1 = 0
+	v_z2 = 0
+	v_z3 = 0
+	v_z4 = 0
+	v_z5 = 0
+	v_o0 = 0
+	v_o1 = 0
+	v_o2 = 0
+	v_o3 = 0
+	v_x = 0
+	for v_x < 8 {
+		v_in0 = self.dequantize(a_c, (0 + v_x))
+		v_in1 = self.dequantize(a_c, (8 + v_x))
+		v_in2 = self.dequantize(a_c, (16 + v_x))
+		v_in3 = self.dequantize(a_c, (24 + v_x))
+		v_in4 = self.dequantize(a_c, (32 + v_x))
+		v_in5 = self.dequantize(a_c, (40 + v_x))
+		v_in6 = self.dequantize(a_c, (48 + v_x))
+		v_in7 = self.dequantize(a_c, (56 + v_x))
+		v_e = ((v_in2 + v_in6) * 4433)
+		v_e2 = (v_e - (v_in6 * 15137))
+		v_e3 = (v_e + (v_in2 * 6270))
+		v_e0 = ((v_in0 + v_in4) * 8192)
+		v_e1 = ((v_in0 - v_in4) * 8192)
+		v_e10 = (v_e0 + v_e3)
+		v_e13 = (v_e0 - v_e3)
+		v_e11 = (v_e1 + v_e2)
+		v_e12 = (v_e1 - v_e2)
+		v_z1 = (v_in7 + v_in1)
+		v_z2 = (v_in5 + v_in3)
+		v_z3 = (v_in7 + v_in3)
+		v_z4 = (v_in5 + v_in1)
+		v_z5 = ((v_z3 + v_z4) * 9633)
+		v_o0 = (((v_in7 * 2446) - (v_z1 * 7373)) + (v_z5 - (v_z3 * 16069)))
+		v_o1 = (((v_in5 * 16819) - (v_z2 * 20995)) + (v_z5 - (v_z4 * 3196)))
+		v_o2 = (((v_in3 * 25172) - (v_z2 * 20995)) + (v_z5 - (v_z3 * 16069)))
+		v_o3 = (((v_in1 * 12299) - (v_z1 * 7373)) + (v_z5 - (v_z4 * 3196)))
+		self.f_workspace[(0 + v_x)] = (((v_e10 + v_o3) + 1024) >> 11)
+		self.f_workspace[(56 + v_x)] = (((v_e10 - v_o3) + 1024) >> 11)
+		self.f_workspace[(8 + v_x)] = (((v_e11 + v_o2) + 1024) >> 11)
+		self.f_workspace[(48 + v_x)] = (((v_e11 - v_o2) + 1024) >> 11)
+		self.f_workspace[(16 + v_x)] = (((v_e12 + v_o1) + 1024) >> 11)
+		self.f_workspace[(40 + v_x)] = (((v_e12 - v_o1) + 1024) >> 11)
+		self.f_workspace[(24 + v_x)] = (((v_e13 + v_o0) + 1024) >> 11)
+		self.f_workspace[(32 + v_x)] = (((v_e13 - v_o0) + 1024) >> 11)
+		v_x += 1
+	}
+}
+
+// -------- func decoder.dequantize
+
+func (self *Decoder) dequantize(a_c uint32, a_i uint32) int64 {
+
+	var (
+		v_coef int64
+	)
+
+	v_coef = ((int64(self.f_block[a_i]) ^ 32768) - 32768)
+	return (v_coef * int64(self.f_comp_quant[a_c][a_i]))
+}
+
+// -------- func decoder.idct_rows
+
+func (self *Decoder) idctRows() {
+
+	var (
+		v_in0 int64
+		v_in1 int64
+		v_in2 int64
+		v_in3 int64
+		v_in4 int64
+		v_in5 int64
+		v_in6 int64
+		v_in7 int64
+		v_e   int64
+		v_e0  int64
+		v_e1  int64
+		v_e2  int64
+		v_e3  int64
+		v_e10 int64
+		v_e11 int64
+		v_e12 int64
+		v_e13 int64
+		v_z1  int64
+		v_z2  int64
+		v_z3  int64
+		v_z4  int64
+		v_z5  int64
+		v_o0  int64
+		v_o1  int64
+		v_o2  int64
+		v_o3  int64
+		v_y   uint32
+		v_r   uint32
+	)
+
+	v_in0 = 0
+	v_in1 = 0
+	v_in2 = 0
+	v_in3 = 0
+	v_in4 = 0
+	v_in5 = 0
+	v_in6 = 0
+	v_in7 = 0
+	v_e = 0
+	v_e0 = 0
+	v_e1 = 0
+	v_e2 = 0
+	v_e3 = 0
+	v_e10 = 0
+	v_e11 = 0
+	v_e12 = 0
+	v_e13 = 0
+	v_z1 = 0
+	v_z2 = 0
+	v_z3 = 0
+	v_z4 = 0
+	v_z5 = 0
+	v_o0 = 0
+	v_o1 = 0
+	v_o2 = 0
+	v_o3 = 0
+	v_y = 0
+	for v_y < 8 {
+		v_r = (8 * v_y)
+		v_in0 = self.f_workspace[(v_r + 0)]
+		v_in1 = self.f_workspace[(v_r + 1)]
+		v_in2 = self.f_workspace[(v_r + 2)]
+		v_in3 = self.f_workspace[(v_r + 3)]
+		v_in4 = self.f_workspace[(v_r + 4)]
+		v_in5 = self.f_workspace[(v_r + 5)]
+		v_in6 = self.f_workspace[(v_r + 6)]
+		v_in7 = self.f_workspace[(v_r + 7)]
+		v_e = ((v_in2 + v_in6) * 4433)
+		v_e2 = (v_e - (v_in6 * 15137))
+		v_e3 = (v_e + (v_in2 * 6270))
+		v_e0 = ((v_in0 + v_in4) * 8192)
+		v_e1 = ((v_in0 - v_in4) * 8192)
+		v_e10 = (v_e0 + v_e3)
+		v_e13 = (v_e0 - v_e3)
+		v_e11 = (v_e1 + v_e2)
+		v_e12 = (v_e1 - v_e2)
+		v_z1 = (v_in7 + v_in1)
+		v_z2 = (v_in5 + v_in3)
+		v_z3 = (v_in7 + v_in3)
+		v_z4 = (v_in5 + v_in1)
+		v_z5 = ((v_z3 + v_z4) * 9633)
+		v_o0 = (((v_in7 * 2446) - (v_z1 * 7373)) + (v_z5 - (v_z3 * 16069)))
+		v_o1 = (((v_in5 * 16819) - (v_z2 * 20995)) + (v_z5 - (v_z4 * 3196)))
+		v_o2 = (((v_in3 * 25172) - (v_z2 * 20995)) + (v_z5 - (v_z3 * 16069)))
+		v_o3 = (((v_in1 * 12299) - (v_z1 * 7373)) + (v_z5 - (v_z4 * 3196)))
+		self.f_pixels[(v_r + 0)] = self.clamp((v_e10 + v_o3))
+		self.f_pixels[(v_r + 7)] = self.clamp((v_e10 - v_o3))
+		self.f_pixels[(v_r + 1)] = self.clamp((v_e11 + v_o2))
+		self.f_pixels[(v_r + 6)] = self.clamp((v_e11 - v_o2))
+		self.f_pixels[(v_r + 2)] = self.clamp((v_e12 + v_o1))
+		self.f_pixels[(v_r + 5)] = self.clamp((v_e12 - v_o1))
+		self.f_pixels[(v_r + 3)] = self.clamp((v_e13 + v_o0))
+		self.f_pixels[(v_r + 4)] = self.clamp((v_e13 - v_o0))
+		v_y += 1
+	}
+}
+
+// -------- func decoder.clamp
+
+func (self *Decoder) clamp(a_x int64) uint8 {
+
+	var (
+		v_v int64
+	)
+
+	v_v = (((a_x + 131072) >> 18) + 128)
+	return uint8(base.I64Min(base.I64Max(v_v, 0), 255))
+}
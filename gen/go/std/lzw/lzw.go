@@ -0,0 +1,750 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package lzw
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadCode             = base.NewError("lzw: bad code")
+	ErrCyclicalPrefixChain = base.NewError("lzw: cyclical prefix chain")
+	ErrBadLiteral          = base.NewError("lzw: bad literal")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a lzw.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_literal_width uint32
+	f_tiff_mode     bool
+	f_stack         [4096]uint8
+	f_suffixes      [4096]uint8
+	f_prefixes      [4096]uint16
+
+	c_decode struct {
+		coroSuspPoint   uint32
+		v_literal_width uint32
+		v_tiff_mode     bool
+		v_clear_code    uint32
+		v_end_code      uint32
+		v_save_code     uint32
+		v_prev_code     uint32
+		v_width         uint32
+		v_bits          uint32
+		v_n_bits        uint32
+		v_code          uint32
+		v_msb_bits      uint32
+		v_s             uint32
+		v_c             uint32
+		v_n_copied      uint64
+	}
+}
+
+// Encoder holds the state of a lzw.encoder. Its zero value is ready to use.
+type Encoder struct {
+	status error
+
+	f_literal_width uint32
+	f_eof           bool
+	f_bits          uint32
+	f_n_bits        uint32
+	f_buf           [4096]uint8
+	f_buf_ri        uint32
+	f_buf_wi        uint32
+	f_htable        [16384]uint32
+
+	c_encode struct {
+		coroSuspPoint   uint32
+		v_literal_width uint32
+		v_clear_code    uint32
+		v_end_code      uint32
+		v_hi            uint32
+		v_width         uint32
+		v_code          uint32
+		v_has_code      bool
+		v_literal       uint32
+		v_key           uint32
+		v_h             uint32
+		v_entry         uint32
+		v_z             error
+	}
+
+	c_fill_buf struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_write_code struct {
+		coroSuspPoint uint32
+		v_n_bits      uint32
+	}
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_literal_width
+
+func (self *Decoder) SetLiteralWidth(a_lw uint32) {
+	if base.IsError(self.status) {
+		return
+	}
+	if a_lw < 2 || a_lw > 8 {
+		self.status = base.ErrBadArgument
+		return
+	}
+
+	self.f_literal_width = a_lw
+}
+
+// -------- func decoder.set_tiff_mode
+
+func (self *Decoder) SetTiffMode(a_m bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_tiff_mode = a_m
+}
+
+// -------- func decoder.decode
+
+func (self *Decoder) Decode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_literal_width uint32
+		v_tiff_mode     bool
+		v_clear_code    uint32
+		v_end_code      uint32
+		v_save_code     uint32
+		v_prev_code     uint32
+		v_width         uint32
+		v_bits          uint32
+		v_n_bits        uint32
+		v_code          uint32
+		v_msb_bits      uint32
+		v_s             uint32
+		v_c             uint32
+		v_expansion     []byte
+		v_n_copied      uint64
+		t_0             uint8
+		t_1             uint8
+	)
+
+	r := self.c_decode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_literal_width = self.c_decode.v_literal_width
+		v_tiff_mode = self.c_decode.v_tiff_mode
+		v_clear_code = self.c_decode.v_clear_code
+		v_end_code = self.c_decode.v_end_code
+		v_save_code = self.c_decode.v_save_code
+		v_prev_code = self.c_decode.v_prev_code
+		v_width = self.c_decode.v_width
+		v_bits = self.c_decode.v_bits
+		v_n_bits = self.c_decode.v_n_bits
+		v_code = self.c_decode.v_code
+		v_msb_bits = self.c_decode.v_msb_bits
+		v_s = self.c_decode.v_s
+		v_c = self.c_decode.v_c
+		v_n_copied = self.c_decode.v_n_copied
+	}
+
+	if r == 0 {
+		v_literal_width = 8
+		if (self.f_literal_width >= 2) && !self.f_tiff_mode {
+			v_literal_width = self.f_literal_width
+		}
+		v_tiff_mode = self.f_tiff_mode
+		v_clear_code = (uint32(1) << v_literal_width)
+		v_end_code = (v_clear_code + 1)
+		v_save_code = v_end_code
+		v_prev_code = 0
+		v_width = (v_literal_width + 1)
+		v_bits = 0
+		v_n_bits = 0
+	}
+	if r == 0 || (1 <= r && r <= 4) {
+	label_0:
+		for {
+			if r == 0 || (1 <= r && r <= 2) {
+				for r != 0 || (v_n_bits < v_width) {
+					if r == 0 || (1 <= r && r <= 2) {
+						if r == 1 || (r == 0 && v_tiff_mode) {
+							if r == 0 || r == 1 {
+								if r == 1 {
+									r = 0
+								}
+								csp = 1
+								if t_0, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits = (((v_bits & 4095) << 8) | uint32(t_0))
+							}
+						} else {
+							if r == 0 || r == 2 {
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if t_1, status = a_src.ReadU8(); status != nil {
+									goto suspend
+								}
+								v_bits |= (uint32(t_1) << v_n_bits)
+							}
+						}
+					}
+					if r == 0 {
+						v_n_bits += 8
+					}
+				}
+			}
+			if r == 0 {
+				v_code = 0
+				if v_tiff_mode {
+					v_msb_bits = (v_bits >> (v_n_bits - v_width))
+					v_code = ((v_msb_bits) & ((uint32(1) << (v_width)) - 1))
+				} else {
+					v_code = ((v_bits) & ((uint32(1) << (v_width)) - 1))
+					v_bits >>= v_width
+				}
+				v_n_bits -= v_width
+			}
+			if r == 0 || (3 <= r && r <= 4) {
+				if r == 3 || (r == 0 && (v_code < v_clear_code)) {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = a_dst.WriteU8(uint8(v_code)); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						if v_save_code <= 4095 {
+							self.f_suffixes[v_save_code] = uint8(v_code)
+							self.f_prefixes[v_save_code] = uint16(v_prev_code)
+						}
+					}
+				} else if r == 0 && (v_code == v_clear_code) {
+					v_save_code = v_end_code
+					v_prev_code = 0
+					v_width = (v_literal_width + 1)
+					continue label_0
+				} else if r == 0 && (v_code == v_end_code) {
+					status = nil
+					goto ok
+				} else if r == 4 || (r == 0 && (v_code <= v_save_code)) {
+					if r == 0 {
+						v_s = 4095
+						v_c = v_code
+						if v_code == v_save_code {
+							v_s -= 1
+							v_c = v_prev_code
+						}
+						for v_c >= v_clear_code {
+							self.f_stack[v_s] = self.f_suffixes[v_c]
+							if v_s == 0 {
+								status = ErrCyclicalPrefixChain
+								goto exit
+							}
+							v_s -= 1
+							v_c = uint32(self.f_prefixes[v_c])
+						}
+						self.f_stack[v_s] = uint8(v_c)
+						if v_code == v_save_code {
+							self.f_stack[4095] = uint8(v_c)
+						}
+					}
+					if r == 0 || r == 4 {
+					label_1:
+						for {
+							if r == 0 {
+								v_expansion = self.f_stack[v_s:]
+								v_n_copied = a_dst.CopyFromSlice(v_expansion)
+								if v_n_copied == uint64(len(v_expansion)) {
+									break label_1
+								}
+								v_s = ((v_s + uint32((v_n_copied & 4095))) & 4095)
+							}
+							if r == 0 || r == 4 {
+								status = base.SuspensionShortWrite
+								if r == 4 {
+									r = 0
+									status = nil
+								} else {
+									csp = 4
+									goto suspend
+								}
+							}
+						}
+					}
+					if r == 0 {
+						if v_save_code <= 4095 {
+							self.f_suffixes[v_save_code] = uint8(v_c)
+							self.f_prefixes[v_save_code] = uint16(v_prev_code)
+						}
+					}
+				} else {
+					status = ErrBadCode
+					goto exit
+				}
+			}
+			if r == 0 {
+				if v_save_code <= 4095 {
+					v_save_code += 1
+					if v_tiff_mode {
+						if ((v_save_code + 1) == (uint32(1) << v_width)) && (v_width < 12) {
+							v_width += 1
+						}
+					} else if (v_save_code == (uint32(1) << v_width)) && (v_width < 12) {
+						v_width += 1
+					}
+				}
+				v_prev_code = v_code
+			}
+		}
+	}
+
+ok:
+	self.c_decode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode.coroSuspPoint = csp
+	self.c_decode.v_literal_width = v_literal_width
+	self.c_decode.v_tiff_mode = v_tiff_mode
+	self.c_decode.v_clear_code = v_clear_code
+	self.c_decode.v_end_code = v_end_code
+	self.c_decode.v_save_code = v_save_code
+	self.c_decode.v_prev_code = v_prev_code
+	self.c_decode.v_width = v_width
+	self.c_decode.v_bits = v_bits
+	self.c_decode.v_n_bits = v_n_bits
+	self.c_decode.v_code = v_code
+	self.c_decode.v_msb_bits = v_msb_bits
+	self.c_decode.v_s = v_s
+	self.c_decode.v_c = v_c
+	self.c_decode.v_n_copied = v_n_copied
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.set_literal_width
+
+func (self *Encoder) SetLiteralWidth(a_lw uint32) {
+	if base.IsError(self.status) {
+		return
+	}
+	if a_lw < 2 || a_lw > 8 {
+		self.status = base.ErrBadArgument
+		return
+	}
+
+	self.f_literal_width = a_lw
+}
+
+// -------- func encoder.encode
+
+func (self *Encoder) Encode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_literal_width uint32
+		v_clear_code    uint32
+		v_end_code      uint32
+		v_hi            uint32
+		v_width         uint32
+		v_code          uint32
+		v_has_code      bool
+		v_literal       uint32
+		v_key           uint32
+		v_h             uint32
+		v_entry         uint32
+		v_z             error
+		t_0             error
+	)
+
+	r := self.c_encode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_literal_width = self.c_encode.v_literal_width
+		v_clear_code = self.c_encode.v_clear_code
+		v_end_code = self.c_encode.v_end_code
+		v_hi = self.c_encode.v_hi
+		v_width = self.c_encode.v_width
+		v_code = self.c_encode.v_code
+		v_has_code = self.c_encode.v_has_code
+		v_literal = self.c_encode.v_literal
+		v_key = self.c_encode.v_key
+		v_h = self.c_encode.v_h
+		v_entry = self.c_encode.v_entry
+		v_z = self.c_encode.v_z
+	}
+
+	if r == 0 {
+		v_literal_width = 8
+		if self.f_literal_width >= 2 {
+			v_literal_width = self.f_literal_width
+		}
+		v_clear_code = (uint32(1) << v_literal_width)
+		v_end_code = (v_clear_code + 1)
+		v_hi = v_end_code
+		v_width = (v_literal_width + 1)
+		v_code = 0
+		v_has_code = false
+		v_literal = 0
+		v_key = 0
+		v_h = 0
+		v_entry = 0
+		self.f_eof = false
+		self.f_bits = 0
+		self.f_n_bits = 0
+		self.f_buf_ri = 0
+		self.f_buf_wi = 0
+		self.clearTable()
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.writeCode(a_dst, v_clear_code, v_width); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || (2 <= r && r <= 4) {
+	label_0:
+		for {
+			if r == 0 || r == 2 {
+				if r == 2 || (r == 0 && (self.f_buf_ri >= self.f_buf_wi)) {
+					if r == 0 {
+						if self.f_eof {
+							break label_0
+						}
+						t_0 = self.fillBuf(a_src)
+						v_z = t_0
+					}
+					if r == 0 || r == 2 {
+						if r == 0 && base.IsError(v_z) {
+							self.f_eof = true
+						} else if r == 2 || (r == 0 && base.IsSuspension(v_z)) {
+							if r == 0 || r == 2 {
+								status = v_z
+								if r == 2 {
+									r = 0
+									status = nil
+								} else {
+									if base.IsError(status) {
+										goto exit
+									}
+									if status != nil {
+										csp = 2
+										goto suspend
+									}
+								}
+							}
+						}
+					}
+					if r == 0 {
+						continue label_0
+					}
+				}
+			}
+			if r == 0 {
+				v_literal = uint32(self.f_buf[self.f_buf_ri])
+				self.f_buf_ri += 1
+				if v_literal >= v_clear_code {
+					status = ErrBadLiteral
+					goto exit
+				}
+				if !v_has_code {
+					v_code = v_literal
+					v_has_code = true
+					continue label_0
+				}
+				v_key = ((v_code << 8) | v_literal)
+				v_h = (((v_key >> 12) ^ v_key) & 16383)
+			label_1:
+				for {
+					v_entry = self.f_htable[v_h]
+					if v_entry == 0 {
+						break label_1
+					}
+					if (v_entry >> 12) == v_key {
+						v_code = (v_entry & 4095)
+						continue label_0
+					}
+					v_h = ((v_h + 1) & 16383)
+				}
+			}
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.writeCode(a_dst, v_code, v_width); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_code = v_literal
+			}
+			if r == 0 || r == 4 {
+				if r == 4 || (r == 0 && (v_hi >= 4094)) {
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.writeCode(a_dst, v_clear_code, v_width); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_hi = v_end_code
+						v_width = (v_literal_width + 1)
+						self.clearTable()
+						continue label_0
+					}
+				}
+			}
+			if r == 0 {
+				v_hi += 1
+				if (v_hi == (uint32(1) << v_width)) && (v_width < 12) {
+					v_width += 1
+				}
+				self.f_htable[v_h] = ((v_key << 12) | v_hi)
+			}
+		}
+	}
+	if r == 0 || (5 <= r && r <= 6) {
+		if (5 <= r && r <= 6) || (r == 0 && v_has_code) {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.writeCode(a_dst, v_code, v_width); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 6 || (r == 0 && (v_hi >= 4094)) {
+					if r == 0 || r == 6 {
+						if r == 6 {
+							r = 0
+						}
+						csp = 6
+						if status = self.writeCode(a_dst, v_clear_code, v_width); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_width = (v_literal_width + 1)
+					}
+				} else {
+					v_hi += 1
+					if (v_hi == (uint32(1) << v_width)) && (v_width < 12) {
+						v_width += 1
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 7 {
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if status = self.writeCode(a_dst, v_end_code, v_width); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 8 {
+		if r == 8 || (r == 0 && (self.f_n_bits > 0)) {
+			if r == 0 || r == 8 {
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if status = a_dst.WriteU8(uint8((self.f_bits & 255))); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_bits = 0
+				self.f_n_bits = 0
+			}
+		}
+	}
+
+	self.c_encode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode.coroSuspPoint = csp
+	self.c_encode.v_literal_width = v_literal_width
+	self.c_encode.v_clear_code = v_clear_code
+	self.c_encode.v_end_code = v_end_code
+	self.c_encode.v_hi = v_hi
+	self.c_encode.v_width = v_width
+	self.c_encode.v_code = v_code
+	self.c_encode.v_has_code = v_has_code
+	self.c_encode.v_literal = v_literal
+	self.c_encode.v_key = v_key
+	self.c_encode.v_h = v_h
+	self.c_encode.v_entry = v_entry
+	self.c_encode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.fill_buf
+
+func (self *Encoder) fillBuf(a_src base.IOReader) (status error) {
+
+	var (
+		v_w base.IOWriter
+		u_w base.IOBuffer
+		v_c uint8
+		t_0 uint8
+	)
+
+	r := self.c_fill_buf.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_fill_buf.v_c
+	}
+
+	if r == 0 {
+		self.f_buf_ri = 0
+		self.f_buf_wi = 0
+		if a_src.Available() > 0 {
+			v_w = base.IOWriter{}
+			{
+				o_0_a_src := a_src
+				o_0_v_w := v_w
+				v_w.Set(&u_w, self.f_buf[:])
+				v_w.CopyFromReader32(&a_src, 4096)
+				self.f_buf_wi = (4096 - uint32(base.U64Min(v_w.Available(), 4096)))
+				v_w = o_0_v_w
+				a_src = o_0_a_src
+			}
+			status = nil
+			goto ok
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_c = t_0
+	}
+	if r == 0 {
+		self.f_buf[0] = v_c
+		self.f_buf_wi = 1
+	}
+
+ok:
+	self.c_fill_buf.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_buf.coroSuspPoint = csp
+	self.c_fill_buf.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func encoder.clear_table
+
+func (self *Encoder) clearTable() {
+
+	var (
+		v_i uint32
+	)
+
+	v_i = 0
+	for v_i < 16384 {
+		self.f_htable[v_i] = 0
+		v_i += 1
+	}
+}
+
+// -------- func encoder.write_code
+
+func (self *Encoder) writeCode(a_dst base.IOWriter, a_code uint32, a_width uint32) (status error) {
+
+	var (
+		v_n_bits uint32
+	)
+
+	r := self.c_write_code.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_n_bits = self.c_write_code.v_n_bits
+	}
+
+	if r == 0 {
+		v_n_bits = (self.f_n_bits + a_width)
+		self.f_bits |= (a_code << self.f_n_bits)
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_n_bits >= 8) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = a_dst.WriteU8(uint8((self.f_bits & 255))); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_bits >>= 8
+				v_n_bits -= 8
+			}
+		}
+	}
+	if r == 0 {
+		self.f_n_bits = v_n_bits
+	}
+
+	self.c_write_code.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_write_code.coroSuspPoint = csp
+	self.c_write_code.v_n_bits = v_n_bits
+
+exit:
+	return status
+}
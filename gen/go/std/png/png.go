@@ -0,0 +1,1325 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package png
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/crc32"
+	"github.com/google/wuffs/gen/go/std/zlib"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadChecksum                        = base.NewError("png: bad checksum")
+	ErrBadChunk                           = base.NewError("png: bad chunk")
+	ErrBadFilter                          = base.NewError("png: bad filter")
+	ErrBadHeader                          = base.NewError("png: bad header")
+	ErrBadPalette                         = base.NewError("png: bad palette")
+	ErrBadTransparency                    = base.NewError("png: bad transparency")
+	ErrMissingPalette                     = base.NewError("png: missing palette")
+	ErrNotEnoughPixelData                 = base.NewError("png: not enough pixel data")
+	ErrTooMuchPixelData                   = base.NewError("png: too much pixel data")
+	ErrUnsupportedCriticalChunk           = base.NewError("png: unsupported critical chunk")
+	ErrBadWorkbufLength                   = base.NewError("png: bad workbuf length")
+	errInternalErrorInconsistentRowIndex  = base.NewError("png: internal error: inconsistent row index")
+	errInternalErrorInconsistentRowLength = base.NewError("png: internal error: inconsistent row length")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a png.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width               uint32
+	f_height              uint32
+	f_call_sequence       uint8
+	f_ignore_checksum     bool
+	f_depth               uint8
+	f_color_type          uint8
+	f_interlaced          bool
+	f_filter_distance     uint32
+	f_bits_per_pixel      uint32
+	f_depth_mask          uint32
+	f_seen_palette        bool
+	f_num_palette_entries uint32
+	f_has_trns            bool
+	f_trns_gray           uint32
+	f_trns_red            uint32
+	f_trns_green          uint32
+	f_trns_blue           uint32
+	f_chunk_length        uint64
+	f_chunk_type          uint32
+	f_crc_got             uint32
+	f_small_chunk_length  uint32
+	f_pass                uint8
+	f_pass_width          uint32
+	f_pass_height         uint32
+	f_pass_y              uint32
+	f_row_length          uint64
+	f_row_wi              uint64
+	f_row_stride          uint64
+	f_chunk_data          [772]uint8
+	f_palette             [1024]uint8
+	f_crc                 crc32.IeeeHasher
+	f_zlib                zlib.Decoder
+
+	c_decode_config struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+		v_dirty_rect  base.RectIEU32
+		scratch       uint64
+	}
+
+	c_read_chunk_header struct {
+		coroSuspPoint uint32
+		v_length      uint32
+		scratch       uint64
+	}
+
+	c_read_small_chunk struct {
+		coroSuspPoint uint32
+		v_n           uint32
+		v_i           uint32
+	}
+
+	c_verify_checksum struct {
+		coroSuspPoint uint32
+		v_crc_want    uint32
+		scratch       uint64
+	}
+
+	c_decode_idats struct {
+		coroSuspPoint uint32
+		v_i           uint64
+		v_z           error
+		scratch       uint64
+	}
+}
+
+// ---------------- Private Consts
+
+const chunk_type_idat uint32 = 0x49444154
+
+const chunk_type_iend uint32 = 0x49454E44
+
+const chunk_type_ihdr uint32 = 0x49484452
+
+const chunk_type_plte uint32 = 0x504C5445
+
+const chunk_type_trns uint32 = 0x74524E53
+
+var interlace_x0 = [8]uint8{
+	0x0,
+	0x0,
+	0x4,
+	0x0,
+	0x2,
+	0x0,
+	0x1,
+	0x0,
+}
+
+var interlace_y0 = [8]uint8{
+	0x0,
+	0x0,
+	0x0,
+	0x4,
+	0x0,
+	0x2,
+	0x0,
+	0x1,
+}
+
+var interlace_log2_dx = [8]uint8{
+	0x0,
+	0x3,
+	0x3,
+	0x2,
+	0x2,
+	0x1,
+	0x1,
+	0x0,
+}
+
+var interlace_log2_dy = [8]uint8{
+	0x0,
+	0x3,
+	0x3,
+	0x3,
+	0x2,
+	0x2,
+	0x1,
+	0x1,
+}
+
+var low_depth_gray_scales = [5]uint8{
+	0x0,
+	0xFF,
+	0x55,
+	0x0,
+	0x11,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+func (self *Decoder) SetIgnoreChecksum(a_ic bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_ignore_checksum = a_ic
+	self.f_zlib.SetIgnoreChecksum(a_ic)
+}
+
+// -------- func decoder.workbuf_len
+
+func (self *Decoder) WorkbufLen() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return (self.f_row_stride * 2)
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		t_0 uint32
+		t_1 uint32
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU32BE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 2303741511) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 || r == 2 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 2 {
+				r = 0
+			}
+			csp = 2
+			if t_1, status = a_src.ReadU32BE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_1 != 218765834) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.readChunkHeader(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if (self.f_chunk_type != chunk_type_ihdr) || (self.f_chunk_length != 13) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.readSmallChunk(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.decodeIhdr(); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || (6 <= r && r <= 11) {
+	label_0:
+		for {
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = self.readChunkHeader(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || (7 <= r && r <= 11) {
+				if r == 0 && (self.f_chunk_type == chunk_type_idat) {
+					break label_0
+				} else if (7 <= r && r <= 8) || (r == 0 && (self.f_chunk_type == chunk_type_plte)) {
+					if r == 0 || r == 7 {
+						if r == 7 {
+							r = 0
+						}
+						csp = 7
+						if status = self.readSmallChunk(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 8 {
+						if r == 8 {
+							r = 0
+						}
+						csp = 8
+						if status = self.decodePlte(); status != nil {
+							goto suspend
+						}
+					}
+				} else if (9 <= r && r <= 10) || (r == 0 && (self.f_chunk_type == chunk_type_trns)) {
+					if r == 0 || r == 9 {
+						if r == 9 {
+							r = 0
+						}
+						csp = 9
+						if status = self.readSmallChunk(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 10 {
+						if r == 10 {
+							r = 0
+						}
+						csp = 10
+						if status = self.decodeTrns(); status != nil {
+							goto suspend
+						}
+					}
+				} else if r == 0 && (self.f_chunk_type == chunk_type_iend) {
+					status = ErrNotEnoughPixelData
+					goto exit
+				} else if r == 0 && (self.f_chunk_type == chunk_type_ihdr) {
+					status = ErrBadChunk
+					goto exit
+				} else if r == 0 && (((self.f_chunk_type >> 24) & 32) == 0) {
+					status = ErrUnsupportedCriticalChunk
+					goto exit
+				} else {
+					if r == 0 || r == 11 {
+						if r == 0 {
+							self.c_decode_config.scratch = uint64((self.f_chunk_length + 4))
+						}
+						if r == 11 {
+							r = 0
+						}
+						csp = 11
+						if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if (self.f_color_type == 3) && !self.f_seen_palette {
+			status = ErrMissingPalette
+			goto exit
+		}
+		a_dst.Initialize(570460296, 0, self.f_width, self.f_height, 1)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_palette    []byte
+		v_dirty_rect base.RectIEU32
+	)
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_dirty_rect = self.c_decode_frame.v_dirty_rect
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 && (self.f_call_sequence == 0) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		} else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+			if r == 0 || r == 1 {
+				for {
+					if r == 0 || r == 1 {
+						status = base.SuspensionEndOfData
+						if r == 1 {
+							r = 0
+							status = nil
+						} else {
+							csp = 1
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if uint64(len(a_workbuf)) < (self.f_row_stride * 2) {
+			status = ErrBadWorkbufLength
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.decodeIdats(a_dst, a_src, a_workbuf); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_palette = nil
+		v_dirty_rect = base.RectIEU32{}
+		v_dirty_rect.SetMaxExclusiveX(self.f_width)
+		v_dirty_rect.SetMaxExclusiveY(self.f_height)
+		a_dst.Update(v_dirty_rect, 0, false, 0, v_palette)
+	}
+	if r == 0 || (3 <= r && r <= 5) {
+	label_0:
+		for {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.readChunkHeader(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 4 {
+				if r == 4 || (r == 0 && (self.f_chunk_type == chunk_type_iend)) {
+					if r == 0 || r == 4 {
+						if r == 0 {
+							self.c_decode_frame.scratch = uint64(4)
+						}
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = a_src.Skip(&self.c_decode_frame.scratch); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						break label_0
+					}
+				} else if r == 0 && ((self.f_chunk_type != chunk_type_idat) && (((self.f_chunk_type >> 24) & 32) == 0)) {
+					status = ErrUnsupportedCriticalChunk
+					goto exit
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 0 {
+					self.c_decode_frame.scratch = uint64((self.f_chunk_length + 4))
+				}
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = a_src.Skip(&self.c_decode_frame.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_call_sequence = 2
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+	self.c_decode_frame.v_dirty_rect = v_dirty_rect
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.read_chunk_header
+
+func (self *Decoder) readChunkHeader(a_src base.IOReader) (status error) {
+
+	var (
+		v_length uint32
+		t_0      uint32
+		t_1      uint32
+	)
+
+	r := self.c_read_chunk_header.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_read_chunk_header.v_length
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_read_chunk_header.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU32BE(&self.c_read_chunk_header.scratch); status != nil {
+			goto suspend
+		}
+		v_length = t_0
+	}
+	if r == 0 {
+		if v_length > 2147483647 {
+			status = ErrBadChunk
+			goto exit
+		}
+		self.f_chunk_length = uint64(v_length)
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_read_chunk_header.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU32BE(&self.c_read_chunk_header.scratch); status != nil {
+			goto suspend
+		}
+		self.f_chunk_type = t_1
+	}
+	if r == 0 {
+		self.f_chunk_data[0] = uint8(((self.f_chunk_type >> 24) & 255))
+		self.f_chunk_data[1] = uint8(((self.f_chunk_type >> 16) & 255))
+		self.f_chunk_data[2] = uint8(((self.f_chunk_type >> 8) & 255))
+		self.f_chunk_data[3] = uint8(((self.f_chunk_type >> 0) & 255))
+		self.f_crc = crc32.IeeeHasher{}
+		self.f_crc_got = self.f_crc.Update(self.f_chunk_data[:4])
+	}
+
+	self.c_read_chunk_header.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_chunk_header.coroSuspPoint = csp
+	self.c_read_chunk_header.v_length = v_length
+
+exit:
+	return status
+}
+
+// -------- func decoder.read_small_chunk
+
+func (self *Decoder) readSmallChunk(a_src base.IOReader) (status error) {
+
+	var (
+		v_n uint32
+		v_i uint32
+		t_0 uint8
+	)
+
+	r := self.c_read_small_chunk.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_n = self.c_read_small_chunk.v_n
+		v_i = self.c_read_small_chunk.v_i
+	}
+
+	if r == 0 {
+		if self.f_chunk_length > 768 {
+			status = ErrBadChunk
+			goto exit
+		}
+		v_n = uint32(base.U64Min(self.f_chunk_length, 768))
+		v_i = 0
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (v_i < v_n) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				self.f_chunk_data[(4 + v_i)] = t_0
+			}
+			if r == 0 {
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		self.f_chunk_length = 0
+		self.f_small_chunk_length = v_n
+		self.f_crc_got = self.f_crc.Update(self.f_chunk_data[4:(4 + v_n)])
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.verifyChecksum(a_src); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_read_small_chunk.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_small_chunk.coroSuspPoint = csp
+	self.c_read_small_chunk.v_n = v_n
+	self.c_read_small_chunk.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.verify_checksum
+
+func (self *Decoder) verifyChecksum(a_src base.IOReader) (status error) {
+
+	var (
+		v_crc_want uint32
+		t_0        uint32
+	)
+
+	r := self.c_verify_checksum.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_crc_want = self.c_verify_checksum.v_crc_want
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_verify_checksum.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU32BE(&self.c_verify_checksum.scratch); status != nil {
+			goto suspend
+		}
+		v_crc_want = t_0
+	}
+	if r == 0 {
+		if !self.f_ignore_checksum && (self.f_crc_got != v_crc_want) {
+			status = ErrBadChecksum
+			goto exit
+		}
+	}
+
+	self.c_verify_checksum.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_verify_checksum.coroSuspPoint = csp
+	self.c_verify_checksum.v_crc_want = v_crc_want
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_ihdr
+
+func (self *Decoder) decodeIhdr() (status error) {
+
+	var (
+		v_width           uint32
+		v_height          uint32
+		v_depth           uint8
+		v_color_type      uint8
+		v_channels        uint32
+		v_bits_per_pixel  uint32
+		v_filter_distance uint32
+		v_row_stride      uint64
+	)
+
+	v_width = ((uint32(self.f_chunk_data[4]) << 24) | (uint32(self.f_chunk_data[5]) << 16) | (uint32(self.f_chunk_data[6]) << 8) | (uint32(self.f_chunk_data[7]) << 0))
+	v_height = ((uint32(self.f_chunk_data[8]) << 24) | (uint32(self.f_chunk_data[9]) << 16) | (uint32(self.f_chunk_data[10]) << 8) | (uint32(self.f_chunk_data[11]) << 0))
+	if (v_width == 0) || (v_width > 2147483647) || (v_height == 0) || (v_height > 2147483647) {
+		status = ErrBadHeader
+		goto exit
+	}
+	self.f_width = v_width
+	self.f_height = v_height
+	v_depth = self.f_chunk_data[12]
+	v_color_type = self.f_chunk_data[13]
+	v_channels = 0
+	if v_color_type == 0 {
+		v_channels = 1
+		if (v_depth != 1) && (v_depth != 2) && (v_depth != 4) && (v_depth != 8) && (v_depth != 16) {
+			status = ErrBadHeader
+			goto exit
+		}
+	} else if v_color_type == 2 {
+		v_channels = 3
+		if (v_depth != 8) && (v_depth != 16) {
+			status = ErrBadHeader
+			goto exit
+		}
+	} else if v_color_type == 3 {
+		v_channels = 1
+		if (v_depth != 1) && (v_depth != 2) && (v_depth != 4) && (v_depth != 8) {
+			status = ErrBadHeader
+			goto exit
+		}
+	} else if v_color_type == 4 {
+		v_channels = 2
+		if (v_depth != 8) && (v_depth != 16) {
+			status = ErrBadHeader
+			goto exit
+		}
+	} else if v_color_type == 6 {
+		v_channels = 4
+		if (v_depth != 8) && (v_depth != 16) {
+			status = ErrBadHeader
+			goto exit
+		}
+	} else {
+		status = ErrBadHeader
+		goto exit
+	}
+	self.f_depth = base.U8Min(v_depth, 16)
+	self.f_color_type = base.U8Min(v_color_type, 6)
+	if (self.f_chunk_data[14] != 0) || (self.f_chunk_data[15] != 0) || (self.f_chunk_data[16] > 1) {
+		status = ErrBadHeader
+		goto exit
+	}
+	self.f_interlaced = (self.f_chunk_data[16] == 1)
+	v_bits_per_pixel = (v_channels * uint32(self.f_depth))
+	if v_bits_per_pixel < 1 {
+		status = ErrBadHeader
+		goto exit
+	}
+	self.f_bits_per_pixel = v_bits_per_pixel
+	v_filter_distance = ((v_bits_per_pixel + 7) >> 3)
+	self.f_filter_distance = base.U32Max(v_filter_distance, 1)
+	if self.f_depth == 16 {
+		self.f_depth_mask = 65535
+	} else {
+		self.f_depth_mask = (((uint32(1) << base.U8Min(self.f_depth, 8)) - 1) & 255)
+	}
+	v_row_stride = ((((uint64(v_width) * uint64(v_bits_per_pixel)) + 7) >> 3) + 1)
+	self.f_row_stride = base.U64Min(v_row_stride, 17179869184)
+exit:
+	return status
+}
+
+// -------- func decoder.decode_plte
+
+func (self *Decoder) decodePlte() (status error) {
+
+	var (
+		v_n                   uint32
+		v_num_palette_entries uint32
+		v_i                   uint32
+	)
+
+	v_n = self.f_small_chunk_length
+	if (v_n == 0) || ((v_n % 3) != 0) {
+		status = ErrBadPalette
+		goto exit
+	}
+	if self.f_color_type != 3 {
+		status = nil
+		goto exit
+	}
+	v_num_palette_entries = (v_n / 3)
+	self.f_num_palette_entries = v_num_palette_entries
+	self.f_seen_palette = true
+	v_i = 0
+	for v_i < v_num_palette_entries {
+		self.f_palette[((4 * v_i) + 0)] = self.f_chunk_data[(4 + (3 * v_i) + 2)]
+		self.f_palette[((4 * v_i) + 1)] = self.f_chunk_data[(4 + (3 * v_i) + 1)]
+		self.f_palette[((4 * v_i) + 2)] = self.f_chunk_data[(4 + (3 * v_i) + 0)]
+		self.f_palette[((4 * v_i) + 3)] = 255
+		v_i += 1
+	}
+	for v_i < 256 {
+		self.f_palette[((4 * v_i) + 0)] = 0
+		self.f_palette[((4 * v_i) + 1)] = 0
+		self.f_palette[((4 * v_i) + 2)] = 0
+		self.f_palette[((4 * v_i) + 3)] = 255
+		v_i += 1
+	}
+exit:
+	return status
+}
+
+// -------- func decoder.decode_trns
+
+func (self *Decoder) decodeTrns() (status error) {
+
+	var (
+		v_n          uint32
+		v_num_alphas uint32
+		v_i          uint32
+	)
+
+	v_n = self.f_small_chunk_length
+	if self.f_color_type == 0 {
+		if v_n != 2 {
+			status = ErrBadTransparency
+			goto exit
+		}
+		self.f_trns_gray = (self.f_depth_mask & ((uint32(self.f_chunk_data[4]) << 8) | uint32(self.f_chunk_data[5])))
+		self.f_has_trns = true
+	} else if self.f_color_type == 2 {
+		if v_n != 6 {
+			status = ErrBadTransparency
+			goto exit
+		}
+		self.f_trns_red = (self.f_depth_mask & ((uint32(self.f_chunk_data[4]) << 8) | uint32(self.f_chunk_data[5])))
+		self.f_trns_green = (self.f_depth_mask & ((uint32(self.f_chunk_data[6]) << 8) | uint32(self.f_chunk_data[7])))
+		self.f_trns_blue = (self.f_depth_mask & ((uint32(self.f_chunk_data[8]) << 8) | uint32(self.f_chunk_data[9])))
+		self.f_has_trns = true
+	} else if self.f_color_type == 3 {
+		if !self.f_seen_palette || (v_n > self.f_num_palette_entries) {
+			status = ErrBadTransparency
+			goto exit
+		}
+		v_num_alphas = base.U32Min(v_n, 256)
+		v_i = 0
+		for v_i < v_num_alphas {
+			self.f_palette[((4 * v_i) + 3)] = self.f_chunk_data[(4 + v_i)]
+			v_i += 1
+		}
+	}
+exit:
+	return status
+}
+
+// -------- func decoder.decode_idats
+
+func (self *Decoder) decodeIdats(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+
+	var (
+		v_curr []byte
+		v_prev []byte
+		v_i    uint64
+		v_w    base.IOWriter
+		u_w    base.IOBuffer
+		v_z    error
+		t_0    error
+	)
+
+	r := self.c_decode_idats.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_i = self.c_decode_idats.v_i
+		v_z = self.c_decode_idats.v_z
+	}
+
+	if r == 0 {
+		v_curr = nil
+		v_prev = nil
+		v_i = 0
+		if self.f_interlaced {
+			self.f_pass = 1
+		} else {
+			self.f_pass = 0
+		}
+		self.startPass()
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+	label_0:
+		for {
+			if r == 0 || (1 <= r && r <= 2) {
+				if (1 <= r && r <= 2) || (r == 0 && (self.f_chunk_length == 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if status = self.verifyChecksum(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.readChunkHeader(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						if self.f_chunk_type != chunk_type_idat {
+							status = ErrNotEnoughPixelData
+							goto exit
+						}
+						continue label_0
+					}
+				}
+			}
+			if r == 0 {
+				if uint64(len(a_workbuf)) < (self.f_row_stride * 2) {
+					status = ErrBadWorkbufLength
+					goto exit
+				}
+				v_curr = base.SliceU8Prefix(a_workbuf, self.f_row_length)
+				v_prev = base.SliceU8Prefix(base.SliceU8Suffix(a_workbuf, self.f_row_stride), self.f_row_length)
+				if self.f_row_wi > uint64(len(v_curr)) {
+					status = errInternalErrorInconsistentRowIndex
+					goto exit
+				}
+				v_w = base.IOWriter{}
+				{
+					o_0_a_src := a_src
+					o_0_v_w := v_w
+					v_w.Set(&u_w, v_curr[self.f_row_wi:])
+					a_src.SetLimit(self.f_chunk_length)
+					a_src.SetMark()
+					t_0 = self.f_zlib.Decode(v_w, a_src)
+					v_z = t_0
+					if !self.f_ignore_checksum {
+						self.f_crc_got = self.f_crc.Update(a_src.SinceMark())
+					}
+					self.f_chunk_length = base.U64SatSub(self.f_chunk_length, uint64(len(a_src.SinceMark())))
+					self.f_row_wi = base.U64SatSub(uint64(len(v_curr)), v_w.Available())
+					v_w = o_0_v_w
+					a_src = o_0_a_src
+				}
+				if (self.f_pass < 8) && (self.f_row_wi == self.f_row_length) {
+					if uint64(len(v_curr)) < 1 {
+						status = errInternalErrorInconsistentRowLength
+						goto exit
+					}
+					if v_curr[0] > 4 {
+						status = ErrBadFilter
+						goto exit
+					}
+					if self.f_pass_y == 0 {
+						v_i = 0
+						for v_i < uint64(len(v_prev)) {
+							v_prev[v_i] = 0
+							v_i += 1
+						}
+					}
+					self.unfilterRow(v_curr, v_prev)
+					self.swizzleRow(a_dst, v_curr)
+					copy(v_prev, v_curr)
+					self.f_row_wi = 0
+					self.f_pass_y += 1
+					if self.f_pass_y >= self.f_pass_height {
+						self.nextPass()
+						self.startPass()
+					}
+				}
+				if v_z == nil {
+					break label_0
+				} else if v_z == base.SuspensionShortWrite {
+					if self.f_pass >= 8 {
+						status = ErrTooMuchPixelData
+						goto exit
+					}
+					continue label_0
+				} else if (self.f_chunk_length == 0) && (v_z == base.SuspensionShortRead) {
+					continue label_0
+				}
+			}
+			if r == 0 || r == 3 {
+				status = v_z
+				if r == 3 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 3
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if self.f_pass < 8 {
+			status = ErrNotEnoughPixelData
+			goto exit
+		}
+	}
+	if r == 0 || (4 <= r && r <= 5) {
+		if r == 4 || (r == 0 && (self.f_chunk_length > 0)) {
+			if r == 0 || r == 4 {
+				if r == 0 {
+					self.c_decode_idats.scratch = uint64((self.f_chunk_length + 4))
+				}
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = a_src.Skip(&self.c_decode_idats.scratch); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.verifyChecksum(a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+	self.c_decode_idats.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_idats.coroSuspPoint = csp
+	self.c_decode_idats.v_i = v_i
+	self.c_decode_idats.v_z = v_z
+
+exit:
+	return status
+}
+
+// -------- func decoder.start_pass
+
+func (self *Decoder) startPass() {
+
+	var (
+		v_x0      uint32
+		v_y0      uint32
+		v_log2_dx uint32
+		v_log2_dy uint32
+	)
+
+	for self.f_pass < 8 {
+		v_x0 = uint32(interlace_x0[self.f_pass])
+		v_y0 = uint32(interlace_y0[self.f_pass])
+		if (self.f_width > v_x0) && (self.f_height > v_y0) {
+			v_log2_dx = uint32(interlace_log2_dx[self.f_pass])
+			v_log2_dy = uint32(interlace_log2_dy[self.f_pass])
+			self.f_pass_width = (((self.f_width - v_x0) + ((uint32(1) << v_log2_dx) - 1)) >> v_log2_dx)
+			self.f_pass_height = (((self.f_height - v_y0) + ((uint32(1) << v_log2_dy) - 1)) >> v_log2_dy)
+			self.f_pass_y = 0
+			self.f_row_length = ((((uint64(self.f_pass_width) * uint64(self.f_bits_per_pixel)) + 7) >> 3) + 1)
+			self.f_row_wi = 0
+			return
+		}
+		self.nextPass()
+	}
+	self.f_row_length = 0
+	self.f_row_wi = 0
+}
+
+// -------- func decoder.next_pass
+
+func (self *Decoder) nextPass() {
+
+	if (self.f_pass == 0) || (self.f_pass >= 7) {
+		self.f_pass = 8
+	} else {
+		self.f_pass += 1
+	}
+}
+
+// -------- func decoder.unfilter_row
+
+func (self *Decoder) unfilterRow(a_curr []byte, a_prev []byte) {
+
+	var (
+		v_d      uint64
+		v_filter uint8
+		v_i      uint64
+		v_j      uint64
+		v_a      uint32
+		v_b      uint32
+		v_c      uint32
+		v_pa     uint32
+		v_pb     uint32
+		v_pc     uint32
+	)
+
+	v_d = uint64(base.U32Max(self.f_filter_distance, 1))
+	v_filter = 0
+	v_i = 1
+	v_j = 1
+	v_a = 0
+	v_b = 0
+	v_c = 0
+	v_pa = 0
+	v_pb = 0
+	v_pc = 0
+	if uint64(len(a_curr)) != uint64(len(a_prev)) {
+		return
+	}
+	if uint64(len(a_curr)) < 1 {
+		return
+	}
+	v_filter = a_curr[0]
+	if v_filter == 1 {
+		v_i = (v_d + 1)
+		for (v_i < uint64(len(a_curr))) && (v_j < uint64(len(a_curr))) {
+			a_curr[v_i] += a_curr[v_j]
+			v_i += 1
+			v_j += 1
+		}
+	} else if v_filter == 2 {
+		for (v_i < uint64(len(a_curr))) && (v_i < uint64(len(a_prev))) {
+			a_curr[v_i] += a_prev[v_i]
+			v_i += 1
+		}
+	} else if v_filter == 3 {
+		for (v_i < uint64(len(a_curr))) && (v_i < uint64(len(a_prev))) && (v_i <= v_d) {
+			a_curr[v_i] += (a_prev[v_i] >> 1)
+			v_i += 1
+		}
+		for (v_i < uint64(len(a_curr))) && (v_j < uint64(len(a_curr))) && (v_i < uint64(len(a_prev))) {
+			v_a = uint32(a_curr[v_j])
+			v_b = uint32(a_prev[v_i])
+			a_curr[v_i] += uint8(((v_a + v_b) >> 1))
+			v_i += 1
+			v_j += 1
+		}
+	} else if v_filter == 4 {
+		for (v_i < uint64(len(a_curr))) && (v_i < uint64(len(a_prev))) && (v_i <= v_d) {
+			a_curr[v_i] += a_prev[v_i]
+			v_i += 1
+		}
+		for (v_i < uint64(len(a_curr))) && (v_j < uint64(len(a_curr))) && (v_i < uint64(len(a_prev))) && (v_j < uint64(len(a_prev))) {
+			v_a = uint32(a_curr[v_j])
+			v_b = uint32(a_prev[v_i])
+			v_c = uint32(a_prev[v_j])
+			v_pa = (base.U32SatSub(v_b, v_c) + base.U32SatSub(v_c, v_b))
+			v_pb = (base.U32SatSub(v_a, v_c) + base.U32SatSub(v_c, v_a))
+			v_pc = (base.U32SatSub((v_a+v_b), (v_c+v_c)) + base.U32SatSub((v_c+v_c), (v_a+v_b)))
+			if (v_pa <= v_pb) && (v_pa <= v_pc) {
+				a_curr[v_i] += uint8(v_a)
+			} else if v_pb <= v_pc {
+				a_curr[v_i] += uint8(v_b)
+			} else {
+				a_curr[v_i] += uint8(v_c)
+			}
+			v_i += 1
+			v_j += 1
+		}
+	}
+}
+
+// -------- func decoder.swizzle_row
+
+func (self *Decoder) swizzleRow(a_dst *base.ImageBuffer, a_curr []byte) {
+
+	var (
+		v_pass   uint8
+		v_tab    base.TableU8
+		v_y      uint64
+		v_dst    []byte
+		v_x      uint32
+		v_dx     uint32
+		v_d      uint64
+		v_depth  uint32
+		v_mask   uint32
+		v_shift  uint32
+		v_o      uint64
+		v_k      uint32
+		v_v      uint32
+		v_r      uint32
+		v_g      uint32
+		v_b      uint32
+		v_p      uint32
+		v_sample [8]uint8
+		v_pixel  [4]uint8
+		v_x4     uint64
+	)
+
+	v_pass = base.U8Min(self.f_pass, 7)
+	v_tab = a_dst.Plane(0)
+	v_y = ((uint64(self.f_pass_y) << interlace_log2_dy[v_pass]) + uint64(interlace_y0[v_pass]))
+	v_dst = v_tab.Row(uint32((v_y & 4294967295)))
+	v_x = uint32(interlace_x0[v_pass])
+	v_dx = (uint32(1) << interlace_log2_dx[v_pass])
+	v_d = uint64(base.U32Max(self.f_filter_distance, 1))
+	v_depth = uint32(self.f_depth)
+	v_mask = self.f_depth_mask
+	v_shift = (8 - base.U32Min(v_depth, 8))
+	v_o = 1
+	v_k = 0
+	v_v = 0
+	v_r = 0
+	v_g = 0
+	v_b = 0
+	v_p = 0
+	v_sample = [8]uint8{}
+	v_pixel = [4]uint8{}
+	v_x4 = 0
+	for (v_k < self.f_pass_width) && (v_o < uint64(len(a_curr))) {
+		if v_depth < 8 {
+			v_v = ((uint32(a_curr[v_o]) >> v_shift) & v_mask)
+		} else {
+			copy(v_sample[:], a_curr[v_o:])
+		}
+		if self.f_color_type == 0 {
+			if v_depth < 8 {
+				v_pixel[0] = uint8(((v_v * uint32(low_depth_gray_scales[base.U32Min(v_depth, 4)])) & 255))
+			} else if v_depth == 8 {
+				v_v = uint32(v_sample[0])
+				v_pixel[0] = v_sample[0]
+			} else {
+				v_v = ((uint32(v_sample[0]) << 8) | uint32(v_sample[1]))
+				v_pixel[0] = v_sample[0]
+			}
+			v_pixel[1] = v_pixel[0]
+			v_pixel[2] = v_pixel[0]
+			v_pixel[3] = 255
+			if self.f_has_trns && (v_v == self.f_trns_gray) {
+				v_pixel[3] = 0
+			}
+		} else if self.f_color_type == 2 {
+			if v_depth == 8 {
+				v_r = uint32(v_sample[0])
+				v_g = uint32(v_sample[1])
+				v_b = uint32(v_sample[2])
+				v_pixel[0] = v_sample[2]
+				v_pixel[1] = v_sample[1]
+				v_pixel[2] = v_sample[0]
+			} else {
+				v_r = ((uint32(v_sample[0]) << 8) | uint32(v_sample[1]))
+				v_g = ((uint32(v_sample[2]) << 8) | uint32(v_sample[3]))
+				v_b = ((uint32(v_sample[4]) << 8) | uint32(v_sample[5]))
+				v_pixel[0] = v_sample[4]
+				v_pixel[1] = v_sample[2]
+				v_pixel[2] = v_sample[0]
+			}
+			v_pixel[3] = 255
+			if self.f_has_trns && (v_r == self.f_trns_red) && (v_g == self.f_trns_green) && (v_b == self.f_trns_blue) {
+				v_pixel[3] = 0
+			}
+		} else if self.f_color_type == 3 {
+			if v_depth < 8 {
+				v_p = (v_v & 255)
+			} else {
+				v_p = uint32(v_sample[0])
+			}
+			v_pixel[0] = self.f_palette[((4 * v_p) + 0)]
+			v_pixel[1] = self.f_palette[((4 * v_p) + 1)]
+			v_pixel[2] = self.f_palette[((4 * v_p) + 2)]
+			v_pixel[3] = self.f_palette[((4 * v_p) + 3)]
+		} else if self.f_color_type == 4 {
+			v_pixel[0] = v_sample[0]
+			v_pixel[1] = v_sample[0]
+			v_pixel[2] = v_sample[0]
+			if v_depth == 8 {
+				v_pixel[3] = v_sample[1]
+			} else {
+				v_pixel[3] = v_sample[2]
+			}
+		} else {
+			if v_depth == 8 {
+				v_pixel[0] = v_sample[2]
+				v_pixel[1] = v_sample[1]
+				v_pixel[2] = v_sample[0]
+				v_pixel[3] = v_sample[3]
+			} else {
+				v_pixel[0] = v_sample[4]
+				v_pixel[1] = v_sample[2]
+				v_pixel[2] = v_sample[0]
+				v_pixel[3] = v_sample[6]
+			}
+		}
+		v_x4 = (uint64(v_x) * 4)
+		if v_x4 < uint64(len(v_dst)) {
+			copy(v_dst[v_x4:], v_pixel[:])
+		}
+		if v_depth >= 8 {
+			v_o = base.U64SatAdd(v_o, v_d)
+		} else if v_shift >= v_depth {
+			v_shift -= v_depth
+		} else {
+			v_shift = (8 - base.U32Min(v_depth, 8))
+			v_o = base.U64SatAdd(v_o, 1)
+		}
+		v_x += v_dx
+		v_k += 1
+	}
+}
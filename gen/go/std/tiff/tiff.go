@@ -0,0 +1,1810 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package tiff
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/lzw"
+	"github.com/google/wuffs/gen/go/std/zlib"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadHeader                            = base.NewError("tiff: bad header")
+	ErrBadIFDEntry                          = base.NewError("tiff: bad IFD entry")
+	ErrBadWorkbufLength                     = base.NewError("tiff: bad workbuf length")
+	ErrNotEnoughPixelData                   = base.NewError("tiff: not enough pixel data")
+	ErrUnsupportedBitDepth                  = base.NewError("tiff: unsupported bit depth")
+	ErrUnsupportedCompression               = base.NewError("tiff: unsupported compression")
+	ErrUnsupportedPhotometricInterpretation = base.NewError("tiff: unsupported photometric interpretation")
+	ErrUnsupportedPlanarConfiguration       = base.NewError("tiff: unsupported planar configuration")
+	ErrUnsupportedPredictor                 = base.NewError("tiff: unsupported predictor")
+	ErrUnsupportedSamplesPerPixel           = base.NewError("tiff: unsupported samples per pixel")
+	ErrTODOUnsupportedBigTIFF               = base.NewError("tiff: TODO: unsupported BigTIFF")
+	ErrTODOUnsupportedImageWidth            = base.NewError("tiff: TODO: unsupported image width")
+	SuspensionSeekRequired                  = base.NewSuspension("tiff: seek required")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a tiff.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width                    uint32
+	f_height                   uint32
+	f_call_sequence            uint8
+	f_big_endian               bool
+	f_pos                      uint64
+	f_seek_pos                 uint64
+	f_value                    uint32
+	f_raw_width                uint32
+	f_raw_height               uint32
+	f_raw_bits_per_sample      uint32
+	f_raw_compression          uint32
+	f_raw_photometric          uint32
+	f_raw_samples_per_pixel    uint32
+	f_raw_rows_per_strip       uint32
+	f_raw_planar_configuration uint32
+	f_raw_predictor            uint32
+	f_raw_tile_width           uint32
+	f_raw_tile_height          uint32
+	f_raw_extra_samples        uint32
+	f_bits_per_sample          uint32
+	f_samples_per_pixel        uint32
+	f_compression              uint32
+	f_photometric              uint32
+	f_predictor                bool
+	f_alpha                    uint32
+	f_is_tiled                 bool
+	f_chunk_width              uint32
+	f_chunk_height             uint32
+	f_chunks_across            uint32
+	f_num_chunks               uint32
+	f_chunk_index              uint32
+	f_chunk_remaining          uint64
+	f_array_types              [4]uint32
+	f_array_counts             [4]uint32
+	f_array_values             [4]uint32
+	f_packbits_literal         uint32
+	f_packbits_run             uint32
+	f_packbits_byte            uint8
+	f_row_length               uint32
+	f_row_wi                   uint32
+	f_row                      [32772]uint8
+	f_palette                  [1024]uint8
+	f_lzw                      lzw.Decoder
+	f_zlib                     zlib.Decoder
+
+	c_decode_config struct {
+		coroSuspPoint uint32
+		v_magic       uint32
+		v_num_entries uint32
+		scratch       uint64
+	}
+
+	c_read_entry struct {
+		coroSuspPoint uint32
+		v_tag         uint32
+		v_field_type  uint32
+		v_count       uint32
+		v_raw         uint32
+		v_which       uint32
+		v_v           uint32
+		scratch       uint64
+	}
+
+	c_read_array struct {
+		coroSuspPoint uint32
+		v_field_type  uint32
+		v_count       uint32
+		v_raw         uint32
+		v_n           uint32
+		v_is_inline   bool
+		v_offset      uint32
+		v_i           uint32
+		v_v           uint32
+		v_c           uint32
+		v_index       uint32
+	}
+
+	c_read_u16 struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_read_u32 struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_seek struct {
+		coroSuspPoint uint32
+		scratch       uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+		v_dirty_rect  base.RectIEU32
+	}
+
+	c_decode_chunk struct {
+		coroSuspPoint uint32
+		v_c           uint32
+		v_offset      uint32
+		v_x0          uint64
+		v_y0          uint64
+		v_y           uint64
+		v_y_end       uint64
+	}
+
+	c_fill_row struct {
+		coroSuspPoint uint32
+	}
+
+	c_fill_row_none struct {
+		coroSuspPoint uint32
+		v_n           uint64
+		v_c           uint8
+	}
+
+	c_fill_row_packbits struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_fill_row_lzw_or_deflate struct {
+		coroSuspPoint uint32
+		v_z           error
+		v_n           uint64
+		v_lzw_status  error
+		v_zlib_status error
+	}
+}
+
+// ---------------- Private Consts
+
+const tag_image_width uint32 = 0x100
+
+const tag_image_length uint32 = 0x101
+
+const tag_bits_per_sample uint32 = 0x102
+
+const tag_compression uint32 = 0x103
+
+const tag_photometric_interpretation uint32 = 0x106
+
+const tag_strip_offsets uint32 = 0x111
+
+const tag_samples_per_pixel uint32 = 0x115
+
+const tag_rows_per_strip uint32 = 0x116
+
+const tag_strip_byte_counts uint32 = 0x117
+
+const tag_planar_configuration uint32 = 0x11C
+
+const tag_predictor uint32 = 0x13D
+
+const tag_color_map uint32 = 0x140
+
+const tag_tile_width uint32 = 0x142
+
+const tag_tile_length uint32 = 0x143
+
+const tag_tile_offsets uint32 = 0x144
+
+const tag_tile_byte_counts uint32 = 0x145
+
+const tag_extra_samples uint32 = 0x152
+
+const field_type_short uint32 = 0x3
+
+const field_type_long uint32 = 0x4
+
+const compression_none uint32 = 0x1
+
+const compression_lzw uint32 = 0x5
+
+const compression_deflate uint32 = 0x8
+
+const compression_packbits uint32 = 0x8005
+
+const compression_old_deflate uint32 = 0x80B2
+
+const photometric_white_is_zero uint32 = 0x0
+
+const photometric_black_is_zero uint32 = 0x1
+
+const photometric_rgb uint32 = 0x2
+
+const photometric_palette uint32 = 0x3
+
+const extra_samples_associated_alpha uint32 = 0x1
+
+const extra_samples_unassociated_alpha uint32 = 0x2
+
+const which_offsets uint32 = 0x0
+
+const which_byte_counts uint32 = 0x1
+
+const which_bits_per_sample uint32 = 0x2
+
+const which_color_map uint32 = 0x3
+
+// ---------------- Function Implementations
+
+// -------- func decoder.seek_position
+
+func (self *Decoder) SeekPosition() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_seek_pos
+}
+
+// -------- func decoder.workbuf_len
+
+func (self *Decoder) WorkbufLen() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return (uint64(self.f_num_chunks) * 8)
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_magic       uint32
+		v_num_entries uint32
+		v_workbuf     []byte
+		t_0           uint32
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_magic = self.c_decode_config.v_magic
+		v_num_entries = self.c_decode_config.v_num_entries
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_magic = t_0
+	}
+	if r == 0 {
+		if v_magic == 2771273 {
+			self.f_big_endian = false
+		} else if v_magic == 704662861 {
+			self.f_big_endian = true
+		} else if (v_magic == 2836809) || (v_magic == 721440077) {
+			status = ErrTODOUnsupportedBigTIFF
+			goto exit
+		} else {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_pos = 4
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.readU32(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if self.f_value < 8 {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.seek(a_src, uint64(self.f_value)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_raw_bits_per_sample = 1
+		self.f_raw_compression = compression_none
+		self.f_raw_photometric = 4294967295
+		self.f_raw_samples_per_pixel = 1
+		self.f_raw_rows_per_strip = 4294967295
+		self.f_raw_planar_configuration = 1
+		self.f_raw_predictor = 1
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.readU16(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_num_entries = self.f_value
+		if v_num_entries == 0 {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 5 {
+		for r != 0 || (v_num_entries > 0) {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.readEntry(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_num_entries -= 1
+			}
+		}
+	}
+	if r == 0 {
+		if (self.f_raw_samples_per_pixel == 0) || (self.f_raw_samples_per_pixel > 4) {
+			status = ErrUnsupportedSamplesPerPixel
+			goto exit
+		}
+		self.f_samples_per_pixel = self.f_raw_samples_per_pixel
+		v_workbuf = nil
+	}
+	if r == 0 || r == 6 {
+		if r == 6 || (r == 0 && (self.f_array_counts[which_bits_per_sample] > 0)) {
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = self.readArray(a_src, v_workbuf, which_bits_per_sample); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 || r == 7 {
+		if r == 7 {
+			r = 0
+		}
+		csp = 7
+		if status = self.configure(); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 8 {
+		if r == 8 || (r == 0 && (self.f_photometric == photometric_palette)) {
+			if r == 0 || r == 8 {
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if status = self.readArray(a_src, v_workbuf, which_color_map); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			self.setGrayPalette()
+		}
+	}
+	if r == 0 {
+		if (self.f_array_counts[which_offsets] < self.f_num_chunks) || (self.f_array_counts[which_byte_counts] < self.f_num_chunks) {
+			status = ErrBadIFDEntry
+			goto exit
+		}
+		a_dst.Initialize(570460296, 0, self.f_width, self.f_height, 1)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_magic = v_magic
+	self.c_decode_config.v_num_entries = v_num_entries
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.read_entry
+
+func (self *Decoder) readEntry(a_src base.IOReader) (status error) {
+
+	var (
+		v_tag        uint32
+		v_field_type uint32
+		v_count      uint32
+		v_raw        uint32
+		v_which      uint32
+		v_v          uint32
+		t_0          uint32
+	)
+
+	r := self.c_read_entry.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_tag = self.c_read_entry.v_tag
+		v_field_type = self.c_read_entry.v_field_type
+		v_count = self.c_read_entry.v_count
+		v_raw = self.c_read_entry.v_raw
+		v_which = self.c_read_entry.v_which
+		v_v = self.c_read_entry.v_v
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.readU16(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_tag = self.f_value
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.readU16(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_field_type = self.f_value
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.readU32(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_count = self.f_value
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_read_entry.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_0, status = a_src.ReadU32LE(&self.c_read_entry.scratch); status != nil {
+			goto suspend
+		}
+		v_raw = t_0
+	}
+	if r == 0 {
+		self.f_pos = base.U64SatAdd(self.f_pos, 4)
+		if (v_tag == tag_bits_per_sample) || (v_tag == tag_strip_offsets) || (v_tag == tag_strip_byte_counts) || (v_tag == tag_color_map) || (v_tag == tag_tile_offsets) || (v_tag == tag_tile_byte_counts) {
+			if ((v_field_type != field_type_short) && (v_field_type != field_type_long)) || (v_count == 0) {
+				status = ErrBadIFDEntry
+				goto exit
+			}
+			v_which = which_bits_per_sample
+			if (v_tag == tag_strip_offsets) || (v_tag == tag_tile_offsets) {
+				v_which = which_offsets
+			} else if (v_tag == tag_strip_byte_counts) || (v_tag == tag_tile_byte_counts) {
+				v_which = which_byte_counts
+			} else if v_tag == tag_color_map {
+				v_which = which_color_map
+			}
+			self.f_array_types[v_which] = v_field_type
+			self.f_array_counts[v_which] = v_count
+			self.f_array_values[v_which] = v_raw
+			if (v_tag == tag_tile_offsets) || (v_tag == tag_tile_byte_counts) {
+				self.f_is_tiled = true
+			}
+			status = nil
+			goto ok
+		}
+		if (v_tag != tag_image_width) && (v_tag != tag_image_length) && (v_tag != tag_compression) && (v_tag != tag_photometric_interpretation) && (v_tag != tag_samples_per_pixel) && (v_tag != tag_rows_per_strip) && (v_tag != tag_planar_configuration) && (v_tag != tag_predictor) && (v_tag != tag_tile_width) && (v_tag != tag_tile_length) && (v_tag != tag_extra_samples) {
+			status = nil
+			goto ok
+		}
+		if ((v_field_type != field_type_short) && (v_field_type != field_type_long)) || (v_count != 1) {
+			status = ErrBadIFDEntry
+			goto exit
+		}
+		v_v = self.inlineElement(v_raw, v_field_type, 0)
+		if v_tag == tag_image_width {
+			self.f_raw_width = v_v
+		} else if v_tag == tag_image_length {
+			self.f_raw_height = v_v
+		} else if v_tag == tag_compression {
+			self.f_raw_compression = v_v
+		} else if v_tag == tag_photometric_interpretation {
+			self.f_raw_photometric = v_v
+		} else if v_tag == tag_samples_per_pixel {
+			self.f_raw_samples_per_pixel = v_v
+		} else if v_tag == tag_rows_per_strip {
+			self.f_raw_rows_per_strip = v_v
+		} else if v_tag == tag_planar_configuration {
+			self.f_raw_planar_configuration = v_v
+		} else if v_tag == tag_predictor {
+			self.f_raw_predictor = v_v
+		} else if v_tag == tag_tile_width {
+			self.f_raw_tile_width = v_v
+			self.f_is_tiled = true
+		} else if v_tag == tag_tile_length {
+			self.f_raw_tile_height = v_v
+			self.f_is_tiled = true
+		} else if v_tag == tag_extra_samples {
+			self.f_raw_extra_samples = v_v
+		}
+	}
+
+ok:
+	self.c_read_entry.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_entry.coroSuspPoint = csp
+	self.c_read_entry.v_tag = v_tag
+	self.c_read_entry.v_field_type = v_field_type
+	self.c_read_entry.v_count = v_count
+	self.c_read_entry.v_raw = v_raw
+	self.c_read_entry.v_which = v_which
+	self.c_read_entry.v_v = v_v
+
+exit:
+	return status
+}
+
+// -------- func decoder.inline_element
+
+func (self *Decoder) inlineElement(a_raw uint32, a_field_type uint32, a_i uint32) uint32 {
+
+	var (
+		v_x uint32
+	)
+
+	v_x = 0
+	if a_field_type == field_type_long {
+		v_x = a_raw
+		if self.f_big_endian {
+			v_x = (((v_x & 255) << 24) | ((v_x & 65280) << 8) | ((v_x >> 8) & 65280) | (v_x >> 24))
+		}
+		return v_x
+	}
+	v_x = (a_raw >> (16 * a_i))
+	if self.f_big_endian {
+		return (((v_x & 255) << 8) | ((v_x >> 8) & 255))
+	}
+	return (v_x & 65535)
+}
+
+// -------- func decoder.read_array
+
+func (self *Decoder) readArray(a_src base.IOReader, a_workbuf []byte, a_which uint32) (status error) {
+
+	var (
+		v_field_type uint32
+		v_count      uint32
+		v_raw        uint32
+		v_n          uint32
+		v_is_inline  bool
+		v_offset     uint32
+		v_i          uint32
+		v_v          uint32
+		v_c          uint32
+		v_index      uint32
+	)
+
+	r := self.c_read_array.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_field_type = self.c_read_array.v_field_type
+		v_count = self.c_read_array.v_count
+		v_raw = self.c_read_array.v_raw
+		v_n = self.c_read_array.v_n
+		v_is_inline = self.c_read_array.v_is_inline
+		v_offset = self.c_read_array.v_offset
+		v_i = self.c_read_array.v_i
+		v_v = self.c_read_array.v_v
+		v_c = self.c_read_array.v_c
+		v_index = self.c_read_array.v_index
+	}
+
+	if r == 0 {
+		v_field_type = self.f_array_types[a_which]
+		v_count = self.f_array_counts[a_which]
+		v_raw = self.f_array_values[a_which]
+		v_n = v_count
+		if a_which == which_bits_per_sample {
+			if v_count != self.f_samples_per_pixel {
+				status = ErrBadIFDEntry
+				goto exit
+			}
+		} else if a_which == which_color_map {
+			if v_count != (uint32(3) << self.f_bits_per_sample) {
+				status = ErrBadIFDEntry
+				goto exit
+			}
+		} else {
+			v_n = base.U32Min(v_count, self.f_num_chunks)
+		}
+		v_is_inline = (v_count <= 1)
+		if v_field_type == field_type_short {
+			v_is_inline = (v_count <= 2)
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 || (r == 0 && !v_is_inline) {
+			if r == 0 {
+				v_offset = self.inlineElement(v_raw, field_type_long, 0)
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.seek(a_src, uint64(v_offset)); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_i = 0
+		v_v = 0
+		v_c = 0
+		v_index = 0
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		for r != 0 || (v_i < v_n) {
+			if r == 0 || (2 <= r && r <= 3) {
+				if r == 0 && v_is_inline {
+					v_v = self.inlineElement(v_raw, v_field_type, (v_i & 1))
+				} else if r == 2 || (r == 0 && (v_field_type == field_type_short)) {
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.readU16(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_v = self.f_value
+					}
+				} else {
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.readU32(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_v = self.f_value
+					}
+				}
+			}
+			if r == 0 {
+				if a_which == which_offsets {
+					self.setChunkValue(a_workbuf, which_offsets, v_i, v_v)
+				} else if a_which == which_byte_counts {
+					self.setChunkValue(a_workbuf, which_byte_counts, v_i, v_v)
+				} else if a_which == which_bits_per_sample {
+					if v_i == 0 {
+						self.f_raw_bits_per_sample = v_v
+					} else if v_v != self.f_raw_bits_per_sample {
+						status = ErrUnsupportedBitDepth
+						goto exit
+					}
+				} else {
+					v_c = 2
+					v_index = v_i
+					if v_index >= (v_count / 3) {
+						v_c = 1
+						v_index = base.U32SatSub(v_index, (v_count / 3))
+						if v_index >= (v_count / 3) {
+							v_c = 0
+							v_index = base.U32SatSub(v_index, (v_count / 3))
+						}
+					}
+					if v_index < 256 {
+						self.f_palette[((4 * v_index) + v_c)] = uint8(((v_v >> 8) & 255))
+						self.f_palette[((4 * v_index) + 3)] = 255
+					}
+				}
+				v_i = base.U32SatAdd(v_i, 1)
+			}
+		}
+	}
+
+	self.c_read_array.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_array.coroSuspPoint = csp
+	self.c_read_array.v_field_type = v_field_type
+	self.c_read_array.v_count = v_count
+	self.c_read_array.v_raw = v_raw
+	self.c_read_array.v_n = v_n
+	self.c_read_array.v_is_inline = v_is_inline
+	self.c_read_array.v_offset = v_offset
+	self.c_read_array.v_i = v_i
+	self.c_read_array.v_v = v_v
+	self.c_read_array.v_c = v_c
+	self.c_read_array.v_index = v_index
+
+exit:
+	return status
+}
+
+// -------- func decoder.configure
+
+func (self *Decoder) configure() (status error) {
+
+	var (
+		v_spp           uint32
+		v_bps           uint32
+		v_num_colors    uint32
+		v_compression   uint32
+		v_chunks_across uint64
+		v_chunks_down   uint64
+		v_across        uint64
+		v_down          uint64
+		v_num_chunks    uint64
+		v_row_length    uint64
+	)
+
+	if (self.f_raw_width == 0) || (self.f_raw_width > 2147483647) || (self.f_raw_height == 0) || (self.f_raw_height > 2147483647) {
+		status = ErrBadHeader
+		goto exit
+	}
+	self.f_width = self.f_raw_width
+	self.f_height = self.f_raw_height
+	v_spp = self.f_samples_per_pixel
+	v_bps = self.f_raw_bits_per_sample
+	v_num_colors = 1
+	if self.f_raw_photometric == photometric_rgb {
+		v_num_colors = 3
+	} else if (self.f_raw_photometric != photometric_white_is_zero) && (self.f_raw_photometric != photometric_black_is_zero) && (self.f_raw_photometric != photometric_palette) {
+		status = ErrUnsupportedPhotometricInterpretation
+		goto exit
+	}
+	self.f_photometric = base.U32Min(self.f_raw_photometric, 3)
+	self.f_alpha = 0
+	if v_spp == (v_num_colors + 1) {
+		if self.f_raw_extra_samples == extra_samples_associated_alpha {
+			self.f_alpha = 1
+		} else if self.f_raw_extra_samples == extra_samples_unassociated_alpha {
+			self.f_alpha = 2
+		}
+		if (self.f_alpha != 0) && (self.f_photometric == photometric_palette) {
+			status = ErrUnsupportedSamplesPerPixel
+			goto exit
+		}
+	} else if v_spp != v_num_colors {
+		status = ErrUnsupportedSamplesPerPixel
+		goto exit
+	}
+	if (v_spp > 1) && (self.f_raw_planar_configuration != 1) {
+		status = ErrUnsupportedPlanarConfiguration
+		goto exit
+	}
+	if v_bps == 8 {
+		self.f_bits_per_sample = 8
+	} else if (v_spp == 1) && ((v_bps == 1) || (v_bps == 2) || (v_bps == 4)) {
+		self.f_bits_per_sample = base.U32Min(v_bps, 4)
+	} else {
+		status = ErrUnsupportedBitDepth
+		goto exit
+	}
+	v_compression = self.f_raw_compression
+	if v_compression == compression_old_deflate {
+		v_compression = compression_deflate
+	}
+	if (v_compression != compression_none) && (v_compression != compression_lzw) && (v_compression != compression_deflate) && (v_compression != compression_packbits) {
+		status = ErrUnsupportedCompression
+		goto exit
+	}
+	self.f_compression = v_compression
+	if self.f_raw_predictor == 2 {
+		if self.f_bits_per_sample != 8 {
+			status = ErrUnsupportedPredictor
+			goto exit
+		}
+		self.f_predictor = true
+	} else if self.f_raw_predictor != 1 {
+		status = ErrUnsupportedPredictor
+		goto exit
+	}
+	v_chunks_across = 1
+	v_chunks_down = 0
+	if self.f_is_tiled {
+		if (self.f_raw_tile_width == 0) || (self.f_raw_tile_width > 2147483647) || (self.f_raw_tile_height == 0) || (self.f_raw_tile_height > 2147483647) {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_chunk_width = self.f_raw_tile_width
+		self.f_chunk_height = self.f_raw_tile_height
+		v_chunks_across = self.divRoundUp(uint64(self.f_width), uint64(self.f_chunk_width))
+	} else {
+		if self.f_raw_rows_per_strip == 0 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_chunk_width = self.f_width
+		self.f_chunk_height = base.U32Min(self.f_raw_rows_per_strip, self.f_height)
+	}
+	v_chunks_down = self.divRoundUp(uint64(self.f_height), uint64(self.f_chunk_height))
+	if (v_chunks_across > 2147483647) || (v_chunks_down > 2147483647) {
+		status = ErrBadHeader
+		goto exit
+	}
+	v_across = v_chunks_across
+	v_down = v_chunks_down
+	v_num_chunks = (v_across * v_down)
+	if v_num_chunks > 4294967295 {
+		status = ErrBadIFDEntry
+		goto exit
+	}
+	self.f_chunks_across = uint32(v_across)
+	self.f_num_chunks = uint32(v_num_chunks)
+	v_row_length = self.divRoundUp((uint64(self.f_chunk_width) * uint64((v_spp * self.f_bits_per_sample))), 8)
+	if v_row_length > 32768 {
+		status = ErrTODOUnsupportedImageWidth
+		goto exit
+	}
+	self.f_row_length = uint32(v_row_length)
+exit:
+	return status
+}
+
+// -------- func decoder.div_round_up
+
+func (self *Decoder) divRoundUp(a_a uint64, a_b uint64) uint64 {
+
+	if a_b == 0 {
+		return 0
+	}
+	return (((a_a + a_b) - 1) / a_b)
+}
+
+// -------- func decoder.set_gray_palette
+
+func (self *Decoder) setGrayPalette() {
+
+	var (
+		v_max   uint32
+		v_scale uint32
+		v_i     uint32
+		v_g     uint32
+	)
+
+	v_max = ((uint32(1) << self.f_bits_per_sample) - 1)
+	v_scale = 255
+	if v_max > 0 {
+		v_scale = (255 / v_max)
+	}
+	v_i = 0
+	v_g = 0
+	for v_i <= v_max {
+		v_g = v_i
+		if self.f_photometric == photometric_white_is_zero {
+			v_g = base.U32SatSub(v_max, v_i)
+		}
+		v_g = ((v_g * v_scale) & 255)
+		self.f_palette[((4 * v_i) + 0)] = uint8(v_g)
+		self.f_palette[((4 * v_i) + 1)] = uint8(v_g)
+		self.f_palette[((4 * v_i) + 2)] = uint8(v_g)
+		self.f_palette[((4 * v_i) + 3)] = 255
+		v_i += 1
+	}
+}
+
+// -------- func decoder.read_u16
+
+func (self *Decoder) readU16(a_src base.IOReader) (status error) {
+
+	var (
+		t_0 uint16
+		t_1 uint16
+	)
+
+	r := self.c_read_u16.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || (1 <= r && r <= 2) {
+		if r == 1 || (r == 0 && self.f_big_endian) {
+			if r == 0 || r == 1 {
+				if r == 0 {
+					self.c_read_u16.scratch = 0
+				}
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU16BE(&self.c_read_u16.scratch); status != nil {
+					goto suspend
+				}
+				self.f_value = uint32(t_0)
+			}
+		} else {
+			if r == 0 || r == 2 {
+				if r == 0 {
+					self.c_read_u16.scratch = 0
+				}
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU16LE(&self.c_read_u16.scratch); status != nil {
+					goto suspend
+				}
+				self.f_value = uint32(t_1)
+			}
+		}
+	}
+	if r == 0 {
+		self.f_pos = base.U64SatAdd(self.f_pos, 2)
+	}
+
+	self.c_read_u16.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_u16.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.read_u32
+
+func (self *Decoder) readU32(a_src base.IOReader) (status error) {
+
+	var (
+		t_0 uint32
+		t_1 uint32
+	)
+
+	r := self.c_read_u32.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || (1 <= r && r <= 2) {
+		if r == 1 || (r == 0 && self.f_big_endian) {
+			if r == 0 || r == 1 {
+				if r == 0 {
+					self.c_read_u32.scratch = 0
+				}
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU32BE(&self.c_read_u32.scratch); status != nil {
+					goto suspend
+				}
+				self.f_value = t_0
+			}
+		} else {
+			if r == 0 || r == 2 {
+				if r == 0 {
+					self.c_read_u32.scratch = 0
+				}
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU32LE(&self.c_read_u32.scratch); status != nil {
+					goto suspend
+				}
+				self.f_value = t_1
+			}
+		}
+	}
+	if r == 0 {
+		self.f_pos = base.U64SatAdd(self.f_pos, 4)
+	}
+
+	self.c_read_u32.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_u32.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.seek
+
+func (self *Decoder) seek(a_src base.IOReader, a_pos uint64) (status error) {
+
+	r := self.c_seek.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || (1 <= r && r <= 2) {
+		if r == 1 || (r == 0 && (a_pos >= self.f_pos)) {
+			if r == 0 || r == 1 {
+				if r == 0 {
+					self.c_seek.scratch = uint64((a_pos - self.f_pos))
+				}
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = a_src.Skip(&self.c_seek.scratch); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 {
+				self.f_seek_pos = a_pos
+			}
+			if r == 0 || r == 2 {
+				status = SuspensionSeekRequired
+				if r == 2 {
+					r = 0
+					status = nil
+				} else {
+					csp = 2
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 {
+		self.f_pos = a_pos
+	}
+
+	self.c_seek.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_seek.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_palette    []byte
+		v_dirty_rect base.RectIEU32
+	)
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_dirty_rect = self.c_decode_frame.v_dirty_rect
+	}
+
+	if r == 0 || r == 1 {
+		if r == 0 && (self.f_call_sequence == 0) {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		} else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+			if r == 0 || r == 1 {
+				for {
+					if r == 0 || r == 1 {
+						status = base.SuspensionEndOfData
+						if r == 1 {
+							r = 0
+							status = nil
+						} else {
+							csp = 1
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		if uint64(len(a_workbuf)) < (uint64(self.f_num_chunks) * 8) {
+			status = ErrBadWorkbufLength
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.readArray(a_src, a_workbuf, which_offsets); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.readArray(a_src, a_workbuf, which_byte_counts); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 4 {
+		for r != 0 || (self.f_chunk_index < self.f_num_chunks) {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.decodeChunk(a_dst, a_src, a_workbuf); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_chunk_index = base.U32SatAdd(self.f_chunk_index, 1)
+			}
+		}
+	}
+	if r == 0 {
+		v_palette = nil
+		v_dirty_rect = base.RectIEU32{}
+		v_dirty_rect.SetMaxExclusiveX(self.f_width)
+		v_dirty_rect.SetMaxExclusiveY(self.f_height)
+		a_dst.Update(v_dirty_rect, 0, false, 0, v_palette)
+		self.f_call_sequence = 2
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+	self.c_decode_frame.v_dirty_rect = v_dirty_rect
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_chunk
+
+func (self *Decoder) decodeChunk(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+
+	var (
+		v_c      uint32
+		v_offset uint32
+		v_x0     uint64
+		v_y0     uint64
+		v_y      uint64
+		v_y_end  uint64
+	)
+
+	r := self.c_decode_chunk.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_chunk.v_c
+		v_offset = self.c_decode_chunk.v_offset
+		v_x0 = self.c_decode_chunk.v_x0
+		v_y0 = self.c_decode_chunk.v_y0
+		v_y = self.c_decode_chunk.v_y
+		v_y_end = self.c_decode_chunk.v_y_end
+	}
+
+	if r == 0 {
+		v_c = self.f_chunk_index
+		v_offset = self.chunkValue(a_workbuf, which_offsets, v_c)
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.seek(a_src, uint64(v_offset)); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_chunk_remaining = uint64(self.chunkValue(a_workbuf, which_byte_counts, v_c))
+		v_x0 = 0
+		v_y0 = 0
+		if self.f_chunks_across > 0 {
+			v_x0 = (uint64((v_c % self.f_chunks_across)) * uint64(self.f_chunk_width))
+			v_y0 = (uint64((v_c / self.f_chunks_across)) * uint64(self.f_chunk_height))
+		}
+		if self.f_compression == compression_lzw {
+			self.f_lzw = lzw.Decoder{}
+			self.f_lzw.SetTiffMode(true)
+		} else if self.f_compression == compression_deflate {
+			self.f_zlib = zlib.Decoder{}
+		}
+		self.f_packbits_literal = 0
+		self.f_packbits_run = 0
+		v_y = v_y0
+		v_y_end = (v_y0 + uint64(self.f_chunk_height))
+		if v_y_end > uint64(self.f_height) {
+			v_y_end = uint64(self.f_height)
+		}
+	}
+	if r == 0 || r == 2 {
+		for r != 0 || (v_y < v_y_end) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.fillRow(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if self.f_predictor {
+					self.unpredictRow()
+				}
+				self.swizzleRow(a_dst, v_x0, v_y)
+				v_y += 1
+			}
+		}
+	}
+
+	self.c_decode_chunk.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_chunk.coroSuspPoint = csp
+	self.c_decode_chunk.v_c = v_c
+	self.c_decode_chunk.v_offset = v_offset
+	self.c_decode_chunk.v_x0 = v_x0
+	self.c_decode_chunk.v_y0 = v_y0
+	self.c_decode_chunk.v_y = v_y
+	self.c_decode_chunk.v_y_end = v_y_end
+
+exit:
+	return status
+}
+
+// -------- func decoder.set_chunk_value
+
+func (self *Decoder) setChunkValue(a_workbuf []byte, a_which uint32, a_i uint32, a_v uint32) {
+
+	var (
+		v_o uint64
+		v_b [4]uint8
+	)
+
+	v_o = ((uint64(a_i) * 8) + uint64((a_which * 4)))
+	v_b = [4]uint8{}
+	v_b[0] = uint8(((a_v >> 0) & 255))
+	v_b[1] = uint8(((a_v >> 8) & 255))
+	v_b[2] = uint8(((a_v >> 16) & 255))
+	v_b[3] = uint8(((a_v >> 24) & 255))
+	if v_o < uint64(len(a_workbuf)) {
+		copy(a_workbuf[v_o:], v_b[:])
+	}
+}
+
+// -------- func decoder.chunk_value
+
+func (self *Decoder) chunkValue(a_workbuf []byte, a_which uint32, a_i uint32) uint32 {
+
+	var (
+		v_o uint64
+		v_b [4]uint8
+	)
+
+	v_o = ((uint64(a_i) * 8) + uint64((a_which * 4)))
+	v_b = [4]uint8{}
+	if v_o < uint64(len(a_workbuf)) {
+		copy(v_b[:], a_workbuf[v_o:])
+	}
+	return ((uint32(v_b[0]) << 0) | (uint32(v_b[1]) << 8) | (uint32(v_b[2]) << 16) | (uint32(v_b[3]) << 24))
+}
+
+// -------- func decoder.fill_row
+
+func (self *Decoder) fillRow(a_src base.IOReader) (status error) {
+
+	r := self.c_fill_row.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 {
+		self.f_row_wi = 0
+	}
+	if r == 0 || (1 <= r && r <= 3) {
+		if r == 1 || (r == 0 && (self.f_compression == compression_none)) {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.fillRowNone(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else if r == 2 || (r == 0 && (self.f_compression == compression_packbits)) {
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.fillRowPackbits(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.fillRowLzwOrDeflate(a_src); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+
+	self.c_fill_row.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_row.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.fill_row_none
+
+func (self *Decoder) fillRowNone(a_src base.IOReader) (status error) {
+
+	var (
+		v_n uint64
+		v_c uint8
+		v_w base.IOWriter
+		u_w base.IOBuffer
+		t_0 uint8
+	)
+
+	r := self.c_fill_row_none.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_n = self.c_fill_row_none.v_n
+		v_c = self.c_fill_row_none.v_c
+	}
+
+	if r == 0 {
+		v_n = 0
+	}
+	if r == 0 || r == 1 {
+	label_0:
+		for r != 0 || (self.f_row_wi < self.f_row_length) {
+			if r == 0 {
+				if self.f_chunk_remaining == 0 {
+					status = ErrNotEnoughPixelData
+					goto exit
+				}
+			}
+			if r == 0 || r == 1 {
+				if r == 1 || (r == 0 && (a_src.Available() == 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_0
+					}
+					if r == 0 {
+						self.f_chunk_remaining = base.U64SatSub(self.f_chunk_remaining, 1)
+						self.f_pos = base.U64SatAdd(self.f_pos, 1)
+						self.f_row[self.f_row_wi] = v_c
+						self.f_row_wi += 1
+						continue label_0
+					}
+				}
+			}
+			if r == 0 {
+				v_w = base.IOWriter{}
+				{
+					o_0_a_src := a_src
+					o_0_v_w := v_w
+					v_w.Set(&u_w, self.f_row[self.f_row_wi:self.f_row_length])
+					a_src.SetLimit(self.f_chunk_remaining)
+					a_src.SetMark()
+					v_w.CopyFromReader32(&a_src, 32768)
+					v_n = uint64(len(a_src.SinceMark()))
+					self.f_chunk_remaining = base.U64SatSub(self.f_chunk_remaining, v_n)
+					self.f_pos = base.U64SatAdd(self.f_pos, v_n)
+					self.f_row_wi = base.U32SatSub(self.f_row_length, uint32(base.U64Min(v_w.Available(), 32768)))
+					v_w = o_0_v_w
+					a_src = o_0_a_src
+				}
+			}
+		}
+	}
+
+	self.c_fill_row_none.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_row_none.coroSuspPoint = csp
+	self.c_fill_row_none.v_n = v_n
+	self.c_fill_row_none.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.fill_row_packbits
+
+func (self *Decoder) fillRowPackbits(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		t_0 uint8
+		t_1 uint8
+	)
+
+	r := self.c_fill_row_packbits.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_fill_row_packbits.v_c
+	}
+
+	if r == 0 {
+		v_c = 0
+	}
+	if r == 0 || (1 <= r && r <= 2) {
+	label_0:
+		for r != 0 || (self.f_row_wi < self.f_row_length) {
+			if r == 0 {
+				if self.f_packbits_run > 0 {
+					self.f_row[self.f_row_wi] = self.f_packbits_byte
+					self.f_row_wi += 1
+					self.f_packbits_run -= 1
+					continue label_0
+				}
+				if self.f_chunk_remaining == 0 {
+					status = ErrNotEnoughPixelData
+					goto exit
+				}
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if t_0, status = a_src.ReadU8(); status != nil {
+					goto suspend
+				}
+				v_c = t_0
+			}
+			if r == 0 {
+				self.f_chunk_remaining = base.U64SatSub(self.f_chunk_remaining, 1)
+				self.f_pos = base.U64SatAdd(self.f_pos, 1)
+			}
+			if r == 0 || r == 2 {
+				if r == 0 && (self.f_packbits_literal > 0) {
+					self.f_row[self.f_row_wi] = v_c
+					self.f_row_wi += 1
+					self.f_packbits_literal -= 1
+				} else if r == 0 && (v_c < 128) {
+					self.f_packbits_literal = (uint32(v_c) + 1)
+				} else if r == 2 || (r == 0 && (v_c > 128)) {
+					if r == 0 {
+						self.f_packbits_run = (257 - uint32(v_c))
+						if self.f_chunk_remaining == 0 {
+							status = ErrNotEnoughPixelData
+							goto exit
+						}
+					}
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if t_1, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						self.f_packbits_byte = t_1
+					}
+					if r == 0 {
+						self.f_chunk_remaining = base.U64SatSub(self.f_chunk_remaining, 1)
+						self.f_pos = base.U64SatAdd(self.f_pos, 1)
+					}
+				}
+			}
+		}
+	}
+
+	self.c_fill_row_packbits.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_row_packbits.coroSuspPoint = csp
+	self.c_fill_row_packbits.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.fill_row_lzw_or_deflate
+
+func (self *Decoder) fillRowLzwOrDeflate(a_src base.IOReader) (status error) {
+
+	var (
+		v_z           error
+		v_n           uint64
+		v_w           base.IOWriter
+		u_w           base.IOBuffer
+		v_lzw_status  error
+		v_zlib_status error
+		t_0           error
+		t_1           error
+	)
+
+	r := self.c_fill_row_lzw_or_deflate.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_z = self.c_fill_row_lzw_or_deflate.v_z
+		v_n = self.c_fill_row_lzw_or_deflate.v_n
+		v_lzw_status = self.c_fill_row_lzw_or_deflate.v_lzw_status
+		v_zlib_status = self.c_fill_row_lzw_or_deflate.v_zlib_status
+	}
+
+	if r == 0 {
+		v_z = nil
+		v_n = 0
+	}
+	if r == 0 || r == 1 {
+	label_0:
+		for {
+			if r == 0 {
+				if self.f_row_wi >= self.f_row_length {
+					status = nil
+					goto ok
+				}
+				v_w = base.IOWriter{}
+				{
+					o_0_a_src := a_src
+					o_0_v_w := v_w
+					v_w.Set(&u_w, self.f_row[self.f_row_wi:self.f_row_length])
+					a_src.SetLimit(self.f_chunk_remaining)
+					a_src.SetMark()
+					if self.f_compression == compression_lzw {
+						t_0 = self.f_lzw.Decode(v_w, a_src)
+						v_lzw_status = t_0
+						v_z = v_lzw_status
+					} else {
+						t_1 = self.f_zlib.Decode(v_w, a_src)
+						v_zlib_status = t_1
+						v_z = v_zlib_status
+					}
+					v_n = uint64(len(a_src.SinceMark()))
+					self.f_chunk_remaining = base.U64SatSub(self.f_chunk_remaining, v_n)
+					self.f_pos = base.U64SatAdd(self.f_pos, v_n)
+					self.f_row_wi = base.U32SatSub(self.f_row_length, uint32(base.U64Min(v_w.Available(), 32768)))
+					v_w = o_0_v_w
+					a_src = o_0_a_src
+				}
+				if self.f_row_wi >= self.f_row_length {
+					status = nil
+					goto ok
+				} else if (v_z == nil) || ((self.f_chunk_remaining == 0) && (v_z == base.SuspensionShortRead)) {
+					status = ErrNotEnoughPixelData
+					goto exit
+				} else if v_z == base.SuspensionShortWrite {
+					continue label_0
+				}
+			}
+			if r == 0 || r == 1 {
+				status = v_z
+				if r == 1 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 1
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+
+ok:
+	self.c_fill_row_lzw_or_deflate.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_row_lzw_or_deflate.coroSuspPoint = csp
+	self.c_fill_row_lzw_or_deflate.v_z = v_z
+	self.c_fill_row_lzw_or_deflate.v_n = v_n
+	self.c_fill_row_lzw_or_deflate.v_lzw_status = v_lzw_status
+	self.c_fill_row_lzw_or_deflate.v_zlib_status = v_zlib_status
+
+exit:
+	return status
+}
+
+// -------- func decoder.unpredict_row
+
+func (self *Decoder) unpredictRow() {
+
+	var (
+		v_spp uint32
+		v_n   uint32
+		v_i   uint32
+	)
+
+	v_spp = self.f_samples_per_pixel
+	v_n = base.U32SatSub(self.f_row_length, v_spp)
+	v_i = 0
+	for v_i < v_n {
+		self.f_row[(v_i + v_spp)] += self.f_row[v_i]
+		v_i += 1
+	}
+}
+
+// -------- func decoder.swizzle_row
+
+func (self *Decoder) swizzleRow(a_dst *base.ImageBuffer, a_x0 uint64, a_y uint64) {
+
+	var (
+		v_tab   base.TableU8
+		v_y     uint32
+		v_dst   []byte
+		v_n     uint32
+		v_spp   uint32
+		v_bps   uint32
+		v_mask  uint32
+		v_shift uint32
+		v_o     uint32
+		v_x     uint64
+		v_x_end uint64
+		v_p     uint32
+		v_a     uint32
+		v_pixel [4]uint8
+		v_x4    uint64
+	)
+
+	v_tab = a_dst.Plane(0)
+	if a_y >= uint64(self.f_height) {
+		return
+	} else if a_y >= 2147483647 {
+		return
+	}
+	v_y = uint32(a_y)
+	v_dst = v_tab.Row(v_y)
+	v_n = self.f_row_length
+	v_spp = self.f_samples_per_pixel
+	v_bps = self.f_bits_per_sample
+	v_mask = ((uint32(1) << v_bps) - 1)
+	v_shift = (8 - v_bps)
+	v_o = 0
+	v_x = a_x0
+	v_x_end = (a_x0 + uint64(self.f_chunk_width))
+	v_p = 0
+	v_a = 0
+	v_pixel = [4]uint8{}
+	v_x4 = 0
+	if v_x_end > uint64(self.f_width) {
+		v_x_end = uint64(self.f_width)
+	}
+	for (v_x < v_x_end) && (v_o < v_n) {
+		if self.f_photometric == photometric_rgb {
+			v_pixel[0] = self.f_row[(v_o + 2)]
+			v_pixel[1] = self.f_row[(v_o + 1)]
+			v_pixel[2] = self.f_row[(v_o + 0)]
+			v_pixel[3] = 255
+			if self.f_alpha != 0 {
+				v_a = uint32(self.f_row[(v_o + 3)])
+				v_pixel[3] = uint8(v_a)
+				if self.f_alpha == extra_samples_associated_alpha {
+					v_pixel[0] = self.unpremultiply(uint32(self.f_row[(v_o+2)]), v_a)
+					v_pixel[1] = self.unpremultiply(uint32(self.f_row[(v_o+1)]), v_a)
+					v_pixel[2] = self.unpremultiply(uint32(self.f_row[(v_o+0)]), v_a)
+				}
+			}
+			v_o += v_spp
+		} else {
+			v_p = ((uint32(self.f_row[v_o]) >> v_shift) & v_mask)
+			v_pixel[0] = self.f_palette[((4 * v_p) + 0)]
+			v_pixel[1] = self.f_palette[((4 * v_p) + 1)]
+			v_pixel[2] = self.f_palette[((4 * v_p) + 2)]
+			v_pixel[3] = self.f_palette[((4 * v_p) + 3)]
+			if v_bps == 8 {
+				if self.f_alpha != 0 {
+					v_pixel[3] = self.f_row[(v_o + 1)]
+				}
+				v_o += v_spp
+			} else if v_shift >= v_bps {
+				v_shift -= v_bps
+			} else {
+				v_shift = (8 - v_bps)
+				v_o += 1
+			}
+		}
+		v_x4 = (base.U64Min(v_x, 2147483647) * 4)
+		if v_x4 < uint64(len(v_dst)) {
+			copy(v_dst[v_x4:], v_pixel[:])
+		}
+		v_x += 1
+	}
+}
+
+// -------- func decoder.unpremultiply
+
+func (self *Decoder) unpremultiply(a_v uint32, a_a uint32) uint8 {
+
+	var (
+		v_x uint32
+	)
+
+	if a_a == 0 {
+		return 0
+	}
+	v_x = (((a_v * 255) + (a_a / 2)) / a_a)
+	if v_x > 255 {
+		return 255
+	}
+	return uint8(v_x)
+}
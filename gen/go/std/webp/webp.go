@@ -0,0 +1,3204 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package webp
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadHuffmanCodeOverSubscribed                 = base.NewError("webp: bad Huffman code (over-subscribed)")
+	ErrBadHuffmanCodeUnderSubscribed                = base.NewError("webp: bad Huffman code (under-subscribed)")
+	ErrBadHuffmanCode                               = base.NewError("webp: bad Huffman code")
+	ErrBadBackReference                             = base.NewError("webp: bad back-reference")
+	ErrBadColorCacheSize                            = base.NewError("webp: bad color cache size")
+	ErrBadHeader                                    = base.NewError("webp: bad header")
+	ErrBadTransform                                 = base.NewError("webp: bad transform")
+	ErrBadWorkbufLength                             = base.NewError("webp: bad workbuf length")
+	ErrTODOUnsupportedLossyImage                    = base.NewError("webp: TODO: unsupported lossy image")
+	errInternalErrorInconsistentHuffmanDecoderState = base.NewError("webp: internal error: inconsistent Huffman decoder state")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a webp.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_width                uint32
+	f_height               uint32
+	f_call_sequence        uint8
+	f_end_of_data          bool
+	f_is_animated          bool
+	f_num_loops            uint32
+	f_riff_length          uint32
+	f_chunk_type           uint32
+	f_chunk_length         uint32
+	f_anmf_length          uint32
+	f_frame_rect           base.RectIEU32
+	f_frame_x0             uint32
+	f_frame_y0             uint32
+	f_frame_duration       uint64
+	f_frame_blend          bool
+	f_frame_disposal       uint8
+	f_vp8l_width           uint32
+	f_vp8l_height          uint32
+	f_bits                 uint64
+	f_n_bits               uint32
+	f_num_transforms       uint32
+	f_seen_transforms      uint32
+	f_transform_types      [4]uint32
+	f_transform_widths     [4]uint32
+	f_predictor_bits       uint32
+	f_predictor_width      uint32
+	f_color_bits           uint32
+	f_color_width          uint32
+	f_palette_width_bits   uint32
+	f_meta_bits            uint32
+	f_meta_width           uint32
+	f_cache_bits           uint32
+	f_huff_table           [5232]uint8
+	f_max_groups           uint32
+	f_group_map            [65536]uint16
+	f_code_lengths         [2328]uint8
+	f_color_cache          [2048]uint32
+	f_sub_image_max_pixels uint32
+	f_palette              [256]uint32
+
+	c_decode_config struct {
+		coroSuspPoint uint32
+		v_riff_length uint32
+		v_max_width   uint32
+		v_max_height  uint32
+		v_sub_width   uint32
+		v_sub_height  uint32
+		scratch       uint64
+	}
+
+	c_decode_frame struct {
+		coroSuspPoint uint32
+		v_workbuf_len uint64
+		scratch       uint64
+	}
+
+	c_next_chunk struct {
+		coroSuspPoint   uint32
+		v_length        uint32
+		v_padded_length uint64
+		scratch         uint64
+	}
+
+	c_decode_anmf_header struct {
+		coroSuspPoint   uint32
+		v_x0            uint32
+		v_y0            uint32
+		v_w             uint32
+		v_h             uint32
+		v_x1            uint32
+		v_y1            uint32
+		v_flags         uint8
+		v_chunk_type    uint32
+		v_length        uint32
+		v_padded_length uint64
+		scratch         uint64
+	}
+
+	c_decode_vp8l_header struct {
+		coroSuspPoint uint32
+		v_c           uint32
+		scratch       uint64
+	}
+
+	c_decode_vp8l struct {
+		coroSuspPoint uint32
+		v_width       uint32
+		v_height      uint32
+		v_t           uint32
+		v_b           uint32
+		v_w           uint32
+		v_h           uint32
+		v_num_colors  uint32
+		v_i           uint32
+	}
+
+	c_decode_frame_image struct {
+		coroSuspPoint uint32
+		v_cache_bits  uint32
+		v_num_groups  uint32
+		v_b           uint32
+		v_w           uint32
+		v_h           uint32
+		v_i           uint32
+		v_n           uint32
+		v_g           uint32
+	}
+
+	c_decode_sub_image struct {
+		coroSuspPoint uint32
+	}
+
+	c_read_color_cache_bits struct {
+		coroSuspPoint uint32
+		v_c           uint32
+	}
+
+	c_read_huffman_groups struct {
+		coroSuspPoint       uint32
+		v_num_green_symbols uint32
+		v_g                 uint32
+		v_next              uint32
+		v_position          uint32
+		v_offset            uint64
+	}
+
+	c_read_huffman_code struct {
+		coroSuspPoint      uint32
+		v_i                uint32
+		v_num_symbols      uint32
+		v_simple_symbol    uint32
+		v_num_code_lengths uint32
+		v_code_length_t    uint64
+		v_max_symbol       uint32
+		v_length_n_bits    uint32
+		v_prev_code_length uint8
+		v_symbol           uint32
+		v_code             uint32
+		v_repeat           uint32
+		v_value            uint8
+	}
+
+	c_fill_bits struct {
+		coroSuspPoint uint32
+		v_c           uint8
+	}
+
+	c_decode_pixels struct {
+		coroSuspPoint     uint32
+		v_num_pixels      uint32
+		v_p               uint32
+		v_x               uint32
+		v_y               uint32
+		v_group           uint64
+		v_code            uint32
+		v_argb            uint32
+		v_length          uint32
+		v_dist_code       uint32
+		v_dist            uint32
+		v_q               uint32
+		v_qx              uint32
+		v_qy              uint32
+		v_i               uint32
+		v_num_cache_codes uint32
+	}
+}
+
+// ---------------- Private Consts
+
+const fourcc_riff uint32 = 0x46464952
+
+const fourcc_webp uint32 = 0x50424557
+
+const fourcc_alph uint32 = 0x48504C41
+
+const fourcc_anim uint32 = 0x4D494E41
+
+const fourcc_anmf uint32 = 0x464D4E41
+
+const fourcc_vp8 uint32 = 0x20385056
+
+const fourcc_vp8l uint32 = 0x4C385056
+
+const fourcc_vp8x uint32 = 0x58385056
+
+const transform_predictor uint32 = 0x0
+
+const transform_color uint32 = 0x1
+
+const transform_subtract_green uint32 = 0x2
+
+const transform_color_indexing uint32 = 0x3
+
+const which_frame uint32 = 0x0
+
+const which_predictor uint32 = 0x1
+
+const which_color uint32 = 0x2
+
+const which_meta uint32 = 0x3
+
+const which_palette uint32 = 0x4
+
+const table_header_size uint64 = 0x240
+
+const table_offset_red uint64 = 0x1470
+
+const table_offset_blue uint64 = 0x18B0
+
+const table_offset_alpha uint64 = 0x1CF0
+
+const table_offset_distance uint64 = 0x2130
+
+const group_size uint64 = 0x23C0
+
+const flicks_per_millisecond uint64 = 0xAC440
+
+var code_length_code_order = [19]uint8{
+	0x11,
+	0x12,
+	0x0,
+	0x1,
+	0x2,
+	0x3,
+	0x4,
+	0x5,
+	0x10,
+	0x6,
+	0x7,
+	0x8,
+	0x9,
+	0xA,
+	0xB,
+	0xC,
+	0xD,
+	0xE,
+	0xF,
+}
+
+var distance_map = [120]uint8{
+	0x18,
+	0x7,
+	0x17,
+	0x19,
+	0x28,
+	0x6,
+	0x27,
+	0x29,
+	0x16,
+	0x1A,
+	0x26,
+	0x2A,
+	0x38,
+	0x5,
+	0x37,
+	0x39,
+	0x15,
+	0x1B,
+	0x36,
+	0x3A,
+	0x25,
+	0x2B,
+	0x48,
+	0x4,
+	0x47,
+	0x49,
+	0x14,
+	0x1C,
+	0x35,
+	0x3B,
+	0x46,
+	0x4A,
+	0x24,
+	0x2C,
+	0x58,
+	0x45,
+	0x4B,
+	0x34,
+	0x3C,
+	0x3,
+	0x57,
+	0x59,
+	0x13,
+	0x1D,
+	0x56,
+	0x5A,
+	0x23,
+	0x2D,
+	0x44,
+	0x4C,
+	0x55,
+	0x5B,
+	0x33,
+	0x3D,
+	0x68,
+	0x2,
+	0x67,
+	0x69,
+	0x12,
+	0x1E,
+	0x66,
+	0x6A,
+	0x22,
+	0x2E,
+	0x54,
+	0x5C,
+	0x43,
+	0x4D,
+	0x65,
+	0x6B,
+	0x32,
+	0x3E,
+	0x78,
+	0x1,
+	0x77,
+	0x79,
+	0x53,
+	0x5D,
+	0x11,
+	0x1F,
+	0x64,
+	0x6C,
+	0x42,
+	0x4E,
+	0x76,
+	0x7A,
+	0x21,
+	0x2F,
+	0x75,
+	0x7B,
+	0x31,
+	0x3F,
+	0x63,
+	0x6D,
+	0x52,
+	0x5E,
+	0x0,
+	0x74,
+	0x7C,
+	0x41,
+	0x4F,
+	0x10,
+	0x20,
+	0x62,
+	0x6E,
+	0x30,
+	0x73,
+	0x7D,
+	0x51,
+	0x5F,
+	0x40,
+	0x72,
+	0x7E,
+	0x61,
+	0x6F,
+	0x50,
+	0x71,
+	0x7F,
+	0x60,
+	0x70,
+}
+
+// ---------------- Function Implementations
+
+// -------- func decoder.workbuf_len
+
+func (self *Decoder) WorkbufLen() uint64 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return (self.codeLengthTableOffset() + table_header_size)
+}
+
+// -------- func decoder.groups_offset
+
+func (self *Decoder) groupsOffset() uint64 {
+
+	return (uint64(self.f_sub_image_max_pixels) * 12)
+}
+
+// -------- func decoder.code_length_table_offset
+
+func (self *Decoder) codeLengthTableOffset() uint64 {
+
+	return (self.groupsOffset() + (uint64((self.f_max_groups + 1)) * group_size))
+}
+
+// -------- func decoder.decode_config
+
+func (self *Decoder) DecodeConfig(a_dst *base.ImageConfig, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_riff_length uint32
+		v_max_width   uint32
+		v_max_height  uint32
+		v_sub_width   uint32
+		v_sub_height  uint32
+		t_0           uint32
+		t_1           uint32
+		t_2           uint32
+		t_3           uint32
+		t_4           uint32
+		t_5           uint16
+	)
+
+	r := self.c_decode_config.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_riff_length = self.c_decode_config.v_riff_length
+		v_max_width = self.c_decode_config.v_max_width
+		v_max_height = self.c_decode_config.v_max_height
+		v_sub_width = self.c_decode_config.v_sub_width
+		v_sub_height = self.c_decode_config.v_sub_height
+	}
+
+	if r == 0 {
+		if self.f_call_sequence >= 1 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != fourcc_riff) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_config.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+			goto suspend
+		}
+		v_riff_length = t_1
+	}
+	if r == 0 || r == 3 {
+		if r == 0 || r == 3 {
+			if r == 0 {
+				self.c_decode_config.scratch = 0
+			}
+			if r == 3 {
+				r = 0
+			}
+			csp = 3
+			if t_2, status = a_src.ReadU32LE(&self.c_decode_config.scratch); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_2 != fourcc_webp) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 {
+		if v_riff_length < 4 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_riff_length = (v_riff_length - 4)
+		self.f_num_loops = 1
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.nextChunk(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || (5 <= r && r <= 15) {
+		if r == 5 || (r == 0 && (self.f_chunk_type == fourcc_vp8l)) {
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.decodeVp8lHeader(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_width = self.f_vp8l_width
+				self.f_height = self.f_vp8l_height
+			}
+		} else if (6 <= r && r <= 15) || (r == 0 && (self.f_chunk_type == fourcc_vp8x)) {
+			if r == 0 {
+				if self.f_chunk_length < 10 {
+					status = ErrBadHeader
+					goto exit
+				}
+			}
+			if r == 0 || r == 6 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(4)
+				}
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 7 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if t_3, status = a_src.ReadU24LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				self.f_width = (t_3 + 1)
+			}
+			if r == 0 || r == 8 {
+				if r == 0 {
+					self.c_decode_config.scratch = 0
+				}
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_4, status = a_src.ReadU24LE(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+				self.f_height = (t_4 + 1)
+			}
+			if r == 0 || r == 9 {
+				if r == 0 {
+					self.c_decode_config.scratch = uint64(base.U32SatSub(self.f_chunk_length, 10))
+				}
+				if r == 9 {
+					r = 0
+				}
+				csp = 9
+				if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || (10 <= r && r <= 14) {
+			label_0:
+				for {
+					if r == 0 || r == 10 {
+						if r == 10 {
+							r = 0
+						}
+						csp = 10
+						if status = self.nextChunk(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 || (11 <= r && r <= 14) {
+						if (11 <= r && r <= 13) || (r == 0 && (self.f_chunk_type == fourcc_anim)) {
+							if r == 0 {
+								if self.f_chunk_length < 6 {
+									status = ErrBadHeader
+									goto exit
+								}
+							}
+							if r == 0 || r == 11 {
+								if r == 0 {
+									self.c_decode_config.scratch = uint64(4)
+								}
+								if r == 11 {
+									r = 0
+								}
+								csp = 11
+								if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 || r == 12 {
+								if r == 0 {
+									self.c_decode_config.scratch = 0
+								}
+								if r == 12 {
+									r = 0
+								}
+								csp = 12
+								if t_5, status = a_src.ReadU16LE(&self.c_decode_config.scratch); status != nil {
+									goto suspend
+								}
+								self.f_num_loops = uint32(t_5)
+							}
+							if r == 0 || r == 13 {
+								if r == 0 {
+									self.c_decode_config.scratch = uint64(base.U32SatSub(self.f_chunk_length, 6))
+								}
+								if r == 13 {
+									r = 0
+								}
+								csp = 13
+								if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+									goto suspend
+								}
+							}
+						} else if r == 0 && ((self.f_chunk_type == fourcc_anmf) || (self.f_chunk_type == fourcc_vp8l) || (self.f_chunk_type == fourcc_vp8) || (self.f_chunk_type == fourcc_alph)) {
+							break label_0
+						} else if r == 0 && (self.f_chunk_type == 0) {
+							status = ErrBadHeader
+							goto exit
+						} else {
+							if r == 0 || r == 14 {
+								if r == 0 {
+									self.c_decode_config.scratch = uint64(self.f_chunk_length)
+								}
+								if r == 14 {
+									r = 0
+								}
+								csp = 14
+								if status = a_src.Skip(&self.c_decode_config.scratch); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+				}
+			}
+			if r == 0 || r == 15 {
+				if r == 0 && (self.f_chunk_type == fourcc_anmf) {
+					self.f_is_animated = true
+				} else if r == 15 || (r == 0 && (self.f_chunk_type == fourcc_vp8l)) {
+					if r == 0 || r == 15 {
+						if r == 15 {
+							r = 0
+						}
+						csp = 15
+						if status = self.decodeVp8lHeader(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						if (self.f_width != self.f_vp8l_width) || (self.f_height != self.f_vp8l_height) {
+							status = ErrBadHeader
+							goto exit
+						}
+					}
+				} else {
+					status = ErrTODOUnsupportedLossyImage
+					goto exit
+				}
+			}
+		} else if r == 0 && (self.f_chunk_type == fourcc_vp8) {
+			status = ErrTODOUnsupportedLossyImage
+			goto exit
+		} else {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 {
+		v_max_width = 16384
+		if self.f_width < 16384 {
+			v_max_width = self.f_width
+		}
+		v_max_height = 16384
+		if self.f_height < 16384 {
+			v_max_height = self.f_height
+		}
+		v_sub_width = ((v_max_width + 3) >> 2)
+		v_sub_height = ((v_max_height + 3) >> 2)
+		self.f_sub_image_max_pixels = (v_sub_width * v_sub_height)
+		self.f_max_groups = 65536
+		if self.f_sub_image_max_pixels < 65536 {
+			self.f_max_groups = self.f_sub_image_max_pixels
+		}
+		a_dst.Initialize(570460296, 0, self.f_width, self.f_height, self.f_num_loops)
+		self.f_call_sequence = 1
+	}
+
+	self.c_decode_config.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_config.coroSuspPoint = csp
+	self.c_decode_config.v_riff_length = v_riff_length
+	self.c_decode_config.v_max_width = v_max_width
+	self.c_decode_config.v_max_height = v_max_height
+	self.c_decode_config.v_sub_width = v_sub_width
+	self.c_decode_config.v_sub_height = v_sub_height
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.decode_frame
+
+func (self *Decoder) DecodeFrame(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	if a_dst == nil {
+		self.status = base.ErrBadArgument
+		return base.ErrBadArgument
+	}
+	a_src.Derive()
+
+	var (
+		v_workbuf_len uint64
+		v_palette     []byte
+	)
+
+	r := self.c_decode_frame.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_workbuf_len = self.c_decode_frame.v_workbuf_len
+	}
+
+	if r == 0 {
+		if self.f_call_sequence == 0 {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || (1 <= r && r <= 2) {
+		if (1 <= r && r <= 2) || (r == 0 && !self.f_end_of_data) {
+			if r == 0 || (1 <= r && r <= 2) {
+				if r == 0 && (self.f_call_sequence == 1) {
+					self.f_call_sequence = 2
+				} else if (1 <= r && r <= 2) || (r == 0 && self.f_is_animated) {
+					if r == 0 || (1 <= r && r <= 2) {
+					label_0:
+						for {
+							if r == 0 || r == 1 {
+								if r == 1 {
+									r = 0
+								}
+								csp = 1
+								if status = self.nextChunk(a_src); status != nil {
+									goto suspend
+								}
+							}
+							if r == 0 {
+								if self.f_chunk_type == fourcc_anmf {
+									break label_0
+								} else if self.f_chunk_type == 0 {
+									self.f_end_of_data = true
+									break label_0
+								}
+							}
+							if r == 0 || r == 2 {
+								if r == 0 {
+									self.c_decode_frame.scratch = uint64(self.f_chunk_length)
+								}
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if status = a_src.Skip(&self.c_decode_frame.scratch); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+				} else {
+					self.f_end_of_data = true
+				}
+			}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 || (r == 0 && self.f_end_of_data) {
+			if r == 0 || r == 3 {
+				for {
+					if r == 0 || r == 3 {
+						status = base.SuspensionEndOfData
+						if r == 3 {
+							r = 0
+							status = nil
+						} else {
+							csp = 3
+							goto suspend
+						}
+					}
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_workbuf_len = (self.codeLengthTableOffset() + table_header_size)
+		if uint64(len(a_workbuf)) < v_workbuf_len {
+			status = ErrBadWorkbufLength
+			goto exit
+		}
+	}
+	if r == 0 || r == 4 {
+		if r == 4 || (r == 0 && self.f_is_animated) {
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.decodeAnmfHeader(a_src); status != nil {
+					goto suspend
+				}
+			}
+		} else {
+			self.f_frame_rect.SetMaxExclusiveX(self.f_width)
+			self.f_frame_rect.SetMaxExclusiveY(self.f_height)
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.decodeVp8l(a_dst, a_src, a_workbuf); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 0 {
+			self.c_decode_frame.scratch = uint64((uint64(self.f_chunk_length) + uint64(self.f_anmf_length)))
+		}
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if status = a_src.Skip(&self.c_decode_frame.scratch); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_chunk_length = 0
+		self.f_anmf_length = 0
+		v_palette = nil
+		a_dst.Update(self.f_frame_rect, self.f_frame_duration, self.f_frame_blend, self.f_frame_disposal, v_palette)
+	}
+
+	self.c_decode_frame.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame.coroSuspPoint = csp
+	self.c_decode_frame.v_workbuf_len = v_workbuf_len
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func decoder.next_chunk
+
+func (self *Decoder) nextChunk(a_src base.IOReader) (status error) {
+
+	var (
+		v_length        uint32
+		v_padded_length uint64
+		t_0             uint32
+		t_1             uint32
+	)
+
+	r := self.c_next_chunk.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_length = self.c_next_chunk.v_length
+		v_padded_length = self.c_next_chunk.v_padded_length
+	}
+
+	if r == 0 {
+		if self.f_riff_length == 0 {
+			self.f_chunk_type = 0
+			self.f_chunk_length = 0
+			status = nil
+			goto ok
+		} else if self.f_riff_length < 8 {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_next_chunk.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU32LE(&self.c_next_chunk.scratch); status != nil {
+			goto suspend
+		}
+		self.f_chunk_type = t_0
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_next_chunk.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU32LE(&self.c_next_chunk.scratch); status != nil {
+			goto suspend
+		}
+		v_length = t_1
+	}
+	if r == 0 {
+		v_padded_length = (uint64(v_length) + uint64((v_length & 1)))
+		if (uint64(self.f_riff_length) < 8) || (v_padded_length > (uint64(self.f_riff_length) - 8)) {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_chunk_length = uint32((v_padded_length & 4294967295))
+		self.f_riff_length = base.U32SatSub(self.f_riff_length, base.U32SatAdd(self.f_chunk_length, 8))
+	}
+
+ok:
+	self.c_next_chunk.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_next_chunk.coroSuspPoint = csp
+	self.c_next_chunk.v_length = v_length
+	self.c_next_chunk.v_padded_length = v_padded_length
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_anmf_header
+
+func (self *Decoder) decodeAnmfHeader(a_src base.IOReader) (status error) {
+
+	var (
+		v_x0            uint32
+		v_y0            uint32
+		v_w             uint32
+		v_h             uint32
+		v_x1            uint32
+		v_y1            uint32
+		v_flags         uint8
+		v_chunk_type    uint32
+		v_length        uint32
+		v_padded_length uint64
+		t_0             uint32
+		t_1             uint32
+		t_2             uint32
+		t_3             uint32
+		t_4             uint32
+		t_5             uint8
+		t_6             uint32
+		t_7             uint32
+	)
+
+	r := self.c_decode_anmf_header.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_x0 = self.c_decode_anmf_header.v_x0
+		v_y0 = self.c_decode_anmf_header.v_y0
+		v_w = self.c_decode_anmf_header.v_w
+		v_h = self.c_decode_anmf_header.v_h
+		v_x1 = self.c_decode_anmf_header.v_x1
+		v_y1 = self.c_decode_anmf_header.v_y1
+		v_flags = self.c_decode_anmf_header.v_flags
+		v_chunk_type = self.c_decode_anmf_header.v_chunk_type
+		v_length = self.c_decode_anmf_header.v_length
+		v_padded_length = self.c_decode_anmf_header.v_padded_length
+	}
+
+	if r == 0 {
+		if self.f_chunk_length < 16 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_anmf_length = (self.f_chunk_length - 16)
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode_anmf_header.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU24LE(&self.c_decode_anmf_header.scratch); status != nil {
+			goto suspend
+		}
+		v_x0 = (t_0 * 2)
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_anmf_header.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU24LE(&self.c_decode_anmf_header.scratch); status != nil {
+			goto suspend
+		}
+		v_y0 = (t_1 * 2)
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_decode_anmf_header.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if t_2, status = a_src.ReadU24LE(&self.c_decode_anmf_header.scratch); status != nil {
+			goto suspend
+		}
+		v_w = (t_2 + 1)
+	}
+	if r == 0 || r == 4 {
+		if r == 0 {
+			self.c_decode_anmf_header.scratch = 0
+		}
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if t_3, status = a_src.ReadU24LE(&self.c_decode_anmf_header.scratch); status != nil {
+			goto suspend
+		}
+		v_h = (t_3 + 1)
+	}
+	if r == 0 {
+		v_x1 = (v_x0 + v_w)
+		v_y1 = (v_y0 + v_h)
+		if (v_x1 > self.f_width) || (v_y1 > self.f_height) {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_frame_rect.SetMinInclusiveX(v_x0)
+		self.f_frame_rect.SetMinInclusiveY(v_y0)
+		self.f_frame_rect.SetMaxExclusiveX(v_x1)
+		self.f_frame_rect.SetMaxExclusiveY(v_y1)
+		self.f_frame_x0 = v_x0
+		self.f_frame_y0 = v_y0
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_decode_anmf_header.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_4, status = a_src.ReadU24LE(&self.c_decode_anmf_header.scratch); status != nil {
+			goto suspend
+		}
+		self.f_frame_duration = (uint64(t_4) * flicks_per_millisecond)
+	}
+	if r == 0 || r == 6 {
+		if r == 6 {
+			r = 0
+		}
+		csp = 6
+		if t_5, status = a_src.ReadU8(); status != nil {
+			goto suspend
+		}
+		v_flags = t_5
+	}
+	if r == 0 {
+		self.f_frame_blend = ((v_flags & 2) == 0)
+		if (v_flags & 1) != 0 {
+			self.f_frame_disposal = 1
+		} else {
+			self.f_frame_disposal = 0
+		}
+	}
+	if r == 0 || (7 <= r && r <= 9) {
+	label_0:
+		for {
+			if r == 0 {
+				if self.f_anmf_length < 8 {
+					status = ErrBadHeader
+					goto exit
+				}
+			}
+			if r == 0 || r == 7 {
+				if r == 0 {
+					self.c_decode_anmf_header.scratch = 0
+				}
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if t_6, status = a_src.ReadU32LE(&self.c_decode_anmf_header.scratch); status != nil {
+					goto suspend
+				}
+				v_chunk_type = t_6
+			}
+			if r == 0 || r == 8 {
+				if r == 0 {
+					self.c_decode_anmf_header.scratch = 0
+				}
+				if r == 8 {
+					r = 0
+				}
+				csp = 8
+				if t_7, status = a_src.ReadU32LE(&self.c_decode_anmf_header.scratch); status != nil {
+					goto suspend
+				}
+				v_length = t_7
+			}
+			if r == 0 {
+				v_padded_length = (uint64(v_length) + uint64((v_length & 1)))
+				if (uint64(self.f_anmf_length) < 8) || (v_padded_length > (uint64(self.f_anmf_length) - 8)) {
+					status = ErrBadHeader
+					goto exit
+				}
+				self.f_chunk_type = v_chunk_type
+				self.f_chunk_length = uint32((v_padded_length & 4294967295))
+				self.f_anmf_length = base.U32SatSub(self.f_anmf_length, base.U32SatAdd(self.f_chunk_length, 8))
+				if v_chunk_type == fourcc_vp8l {
+					break label_0
+				} else if (v_chunk_type == fourcc_vp8) || (v_chunk_type == fourcc_alph) {
+					status = ErrTODOUnsupportedLossyImage
+					goto exit
+				}
+			}
+			if r == 0 || r == 9 {
+				if r == 0 {
+					self.c_decode_anmf_header.scratch = uint64(self.f_chunk_length)
+				}
+				if r == 9 {
+					r = 0
+				}
+				csp = 9
+				if status = a_src.Skip(&self.c_decode_anmf_header.scratch); status != nil {
+					goto suspend
+				}
+			}
+		}
+	}
+	if r == 0 || r == 10 {
+		if r == 10 {
+			r = 0
+		}
+		csp = 10
+		if status = self.decodeVp8lHeader(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		if (v_w != self.f_vp8l_width) || (v_h != self.f_vp8l_height) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+
+	self.c_decode_anmf_header.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_anmf_header.coroSuspPoint = csp
+	self.c_decode_anmf_header.v_x0 = v_x0
+	self.c_decode_anmf_header.v_y0 = v_y0
+	self.c_decode_anmf_header.v_w = v_w
+	self.c_decode_anmf_header.v_h = v_h
+	self.c_decode_anmf_header.v_x1 = v_x1
+	self.c_decode_anmf_header.v_y1 = v_y1
+	self.c_decode_anmf_header.v_flags = v_flags
+	self.c_decode_anmf_header.v_chunk_type = v_chunk_type
+	self.c_decode_anmf_header.v_length = v_length
+	self.c_decode_anmf_header.v_padded_length = v_padded_length
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_vp8l_header
+
+func (self *Decoder) decodeVp8lHeader(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint32
+		t_0 uint8
+		t_1 uint32
+	)
+
+	r := self.c_decode_vp8l_header.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_decode_vp8l_header.v_c
+	}
+
+	if r == 0 {
+		if self.f_chunk_length < 5 {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 || r == 1 {
+			if r == 1 {
+				r = 0
+			}
+			csp = 1
+			if t_0, status = a_src.ReadU8(); status != nil {
+				goto suspend
+			}
+		}
+		if r == 0 && (t_0 != 47) {
+			status = ErrBadHeader
+			goto exit
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 0 {
+			self.c_decode_vp8l_header.scratch = 0
+		}
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if t_1, status = a_src.ReadU32LE(&self.c_decode_vp8l_header.scratch); status != nil {
+			goto suspend
+		}
+		v_c = t_1
+	}
+	if r == 0 {
+		if (v_c >> 29) != 0 {
+			status = ErrBadHeader
+			goto exit
+		}
+		self.f_vp8l_width = ((v_c & 16383) + 1)
+		self.f_vp8l_height = (((v_c >> 14) & 16383) + 1)
+		self.f_chunk_length = base.U32SatSub(self.f_chunk_length, 5)
+	}
+
+	self.c_decode_vp8l_header.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_vp8l_header.coroSuspPoint = csp
+	self.c_decode_vp8l_header.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_vp8l
+
+func (self *Decoder) decodeVp8l(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte) (status error) {
+
+	var (
+		v_width      uint32
+		v_height     uint32
+		v_t          uint32
+		v_b          uint32
+		v_w          uint32
+		v_h          uint32
+		v_num_colors uint32
+		v_i          uint32
+	)
+
+	r := self.c_decode_vp8l.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_width = self.c_decode_vp8l.v_width
+		v_height = self.c_decode_vp8l.v_height
+		v_t = self.c_decode_vp8l.v_t
+		v_b = self.c_decode_vp8l.v_b
+		v_w = self.c_decode_vp8l.v_w
+		v_h = self.c_decode_vp8l.v_h
+		v_num_colors = self.c_decode_vp8l.v_num_colors
+		v_i = self.c_decode_vp8l.v_i
+	}
+
+	if r == 0 {
+		self.f_bits = 0
+		self.f_n_bits = 0
+		self.f_num_transforms = 0
+		self.f_seen_transforms = 0
+		self.f_meta_bits = 0
+		v_width = self.f_vp8l_width
+		v_height = self.f_vp8l_height
+		v_t = 0
+		v_b = 0
+		v_w = 0
+		v_h = 0
+		v_num_colors = 0
+		v_i = 0
+	}
+	if r == 0 || (1 <= r && r <= 4) {
+	label_0:
+		for {
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				if self.takeBits(1) == 0 {
+					break label_0
+				}
+				v_t = (self.takeBits(2) & 3)
+				if ((self.f_seen_transforms >> v_t) & 1) != 0 {
+					status = ErrBadTransform
+					goto exit
+				}
+				self.f_seen_transforms |= (uint32(1) << v_t)
+				v_i = self.f_num_transforms
+				if v_i >= 4 {
+					status = errInternalErrorInconsistentHuffmanDecoderState
+					goto exit
+				}
+				self.f_transform_types[v_i] = v_t
+				self.f_transform_widths[v_i] = v_width
+				self.f_num_transforms = (v_i + 1)
+			}
+			if r == 0 || (2 <= r && r <= 4) {
+				if (2 <= r && r <= 3) || (r == 0 && ((v_t == transform_predictor) || (v_t == transform_color))) {
+					if r == 0 {
+						v_b = ((self.takeBits(3) & 7) + 2)
+						v_w = self.divRoundUp(v_width, v_b)
+						v_h = self.divRoundUp(v_height, v_b)
+					}
+					if r == 0 || (2 <= r && r <= 3) {
+						if r == 2 || (r == 0 && (v_t == transform_predictor)) {
+							if r == 0 {
+								self.f_predictor_bits = v_b
+								self.f_predictor_width = v_w
+							}
+							if r == 0 || r == 2 {
+								if r == 2 {
+									r = 0
+								}
+								csp = 2
+								if status = self.decodeSubImage(a_dst, a_src, a_workbuf, which_predictor, v_w, v_h); status != nil {
+									goto suspend
+								}
+							}
+						} else {
+							if r == 0 {
+								self.f_color_bits = v_b
+								self.f_color_width = v_w
+							}
+							if r == 0 || r == 3 {
+								if r == 3 {
+									r = 0
+								}
+								csp = 3
+								if status = self.decodeSubImage(a_dst, a_src, a_workbuf, which_color, v_w, v_h); status != nil {
+									goto suspend
+								}
+							}
+						}
+					}
+				} else if r == 4 || (r == 0 && (v_t == transform_color_indexing)) {
+					if r == 0 {
+						v_num_colors = ((self.takeBits(8) & 255) + 1)
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.decodeSubImage(a_dst, a_src, a_workbuf, which_palette, v_num_colors, 1); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						self.decodePalette(v_num_colors)
+						if v_num_colors <= 2 {
+							self.f_palette_width_bits = 3
+						} else if v_num_colors <= 4 {
+							self.f_palette_width_bits = 2
+						} else if v_num_colors <= 16 {
+							self.f_palette_width_bits = 1
+						} else {
+							self.f_palette_width_bits = 0
+						}
+						v_width = self.divRoundUp(v_width, self.f_palette_width_bits)
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.decodeFrameImage(a_dst, a_src, a_workbuf, v_width, v_height); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_i = self.f_num_transforms
+		for v_i > 0 {
+			v_t = self.f_transform_types[(v_i - 1)]
+			v_w = self.f_transform_widths[(v_i - 1)]
+			v_i -= 1
+			if v_t == transform_predictor {
+				self.applyPredictorTransform(a_dst, a_workbuf, v_w, v_height)
+			} else if v_t == transform_color {
+				self.applyColorTransform(a_dst, a_workbuf, v_w, v_height)
+			} else if v_t == transform_subtract_green {
+				self.applySubtractGreenTransform(a_dst, v_w, v_height)
+			} else {
+				self.applyColorIndexingTransform(a_dst, v_w, v_height)
+			}
+		}
+	}
+
+	self.c_decode_vp8l.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_vp8l.coroSuspPoint = csp
+	self.c_decode_vp8l.v_width = v_width
+	self.c_decode_vp8l.v_height = v_height
+	self.c_decode_vp8l.v_t = v_t
+	self.c_decode_vp8l.v_b = v_b
+	self.c_decode_vp8l.v_w = v_w
+	self.c_decode_vp8l.v_h = v_h
+	self.c_decode_vp8l.v_num_colors = v_num_colors
+	self.c_decode_vp8l.v_i = v_i
+
+exit:
+	return status
+}
+
+// -------- func decoder.div_round_up
+
+func (self *Decoder) divRoundUp(a_n uint32, a_b uint32) uint32 {
+
+	return ((a_n + ((uint32(1) << a_b) - 1)) >> a_b)
+}
+
+// -------- func decoder.decode_palette
+
+func (self *Decoder) decodePalette(a_n uint32) {
+
+	var (
+		v_i uint32
+	)
+
+	v_i = 1
+	for v_i < a_n {
+		self.f_palette[v_i] = self.addPixels(self.f_palette[v_i], self.f_palette[(v_i-1)])
+		v_i += 1
+	}
+	for v_i < 256 {
+		self.f_palette[v_i] = 0
+		v_i += 1
+	}
+}
+
+// -------- func decoder.decode_frame_image
+
+func (self *Decoder) decodeFrameImage(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte, a_width uint32, a_height uint32) (status error) {
+
+	var (
+		v_cache_bits uint32
+		v_num_groups uint32
+		v_b          uint32
+		v_w          uint32
+		v_h          uint32
+		v_i          uint32
+		v_n          uint32
+		v_g          uint32
+	)
+
+	r := self.c_decode_frame_image.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_cache_bits = self.c_decode_frame_image.v_cache_bits
+		v_num_groups = self.c_decode_frame_image.v_num_groups
+		v_b = self.c_decode_frame_image.v_b
+		v_w = self.c_decode_frame_image.v_w
+		v_h = self.c_decode_frame_image.v_h
+		v_i = self.c_decode_frame_image.v_i
+		v_n = self.c_decode_frame_image.v_n
+		v_g = self.c_decode_frame_image.v_g
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.readColorCacheBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_cache_bits = self.f_cache_bits
+		v_num_groups = 1
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 || (r == 0 && (self.takeBits(1) != 0)) {
+			if r == 0 {
+				v_b = ((self.takeBits(3) & 7) + 2)
+				v_w = self.divRoundUp(a_width, v_b)
+				v_h = self.divRoundUp(a_height, v_b)
+			}
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.decodeSubImage(a_dst, a_src, a_workbuf, which_meta, v_w, v_h); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_i = 0
+				for v_i < 65536 {
+					self.f_group_map[v_i] = 0
+					v_i += 1
+				}
+				v_n = (v_w * v_h)
+				v_i = 0
+				for v_i < v_n {
+					v_g = ((self.loadSubPixel(a_workbuf, which_meta, uint64(v_i)) >> 8) & 65535)
+					self.f_group_map[v_g] = 1
+					if v_num_groups <= v_g {
+						v_num_groups = (v_g + 1)
+					}
+					v_i += 1
+				}
+				self.f_meta_bits = v_b
+				self.f_meta_width = v_w
+			}
+		}
+	}
+	if r == 0 {
+		self.f_cache_bits = v_cache_bits
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.readHuffmanGroups(a_src, a_workbuf, v_num_groups); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.decodePixels(a_dst, a_src, a_workbuf, which_frame, a_width, a_height); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_decode_frame_image.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_frame_image.coroSuspPoint = csp
+	self.c_decode_frame_image.v_cache_bits = v_cache_bits
+	self.c_decode_frame_image.v_num_groups = v_num_groups
+	self.c_decode_frame_image.v_b = v_b
+	self.c_decode_frame_image.v_w = v_w
+	self.c_decode_frame_image.v_h = v_h
+	self.c_decode_frame_image.v_i = v_i
+	self.c_decode_frame_image.v_n = v_n
+	self.c_decode_frame_image.v_g = v_g
+
+exit:
+	return status
+}
+
+// -------- func decoder.decode_sub_image
+
+func (self *Decoder) decodeSubImage(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte, a_which uint32, a_width uint32, a_height uint32) (status error) {
+
+	r := self.c_decode_sub_image.coroSuspPoint
+	csp := uint32(0)
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.readColorCacheBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 {
+			r = 0
+		}
+		csp = 2
+		if status = self.readHuffmanGroups(a_src, a_workbuf, 1); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = self.decodePixels(a_dst, a_src, a_workbuf, a_which, a_width, a_height); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_decode_sub_image.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_sub_image.coroSuspPoint = csp
+
+exit:
+	return status
+}
+
+// -------- func decoder.read_color_cache_bits
+
+func (self *Decoder) readColorCacheBits(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint32
+	)
+
+	r := self.c_read_color_cache_bits.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_read_color_cache_bits.v_c
+	}
+
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		self.f_cache_bits = 0
+		if self.takeBits(1) != 0 {
+			v_c = (self.takeBits(4) & 15)
+			if (v_c < 1) || (11 < v_c) {
+				status = ErrBadColorCacheSize
+				goto exit
+			}
+			self.f_cache_bits = v_c
+		}
+	}
+
+	self.c_read_color_cache_bits.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_color_cache_bits.coroSuspPoint = csp
+	self.c_read_color_cache_bits.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.read_huffman_groups
+
+func (self *Decoder) readHuffmanGroups(a_src base.IOReader, a_workbuf []byte, a_n uint32) (status error) {
+
+	var (
+		v_num_green_symbols uint32
+		v_g                 uint32
+		v_next              uint32
+		v_position          uint32
+		v_offset            uint64
+	)
+
+	r := self.c_read_huffman_groups.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_green_symbols = self.c_read_huffman_groups.v_num_green_symbols
+		v_g = self.c_read_huffman_groups.v_g
+		v_next = self.c_read_huffman_groups.v_next
+		v_position = self.c_read_huffman_groups.v_position
+		v_offset = self.c_read_huffman_groups.v_offset
+	}
+
+	if r == 0 {
+		v_num_green_symbols = 280
+		if self.f_cache_bits > 0 {
+			v_num_green_symbols += (uint32(1) << self.f_cache_bits)
+		}
+		v_g = 0
+		v_next = 0
+		v_position = 0
+		v_offset = 0
+	}
+	if r == 0 || (1 <= r && r <= 5) {
+		for r != 0 || (v_g < a_n) {
+			if r == 0 {
+				v_position = 0
+				if self.f_meta_bits > 0 {
+					if self.f_group_map[v_g] == 0 {
+						v_position = self.f_max_groups
+					} else if v_next < self.f_max_groups {
+						v_position = v_next
+						self.f_group_map[v_g] = uint16(v_next)
+						v_next += 1
+					} else {
+						status = errInternalErrorInconsistentHuffmanDecoderState
+						goto exit
+					}
+				}
+				v_offset = (self.groupsOffset() + (uint64(v_position) * group_size))
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.readHuffmanCode(a_src, a_workbuf, v_offset, v_num_green_symbols); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.readHuffmanCode(a_src, a_workbuf, (v_offset + table_offset_red), 256); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.readHuffmanCode(a_src, a_workbuf, (v_offset + table_offset_blue), 256); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 4 {
+				if r == 4 {
+					r = 0
+				}
+				csp = 4
+				if status = self.readHuffmanCode(a_src, a_workbuf, (v_offset + table_offset_alpha), 256); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 || r == 5 {
+				if r == 5 {
+					r = 0
+				}
+				csp = 5
+				if status = self.readHuffmanCode(a_src, a_workbuf, (v_offset + table_offset_distance), 40); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_g += 1
+			}
+		}
+	}
+
+	self.c_read_huffman_groups.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_huffman_groups.coroSuspPoint = csp
+	self.c_read_huffman_groups.v_num_green_symbols = v_num_green_symbols
+	self.c_read_huffman_groups.v_g = v_g
+	self.c_read_huffman_groups.v_next = v_next
+	self.c_read_huffman_groups.v_position = v_position
+	self.c_read_huffman_groups.v_offset = v_offset
+
+exit:
+	return status
+}
+
+// -------- func decoder.read_huffman_code
+
+func (self *Decoder) readHuffmanCode(a_src base.IOReader, a_workbuf []byte, a_t uint64, a_n uint32) (status error) {
+
+	var (
+		v_i                uint32
+		v_num_symbols      uint32
+		v_simple_symbol    uint32
+		v_num_code_lengths uint32
+		v_code_length_t    uint64
+		v_max_symbol       uint32
+		v_length_n_bits    uint32
+		v_prev_code_length uint8
+		v_symbol           uint32
+		v_code             uint32
+		v_repeat           uint32
+		v_value            uint8
+	)
+
+	r := self.c_read_huffman_code.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_i = self.c_read_huffman_code.v_i
+		v_num_symbols = self.c_read_huffman_code.v_num_symbols
+		v_simple_symbol = self.c_read_huffman_code.v_simple_symbol
+		v_num_code_lengths = self.c_read_huffman_code.v_num_code_lengths
+		v_code_length_t = self.c_read_huffman_code.v_code_length_t
+		v_max_symbol = self.c_read_huffman_code.v_max_symbol
+		v_length_n_bits = self.c_read_huffman_code.v_length_n_bits
+		v_prev_code_length = self.c_read_huffman_code.v_prev_code_length
+		v_symbol = self.c_read_huffman_code.v_symbol
+		v_code = self.c_read_huffman_code.v_code
+		v_repeat = self.c_read_huffman_code.v_repeat
+		v_value = self.c_read_huffman_code.v_value
+	}
+
+	if r == 0 {
+		v_i = 0
+		for v_i < a_n {
+			self.f_code_lengths[v_i] = 0
+			v_i += 1
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 2 {
+		if r == 2 || (r == 0 && (self.takeBits(1) != 0)) {
+			if r == 0 {
+				v_num_symbols = ((self.takeBits(1) & 1) + 1)
+				v_simple_symbol = 0
+				if self.takeBits(1) != 0 {
+					v_simple_symbol = self.takeBits(8)
+				} else {
+					v_simple_symbol = self.takeBits(1)
+				}
+				if v_simple_symbol < a_n {
+					self.f_code_lengths[v_simple_symbol] = 1
+				}
+				if v_num_symbols == 2 {
+					v_simple_symbol = self.takeBits(8)
+					if v_simple_symbol < a_n {
+						self.f_code_lengths[v_simple_symbol] = 1
+					}
+				}
+			}
+			if r == 0 || r == 2 {
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if status = self.buildHuffman(a_workbuf, a_t, a_n); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				status = nil
+				goto ok
+			}
+		}
+	}
+	if r == 0 {
+		v_num_code_lengths = ((self.takeBits(4) & 15) + 4)
+		v_i = 0
+	}
+	if r == 0 || r == 3 {
+		for r != 0 || (v_i < v_num_code_lengths) {
+			if r == 0 || r == 3 {
+				if r == 3 {
+					r = 0
+				}
+				csp = 3
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				self.f_code_lengths[code_length_code_order[v_i]] = uint8((self.takeBits(3) & 7))
+				v_i += 1
+			}
+		}
+	}
+	if r == 0 {
+		for v_i < 19 {
+			self.f_code_lengths[code_length_code_order[v_i]] = 0
+			v_i += 1
+		}
+		v_code_length_t = self.codeLengthTableOffset()
+	}
+	if r == 0 || r == 4 {
+		if r == 4 {
+			r = 0
+		}
+		csp = 4
+		if status = self.buildHuffman(a_workbuf, v_code_length_t, 19); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_max_symbol = a_n
+	}
+	if r == 0 || r == 5 {
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if status = self.fillBits(a_src); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 || r == 6 {
+		if r == 6 || (r == 0 && (self.takeBits(1) != 0)) {
+			if r == 0 {
+				v_length_n_bits = ((2 * (self.takeBits(3) & 7)) + 2)
+			}
+			if r == 0 || r == 6 {
+				if r == 6 {
+					r = 0
+				}
+				csp = 6
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_max_symbol = (2 + (self.takeBits(v_length_n_bits) & 65535))
+				if v_max_symbol > a_n {
+					status = ErrBadHuffmanCode
+					goto exit
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_i = 0
+		for v_i < a_n {
+			self.f_code_lengths[v_i] = 0
+			v_i += 1
+		}
+		v_prev_code_length = 8
+		v_symbol = 0
+		v_code = 0
+		v_repeat = 0
+		v_value = 0
+	}
+	if r == 0 || r == 7 {
+	label_0:
+		for r != 0 || (v_symbol < a_n) {
+			if r == 0 {
+				if v_max_symbol == 0 {
+					break label_0
+				}
+				v_max_symbol -= 1
+			}
+			if r == 0 || r == 7 {
+				if r == 7 {
+					r = 0
+				}
+				csp = 7
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_code = self.decodeHuffman(a_workbuf, v_code_length_t)
+				if v_code < 16 {
+					self.f_code_lengths[v_symbol] = uint8(v_code)
+					v_symbol += 1
+					if v_code != 0 {
+						v_prev_code_length = uint8(v_code)
+					}
+					continue label_0
+				}
+				if v_code == 16 {
+					v_repeat = ((self.takeBits(2) & 3) + 3)
+					v_value = v_prev_code_length
+				} else if v_code == 17 {
+					v_repeat = ((self.takeBits(3) & 7) + 3)
+					v_value = 0
+				} else if v_code == 18 {
+					v_repeat = ((self.takeBits(7) & 127) + 11)
+					v_value = 0
+				} else {
+					status = ErrBadHuffmanCode
+					goto exit
+				}
+				for v_repeat > 0 {
+					if v_symbol >= a_n {
+						status = ErrBadHuffmanCode
+						goto exit
+					}
+					self.f_code_lengths[v_symbol] = v_value
+					v_symbol += 1
+					v_repeat -= 1
+				}
+			}
+		}
+	}
+	if r == 0 || r == 8 {
+		if r == 8 {
+			r = 0
+		}
+		csp = 8
+		if status = self.buildHuffman(a_workbuf, a_t, a_n); status != nil {
+			goto suspend
+		}
+	}
+
+ok:
+	self.c_read_huffman_code.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_read_huffman_code.coroSuspPoint = csp
+	self.c_read_huffman_code.v_i = v_i
+	self.c_read_huffman_code.v_num_symbols = v_num_symbols
+	self.c_read_huffman_code.v_simple_symbol = v_simple_symbol
+	self.c_read_huffman_code.v_num_code_lengths = v_num_code_lengths
+	self.c_read_huffman_code.v_code_length_t = v_code_length_t
+	self.c_read_huffman_code.v_max_symbol = v_max_symbol
+	self.c_read_huffman_code.v_length_n_bits = v_length_n_bits
+	self.c_read_huffman_code.v_prev_code_length = v_prev_code_length
+	self.c_read_huffman_code.v_symbol = v_symbol
+	self.c_read_huffman_code.v_code = v_code
+	self.c_read_huffman_code.v_repeat = v_repeat
+	self.c_read_huffman_code.v_value = v_value
+
+exit:
+	return status
+}
+
+// -------- func decoder.build_huffman
+
+func (self *Decoder) buildHuffman(a_workbuf []byte, a_t uint64, a_n uint32) (status error) {
+
+	var (
+		v_counts      [16]uint32
+		v_num_symbols uint32
+		v_last_symbol uint32
+		v_i           uint32
+		v_length      uint32
+		v_remaining   uint32
+		v_l           uint32
+		v_next_codes  [16]uint32
+		v_deltas      [16]uint32
+		v_code        uint32
+		v_vals_length uint32
+		v_c           uint32
+		v_maxcode     uint32
+		v_v           uint32
+		v_reversed    uint32
+		v_j           uint32
+		v_unused_bits uint32
+		v_key         uint32
+		v_index       uint32
+	)
+
+	v_counts = [16]uint32{}
+	v_num_symbols = 0
+	v_last_symbol = 0
+	v_i = 0
+	v_length = 0
+	v_i = 0
+	for v_i < a_n {
+		v_length = uint32(self.f_code_lengths[v_i])
+		if v_length != 0 {
+			if (v_counts[v_length] >= 2328) || (v_num_symbols >= 2328) {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			v_counts[v_length] += 1
+			v_num_symbols += 1
+			v_last_symbol = v_i
+		}
+		v_i += 1
+	}
+	if v_num_symbols == 0 {
+		status = ErrBadHuffmanCode
+		goto exit
+	} else if v_num_symbols == 1 {
+		v_i = 0
+		for v_i < 256 {
+			self.setTableValue(v_i, v_last_symbol)
+			v_i += 1
+		}
+		self.copyTable(a_workbuf, a_t, 0)
+		status = nil
+		goto exit
+	}
+	v_i = 0
+	for v_i < 256 {
+		self.setTableValue(v_i, 65535)
+		v_i += 1
+	}
+	v_remaining = 1
+	v_l = 1
+	for v_l <= 15 {
+		if v_remaining > 1073741824 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_remaining <<= 1
+		if v_remaining < v_counts[v_l] {
+			status = ErrBadHuffmanCodeOverSubscribed
+			goto exit
+		}
+		v_remaining -= v_counts[v_l]
+		v_l += 1
+	}
+	if v_remaining != 0 {
+		status = ErrBadHuffmanCodeUnderSubscribed
+		goto exit
+	}
+	v_next_codes = [16]uint32{}
+	v_deltas = [16]uint32{}
+	v_code = 0
+	v_vals_length = 0
+	v_l = 1
+	for v_l <= 15 {
+		v_c = ((v_code + v_counts[(v_l-1)]) << 1)
+		if v_c > 32768 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_code = v_c
+		v_next_codes[v_l] = v_code
+		v_maxcode = (v_code + v_counts[v_l])
+		if v_maxcode > 32768 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		self.setTableValue((256 + v_l), v_maxcode)
+		if v_l >= 9 {
+			v_deltas[v_l] = ((v_vals_length - v_code) & 65535)
+			self.setTableValue((272 + v_l), v_deltas[v_l])
+			v_v = (v_vals_length + v_counts[v_l])
+			if v_v > a_n {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			v_vals_length = v_v
+		}
+		v_l += 1
+	}
+	v_reversed = 0
+	v_j = 0
+	v_unused_bits = 0
+	v_key = 0
+	v_index = 0
+	v_i = 0
+label_0:
+	for v_i < a_n {
+		v_length = uint32(self.f_code_lengths[v_i])
+		if v_length == 0 {
+			v_i += 1
+			continue label_0
+		}
+		v_code = v_next_codes[v_length]
+		if v_code >= 32768 {
+			status = errInternalErrorInconsistentHuffmanDecoderState
+			goto exit
+		}
+		v_next_codes[v_length] = (v_code + 1)
+		if v_length <= 8 {
+			v_unused_bits = (8 - v_length)
+			v_reversed = 0
+			v_j = 0
+			for v_j < 8 {
+				v_reversed = (((v_reversed << 1) | ((v_code >> v_j) & 1)) & 255)
+				v_j += 1
+			}
+			v_key = (v_reversed >> v_unused_bits)
+			for v_key < 256 {
+				self.setTableValue(v_key, ((v_length << 12) | v_i))
+				v_key += (uint32(1) << v_length)
+			}
+		} else {
+			v_index = ((v_code + v_deltas[v_length]) & 65535)
+			if v_index >= 2328 {
+				status = errInternalErrorInconsistentHuffmanDecoderState
+				goto exit
+			}
+			self.setTableValue((288 + v_index), v_i)
+		}
+		v_i += 1
+	}
+	self.copyTable(a_workbuf, a_t, v_vals_length)
+exit:
+	return status
+}
+
+// -------- func decoder.set_table_value
+
+func (self *Decoder) setTableValue(a_i uint32, a_v uint32) {
+
+	self.f_huff_table[(2 * a_i)] = uint8((a_v & 255))
+	self.f_huff_table[((2 * a_i) + 1)] = uint8((a_v >> 8))
+}
+
+// -------- func decoder.copy_table
+
+func (self *Decoder) copyTable(a_workbuf []byte, a_t uint64, a_n uint32) {
+
+	if a_t <= uint64(len(a_workbuf)) {
+		copy(a_workbuf[a_t:], self.f_huff_table[:(576+(2*a_n))])
+	}
+}
+
+// -------- func decoder.load_table_value
+
+func (self *Decoder) loadTableValue(a_workbuf []byte, a_o uint64) uint32 {
+
+	var (
+		v_value [2]uint8
+	)
+
+	v_value = [2]uint8{}
+	if a_o < uint64(len(a_workbuf)) {
+		copy(v_value[:], a_workbuf[a_o:])
+	}
+	return (uint32(v_value[0]) | (uint32(v_value[1]) << 8))
+}
+
+// -------- func decoder.fill_bits
+
+func (self *Decoder) fillBits(a_src base.IOReader) (status error) {
+
+	var (
+		v_c uint8
+		t_0 uint8
+	)
+
+	r := self.c_fill_bits.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_c = self.c_fill_bits.v_c
+	}
+
+	if r == 0 {
+		v_c = 0
+	}
+	if r == 0 || r == 1 {
+		for r != 0 || (self.f_n_bits < 32) {
+			if r == 0 {
+				v_c = 0
+			}
+			if r == 0 || r == 1 {
+				if r == 1 || (r == 0 && (self.f_chunk_length > 0)) {
+					if r == 0 || r == 1 {
+						if r == 1 {
+							r = 0
+						}
+						csp = 1
+						if t_0, status = a_src.ReadU8(); status != nil {
+							goto suspend
+						}
+						v_c = t_0
+					}
+					if r == 0 {
+						self.f_chunk_length = base.U32SatSub(self.f_chunk_length, 1)
+					}
+				}
+			}
+			if r == 0 {
+				self.f_bits |= (uint64(v_c) << self.f_n_bits)
+				self.f_n_bits += 8
+			}
+		}
+	}
+
+	self.c_fill_bits.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_fill_bits.coroSuspPoint = csp
+	self.c_fill_bits.v_c = v_c
+
+exit:
+	return status
+}
+
+// -------- func decoder.take_bits
+
+func (self *Decoder) takeBits(a_n uint32) uint32 {
+
+	var (
+		v_v uint64
+	)
+
+	if self.f_n_bits < a_n {
+		return 0
+	}
+	v_v = ((self.f_bits) & ((uint64(1) << (a_n)) - 1))
+	self.f_bits >>= a_n
+	self.f_n_bits -= a_n
+	return uint32((v_v & 4294967295))
+}
+
+// -------- func decoder.decode_huffman
+
+func (self *Decoder) decodeHuffman(a_workbuf []byte, a_t uint64) uint32 {
+
+	var (
+		v_entry uint32
+		v_n     uint32
+		v_bits  uint64
+		v_code  uint32
+		v_index uint32
+	)
+
+	v_entry = self.loadTableValue(a_workbuf, (a_t + ((self.f_bits & 255) * 2)))
+	v_n = 0
+	if v_entry != 65535 {
+		v_n = (v_entry >> 12)
+		if self.f_n_bits < v_n {
+			return 65535
+		}
+		self.f_bits >>= v_n
+		self.f_n_bits -= v_n
+		return (v_entry & 4095)
+	}
+	v_bits = self.f_bits
+	v_code = 0
+	v_index = 0
+	v_n = 1
+	for v_n <= 15 {
+		v_code = (((v_code << 1) | uint32((v_bits & 1))) & 32767)
+		v_bits >>= 1
+		if (v_n >= 9) && (v_code < self.loadTableValue(a_workbuf, (a_t+512+uint64((v_n*2))))) {
+			if self.f_n_bits < v_n {
+				return 65535
+			}
+			self.f_bits >>= v_n
+			self.f_n_bits -= v_n
+			v_index = ((v_code + self.loadTableValue(a_workbuf, (a_t+544+uint64((v_n*2))))) & 65535)
+			return self.loadTableValue(a_workbuf, (a_t + 576 + uint64((v_index * 2))))
+		}
+		v_n += 1
+	}
+	return 65535
+}
+
+// -------- func decoder.decode_lz77_value
+
+func (self *Decoder) decodeLz77Value(a_p uint32) uint32 {
+
+	var (
+		v_extra_bits uint32
+		v_offset     uint32
+		v_extra      uint32
+	)
+
+	if a_p < 4 {
+		return (a_p + 1)
+	}
+	v_extra_bits = ((a_p - 2) >> 1)
+	v_offset = ((2 + (a_p & 1)) << v_extra_bits)
+	v_extra = (self.takeBits(v_extra_bits) & 262143)
+	return (v_offset + v_extra + 1)
+}
+
+// -------- func decoder.distance
+
+func (self *Decoder) distance(a_code uint32, a_width uint32) uint32 {
+
+	var (
+		v_m uint32
+		v_d uint32
+	)
+
+	if a_code > 120 {
+		return (a_code - 120)
+	} else if a_code < 1 {
+		return 1
+	}
+	v_m = uint32(distance_map[(a_code - 1)])
+	v_d = (((v_m >> 4) * a_width) + 8)
+	if v_d <= (v_m & 15) {
+		return 1
+	}
+	return (v_d - (v_m & 15))
+}
+
+// -------- func decoder.decode_pixels
+
+func (self *Decoder) decodePixels(a_dst *base.ImageBuffer, a_src base.IOReader, a_workbuf []byte, a_which uint32, a_width uint32, a_height uint32) (status error) {
+
+	var (
+		v_num_pixels      uint32
+		v_p               uint32
+		v_x               uint32
+		v_y               uint32
+		v_group           uint64
+		v_code            uint32
+		v_argb            uint32
+		v_length          uint32
+		v_dist_code       uint32
+		v_dist            uint32
+		v_q               uint32
+		v_qx              uint32
+		v_qy              uint32
+		v_i               uint32
+		v_num_cache_codes uint32
+	)
+
+	r := self.c_decode_pixels.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_num_pixels = self.c_decode_pixels.v_num_pixels
+		v_p = self.c_decode_pixels.v_p
+		v_x = self.c_decode_pixels.v_x
+		v_y = self.c_decode_pixels.v_y
+		v_group = self.c_decode_pixels.v_group
+		v_code = self.c_decode_pixels.v_code
+		v_argb = self.c_decode_pixels.v_argb
+		v_length = self.c_decode_pixels.v_length
+		v_dist_code = self.c_decode_pixels.v_dist_code
+		v_dist = self.c_decode_pixels.v_dist
+		v_q = self.c_decode_pixels.v_q
+		v_qx = self.c_decode_pixels.v_qx
+		v_qy = self.c_decode_pixels.v_qy
+		v_i = self.c_decode_pixels.v_i
+		v_num_cache_codes = self.c_decode_pixels.v_num_cache_codes
+	}
+
+	if r == 0 {
+		v_num_pixels = (a_width * a_height)
+		v_p = 0
+		v_x = 0
+		v_y = 0
+		v_group = 0
+		v_code = 0
+		v_argb = 0
+		v_length = 0
+		v_dist_code = 0
+		v_dist = 0
+		v_q = 0
+		v_qx = 0
+		v_qy = 0
+		v_i = 0
+		for v_i < 2048 {
+			self.f_color_cache[v_i] = 0
+			v_i += 1
+		}
+		v_num_cache_codes = 0
+		if self.f_cache_bits > 0 {
+			v_num_cache_codes = (uint32(1) << self.f_cache_bits)
+		}
+	}
+	if r == 0 || (1 <= r && r <= 7) {
+		for r != 0 || (v_p < v_num_pixels) {
+			if r == 0 {
+				v_group = self.groupOffset(a_workbuf, a_which, v_x, v_y)
+			}
+			if r == 0 || r == 1 {
+				if r == 1 {
+					r = 0
+				}
+				csp = 1
+				if status = self.fillBits(a_src); status != nil {
+					goto suspend
+				}
+			}
+			if r == 0 {
+				v_code = self.decodeHuffman(a_workbuf, v_group)
+			}
+			if r == 0 || (2 <= r && r <= 7) {
+				if (2 <= r && r <= 4) || (r == 0 && (v_code < 256)) {
+					if r == 0 {
+						v_argb = (v_code << 8)
+					}
+					if r == 0 || r == 2 {
+						if r == 2 {
+							r = 0
+						}
+						csp = 2
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_argb |= ((self.decodeHuffman(a_workbuf, (v_group+table_offset_red)) & 255) << 16)
+					}
+					if r == 0 || r == 3 {
+						if r == 3 {
+							r = 0
+						}
+						csp = 3
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_argb |= ((self.decodeHuffman(a_workbuf, (v_group+table_offset_blue)) & 255) << 0)
+					}
+					if r == 0 || r == 4 {
+						if r == 4 {
+							r = 0
+						}
+						csp = 4
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_argb |= ((self.decodeHuffman(a_workbuf, (v_group+table_offset_alpha)) & 255) << 24)
+						self.putPixel(a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb)
+						v_p = base.U32SatAdd(v_p, 1)
+						v_x = base.U32SatAdd(v_x, 1)
+						if v_x >= a_width {
+							v_x = 0
+							v_y = base.U32SatAdd(v_y, 1)
+						}
+					}
+				} else if (5 <= r && r <= 7) || (r == 0 && (v_code < 280)) {
+					if r == 0 || r == 5 {
+						if r == 5 {
+							r = 0
+						}
+						csp = 5
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_length = self.decodeLz77Value((v_code - 256))
+					}
+					if r == 0 || r == 6 {
+						if r == 6 {
+							r = 0
+						}
+						csp = 6
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_code = self.decodeHuffman(a_workbuf, (v_group + table_offset_distance))
+						if v_code >= 40 {
+							status = ErrBadHuffmanCode
+							goto exit
+						}
+					}
+					if r == 0 || r == 7 {
+						if r == 7 {
+							r = 0
+						}
+						csp = 7
+						if status = self.fillBits(a_src); status != nil {
+							goto suspend
+						}
+					}
+					if r == 0 {
+						v_dist_code = self.decodeLz77Value(v_code)
+						v_dist = self.distance(v_dist_code, a_width)
+						if (v_dist > v_p) || (v_length > base.U32SatSub(v_num_pixels, v_p)) {
+							status = ErrBadBackReference
+							goto exit
+						}
+						v_q = base.U32SatSub(v_p, v_dist)
+						v_qx = 0
+						v_qy = 0
+						if a_width > 0 {
+							v_qx = (v_q % a_width)
+							v_qy = (v_q / a_width)
+						}
+						for v_length > 0 {
+							v_argb = self.getPixel(a_dst, a_workbuf, a_which, v_qx, v_qy, v_q)
+							self.putPixel(a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb)
+							v_p = base.U32SatAdd(v_p, 1)
+							v_x = base.U32SatAdd(v_x, 1)
+							if v_x >= a_width {
+								v_x = 0
+								v_y = base.U32SatAdd(v_y, 1)
+							}
+							v_q = base.U32SatAdd(v_q, 1)
+							v_qx = base.U32SatAdd(v_qx, 1)
+							if v_qx >= a_width {
+								v_qx = 0
+								v_qy = base.U32SatAdd(v_qy, 1)
+							}
+							v_length -= 1
+						}
+					}
+				} else if r == 0 && ((v_code - 280) < v_num_cache_codes) {
+					v_argb = self.f_color_cache[((v_code - 280) & 2047)]
+					self.putPixel(a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb)
+					v_p = base.U32SatAdd(v_p, 1)
+					v_x = base.U32SatAdd(v_x, 1)
+					if v_x >= a_width {
+						v_x = 0
+						v_y = base.U32SatAdd(v_y, 1)
+					}
+				} else {
+					status = ErrBadHuffmanCode
+					goto exit
+				}
+			}
+		}
+	}
+
+	self.c_decode_pixels.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode_pixels.coroSuspPoint = csp
+	self.c_decode_pixels.v_num_pixels = v_num_pixels
+	self.c_decode_pixels.v_p = v_p
+	self.c_decode_pixels.v_x = v_x
+	self.c_decode_pixels.v_y = v_y
+	self.c_decode_pixels.v_group = v_group
+	self.c_decode_pixels.v_code = v_code
+	self.c_decode_pixels.v_argb = v_argb
+	self.c_decode_pixels.v_length = v_length
+	self.c_decode_pixels.v_dist_code = v_dist_code
+	self.c_decode_pixels.v_dist = v_dist
+	self.c_decode_pixels.v_q = v_q
+	self.c_decode_pixels.v_qx = v_qx
+	self.c_decode_pixels.v_qy = v_qy
+	self.c_decode_pixels.v_i = v_i
+	self.c_decode_pixels.v_num_cache_codes = v_num_cache_codes
+
+exit:
+	return status
+}
+
+// -------- func decoder.group_offset
+
+func (self *Decoder) groupOffset(a_workbuf []byte, a_which uint32, a_x uint32, a_y uint32) uint64 {
+
+	var (
+		v_i uint64
+		v_g uint32
+	)
+
+	if (a_which != which_frame) || (self.f_meta_bits == 0) {
+		return self.groupsOffset()
+	}
+	v_i = ((uint64((a_y >> self.f_meta_bits)) * uint64(self.f_meta_width)) + uint64((a_x >> self.f_meta_bits)))
+	v_g = ((self.loadSubPixel(a_workbuf, which_meta, v_i) >> 8) & 65535)
+	return (self.groupsOffset() + (uint64(self.f_group_map[v_g]) * group_size))
+}
+
+// -------- func decoder.put_pixel
+
+func (self *Decoder) putPixel(a_dst *base.ImageBuffer, a_workbuf []byte, a_which uint32, a_x uint32, a_y uint32, a_p uint32, a_argb uint32) {
+
+	var (
+		v_tab base.TableU8
+		v_h   uint64
+	)
+
+	if a_which == which_frame {
+		v_tab = a_dst.Plane(0)
+		self.storePixel(v_tab.Row((self.f_frame_y0 + a_y)), (self.f_frame_x0 + a_x), a_argb)
+	} else if a_which == which_predictor {
+		self.storeSubPixel(a_workbuf, which_predictor, uint64(a_p), a_argb)
+	} else if a_which == which_color {
+		self.storeSubPixel(a_workbuf, which_color, uint64(a_p), a_argb)
+	} else if a_which == which_meta {
+		self.storeSubPixel(a_workbuf, which_meta, uint64(a_p), a_argb)
+	} else {
+		self.f_palette[(a_p & 255)] = a_argb
+	}
+	if self.f_cache_bits > 0 {
+		v_h = ((uint64(a_argb) * 506832829) & 4294967295)
+		self.f_color_cache[((v_h >> (32 - self.f_cache_bits)) & 2047)] = a_argb
+	}
+}
+
+// -------- func decoder.get_pixel
+
+func (self *Decoder) getPixel(a_dst *base.ImageBuffer, a_workbuf []byte, a_which uint32, a_x uint32, a_y uint32, a_p uint32) uint32 {
+
+	var (
+		v_tab base.TableU8
+	)
+
+	if a_which == which_frame {
+		v_tab = a_dst.Plane(0)
+		return self.loadPixel(v_tab.Row((self.f_frame_y0 + a_y)), (self.f_frame_x0 + a_x))
+	} else if a_which == which_predictor {
+		return self.loadSubPixel(a_workbuf, which_predictor, uint64(a_p))
+	} else if a_which == which_color {
+		return self.loadSubPixel(a_workbuf, which_color, uint64(a_p))
+	} else if a_which == which_meta {
+		return self.loadSubPixel(a_workbuf, which_meta, uint64(a_p))
+	}
+	return self.f_palette[(a_p & 255)]
+}
+
+// -------- func decoder.load_sub_pixel
+
+func (self *Decoder) loadSubPixel(a_workbuf []byte, a_which uint32, a_i uint64) uint32 {
+
+	var (
+		v_n      uint64
+		v_offset uint64
+	)
+
+	v_n = uint64(self.f_sub_image_max_pixels)
+	if a_i >= v_n {
+		return 0
+	}
+	v_offset = (uint64((a_which - 1)) * v_n * 4)
+	if v_offset > uint64(len(a_workbuf)) {
+		return 0
+	}
+	return self.loadPixel(a_workbuf[v_offset:], uint32(a_i))
+}
+
+// -------- func decoder.store_sub_pixel
+
+func (self *Decoder) storeSubPixel(a_workbuf []byte, a_which uint32, a_i uint64, a_argb uint32) {
+
+	var (
+		v_n      uint64
+		v_offset uint64
+	)
+
+	v_n = uint64(self.f_sub_image_max_pixels)
+	if a_i >= v_n {
+		return
+	}
+	v_offset = (uint64((a_which - 1)) * v_n * 4)
+	if v_offset > uint64(len(a_workbuf)) {
+		return
+	}
+	self.storePixel(a_workbuf[v_offset:], uint32(a_i), a_argb)
+}
+
+// -------- func decoder.load_pixel
+
+func (self *Decoder) loadPixel(a_row []byte, a_x uint32) uint32 {
+
+	var (
+		v_x4    uint64
+		v_pixel [4]uint8
+	)
+
+	v_x4 = (uint64(a_x) * 4)
+	v_pixel = [4]uint8{}
+	if v_x4 < uint64(len(a_row)) {
+		copy(v_pixel[:], a_row[v_x4:])
+	}
+	return ((uint32(v_pixel[0]) << 0) | (uint32(v_pixel[1]) << 8) | (uint32(v_pixel[2]) << 16) | (uint32(v_pixel[3]) << 24))
+}
+
+// -------- func decoder.store_pixel
+
+func (self *Decoder) storePixel(a_row []byte, a_x uint32, a_argb uint32) {
+
+	var (
+		v_x4    uint64
+		v_pixel [4]uint8
+	)
+
+	v_x4 = (uint64(a_x) * 4)
+	v_pixel = [4]uint8{}
+	v_pixel[0] = uint8(((a_argb >> 0) & 255))
+	v_pixel[1] = uint8(((a_argb >> 8) & 255))
+	v_pixel[2] = uint8(((a_argb >> 16) & 255))
+	v_pixel[3] = uint8(((a_argb >> 24) & 255))
+	if v_x4 < uint64(len(a_row)) {
+		copy(a_row[v_x4:], v_pixel[:])
+	}
+}
+
+// -------- func decoder.add_pixels
+
+func (self *Decoder) addPixels(a_a uint32, a_b uint32) uint32 {
+
+	return ((((a_a & 4278255360) + (a_b & 4278255360)) & 4278255360) | (((a_a & 16711935) + (a_b & 16711935)) & 16711935))
+}
+
+// -------- func decoder.apply_predictor_transform
+
+func (self *Decoder) applyPredictorTransform(a_dst *base.ImageBuffer, a_workbuf []byte, a_width uint32, a_height uint32) {
+
+	var (
+		v_tab        base.TableU8
+		v_curr       []byte
+		v_prev       []byte
+		v_x0         uint32
+		v_x          uint32
+		v_y          uint32
+		v_l          uint32
+		v_t          uint32
+		v_tr         uint32
+		v_tl         uint32
+		v_prediction uint32
+		v_mode       uint32
+		v_bits       uint32
+		v_i          uint64
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_curr = nil
+	v_prev = nil
+	v_x0 = self.f_frame_x0
+	v_x = 0
+	v_y = 0
+	v_l = 0
+	v_t = 0
+	v_tr = 0
+	v_tl = 0
+	v_prediction = 0
+	v_mode = 0
+	v_bits = self.f_predictor_bits
+	v_i = 0
+	for v_y < a_height {
+		v_curr = v_tab.Row((self.f_frame_y0 + v_y))
+		v_x = 0
+		for v_x < a_width {
+			if v_y == 0 {
+				if v_x == 0 {
+					v_prediction = 4278190080
+				} else {
+					v_prediction = v_l
+				}
+			} else if v_x == 0 {
+				v_prediction = self.loadPixel(v_prev, v_x0)
+			} else {
+				v_i = ((uint64((v_y >> v_bits)) * uint64(self.f_predictor_width)) + uint64((v_x >> v_bits)))
+				v_mode = ((self.loadSubPixel(a_workbuf, which_predictor, v_i) >> 8) & 15)
+				v_t = self.loadPixel(v_prev, (v_x0 + v_x))
+				v_tl = self.loadPixel(v_prev, (v_x0 + (v_x - 1)))
+				if base.U32SatAdd(v_x, 1) < a_width {
+					v_tr = self.loadPixel(v_prev, (v_x0 + base.U32SatAdd(v_x, 1)))
+				} else {
+					v_tr = self.loadPixel(v_curr, v_x0)
+				}
+				v_prediction = self.predict(v_mode, v_l, v_t, v_tr, v_tl)
+			}
+			v_l = self.loadPixel(v_curr, (v_x0 + v_x))
+			v_l = self.addPixels(v_l, v_prediction)
+			self.storePixel(v_curr, (v_x0 + v_x), v_l)
+			v_x = base.U32SatAdd(v_x, 1)
+		}
+		v_prev = v_curr
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+}
+
+// -------- func decoder.predict
+
+func (self *Decoder) predict(a_mode uint32, a_l uint32, a_t uint32, a_tr uint32, a_tl uint32) uint32 {
+
+	var (
+		v_p uint32
+		v_q uint32
+	)
+
+	v_p = 0
+	v_q = 0
+	if a_mode == 1 {
+		return a_l
+	} else if a_mode == 2 {
+		return a_t
+	} else if a_mode == 3 {
+		return a_tr
+	} else if a_mode == 4 {
+		return a_tl
+	} else if a_mode == 5 {
+		v_p = self.average2(a_l, a_tr)
+		return self.average2(v_p, a_t)
+	} else if a_mode == 6 {
+		return self.average2(a_l, a_tl)
+	} else if a_mode == 7 {
+		return self.average2(a_l, a_t)
+	} else if a_mode == 8 {
+		return self.average2(a_tl, a_t)
+	} else if a_mode == 9 {
+		return self.average2(a_t, a_tr)
+	} else if a_mode == 10 {
+		v_p = self.average2(a_l, a_tl)
+		v_q = self.average2(a_t, a_tr)
+		return self.average2(v_p, v_q)
+	} else if a_mode == 11 {
+		return self.selectNearer(a_l, a_t, a_tl)
+	} else if a_mode == 12 {
+		return self.clampAddSubtractFull(a_l, a_t, a_tl)
+	} else if a_mode == 13 {
+		v_p = self.average2(a_l, a_t)
+		return self.clampAddSubtractHalf(v_p, a_tl)
+	}
+	return 4278190080
+}
+
+// -------- func decoder.average2
+
+func (self *Decoder) average2(a_a uint32, a_b uint32) uint32 {
+
+	return ((((a_a ^ a_b) & 4278124286) >> 1) + (a_a & a_b))
+}
+
+// -------- func decoder.select_nearer
+
+func (self *Decoder) selectNearer(a_l uint32, a_t uint32, a_tl uint32) uint32 {
+
+	var (
+		v_distance_to_l uint32
+		v_distance_to_t uint32
+	)
+
+	v_distance_to_l = (self.absDiff(((a_t>>0)&255), ((a_tl>>0)&255)) + self.absDiff(((a_t>>8)&255), ((a_tl>>8)&255)) + self.absDiff(((a_t>>16)&255), ((a_tl>>16)&255)) + self.absDiff(((a_t>>24)&255), ((a_tl>>24)&255)))
+	v_distance_to_t = (self.absDiff(((a_l>>0)&255), ((a_tl>>0)&255)) + self.absDiff(((a_l>>8)&255), ((a_tl>>8)&255)) + self.absDiff(((a_l>>16)&255), ((a_tl>>16)&255)) + self.absDiff(((a_l>>24)&255), ((a_tl>>24)&255)))
+	if v_distance_to_l < v_distance_to_t {
+		return a_l
+	}
+	return a_t
+}
+
+// -------- func decoder.abs_diff
+
+func (self *Decoder) absDiff(a_a uint32, a_b uint32) uint32 {
+
+	if a_a >= a_b {
+		return (a_a - a_b)
+	}
+	return base.U32SatSub(a_b, a_a)
+}
+
+// -------- func decoder.clamp_add_subtract_full
+
+func (self *Decoder) clampAddSubtractFull(a_a uint32, a_b uint32, a_c uint32) uint32 {
+
+	return ((self.clampFull(((a_a>>0)&255), ((a_b>>0)&255), ((a_c>>0)&255)) << 0) | (self.clampFull(((a_a>>8)&255), ((a_b>>8)&255), ((a_c>>8)&255)) << 8) | (self.clampFull(((a_a>>16)&255), ((a_b>>16)&255), ((a_c>>16)&255)) << 16) | (self.clampFull(((a_a>>24)&255), ((a_b>>24)&255), ((a_c>>24)&255)) << 24))
+}
+
+// -------- func decoder.clamp_full
+
+func (self *Decoder) clampFull(a_a uint32, a_b uint32, a_c uint32) uint32 {
+
+	var (
+		v_v uint32
+	)
+
+	v_v = (a_a + a_b)
+	if v_v <= a_c {
+		return 0
+	}
+	v_v -= a_c
+	if v_v > 255 {
+		return 255
+	}
+	return v_v
+}
+
+// -------- func decoder.clamp_add_subtract_half
+
+func (self *Decoder) clampAddSubtractHalf(a_a uint32, a_b uint32) uint32 {
+
+	return ((self.clampHalf(((a_a>>0)&255), ((a_b>>0)&255)) << 0) | (self.clampHalf(((a_a>>8)&255), ((a_b>>8)&255)) << 8) | (self.clampHalf(((a_a>>16)&255), ((a_b>>16)&255)) << 16) | (self.clampHalf(((a_a>>24)&255), ((a_b>>24)&255)) << 24))
+}
+
+// -------- func decoder.clamp_half
+
+func (self *Decoder) clampHalf(a_a uint32, a_b uint32) uint32 {
+
+	var (
+		v_d uint32
+	)
+
+	v_d = 0
+	if a_b >= a_a {
+		v_d = ((a_b - a_a) / 2)
+		if v_d >= a_a {
+			return 0
+		}
+		return (a_a - v_d)
+	}
+	v_d = (base.U32SatSub(a_a, a_b) / 2)
+	if v_d > (255 - a_a) {
+		return 255
+	}
+	return (a_a + v_d)
+}
+
+// -------- func decoder.apply_color_transform
+
+func (self *Decoder) applyColorTransform(a_dst *base.ImageBuffer, a_workbuf []byte, a_width uint32, a_height uint32) {
+
+	var (
+		v_tab  base.TableU8
+		v_row  []byte
+		v_x0   uint32
+		v_x    uint32
+		v_y    uint32
+		v_bits uint32
+		v_i    uint64
+		v_m    uint32
+		v_argb uint32
+		v_g    uint32
+		v_r    uint32
+		v_b    uint32
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_row = nil
+	v_x0 = self.f_frame_x0
+	v_x = 0
+	v_y = 0
+	v_bits = self.f_color_bits
+	v_i = 0
+	v_m = 0
+	v_argb = 0
+	v_g = 0
+	v_r = 0
+	v_b = 0
+	for v_y < a_height {
+		v_row = v_tab.Row((self.f_frame_y0 + v_y))
+		v_x = 0
+		for v_x < a_width {
+			v_i = ((uint64((v_y >> v_bits)) * uint64(self.f_color_width)) + uint64((v_x >> v_bits)))
+			v_m = self.loadSubPixel(a_workbuf, which_color, v_i)
+			v_argb = self.loadPixel(v_row, (v_x0 + v_x))
+			v_g = ((v_argb >> 8) & 255)
+			v_r = (((v_argb >> 16) + self.colorTransformDelta(((v_m>>0)&255), v_g)) & 255)
+			v_b = (((v_argb + self.colorTransformDelta(((v_m>>8)&255), v_g)) + self.colorTransformDelta(((v_m>>16)&255), v_r)) & 255)
+			v_argb = ((v_argb & 4278255360) | (v_r << 16) | v_b)
+			self.storePixel(v_row, (v_x0 + v_x), v_argb)
+			v_x = base.U32SatAdd(v_x, 1)
+		}
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+}
+
+// -------- func decoder.color_transform_delta
+
+func (self *Decoder) colorTransformDelta(a_t uint32, a_c uint32) uint32 {
+
+	var (
+		v_t_magnitude uint32
+		v_c_magnitude uint32
+		v_negative    bool
+		v_product     uint32
+	)
+
+	v_t_magnitude = 0
+	v_c_magnitude = 0
+	v_negative = false
+	if a_t < 128 {
+		v_t_magnitude = a_t
+	} else {
+		v_t_magnitude = (256 - a_t)
+		v_negative = !v_negative
+	}
+	if a_c < 128 {
+		v_c_magnitude = a_c
+	} else {
+		v_c_magnitude = (256 - a_c)
+		v_negative = !v_negative
+	}
+	v_product = (v_t_magnitude * v_c_magnitude)
+	if v_negative {
+		return (0 - ((v_product + 31) >> 5))
+	}
+	return (v_product >> 5)
+}
+
+// -------- func decoder.apply_subtract_green_transform
+
+func (self *Decoder) applySubtractGreenTransform(a_dst *base.ImageBuffer, a_width uint32, a_height uint32) {
+
+	var (
+		v_tab  base.TableU8
+		v_row  []byte
+		v_x0   uint32
+		v_x    uint32
+		v_y    uint32
+		v_argb uint32
+		v_g    uint32
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_row = nil
+	v_x0 = self.f_frame_x0
+	v_x = 0
+	v_y = 0
+	v_argb = 0
+	v_g = 0
+	for v_y < a_height {
+		v_row = v_tab.Row((self.f_frame_y0 + v_y))
+		v_x = 0
+		for v_x < a_width {
+			v_argb = self.loadPixel(v_row, (v_x0 + v_x))
+			v_g = ((v_argb >> 8) & 255)
+			v_argb = self.addPixels(v_argb, ((v_g << 16) | v_g))
+			self.storePixel(v_row, (v_x0 + v_x), v_argb)
+			v_x = base.U32SatAdd(v_x, 1)
+		}
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+}
+
+// -------- func decoder.apply_color_indexing_transform
+
+func (self *Decoder) applyColorIndexingTransform(a_dst *base.ImageBuffer, a_width uint32, a_height uint32) {
+
+	var (
+		v_tab            base.TableU8
+		v_row            []byte
+		v_x0             uint32
+		v_x              uint32
+		v_y              uint32
+		v_width_bits     uint32
+		v_bits_per_index uint32
+		v_index_mask     uint32
+		v_g              uint32
+		v_shift          uint32
+	)
+
+	v_tab = a_dst.Plane(0)
+	v_row = nil
+	v_x0 = self.f_frame_x0
+	v_x = 0
+	v_y = 0
+	v_width_bits = self.f_palette_width_bits
+	v_bits_per_index = (uint32(8) >> v_width_bits)
+	v_index_mask = ((uint32(1) << v_bits_per_index) - 1)
+	v_g = 0
+	v_shift = 0
+	for v_y < a_height {
+		v_row = v_tab.Row((self.f_frame_y0 + v_y))
+		v_x = a_width
+		for v_x > 0 {
+			v_x -= 1
+			v_g = ((self.loadPixel(v_row, (v_x0+(v_x>>v_width_bits))) >> 8) & 255)
+			v_shift = (v_bits_per_index * (v_x & ((uint32(1) << v_width_bits) - 1)))
+			self.storePixel(v_row, (v_x0 + v_x), self.f_palette[((v_g>>(v_shift&7))&v_index_mask)])
+		}
+		v_y = base.U32SatAdd(v_y, 1)
+	}
+}
@@ -0,0 +1,403 @@
+// Code generated by wuffs-go. DO NOT EDIT.
+
+package zlib
+
+import (
+	"github.com/google/wuffs/lib/go/base"
+
+	"github.com/google/wuffs/gen/go/std/adler32"
+	"github.com/google/wuffs/gen/go/std/deflate"
+)
+
+// ---------------- Status Codes
+
+var (
+	ErrBadChecksum               = base.NewError("zlib: bad checksum")
+	ErrBadCompressionMethod      = base.NewError("zlib: bad compression method")
+	ErrBadCompressionWindowSize  = base.NewError("zlib: bad compression window size")
+	ErrBadParityCheck            = base.NewError("zlib: bad parity check")
+	ErrIncorrectDictionary       = base.NewError("zlib: incorrect dictionary")
+	SuspensionDictionaryRequired = base.NewSuspension("zlib: dictionary required")
+)
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+// Decoder holds the state of a zlib.decoder. Its zero value is ready to use.
+type Decoder struct {
+	status error
+
+	f_flate                  deflate.Decoder
+	f_checksum               adler32.Hasher
+	f_ignore_checksum        bool
+	f_dict_id_hasher         adler32.Hasher
+	f_dict_id_got            uint32
+	f_dict_id_want           uint32
+	f_waiting_for_dictionary bool
+	f_got_dictionary         bool
+	f_bad_call_sequence      bool
+
+	c_decode struct {
+		coroSuspPoint   uint32
+		v_x             uint16
+		v_checksum_got  uint32
+		v_z             error
+		v_checksum_want uint32
+		scratch         uint64
+	}
+}
+
+// Encoder holds the state of a zlib.encoder. Its zero value is ready to use.
+type Encoder struct {
+	status error
+
+	f_flate     deflate.Encoder
+	f_checksum  adler32.Hasher
+	f_level     uint32
+	f_level_set bool
+
+	c_encode struct {
+		coroSuspPoint uint32
+		v_x           uint16
+		v_checksum    uint32
+		v_z           error
+		scratch       uint64
+	}
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+func (self *Decoder) SetIgnoreChecksum(a_ic bool) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_ignore_checksum = a_ic
+}
+
+// -------- func decoder.dictionary_id
+
+func (self *Decoder) DictionaryId() uint32 {
+	if base.IsError(self.status) {
+		return 0
+	}
+
+	return self.f_dict_id_want
+}
+
+// -------- func decoder.set_dictionary
+
+func (self *Decoder) SetDictionary(a_dict []byte) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	if !self.f_waiting_for_dictionary {
+		self.f_bad_call_sequence = true
+		return
+	}
+	self.f_waiting_for_dictionary = false
+	self.f_got_dictionary = true
+	self.f_dict_id_got = self.f_dict_id_hasher.Update(a_dict)
+	self.f_flate.AddHistory(a_dict)
+}
+
+// -------- func decoder.decode
+
+func (self *Decoder) Decode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_x             uint16
+		v_checksum_got  uint32
+		v_z             error
+		v_checksum_want uint32
+		t_0             uint16
+		t_1             uint32
+		t_2             error
+		t_3             uint32
+	)
+
+	r := self.c_decode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_x = self.c_decode.v_x
+		v_checksum_got = self.c_decode.v_checksum_got
+		v_z = self.c_decode.v_z
+		v_checksum_want = self.c_decode.v_checksum_want
+	}
+
+	if r == 0 {
+		if self.f_bad_call_sequence {
+			status = base.ErrInvalidCallSequence
+			goto exit
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_decode.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if t_0, status = a_src.ReadU16BE(&self.c_decode.scratch); status != nil {
+			goto suspend
+		}
+		v_x = t_0
+	}
+	if r == 0 {
+		if ((v_x >> 8) & 15) != 8 {
+			status = ErrBadCompressionMethod
+			goto exit
+		}
+		if (v_x >> 12) > 7 {
+			status = ErrBadCompressionWindowSize
+			goto exit
+		}
+		if (v_x % 31) != 0 {
+			status = ErrBadParityCheck
+			goto exit
+		}
+	}
+	if r == 0 || (2 <= r && r <= 3) {
+		if (2 <= r && r <= 3) || (r == 0 && ((v_x & 32) != 0)) {
+			if r == 0 || r == 2 {
+				if r == 0 {
+					self.c_decode.scratch = 0
+				}
+				if r == 2 {
+					r = 0
+				}
+				csp = 2
+				if t_1, status = a_src.ReadU32BE(&self.c_decode.scratch); status != nil {
+					goto suspend
+				}
+				self.f_dict_id_want = t_1
+			}
+			if r == 0 {
+				self.f_waiting_for_dictionary = true
+			}
+			if r == 0 || r == 3 {
+				for r != 0 || !self.f_got_dictionary {
+					if r == 0 || r == 3 {
+						status = SuspensionDictionaryRequired
+						if r == 3 {
+							r = 0
+							status = nil
+						} else {
+							csp = 3
+							goto suspend
+						}
+					}
+				}
+			}
+			if r == 0 {
+				if self.f_bad_call_sequence {
+					status = base.ErrInvalidCallSequence
+					goto exit
+				} else if self.f_dict_id_got != self.f_dict_id_want {
+					status = ErrIncorrectDictionary
+					goto exit
+				}
+			}
+		}
+	}
+	if r == 0 {
+		v_checksum_got = 0
+	}
+	if r == 0 || r == 4 {
+	label_0:
+		for {
+			if r == 0 {
+				a_dst.SetMark()
+				t_2 = self.f_flate.Decode(a_dst, a_src)
+				v_z = t_2
+				if !self.f_ignore_checksum {
+					v_checksum_got = self.f_checksum.Update(a_dst.SinceMark())
+				}
+				if v_z == nil {
+					break label_0
+				}
+			}
+			if r == 0 || r == 4 {
+				status = v_z
+				if r == 4 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 4
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 5 {
+		if r == 0 {
+			self.c_decode.scratch = 0
+		}
+		if r == 5 {
+			r = 0
+		}
+		csp = 5
+		if t_3, status = a_src.ReadU32BE(&self.c_decode.scratch); status != nil {
+			goto suspend
+		}
+		v_checksum_want = t_3
+	}
+	if r == 0 {
+		if !self.f_ignore_checksum && (v_checksum_got != v_checksum_want) {
+			status = ErrBadChecksum
+			goto exit
+		}
+	}
+
+	self.c_decode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_decode.coroSuspPoint = csp
+	self.c_decode.v_x = v_x
+	self.c_decode.v_checksum_got = v_checksum_got
+	self.c_decode.v_z = v_z
+	self.c_decode.v_checksum_want = v_checksum_want
+
+exit:
+	self.status = status
+	return status
+}
+
+// -------- func encoder.set_level
+
+func (self *Encoder) SetLevel(a_level uint32) {
+	if base.IsError(self.status) {
+		return
+	}
+
+	self.f_flate.SetLevel(a_level)
+	self.f_level = base.U32Min(a_level, 9)
+	self.f_level_set = true
+}
+
+// -------- func encoder.encode
+
+func (self *Encoder) Encode(a_dst base.IOWriter, a_src base.IOReader) (status error) {
+	if base.IsError(self.status) {
+		return self.status
+	}
+	a_dst.Derive()
+	a_src.Derive()
+
+	var (
+		v_x        uint16
+		v_checksum uint32
+		v_z        error
+		t_0        error
+	)
+
+	r := self.c_encode.coroSuspPoint
+	csp := uint32(0)
+	if r != 0 {
+		v_x = self.c_encode.v_x
+		v_checksum = self.c_encode.v_checksum
+		v_z = self.c_encode.v_z
+	}
+
+	if r == 0 {
+		if !self.f_level_set {
+			self.f_level_set = true
+			self.f_level = 6
+		}
+		v_x = 30876
+		if self.f_level < 2 {
+			v_x = 30721
+		} else if self.f_level < 6 {
+			v_x = 30814
+		} else if self.f_level > 6 {
+			v_x = 30938
+		}
+	}
+	if r == 0 || r == 1 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 1 {
+			r = 0
+		}
+		csp = 1
+		if status = a_dst.WriteU16BE(&self.c_encode.scratch, v_x); status != nil {
+			goto suspend
+		}
+	}
+	if r == 0 {
+		v_checksum = 0
+	}
+	if r == 0 || r == 2 {
+	label_0:
+		for {
+			if r == 0 {
+				a_src.SetMark()
+				t_0 = self.f_flate.Encode(a_dst, a_src)
+				v_z = t_0
+				v_checksum = self.f_checksum.Update(a_src.SinceMark())
+				if v_z == nil {
+					break label_0
+				}
+			}
+			if r == 0 || r == 2 {
+				status = v_z
+				if r == 2 {
+					r = 0
+					status = nil
+				} else {
+					if base.IsError(status) {
+						goto exit
+					}
+					if status != nil {
+						csp = 2
+						goto suspend
+					}
+				}
+			}
+		}
+	}
+	if r == 0 || r == 3 {
+		if r == 0 {
+			self.c_encode.scratch = 0
+		}
+		if r == 3 {
+			r = 0
+		}
+		csp = 3
+		if status = a_dst.WriteU32BE(&self.c_encode.scratch, v_checksum); status != nil {
+			goto suspend
+		}
+	}
+
+	self.c_encode.coroSuspPoint = 0
+	goto exit
+
+suspend:
+	self.c_encode.coroSuspPoint = csp
+	self.c_encode.v_x = v_x
+	self.c_encode.v_checksum = v_checksum
+	self.c_encode.v_z = v_z
+
+exit:
+	self.status = status
+	return status
+}
@@ -59,6 +59,38 @@ typedef int64_t wuffs_base__flicks;
 
 // --------
 
+static inline int8_t wuffs_base__i8__min(int8_t x, int8_t y) {
+  return x < y ? x : y;
+}
+
+static inline int8_t wuffs_base__i8__max(int8_t x, int8_t y) {
+  return x > y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__min(int16_t x, int16_t y) {
+  return x < y ? x : y;
+}
+
+static inline int16_t wuffs_base__i16__max(int16_t x, int16_t y) {
+  return x > y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__min(int32_t x, int32_t y) {
+  return x < y ? x : y;
+}
+
+static inline int32_t wuffs_base__i32__max(int32_t x, int32_t y) {
+  return x > y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__min(int64_t x, int64_t y) {
+  return x < y ? x : y;
+}
+
+static inline int64_t wuffs_base__i64__max(int64_t x, int64_t y) {
+  return x > y ? x : y;
+}
+
 static inline uint8_t wuffs_base__u8__min(uint8_t x, uint8_t y) {
   return x < y ? x : y;
 }
@@ -663,6 +695,56 @@ static inline wuffs_base__io_writer wuffs_base__io_buffer__writer(
   return ret;
 }
 
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace base {
+
+// io_buffer is a wuffs_base__io_buffer with std::span-style constructors, from
+// a pointer and length, from an array or from any container (such as a
+// std::vector<uint8_t>, std::array<uint8_t, N> or std::span<uint8_t>) that has
+// data() and size() methods.
+//
+// By default, the buffer is empty, ready to be written to. Pass filled = true
+// for a buffer whose contents are ready to be read from. Pass closed = true if
+// no further writes are expected, e.g. if the contents are the complete input.
+class io_buffer : public wuffs_base__io_buffer {
+ public:
+  io_buffer() : wuffs_base__io_buffer() {}
+
+  io_buffer(uint8_t* ptr, size_t len, bool filled = false, bool closed = false)
+      : wuffs_base__io_buffer() {
+    this->ptr = ptr;
+    this->len = len;
+    this->wi = filled ? len : 0;
+    this->closed = closed;
+  }
+
+  template <size_t N>
+  io_buffer(uint8_t (&array)[N], bool filled = false, bool closed = false)
+      : io_buffer(array, N, filled, closed) {}
+
+  template <typename Container>
+  io_buffer(Container& c, bool filled = false, bool closed = false)
+      : io_buffer(c.data(), c.size(), filled, closed) {}
+
+  wuffs_base__io_reader reader() { return wuffs_base__io_buffer__reader(this); }
+  wuffs_base__io_writer writer() { return wuffs_base__io_buffer__writer(this); }
+
+  // reader_data and reader_size are the not-yet-read contents, ptr[ri:wi].
+  uint8_t* reader_data() const { return this->ptr + this->ri; }
+  size_t reader_size() const { return this->wi - this->ri; }
+
+  // writer_data and writer_size are the not-yet-written space, ptr[wi:len].
+  uint8_t* writer_data() const { return this->ptr + this->wi; }
+  size_t writer_size() const { return this->len - this->wi; }
+};
+
+}  // namespace base
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 // ---------------- Images
 
 // wuffs_base__pixel_format encodes the format of the bytes that constitute an
@@ -832,6 +914,45 @@ static inline uint32_t wuffs_base__pixel_format__num_planes(
   return f ? (((f >> 20) & 0x03) + 1) : 0;
 }
 
+// wuffs_base__pixel_format__bits_per_pixel returns the number of bits per
+// pixel for a packed (single plane) pixel format. For indexed formats, this is
+// the number of bits per index value. It returns zero for invalid or planar
+// pixel formats.
+static inline uint32_t wuffs_base__pixel_format__bits_per_pixel(
+    wuffs_base__pixel_format f) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  if (!f || ((f >> 20) & 0x03)) {
+    return 0;
+  }
+  if ((f >> 16) & 0x0F) {
+    return depths[(f >> 16) & 0x0F];
+  }
+  return depths[(f >> 0) & 0x0F] + depths[(f >> 4) & 0x0F] +
+         depths[(f >> 8) & 0x0F] + depths[(f >> 12) & 0x0F];
+}
+
+// wuffs_base__pixel_format__plane_bits_per_pixel returns the number of bits
+// per pixel in the p'th plane. For packed pixel formats, this is the same as
+// wuffs_base__pixel_format__bits_per_pixel for p == 0. For planar pixel
+// formats, each plane holds one channel, so this is that channel's depth. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__pixel_format__plane_bits_per_pixel(
+    wuffs_base__pixel_format f,
+    uint32_t p) {
+  static const uint32_t depths[16] = {
+      0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64,
+  };
+  uint32_t n = wuffs_base__pixel_format__num_planes(f);
+  if (p >= n) {
+    return 0;
+  } else if (n == 1) {
+    return wuffs_base__pixel_format__bits_per_pixel(f);
+  }
+  return depths[(f >> (4 * p)) & 0x0F];
+}
+
 typedef struct {
   wuffs_base__table_u8 planes[WUFFS_BASE__PIXEL_FORMAT__NUM_PLANES_MAX];
 } wuffs_base__pixel_buffer;
@@ -930,6 +1051,51 @@ typedef struct {
   } private_impl;
 } wuffs_base__image_config;
 
+// wuffs_base__image_config__plane_row_length returns the number of bytes per
+// row in the p'th plane. For planar pixel formats, the pixel subsampling
+// applies. It returns zero if there is no p'th plane.
+static inline uint64_t wuffs_base__image_config__plane_row_length(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c) {
+    return 0;
+  }
+  uint64_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(
+      c->private_impl.pixfmt, p);
+  uint64_t width = c->private_impl.width;
+  if ((width > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    width = ((width - 1 + wuffs_base__pixel_subsampling__bias_x(
+                              c->private_impl.pixsub, p)) >>
+             wuffs_base__pixel_subsampling__shift_x(c->private_impl.pixsub,
+                                                    p)) +
+            1;
+  }
+  return ((width * bpp) + 7) / 8;
+}
+
+// wuffs_base__image_config__plane_height returns the number of rows in the
+// p'th plane. For planar pixel formats, the pixel subsampling applies. It
+// returns zero if there is no p'th plane.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (!c || (p >= wuffs_base__pixel_format__num_planes(
+                      c->private_impl.pixfmt))) {
+    return 0;
+  }
+  uint64_t height = c->private_impl.height;
+  if ((height > 0) &&
+      (wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt) > 1)) {
+    height = ((height - 1 + wuffs_base__pixel_subsampling__bias_y(
+                                c->private_impl.pixsub, p)) >>
+              wuffs_base__pixel_subsampling__shift_y(c->private_impl.pixsub,
+                                                     p)) +
+             1;
+  }
+  return (uint32_t)height;
+}
+
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
@@ -941,19 +1107,34 @@ static inline void wuffs_base__image_config__initialize(
   if (!c) {
     return;
   }
-  if (pixfmt) {
-    uint64_t wh = ((uint64_t)width) * ((uint64_t)height);
-    // TODO: handle things other than 1 byte per pixel.
-    if (wh <= ((uint64_t)SIZE_MAX)) {
-      c->private_impl.pixfmt = pixfmt;
-      c->private_impl.pixsub = pixsub;
-      c->private_impl.width = width;
-      c->private_impl.height = height;
-      c->private_impl.num_loops = num_loops;
-      return;
+  c->private_impl.pixfmt = pixfmt;
+  c->private_impl.pixsub = pixsub;
+  c->private_impl.width = width;
+  c->private_impl.height = height;
+  c->private_impl.num_loops = num_loops;
+
+  // Check that every plane has a non-zero bit depth, and that the total size
+  // of the planes does not overflow a size_t. The maximum row length in bytes
+  // is (((1<<32) * 64) / 8), which does not overflow a uint64_t, but the
+  // product of that and the height might.
+  uint64_t total = 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    if (!wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p)) {
+      break;
+    }
+    uint64_t row_length = wuffs_base__image_config__plane_row_length(c, p);
+    uint64_t plane_height = wuffs_base__image_config__plane_height(c, p);
+    if ((plane_height > 0) &&
+        (row_length > ((((uint64_t)SIZE_MAX) - total) / plane_height))) {
+      break;
     }
+    total += row_length * plane_height;
+  }
+  if ((n == 0) || (p < n)) {
+    *c = ((wuffs_base__image_config){});
   }
-  *c = ((wuffs_base__image_config){});
 }
 
 static inline void wuffs_base__image_config__invalidate(
@@ -993,16 +1174,26 @@ static inline uint32_t wuffs_base__image_config__num_loops(
   return c ? c->private_impl.num_loops : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
+// wuffs_base__image_config__pixbuf_size returns the number of bytes needed to
+// hold the image's pixel buffer: the sum of every plane's size, laid out
+// consecutively.
+//
+// TODO: should this allow decoding into a color model different from the
+// format's intrinsic one? For example, decoding a JPEG image straight to RGBA
+// instead of to YCbCr?
 static inline size_t wuffs_base__image_config__pixbuf_size(
     wuffs_base__image_config* c) {
   if (c) {
-    uint64_t wh =
-        ((uint64_t)c->private_impl.width) * ((uint64_t)c->private_impl.height);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+    // wuffs_base__image_config__initialize checked that this doesn't
+    // overflow.
+    uint64_t total = 0;
+    uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+    uint32_t p;
+    for (p = 0; p < n; p++) {
+      total += wuffs_base__image_config__plane_row_length(c, p) *
+               ((uint64_t)wuffs_base__image_config__plane_height(c, p));
+    }
+    return (size_t)total;
   }
   return 0;
 }
@@ -1056,6 +1247,10 @@ static inline void wuffs_base__image_buffer__set_from_pixbuf(
   b->private_impl.pixbuf = pixbuf;
 }
 
+// wuffs_base__image_buffer__set_from_slice sets b to use pixbuf_memory as its
+// pixel buffer. For planar pixel formats, the planes are laid out
+// consecutively, as per wuffs_base__image_config__pixbuf_size.
+//
 // TODO: Should this function return bool? An error type?
 static inline void wuffs_base__image_buffer__set_from_slice(
     wuffs_base__image_buffer* b,
@@ -1065,18 +1260,25 @@ static inline void wuffs_base__image_buffer__set_from_slice(
     return;
   }
   *b = ((wuffs_base__image_buffer){});
-  // TODO: don't assume 1 byte per pixel. Don't assume packed.
-  uint64_t wh = ((uint64_t)config.private_impl.width) *
-                ((uint64_t)config.private_impl.height);
-  if (wh > pixbuf_memory.len) {
+  if (!wuffs_base__image_config__is_valid(&config) ||
+      (wuffs_base__image_config__pixbuf_size(&config) > pixbuf_memory.len)) {
     return;
   }
   b->private_impl.config = config;
-  wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[0];
-  tab->ptr = pixbuf_memory.ptr;
-  tab->width = config.private_impl.width;
-  tab->height = config.private_impl.height;
-  tab->stride = config.private_impl.width;
+  uint8_t* ptr = pixbuf_memory.ptr;
+  uint32_t n = wuffs_base__pixel_format__num_planes(config.private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    size_t row_length =
+        (size_t)wuffs_base__image_config__plane_row_length(&config, p);
+    uint32_t plane_height = wuffs_base__image_config__plane_height(&config, p);
+    wuffs_base__table_u8* tab = &b->private_impl.pixbuf.planes[p];
+    tab->ptr = ptr;
+    tab->width = row_length;
+    tab->height = plane_height;
+    tab->stride = row_length;
+    ptr += row_length * plane_height;
+  }
 }
 
 // The palette argument is ignored unless its length is exactly 1024.
@@ -1188,18 +1390,30 @@ static inline wuffs_base__table_u8 wuffs_base__image_buffer__plane(
              : ((wuffs_base__table_u8){});
 }
 
+// ---------------- Saved State
+
+// WUFFS_BASE__SAVED_STATE__HEADER_LENGTH is the length of the header at the
+// start of every saved state, as written by a wuffs_foo__bar__save_state
+// function. The header holds a format version, a fingerprint of the generated
+// code, the total length (including the header) and a checksum of the rest.
+//
+// Each wuffs_foo__bar struct's WUFFS_FOO__BAR__STATE_LENGTH macro is the total
+// length of its saved state.
+#define WUFFS_BASE__SAVED_STATE__HEADER_LENGTH 16
+
 #endif  // WUFFS_BASE_HEADER_H
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
-  // ---------------- BEGIN USE "std/lzw"
+// ---------------- BEGIN USE "std/lzw"
 
 #ifndef WUFFS_LZW_H
 #define WUFFS_LZW_H
 
-  // Code generated by wuffs-c. DO NOT EDIT.
+// Code generated by wuffs-c. DO NOT EDIT.
+
 
-  // ---------------- Use Declarations
+// ---------------- Use Declarations
 
 #ifdef __cplusplus
 extern "C" {
@@ -1219,26 +1433,25 @@ typedef int32_t wuffs_lzw__status;
 
 #define wuffs_lzw__packageid 1316776  // 0x001417A8
 
-#define WUFFS_LZW__STATUS_OK 0                            // 0x00000000
-#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_LZW__STATUS_OK 0  // 0x00000000
+#define WUFFS_LZW__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                    // 0x80000006
-#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_LZW__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_LZW__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_LZW__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_LZW__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_LZW__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_LZW__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_LZW__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_LZW__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
+#define WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_LZW__SUSPENSION_END_OF_DATA 14  // 0x0000000E
 
-#define WUFFS_LZW__ERROR_BAD_CODE -799105024               // 0xD05EA000
+#define WUFFS_LZW__ERROR_BAD_CODE -799105024  // 0xD05EA000
 #define WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN -799105023  // 0xD05EA001
+#define WUFFS_LZW__ERROR_BAD_LITERAL -799105022  // 0xD05EA002
 
 bool wuffs_lzw__status__is_error(wuffs_lzw__status s);
 
@@ -1261,6 +1474,7 @@ typedef struct {
     uint32_t magic;
 
     uint32_t f_literal_width;
+    bool f_tiff_mode;
     uint8_t f_stack[4096];
     uint8_t f_suffixes[4096];
     uint16_t f_prefixes[4096];
@@ -1268,6 +1482,7 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       uint32_t v_literal_width;
+      bool v_tiff_mode;
       uint32_t v_clear_code;
       uint32_t v_end_code;
       uint32_t v_save_code;
@@ -1276,6 +1491,7 @@ typedef struct {
       uint32_t v_bits;
       uint32_t v_n_bits;
       uint32_t v_code;
+      uint32_t v_msb_bits;
       uint32_t v_s;
       uint32_t v_c;
       uint64_t v_n_copied;
@@ -1283,6 +1499,61 @@ typedef struct {
   } private_impl;
 } wuffs_lzw__decoder;
 
+// WUFFS_LZW__DECODER__STATE_LENGTH is the length of a wuffs_lzw__decoder's
+// saved state.
+#define WUFFS_LZW__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 16454)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_lzw__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_lzw__status status;
+    uint32_t magic;
+
+    uint32_t f_literal_width;
+    bool f_eof;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_buf[4096];
+    uint32_t f_buf_ri;
+    uint32_t f_buf_wi;
+    uint32_t f_htable[16384];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_literal_width;
+      uint32_t v_clear_code;
+      uint32_t v_end_code;
+      uint32_t v_hi;
+      uint32_t v_width;
+      uint32_t v_code;
+      bool v_has_code;
+      uint32_t v_literal;
+      uint32_t v_key;
+      uint32_t v_h;
+      uint32_t v_entry;
+      wuffs_lzw__status v_z;
+    } c_encode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_buf[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n_bits;
+    } c_write_code[1];
+  } private_impl;
+} wuffs_lzw__encoder;
+
+// WUFFS_LZW__ENCODER__STATE_LENGTH is the length of a wuffs_lzw__encoder's
+// saved state.
+#define WUFFS_LZW__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 69719)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_lzw__decoder__check_wuffs_version is an initializer function.
@@ -1291,22 +1562,229 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_lzw__decoder__check_wuffs_version(wuffs_lzw__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__decoder returns sizeof(wuffs_lzw__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__decoder(void);
+
+// wuffs_lzw__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_lzw__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_lzw__encoder__check_wuffs_version(wuffs_lzw__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_lzw__encoder returns sizeof(wuffs_lzw__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_lzw__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_lzw__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__decoder__save_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__decoder__restore_state(wuffs_lzw__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_lzw__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_LZW__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_lzw__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_lzw__status wuffs_lzw__encoder__save_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_lzw__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_LZW__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_lzw__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_lzw__status wuffs_lzw__encoder__restore_state(wuffs_lzw__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
 void wuffs_lzw__decoder__set_literal_width(wuffs_lzw__decoder* self,
-                                           uint32_t a_lw);
+    uint32_t a_lw);
+
+void wuffs_lzw__decoder__set_tiff_mode(wuffs_lzw__decoder* self, bool a_m);
 
 wuffs_lzw__status wuffs_lzw__decoder__decode(wuffs_lzw__decoder* self,
-                                             wuffs_base__io_writer a_dst,
-                                             wuffs_base__io_reader a_src);
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
+
+void wuffs_lzw__encoder__set_literal_width(wuffs_lzw__encoder* self,
+    uint32_t a_lw);
+
+wuffs_lzw__status wuffs_lzw__encoder__encode(wuffs_lzw__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__io_reader a_src);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace lzw {
+
+// status is a strongly typed wuffs_lzw__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_LZW__STATUS_OK) {}
+  constexpr explicit status(wuffs_lzw__status repr) : repr_(repr) {}
+
+  constexpr wuffs_lzw__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_LZW__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_lzw__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_lzw__status repr_;
+};
+
+constexpr status status_ok(WUFFS_LZW__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_LZW__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_LZW__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_LZW__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_LZW__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_LZW__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_LZW__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_LZW__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_LZW__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_LZW__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_LZW__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_LZW__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_LZW__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_LZW__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_code(WUFFS_LZW__ERROR_BAD_CODE);
+constexpr status error_cyclical_prefix_chain(
+    WUFFS_LZW__ERROR_CYCLICAL_PREFIX_CHAIN);
+constexpr status error_bad_literal(WUFFS_LZW__ERROR_BAD_LITERAL);
+
+// decoder is an RAII wrapper for a wuffs_lzw__decoder. Its constructor
+// calls wuffs_lzw__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_lzw__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__decoder__save_state and
+  // wuffs_lzw__decoder__restore_state. A saved state is
+  // WUFFS_LZW__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__decoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__decoder__set_literal_width(&c_, lw);
+  }
+
+  void set_tiff_mode(bool m) {
+    wuffs_lzw__decoder__set_tiff_mode(&c_, m);
+  }
+
+  status decode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__decoder__decode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_lzw__encoder. Its constructor
+// calls wuffs_lzw__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_lzw__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_lzw__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_lzw__encoder__save_state and
+  // wuffs_lzw__encoder__restore_state. A saved state is
+  // WUFFS_LZW__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_lzw__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_lzw__encoder__restore_state(&c_, src));
+  }
+
+  void set_literal_width(uint32_t lw) {
+    wuffs_lzw__encoder__set_literal_width(&c_, lw);
+  }
+
+  status encode(wuffs_base__io_writer dst, wuffs_base__io_reader src) {
+    return status(wuffs_lzw__encoder__encode(&c_, dst, src));
+  }
+
+ private:
+  wuffs_lzw__encoder c_;
+};
+
+}  // namespace lzw
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_LZW_H
 
 // ---------------- END   USE "std/lzw"
@@ -1329,34 +1807,32 @@ typedef int32_t wuffs_gif__status;
 
 #define wuffs_gif__packageid 1017222  // 0x000F8586
 
-#define WUFFS_GIF__STATUS_OK 0                            // 0x00000000
-#define WUFFS_GIF__ERROR_BAD_WUFFS_VERSION -2147483647    // 0x80000001
+#define WUFFS_GIF__STATUS_OK 0  // 0x00000000
+#define WUFFS_GIF__ERROR_BAD_WUFFS_VERSION -2147483647  // 0x80000001
 #define WUFFS_GIF__ERROR_BAD_SIZEOF_RECEIVER -2147483646  // 0x80000002
-#define WUFFS_GIF__ERROR_BAD_RECEIVER -2147483645         // 0x80000003
-#define WUFFS_GIF__ERROR_BAD_ARGUMENT -2147483644         // 0x80000004
-#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED \
-  -2147483643  // 0x80000005
-#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE \
-  -2147483642                                                    // 0x80000006
-#define WUFFS_GIF__ERROR_INVALID_I_O_OPERATION -2147483641       // 0x80000007
-#define WUFFS_GIF__ERROR_CLOSED_FOR_WRITES -2147483640           // 0x80000008
-#define WUFFS_GIF__ERROR_UNEXPECTED_EOF -2147483639              // 0x80000009
-#define WUFFS_GIF__SUSPENSION_SHORT_READ 10                      // 0x0000000A
-#define WUFFS_GIF__SUSPENSION_SHORT_WRITE 11                     // 0x0000000B
+#define WUFFS_GIF__ERROR_BAD_RECEIVER -2147483645  // 0x80000003
+#define WUFFS_GIF__ERROR_BAD_ARGUMENT -2147483644  // 0x80000004
+#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED -2147483643  // 0x80000005
+#define WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE -2147483642  // 0x80000006
+#define WUFFS_GIF__ERROR_INVALID_I_O_OPERATION -2147483641  // 0x80000007
+#define WUFFS_GIF__ERROR_CLOSED_FOR_WRITES -2147483640  // 0x80000008
+#define WUFFS_GIF__ERROR_UNEXPECTED_EOF -2147483639  // 0x80000009
+#define WUFFS_GIF__SUSPENSION_SHORT_READ 10  // 0x0000000A
+#define WUFFS_GIF__SUSPENSION_SHORT_WRITE 11  // 0x0000000B
 #define WUFFS_GIF__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483636  // 0x8000000C
-#define WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE -2147483635       // 0x8000000D
-#define WUFFS_GIF__SUSPENSION_END_OF_DATA 14                     // 0x0000000E
-
-#define WUFFS_GIF__ERROR_BAD_BLOCK -1105848320              // 0xBE161800
-#define WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL -1105848319    // 0xBE161801
-#define WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL -1105848318    // 0xBE161802
-#define WUFFS_GIF__ERROR_BAD_HEADER -1105848317             // 0xBE161803
-#define WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH -1105848316      // 0xBE161804
+#define WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE -2147483635  // 0x8000000D
+#define WUFFS_GIF__SUSPENSION_END_OF_DATA 14  // 0x0000000E
+
+#define WUFFS_GIF__ERROR_BAD_BLOCK -1105848320  // 0xBE161800
+#define WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL -1105848319  // 0xBE161801
+#define WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL -1105848318  // 0xBE161802
+#define WUFFS_GIF__ERROR_BAD_HEADER -1105848317  // 0xBE161803
+#define WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH -1105848316  // 0xBE161804
 #define WUFFS_GIF__ERROR_NOT_ENOUGH_PIXEL_DATA -1105848315  // 0xBE161805
-#define WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA -1105848314    // 0xBE161806
-#define WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI \
-  -1105848313                                                      // 0xBE161807
-#define WUFFS_GIF__ERROR_TODO_UNSUPPORTED_INTERLACING -1105848312  // 0xBE161808
+#define WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA -1105848314  // 0xBE161806
+#define WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI -1105848313  // 0xBE161807
+#define WUFFS_GIF__ERROR_UNSUPPORTED_IMAGE_DIMENSIONS -1105848312  // 0xBE161808
+#define WUFFS_GIF__ERROR_UNSUPPORTED_PIXEL_FORMAT -1105848311  // 0xBE161809
 
 bool wuffs_gif__status__is_error(wuffs_gif__status s);
 
@@ -1385,7 +1861,7 @@ typedef struct {
     bool f_previous_lzw_decode_ended_abruptly;
     bool f_previous_use_global_palette;
     uint8_t f_background_color_index;
-    bool f_interlace;
+    uint8_t f_interlace;
     bool f_seen_num_loops;
     uint32_t f_num_loops;
     bool f_seen_graphic_control;
@@ -1398,6 +1874,7 @@ typedef struct {
     uint32_t f_dst_y;
     uint32_t f_dst_x0;
     uint32_t f_dst_x1;
+    uint32_t f_dst_y0;
     uint32_t f_dst_y1;
     uint32_t f_uncompressed_ri;
     uint32_t f_uncompressed_wi;
@@ -1473,6 +1950,102 @@ typedef struct {
   } private_impl;
 } wuffs_gif__decoder;
 
+// WUFFS_GIF__DECODER__STATE_LENGTH is the length of a wuffs_gif__decoder's
+// saved state.
+#define WUFFS_GIF__DECODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 6396 + WUFFS_LZW__DECODER__STATE_LENGTH)
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_gif__encoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_gif__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint32_t f_num_loops;
+    uint8_t f_call_sequence;
+    uint32_t f_frame_x0;
+    uint32_t f_frame_y0;
+    uint32_t f_frame_x1;
+    uint32_t f_frame_y1;
+    uint32_t f_src_x;
+    uint32_t f_src_y;
+    uint32_t f_palette_bits;
+    uint32_t f_global_palette_bits;
+    uint8_t f_palettes[2][1024];
+    uint8_t f_block[255];
+    uint32_t f_block_ri;
+    uint32_t f_block_wi;
+    wuffs_lzw__encoder f_lzw;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_width;
+      uint32_t v_height;
+      uint64_t scratch;
+    } c_encode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_encode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_encode_trailer[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_encode_lsd[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+    } c_encode_palette[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_num_loops;
+      uint32_t v_i;
+      uint64_t scratch;
+    } c_encode_ae[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_flags;
+      uint8_t v_disposal;
+      uint8_t v_transparent_index;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+      uint64_t v_centiseconds;
+      uint64_t v_duration;
+      uint64_t scratch;
+    } c_encode_gc[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_use_global_palette;
+      uint32_t v_num_palette_entries;
+      uint32_t v_i;
+      uint64_t scratch;
+    } c_encode_id[1];
+    struct {
+      uint32_t coro_susp_point;
+      bool v_last_row;
+      wuffs_gif__status v_z;
+    } c_encode_pixels[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint32_t v_new_ri;
+    } c_write_block[1];
+  } private_impl;
+} wuffs_gif__encoder;
+
+// WUFFS_GIF__ENCODER__STATE_LENGTH is the length of a wuffs_gif__encoder's
+// saved state.
+#define WUFFS_GIF__ENCODER__STATE_LENGTH (WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 2517 + WUFFS_LZW__ENCODER__STATE_LENGTH)
+
 // ---------------- Public Initializer Prototypes
 
 // wuffs_gif__decoder__check_wuffs_version is an initializer function.
@@ -1481,27 +2054,252 @@ typedef struct {
 //
 // Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
 void wuffs_gif__decoder__check_wuffs_version(wuffs_gif__decoder* self,
-                                             size_t sizeof_star_self,
-                                             uint32_t wuffs_version);
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gif__decoder returns sizeof(wuffs_gif__decoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gif__decoder(void);
+
+// wuffs_gif__encoder__check_wuffs_version is an initializer function.
+//
+// It should be called before any other wuffs_gif__encoder__* function.
+//
+// Pass sizeof(*self) and WUFFS_VERSION for sizeof_star_self and wuffs_version.
+void wuffs_gif__encoder__check_wuffs_version(wuffs_gif__encoder* self,
+    size_t sizeof_star_self, uint32_t wuffs_version);
+
+// sizeof__wuffs_gif__encoder returns sizeof(wuffs_gif__encoder), for callers
+// that cannot see the struct definition, such as other programming languages'
+// foreign function interfaces.
+size_t sizeof__wuffs_gif__encoder(void);
+
+// ---------------- Public Saved State Prototypes
+
+// wuffs_gif__decoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GIF__DECODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gif__decoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gif__status wuffs_gif__decoder__save_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gif__decoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GIF__DECODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gif__decoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gif__status wuffs_gif__decoder__restore_state(wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_src);
+
+// wuffs_gif__encoder__save_state writes self's state, including that of any
+// suspended coroutine, to the first WUFFS_GIF__ENCODER__STATE_LENGTH bytes of
+// dst.
+//
+// The saved state can be restored, by wuffs_gif__encoder__restore_state, into a
+// different struct, possibly in a different process, as long as it runs code
+// generated from the same Wuffs source by the same Wuffs compiler.
+wuffs_gif__status wuffs_gif__encoder__save_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_dst);
+
+// wuffs_gif__encoder__restore_state sets self's state to that saved in src,
+// which must be exactly WUFFS_GIF__ENCODER__STATE_LENGTH bytes long. The self
+// argument must have been initialized by
+// wuffs_gif__encoder__check_wuffs_version.
+//
+// Restoring checks the saved state's header, including a checksum, before
+// modifying self. It then checks each value against its type, such as bools
+// being 0 or 1. If those later checks fail, self is left with a sticky error
+// status. The checks reject mismatched or corrupted saved states, but they
+// cannot re-prove every fact that the Wuffs compiler proved, so saved states
+// should only be restored from trusted sources.
+wuffs_gif__status wuffs_gif__encoder__restore_state(wuffs_gif__encoder* self,
+    wuffs_base__slice_u8 a_src);
 
 // ---------------- Public Function Prototypes
 
-wuffs_gif__status wuffs_gif__decoder__decode_config(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_config* a_dst,
-    wuffs_base__io_reader a_src);
+wuffs_gif__status wuffs_gif__decoder__decode_config(wuffs_gif__decoder* self,
+    wuffs_base__image_config* a_dst, wuffs_base__io_reader a_src);
 
-wuffs_gif__status wuffs_gif__decoder__decode_frame(
-    wuffs_gif__decoder* self,
-    wuffs_base__image_buffer* a_dst,
-    wuffs_base__io_reader a_src);
+wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
+    wuffs_base__image_buffer* a_dst, wuffs_base__io_reader a_src);
 
 wuffs_gif__status wuffs_gif__decoder__decode_up_to_id_part1(
-    wuffs_gif__decoder* self,
-    wuffs_base__io_reader a_src);
+    wuffs_gif__decoder* self, wuffs_base__io_reader a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_config(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_config* a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_frame(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst, wuffs_base__image_buffer* a_src);
+
+wuffs_gif__status wuffs_gif__encoder__encode_trailer(wuffs_gif__encoder* self,
+    wuffs_base__io_writer a_dst);
 
 #ifdef __cplusplus
 }  // extern "C"
 #endif
 
+// ---------------- C++ Wrappers
+
+#if defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
+namespace wuffs {
+namespace gif {
+
+// status is a strongly typed wuffs_gif__status.
+class status {
+ public:
+  constexpr status() : repr_(WUFFS_GIF__STATUS_OK) {}
+  constexpr explicit status(wuffs_gif__status repr) : repr_(repr) {}
+
+  constexpr wuffs_gif__status repr() const { return repr_; }
+  constexpr bool is_ok() const { return repr_ == WUFFS_GIF__STATUS_OK; }
+  constexpr bool is_error() const { return repr_ < 0; }
+  constexpr bool is_suspension() const { return repr_ > 0; }
+  const char* message() const { return wuffs_gif__status__string(repr_); }
+
+  constexpr bool operator==(status s) const { return repr_ == s.repr_; }
+  constexpr bool operator!=(status s) const { return repr_ != s.repr_; }
+
+ private:
+  wuffs_gif__status repr_;
+};
+
+constexpr status status_ok(WUFFS_GIF__STATUS_OK);
+constexpr status error_bad_wuffs_version(WUFFS_GIF__ERROR_BAD_WUFFS_VERSION);
+constexpr status error_bad_sizeof_receiver(
+    WUFFS_GIF__ERROR_BAD_SIZEOF_RECEIVER);
+constexpr status error_bad_receiver(WUFFS_GIF__ERROR_BAD_RECEIVER);
+constexpr status error_bad_argument(WUFFS_GIF__ERROR_BAD_ARGUMENT);
+constexpr status error_check_wuffs_version_not_called(
+    WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_NOT_CALLED);
+constexpr status error_check_wuffs_version_called_twice(
+    WUFFS_GIF__ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE);
+constexpr status error_invalid_i_o_operation(
+    WUFFS_GIF__ERROR_INVALID_I_O_OPERATION);
+constexpr status error_closed_for_writes(WUFFS_GIF__ERROR_CLOSED_FOR_WRITES);
+constexpr status error_unexpected_eof(WUFFS_GIF__ERROR_UNEXPECTED_EOF);
+constexpr status suspension_short_read(WUFFS_GIF__SUSPENSION_SHORT_READ);
+constexpr status suspension_short_write(WUFFS_GIF__SUSPENSION_SHORT_WRITE);
+constexpr status error_cannot_return_a_suspension(
+    WUFFS_GIF__ERROR_CANNOT_RETURN_A_SUSPENSION);
+constexpr status error_invalid_call_sequence(
+    WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE);
+constexpr status suspension_end_of_data(WUFFS_GIF__SUSPENSION_END_OF_DATA);
+constexpr status error_bad_block(WUFFS_GIF__ERROR_BAD_BLOCK);
+constexpr status error_bad_extension_label(
+    WUFFS_GIF__ERROR_BAD_EXTENSION_LABEL);
+constexpr status error_bad_graphic_control(
+    WUFFS_GIF__ERROR_BAD_GRAPHIC_CONTROL);
+constexpr status error_bad_header(WUFFS_GIF__ERROR_BAD_HEADER);
+constexpr status error_bad_literal_width(WUFFS_GIF__ERROR_BAD_LITERAL_WIDTH);
+constexpr status error_not_enough_pixel_data(
+    WUFFS_GIF__ERROR_NOT_ENOUGH_PIXEL_DATA);
+constexpr status error_too_much_pixel_data(
+    WUFFS_GIF__ERROR_TOO_MUCH_PIXEL_DATA);
+constexpr status error_internal_error_inconsistent_ri_wi(
+    WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI);
+constexpr status error_unsupported_image_dimensions(
+    WUFFS_GIF__ERROR_UNSUPPORTED_IMAGE_DIMENSIONS);
+constexpr status error_unsupported_pixel_format(
+    WUFFS_GIF__ERROR_UNSUPPORTED_PIXEL_FORMAT);
+
+// decoder is an RAII wrapper for a wuffs_gif__decoder. Its constructor
+// calls wuffs_gif__decoder__check_wuffs_version.
+class decoder {
+ public:
+  decoder() : c_() {
+    wuffs_gif__decoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  decoder(const decoder&) = delete;
+  decoder& operator=(const decoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gif__decoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gif__decoder__save_state and
+  // wuffs_gif__decoder__restore_state. A saved state is
+  // WUFFS_GIF__DECODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gif__decoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gif__decoder__restore_state(&c_, src));
+  }
+
+  status decode_config(
+      wuffs_base__image_config* dst, wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_config(&c_, dst, src));
+  }
+
+  status decode_frame(
+      wuffs_base__image_buffer* dst, wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_frame(&c_, dst, src));
+  }
+
+  status decode_up_to_id_part1(wuffs_base__io_reader src) {
+    return status(wuffs_gif__decoder__decode_up_to_id_part1(&c_, src));
+  }
+
+ private:
+  wuffs_gif__decoder c_;
+};
+
+// encoder is an RAII wrapper for a wuffs_gif__encoder. Its constructor
+// calls wuffs_gif__encoder__check_wuffs_version.
+class encoder {
+ public:
+  encoder() : c_() {
+    wuffs_gif__encoder__check_wuffs_version(&c_, sizeof c_, WUFFS_VERSION);
+  }
+
+  encoder(const encoder&) = delete;
+  encoder& operator=(const encoder&) = delete;
+
+  // c_struct returns the wrapped C struct, for passing to the C API.
+  wuffs_gif__encoder* c_struct() { return &c_; }
+
+  // save_state and restore_state wrap wuffs_gif__encoder__save_state and
+  // wuffs_gif__encoder__restore_state. A saved state is
+  // WUFFS_GIF__ENCODER__STATE_LENGTH bytes long.
+  status save_state(wuffs_base__slice_u8 dst) {
+    return status(wuffs_gif__encoder__save_state(&c_, dst));
+  }
+  status restore_state(wuffs_base__slice_u8 src) {
+    return status(wuffs_gif__encoder__restore_state(&c_, src));
+  }
+
+  status encode_config(
+      wuffs_base__io_writer dst, wuffs_base__image_config* src) {
+    return status(wuffs_gif__encoder__encode_config(&c_, dst, src));
+  }
+
+  status encode_frame(
+      wuffs_base__io_writer dst, wuffs_base__image_buffer* src) {
+    return status(wuffs_gif__encoder__encode_frame(&c_, dst, src));
+  }
+
+  status encode_trailer(wuffs_base__io_writer dst) {
+    return status(wuffs_gif__encoder__encode_trailer(&c_, dst));
+  }
+
+ private:
+  wuffs_gif__encoder c_;
+};
+
+}  // namespace gif
+}  // namespace wuffs
+
+#endif  // defined(__cplusplus) && !defined(WUFFS_CONFIG__NO_CPLUSPLUS_WRAPPERS)
+
 #endif  // WUFFS_GIF_H
@@ -0,0 +1,152 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package adler32 is the Rust translation of the Wuffs std/adler32 package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Hasher holds the state of a adler32.hasher. Its default value is ready to use.
+pub struct Hasher {
+    status: wuffs_base::Status,
+    f_state: u32,
+    f_started: bool,
+}
+
+impl Default for Hasher {
+    fn default() -> Self {
+        Hasher {
+            status: wuffs_base::Status::Ok,
+            f_state: 0,
+            f_started: false,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func hasher.update
+
+impl Hasher {
+    pub fn update(&mut self, mut a_x: wuffs_base::SliceU8) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        let mut v_s1: u32 = 0;
+        let mut v_s2: u32 = 0;
+        let mut v_remaining: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+
+        if !self.f_started {
+            self.f_started = true;
+            self.f_state = 1;
+        }
+        v_s1 = (self.f_state & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0));
+        v_s2 = u32::checked_shr(self.f_state, 32 - (16 as u32)).unwrap_or(0);
+        while (a_x.len() as u64) > 0 {
+            v_remaining = wuffs_base::SliceU8::default();
+            if (a_x.len() as u64) > 5552 {
+                v_remaining = unsafe { a_x.subslice_i(5552 as usize) };
+                a_x = unsafe { a_x.subslice_j(5552 as usize) };
+            }
+            {
+                let i_slice_p = a_x;
+                let mut v_p = wuffs_base::SliceU8::default();
+                let mut i_p: usize = 0;
+                let i_end0_p = (i_slice_p.len() / 8) * 8;
+                while i_p < i_end0_p {
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                }
+                let i_end1_p = (i_slice_p.len() / 1) * 1;
+                while i_p < i_end1_p {
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_s1 = u32::wrapping_add(
+                        v_s1,
+                        ((*unsafe { v_p.get_unchecked(0 as usize) }) as u32),
+                    );
+                    v_s2 = u32::wrapping_add(v_s2, v_s1);
+                    i_p += 1;
+                }
+            }
+            v_s1 %= 65521;
+            v_s2 %= 65521;
+            a_x = v_remaining;
+        }
+        self.f_state = (((v_s2 & 65535) << 16) | (v_s1 & 65535));
+        return self.f_state;
+    }
+}
@@ -0,0 +1,1517 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package bmp is the Rust translation of the Wuffs std/bmp package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("bmp: bad header");
+pub const ERROR_BAD_PALETTE: wuffs_base::Status = wuffs_base::Status::Error("bmp: bad palette");
+pub const ERROR_UNSUPPORTED_BIT_DEPTH: wuffs_base::Status =
+    wuffs_base::Status::Error("bmp: unsupported bit depth");
+pub const ERROR_UNSUPPORTED_COMPRESSION: wuffs_base::Status =
+    wuffs_base::Status::Error("bmp: unsupported compression");
+pub const ERROR_UNSUPPORTED_HEADER_SIZE: wuffs_base::Status =
+    wuffs_base::Status::Error("bmp: unsupported header size");
+pub const ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH: wuffs_base::Status =
+    wuffs_base::Status::Error("bmp: TODO: unsupported image width");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a bmp.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_ico_mode: bool,
+    f_top_down: bool,
+    f_bits_per_pixel: u32,
+    f_compression: u32,
+    f_pixel_data_offset: u32,
+    f_channel_masks: [u32; 4],
+    f_channel_shifts: [u32; 4],
+    f_channel_num_bits: [u32; 4],
+    f_row_length: u32,
+    f_row_wi: u32,
+    f_dst_y: u32,
+    f_row: [u8; 32772],
+    f_palette: [u8; 1024],
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_read_row: DecoderReadRowCoro,
+    c_decode_rle: DecoderDecodeRleCoro,
+    c_decode_and_mask: DecoderDecodeAndMaskCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_ico_mode: false,
+            f_top_down: false,
+            f_bits_per_pixel: 0,
+            f_compression: 0,
+            f_pixel_data_offset: 0,
+            f_channel_masks: [0; 4],
+            f_channel_shifts: [0; 4],
+            f_channel_num_bits: [0; 4],
+            f_row_length: 0,
+            f_row_wi: 0,
+            f_dst_y: 0,
+            f_row: [0; 32772],
+            f_palette: [0; 1024],
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_read_row: DecoderReadRowCoro::default(),
+            c_decode_rle: DecoderDecodeRleCoro::default(),
+            c_decode_and_mask: DecoderDecodeAndMaskCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_num_read: u64,
+    v_header_size: u32,
+    v_width: u32,
+    v_height: u32,
+    v_bits_per_pixel: u32,
+    v_compression: u32,
+    v_num_colors: u32,
+    v_num_masks: u32,
+    v_i: u32,
+    v_num_entries: u32,
+    v_row_length: u64,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_num_read: 0,
+            v_header_size: 0,
+            v_width: 0,
+            v_height: 0,
+            v_bits_per_pixel: 0,
+            v_compression: 0,
+            v_num_colors: 0,
+            v_num_masks: 0,
+            v_i: 0,
+            v_num_entries: 0,
+            v_row_length: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+    v_dirty_rect: wuffs_base::RectIeU32,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro {
+            coro_susp_point: 0,
+            v_dirty_rect: wuffs_base::RectIeU32::default(),
+        }
+    }
+}
+
+struct DecoderReadRowCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderReadRowCoro {
+    fn default() -> Self {
+        DecoderReadRowCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderDecodeRleCoro {
+    coro_susp_point: u32,
+    v_rle4: bool,
+    v_x: u32,
+    v_a: u32,
+    v_b: u8,
+    v_c: u8,
+    v_n: u32,
+    v_num_bytes: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeRleCoro {
+    fn default() -> Self {
+        DecoderDecodeRleCoro {
+            coro_susp_point: 0,
+            v_rle4: false,
+            v_x: 0,
+            v_a: 0,
+            v_b: 0,
+            v_c: 0,
+            v_n: 0,
+            v_num_bytes: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeAndMaskCoro {
+    coro_susp_point: u32,
+    v_row_length: u64,
+}
+
+impl Default for DecoderDecodeAndMaskCoro {
+    fn default() -> Self {
+        DecoderDecodeAndMaskCoro {
+            coro_susp_point: 0,
+            v_row_length: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+const COMPRESSION_RGB: u32 = 0x0;
+
+const COMPRESSION_RLE8: u32 = 0x1;
+
+const COMPRESSION_RLE4: u32 = 0x2;
+
+const COMPRESSION_BITFIELDS: u32 = 0x3;
+
+const COMPRESSION_ALPHA_BITFIELDS: u32 = 0x6;
+
+static RGBA_TO_BGRA: [u8; 4] = [0x2, 0x1, 0x0, 0x3];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ico_mode
+
+impl Decoder {
+    pub fn set_ico_mode(&mut self, mut a_m: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_ico_mode = a_m;
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_num_read: u64 = 0;
+        let mut v_header_size: u32 = 0;
+        let mut v_width: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut v_bits_per_pixel: u32 = 0;
+        let mut v_compression: u32 = 0;
+        let mut v_num_colors: u32 = 0;
+        let mut v_num_masks: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_num_entries: u32 = 0;
+        let mut v_row_length: u64 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u32 = 0;
+        let mut t_2: u32 = 0;
+        let mut t_3: u32 = 0;
+        let mut t_4: u32 = 0;
+        let mut t_5: u16 = 0;
+        let mut t_6: u16 = 0;
+        let mut t_7: u32 = 0;
+        let mut t_8: u32 = 0;
+        let mut t_9: u32 = 0;
+        let mut t_10: u8 = 0;
+        let mut t_11: u8 = 0;
+        let mut t_12: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_read = self.c_decode_config.v_num_read;
+            v_header_size = self.c_decode_config.v_header_size;
+            v_width = self.c_decode_config.v_width;
+            v_height = self.c_decode_config.v_height;
+            v_bits_per_pixel = self.c_decode_config.v_bits_per_pixel;
+            v_compression = self.c_decode_config.v_compression;
+            v_num_colors = self.c_decode_config.v_num_colors;
+            v_num_masks = self.c_decode_config.v_num_masks;
+            v_i = self.c_decode_config.v_i;
+            v_num_entries = self.c_decode_config.v_num_entries;
+            v_row_length = self.c_decode_config.v_row_length;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                    v_num_read = 0;
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    if (1 <= r && r <= 3) || (r == 0 && !self.f_ico_mode) {
+                        if r == 0 || r == 1 {
+                            if r == 0 || r == 1 {
+                                if r == 0 {
+                                    self.c_decode_config.scratch = 0;
+                                }
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status =
+                                    a_src.read_u16le(&mut t_0, &mut self.c_decode_config.scratch);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 && (t_0 != 19778) {
+                                status = ERROR_BAD_HEADER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = (8 as u64);
+                            }
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = a_src.read_u32le(&mut t_1, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_pixel_data_offset = t_1;
+                        }
+                        if r == 0 {
+                            v_num_read = 14;
+                        }
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u32le(&mut t_2, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_header_size = t_2;
+                }
+                if r == 0 {
+                    if (v_header_size != 40)
+                        && (v_header_size != 52)
+                        && (v_header_size != 56)
+                        && (v_header_size != 108)
+                        && (v_header_size != 124)
+                    {
+                        status = ERROR_UNSUPPORTED_HEADER_SIZE;
+                        break 'exit;
+                    }
+                    v_num_read = u64::saturating_add(v_num_read, (v_header_size as u64));
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u32le(&mut t_3, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_width = t_3;
+                }
+                if r == 0 {
+                    if v_width > 2147483647 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_width = v_width;
+                }
+                if r == 0 || r == 6 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_src.read_u32le(&mut t_4, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_height = t_4;
+                }
+                if r == 0 {
+                    if v_height == 2147483648 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    } else if v_height > 2147483648 {
+                        self.f_top_down = true;
+                        v_height = ((4294967295 - v_height) + 1);
+                    }
+                    if self.f_ico_mode {
+                        v_height /= 2;
+                    }
+                    if v_height > 2147483647 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_height = v_height;
+                }
+                if r == 0 || r == 7 {
+                    if r == 0 || r == 7 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 7 {
+                            r = 0;
+                        }
+                        csp = 7;
+                        status = a_src.read_u16le(&mut t_5, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_5 != 1) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 8 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 8 {
+                        r = 0;
+                    }
+                    csp = 8;
+                    status = a_src.read_u16le(&mut t_6, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_bits_per_pixel = (t_6 as u32);
+                }
+                if r == 0 || r == 9 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 9 {
+                        r = 0;
+                    }
+                    csp = 9;
+                    status = a_src.read_u32le(&mut t_7, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_compression = t_7;
+                }
+                if r == 0 {
+                    self.f_compression = v_compression;
+                }
+                if r == 0 || r == 10 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = (12 as u64);
+                    }
+                    if r == 10 {
+                        r = 0;
+                    }
+                    csp = 10;
+                    status = a_src.skip(&mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 11 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 11 {
+                        r = 0;
+                    }
+                    csp = 11;
+                    status = a_src.read_u32le(&mut t_8, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_num_colors = t_8;
+                }
+                if r == 0 || r == 12 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = (4 as u64);
+                    }
+                    if r == 12 {
+                        r = 0;
+                    }
+                    csp = 12;
+                    status = a_src.skip(&mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_num_masks = 0;
+                    if v_header_size >= 56 {
+                        v_num_masks = 4;
+                    } else if v_header_size >= 52 {
+                        v_num_masks = 3;
+                    } else if v_compression == COMPRESSION_BITFIELDS {
+                        v_num_masks = 3;
+                        v_num_read = u64::saturating_add(v_num_read, 12);
+                    } else if v_compression == COMPRESSION_ALPHA_BITFIELDS {
+                        v_num_masks = 4;
+                        v_num_read = u64::saturating_add(v_num_read, 16);
+                    }
+                    v_i = 0;
+                }
+                if r == 0 || r == 13 {
+                    while r != 0 || (v_i < v_num_masks) {
+                        if r == 0 || r == 13 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 13 {
+                                r = 0;
+                            }
+                            csp = 13;
+                            status = a_src.read_u32le(&mut t_9, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            (*unsafe {
+                                self.f_channel_masks.get_unchecked_mut(
+                                    (*unsafe { RGBA_TO_BGRA.get_unchecked(v_i as usize) }) as usize,
+                                )
+                            }) = t_9;
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 || r == 14 {
+                    if r == 14 || (r == 0 && (v_header_size > 40)) {
+                        if r == 0 || r == 14 {
+                            if r == 0 {
+                                self.c_decode_config.scratch =
+                                    (u32::saturating_sub((v_header_size - 40), (4 * v_num_masks))
+                                        as u64);
+                            }
+                            if r == 14 {
+                                r = 0;
+                            }
+                            csp = 14;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else if r == 0 && (v_num_masks < 4) {
+                        (*unsafe { self.f_channel_masks.get_unchecked_mut(3 as usize) }) = 0;
+                    }
+                }
+                if r == 0 {
+                    if v_compression == COMPRESSION_RGB {
+                        if v_bits_per_pixel == 16 {
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(0 as usize) }) = 31;
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(1 as usize) }) = 992;
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(2 as usize) }) =
+                                31744;
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(3 as usize) }) = 0;
+                        } else if v_bits_per_pixel == 32 {
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(0 as usize) }) = 255;
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(1 as usize) }) =
+                                65280;
+                            (*unsafe { self.f_channel_masks.get_unchecked_mut(2 as usize) }) =
+                                16711680;
+                            if self.f_ico_mode {
+                                (*unsafe { self.f_channel_masks.get_unchecked_mut(3 as usize) }) =
+                                    4278190080;
+                            } else {
+                                (*unsafe { self.f_channel_masks.get_unchecked_mut(3 as usize) }) =
+                                    0;
+                            }
+                        } else if (v_bits_per_pixel != 1)
+                            && (v_bits_per_pixel != 4)
+                            && (v_bits_per_pixel != 8)
+                            && (v_bits_per_pixel != 24)
+                        {
+                            status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                            break 'exit;
+                        }
+                    } else if v_compression == COMPRESSION_RLE8 {
+                        if v_bits_per_pixel != 8 {
+                            status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                            break 'exit;
+                        }
+                    } else if v_compression == COMPRESSION_RLE4 {
+                        if v_bits_per_pixel != 4 {
+                            status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                            break 'exit;
+                        }
+                    } else if (v_compression == COMPRESSION_BITFIELDS)
+                        || (v_compression == COMPRESSION_ALPHA_BITFIELDS)
+                    {
+                        if (v_bits_per_pixel != 16) && (v_bits_per_pixel != 32) {
+                            status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                            break 'exit;
+                        }
+                    } else {
+                        status = ERROR_UNSUPPORTED_COMPRESSION;
+                        break 'exit;
+                    }
+                    self.f_bits_per_pixel = u32::min(v_bits_per_pixel, 32);
+                    self.set_channel_shifts(0);
+                    self.set_channel_shifts(1);
+                    self.set_channel_shifts(2);
+                    self.set_channel_shifts(3);
+                }
+                if r == 0 || (15 <= r && r <= 19) {
+                    if (15 <= r && r <= 18) || (r == 0 && (v_bits_per_pixel <= 8)) {
+                        if r == 0 {
+                            if v_num_colors == 0 {
+                                v_num_colors = (1u32 << u32::min(self.f_bits_per_pixel, 8));
+                            } else if v_num_colors > 256 {
+                                status = ERROR_BAD_PALETTE;
+                                break 'exit;
+                            }
+                            v_num_entries = u32::min(v_num_colors, 256);
+                            v_i = 0;
+                        }
+                        if r == 0 || (15 <= r && r <= 18) {
+                            while r != 0 || (v_i < v_num_entries) {
+                                if r == 0 || r == 15 {
+                                    if r == 15 {
+                                        r = 0;
+                                    }
+                                    csp = 15;
+                                    status = a_src.read_u8(&mut t_10);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    (*unsafe {
+                                        self.f_palette.get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                    }) = t_10;
+                                }
+                                if r == 0 || r == 16 {
+                                    if r == 16 {
+                                        r = 0;
+                                    }
+                                    csp = 16;
+                                    status = a_src.read_u8(&mut t_11);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    (*unsafe {
+                                        self.f_palette.get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                    }) = t_11;
+                                }
+                                if r == 0 || r == 17 {
+                                    if r == 17 {
+                                        r = 0;
+                                    }
+                                    csp = 17;
+                                    status = a_src.read_u8(&mut t_12);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    (*unsafe {
+                                        self.f_palette.get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                    }) = t_12;
+                                }
+                                if r == 0 {
+                                    (*unsafe {
+                                        self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                    }) = 255;
+                                }
+                                if r == 0 || r == 18 {
+                                    if r == 0 {
+                                        self.c_decode_config.scratch = (1 as u64);
+                                    }
+                                    if r == 18 {
+                                        r = 0;
+                                    }
+                                    csp = 18;
+                                    status = a_src.skip(&mut self.c_decode_config.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_i += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            while v_i < 256 {
+                                (*unsafe {
+                                    self.f_palette.get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palette.get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palette.get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                }) = 255;
+                                v_i += 1;
+                            }
+                        }
+                    } else if r == 19 || (r == 0 && (v_num_colors > 0)) {
+                        if r == 0 {
+                            if (v_num_colors > 256) || self.f_ico_mode {
+                                status = ERROR_BAD_PALETTE;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 19 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = ((4 * v_num_colors) as u64);
+                            }
+                            if r == 19 {
+                                r = 0;
+                            }
+                            csp = 19;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_num_read = u64::saturating_add(v_num_read, (4 * (v_num_colors as u64)));
+                }
+                if r == 0 || r == 20 {
+                    if r == 20 || (r == 0 && !self.f_ico_mode) {
+                        if r == 0 {
+                            if (self.f_pixel_data_offset as u64) < v_num_read {
+                                status = ERROR_BAD_HEADER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 20 {
+                            if r == 0 {
+                                self.c_decode_config.scratch =
+                                    (((self.f_pixel_data_offset as u64) - v_num_read) as u64);
+                            }
+                            if r == 20 {
+                                r = 0;
+                            }
+                            csp = 20;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_row_length =
+                        (((((v_width as u64) * (self.f_bits_per_pixel as u64)) + 31) >> 5) * 4);
+                    if (v_compression != COMPRESSION_RLE8)
+                        && (v_compression != COMPRESSION_RLE4)
+                        && (v_row_length > 32768)
+                    {
+                        status = ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH;
+                        break 'exit;
+                    }
+                    self.f_row_length = (u64::min(v_row_length, 32768) as u32);
+                    a_dst.initialize(570460296, 0, self.f_width, self.f_height, 1);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_num_read = v_num_read;
+            self.c_decode_config.v_header_size = v_header_size;
+            self.c_decode_config.v_width = v_width;
+            self.c_decode_config.v_height = v_height;
+            self.c_decode_config.v_bits_per_pixel = v_bits_per_pixel;
+            self.c_decode_config.v_compression = v_compression;
+            self.c_decode_config.v_num_colors = v_num_colors;
+            self.c_decode_config.v_num_masks = v_num_masks;
+            self.c_decode_config.v_i = v_i;
+            self.c_decode_config.v_num_entries = v_num_entries;
+            self.c_decode_config.v_row_length = v_row_length;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_dirty_rect: wuffs_base::RectIeU32 = wuffs_base::RectIeU32::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_dirty_rect = self.c_decode_frame.v_dirty_rect;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 && (self.f_call_sequence == 0) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    } else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+                        if r == 0 || r == 1 {
+                            loop {
+                                if r == 0 || r == 1 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 1 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 3) {
+                    if r == 2
+                        || (r == 0
+                            && ((self.f_compression == COMPRESSION_RLE8)
+                                || (self.f_compression == COMPRESSION_RLE4)))
+                    {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.decode_rle(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || r == 3 {
+                            while r != 0 || (self.f_dst_y < self.f_height) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.read_row(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    self.swizzle_row(a_dst);
+                                    self.f_dst_y = u32::saturating_add(self.f_dst_y, 1);
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 || (r == 0 && self.f_ico_mode) {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.decode_and_mask(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_palette = wuffs_base::SliceU8::default();
+                    v_dirty_rect = wuffs_base::RectIeU32::default();
+                    v_dirty_rect.set_max_exclusive_x(self.f_width);
+                    v_dirty_rect.set_max_exclusive_y(self.f_height);
+                    a_dst.update(v_dirty_rect, 0, false, 0, v_palette);
+                    self.f_call_sequence = 2;
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+            self.c_decode_frame.v_dirty_rect = v_dirty_rect;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.set_channel_shifts
+
+impl Decoder {
+    fn set_channel_shifts(&mut self, mut a_c: u32) {
+        let mut v_mask: u32 = 0;
+        let mut v_shift: u32 = 0;
+        let mut v_num_bits: u32 = 0;
+
+        v_mask = (*unsafe { self.f_channel_masks.get_unchecked(a_c as usize) });
+        v_shift = 0;
+        v_num_bits = 0;
+        if v_mask != 0 {
+            while ((v_mask & 1) == 0) && (v_shift < 31) {
+                v_mask >>= 1;
+                v_shift += 1;
+            }
+            while (v_mask != 0) && (v_num_bits < 32) {
+                v_mask >>= 1;
+                v_num_bits += 1;
+            }
+        }
+        (*unsafe { self.f_channel_shifts.get_unchecked_mut(a_c as usize) }) = v_shift;
+        (*unsafe { self.f_channel_num_bits.get_unchecked_mut(a_c as usize) }) = v_num_bits;
+    }
+}
+
+// -------- func decoder.read_row
+
+impl Decoder {
+    fn read_row(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_row.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_read_row.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    'label_0: while r != 0 || (self.f_row_wi < self.f_row_length) {
+                        if r == 0 || r == 1 {
+                            if r == 1 || (r == 0 && (a_src.available() == 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_0;
+                                }
+                                if r == 0 {
+                                    (*unsafe {
+                                        self.f_row.get_unchecked_mut(self.f_row_wi as usize)
+                                    }) = v_c;
+                                    self.f_row_wi += 1;
+                                    continue 'label_0;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_w = wuffs_base::IoWriter::default();
+                            {
+                                let o_0_a_src = a_src;
+                                let o_0_v_w = v_w;
+                                unsafe {
+                                    v_w.set(&mut u_w, unsafe {
+                                        wuffs_base::SliceU8::from_array(&mut self.f_row)
+                                            .subslice_ij(
+                                                self.f_row_wi as usize,
+                                                self.f_row_length as usize,
+                                            )
+                                    })
+                                };
+                                v_w.copy_from_reader32(&mut a_src, 32768);
+                                self.f_row_wi = u32::saturating_sub(
+                                    self.f_row_length,
+                                    (u64::min(v_w.available(), 32768) as u32),
+                                );
+                                v_w = o_0_v_w;
+                                a_src = o_0_a_src;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_row_wi = 0;
+                }
+                self.c_read_row.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_row.coro_susp_point = csp;
+            self.c_read_row.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.swizzle_row
+
+impl Decoder {
+    fn swizzle_row(&mut self, mut a_dst: &mut wuffs_base::ImageBuffer) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_n: u32 = 0;
+        let mut v_bits_per_pixel: u32 = 0;
+        let mut v_mask: u32 = 0;
+        let mut v_shift: u32 = 0;
+        let mut v_o: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_pixel: [u8; 4] = [0; 4];
+        let mut v_x4: u64 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_dst = v_tab.row(self.file_row_to_y(self.f_dst_y));
+        v_n = self.f_row_length;
+        v_bits_per_pixel = self.f_bits_per_pixel;
+        v_mask = ((1u32 << u32::min(v_bits_per_pixel, 8)) - 1);
+        v_shift = (8 - u32::min(v_bits_per_pixel, 8));
+        v_o = 0;
+        v_x = 0;
+        v_p = 0;
+        v_v = 0;
+        v_pixel = [0; 4];
+        v_x4 = 0;
+        while (v_x < self.f_width) && (v_o < v_n) {
+            if v_bits_per_pixel <= 8 {
+                v_p = ((((*unsafe { self.f_row.get_unchecked(v_o as usize) }) as u32) >> v_shift)
+                    & v_mask);
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 0) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 1) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 2) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 3) as usize) });
+                if v_shift >= v_bits_per_pixel {
+                    v_shift -= v_bits_per_pixel;
+                } else {
+                    v_shift = (8 - u32::min(v_bits_per_pixel, 8));
+                    v_o += 1;
+                }
+            } else if v_bits_per_pixel == 24 {
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 0) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 2) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 255;
+                v_o += 3;
+            } else {
+                if v_bits_per_pixel == 16 {
+                    v_v = ((((*unsafe { self.f_row.get_unchecked((v_o + 0) as usize) }) as u32)
+                        << 0)
+                        | (((*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) }) as u32)
+                            << 8));
+                    v_o += 2;
+                } else {
+                    v_v = ((((*unsafe { self.f_row.get_unchecked((v_o + 0) as usize) }) as u32)
+                        << 0)
+                        | (((*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) }) as u32)
+                            << 8)
+                        | (((*unsafe { self.f_row.get_unchecked((v_o + 2) as usize) }) as u32)
+                            << 16)
+                        | (((*unsafe { self.f_row.get_unchecked((v_o + 3) as usize) }) as u32)
+                            << 24));
+                    v_o += 4;
+                }
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) = self.extract_channel(v_v, 0);
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) = self.extract_channel(v_v, 1);
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) = self.extract_channel(v_v, 2);
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = self.extract_channel(v_v, 3);
+            }
+            v_x4 = ((v_x as u64) * 4);
+            if v_x4 < (v_dst.len() as u64) {
+                unsafe { v_dst.subslice_i(v_x4 as usize) }
+                    .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) });
+            }
+            v_x += 1;
+        }
+    }
+}
+
+// -------- func decoder.extract_channel
+
+impl Decoder {
+    fn extract_channel(&mut self, mut a_v: u32, mut a_c: u32) -> u8 {
+        let mut v_num_bits: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_nb: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_n: u32 = 0;
+
+        v_num_bits = (*unsafe { self.f_channel_num_bits.get_unchecked(a_c as usize) });
+        if v_num_bits == 0 {
+            if a_c == 3 {
+                return 255;
+            }
+            return 0;
+        }
+        v_x = ((a_v & (*unsafe { self.f_channel_masks.get_unchecked(a_c as usize) }))
+            >> (*unsafe { self.f_channel_shifts.get_unchecked(a_c as usize) }));
+        if v_num_bits >= 8 {
+            return (((v_x >> (v_num_bits - 8)) & 255) as u8);
+        }
+        v_nb = u32::min(v_num_bits, 7);
+        v_y = 0;
+        v_n = 0;
+        while v_n < 8 {
+            v_y = (((v_y << v_nb) | v_x) & 16383);
+            v_n += v_nb;
+        }
+        return (((v_y >> (v_n - 8)) & 255) as u8);
+    }
+}
+
+// -------- func decoder.decode_rle
+
+impl Decoder {
+    fn decode_rle(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_rle4: bool = false;
+        let mut v_x: u32 = 0;
+        let mut v_a: u32 = 0;
+        let mut v_b: u8 = 0;
+        let mut v_c: u8 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_num_bytes: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_rle.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_rle4 = self.c_decode_rle.v_rle4;
+            v_x = self.c_decode_rle.v_x;
+            v_a = self.c_decode_rle.v_a;
+            v_b = self.c_decode_rle.v_b;
+            v_c = self.c_decode_rle.v_c;
+            v_n = self.c_decode_rle.v_n;
+            v_num_bytes = self.c_decode_rle.v_num_bytes;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.clear_image(a_dst);
+                    v_rle4 = (self.f_compression == COMPRESSION_RLE4);
+                    v_x = 0;
+                    v_a = 0;
+                    v_b = 0;
+                    v_c = 0;
+                    v_n = 0;
+                    v_num_bytes = 0;
+                }
+                if r == 0 || (1 <= r && r <= 6) {
+                    'label_0: loop {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_a = (t_0 as u32);
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u8(&mut t_1);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_b = t_1;
+                        }
+                        if r == 0 || (3 <= r && r <= 6) {
+                            if r == 0 && (v_a > 0) {
+                                v_n = 0;
+                                while v_n < v_a {
+                                    if v_rle4 && ((v_n & 1) == 0) {
+                                        v_c = (v_b >> 4);
+                                    } else if v_rle4 {
+                                        v_c = (v_b & 15);
+                                    } else {
+                                        v_c = v_b;
+                                    }
+                                    self.write_index(a_dst, v_x, v_c);
+                                    v_x = u32::saturating_add(v_x, 1);
+                                    v_n += 1;
+                                }
+                            } else if r == 0 && (v_b == 0) {
+                                v_x = 0;
+                                self.f_dst_y = u32::saturating_add(self.f_dst_y, 1);
+                            } else if r == 0 && (v_b == 1) {
+                                break 'label_0;
+                            } else if (3 <= r && r <= 4) || (r == 0 && (v_b == 2)) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = a_src.read_u8(&mut t_2);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_x = u32::saturating_add(v_x, (t_2 as u32));
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = a_src.read_u8(&mut t_3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    self.f_dst_y = u32::saturating_add(self.f_dst_y, (t_3 as u32));
+                                }
+                            } else {
+                                if r == 0 {
+                                    v_a = (v_b as u32);
+                                    if v_rle4 {
+                                        v_num_bytes = ((v_a + 1) >> 1);
+                                    } else {
+                                        v_num_bytes = v_a;
+                                    }
+                                    v_n = 0;
+                                }
+                                if r == 0 || r == 5 {
+                                    while r != 0 || (v_n < v_a) {
+                                        if r == 0 || r == 5 {
+                                            if r == 5 || (r == 0 && (!v_rle4 || ((v_n & 1) == 0))) {
+                                                if r == 0 || r == 5 {
+                                                    if r == 5 {
+                                                        r = 0;
+                                                    }
+                                                    csp = 5;
+                                                    status = a_src.read_u8(&mut t_4);
+                                                    if !status.is_ok() {
+                                                        break 'suspend;
+                                                    }
+                                                    v_b = t_4;
+                                                }
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if v_rle4 && ((v_n & 1) == 0) {
+                                                v_c = (v_b >> 4);
+                                            } else if v_rle4 {
+                                                v_c = (v_b & 15);
+                                            } else {
+                                                v_c = v_b;
+                                            }
+                                            self.write_index(a_dst, v_x, v_c);
+                                            v_x = u32::saturating_add(v_x, 1);
+                                            v_n += 1;
+                                        }
+                                    }
+                                }
+                                if r == 0 || r == 6 {
+                                    if r == 6 || (r == 0 && ((v_num_bytes & 1) != 0)) {
+                                        if r == 0 || r == 6 {
+                                            if r == 0 {
+                                                self.c_decode_rle.scratch = (1 as u64);
+                                            }
+                                            if r == 6 {
+                                                r = 0;
+                                            }
+                                            csp = 6;
+                                            status = a_src.skip(&mut self.c_decode_rle.scratch);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_decode_rle.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_rle.coro_susp_point = csp;
+            self.c_decode_rle.v_rle4 = v_rle4;
+            self.c_decode_rle.v_x = v_x;
+            self.c_decode_rle.v_a = v_a;
+            self.c_decode_rle.v_b = v_b;
+            self.c_decode_rle.v_c = v_c;
+            self.c_decode_rle.v_n = v_n;
+            self.c_decode_rle.v_num_bytes = v_num_bytes;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.clear_image
+
+impl Decoder {
+    fn clear_image(&mut self, mut a_dst: &mut wuffs_base::ImageBuffer) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_y: u32 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_y = 0;
+        while v_y < self.f_height {
+            {
+                let i_slice_p = v_tab.row(v_y);
+                let mut v_p = wuffs_base::SliceU8::default();
+                let mut i_p: usize = 0;
+                let i_end0_p = (i_slice_p.len() / 1) * 1;
+                while i_p < i_end0_p {
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    (*unsafe { v_p.get_unchecked_mut(0 as usize) }) = 0;
+                    i_p += 1;
+                }
+            }
+            v_y = u32::saturating_add(v_y, 1);
+        }
+    }
+}
+
+// -------- func decoder.write_index
+
+impl Decoder {
+    fn write_index(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_x: u32,
+        mut a_index: u8,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x4: u64 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_pixel: [u8; 4] = [0; 4];
+
+        if (a_x >= self.f_width) || (self.f_dst_y >= self.f_height) {
+            return;
+        }
+        v_tab = a_dst.plane(0);
+        v_dst = v_tab.row(self.file_row_to_y(self.f_dst_y));
+        v_x4 = ((a_x as u64) * 4);
+        v_p = (a_index as u32);
+        v_pixel = [0; 4];
+        (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+            (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 0) as usize) });
+        (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+            (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 1) as usize) });
+        (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+            (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 2) as usize) });
+        (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+            (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 3) as usize) });
+        if v_x4 < (v_dst.len() as u64) {
+            unsafe { v_dst.subslice_i(v_x4 as usize) }
+                .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) });
+        }
+    }
+}
+
+// -------- func decoder.decode_and_mask
+
+impl Decoder {
+    fn decode_and_mask(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_row_length: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_and_mask.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_row_length = self.c_decode_and_mask.v_row_length;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_row_length = ((((self.f_width as u64) + 31) >> 5) * 4);
+                    if v_row_length > 32768 {
+                        status = ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH;
+                        break 'exit;
+                    }
+                    self.f_row_length = (u64::min(v_row_length, 32768) as u32);
+                    self.f_dst_y = 0;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (self.f_dst_y < self.f_height) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.read_row(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            if self.f_bits_per_pixel < 32 {
+                                self.apply_and_mask_row(a_dst);
+                            }
+                            self.f_dst_y = u32::saturating_add(self.f_dst_y, 1);
+                        }
+                    }
+                }
+                self.c_decode_and_mask.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_and_mask.coro_susp_point = csp;
+            self.c_decode_and_mask.v_row_length = v_row_length;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.apply_and_mask_row
+
+impl Decoder {
+    fn apply_and_mask_row(&mut self, mut a_dst: &mut wuffs_base::ImageBuffer) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_n: u32 = 0;
+        let mut v_zeroes: [u8; 4] = [0; 4];
+        let mut v_x: u32 = 0;
+        let mut v_x4: u64 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_dst = v_tab.row(self.file_row_to_y(self.f_dst_y));
+        v_n = self.f_row_length;
+        v_zeroes = [0; 4];
+        v_x = 0;
+        v_x4 = 0;
+        while (v_x < self.f_width) && ((v_x >> 3) < v_n) {
+            if (((*unsafe { self.f_row.get_unchecked((v_x >> 3) as usize) }) >> (7 - (v_x & 7)))
+                & 1)
+                != 0
+            {
+                v_x4 = ((v_x as u64) * 4);
+                if v_x4 < (v_dst.len() as u64) {
+                    unsafe { v_dst.subslice_i(v_x4 as usize) }
+                        .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_zeroes) });
+                }
+            }
+            v_x += 1;
+        }
+    }
+}
+
+// -------- func decoder.file_row_to_y
+
+impl Decoder {
+    fn file_row_to_y(&mut self, mut a_r: u32) -> u32 {
+        if self.f_top_down || (a_r >= self.f_height) {
+            return a_r;
+        }
+        return ((self.f_height - 1) - a_r);
+    }
+}
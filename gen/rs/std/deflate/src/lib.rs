@@ -0,0 +1,4405 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package deflate is the Rust translation of the Wuffs std/deflate package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman code (over-subscribed)");
+pub const ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman code (under-subscribed)");
+pub const ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman code length count");
+pub const ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman code length repetition");
+pub const ERROR_BAD_HUFFMAN_CODE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman code");
+pub const ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad Huffman minimum code length");
+pub const ERROR_BAD_BLOCK: wuffs_base::Status = wuffs_base::Status::Error("deflate: bad block");
+pub const ERROR_BAD_DISTANCE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad distance");
+pub const ERROR_BAD_DISTANCE_CODE_COUNT: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad distance code count");
+pub const ERROR_BAD_LITERAL_LENGTH_CODE_COUNT: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: bad literal/length code count");
+pub const ERROR_INCONSISTENT_STORED_BLOCK_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: inconsistent stored block length");
+pub const ERROR_MISSING_END_OF_BLOCK_CODE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: missing end-of-block code");
+pub const ERROR_NO_HUFFMAN_CODES: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: no Huffman codes");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: internal error: inconsistent Huffman decoder state");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: internal error: inconsistent Huffman end_of_block");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: internal error: inconsistent distance");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: internal error: inconsistent n_bits");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE: wuffs_base::Status =
+    wuffs_base::Status::Error("deflate: internal error: inconsistent encoder state");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a deflate.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_bits: u32,
+    f_n_bits: u32,
+    f_huffs: [[u32; 1234]; 2],
+    f_n_huffs_bits: [u32; 2],
+    f_history: [u8; 32768],
+    f_history_index: u32,
+    f_code_lengths: [u8; 320],
+    f_end_of_block: bool,
+    c_decode: DecoderDecodeCoro,
+    c_decode_blocks: DecoderDecodeBlocksCoro,
+    c_decode_uncompressed: DecoderDecodeUncompressedCoro,
+    c_init_fixed_huffman: DecoderInitFixedHuffmanCoro,
+    c_init_dynamic_huffman: DecoderInitDynamicHuffmanCoro,
+    c_decode_huffman_slow: DecoderDecodeHuffmanSlowCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_bits: 0,
+            f_n_bits: 0,
+            f_huffs: [[0; 1234]; 2],
+            f_n_huffs_bits: [0; 2],
+            f_history: [0; 32768],
+            f_history_index: 0,
+            f_code_lengths: [0; 320],
+            f_end_of_block: false,
+            c_decode: DecoderDecodeCoro::default(),
+            c_decode_blocks: DecoderDecodeBlocksCoro::default(),
+            c_decode_uncompressed: DecoderDecodeUncompressedCoro::default(),
+            c_init_fixed_huffman: DecoderInitFixedHuffmanCoro::default(),
+            c_init_dynamic_huffman: DecoderInitDynamicHuffmanCoro::default(),
+            c_decode_huffman_slow: DecoderDecodeHuffmanSlowCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeCoro {
+    coro_susp_point: u32,
+    v_z: wuffs_base::Status,
+}
+
+impl Default for DecoderDecodeCoro {
+    fn default() -> Self {
+        DecoderDecodeCoro {
+            coro_susp_point: 0,
+            v_z: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+struct DecoderDecodeBlocksCoro {
+    coro_susp_point: u32,
+    v_final: u32,
+    v_type: u32,
+}
+
+impl Default for DecoderDecodeBlocksCoro {
+    fn default() -> Self {
+        DecoderDecodeBlocksCoro {
+            coro_susp_point: 0,
+            v_final: 0,
+            v_type: 0,
+        }
+    }
+}
+
+struct DecoderDecodeUncompressedCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_n_copied: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeUncompressedCoro {
+    fn default() -> Self {
+        DecoderDecodeUncompressedCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_n_copied: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderInitFixedHuffmanCoro {
+    coro_susp_point: u32,
+    v_i: u32,
+}
+
+impl Default for DecoderInitFixedHuffmanCoro {
+    fn default() -> Self {
+        DecoderInitFixedHuffmanCoro {
+            coro_susp_point: 0,
+            v_i: 0,
+        }
+    }
+}
+
+struct DecoderInitDynamicHuffmanCoro {
+    coro_susp_point: u32,
+    v_bits: u32,
+    v_n_bits: u32,
+    v_n_lit: u32,
+    v_n_dist: u32,
+    v_n_clen: u32,
+    v_i: u32,
+    v_mask: u32,
+    v_table_entry: u32,
+    v_table_entry_n_bits: u32,
+    v_n_extra_bits: u32,
+    v_rep_symbol: u8,
+    v_rep_count: u32,
+}
+
+impl Default for DecoderInitDynamicHuffmanCoro {
+    fn default() -> Self {
+        DecoderInitDynamicHuffmanCoro {
+            coro_susp_point: 0,
+            v_bits: 0,
+            v_n_bits: 0,
+            v_n_lit: 0,
+            v_n_dist: 0,
+            v_n_clen: 0,
+            v_i: 0,
+            v_mask: 0,
+            v_table_entry: 0,
+            v_table_entry_n_bits: 0,
+            v_n_extra_bits: 0,
+            v_rep_symbol: 0,
+            v_rep_count: 0,
+        }
+    }
+}
+
+struct DecoderDecodeHuffmanSlowCoro {
+    coro_susp_point: u32,
+    v_bits: u32,
+    v_n_bits: u32,
+    v_table_entry: u32,
+    v_table_entry_n_bits: u32,
+    v_lmask: u32,
+    v_dmask: u32,
+    v_redir_top: u32,
+    v_redir_mask: u32,
+    v_length: u32,
+    v_dist_minus_1: u32,
+    v_n_copied: u32,
+    v_hlen: u32,
+    v_hdist: u32,
+}
+
+impl Default for DecoderDecodeHuffmanSlowCoro {
+    fn default() -> Self {
+        DecoderDecodeHuffmanSlowCoro {
+            coro_susp_point: 0,
+            v_bits: 0,
+            v_n_bits: 0,
+            v_table_entry: 0,
+            v_table_entry_n_bits: 0,
+            v_lmask: 0,
+            v_dmask: 0,
+            v_redir_top: 0,
+            v_redir_mask: 0,
+            v_length: 0,
+            v_dist_minus_1: 0,
+            v_n_copied: 0,
+            v_hlen: 0,
+            v_hdist: 0,
+        }
+    }
+}
+
+/// Encoder holds the state of a deflate.encoder. Its default value is ready to use.
+pub struct Encoder {
+    status: wuffs_base::Status,
+    f_level: u32,
+    f_level_set: bool,
+    f_eof: bool,
+    f_bits: u32,
+    f_n_bits: u32,
+    f_window: [u8; 65536],
+    f_w_pos: u32,
+    f_w_end: u32,
+    f_block_start: u32,
+    f_head: [u16; 32768],
+    f_prev: [u16; 32768],
+    f_ins_pos: u32,
+    f_tokens: [u32; 16384],
+    f_n_tokens: u32,
+    f_freqs: [[u32; 288]; 3],
+    f_lens: [[u8; 288]; 3],
+    f_codes: [[u16; 288]; 3],
+    f_n_lcodes: u32,
+    f_n_dcodes: u32,
+    f_n_clcodes: u32,
+    f_clseq: [u16; 320],
+    f_n_clseq: u32,
+    f_hsort_keys: [u32; 512],
+    f_hsort_syms: [u16; 512],
+    c_encode: EncoderEncodeCoro,
+    c_fill_window: EncoderFillWindowCoro,
+    c_write_block: EncoderWriteBlockCoro,
+    c_write_stored: EncoderWriteStoredCoro,
+    c_write_bits: EncoderWriteBitsCoro,
+    c_write_dynamic_header: EncoderWriteDynamicHeaderCoro,
+    c_write_tokens: EncoderWriteTokensCoro,
+}
+
+impl Default for Encoder {
+    fn default() -> Self {
+        Encoder {
+            status: wuffs_base::Status::Ok,
+            f_level: 0,
+            f_level_set: false,
+            f_eof: false,
+            f_bits: 0,
+            f_n_bits: 0,
+            f_window: [0; 65536],
+            f_w_pos: 0,
+            f_w_end: 0,
+            f_block_start: 0,
+            f_head: [0; 32768],
+            f_prev: [0; 32768],
+            f_ins_pos: 0,
+            f_tokens: [0; 16384],
+            f_n_tokens: 0,
+            f_freqs: [[0; 288]; 3],
+            f_lens: [[0; 288]; 3],
+            f_codes: [[0; 288]; 3],
+            f_n_lcodes: 0,
+            f_n_dcodes: 0,
+            f_n_clcodes: 0,
+            f_clseq: [0; 320],
+            f_n_clseq: 0,
+            f_hsort_keys: [0; 512],
+            f_hsort_syms: [0; 512],
+            c_encode: EncoderEncodeCoro::default(),
+            c_fill_window: EncoderFillWindowCoro::default(),
+            c_write_block: EncoderWriteBlockCoro::default(),
+            c_write_stored: EncoderWriteStoredCoro::default(),
+            c_write_bits: EncoderWriteBitsCoro::default(),
+            c_write_dynamic_header: EncoderWriteDynamicHeaderCoro::default(),
+            c_write_tokens: EncoderWriteTokensCoro::default(),
+        }
+    }
+}
+
+struct EncoderEncodeCoro {
+    coro_susp_point: u32,
+    v_z: wuffs_base::Status,
+}
+
+impl Default for EncoderEncodeCoro {
+    fn default() -> Self {
+        EncoderEncodeCoro {
+            coro_susp_point: 0,
+            v_z: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+struct EncoderFillWindowCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for EncoderFillWindowCoro {
+    fn default() -> Self {
+        EncoderFillWindowCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct EncoderWriteBlockCoro {
+    coro_susp_point: u32,
+    v_stored: bool,
+    v_fixed: bool,
+    v_n_extra_bits: u64,
+    v_fixed_cost: u64,
+    v_dynamic_cost: u64,
+    v_n_bytes: u64,
+    v_stored_cost: u64,
+}
+
+impl Default for EncoderWriteBlockCoro {
+    fn default() -> Self {
+        EncoderWriteBlockCoro {
+            coro_susp_point: 0,
+            v_stored: false,
+            v_fixed: false,
+            v_n_extra_bits: 0,
+            v_fixed_cost: 0,
+            v_dynamic_cost: 0,
+            v_n_bytes: 0,
+            v_stored_cost: 0,
+        }
+    }
+}
+
+struct EncoderWriteStoredCoro {
+    coro_susp_point: u32,
+    v_start: u32,
+    v_end: u32,
+    v_remaining: u32,
+    v_length: u32,
+    v_n_copied: u32,
+    v_new_start: u32,
+    scratch: u64,
+}
+
+impl Default for EncoderWriteStoredCoro {
+    fn default() -> Self {
+        EncoderWriteStoredCoro {
+            coro_susp_point: 0,
+            v_start: 0,
+            v_end: 0,
+            v_remaining: 0,
+            v_length: 0,
+            v_n_copied: 0,
+            v_new_start: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderWriteBitsCoro {
+    coro_susp_point: u32,
+    v_n_bits: u32,
+}
+
+impl Default for EncoderWriteBitsCoro {
+    fn default() -> Self {
+        EncoderWriteBitsCoro {
+            coro_susp_point: 0,
+            v_n_bits: 0,
+        }
+    }
+}
+
+struct EncoderWriteDynamicHeaderCoro {
+    coro_susp_point: u32,
+    v_i: u32,
+    v_symbol: u32,
+    v_n_extra_bits: u32,
+}
+
+impl Default for EncoderWriteDynamicHeaderCoro {
+    fn default() -> Self {
+        EncoderWriteDynamicHeaderCoro {
+            coro_susp_point: 0,
+            v_i: 0,
+            v_symbol: 0,
+            v_n_extra_bits: 0,
+        }
+    }
+}
+
+struct EncoderWriteTokensCoro {
+    coro_susp_point: u32,
+    v_i: u32,
+    v_t: u32,
+    v_length: u32,
+    v_lc: u32,
+    v_d: u32,
+    v_dc: u32,
+    v_magic: u32,
+}
+
+impl Default for EncoderWriteTokensCoro {
+    fn default() -> Self {
+        EncoderWriteTokensCoro {
+            coro_susp_point: 0,
+            v_i: 0,
+            v_t: 0,
+            v_length: 0,
+            v_lc: 0,
+            v_d: 0,
+            v_dc: 0,
+            v_magic: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+static CODE_ORDER: [u8; 19] = [
+    0x10, 0x11, 0x12, 0x0, 0x8, 0x7, 0x9, 0x6, 0xA, 0x5, 0xB, 0x4, 0xC, 0x3, 0xD, 0x2, 0xE, 0x1,
+    0xF,
+];
+
+static REVERSE8: [u8; 256] = [
+    0x0, 0x80, 0x40, 0xC0, 0x20, 0xA0, 0x60, 0xE0, 0x10, 0x90, 0x50, 0xD0, 0x30, 0xB0, 0x70, 0xF0,
+    0x8, 0x88, 0x48, 0xC8, 0x28, 0xA8, 0x68, 0xE8, 0x18, 0x98, 0x58, 0xD8, 0x38, 0xB8, 0x78, 0xF8,
+    0x4, 0x84, 0x44, 0xC4, 0x24, 0xA4, 0x64, 0xE4, 0x14, 0x94, 0x54, 0xD4, 0x34, 0xB4, 0x74, 0xF4,
+    0xC, 0x8C, 0x4C, 0xCC, 0x2C, 0xAC, 0x6C, 0xEC, 0x1C, 0x9C, 0x5C, 0xDC, 0x3C, 0xBC, 0x7C, 0xFC,
+    0x2, 0x82, 0x42, 0xC2, 0x22, 0xA2, 0x62, 0xE2, 0x12, 0x92, 0x52, 0xD2, 0x32, 0xB2, 0x72, 0xF2,
+    0xA, 0x8A, 0x4A, 0xCA, 0x2A, 0xAA, 0x6A, 0xEA, 0x1A, 0x9A, 0x5A, 0xDA, 0x3A, 0xBA, 0x7A, 0xFA,
+    0x6, 0x86, 0x46, 0xC6, 0x26, 0xA6, 0x66, 0xE6, 0x16, 0x96, 0x56, 0xD6, 0x36, 0xB6, 0x76, 0xF6,
+    0xE, 0x8E, 0x4E, 0xCE, 0x2E, 0xAE, 0x6E, 0xEE, 0x1E, 0x9E, 0x5E, 0xDE, 0x3E, 0xBE, 0x7E, 0xFE,
+    0x1, 0x81, 0x41, 0xC1, 0x21, 0xA1, 0x61, 0xE1, 0x11, 0x91, 0x51, 0xD1, 0x31, 0xB1, 0x71, 0xF1,
+    0x9, 0x89, 0x49, 0xC9, 0x29, 0xA9, 0x69, 0xE9, 0x19, 0x99, 0x59, 0xD9, 0x39, 0xB9, 0x79, 0xF9,
+    0x5, 0x85, 0x45, 0xC5, 0x25, 0xA5, 0x65, 0xE5, 0x15, 0x95, 0x55, 0xD5, 0x35, 0xB5, 0x75, 0xF5,
+    0xD, 0x8D, 0x4D, 0xCD, 0x2D, 0xAD, 0x6D, 0xED, 0x1D, 0x9D, 0x5D, 0xDD, 0x3D, 0xBD, 0x7D, 0xFD,
+    0x3, 0x83, 0x43, 0xC3, 0x23, 0xA3, 0x63, 0xE3, 0x13, 0x93, 0x53, 0xD3, 0x33, 0xB3, 0x73, 0xF3,
+    0xB, 0x8B, 0x4B, 0xCB, 0x2B, 0xAB, 0x6B, 0xEB, 0x1B, 0x9B, 0x5B, 0xDB, 0x3B, 0xBB, 0x7B, 0xFB,
+    0x7, 0x87, 0x47, 0xC7, 0x27, 0xA7, 0x67, 0xE7, 0x17, 0x97, 0x57, 0xD7, 0x37, 0xB7, 0x77, 0xF7,
+    0xF, 0x8F, 0x4F, 0xCF, 0x2F, 0xAF, 0x6F, 0xEF, 0x1F, 0x9F, 0x5F, 0xDF, 0x3F, 0xBF, 0x7F, 0xFF,
+];
+
+static LCODE_MAGIC_NUMBERS: [u32; 32] = [
+    0x40000300, 0x40000400, 0x40000500, 0x40000600, 0x40000700, 0x40000800, 0x40000900, 0x40000A00,
+    0x40000B10, 0x40000D10, 0x40000F10, 0x40001110, 0x40001320, 0x40001720, 0x40001B20, 0x40001F20,
+    0x40002330, 0x40002B30, 0x40003330, 0x40003B30, 0x40004340, 0x40005340, 0x40006340, 0x40007340,
+    0x40008350, 0x4000A350, 0x4000C350, 0x4000E350, 0x40010200, 0x8000000, 0x8000000, 0x8000000,
+];
+
+static DCODE_MAGIC_NUMBERS: [u32; 32] = [
+    0x40000000, 0x40000100, 0x40000200, 0x40000300, 0x40000410, 0x40000610, 0x40000820, 0x40000C20,
+    0x40001030, 0x40001830, 0x40002040, 0x40003040, 0x40004050, 0x40006050, 0x40008060, 0x4000C060,
+    0x40010070, 0x40018070, 0x40020080, 0x40030080, 0x40040090, 0x40060090, 0x400800A0, 0x400C00A0,
+    0x401000B0, 0x401800B0, 0x402000C0, 0x403000C0, 0x404000D0, 0x406000D0, 0x8000000, 0x8000000,
+];
+
+static LENGTH_CODES: [u8; 256] = [
+    0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0x8, 0x8, 0x9, 0x9, 0xA, 0xA, 0xB, 0xB, 0xC, 0xC, 0xC,
+    0xC, 0xD, 0xD, 0xD, 0xD, 0xE, 0xE, 0xE, 0xE, 0xF, 0xF, 0xF, 0xF, 0x10, 0x10, 0x10, 0x10, 0x10,
+    0x10, 0x10, 0x10, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x12, 0x12, 0x12, 0x12,
+    0x12, 0x12, 0x12, 0x13, 0x13, 0x13, 0x13, 0x13, 0x13, 0x13, 0x13, 0x14, 0x14, 0x14, 0x14, 0x14,
+    0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x14, 0x15, 0x15, 0x15, 0x15, 0x15,
+    0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x15, 0x16, 0x16, 0x16, 0x16, 0x16,
+    0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x17, 0x17, 0x17, 0x17, 0x17,
+    0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x18, 0x18, 0x18, 0x18, 0x18,
+    0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18,
+    0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x19, 0x19, 0x19, 0x19, 0x19,
+    0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19,
+    0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A,
+    0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A,
+    0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B,
+    0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B,
+    0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1C,
+];
+
+static DISTANCE_CODES: [u8; 512] = [
+    0x0, 0x1, 0x2, 0x3, 0x4, 0x4, 0x5, 0x5, 0x6, 0x6, 0x6, 0x6, 0x7, 0x7, 0x7, 0x7, 0x8, 0x8, 0x8,
+    0x8, 0x8, 0x8, 0x8, 0x8, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0xA, 0xA, 0xA, 0xA, 0xA, 0xA,
+    0xA, 0xA, 0xA, 0xA, 0xA, 0xA, 0xA, 0xA, 0xA, 0xA, 0xB, 0xB, 0xB, 0xB, 0xB, 0xB, 0xB, 0xB, 0xB,
+    0xB, 0xB, 0xB, 0xB, 0xB, 0xB, 0xB, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC,
+    0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC, 0xC,
+    0xC, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD,
+    0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xD, 0xE, 0xE, 0xE, 0xE, 0xE,
+    0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE,
+    0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE,
+    0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE, 0xE,
+    0xE, 0xE, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF,
+    0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF,
+    0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF,
+    0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0xF, 0x0, 0x0, 0x10, 0x11, 0x12, 0x12, 0x13, 0x13,
+    0x14, 0x14, 0x14, 0x14, 0x15, 0x15, 0x15, 0x15, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16, 0x16,
+    0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x17, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18,
+    0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19,
+    0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x19, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A,
+    0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A,
+    0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1A, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B,
+    0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B,
+    0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1B, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C,
+    0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C,
+    0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C,
+    0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C,
+    0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1C, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D,
+    0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D,
+    0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D,
+    0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D,
+    0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D, 0x1D,
+];
+
+static LEVEL_MAX_CHAINS: [u32; 10] = [0x0, 0x4, 0x8, 0x20, 0x10, 0x20, 0x80, 0x100, 0x400, 0x1000];
+
+static LEVEL_NICE_LENGTHS: [u32; 10] = [0x0, 0x8, 0x10, 0x20, 0x10, 0x20, 0x80, 0x80, 0x102, 0x102];
+
+static LEVEL_LAZY_LENGTHS: [u32; 10] = [0x0, 0x0, 0x0, 0x0, 0x4, 0x10, 0x10, 0x20, 0x80, 0x102];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.decode
+
+impl Decoder {
+    pub fn decode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_z = self.c_decode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || r == 1 {
+                        loop {
+                            if r == 0 {
+                                a_dst.set_mark();
+                                t_0 = self.decode_blocks(a_dst, a_src);
+                                v_z = t_0;
+                                if !v_z.is_suspension() {
+                                    status = v_z;
+                                    if status.is_ok() {
+                                        break 'ok;
+                                    }
+                                    if status.is_suspension() {
+                                        status = wuffs_base::ERROR_CANNOT_RETURN_A_SUSPENSION;
+                                    }
+                                    break 'exit;
+                                }
+                                self.add_history(a_dst.since_mark());
+                            }
+                            if r == 0 || r == 1 {
+                                status = v_z;
+                                if r == 1 {
+                                    r = 0;
+                                    status = wuffs_base::Status::Ok;
+                                } else {
+                                    if status.is_error() {
+                                        break 'exit;
+                                    }
+                                    if !status.is_ok() {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_decode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode.coro_susp_point = csp;
+            self.c_decode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.add_history
+
+impl Decoder {
+    pub fn add_history(&mut self, mut a_hist: wuffs_base::SliceU8) {
+        if self.status.is_error() {
+            return;
+        }
+
+        let mut v_n_copied: u64 = 0;
+        let mut v_already_full: u32 = 0;
+
+        if (a_hist.len() as u64) >= 32768 {
+            a_hist = a_hist.suffix(32768);
+            unsafe { wuffs_base::SliceU8::from_array(&mut self.f_history) }.copy_from_slice(a_hist);
+            self.f_history_index = 32768;
+        } else {
+            v_n_copied = unsafe {
+                wuffs_base::SliceU8::from_array(&mut self.f_history)
+                    .subslice_i((self.f_history_index & 32767) as usize)
+            }
+            .copy_from_slice(a_hist);
+            if v_n_copied < (a_hist.len() as u64) {
+                a_hist = unsafe { a_hist.subslice_i(v_n_copied as usize) };
+                v_n_copied = unsafe { wuffs_base::SliceU8::from_array(&mut self.f_history) }
+                    .copy_from_slice(a_hist);
+                self.f_history_index = (((v_n_copied & 32767) as u32) + 32768);
+            } else {
+                v_already_full = 0;
+                if self.f_history_index >= 32768 {
+                    v_already_full = 32768;
+                }
+                self.f_history_index = ((self.f_history_index & 32767)
+                    + ((v_n_copied & 32767) as u32)
+                    + v_already_full);
+            }
+        }
+    }
+}
+
+// -------- func decoder.decode_blocks
+
+impl Decoder {
+    fn decode_blocks(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_final: u32 = 0;
+        let mut v_type: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_blocks.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_final = self.c_decode_blocks.v_final;
+            v_type = self.c_decode_blocks.v_type;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_final = 0;
+                }
+                if r == 0 || (1 <= r && r <= 6) {
+                    'label_0: while r != 0 || (v_final == 0) {
+                        if r == 0 || r == 1 {
+                            while r != 0 || (self.f_n_bits < 3) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    self.f_bits |= ((t_0 as u32) << self.f_n_bits);
+                                }
+                                if r == 0 {
+                                    self.f_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_final = (self.f_bits & 1);
+                            v_type = ((self.f_bits >> 1) & 3);
+                            self.f_bits >>= 3;
+                            self.f_n_bits -= 3;
+                        }
+                        if r == 0 || (2 <= r && r <= 4) {
+                            if r == 2 || (r == 0 && (v_type == 0)) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.decode_uncompressed(a_dst, a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    continue 'label_0;
+                                }
+                            } else if r == 3 || (r == 0 && (v_type == 1)) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.init_fixed_huffman();
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else if r == 4 || (r == 0 && (v_type == 2)) {
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.init_dynamic_huffman(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else {
+                                status = ERROR_BAD_BLOCK;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_end_of_block = false;
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.decode_huffman_fast(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            if self.f_end_of_block {
+                                continue 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = self.decode_huffman_slow(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            if self.f_end_of_block {
+                                continue 'label_0;
+                            }
+                            status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK;
+                            break 'exit;
+                        }
+                    }
+                }
+                self.c_decode_blocks.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_blocks.coro_susp_point = csp;
+            self.c_decode_blocks.v_final = v_final;
+            self.c_decode_blocks.v_type = v_type;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_uncompressed
+
+impl Decoder {
+    fn decode_uncompressed(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_n_copied: u32 = 0;
+        let mut t_0: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_uncompressed.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_decode_uncompressed.v_length;
+            v_n_copied = self.c_decode_uncompressed.v_n_copied;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+                            status = ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS;
+                            break 'exit;
+                        }
+                        self.f_n_bits = 0;
+                        self.f_bits = 0;
+                    }
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_uncompressed.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status =
+                            a_src.read_u32le(&mut t_0, &mut self.c_decode_uncompressed.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        v_length = t_0;
+                    }
+                    if r == 0 {
+                        if ((v_length & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0))
+                            + u32::checked_shr(v_length, 32 - (16 as u32)).unwrap_or(0))
+                            != 65535
+                        {
+                            status = ERROR_INCONSISTENT_STORED_BLOCK_LENGTH;
+                            break 'exit;
+                        }
+                        v_length = (v_length & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0));
+                    }
+                    if r == 0 || (2 <= r && r <= 3) {
+                        loop {
+                            if r == 0 {
+                                v_n_copied = a_dst.copy_from_reader32(&mut a_src, v_length);
+                                if v_length <= v_n_copied {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                }
+                                v_length -= v_n_copied;
+                            }
+                            if r == 0 || (2 <= r && r <= 3) {
+                                if r == 2 || (r == 0 && (a_dst.available() == 0)) {
+                                    if r == 0 || r == 2 {
+                                        status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                        if r == 2 {
+                                            r = 0;
+                                            status = wuffs_base::Status::Ok;
+                                        } else {
+                                            csp = 2;
+                                            break 'suspend;
+                                        }
+                                    }
+                                } else {
+                                    if r == 0 || r == 3 {
+                                        status = wuffs_base::SUSPENSION_SHORT_READ;
+                                        if r == 3 {
+                                            r = 0;
+                                            status = wuffs_base::Status::Ok;
+                                        } else {
+                                            csp = 3;
+                                            break 'suspend;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_decode_uncompressed.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_uncompressed.coro_susp_point = csp;
+            self.c_decode_uncompressed.v_length = v_length;
+            self.c_decode_uncompressed.v_n_copied = v_n_copied;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.init_fixed_huffman
+
+impl Decoder {
+    fn init_fixed_huffman(&mut self) -> wuffs_base::Status {
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_init_fixed_huffman.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_i = self.c_init_fixed_huffman.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_i = 0;
+                    while v_i < 144 {
+                        (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 8;
+                        v_i += 1;
+                    }
+                    while v_i < 256 {
+                        (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 9;
+                        v_i += 1;
+                    }
+                    while v_i < 280 {
+                        (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 7;
+                        v_i += 1;
+                    }
+                    while v_i < 288 {
+                        (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 8;
+                        v_i += 1;
+                    }
+                    while v_i < 320 {
+                        (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 5;
+                        v_i += 1;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.init_huff(0, 0, 288, 257);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.init_huff(1, 288, 320, 0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_init_fixed_huffman.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_init_fixed_huffman.coro_susp_point = csp;
+            self.c_init_fixed_huffman.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.init_dynamic_huffman
+
+impl Decoder {
+    fn init_dynamic_huffman(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_bits: u32 = 0;
+        let mut v_n_bits: u32 = 0;
+        let mut v_n_lit: u32 = 0;
+        let mut v_n_dist: u32 = 0;
+        let mut v_n_clen: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_mask: u32 = 0;
+        let mut v_table_entry: u32 = 0;
+        let mut v_table_entry_n_bits: u32 = 0;
+        let mut v_n_extra_bits: u32 = 0;
+        let mut v_rep_symbol: u8 = 0;
+        let mut v_rep_count: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_init_dynamic_huffman.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_bits = self.c_init_dynamic_huffman.v_bits;
+            v_n_bits = self.c_init_dynamic_huffman.v_n_bits;
+            v_n_lit = self.c_init_dynamic_huffman.v_n_lit;
+            v_n_dist = self.c_init_dynamic_huffman.v_n_dist;
+            v_n_clen = self.c_init_dynamic_huffman.v_n_clen;
+            v_i = self.c_init_dynamic_huffman.v_i;
+            v_mask = self.c_init_dynamic_huffman.v_mask;
+            v_table_entry = self.c_init_dynamic_huffman.v_table_entry;
+            v_table_entry_n_bits = self.c_init_dynamic_huffman.v_table_entry_n_bits;
+            v_n_extra_bits = self.c_init_dynamic_huffman.v_n_extra_bits;
+            v_rep_symbol = self.c_init_dynamic_huffman.v_rep_symbol;
+            v_rep_count = self.c_init_dynamic_huffman.v_rep_count;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_bits = self.f_bits;
+                    v_n_bits = self.f_n_bits;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_n_bits < 14) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_bits |= ((t_0 as u32) << v_n_bits);
+                        }
+                        if r == 0 {
+                            v_n_bits += 8;
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_n_lit = ((v_bits & u32::MAX.checked_shr(32 - (5 as u32)).unwrap_or(0)) + 257);
+                    if v_n_lit > 286 {
+                        status = ERROR_BAD_LITERAL_LENGTH_CODE_COUNT;
+                        break 'exit;
+                    }
+                    v_bits >>= 5;
+                    v_n_dist = ((v_bits & u32::MAX.checked_shr(32 - (5 as u32)).unwrap_or(0)) + 1);
+                    if v_n_dist > 30 {
+                        status = ERROR_BAD_DISTANCE_CODE_COUNT;
+                        break 'exit;
+                    }
+                    v_bits >>= 5;
+                    v_n_clen = ((v_bits & u32::MAX.checked_shr(32 - (4 as u32)).unwrap_or(0)) + 4);
+                    v_bits >>= 4;
+                    v_n_bits -= 14;
+                    v_i = 0;
+                }
+                if r == 0 || r == 2 {
+                    while r != 0 || (v_i < v_n_clen) {
+                        if r == 0 || r == 2 {
+                            while r != 0 || (v_n_bits < 3) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = a_src.read_u8(&mut t_1);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_bits |= ((t_1 as u32) << v_n_bits);
+                                }
+                                if r == 0 {
+                                    v_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            (*unsafe {
+                                self.f_code_lengths.get_unchecked_mut(
+                                    (*unsafe { CODE_ORDER.get_unchecked(v_i as usize) }) as usize,
+                                )
+                            }) = ((v_bits & 7) as u8);
+                            v_bits >>= 3;
+                            v_n_bits -= 3;
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    while v_i < 19 {
+                        (*unsafe {
+                            self.f_code_lengths.get_unchecked_mut(
+                                (*unsafe { CODE_ORDER.get_unchecked(v_i as usize) }) as usize,
+                            )
+                        }) = 0;
+                        v_i += 1;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.init_huff(0, 0, 19, 4095);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_mask =
+                        ((1u32 << (*unsafe { self.f_n_huffs_bits.get_unchecked(0 as usize) })) - 1);
+                    v_i = 0;
+                }
+                if r == 0 || (4 <= r && r <= 5) {
+                    'label_0: while r != 0 || (v_i < (v_n_lit + v_n_dist)) {
+                        if r == 0 {
+                            v_table_entry = 0;
+                        }
+                        if r == 0 || r == 4 {
+                            'label_1: loop {
+                                if r == 0 {
+                                    v_table_entry = (*unsafe {
+                                        self.f_huffs
+                                            .get_unchecked(0 as usize)
+                                            .get_unchecked((v_bits & v_mask) as usize)
+                                    });
+                                    v_table_entry_n_bits = (v_table_entry & 15);
+                                    if v_n_bits >= v_table_entry_n_bits {
+                                        v_bits >>= v_table_entry_n_bits;
+                                        v_n_bits -= v_table_entry_n_bits;
+                                        break 'label_1;
+                                    }
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = a_src.read_u8(&mut t_2);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_bits |= ((t_2 as u32) << v_n_bits);
+                                }
+                                if r == 0 {
+                                    v_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if (v_table_entry >> 24) != 128 {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                            v_table_entry = ((v_table_entry >> 8) & 255);
+                            if v_table_entry < 16 {
+                                (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) =
+                                    (v_table_entry as u8);
+                                v_i += 1;
+                                continue 'label_0;
+                            }
+                            v_n_extra_bits = 0;
+                            v_rep_symbol = 0;
+                            v_rep_count = 0;
+                            if v_table_entry == 16 {
+                                v_n_extra_bits = 2;
+                                if v_i <= 0 {
+                                    status = ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION;
+                                    break 'exit;
+                                }
+                                v_rep_symbol = (*unsafe {
+                                    self.f_code_lengths.get_unchecked((v_i - 1) as usize)
+                                });
+                                v_rep_count = 3;
+                            } else if v_table_entry == 17 {
+                                v_n_extra_bits = 3;
+                                v_rep_symbol = 0;
+                                v_rep_count = 3;
+                            } else if v_table_entry == 18 {
+                                v_n_extra_bits = 7;
+                                v_rep_symbol = 0;
+                                v_rep_count = 11;
+                            } else {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            while r != 0 || (v_n_bits < v_n_extra_bits) {
+                                if r == 0 || r == 5 {
+                                    if r == 5 {
+                                        r = 0;
+                                    }
+                                    csp = 5;
+                                    status = a_src.read_u8(&mut t_3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_bits |= ((t_3 as u32) << v_n_bits);
+                                }
+                                if r == 0 {
+                                    v_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_rep_count += (v_bits
+                                & u32::MAX
+                                    .checked_shr(32 - (v_n_extra_bits as u32))
+                                    .unwrap_or(0));
+                            v_bits >>= v_n_extra_bits;
+                            v_n_bits -= v_n_extra_bits;
+                            while v_rep_count > 0 {
+                                if v_i >= (v_n_lit + v_n_dist) {
+                                    status = ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT;
+                                    break 'exit;
+                                }
+                                (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) =
+                                    v_rep_symbol;
+                                v_i += 1;
+                                v_rep_count -= 1;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if v_i != (v_n_lit + v_n_dist) {
+                        status = ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT;
+                        break 'exit;
+                    }
+                    if (*unsafe { self.f_code_lengths.get_unchecked(256 as usize) }) == 0 {
+                        status = ERROR_MISSING_END_OF_BLOCK_CODE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = self.init_huff(0, 0, v_n_lit, 257);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = self.init_huff(1, v_n_lit, (v_n_lit + v_n_dist), 0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_bits = v_bits;
+                    self.f_n_bits = v_n_bits;
+                }
+                self.c_init_dynamic_huffman.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_init_dynamic_huffman.coro_susp_point = csp;
+            self.c_init_dynamic_huffman.v_bits = v_bits;
+            self.c_init_dynamic_huffman.v_n_bits = v_n_bits;
+            self.c_init_dynamic_huffman.v_n_lit = v_n_lit;
+            self.c_init_dynamic_huffman.v_n_dist = v_n_dist;
+            self.c_init_dynamic_huffman.v_n_clen = v_n_clen;
+            self.c_init_dynamic_huffman.v_i = v_i;
+            self.c_init_dynamic_huffman.v_mask = v_mask;
+            self.c_init_dynamic_huffman.v_table_entry = v_table_entry;
+            self.c_init_dynamic_huffman.v_table_entry_n_bits = v_table_entry_n_bits;
+            self.c_init_dynamic_huffman.v_n_extra_bits = v_n_extra_bits;
+            self.c_init_dynamic_huffman.v_rep_symbol = v_rep_symbol;
+            self.c_init_dynamic_huffman.v_rep_count = v_rep_count;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.init_huff
+
+impl Decoder {
+    fn init_huff(
+        &mut self,
+        mut a_which: u32,
+        mut a_n_codes0: u32,
+        mut a_n_codes1: u32,
+        mut a_base_symbol: u32,
+    ) -> wuffs_base::Status {
+        let mut v_counts: [u16; 16] = [0; 16];
+        let mut v_i: u32 = 0;
+        let mut v_remaining: u32 = 0;
+        let mut v_offsets: [u16; 16] = [0; 16];
+        let mut v_n_symbols: u32 = 0;
+        let mut v_count: u32 = 0;
+        let mut v_symbols: [u16; 320] = [0; 320];
+        let mut v_min_cl: u32 = 0;
+        let mut v_max_cl: u32 = 0;
+        let mut v_initial_high_bits: u32 = 0;
+        let mut v_prev_cl: u32 = 0;
+        let mut v_prev_redirect_key: u32 = 0;
+        let mut v_top: u32 = 0;
+        let mut v_next_top: u32 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_key: u32 = 0;
+        let mut v_value: u32 = 0;
+        let mut v_cl: u32 = 0;
+        let mut v_tmp: u32 = 0;
+        let mut v_redirect_key: u32 = 0;
+        let mut v_j: u32 = 0;
+        let mut v_reversed_key: u32 = 0;
+        let mut v_symbol: u32 = 0;
+        let mut v_high_bits: u32 = 0;
+        let mut v_delta: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_counts = [0; 16];
+            v_i = a_n_codes0;
+            while v_i < a_n_codes1 {
+                if (*unsafe {
+                    v_counts.get_unchecked(
+                        (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as usize,
+                    )
+                }) >= 320
+                {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                (*unsafe {
+                    v_counts.get_unchecked_mut(
+                        (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as usize,
+                    )
+                }) += 1;
+                v_i += 1;
+            }
+            if (((*unsafe { v_counts.get_unchecked(0 as usize) }) as u32) + a_n_codes0)
+                == a_n_codes1
+            {
+                status = ERROR_NO_HUFFMAN_CODES;
+                break 'exit;
+            }
+            v_remaining = 1;
+            v_i = 1;
+            while v_i <= 15 {
+                if v_remaining > 1073741824 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_remaining <<= 1;
+                if v_remaining < ((*unsafe { v_counts.get_unchecked(v_i as usize) }) as u32) {
+                    status = ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED;
+                    break 'exit;
+                }
+                v_remaining -= ((*unsafe { v_counts.get_unchecked(v_i as usize) }) as u32);
+                v_i += 1;
+            }
+            if v_remaining != 0 {
+                if (a_which != 1)
+                    || ((*unsafe { v_counts.get_unchecked(1 as usize) }) != 1)
+                    || (v_remaining != 16384)
+                {
+                    status = ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
+                    break 'exit;
+                }
+                (*unsafe {
+                    self.f_huffs
+                        .get_unchecked_mut(1 as usize)
+                        .get_unchecked_mut(1 as usize)
+                }) = 134217728;
+            }
+            v_offsets = [0; 16];
+            v_n_symbols = 0;
+            v_i = 1;
+            while v_i <= 15 {
+                (*unsafe { v_offsets.get_unchecked_mut(v_i as usize) }) = (v_n_symbols as u16);
+                v_count = ((*unsafe { v_counts.get_unchecked(v_i as usize) }) as u32);
+                if v_n_symbols > (320 - v_count) {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_n_symbols = (v_n_symbols + v_count);
+                v_i += 1;
+            }
+            if v_n_symbols > 288 {
+                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                break 'exit;
+            }
+            v_symbols = [0; 320];
+            v_i = a_n_codes0;
+            while v_i < a_n_codes1 {
+                if v_i < a_n_codes0 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                if (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) != 0 {
+                    if (*unsafe {
+                        v_offsets.get_unchecked(
+                            (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as usize,
+                        )
+                    }) >= 320
+                    {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    (*unsafe {
+                        v_symbols.get_unchecked_mut(
+                            (*unsafe {
+                                v_offsets.get_unchecked(
+                                    (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) })
+                                        as usize,
+                                )
+                            }) as usize,
+                        )
+                    }) = ((v_i - a_n_codes0) as u16);
+                    (*unsafe {
+                        v_offsets.get_unchecked_mut(
+                            (*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as usize,
+                        )
+                    }) += 1;
+                }
+                v_i += 1;
+            }
+            v_min_cl = 1;
+            'label_0: loop {
+                if (*unsafe { v_counts.get_unchecked(v_min_cl as usize) }) != 0 {
+                    break 'label_0;
+                }
+                if v_min_cl >= 9 {
+                    status = ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH;
+                    break 'exit;
+                }
+                v_min_cl += 1;
+            }
+            v_max_cl = 15;
+            'label_1: loop {
+                if (*unsafe { v_counts.get_unchecked(v_max_cl as usize) }) != 0 {
+                    break 'label_1;
+                }
+                if v_max_cl <= 1 {
+                    status = ERROR_NO_HUFFMAN_CODES;
+                    break 'exit;
+                }
+                v_max_cl -= 1;
+            }
+            if v_max_cl <= 9 {
+                (*unsafe { self.f_n_huffs_bits.get_unchecked_mut(a_which as usize) }) = v_max_cl;
+            } else {
+                (*unsafe { self.f_n_huffs_bits.get_unchecked_mut(a_which as usize) }) = 9;
+            }
+            v_i = 0;
+            if (v_n_symbols != ((*unsafe { v_offsets.get_unchecked(v_max_cl as usize) }) as u32))
+                || (v_n_symbols != ((*unsafe { v_offsets.get_unchecked(15 as usize) }) as u32))
+            {
+                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                break 'exit;
+            }
+            if (a_n_codes0 + ((*unsafe { v_symbols.get_unchecked(0 as usize) }) as u32)) >= 320 {
+                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                break 'exit;
+            }
+            v_initial_high_bits = 512;
+            if v_max_cl < 9 {
+                v_initial_high_bits = (1u32 << v_max_cl);
+            }
+            v_prev_cl = ((*unsafe {
+                self.f_code_lengths.get_unchecked(
+                    (a_n_codes0 + ((*unsafe { v_symbols.get_unchecked(0 as usize) }) as u32))
+                        as usize,
+                )
+            }) as u32);
+            v_prev_redirect_key = 4294967295;
+            v_top = 0;
+            v_next_top = 512;
+            v_code = 0;
+            v_key = 0;
+            v_value = 0;
+            'label_2: loop {
+                if (a_n_codes0 + ((*unsafe { v_symbols.get_unchecked(v_i as usize) }) as u32))
+                    >= 320
+                {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_cl = ((*unsafe {
+                    self.f_code_lengths.get_unchecked(
+                        (a_n_codes0 + ((*unsafe { v_symbols.get_unchecked(v_i as usize) }) as u32))
+                            as usize,
+                    )
+                }) as u32);
+                if v_cl > v_prev_cl {
+                    v_code <<= (v_cl - v_prev_cl);
+                    if v_code >= 32768 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                }
+                v_prev_cl = v_cl;
+                v_key = v_code;
+                if v_cl > 9 {
+                    v_tmp = (v_cl - 9);
+                    v_cl = v_tmp;
+                    v_redirect_key = ((v_key >> v_tmp) & 511);
+                    v_key = (v_key & u32::MAX.checked_shr(32 - (v_tmp as u32)).unwrap_or(0));
+                    if v_prev_redirect_key != v_redirect_key {
+                        v_prev_redirect_key = v_redirect_key;
+                        v_remaining = (1u32 << v_cl);
+                        v_j = v_prev_cl;
+                        'label_3: while v_j <= 15 {
+                            if v_remaining
+                                <= ((*unsafe { v_counts.get_unchecked(v_j as usize) }) as u32)
+                            {
+                                break 'label_3;
+                            }
+                            v_remaining -=
+                                ((*unsafe { v_counts.get_unchecked(v_j as usize) }) as u32);
+                            if v_remaining > 1073741824 {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                            v_remaining <<= 1;
+                            v_j += 1;
+                        }
+                        if (v_j <= 9) || (15 < v_j) {
+                            status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                            break 'exit;
+                        }
+                        v_tmp = (v_j - 9);
+                        v_initial_high_bits = (1u32 << v_tmp);
+                        v_top = v_next_top;
+                        if (v_top + (1u32 << v_tmp)) > 1234 {
+                            status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                            break 'exit;
+                        }
+                        v_next_top = (v_top + (1u32 << v_tmp));
+                        v_redirect_key =
+                            (((*unsafe { REVERSE8.get_unchecked((v_redirect_key >> 1) as usize) })
+                                as u32)
+                                | ((v_redirect_key & 1) << 8));
+                        (*unsafe {
+                            self.f_huffs
+                                .get_unchecked_mut(a_which as usize)
+                                .get_unchecked_mut(v_redirect_key as usize)
+                        }) = (268435465 | (v_top << 8) | (v_tmp << 4));
+                    }
+                }
+                if (v_key >= 512) || ((*unsafe { v_counts.get_unchecked(v_prev_cl as usize) }) <= 0)
+                {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                (*unsafe { v_counts.get_unchecked_mut(v_prev_cl as usize) }) -= 1;
+                v_reversed_key = (((*unsafe { REVERSE8.get_unchecked((v_key >> 1) as usize) })
+                    as u32)
+                    | ((v_key & 1) << 8));
+                v_reversed_key >>= (9 - v_cl);
+                v_symbol = ((*unsafe { v_symbols.get_unchecked(v_i as usize) }) as u32);
+                if v_symbol == 256 {
+                    v_value = (536870912 | v_cl);
+                } else if (v_symbol < 256) && (a_which == 0) {
+                    v_value = (2147483648 | (v_symbol << 8) | v_cl);
+                } else if v_symbol >= a_base_symbol {
+                    v_symbol -= a_base_symbol;
+                    if a_which == 0 {
+                        v_value = ((*unsafe {
+                            LCODE_MAGIC_NUMBERS.get_unchecked((v_symbol & 31) as usize)
+                        }) | v_cl);
+                    } else {
+                        v_value = ((*unsafe {
+                            DCODE_MAGIC_NUMBERS.get_unchecked((v_symbol & 31) as usize)
+                        }) | v_cl);
+                    }
+                } else {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_high_bits = v_initial_high_bits;
+                v_delta = (1u32 << v_cl);
+                while v_high_bits >= v_delta {
+                    v_high_bits -= v_delta;
+                    if (v_top + ((v_high_bits | v_reversed_key) & 511)) >= 1234 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    (*unsafe {
+                        self.f_huffs
+                            .get_unchecked_mut(a_which as usize)
+                            .get_unchecked_mut(
+                                (v_top + ((v_high_bits | v_reversed_key) & 511)) as usize,
+                            )
+                    }) = v_value;
+                }
+                v_i += 1;
+                if v_i >= v_n_symbols {
+                    break 'label_2;
+                }
+                v_code += 1;
+                if v_code >= 32768 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+            }
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_huffman_fast
+
+impl Decoder {
+    fn decode_huffman_fast(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_bits: u32 = 0;
+        let mut v_n_bits: u32 = 0;
+        let mut v_table_entry: u32 = 0;
+        let mut v_table_entry_n_bits: u32 = 0;
+        let mut v_lmask: u32 = 0;
+        let mut v_dmask: u32 = 0;
+        let mut v_redir_top: u32 = 0;
+        let mut v_redir_mask: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_dist_minus_1: u32 = 0;
+        let mut v_n_copied: u32 = 0;
+        let mut v_hlen: u32 = 0;
+        let mut v_hdist: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut t_5: u8 = 0;
+        let mut t_6: u8 = 0;
+        let mut t_7: u8 = 0;
+        let mut t_8: u8 = 0;
+        let mut t_9: u8 = 0;
+        let mut t_10: u8 = 0;
+        let mut t_11: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+                status = ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS;
+                break 'exit;
+            }
+            v_bits = self.f_bits;
+            v_n_bits = self.f_n_bits;
+            v_table_entry = 0;
+            v_table_entry_n_bits = 0;
+            v_lmask = ((1u32 << (*unsafe { self.f_n_huffs_bits.get_unchecked(0 as usize) })) - 1);
+            v_dmask = ((1u32 << (*unsafe { self.f_n_huffs_bits.get_unchecked(1 as usize) })) - 1);
+            'label_0: while (a_dst.available() >= 258) && (a_src.available() >= 12) {
+                if v_n_bits < 15 {
+                    t_0 = unsafe { a_src.read_u8_fast() };
+                    v_bits |= ((t_0 as u32) << v_n_bits);
+                    v_n_bits += 8;
+                    t_1 = unsafe { a_src.read_u8_fast() };
+                    v_bits |= ((t_1 as u32) << v_n_bits);
+                    v_n_bits += 8;
+                } else {
+                }
+                v_table_entry = (*unsafe {
+                    self.f_huffs
+                        .get_unchecked(0 as usize)
+                        .get_unchecked((v_bits & v_lmask) as usize)
+                });
+                v_table_entry_n_bits = (v_table_entry & 15);
+                v_bits >>= v_table_entry_n_bits;
+                v_n_bits -= v_table_entry_n_bits;
+                if (v_table_entry >> 31) != 0 {
+                    unsafe { a_dst.write_u8_fast((((v_table_entry >> 8) & 255) as u8)) };
+                    continue 'label_0;
+                } else if (v_table_entry >> 30) != 0 {
+                } else if (v_table_entry >> 29) != 0 {
+                    self.f_end_of_block = true;
+                    break 'label_0;
+                } else if (v_table_entry >> 28) != 0 {
+                    if v_n_bits < 15 {
+                        t_2 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_2 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                        t_3 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_3 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                    } else {
+                    }
+                    v_redir_top = ((v_table_entry >> 8) & 65535);
+                    v_redir_mask = ((1u32 << ((v_table_entry >> 4) & 15)) - 1);
+                    if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    v_table_entry = (*unsafe {
+                        self.f_huffs
+                            .get_unchecked(0 as usize)
+                            .get_unchecked((v_redir_top + (v_bits & v_redir_mask)) as usize)
+                    });
+                    v_table_entry_n_bits = (v_table_entry & 15);
+                    v_bits >>= v_table_entry_n_bits;
+                    v_n_bits -= v_table_entry_n_bits;
+                    if (v_table_entry >> 31) != 0 {
+                        unsafe { a_dst.write_u8_fast((((v_table_entry >> 8) & 255) as u8)) };
+                        continue 'label_0;
+                    } else if (v_table_entry >> 30) != 0 {
+                    } else if (v_table_entry >> 29) != 0 {
+                        self.f_end_of_block = true;
+                        break 'label_0;
+                    } else if (v_table_entry >> 28) != 0 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    } else if (v_table_entry >> 27) != 0 {
+                        status = ERROR_BAD_HUFFMAN_CODE;
+                        break 'exit;
+                    } else {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                } else if (v_table_entry >> 27) != 0 {
+                    status = ERROR_BAD_HUFFMAN_CODE;
+                    break 'exit;
+                } else {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_length = ((v_table_entry >> 8) & 32767);
+                v_table_entry_n_bits = ((v_table_entry >> 4) & 15);
+                if v_table_entry_n_bits > 0 {
+                    if v_n_bits < 15 {
+                        t_4 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_4 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                        t_5 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_5 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                    } else {
+                    }
+                    v_length = ((v_length
+                        + (v_bits
+                            & u32::MAX
+                                .checked_shr(32 - (v_table_entry_n_bits as u32))
+                                .unwrap_or(0)))
+                        & 32767);
+                    v_bits >>= v_table_entry_n_bits;
+                    v_n_bits -= v_table_entry_n_bits;
+                } else {
+                }
+                if v_length > 258 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                if v_n_bits < 15 {
+                    t_6 = unsafe { a_src.read_u8_fast() };
+                    v_bits |= ((t_6 as u32) << v_n_bits);
+                    v_n_bits += 8;
+                    t_7 = unsafe { a_src.read_u8_fast() };
+                    v_bits |= ((t_7 as u32) << v_n_bits);
+                    v_n_bits += 8;
+                } else {
+                }
+                v_table_entry = (*unsafe {
+                    self.f_huffs
+                        .get_unchecked(1 as usize)
+                        .get_unchecked((v_bits & v_dmask) as usize)
+                });
+                v_table_entry_n_bits = (v_table_entry & 15);
+                v_bits >>= v_table_entry_n_bits;
+                v_n_bits -= v_table_entry_n_bits;
+                if (v_table_entry >> 28) == 1 {
+                    if v_n_bits < 15 {
+                        t_8 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_8 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                        t_9 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_9 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                    } else {
+                    }
+                    v_redir_top = ((v_table_entry >> 8) & 65535);
+                    v_redir_mask = ((1u32 << ((v_table_entry >> 4) & 15)) - 1);
+                    if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    v_table_entry = (*unsafe {
+                        self.f_huffs
+                            .get_unchecked(1 as usize)
+                            .get_unchecked((v_redir_top + (v_bits & v_redir_mask)) as usize)
+                    });
+                    v_table_entry_n_bits = (v_table_entry & 15);
+                    v_bits >>= v_table_entry_n_bits;
+                    v_n_bits -= v_table_entry_n_bits;
+                } else {
+                }
+                if (v_table_entry >> 24) != 64 {
+                    if (v_table_entry >> 24) == 8 {
+                        status = ERROR_BAD_HUFFMAN_CODE;
+                        break 'exit;
+                    }
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_dist_minus_1 = ((v_table_entry >> 8) & 32767);
+                v_table_entry_n_bits = ((v_table_entry >> 4) & 15);
+                if v_table_entry_n_bits > 0 {
+                    if v_n_bits < 15 {
+                        t_10 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_10 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                        t_11 = unsafe { a_src.read_u8_fast() };
+                        v_bits |= ((t_11 as u32) << v_n_bits);
+                        v_n_bits += 8;
+                    }
+                    v_dist_minus_1 = ((v_dist_minus_1
+                        + (v_bits
+                            & u32::MAX
+                                .checked_shr(32 - (v_table_entry_n_bits as u32))
+                                .unwrap_or(0)))
+                        & 32767);
+                    v_bits >>= v_table_entry_n_bits;
+                    v_n_bits -= v_table_entry_n_bits;
+                }
+                v_n_copied = 0;
+                'label_1: loop {
+                    if ((v_dist_minus_1 + 1) as u64) > (a_dst.since_mark().len() as u64) {
+                        v_hlen = 0;
+                        v_hdist = ((((v_dist_minus_1 + 1) as u64)
+                            - (a_dst.since_mark().len() as u64))
+                            as u32);
+                        if v_length > v_hdist {
+                            v_length -= v_hdist;
+                            v_hlen = v_hdist;
+                            if v_length > 258 {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                        } else {
+                            v_hlen = v_length;
+                            v_length = 0;
+                        }
+                        if self.f_history_index < v_hdist {
+                            status = ERROR_BAD_DISTANCE;
+                            break 'exit;
+                        }
+                        v_hdist = (self.f_history_index - v_hdist);
+                        'label_2: loop {
+                            v_n_copied = a_dst.copy_from_slice32(
+                                unsafe {
+                                    wuffs_base::SliceU8::from_array(&mut self.f_history)
+                                        .subslice_i((v_hdist & 32767) as usize)
+                                },
+                                v_hlen,
+                            );
+                            if v_hlen <= v_n_copied {
+                                break 'label_2;
+                            }
+                            v_hlen -= v_n_copied;
+                            a_dst.copy_from_slice32(
+                                unsafe { wuffs_base::SliceU8::from_array(&mut self.f_history) },
+                                v_hlen,
+                            );
+                            break 'label_2;
+                        }
+                        if v_length == 0 {
+                            continue 'label_0;
+                        }
+                        if ((v_dist_minus_1 + 1) as u64) > (a_dst.since_mark().len() as u64) {
+                            status = ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE;
+                            break 'exit;
+                        }
+                    }
+                    if (v_length as u64) > a_dst.available() {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE;
+                        break 'exit;
+                    }
+                    unsafe { a_dst.copy_from_history32_fast((v_dist_minus_1 + 1), v_length) };
+                    break 'label_1;
+                }
+            }
+            while v_n_bits >= 8 {
+                v_n_bits -= 8;
+                status = a_src.unread_u8();
+                if !status.is_ok() {
+                    break 'exit;
+                }
+            }
+            self.f_bits = (v_bits & ((1u32 << v_n_bits) - 1));
+            self.f_n_bits = v_n_bits;
+            if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+                status = ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS;
+                break 'exit;
+            }
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_huffman_slow
+
+impl Decoder {
+    fn decode_huffman_slow(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_bits: u32 = 0;
+        let mut v_n_bits: u32 = 0;
+        let mut v_table_entry: u32 = 0;
+        let mut v_table_entry_n_bits: u32 = 0;
+        let mut v_lmask: u32 = 0;
+        let mut v_dmask: u32 = 0;
+        let mut v_redir_top: u32 = 0;
+        let mut v_redir_mask: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_dist_minus_1: u32 = 0;
+        let mut v_n_copied: u32 = 0;
+        let mut v_hlen: u32 = 0;
+        let mut v_hdist: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut t_5: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_huffman_slow.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_bits = self.c_decode_huffman_slow.v_bits;
+            v_n_bits = self.c_decode_huffman_slow.v_n_bits;
+            v_table_entry = self.c_decode_huffman_slow.v_table_entry;
+            v_table_entry_n_bits = self.c_decode_huffman_slow.v_table_entry_n_bits;
+            v_lmask = self.c_decode_huffman_slow.v_lmask;
+            v_dmask = self.c_decode_huffman_slow.v_dmask;
+            v_redir_top = self.c_decode_huffman_slow.v_redir_top;
+            v_redir_mask = self.c_decode_huffman_slow.v_redir_mask;
+            v_length = self.c_decode_huffman_slow.v_length;
+            v_dist_minus_1 = self.c_decode_huffman_slow.v_dist_minus_1;
+            v_n_copied = self.c_decode_huffman_slow.v_n_copied;
+            v_hlen = self.c_decode_huffman_slow.v_hlen;
+            v_hdist = self.c_decode_huffman_slow.v_hdist;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS;
+                        break 'exit;
+                    }
+                    v_bits = self.f_bits;
+                    v_n_bits = self.f_n_bits;
+                    v_table_entry = 0;
+                    v_table_entry_n_bits = 0;
+                    v_lmask =
+                        ((1u32 << (*unsafe { self.f_n_huffs_bits.get_unchecked(0 as usize) })) - 1);
+                    v_dmask =
+                        ((1u32 << (*unsafe { self.f_n_huffs_bits.get_unchecked(1 as usize) })) - 1);
+                }
+                if r == 0 || (1 <= r && r <= 11) {
+                    'label_0: loop {
+                        if r == 0 || r == 1 {
+                            'label_1: loop {
+                                if r == 0 {
+                                    v_table_entry = (*unsafe {
+                                        self.f_huffs
+                                            .get_unchecked(0 as usize)
+                                            .get_unchecked((v_bits & v_lmask) as usize)
+                                    });
+                                    v_table_entry_n_bits = (v_table_entry & 15);
+                                    if v_n_bits >= v_table_entry_n_bits {
+                                        v_bits >>= v_table_entry_n_bits;
+                                        v_n_bits -= v_table_entry_n_bits;
+                                        break 'label_1;
+                                    }
+                                }
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_bits |= ((t_0 as u32) << v_n_bits);
+                                }
+                                if r == 0 {
+                                    v_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 || (2 <= r && r <= 4) {
+                            if r == 2 || (r == 0 && ((v_table_entry >> 31) != 0)) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = a_dst.write_u8((((v_table_entry >> 8) & 255) as u8));
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    continue 'label_0;
+                                }
+                            } else if r == 0 && ((v_table_entry >> 30) != 0) {
+                            } else if r == 0 && ((v_table_entry >> 29) != 0) {
+                                self.f_end_of_block = true;
+                                break 'label_0;
+                            } else if (3 <= r && r <= 4) || (r == 0 && ((v_table_entry >> 28) != 0))
+                            {
+                                if r == 0 {
+                                    v_redir_top = ((v_table_entry >> 8) & 65535);
+                                    v_redir_mask = ((1u32 << ((v_table_entry >> 4) & 15)) - 1);
+                                }
+                                if r == 0 || r == 3 {
+                                    'label_2: loop {
+                                        if r == 0 {
+                                            if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+                                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                                break 'exit;
+                                            }
+                                            v_table_entry = (*unsafe {
+                                                self.f_huffs
+                                                    .get_unchecked(0 as usize)
+                                                    .get_unchecked(
+                                                        (v_redir_top + (v_bits & v_redir_mask))
+                                                            as usize,
+                                                    )
+                                            });
+                                            v_table_entry_n_bits = (v_table_entry & 15);
+                                            if v_n_bits >= v_table_entry_n_bits {
+                                                v_bits >>= v_table_entry_n_bits;
+                                                v_n_bits -= v_table_entry_n_bits;
+                                                break 'label_2;
+                                            }
+                                        }
+                                        if r == 0 || r == 3 {
+                                            if r == 3 {
+                                                r = 0;
+                                            }
+                                            csp = 3;
+                                            status = a_src.read_u8(&mut t_1);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_bits |= ((t_1 as u32) << v_n_bits);
+                                        }
+                                        if r == 0 {
+                                            v_n_bits += 8;
+                                        }
+                                    }
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 || (r == 0 && ((v_table_entry >> 31) != 0)) {
+                                        if r == 0 || r == 4 {
+                                            if r == 4 {
+                                                r = 0;
+                                            }
+                                            csp = 4;
+                                            status = a_dst
+                                                .write_u8((((v_table_entry >> 8) & 255) as u8));
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                        if r == 0 {
+                                            continue 'label_0;
+                                        }
+                                    } else if r == 0 && ((v_table_entry >> 30) != 0) {
+                                    } else if r == 0 && ((v_table_entry >> 29) != 0) {
+                                        self.f_end_of_block = true;
+                                        break 'label_0;
+                                    } else if r == 0 && ((v_table_entry >> 28) != 0) {
+                                        status =
+                                            ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                        break 'exit;
+                                    } else if r == 0 && ((v_table_entry >> 27) != 0) {
+                                        status = ERROR_BAD_HUFFMAN_CODE;
+                                        break 'exit;
+                                    } else {
+                                        status =
+                                            ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                        break 'exit;
+                                    }
+                                }
+                            } else if r == 0 && ((v_table_entry >> 27) != 0) {
+                                status = ERROR_BAD_HUFFMAN_CODE;
+                                break 'exit;
+                            } else {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 {
+                            v_length = ((v_table_entry >> 8) & 32767);
+                            v_table_entry_n_bits = ((v_table_entry >> 4) & 15);
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 || (r == 0 && (v_table_entry_n_bits > 0)) {
+                                if r == 0 || r == 5 {
+                                    while r != 0 || (v_n_bits < v_table_entry_n_bits) {
+                                        if r == 0 || r == 5 {
+                                            if r == 5 {
+                                                r = 0;
+                                            }
+                                            csp = 5;
+                                            status = a_src.read_u8(&mut t_2);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_bits |= ((t_2 as u32) << v_n_bits);
+                                        }
+                                        if r == 0 {
+                                            v_n_bits += 8;
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_length = ((v_length
+                                        + (v_bits
+                                            & u32::MAX
+                                                .checked_shr(32 - (v_table_entry_n_bits as u32))
+                                                .unwrap_or(0)))
+                                        & 32767);
+                                    v_bits >>= v_table_entry_n_bits;
+                                    v_n_bits -= v_table_entry_n_bits;
+                                }
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            'label_3: loop {
+                                if r == 0 {
+                                    v_table_entry = (*unsafe {
+                                        self.f_huffs
+                                            .get_unchecked(1 as usize)
+                                            .get_unchecked((v_bits & v_dmask) as usize)
+                                    });
+                                    v_table_entry_n_bits = (v_table_entry & 15);
+                                    if v_n_bits >= v_table_entry_n_bits {
+                                        v_bits >>= v_table_entry_n_bits;
+                                        v_n_bits -= v_table_entry_n_bits;
+                                        break 'label_3;
+                                    }
+                                }
+                                if r == 0 || r == 6 {
+                                    if r == 6 {
+                                        r = 0;
+                                    }
+                                    csp = 6;
+                                    status = a_src.read_u8(&mut t_3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_bits |= ((t_3 as u32) << v_n_bits);
+                                }
+                                if r == 0 {
+                                    v_n_bits += 8;
+                                }
+                            }
+                        }
+                        if r == 0 || r == 7 {
+                            if r == 7 || (r == 0 && ((v_table_entry >> 28) == 1)) {
+                                if r == 0 {
+                                    v_redir_top = ((v_table_entry >> 8) & 65535);
+                                    v_redir_mask = ((1u32 << ((v_table_entry >> 4) & 15)) - 1);
+                                }
+                                if r == 0 || r == 7 {
+                                    'label_4: loop {
+                                        if r == 0 {
+                                            if (v_redir_top + (v_bits & v_redir_mask)) >= 1234 {
+                                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                                break 'exit;
+                                            }
+                                            v_table_entry = (*unsafe {
+                                                self.f_huffs
+                                                    .get_unchecked(1 as usize)
+                                                    .get_unchecked(
+                                                        (v_redir_top + (v_bits & v_redir_mask))
+                                                            as usize,
+                                                    )
+                                            });
+                                            v_table_entry_n_bits = (v_table_entry & 15);
+                                            if v_n_bits >= v_table_entry_n_bits {
+                                                v_bits >>= v_table_entry_n_bits;
+                                                v_n_bits -= v_table_entry_n_bits;
+                                                break 'label_4;
+                                            }
+                                        }
+                                        if r == 0 || r == 7 {
+                                            if r == 7 {
+                                                r = 0;
+                                            }
+                                            csp = 7;
+                                            status = a_src.read_u8(&mut t_4);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_bits |= ((t_4 as u32) << v_n_bits);
+                                        }
+                                        if r == 0 {
+                                            v_n_bits += 8;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if (v_table_entry >> 24) != 64 {
+                                if (v_table_entry >> 24) == 8 {
+                                    status = ERROR_BAD_HUFFMAN_CODE;
+                                    break 'exit;
+                                }
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                            v_dist_minus_1 = ((v_table_entry >> 8) & 32767);
+                            v_table_entry_n_bits = ((v_table_entry >> 4) & 15);
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 8 || (r == 0 && (v_table_entry_n_bits > 0)) {
+                                if r == 0 || r == 8 {
+                                    while r != 0 || (v_n_bits < v_table_entry_n_bits) {
+                                        if r == 0 || r == 8 {
+                                            if r == 8 {
+                                                r = 0;
+                                            }
+                                            csp = 8;
+                                            status = a_src.read_u8(&mut t_5);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_bits |= ((t_5 as u32) << v_n_bits);
+                                        }
+                                        if r == 0 {
+                                            v_n_bits += 8;
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_dist_minus_1 = ((v_dist_minus_1
+                                        + (v_bits
+                                            & u32::MAX
+                                                .checked_shr(32 - (v_table_entry_n_bits as u32))
+                                                .unwrap_or(0)))
+                                        & 32767);
+                                    v_bits >>= v_table_entry_n_bits;
+                                    v_n_bits -= v_table_entry_n_bits;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_n_copied = 0;
+                        }
+                        if r == 0 || (9 <= r && r <= 11) {
+                            'label_5: loop {
+                                if r == 0 || (9 <= r && r <= 10) {
+                                    if (9 <= r && r <= 10)
+                                        || (r == 0
+                                            && (((v_dist_minus_1 + 1) as u64)
+                                                > (a_dst.since_mark().len() as u64)))
+                                    {
+                                        if r == 0 {
+                                            v_hlen = 0;
+                                            v_hdist = ((((v_dist_minus_1 + 1) as u64)
+                                                - (a_dst.since_mark().len() as u64))
+                                                as u32);
+                                            if v_length > v_hdist {
+                                                v_length -= v_hdist;
+                                                v_hlen = v_hdist;
+                                            } else {
+                                                v_hlen = v_length;
+                                                v_length = 0;
+                                            }
+                                            if self.f_history_index < v_hdist {
+                                                status = ERROR_BAD_DISTANCE;
+                                                break 'exit;
+                                            }
+                                            v_hdist = (self.f_history_index - v_hdist);
+                                        }
+                                        if r == 0 || r == 9 {
+                                            'label_6: loop {
+                                                if r == 0 {
+                                                    v_n_copied = a_dst.copy_from_slice32(
+                                                        unsafe {
+                                                            wuffs_base::SliceU8::from_array(
+                                                                &mut self.f_history,
+                                                            )
+                                                            .subslice_i((v_hdist & 32767) as usize)
+                                                        },
+                                                        v_hlen,
+                                                    );
+                                                    if v_hlen <= v_n_copied {
+                                                        v_hlen = 0;
+                                                        break 'label_6;
+                                                    }
+                                                    if v_n_copied > 0 {
+                                                        v_hlen -= v_n_copied;
+                                                        v_hdist = (u32::wrapping_add(
+                                                            v_hdist, v_n_copied,
+                                                        ) & 32767);
+                                                        if v_hdist == 0 {
+                                                            break 'label_6;
+                                                        }
+                                                    }
+                                                }
+                                                if r == 0 || r == 9 {
+                                                    status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                                    if r == 9 {
+                                                        r = 0;
+                                                        status = wuffs_base::Status::Ok;
+                                                    } else {
+                                                        csp = 9;
+                                                        break 'suspend;
+                                                    }
+                                                }
+                                            }
+                                        }
+                                        if r == 0 || r == 10 {
+                                            if r == 10 || (r == 0 && (v_hlen > 0)) {
+                                                if r == 0 || r == 10 {
+                                                    'label_7: loop {
+                                                        if r == 0 {
+                                                            v_n_copied = a_dst.copy_from_slice32(
+                                                                unsafe {
+                                                                    wuffs_base::SliceU8::from_array(
+                                                                        &mut self.f_history,
+                                                                    )
+                                                                    .subslice_i(
+                                                                        (v_hdist & 32767) as usize,
+                                                                    )
+                                                                },
+                                                                v_hlen,
+                                                            );
+                                                            if v_hlen <= v_n_copied {
+                                                                v_hlen = 0;
+                                                                break 'label_7;
+                                                            }
+                                                            v_hlen -= v_n_copied;
+                                                            v_hdist = u32::wrapping_add(
+                                                                v_hdist, v_n_copied,
+                                                            );
+                                                        }
+                                                        if r == 0 || r == 10 {
+                                                            status =
+                                                                wuffs_base::SUSPENSION_SHORT_WRITE;
+                                                            if r == 10 {
+                                                                r = 0;
+                                                                status = wuffs_base::Status::Ok;
+                                                            } else {
+                                                                csp = 10;
+                                                                break 'suspend;
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if v_length == 0 {
+                                                continue 'label_0;
+                                            }
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_n_copied =
+                                        a_dst.copy_from_history32((v_dist_minus_1 + 1), v_length);
+                                    if v_length <= v_n_copied {
+                                        v_length = 0;
+                                        break 'label_5;
+                                    }
+                                    v_length -= v_n_copied;
+                                }
+                                if r == 0 || r == 11 {
+                                    status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                    if r == 11 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 11;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_bits = v_bits;
+                    self.f_n_bits = v_n_bits;
+                    if (self.f_n_bits >= 8) || ((self.f_bits >> self.f_n_bits) != 0) {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS;
+                        break 'exit;
+                    }
+                }
+                self.c_decode_huffman_slow.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_huffman_slow.coro_susp_point = csp;
+            self.c_decode_huffman_slow.v_bits = v_bits;
+            self.c_decode_huffman_slow.v_n_bits = v_n_bits;
+            self.c_decode_huffman_slow.v_table_entry = v_table_entry;
+            self.c_decode_huffman_slow.v_table_entry_n_bits = v_table_entry_n_bits;
+            self.c_decode_huffman_slow.v_lmask = v_lmask;
+            self.c_decode_huffman_slow.v_dmask = v_dmask;
+            self.c_decode_huffman_slow.v_redir_top = v_redir_top;
+            self.c_decode_huffman_slow.v_redir_mask = v_redir_mask;
+            self.c_decode_huffman_slow.v_length = v_length;
+            self.c_decode_huffman_slow.v_dist_minus_1 = v_dist_minus_1;
+            self.c_decode_huffman_slow.v_n_copied = v_n_copied;
+            self.c_decode_huffman_slow.v_hlen = v_hlen;
+            self.c_decode_huffman_slow.v_hdist = v_hdist;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.set_level
+
+impl Encoder {
+    pub fn set_level(&mut self, mut a_level: u32) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_level = u32::min(a_level, 9);
+        self.f_level_set = true;
+    }
+}
+
+// -------- func encoder.encode
+
+impl Encoder {
+    pub fn encode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_z = self.c_encode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        if !self.f_level_set {
+                            self.f_level_set = true;
+                            self.f_level = 6;
+                        }
+                    }
+                    if r == 0 || (1 <= r && r <= 5) {
+                        'label_0: loop {
+                            if r == 0 || r == 1 {
+                                if r == 1 || (r == 0 && !self.f_eof) {
+                                    if r == 0 {
+                                        t_0 = self.fill_window(a_src);
+                                        v_z = t_0;
+                                    }
+                                    if r == 0 || r == 1 {
+                                        if r == 0 && v_z.is_error() {
+                                            self.f_eof = true;
+                                        } else if r == 1 || (r == 0 && v_z.is_suspension()) {
+                                            if r == 0 || r == 1 {
+                                                status = v_z;
+                                                if r == 1 {
+                                                    r = 0;
+                                                    status = wuffs_base::Status::Ok;
+                                                } else {
+                                                    if status.is_error() {
+                                                        break 'exit;
+                                                    }
+                                                    if !status.is_ok() {
+                                                        csp = 1;
+                                                        break 'suspend;
+                                                    }
+                                                }
+                                            }
+                                            if r == 0 {
+                                                continue 'label_0;
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                self.tokenize();
+                            }
+                            if r == 0 || (2 <= r && r <= 3) {
+                                if (2 <= r && r <= 3)
+                                    || (r == 0 && (self.f_eof && (self.f_w_pos >= self.f_w_end)))
+                                {
+                                    if r == 0 || r == 2 {
+                                        if r == 2 {
+                                            r = 0;
+                                        }
+                                        csp = 2;
+                                        status = self.write_block(a_dst, 1);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 || r == 3 {
+                                        if r == 3 || (r == 0 && (self.f_n_bits > 0)) {
+                                            if r == 0 || r == 3 {
+                                                if r == 3 {
+                                                    r = 0;
+                                                }
+                                                csp = 3;
+                                                status =
+                                                    a_dst.write_u8(((self.f_bits & 255) as u8));
+                                                if !status.is_ok() {
+                                                    break 'suspend;
+                                                }
+                                            }
+                                            if r == 0 {
+                                                self.f_bits = 0;
+                                                self.f_n_bits = 0;
+                                            }
+                                        }
+                                    }
+                                    if r == 0 {
+                                        status = wuffs_base::Status::Ok;
+                                        break 'ok;
+                                    }
+                                }
+                            }
+                            if r == 0 || (4 <= r && r <= 5) {
+                                if r == 4 || (r == 0 && (self.f_n_tokens < 16383)) {
+                                    if r == 0 || r == 4 {
+                                        if r == 4 || (r == 0 && (self.f_n_tokens > 0)) {
+                                            if r == 0 || r == 4 {
+                                                if r == 4 {
+                                                    r = 0;
+                                                }
+                                                csp = 4;
+                                                status = self.write_block(a_dst, 0);
+                                                if !status.is_ok() {
+                                                    break 'suspend;
+                                                }
+                                            }
+                                        }
+                                    }
+                                    if r == 0 {
+                                        self.slide();
+                                    }
+                                } else {
+                                    if r == 0 || r == 5 {
+                                        if r == 5 {
+                                            r = 0;
+                                        }
+                                        csp = 5;
+                                        status = self.write_block(a_dst, 0);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_encode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode.coro_susp_point = csp;
+            self.c_encode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.fill_window
+
+impl Encoder {
+    fn fill_window(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_window.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_fill_window.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    'label_0: while r != 0 || (self.f_w_end < 65536) {
+                        if r == 0 {
+                            if a_src.available() > 0 {
+                                v_w = wuffs_base::IoWriter::default();
+                                {
+                                    let o_0_a_src = a_src;
+                                    let o_0_v_w = v_w;
+                                    unsafe {
+                                        v_w.set(&mut u_w, unsafe {
+                                            wuffs_base::SliceU8::from_array(&mut self.f_window)
+                                                .subslice_i(self.f_w_end as usize)
+                                        })
+                                    };
+                                    v_w.copy_from_reader32(&mut a_src, 65536);
+                                    self.f_w_end =
+                                        (65536 - (u64::min(v_w.available(), 65536) as u32));
+                                    v_w = o_0_v_w;
+                                    a_src = o_0_a_src;
+                                }
+                                continue 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_c = t_0;
+                        }
+                        if r == 0 {
+                            if self.f_w_end < 65536 {
+                                (*unsafe {
+                                    self.f_window.get_unchecked_mut(self.f_w_end as usize)
+                                }) = v_c;
+                                self.f_w_end += 1;
+                            }
+                        }
+                    }
+                }
+                self.c_fill_window.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_window.coro_susp_point = csp;
+            self.c_fill_window.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.slide
+
+impl Encoder {
+    fn slide(&mut self) {
+        let mut v_i: u32 = 0;
+
+        v_i = 0;
+        while v_i < 32768 {
+            (*unsafe { self.f_window.get_unchecked_mut(v_i as usize) }) =
+                (*unsafe { self.f_window.get_unchecked((v_i + 32768) as usize) });
+            (*unsafe { self.f_head.get_unchecked_mut(v_i as usize) }) =
+                u16::saturating_sub((*unsafe { self.f_head.get_unchecked(v_i as usize) }), 32768);
+            (*unsafe { self.f_prev.get_unchecked_mut(v_i as usize) }) =
+                u16::saturating_sub((*unsafe { self.f_prev.get_unchecked(v_i as usize) }), 32768);
+            v_i += 1;
+        }
+        self.f_w_pos = u32::saturating_sub(self.f_w_pos, 32768);
+        self.f_w_end = u32::saturating_sub(self.f_w_end, 32768);
+        self.f_block_start = u32::saturating_sub(self.f_block_start, 32768);
+        self.f_ins_pos = u32::saturating_sub(self.f_ins_pos, 32768);
+    }
+}
+
+// -------- func encoder.tokenize
+
+impl Encoder {
+    fn tokenize(&mut self) {
+        let mut v_limit: u32 = 0;
+        let mut v_lazy_length: u32 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_m: u32 = 0;
+        let mut v_m_length: u32 = 0;
+        let mut v_m2: u32 = 0;
+        let mut v_q: u32 = 0;
+
+        v_limit = self.f_w_end;
+        if !self.f_eof {
+            v_limit = u32::saturating_sub(self.f_w_end, 262);
+        }
+        v_lazy_length = (*unsafe { LEVEL_LAZY_LENGTHS.get_unchecked(self.f_level as usize) });
+        v_p = 0;
+        v_m = 0;
+        v_m_length = 0;
+        'label_0: while (self.f_w_pos < v_limit) && (self.f_n_tokens < 16383) {
+            if self.f_w_pos >= 65536 {
+                return;
+            }
+            v_p = self.f_w_pos;
+            if self.f_level == 0 {
+                self.add_token(((*unsafe { self.f_window.get_unchecked(v_p as usize) }) as u32));
+                self.f_w_pos = (v_p + 1);
+                continue 'label_0;
+            }
+            v_m = self.find_match(v_p);
+            v_m_length = (v_m & 511);
+            'label_1: while (v_m_length >= 3)
+                && (v_m_length < v_lazy_length)
+                && (self.f_n_tokens < 16382)
+            {
+                if (v_p + 1) >= v_limit {
+                    break 'label_1;
+                }
+                v_m2 = self.find_match((v_p + 1));
+                if (v_m2 & 511) <= v_m_length {
+                    break 'label_1;
+                }
+                self.add_token(((*unsafe { self.f_window.get_unchecked(v_p as usize) }) as u32));
+                v_p = (v_p + 1);
+                v_m = v_m2;
+                v_m_length = (v_m & 511);
+            }
+            if v_m_length >= 3 {
+                self.add_token(v_m);
+                v_q = (v_p + v_m_length);
+                self.f_w_pos = u32::min(v_q, self.f_w_end);
+            } else {
+                self.add_token(((*unsafe { self.f_window.get_unchecked(v_p as usize) }) as u32));
+                self.f_w_pos = (v_p + 1);
+            }
+        }
+    }
+}
+
+// -------- func encoder.add_token
+
+impl Encoder {
+    fn add_token(&mut self, mut a_t: u32) {
+        if self.f_n_tokens < 16384 {
+            (*unsafe { self.f_tokens.get_unchecked_mut(self.f_n_tokens as usize) }) = a_t;
+            self.f_n_tokens += 1;
+        }
+    }
+}
+
+// -------- func encoder.insert
+
+impl Encoder {
+    fn insert(&mut self, mut a_up_to: u32) {
+        let mut v_h: u32 = 0;
+
+        while self.f_ins_pos < a_up_to {
+            if (self.f_ins_pos + 2) >= self.f_w_end {
+                self.f_ins_pos = a_up_to;
+                return;
+            }
+            v_h = (((((*unsafe {
+                self.f_window
+                    .get_unchecked((self.f_ins_pos & 65535) as usize)
+            }) as u32)
+                << 10)
+                ^ (((*unsafe {
+                    self.f_window
+                        .get_unchecked(((self.f_ins_pos + 1) & 65535) as usize)
+                }) as u32)
+                    << 5)
+                ^ ((*unsafe {
+                    self.f_window
+                        .get_unchecked(((self.f_ins_pos + 2) & 65535) as usize)
+                }) as u32))
+                & 32767);
+            (*unsafe {
+                self.f_prev
+                    .get_unchecked_mut((self.f_ins_pos & 32767) as usize)
+            }) = (*unsafe { self.f_head.get_unchecked(v_h as usize) });
+            (*unsafe { self.f_head.get_unchecked_mut(v_h as usize) }) = (self.f_ins_pos as u16);
+            self.f_ins_pos += 1;
+        }
+    }
+}
+
+// -------- func encoder.find_match
+
+impl Encoder {
+    fn find_match(&mut self, mut a_p: u32) -> u32 {
+        let mut v_remaining: u32 = 0;
+        let mut v_max_length: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_candidate: u32 = 0;
+        let mut v_chain: u32 = 0;
+        let mut v_nice_length: u32 = 0;
+        let mut v_best_length: u32 = 0;
+        let mut v_best_distance: u32 = 0;
+        let mut v_distance: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_next: u32 = 0;
+
+        self.insert(a_p);
+        v_remaining = u32::saturating_sub(self.f_w_end, a_p);
+        if v_remaining < 3 {
+            self.insert((a_p + 1));
+            return 0;
+        }
+        v_max_length = u32::min(v_remaining, 258);
+        v_h = (((((*unsafe { self.f_window.get_unchecked(a_p as usize) }) as u32) << 10)
+            ^ (((*unsafe { self.f_window.get_unchecked(((a_p + 1) & 65535) as usize) }) as u32)
+                << 5)
+            ^ ((*unsafe { self.f_window.get_unchecked(((a_p + 2) & 65535) as usize) }) as u32))
+            & 32767);
+        v_candidate = ((*unsafe { self.f_head.get_unchecked(v_h as usize) }) as u32);
+        v_chain = (*unsafe { LEVEL_MAX_CHAINS.get_unchecked(self.f_level as usize) });
+        v_nice_length = (*unsafe { LEVEL_NICE_LENGTHS.get_unchecked(self.f_level as usize) });
+        v_best_length = 2;
+        v_best_distance = 0;
+        v_distance = 0;
+        v_n = 0;
+        v_next = 0;
+        'label_0: while (v_candidate > 0) && (v_chain > 0) {
+            if a_p <= v_candidate {
+                break 'label_0;
+            } else if (a_p - v_candidate) > 32768 {
+                break 'label_0;
+            }
+            v_distance = (a_p - v_candidate);
+            if (*unsafe {
+                self.f_window
+                    .get_unchecked(((v_candidate + v_best_length) & 65535) as usize)
+            }) == (*unsafe {
+                self.f_window
+                    .get_unchecked(((a_p + v_best_length) & 65535) as usize)
+            }) {
+                v_n = 0;
+                'label_1: while v_n < v_max_length {
+                    if (*unsafe {
+                        self.f_window
+                            .get_unchecked(((v_candidate + v_n) & 65535) as usize)
+                    }) != (*unsafe {
+                        self.f_window.get_unchecked(((a_p + v_n) & 65535) as usize)
+                    }) {
+                        break 'label_1;
+                    }
+                    v_n += 1;
+                }
+                if v_n > v_best_length {
+                    v_best_length = v_n;
+                    v_best_distance = v_distance;
+                    if (v_n >= v_nice_length) || (v_n >= v_max_length) {
+                        break 'label_0;
+                    }
+                }
+            }
+            v_next =
+                ((*unsafe { self.f_prev.get_unchecked((v_candidate & 32767) as usize) }) as u32);
+            if v_next >= v_candidate {
+                break 'label_0;
+            }
+            v_candidate = v_next;
+            v_chain = u32::saturating_sub(v_chain, 1);
+        }
+        self.insert((a_p + 1));
+        if (v_best_length < 3) || ((v_best_length == 3) && (v_best_distance > 4096)) {
+            return 0;
+        }
+        return ((v_best_distance << 16) | v_best_length);
+    }
+}
+
+// -------- func encoder.write_block
+
+impl Encoder {
+    fn write_block(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_final: u32,
+    ) -> wuffs_base::Status {
+        let mut v_stored: bool = false;
+        let mut v_fixed: bool = false;
+        let mut v_n_extra_bits: u64 = 0;
+        let mut v_fixed_cost: u64 = 0;
+        let mut v_dynamic_cost: u64 = 0;
+        let mut v_n_bytes: u64 = 0;
+        let mut v_stored_cost: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_block.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_stored = self.c_write_block.v_stored;
+            v_fixed = self.c_write_block.v_fixed;
+            v_n_extra_bits = self.c_write_block.v_n_extra_bits;
+            v_fixed_cost = self.c_write_block.v_fixed_cost;
+            v_dynamic_cost = self.c_write_block.v_dynamic_cost;
+            v_n_bytes = self.c_write_block.v_n_bytes;
+            v_stored_cost = self.c_write_block.v_stored_cost;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_stored = (self.f_level == 0);
+                    v_fixed = false;
+                    if !v_stored {
+                        v_n_extra_bits = self.count_frequencies();
+                        v_fixed_cost = u64::wrapping_add(self.fixed_cost(), v_n_extra_bits);
+                        v_dynamic_cost =
+                            u64::wrapping_add(self.build_dynamic_huffman(), v_n_extra_bits);
+                        v_n_bytes = (u32::saturating_sub(self.f_w_pos, self.f_block_start) as u64);
+                        v_stored_cost = (8 * (v_n_bytes + 5 + (5 * (v_n_bytes / 65535))));
+                        v_stored =
+                            ((v_stored_cost <= v_fixed_cost) && (v_stored_cost <= v_dynamic_cost));
+                        v_fixed = (v_fixed_cost <= v_dynamic_cost);
+                    }
+                }
+                if r == 0 || (1 <= r && r <= 5) {
+                    if r == 1 || (r == 0 && v_stored) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.write_stored(a_dst, a_final);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || (2 <= r && r <= 4) {
+                            if r == 2 || (r == 0 && v_fixed) {
+                                if r == 0 {
+                                    self.init_fixed_huffman();
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.write_bits(a_dst, (a_final | 2), 3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.write_bits(a_dst, (a_final | 4), 3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.write_dynamic_header(a_dst);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.write_tokens(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_n_tokens = 0;
+                    self.f_block_start = self.f_w_pos;
+                }
+                self.c_write_block.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_block.coro_susp_point = csp;
+            self.c_write_block.v_stored = v_stored;
+            self.c_write_block.v_fixed = v_fixed;
+            self.c_write_block.v_n_extra_bits = v_n_extra_bits;
+            self.c_write_block.v_fixed_cost = v_fixed_cost;
+            self.c_write_block.v_dynamic_cost = v_dynamic_cost;
+            self.c_write_block.v_n_bytes = v_n_bytes;
+            self.c_write_block.v_stored_cost = v_stored_cost;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.write_stored
+
+impl Encoder {
+    fn write_stored(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_final: u32,
+    ) -> wuffs_base::Status {
+        let mut v_start: u32 = 0;
+        let mut v_end: u32 = 0;
+        let mut v_remaining: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_n_copied: u32 = 0;
+        let mut v_new_start: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_stored.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_start = self.c_write_stored.v_start;
+            v_end = self.c_write_stored.v_end;
+            v_remaining = self.c_write_stored.v_remaining;
+            v_length = self.c_write_stored.v_length;
+            v_n_copied = self.c_write_stored.v_n_copied;
+            v_new_start = self.c_write_stored.v_new_start;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_start = self.f_block_start;
+                    v_end = self.f_w_pos;
+                    v_remaining = 0;
+                    v_length = 0;
+                    v_n_copied = 0;
+                    v_new_start = 0;
+                }
+                if r == 0 || (1 <= r && r <= 6) {
+                    'label_0: loop {
+                        if r == 0 {
+                            v_remaining = u32::saturating_sub(v_end, v_start);
+                            v_length = u32::min(v_remaining, 65535);
+                        }
+                        if r == 0 || (1 <= r && r <= 2) {
+                            if r == 1 || (r == 0 && ((v_start + v_length) < v_end)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = self.write_bits(a_dst, 0, 3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.write_bits(a_dst, a_final, 3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 || (r == 0 && (self.f_n_bits > 0)) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = a_dst.write_u8(((self.f_bits & 255) as u8));
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    self.f_bits = 0;
+                                    self.f_n_bits = 0;
+                                }
+                            }
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 0 {
+                                self.c_write_stored.scratch = 0;
+                            }
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = a_dst
+                                .write_u16le((v_length as u16), &mut self.c_write_stored.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 0 {
+                                self.c_write_stored.scratch = 0;
+                            }
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = a_dst.write_u16le(
+                                ((65535 - v_length) as u16),
+                                &mut self.c_write_stored.scratch,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            'label_1: while r != 0 || (v_length > 0) {
+                                if r == 0 {
+                                    v_n_copied = a_dst.copy_from_slice32(
+                                        unsafe {
+                                            wuffs_base::SliceU8::from_array(&mut self.f_window)
+                                                .subslice_i(v_start as usize)
+                                        },
+                                        v_length,
+                                    );
+                                    v_new_start = u32::wrapping_add(v_start, v_n_copied);
+                                    if v_new_start > v_end {
+                                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_ENCODER_STATE;
+                                        break 'exit;
+                                    }
+                                    v_start = v_new_start;
+                                    if v_length <= v_n_copied {
+                                        break 'label_1;
+                                    }
+                                    v_length -= v_n_copied;
+                                }
+                                if r == 0 || r == 6 {
+                                    status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                    if r == 6 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 6;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if v_start >= v_end {
+                                break 'label_0;
+                            }
+                        }
+                    }
+                }
+                self.c_write_stored.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_stored.coro_susp_point = csp;
+            self.c_write_stored.v_start = v_start;
+            self.c_write_stored.v_end = v_end;
+            self.c_write_stored.v_remaining = v_remaining;
+            self.c_write_stored.v_length = v_length;
+            self.c_write_stored.v_n_copied = v_n_copied;
+            self.c_write_stored.v_new_start = v_new_start;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.write_bits
+
+impl Encoder {
+    fn write_bits(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_x: u32,
+        mut a_n: u32,
+    ) -> wuffs_base::Status {
+        let mut v_n_bits: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_bits.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_n_bits = self.c_write_bits.v_n_bits;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_bits |= (a_x << self.f_n_bits);
+                    v_n_bits = (self.f_n_bits + a_n);
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_n_bits >= 8) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_dst.write_u8(((self.f_bits & 255) as u8));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_bits >>= 8;
+                            v_n_bits -= 8;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_n_bits = v_n_bits;
+                }
+                self.c_write_bits.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_bits.coro_susp_point = csp;
+            self.c_write_bits.v_n_bits = v_n_bits;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.write_dynamic_header
+
+impl Encoder {
+    fn write_dynamic_header(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_i: u32 = 0;
+        let mut v_symbol: u32 = 0;
+        let mut v_n_extra_bits: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_dynamic_header.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_i = self.c_write_dynamic_header.v_i;
+            v_symbol = self.c_write_dynamic_header.v_symbol;
+            v_n_extra_bits = self.c_write_dynamic_header.v_n_extra_bits;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.write_bits(a_dst, u32::saturating_sub(self.f_n_lcodes, 257), 5);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.write_bits(a_dst, u32::saturating_sub(self.f_n_dcodes, 1), 5);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.write_bits(a_dst, u32::saturating_sub(self.f_n_clcodes, 4), 4);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_i = 0;
+                }
+                if r == 0 || r == 4 {
+                    while r != 0 || (v_i < self.f_n_clcodes) {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.write_bits(
+                                a_dst,
+                                (((*unsafe {
+                                    self.f_lens.get_unchecked(2 as usize).get_unchecked(
+                                        (*unsafe { CODE_ORDER.get_unchecked(v_i as usize) })
+                                            as usize,
+                                    )
+                                }) & 7) as u32),
+                                3,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_symbol = 0;
+                    v_n_extra_bits = 0;
+                    v_i = 0;
+                }
+                if r == 0 || (5 <= r && r <= 6) {
+                    while r != 0 || (v_i < self.f_n_clseq) {
+                        if r == 0 {
+                            v_symbol = (((*unsafe { self.f_clseq.get_unchecked(v_i as usize) })
+                                & 255) as u32);
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.write_bits(
+                                a_dst,
+                                ((*unsafe {
+                                    self.f_codes
+                                        .get_unchecked(2 as usize)
+                                        .get_unchecked(v_symbol as usize)
+                                }) as u32),
+                                ((*unsafe {
+                                    self.f_lens
+                                        .get_unchecked(2 as usize)
+                                        .get_unchecked(v_symbol as usize)
+                                }) as u32),
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_n_extra_bits = 0;
+                            if v_symbol == 16 {
+                                v_n_extra_bits = 2;
+                            } else if v_symbol == 17 {
+                                v_n_extra_bits = 3;
+                            } else if v_symbol == 18 {
+                                v_n_extra_bits = 7;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            if r == 6 || (r == 0 && (v_n_extra_bits > 0)) {
+                                if r == 0 || r == 6 {
+                                    if r == 6 {
+                                        r = 0;
+                                    }
+                                    csp = 6;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        (((*unsafe { self.f_clseq.get_unchecked(v_i as usize) })
+                                            >> 8) as u32),
+                                        v_n_extra_bits,
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                self.c_write_dynamic_header.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_dynamic_header.coro_susp_point = csp;
+            self.c_write_dynamic_header.v_i = v_i;
+            self.c_write_dynamic_header.v_symbol = v_symbol;
+            self.c_write_dynamic_header.v_n_extra_bits = v_n_extra_bits;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.write_tokens
+
+impl Encoder {
+    fn write_tokens(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_i: u32 = 0;
+        let mut v_t: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_lc: u32 = 0;
+        let mut v_d: u32 = 0;
+        let mut v_dc: u32 = 0;
+        let mut v_magic: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_tokens.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_i = self.c_write_tokens.v_i;
+            v_t = self.c_write_tokens.v_t;
+            v_length = self.c_write_tokens.v_length;
+            v_lc = self.c_write_tokens.v_lc;
+            v_d = self.c_write_tokens.v_d;
+            v_dc = self.c_write_tokens.v_dc;
+            v_magic = self.c_write_tokens.v_magic;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_i = 0;
+                    v_t = 0;
+                    v_length = 0;
+                    v_lc = 0;
+                    v_d = 0;
+                    v_dc = 0;
+                    v_magic = 0;
+                }
+                if r == 0 || (1 <= r && r <= 5) {
+                    while r != 0 || (v_i < self.f_n_tokens) {
+                        if r == 0 {
+                            v_t = (*unsafe { self.f_tokens.get_unchecked(v_i as usize) });
+                        }
+                        if r == 0 || (1 <= r && r <= 5) {
+                            if r == 1 || (r == 0 && ((v_t >> 16) == 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        ((*unsafe {
+                                            self.f_codes
+                                                .get_unchecked(0 as usize)
+                                                .get_unchecked((v_t & 255) as usize)
+                                        }) as u32),
+                                        ((*unsafe {
+                                            self.f_lens
+                                                .get_unchecked(0 as usize)
+                                                .get_unchecked((v_t & 255) as usize)
+                                        }) as u32),
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else {
+                                if r == 0 {
+                                    v_length = (v_t & 511);
+                                    v_lc = ((*unsafe {
+                                        LENGTH_CODES.get_unchecked(
+                                            (u32::wrapping_sub(v_length, 3) & 255) as usize,
+                                        )
+                                    }) as u32);
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        ((*unsafe {
+                                            self.f_codes
+                                                .get_unchecked(0 as usize)
+                                                .get_unchecked((257 + v_lc) as usize)
+                                        }) as u32),
+                                        ((*unsafe {
+                                            self.f_lens
+                                                .get_unchecked(0 as usize)
+                                                .get_unchecked((257 + v_lc) as usize)
+                                        }) as u32),
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_magic = (*unsafe {
+                                        LCODE_MAGIC_NUMBERS.get_unchecked(v_lc as usize)
+                                    });
+                                }
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        (u32::wrapping_sub(v_length, ((v_magic >> 8) & 511)) & 31),
+                                        ((v_magic >> 4) & 15),
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_d = (u32::wrapping_sub((v_t >> 16), 1) & 32767);
+                                    v_dc = self.dcode(v_d);
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        ((*unsafe {
+                                            self.f_codes
+                                                .get_unchecked(1 as usize)
+                                                .get_unchecked(v_dc as usize)
+                                        }) as u32),
+                                        ((*unsafe {
+                                            self.f_lens
+                                                .get_unchecked(1 as usize)
+                                                .get_unchecked(v_dc as usize)
+                                        }) as u32),
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_magic = (*unsafe {
+                                        DCODE_MAGIC_NUMBERS.get_unchecked(v_dc as usize)
+                                    });
+                                }
+                                if r == 0 || r == 5 {
+                                    if r == 5 {
+                                        r = 0;
+                                    }
+                                    csp = 5;
+                                    status = self.write_bits(
+                                        a_dst,
+                                        (u32::wrapping_sub(v_d, ((v_magic >> 8) & 32767)) & 8191),
+                                        ((v_magic >> 4) & 15),
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = self.write_bits(
+                        a_dst,
+                        ((*unsafe {
+                            self.f_codes
+                                .get_unchecked(0 as usize)
+                                .get_unchecked(256 as usize)
+                        }) as u32),
+                        ((*unsafe {
+                            self.f_lens
+                                .get_unchecked(0 as usize)
+                                .get_unchecked(256 as usize)
+                        }) as u32),
+                    );
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_write_tokens.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_tokens.coro_susp_point = csp;
+            self.c_write_tokens.v_i = v_i;
+            self.c_write_tokens.v_t = v_t;
+            self.c_write_tokens.v_length = v_length;
+            self.c_write_tokens.v_lc = v_lc;
+            self.c_write_tokens.v_d = v_d;
+            self.c_write_tokens.v_dc = v_dc;
+            self.c_write_tokens.v_magic = v_magic;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.dcode
+
+impl Encoder {
+    fn dcode(&mut self, mut a_d: u32) -> u32 {
+        if a_d < 256 {
+            return ((*unsafe { DISTANCE_CODES.get_unchecked(a_d as usize) }) as u32);
+        }
+        return ((*unsafe { DISTANCE_CODES.get_unchecked((256 + (a_d >> 7)) as usize) }) as u32);
+    }
+}
+
+// -------- func encoder.count_frequencies
+
+impl Encoder {
+    fn count_frequencies(&mut self) -> u64 {
+        let mut v_i: u32 = 0;
+        let mut v_n_extra_bits: u64 = 0;
+        let mut v_t: u32 = 0;
+        let mut v_lc: u32 = 0;
+        let mut v_dc: u32 = 0;
+
+        v_i = 0;
+        while v_i < 288 {
+            (*unsafe {
+                self.f_freqs
+                    .get_unchecked_mut(0 as usize)
+                    .get_unchecked_mut(v_i as usize)
+            }) = 0;
+            (*unsafe {
+                self.f_freqs
+                    .get_unchecked_mut(1 as usize)
+                    .get_unchecked_mut(v_i as usize)
+            }) = 0;
+            v_i += 1;
+        }
+        v_n_extra_bits = 0;
+        v_t = 0;
+        v_lc = 0;
+        v_dc = 0;
+        v_i = 0;
+        while v_i < self.f_n_tokens {
+            v_t = (*unsafe { self.f_tokens.get_unchecked(v_i as usize) });
+            if (v_t >> 16) == 0 {
+                (*unsafe {
+                    self.f_freqs
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut((v_t & 255) as usize)
+                }) = u32::wrapping_add(
+                    (*unsafe {
+                        self.f_freqs
+                            .get_unchecked(0 as usize)
+                            .get_unchecked((v_t & 255) as usize)
+                    }),
+                    1,
+                );
+            } else {
+                v_lc = ((*unsafe {
+                    LENGTH_CODES.get_unchecked((u32::wrapping_sub((v_t & 511), 3) & 255) as usize)
+                }) as u32);
+                (*unsafe {
+                    self.f_freqs
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut((257 + v_lc) as usize)
+                }) = u32::wrapping_add(
+                    (*unsafe {
+                        self.f_freqs
+                            .get_unchecked(0 as usize)
+                            .get_unchecked((257 + v_lc) as usize)
+                    }),
+                    1,
+                );
+                v_n_extra_bits = u64::wrapping_add(
+                    v_n_extra_bits,
+                    ((((*unsafe { LCODE_MAGIC_NUMBERS.get_unchecked(v_lc as usize) }) >> 4) & 15)
+                        as u64),
+                );
+                v_dc = self.dcode((u32::wrapping_sub((v_t >> 16), 1) & 32767));
+                (*unsafe {
+                    self.f_freqs
+                        .get_unchecked_mut(1 as usize)
+                        .get_unchecked_mut(v_dc as usize)
+                }) = u32::wrapping_add(
+                    (*unsafe {
+                        self.f_freqs
+                            .get_unchecked(1 as usize)
+                            .get_unchecked(v_dc as usize)
+                    }),
+                    1,
+                );
+                v_n_extra_bits = u64::wrapping_add(
+                    v_n_extra_bits,
+                    ((((*unsafe { DCODE_MAGIC_NUMBERS.get_unchecked(v_dc as usize) }) >> 4) & 15)
+                        as u64),
+                );
+            }
+            v_i += 1;
+        }
+        (*unsafe {
+            self.f_freqs
+                .get_unchecked_mut(0 as usize)
+                .get_unchecked_mut(256 as usize)
+        }) = 1;
+        return v_n_extra_bits;
+    }
+}
+
+// -------- func encoder.fixed_cost
+
+impl Encoder {
+    fn fixed_cost(&mut self) -> u64 {
+        let mut v_cost: u64 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_n: u64 = 0;
+
+        v_cost = 0;
+        v_i = 0;
+        while v_i < 288 {
+            v_n = 8;
+            if v_i < 144 {
+                v_n = 8;
+            } else if v_i < 256 {
+                v_n = 9;
+            } else if v_i < 280 {
+                v_n = 7;
+            }
+            v_cost = u64::wrapping_add(
+                v_cost,
+                (((*unsafe {
+                    self.f_freqs
+                        .get_unchecked(0 as usize)
+                        .get_unchecked(v_i as usize)
+                }) as u64)
+                    * v_n),
+            );
+            v_cost = u64::wrapping_add(
+                v_cost,
+                (((*unsafe {
+                    self.f_freqs
+                        .get_unchecked(1 as usize)
+                        .get_unchecked(v_i as usize)
+                }) as u64)
+                    * 5),
+            );
+            v_i += 1;
+        }
+        return v_cost;
+    }
+}
+
+// -------- func encoder.init_fixed_huffman
+
+impl Encoder {
+    fn init_fixed_huffman(&mut self) {
+        let mut v_i: u32 = 0;
+
+        v_i = 0;
+        while v_i < 288 {
+            if v_i < 144 {
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 8;
+            } else if v_i < 256 {
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 9;
+            } else if v_i < 280 {
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 7;
+            } else {
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(0 as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 8;
+            }
+            v_i += 1;
+        }
+        v_i = 0;
+        while v_i < 32 {
+            (*unsafe {
+                self.f_lens
+                    .get_unchecked_mut(1 as usize)
+                    .get_unchecked_mut(v_i as usize)
+            }) = 5;
+            v_i += 1;
+        }
+        self.build_codes(0, 288);
+        self.build_codes(1, 32);
+    }
+}
+
+// -------- func encoder.build_dynamic_huffman
+
+impl Encoder {
+    fn build_dynamic_huffman(&mut self) -> u64 {
+        let mut v_cost: u64 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_symbol: u32 = 0;
+
+        self.ensure_two_symbols(0, 286);
+        self.ensure_two_symbols(1, 30);
+        self.build_huffman(0, 286, 15);
+        self.build_huffman(1, 30, 15);
+        v_cost = 0;
+        v_i = 0;
+        while v_i < 286 {
+            v_cost = u64::wrapping_add(
+                v_cost,
+                (((*unsafe {
+                    self.f_freqs
+                        .get_unchecked(0 as usize)
+                        .get_unchecked(v_i as usize)
+                }) as u64)
+                    * ((*unsafe {
+                        self.f_lens
+                            .get_unchecked(0 as usize)
+                            .get_unchecked(v_i as usize)
+                    }) as u64)),
+            );
+            if v_i < 30 {
+                v_cost = u64::wrapping_add(
+                    v_cost,
+                    (((*unsafe {
+                        self.f_freqs
+                            .get_unchecked(1 as usize)
+                            .get_unchecked(v_i as usize)
+                    }) as u64)
+                        * ((*unsafe {
+                            self.f_lens
+                                .get_unchecked(1 as usize)
+                                .get_unchecked(v_i as usize)
+                        }) as u64)),
+                );
+            }
+            v_i += 1;
+        }
+        self.f_n_lcodes = 286;
+        'label_0: while self.f_n_lcodes > 257 {
+            if (*unsafe {
+                self.f_lens
+                    .get_unchecked(0 as usize)
+                    .get_unchecked((self.f_n_lcodes - 1) as usize)
+            }) != 0
+            {
+                break 'label_0;
+            }
+            self.f_n_lcodes -= 1;
+        }
+        self.f_n_dcodes = 30;
+        'label_1: while self.f_n_dcodes > 1 {
+            if (*unsafe {
+                self.f_lens
+                    .get_unchecked(1 as usize)
+                    .get_unchecked((self.f_n_dcodes - 1) as usize)
+            }) != 0
+            {
+                break 'label_1;
+            }
+            self.f_n_dcodes -= 1;
+        }
+        self.build_clseq();
+        self.ensure_two_symbols(2, 19);
+        self.build_huffman(2, 19, 7);
+        self.f_n_clcodes = 19;
+        'label_2: while self.f_n_clcodes > 4 {
+            if (*unsafe {
+                self.f_lens.get_unchecked(2 as usize).get_unchecked(
+                    (*unsafe { CODE_ORDER.get_unchecked((self.f_n_clcodes - 1) as usize) })
+                        as usize,
+                )
+            }) != 0
+            {
+                break 'label_2;
+            }
+            self.f_n_clcodes -= 1;
+        }
+        v_cost = u64::wrapping_add(v_cost, (14 + (3 * (self.f_n_clcodes as u64))));
+        v_symbol = 0;
+        v_i = 0;
+        while v_i < self.f_n_clseq {
+            v_symbol = (((*unsafe { self.f_clseq.get_unchecked(v_i as usize) }) & 255) as u32);
+            v_cost = u64::wrapping_add(
+                v_cost,
+                ((*unsafe {
+                    self.f_lens
+                        .get_unchecked(2 as usize)
+                        .get_unchecked(v_symbol as usize)
+                }) as u64),
+            );
+            if v_symbol == 16 {
+                v_cost = u64::wrapping_add(v_cost, 2);
+            } else if v_symbol == 17 {
+                v_cost = u64::wrapping_add(v_cost, 3);
+            } else if v_symbol == 18 {
+                v_cost = u64::wrapping_add(v_cost, 7);
+            }
+            v_i += 1;
+        }
+        return v_cost;
+    }
+}
+
+// -------- func encoder.build_clseq
+
+impl Encoder {
+    fn build_clseq(&mut self) {
+        let mut v_i: u32 = 0;
+        let mut v_total: u32 = 0;
+        let mut v_cl: u8 = 0;
+        let mut v_prev_cl: u32 = 0;
+        let mut v_run: u32 = 0;
+        let mut v_symbol: u32 = 0;
+        let mut v_extra: u32 = 0;
+        let mut v_rep: u32 = 0;
+
+        v_i = 0;
+        while v_i < 19 {
+            (*unsafe {
+                self.f_freqs
+                    .get_unchecked_mut(2 as usize)
+                    .get_unchecked_mut(v_i as usize)
+            }) = 0;
+            v_i += 1;
+        }
+        self.f_n_clseq = 0;
+        v_total = (self.f_n_lcodes + self.f_n_dcodes);
+        v_cl = 0;
+        v_prev_cl = 255;
+        v_run = 0;
+        v_symbol = 0;
+        v_extra = 0;
+        v_rep = 0;
+        v_i = 0;
+        while v_i < v_total {
+            v_cl = self.code_length(v_i);
+            v_run = 1;
+            'label_0: while v_run < 138 {
+                if u32::wrapping_add(v_i, v_run) >= v_total {
+                    break 'label_0;
+                }
+                if self.code_length(u32::wrapping_add(v_i, v_run)) != v_cl {
+                    break 'label_0;
+                }
+                v_run += 1;
+            }
+            v_symbol = (v_cl as u32);
+            v_extra = 0;
+            if v_cl == 0 {
+                if v_run >= 11 {
+                    v_symbol = 18;
+                    v_extra = (v_run - 11);
+                } else if v_run >= 3 {
+                    v_symbol = 17;
+                    v_extra = (v_run - 3);
+                } else {
+                    v_run = 1;
+                }
+            } else if ((v_cl as u32) == v_prev_cl) && (v_run >= 3) {
+                v_symbol = 16;
+                v_rep = u32::saturating_sub(u32::min(v_run, 6), 3);
+                v_extra = v_rep;
+                v_run = (v_rep + 3);
+            } else {
+                v_run = 1;
+            }
+            v_prev_cl = (v_cl as u32);
+            v_i = u32::wrapping_add(v_i, v_run);
+            if self.f_n_clseq >= 320 {
+                return;
+            }
+            (*unsafe { self.f_clseq.get_unchecked_mut(self.f_n_clseq as usize) }) =
+                ((v_symbol | (v_extra << 8)) as u16);
+            self.f_n_clseq += 1;
+            (*unsafe {
+                self.f_freqs
+                    .get_unchecked_mut(2 as usize)
+                    .get_unchecked_mut(v_symbol as usize)
+            }) = u32::wrapping_add(
+                (*unsafe {
+                    self.f_freqs
+                        .get_unchecked(2 as usize)
+                        .get_unchecked(v_symbol as usize)
+                }),
+                1,
+            );
+        }
+    }
+}
+
+// -------- func encoder.code_length
+
+impl Encoder {
+    fn code_length(&mut self, mut a_i: u32) -> u8 {
+        let mut v_j: u32 = 0;
+
+        if a_i < self.f_n_lcodes {
+            return (*unsafe {
+                self.f_lens
+                    .get_unchecked(0 as usize)
+                    .get_unchecked(a_i as usize)
+            });
+        }
+        v_j = (a_i - self.f_n_lcodes);
+        if v_j < self.f_n_dcodes {
+            return (*unsafe {
+                self.f_lens
+                    .get_unchecked(1 as usize)
+                    .get_unchecked(v_j as usize)
+            });
+        }
+        return 0;
+    }
+}
+
+// -------- func encoder.ensure_two_symbols
+
+impl Encoder {
+    fn ensure_two_symbols(&mut self, mut a_which: u32, mut a_n: u32) {
+        let mut v_n_used: u32 = 0;
+        let mut v_i: u32 = 0;
+
+        v_n_used = 0;
+        v_i = 0;
+        while v_i < a_n {
+            if ((*unsafe {
+                self.f_freqs
+                    .get_unchecked(a_which as usize)
+                    .get_unchecked(v_i as usize)
+            }) > 0)
+                && (v_n_used < 2)
+            {
+                v_n_used += 1;
+            }
+            v_i += 1;
+        }
+        v_i = 0;
+        while (v_i < 2) && (v_n_used < 2) {
+            if (*unsafe {
+                self.f_freqs
+                    .get_unchecked(a_which as usize)
+                    .get_unchecked(v_i as usize)
+            }) == 0
+            {
+                (*unsafe {
+                    self.f_freqs
+                        .get_unchecked_mut(a_which as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 1;
+                v_n_used += 1;
+            }
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func encoder.build_huffman
+
+impl Encoder {
+    fn build_huffman(&mut self, mut a_which: u32, mut a_n: u32, mut a_max_length: u32) {
+        let mut v_n_used: u32 = 0;
+        let mut v_f: u32 = 0;
+        let mut v_j: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_root: u32 = 0;
+        let mut v_leaf: u32 = 0;
+        let mut v_next: u32 = 0;
+        let mut v_n_used_minus_1: u32 = 0;
+        let mut v_n_used_minus_2: u32 = 0;
+        let mut v_avbl: u32 = 0;
+        let mut v_used: u32 = 0;
+        let mut v_depth: u32 = 0;
+        let mut v_counts: [u32; 16] = [0; 16];
+        let mut v_total: u64 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_count: u32 = 0;
+
+        v_n_used = 0;
+        v_f = 0;
+        v_j = 0;
+        v_i = 0;
+        while v_i < a_n {
+            (*unsafe {
+                self.f_lens
+                    .get_unchecked_mut(a_which as usize)
+                    .get_unchecked_mut(v_i as usize)
+            }) = 0;
+            v_f = (*unsafe {
+                self.f_freqs
+                    .get_unchecked(a_which as usize)
+                    .get_unchecked(v_i as usize)
+            });
+            if v_f > 0 {
+                if v_n_used >= 288 {
+                    return;
+                }
+                v_j = v_n_used;
+                'label_0: while v_j > 0 {
+                    if (*unsafe { self.f_hsort_keys.get_unchecked((v_j - 1) as usize) }) <= v_f {
+                        break 'label_0;
+                    }
+                    (*unsafe { self.f_hsort_keys.get_unchecked_mut(v_j as usize) }) =
+                        (*unsafe { self.f_hsort_keys.get_unchecked((v_j - 1) as usize) });
+                    (*unsafe { self.f_hsort_syms.get_unchecked_mut(v_j as usize) }) =
+                        (*unsafe { self.f_hsort_syms.get_unchecked((v_j - 1) as usize) });
+                    v_j -= 1;
+                }
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut(v_j as usize) }) = v_f;
+                (*unsafe { self.f_hsort_syms.get_unchecked_mut(v_j as usize) }) = (v_i as u16);
+                v_n_used += 1;
+            }
+            v_i += 1;
+        }
+        if v_n_used < 2 {
+            if v_n_used == 1 {
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(a_which as usize)
+                        .get_unchecked_mut(
+                            (*unsafe { self.f_hsort_syms.get_unchecked(0 as usize) }) as usize,
+                        )
+                }) = 1;
+            }
+            return;
+        }
+        (*unsafe { self.f_hsort_keys.get_unchecked_mut(0 as usize) }) = u32::wrapping_add(
+            (*unsafe { self.f_hsort_keys.get_unchecked(0 as usize) }),
+            (*unsafe { self.f_hsort_keys.get_unchecked(1 as usize) }),
+        );
+        v_root = 0;
+        v_leaf = 2;
+        v_next = 1;
+        v_n_used_minus_1 = (v_n_used - 1);
+        v_n_used_minus_2 = (v_n_used - 2);
+        while v_next < v_n_used_minus_1 {
+            if (v_leaf >= v_n_used)
+                || ((*unsafe { self.f_hsort_keys.get_unchecked((v_root & 511) as usize) })
+                    < (*unsafe { self.f_hsort_keys.get_unchecked((v_leaf & 511) as usize) }))
+            {
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_next & 511) as usize) }) =
+                    (*unsafe { self.f_hsort_keys.get_unchecked((v_root & 511) as usize) });
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_root & 511) as usize) }) = v_next;
+                v_root = u32::wrapping_add(v_root, 1);
+            } else {
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_next & 511) as usize) }) =
+                    (*unsafe { self.f_hsort_keys.get_unchecked((v_leaf & 511) as usize) });
+                v_leaf = u32::wrapping_add(v_leaf, 1);
+            }
+            if (v_leaf >= v_n_used)
+                || ((v_root < v_next)
+                    && ((*unsafe { self.f_hsort_keys.get_unchecked((v_root & 511) as usize) })
+                        < (*unsafe { self.f_hsort_keys.get_unchecked((v_leaf & 511) as usize) })))
+            {
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_next & 511) as usize) }) =
+                    u32::wrapping_add(
+                        (*unsafe { self.f_hsort_keys.get_unchecked((v_next & 511) as usize) }),
+                        (*unsafe { self.f_hsort_keys.get_unchecked((v_root & 511) as usize) }),
+                    );
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_root & 511) as usize) }) = v_next;
+                v_root = u32::wrapping_add(v_root, 1);
+            } else {
+                (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_next & 511) as usize) }) =
+                    u32::wrapping_add(
+                        (*unsafe { self.f_hsort_keys.get_unchecked((v_next & 511) as usize) }),
+                        (*unsafe { self.f_hsort_keys.get_unchecked((v_leaf & 511) as usize) }),
+                    );
+                v_leaf = u32::wrapping_add(v_leaf, 1);
+            }
+            v_next += 1;
+        }
+        (*unsafe {
+            self.f_hsort_keys
+                .get_unchecked_mut(v_n_used_minus_2 as usize)
+        }) = 0;
+        v_next = v_n_used_minus_2;
+        while v_next > 0 {
+            v_next -= 1;
+            (*unsafe { self.f_hsort_keys.get_unchecked_mut((v_next & 511) as usize) }) =
+                u32::wrapping_add(
+                    (*unsafe {
+                        self.f_hsort_keys.get_unchecked(
+                            ((*unsafe { self.f_hsort_keys.get_unchecked((v_next & 511) as usize) })
+                                & 511) as usize,
+                        )
+                    }),
+                    1,
+                );
+        }
+        v_avbl = 1;
+        v_used = 0;
+        v_depth = 0;
+        v_root = v_n_used_minus_1;
+        v_next = v_n_used;
+        while (v_avbl > 0) && (v_next > 0) {
+            'label_1: while v_root > 0 {
+                if (*unsafe {
+                    self.f_hsort_keys
+                        .get_unchecked(((v_root - 1) & 511) as usize)
+                }) != v_depth
+                {
+                    break 'label_1;
+                }
+                v_used = u32::wrapping_add(v_used, 1);
+                v_root -= 1;
+            }
+            while (v_avbl > v_used) && (v_next > 0) {
+                (*unsafe {
+                    self.f_hsort_keys
+                        .get_unchecked_mut(((v_next - 1) & 511) as usize)
+                }) = v_depth;
+                v_next -= 1;
+                v_avbl = u32::saturating_sub(v_avbl, 1);
+            }
+            v_avbl = ((v_used & 511) * 2);
+            v_depth = u32::wrapping_add(v_depth, 1);
+            v_used = 0;
+        }
+        v_counts = [0; 16];
+        v_i = 0;
+        while v_i < v_n_used {
+            v_depth = (*unsafe { self.f_hsort_keys.get_unchecked(v_i as usize) });
+            if v_depth > a_max_length {
+                v_depth = a_max_length;
+            }
+            (*unsafe { v_counts.get_unchecked_mut((v_depth & 15) as usize) }) = u32::wrapping_add(
+                (*unsafe { v_counts.get_unchecked((v_depth & 15) as usize) }),
+                1,
+            );
+            v_i += 1;
+        }
+        v_total = 0;
+        v_length = 1;
+        'label_2: while a_max_length >= v_length {
+            v_total = u64::wrapping_add(
+                v_total,
+                (((*unsafe { v_counts.get_unchecked(v_length as usize) }) as u64)
+                    << (a_max_length - v_length)),
+            );
+            if v_length >= 15 {
+                break 'label_2;
+            }
+            v_length += 1;
+        }
+        while v_total > (1u64 << a_max_length) {
+            if (*unsafe { v_counts.get_unchecked(a_max_length as usize) }) > 0 {
+                (*unsafe { v_counts.get_unchecked_mut(a_max_length as usize) }) -= 1;
+            }
+            v_length = a_max_length;
+            'label_3: while v_length > 1 {
+                v_length -= 1;
+                if (*unsafe { v_counts.get_unchecked(v_length as usize) }) > 0 {
+                    (*unsafe { v_counts.get_unchecked_mut(v_length as usize) }) -= 1;
+                    (*unsafe { v_counts.get_unchecked_mut(((v_length + 1) & 15) as usize) }) =
+                        u32::wrapping_add(
+                            (*unsafe { v_counts.get_unchecked(((v_length + 1) & 15) as usize) }),
+                            2,
+                        );
+                    break 'label_3;
+                }
+            }
+            v_total = u64::saturating_sub(v_total, 1);
+        }
+        v_i = 0;
+        v_length = a_max_length;
+        v_count = 0;
+        while v_length > 0 {
+            v_count = (*unsafe { v_counts.get_unchecked(v_length as usize) });
+            'label_4: while v_count > 0 {
+                if v_i >= v_n_used {
+                    break 'label_4;
+                }
+                (*unsafe {
+                    self.f_lens
+                        .get_unchecked_mut(a_which as usize)
+                        .get_unchecked_mut(
+                            (*unsafe { self.f_hsort_syms.get_unchecked(v_i as usize) }) as usize,
+                        )
+                }) = (v_length as u8);
+                v_i += 1;
+                v_count -= 1;
+            }
+            v_length -= 1;
+        }
+        self.build_codes(a_which, a_n);
+    }
+}
+
+// -------- func encoder.build_codes
+
+impl Encoder {
+    fn build_codes(&mut self, mut a_which: u32, mut a_n: u32) {
+        let mut v_counts: [u32; 16] = [0; 16];
+        let mut v_i: u32 = 0;
+        let mut v_next_codes: [u32; 16] = [0; 16];
+        let mut v_code: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_r: u32 = 0;
+
+        v_counts = [0; 16];
+        v_i = 0;
+        while v_i < a_n {
+            (*unsafe {
+                v_counts.get_unchecked_mut(
+                    (*unsafe {
+                        self.f_lens
+                            .get_unchecked(a_which as usize)
+                            .get_unchecked(v_i as usize)
+                    }) as usize,
+                )
+            }) = u32::wrapping_add(
+                (*unsafe {
+                    v_counts.get_unchecked(
+                        (*unsafe {
+                            self.f_lens
+                                .get_unchecked(a_which as usize)
+                                .get_unchecked(v_i as usize)
+                        }) as usize,
+                    )
+                }),
+                1,
+            );
+            v_i += 1;
+        }
+        (*unsafe { v_counts.get_unchecked_mut(0 as usize) }) = 0;
+        v_next_codes = [0; 16];
+        v_code = 0;
+        v_length = 0;
+        while v_length < 15 {
+            v_code = ((u32::wrapping_add(
+                v_code,
+                (*unsafe { v_counts.get_unchecked(v_length as usize) }),
+            ) & 32767)
+                << 1);
+            (*unsafe { v_next_codes.get_unchecked_mut((v_length + 1) as usize) }) = v_code;
+            v_length += 1;
+        }
+        v_c = 0;
+        v_r = 0;
+        v_i = 0;
+        while v_i < a_n {
+            v_length = ((*unsafe {
+                self.f_lens
+                    .get_unchecked(a_which as usize)
+                    .get_unchecked(v_i as usize)
+            }) as u32);
+            if v_length > 0 {
+                v_c = ((*unsafe { v_next_codes.get_unchecked(v_length as usize) }) & 65535);
+                (*unsafe { v_next_codes.get_unchecked_mut(v_length as usize) }) = u32::wrapping_add(
+                    (*unsafe { v_next_codes.get_unchecked(v_length as usize) }),
+                    1,
+                );
+                v_r = ((((*unsafe { REVERSE8.get_unchecked((v_c & 255) as usize) }) as u32) << 8)
+                    | ((*unsafe { REVERSE8.get_unchecked((v_c >> 8) as usize) }) as u32));
+                (*unsafe {
+                    self.f_codes
+                        .get_unchecked_mut(a_which as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = ((v_r >> (16 - v_length)) as u16);
+            } else {
+                (*unsafe {
+                    self.f_codes
+                        .get_unchecked_mut(a_which as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 0;
+            }
+            v_i += 1;
+        }
+    }
+}
@@ -0,0 +1,3255 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package gif is the Rust translation of the Wuffs std/gif package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_lzw as lzw;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_BLOCK: wuffs_base::Status = wuffs_base::Status::Error("gif: bad block");
+pub const ERROR_BAD_EXTENSION_LABEL: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: bad extension label");
+pub const ERROR_BAD_GRAPHIC_CONTROL: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: bad graphic control");
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("gif: bad header");
+pub const ERROR_BAD_LITERAL_WIDTH: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: bad literal width");
+pub const ERROR_NOT_ENOUGH_PIXEL_DATA: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: not enough pixel data");
+pub const ERROR_TOO_MUCH_PIXEL_DATA: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: too much pixel data");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: internal error: inconsistent ri/wi");
+pub const ERROR_UNSUPPORTED_IMAGE_DIMENSIONS: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: unsupported image dimensions");
+pub const ERROR_UNSUPPORTED_PIXEL_FORMAT: wuffs_base::Status =
+    wuffs_base::Status::Error("gif: unsupported pixel format");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a gif.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_end_of_data: bool,
+    f_previous_lzw_decode_ended_abruptly: bool,
+    f_previous_use_global_palette: bool,
+    f_background_color_index: u8,
+    f_interlace: u8,
+    f_seen_num_loops: bool,
+    f_num_loops: u32,
+    f_seen_graphic_control: bool,
+    f_gc_has_transparent_index: bool,
+    f_gc_transparent_index: u8,
+    f_gc_disposal: u8,
+    f_gc_duration: u64,
+    f_frame_rect: wuffs_base::RectIeU32,
+    f_dst_x: u32,
+    f_dst_y: u32,
+    f_dst_x0: u32,
+    f_dst_x1: u32,
+    f_dst_y0: u32,
+    f_dst_y1: u32,
+    f_uncompressed_ri: u32,
+    f_uncompressed_wi: u32,
+    f_uncompressed: [u8; 4096],
+    f_palettes: [[u8; 1024]; 2],
+    f_lzw: lzw::Decoder,
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_decode_up_to_id_part1: DecoderDecodeUpToIdPart1Coro,
+    c_decode_header: DecoderDecodeHeaderCoro,
+    c_decode_lsd: DecoderDecodeLsdCoro,
+    c_decode_extension: DecoderDecodeExtensionCoro,
+    c_skip_blocks: DecoderSkipBlocksCoro,
+    c_decode_ae: DecoderDecodeAeCoro,
+    c_decode_gc: DecoderDecodeGcCoro,
+    c_decode_id_part0: DecoderDecodeIdPart0Coro,
+    c_decode_id_part1: DecoderDecodeIdPart1Coro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_end_of_data: false,
+            f_previous_lzw_decode_ended_abruptly: false,
+            f_previous_use_global_palette: false,
+            f_background_color_index: 0,
+            f_interlace: 0,
+            f_seen_num_loops: false,
+            f_num_loops: 0,
+            f_seen_graphic_control: false,
+            f_gc_has_transparent_index: false,
+            f_gc_transparent_index: 0,
+            f_gc_disposal: 0,
+            f_gc_duration: 0,
+            f_frame_rect: wuffs_base::RectIeU32::default(),
+            f_dst_x: 0,
+            f_dst_y: 0,
+            f_dst_x0: 0,
+            f_dst_x1: 0,
+            f_dst_y0: 0,
+            f_dst_y1: 0,
+            f_uncompressed_ri: 0,
+            f_uncompressed_wi: 0,
+            f_uncompressed: [0; 4096],
+            f_palettes: [[0; 1024]; 2],
+            f_lzw: lzw::Decoder::default(),
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_decode_up_to_id_part1: DecoderDecodeUpToIdPart1Coro::default(),
+            c_decode_header: DecoderDecodeHeaderCoro::default(),
+            c_decode_lsd: DecoderDecodeLsdCoro::default(),
+            c_decode_extension: DecoderDecodeExtensionCoro::default(),
+            c_skip_blocks: DecoderSkipBlocksCoro::default(),
+            c_decode_ae: DecoderDecodeAeCoro::default(),
+            c_decode_gc: DecoderDecodeGcCoro::default(),
+            c_decode_id_part0: DecoderDecodeIdPart0Coro::default(),
+            c_decode_id_part1: DecoderDecodeIdPart1Coro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_num_loops: u32,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_num_loops: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro { coro_susp_point: 0 }
+    }
+}
+
+struct DecoderDecodeUpToIdPart1Coro {
+    coro_susp_point: u32,
+    v_block_type: u8,
+}
+
+impl Default for DecoderDecodeUpToIdPart1Coro {
+    fn default() -> Self {
+        DecoderDecodeUpToIdPart1Coro {
+            coro_susp_point: 0,
+            v_block_type: 0,
+        }
+    }
+}
+
+struct DecoderDecodeHeaderCoro {
+    coro_susp_point: u32,
+    v_c: [u8; 6],
+    v_i: u32,
+}
+
+impl Default for DecoderDecodeHeaderCoro {
+    fn default() -> Self {
+        DecoderDecodeHeaderCoro {
+            coro_susp_point: 0,
+            v_c: [0; 6],
+            v_i: 0,
+        }
+    }
+}
+
+struct DecoderDecodeLsdCoro {
+    coro_susp_point: u32,
+    v_c: [u8; 7],
+    v_i: u32,
+    v_flags: u8,
+    v_num_palette_entries: u32,
+    v_argb: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeLsdCoro {
+    fn default() -> Self {
+        DecoderDecodeLsdCoro {
+            coro_susp_point: 0,
+            v_c: [0; 7],
+            v_i: 0,
+            v_flags: 0,
+            v_num_palette_entries: 0,
+            v_argb: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeExtensionCoro {
+    coro_susp_point: u32,
+    v_label: u8,
+}
+
+impl Default for DecoderDecodeExtensionCoro {
+    fn default() -> Self {
+        DecoderDecodeExtensionCoro {
+            coro_susp_point: 0,
+            v_label: 0,
+        }
+    }
+}
+
+struct DecoderSkipBlocksCoro {
+    coro_susp_point: u32,
+    v_block_size: u8,
+    scratch: u64,
+}
+
+impl Default for DecoderSkipBlocksCoro {
+    fn default() -> Self {
+        DecoderSkipBlocksCoro {
+            coro_susp_point: 0,
+            v_block_size: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeAeCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+    v_block_size: u8,
+    v_not_animexts: bool,
+    v_not_netscape: bool,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeAeCoro {
+    fn default() -> Self {
+        DecoderDecodeAeCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+            v_block_size: 0,
+            v_not_animexts: false,
+            v_not_netscape: false,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeGcCoro {
+    coro_susp_point: u32,
+    v_flags: u8,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeGcCoro {
+    fn default() -> Self {
+        DecoderDecodeGcCoro {
+            coro_susp_point: 0,
+            v_flags: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeIdPart0Coro {
+    coro_susp_point: u32,
+    v_frame_x: u32,
+    v_frame_y: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeIdPart0Coro {
+    fn default() -> Self {
+        DecoderDecodeIdPart0Coro {
+            coro_susp_point: 0,
+            v_frame_x: 0,
+            v_frame_y: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeIdPart1Coro {
+    coro_susp_point: u32,
+    v_flags: u8,
+    v_use_local_palette: bool,
+    v_num_palette_entries: u32,
+    v_i: u32,
+    v_argb: u32,
+    v_lw: u8,
+    v_block_size: u64,
+    v_z: wuffs_base::Status,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeIdPart1Coro {
+    fn default() -> Self {
+        DecoderDecodeIdPart1Coro {
+            coro_susp_point: 0,
+            v_flags: 0,
+            v_use_local_palette: false,
+            v_num_palette_entries: 0,
+            v_i: 0,
+            v_argb: 0,
+            v_lw: 0,
+            v_block_size: 0,
+            v_z: wuffs_base::Status::Ok,
+            scratch: 0,
+        }
+    }
+}
+
+/// Encoder holds the state of a gif.encoder. Its default value is ready to use.
+pub struct Encoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_num_loops: u32,
+    f_call_sequence: u8,
+    f_frame_x0: u32,
+    f_frame_y0: u32,
+    f_frame_x1: u32,
+    f_frame_y1: u32,
+    f_src_x: u32,
+    f_src_y: u32,
+    f_palette_bits: u32,
+    f_global_palette_bits: u32,
+    f_palettes: [[u8; 1024]; 2],
+    f_block: [u8; 255],
+    f_block_ri: u32,
+    f_block_wi: u32,
+    f_lzw: lzw::Encoder,
+    c_encode_config: EncoderEncodeConfigCoro,
+    c_encode_frame: EncoderEncodeFrameCoro,
+    c_encode_trailer: EncoderEncodeTrailerCoro,
+    c_encode_lsd: EncoderEncodeLsdCoro,
+    c_encode_palette: EncoderEncodePaletteCoro,
+    c_encode_ae: EncoderEncodeAeCoro,
+    c_encode_gc: EncoderEncodeGcCoro,
+    c_encode_id: EncoderEncodeIdCoro,
+    c_encode_pixels: EncoderEncodePixelsCoro,
+    c_write_block: EncoderWriteBlockCoro,
+}
+
+impl Default for Encoder {
+    fn default() -> Self {
+        Encoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_num_loops: 0,
+            f_call_sequence: 0,
+            f_frame_x0: 0,
+            f_frame_y0: 0,
+            f_frame_x1: 0,
+            f_frame_y1: 0,
+            f_src_x: 0,
+            f_src_y: 0,
+            f_palette_bits: 0,
+            f_global_palette_bits: 0,
+            f_palettes: [[0; 1024]; 2],
+            f_block: [0; 255],
+            f_block_ri: 0,
+            f_block_wi: 0,
+            f_lzw: lzw::Encoder::default(),
+            c_encode_config: EncoderEncodeConfigCoro::default(),
+            c_encode_frame: EncoderEncodeFrameCoro::default(),
+            c_encode_trailer: EncoderEncodeTrailerCoro::default(),
+            c_encode_lsd: EncoderEncodeLsdCoro::default(),
+            c_encode_palette: EncoderEncodePaletteCoro::default(),
+            c_encode_ae: EncoderEncodeAeCoro::default(),
+            c_encode_gc: EncoderEncodeGcCoro::default(),
+            c_encode_id: EncoderEncodeIdCoro::default(),
+            c_encode_pixels: EncoderEncodePixelsCoro::default(),
+            c_write_block: EncoderWriteBlockCoro::default(),
+        }
+    }
+}
+
+struct EncoderEncodeConfigCoro {
+    coro_susp_point: u32,
+    v_width: u32,
+    v_height: u32,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeConfigCoro {
+    fn default() -> Self {
+        EncoderEncodeConfigCoro {
+            coro_susp_point: 0,
+            v_width: 0,
+            v_height: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderEncodeFrameCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for EncoderEncodeFrameCoro {
+    fn default() -> Self {
+        EncoderEncodeFrameCoro { coro_susp_point: 0 }
+    }
+}
+
+struct EncoderEncodeTrailerCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for EncoderEncodeTrailerCoro {
+    fn default() -> Self {
+        EncoderEncodeTrailerCoro { coro_susp_point: 0 }
+    }
+}
+
+struct EncoderEncodeLsdCoro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeLsdCoro {
+    fn default() -> Self {
+        EncoderEncodeLsdCoro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderEncodePaletteCoro {
+    coro_susp_point: u32,
+    v_num_palette_entries: u32,
+    v_i: u32,
+}
+
+impl Default for EncoderEncodePaletteCoro {
+    fn default() -> Self {
+        EncoderEncodePaletteCoro {
+            coro_susp_point: 0,
+            v_num_palette_entries: 0,
+            v_i: 0,
+        }
+    }
+}
+
+struct EncoderEncodeAeCoro {
+    coro_susp_point: u32,
+    v_num_loops: u32,
+    v_i: u32,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeAeCoro {
+    fn default() -> Self {
+        EncoderEncodeAeCoro {
+            coro_susp_point: 0,
+            v_num_loops: 0,
+            v_i: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderEncodeGcCoro {
+    coro_susp_point: u32,
+    v_flags: u8,
+    v_disposal: u8,
+    v_transparent_index: u8,
+    v_num_palette_entries: u32,
+    v_i: u32,
+    v_centiseconds: u64,
+    v_duration: u64,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeGcCoro {
+    fn default() -> Self {
+        EncoderEncodeGcCoro {
+            coro_susp_point: 0,
+            v_flags: 0,
+            v_disposal: 0,
+            v_transparent_index: 0,
+            v_num_palette_entries: 0,
+            v_i: 0,
+            v_centiseconds: 0,
+            v_duration: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderEncodeIdCoro {
+    coro_susp_point: u32,
+    v_use_global_palette: bool,
+    v_num_palette_entries: u32,
+    v_i: u32,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeIdCoro {
+    fn default() -> Self {
+        EncoderEncodeIdCoro {
+            coro_susp_point: 0,
+            v_use_global_palette: false,
+            v_num_palette_entries: 0,
+            v_i: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct EncoderEncodePixelsCoro {
+    coro_susp_point: u32,
+    v_last_row: bool,
+    v_z: wuffs_base::Status,
+}
+
+impl Default for EncoderEncodePixelsCoro {
+    fn default() -> Self {
+        EncoderEncodePixelsCoro {
+            coro_susp_point: 0,
+            v_last_row: false,
+            v_z: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+struct EncoderWriteBlockCoro {
+    coro_susp_point: u32,
+    v_n: u64,
+    v_new_ri: u32,
+}
+
+impl Default for EncoderWriteBlockCoro {
+    fn default() -> Self {
+        EncoderWriteBlockCoro {
+            coro_susp_point: 0,
+            v_n: 0,
+            v_new_ri: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+static ANIMEXTS1DOT0: [u8; 11] = [
+    0x41, 0x4E, 0x49, 0x4D, 0x45, 0x58, 0x54, 0x53, 0x31, 0x2E, 0x30,
+];
+
+static NETSCAPE2DOT0: [u8; 11] = [
+    0x4E, 0x45, 0x54, 0x53, 0x43, 0x41, 0x50, 0x45, 0x32, 0x2E, 0x30,
+];
+
+static INTERLACE_START: [u32; 5] = [0xFFFFFFFF, 0x1, 0x2, 0x4, 0x0];
+
+static INTERLACE_DELTA: [u8; 5] = [0x1, 0x2, 0x4, 0x8, 0x8];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_num_loops: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_loops = self.c_decode_config.v_num_loops;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.decode_header(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.decode_lsd(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.decode_up_to_id_part1(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_num_loops = 1;
+                    if self.f_seen_num_loops {
+                        v_num_loops = self.f_num_loops;
+                    }
+                    a_dst.initialize(570984584, 0, self.f_width, self.f_height, v_num_loops);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_num_loops = v_num_loops;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence == 0 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 || (r == 0 && !self.f_end_of_data) {
+                        if r == 0 || r == 1 {
+                            if self.f_call_sequence == 1 {
+                                self.f_call_sequence = 2;
+                            } else {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = self.decode_up_to_id_part1(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 || (r == 0 && self.f_end_of_data) {
+                        if r == 0 || r == 2 {
+                            loop {
+                                if r == 0 || r == 2 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 2 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 2;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.decode_id_part1(a_dst, a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_up_to_id_part1
+
+impl Decoder {
+    pub fn decode_up_to_id_part1(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_block_type: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_up_to_id_part1.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_block_type = self.c_decode_up_to_id_part1.v_block_type;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || (1 <= r && r <= 3) {
+                    'label_0: loop {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_block_type = t_0;
+                        }
+                        if r == 0 || (2 <= r && r <= 3) {
+                            if r == 2 || (r == 0 && (v_block_type == 33)) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.decode_extension(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else if r == 3 || (r == 0 && (v_block_type == 44)) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.decode_id_part0(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    break 'label_0;
+                                }
+                            } else if r == 0 && (v_block_type == 59) {
+                                self.f_end_of_data = true;
+                                break 'label_0;
+                            } else {
+                                status = ERROR_BAD_BLOCK;
+                                break 'exit;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_up_to_id_part1.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_up_to_id_part1.coro_susp_point = csp;
+            self.c_decode_up_to_id_part1.v_block_type = v_block_type;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_header
+
+impl Decoder {
+    fn decode_header(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: [u8; 6] = [0; 6];
+        let mut v_i: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_header.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_header.v_c;
+            v_i = self.c_decode_header.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = [0; 6];
+                    v_i = 0;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_i < 6) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            (*unsafe { v_c.get_unchecked_mut(v_i as usize) }) = t_0;
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    if ((*unsafe { v_c.get_unchecked(0 as usize) }) != 71)
+                        || ((*unsafe { v_c.get_unchecked(1 as usize) }) != 73)
+                        || ((*unsafe { v_c.get_unchecked(2 as usize) }) != 70)
+                        || ((*unsafe { v_c.get_unchecked(3 as usize) }) != 56)
+                        || (((*unsafe { v_c.get_unchecked(4 as usize) }) != 55)
+                            && ((*unsafe { v_c.get_unchecked(4 as usize) }) != 57))
+                        || ((*unsafe { v_c.get_unchecked(5 as usize) }) != 97)
+                    {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                self.c_decode_header.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_header.coro_susp_point = csp;
+            self.c_decode_header.v_c = v_c;
+            self.c_decode_header.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_lsd
+
+impl Decoder {
+    fn decode_lsd(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: [u8; 7] = [0; 7];
+        let mut v_i: u32 = 0;
+        let mut v_flags: u8 = 0;
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_argb: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_lsd.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_lsd.v_c;
+            v_i = self.c_decode_lsd.v_i;
+            v_flags = self.c_decode_lsd.v_flags;
+            v_num_palette_entries = self.c_decode_lsd.v_num_palette_entries;
+            v_argb = self.c_decode_lsd.v_argb;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = [0; 7];
+                    v_i = 0;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_i < 7) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            (*unsafe { v_c.get_unchecked_mut(v_i as usize) }) = t_0;
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_width = (((*unsafe { v_c.get_unchecked(0 as usize) }) as u32)
+                        | (((*unsafe { v_c.get_unchecked(1 as usize) }) as u32) << 8));
+                    self.f_height = (((*unsafe { v_c.get_unchecked(2 as usize) }) as u32)
+                        | (((*unsafe { v_c.get_unchecked(3 as usize) }) as u32) << 8));
+                    v_flags = (*unsafe { v_c.get_unchecked(4 as usize) });
+                    self.f_background_color_index = (*unsafe { v_c.get_unchecked(5 as usize) });
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 || (r == 0 && ((v_flags & 128) != 0)) {
+                        if r == 0 {
+                            v_num_palette_entries = (1u32 << (1 + (v_flags & 7)));
+                            v_i = 0;
+                        }
+                        if r == 0 || r == 2 {
+                            while r != 0 || (v_i < v_num_palette_entries) {
+                                if r == 0 || r == 2 {
+                                    if r == 0 {
+                                        self.c_decode_lsd.scratch = 0;
+                                    }
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status =
+                                        a_src.read_u24be(&mut t_1, &mut self.c_decode_lsd.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_argb = (t_1 | 4278190080);
+                                }
+                                if r == 0 {
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(0 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                    }) = (((v_argb >> 0) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(0 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                    }) = (((v_argb >> 8) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(0 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                    }) = (((v_argb >> 16) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(0 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                    }) = (((v_argb >> 24) & 255) as u8);
+                                    v_i += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            while v_i < 256 {
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(0 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(0 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(0 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(0 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                }) = 0;
+                                v_i += 1;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_lsd.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_lsd.coro_susp_point = csp;
+            self.c_decode_lsd.v_c = v_c;
+            self.c_decode_lsd.v_i = v_i;
+            self.c_decode_lsd.v_flags = v_flags;
+            self.c_decode_lsd.v_num_palette_entries = v_num_palette_entries;
+            self.c_decode_lsd.v_argb = v_argb;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_extension
+
+impl Decoder {
+    fn decode_extension(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_label: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_extension.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_label = self.c_decode_extension.v_label;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u8(&mut t_0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        v_label = t_0;
+                    }
+                    if r == 0 || (2 <= r && r <= 3) {
+                        if r == 2 || (r == 0 && (v_label == 249)) {
+                            if r == 0 || r == 2 {
+                                if r == 2 {
+                                    r = 0;
+                                }
+                                csp = 2;
+                                status = self.decode_gc(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                status = wuffs_base::Status::Ok;
+                                break 'ok;
+                            }
+                        } else if r == 3 || (r == 0 && (v_label == 255)) {
+                            if r == 0 || r == 3 {
+                                if r == 3 {
+                                    r = 0;
+                                }
+                                csp = 3;
+                                status = self.decode_ae(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                status = wuffs_base::Status::Ok;
+                                break 'ok;
+                            }
+                        }
+                    }
+                    if r == 0 || r == 4 {
+                        if r == 4 {
+                            r = 0;
+                        }
+                        csp = 4;
+                        status = self.skip_blocks(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                }
+                self.c_decode_extension.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_extension.coro_susp_point = csp;
+            self.c_decode_extension.v_label = v_label;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.skip_blocks
+
+impl Decoder {
+    fn skip_blocks(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_block_size: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_skip_blocks.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_block_size = self.c_skip_blocks.v_block_size;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || (1 <= r && r <= 2) {
+                        loop {
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = a_src.read_u8(&mut t_0);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_block_size = t_0;
+                            }
+                            if r == 0 {
+                                if v_block_size == 0 {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                if r == 0 {
+                                    self.c_skip_blocks.scratch = ((v_block_size as u32) as u64);
+                                }
+                                if r == 2 {
+                                    r = 0;
+                                }
+                                csp = 2;
+                                status = a_src.skip(&mut self.c_skip_blocks.scratch);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_skip_blocks.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_skip_blocks.coro_susp_point = csp;
+            self.c_skip_blocks.v_block_size = v_block_size;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_ae
+
+impl Decoder {
+    fn decode_ae(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut v_block_size: u8 = 0;
+        let mut v_not_animexts: bool = false;
+        let mut v_not_netscape: bool = false;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_ae.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_ae.v_c;
+            v_block_size = self.c_decode_ae.v_block_size;
+            v_not_animexts = self.c_decode_ae.v_not_animexts;
+            v_not_netscape = self.c_decode_ae.v_not_netscape;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || (1 <= r && r <= 8) {
+                        'label_0: loop {
+                            if r == 0 {
+                                v_c = 0;
+                            }
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = a_src.read_u8(&mut t_0);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_block_size = t_0;
+                            }
+                            if r == 0 {
+                                if v_block_size == 0 {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                if r == 2 || (r == 0 && (v_block_size != 11)) {
+                                    if r == 0 || r == 2 {
+                                        if r == 0 {
+                                            self.c_decode_ae.scratch =
+                                                ((v_block_size as u32) as u64);
+                                        }
+                                        if r == 2 {
+                                            r = 0;
+                                        }
+                                        csp = 2;
+                                        status = a_src.skip(&mut self.c_decode_ae.scratch);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 {
+                                        break 'label_0;
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                v_not_animexts = false;
+                                v_not_netscape = false;
+                                v_block_size = 0;
+                            }
+                            if r == 0 || r == 3 {
+                                while r != 0 || (v_block_size < 11) {
+                                    if r == 0 || r == 3 {
+                                        if r == 3 {
+                                            r = 0;
+                                        }
+                                        csp = 3;
+                                        status = a_src.read_u8(&mut t_1);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                        v_c = t_1;
+                                    }
+                                    if r == 0 {
+                                        v_not_animexts = (v_not_animexts
+                                            || (v_c
+                                                != (*unsafe {
+                                                    ANIMEXTS1DOT0
+                                                        .get_unchecked(v_block_size as usize)
+                                                })));
+                                        v_not_netscape = (v_not_netscape
+                                            || (v_c
+                                                != (*unsafe {
+                                                    NETSCAPE2DOT0
+                                                        .get_unchecked(v_block_size as usize)
+                                                })));
+                                        v_block_size += 1;
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                if v_not_animexts && v_not_netscape {
+                                    break 'label_0;
+                                }
+                            }
+                            if r == 0 || r == 4 {
+                                if r == 4 {
+                                    r = 0;
+                                }
+                                csp = 4;
+                                status = a_src.read_u8(&mut t_2);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_block_size = t_2;
+                            }
+                            if r == 0 || r == 5 {
+                                if r == 5 || (r == 0 && (v_block_size != 3)) {
+                                    if r == 0 || r == 5 {
+                                        if r == 0 {
+                                            self.c_decode_ae.scratch =
+                                                ((v_block_size as u32) as u64);
+                                        }
+                                        if r == 5 {
+                                            r = 0;
+                                        }
+                                        csp = 5;
+                                        status = a_src.skip(&mut self.c_decode_ae.scratch);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 {
+                                        break 'label_0;
+                                    }
+                                }
+                            }
+                            if r == 0 || r == 6 {
+                                if r == 6 {
+                                    r = 0;
+                                }
+                                csp = 6;
+                                status = a_src.read_u8(&mut t_3);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_c = t_3;
+                            }
+                            if r == 0 || r == 7 {
+                                if r == 7 || (r == 0 && (v_c != 1)) {
+                                    if r == 0 || r == 7 {
+                                        if r == 0 {
+                                            self.c_decode_ae.scratch = (2 as u64);
+                                        }
+                                        if r == 7 {
+                                            r = 0;
+                                        }
+                                        csp = 7;
+                                        status = a_src.skip(&mut self.c_decode_ae.scratch);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 {
+                                        break 'label_0;
+                                    }
+                                }
+                            }
+                            if r == 0 || r == 8 {
+                                if r == 0 {
+                                    self.c_decode_ae.scratch = 0;
+                                }
+                                if r == 8 {
+                                    r = 0;
+                                }
+                                csp = 8;
+                                status = a_src.read_u16le(&mut t_4, &mut self.c_decode_ae.scratch);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                self.f_num_loops = (t_4 as u32);
+                            }
+                            if r == 0 {
+                                self.f_seen_num_loops = true;
+                                if (0 < self.f_num_loops) && (self.f_num_loops <= 65535) {
+                                    self.f_num_loops += 1;
+                                }
+                                break 'label_0;
+                            }
+                        }
+                    }
+                    if r == 0 || r == 9 {
+                        if r == 9 {
+                            r = 0;
+                        }
+                        csp = 9;
+                        status = self.skip_blocks(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                }
+                self.c_decode_ae.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_ae.coro_susp_point = csp;
+            self.c_decode_ae.v_c = v_c;
+            self.c_decode_ae.v_block_size = v_block_size;
+            self.c_decode_ae.v_not_animexts = v_not_animexts;
+            self.c_decode_ae.v_not_netscape = v_not_netscape;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_gc
+
+impl Decoder {
+    fn decode_gc(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_flags: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u16 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_gc.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_flags = self.c_decode_gc.v_flags;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_seen_graphic_control {
+                        status = ERROR_BAD_GRAPHIC_CONTROL;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u8(&mut t_0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 4) {
+                        status = ERROR_BAD_GRAPHIC_CONTROL;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u8(&mut t_1);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_flags = t_1;
+                }
+                if r == 0 {
+                    self.f_gc_has_transparent_index = ((v_flags & 1) != 0);
+                    v_flags = ((v_flags >> 2) & 7);
+                    if v_flags == 2 {
+                        self.f_gc_disposal = 1;
+                    } else if (v_flags == 3) || (v_flags == 4) {
+                        self.f_gc_disposal = 2;
+                    } else {
+                        self.f_gc_disposal = 0;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_decode_gc.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u16le(&mut t_2, &mut self.c_decode_gc.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_gc_duration = ((t_2 as u64) * 7056000);
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u8(&mut t_3);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_gc_transparent_index = t_3;
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 || r == 5 {
+                        if r == 5 {
+                            r = 0;
+                        }
+                        csp = 5;
+                        status = a_src.read_u8(&mut t_4);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_4 != 0) {
+                        status = ERROR_BAD_GRAPHIC_CONTROL;
+                        break 'exit;
+                    }
+                }
+                if r == 0 {
+                    self.f_seen_graphic_control = true;
+                }
+                self.c_decode_gc.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_gc.coro_susp_point = csp;
+            self.c_decode_gc.v_flags = v_flags;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_id_part0
+
+impl Decoder {
+    fn decode_id_part0(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_frame_x: u32 = 0;
+        let mut v_frame_y: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u16 = 0;
+        let mut t_2: u16 = 0;
+        let mut t_3: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_id_part0.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_frame_x = self.c_decode_id_part0.v_frame_x;
+            v_frame_y = self.c_decode_id_part0.v_frame_y;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_id_part0.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16le(&mut t_0, &mut self.c_decode_id_part0.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_frame_x = (t_0 as u32);
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_id_part0.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u16le(&mut t_1, &mut self.c_decode_id_part0.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_frame_y = (t_1 as u32);
+                }
+                if r == 0 {
+                    self.f_frame_rect.set_min_inclusive_x(v_frame_x);
+                    self.f_frame_rect.set_min_inclusive_y(v_frame_y);
+                    self.f_dst_x = v_frame_x;
+                    self.f_dst_y = v_frame_y;
+                    self.f_dst_x0 = v_frame_x;
+                    self.f_dst_y0 = v_frame_y;
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_decode_id_part0.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u16le(&mut t_2, &mut self.c_decode_id_part0.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_frame_x = u32::wrapping_add(v_frame_x, (t_2 as u32));
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_decode_id_part0.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u16le(&mut t_3, &mut self.c_decode_id_part0.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_frame_y = u32::wrapping_add(v_frame_y, (t_3 as u32));
+                }
+                if r == 0 {
+                    self.f_frame_rect.set_max_exclusive_x(v_frame_x);
+                    self.f_frame_rect.set_max_exclusive_y(v_frame_y);
+                    self.f_dst_x1 = v_frame_x;
+                    self.f_dst_y1 = v_frame_y;
+                    if self.f_call_sequence == 0 {
+                        self.f_width = u32::max(self.f_width, v_frame_x);
+                        self.f_height = u32::max(self.f_height, v_frame_y);
+                    }
+                }
+                self.c_decode_id_part0.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_id_part0.coro_susp_point = csp;
+            self.c_decode_id_part0.v_frame_x = v_frame_x;
+            self.c_decode_id_part0.v_frame_y = v_frame_y;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_id_part1
+
+impl Decoder {
+    fn decode_id_part1(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_flags: u8 = 0;
+        let mut v_use_local_palette: bool = false;
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_argb: u32 = 0;
+        let mut v_lw: u8 = 0;
+        let mut v_block_size: u64 = 0;
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut t_0: u8 = 0;
+        let mut t_1: u32 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_id_part1.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_flags = self.c_decode_id_part1.v_flags;
+            v_use_local_palette = self.c_decode_id_part1.v_use_local_palette;
+            v_num_palette_entries = self.c_decode_id_part1.v_num_palette_entries;
+            v_i = self.c_decode_id_part1.v_i;
+            v_argb = self.c_decode_id_part1.v_argb;
+            v_lw = self.c_decode_id_part1.v_lw;
+            v_block_size = self.c_decode_id_part1.v_block_size;
+            v_z = self.c_decode_id_part1.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u8(&mut t_0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_flags = t_0;
+                }
+                if r == 0 {
+                    if (v_flags & 64) != 0 {
+                        self.f_interlace = 4;
+                    } else {
+                        self.f_interlace = 0;
+                    }
+                    v_use_local_palette = ((v_flags & 128) != 0);
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 || (r == 0 && v_use_local_palette) {
+                        if r == 0 {
+                            v_num_palette_entries = (1u32 << (1 + (v_flags & 7)));
+                            v_i = 0;
+                        }
+                        if r == 0 || r == 2 {
+                            while r != 0 || (v_i < v_num_palette_entries) {
+                                if r == 0 || r == 2 {
+                                    if r == 0 {
+                                        self.c_decode_id_part1.scratch = 0;
+                                    }
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = a_src
+                                        .read_u24be(&mut t_1, &mut self.c_decode_id_part1.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_argb = (t_1 | 4278190080);
+                                }
+                                if r == 0 {
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(1 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                    }) = (((v_argb >> 0) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(1 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                    }) = (((v_argb >> 8) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(1 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                    }) = (((v_argb >> 16) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palettes
+                                            .get_unchecked_mut(1 as usize)
+                                            .get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                    }) = (((v_argb >> 24) & 255) as u8);
+                                    v_i += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            while v_i < 256 {
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(1 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 0) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(1 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 1) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(1 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 2) as usize)
+                                }) = 0;
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked_mut(1 as usize)
+                                        .get_unchecked_mut(((4 * v_i) + 3) as usize)
+                                }) = 0;
+                                v_i += 1;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if self.f_previous_lzw_decode_ended_abruptly {
+                        self.f_lzw = lzw::Decoder::default();
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u8(&mut t_2);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_lw = t_2;
+                }
+                if r == 0 {
+                    if (v_lw < 2) || (8 < v_lw) {
+                        status = ERROR_BAD_LITERAL_WIDTH;
+                        break 'exit;
+                    }
+                    self.f_lzw.set_literal_width((v_lw as u32));
+                    self.f_previous_lzw_decode_ended_abruptly = true;
+                }
+                if r == 0 || (4 <= r && r <= 6) {
+                    'label_0: loop {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = a_src.read_u8(&mut t_3);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_block_size = (t_3 as u64);
+                        }
+                        if r == 0 {
+                            if v_block_size == 0 {
+                                break 'label_0;
+                            }
+                            self.f_previous_lzw_decode_ended_abruptly = true;
+                        }
+                        if r == 0 || (5 <= r && r <= 6) {
+                            'label_1: loop {
+                                if r == 0 {
+                                    v_w = wuffs_base::IoWriter::default();
+                                    {
+                                        let o_0_a_src = a_src;
+                                        let o_0_v_w = v_w;
+                                        unsafe {
+                                            v_w.set(&mut u_w, unsafe {
+                                                wuffs_base::SliceU8::from_array(
+                                                    &mut self.f_uncompressed,
+                                                )
+                                                .subslice_i(self.f_uncompressed_wi as usize)
+                                            })
+                                        };
+                                        a_src.set_limit(v_block_size);
+                                        a_src.set_mark();
+                                        t_4 = self.f_lzw.decode(v_w, a_src);
+                                        v_z = t_4;
+                                        self.f_uncompressed_wi =
+                                            (4096 - (u64::min(v_w.available(), 4096) as u32));
+                                        v_block_size = u64::saturating_sub(
+                                            v_block_size,
+                                            (a_src.since_mark().len() as u64),
+                                        );
+                                        v_w = o_0_v_w;
+                                        a_src = o_0_a_src;
+                                    }
+                                }
+                                if r == 0 || r == 5 {
+                                    if r == 5
+                                        || (r == 0
+                                            && (v_z.is_ok()
+                                                || (v_z == wuffs_base::SUSPENSION_SHORT_WRITE)))
+                                    {
+                                        if r == 0 || r == 5 {
+                                            if r == 5 {
+                                                r = 0;
+                                            }
+                                            csp = 5;
+                                            status = self.copy_to_image_buffer(a_dst);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if v_z == wuffs_base::SUSPENSION_SHORT_WRITE {
+                                                continue 'label_1;
+                                            }
+                                            self.f_previous_lzw_decode_ended_abruptly = false;
+                                            break 'label_1;
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    if (v_block_size == 0)
+                                        && (v_z == wuffs_base::SUSPENSION_SHORT_READ)
+                                    {
+                                        break 'label_1;
+                                    }
+                                }
+                                if r == 0 || r == 6 {
+                                    status = v_z;
+                                    if r == 6 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        if status.is_error() {
+                                            break 'exit;
+                                        }
+                                        if !status.is_ok() {
+                                            csp = 6;
+                                            break 'suspend;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 || (r == 0 && (self.f_uncompressed_ri != self.f_uncompressed_wi)) {
+                        if r == 0 || r == 7 {
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status = self.copy_to_image_buffer(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_palette = wuffs_base::SliceU8::default();
+                    if v_use_local_palette {
+                        v_palette = unsafe {
+                            wuffs_base::SliceU8::from_array(
+                                &mut (*unsafe { self.f_palettes.get_unchecked_mut(1 as usize) }),
+                            )
+                        };
+                    } else if !self.f_previous_use_global_palette {
+                        v_palette = unsafe {
+                            wuffs_base::SliceU8::from_array(
+                                &mut (*unsafe { self.f_palettes.get_unchecked_mut(0 as usize) }),
+                            )
+                        };
+                    }
+                    a_dst.update(
+                        self.f_frame_rect,
+                        self.f_gc_duration,
+                        true,
+                        self.f_gc_disposal,
+                        v_palette,
+                    );
+                    self.f_previous_use_global_palette = !v_use_local_palette;
+                    self.f_seen_graphic_control = false;
+                    self.f_gc_has_transparent_index = false;
+                    self.f_gc_transparent_index = 0;
+                    self.f_gc_disposal = 0;
+                    self.f_gc_duration = 0;
+                }
+                self.c_decode_id_part1.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_id_part1.coro_susp_point = csp;
+            self.c_decode_id_part1.v_flags = v_flags;
+            self.c_decode_id_part1.v_use_local_palette = v_use_local_palette;
+            self.c_decode_id_part1.v_num_palette_entries = v_num_palette_entries;
+            self.c_decode_id_part1.v_i = v_i;
+            self.c_decode_id_part1.v_argb = v_argb;
+            self.c_decode_id_part1.v_lw = v_lw;
+            self.c_decode_id_part1.v_block_size = v_block_size;
+            self.c_decode_id_part1.v_z = v_z;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.copy_to_image_buffer
+
+impl Decoder {
+    fn copy_to_image_buffer(
+        &mut self,
+        mut a_ib: &mut wuffs_base::ImageBuffer,
+    ) -> wuffs_base::Status {
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_src: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_n: u32 = 0;
+        let mut v_new_ri: u32 = 0;
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_dst = wuffs_base::SliceU8::default();
+            v_src = wuffs_base::SliceU8::default();
+            v_n = 0;
+            v_new_ri = 0;
+            v_tab = a_ib.plane(0);
+            'label_0: while self.f_uncompressed_wi > self.f_uncompressed_ri {
+                v_src = unsafe {
+                    wuffs_base::SliceU8::from_array(&mut self.f_uncompressed).subslice_ij(
+                        self.f_uncompressed_ri as usize,
+                        self.f_uncompressed_wi as usize,
+                    )
+                };
+                if self.f_dst_y >= self.f_dst_y1 {
+                    status = ERROR_TOO_MUCH_PIXEL_DATA;
+                    break 'exit;
+                }
+                v_dst = v_tab.row(self.f_dst_y);
+                if (self.f_dst_x as u64) < (v_dst.len() as u64) {
+                    if ((self.f_dst_x as u64) <= (self.f_dst_x1 as u64))
+                        && ((self.f_dst_x1 as u64) <= (v_dst.len() as u64))
+                    {
+                        v_dst = unsafe {
+                            v_dst.subslice_ij(
+                                (self.f_dst_x as u64) as usize,
+                                (self.f_dst_x1 as u64) as usize,
+                            )
+                        };
+                    } else {
+                        v_dst = unsafe { v_dst.subslice_i((self.f_dst_x as u64) as usize) };
+                    }
+                    v_n = ((v_dst.copy_from_slice(v_src) & 4294967295) as u32);
+                    v_new_ri = u32::saturating_add(self.f_uncompressed_ri, v_n);
+                    self.f_uncompressed_ri = u32::min(v_new_ri, 4096);
+                    self.f_dst_x = u32::saturating_add(self.f_dst_x, v_n);
+                }
+                if self.f_dst_x1 <= self.f_dst_x {
+                    self.f_dst_x = self.f_dst_x0;
+                    self.advance_dst_y();
+                    continue 'label_0;
+                }
+                if self.f_uncompressed_wi == self.f_uncompressed_ri {
+                    break 'label_0;
+                } else if self.f_uncompressed_wi < self.f_uncompressed_ri {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI;
+                    break 'exit;
+                }
+                v_n = (self.f_dst_x1 - self.f_dst_x);
+                v_n = u32::min(v_n, (self.f_uncompressed_wi - self.f_uncompressed_ri));
+                v_new_ri = u32::saturating_add(self.f_uncompressed_ri, v_n);
+                self.f_uncompressed_ri = u32::min(v_new_ri, 4096);
+                self.f_dst_x = u32::saturating_add(self.f_dst_x, v_n);
+                if self.f_dst_x1 <= self.f_dst_x {
+                    self.f_dst_x = self.f_dst_x0;
+                    self.advance_dst_y();
+                    continue 'label_0;
+                }
+                if self.f_uncompressed_ri != self.f_uncompressed_wi {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_RI_WI;
+                    break 'exit;
+                }
+                break 'label_0;
+            }
+            self.f_uncompressed_ri = 0;
+            self.f_uncompressed_wi = 0;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.advance_dst_y
+
+impl Decoder {
+    fn advance_dst_y(&mut self) {
+        self.f_dst_y = u32::wrapping_add(
+            self.f_dst_y,
+            ((*unsafe { INTERLACE_DELTA.get_unchecked(self.f_interlace as usize) }) as u32),
+        );
+        while (self.f_interlace > 1) && (self.f_dst_y >= self.f_dst_y1) {
+            self.f_interlace -= 1;
+            self.f_dst_y = u32::wrapping_add(
+                self.f_dst_y0,
+                (*unsafe { INTERLACE_START.get_unchecked(self.f_interlace as usize) }),
+            );
+        }
+    }
+}
+
+// -------- func encoder.encode_config
+
+impl Encoder {
+    pub fn encode_config(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: &mut wuffs_base::ImageConfig,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+
+        let mut v_width: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_width = self.c_encode_config.v_width;
+            v_height = self.c_encode_config.v_height;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                    v_width = a_src.width();
+                    v_height = a_src.height();
+                    if (v_width > 65535) || (v_height > 65535) {
+                        status = ERROR_UNSUPPORTED_IMAGE_DIMENSIONS;
+                        break 'exit;
+                    }
+                    self.f_width = v_width;
+                    self.f_height = v_height;
+                    self.f_num_loops = a_src.num_loops();
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_encode_config.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u48be(78380036274529, &mut self.c_encode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_call_sequence = 1;
+                }
+                self.c_encode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_config.coro_susp_point = csp;
+            self.c_encode_config.v_width = v_width;
+            self.c_encode_config.v_height = v_height;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.encode_frame
+
+impl Encoder {
+    pub fn encode_frame(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: &mut wuffs_base::ImageBuffer,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if (self.f_call_sequence == 0) || (self.f_call_sequence >= 3) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                    v_tab = a_src.plane(0);
+                    if (v_tab.width as u64) != (self.f_width as u64) {
+                        status = ERROR_UNSUPPORTED_PIXEL_FORMAT;
+                        break 'exit;
+                    }
+                    self.prepare_frame(a_src);
+                }
+                if r == 0 || (1 <= r && r <= 2) {
+                    if (1 <= r && r <= 2) || (r == 0 && (self.f_call_sequence == 1)) {
+                        if r == 0 {
+                            self.f_global_palette_bits = self.f_palette_bits;
+                            unsafe {
+                                wuffs_base::SliceU8::from_array(
+                                    &mut (*unsafe {
+                                        self.f_palettes.get_unchecked_mut(0 as usize)
+                                    }),
+                                )
+                            }
+                            .copy_from_slice(unsafe {
+                                wuffs_base::SliceU8::from_array(
+                                    &mut (*unsafe {
+                                        self.f_palettes.get_unchecked_mut(1 as usize)
+                                    }),
+                                )
+                            });
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.encode_lsd(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 || (r == 0 && (self.f_num_loops != 1)) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.encode_ae(a_dst);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            self.f_call_sequence = 2;
+                        }
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.encode_gc(a_dst, a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = self.encode_id(a_dst);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = self.encode_pixels(a_dst, a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_encode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_frame.coro_susp_point = csp;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.encode_trailer
+
+impl Encoder {
+    pub fn encode_trailer(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_trailer.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if (self.f_call_sequence == 0) || (self.f_call_sequence >= 3) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 || (r == 0 && (self.f_call_sequence == 1)) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.encode_lsd(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_dst.write_u8(59);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_call_sequence = 3;
+                }
+                self.c_encode_trailer.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_trailer.coro_susp_point = csp;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.prepare_frame
+
+impl Encoder {
+    fn prepare_frame(&mut self, mut a_src: &mut wuffs_base::ImageBuffer) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_max_index: u8 = 0;
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_y: u32 = 0;
+        let mut v_bits: u32 = 0;
+
+        v_tab = a_src.plane(0);
+        self.f_frame_x1 = u32::min(a_src.dirty_rect().max_exclusive_x, self.f_width);
+        self.f_frame_y1 = u32::min(a_src.dirty_rect().max_exclusive_y, self.f_height);
+        self.f_frame_y1 = u32::min(
+            self.f_frame_y1,
+            (u64::min((v_tab.height as u64), 65535) as u32),
+        );
+        self.f_frame_x0 = u32::min(a_src.dirty_rect().min_inclusive_x, self.f_frame_x1);
+        self.f_frame_y0 = u32::min(a_src.dirty_rect().min_inclusive_y, self.f_frame_y1);
+        self.f_src_x = self.f_frame_x0;
+        self.f_src_y = self.f_frame_y0;
+        v_max_index = 0;
+        v_row = wuffs_base::SliceU8::default();
+        v_y = self.f_frame_y0;
+        while v_y < self.f_frame_y1 {
+            v_row = v_tab.row(v_y);
+            if ((self.f_frame_x0 as u64) <= (self.f_frame_x1 as u64))
+                && ((self.f_frame_x1 as u64) <= (v_row.len() as u64))
+            {
+                v_row = unsafe {
+                    v_row.subslice_ij(
+                        (self.f_frame_x0 as u64) as usize,
+                        (self.f_frame_x1 as u64) as usize,
+                    )
+                };
+            }
+            {
+                let i_slice_p = v_row;
+                let mut v_p = wuffs_base::SliceU8::default();
+                let mut i_p: usize = 0;
+                let i_end0_p = (i_slice_p.len() / 1) * 1;
+                while i_p < i_end0_p {
+                    v_p = unsafe { i_slice_p.subslice_ij(i_p, i_p + 1) };
+                    v_max_index = u8::max(v_max_index, (*unsafe { v_p.get_unchecked(0 as usize) }));
+                    i_p += 1;
+                }
+            }
+            v_y = u32::saturating_add(v_y, 1);
+        }
+        v_bits = 1;
+        while (v_bits < 8) && ((1u32 << v_bits) <= (v_max_index as u32)) {
+            v_bits += 1;
+        }
+        self.f_palette_bits = v_bits;
+        unsafe {
+            wuffs_base::SliceU8::from_array(
+                &mut (*unsafe { self.f_palettes.get_unchecked_mut(1 as usize) }),
+            )
+        }
+        .copy_from_slice(a_src.palette_slice());
+    }
+}
+
+// -------- func encoder.encode_lsd
+
+impl Encoder {
+    fn encode_lsd(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_lsd.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_encode_lsd.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_dst
+                            .write_u16le((self.f_width as u16), &mut self.c_encode_lsd.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 2 {
+                        if r == 0 {
+                            self.c_encode_lsd.scratch = 0;
+                        }
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_dst
+                            .write_u16le((self.f_height as u16), &mut self.c_encode_lsd.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 3 {
+                        if r == 3 || (r == 0 && (self.f_global_palette_bits == 0)) {
+                            if r == 0 || r == 3 {
+                                if r == 0 {
+                                    self.c_encode_lsd.scratch = 0;
+                                }
+                                if r == 3 {
+                                    r = 0;
+                                }
+                                csp = 3;
+                                status = a_dst.write_u24be(0, &mut self.c_encode_lsd.scratch);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                status = wuffs_base::Status::Ok;
+                                break 'ok;
+                            }
+                        }
+                    }
+                    if r == 0 || r == 4 {
+                        if r == 4 {
+                            r = 0;
+                        }
+                        csp = 4;
+                        status = a_dst.write_u8(
+                            (240 | ((u32::wrapping_sub(self.f_global_palette_bits, 1) & 7) as u8)),
+                        );
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 5 {
+                        if r == 0 {
+                            self.c_encode_lsd.scratch = 0;
+                        }
+                        if r == 5 {
+                            r = 0;
+                        }
+                        csp = 5;
+                        status = a_dst.write_u16be(0, &mut self.c_encode_lsd.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 6 {
+                        if r == 6 {
+                            r = 0;
+                        }
+                        csp = 6;
+                        status = self.encode_palette(a_dst);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                }
+                self.c_encode_lsd.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_lsd.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.encode_palette
+
+impl Encoder {
+    fn encode_palette(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_palette.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_palette_entries = self.c_encode_palette.v_num_palette_entries;
+            v_i = self.c_encode_palette.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_num_palette_entries = (1u32 << self.f_palette_bits);
+                    v_i = 0;
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    while r != 0 || (v_i < v_num_palette_entries) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_dst.write_u8(
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked(1 as usize)
+                                        .get_unchecked(((4 * v_i) + 2) as usize)
+                                }),
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_dst.write_u8(
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked(1 as usize)
+                                        .get_unchecked(((4 * v_i) + 1) as usize)
+                                }),
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = a_dst.write_u8(
+                                (*unsafe {
+                                    self.f_palettes
+                                        .get_unchecked(1 as usize)
+                                        .get_unchecked(((4 * v_i) + 0) as usize)
+                                }),
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                self.c_encode_palette.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_palette.coro_susp_point = csp;
+            self.c_encode_palette.v_num_palette_entries = v_num_palette_entries;
+            self.c_encode_palette.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.encode_ae
+
+impl Encoder {
+    fn encode_ae(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_num_loops: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_ae.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_loops = self.c_encode_ae.v_num_loops;
+            v_i = self.c_encode_ae.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_num_loops = self.f_num_loops;
+                    if v_num_loops > 0 {
+                        v_num_loops -= 1;
+                        v_num_loops = u32::min(v_num_loops, 65535);
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_encode_ae.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u24be(2227979, &mut self.c_encode_ae.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_i = 0;
+                }
+                if r == 0 || r == 2 {
+                    while r != 0 || (v_i < 11) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_dst
+                                .write_u8((*unsafe { NETSCAPE2DOT0.get_unchecked(v_i as usize) }));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_encode_ae.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_dst.write_u16be(769, &mut self.c_encode_ae.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_encode_ae.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_dst.write_u16le(
+                        ((v_num_loops & 65535) as u16),
+                        &mut self.c_encode_ae.scratch,
+                    );
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_dst.write_u8(0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_encode_ae.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_ae.coro_susp_point = csp;
+            self.c_encode_ae.v_num_loops = v_num_loops;
+            self.c_encode_ae.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.encode_gc
+
+impl Encoder {
+    fn encode_gc(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: &mut wuffs_base::ImageBuffer,
+    ) -> wuffs_base::Status {
+        let mut v_flags: u8 = 0;
+        let mut v_disposal: u8 = 0;
+        let mut v_transparent_index: u8 = 0;
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_centiseconds: u64 = 0;
+        let mut v_duration: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_gc.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_flags = self.c_encode_gc.v_flags;
+            v_disposal = self.c_encode_gc.v_disposal;
+            v_transparent_index = self.c_encode_gc.v_transparent_index;
+            v_num_palette_entries = self.c_encode_gc.v_num_palette_entries;
+            v_i = self.c_encode_gc.v_i;
+            v_centiseconds = self.c_encode_gc.v_centiseconds;
+            v_duration = self.c_encode_gc.v_duration;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        v_flags = 0;
+                        v_disposal = a_src.disposal();
+                        if v_disposal == 1 {
+                            v_flags = 8;
+                        } else if v_disposal == 2 {
+                            v_flags = 12;
+                        }
+                        v_transparent_index = 0;
+                        v_num_palette_entries = (1u32 << self.f_palette_bits);
+                        v_i = 0;
+                        'label_0: while v_i < v_num_palette_entries {
+                            if (*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(1 as usize)
+                                    .get_unchecked(((4 * v_i) + 3) as usize)
+                            }) == 0
+                            {
+                                v_flags |= 1;
+                                v_transparent_index = ((v_i & 255) as u8);
+                                break 'label_0;
+                            }
+                            v_i += 1;
+                        }
+                        v_centiseconds = (u64::saturating_add(a_src.duration(), 3528000) / 7056000);
+                        v_duration = u64::min(v_centiseconds, 65535);
+                        if (v_flags == 0) && (v_duration == 0) {
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                    }
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_encode_gc.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_dst.write_u24be(2226436, &mut self.c_encode_gc.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 2 {
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_dst.write_u8(v_flags);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 3 {
+                        if r == 0 {
+                            self.c_encode_gc.scratch = 0;
+                        }
+                        if r == 3 {
+                            r = 0;
+                        }
+                        csp = 3;
+                        status =
+                            a_dst.write_u16le((v_duration as u16), &mut self.c_encode_gc.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 4 {
+                        if r == 4 {
+                            r = 0;
+                        }
+                        csp = 4;
+                        status = a_dst.write_u8(v_transparent_index);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 5 {
+                        if r == 5 {
+                            r = 0;
+                        }
+                        csp = 5;
+                        status = a_dst.write_u8(0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                }
+                self.c_encode_gc.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_gc.coro_susp_point = csp;
+            self.c_encode_gc.v_flags = v_flags;
+            self.c_encode_gc.v_disposal = v_disposal;
+            self.c_encode_gc.v_transparent_index = v_transparent_index;
+            self.c_encode_gc.v_num_palette_entries = v_num_palette_entries;
+            self.c_encode_gc.v_i = v_i;
+            self.c_encode_gc.v_centiseconds = v_centiseconds;
+            self.c_encode_gc.v_duration = v_duration;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.encode_id
+
+impl Encoder {
+    fn encode_id(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_use_global_palette: bool = false;
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_id.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_use_global_palette = self.c_encode_id.v_use_global_palette;
+            v_num_palette_entries = self.c_encode_id.v_num_palette_entries;
+            v_i = self.c_encode_id.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_use_global_palette = (self.f_palette_bits <= self.f_global_palette_bits);
+                    if v_use_global_palette {
+                        v_num_palette_entries = (1u32 << self.f_palette_bits);
+                        v_i = 0;
+                        'label_0: while v_i < v_num_palette_entries {
+                            if ((*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(0 as usize)
+                                    .get_unchecked(((4 * v_i) + 0) as usize)
+                            }) != (*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(1 as usize)
+                                    .get_unchecked(((4 * v_i) + 0) as usize)
+                            })) || ((*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(0 as usize)
+                                    .get_unchecked(((4 * v_i) + 1) as usize)
+                            }) != (*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(1 as usize)
+                                    .get_unchecked(((4 * v_i) + 1) as usize)
+                            })) || ((*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(0 as usize)
+                                    .get_unchecked(((4 * v_i) + 2) as usize)
+                            }) != (*unsafe {
+                                self.f_palettes
+                                    .get_unchecked(1 as usize)
+                                    .get_unchecked(((4 * v_i) + 2) as usize)
+                            })) {
+                                v_use_global_palette = false;
+                                break 'label_0;
+                            }
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u8(44);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_encode_id.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status =
+                        a_dst.write_u16le((self.f_frame_x0 as u16), &mut self.c_encode_id.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_encode_id.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status =
+                        a_dst.write_u16le((self.f_frame_y0 as u16), &mut self.c_encode_id.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_encode_id.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_dst.write_u16le(
+                        ((u32::wrapping_sub(self.f_frame_x1, self.f_frame_x0) & 65535) as u16),
+                        &mut self.c_encode_id.scratch,
+                    );
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 {
+                        self.c_encode_id.scratch = 0;
+                    }
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_dst.write_u16le(
+                        ((u32::wrapping_sub(self.f_frame_y1, self.f_frame_y0) & 65535) as u16),
+                        &mut self.c_encode_id.scratch,
+                    );
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || (6 <= r && r <= 8) {
+                    if r == 6 || (r == 0 && v_use_global_palette) {
+                        if r == 0 || r == 6 {
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = a_dst.write_u8(0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || r == 7 {
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status = a_dst.write_u8(
+                                (128 | ((u32::wrapping_sub(self.f_palette_bits, 1) & 7) as u8)),
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = self.encode_palette(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                self.c_encode_id.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_id.coro_susp_point = csp;
+            self.c_encode_id.v_use_global_palette = v_use_global_palette;
+            self.c_encode_id.v_num_palette_entries = v_num_palette_entries;
+            self.c_encode_id.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.encode_pixels
+
+impl Encoder {
+    fn encode_pixels(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: &mut wuffs_base::ImageBuffer,
+    ) -> wuffs_base::Status {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_last_row: bool = false;
+        let mut v_r: wuffs_base::IoReader = wuffs_base::IoReader::default();
+        let mut u_r = wuffs_base::IoBuffer::default();
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode_pixels.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_last_row = self.c_encode_pixels.v_last_row;
+            v_z = self.c_encode_pixels.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || (1 <= r && r <= 2) {
+                    if r == 1 || (r == 0 && (self.f_palette_bits > 2)) {
+                        if r == 0 {
+                            self.f_lzw.set_literal_width(self.f_palette_bits);
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_dst.write_u8((self.f_palette_bits as u8));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 {
+                            self.f_lzw.set_literal_width(2);
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_dst.write_u8(2);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || (3 <= r && r <= 4) {
+                    'label_0: loop {
+                        if r == 0 {
+                            v_tab = a_src.plane(0);
+                            v_row = wuffs_base::SliceU8::default();
+                            if self.f_src_y < self.f_frame_y1 {
+                                v_row = v_tab.row(self.f_src_y);
+                                if ((self.f_src_x as u64) <= (self.f_frame_x1 as u64))
+                                    && ((self.f_frame_x1 as u64) <= (v_row.len() as u64))
+                                {
+                                    v_row = unsafe {
+                                        v_row.subslice_ij(
+                                            (self.f_src_x as u64) as usize,
+                                            (self.f_frame_x1 as u64) as usize,
+                                        )
+                                    };
+                                } else {
+                                    v_row = unsafe { v_row.subslice_j(0 as usize) };
+                                }
+                            }
+                            v_last_row = (self.f_frame_y1 <= u32::saturating_add(self.f_src_y, 1));
+                            v_r = wuffs_base::IoReader::default();
+                            v_w = wuffs_base::IoWriter::default();
+                            {
+                                let o_0_v_r = v_r;
+                                let o_0_v_w = v_w;
+                                unsafe { v_r.set(&mut u_r, v_row, v_last_row) };
+                                unsafe {
+                                    v_w.set(&mut u_w, unsafe {
+                                        wuffs_base::SliceU8::from_array(&mut self.f_block)
+                                            .subslice_i(self.f_block_wi as usize)
+                                    })
+                                };
+                                t_0 = self.f_lzw.encode(v_w, v_r);
+                                v_z = t_0;
+                                self.f_src_x = u32::saturating_add(
+                                    self.f_src_x,
+                                    ((u64::saturating_sub((v_row.len() as u64), v_r.available())
+                                        & 4294967295) as u32),
+                                );
+                                self.f_block_wi = (255 - (u64::min(v_w.available(), 255) as u32));
+                                v_w = o_0_v_w;
+                                v_r = o_0_v_r;
+                            }
+                        }
+                        if r == 0 || (3 <= r && r <= 4) {
+                            if r == 0 && v_z.is_ok() {
+                                break 'label_0;
+                            } else if r == 0 && (v_z == wuffs_base::SUSPENSION_SHORT_READ) {
+                                self.f_src_x = self.f_frame_x0;
+                                self.f_src_y = u32::saturating_add(self.f_src_y, 1);
+                            } else if r == 3
+                                || (r == 0 && (v_z == wuffs_base::SUSPENSION_SHORT_WRITE))
+                            {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.write_block(a_dst);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else {
+                                if r == 0 || r == 4 {
+                                    status = v_z;
+                                    if r == 4 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        if status.is_error() {
+                                            break 'exit;
+                                        }
+                                        if !status.is_ok() {
+                                            csp = 4;
+                                            break 'suspend;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 || (r == 0 && (self.f_block_wi > 0)) {
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.write_block(a_dst);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_dst.write_u8(0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_encode_pixels.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode_pixels.coro_susp_point = csp;
+            self.c_encode_pixels.v_last_row = v_last_row;
+            self.c_encode_pixels.v_z = v_z;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.write_block
+
+impl Encoder {
+    fn write_block(&mut self, mut a_dst: wuffs_base::IoWriter) -> wuffs_base::Status {
+        let mut v_n: u64 = 0;
+        let mut v_new_ri: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_block.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_n = self.c_write_block.v_n;
+            v_new_ri = self.c_write_block.v_new_ri;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u8((self.f_block_wi as u8));
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    while r != 0 || (self.f_block_ri < self.f_block_wi) {
+                        if r == 0 {
+                            v_n = a_dst.copy_from_slice(unsafe {
+                                wuffs_base::SliceU8::from_array(&mut self.f_block)
+                                    .subslice_ij(self.f_block_ri as usize, self.f_block_wi as usize)
+                            });
+                            v_new_ri =
+                                u32::saturating_add(self.f_block_ri, (u64::min(v_n, 255) as u32));
+                            self.f_block_ri = u32::min(v_new_ri, 255);
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 || (r == 0 && (self.f_block_ri < self.f_block_wi)) {
+                                if r == 0 || r == 2 {
+                                    status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                    if r == 2 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 2;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_block_ri = 0;
+                    self.f_block_wi = 0;
+                }
+                self.c_write_block.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_block.coro_susp_point = csp;
+            self.c_write_block.v_n = v_n;
+            self.c_write_block.v_new_ri = v_new_ri;
+        }
+
+        status
+    }
+}
@@ -0,0 +1,1083 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package gzip is the Rust translation of the Wuffs std/gzip package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_crc32 as crc32;
+use wuffs_std_deflate as deflate;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_CHECKSUM: wuffs_base::Status = wuffs_base::Status::Error("gzip: bad checksum");
+pub const ERROR_BAD_COMPRESSION_METHOD: wuffs_base::Status =
+    wuffs_base::Status::Error("gzip: bad compression method");
+pub const ERROR_BAD_ENCODING_FLAGS: wuffs_base::Status =
+    wuffs_base::Status::Error("gzip: bad encoding flags");
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("gzip: bad header");
+pub const ERROR_BAD_HEADER_CHECKSUM: wuffs_base::Status =
+    wuffs_base::Status::Error("gzip: bad header checksum");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a gzip.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_flate: deflate::Decoder,
+    f_checksum: crc32::IeeeHasher,
+    f_ignore_checksum: bool,
+    f_multi_member: bool,
+    f_mtime: u32,
+    f_os: u8,
+    f_fname: [u8; 255],
+    f_fname_length: u32,
+    f_fcomment: [u8; 1024],
+    f_fcomment_length: u32,
+    f_header_hasher: crc32::IeeeHasher,
+    f_header_checksum: u32,
+    f_hash_header: bool,
+    f_hbuf: [u8; 4],
+    c_decode: DecoderDecodeCoro,
+    c_peek_u8: DecoderPeekU8Coro,
+    c_decode_member: DecoderDecodeMemberCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_flate: deflate::Decoder::default(),
+            f_checksum: crc32::IeeeHasher::default(),
+            f_ignore_checksum: false,
+            f_multi_member: false,
+            f_mtime: 0,
+            f_os: 0,
+            f_fname: [0; 255],
+            f_fname_length: 0,
+            f_fcomment: [0; 1024],
+            f_fcomment_length: 0,
+            f_header_hasher: crc32::IeeeHasher::default(),
+            f_header_checksum: 0,
+            f_hash_header: false,
+            f_hbuf: [0; 4],
+            c_decode: DecoderDecodeCoro::default(),
+            c_peek_u8: DecoderPeekU8Coro::default(),
+            c_decode_member: DecoderDecodeMemberCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeCoro {
+    coro_susp_point: u32,
+    v_z: wuffs_base::Status,
+}
+
+impl Default for DecoderDecodeCoro {
+    fn default() -> Self {
+        DecoderDecodeCoro {
+            coro_susp_point: 0,
+            v_z: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+struct DecoderPeekU8Coro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderPeekU8Coro {
+    fn default() -> Self {
+        DecoderPeekU8Coro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderDecodeMemberCoro {
+    coro_susp_point: u32,
+    v_flags: u8,
+    v_xfl: u8,
+    v_c: u8,
+    v_truncated: bool,
+    v_xlen: u16,
+    v_header_checksum_want: u16,
+    v_checksum_got: u32,
+    v_decoded_length_got: u32,
+    v_z: wuffs_base::Status,
+    v_checksum_want: u32,
+    v_decoded_length_want: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeMemberCoro {
+    fn default() -> Self {
+        DecoderDecodeMemberCoro {
+            coro_susp_point: 0,
+            v_flags: 0,
+            v_xfl: 0,
+            v_c: 0,
+            v_truncated: false,
+            v_xlen: 0,
+            v_header_checksum_want: 0,
+            v_checksum_got: 0,
+            v_decoded_length_got: 0,
+            v_z: wuffs_base::Status::Ok,
+            v_checksum_want: 0,
+            v_decoded_length_want: 0,
+            scratch: 0,
+        }
+    }
+}
+
+/// Encoder holds the state of a gzip.encoder. Its default value is ready to use.
+pub struct Encoder {
+    status: wuffs_base::Status,
+    f_flate: deflate::Encoder,
+    f_checksum: crc32::IeeeHasher,
+    f_level: u32,
+    f_level_set: bool,
+    c_encode: EncoderEncodeCoro,
+}
+
+impl Default for Encoder {
+    fn default() -> Self {
+        Encoder {
+            status: wuffs_base::Status::Ok,
+            f_flate: deflate::Encoder::default(),
+            f_checksum: crc32::IeeeHasher::default(),
+            f_level: 0,
+            f_level_set: false,
+            c_encode: EncoderEncodeCoro::default(),
+        }
+    }
+}
+
+struct EncoderEncodeCoro {
+    coro_susp_point: u32,
+    v_xfl: u8,
+    v_checksum: u32,
+    v_isize: u32,
+    v_z: wuffs_base::Status,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeCoro {
+    fn default() -> Self {
+        EncoderEncodeCoro {
+            coro_susp_point: 0,
+            v_xfl: 0,
+            v_checksum: 0,
+            v_isize: 0,
+            v_z: wuffs_base::Status::Ok,
+            scratch: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+impl Decoder {
+    pub fn set_ignore_checksum(&mut self, mut a_ic: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_ignore_checksum = a_ic;
+    }
+}
+
+// -------- func decoder.set_multi_member
+
+impl Decoder {
+    pub fn set_multi_member(&mut self, mut a_mm: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_multi_member = a_mm;
+    }
+}
+
+// -------- func decoder.modification_time
+
+impl Decoder {
+    pub fn modification_time(&mut self) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_mtime;
+    }
+}
+
+// -------- func decoder.operating_system
+
+impl Decoder {
+    pub fn operating_system(&mut self) -> u8 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_os;
+    }
+}
+
+// -------- func decoder.file_name
+
+impl Decoder {
+    pub fn file_name(&mut self) -> wuffs_base::SliceU8<'_> {
+        if self.status.is_error() {
+            return wuffs_base::SliceU8::default();
+        }
+
+        return unsafe {
+            wuffs_base::SliceU8::from_array(&mut self.f_fname)
+                .subslice_j(self.f_fname_length as usize)
+        };
+    }
+}
+
+// -------- func decoder.comment
+
+impl Decoder {
+    pub fn comment(&mut self) -> wuffs_base::SliceU8<'_> {
+        if self.status.is_error() {
+            return wuffs_base::SliceU8::default();
+        }
+
+        return unsafe {
+            wuffs_base::SliceU8::from_array(&mut self.f_fcomment)
+                .subslice_j(self.f_fcomment_length as usize)
+        };
+    }
+}
+
+// -------- func decoder.decode
+
+impl Decoder {
+    pub fn decode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_z = self.c_decode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || (1 <= r && r <= 2) {
+                        loop {
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = self.decode_member(a_dst, a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                if !self.f_multi_member {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                'label_0: loop {
+                                    if r == 0 {
+                                        t_0 = self.peek_u8(a_src);
+                                        v_z = t_0;
+                                        if v_z.is_ok() {
+                                            break 'label_0;
+                                        } else if v_z.is_error() {
+                                            status = wuffs_base::Status::Ok;
+                                            break 'ok;
+                                        }
+                                    }
+                                    if r == 0 || r == 2 {
+                                        status = v_z;
+                                        if r == 2 {
+                                            r = 0;
+                                            status = wuffs_base::Status::Ok;
+                                        } else {
+                                            if status.is_error() {
+                                                break 'exit;
+                                            }
+                                            if !status.is_ok() {
+                                                csp = 2;
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                self.f_flate = deflate::Decoder::default();
+                                self.f_checksum = crc32::IeeeHasher::default();
+                            }
+                        }
+                    }
+                }
+                self.c_decode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode.coro_susp_point = csp;
+            self.c_decode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.peek_u8
+
+impl Decoder {
+    fn peek_u8(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_peek_u8.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_peek_u8.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u8(&mut t_0);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_c = t_0;
+                }
+                if r == 0 {
+                    status = a_src.unread_u8();
+                    if !status.is_ok() {
+                        break 'exit;
+                    }
+                }
+                self.c_peek_u8.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_peek_u8.coro_susp_point = csp;
+            self.c_peek_u8.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_member
+
+impl Decoder {
+    fn decode_member(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        let mut v_flags: u8 = 0;
+        let mut v_xfl: u8 = 0;
+        let mut v_c: u8 = 0;
+        let mut v_truncated: bool = false;
+        let mut v_xlen: u16 = 0;
+        let mut v_header_checksum_want: u16 = 0;
+        let mut v_checksum_got: u32 = 0;
+        let mut v_decoded_length_got: u32 = 0;
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut v_checksum_want: u32 = 0;
+        let mut v_decoded_length_want: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u32 = 0;
+        let mut t_5: u8 = 0;
+        let mut t_6: u8 = 0;
+        let mut t_7: u16 = 0;
+        let mut t_8: u8 = 0;
+        let mut t_9: u8 = 0;
+        let mut t_10: u8 = 0;
+        let mut t_11: u16 = 0;
+        let mut t_12: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_13: u32 = 0;
+        let mut t_14: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_member.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_flags = self.c_decode_member.v_flags;
+            v_xfl = self.c_decode_member.v_xfl;
+            v_c = self.c_decode_member.v_c;
+            v_truncated = self.c_decode_member.v_truncated;
+            v_xlen = self.c_decode_member.v_xlen;
+            v_header_checksum_want = self.c_decode_member.v_header_checksum_want;
+            v_checksum_got = self.c_decode_member.v_checksum_got;
+            v_decoded_length_got = self.c_decode_member.v_decoded_length_got;
+            v_z = self.c_decode_member.v_z;
+            v_checksum_want = self.c_decode_member.v_checksum_want;
+            v_decoded_length_want = self.c_decode_member.v_decoded_length_want;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_mtime = 0;
+                    self.f_os = 0;
+                    self.f_fname_length = 0;
+                    self.f_fcomment_length = 0;
+                    self.f_header_checksum = 0;
+                    self.f_header_hasher = crc32::IeeeHasher::default();
+                    self.f_hash_header = false;
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u8(&mut t_0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 31) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 || r == 2 {
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_src.read_u8(&mut t_1);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_1 != 139) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 || r == 3 {
+                        if r == 3 {
+                            r = 0;
+                        }
+                        csp = 3;
+                        status = a_src.read_u8(&mut t_2);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_2 != 8) {
+                        status = ERROR_BAD_COMPRESSION_METHOD;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u8(&mut t_3);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_flags = t_3;
+                }
+                if r == 0 {
+                    if (v_flags & 224) != 0 {
+                        status = ERROR_BAD_ENCODING_FLAGS;
+                        break 'exit;
+                    }
+                    self.f_hash_header = ((v_flags & 2) != 0);
+                    self.hash(559903, 3);
+                    self.hash((v_flags as u32), 1);
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 {
+                        self.c_decode_member.scratch = 0;
+                    }
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u32le(&mut t_4, &mut self.c_decode_member.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_mtime = t_4;
+                }
+                if r == 0 {
+                    self.hash(self.f_mtime, 4);
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_src.read_u8(&mut t_5);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_xfl = t_5;
+                }
+                if r == 0 {
+                    self.hash((v_xfl as u32), 1);
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = a_src.read_u8(&mut t_6);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_os = t_6;
+                }
+                if r == 0 {
+                    self.hash((self.f_os as u32), 1);
+                    v_c = 0;
+                    v_truncated = false;
+                }
+                if r == 0 || (8 <= r && r <= 10) {
+                    if (8 <= r && r <= 10) || (r == 0 && ((v_flags & 4) != 0)) {
+                        if r == 0 || r == 8 {
+                            if r == 0 {
+                                self.c_decode_member.scratch = 0;
+                            }
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = a_src.read_u16le(&mut t_7, &mut self.c_decode_member.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_xlen = t_7;
+                        }
+                        if r == 0 {
+                            self.hash((v_xlen as u32), 2);
+                        }
+                        if r == 0 || (9 <= r && r <= 10) {
+                            if r == 9 || (r == 0 && self.f_hash_header) {
+                                if r == 0 || r == 9 {
+                                    while r != 0 || (v_xlen > 0) {
+                                        if r == 0 || r == 9 {
+                                            if r == 9 {
+                                                r = 0;
+                                            }
+                                            csp = 9;
+                                            status = a_src.read_u8(&mut t_8);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_c = t_8;
+                                        }
+                                        if r == 0 {
+                                            self.hash((v_c as u32), 1);
+                                            v_xlen -= 1;
+                                        }
+                                    }
+                                }
+                            } else {
+                                if r == 0 || r == 10 {
+                                    if r == 0 {
+                                        self.c_decode_member.scratch = ((v_xlen as u32) as u64);
+                                    }
+                                    if r == 10 {
+                                        r = 0;
+                                    }
+                                    csp = 10;
+                                    status = a_src.skip(&mut self.c_decode_member.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 11 {
+                    if r == 11 || (r == 0 && ((v_flags & 8) != 0)) {
+                        if r == 0 || r == 11 {
+                            'label_0: loop {
+                                if r == 0 || r == 11 {
+                                    if r == 11 {
+                                        r = 0;
+                                    }
+                                    csp = 11;
+                                    status = a_src.read_u8(&mut t_9);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_9;
+                                }
+                                if r == 0 {
+                                    self.hash((v_c as u32), 1);
+                                    if v_c == 0 {
+                                        break 'label_0;
+                                    } else if v_truncated {
+                                        continue 'label_0;
+                                    } else if v_c < 128 {
+                                        if self.f_fname_length >= 255 {
+                                            v_truncated = true;
+                                            continue 'label_0;
+                                        }
+                                        (*unsafe {
+                                            self.f_fname
+                                                .get_unchecked_mut(self.f_fname_length as usize)
+                                        }) = v_c;
+                                        self.f_fname_length += 1;
+                                    } else {
+                                        if self.f_fname_length >= 254 {
+                                            v_truncated = true;
+                                            continue 'label_0;
+                                        }
+                                        (*unsafe {
+                                            self.f_fname
+                                                .get_unchecked_mut(self.f_fname_length as usize)
+                                        }) = (192 | (v_c >> 6));
+                                        (*unsafe {
+                                            self.f_fname.get_unchecked_mut(
+                                                (self.f_fname_length + 1) as usize,
+                                            )
+                                        }) = (128 | (v_c & 63));
+                                        self.f_fname_length += 2;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 12 {
+                    if r == 12 || (r == 0 && ((v_flags & 16) != 0)) {
+                        if r == 0 {
+                            v_truncated = false;
+                        }
+                        if r == 0 || r == 12 {
+                            'label_1: loop {
+                                if r == 0 || r == 12 {
+                                    if r == 12 {
+                                        r = 0;
+                                    }
+                                    csp = 12;
+                                    status = a_src.read_u8(&mut t_10);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_10;
+                                }
+                                if r == 0 {
+                                    self.hash((v_c as u32), 1);
+                                    if v_c == 0 {
+                                        break 'label_1;
+                                    } else if v_truncated {
+                                        continue 'label_1;
+                                    } else if v_c < 128 {
+                                        if self.f_fcomment_length >= 1024 {
+                                            v_truncated = true;
+                                            continue 'label_1;
+                                        }
+                                        (*unsafe {
+                                            self.f_fcomment
+                                                .get_unchecked_mut(self.f_fcomment_length as usize)
+                                        }) = v_c;
+                                        self.f_fcomment_length += 1;
+                                    } else {
+                                        if self.f_fcomment_length >= 1023 {
+                                            v_truncated = true;
+                                            continue 'label_1;
+                                        }
+                                        (*unsafe {
+                                            self.f_fcomment
+                                                .get_unchecked_mut(self.f_fcomment_length as usize)
+                                        }) = (192 | (v_c >> 6));
+                                        (*unsafe {
+                                            self.f_fcomment.get_unchecked_mut(
+                                                (self.f_fcomment_length + 1) as usize,
+                                            )
+                                        }) = (128 | (v_c & 63));
+                                        self.f_fcomment_length += 2;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 13 {
+                    if r == 13 || (r == 0 && ((v_flags & 2) != 0)) {
+                        if r == 0 || r == 13 {
+                            if r == 0 {
+                                self.c_decode_member.scratch = 0;
+                            }
+                            if r == 13 {
+                                r = 0;
+                            }
+                            csp = 13;
+                            status = a_src.read_u16le(&mut t_11, &mut self.c_decode_member.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_header_checksum_want = t_11;
+                        }
+                        if r == 0 {
+                            if !self.f_ignore_checksum
+                                && ((self.f_header_checksum & 65535)
+                                    != (v_header_checksum_want as u32))
+                            {
+                                status = ERROR_BAD_HEADER_CHECKSUM;
+                                break 'exit;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_checksum_got = 0;
+                    v_decoded_length_got = 0;
+                }
+                if r == 0 || r == 14 {
+                    'label_2: loop {
+                        if r == 0 {
+                            a_dst.set_mark();
+                            t_12 = self.f_flate.decode(a_dst, a_src);
+                            v_z = t_12;
+                            if !self.f_ignore_checksum {
+                                v_checksum_got = self.f_checksum.update(a_dst.since_mark());
+                                v_decoded_length_got = u32::wrapping_add(
+                                    v_decoded_length_got,
+                                    (((a_dst.since_mark().len() as u64) & 4294967295) as u32),
+                                );
+                            }
+                            if v_z.is_ok() {
+                                break 'label_2;
+                            }
+                        }
+                        if r == 0 || r == 14 {
+                            status = v_z;
+                            if r == 14 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                if status.is_error() {
+                                    break 'exit;
+                                }
+                                if !status.is_ok() {
+                                    csp = 14;
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 15 {
+                    if r == 0 {
+                        self.c_decode_member.scratch = 0;
+                    }
+                    if r == 15 {
+                        r = 0;
+                    }
+                    csp = 15;
+                    status = a_src.read_u32le(&mut t_13, &mut self.c_decode_member.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_checksum_want = t_13;
+                }
+                if r == 0 || r == 16 {
+                    if r == 0 {
+                        self.c_decode_member.scratch = 0;
+                    }
+                    if r == 16 {
+                        r = 0;
+                    }
+                    csp = 16;
+                    status = a_src.read_u32le(&mut t_14, &mut self.c_decode_member.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_decoded_length_want = t_14;
+                }
+                if r == 0 {
+                    if !self.f_ignore_checksum
+                        && ((v_checksum_got != v_checksum_want)
+                            || (v_decoded_length_got != v_decoded_length_want))
+                    {
+                        status = ERROR_BAD_CHECKSUM;
+                        break 'exit;
+                    }
+                }
+                self.c_decode_member.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_member.coro_susp_point = csp;
+            self.c_decode_member.v_flags = v_flags;
+            self.c_decode_member.v_xfl = v_xfl;
+            self.c_decode_member.v_c = v_c;
+            self.c_decode_member.v_truncated = v_truncated;
+            self.c_decode_member.v_xlen = v_xlen;
+            self.c_decode_member.v_header_checksum_want = v_header_checksum_want;
+            self.c_decode_member.v_checksum_got = v_checksum_got;
+            self.c_decode_member.v_decoded_length_got = v_decoded_length_got;
+            self.c_decode_member.v_z = v_z;
+            self.c_decode_member.v_checksum_want = v_checksum_want;
+            self.c_decode_member.v_decoded_length_want = v_decoded_length_want;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.hash
+
+impl Decoder {
+    fn hash(&mut self, mut a_x: u32, mut a_n: u32) {
+        if !self.f_hash_header {
+            return;
+        }
+        (*unsafe { self.f_hbuf.get_unchecked_mut(0 as usize) }) = (((a_x >> 0) & 255) as u8);
+        (*unsafe { self.f_hbuf.get_unchecked_mut(1 as usize) }) = (((a_x >> 8) & 255) as u8);
+        (*unsafe { self.f_hbuf.get_unchecked_mut(2 as usize) }) = (((a_x >> 16) & 255) as u8);
+        (*unsafe { self.f_hbuf.get_unchecked_mut(3 as usize) }) = (((a_x >> 24) & 255) as u8);
+        self.f_header_checksum = self.f_header_hasher.update(unsafe {
+            wuffs_base::SliceU8::from_array(&mut self.f_hbuf).subslice_j(a_n as usize)
+        });
+    }
+}
+
+// -------- func encoder.set_level
+
+impl Encoder {
+    pub fn set_level(&mut self, mut a_level: u32) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_flate.set_level(a_level);
+        self.f_level = u32::min(a_level, 9);
+        self.f_level_set = true;
+    }
+}
+
+// -------- func encoder.encode
+
+impl Encoder {
+    pub fn encode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_xfl: u8 = 0;
+        let mut v_checksum: u32 = 0;
+        let mut v_isize: u32 = 0;
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_xfl = self.c_encode.v_xfl;
+            v_checksum = self.c_encode.v_checksum;
+            v_isize = self.c_encode.v_isize;
+            v_z = self.c_encode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if !self.f_level_set {
+                        self.f_level_set = true;
+                        self.f_level = 6;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u32le(559903, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_dst.write_u32le(0, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_xfl = 0;
+                    if self.f_level == 9 {
+                        v_xfl = 2;
+                    } else if self.f_level < 2 {
+                        v_xfl = 4;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_dst.write_u8(v_xfl);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_dst.write_u8(255);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_checksum = 0;
+                    v_isize = 0;
+                }
+                if r == 0 || r == 5 {
+                    'label_0: loop {
+                        if r == 0 {
+                            a_src.set_mark();
+                            t_0 = self.f_flate.encode(a_dst, a_src);
+                            v_z = t_0;
+                            v_checksum = self.f_checksum.update(a_src.since_mark());
+                            v_isize = u32::wrapping_add(
+                                v_isize,
+                                (((a_src.since_mark().len() as u64) & 4294967295) as u32),
+                            );
+                            if v_z.is_ok() {
+                                break 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            status = v_z;
+                            if r == 5 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                if status.is_error() {
+                                    break 'exit;
+                                }
+                                if !status.is_ok() {
+                                    csp = 5;
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 6 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_dst.write_u32le(v_checksum, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 7 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = a_dst.write_u32le(v_isize, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_encode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode.coro_susp_point = csp;
+            self.c_encode.v_xfl = v_xfl;
+            self.c_encode.v_checksum = v_checksum;
+            self.c_encode.v_isize = v_isize;
+            self.c_encode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
@@ -0,0 +1,629 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package ico is the Rust translation of the Wuffs std/ico package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_bmp as bmp;
+use wuffs_std_png as png;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("ico: bad header");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a ico.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_call_sequence: u8,
+    f_is_cur: bool,
+    f_num_sub_images: u32,
+    f_best_width: u32,
+    f_best_height: u32,
+    f_best_bits_per_pixel: u32,
+    f_best_offset: u32,
+    f_best_is_png: bool,
+    f_best_hotspot_x: u32,
+    f_best_hotspot_y: u32,
+    f_bmp: bmp::Decoder,
+    f_png: png::Decoder,
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_call_sequence: 0,
+            f_is_cur: false,
+            f_num_sub_images: 0,
+            f_best_width: 0,
+            f_best_height: 0,
+            f_best_bits_per_pixel: 0,
+            f_best_offset: 0,
+            f_best_is_png: false,
+            f_best_hotspot_x: 0,
+            f_best_hotspot_y: 0,
+            f_bmp: bmp::Decoder::default(),
+            f_png: png::Decoder::default(),
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_file_type: u16,
+    v_num_images: u32,
+    v_i: u32,
+    v_width: u32,
+    v_height: u32,
+    v_x: u32,
+    v_y: u32,
+    v_bits_per_pixel: u32,
+    v_offset: u32,
+    v_num_read: u64,
+    v_c: u8,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_file_type: 0,
+            v_num_images: 0,
+            v_i: 0,
+            v_width: 0,
+            v_height: 0,
+            v_x: 0,
+            v_y: 0,
+            v_bits_per_pixel: 0,
+            v_offset: 0,
+            v_num_read: 0,
+            v_c: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro { coro_susp_point: 0 }
+    }
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.is_cursor
+
+impl Decoder {
+    pub fn is_cursor(&mut self) -> bool {
+        if self.status.is_error() {
+            return false;
+        }
+
+        return self.f_is_cur;
+    }
+}
+
+// -------- func decoder.hotspot_x
+
+impl Decoder {
+    pub fn hotspot_x(&mut self) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_best_hotspot_x;
+    }
+}
+
+// -------- func decoder.hotspot_y
+
+impl Decoder {
+    pub fn hotspot_y(&mut self) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_best_hotspot_y;
+    }
+}
+
+// -------- func decoder.num_images
+
+impl Decoder {
+    pub fn num_images(&mut self) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_num_sub_images;
+    }
+}
+
+// -------- func decoder.workbuf_len
+
+impl Decoder {
+    pub fn workbuf_len(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        if self.f_best_is_png {
+            return self.f_png.workbuf_len();
+        }
+        return 0;
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_file_type: u16 = 0;
+        let mut v_num_images: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_width: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_bits_per_pixel: u32 = 0;
+        let mut v_offset: u32 = 0;
+        let mut v_num_read: u64 = 0;
+        let mut v_c: u8 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u16 = 0;
+        let mut t_2: u16 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut t_5: u16 = 0;
+        let mut t_6: u16 = 0;
+        let mut t_7: u32 = 0;
+        let mut t_8: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_file_type = self.c_decode_config.v_file_type;
+            v_num_images = self.c_decode_config.v_num_images;
+            v_i = self.c_decode_config.v_i;
+            v_width = self.c_decode_config.v_width;
+            v_height = self.c_decode_config.v_height;
+            v_x = self.c_decode_config.v_x;
+            v_y = self.c_decode_config.v_y;
+            v_bits_per_pixel = self.c_decode_config.v_bits_per_pixel;
+            v_offset = self.c_decode_config.v_offset;
+            v_num_read = self.c_decode_config.v_num_read;
+            v_c = self.c_decode_config.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u16le(&mut t_0, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 0) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u16le(&mut t_1, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_file_type = t_1;
+                }
+                if r == 0 {
+                    if (v_file_type != 1) && (v_file_type != 2) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_is_cur = (v_file_type == 2);
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u16le(&mut t_2, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_num_images = (t_2 as u32);
+                }
+                if r == 0 {
+                    if v_num_images == 0 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_num_sub_images = v_num_images;
+                    v_i = 0;
+                    v_width = 0;
+                    v_height = 0;
+                    v_x = 0;
+                    v_y = 0;
+                    v_bits_per_pixel = 0;
+                    v_offset = 0;
+                }
+                if r == 0 || (4 <= r && r <= 10) {
+                    while r != 0 || (v_i < v_num_images) {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = a_src.read_u8(&mut t_3);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_width = (t_3 as u32);
+                        }
+                        if r == 0 {
+                            if v_width == 0 {
+                                v_width = 256;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = a_src.read_u8(&mut t_4);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_height = (t_4 as u32);
+                        }
+                        if r == 0 {
+                            if v_height == 0 {
+                                v_height = 256;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = (2 as u64);
+                            }
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 7 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status = a_src.read_u16le(&mut t_5, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_x = (t_5 as u32);
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = a_src.read_u16le(&mut t_6, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_y = (t_6 as u32);
+                        }
+                        if r == 0 || r == 9 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = (4 as u64);
+                            }
+                            if r == 9 {
+                                r = 0;
+                            }
+                            csp = 9;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 10 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 10 {
+                                r = 0;
+                            }
+                            csp = 10;
+                            status = a_src.read_u32le(&mut t_7, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_offset = t_7;
+                        }
+                        if r == 0 {
+                            v_bits_per_pixel = v_y;
+                            if self.f_is_cur {
+                                v_bits_per_pixel = 0;
+                            }
+                            if (v_i == 0) || self.is_better(v_width, v_height, v_bits_per_pixel) {
+                                self.f_best_width = v_width;
+                                self.f_best_height = v_height;
+                                self.f_best_bits_per_pixel = v_bits_per_pixel;
+                                self.f_best_offset = v_offset;
+                                if self.f_is_cur {
+                                    self.f_best_hotspot_x = v_x;
+                                    self.f_best_hotspot_y = v_y;
+                                }
+                            }
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_num_read = (6 + (16 * (v_num_images as u64)));
+                    if (self.f_best_offset as u64) < v_num_read {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 11 {
+                    if r == 0 {
+                        self.c_decode_config.scratch =
+                            (((self.f_best_offset as u64) - v_num_read) as u64);
+                    }
+                    if r == 11 {
+                        r = 0;
+                    }
+                    csp = 11;
+                    status = a_src.skip(&mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 12 {
+                    if r == 12 {
+                        r = 0;
+                    }
+                    csp = 12;
+                    status = a_src.read_u8(&mut t_8);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_c = t_8;
+                }
+                if r == 0 {
+                    status = a_src.unread_u8();
+                    if !status.is_ok() {
+                        break 'exit;
+                    }
+                    self.f_best_is_png = (v_c == 137);
+                }
+                if r == 0 || (13 <= r && r <= 14) {
+                    if r == 13 || (r == 0 && self.f_best_is_png) {
+                        if r == 0 || r == 13 {
+                            if r == 13 {
+                                r = 0;
+                            }
+                            csp = 13;
+                            status = self.f_png.decode_config(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 {
+                            self.f_bmp.set_ico_mode(true);
+                        }
+                        if r == 0 || r == 14 {
+                            if r == 14 {
+                                r = 0;
+                            }
+                            csp = 14;
+                            status = self.f_bmp.decode_config(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_file_type = v_file_type;
+            self.c_decode_config.v_num_images = v_num_images;
+            self.c_decode_config.v_i = v_i;
+            self.c_decode_config.v_width = v_width;
+            self.c_decode_config.v_height = v_height;
+            self.c_decode_config.v_x = v_x;
+            self.c_decode_config.v_y = v_y;
+            self.c_decode_config.v_bits_per_pixel = v_bits_per_pixel;
+            self.c_decode_config.v_offset = v_offset;
+            self.c_decode_config.v_num_read = v_num_read;
+            self.c_decode_config.v_c = v_c;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 && (self.f_call_sequence == 0) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    } else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+                        if r == 0 || r == 1 {
+                            loop {
+                                if r == 0 || r == 1 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 1 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 3) {
+                    if r == 2 || (r == 0 && self.f_best_is_png) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.f_png.decode_frame(a_dst, a_src, a_workbuf);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.f_bmp.decode_frame(a_dst, a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_call_sequence = 2;
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.is_better
+
+impl Decoder {
+    fn is_better(
+        &mut self,
+        mut a_width: u32,
+        mut a_height: u32,
+        mut a_bits_per_pixel: u32,
+    ) -> bool {
+        let mut v_area: u32 = 0;
+        let mut v_best_area: u32 = 0;
+
+        v_area = (a_width * a_height);
+        v_best_area = (self.f_best_width * self.f_best_height);
+        if v_area != v_best_area {
+            return (v_area > v_best_area);
+        }
+        return (a_bits_per_pixel > self.f_best_bits_per_pixel);
+    }
+}
@@ -0,0 +1,3729 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package jpeg is the Rust translation of the Wuffs std/jpeg package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_DHT_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad DHT marker");
+pub const ERROR_BAD_DQT_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad DQT marker");
+pub const ERROR_BAD_DRI_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad DRI marker");
+pub const ERROR_BAD_HUFFMAN_CODE: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad Huffman code");
+pub const ERROR_BAD_RST_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad RST marker");
+pub const ERROR_BAD_SOF_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad SOF marker");
+pub const ERROR_BAD_SOS_MARKER: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad SOS marker");
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("jpeg: bad header");
+pub const ERROR_BAD_MARKER: wuffs_base::Status = wuffs_base::Status::Error("jpeg: bad marker");
+pub const ERROR_MISSING_HUFFMAN_TABLE: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: missing Huffman table");
+pub const ERROR_MISSING_QUANTIZATION_TABLE: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: missing quantization table");
+pub const ERROR_UNSUPPORTED_CODING_PROCESS: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: unsupported coding process");
+pub const ERROR_TODO_UNSUPPORTED_ARITHMETIC_CODING: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: TODO: unsupported arithmetic coding");
+pub const ERROR_TODO_UNSUPPORTED_NUMBER_OF_COMPONENTS: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: TODO: unsupported number of components");
+pub const ERROR_TODO_UNSUPPORTED_PRECISION: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: TODO: unsupported precision");
+pub const ERROR_TODO_UNSUPPORTED_SUBSAMPLING: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: TODO: unsupported subsampling");
+pub const ERROR_BAD_WORKBUF_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("jpeg: bad workbuf length");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a jpeg.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_marker: u8,
+    f_marker_pending: u8,
+    f_progressive: bool,
+    f_num_components: u32,
+    f_max_h: u32,
+    f_max_v: u32,
+    f_mcus_across: u32,
+    f_mcus_down: u32,
+    f_comp_id: [u8; 3],
+    f_comp_h: [u32; 3],
+    f_comp_v: [u32; 3],
+    f_comp_tq: [u8; 3],
+    f_comp_width_in_blocks: [u32; 3],
+    f_comp_height_in_blocks: [u32; 3],
+    f_comp_stride: [u32; 3],
+    f_comp_offset: [u64; 3],
+    f_num_blocks: u64,
+    f_comp_quant_latched: [bool; 3],
+    f_comp_quant: [[u16; 64]; 3],
+    f_scan_num_components: u32,
+    f_scan_comp: [u32; 3],
+    f_scan_td: [u32; 3],
+    f_scan_ta: [u32; 3],
+    f_ss: u32,
+    f_se: u32,
+    f_ah: u32,
+    f_al: u32,
+    f_dc_pred: [u16; 3],
+    f_eobrun: u32,
+    f_restart_interval: u32,
+    f_restarts_to_go: u32,
+    f_next_restart: u32,
+    f_bits: u32,
+    f_n_bits: u32,
+    f_quant_defined: [bool; 4],
+    f_quant: [[u16; 64]; 4],
+    f_huff_defined: [bool; 8],
+    f_huff_lut: [[u16; 256]; 8],
+    f_huff_maxcode: [[u32; 17]; 8],
+    f_huff_mincode: [[u32; 17]; 8],
+    f_huff_valptr: [[u32; 17]; 8],
+    f_huff_vals: [[u8; 256]; 8],
+    f_huff_counts: [u32; 17],
+    f_block: [u16; 64],
+    f_workspace: [i64; 64],
+    f_pixels: [u8; 64],
+    f_coef_bytes: [u8; 128],
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_read_marker: DecoderReadMarkerCoro,
+    c_decode_misc_marker: DecoderDecodeMiscMarkerCoro,
+    c_decode_sof: DecoderDecodeSofCoro,
+    c_decode_dht: DecoderDecodeDhtCoro,
+    c_decode_dqt: DecoderDecodeDqtCoro,
+    c_decode_dri: DecoderDecodeDriCoro,
+    c_decode_sos: DecoderDecodeSosCoro,
+    c_decode_scan: DecoderDecodeScanCoro,
+    c_process_restart: DecoderProcessRestartCoro,
+    c_fill_bits: DecoderFillBitsCoro,
+    c_decode_block: DecoderDecodeBlockCoro,
+    c_decode_dc_first: DecoderDecodeDcFirstCoro,
+    c_decode_dc_refine: DecoderDecodeDcRefineCoro,
+    c_decode_ac_first: DecoderDecodeAcFirstCoro,
+    c_decode_ac_refine: DecoderDecodeAcRefineCoro,
+    c_refine_coefficient: DecoderRefineCoefficientCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_marker: 0,
+            f_marker_pending: 0,
+            f_progressive: false,
+            f_num_components: 0,
+            f_max_h: 0,
+            f_max_v: 0,
+            f_mcus_across: 0,
+            f_mcus_down: 0,
+            f_comp_id: [0; 3],
+            f_comp_h: [0; 3],
+            f_comp_v: [0; 3],
+            f_comp_tq: [0; 3],
+            f_comp_width_in_blocks: [0; 3],
+            f_comp_height_in_blocks: [0; 3],
+            f_comp_stride: [0; 3],
+            f_comp_offset: [0; 3],
+            f_num_blocks: 0,
+            f_comp_quant_latched: [false; 3],
+            f_comp_quant: [[0; 64]; 3],
+            f_scan_num_components: 0,
+            f_scan_comp: [0; 3],
+            f_scan_td: [0; 3],
+            f_scan_ta: [0; 3],
+            f_ss: 0,
+            f_se: 0,
+            f_ah: 0,
+            f_al: 0,
+            f_dc_pred: [0; 3],
+            f_eobrun: 0,
+            f_restart_interval: 0,
+            f_restarts_to_go: 0,
+            f_next_restart: 0,
+            f_bits: 0,
+            f_n_bits: 0,
+            f_quant_defined: [false; 4],
+            f_quant: [[0; 64]; 4],
+            f_huff_defined: [false; 8],
+            f_huff_lut: [[0; 256]; 8],
+            f_huff_maxcode: [[0; 17]; 8],
+            f_huff_mincode: [[0; 17]; 8],
+            f_huff_valptr: [[0; 17]; 8],
+            f_huff_vals: [[0; 256]; 8],
+            f_huff_counts: [0; 17],
+            f_block: [0; 64],
+            f_workspace: [0; 64],
+            f_pixels: [0; 64],
+            f_coef_bytes: [0; 128],
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_read_marker: DecoderReadMarkerCoro::default(),
+            c_decode_misc_marker: DecoderDecodeMiscMarkerCoro::default(),
+            c_decode_sof: DecoderDecodeSofCoro::default(),
+            c_decode_dht: DecoderDecodeDhtCoro::default(),
+            c_decode_dqt: DecoderDecodeDqtCoro::default(),
+            c_decode_dri: DecoderDecodeDriCoro::default(),
+            c_decode_sos: DecoderDecodeSosCoro::default(),
+            c_decode_scan: DecoderDecodeScanCoro::default(),
+            c_process_restart: DecoderProcessRestartCoro::default(),
+            c_fill_bits: DecoderFillBitsCoro::default(),
+            c_decode_block: DecoderDecodeBlockCoro::default(),
+            c_decode_dc_first: DecoderDecodeDcFirstCoro::default(),
+            c_decode_dc_refine: DecoderDecodeDcRefineCoro::default(),
+            c_decode_ac_first: DecoderDecodeAcFirstCoro::default(),
+            c_decode_ac_refine: DecoderDecodeAcRefineCoro::default(),
+            c_refine_coefficient: DecoderRefineCoefficientCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_m: u8,
+    v_pixfmt: u32,
+    v_pixsub: u32,
+    v_e: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_m: 0,
+            v_pixfmt: 0,
+            v_pixsub: 0,
+            v_e: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+    v_j: u64,
+    v_m: u8,
+    v_dirty_rect: wuffs_base::RectIeU32,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro {
+            coro_susp_point: 0,
+            v_j: 0,
+            v_m: 0,
+            v_dirty_rect: wuffs_base::RectIeU32::default(),
+        }
+    }
+}
+
+struct DecoderReadMarkerCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderReadMarkerCoro {
+    fn default() -> Self {
+        DecoderReadMarkerCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderDecodeMiscMarkerCoro {
+    coro_susp_point: u32,
+    v_m: u8,
+    v_length: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeMiscMarkerCoro {
+    fn default() -> Self {
+        DecoderDecodeMiscMarkerCoro {
+            coro_susp_point: 0,
+            v_m: 0,
+            v_length: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeSofCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_height: u32,
+    v_width: u32,
+    v_n: u32,
+    v_num_components: u32,
+    v_max_h: u32,
+    v_max_v: u32,
+    v_i: u32,
+    v_hv: u8,
+    v_h: u32,
+    v_v: u32,
+    v_tq: u8,
+    v_mcu_width: u32,
+    v_mcu_height: u32,
+    v_mcus_across: u32,
+    v_mcus_down: u32,
+    v_num_blocks: u64,
+    v_cw: u32,
+    v_ch: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeSofCoro {
+    fn default() -> Self {
+        DecoderDecodeSofCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_height: 0,
+            v_width: 0,
+            v_n: 0,
+            v_num_components: 0,
+            v_max_h: 0,
+            v_max_v: 0,
+            v_i: 0,
+            v_hv: 0,
+            v_h: 0,
+            v_v: 0,
+            v_tq: 0,
+            v_mcu_width: 0,
+            v_mcu_height: 0,
+            v_mcus_across: 0,
+            v_mcus_down: 0,
+            v_num_blocks: 0,
+            v_cw: 0,
+            v_ch: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeDhtCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_n: u32,
+    v_tc_th: u8,
+    v_t: u32,
+    v_total: u32,
+    v_i: u32,
+    v_num_symbols: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeDhtCoro {
+    fn default() -> Self {
+        DecoderDecodeDhtCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_n: 0,
+            v_tc_th: 0,
+            v_t: 0,
+            v_total: 0,
+            v_i: 0,
+            v_num_symbols: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeDqtCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_n: u32,
+    v_pq_tq: u8,
+    v_pq: u8,
+    v_tq: u8,
+    v_i: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeDqtCoro {
+    fn default() -> Self {
+        DecoderDecodeDqtCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_n: 0,
+            v_pq_tq: 0,
+            v_pq: 0,
+            v_tq: 0,
+            v_i: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeDriCoro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeDriCoro {
+    fn default() -> Self {
+        DecoderDecodeDriCoro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeSosCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_n: u32,
+    v_num_components: u32,
+    v_i: u32,
+    v_id: u8,
+    v_td_ta: u8,
+    v_j: u32,
+    v_ss: u32,
+    v_se: u32,
+    v_ah_al: u8,
+    v_ah: u32,
+    v_al: u32,
+    v_c: u32,
+    v_k: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeSosCoro {
+    fn default() -> Self {
+        DecoderDecodeSosCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_n: 0,
+            v_num_components: 0,
+            v_i: 0,
+            v_id: 0,
+            v_td_ta: 0,
+            v_j: 0,
+            v_ss: 0,
+            v_se: 0,
+            v_ah_al: 0,
+            v_ah: 0,
+            v_al: 0,
+            v_c: 0,
+            v_k: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeScanCoro {
+    coro_susp_point: u32,
+    v_mcus_x: u32,
+    v_mcus_y: u32,
+    v_my: u32,
+    v_mx: u32,
+    v_i: u32,
+    v_c: u32,
+    v_h: u32,
+    v_v: u32,
+    v_y: u32,
+    v_x: u32,
+}
+
+impl Default for DecoderDecodeScanCoro {
+    fn default() -> Self {
+        DecoderDecodeScanCoro {
+            coro_susp_point: 0,
+            v_mcus_x: 0,
+            v_mcus_y: 0,
+            v_my: 0,
+            v_mx: 0,
+            v_i: 0,
+            v_c: 0,
+            v_h: 0,
+            v_v: 0,
+            v_y: 0,
+            v_x: 0,
+        }
+    }
+}
+
+struct DecoderProcessRestartCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderProcessRestartCoro {
+    fn default() -> Self {
+        DecoderProcessRestartCoro { coro_susp_point: 0 }
+    }
+}
+
+struct DecoderFillBitsCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderFillBitsCoro {
+    fn default() -> Self {
+        DecoderFillBitsCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderDecodeBlockCoro {
+    coro_susp_point: u32,
+    v_c: u32,
+    v_i: u32,
+    v_index: u64,
+    v_offset: u64,
+}
+
+impl Default for DecoderDecodeBlockCoro {
+    fn default() -> Self {
+        DecoderDecodeBlockCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+            v_i: 0,
+            v_index: 0,
+            v_offset: 0,
+        }
+    }
+}
+
+struct DecoderDecodeDcFirstCoro {
+    coro_susp_point: u32,
+    v_c: u32,
+    v_n: u32,
+    v_v: u32,
+    v_coef: u32,
+}
+
+impl Default for DecoderDecodeDcFirstCoro {
+    fn default() -> Self {
+        DecoderDecodeDcFirstCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+            v_n: 0,
+            v_v: 0,
+            v_coef: 0,
+        }
+    }
+}
+
+struct DecoderDecodeDcRefineCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderDecodeDcRefineCoro {
+    fn default() -> Self {
+        DecoderDecodeDcRefineCoro { coro_susp_point: 0 }
+    }
+}
+
+struct DecoderDecodeAcFirstCoro {
+    coro_susp_point: u32,
+    v_t: u32,
+    v_k: u32,
+    v_rs: u32,
+    v_r: u32,
+    v_n: u32,
+    v_v: u32,
+    v_coef: u32,
+    v_eobrun: u32,
+}
+
+impl Default for DecoderDecodeAcFirstCoro {
+    fn default() -> Self {
+        DecoderDecodeAcFirstCoro {
+            coro_susp_point: 0,
+            v_t: 0,
+            v_k: 0,
+            v_rs: 0,
+            v_r: 0,
+            v_n: 0,
+            v_v: 0,
+            v_coef: 0,
+            v_eobrun: 0,
+        }
+    }
+}
+
+struct DecoderDecodeAcRefineCoro {
+    coro_susp_point: u32,
+    v_t: u32,
+    v_p1: u32,
+    v_k: u32,
+    v_v: u16,
+    v_z: u32,
+    v_rs: u32,
+    v_r: u32,
+    v_n: u32,
+    v_eobrun: u32,
+    v_i: u32,
+}
+
+impl Default for DecoderDecodeAcRefineCoro {
+    fn default() -> Self {
+        DecoderDecodeAcRefineCoro {
+            coro_susp_point: 0,
+            v_t: 0,
+            v_p1: 0,
+            v_k: 0,
+            v_v: 0,
+            v_z: 0,
+            v_rs: 0,
+            v_r: 0,
+            v_n: 0,
+            v_eobrun: 0,
+            v_i: 0,
+        }
+    }
+}
+
+struct DecoderRefineCoefficientCoro {
+    coro_susp_point: u32,
+    v_v: u16,
+}
+
+impl Default for DecoderRefineCoefficientCoro {
+    fn default() -> Self {
+        DecoderRefineCoefficientCoro {
+            coro_susp_point: 0,
+            v_v: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+static ZIGZAG: [u8; 80] = [
+    0x0, 0x1, 0x8, 0x10, 0x9, 0x2, 0x3, 0xA, 0x11, 0x18, 0x20, 0x19, 0x12, 0xB, 0x4, 0x5, 0xC,
+    0x13, 0x1A, 0x21, 0x28, 0x30, 0x29, 0x22, 0x1B, 0x14, 0xD, 0x6, 0x7, 0xE, 0x15, 0x1C, 0x23,
+    0x2A, 0x31, 0x38, 0x39, 0x32, 0x2B, 0x24, 0x1D, 0x16, 0xF, 0x17, 0x1E, 0x25, 0x2C, 0x33, 0x3A,
+    0x3B, 0x34, 0x2D, 0x26, 0x1F, 0x27, 0x2E, 0x35, 0x3C, 0x3D, 0x36, 0x2F, 0x37, 0x3E, 0x3F, 0x3F,
+    0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F, 0x3F,
+];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.workbuf_len
+
+impl Decoder {
+    pub fn workbuf_len(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        if self.f_progressive {
+            return (self.f_num_blocks * 128);
+        }
+        return 0;
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_m: u8 = 0;
+        let mut v_pixfmt: u32 = 0;
+        let mut v_pixsub: u32 = 0;
+        let mut v_e: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_m = self.c_decode_config.v_m;
+            v_pixfmt = self.c_decode_config.v_pixfmt;
+            v_pixsub = self.c_decode_config.v_pixsub;
+            v_e = self.c_decode_config.v_e;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u16be(&mut t_0, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 65496) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 4) {
+                    'label_0: loop {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.read_marker(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_m = self.f_marker;
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 || (r == 0 && ((v_m == 192) || (v_m == 193) || (v_m == 194)))
+                            {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.decode_sof(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    break 'label_0;
+                                }
+                            } else if r == 0 && ((v_m == 195) || ((v_m >= 197) && (v_m <= 200))) {
+                                status = ERROR_UNSUPPORTED_CODING_PROCESS;
+                                break 'exit;
+                            } else if r == 0 && ((v_m >= 201) && (v_m <= 207) && (v_m != 204)) {
+                                status = ERROR_TODO_UNSUPPORTED_ARITHMETIC_CODING;
+                                break 'exit;
+                            } else if r == 0 && ((v_m == 216) || (v_m == 217) || (v_m == 218)) {
+                                status = ERROR_BAD_MARKER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.decode_misc_marker(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_pixfmt = 268435464;
+                    v_pixsub = 0;
+                    if self.f_num_components == 3 {
+                        v_pixfmt = 1075841160;
+                        if ((*unsafe { self.f_comp_h.get_unchecked(0 as usize) })
+                            != (*unsafe { self.f_comp_h.get_unchecked(1 as usize) }))
+                            || ((*unsafe { self.f_comp_v.get_unchecked(0 as usize) })
+                                != (*unsafe { self.f_comp_v.get_unchecked(1 as usize) }))
+                        {
+                            v_e = ((u32::saturating_sub(
+                                u32::min((*unsafe { self.f_comp_h.get_unchecked(0 as usize) }), 2),
+                                1,
+                            ) << 4)
+                                | u32::saturating_sub(
+                                    u32::min(
+                                        (*unsafe { self.f_comp_v.get_unchecked(0 as usize) }),
+                                        2,
+                                    ),
+                                    1,
+                                ));
+                            v_pixsub = ((v_e << 16) | (v_e << 8));
+                        }
+                    }
+                    a_dst.initialize(v_pixfmt, v_pixsub, self.f_width, self.f_height, 1);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_m = v_m;
+            self.c_decode_config.v_pixfmt = v_pixfmt;
+            self.c_decode_config.v_pixsub = v_pixsub;
+            self.c_decode_config.v_e = v_e;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_coefs: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_j: u64 = 0;
+        let mut v_m: u8 = 0;
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_dirty_rect: wuffs_base::RectIeU32 = wuffs_base::RectIeU32::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_j = self.c_decode_frame.v_j;
+            v_m = self.c_decode_frame.v_m;
+            v_dirty_rect = self.c_decode_frame.v_dirty_rect;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 && (self.f_call_sequence == 0) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    } else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+                        if r == 0 || r == 1 {
+                            loop {
+                                if r == 0 || r == 1 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 1 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if self.f_progressive {
+                        if (a_workbuf.len() as u64) < (self.f_num_blocks * 128) {
+                            status = ERROR_BAD_WORKBUF_LENGTH;
+                            break 'exit;
+                        }
+                        v_coefs = a_workbuf.prefix((self.f_num_blocks * 128));
+                        v_j = 0;
+                        while v_j < (v_coefs.len() as u64) {
+                            (*unsafe { v_coefs.get_unchecked_mut(v_j as usize) }) = 0;
+                            v_j += 1;
+                        }
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 5) {
+                    'label_0: loop {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.read_marker(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_m = self.f_marker;
+                        }
+                        if r == 0 || (3 <= r && r <= 5) {
+                            if (3 <= r && r <= 4) || (r == 0 && (v_m == 218)) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.decode_sos(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.decode_scan(a_dst, a_src, a_workbuf);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else if r == 0 && (v_m == 217) {
+                                break 'label_0;
+                            } else if r == 0
+                                && (((v_m >= 192) && (v_m <= 207) && (v_m != 196) && (v_m != 204))
+                                    || (v_m == 216))
+                            {
+                                status = ERROR_BAD_MARKER;
+                                break 'exit;
+                            } else {
+                                if r == 0 || r == 5 {
+                                    if r == 5 {
+                                        r = 0;
+                                    }
+                                    csp = 5;
+                                    status = self.decode_misc_marker(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if self.f_progressive {
+                        self.write_progressive_blocks(a_dst, a_workbuf);
+                    }
+                    v_palette = wuffs_base::SliceU8::default();
+                    v_dirty_rect = wuffs_base::RectIeU32::default();
+                    v_dirty_rect.set_max_exclusive_x(self.f_width);
+                    v_dirty_rect.set_max_exclusive_y(self.f_height);
+                    a_dst.update(v_dirty_rect, 0, false, 0, v_palette);
+                    self.f_call_sequence = 2;
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+            self.c_decode_frame.v_j = v_j;
+            self.c_decode_frame.v_m = v_m;
+            self.c_decode_frame.v_dirty_rect = v_dirty_rect;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.read_marker
+
+impl Decoder {
+    fn read_marker(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_marker.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_read_marker.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        if self.f_marker_pending != 0 {
+                            self.f_marker = self.f_marker_pending;
+                            self.f_marker_pending = 0;
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        v_c = 0;
+                    }
+                    if r == 0 || (1 <= r && r <= 3) {
+                        'label_0: loop {
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = a_src.read_u8(&mut t_0);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_c = t_0;
+                            }
+                            if r == 0 {
+                                if v_c != 255 {
+                                    continue 'label_0;
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                if r == 2 {
+                                    r = 0;
+                                }
+                                csp = 2;
+                                status = a_src.read_u8(&mut t_1);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                                v_c = t_1;
+                            }
+                            if r == 0 || r == 3 {
+                                while r != 0 || (v_c == 255) {
+                                    if r == 0 || r == 3 {
+                                        if r == 3 {
+                                            r = 0;
+                                        }
+                                        csp = 3;
+                                        status = a_src.read_u8(&mut t_2);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                        v_c = t_2;
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                if v_c != 0 {
+                                    break 'label_0;
+                                }
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        self.f_marker = v_c;
+                    }
+                }
+                self.c_read_marker.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_marker.coro_susp_point = csp;
+            self.c_read_marker.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_misc_marker
+
+impl Decoder {
+    fn decode_misc_marker(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_m: u8 = 0;
+        let mut v_length: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_misc_marker.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_m = self.c_decode_misc_marker.v_m;
+            v_length = self.c_decode_misc_marker.v_length;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_m = self.f_marker;
+                }
+                if r == 0 || (1 <= r && r <= 5) {
+                    if r == 1 || (r == 0 && (v_m == 196)) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.decode_dht(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else if r == 2 || (r == 0 && (v_m == 219)) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.decode_dqt(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else if r == 3 || (r == 0 && (v_m == 221)) {
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.decode_dri(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else if r == 0 && ((v_m == 1) || ((v_m >= 208) && (v_m <= 215))) {
+                    } else {
+                        if r == 0 || r == 4 {
+                            if r == 0 {
+                                self.c_decode_misc_marker.scratch = 0;
+                            }
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status =
+                                a_src.read_u16be(&mut t_0, &mut self.c_decode_misc_marker.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_length = (t_0 as u32);
+                        }
+                        if r == 0 {
+                            if v_length < 2 {
+                                status = ERROR_BAD_MARKER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 0 {
+                                self.c_decode_misc_marker.scratch = ((v_length - 2) as u64);
+                            }
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = a_src.skip(&mut self.c_decode_misc_marker.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_misc_marker.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_misc_marker.coro_susp_point = csp;
+            self.c_decode_misc_marker.v_m = v_m;
+            self.c_decode_misc_marker.v_length = v_length;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_sof
+
+impl Decoder {
+    fn decode_sof(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut v_width: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_num_components: u32 = 0;
+        let mut v_max_h: u32 = 0;
+        let mut v_max_v: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_hv: u8 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_tq: u8 = 0;
+        let mut v_mcu_width: u32 = 0;
+        let mut v_mcu_height: u32 = 0;
+        let mut v_mcus_across: u32 = 0;
+        let mut v_mcus_down: u32 = 0;
+        let mut v_num_blocks: u64 = 0;
+        let mut v_cw: u32 = 0;
+        let mut v_ch: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u16 = 0;
+        let mut t_3: u16 = 0;
+        let mut t_4: u8 = 0;
+        let mut t_5: u8 = 0;
+        let mut t_6: u8 = 0;
+        let mut t_7: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_sof.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_decode_sof.v_length;
+            v_height = self.c_decode_sof.v_height;
+            v_width = self.c_decode_sof.v_width;
+            v_n = self.c_decode_sof.v_n;
+            v_num_components = self.c_decode_sof.v_num_components;
+            v_max_h = self.c_decode_sof.v_max_h;
+            v_max_v = self.c_decode_sof.v_max_v;
+            v_i = self.c_decode_sof.v_i;
+            v_hv = self.c_decode_sof.v_hv;
+            v_h = self.c_decode_sof.v_h;
+            v_v = self.c_decode_sof.v_v;
+            v_tq = self.c_decode_sof.v_tq;
+            v_mcu_width = self.c_decode_sof.v_mcu_width;
+            v_mcu_height = self.c_decode_sof.v_mcu_height;
+            v_mcus_across = self.c_decode_sof.v_mcus_across;
+            v_mcus_down = self.c_decode_sof.v_mcus_down;
+            v_num_blocks = self.c_decode_sof.v_num_blocks;
+            v_cw = self.c_decode_sof.v_cw;
+            v_ch = self.c_decode_sof.v_ch;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_progressive = (self.f_marker == 194);
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_sof.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16be(&mut t_0, &mut self.c_decode_sof.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_length = (t_0 as u32);
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 || r == 2 {
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_src.read_u8(&mut t_1);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_1 != 8) {
+                        status = ERROR_TODO_UNSUPPORTED_PRECISION;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_decode_sof.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u16be(&mut t_2, &mut self.c_decode_sof.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_height = (t_2 as u32);
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_decode_sof.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u16be(&mut t_3, &mut self.c_decode_sof.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_width = (t_3 as u32);
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u8(&mut t_4);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_n = (t_4 as u32);
+                }
+                if r == 0 {
+                    if (v_width == 0) || (v_height == 0) {
+                        status = ERROR_BAD_SOF_MARKER;
+                        break 'exit;
+                    } else if (v_n == 2) || (v_n == 4) {
+                        status = ERROR_TODO_UNSUPPORTED_NUMBER_OF_COMPONENTS;
+                        break 'exit;
+                    } else if ((v_n != 1) && (v_n != 3)) || (v_length != (8 + (3 * v_n))) {
+                        status = ERROR_BAD_SOF_MARKER;
+                        break 'exit;
+                    }
+                    self.f_width = v_width;
+                    self.f_height = v_height;
+                    self.f_num_components = u32::min(v_n, 3);
+                    v_num_components = self.f_num_components;
+                    v_max_h = 1;
+                    v_max_v = 1;
+                    v_i = 0;
+                }
+                if r == 0 || (6 <= r && r <= 8) {
+                    while r != 0 || (v_i < v_num_components) {
+                        if r == 0 || r == 6 {
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = a_src.read_u8(&mut t_5);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            (*unsafe { self.f_comp_id.get_unchecked_mut(v_i as usize) }) = t_5;
+                        }
+                        if r == 0 || r == 7 {
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status = a_src.read_u8(&mut t_6);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_hv = t_6;
+                        }
+                        if r == 0 {
+                            v_h = ((v_hv >> 4) as u32);
+                            v_v = ((v_hv & 15) as u32);
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = a_src.read_u8(&mut t_7);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_tq = t_7;
+                        }
+                        if r == 0 {
+                            if (v_h < 1) || (v_h > 4) || (v_v < 1) || (v_v > 4) || (v_tq > 3) {
+                                status = ERROR_BAD_SOF_MARKER;
+                                break 'exit;
+                            }
+                            (*unsafe { self.f_comp_h.get_unchecked_mut(v_i as usize) }) = v_h;
+                            (*unsafe { self.f_comp_v.get_unchecked_mut(v_i as usize) }) = v_v;
+                            (*unsafe { self.f_comp_tq.get_unchecked_mut(v_i as usize) }) = v_tq;
+                            (*unsafe {
+                                self.f_comp_quant_latched.get_unchecked_mut(v_i as usize)
+                            }) = false;
+                            v_max_h = u32::max(v_max_h, v_h);
+                            v_max_v = u32::max(v_max_v, v_v);
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_max_h = v_max_h;
+                    self.f_max_v = v_max_v;
+                    if v_num_components == 3 {
+                        if ((*unsafe { self.f_comp_h.get_unchecked(1 as usize) })
+                            != (*unsafe { self.f_comp_h.get_unchecked(2 as usize) }))
+                            || ((*unsafe { self.f_comp_v.get_unchecked(1 as usize) })
+                                != (*unsafe { self.f_comp_v.get_unchecked(2 as usize) }))
+                        {
+                            status = ERROR_TODO_UNSUPPORTED_SUBSAMPLING;
+                            break 'exit;
+                        } else if ((*unsafe { self.f_comp_h.get_unchecked(0 as usize) })
+                            != (*unsafe { self.f_comp_h.get_unchecked(1 as usize) }))
+                            || ((*unsafe { self.f_comp_v.get_unchecked(0 as usize) })
+                                != (*unsafe { self.f_comp_v.get_unchecked(1 as usize) }))
+                        {
+                            if ((*unsafe { self.f_comp_h.get_unchecked(1 as usize) }) != 1)
+                                || ((*unsafe { self.f_comp_v.get_unchecked(1 as usize) }) != 1)
+                                || ((*unsafe { self.f_comp_h.get_unchecked(0 as usize) }) > 2)
+                                || ((*unsafe { self.f_comp_v.get_unchecked(0 as usize) }) > 2)
+                            {
+                                status = ERROR_TODO_UNSUPPORTED_SUBSAMPLING;
+                                break 'exit;
+                            }
+                        }
+                    }
+                    v_mcu_width = (8 * u32::max(v_max_h, 1));
+                    v_mcu_height = (8 * u32::max(v_max_v, 1));
+                    v_mcus_across = ((v_width + (v_mcu_width - 1)) / v_mcu_width);
+                    v_mcus_down = ((v_height + (v_mcu_height - 1)) / v_mcu_height);
+                    self.f_mcus_across = u32::min(v_mcus_across, 8192);
+                    self.f_mcus_down = u32::min(v_mcus_down, 8192);
+                    v_num_blocks = 0;
+                    v_i = 0;
+                    while v_i < v_num_components {
+                        v_cw = (((v_width
+                            * (*unsafe { self.f_comp_h.get_unchecked(v_i as usize) }))
+                            + (u32::max(v_max_h, 1) - 1))
+                            / u32::max(v_max_h, 1));
+                        v_ch = (((v_height
+                            * (*unsafe { self.f_comp_v.get_unchecked(v_i as usize) }))
+                            + (u32::max(v_max_v, 1) - 1))
+                            / u32::max(v_max_v, 1));
+                        (*unsafe { self.f_comp_width_in_blocks.get_unchecked_mut(v_i as usize) }) =
+                            ((u32::min(v_cw, 65535) + 7) / 8);
+                        (*unsafe {
+                            self.f_comp_height_in_blocks.get_unchecked_mut(v_i as usize)
+                        }) = ((u32::min(v_ch, 65535) + 7) / 8);
+                        (*unsafe { self.f_comp_stride.get_unchecked_mut(v_i as usize) }) = (self
+                            .f_mcus_across
+                            * (*unsafe { self.f_comp_h.get_unchecked(v_i as usize) }));
+                        (*unsafe { self.f_comp_offset.get_unchecked_mut(v_i as usize) }) =
+                            u64::min(v_num_blocks, 2147483648);
+                        v_num_blocks = (u64::min(v_num_blocks, 2147483648)
+                            + (((self.f_mcus_across
+                                * (*unsafe { self.f_comp_h.get_unchecked(v_i as usize) }))
+                                as u64)
+                                * ((self.f_mcus_down
+                                    * (*unsafe { self.f_comp_v.get_unchecked(v_i as usize) }))
+                                    as u64)));
+                        v_i += 1;
+                    }
+                    self.f_num_blocks = v_num_blocks;
+                }
+                self.c_decode_sof.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_sof.coro_susp_point = csp;
+            self.c_decode_sof.v_length = v_length;
+            self.c_decode_sof.v_height = v_height;
+            self.c_decode_sof.v_width = v_width;
+            self.c_decode_sof.v_n = v_n;
+            self.c_decode_sof.v_num_components = v_num_components;
+            self.c_decode_sof.v_max_h = v_max_h;
+            self.c_decode_sof.v_max_v = v_max_v;
+            self.c_decode_sof.v_i = v_i;
+            self.c_decode_sof.v_hv = v_hv;
+            self.c_decode_sof.v_h = v_h;
+            self.c_decode_sof.v_v = v_v;
+            self.c_decode_sof.v_tq = v_tq;
+            self.c_decode_sof.v_mcu_width = v_mcu_width;
+            self.c_decode_sof.v_mcu_height = v_mcu_height;
+            self.c_decode_sof.v_mcus_across = v_mcus_across;
+            self.c_decode_sof.v_mcus_down = v_mcus_down;
+            self.c_decode_sof.v_num_blocks = v_num_blocks;
+            self.c_decode_sof.v_cw = v_cw;
+            self.c_decode_sof.v_ch = v_ch;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_dht
+
+impl Decoder {
+    fn decode_dht(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_tc_th: u8 = 0;
+        let mut v_t: u32 = 0;
+        let mut v_total: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_num_symbols: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_dht.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_decode_dht.v_length;
+            v_n = self.c_decode_dht.v_n;
+            v_tc_th = self.c_decode_dht.v_tc_th;
+            v_t = self.c_decode_dht.v_t;
+            v_total = self.c_decode_dht.v_total;
+            v_i = self.c_decode_dht.v_i;
+            v_num_symbols = self.c_decode_dht.v_num_symbols;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_dht.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16be(&mut t_0, &mut self.c_decode_dht.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_length = (t_0 as u32);
+                }
+                if r == 0 {
+                    if v_length < 2 {
+                        status = ERROR_BAD_DHT_MARKER;
+                        break 'exit;
+                    }
+                    v_n = (v_length - 2);
+                }
+                if r == 0 || (2 <= r && r <= 5) {
+                    while r != 0 || (v_n > 0) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u8(&mut t_1);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_tc_th = t_1;
+                        }
+                        if r == 0 {
+                            if ((v_tc_th >> 4) > 1) || ((v_tc_th & 15) > 3) || (v_n < 17) {
+                                status = ERROR_BAD_DHT_MARKER;
+                                break 'exit;
+                            }
+                            v_t = (((((v_tc_th >> 4) & 1) << 2) | (v_tc_th & 3)) as u32);
+                            v_n -= 17;
+                            v_total = 0;
+                            v_i = 1;
+                        }
+                        if r == 0 || r == 3 {
+                            while r != 0 || (v_i < 17) {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = a_src.read_u8(&mut t_2);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    (*unsafe {
+                                        self.f_huff_counts.get_unchecked_mut(v_i as usize)
+                                    }) = (t_2 as u32);
+                                }
+                                if r == 0 {
+                                    v_total = (u32::min(v_total, 65535)
+                                        + (*unsafe {
+                                            self.f_huff_counts.get_unchecked(v_i as usize)
+                                        }));
+                                    v_i += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if (v_total > 256) || (v_n < v_total) {
+                                status = ERROR_BAD_DHT_MARKER;
+                                break 'exit;
+                            }
+                            v_n -= v_total;
+                            v_num_symbols = v_total;
+                            v_i = 0;
+                        }
+                        if r == 0 || r == 4 {
+                            while r != 0 || (v_i < v_num_symbols) {
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = a_src.read_u8(&mut t_3);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    (*unsafe {
+                                        self.f_huff_vals
+                                            .get_unchecked_mut(v_t as usize)
+                                            .get_unchecked_mut(v_i as usize)
+                                    }) = t_3;
+                                }
+                                if r == 0 {
+                                    v_i += 1;
+                                }
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.build_huffman_table(v_t);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_dht.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_dht.coro_susp_point = csp;
+            self.c_decode_dht.v_length = v_length;
+            self.c_decode_dht.v_n = v_n;
+            self.c_decode_dht.v_tc_th = v_tc_th;
+            self.c_decode_dht.v_t = v_t;
+            self.c_decode_dht.v_total = v_total;
+            self.c_decode_dht.v_i = v_i;
+            self.c_decode_dht.v_num_symbols = v_num_symbols;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.build_huffman_table
+
+impl Decoder {
+    fn build_huffman_table(&mut self, mut a_t: u32) -> wuffs_base::Status {
+        let mut v_i: u32 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_k: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_count: u32 = 0;
+        let mut v_max_code: u32 = 0;
+        let mut v_j: u32 = 0;
+        let mut v_entry: u16 = 0;
+        let mut v_shift: u32 = 0;
+        let mut v_lo: u32 = 0;
+        let mut v_hi: u32 = 0;
+        let mut v_next_code: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_i = 0;
+            while v_i < 256 {
+                (*unsafe {
+                    self.f_huff_lut
+                        .get_unchecked_mut(a_t as usize)
+                        .get_unchecked_mut(v_i as usize)
+                }) = 0;
+                v_i += 1;
+            }
+            v_code = 0;
+            v_k = 0;
+            v_length = 1;
+            while v_length < 17 {
+                v_count = (*unsafe { self.f_huff_counts.get_unchecked(v_length as usize) });
+                v_max_code = ((1u32 << v_length) - 1);
+                if (v_code + v_count) > v_max_code {
+                    status = ERROR_BAD_DHT_MARKER;
+                    break 'exit;
+                }
+                (*unsafe {
+                    self.f_huff_valptr
+                        .get_unchecked_mut(a_t as usize)
+                        .get_unchecked_mut(v_length as usize)
+                }) = v_k;
+                (*unsafe {
+                    self.f_huff_mincode
+                        .get_unchecked_mut(a_t as usize)
+                        .get_unchecked_mut(v_length as usize)
+                }) = v_code;
+                (*unsafe {
+                    self.f_huff_maxcode
+                        .get_unchecked_mut(a_t as usize)
+                        .get_unchecked_mut(v_length as usize)
+                }) = 0;
+                if v_count > 0 {
+                    (*unsafe {
+                        self.f_huff_maxcode
+                            .get_unchecked_mut(a_t as usize)
+                            .get_unchecked_mut(v_length as usize)
+                    }) = (v_code + v_count);
+                }
+                v_j = 0;
+                while v_j < v_count {
+                    if v_length <= 8 {
+                        v_entry = (((v_length << 8)
+                            | ((*unsafe {
+                                self.f_huff_vals
+                                    .get_unchecked(a_t as usize)
+                                    .get_unchecked(v_k as usize)
+                            }) as u32)) as u16);
+                        v_shift = (8 - v_length);
+                        v_lo = (((v_code + v_j) & 255) << v_shift);
+                        v_hi = ((((v_code + v_j) & 255) + 1) << v_shift);
+                        while (v_lo < v_hi) && (v_lo < 256) {
+                            (*unsafe {
+                                self.f_huff_lut
+                                    .get_unchecked_mut(a_t as usize)
+                                    .get_unchecked_mut(v_lo as usize)
+                            }) = v_entry;
+                            v_lo += 1;
+                        }
+                    }
+                    if v_k < 255 {
+                        v_k += 1;
+                    }
+                    v_j += 1;
+                }
+                v_next_code = ((v_code + v_count) << 1);
+                v_code = u32::min(v_next_code, 65535);
+                v_length += 1;
+            }
+            (*unsafe { self.f_huff_defined.get_unchecked_mut(a_t as usize) }) = true;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_dqt
+
+impl Decoder {
+    fn decode_dqt(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_pq_tq: u8 = 0;
+        let mut v_pq: u8 = 0;
+        let mut v_tq: u8 = 0;
+        let mut v_i: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_dqt.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_decode_dqt.v_length;
+            v_n = self.c_decode_dqt.v_n;
+            v_pq_tq = self.c_decode_dqt.v_pq_tq;
+            v_pq = self.c_decode_dqt.v_pq;
+            v_tq = self.c_decode_dqt.v_tq;
+            v_i = self.c_decode_dqt.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_dqt.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16be(&mut t_0, &mut self.c_decode_dqt.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_length = (t_0 as u32);
+                }
+                if r == 0 {
+                    if v_length < 2 {
+                        status = ERROR_BAD_DQT_MARKER;
+                        break 'exit;
+                    }
+                    v_n = (v_length - 2);
+                }
+                if r == 0 || (2 <= r && r <= 4) {
+                    while r != 0 || (v_n > 0) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u8(&mut t_1);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_pq_tq = t_1;
+                        }
+                        if r == 0 {
+                            v_n -= 1;
+                            if (v_pq_tq & 236) != 0 {
+                                status = ERROR_BAD_DQT_MARKER;
+                                break 'exit;
+                            }
+                            v_pq = ((v_pq_tq >> 4) & 1);
+                            v_tq = (v_pq_tq & 3);
+                            v_i = 0;
+                        }
+                        if r == 0 || (3 <= r && r <= 4) {
+                            if r == 3 || (r == 0 && (v_pq == 0)) {
+                                if r == 0 {
+                                    if v_n < 64 {
+                                        status = ERROR_BAD_DQT_MARKER;
+                                        break 'exit;
+                                    }
+                                    v_n -= 64;
+                                }
+                                if r == 0 || r == 3 {
+                                    while r != 0 || (v_i < 64) {
+                                        if r == 0 || r == 3 {
+                                            if r == 3 {
+                                                r = 0;
+                                            }
+                                            csp = 3;
+                                            status = a_src.read_u8(&mut t_2);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            (*unsafe {
+                                                self.f_quant
+                                                    .get_unchecked_mut(v_tq as usize)
+                                                    .get_unchecked_mut(
+                                                        (*unsafe {
+                                                            ZIGZAG.get_unchecked(v_i as usize)
+                                                        })
+                                                            as usize,
+                                                    )
+                                            }) = (t_2 as u16);
+                                        }
+                                        if r == 0 {
+                                            v_i += 1;
+                                        }
+                                    }
+                                }
+                            } else {
+                                if r == 0 {
+                                    if v_n < 128 {
+                                        status = ERROR_BAD_DQT_MARKER;
+                                        break 'exit;
+                                    }
+                                    v_n -= 128;
+                                }
+                                if r == 0 || r == 4 {
+                                    while r != 0 || (v_i < 64) {
+                                        if r == 0 || r == 4 {
+                                            if r == 0 {
+                                                self.c_decode_dqt.scratch = 0;
+                                            }
+                                            if r == 4 {
+                                                r = 0;
+                                            }
+                                            csp = 4;
+                                            status = a_src.read_u16be(
+                                                &mut t_3,
+                                                &mut self.c_decode_dqt.scratch,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            (*unsafe {
+                                                self.f_quant
+                                                    .get_unchecked_mut(v_tq as usize)
+                                                    .get_unchecked_mut(
+                                                        (*unsafe {
+                                                            ZIGZAG.get_unchecked(v_i as usize)
+                                                        })
+                                                            as usize,
+                                                    )
+                                            }) = t_3;
+                                        }
+                                        if r == 0 {
+                                            v_i += 1;
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            (*unsafe { self.f_quant_defined.get_unchecked_mut(v_tq as usize) }) =
+                                true;
+                        }
+                    }
+                }
+                self.c_decode_dqt.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_dqt.coro_susp_point = csp;
+            self.c_decode_dqt.v_length = v_length;
+            self.c_decode_dqt.v_n = v_n;
+            self.c_decode_dqt.v_pq_tq = v_pq_tq;
+            self.c_decode_dqt.v_pq = v_pq;
+            self.c_decode_dqt.v_tq = v_tq;
+            self.c_decode_dqt.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_dri
+
+impl Decoder {
+    fn decode_dri(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut t_0: u16 = 0;
+        let mut t_1: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_dri.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_dri.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u16be(&mut t_0, &mut self.c_decode_dri.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 4) {
+                        status = ERROR_BAD_DRI_MARKER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_dri.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u16be(&mut t_1, &mut self.c_decode_dri.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_restart_interval = (t_1 as u32);
+                }
+                self.c_decode_dri.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_dri.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_sos
+
+impl Decoder {
+    fn decode_sos(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_num_components: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_id: u8 = 0;
+        let mut v_td_ta: u8 = 0;
+        let mut v_j: u32 = 0;
+        let mut v_ss: u32 = 0;
+        let mut v_se: u32 = 0;
+        let mut v_ah_al: u8 = 0;
+        let mut v_ah: u32 = 0;
+        let mut v_al: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_k: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut t_3: u8 = 0;
+        let mut t_4: u8 = 0;
+        let mut t_5: u8 = 0;
+        let mut t_6: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_sos.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_decode_sos.v_length;
+            v_n = self.c_decode_sos.v_n;
+            v_num_components = self.c_decode_sos.v_num_components;
+            v_i = self.c_decode_sos.v_i;
+            v_id = self.c_decode_sos.v_id;
+            v_td_ta = self.c_decode_sos.v_td_ta;
+            v_j = self.c_decode_sos.v_j;
+            v_ss = self.c_decode_sos.v_ss;
+            v_se = self.c_decode_sos.v_se;
+            v_ah_al = self.c_decode_sos.v_ah_al;
+            v_ah = self.c_decode_sos.v_ah;
+            v_al = self.c_decode_sos.v_al;
+            v_c = self.c_decode_sos.v_c;
+            v_k = self.c_decode_sos.v_k;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_sos.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16be(&mut t_0, &mut self.c_decode_sos.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_length = (t_0 as u32);
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u8(&mut t_1);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_n = (t_1 as u32);
+                }
+                if r == 0 {
+                    if (v_n < 1) || (v_n > self.f_num_components) || (v_length != (6 + (2 * v_n))) {
+                        status = ERROR_BAD_SOS_MARKER;
+                        break 'exit;
+                    }
+                    v_num_components = u32::min(v_n, 3);
+                    self.f_scan_num_components = v_num_components;
+                    v_i = 0;
+                }
+                if r == 0 || (3 <= r && r <= 4) {
+                    while r != 0 || (v_i < v_num_components) {
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = a_src.read_u8(&mut t_2);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_id = t_2;
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = a_src.read_u8(&mut t_3);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_td_ta = t_3;
+                        }
+                        if r == 0 {
+                            if ((v_td_ta >> 4) > 3) || ((v_td_ta & 15) > 3) {
+                                status = ERROR_BAD_SOS_MARKER;
+                                break 'exit;
+                            }
+                            (*unsafe { self.f_scan_td.get_unchecked_mut(v_i as usize) }) =
+                                ((v_td_ta >> 4) as u32);
+                            (*unsafe { self.f_scan_ta.get_unchecked_mut(v_i as usize) }) =
+                                ((v_td_ta & 15) as u32);
+                            v_j = 0;
+                            'label_0: loop {
+                                if (v_j >= self.f_num_components) || (v_j >= 3) {
+                                    status = ERROR_BAD_SOS_MARKER;
+                                    break 'exit;
+                                }
+                                if (*unsafe { self.f_comp_id.get_unchecked(v_j as usize) }) == v_id
+                                {
+                                    break 'label_0;
+                                }
+                                v_j += 1;
+                            }
+                            (*unsafe { self.f_scan_comp.get_unchecked_mut(v_i as usize) }) =
+                                u32::min(v_j, 2);
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u8(&mut t_4);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_ss = (t_4 as u32);
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_src.read_u8(&mut t_5);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_se = (t_5 as u32);
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = a_src.read_u8(&mut t_6);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_ah_al = t_6;
+                }
+                if r == 0 {
+                    v_ah = ((v_ah_al >> 4) as u32);
+                    v_al = ((v_ah_al & 15) as u32);
+                    if self.f_progressive {
+                        if (v_ss > 63)
+                            || (v_se > 63)
+                            || (v_ss > v_se)
+                            || (v_ah > 13)
+                            || (v_al > 13)
+                            || ((v_ss == 0) && (v_se != 0))
+                            || ((v_ss != 0) && (v_num_components != 1))
+                        {
+                            status = ERROR_BAD_SOS_MARKER;
+                            break 'exit;
+                        }
+                        self.f_ss = v_ss;
+                        self.f_se = v_se;
+                        self.f_ah = v_ah;
+                        self.f_al = v_al;
+                    } else {
+                        self.f_ss = 0;
+                        self.f_se = 63;
+                        self.f_ah = 0;
+                        self.f_al = 0;
+                    }
+                    v_i = 0;
+                    while v_i < v_num_components {
+                        v_c = (*unsafe { self.f_scan_comp.get_unchecked(v_i as usize) });
+                        if !(*unsafe { self.f_comp_quant_latched.get_unchecked(v_c as usize) }) {
+                            if !(*unsafe {
+                                self.f_quant_defined.get_unchecked(
+                                    (*unsafe { self.f_comp_tq.get_unchecked(v_c as usize) })
+                                        as usize,
+                                )
+                            }) {
+                                status = ERROR_MISSING_QUANTIZATION_TABLE;
+                                break 'exit;
+                            }
+                            v_k = 0;
+                            while v_k < 64 {
+                                (*unsafe {
+                                    self.f_comp_quant
+                                        .get_unchecked_mut(v_c as usize)
+                                        .get_unchecked_mut(v_k as usize)
+                                }) = (*unsafe {
+                                    self.f_quant
+                                        .get_unchecked(
+                                            (*unsafe { self.f_comp_tq.get_unchecked(v_c as usize) })
+                                                as usize,
+                                        )
+                                        .get_unchecked(v_k as usize)
+                                });
+                                v_k += 1;
+                            }
+                            (*unsafe {
+                                self.f_comp_quant_latched.get_unchecked_mut(v_c as usize)
+                            }) = true;
+                        }
+                        if (self.f_ss == 0)
+                            && (self.f_ah == 0)
+                            && !(*unsafe {
+                                self.f_huff_defined.get_unchecked(
+                                    (*unsafe { self.f_scan_td.get_unchecked(v_i as usize) })
+                                        as usize,
+                                )
+                            })
+                        {
+                            status = ERROR_MISSING_HUFFMAN_TABLE;
+                            break 'exit;
+                        }
+                        if (self.f_se > 0)
+                            && !(*unsafe {
+                                self.f_huff_defined.get_unchecked(
+                                    (4 + (*unsafe { self.f_scan_ta.get_unchecked(v_i as usize) }))
+                                        as usize,
+                                )
+                            })
+                        {
+                            status = ERROR_MISSING_HUFFMAN_TABLE;
+                            break 'exit;
+                        }
+                        v_i += 1;
+                    }
+                }
+                self.c_decode_sos.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_sos.coro_susp_point = csp;
+            self.c_decode_sos.v_length = v_length;
+            self.c_decode_sos.v_n = v_n;
+            self.c_decode_sos.v_num_components = v_num_components;
+            self.c_decode_sos.v_i = v_i;
+            self.c_decode_sos.v_id = v_id;
+            self.c_decode_sos.v_td_ta = v_td_ta;
+            self.c_decode_sos.v_j = v_j;
+            self.c_decode_sos.v_ss = v_ss;
+            self.c_decode_sos.v_se = v_se;
+            self.c_decode_sos.v_ah_al = v_ah_al;
+            self.c_decode_sos.v_ah = v_ah;
+            self.c_decode_sos.v_al = v_al;
+            self.c_decode_sos.v_c = v_c;
+            self.c_decode_sos.v_k = v_k;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_scan
+
+impl Decoder {
+    fn decode_scan(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        let mut v_mcus_x: u32 = 0;
+        let mut v_mcus_y: u32 = 0;
+        let mut v_my: u32 = 0;
+        let mut v_mx: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_scan.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_mcus_x = self.c_decode_scan.v_mcus_x;
+            v_mcus_y = self.c_decode_scan.v_mcus_y;
+            v_my = self.c_decode_scan.v_my;
+            v_mx = self.c_decode_scan.v_mx;
+            v_i = self.c_decode_scan.v_i;
+            v_c = self.c_decode_scan.v_c;
+            v_h = self.c_decode_scan.v_h;
+            v_v = self.c_decode_scan.v_v;
+            v_y = self.c_decode_scan.v_y;
+            v_x = self.c_decode_scan.v_x;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_bits = 0;
+                    self.f_n_bits = 0;
+                    self.f_marker_pending = 0;
+                    self.f_eobrun = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(0 as usize) }) = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(1 as usize) }) = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(2 as usize) }) = 0;
+                    self.f_restarts_to_go = self.f_restart_interval;
+                    self.f_next_restart = 0;
+                    v_mcus_x = self.f_mcus_across;
+                    v_mcus_y = self.f_mcus_down;
+                    if self.f_scan_num_components == 1 {
+                        v_mcus_x = (*unsafe {
+                            self.f_comp_width_in_blocks.get_unchecked(
+                                (*unsafe { self.f_scan_comp.get_unchecked(0 as usize) }) as usize,
+                            )
+                        });
+                        v_mcus_y = (*unsafe {
+                            self.f_comp_height_in_blocks.get_unchecked(
+                                (*unsafe { self.f_scan_comp.get_unchecked(0 as usize) }) as usize,
+                            )
+                        });
+                    }
+                    v_my = 0;
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    while r != 0 || (v_my < v_mcus_y) {
+                        if r == 0 {
+                            v_mx = 0;
+                        }
+                        if r == 0 || (1 <= r && r <= 3) {
+                            while r != 0 || (v_mx < v_mcus_x) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 || (r == 0 && (self.f_restart_interval > 0)) {
+                                        if r == 0 || r == 1 {
+                                            if r == 1 || (r == 0 && (self.f_restarts_to_go == 0)) {
+                                                if r == 0 || r == 1 {
+                                                    if r == 1 {
+                                                        r = 0;
+                                                    }
+                                                    csp = 1;
+                                                    status = self.process_restart(a_src);
+                                                    if !status.is_ok() {
+                                                        break 'suspend;
+                                                    }
+                                                }
+                                            }
+                                        }
+                                        if r == 0 {
+                                            self.f_restarts_to_go =
+                                                u32::saturating_sub(self.f_restarts_to_go, 1);
+                                        }
+                                    }
+                                }
+                                if r == 0 || (2 <= r && r <= 3) {
+                                    if r == 2 || (r == 0 && (self.f_scan_num_components == 1)) {
+                                        if r == 0 || r == 2 {
+                                            if r == 2 {
+                                                r = 0;
+                                            }
+                                            csp = 2;
+                                            status = self.decode_block(
+                                                a_dst, a_src, a_workbuf, 0, v_mx, v_my,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    } else {
+                                        if r == 0 {
+                                            v_i = 0;
+                                        }
+                                        if r == 0 || r == 3 {
+                                            while r != 0 || (v_i < self.f_scan_num_components) {
+                                                if r == 0 {
+                                                    v_c = (*unsafe {
+                                                        self.f_scan_comp.get_unchecked(v_i as usize)
+                                                    });
+                                                    v_h = (*unsafe {
+                                                        self.f_comp_h.get_unchecked(v_c as usize)
+                                                    });
+                                                    v_v = (*unsafe {
+                                                        self.f_comp_v.get_unchecked(v_c as usize)
+                                                    });
+                                                    v_y = 0;
+                                                }
+                                                if r == 0 || r == 3 {
+                                                    while r != 0 || (v_y < v_v) {
+                                                        if r == 0 {
+                                                            v_x = 0;
+                                                        }
+                                                        if r == 0 || r == 3 {
+                                                            while r != 0 || (v_x < v_h) {
+                                                                if r == 0 || r == 3 {
+                                                                    if r == 3 {
+                                                                        r = 0;
+                                                                    }
+                                                                    csp = 3;
+                                                                    status = self.decode_block(
+                                                                        a_dst,
+                                                                        a_src,
+                                                                        a_workbuf,
+                                                                        v_i,
+                                                                        ((v_mx * v_h) + v_x),
+                                                                        ((v_my * v_v) + v_y),
+                                                                    );
+                                                                    if !status.is_ok() {
+                                                                        break 'suspend;
+                                                                    }
+                                                                }
+                                                                if r == 0 {
+                                                                    v_x += 1;
+                                                                }
+                                                            }
+                                                        }
+                                                        if r == 0 {
+                                                            v_y += 1;
+                                                        }
+                                                    }
+                                                }
+                                                if r == 0 {
+                                                    v_i += 1;
+                                                }
+                                            }
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_mx += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_my += 1;
+                        }
+                    }
+                }
+                self.c_decode_scan.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_scan.coro_susp_point = csp;
+            self.c_decode_scan.v_mcus_x = v_mcus_x;
+            self.c_decode_scan.v_mcus_y = v_mcus_y;
+            self.c_decode_scan.v_my = v_my;
+            self.c_decode_scan.v_mx = v_mx;
+            self.c_decode_scan.v_i = v_i;
+            self.c_decode_scan.v_c = v_c;
+            self.c_decode_scan.v_h = v_h;
+            self.c_decode_scan.v_v = v_v;
+            self.c_decode_scan.v_y = v_y;
+            self.c_decode_scan.v_x = v_x;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.process_restart
+
+impl Decoder {
+    fn process_restart(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_process_restart.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_bits = 0;
+                    self.f_n_bits = 0;
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.read_marker(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    if (self.f_marker as u32) != (208 + self.f_next_restart) {
+                        status = ERROR_BAD_RST_MARKER;
+                        break 'exit;
+                    }
+                    self.f_next_restart = ((self.f_next_restart + 1) & 7);
+                    self.f_restarts_to_go = self.f_restart_interval;
+                    self.f_eobrun = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(0 as usize) }) = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(1 as usize) }) = 0;
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(2 as usize) }) = 0;
+                }
+                self.c_process_restart.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_process_restart.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.fill_bits
+
+impl Decoder {
+    fn fill_bits(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut t_2: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_bits.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_fill_bits.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = 0;
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    while r != 0 || (self.f_n_bits <= 24) {
+                        if r == 0 {
+                            v_c = 0;
+                        }
+                        if r == 0 || (1 <= r && r <= 3) {
+                            if (1 <= r && r <= 3) || (r == 0 && (self.f_marker_pending == 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_0;
+                                }
+                                if r == 0 || (2 <= r && r <= 3) {
+                                    if (2 <= r && r <= 3) || (r == 0 && (v_c == 255)) {
+                                        if r == 0 || r == 2 {
+                                            if r == 2 {
+                                                r = 0;
+                                            }
+                                            csp = 2;
+                                            status = a_src.read_u8(&mut t_1);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            v_c = t_1;
+                                        }
+                                        if r == 0 || r == 3 {
+                                            while r != 0 || (v_c == 255) {
+                                                if r == 0 || r == 3 {
+                                                    if r == 3 {
+                                                        r = 0;
+                                                    }
+                                                    csp = 3;
+                                                    status = a_src.read_u8(&mut t_2);
+                                                    if !status.is_ok() {
+                                                        break 'suspend;
+                                                    }
+                                                    v_c = t_2;
+                                                }
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if v_c == 0 {
+                                                v_c = 255;
+                                            } else {
+                                                self.f_marker_pending = v_c;
+                                                v_c = 0;
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            self.f_bits = (((self.f_bits & 16777215) << 8) | (v_c as u32));
+                            self.f_n_bits += 8;
+                        }
+                    }
+                }
+                self.c_fill_bits.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_bits.coro_susp_point = csp;
+            self.c_fill_bits.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.take_bits
+
+impl Decoder {
+    fn take_bits(&mut self, mut a_n: u32) -> u32 {
+        let mut v_v: u32 = 0;
+
+        if (a_n == 0) || (self.f_n_bits < a_n) {
+            return 0;
+        }
+        self.f_n_bits -= a_n;
+        v_v = (self.f_bits >> self.f_n_bits);
+        return (v_v & u32::MAX.checked_shr(32 - (a_n as u32)).unwrap_or(0));
+    }
+}
+
+// -------- func decoder.decode_huffman
+
+impl Decoder {
+    fn decode_huffman(&mut self, mut a_t: u32) -> u32 {
+        let mut v_n_bits: u32 = 0;
+        let mut v_entry: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_code: u32 = 0;
+
+        v_n_bits = self.f_n_bits;
+        if v_n_bits < 16 {
+            return 256;
+        }
+        v_entry = ((*unsafe {
+            self.f_huff_lut
+                .get_unchecked(a_t as usize)
+                .get_unchecked(((self.f_bits >> (v_n_bits - 8)) & 255) as usize)
+        }) as u32);
+        if v_entry != 0 {
+            self.f_n_bits = u32::saturating_sub(v_n_bits, (v_entry >> 8));
+            return (v_entry & 255);
+        }
+        v_length = 9;
+        while v_length < 17 {
+            v_code = (self.f_bits >> (v_n_bits - v_length));
+            v_code = (v_code & u32::MAX.checked_shr(32 - (v_length as u32)).unwrap_or(0));
+            if v_code
+                < (*unsafe {
+                    self.f_huff_maxcode
+                        .get_unchecked(a_t as usize)
+                        .get_unchecked(v_length as usize)
+                })
+            {
+                self.f_n_bits = (v_n_bits - v_length);
+                return ((*unsafe {
+                    self.f_huff_vals.get_unchecked(a_t as usize).get_unchecked(
+                        (u32::wrapping_sub(
+                            u32::wrapping_add(
+                                (*unsafe {
+                                    self.f_huff_valptr
+                                        .get_unchecked(a_t as usize)
+                                        .get_unchecked(v_length as usize)
+                                }),
+                                v_code,
+                            ),
+                            (*unsafe {
+                                self.f_huff_mincode
+                                    .get_unchecked(a_t as usize)
+                                    .get_unchecked(v_length as usize)
+                            }),
+                        ) & 255) as usize,
+                    )
+                }) as u32);
+            }
+            v_length += 1;
+        }
+        return 256;
+    }
+}
+
+// -------- func decoder.extend
+
+impl Decoder {
+    fn extend(&mut self, mut a_v: u32, mut a_n: u32) -> u16 {
+        let mut v_v: u32 = 0;
+
+        if a_n == 0 {
+            return 0;
+        }
+        v_v = a_v;
+        if (v_v >> (a_n - 1)) == 0 {
+            v_v = u32::wrapping_add(u32::wrapping_sub(v_v, (1u32 << a_n)), 1);
+        }
+        return ((v_v & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0)) as u16);
+    }
+}
+
+// -------- func decoder.decode_block
+
+impl Decoder {
+    fn decode_block(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_s: u32,
+        mut a_bx: u32,
+        mut a_by: u32,
+    ) -> wuffs_base::Status {
+        let mut v_c: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_index: u64 = 0;
+        let mut v_offset: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_block.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_block.v_c;
+            v_i = self.c_decode_block.v_i;
+            v_index = self.c_decode_block.v_index;
+            v_offset = self.c_decode_block.v_offset;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        v_c = (*unsafe { self.f_scan_comp.get_unchecked(a_s as usize) });
+                    }
+                    if r == 0 || (1 <= r && r <= 2) {
+                        if (1 <= r && r <= 2) || (r == 0 && !self.f_progressive) {
+                            if r == 0 {
+                                v_i = 0;
+                                while v_i < 64 {
+                                    (*unsafe { self.f_block.get_unchecked_mut(v_i as usize) }) = 0;
+                                    v_i += 1;
+                                }
+                            }
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = self.decode_dc_first(a_src, a_s);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                if r == 2 {
+                                    r = 0;
+                                }
+                                csp = 2;
+                                status = self.decode_ac_first(a_src, a_s);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                self.write_block(a_dst, v_c, a_bx, a_by);
+                                status = wuffs_base::Status::Ok;
+                                break 'ok;
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        v_index = ((*unsafe { self.f_comp_offset.get_unchecked(v_c as usize) })
+                            + ((a_by as u64)
+                                * ((*unsafe { self.f_comp_stride.get_unchecked(v_c as usize) })
+                                    as u64))
+                            + (a_bx as u64));
+                        v_offset = (v_index * 128);
+                        if (a_workbuf.len() as u64) < v_offset {
+                            status = ERROR_BAD_WORKBUF_LENGTH;
+                            break 'exit;
+                        } else if ((a_workbuf.len() as u64) - v_offset) < 128 {
+                            status = ERROR_BAD_WORKBUF_LENGTH;
+                            break 'exit;
+                        }
+                        self.load_coefs(unsafe { a_workbuf.subslice_i(v_offset as usize) });
+                    }
+                    if r == 0 || (3 <= r && r <= 6) {
+                        if (3 <= r && r <= 4) || (r == 0 && (self.f_ss == 0)) {
+                            if r == 0 || (3 <= r && r <= 4) {
+                                if r == 3 || (r == 0 && (self.f_ah == 0)) {
+                                    if r == 0 || r == 3 {
+                                        if r == 3 {
+                                            r = 0;
+                                        }
+                                        csp = 3;
+                                        status = self.decode_dc_first(a_src, a_s);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                } else {
+                                    if r == 0 || r == 4 {
+                                        if r == 4 {
+                                            r = 0;
+                                        }
+                                        csp = 4;
+                                        status = self.decode_dc_refine(a_src);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                }
+                            }
+                        } else if r == 5 || (r == 0 && (self.f_ah == 0)) {
+                            if r == 0 || r == 5 {
+                                if r == 5 {
+                                    r = 0;
+                                }
+                                csp = 5;
+                                status = self.decode_ac_first(a_src, a_s);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                        } else {
+                            if r == 0 || r == 6 {
+                                if r == 6 {
+                                    r = 0;
+                                }
+                                csp = 6;
+                                status = self.decode_ac_refine(a_src, a_s);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        if v_offset > (a_workbuf.len() as u64) {
+                            status = ERROR_BAD_WORKBUF_LENGTH;
+                            break 'exit;
+                        }
+                        self.store_coefs(unsafe { a_workbuf.subslice_i(v_offset as usize) });
+                    }
+                }
+                self.c_decode_block.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_block.coro_susp_point = csp;
+            self.c_decode_block.v_c = v_c;
+            self.c_decode_block.v_i = v_i;
+            self.c_decode_block.v_index = v_index;
+            self.c_decode_block.v_offset = v_offset;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.load_coefs
+
+impl Decoder {
+    fn load_coefs(&mut self, mut a_coefs: wuffs_base::SliceU8) {
+        let mut v_i: u32 = 0;
+
+        unsafe { wuffs_base::SliceU8::from_array(&mut self.f_coef_bytes) }.copy_from_slice(a_coefs);
+        v_i = 0;
+        while v_i < 64 {
+            (*unsafe { self.f_block.get_unchecked_mut(v_i as usize) }) =
+                (((*unsafe { self.f_coef_bytes.get_unchecked((2 * v_i) as usize) }) as u16)
+                    | (((*unsafe { self.f_coef_bytes.get_unchecked(((2 * v_i) + 1) as usize) })
+                        as u16)
+                        << 8));
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func decoder.store_coefs
+
+impl Decoder {
+    fn store_coefs(&mut self, mut a_coefs: wuffs_base::SliceU8) {
+        let mut v_i: u32 = 0;
+
+        v_i = 0;
+        while v_i < 64 {
+            (*unsafe { self.f_coef_bytes.get_unchecked_mut((2 * v_i) as usize) }) =
+                (((*unsafe { self.f_block.get_unchecked(v_i as usize) }) & 255) as u8);
+            (*unsafe {
+                self.f_coef_bytes
+                    .get_unchecked_mut(((2 * v_i) + 1) as usize)
+            }) = (((*unsafe { self.f_block.get_unchecked(v_i as usize) }) >> 8) as u8);
+            v_i += 1;
+        }
+        a_coefs.copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut self.f_coef_bytes) });
+    }
+}
+
+// -------- func decoder.decode_dc_first
+
+impl Decoder {
+    fn decode_dc_first(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_s: u32,
+    ) -> wuffs_base::Status {
+        let mut v_c: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_coef: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_dc_first.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_dc_first.v_c;
+            v_n = self.c_decode_dc_first.v_n;
+            v_v = self.c_decode_dc_first.v_v;
+            v_coef = self.c_decode_dc_first.v_coef;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = (*unsafe { self.f_scan_comp.get_unchecked(a_s as usize) });
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.fill_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_n = self
+                        .decode_huffman((*unsafe { self.f_scan_td.get_unchecked(a_s as usize) }));
+                    if v_n > 15 {
+                        status = ERROR_BAD_HUFFMAN_CODE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.fill_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_v = self.take_bits(v_n);
+                    (*unsafe { self.f_dc_pred.get_unchecked_mut(v_c as usize) }) =
+                        u16::wrapping_add(
+                            (*unsafe { self.f_dc_pred.get_unchecked(v_c as usize) }),
+                            self.extend(v_v, v_n),
+                        );
+                    v_coef = (((*unsafe { self.f_dc_pred.get_unchecked(v_c as usize) }) as u32)
+                        << self.f_al);
+                    (*unsafe { self.f_block.get_unchecked_mut(0 as usize) }) =
+                        ((v_coef & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0)) as u16);
+                }
+                self.c_decode_dc_first.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_dc_first.coro_susp_point = csp;
+            self.c_decode_dc_first.v_c = v_c;
+            self.c_decode_dc_first.v_n = v_n;
+            self.c_decode_dc_first.v_v = v_v;
+            self.c_decode_dc_first.v_coef = v_coef;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_dc_refine
+
+impl Decoder {
+    fn decode_dc_refine(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_dc_refine.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.fill_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    if self.take_bits(1) != 0 {
+                        (*unsafe { self.f_block.get_unchecked_mut(0 as usize) }) |=
+                            ((1u32 << self.f_al) as u16);
+                    }
+                }
+                self.c_decode_dc_refine.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_dc_refine.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_ac_first
+
+impl Decoder {
+    fn decode_ac_first(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_s: u32,
+    ) -> wuffs_base::Status {
+        let mut v_t: u32 = 0;
+        let mut v_k: u32 = 0;
+        let mut v_rs: u32 = 0;
+        let mut v_r: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_coef: u32 = 0;
+        let mut v_eobrun: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_ac_first.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_t = self.c_decode_ac_first.v_t;
+            v_k = self.c_decode_ac_first.v_k;
+            v_rs = self.c_decode_ac_first.v_rs;
+            v_r = self.c_decode_ac_first.v_r;
+            v_n = self.c_decode_ac_first.v_n;
+            v_v = self.c_decode_ac_first.v_v;
+            v_coef = self.c_decode_ac_first.v_coef;
+            v_eobrun = self.c_decode_ac_first.v_eobrun;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        if self.f_eobrun > 0 {
+                            self.f_eobrun -= 1;
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        v_t = (4 + (*unsafe { self.f_scan_ta.get_unchecked(a_s as usize) }));
+                        v_k = u32::max(self.f_ss, 1);
+                    }
+                    if r == 0 || (1 <= r && r <= 3) {
+                        'label_0: while r != 0 || (v_k <= self.f_se) {
+                            if r == 0 || r == 1 {
+                                if r == 1 {
+                                    r = 0;
+                                }
+                                csp = 1;
+                                status = self.fill_bits(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                v_rs = self.decode_huffman(v_t);
+                                if v_rs > 255 {
+                                    status = ERROR_BAD_HUFFMAN_CODE;
+                                    break 'exit;
+                                }
+                                v_r = (v_rs >> 4);
+                                v_n = (v_rs & 15);
+                            }
+                            if r == 0 || (2 <= r && r <= 3) {
+                                if r == 2 || (r == 0 && (v_n != 0)) {
+                                    if r == 0 || r == 2 {
+                                        if r == 2 {
+                                            r = 0;
+                                        }
+                                        csp = 2;
+                                        status = self.fill_bits(a_src);
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 {
+                                        v_v = self.take_bits(v_n);
+                                        v_coef = ((self.extend(v_v, v_n) as u32) << self.f_al);
+                                        (*unsafe {
+                                            self.f_block.get_unchecked_mut(
+                                                (*unsafe {
+                                                    ZIGZAG.get_unchecked((v_k + v_r) as usize)
+                                                })
+                                                    as usize,
+                                            )
+                                        }) = ((v_coef
+                                            & u32::MAX.checked_shr(32 - (16 as u32)).unwrap_or(0))
+                                            as u16);
+                                        v_k += (v_r + 1);
+                                    }
+                                } else if r == 0 && (v_r == 15) {
+                                    v_k += 16;
+                                } else {
+                                    if r == 0 || r == 3 {
+                                        if r == 3 || (r == 0 && ((v_r > 0) && self.f_progressive)) {
+                                            if r == 0 || r == 3 {
+                                                if r == 3 {
+                                                    r = 0;
+                                                }
+                                                csp = 3;
+                                                status = self.fill_bits(a_src);
+                                                if !status.is_ok() {
+                                                    break 'suspend;
+                                                }
+                                            }
+                                            if r == 0 {
+                                                v_eobrun =
+                                                    (((1u32 << v_r) - 1) + self.take_bits(v_r));
+                                                self.f_eobrun = u32::min(v_eobrun, 32767);
+                                            }
+                                        }
+                                    }
+                                    if r == 0 {
+                                        break 'label_0;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_decode_ac_first.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_ac_first.coro_susp_point = csp;
+            self.c_decode_ac_first.v_t = v_t;
+            self.c_decode_ac_first.v_k = v_k;
+            self.c_decode_ac_first.v_rs = v_rs;
+            self.c_decode_ac_first.v_r = v_r;
+            self.c_decode_ac_first.v_n = v_n;
+            self.c_decode_ac_first.v_v = v_v;
+            self.c_decode_ac_first.v_coef = v_coef;
+            self.c_decode_ac_first.v_eobrun = v_eobrun;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_ac_refine
+
+impl Decoder {
+    fn decode_ac_refine(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_s: u32,
+    ) -> wuffs_base::Status {
+        let mut v_t: u32 = 0;
+        let mut v_p1: u32 = 0;
+        let mut v_k: u32 = 0;
+        let mut v_v: u16 = 0;
+        let mut v_z: u32 = 0;
+        let mut v_rs: u32 = 0;
+        let mut v_r: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_eobrun: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_ac_refine.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_t = self.c_decode_ac_refine.v_t;
+            v_p1 = self.c_decode_ac_refine.v_p1;
+            v_k = self.c_decode_ac_refine.v_k;
+            v_v = self.c_decode_ac_refine.v_v;
+            v_z = self.c_decode_ac_refine.v_z;
+            v_rs = self.c_decode_ac_refine.v_rs;
+            v_r = self.c_decode_ac_refine.v_r;
+            v_n = self.c_decode_ac_refine.v_n;
+            v_eobrun = self.c_decode_ac_refine.v_eobrun;
+            v_i = self.c_decode_ac_refine.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_t = (4 + (*unsafe { self.f_scan_ta.get_unchecked(a_s as usize) }));
+                    v_p1 = (1u32 << self.f_al);
+                    v_k = self.f_ss;
+                    v_v = 0;
+                    v_z = 0;
+                }
+                if r == 0 || (1 <= r && r <= 4) {
+                    if (1 <= r && r <= 4) || (r == 0 && (self.f_eobrun == 0)) {
+                        if r == 0 || (1 <= r && r <= 4) {
+                            'label_0: while r != 0 || (v_k <= self.f_se) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_rs = self.decode_huffman(v_t);
+                                    if v_rs > 255 {
+                                        status = ERROR_BAD_HUFFMAN_CODE;
+                                        break 'exit;
+                                    }
+                                    v_r = (v_rs >> 4);
+                                    v_n = (v_rs & 15);
+                                    v_v = 0;
+                                }
+                                if r == 0 || (2 <= r && r <= 3) {
+                                    if r == 2 || (r == 0 && (v_n != 0)) {
+                                        if r == 0 || r == 2 {
+                                            if r == 2 {
+                                                r = 0;
+                                            }
+                                            csp = 2;
+                                            status = self.fill_bits(a_src);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if self.take_bits(1) != 0 {
+                                                v_v = (v_p1 as u16);
+                                            } else {
+                                                v_v = u16::wrapping_sub(0u16, (v_p1 as u16));
+                                            }
+                                        }
+                                    } else if r == 3 || (r == 0 && (v_r != 15)) {
+                                        if r == 0 {
+                                            self.f_eobrun = (1u32 << v_r);
+                                        }
+                                        if r == 0 || r == 3 {
+                                            if r == 3 || (r == 0 && (v_r > 0)) {
+                                                if r == 0 || r == 3 {
+                                                    if r == 3 {
+                                                        r = 0;
+                                                    }
+                                                    csp = 3;
+                                                    status = self.fill_bits(a_src);
+                                                    if !status.is_ok() {
+                                                        break 'suspend;
+                                                    }
+                                                }
+                                                if r == 0 {
+                                                    v_eobrun =
+                                                        (self.f_eobrun + self.take_bits(v_r));
+                                                    self.f_eobrun = u32::min(v_eobrun, 32767);
+                                                }
+                                            }
+                                        }
+                                        if r == 0 {
+                                            break 'label_0;
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_z = v_r;
+                                }
+                                if r == 0 || r == 4 {
+                                    'label_1: while r != 0 || (v_k <= self.f_se) {
+                                        if r == 0 {
+                                            v_i = ((*unsafe { ZIGZAG.get_unchecked(v_k as usize) })
+                                                as u32);
+                                        }
+                                        if r == 0 || r == 4 {
+                                            if r == 4
+                                                || (r == 0
+                                                    && ((*unsafe {
+                                                        self.f_block.get_unchecked(v_i as usize)
+                                                    }) != 0))
+                                            {
+                                                if r == 0 || r == 4 {
+                                                    if r == 4 {
+                                                        r = 0;
+                                                    }
+                                                    csp = 4;
+                                                    status =
+                                                        self.refine_coefficient(a_src, v_i, v_p1);
+                                                    if !status.is_ok() {
+                                                        break 'suspend;
+                                                    }
+                                                }
+                                            } else if r == 0 && (v_z == 0) {
+                                                break 'label_1;
+                                            } else {
+                                                v_z -= 1;
+                                            }
+                                        }
+                                        if r == 0 {
+                                            v_k += 1;
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    if v_v != 0 {
+                                        (*unsafe {
+                                            self.f_block.get_unchecked_mut(
+                                                (*unsafe { ZIGZAG.get_unchecked(v_k as usize) })
+                                                    as usize,
+                                            )
+                                        }) = v_v;
+                                    }
+                                    v_k += 1;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 || (r == 0 && (self.f_eobrun > 0)) {
+                        if r == 0 || r == 5 {
+                            while r != 0 || (v_k <= self.f_se) {
+                                if r == 0 || r == 5 {
+                                    if r == 5
+                                        || (r == 0
+                                            && ((*unsafe {
+                                                self.f_block.get_unchecked(
+                                                    (*unsafe { ZIGZAG.get_unchecked(v_k as usize) })
+                                                        as usize,
+                                                )
+                                            }) != 0))
+                                    {
+                                        if r == 0 || r == 5 {
+                                            if r == 5 {
+                                                r = 0;
+                                            }
+                                            csp = 5;
+                                            status = self.refine_coefficient(
+                                                a_src,
+                                                ((*unsafe { ZIGZAG.get_unchecked(v_k as usize) })
+                                                    as u32),
+                                                v_p1,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    v_k += 1;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            self.f_eobrun = u32::saturating_sub(self.f_eobrun, 1);
+                        }
+                    }
+                }
+                self.c_decode_ac_refine.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_ac_refine.coro_susp_point = csp;
+            self.c_decode_ac_refine.v_t = v_t;
+            self.c_decode_ac_refine.v_p1 = v_p1;
+            self.c_decode_ac_refine.v_k = v_k;
+            self.c_decode_ac_refine.v_v = v_v;
+            self.c_decode_ac_refine.v_z = v_z;
+            self.c_decode_ac_refine.v_rs = v_rs;
+            self.c_decode_ac_refine.v_r = v_r;
+            self.c_decode_ac_refine.v_n = v_n;
+            self.c_decode_ac_refine.v_eobrun = v_eobrun;
+            self.c_decode_ac_refine.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.refine_coefficient
+
+impl Decoder {
+    fn refine_coefficient(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_i: u32,
+        mut a_p1: u32,
+    ) -> wuffs_base::Status {
+        let mut v_v: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_refine_coefficient.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_v = self.c_refine_coefficient.v_v;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = self.fill_bits(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 {
+                        if self.take_bits(1) == 0 {
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        v_v = (*unsafe { self.f_block.get_unchecked(a_i as usize) });
+                        if ((v_v as u32) & a_p1) != 0 {
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        if (v_v >> 15) == 0 {
+                            (*unsafe { self.f_block.get_unchecked_mut(a_i as usize) }) =
+                                u16::wrapping_add(v_v, (a_p1 as u16));
+                        } else {
+                            (*unsafe { self.f_block.get_unchecked_mut(a_i as usize) }) =
+                                u16::wrapping_sub(v_v, (a_p1 as u16));
+                        }
+                    }
+                }
+                self.c_refine_coefficient.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_refine_coefficient.coro_susp_point = csp;
+            self.c_refine_coefficient.v_v = v_v;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.write_progressive_blocks
+
+impl Decoder {
+    fn write_progressive_blocks(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) {
+        let mut v_c: u32 = 0;
+        let mut v_by: u32 = 0;
+        let mut v_bx: u32 = 0;
+        let mut v_index: u64 = 0;
+        let mut v_offset: u64 = 0;
+
+        v_c = 0;
+        while v_c < self.f_num_components {
+            v_by = 0;
+            while v_by < (*unsafe { self.f_comp_height_in_blocks.get_unchecked(v_c as usize) }) {
+                v_bx = 0;
+                while v_bx < (*unsafe { self.f_comp_width_in_blocks.get_unchecked(v_c as usize) }) {
+                    v_index = ((*unsafe { self.f_comp_offset.get_unchecked(v_c as usize) })
+                        + ((v_by as u64)
+                            * ((*unsafe { self.f_comp_stride.get_unchecked(v_c as usize) })
+                                as u64))
+                        + (v_bx as u64));
+                    v_offset = (v_index * 128);
+                    if v_offset < (a_workbuf.len() as u64) {
+                        self.load_coefs(unsafe { a_workbuf.subslice_i(v_offset as usize) });
+                        self.write_block(a_dst, v_c, v_bx, v_by);
+                    }
+                    v_bx += 1;
+                }
+                v_by += 1;
+            }
+            v_c += 1;
+        }
+    }
+}
+
+// -------- func decoder.write_block
+
+impl Decoder {
+    fn write_block(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_c: u32,
+        mut a_bx: u32,
+        mut a_by: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_x0: u64 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_py: u32 = 0;
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+
+        self.idct_columns(a_c);
+        self.idct_rows();
+        v_tab = a_dst.plane(a_c);
+        v_x0 = ((a_bx as u64) * 8);
+        v_y = 0;
+        'label_0: while v_y < 8 {
+            v_py = ((a_by * 8) + v_y);
+            if (v_py as u64) >= (v_tab.height as u64) {
+                break 'label_0;
+            }
+            v_row = v_tab.row(v_py);
+            if v_x0 < (v_row.len() as u64) {
+                v_row = unsafe { v_row.subslice_i(v_x0 as usize) };
+                if (v_row.len() as u64) > 8 {
+                    v_row = unsafe { v_row.subslice_j(8 as usize) };
+                }
+                v_row.copy_from_slice(unsafe {
+                    wuffs_base::SliceU8::from_array(&mut self.f_pixels)
+                        .subslice_i((8 * v_y) as usize)
+                });
+            }
+            v_y += 1;
+        }
+    }
+}
+
+// -------- func decoder.idct_columns
+
+impl Decoder {
+    fn idct_columns(&mut self, mut a_c: u32) {
+        let mut v_in0: i64 = 0;
+        let mut v_in1: i64 = 0;
+        let mut v_in2: i64 = 0;
+        let mut v_in3: i64 = 0;
+        let mut v_in4: i64 = 0;
+        let mut v_in5: i64 = 0;
+        let mut v_in6: i64 = 0;
+        let mut v_in7: i64 = 0;
+        let mut v_e: i64 = 0;
+        let mut v_e0: i64 = 0;
+        let mut v_e1: i64 = 0;
+        let mut v_e2: i64 = 0;
+        let mut v_e3: i64 = 0;
+        let mut v_e10: i64 = 0;
+        let mut v_e11: i64 = 0;
+        let mut v_e12: i64 = 0;
+        let mut v_e13: i64 = 0;
+        let mut v_z1: i64 = 0;
+        let mut v_z2: i64 = 0;
+        let mut v_z3: i64 = 0;
+        let mut v_z4: i64 = 0;
+        let mut v_z5: i64 = 0;
+        let mut v_o0: i64 = 0;
+        let mut v_o1: i64 = 0;
+        let mut v_o2: i64 = 0;
+        let mut v_o3: i64 = 0;
+        let mut v_x: u32 = 0;
+
+        v_in0 = 0;
+        v_in1 = 0;
+        v_in2 = 0;
+        v_in3 = 0;
+        v_in4 = 0;
+        v_in5 = 0;
+        v_in6 = 0;
+        v_in7 = 0;
+        v_e = 0;
+        v_e0 = 0;
+        v_e1 = 0;
+        v_e2 = 0;
+        v_e3 = 0;
+        v_e10 = 0;
+        v_e11 = 0;
+        v_e12 = 0;
+        v_e13 = 0;
+        v_z1 = 0;
+        v_z2 = 0;
+        v_z3 = 0;
+        v_z4 = 0;
+        v_z5 = 0;
+        v_o0 = 0;
+        v_o1 = 0;
+        v_o2 = 0;
+        v_o3 = 0;
+        v_x = 0;
+        while v_x < 8 {
+            v_in0 = self.dequantize(a_c, (0 + v_x));
+            v_in1 = self.dequantize(a_c, (8 + v_x));
+            v_in2 = self.dequantize(a_c, (16 + v_x));
+            v_in3 = self.dequantize(a_c, (24 + v_x));
+            v_in4 = self.dequantize(a_c, (32 + v_x));
+            v_in5 = self.dequantize(a_c, (40 + v_x));
+            v_in6 = self.dequantize(a_c, (48 + v_x));
+            v_in7 = self.dequantize(a_c, (56 + v_x));
+            v_e = ((v_in2 + v_in6) * 4433);
+            v_e2 = (v_e - (v_in6 * 15137));
+            v_e3 = (v_e + (v_in2 * 6270));
+            v_e0 = ((v_in0 + v_in4) * 8192);
+            v_e1 = ((v_in0 - v_in4) * 8192);
+            v_e10 = (v_e0 + v_e3);
+            v_e13 = (v_e0 - v_e3);
+            v_e11 = (v_e1 + v_e2);
+            v_e12 = (v_e1 - v_e2);
+            v_z1 = (v_in7 + v_in1);
+            v_z2 = (v_in5 + v_in3);
+            v_z3 = (v_in7 + v_in3);
+            v_z4 = (v_in5 + v_in1);
+            v_z5 = ((v_z3 + v_z4) * 9633);
+            v_o0 = (((v_in7 * 2446) - (v_z1 * 7373)) + (v_z5 - (v_z3 * 16069)));
+            v_o1 = (((v_in5 * 16819) - (v_z2 * 20995)) + (v_z5 - (v_z4 * 3196)));
+            v_o2 = (((v_in3 * 25172) - (v_z2 * 20995)) + (v_z5 - (v_z3 * 16069)));
+            v_o3 = (((v_in1 * 12299) - (v_z1 * 7373)) + (v_z5 - (v_z4 * 3196)));
+            (*unsafe { self.f_workspace.get_unchecked_mut((0 + v_x) as usize) }) =
+                (((v_e10 + v_o3) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((56 + v_x) as usize) }) =
+                (((v_e10 - v_o3) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((8 + v_x) as usize) }) =
+                (((v_e11 + v_o2) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((48 + v_x) as usize) }) =
+                (((v_e11 - v_o2) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((16 + v_x) as usize) }) =
+                (((v_e12 + v_o1) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((40 + v_x) as usize) }) =
+                (((v_e12 - v_o1) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((24 + v_x) as usize) }) =
+                (((v_e13 + v_o0) + 1024) >> 11);
+            (*unsafe { self.f_workspace.get_unchecked_mut((32 + v_x) as usize) }) =
+                (((v_e13 - v_o0) + 1024) >> 11);
+            v_x += 1;
+        }
+    }
+}
+
+// -------- func decoder.dequantize
+
+impl Decoder {
+    fn dequantize(&mut self, mut a_c: u32, mut a_i: u32) -> i64 {
+        let mut v_coef: i64 = 0;
+
+        v_coef =
+            ((((*unsafe { self.f_block.get_unchecked(a_i as usize) }) as i64) ^ 32768) - 32768);
+        return (v_coef
+            * ((*unsafe {
+                self.f_comp_quant
+                    .get_unchecked(a_c as usize)
+                    .get_unchecked(a_i as usize)
+            }) as i64));
+    }
+}
+
+// -------- func decoder.idct_rows
+
+impl Decoder {
+    fn idct_rows(&mut self) {
+        let mut v_in0: i64 = 0;
+        let mut v_in1: i64 = 0;
+        let mut v_in2: i64 = 0;
+        let mut v_in3: i64 = 0;
+        let mut v_in4: i64 = 0;
+        let mut v_in5: i64 = 0;
+        let mut v_in6: i64 = 0;
+        let mut v_in7: i64 = 0;
+        let mut v_e: i64 = 0;
+        let mut v_e0: i64 = 0;
+        let mut v_e1: i64 = 0;
+        let mut v_e2: i64 = 0;
+        let mut v_e3: i64 = 0;
+        let mut v_e10: i64 = 0;
+        let mut v_e11: i64 = 0;
+        let mut v_e12: i64 = 0;
+        let mut v_e13: i64 = 0;
+        let mut v_z1: i64 = 0;
+        let mut v_z2: i64 = 0;
+        let mut v_z3: i64 = 0;
+        let mut v_z4: i64 = 0;
+        let mut v_z5: i64 = 0;
+        let mut v_o0: i64 = 0;
+        let mut v_o1: i64 = 0;
+        let mut v_o2: i64 = 0;
+        let mut v_o3: i64 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_r: u32 = 0;
+
+        v_in0 = 0;
+        v_in1 = 0;
+        v_in2 = 0;
+        v_in3 = 0;
+        v_in4 = 0;
+        v_in5 = 0;
+        v_in6 = 0;
+        v_in7 = 0;
+        v_e = 0;
+        v_e0 = 0;
+        v_e1 = 0;
+        v_e2 = 0;
+        v_e3 = 0;
+        v_e10 = 0;
+        v_e11 = 0;
+        v_e12 = 0;
+        v_e13 = 0;
+        v_z1 = 0;
+        v_z2 = 0;
+        v_z3 = 0;
+        v_z4 = 0;
+        v_z5 = 0;
+        v_o0 = 0;
+        v_o1 = 0;
+        v_o2 = 0;
+        v_o3 = 0;
+        v_y = 0;
+        while v_y < 8 {
+            v_r = (8 * v_y);
+            v_in0 = (*unsafe { self.f_workspace.get_unchecked((v_r + 0) as usize) });
+            v_in1 = (*unsafe { self.f_workspace.get_unchecked((v_r + 1) as usize) });
+            v_in2 = (*unsafe { self.f_workspace.get_unchecked((v_r + 2) as usize) });
+            v_in3 = (*unsafe { self.f_workspace.get_unchecked((v_r + 3) as usize) });
+            v_in4 = (*unsafe { self.f_workspace.get_unchecked((v_r + 4) as usize) });
+            v_in5 = (*unsafe { self.f_workspace.get_unchecked((v_r + 5) as usize) });
+            v_in6 = (*unsafe { self.f_workspace.get_unchecked((v_r + 6) as usize) });
+            v_in7 = (*unsafe { self.f_workspace.get_unchecked((v_r + 7) as usize) });
+            v_e = ((v_in2 + v_in6) * 4433);
+            v_e2 = (v_e - (v_in6 * 15137));
+            v_e3 = (v_e + (v_in2 * 6270));
+            v_e0 = ((v_in0 + v_in4) * 8192);
+            v_e1 = ((v_in0 - v_in4) * 8192);
+            v_e10 = (v_e0 + v_e3);
+            v_e13 = (v_e0 - v_e3);
+            v_e11 = (v_e1 + v_e2);
+            v_e12 = (v_e1 - v_e2);
+            v_z1 = (v_in7 + v_in1);
+            v_z2 = (v_in5 + v_in3);
+            v_z3 = (v_in7 + v_in3);
+            v_z4 = (v_in5 + v_in1);
+            v_z5 = ((v_z3 + v_z4) * 9633);
+            v_o0 = (((v_in7 * 2446) - (v_z1 * 7373)) + (v_z5 - (v_z3 * 16069)));
+            v_o1 = (((v_in5 * 16819) - (v_z2 * 20995)) + (v_z5 - (v_z4 * 3196)));
+            v_o2 = (((v_in3 * 25172) - (v_z2 * 20995)) + (v_z5 - (v_z3 * 16069)));
+            v_o3 = (((v_in1 * 12299) - (v_z1 * 7373)) + (v_z5 - (v_z4 * 3196)));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 0) as usize) }) =
+                self.clamp((v_e10 + v_o3));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 7) as usize) }) =
+                self.clamp((v_e10 - v_o3));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 1) as usize) }) =
+                self.clamp((v_e11 + v_o2));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 6) as usize) }) =
+                self.clamp((v_e11 - v_o2));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 2) as usize) }) =
+                self.clamp((v_e12 + v_o1));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 5) as usize) }) =
+                self.clamp((v_e12 - v_o1));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 3) as usize) }) =
+                self.clamp((v_e13 + v_o0));
+            (*unsafe { self.f_pixels.get_unchecked_mut((v_r + 4) as usize) }) =
+                self.clamp((v_e13 - v_o0));
+            v_y += 1;
+        }
+    }
+}
+
+// -------- func decoder.clamp
+
+impl Decoder {
+    fn clamp(&mut self, mut a_x: i64) -> u8 {
+        let mut v_v: i64 = 0;
+
+        v_v = (((a_x + 131072) >> 18) + 128);
+        return (i64::min(i64::max(v_v, 0), 255) as u8);
+    }
+}
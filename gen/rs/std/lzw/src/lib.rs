@@ -0,0 +1,930 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package lzw is the Rust translation of the Wuffs std/lzw package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_CODE: wuffs_base::Status = wuffs_base::Status::Error("lzw: bad code");
+pub const ERROR_CYCLICAL_PREFIX_CHAIN: wuffs_base::Status =
+    wuffs_base::Status::Error("lzw: cyclical prefix chain");
+pub const ERROR_BAD_LITERAL: wuffs_base::Status = wuffs_base::Status::Error("lzw: bad literal");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a lzw.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_literal_width: u32,
+    f_tiff_mode: bool,
+    f_stack: [u8; 4096],
+    f_suffixes: [u8; 4096],
+    f_prefixes: [u16; 4096],
+    c_decode: DecoderDecodeCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_literal_width: 0,
+            f_tiff_mode: false,
+            f_stack: [0; 4096],
+            f_suffixes: [0; 4096],
+            f_prefixes: [0; 4096],
+            c_decode: DecoderDecodeCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeCoro {
+    coro_susp_point: u32,
+    v_literal_width: u32,
+    v_tiff_mode: bool,
+    v_clear_code: u32,
+    v_end_code: u32,
+    v_save_code: u32,
+    v_prev_code: u32,
+    v_width: u32,
+    v_bits: u32,
+    v_n_bits: u32,
+    v_code: u32,
+    v_msb_bits: u32,
+    v_s: u32,
+    v_c: u32,
+    v_n_copied: u64,
+}
+
+impl Default for DecoderDecodeCoro {
+    fn default() -> Self {
+        DecoderDecodeCoro {
+            coro_susp_point: 0,
+            v_literal_width: 0,
+            v_tiff_mode: false,
+            v_clear_code: 0,
+            v_end_code: 0,
+            v_save_code: 0,
+            v_prev_code: 0,
+            v_width: 0,
+            v_bits: 0,
+            v_n_bits: 0,
+            v_code: 0,
+            v_msb_bits: 0,
+            v_s: 0,
+            v_c: 0,
+            v_n_copied: 0,
+        }
+    }
+}
+
+/// Encoder holds the state of a lzw.encoder. Its default value is ready to use.
+pub struct Encoder {
+    status: wuffs_base::Status,
+    f_literal_width: u32,
+    f_eof: bool,
+    f_bits: u32,
+    f_n_bits: u32,
+    f_buf: [u8; 4096],
+    f_buf_ri: u32,
+    f_buf_wi: u32,
+    f_htable: [u32; 16384],
+    c_encode: EncoderEncodeCoro,
+    c_fill_buf: EncoderFillBufCoro,
+    c_write_code: EncoderWriteCodeCoro,
+}
+
+impl Default for Encoder {
+    fn default() -> Self {
+        Encoder {
+            status: wuffs_base::Status::Ok,
+            f_literal_width: 0,
+            f_eof: false,
+            f_bits: 0,
+            f_n_bits: 0,
+            f_buf: [0; 4096],
+            f_buf_ri: 0,
+            f_buf_wi: 0,
+            f_htable: [0; 16384],
+            c_encode: EncoderEncodeCoro::default(),
+            c_fill_buf: EncoderFillBufCoro::default(),
+            c_write_code: EncoderWriteCodeCoro::default(),
+        }
+    }
+}
+
+struct EncoderEncodeCoro {
+    coro_susp_point: u32,
+    v_literal_width: u32,
+    v_clear_code: u32,
+    v_end_code: u32,
+    v_hi: u32,
+    v_width: u32,
+    v_code: u32,
+    v_has_code: bool,
+    v_literal: u32,
+    v_key: u32,
+    v_h: u32,
+    v_entry: u32,
+    v_z: wuffs_base::Status,
+}
+
+impl Default for EncoderEncodeCoro {
+    fn default() -> Self {
+        EncoderEncodeCoro {
+            coro_susp_point: 0,
+            v_literal_width: 0,
+            v_clear_code: 0,
+            v_end_code: 0,
+            v_hi: 0,
+            v_width: 0,
+            v_code: 0,
+            v_has_code: false,
+            v_literal: 0,
+            v_key: 0,
+            v_h: 0,
+            v_entry: 0,
+            v_z: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+struct EncoderFillBufCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for EncoderFillBufCoro {
+    fn default() -> Self {
+        EncoderFillBufCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct EncoderWriteCodeCoro {
+    coro_susp_point: u32,
+    v_n_bits: u32,
+}
+
+impl Default for EncoderWriteCodeCoro {
+    fn default() -> Self {
+        EncoderWriteCodeCoro {
+            coro_susp_point: 0,
+            v_n_bits: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_literal_width
+
+impl Decoder {
+    pub fn set_literal_width(&mut self, mut a_lw: u32) {
+        if self.status.is_error() {
+            return;
+        }
+        if a_lw < 2 || a_lw > 8 {
+            self.status = wuffs_base::ERROR_BAD_ARGUMENT;
+            return;
+        }
+
+        self.f_literal_width = a_lw;
+    }
+}
+
+// -------- func decoder.set_tiff_mode
+
+impl Decoder {
+    pub fn set_tiff_mode(&mut self, mut a_m: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_tiff_mode = a_m;
+    }
+}
+
+// -------- func decoder.decode
+
+impl Decoder {
+    pub fn decode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_literal_width: u32 = 0;
+        let mut v_tiff_mode: bool = false;
+        let mut v_clear_code: u32 = 0;
+        let mut v_end_code: u32 = 0;
+        let mut v_save_code: u32 = 0;
+        let mut v_prev_code: u32 = 0;
+        let mut v_width: u32 = 0;
+        let mut v_bits: u32 = 0;
+        let mut v_n_bits: u32 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_msb_bits: u32 = 0;
+        let mut v_s: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_expansion: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_n_copied: u64 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_literal_width = self.c_decode.v_literal_width;
+            v_tiff_mode = self.c_decode.v_tiff_mode;
+            v_clear_code = self.c_decode.v_clear_code;
+            v_end_code = self.c_decode.v_end_code;
+            v_save_code = self.c_decode.v_save_code;
+            v_prev_code = self.c_decode.v_prev_code;
+            v_width = self.c_decode.v_width;
+            v_bits = self.c_decode.v_bits;
+            v_n_bits = self.c_decode.v_n_bits;
+            v_code = self.c_decode.v_code;
+            v_msb_bits = self.c_decode.v_msb_bits;
+            v_s = self.c_decode.v_s;
+            v_c = self.c_decode.v_c;
+            v_n_copied = self.c_decode.v_n_copied;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        v_literal_width = 8;
+                        if (self.f_literal_width >= 2) && !self.f_tiff_mode {
+                            v_literal_width = self.f_literal_width;
+                        }
+                        v_tiff_mode = self.f_tiff_mode;
+                        v_clear_code = (1u32 << v_literal_width);
+                        v_end_code = (v_clear_code + 1);
+                        v_save_code = v_end_code;
+                        v_prev_code = 0;
+                        v_width = (v_literal_width + 1);
+                        v_bits = 0;
+                        v_n_bits = 0;
+                    }
+                    if r == 0 || (1 <= r && r <= 4) {
+                        'label_0: loop {
+                            if r == 0 || (1 <= r && r <= 2) {
+                                while r != 0 || (v_n_bits < v_width) {
+                                    if r == 0 || (1 <= r && r <= 2) {
+                                        if r == 1 || (r == 0 && v_tiff_mode) {
+                                            if r == 0 || r == 1 {
+                                                if r == 1 {
+                                                    r = 0;
+                                                }
+                                                csp = 1;
+                                                status = a_src.read_u8(&mut t_0);
+                                                if !status.is_ok() {
+                                                    break 'suspend;
+                                                }
+                                                v_bits = (((v_bits & 4095) << 8) | (t_0 as u32));
+                                            }
+                                        } else {
+                                            if r == 0 || r == 2 {
+                                                if r == 2 {
+                                                    r = 0;
+                                                }
+                                                csp = 2;
+                                                status = a_src.read_u8(&mut t_1);
+                                                if !status.is_ok() {
+                                                    break 'suspend;
+                                                }
+                                                v_bits |= ((t_1 as u32) << v_n_bits);
+                                            }
+                                        }
+                                    }
+                                    if r == 0 {
+                                        v_n_bits += 8;
+                                    }
+                                }
+                            }
+                            if r == 0 {
+                                v_code = 0;
+                                if v_tiff_mode {
+                                    v_msb_bits = (v_bits >> (v_n_bits - v_width));
+                                    v_code = (v_msb_bits
+                                        & u32::MAX.checked_shr(32 - (v_width as u32)).unwrap_or(0));
+                                } else {
+                                    v_code = (v_bits
+                                        & u32::MAX.checked_shr(32 - (v_width as u32)).unwrap_or(0));
+                                    v_bits >>= v_width;
+                                }
+                                v_n_bits -= v_width;
+                            }
+                            if r == 0 || (3 <= r && r <= 4) {
+                                if r == 3 || (r == 0 && (v_code < v_clear_code)) {
+                                    if r == 0 || r == 3 {
+                                        if r == 3 {
+                                            r = 0;
+                                        }
+                                        csp = 3;
+                                        status = a_dst.write_u8((v_code as u8));
+                                        if !status.is_ok() {
+                                            break 'suspend;
+                                        }
+                                    }
+                                    if r == 0 {
+                                        if v_save_code <= 4095 {
+                                            (*unsafe {
+                                                self.f_suffixes
+                                                    .get_unchecked_mut(v_save_code as usize)
+                                            }) = (v_code as u8);
+                                            (*unsafe {
+                                                self.f_prefixes
+                                                    .get_unchecked_mut(v_save_code as usize)
+                                            }) = (v_prev_code as u16);
+                                        }
+                                    }
+                                } else if r == 0 && (v_code == v_clear_code) {
+                                    v_save_code = v_end_code;
+                                    v_prev_code = 0;
+                                    v_width = (v_literal_width + 1);
+                                    continue 'label_0;
+                                } else if r == 0 && (v_code == v_end_code) {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                } else if r == 4 || (r == 0 && (v_code <= v_save_code)) {
+                                    if r == 0 {
+                                        v_s = 4095;
+                                        v_c = v_code;
+                                        if v_code == v_save_code {
+                                            v_s -= 1;
+                                            v_c = v_prev_code;
+                                        }
+                                        while v_c >= v_clear_code {
+                                            (*unsafe {
+                                                self.f_stack.get_unchecked_mut(v_s as usize)
+                                            }) = (*unsafe {
+                                                self.f_suffixes.get_unchecked(v_c as usize)
+                                            });
+                                            if v_s == 0 {
+                                                status = ERROR_CYCLICAL_PREFIX_CHAIN;
+                                                break 'exit;
+                                            }
+                                            v_s -= 1;
+                                            v_c = ((*unsafe {
+                                                self.f_prefixes.get_unchecked(v_c as usize)
+                                            })
+                                                as u32);
+                                        }
+                                        (*unsafe {
+                                            self.f_stack.get_unchecked_mut(v_s as usize)
+                                        }) = (v_c as u8);
+                                        if v_code == v_save_code {
+                                            (*unsafe {
+                                                self.f_stack.get_unchecked_mut(4095 as usize)
+                                            }) = (v_c as u8);
+                                        }
+                                    }
+                                    if r == 0 || r == 4 {
+                                        'label_1: loop {
+                                            if r == 0 {
+                                                v_expansion = unsafe {
+                                                    wuffs_base::SliceU8::from_array(
+                                                        &mut self.f_stack,
+                                                    )
+                                                    .subslice_i(v_s as usize)
+                                                };
+                                                v_n_copied = a_dst.copy_from_slice(v_expansion);
+                                                if v_n_copied == (v_expansion.len() as u64) {
+                                                    break 'label_1;
+                                                }
+                                                v_s = ((v_s + ((v_n_copied & 4095) as u32)) & 4095);
+                                            }
+                                            if r == 0 || r == 4 {
+                                                status = wuffs_base::SUSPENSION_SHORT_WRITE;
+                                                if r == 4 {
+                                                    r = 0;
+                                                    status = wuffs_base::Status::Ok;
+                                                } else {
+                                                    csp = 4;
+                                                    break 'suspend;
+                                                }
+                                            }
+                                        }
+                                    }
+                                    if r == 0 {
+                                        if v_save_code <= 4095 {
+                                            (*unsafe {
+                                                self.f_suffixes
+                                                    .get_unchecked_mut(v_save_code as usize)
+                                            }) = (v_c as u8);
+                                            (*unsafe {
+                                                self.f_prefixes
+                                                    .get_unchecked_mut(v_save_code as usize)
+                                            }) = (v_prev_code as u16);
+                                        }
+                                    }
+                                } else {
+                                    status = ERROR_BAD_CODE;
+                                    break 'exit;
+                                }
+                            }
+                            if r == 0 {
+                                if v_save_code <= 4095 {
+                                    v_save_code += 1;
+                                    if v_tiff_mode {
+                                        if ((v_save_code + 1) == (1u32 << v_width))
+                                            && (v_width < 12)
+                                        {
+                                            v_width += 1;
+                                        }
+                                    } else if (v_save_code == (1u32 << v_width)) && (v_width < 12) {
+                                        v_width += 1;
+                                    }
+                                }
+                                v_prev_code = v_code;
+                            }
+                        }
+                    }
+                }
+                self.c_decode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode.coro_susp_point = csp;
+            self.c_decode.v_literal_width = v_literal_width;
+            self.c_decode.v_tiff_mode = v_tiff_mode;
+            self.c_decode.v_clear_code = v_clear_code;
+            self.c_decode.v_end_code = v_end_code;
+            self.c_decode.v_save_code = v_save_code;
+            self.c_decode.v_prev_code = v_prev_code;
+            self.c_decode.v_width = v_width;
+            self.c_decode.v_bits = v_bits;
+            self.c_decode.v_n_bits = v_n_bits;
+            self.c_decode.v_code = v_code;
+            self.c_decode.v_msb_bits = v_msb_bits;
+            self.c_decode.v_s = v_s;
+            self.c_decode.v_c = v_c;
+            self.c_decode.v_n_copied = v_n_copied;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.set_literal_width
+
+impl Encoder {
+    pub fn set_literal_width(&mut self, mut a_lw: u32) {
+        if self.status.is_error() {
+            return;
+        }
+        if a_lw < 2 || a_lw > 8 {
+            self.status = wuffs_base::ERROR_BAD_ARGUMENT;
+            return;
+        }
+
+        self.f_literal_width = a_lw;
+    }
+}
+
+// -------- func encoder.encode
+
+impl Encoder {
+    pub fn encode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_literal_width: u32 = 0;
+        let mut v_clear_code: u32 = 0;
+        let mut v_end_code: u32 = 0;
+        let mut v_hi: u32 = 0;
+        let mut v_width: u32 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_has_code: bool = false;
+        let mut v_literal: u32 = 0;
+        let mut v_key: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_entry: u32 = 0;
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_literal_width = self.c_encode.v_literal_width;
+            v_clear_code = self.c_encode.v_clear_code;
+            v_end_code = self.c_encode.v_end_code;
+            v_hi = self.c_encode.v_hi;
+            v_width = self.c_encode.v_width;
+            v_code = self.c_encode.v_code;
+            v_has_code = self.c_encode.v_has_code;
+            v_literal = self.c_encode.v_literal;
+            v_key = self.c_encode.v_key;
+            v_h = self.c_encode.v_h;
+            v_entry = self.c_encode.v_entry;
+            v_z = self.c_encode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_literal_width = 8;
+                    if self.f_literal_width >= 2 {
+                        v_literal_width = self.f_literal_width;
+                    }
+                    v_clear_code = (1u32 << v_literal_width);
+                    v_end_code = (v_clear_code + 1);
+                    v_hi = v_end_code;
+                    v_width = (v_literal_width + 1);
+                    v_code = 0;
+                    v_has_code = false;
+                    v_literal = 0;
+                    v_key = 0;
+                    v_h = 0;
+                    v_entry = 0;
+                    self.f_eof = false;
+                    self.f_bits = 0;
+                    self.f_n_bits = 0;
+                    self.f_buf_ri = 0;
+                    self.f_buf_wi = 0;
+                    self.clear_table();
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.write_code(a_dst, v_clear_code, v_width);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 4) {
+                    'label_0: loop {
+                        if r == 0 || r == 2 {
+                            if r == 2 || (r == 0 && (self.f_buf_ri >= self.f_buf_wi)) {
+                                if r == 0 {
+                                    if self.f_eof {
+                                        break 'label_0;
+                                    }
+                                    t_0 = self.fill_buf(a_src);
+                                    v_z = t_0;
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 0 && v_z.is_error() {
+                                        self.f_eof = true;
+                                    } else if r == 2 || (r == 0 && v_z.is_suspension()) {
+                                        if r == 0 || r == 2 {
+                                            status = v_z;
+                                            if r == 2 {
+                                                r = 0;
+                                                status = wuffs_base::Status::Ok;
+                                            } else {
+                                                if status.is_error() {
+                                                    break 'exit;
+                                                }
+                                                if !status.is_ok() {
+                                                    csp = 2;
+                                                    break 'suspend;
+                                                }
+                                            }
+                                        }
+                                    }
+                                }
+                                if r == 0 {
+                                    continue 'label_0;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_literal =
+                                ((*unsafe { self.f_buf.get_unchecked(self.f_buf_ri as usize) })
+                                    as u32);
+                            self.f_buf_ri += 1;
+                            if v_literal >= v_clear_code {
+                                status = ERROR_BAD_LITERAL;
+                                break 'exit;
+                            }
+                            if !v_has_code {
+                                v_code = v_literal;
+                                v_has_code = true;
+                                continue 'label_0;
+                            }
+                            v_key = ((v_code << 8) | v_literal);
+                            v_h = (((v_key >> 12) ^ v_key) & 16383);
+                            'label_1: loop {
+                                v_entry = (*unsafe { self.f_htable.get_unchecked(v_h as usize) });
+                                if v_entry == 0 {
+                                    break 'label_1;
+                                }
+                                if (v_entry >> 12) == v_key {
+                                    v_code = (v_entry & 4095);
+                                    continue 'label_0;
+                                }
+                                v_h = ((v_h + 1) & 16383);
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.write_code(a_dst, v_code, v_width);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_code = v_literal;
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 4 || (r == 0 && (v_hi >= 4094)) {
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.write_code(a_dst, v_clear_code, v_width);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_hi = v_end_code;
+                                    v_width = (v_literal_width + 1);
+                                    self.clear_table();
+                                    continue 'label_0;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_hi += 1;
+                            if (v_hi == (1u32 << v_width)) && (v_width < 12) {
+                                v_width += 1;
+                            }
+                            (*unsafe { self.f_htable.get_unchecked_mut(v_h as usize) }) =
+                                ((v_key << 12) | v_hi);
+                        }
+                    }
+                }
+                if r == 0 || (5 <= r && r <= 6) {
+                    if (5 <= r && r <= 6) || (r == 0 && v_has_code) {
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.write_code(a_dst, v_code, v_width);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            if r == 6 || (r == 0 && (v_hi >= 4094)) {
+                                if r == 0 || r == 6 {
+                                    if r == 6 {
+                                        r = 0;
+                                    }
+                                    csp = 6;
+                                    status = self.write_code(a_dst, v_clear_code, v_width);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_width = (v_literal_width + 1);
+                                }
+                            } else {
+                                v_hi += 1;
+                                if (v_hi == (1u32 << v_width)) && (v_width < 12) {
+                                    v_width += 1;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = self.write_code(a_dst, v_end_code, v_width);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 8 {
+                    if r == 8 || (r == 0 && (self.f_n_bits > 0)) {
+                        if r == 0 || r == 8 {
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = a_dst.write_u8(((self.f_bits & 255) as u8));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_bits = 0;
+                            self.f_n_bits = 0;
+                        }
+                    }
+                }
+                self.c_encode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode.coro_susp_point = csp;
+            self.c_encode.v_literal_width = v_literal_width;
+            self.c_encode.v_clear_code = v_clear_code;
+            self.c_encode.v_end_code = v_end_code;
+            self.c_encode.v_hi = v_hi;
+            self.c_encode.v_width = v_width;
+            self.c_encode.v_code = v_code;
+            self.c_encode.v_has_code = v_has_code;
+            self.c_encode.v_literal = v_literal;
+            self.c_encode.v_key = v_key;
+            self.c_encode.v_h = v_h;
+            self.c_encode.v_entry = v_entry;
+            self.c_encode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.fill_buf
+
+impl Encoder {
+    fn fill_buf(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_buf.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_fill_buf.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        self.f_buf_ri = 0;
+                        self.f_buf_wi = 0;
+                        if a_src.available() > 0 {
+                            v_w = wuffs_base::IoWriter::default();
+                            {
+                                let o_0_a_src = a_src;
+                                let o_0_v_w = v_w;
+                                unsafe {
+                                    v_w.set(&mut u_w, unsafe {
+                                        wuffs_base::SliceU8::from_array(&mut self.f_buf)
+                                    })
+                                };
+                                v_w.copy_from_reader32(&mut a_src, 4096);
+                                self.f_buf_wi = (4096 - (u64::min(v_w.available(), 4096) as u32));
+                                v_w = o_0_v_w;
+                                a_src = o_0_a_src;
+                            }
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                    }
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u8(&mut t_0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        v_c = t_0;
+                    }
+                    if r == 0 {
+                        (*unsafe { self.f_buf.get_unchecked_mut(0 as usize) }) = v_c;
+                        self.f_buf_wi = 1;
+                    }
+                }
+                self.c_fill_buf.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_buf.coro_susp_point = csp;
+            self.c_fill_buf.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func encoder.clear_table
+
+impl Encoder {
+    fn clear_table(&mut self) {
+        let mut v_i: u32 = 0;
+
+        v_i = 0;
+        while v_i < 16384 {
+            (*unsafe { self.f_htable.get_unchecked_mut(v_i as usize) }) = 0;
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func encoder.write_code
+
+impl Encoder {
+    fn write_code(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_code: u32,
+        mut a_width: u32,
+    ) -> wuffs_base::Status {
+        let mut v_n_bits: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_write_code.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_n_bits = self.c_write_code.v_n_bits;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_n_bits = (self.f_n_bits + a_width);
+                    self.f_bits |= (a_code << self.f_n_bits);
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_n_bits >= 8) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_dst.write_u8(((self.f_bits & 255) as u8));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_bits >>= 8;
+                            v_n_bits -= 8;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_n_bits = v_n_bits;
+                }
+                self.c_write_code.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_write_code.coro_susp_point = csp;
+            self.c_write_code.v_n_bits = v_n_bits;
+        }
+
+        status
+    }
+}
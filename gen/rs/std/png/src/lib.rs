@@ -0,0 +1,1610 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package png is the Rust translation of the Wuffs std/png package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_crc32 as crc32;
+use wuffs_std_zlib as zlib;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_CHECKSUM: wuffs_base::Status = wuffs_base::Status::Error("png: bad checksum");
+pub const ERROR_BAD_CHUNK: wuffs_base::Status = wuffs_base::Status::Error("png: bad chunk");
+pub const ERROR_BAD_FILTER: wuffs_base::Status = wuffs_base::Status::Error("png: bad filter");
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("png: bad header");
+pub const ERROR_BAD_PALETTE: wuffs_base::Status = wuffs_base::Status::Error("png: bad palette");
+pub const ERROR_BAD_TRANSPARENCY: wuffs_base::Status =
+    wuffs_base::Status::Error("png: bad transparency");
+pub const ERROR_MISSING_PALETTE: wuffs_base::Status =
+    wuffs_base::Status::Error("png: missing palette");
+pub const ERROR_NOT_ENOUGH_PIXEL_DATA: wuffs_base::Status =
+    wuffs_base::Status::Error("png: not enough pixel data");
+pub const ERROR_TOO_MUCH_PIXEL_DATA: wuffs_base::Status =
+    wuffs_base::Status::Error("png: too much pixel data");
+pub const ERROR_UNSUPPORTED_CRITICAL_CHUNK: wuffs_base::Status =
+    wuffs_base::Status::Error("png: unsupported critical chunk");
+pub const ERROR_BAD_WORKBUF_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("png: bad workbuf length");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_ROW_INDEX: wuffs_base::Status =
+    wuffs_base::Status::Error("png: internal error: inconsistent row index");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_ROW_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("png: internal error: inconsistent row length");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a png.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_ignore_checksum: bool,
+    f_depth: u8,
+    f_color_type: u8,
+    f_interlaced: bool,
+    f_filter_distance: u32,
+    f_bits_per_pixel: u32,
+    f_depth_mask: u32,
+    f_seen_palette: bool,
+    f_num_palette_entries: u32,
+    f_has_trns: bool,
+    f_trns_gray: u32,
+    f_trns_red: u32,
+    f_trns_green: u32,
+    f_trns_blue: u32,
+    f_chunk_length: u64,
+    f_chunk_type: u32,
+    f_crc_got: u32,
+    f_small_chunk_length: u32,
+    f_pass: u8,
+    f_pass_width: u32,
+    f_pass_height: u32,
+    f_pass_y: u32,
+    f_row_length: u64,
+    f_row_wi: u64,
+    f_row_stride: u64,
+    f_chunk_data: [u8; 772],
+    f_palette: [u8; 1024],
+    f_crc: crc32::IeeeHasher,
+    f_zlib: zlib::Decoder,
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_read_chunk_header: DecoderReadChunkHeaderCoro,
+    c_read_small_chunk: DecoderReadSmallChunkCoro,
+    c_verify_checksum: DecoderVerifyChecksumCoro,
+    c_decode_idats: DecoderDecodeIdatsCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_ignore_checksum: false,
+            f_depth: 0,
+            f_color_type: 0,
+            f_interlaced: false,
+            f_filter_distance: 0,
+            f_bits_per_pixel: 0,
+            f_depth_mask: 0,
+            f_seen_palette: false,
+            f_num_palette_entries: 0,
+            f_has_trns: false,
+            f_trns_gray: 0,
+            f_trns_red: 0,
+            f_trns_green: 0,
+            f_trns_blue: 0,
+            f_chunk_length: 0,
+            f_chunk_type: 0,
+            f_crc_got: 0,
+            f_small_chunk_length: 0,
+            f_pass: 0,
+            f_pass_width: 0,
+            f_pass_height: 0,
+            f_pass_y: 0,
+            f_row_length: 0,
+            f_row_wi: 0,
+            f_row_stride: 0,
+            f_chunk_data: [0; 772],
+            f_palette: [0; 1024],
+            f_crc: crc32::IeeeHasher::default(),
+            f_zlib: zlib::Decoder::default(),
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_read_chunk_header: DecoderReadChunkHeaderCoro::default(),
+            c_read_small_chunk: DecoderReadSmallChunkCoro::default(),
+            c_verify_checksum: DecoderVerifyChecksumCoro::default(),
+            c_decode_idats: DecoderDecodeIdatsCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+    v_dirty_rect: wuffs_base::RectIeU32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro {
+            coro_susp_point: 0,
+            v_dirty_rect: wuffs_base::RectIeU32::default(),
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderReadChunkHeaderCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderReadChunkHeaderCoro {
+    fn default() -> Self {
+        DecoderReadChunkHeaderCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderReadSmallChunkCoro {
+    coro_susp_point: u32,
+    v_n: u32,
+    v_i: u32,
+}
+
+impl Default for DecoderReadSmallChunkCoro {
+    fn default() -> Self {
+        DecoderReadSmallChunkCoro {
+            coro_susp_point: 0,
+            v_n: 0,
+            v_i: 0,
+        }
+    }
+}
+
+struct DecoderVerifyChecksumCoro {
+    coro_susp_point: u32,
+    v_crc_want: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderVerifyChecksumCoro {
+    fn default() -> Self {
+        DecoderVerifyChecksumCoro {
+            coro_susp_point: 0,
+            v_crc_want: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeIdatsCoro {
+    coro_susp_point: u32,
+    v_i: u64,
+    v_z: wuffs_base::Status,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeIdatsCoro {
+    fn default() -> Self {
+        DecoderDecodeIdatsCoro {
+            coro_susp_point: 0,
+            v_i: 0,
+            v_z: wuffs_base::Status::Ok,
+            scratch: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+const CHUNK_TYPE_IDAT: u32 = 0x49444154;
+
+const CHUNK_TYPE_IEND: u32 = 0x49454E44;
+
+const CHUNK_TYPE_IHDR: u32 = 0x49484452;
+
+const CHUNK_TYPE_PLTE: u32 = 0x504C5445;
+
+const CHUNK_TYPE_TRNS: u32 = 0x74524E53;
+
+static INTERLACE_X0: [u8; 8] = [0x0, 0x0, 0x4, 0x0, 0x2, 0x0, 0x1, 0x0];
+
+static INTERLACE_Y0: [u8; 8] = [0x0, 0x0, 0x0, 0x4, 0x0, 0x2, 0x0, 0x1];
+
+static INTERLACE_LOG2_DX: [u8; 8] = [0x0, 0x3, 0x3, 0x2, 0x2, 0x1, 0x1, 0x0];
+
+static INTERLACE_LOG2_DY: [u8; 8] = [0x0, 0x3, 0x3, 0x3, 0x2, 0x2, 0x1, 0x1];
+
+static LOW_DEPTH_GRAY_SCALES: [u8; 5] = [0x0, 0xFF, 0x55, 0x0, 0x11];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+impl Decoder {
+    pub fn set_ignore_checksum(&mut self, mut a_ic: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_ignore_checksum = a_ic;
+        self.f_zlib.set_ignore_checksum(a_ic);
+    }
+}
+
+// -------- func decoder.workbuf_len
+
+impl Decoder {
+    pub fn workbuf_len(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return (self.f_row_stride * 2);
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u32be(&mut t_0, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 2303741511) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 || r == 2 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_src.read_u32be(&mut t_1, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_1 != 218765834) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.read_chunk_header(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    if (self.f_chunk_type != CHUNK_TYPE_IHDR) || (self.f_chunk_length != 13) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = self.read_small_chunk(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = self.decode_ihdr();
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || (6 <= r && r <= 11) {
+                    'label_0: loop {
+                        if r == 0 || r == 6 {
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = self.read_chunk_header(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || (7 <= r && r <= 11) {
+                            if r == 0 && (self.f_chunk_type == CHUNK_TYPE_IDAT) {
+                                break 'label_0;
+                            } else if (7 <= r && r <= 8)
+                                || (r == 0 && (self.f_chunk_type == CHUNK_TYPE_PLTE))
+                            {
+                                if r == 0 || r == 7 {
+                                    if r == 7 {
+                                        r = 0;
+                                    }
+                                    csp = 7;
+                                    status = self.read_small_chunk(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || r == 8 {
+                                    if r == 8 {
+                                        r = 0;
+                                    }
+                                    csp = 8;
+                                    status = self.decode_plte();
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else if (9 <= r && r <= 10)
+                                || (r == 0 && (self.f_chunk_type == CHUNK_TYPE_TRNS))
+                            {
+                                if r == 0 || r == 9 {
+                                    if r == 9 {
+                                        r = 0;
+                                    }
+                                    csp = 9;
+                                    status = self.read_small_chunk(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || r == 10 {
+                                    if r == 10 {
+                                        r = 0;
+                                    }
+                                    csp = 10;
+                                    status = self.decode_trns();
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            } else if r == 0 && (self.f_chunk_type == CHUNK_TYPE_IEND) {
+                                status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                break 'exit;
+                            } else if r == 0 && (self.f_chunk_type == CHUNK_TYPE_IHDR) {
+                                status = ERROR_BAD_CHUNK;
+                                break 'exit;
+                            } else if r == 0 && (((self.f_chunk_type >> 24) & 32) == 0) {
+                                status = ERROR_UNSUPPORTED_CRITICAL_CHUNK;
+                                break 'exit;
+                            } else {
+                                if r == 0 || r == 11 {
+                                    if r == 0 {
+                                        self.c_decode_config.scratch =
+                                            ((self.f_chunk_length + 4) as u64);
+                                    }
+                                    if r == 11 {
+                                        r = 0;
+                                    }
+                                    csp = 11;
+                                    status = a_src.skip(&mut self.c_decode_config.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if (self.f_color_type == 3) && !self.f_seen_palette {
+                        status = ERROR_MISSING_PALETTE;
+                        break 'exit;
+                    }
+                    a_dst.initialize(570460296, 0, self.f_width, self.f_height, 1);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_dirty_rect: wuffs_base::RectIeU32 = wuffs_base::RectIeU32::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_dirty_rect = self.c_decode_frame.v_dirty_rect;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 && (self.f_call_sequence == 0) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    } else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+                        if r == 0 || r == 1 {
+                            loop {
+                                if r == 0 || r == 1 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 1 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if (a_workbuf.len() as u64) < (self.f_row_stride * 2) {
+                        status = ERROR_BAD_WORKBUF_LENGTH;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.decode_idats(a_dst, a_src, a_workbuf);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_palette = wuffs_base::SliceU8::default();
+                    v_dirty_rect = wuffs_base::RectIeU32::default();
+                    v_dirty_rect.set_max_exclusive_x(self.f_width);
+                    v_dirty_rect.set_max_exclusive_y(self.f_height);
+                    a_dst.update(v_dirty_rect, 0, false, 0, v_palette);
+                }
+                if r == 0 || (3 <= r && r <= 5) {
+                    'label_0: loop {
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.read_chunk_header(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 4 || (r == 0 && (self.f_chunk_type == CHUNK_TYPE_IEND)) {
+                                if r == 0 || r == 4 {
+                                    if r == 0 {
+                                        self.c_decode_frame.scratch = (4 as u64);
+                                    }
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = a_src.skip(&mut self.c_decode_frame.scratch);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    break 'label_0;
+                                }
+                            } else if r == 0
+                                && ((self.f_chunk_type != CHUNK_TYPE_IDAT)
+                                    && (((self.f_chunk_type >> 24) & 32) == 0))
+                            {
+                                status = ERROR_UNSUPPORTED_CRITICAL_CHUNK;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 0 {
+                                self.c_decode_frame.scratch = ((self.f_chunk_length + 4) as u64);
+                            }
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = a_src.skip(&mut self.c_decode_frame.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_call_sequence = 2;
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+            self.c_decode_frame.v_dirty_rect = v_dirty_rect;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.read_chunk_header
+
+impl Decoder {
+    fn read_chunk_header(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_chunk_header.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_read_chunk_header.v_length;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_read_chunk_header.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u32be(&mut t_0, &mut self.c_read_chunk_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_length = t_0;
+                }
+                if r == 0 {
+                    if v_length > 2147483647 {
+                        status = ERROR_BAD_CHUNK;
+                        break 'exit;
+                    }
+                    self.f_chunk_length = (v_length as u64);
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_read_chunk_header.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u32be(&mut t_1, &mut self.c_read_chunk_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_chunk_type = t_1;
+                }
+                if r == 0 {
+                    (*unsafe { self.f_chunk_data.get_unchecked_mut(0 as usize) }) =
+                        (((self.f_chunk_type >> 24) & 255) as u8);
+                    (*unsafe { self.f_chunk_data.get_unchecked_mut(1 as usize) }) =
+                        (((self.f_chunk_type >> 16) & 255) as u8);
+                    (*unsafe { self.f_chunk_data.get_unchecked_mut(2 as usize) }) =
+                        (((self.f_chunk_type >> 8) & 255) as u8);
+                    (*unsafe { self.f_chunk_data.get_unchecked_mut(3 as usize) }) =
+                        (((self.f_chunk_type >> 0) & 255) as u8);
+                    self.f_crc = crc32::IeeeHasher::default();
+                    self.f_crc_got = self.f_crc.update(unsafe {
+                        wuffs_base::SliceU8::from_array(&mut self.f_chunk_data)
+                            .subslice_j(4 as usize)
+                    });
+                }
+                self.c_read_chunk_header.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_chunk_header.coro_susp_point = csp;
+            self.c_read_chunk_header.v_length = v_length;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.read_small_chunk
+
+impl Decoder {
+    fn read_small_chunk(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_n: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_small_chunk.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_n = self.c_read_small_chunk.v_n;
+            v_i = self.c_read_small_chunk.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_chunk_length > 768 {
+                        status = ERROR_BAD_CHUNK;
+                        break 'exit;
+                    }
+                    v_n = (u64::min(self.f_chunk_length, 768) as u32);
+                    v_i = 0;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (v_i < v_n) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            (*unsafe { self.f_chunk_data.get_unchecked_mut((4 + v_i) as usize) }) =
+                                t_0;
+                        }
+                        if r == 0 {
+                            v_i += 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_chunk_length = 0;
+                    self.f_small_chunk_length = v_n;
+                    self.f_crc_got = self.f_crc.update(unsafe {
+                        wuffs_base::SliceU8::from_array(&mut self.f_chunk_data)
+                            .subslice_ij(4 as usize, (4 + v_n) as usize)
+                    });
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.verify_checksum(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_read_small_chunk.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_small_chunk.coro_susp_point = csp;
+            self.c_read_small_chunk.v_n = v_n;
+            self.c_read_small_chunk.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.verify_checksum
+
+impl Decoder {
+    fn verify_checksum(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_crc_want: u32 = 0;
+        let mut t_0: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_verify_checksum.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_crc_want = self.c_verify_checksum.v_crc_want;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_verify_checksum.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u32be(&mut t_0, &mut self.c_verify_checksum.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_crc_want = t_0;
+                }
+                if r == 0 {
+                    if !self.f_ignore_checksum && (self.f_crc_got != v_crc_want) {
+                        status = ERROR_BAD_CHECKSUM;
+                        break 'exit;
+                    }
+                }
+                self.c_verify_checksum.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_verify_checksum.coro_susp_point = csp;
+            self.c_verify_checksum.v_crc_want = v_crc_want;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_ihdr
+
+impl Decoder {
+    fn decode_ihdr(&mut self) -> wuffs_base::Status {
+        let mut v_width: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut v_depth: u8 = 0;
+        let mut v_color_type: u8 = 0;
+        let mut v_channels: u32 = 0;
+        let mut v_bits_per_pixel: u32 = 0;
+        let mut v_filter_distance: u32 = 0;
+        let mut v_row_stride: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_width = ((((*unsafe { self.f_chunk_data.get_unchecked(4 as usize) }) as u32) << 24)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(5 as usize) }) as u32) << 16)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(6 as usize) }) as u32) << 8)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(7 as usize) }) as u32) << 0));
+            v_height = ((((*unsafe { self.f_chunk_data.get_unchecked(8 as usize) }) as u32) << 24)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(9 as usize) }) as u32) << 16)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(10 as usize) }) as u32) << 8)
+                | (((*unsafe { self.f_chunk_data.get_unchecked(11 as usize) }) as u32) << 0));
+            if (v_width == 0)
+                || (v_width > 2147483647)
+                || (v_height == 0)
+                || (v_height > 2147483647)
+            {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            self.f_width = v_width;
+            self.f_height = v_height;
+            v_depth = (*unsafe { self.f_chunk_data.get_unchecked(12 as usize) });
+            v_color_type = (*unsafe { self.f_chunk_data.get_unchecked(13 as usize) });
+            v_channels = 0;
+            if v_color_type == 0 {
+                v_channels = 1;
+                if (v_depth != 1)
+                    && (v_depth != 2)
+                    && (v_depth != 4)
+                    && (v_depth != 8)
+                    && (v_depth != 16)
+                {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+            } else if v_color_type == 2 {
+                v_channels = 3;
+                if (v_depth != 8) && (v_depth != 16) {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+            } else if v_color_type == 3 {
+                v_channels = 1;
+                if (v_depth != 1) && (v_depth != 2) && (v_depth != 4) && (v_depth != 8) {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+            } else if v_color_type == 4 {
+                v_channels = 2;
+                if (v_depth != 8) && (v_depth != 16) {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+            } else if v_color_type == 6 {
+                v_channels = 4;
+                if (v_depth != 8) && (v_depth != 16) {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+            } else {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            self.f_depth = u8::min(v_depth, 16);
+            self.f_color_type = u8::min(v_color_type, 6);
+            if ((*unsafe { self.f_chunk_data.get_unchecked(14 as usize) }) != 0)
+                || ((*unsafe { self.f_chunk_data.get_unchecked(15 as usize) }) != 0)
+                || ((*unsafe { self.f_chunk_data.get_unchecked(16 as usize) }) > 1)
+            {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            self.f_interlaced = ((*unsafe { self.f_chunk_data.get_unchecked(16 as usize) }) == 1);
+            v_bits_per_pixel = (v_channels * (self.f_depth as u32));
+            if v_bits_per_pixel < 1 {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            self.f_bits_per_pixel = v_bits_per_pixel;
+            v_filter_distance = ((v_bits_per_pixel + 7) >> 3);
+            self.f_filter_distance = u32::max(v_filter_distance, 1);
+            if self.f_depth == 16 {
+                self.f_depth_mask = 65535;
+            } else {
+                self.f_depth_mask = (((1u32 << u8::min(self.f_depth, 8)) - 1) & 255);
+            }
+            v_row_stride = (((((v_width as u64) * (v_bits_per_pixel as u64)) + 7) >> 3) + 1);
+            self.f_row_stride = u64::min(v_row_stride, 17179869184);
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_plte
+
+impl Decoder {
+    fn decode_plte(&mut self) -> wuffs_base::Status {
+        let mut v_n: u32 = 0;
+        let mut v_num_palette_entries: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_n = self.f_small_chunk_length;
+            if (v_n == 0) || ((v_n % 3) != 0) {
+                status = ERROR_BAD_PALETTE;
+                break 'exit;
+            }
+            if self.f_color_type != 3 {
+                status = wuffs_base::Status::Ok;
+                break 'exit;
+            }
+            v_num_palette_entries = (v_n / 3);
+            self.f_num_palette_entries = v_num_palette_entries;
+            self.f_seen_palette = true;
+            v_i = 0;
+            while v_i < v_num_palette_entries {
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 0) as usize) }) = (*unsafe {
+                    self.f_chunk_data
+                        .get_unchecked((4 + (3 * v_i) + 2) as usize)
+                });
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 1) as usize) }) = (*unsafe {
+                    self.f_chunk_data
+                        .get_unchecked((4 + (3 * v_i) + 1) as usize)
+                });
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 2) as usize) }) = (*unsafe {
+                    self.f_chunk_data
+                        .get_unchecked((4 + (3 * v_i) + 0) as usize)
+                });
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize) }) = 255;
+                v_i += 1;
+            }
+            while v_i < 256 {
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 0) as usize) }) = 0;
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 1) as usize) }) = 0;
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 2) as usize) }) = 0;
+                (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize) }) = 255;
+                v_i += 1;
+            }
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_trns
+
+impl Decoder {
+    fn decode_trns(&mut self) -> wuffs_base::Status {
+        let mut v_n: u32 = 0;
+        let mut v_num_alphas: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_n = self.f_small_chunk_length;
+            if self.f_color_type == 0 {
+                if v_n != 2 {
+                    status = ERROR_BAD_TRANSPARENCY;
+                    break 'exit;
+                }
+                self.f_trns_gray = (self.f_depth_mask
+                    & ((((*unsafe { self.f_chunk_data.get_unchecked(4 as usize) }) as u32) << 8)
+                        | ((*unsafe { self.f_chunk_data.get_unchecked(5 as usize) }) as u32)));
+                self.f_has_trns = true;
+            } else if self.f_color_type == 2 {
+                if v_n != 6 {
+                    status = ERROR_BAD_TRANSPARENCY;
+                    break 'exit;
+                }
+                self.f_trns_red = (self.f_depth_mask
+                    & ((((*unsafe { self.f_chunk_data.get_unchecked(4 as usize) }) as u32) << 8)
+                        | ((*unsafe { self.f_chunk_data.get_unchecked(5 as usize) }) as u32)));
+                self.f_trns_green = (self.f_depth_mask
+                    & ((((*unsafe { self.f_chunk_data.get_unchecked(6 as usize) }) as u32) << 8)
+                        | ((*unsafe { self.f_chunk_data.get_unchecked(7 as usize) }) as u32)));
+                self.f_trns_blue = (self.f_depth_mask
+                    & ((((*unsafe { self.f_chunk_data.get_unchecked(8 as usize) }) as u32) << 8)
+                        | ((*unsafe { self.f_chunk_data.get_unchecked(9 as usize) }) as u32)));
+                self.f_has_trns = true;
+            } else if self.f_color_type == 3 {
+                if !self.f_seen_palette || (v_n > self.f_num_palette_entries) {
+                    status = ERROR_BAD_TRANSPARENCY;
+                    break 'exit;
+                }
+                v_num_alphas = u32::min(v_n, 256);
+                v_i = 0;
+                while v_i < v_num_alphas {
+                    (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize) }) =
+                        (*unsafe { self.f_chunk_data.get_unchecked((4 + v_i) as usize) });
+                    v_i += 1;
+                }
+            }
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_idats
+
+impl Decoder {
+    fn decode_idats(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        let mut v_curr: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_prev: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_i: u64 = 0;
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_idats.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_i = self.c_decode_idats.v_i;
+            v_z = self.c_decode_idats.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_curr = wuffs_base::SliceU8::default();
+                    v_prev = wuffs_base::SliceU8::default();
+                    v_i = 0;
+                    if self.f_interlaced {
+                        self.f_pass = 1;
+                    } else {
+                        self.f_pass = 0;
+                    }
+                    self.start_pass();
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    'label_0: loop {
+                        if r == 0 || (1 <= r && r <= 2) {
+                            if (1 <= r && r <= 2) || (r == 0 && (self.f_chunk_length == 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = self.verify_checksum(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.read_chunk_header(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    if self.f_chunk_type != CHUNK_TYPE_IDAT {
+                                        status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                        break 'exit;
+                                    }
+                                    continue 'label_0;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if (a_workbuf.len() as u64) < (self.f_row_stride * 2) {
+                                status = ERROR_BAD_WORKBUF_LENGTH;
+                                break 'exit;
+                            }
+                            v_curr = a_workbuf.prefix(self.f_row_length);
+                            v_prev = a_workbuf
+                                .suffix(self.f_row_stride)
+                                .prefix(self.f_row_length);
+                            if self.f_row_wi > (v_curr.len() as u64) {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_ROW_INDEX;
+                                break 'exit;
+                            }
+                            v_w = wuffs_base::IoWriter::default();
+                            {
+                                let o_0_a_src = a_src;
+                                let o_0_v_w = v_w;
+                                unsafe {
+                                    v_w.set(&mut u_w, unsafe {
+                                        v_curr.subslice_i(self.f_row_wi as usize)
+                                    })
+                                };
+                                a_src.set_limit(self.f_chunk_length);
+                                a_src.set_mark();
+                                t_0 = self.f_zlib.decode(v_w, a_src);
+                                v_z = t_0;
+                                if !self.f_ignore_checksum {
+                                    self.f_crc_got = self.f_crc.update(a_src.since_mark());
+                                }
+                                self.f_chunk_length = u64::saturating_sub(
+                                    self.f_chunk_length,
+                                    (a_src.since_mark().len() as u64),
+                                );
+                                self.f_row_wi =
+                                    u64::saturating_sub((v_curr.len() as u64), v_w.available());
+                                v_w = o_0_v_w;
+                                a_src = o_0_a_src;
+                            }
+                            if (self.f_pass < 8) && (self.f_row_wi == self.f_row_length) {
+                                if (v_curr.len() as u64) < 1 {
+                                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_ROW_LENGTH;
+                                    break 'exit;
+                                }
+                                if (*unsafe { v_curr.get_unchecked(0 as usize) }) > 4 {
+                                    status = ERROR_BAD_FILTER;
+                                    break 'exit;
+                                }
+                                if self.f_pass_y == 0 {
+                                    v_i = 0;
+                                    while v_i < (v_prev.len() as u64) {
+                                        (*unsafe { v_prev.get_unchecked_mut(v_i as usize) }) = 0;
+                                        v_i += 1;
+                                    }
+                                }
+                                self.unfilter_row(v_curr, v_prev);
+                                self.swizzle_row(a_dst, v_curr);
+                                v_prev.copy_from_slice(v_curr);
+                                self.f_row_wi = 0;
+                                self.f_pass_y = u32::wrapping_add(self.f_pass_y, 1);
+                                if self.f_pass_y >= self.f_pass_height {
+                                    self.next_pass();
+                                    self.start_pass();
+                                }
+                            }
+                            if v_z.is_ok() {
+                                break 'label_0;
+                            } else if v_z == wuffs_base::SUSPENSION_SHORT_WRITE {
+                                if self.f_pass >= 8 {
+                                    status = ERROR_TOO_MUCH_PIXEL_DATA;
+                                    break 'exit;
+                                }
+                                continue 'label_0;
+                            } else if (self.f_chunk_length == 0)
+                                && (v_z == wuffs_base::SUSPENSION_SHORT_READ)
+                            {
+                                continue 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            status = v_z;
+                            if r == 3 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                if status.is_error() {
+                                    break 'exit;
+                                }
+                                if !status.is_ok() {
+                                    csp = 3;
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if self.f_pass < 8 {
+                        status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || (4 <= r && r <= 5) {
+                    if r == 4 || (r == 0 && (self.f_chunk_length > 0)) {
+                        if r == 0 || r == 4 {
+                            if r == 0 {
+                                self.c_decode_idats.scratch = ((self.f_chunk_length + 4) as u64);
+                            }
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = a_src.skip(&mut self.c_decode_idats.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.verify_checksum(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_idats.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_idats.coro_susp_point = csp;
+            self.c_decode_idats.v_i = v_i;
+            self.c_decode_idats.v_z = v_z;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.start_pass
+
+impl Decoder {
+    fn start_pass(&mut self) {
+        let mut v_x0: u32 = 0;
+        let mut v_y0: u32 = 0;
+        let mut v_log2_dx: u32 = 0;
+        let mut v_log2_dy: u32 = 0;
+
+        while self.f_pass < 8 {
+            v_x0 = ((*unsafe { INTERLACE_X0.get_unchecked(self.f_pass as usize) }) as u32);
+            v_y0 = ((*unsafe { INTERLACE_Y0.get_unchecked(self.f_pass as usize) }) as u32);
+            if (self.f_width > v_x0) && (self.f_height > v_y0) {
+                v_log2_dx =
+                    ((*unsafe { INTERLACE_LOG2_DX.get_unchecked(self.f_pass as usize) }) as u32);
+                v_log2_dy =
+                    ((*unsafe { INTERLACE_LOG2_DY.get_unchecked(self.f_pass as usize) }) as u32);
+                self.f_pass_width =
+                    (((self.f_width - v_x0) + ((1u32 << v_log2_dx) - 1)) >> v_log2_dx);
+                self.f_pass_height =
+                    (((self.f_height - v_y0) + ((1u32 << v_log2_dy) - 1)) >> v_log2_dy);
+                self.f_pass_y = 0;
+                self.f_row_length =
+                    (((((self.f_pass_width as u64) * (self.f_bits_per_pixel as u64)) + 7) >> 3)
+                        + 1);
+                self.f_row_wi = 0;
+                return;
+            }
+            self.next_pass();
+        }
+        self.f_row_length = 0;
+        self.f_row_wi = 0;
+    }
+}
+
+// -------- func decoder.next_pass
+
+impl Decoder {
+    fn next_pass(&mut self) {
+        if (self.f_pass == 0) || (self.f_pass >= 7) {
+            self.f_pass = 8;
+        } else {
+            self.f_pass += 1;
+        }
+    }
+}
+
+// -------- func decoder.unfilter_row
+
+impl Decoder {
+    fn unfilter_row(&mut self, mut a_curr: wuffs_base::SliceU8, mut a_prev: wuffs_base::SliceU8) {
+        let mut v_d: u64 = 0;
+        let mut v_filter: u8 = 0;
+        let mut v_i: u64 = 0;
+        let mut v_j: u64 = 0;
+        let mut v_a: u32 = 0;
+        let mut v_b: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_pa: u32 = 0;
+        let mut v_pb: u32 = 0;
+        let mut v_pc: u32 = 0;
+
+        v_d = (u32::max(self.f_filter_distance, 1) as u64);
+        v_filter = 0;
+        v_i = 1;
+        v_j = 1;
+        v_a = 0;
+        v_b = 0;
+        v_c = 0;
+        v_pa = 0;
+        v_pb = 0;
+        v_pc = 0;
+        if (a_curr.len() as u64) != (a_prev.len() as u64) {
+            return;
+        }
+        if (a_curr.len() as u64) < 1 {
+            return;
+        }
+        v_filter = (*unsafe { a_curr.get_unchecked(0 as usize) });
+        if v_filter == 1 {
+            v_i = (v_d + 1);
+            while (v_i < (a_curr.len() as u64)) && (v_j < (a_curr.len() as u64)) {
+                (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                    (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                    (*unsafe { a_curr.get_unchecked(v_j as usize) }),
+                );
+                v_i += 1;
+                v_j += 1;
+            }
+        } else if v_filter == 2 {
+            while (v_i < (a_curr.len() as u64)) && (v_i < (a_prev.len() as u64)) {
+                (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                    (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                    (*unsafe { a_prev.get_unchecked(v_i as usize) }),
+                );
+                v_i += 1;
+            }
+        } else if v_filter == 3 {
+            while (v_i < (a_curr.len() as u64)) && (v_i < (a_prev.len() as u64)) && (v_i <= v_d) {
+                (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                    (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                    ((*unsafe { a_prev.get_unchecked(v_i as usize) }) >> 1),
+                );
+                v_i += 1;
+            }
+            while (v_i < (a_curr.len() as u64))
+                && (v_j < (a_curr.len() as u64))
+                && (v_i < (a_prev.len() as u64))
+            {
+                v_a = ((*unsafe { a_curr.get_unchecked(v_j as usize) }) as u32);
+                v_b = ((*unsafe { a_prev.get_unchecked(v_i as usize) }) as u32);
+                (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                    (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                    (((v_a + v_b) >> 1) as u8),
+                );
+                v_i += 1;
+                v_j += 1;
+            }
+        } else if v_filter == 4 {
+            while (v_i < (a_curr.len() as u64)) && (v_i < (a_prev.len() as u64)) && (v_i <= v_d) {
+                (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                    (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                    (*unsafe { a_prev.get_unchecked(v_i as usize) }),
+                );
+                v_i += 1;
+            }
+            while (v_i < (a_curr.len() as u64))
+                && (v_j < (a_curr.len() as u64))
+                && (v_i < (a_prev.len() as u64))
+                && (v_j < (a_prev.len() as u64))
+            {
+                v_a = ((*unsafe { a_curr.get_unchecked(v_j as usize) }) as u32);
+                v_b = ((*unsafe { a_prev.get_unchecked(v_i as usize) }) as u32);
+                v_c = ((*unsafe { a_prev.get_unchecked(v_j as usize) }) as u32);
+                v_pa = (u32::saturating_sub(v_b, v_c) + u32::saturating_sub(v_c, v_b));
+                v_pb = (u32::saturating_sub(v_a, v_c) + u32::saturating_sub(v_c, v_a));
+                v_pc = (u32::saturating_sub((v_a + v_b), (v_c + v_c))
+                    + u32::saturating_sub((v_c + v_c), (v_a + v_b)));
+                if (v_pa <= v_pb) && (v_pa <= v_pc) {
+                    (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                        (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                        (v_a as u8),
+                    );
+                } else if v_pb <= v_pc {
+                    (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                        (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                        (v_b as u8),
+                    );
+                } else {
+                    (*unsafe { a_curr.get_unchecked_mut(v_i as usize) }) = u8::wrapping_add(
+                        (*unsafe { a_curr.get_unchecked(v_i as usize) }),
+                        (v_c as u8),
+                    );
+                }
+                v_i += 1;
+                v_j += 1;
+            }
+        }
+    }
+}
+
+// -------- func decoder.swizzle_row
+
+impl Decoder {
+    fn swizzle_row(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_curr: wuffs_base::SliceU8,
+    ) {
+        let mut v_pass: u8 = 0;
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_y: u64 = 0;
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x: u32 = 0;
+        let mut v_dx: u32 = 0;
+        let mut v_d: u64 = 0;
+        let mut v_depth: u32 = 0;
+        let mut v_mask: u32 = 0;
+        let mut v_shift: u32 = 0;
+        let mut v_o: u64 = 0;
+        let mut v_k: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_r: u32 = 0;
+        let mut v_g: u32 = 0;
+        let mut v_b: u32 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_sample: [u8; 8] = [0; 8];
+        let mut v_pixel: [u8; 4] = [0; 4];
+        let mut v_x4: u64 = 0;
+
+        v_pass = u8::min(self.f_pass, 7);
+        v_tab = a_dst.plane(0);
+        v_y = (((self.f_pass_y as u64)
+            << (*unsafe { INTERLACE_LOG2_DY.get_unchecked(v_pass as usize) }))
+            + ((*unsafe { INTERLACE_Y0.get_unchecked(v_pass as usize) }) as u64));
+        v_dst = v_tab.row(((v_y & 4294967295) as u32));
+        v_x = ((*unsafe { INTERLACE_X0.get_unchecked(v_pass as usize) }) as u32);
+        v_dx = (1u32 << (*unsafe { INTERLACE_LOG2_DX.get_unchecked(v_pass as usize) }));
+        v_d = (u32::max(self.f_filter_distance, 1) as u64);
+        v_depth = (self.f_depth as u32);
+        v_mask = self.f_depth_mask;
+        v_shift = (8 - u32::min(v_depth, 8));
+        v_o = 1;
+        v_k = 0;
+        v_v = 0;
+        v_r = 0;
+        v_g = 0;
+        v_b = 0;
+        v_p = 0;
+        v_sample = [0; 8];
+        v_pixel = [0; 4];
+        v_x4 = 0;
+        while (v_k < self.f_pass_width) && (v_o < (a_curr.len() as u64)) {
+            if v_depth < 8 {
+                v_v = ((((*unsafe { a_curr.get_unchecked(v_o as usize) }) as u32) >> v_shift)
+                    & v_mask);
+            } else {
+                unsafe { wuffs_base::SliceU8::from_array(&mut v_sample) }
+                    .copy_from_slice(unsafe { a_curr.subslice_i(v_o as usize) });
+            }
+            if self.f_color_type == 0 {
+                if v_depth < 8 {
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) = (((v_v
+                        * ((*unsafe {
+                            LOW_DEPTH_GRAY_SCALES.get_unchecked(u32::min(v_depth, 4) as usize)
+                        }) as u32))
+                        & 255)
+                        as u8);
+                } else if v_depth == 8 {
+                    v_v = ((*unsafe { v_sample.get_unchecked(0 as usize) }) as u32);
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                } else {
+                    v_v = ((((*unsafe { v_sample.get_unchecked(0 as usize) }) as u32) << 8)
+                        | ((*unsafe { v_sample.get_unchecked(1 as usize) }) as u32));
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                }
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { v_pixel.get_unchecked(0 as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { v_pixel.get_unchecked(0 as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 255;
+                if self.f_has_trns && (v_v == self.f_trns_gray) {
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 0;
+                }
+            } else if self.f_color_type == 2 {
+                if v_depth == 8 {
+                    v_r = ((*unsafe { v_sample.get_unchecked(0 as usize) }) as u32);
+                    v_g = ((*unsafe { v_sample.get_unchecked(1 as usize) }) as u32);
+                    v_b = ((*unsafe { v_sample.get_unchecked(2 as usize) }) as u32);
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(2 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(1 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                } else {
+                    v_r = ((((*unsafe { v_sample.get_unchecked(0 as usize) }) as u32) << 8)
+                        | ((*unsafe { v_sample.get_unchecked(1 as usize) }) as u32));
+                    v_g = ((((*unsafe { v_sample.get_unchecked(2 as usize) }) as u32) << 8)
+                        | ((*unsafe { v_sample.get_unchecked(3 as usize) }) as u32));
+                    v_b = ((((*unsafe { v_sample.get_unchecked(4 as usize) }) as u32) << 8)
+                        | ((*unsafe { v_sample.get_unchecked(5 as usize) }) as u32));
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(4 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(2 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                }
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 255;
+                if self.f_has_trns
+                    && (v_r == self.f_trns_red)
+                    && (v_g == self.f_trns_green)
+                    && (v_b == self.f_trns_blue)
+                {
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 0;
+                }
+            } else if self.f_color_type == 3 {
+                if v_depth < 8 {
+                    v_p = (v_v & 255);
+                } else {
+                    v_p = ((*unsafe { v_sample.get_unchecked(0 as usize) }) as u32);
+                }
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 0) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 1) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 2) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 3) as usize) });
+            } else if self.f_color_type == 4 {
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { v_sample.get_unchecked(0 as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { v_sample.get_unchecked(0 as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { v_sample.get_unchecked(0 as usize) });
+                if v_depth == 8 {
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(1 as usize) });
+                } else {
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(2 as usize) });
+                }
+            } else {
+                if v_depth == 8 {
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(2 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(1 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(3 as usize) });
+                } else {
+                    (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(4 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(2 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(0 as usize) });
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                        (*unsafe { v_sample.get_unchecked(6 as usize) });
+                }
+            }
+            v_x4 = ((v_x as u64) * 4);
+            if v_x4 < (v_dst.len() as u64) {
+                unsafe { v_dst.subslice_i(v_x4 as usize) }
+                    .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) });
+            }
+            if v_depth >= 8 {
+                v_o = u64::saturating_add(v_o, v_d);
+            } else if v_shift >= v_depth {
+                v_shift -= v_depth;
+            } else {
+                v_shift = (8 - u32::min(v_depth, 8));
+                v_o = u64::saturating_add(v_o, 1);
+            }
+            v_x = u32::wrapping_add(v_x, v_dx);
+            v_k += 1;
+        }
+    }
+}
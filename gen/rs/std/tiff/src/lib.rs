@@ -0,0 +1,2248 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package tiff is the Rust translation of the Wuffs std/tiff package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_lzw as lzw;
+use wuffs_std_zlib as zlib;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("tiff: bad header");
+pub const ERROR_BAD_IFD_ENTRY: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: bad IFD entry");
+pub const ERROR_BAD_WORKBUF_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: bad workbuf length");
+pub const ERROR_NOT_ENOUGH_PIXEL_DATA: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: not enough pixel data");
+pub const ERROR_UNSUPPORTED_BIT_DEPTH: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported bit depth");
+pub const ERROR_UNSUPPORTED_COMPRESSION: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported compression");
+pub const ERROR_UNSUPPORTED_PHOTOMETRIC_INTERPRETATION: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported photometric interpretation");
+pub const ERROR_UNSUPPORTED_PLANAR_CONFIGURATION: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported planar configuration");
+pub const ERROR_UNSUPPORTED_PREDICTOR: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported predictor");
+pub const ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: unsupported samples per pixel");
+pub const ERROR_TODO_UNSUPPORTED_BIGTIFF: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: TODO: unsupported BigTIFF");
+pub const ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH: wuffs_base::Status =
+    wuffs_base::Status::Error("tiff: TODO: unsupported image width");
+pub const SUSPENSION_SEEK_REQUIRED: wuffs_base::Status =
+    wuffs_base::Status::Suspension("tiff: seek required");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a tiff.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_big_endian: bool,
+    f_pos: u64,
+    f_seek_pos: u64,
+    f_value: u32,
+    f_raw_width: u32,
+    f_raw_height: u32,
+    f_raw_bits_per_sample: u32,
+    f_raw_compression: u32,
+    f_raw_photometric: u32,
+    f_raw_samples_per_pixel: u32,
+    f_raw_rows_per_strip: u32,
+    f_raw_planar_configuration: u32,
+    f_raw_predictor: u32,
+    f_raw_tile_width: u32,
+    f_raw_tile_height: u32,
+    f_raw_extra_samples: u32,
+    f_bits_per_sample: u32,
+    f_samples_per_pixel: u32,
+    f_compression: u32,
+    f_photometric: u32,
+    f_predictor: bool,
+    f_alpha: u32,
+    f_is_tiled: bool,
+    f_chunk_width: u32,
+    f_chunk_height: u32,
+    f_chunks_across: u32,
+    f_num_chunks: u32,
+    f_chunk_index: u32,
+    f_chunk_remaining: u64,
+    f_array_types: [u32; 4],
+    f_array_counts: [u32; 4],
+    f_array_values: [u32; 4],
+    f_packbits_literal: u32,
+    f_packbits_run: u32,
+    f_packbits_byte: u8,
+    f_row_length: u32,
+    f_row_wi: u32,
+    f_row: [u8; 32772],
+    f_palette: [u8; 1024],
+    f_lzw: lzw::Decoder,
+    f_zlib: zlib::Decoder,
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_read_entry: DecoderReadEntryCoro,
+    c_read_array: DecoderReadArrayCoro,
+    c_read_u16: DecoderReadU16Coro,
+    c_read_u32: DecoderReadU32Coro,
+    c_seek: DecoderSeekCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_decode_chunk: DecoderDecodeChunkCoro,
+    c_fill_row: DecoderFillRowCoro,
+    c_fill_row_none: DecoderFillRowNoneCoro,
+    c_fill_row_packbits: DecoderFillRowPackbitsCoro,
+    c_fill_row_lzw_or_deflate: DecoderFillRowLzwOrDeflateCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_big_endian: false,
+            f_pos: 0,
+            f_seek_pos: 0,
+            f_value: 0,
+            f_raw_width: 0,
+            f_raw_height: 0,
+            f_raw_bits_per_sample: 0,
+            f_raw_compression: 0,
+            f_raw_photometric: 0,
+            f_raw_samples_per_pixel: 0,
+            f_raw_rows_per_strip: 0,
+            f_raw_planar_configuration: 0,
+            f_raw_predictor: 0,
+            f_raw_tile_width: 0,
+            f_raw_tile_height: 0,
+            f_raw_extra_samples: 0,
+            f_bits_per_sample: 0,
+            f_samples_per_pixel: 0,
+            f_compression: 0,
+            f_photometric: 0,
+            f_predictor: false,
+            f_alpha: 0,
+            f_is_tiled: false,
+            f_chunk_width: 0,
+            f_chunk_height: 0,
+            f_chunks_across: 0,
+            f_num_chunks: 0,
+            f_chunk_index: 0,
+            f_chunk_remaining: 0,
+            f_array_types: [0; 4],
+            f_array_counts: [0; 4],
+            f_array_values: [0; 4],
+            f_packbits_literal: 0,
+            f_packbits_run: 0,
+            f_packbits_byte: 0,
+            f_row_length: 0,
+            f_row_wi: 0,
+            f_row: [0; 32772],
+            f_palette: [0; 1024],
+            f_lzw: lzw::Decoder::default(),
+            f_zlib: zlib::Decoder::default(),
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_read_entry: DecoderReadEntryCoro::default(),
+            c_read_array: DecoderReadArrayCoro::default(),
+            c_read_u16: DecoderReadU16Coro::default(),
+            c_read_u32: DecoderReadU32Coro::default(),
+            c_seek: DecoderSeekCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_decode_chunk: DecoderDecodeChunkCoro::default(),
+            c_fill_row: DecoderFillRowCoro::default(),
+            c_fill_row_none: DecoderFillRowNoneCoro::default(),
+            c_fill_row_packbits: DecoderFillRowPackbitsCoro::default(),
+            c_fill_row_lzw_or_deflate: DecoderFillRowLzwOrDeflateCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_magic: u32,
+    v_num_entries: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_magic: 0,
+            v_num_entries: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderReadEntryCoro {
+    coro_susp_point: u32,
+    v_tag: u32,
+    v_field_type: u32,
+    v_count: u32,
+    v_raw: u32,
+    v_which: u32,
+    v_v: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderReadEntryCoro {
+    fn default() -> Self {
+        DecoderReadEntryCoro {
+            coro_susp_point: 0,
+            v_tag: 0,
+            v_field_type: 0,
+            v_count: 0,
+            v_raw: 0,
+            v_which: 0,
+            v_v: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderReadArrayCoro {
+    coro_susp_point: u32,
+    v_field_type: u32,
+    v_count: u32,
+    v_raw: u32,
+    v_n: u32,
+    v_is_inline: bool,
+    v_offset: u32,
+    v_i: u32,
+    v_v: u32,
+    v_c: u32,
+    v_index: u32,
+}
+
+impl Default for DecoderReadArrayCoro {
+    fn default() -> Self {
+        DecoderReadArrayCoro {
+            coro_susp_point: 0,
+            v_field_type: 0,
+            v_count: 0,
+            v_raw: 0,
+            v_n: 0,
+            v_is_inline: false,
+            v_offset: 0,
+            v_i: 0,
+            v_v: 0,
+            v_c: 0,
+            v_index: 0,
+        }
+    }
+}
+
+struct DecoderReadU16Coro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderReadU16Coro {
+    fn default() -> Self {
+        DecoderReadU16Coro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderReadU32Coro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderReadU32Coro {
+    fn default() -> Self {
+        DecoderReadU32Coro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderSeekCoro {
+    coro_susp_point: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderSeekCoro {
+    fn default() -> Self {
+        DecoderSeekCoro {
+            coro_susp_point: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+    v_dirty_rect: wuffs_base::RectIeU32,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro {
+            coro_susp_point: 0,
+            v_dirty_rect: wuffs_base::RectIeU32::default(),
+        }
+    }
+}
+
+struct DecoderDecodeChunkCoro {
+    coro_susp_point: u32,
+    v_c: u32,
+    v_offset: u32,
+    v_x0: u64,
+    v_y0: u64,
+    v_y: u64,
+    v_y_end: u64,
+}
+
+impl Default for DecoderDecodeChunkCoro {
+    fn default() -> Self {
+        DecoderDecodeChunkCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+            v_offset: 0,
+            v_x0: 0,
+            v_y0: 0,
+            v_y: 0,
+            v_y_end: 0,
+        }
+    }
+}
+
+struct DecoderFillRowCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderFillRowCoro {
+    fn default() -> Self {
+        DecoderFillRowCoro { coro_susp_point: 0 }
+    }
+}
+
+struct DecoderFillRowNoneCoro {
+    coro_susp_point: u32,
+    v_n: u64,
+    v_c: u8,
+}
+
+impl Default for DecoderFillRowNoneCoro {
+    fn default() -> Self {
+        DecoderFillRowNoneCoro {
+            coro_susp_point: 0,
+            v_n: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderFillRowPackbitsCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderFillRowPackbitsCoro {
+    fn default() -> Self {
+        DecoderFillRowPackbitsCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderFillRowLzwOrDeflateCoro {
+    coro_susp_point: u32,
+    v_z: wuffs_base::Status,
+    v_n: u64,
+    v_lzw_status: wuffs_base::Status,
+    v_zlib_status: wuffs_base::Status,
+}
+
+impl Default for DecoderFillRowLzwOrDeflateCoro {
+    fn default() -> Self {
+        DecoderFillRowLzwOrDeflateCoro {
+            coro_susp_point: 0,
+            v_z: wuffs_base::Status::Ok,
+            v_n: 0,
+            v_lzw_status: wuffs_base::Status::Ok,
+            v_zlib_status: wuffs_base::Status::Ok,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+const TAG_IMAGE_WIDTH: u32 = 0x100;
+
+const TAG_IMAGE_LENGTH: u32 = 0x101;
+
+const TAG_BITS_PER_SAMPLE: u32 = 0x102;
+
+const TAG_COMPRESSION: u32 = 0x103;
+
+const TAG_PHOTOMETRIC_INTERPRETATION: u32 = 0x106;
+
+const TAG_STRIP_OFFSETS: u32 = 0x111;
+
+const TAG_SAMPLES_PER_PIXEL: u32 = 0x115;
+
+const TAG_ROWS_PER_STRIP: u32 = 0x116;
+
+const TAG_STRIP_BYTE_COUNTS: u32 = 0x117;
+
+const TAG_PLANAR_CONFIGURATION: u32 = 0x11C;
+
+const TAG_PREDICTOR: u32 = 0x13D;
+
+const TAG_COLOR_MAP: u32 = 0x140;
+
+const TAG_TILE_WIDTH: u32 = 0x142;
+
+const TAG_TILE_LENGTH: u32 = 0x143;
+
+const TAG_TILE_OFFSETS: u32 = 0x144;
+
+const TAG_TILE_BYTE_COUNTS: u32 = 0x145;
+
+const TAG_EXTRA_SAMPLES: u32 = 0x152;
+
+const FIELD_TYPE_SHORT: u32 = 0x3;
+
+const FIELD_TYPE_LONG: u32 = 0x4;
+
+const COMPRESSION_NONE: u32 = 0x1;
+
+const COMPRESSION_LZW: u32 = 0x5;
+
+const COMPRESSION_DEFLATE: u32 = 0x8;
+
+const COMPRESSION_PACKBITS: u32 = 0x8005;
+
+const COMPRESSION_OLD_DEFLATE: u32 = 0x80B2;
+
+const PHOTOMETRIC_WHITE_IS_ZERO: u32 = 0x0;
+
+const PHOTOMETRIC_BLACK_IS_ZERO: u32 = 0x1;
+
+const PHOTOMETRIC_RGB: u32 = 0x2;
+
+const PHOTOMETRIC_PALETTE: u32 = 0x3;
+
+const EXTRA_SAMPLES_ASSOCIATED_ALPHA: u32 = 0x1;
+
+const EXTRA_SAMPLES_UNASSOCIATED_ALPHA: u32 = 0x2;
+
+const WHICH_OFFSETS: u32 = 0x0;
+
+const WHICH_BYTE_COUNTS: u32 = 0x1;
+
+const WHICH_BITS_PER_SAMPLE: u32 = 0x2;
+
+const WHICH_COLOR_MAP: u32 = 0x3;
+
+// ---------------- Function Implementations
+
+// -------- func decoder.seek_position
+
+impl Decoder {
+    pub fn seek_position(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_seek_pos;
+    }
+}
+
+// -------- func decoder.workbuf_len
+
+impl Decoder {
+    pub fn workbuf_len(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return ((self.f_num_chunks as u64) * 8);
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_magic: u32 = 0;
+        let mut v_num_entries: u32 = 0;
+        let mut v_workbuf: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut t_0: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_magic = self.c_decode_config.v_magic;
+            v_num_entries = self.c_decode_config.v_num_entries;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u32le(&mut t_0, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_magic = t_0;
+                }
+                if r == 0 {
+                    if v_magic == 2771273 {
+                        self.f_big_endian = false;
+                    } else if v_magic == 704662861 {
+                        self.f_big_endian = true;
+                    } else if (v_magic == 2836809) || (v_magic == 721440077) {
+                        status = ERROR_TODO_UNSUPPORTED_BIGTIFF;
+                        break 'exit;
+                    } else {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_pos = 4;
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.read_u32(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    if self.f_value < 8 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.seek(a_src, (self.f_value as u64));
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_raw_bits_per_sample = 1;
+                    self.f_raw_compression = COMPRESSION_NONE;
+                    self.f_raw_photometric = 4294967295;
+                    self.f_raw_samples_per_pixel = 1;
+                    self.f_raw_rows_per_strip = 4294967295;
+                    self.f_raw_planar_configuration = 1;
+                    self.f_raw_predictor = 1;
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = self.read_u16(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_num_entries = self.f_value;
+                    if v_num_entries == 0 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    while r != 0 || (v_num_entries > 0) {
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.read_entry(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_num_entries -= 1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    if (self.f_raw_samples_per_pixel == 0) || (self.f_raw_samples_per_pixel > 4) {
+                        status = ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+                        break 'exit;
+                    }
+                    self.f_samples_per_pixel = self.f_raw_samples_per_pixel;
+                    v_workbuf = wuffs_base::SliceU8::default();
+                }
+                if r == 0 || r == 6 {
+                    if r == 6
+                        || (r == 0
+                            && ((*unsafe {
+                                self.f_array_counts
+                                    .get_unchecked(WHICH_BITS_PER_SAMPLE as usize)
+                            }) > 0))
+                    {
+                        if r == 0 || r == 6 {
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = self.read_array(a_src, v_workbuf, WHICH_BITS_PER_SAMPLE);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 7 {
+                    if r == 7 {
+                        r = 0;
+                    }
+                    csp = 7;
+                    status = self.configure();
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 8 {
+                    if r == 8 || (r == 0 && (self.f_photometric == PHOTOMETRIC_PALETTE)) {
+                        if r == 0 || r == 8 {
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = self.read_array(a_src, v_workbuf, WHICH_COLOR_MAP);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        self.set_gray_palette();
+                    }
+                }
+                if r == 0 {
+                    if ((*unsafe { self.f_array_counts.get_unchecked(WHICH_OFFSETS as usize) })
+                        < self.f_num_chunks)
+                        || ((*unsafe {
+                            self.f_array_counts
+                                .get_unchecked(WHICH_BYTE_COUNTS as usize)
+                        }) < self.f_num_chunks)
+                    {
+                        status = ERROR_BAD_IFD_ENTRY;
+                        break 'exit;
+                    }
+                    a_dst.initialize(570460296, 0, self.f_width, self.f_height, 1);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_magic = v_magic;
+            self.c_decode_config.v_num_entries = v_num_entries;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.read_entry
+
+impl Decoder {
+    fn read_entry(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_tag: u32 = 0;
+        let mut v_field_type: u32 = 0;
+        let mut v_count: u32 = 0;
+        let mut v_raw: u32 = 0;
+        let mut v_which: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut t_0: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_entry.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_tag = self.c_read_entry.v_tag;
+            v_field_type = self.c_read_entry.v_field_type;
+            v_count = self.c_read_entry.v_count;
+            v_raw = self.c_read_entry.v_raw;
+            v_which = self.c_read_entry.v_which;
+            v_v = self.c_read_entry.v_v;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = self.read_u16(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 {
+                        v_tag = self.f_value;
+                    }
+                    if r == 0 || r == 2 {
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = self.read_u16(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 {
+                        v_field_type = self.f_value;
+                    }
+                    if r == 0 || r == 3 {
+                        if r == 3 {
+                            r = 0;
+                        }
+                        csp = 3;
+                        status = self.read_u32(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 {
+                        v_count = self.f_value;
+                    }
+                    if r == 0 || r == 4 {
+                        if r == 0 {
+                            self.c_read_entry.scratch = 0;
+                        }
+                        if r == 4 {
+                            r = 0;
+                        }
+                        csp = 4;
+                        status = a_src.read_u32le(&mut t_0, &mut self.c_read_entry.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        v_raw = t_0;
+                    }
+                    if r == 0 {
+                        self.f_pos = u64::saturating_add(self.f_pos, 4);
+                        if (v_tag == TAG_BITS_PER_SAMPLE)
+                            || (v_tag == TAG_STRIP_OFFSETS)
+                            || (v_tag == TAG_STRIP_BYTE_COUNTS)
+                            || (v_tag == TAG_COLOR_MAP)
+                            || (v_tag == TAG_TILE_OFFSETS)
+                            || (v_tag == TAG_TILE_BYTE_COUNTS)
+                        {
+                            if ((v_field_type != FIELD_TYPE_SHORT)
+                                && (v_field_type != FIELD_TYPE_LONG))
+                                || (v_count == 0)
+                            {
+                                status = ERROR_BAD_IFD_ENTRY;
+                                break 'exit;
+                            }
+                            v_which = WHICH_BITS_PER_SAMPLE;
+                            if (v_tag == TAG_STRIP_OFFSETS) || (v_tag == TAG_TILE_OFFSETS) {
+                                v_which = WHICH_OFFSETS;
+                            } else if (v_tag == TAG_STRIP_BYTE_COUNTS)
+                                || (v_tag == TAG_TILE_BYTE_COUNTS)
+                            {
+                                v_which = WHICH_BYTE_COUNTS;
+                            } else if v_tag == TAG_COLOR_MAP {
+                                v_which = WHICH_COLOR_MAP;
+                            }
+                            (*unsafe { self.f_array_types.get_unchecked_mut(v_which as usize) }) =
+                                v_field_type;
+                            (*unsafe { self.f_array_counts.get_unchecked_mut(v_which as usize) }) =
+                                v_count;
+                            (*unsafe { self.f_array_values.get_unchecked_mut(v_which as usize) }) =
+                                v_raw;
+                            if (v_tag == TAG_TILE_OFFSETS) || (v_tag == TAG_TILE_BYTE_COUNTS) {
+                                self.f_is_tiled = true;
+                            }
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        if (v_tag != TAG_IMAGE_WIDTH)
+                            && (v_tag != TAG_IMAGE_LENGTH)
+                            && (v_tag != TAG_COMPRESSION)
+                            && (v_tag != TAG_PHOTOMETRIC_INTERPRETATION)
+                            && (v_tag != TAG_SAMPLES_PER_PIXEL)
+                            && (v_tag != TAG_ROWS_PER_STRIP)
+                            && (v_tag != TAG_PLANAR_CONFIGURATION)
+                            && (v_tag != TAG_PREDICTOR)
+                            && (v_tag != TAG_TILE_WIDTH)
+                            && (v_tag != TAG_TILE_LENGTH)
+                            && (v_tag != TAG_EXTRA_SAMPLES)
+                        {
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        }
+                        if ((v_field_type != FIELD_TYPE_SHORT) && (v_field_type != FIELD_TYPE_LONG))
+                            || (v_count != 1)
+                        {
+                            status = ERROR_BAD_IFD_ENTRY;
+                            break 'exit;
+                        }
+                        v_v = self.inline_element(v_raw, v_field_type, 0);
+                        if v_tag == TAG_IMAGE_WIDTH {
+                            self.f_raw_width = v_v;
+                        } else if v_tag == TAG_IMAGE_LENGTH {
+                            self.f_raw_height = v_v;
+                        } else if v_tag == TAG_COMPRESSION {
+                            self.f_raw_compression = v_v;
+                        } else if v_tag == TAG_PHOTOMETRIC_INTERPRETATION {
+                            self.f_raw_photometric = v_v;
+                        } else if v_tag == TAG_SAMPLES_PER_PIXEL {
+                            self.f_raw_samples_per_pixel = v_v;
+                        } else if v_tag == TAG_ROWS_PER_STRIP {
+                            self.f_raw_rows_per_strip = v_v;
+                        } else if v_tag == TAG_PLANAR_CONFIGURATION {
+                            self.f_raw_planar_configuration = v_v;
+                        } else if v_tag == TAG_PREDICTOR {
+                            self.f_raw_predictor = v_v;
+                        } else if v_tag == TAG_TILE_WIDTH {
+                            self.f_raw_tile_width = v_v;
+                            self.f_is_tiled = true;
+                        } else if v_tag == TAG_TILE_LENGTH {
+                            self.f_raw_tile_height = v_v;
+                            self.f_is_tiled = true;
+                        } else if v_tag == TAG_EXTRA_SAMPLES {
+                            self.f_raw_extra_samples = v_v;
+                        }
+                    }
+                }
+                self.c_read_entry.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_entry.coro_susp_point = csp;
+            self.c_read_entry.v_tag = v_tag;
+            self.c_read_entry.v_field_type = v_field_type;
+            self.c_read_entry.v_count = v_count;
+            self.c_read_entry.v_raw = v_raw;
+            self.c_read_entry.v_which = v_which;
+            self.c_read_entry.v_v = v_v;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.inline_element
+
+impl Decoder {
+    fn inline_element(&mut self, mut a_raw: u32, mut a_field_type: u32, mut a_i: u32) -> u32 {
+        let mut v_x: u32 = 0;
+
+        v_x = 0;
+        if a_field_type == FIELD_TYPE_LONG {
+            v_x = a_raw;
+            if self.f_big_endian {
+                v_x = (((v_x & 255) << 24)
+                    | ((v_x & 65280) << 8)
+                    | ((v_x >> 8) & 65280)
+                    | (v_x >> 24));
+            }
+            return v_x;
+        }
+        v_x = (a_raw >> (16 * a_i));
+        if self.f_big_endian {
+            return (((v_x & 255) << 8) | ((v_x >> 8) & 255));
+        }
+        return (v_x & 65535);
+    }
+}
+
+// -------- func decoder.read_array
+
+impl Decoder {
+    fn read_array(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+    ) -> wuffs_base::Status {
+        let mut v_field_type: u32 = 0;
+        let mut v_count: u32 = 0;
+        let mut v_raw: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_is_inline: bool = false;
+        let mut v_offset: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_index: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_array.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_field_type = self.c_read_array.v_field_type;
+            v_count = self.c_read_array.v_count;
+            v_raw = self.c_read_array.v_raw;
+            v_n = self.c_read_array.v_n;
+            v_is_inline = self.c_read_array.v_is_inline;
+            v_offset = self.c_read_array.v_offset;
+            v_i = self.c_read_array.v_i;
+            v_v = self.c_read_array.v_v;
+            v_c = self.c_read_array.v_c;
+            v_index = self.c_read_array.v_index;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_field_type = (*unsafe { self.f_array_types.get_unchecked(a_which as usize) });
+                    v_count = (*unsafe { self.f_array_counts.get_unchecked(a_which as usize) });
+                    v_raw = (*unsafe { self.f_array_values.get_unchecked(a_which as usize) });
+                    v_n = v_count;
+                    if a_which == WHICH_BITS_PER_SAMPLE {
+                        if v_count != self.f_samples_per_pixel {
+                            status = ERROR_BAD_IFD_ENTRY;
+                            break 'exit;
+                        }
+                    } else if a_which == WHICH_COLOR_MAP {
+                        if v_count != (3u32 << self.f_bits_per_sample) {
+                            status = ERROR_BAD_IFD_ENTRY;
+                            break 'exit;
+                        }
+                    } else {
+                        v_n = u32::min(v_count, self.f_num_chunks);
+                    }
+                    v_is_inline = (v_count <= 1);
+                    if v_field_type == FIELD_TYPE_SHORT {
+                        v_is_inline = (v_count <= 2);
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 || (r == 0 && !v_is_inline) {
+                        if r == 0 {
+                            v_offset = self.inline_element(v_raw, FIELD_TYPE_LONG, 0);
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.seek(a_src, (v_offset as u64));
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_i = 0;
+                    v_v = 0;
+                    v_c = 0;
+                    v_index = 0;
+                }
+                if r == 0 || (2 <= r && r <= 3) {
+                    while r != 0 || (v_i < v_n) {
+                        if r == 0 || (2 <= r && r <= 3) {
+                            if r == 0 && v_is_inline {
+                                v_v = self.inline_element(v_raw, v_field_type, (v_i & 1));
+                            } else if r == 2 || (r == 0 && (v_field_type == FIELD_TYPE_SHORT)) {
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.read_u16(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_v = self.f_value;
+                                }
+                            } else {
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.read_u32(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_v = self.f_value;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if a_which == WHICH_OFFSETS {
+                                self.set_chunk_value(a_workbuf, WHICH_OFFSETS, v_i, v_v);
+                            } else if a_which == WHICH_BYTE_COUNTS {
+                                self.set_chunk_value(a_workbuf, WHICH_BYTE_COUNTS, v_i, v_v);
+                            } else if a_which == WHICH_BITS_PER_SAMPLE {
+                                if v_i == 0 {
+                                    self.f_raw_bits_per_sample = v_v;
+                                } else if v_v != self.f_raw_bits_per_sample {
+                                    status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                                    break 'exit;
+                                }
+                            } else {
+                                v_c = 2;
+                                v_index = v_i;
+                                if v_index >= (v_count / 3) {
+                                    v_c = 1;
+                                    v_index = u32::saturating_sub(v_index, (v_count / 3));
+                                    if v_index >= (v_count / 3) {
+                                        v_c = 0;
+                                        v_index = u32::saturating_sub(v_index, (v_count / 3));
+                                    }
+                                }
+                                if v_index < 256 {
+                                    (*unsafe {
+                                        self.f_palette
+                                            .get_unchecked_mut(((4 * v_index) + v_c) as usize)
+                                    }) = (((v_v >> 8) & 255) as u8);
+                                    (*unsafe {
+                                        self.f_palette
+                                            .get_unchecked_mut(((4 * v_index) + 3) as usize)
+                                    }) = 255;
+                                }
+                            }
+                            v_i = u32::saturating_add(v_i, 1);
+                        }
+                    }
+                }
+                self.c_read_array.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_array.coro_susp_point = csp;
+            self.c_read_array.v_field_type = v_field_type;
+            self.c_read_array.v_count = v_count;
+            self.c_read_array.v_raw = v_raw;
+            self.c_read_array.v_n = v_n;
+            self.c_read_array.v_is_inline = v_is_inline;
+            self.c_read_array.v_offset = v_offset;
+            self.c_read_array.v_i = v_i;
+            self.c_read_array.v_v = v_v;
+            self.c_read_array.v_c = v_c;
+            self.c_read_array.v_index = v_index;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.configure
+
+impl Decoder {
+    fn configure(&mut self) -> wuffs_base::Status {
+        let mut v_spp: u32 = 0;
+        let mut v_bps: u32 = 0;
+        let mut v_num_colors: u32 = 0;
+        let mut v_compression: u32 = 0;
+        let mut v_chunks_across: u64 = 0;
+        let mut v_chunks_down: u64 = 0;
+        let mut v_across: u64 = 0;
+        let mut v_down: u64 = 0;
+        let mut v_num_chunks: u64 = 0;
+        let mut v_row_length: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            if (self.f_raw_width == 0)
+                || (self.f_raw_width > 2147483647)
+                || (self.f_raw_height == 0)
+                || (self.f_raw_height > 2147483647)
+            {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            self.f_width = self.f_raw_width;
+            self.f_height = self.f_raw_height;
+            v_spp = self.f_samples_per_pixel;
+            v_bps = self.f_raw_bits_per_sample;
+            v_num_colors = 1;
+            if self.f_raw_photometric == PHOTOMETRIC_RGB {
+                v_num_colors = 3;
+            } else if (self.f_raw_photometric != PHOTOMETRIC_WHITE_IS_ZERO)
+                && (self.f_raw_photometric != PHOTOMETRIC_BLACK_IS_ZERO)
+                && (self.f_raw_photometric != PHOTOMETRIC_PALETTE)
+            {
+                status = ERROR_UNSUPPORTED_PHOTOMETRIC_INTERPRETATION;
+                break 'exit;
+            }
+            self.f_photometric = u32::min(self.f_raw_photometric, 3);
+            self.f_alpha = 0;
+            if v_spp == (v_num_colors + 1) {
+                if self.f_raw_extra_samples == EXTRA_SAMPLES_ASSOCIATED_ALPHA {
+                    self.f_alpha = 1;
+                } else if self.f_raw_extra_samples == EXTRA_SAMPLES_UNASSOCIATED_ALPHA {
+                    self.f_alpha = 2;
+                }
+                if (self.f_alpha != 0) && (self.f_photometric == PHOTOMETRIC_PALETTE) {
+                    status = ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+                    break 'exit;
+                }
+            } else if v_spp != v_num_colors {
+                status = ERROR_UNSUPPORTED_SAMPLES_PER_PIXEL;
+                break 'exit;
+            }
+            if (v_spp > 1) && (self.f_raw_planar_configuration != 1) {
+                status = ERROR_UNSUPPORTED_PLANAR_CONFIGURATION;
+                break 'exit;
+            }
+            if v_bps == 8 {
+                self.f_bits_per_sample = 8;
+            } else if (v_spp == 1) && ((v_bps == 1) || (v_bps == 2) || (v_bps == 4)) {
+                self.f_bits_per_sample = u32::min(v_bps, 4);
+            } else {
+                status = ERROR_UNSUPPORTED_BIT_DEPTH;
+                break 'exit;
+            }
+            v_compression = self.f_raw_compression;
+            if v_compression == COMPRESSION_OLD_DEFLATE {
+                v_compression = COMPRESSION_DEFLATE;
+            }
+            if (v_compression != COMPRESSION_NONE)
+                && (v_compression != COMPRESSION_LZW)
+                && (v_compression != COMPRESSION_DEFLATE)
+                && (v_compression != COMPRESSION_PACKBITS)
+            {
+                status = ERROR_UNSUPPORTED_COMPRESSION;
+                break 'exit;
+            }
+            self.f_compression = v_compression;
+            if self.f_raw_predictor == 2 {
+                if self.f_bits_per_sample != 8 {
+                    status = ERROR_UNSUPPORTED_PREDICTOR;
+                    break 'exit;
+                }
+                self.f_predictor = true;
+            } else if self.f_raw_predictor != 1 {
+                status = ERROR_UNSUPPORTED_PREDICTOR;
+                break 'exit;
+            }
+            v_chunks_across = 1;
+            v_chunks_down = 0;
+            if self.f_is_tiled {
+                if (self.f_raw_tile_width == 0)
+                    || (self.f_raw_tile_width > 2147483647)
+                    || (self.f_raw_tile_height == 0)
+                    || (self.f_raw_tile_height > 2147483647)
+                {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+                self.f_chunk_width = self.f_raw_tile_width;
+                self.f_chunk_height = self.f_raw_tile_height;
+                v_chunks_across =
+                    self.div_round_up((self.f_width as u64), (self.f_chunk_width as u64));
+            } else {
+                if self.f_raw_rows_per_strip == 0 {
+                    status = ERROR_BAD_HEADER;
+                    break 'exit;
+                }
+                self.f_chunk_width = self.f_width;
+                self.f_chunk_height = u32::min(self.f_raw_rows_per_strip, self.f_height);
+            }
+            v_chunks_down = self.div_round_up((self.f_height as u64), (self.f_chunk_height as u64));
+            if (v_chunks_across > 2147483647) || (v_chunks_down > 2147483647) {
+                status = ERROR_BAD_HEADER;
+                break 'exit;
+            }
+            v_across = v_chunks_across;
+            v_down = v_chunks_down;
+            v_num_chunks = (v_across * v_down);
+            if v_num_chunks > 4294967295 {
+                status = ERROR_BAD_IFD_ENTRY;
+                break 'exit;
+            }
+            self.f_chunks_across = (v_across as u32);
+            self.f_num_chunks = (v_num_chunks as u32);
+            v_row_length = self.div_round_up(
+                ((self.f_chunk_width as u64) * ((v_spp * self.f_bits_per_sample) as u64)),
+                8,
+            );
+            if v_row_length > 32768 {
+                status = ERROR_TODO_UNSUPPORTED_IMAGE_WIDTH;
+                break 'exit;
+            }
+            self.f_row_length = (v_row_length as u32);
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.div_round_up
+
+impl Decoder {
+    fn div_round_up(&mut self, mut a_a: u64, mut a_b: u64) -> u64 {
+        if a_b == 0 {
+            return 0;
+        }
+        return (((a_a + a_b) - 1) / a_b);
+    }
+}
+
+// -------- func decoder.set_gray_palette
+
+impl Decoder {
+    fn set_gray_palette(&mut self) {
+        let mut v_max: u32 = 0;
+        let mut v_scale: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_g: u32 = 0;
+
+        v_max = ((1u32 << self.f_bits_per_sample) - 1);
+        v_scale = 255;
+        if v_max > 0 {
+            v_scale = (255 / v_max);
+        }
+        v_i = 0;
+        v_g = 0;
+        while v_i <= v_max {
+            v_g = v_i;
+            if self.f_photometric == PHOTOMETRIC_WHITE_IS_ZERO {
+                v_g = u32::saturating_sub(v_max, v_i);
+            }
+            v_g = ((v_g * v_scale) & 255);
+            (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 0) as usize) }) = (v_g as u8);
+            (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 1) as usize) }) = (v_g as u8);
+            (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 2) as usize) }) = (v_g as u8);
+            (*unsafe { self.f_palette.get_unchecked_mut(((4 * v_i) + 3) as usize) }) = 255;
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func decoder.read_u16
+
+impl Decoder {
+    fn read_u16(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut t_0: u16 = 0;
+        let mut t_1: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_u16.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || (1 <= r && r <= 2) {
+                    if r == 1 || (r == 0 && self.f_big_endian) {
+                        if r == 0 || r == 1 {
+                            if r == 0 {
+                                self.c_read_u16.scratch = 0;
+                            }
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u16be(&mut t_0, &mut self.c_read_u16.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_value = (t_0 as u32);
+                        }
+                    } else {
+                        if r == 0 || r == 2 {
+                            if r == 0 {
+                                self.c_read_u16.scratch = 0;
+                            }
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u16le(&mut t_1, &mut self.c_read_u16.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_value = (t_1 as u32);
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_pos = u64::saturating_add(self.f_pos, 2);
+                }
+                self.c_read_u16.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_u16.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.read_u32
+
+impl Decoder {
+    fn read_u32(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_u32.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || (1 <= r && r <= 2) {
+                    if r == 1 || (r == 0 && self.f_big_endian) {
+                        if r == 0 || r == 1 {
+                            if r == 0 {
+                                self.c_read_u32.scratch = 0;
+                            }
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u32be(&mut t_0, &mut self.c_read_u32.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_value = t_0;
+                        }
+                    } else {
+                        if r == 0 || r == 2 {
+                            if r == 0 {
+                                self.c_read_u32.scratch = 0;
+                            }
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u32le(&mut t_1, &mut self.c_read_u32.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_value = t_1;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_pos = u64::saturating_add(self.f_pos, 4);
+                }
+                self.c_read_u32.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_u32.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.seek
+
+impl Decoder {
+    fn seek(&mut self, mut a_src: wuffs_base::IoReader, mut a_pos: u64) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_seek.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || (1 <= r && r <= 2) {
+                    if r == 1 || (r == 0 && (a_pos >= self.f_pos)) {
+                        if r == 0 || r == 1 {
+                            if r == 0 {
+                                self.c_seek.scratch = ((a_pos - self.f_pos) as u64);
+                            }
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.skip(&mut self.c_seek.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 {
+                            self.f_seek_pos = a_pos;
+                        }
+                        if r == 0 || r == 2 {
+                            status = SUSPENSION_SEEK_REQUIRED;
+                            if r == 2 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                csp = 2;
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_pos = a_pos;
+                }
+                self.c_seek.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_seek.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_dirty_rect: wuffs_base::RectIeU32 = wuffs_base::RectIeU32::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_dirty_rect = self.c_decode_frame.v_dirty_rect;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 0 && (self.f_call_sequence == 0) {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    } else if r == 1 || (r == 0 && (self.f_call_sequence == 2)) {
+                        if r == 0 || r == 1 {
+                            loop {
+                                if r == 0 || r == 1 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 1 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    if (a_workbuf.len() as u64) < ((self.f_num_chunks as u64) * 8) {
+                        status = ERROR_BAD_WORKBUF_LENGTH;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.read_array(a_src, a_workbuf, WHICH_OFFSETS);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = self.read_array(a_src, a_workbuf, WHICH_BYTE_COUNTS);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    while r != 0 || (self.f_chunk_index < self.f_num_chunks) {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.decode_chunk(a_dst, a_src, a_workbuf);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_chunk_index = u32::saturating_add(self.f_chunk_index, 1);
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_palette = wuffs_base::SliceU8::default();
+                    v_dirty_rect = wuffs_base::RectIeU32::default();
+                    v_dirty_rect.set_max_exclusive_x(self.f_width);
+                    v_dirty_rect.set_max_exclusive_y(self.f_height);
+                    a_dst.update(v_dirty_rect, 0, false, 0, v_palette);
+                    self.f_call_sequence = 2;
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+            self.c_decode_frame.v_dirty_rect = v_dirty_rect;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_chunk
+
+impl Decoder {
+    fn decode_chunk(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        let mut v_c: u32 = 0;
+        let mut v_offset: u32 = 0;
+        let mut v_x0: u64 = 0;
+        let mut v_y0: u64 = 0;
+        let mut v_y: u64 = 0;
+        let mut v_y_end: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_chunk.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_chunk.v_c;
+            v_offset = self.c_decode_chunk.v_offset;
+            v_x0 = self.c_decode_chunk.v_x0;
+            v_y0 = self.c_decode_chunk.v_y0;
+            v_y = self.c_decode_chunk.v_y;
+            v_y_end = self.c_decode_chunk.v_y_end;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = self.f_chunk_index;
+                    v_offset = self.chunk_value(a_workbuf, WHICH_OFFSETS, v_c);
+                }
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.seek(a_src, (v_offset as u64));
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_chunk_remaining =
+                        (self.chunk_value(a_workbuf, WHICH_BYTE_COUNTS, v_c) as u64);
+                    v_x0 = 0;
+                    v_y0 = 0;
+                    if self.f_chunks_across > 0 {
+                        v_x0 =
+                            (((v_c % self.f_chunks_across) as u64) * (self.f_chunk_width as u64));
+                        v_y0 =
+                            (((v_c / self.f_chunks_across) as u64) * (self.f_chunk_height as u64));
+                    }
+                    if self.f_compression == COMPRESSION_LZW {
+                        self.f_lzw = lzw::Decoder::default();
+                        self.f_lzw.set_tiff_mode(true);
+                    } else if self.f_compression == COMPRESSION_DEFLATE {
+                        self.f_zlib = zlib::Decoder::default();
+                    }
+                    self.f_packbits_literal = 0;
+                    self.f_packbits_run = 0;
+                    v_y = v_y0;
+                    v_y_end = (v_y0 + (self.f_chunk_height as u64));
+                    if v_y_end > (self.f_height as u64) {
+                        v_y_end = (self.f_height as u64);
+                    }
+                }
+                if r == 0 || r == 2 {
+                    while r != 0 || (v_y < v_y_end) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.fill_row(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            if self.f_predictor {
+                                self.unpredict_row();
+                            }
+                            self.swizzle_row(a_dst, v_x0, v_y);
+                            v_y += 1;
+                        }
+                    }
+                }
+                self.c_decode_chunk.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_chunk.coro_susp_point = csp;
+            self.c_decode_chunk.v_c = v_c;
+            self.c_decode_chunk.v_offset = v_offset;
+            self.c_decode_chunk.v_x0 = v_x0;
+            self.c_decode_chunk.v_y0 = v_y0;
+            self.c_decode_chunk.v_y = v_y;
+            self.c_decode_chunk.v_y_end = v_y_end;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.set_chunk_value
+
+impl Decoder {
+    fn set_chunk_value(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_i: u32,
+        mut a_v: u32,
+    ) {
+        let mut v_o: u64 = 0;
+        let mut v_b: [u8; 4] = [0; 4];
+
+        v_o = (((a_i as u64) * 8) + ((a_which * 4) as u64));
+        v_b = [0; 4];
+        (*unsafe { v_b.get_unchecked_mut(0 as usize) }) = (((a_v >> 0) & 255) as u8);
+        (*unsafe { v_b.get_unchecked_mut(1 as usize) }) = (((a_v >> 8) & 255) as u8);
+        (*unsafe { v_b.get_unchecked_mut(2 as usize) }) = (((a_v >> 16) & 255) as u8);
+        (*unsafe { v_b.get_unchecked_mut(3 as usize) }) = (((a_v >> 24) & 255) as u8);
+        if v_o < (a_workbuf.len() as u64) {
+            unsafe { a_workbuf.subslice_i(v_o as usize) }
+                .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_b) });
+        }
+    }
+}
+
+// -------- func decoder.chunk_value
+
+impl Decoder {
+    fn chunk_value(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_i: u32,
+    ) -> u32 {
+        let mut v_o: u64 = 0;
+        let mut v_b: [u8; 4] = [0; 4];
+
+        v_o = (((a_i as u64) * 8) + ((a_which * 4) as u64));
+        v_b = [0; 4];
+        if v_o < (a_workbuf.len() as u64) {
+            unsafe { wuffs_base::SliceU8::from_array(&mut v_b) }
+                .copy_from_slice(unsafe { a_workbuf.subslice_i(v_o as usize) });
+        }
+        return ((((*unsafe { v_b.get_unchecked(0 as usize) }) as u32) << 0)
+            | (((*unsafe { v_b.get_unchecked(1 as usize) }) as u32) << 8)
+            | (((*unsafe { v_b.get_unchecked(2 as usize) }) as u32) << 16)
+            | (((*unsafe { v_b.get_unchecked(3 as usize) }) as u32) << 24));
+    }
+}
+
+// -------- func decoder.fill_row
+
+impl Decoder {
+    fn fill_row(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_row.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_row_wi = 0;
+                }
+                if r == 0 || (1 <= r && r <= 3) {
+                    if r == 1 || (r == 0 && (self.f_compression == COMPRESSION_NONE)) {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.fill_row_none(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else if r == 2 || (r == 0 && (self.f_compression == COMPRESSION_PACKBITS)) {
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.fill_row_packbits(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.fill_row_lzw_or_deflate(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                self.c_fill_row.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_row.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.fill_row_none
+
+impl Decoder {
+    fn fill_row_none(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_n: u64 = 0;
+        let mut v_c: u8 = 0;
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_row_none.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_n = self.c_fill_row_none.v_n;
+            v_c = self.c_fill_row_none.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_n = 0;
+                }
+                if r == 0 || r == 1 {
+                    'label_0: while r != 0 || (self.f_row_wi < self.f_row_length) {
+                        if r == 0 {
+                            if self.f_chunk_remaining == 0 {
+                                status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 || (r == 0 && (a_src.available() == 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_0;
+                                }
+                                if r == 0 {
+                                    self.f_chunk_remaining =
+                                        u64::saturating_sub(self.f_chunk_remaining, 1);
+                                    self.f_pos = u64::saturating_add(self.f_pos, 1);
+                                    (*unsafe {
+                                        self.f_row.get_unchecked_mut(self.f_row_wi as usize)
+                                    }) = v_c;
+                                    self.f_row_wi += 1;
+                                    continue 'label_0;
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            v_w = wuffs_base::IoWriter::default();
+                            {
+                                let o_0_a_src = a_src;
+                                let o_0_v_w = v_w;
+                                unsafe {
+                                    v_w.set(&mut u_w, unsafe {
+                                        wuffs_base::SliceU8::from_array(&mut self.f_row)
+                                            .subslice_ij(
+                                                self.f_row_wi as usize,
+                                                self.f_row_length as usize,
+                                            )
+                                    })
+                                };
+                                a_src.set_limit(self.f_chunk_remaining);
+                                a_src.set_mark();
+                                v_w.copy_from_reader32(&mut a_src, 32768);
+                                v_n = (a_src.since_mark().len() as u64);
+                                self.f_chunk_remaining =
+                                    u64::saturating_sub(self.f_chunk_remaining, v_n);
+                                self.f_pos = u64::saturating_add(self.f_pos, v_n);
+                                self.f_row_wi = u32::saturating_sub(
+                                    self.f_row_length,
+                                    (u64::min(v_w.available(), 32768) as u32),
+                                );
+                                v_w = o_0_v_w;
+                                a_src = o_0_a_src;
+                            }
+                        }
+                    }
+                }
+                self.c_fill_row_none.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_row_none.coro_susp_point = csp;
+            self.c_fill_row_none.v_n = v_n;
+            self.c_fill_row_none.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.fill_row_packbits
+
+impl Decoder {
+    fn fill_row_packbits(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_row_packbits.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_fill_row_packbits.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = 0;
+                }
+                if r == 0 || (1 <= r && r <= 2) {
+                    'label_0: while r != 0 || (self.f_row_wi < self.f_row_length) {
+                        if r == 0 {
+                            if self.f_packbits_run > 0 {
+                                (*unsafe {
+                                    self.f_row.get_unchecked_mut(self.f_row_wi as usize)
+                                }) = self.f_packbits_byte;
+                                self.f_row_wi += 1;
+                                self.f_packbits_run -= 1;
+                                continue 'label_0;
+                            }
+                            if self.f_chunk_remaining == 0 {
+                                status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = a_src.read_u8(&mut t_0);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_c = t_0;
+                        }
+                        if r == 0 {
+                            self.f_chunk_remaining = u64::saturating_sub(self.f_chunk_remaining, 1);
+                            self.f_pos = u64::saturating_add(self.f_pos, 1);
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 0 && (self.f_packbits_literal > 0) {
+                                (*unsafe {
+                                    self.f_row.get_unchecked_mut(self.f_row_wi as usize)
+                                }) = v_c;
+                                self.f_row_wi += 1;
+                                self.f_packbits_literal -= 1;
+                            } else if r == 0 && (v_c < 128) {
+                                self.f_packbits_literal = ((v_c as u32) + 1);
+                            } else if r == 2 || (r == 0 && (v_c > 128)) {
+                                if r == 0 {
+                                    self.f_packbits_run = (257 - (v_c as u32));
+                                    if self.f_chunk_remaining == 0 {
+                                        status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                        break 'exit;
+                                    }
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = a_src.read_u8(&mut t_1);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    self.f_packbits_byte = t_1;
+                                }
+                                if r == 0 {
+                                    self.f_chunk_remaining =
+                                        u64::saturating_sub(self.f_chunk_remaining, 1);
+                                    self.f_pos = u64::saturating_add(self.f_pos, 1);
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_fill_row_packbits.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_row_packbits.coro_susp_point = csp;
+            self.c_fill_row_packbits.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.fill_row_lzw_or_deflate
+
+impl Decoder {
+    fn fill_row_lzw_or_deflate(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut v_n: u64 = 0;
+        let mut v_w: wuffs_base::IoWriter = wuffs_base::IoWriter::default();
+        let mut u_w = wuffs_base::IoBuffer::default();
+        let mut v_lzw_status: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut v_zlib_status: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_1: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_row_lzw_or_deflate.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_z = self.c_fill_row_lzw_or_deflate.v_z;
+            v_n = self.c_fill_row_lzw_or_deflate.v_n;
+            v_lzw_status = self.c_fill_row_lzw_or_deflate.v_lzw_status;
+            v_zlib_status = self.c_fill_row_lzw_or_deflate.v_zlib_status;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        v_z = wuffs_base::Status::Ok;
+                        v_n = 0;
+                    }
+                    if r == 0 || r == 1 {
+                        'label_0: loop {
+                            if r == 0 {
+                                if self.f_row_wi >= self.f_row_length {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                }
+                                v_w = wuffs_base::IoWriter::default();
+                                {
+                                    let o_0_a_src = a_src;
+                                    let o_0_v_w = v_w;
+                                    unsafe {
+                                        v_w.set(&mut u_w, unsafe {
+                                            wuffs_base::SliceU8::from_array(&mut self.f_row)
+                                                .subslice_ij(
+                                                    self.f_row_wi as usize,
+                                                    self.f_row_length as usize,
+                                                )
+                                        })
+                                    };
+                                    a_src.set_limit(self.f_chunk_remaining);
+                                    a_src.set_mark();
+                                    if self.f_compression == COMPRESSION_LZW {
+                                        t_0 = self.f_lzw.decode(v_w, a_src);
+                                        v_lzw_status = t_0;
+                                        v_z = v_lzw_status;
+                                    } else {
+                                        t_1 = self.f_zlib.decode(v_w, a_src);
+                                        v_zlib_status = t_1;
+                                        v_z = v_zlib_status;
+                                    }
+                                    v_n = (a_src.since_mark().len() as u64);
+                                    self.f_chunk_remaining =
+                                        u64::saturating_sub(self.f_chunk_remaining, v_n);
+                                    self.f_pos = u64::saturating_add(self.f_pos, v_n);
+                                    self.f_row_wi = u32::saturating_sub(
+                                        self.f_row_length,
+                                        (u64::min(v_w.available(), 32768) as u32),
+                                    );
+                                    v_w = o_0_v_w;
+                                    a_src = o_0_a_src;
+                                }
+                                if self.f_row_wi >= self.f_row_length {
+                                    status = wuffs_base::Status::Ok;
+                                    break 'ok;
+                                } else if v_z.is_ok()
+                                    || ((self.f_chunk_remaining == 0)
+                                        && (v_z == wuffs_base::SUSPENSION_SHORT_READ))
+                                {
+                                    status = ERROR_NOT_ENOUGH_PIXEL_DATA;
+                                    break 'exit;
+                                } else if v_z == wuffs_base::SUSPENSION_SHORT_WRITE {
+                                    continue 'label_0;
+                                }
+                            }
+                            if r == 0 || r == 1 {
+                                status = v_z;
+                                if r == 1 {
+                                    r = 0;
+                                    status = wuffs_base::Status::Ok;
+                                } else {
+                                    if status.is_error() {
+                                        break 'exit;
+                                    }
+                                    if !status.is_ok() {
+                                        csp = 1;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                self.c_fill_row_lzw_or_deflate.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_row_lzw_or_deflate.coro_susp_point = csp;
+            self.c_fill_row_lzw_or_deflate.v_z = v_z;
+            self.c_fill_row_lzw_or_deflate.v_n = v_n;
+            self.c_fill_row_lzw_or_deflate.v_lzw_status = v_lzw_status;
+            self.c_fill_row_lzw_or_deflate.v_zlib_status = v_zlib_status;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.unpredict_row
+
+impl Decoder {
+    fn unpredict_row(&mut self) {
+        let mut v_spp: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_i: u32 = 0;
+
+        v_spp = self.f_samples_per_pixel;
+        v_n = u32::saturating_sub(self.f_row_length, v_spp);
+        v_i = 0;
+        while v_i < v_n {
+            (*unsafe { self.f_row.get_unchecked_mut((v_i + v_spp) as usize) }) = u8::wrapping_add(
+                (*unsafe { self.f_row.get_unchecked((v_i + v_spp) as usize) }),
+                (*unsafe { self.f_row.get_unchecked(v_i as usize) }),
+            );
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func decoder.swizzle_row
+
+impl Decoder {
+    fn swizzle_row(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_x0: u64,
+        mut a_y: u64,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_y: u32 = 0;
+        let mut v_dst: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_n: u32 = 0;
+        let mut v_spp: u32 = 0;
+        let mut v_bps: u32 = 0;
+        let mut v_mask: u32 = 0;
+        let mut v_shift: u32 = 0;
+        let mut v_o: u32 = 0;
+        let mut v_x: u64 = 0;
+        let mut v_x_end: u64 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_a: u32 = 0;
+        let mut v_pixel: [u8; 4] = [0; 4];
+        let mut v_x4: u64 = 0;
+
+        v_tab = a_dst.plane(0);
+        if a_y >= (self.f_height as u64) {
+            return;
+        } else if a_y >= 2147483647 {
+            return;
+        }
+        v_y = (a_y as u32);
+        v_dst = v_tab.row(v_y);
+        v_n = self.f_row_length;
+        v_spp = self.f_samples_per_pixel;
+        v_bps = self.f_bits_per_sample;
+        v_mask = ((1u32 << v_bps) - 1);
+        v_shift = (8 - v_bps);
+        v_o = 0;
+        v_x = a_x0;
+        v_x_end = (a_x0 + (self.f_chunk_width as u64));
+        v_p = 0;
+        v_a = 0;
+        v_pixel = [0; 4];
+        v_x4 = 0;
+        if v_x_end > (self.f_width as u64) {
+            v_x_end = (self.f_width as u64);
+        }
+        while (v_x < v_x_end) && (v_o < v_n) {
+            if self.f_photometric == PHOTOMETRIC_RGB {
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 2) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { self.f_row.get_unchecked((v_o + 0) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = 255;
+                if self.f_alpha != 0 {
+                    v_a = ((*unsafe { self.f_row.get_unchecked((v_o + 3) as usize) }) as u32);
+                    (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = (v_a as u8);
+                    if self.f_alpha == EXTRA_SAMPLES_ASSOCIATED_ALPHA {
+                        (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) = self.unpremultiply(
+                            ((*unsafe { self.f_row.get_unchecked((v_o + 2) as usize) }) as u32),
+                            v_a,
+                        );
+                        (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) = self.unpremultiply(
+                            ((*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) }) as u32),
+                            v_a,
+                        );
+                        (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) = self.unpremultiply(
+                            ((*unsafe { self.f_row.get_unchecked((v_o + 0) as usize) }) as u32),
+                            v_a,
+                        );
+                    }
+                }
+                v_o += v_spp;
+            } else {
+                v_p = ((((*unsafe { self.f_row.get_unchecked(v_o as usize) }) as u32) >> v_shift)
+                    & v_mask);
+                (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 0) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 1) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 2) as usize) });
+                (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                    (*unsafe { self.f_palette.get_unchecked(((4 * v_p) + 3) as usize) });
+                if v_bps == 8 {
+                    if self.f_alpha != 0 {
+                        (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) =
+                            (*unsafe { self.f_row.get_unchecked((v_o + 1) as usize) });
+                    }
+                    v_o += v_spp;
+                } else if v_shift >= v_bps {
+                    v_shift -= v_bps;
+                } else {
+                    v_shift = (8 - v_bps);
+                    v_o += 1;
+                }
+            }
+            v_x4 = (u64::min(v_x, 2147483647) * 4);
+            if v_x4 < (v_dst.len() as u64) {
+                unsafe { v_dst.subslice_i(v_x4 as usize) }
+                    .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) });
+            }
+            v_x += 1;
+        }
+    }
+}
+
+// -------- func decoder.unpremultiply
+
+impl Decoder {
+    fn unpremultiply(&mut self, mut a_v: u32, mut a_a: u32) -> u8 {
+        let mut v_x: u32 = 0;
+
+        if a_a == 0 {
+            return 0;
+        }
+        v_x = (((a_v * 255) + (a_a / 2)) / a_a);
+        if v_x > 255 {
+            return 255;
+        }
+        return (v_x as u8);
+    }
+}
@@ -0,0 +1,3761 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package webp is the Rust translation of the Wuffs std/webp package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad Huffman code (over-subscribed)");
+pub const ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad Huffman code (under-subscribed)");
+pub const ERROR_BAD_HUFFMAN_CODE: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad Huffman code");
+pub const ERROR_BAD_BACK_REFERENCE: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad back-reference");
+pub const ERROR_BAD_COLOR_CACHE_SIZE: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad color cache size");
+pub const ERROR_BAD_HEADER: wuffs_base::Status = wuffs_base::Status::Error("webp: bad header");
+pub const ERROR_BAD_TRANSFORM: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad transform");
+pub const ERROR_BAD_WORKBUF_LENGTH: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: bad workbuf length");
+pub const ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: TODO: unsupported lossy image");
+pub const ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE: wuffs_base::Status =
+    wuffs_base::Status::Error("webp: internal error: inconsistent Huffman decoder state");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a webp.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_width: u32,
+    f_height: u32,
+    f_call_sequence: u8,
+    f_end_of_data: bool,
+    f_is_animated: bool,
+    f_num_loops: u32,
+    f_riff_length: u32,
+    f_chunk_type: u32,
+    f_chunk_length: u32,
+    f_anmf_length: u32,
+    f_frame_rect: wuffs_base::RectIeU32,
+    f_frame_x0: u32,
+    f_frame_y0: u32,
+    f_frame_duration: u64,
+    f_frame_blend: bool,
+    f_frame_disposal: u8,
+    f_vp8l_width: u32,
+    f_vp8l_height: u32,
+    f_bits: u64,
+    f_n_bits: u32,
+    f_num_transforms: u32,
+    f_seen_transforms: u32,
+    f_transform_types: [u32; 4],
+    f_transform_widths: [u32; 4],
+    f_predictor_bits: u32,
+    f_predictor_width: u32,
+    f_color_bits: u32,
+    f_color_width: u32,
+    f_palette_width_bits: u32,
+    f_meta_bits: u32,
+    f_meta_width: u32,
+    f_cache_bits: u32,
+    f_huff_table: [u8; 5232],
+    f_max_groups: u32,
+    f_group_map: [u16; 65536],
+    f_code_lengths: [u8; 2328],
+    f_color_cache: [u32; 2048],
+    f_sub_image_max_pixels: u32,
+    f_palette: [u32; 256],
+    c_decode_config: DecoderDecodeConfigCoro,
+    c_decode_frame: DecoderDecodeFrameCoro,
+    c_next_chunk: DecoderNextChunkCoro,
+    c_decode_anmf_header: DecoderDecodeAnmfHeaderCoro,
+    c_decode_vp8l_header: DecoderDecodeVp8lHeaderCoro,
+    c_decode_vp8l: DecoderDecodeVp8lCoro,
+    c_decode_frame_image: DecoderDecodeFrameImageCoro,
+    c_decode_sub_image: DecoderDecodeSubImageCoro,
+    c_read_color_cache_bits: DecoderReadColorCacheBitsCoro,
+    c_read_huffman_groups: DecoderReadHuffmanGroupsCoro,
+    c_read_huffman_code: DecoderReadHuffmanCodeCoro,
+    c_fill_bits: DecoderFillBitsCoro,
+    c_decode_pixels: DecoderDecodePixelsCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_width: 0,
+            f_height: 0,
+            f_call_sequence: 0,
+            f_end_of_data: false,
+            f_is_animated: false,
+            f_num_loops: 0,
+            f_riff_length: 0,
+            f_chunk_type: 0,
+            f_chunk_length: 0,
+            f_anmf_length: 0,
+            f_frame_rect: wuffs_base::RectIeU32::default(),
+            f_frame_x0: 0,
+            f_frame_y0: 0,
+            f_frame_duration: 0,
+            f_frame_blend: false,
+            f_frame_disposal: 0,
+            f_vp8l_width: 0,
+            f_vp8l_height: 0,
+            f_bits: 0,
+            f_n_bits: 0,
+            f_num_transforms: 0,
+            f_seen_transforms: 0,
+            f_transform_types: [0; 4],
+            f_transform_widths: [0; 4],
+            f_predictor_bits: 0,
+            f_predictor_width: 0,
+            f_color_bits: 0,
+            f_color_width: 0,
+            f_palette_width_bits: 0,
+            f_meta_bits: 0,
+            f_meta_width: 0,
+            f_cache_bits: 0,
+            f_huff_table: [0; 5232],
+            f_max_groups: 0,
+            f_group_map: [0; 65536],
+            f_code_lengths: [0; 2328],
+            f_color_cache: [0; 2048],
+            f_sub_image_max_pixels: 0,
+            f_palette: [0; 256],
+            c_decode_config: DecoderDecodeConfigCoro::default(),
+            c_decode_frame: DecoderDecodeFrameCoro::default(),
+            c_next_chunk: DecoderNextChunkCoro::default(),
+            c_decode_anmf_header: DecoderDecodeAnmfHeaderCoro::default(),
+            c_decode_vp8l_header: DecoderDecodeVp8lHeaderCoro::default(),
+            c_decode_vp8l: DecoderDecodeVp8lCoro::default(),
+            c_decode_frame_image: DecoderDecodeFrameImageCoro::default(),
+            c_decode_sub_image: DecoderDecodeSubImageCoro::default(),
+            c_read_color_cache_bits: DecoderReadColorCacheBitsCoro::default(),
+            c_read_huffman_groups: DecoderReadHuffmanGroupsCoro::default(),
+            c_read_huffman_code: DecoderReadHuffmanCodeCoro::default(),
+            c_fill_bits: DecoderFillBitsCoro::default(),
+            c_decode_pixels: DecoderDecodePixelsCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeConfigCoro {
+    coro_susp_point: u32,
+    v_riff_length: u32,
+    v_max_width: u32,
+    v_max_height: u32,
+    v_sub_width: u32,
+    v_sub_height: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeConfigCoro {
+    fn default() -> Self {
+        DecoderDecodeConfigCoro {
+            coro_susp_point: 0,
+            v_riff_length: 0,
+            v_max_width: 0,
+            v_max_height: 0,
+            v_sub_width: 0,
+            v_sub_height: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameCoro {
+    coro_susp_point: u32,
+    v_workbuf_len: u64,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeFrameCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameCoro {
+            coro_susp_point: 0,
+            v_workbuf_len: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderNextChunkCoro {
+    coro_susp_point: u32,
+    v_length: u32,
+    v_padded_length: u64,
+    scratch: u64,
+}
+
+impl Default for DecoderNextChunkCoro {
+    fn default() -> Self {
+        DecoderNextChunkCoro {
+            coro_susp_point: 0,
+            v_length: 0,
+            v_padded_length: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeAnmfHeaderCoro {
+    coro_susp_point: u32,
+    v_x0: u32,
+    v_y0: u32,
+    v_w: u32,
+    v_h: u32,
+    v_x1: u32,
+    v_y1: u32,
+    v_flags: u8,
+    v_chunk_type: u32,
+    v_length: u32,
+    v_padded_length: u64,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeAnmfHeaderCoro {
+    fn default() -> Self {
+        DecoderDecodeAnmfHeaderCoro {
+            coro_susp_point: 0,
+            v_x0: 0,
+            v_y0: 0,
+            v_w: 0,
+            v_h: 0,
+            v_x1: 0,
+            v_y1: 0,
+            v_flags: 0,
+            v_chunk_type: 0,
+            v_length: 0,
+            v_padded_length: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeVp8lHeaderCoro {
+    coro_susp_point: u32,
+    v_c: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeVp8lHeaderCoro {
+    fn default() -> Self {
+        DecoderDecodeVp8lHeaderCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+            scratch: 0,
+        }
+    }
+}
+
+struct DecoderDecodeVp8lCoro {
+    coro_susp_point: u32,
+    v_width: u32,
+    v_height: u32,
+    v_t: u32,
+    v_b: u32,
+    v_w: u32,
+    v_h: u32,
+    v_num_colors: u32,
+    v_i: u32,
+}
+
+impl Default for DecoderDecodeVp8lCoro {
+    fn default() -> Self {
+        DecoderDecodeVp8lCoro {
+            coro_susp_point: 0,
+            v_width: 0,
+            v_height: 0,
+            v_t: 0,
+            v_b: 0,
+            v_w: 0,
+            v_h: 0,
+            v_num_colors: 0,
+            v_i: 0,
+        }
+    }
+}
+
+struct DecoderDecodeFrameImageCoro {
+    coro_susp_point: u32,
+    v_cache_bits: u32,
+    v_num_groups: u32,
+    v_b: u32,
+    v_w: u32,
+    v_h: u32,
+    v_i: u32,
+    v_n: u32,
+    v_g: u32,
+}
+
+impl Default for DecoderDecodeFrameImageCoro {
+    fn default() -> Self {
+        DecoderDecodeFrameImageCoro {
+            coro_susp_point: 0,
+            v_cache_bits: 0,
+            v_num_groups: 0,
+            v_b: 0,
+            v_w: 0,
+            v_h: 0,
+            v_i: 0,
+            v_n: 0,
+            v_g: 0,
+        }
+    }
+}
+
+struct DecoderDecodeSubImageCoro {
+    coro_susp_point: u32,
+}
+
+impl Default for DecoderDecodeSubImageCoro {
+    fn default() -> Self {
+        DecoderDecodeSubImageCoro { coro_susp_point: 0 }
+    }
+}
+
+struct DecoderReadColorCacheBitsCoro {
+    coro_susp_point: u32,
+    v_c: u32,
+}
+
+impl Default for DecoderReadColorCacheBitsCoro {
+    fn default() -> Self {
+        DecoderReadColorCacheBitsCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderReadHuffmanGroupsCoro {
+    coro_susp_point: u32,
+    v_num_green_symbols: u32,
+    v_g: u32,
+    v_next: u32,
+    v_position: u32,
+    v_offset: u64,
+}
+
+impl Default for DecoderReadHuffmanGroupsCoro {
+    fn default() -> Self {
+        DecoderReadHuffmanGroupsCoro {
+            coro_susp_point: 0,
+            v_num_green_symbols: 0,
+            v_g: 0,
+            v_next: 0,
+            v_position: 0,
+            v_offset: 0,
+        }
+    }
+}
+
+struct DecoderReadHuffmanCodeCoro {
+    coro_susp_point: u32,
+    v_i: u32,
+    v_num_symbols: u32,
+    v_simple_symbol: u32,
+    v_num_code_lengths: u32,
+    v_code_length_t: u64,
+    v_max_symbol: u32,
+    v_length_n_bits: u32,
+    v_prev_code_length: u8,
+    v_symbol: u32,
+    v_code: u32,
+    v_repeat: u32,
+    v_value: u8,
+}
+
+impl Default for DecoderReadHuffmanCodeCoro {
+    fn default() -> Self {
+        DecoderReadHuffmanCodeCoro {
+            coro_susp_point: 0,
+            v_i: 0,
+            v_num_symbols: 0,
+            v_simple_symbol: 0,
+            v_num_code_lengths: 0,
+            v_code_length_t: 0,
+            v_max_symbol: 0,
+            v_length_n_bits: 0,
+            v_prev_code_length: 0,
+            v_symbol: 0,
+            v_code: 0,
+            v_repeat: 0,
+            v_value: 0,
+        }
+    }
+}
+
+struct DecoderFillBitsCoro {
+    coro_susp_point: u32,
+    v_c: u8,
+}
+
+impl Default for DecoderFillBitsCoro {
+    fn default() -> Self {
+        DecoderFillBitsCoro {
+            coro_susp_point: 0,
+            v_c: 0,
+        }
+    }
+}
+
+struct DecoderDecodePixelsCoro {
+    coro_susp_point: u32,
+    v_num_pixels: u32,
+    v_p: u32,
+    v_x: u32,
+    v_y: u32,
+    v_group: u64,
+    v_code: u32,
+    v_argb: u32,
+    v_length: u32,
+    v_dist_code: u32,
+    v_dist: u32,
+    v_q: u32,
+    v_qx: u32,
+    v_qy: u32,
+    v_i: u32,
+    v_num_cache_codes: u32,
+}
+
+impl Default for DecoderDecodePixelsCoro {
+    fn default() -> Self {
+        DecoderDecodePixelsCoro {
+            coro_susp_point: 0,
+            v_num_pixels: 0,
+            v_p: 0,
+            v_x: 0,
+            v_y: 0,
+            v_group: 0,
+            v_code: 0,
+            v_argb: 0,
+            v_length: 0,
+            v_dist_code: 0,
+            v_dist: 0,
+            v_q: 0,
+            v_qx: 0,
+            v_qy: 0,
+            v_i: 0,
+            v_num_cache_codes: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+const FOURCC_RIFF: u32 = 0x46464952;
+
+const FOURCC_WEBP: u32 = 0x50424557;
+
+const FOURCC_ALPH: u32 = 0x48504C41;
+
+const FOURCC_ANIM: u32 = 0x4D494E41;
+
+const FOURCC_ANMF: u32 = 0x464D4E41;
+
+const FOURCC_VP8: u32 = 0x20385056;
+
+const FOURCC_VP8L: u32 = 0x4C385056;
+
+const FOURCC_VP8X: u32 = 0x58385056;
+
+const TRANSFORM_PREDICTOR: u32 = 0x0;
+
+const TRANSFORM_COLOR: u32 = 0x1;
+
+const TRANSFORM_SUBTRACT_GREEN: u32 = 0x2;
+
+const TRANSFORM_COLOR_INDEXING: u32 = 0x3;
+
+const WHICH_FRAME: u32 = 0x0;
+
+const WHICH_PREDICTOR: u32 = 0x1;
+
+const WHICH_COLOR: u32 = 0x2;
+
+const WHICH_META: u32 = 0x3;
+
+const WHICH_PALETTE: u32 = 0x4;
+
+const TABLE_HEADER_SIZE: u64 = 0x240;
+
+const TABLE_OFFSET_RED: u64 = 0x1470;
+
+const TABLE_OFFSET_BLUE: u64 = 0x18B0;
+
+const TABLE_OFFSET_ALPHA: u64 = 0x1CF0;
+
+const TABLE_OFFSET_DISTANCE: u64 = 0x2130;
+
+const GROUP_SIZE: u64 = 0x23C0;
+
+const FLICKS_PER_MILLISECOND: u64 = 0xAC440;
+
+static CODE_LENGTH_CODE_ORDER: [u8; 19] = [
+    0x11, 0x12, 0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x10, 0x6, 0x7, 0x8, 0x9, 0xA, 0xB, 0xC, 0xD, 0xE,
+    0xF,
+];
+
+static DISTANCE_MAP: [u8; 120] = [
+    0x18, 0x7, 0x17, 0x19, 0x28, 0x6, 0x27, 0x29, 0x16, 0x1A, 0x26, 0x2A, 0x38, 0x5, 0x37, 0x39,
+    0x15, 0x1B, 0x36, 0x3A, 0x25, 0x2B, 0x48, 0x4, 0x47, 0x49, 0x14, 0x1C, 0x35, 0x3B, 0x46, 0x4A,
+    0x24, 0x2C, 0x58, 0x45, 0x4B, 0x34, 0x3C, 0x3, 0x57, 0x59, 0x13, 0x1D, 0x56, 0x5A, 0x23, 0x2D,
+    0x44, 0x4C, 0x55, 0x5B, 0x33, 0x3D, 0x68, 0x2, 0x67, 0x69, 0x12, 0x1E, 0x66, 0x6A, 0x22, 0x2E,
+    0x54, 0x5C, 0x43, 0x4D, 0x65, 0x6B, 0x32, 0x3E, 0x78, 0x1, 0x77, 0x79, 0x53, 0x5D, 0x11, 0x1F,
+    0x64, 0x6C, 0x42, 0x4E, 0x76, 0x7A, 0x21, 0x2F, 0x75, 0x7B, 0x31, 0x3F, 0x63, 0x6D, 0x52, 0x5E,
+    0x0, 0x74, 0x7C, 0x41, 0x4F, 0x10, 0x20, 0x62, 0x6E, 0x30, 0x73, 0x7D, 0x51, 0x5F, 0x40, 0x72,
+    0x7E, 0x61, 0x6F, 0x50, 0x71, 0x7F, 0x60, 0x70,
+];
+
+// ---------------- Function Implementations
+
+// -------- func decoder.workbuf_len
+
+impl Decoder {
+    pub fn workbuf_len(&mut self) -> u64 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return (self.code_length_table_offset() + TABLE_HEADER_SIZE);
+    }
+}
+
+// -------- func decoder.groups_offset
+
+impl Decoder {
+    fn groups_offset(&mut self) -> u64 {
+        return ((self.f_sub_image_max_pixels as u64) * 12);
+    }
+}
+
+// -------- func decoder.code_length_table_offset
+
+impl Decoder {
+    fn code_length_table_offset(&mut self) -> u64 {
+        return (self.groups_offset() + (((self.f_max_groups + 1) as u64) * GROUP_SIZE));
+    }
+}
+
+// -------- func decoder.decode_config
+
+impl Decoder {
+    pub fn decode_config(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageConfig,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_riff_length: u32 = 0;
+        let mut v_max_width: u32 = 0;
+        let mut v_max_height: u32 = 0;
+        let mut v_sub_width: u32 = 0;
+        let mut v_sub_height: u32 = 0;
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut t_2: u32 = 0;
+        let mut t_3: u32 = 0;
+        let mut t_4: u32 = 0;
+        let mut t_5: u16 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_config.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_riff_length = self.c_decode_config.v_riff_length;
+            v_max_width = self.c_decode_config.v_max_width;
+            v_max_height = self.c_decode_config.v_max_height;
+            v_sub_width = self.c_decode_config.v_sub_width;
+            v_sub_height = self.c_decode_config.v_sub_height;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence >= 1 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u32le(&mut t_0, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != FOURCC_RIFF) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_config.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u32le(&mut t_1, &mut self.c_decode_config.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_riff_length = t_1;
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 || r == 3 {
+                        if r == 0 {
+                            self.c_decode_config.scratch = 0;
+                        }
+                        if r == 3 {
+                            r = 0;
+                        }
+                        csp = 3;
+                        status = a_src.read_u32le(&mut t_2, &mut self.c_decode_config.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_2 != FOURCC_WEBP) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 {
+                    if v_riff_length < 4 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_riff_length = (v_riff_length - 4);
+                    self.f_num_loops = 1;
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = self.next_chunk(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || (5 <= r && r <= 15) {
+                    if r == 5 || (r == 0 && (self.f_chunk_type == FOURCC_VP8L)) {
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.decode_vp8l_header(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            self.f_width = self.f_vp8l_width;
+                            self.f_height = self.f_vp8l_height;
+                        }
+                    } else if (6 <= r && r <= 15) || (r == 0 && (self.f_chunk_type == FOURCC_VP8X))
+                    {
+                        if r == 0 {
+                            if self.f_chunk_length < 10 {
+                                status = ERROR_BAD_HEADER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 6 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = (4 as u64);
+                            }
+                            if r == 6 {
+                                r = 0;
+                            }
+                            csp = 6;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 7 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status = a_src.read_u24le(&mut t_3, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_width = (t_3 + 1);
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 0 {
+                                self.c_decode_config.scratch = 0;
+                            }
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status = a_src.read_u24le(&mut t_4, &mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_height = (t_4 + 1);
+                        }
+                        if r == 0 || r == 9 {
+                            if r == 0 {
+                                self.c_decode_config.scratch =
+                                    (u32::saturating_sub(self.f_chunk_length, 10) as u64);
+                            }
+                            if r == 9 {
+                                r = 0;
+                            }
+                            csp = 9;
+                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || (10 <= r && r <= 14) {
+                            'label_0: loop {
+                                if r == 0 || r == 10 {
+                                    if r == 10 {
+                                        r = 0;
+                                    }
+                                    csp = 10;
+                                    status = self.next_chunk(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 || (11 <= r && r <= 14) {
+                                    if (11 <= r && r <= 13)
+                                        || (r == 0 && (self.f_chunk_type == FOURCC_ANIM))
+                                    {
+                                        if r == 0 {
+                                            if self.f_chunk_length < 6 {
+                                                status = ERROR_BAD_HEADER;
+                                                break 'exit;
+                                            }
+                                        }
+                                        if r == 0 || r == 11 {
+                                            if r == 0 {
+                                                self.c_decode_config.scratch = (4 as u64);
+                                            }
+                                            if r == 11 {
+                                                r = 0;
+                                            }
+                                            csp = 11;
+                                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                        if r == 0 || r == 12 {
+                                            if r == 0 {
+                                                self.c_decode_config.scratch = 0;
+                                            }
+                                            if r == 12 {
+                                                r = 0;
+                                            }
+                                            csp = 12;
+                                            status = a_src.read_u16le(
+                                                &mut t_5,
+                                                &mut self.c_decode_config.scratch,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                            self.f_num_loops = (t_5 as u32);
+                                        }
+                                        if r == 0 || r == 13 {
+                                            if r == 0 {
+                                                self.c_decode_config.scratch =
+                                                    (u32::saturating_sub(self.f_chunk_length, 6)
+                                                        as u64);
+                                            }
+                                            if r == 13 {
+                                                r = 0;
+                                            }
+                                            csp = 13;
+                                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    } else if r == 0
+                                        && ((self.f_chunk_type == FOURCC_ANMF)
+                                            || (self.f_chunk_type == FOURCC_VP8L)
+                                            || (self.f_chunk_type == FOURCC_VP8)
+                                            || (self.f_chunk_type == FOURCC_ALPH))
+                                    {
+                                        break 'label_0;
+                                    } else if r == 0 && (self.f_chunk_type == 0) {
+                                        status = ERROR_BAD_HEADER;
+                                        break 'exit;
+                                    } else {
+                                        if r == 0 || r == 14 {
+                                            if r == 0 {
+                                                self.c_decode_config.scratch =
+                                                    (self.f_chunk_length as u64);
+                                            }
+                                            if r == 14 {
+                                                r = 0;
+                                            }
+                                            csp = 14;
+                                            status = a_src.skip(&mut self.c_decode_config.scratch);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 || r == 15 {
+                            if r == 0 && (self.f_chunk_type == FOURCC_ANMF) {
+                                self.f_is_animated = true;
+                            } else if r == 15 || (r == 0 && (self.f_chunk_type == FOURCC_VP8L)) {
+                                if r == 0 || r == 15 {
+                                    if r == 15 {
+                                        r = 0;
+                                    }
+                                    csp = 15;
+                                    status = self.decode_vp8l_header(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    if (self.f_width != self.f_vp8l_width)
+                                        || (self.f_height != self.f_vp8l_height)
+                                    {
+                                        status = ERROR_BAD_HEADER;
+                                        break 'exit;
+                                    }
+                                }
+                            } else {
+                                status = ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+                                break 'exit;
+                            }
+                        }
+                    } else if r == 0 && (self.f_chunk_type == FOURCC_VP8) {
+                        status = ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+                        break 'exit;
+                    } else {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 {
+                    v_max_width = 16384;
+                    if self.f_width < 16384 {
+                        v_max_width = self.f_width;
+                    }
+                    v_max_height = 16384;
+                    if self.f_height < 16384 {
+                        v_max_height = self.f_height;
+                    }
+                    v_sub_width = ((v_max_width + 3) >> 2);
+                    v_sub_height = ((v_max_height + 3) >> 2);
+                    self.f_sub_image_max_pixels = (v_sub_width * v_sub_height);
+                    self.f_max_groups = 65536;
+                    if self.f_sub_image_max_pixels < 65536 {
+                        self.f_max_groups = self.f_sub_image_max_pixels;
+                    }
+                    a_dst.initialize(570460296, 0, self.f_width, self.f_height, self.f_num_loops);
+                    self.f_call_sequence = 1;
+                }
+                self.c_decode_config.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_config.coro_susp_point = csp;
+            self.c_decode_config.v_riff_length = v_riff_length;
+            self.c_decode_config.v_max_width = v_max_width;
+            self.c_decode_config.v_max_height = v_max_height;
+            self.c_decode_config.v_sub_width = v_sub_width;
+            self.c_decode_config.v_sub_height = v_sub_height;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.decode_frame
+
+impl Decoder {
+    pub fn decode_frame(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_src.derive();
+
+        let mut v_workbuf_len: u64 = 0;
+        let mut v_palette: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_workbuf_len = self.c_decode_frame.v_workbuf_len;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_call_sequence == 0 {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || (1 <= r && r <= 2) {
+                    if (1 <= r && r <= 2) || (r == 0 && !self.f_end_of_data) {
+                        if r == 0 || (1 <= r && r <= 2) {
+                            if r == 0 && (self.f_call_sequence == 1) {
+                                self.f_call_sequence = 2;
+                            } else if (1 <= r && r <= 2) || (r == 0 && self.f_is_animated) {
+                                if r == 0 || (1 <= r && r <= 2) {
+                                    'label_0: loop {
+                                        if r == 0 || r == 1 {
+                                            if r == 1 {
+                                                r = 0;
+                                            }
+                                            csp = 1;
+                                            status = self.next_chunk(a_src);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                        if r == 0 {
+                                            if self.f_chunk_type == FOURCC_ANMF {
+                                                break 'label_0;
+                                            } else if self.f_chunk_type == 0 {
+                                                self.f_end_of_data = true;
+                                                break 'label_0;
+                                            }
+                                        }
+                                        if r == 0 || r == 2 {
+                                            if r == 0 {
+                                                self.c_decode_frame.scratch =
+                                                    (self.f_chunk_length as u64);
+                                            }
+                                            if r == 2 {
+                                                r = 0;
+                                            }
+                                            csp = 2;
+                                            status = a_src.skip(&mut self.c_decode_frame.scratch);
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                            } else {
+                                self.f_end_of_data = true;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 || (r == 0 && self.f_end_of_data) {
+                        if r == 0 || r == 3 {
+                            loop {
+                                if r == 0 || r == 3 {
+                                    status = wuffs_base::SUSPENSION_END_OF_DATA;
+                                    if r == 3 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 3;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_workbuf_len = (self.code_length_table_offset() + TABLE_HEADER_SIZE);
+                    if (a_workbuf.len() as u64) < v_workbuf_len {
+                        status = ERROR_BAD_WORKBUF_LENGTH;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 || (r == 0 && self.f_is_animated) {
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.decode_anmf_header(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    } else {
+                        self.f_frame_rect.set_max_exclusive_x(self.f_width);
+                        self.f_frame_rect.set_max_exclusive_y(self.f_height);
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = self.decode_vp8l(a_dst, a_src, a_workbuf);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 6 {
+                    if r == 0 {
+                        self.c_decode_frame.scratch =
+                            (((self.f_chunk_length as u64) + (self.f_anmf_length as u64)) as u64);
+                    }
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_src.skip(&mut self.c_decode_frame.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_chunk_length = 0;
+                    self.f_anmf_length = 0;
+                    v_palette = wuffs_base::SliceU8::default();
+                    a_dst.update(
+                        self.f_frame_rect,
+                        self.f_frame_duration,
+                        self.f_frame_blend,
+                        self.f_frame_disposal,
+                        v_palette,
+                    );
+                }
+                self.c_decode_frame.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame.coro_susp_point = csp;
+            self.c_decode_frame.v_workbuf_len = v_workbuf_len;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func decoder.next_chunk
+
+impl Decoder {
+    fn next_chunk(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_length: u32 = 0;
+        let mut v_padded_length: u64 = 0;
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_next_chunk.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_length = self.c_next_chunk.v_length;
+            v_padded_length = self.c_next_chunk.v_padded_length;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        if self.f_riff_length == 0 {
+                            self.f_chunk_type = 0;
+                            self.f_chunk_length = 0;
+                            status = wuffs_base::Status::Ok;
+                            break 'ok;
+                        } else if self.f_riff_length < 8 {
+                            status = ERROR_BAD_HEADER;
+                            break 'exit;
+                        }
+                    }
+                    if r == 0 || r == 1 {
+                        if r == 0 {
+                            self.c_next_chunk.scratch = 0;
+                        }
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u32le(&mut t_0, &mut self.c_next_chunk.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        self.f_chunk_type = t_0;
+                    }
+                    if r == 0 || r == 2 {
+                        if r == 0 {
+                            self.c_next_chunk.scratch = 0;
+                        }
+                        if r == 2 {
+                            r = 0;
+                        }
+                        csp = 2;
+                        status = a_src.read_u32le(&mut t_1, &mut self.c_next_chunk.scratch);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                        v_length = t_1;
+                    }
+                    if r == 0 {
+                        v_padded_length = ((v_length as u64) + ((v_length & 1) as u64));
+                        if ((self.f_riff_length as u64) < 8)
+                            || (v_padded_length > ((self.f_riff_length as u64) - 8))
+                        {
+                            status = ERROR_BAD_HEADER;
+                            break 'exit;
+                        }
+                        self.f_chunk_length = ((v_padded_length & 4294967295) as u32);
+                        self.f_riff_length = u32::saturating_sub(
+                            self.f_riff_length,
+                            u32::saturating_add(self.f_chunk_length, 8),
+                        );
+                    }
+                }
+                self.c_next_chunk.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_next_chunk.coro_susp_point = csp;
+            self.c_next_chunk.v_length = v_length;
+            self.c_next_chunk.v_padded_length = v_padded_length;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_anmf_header
+
+impl Decoder {
+    fn decode_anmf_header(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_x0: u32 = 0;
+        let mut v_y0: u32 = 0;
+        let mut v_w: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_x1: u32 = 0;
+        let mut v_y1: u32 = 0;
+        let mut v_flags: u8 = 0;
+        let mut v_chunk_type: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_padded_length: u64 = 0;
+        let mut t_0: u32 = 0;
+        let mut t_1: u32 = 0;
+        let mut t_2: u32 = 0;
+        let mut t_3: u32 = 0;
+        let mut t_4: u32 = 0;
+        let mut t_5: u8 = 0;
+        let mut t_6: u32 = 0;
+        let mut t_7: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_anmf_header.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_x0 = self.c_decode_anmf_header.v_x0;
+            v_y0 = self.c_decode_anmf_header.v_y0;
+            v_w = self.c_decode_anmf_header.v_w;
+            v_h = self.c_decode_anmf_header.v_h;
+            v_x1 = self.c_decode_anmf_header.v_x1;
+            v_y1 = self.c_decode_anmf_header.v_y1;
+            v_flags = self.c_decode_anmf_header.v_flags;
+            v_chunk_type = self.c_decode_anmf_header.v_chunk_type;
+            v_length = self.c_decode_anmf_header.v_length;
+            v_padded_length = self.c_decode_anmf_header.v_padded_length;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_chunk_length < 16 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_anmf_length = (self.f_chunk_length - 16);
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode_anmf_header.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u24le(&mut t_0, &mut self.c_decode_anmf_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_x0 = (t_0 * 2);
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_anmf_header.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u24le(&mut t_1, &mut self.c_decode_anmf_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_y0 = (t_1 * 2);
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_decode_anmf_header.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_src.read_u24le(&mut t_2, &mut self.c_decode_anmf_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_w = (t_2 + 1);
+                }
+                if r == 0 || r == 4 {
+                    if r == 0 {
+                        self.c_decode_anmf_header.scratch = 0;
+                    }
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = a_src.read_u24le(&mut t_3, &mut self.c_decode_anmf_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_h = (t_3 + 1);
+                }
+                if r == 0 {
+                    v_x1 = (v_x0 + v_w);
+                    v_y1 = (v_y0 + v_h);
+                    if (v_x1 > self.f_width) || (v_y1 > self.f_height) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_frame_rect.set_min_inclusive_x(v_x0);
+                    self.f_frame_rect.set_min_inclusive_y(v_y0);
+                    self.f_frame_rect.set_max_exclusive_x(v_x1);
+                    self.f_frame_rect.set_max_exclusive_y(v_y1);
+                    self.f_frame_x0 = v_x0;
+                    self.f_frame_y0 = v_y0;
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 {
+                        self.c_decode_anmf_header.scratch = 0;
+                    }
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u24le(&mut t_4, &mut self.c_decode_anmf_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    self.f_frame_duration = ((t_4 as u64) * FLICKS_PER_MILLISECOND);
+                }
+                if r == 0 || r == 6 {
+                    if r == 6 {
+                        r = 0;
+                    }
+                    csp = 6;
+                    status = a_src.read_u8(&mut t_5);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_flags = t_5;
+                }
+                if r == 0 {
+                    self.f_frame_blend = ((v_flags & 2) == 0);
+                    if (v_flags & 1) != 0 {
+                        self.f_frame_disposal = 1;
+                    } else {
+                        self.f_frame_disposal = 0;
+                    }
+                }
+                if r == 0 || (7 <= r && r <= 9) {
+                    'label_0: loop {
+                        if r == 0 {
+                            if self.f_anmf_length < 8 {
+                                status = ERROR_BAD_HEADER;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 7 {
+                            if r == 0 {
+                                self.c_decode_anmf_header.scratch = 0;
+                            }
+                            if r == 7 {
+                                r = 0;
+                            }
+                            csp = 7;
+                            status =
+                                a_src.read_u32le(&mut t_6, &mut self.c_decode_anmf_header.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_chunk_type = t_6;
+                        }
+                        if r == 0 || r == 8 {
+                            if r == 0 {
+                                self.c_decode_anmf_header.scratch = 0;
+                            }
+                            if r == 8 {
+                                r = 0;
+                            }
+                            csp = 8;
+                            status =
+                                a_src.read_u32le(&mut t_7, &mut self.c_decode_anmf_header.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            v_length = t_7;
+                        }
+                        if r == 0 {
+                            v_padded_length = ((v_length as u64) + ((v_length & 1) as u64));
+                            if ((self.f_anmf_length as u64) < 8)
+                                || (v_padded_length > ((self.f_anmf_length as u64) - 8))
+                            {
+                                status = ERROR_BAD_HEADER;
+                                break 'exit;
+                            }
+                            self.f_chunk_type = v_chunk_type;
+                            self.f_chunk_length = ((v_padded_length & 4294967295) as u32);
+                            self.f_anmf_length = u32::saturating_sub(
+                                self.f_anmf_length,
+                                u32::saturating_add(self.f_chunk_length, 8),
+                            );
+                            if v_chunk_type == FOURCC_VP8L {
+                                break 'label_0;
+                            } else if (v_chunk_type == FOURCC_VP8) || (v_chunk_type == FOURCC_ALPH)
+                            {
+                                status = ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE;
+                                break 'exit;
+                            }
+                        }
+                        if r == 0 || r == 9 {
+                            if r == 0 {
+                                self.c_decode_anmf_header.scratch = (self.f_chunk_length as u64);
+                            }
+                            if r == 9 {
+                                r = 0;
+                            }
+                            csp = 9;
+                            status = a_src.skip(&mut self.c_decode_anmf_header.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 10 {
+                    if r == 10 {
+                        r = 0;
+                    }
+                    csp = 10;
+                    status = self.decode_vp8l_header(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    if (v_w != self.f_vp8l_width) || (v_h != self.f_vp8l_height) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                self.c_decode_anmf_header.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_anmf_header.coro_susp_point = csp;
+            self.c_decode_anmf_header.v_x0 = v_x0;
+            self.c_decode_anmf_header.v_y0 = v_y0;
+            self.c_decode_anmf_header.v_w = v_w;
+            self.c_decode_anmf_header.v_h = v_h;
+            self.c_decode_anmf_header.v_x1 = v_x1;
+            self.c_decode_anmf_header.v_y1 = v_y1;
+            self.c_decode_anmf_header.v_flags = v_flags;
+            self.c_decode_anmf_header.v_chunk_type = v_chunk_type;
+            self.c_decode_anmf_header.v_length = v_length;
+            self.c_decode_anmf_header.v_padded_length = v_padded_length;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_vp8l_header
+
+impl Decoder {
+    fn decode_vp8l_header(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u32 = 0;
+        let mut t_0: u8 = 0;
+        let mut t_1: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_vp8l_header.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_decode_vp8l_header.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_chunk_length < 5 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = a_src.read_u8(&mut t_0);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 && (t_0 != 47) {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 0 {
+                        self.c_decode_vp8l_header.scratch = 0;
+                    }
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = a_src.read_u32le(&mut t_1, &mut self.c_decode_vp8l_header.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_c = t_1;
+                }
+                if r == 0 {
+                    if (v_c >> 29) != 0 {
+                        status = ERROR_BAD_HEADER;
+                        break 'exit;
+                    }
+                    self.f_vp8l_width = ((v_c & 16383) + 1);
+                    self.f_vp8l_height = (((v_c >> 14) & 16383) + 1);
+                    self.f_chunk_length = u32::saturating_sub(self.f_chunk_length, 5);
+                }
+                self.c_decode_vp8l_header.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_vp8l_header.coro_susp_point = csp;
+            self.c_decode_vp8l_header.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_vp8l
+
+impl Decoder {
+    fn decode_vp8l(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+    ) -> wuffs_base::Status {
+        let mut v_width: u32 = 0;
+        let mut v_height: u32 = 0;
+        let mut v_t: u32 = 0;
+        let mut v_b: u32 = 0;
+        let mut v_w: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_num_colors: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_vp8l.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_width = self.c_decode_vp8l.v_width;
+            v_height = self.c_decode_vp8l.v_height;
+            v_t = self.c_decode_vp8l.v_t;
+            v_b = self.c_decode_vp8l.v_b;
+            v_w = self.c_decode_vp8l.v_w;
+            v_h = self.c_decode_vp8l.v_h;
+            v_num_colors = self.c_decode_vp8l.v_num_colors;
+            v_i = self.c_decode_vp8l.v_i;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    self.f_bits = 0;
+                    self.f_n_bits = 0;
+                    self.f_num_transforms = 0;
+                    self.f_seen_transforms = 0;
+                    self.f_meta_bits = 0;
+                    v_width = self.f_vp8l_width;
+                    v_height = self.f_vp8l_height;
+                    v_t = 0;
+                    v_b = 0;
+                    v_w = 0;
+                    v_h = 0;
+                    v_num_colors = 0;
+                    v_i = 0;
+                }
+                if r == 0 || (1 <= r && r <= 4) {
+                    'label_0: loop {
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.fill_bits(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            if self.take_bits(1) == 0 {
+                                break 'label_0;
+                            }
+                            v_t = (self.take_bits(2) & 3);
+                            if ((self.f_seen_transforms >> v_t) & 1) != 0 {
+                                status = ERROR_BAD_TRANSFORM;
+                                break 'exit;
+                            }
+                            self.f_seen_transforms |= (1u32 << v_t);
+                            v_i = self.f_num_transforms;
+                            if v_i >= 4 {
+                                status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                break 'exit;
+                            }
+                            (*unsafe { self.f_transform_types.get_unchecked_mut(v_i as usize) }) =
+                                v_t;
+                            (*unsafe { self.f_transform_widths.get_unchecked_mut(v_i as usize) }) =
+                                v_width;
+                            self.f_num_transforms = (v_i + 1);
+                        }
+                        if r == 0 || (2 <= r && r <= 4) {
+                            if (2 <= r && r <= 3)
+                                || (r == 0
+                                    && ((v_t == TRANSFORM_PREDICTOR) || (v_t == TRANSFORM_COLOR)))
+                            {
+                                if r == 0 {
+                                    v_b = ((self.take_bits(3) & 7) + 2);
+                                    v_w = self.div_round_up(v_width, v_b);
+                                    v_h = self.div_round_up(v_height, v_b);
+                                }
+                                if r == 0 || (2 <= r && r <= 3) {
+                                    if r == 2 || (r == 0 && (v_t == TRANSFORM_PREDICTOR)) {
+                                        if r == 0 {
+                                            self.f_predictor_bits = v_b;
+                                            self.f_predictor_width = v_w;
+                                        }
+                                        if r == 0 || r == 2 {
+                                            if r == 2 {
+                                                r = 0;
+                                            }
+                                            csp = 2;
+                                            status = self.decode_sub_image(
+                                                a_dst,
+                                                a_src,
+                                                a_workbuf,
+                                                WHICH_PREDICTOR,
+                                                v_w,
+                                                v_h,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    } else {
+                                        if r == 0 {
+                                            self.f_color_bits = v_b;
+                                            self.f_color_width = v_w;
+                                        }
+                                        if r == 0 || r == 3 {
+                                            if r == 3 {
+                                                r = 0;
+                                            }
+                                            csp = 3;
+                                            status = self.decode_sub_image(
+                                                a_dst,
+                                                a_src,
+                                                a_workbuf,
+                                                WHICH_COLOR,
+                                                v_w,
+                                                v_h,
+                                            );
+                                            if !status.is_ok() {
+                                                break 'suspend;
+                                            }
+                                        }
+                                    }
+                                }
+                            } else if r == 4 || (r == 0 && (v_t == TRANSFORM_COLOR_INDEXING)) {
+                                if r == 0 {
+                                    v_num_colors = ((self.take_bits(8) & 255) + 1);
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.decode_sub_image(
+                                        a_dst,
+                                        a_src,
+                                        a_workbuf,
+                                        WHICH_PALETTE,
+                                        v_num_colors,
+                                        1,
+                                    );
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    self.decode_palette(v_num_colors);
+                                    if v_num_colors <= 2 {
+                                        self.f_palette_width_bits = 3;
+                                    } else if v_num_colors <= 4 {
+                                        self.f_palette_width_bits = 2;
+                                    } else if v_num_colors <= 16 {
+                                        self.f_palette_width_bits = 1;
+                                    } else {
+                                        self.f_palette_width_bits = 0;
+                                    }
+                                    v_width = self.div_round_up(v_width, self.f_palette_width_bits);
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = self.decode_frame_image(a_dst, a_src, a_workbuf, v_width, v_height);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_i = self.f_num_transforms;
+                    while v_i > 0 {
+                        v_t =
+                            (*unsafe { self.f_transform_types.get_unchecked((v_i - 1) as usize) });
+                        v_w =
+                            (*unsafe { self.f_transform_widths.get_unchecked((v_i - 1) as usize) });
+                        v_i -= 1;
+                        if v_t == TRANSFORM_PREDICTOR {
+                            self.apply_predictor_transform(a_dst, a_workbuf, v_w, v_height);
+                        } else if v_t == TRANSFORM_COLOR {
+                            self.apply_color_transform(a_dst, a_workbuf, v_w, v_height);
+                        } else if v_t == TRANSFORM_SUBTRACT_GREEN {
+                            self.apply_subtract_green_transform(a_dst, v_w, v_height);
+                        } else {
+                            self.apply_color_indexing_transform(a_dst, v_w, v_height);
+                        }
+                    }
+                }
+                self.c_decode_vp8l.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_vp8l.coro_susp_point = csp;
+            self.c_decode_vp8l.v_width = v_width;
+            self.c_decode_vp8l.v_height = v_height;
+            self.c_decode_vp8l.v_t = v_t;
+            self.c_decode_vp8l.v_b = v_b;
+            self.c_decode_vp8l.v_w = v_w;
+            self.c_decode_vp8l.v_h = v_h;
+            self.c_decode_vp8l.v_num_colors = v_num_colors;
+            self.c_decode_vp8l.v_i = v_i;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.div_round_up
+
+impl Decoder {
+    fn div_round_up(&mut self, mut a_n: u32, mut a_b: u32) -> u32 {
+        return ((a_n + ((1u32 << a_b) - 1)) >> a_b);
+    }
+}
+
+// -------- func decoder.decode_palette
+
+impl Decoder {
+    fn decode_palette(&mut self, mut a_n: u32) {
+        let mut v_i: u32 = 0;
+
+        v_i = 1;
+        while v_i < a_n {
+            (*unsafe { self.f_palette.get_unchecked_mut(v_i as usize) }) = self.add_pixels(
+                (*unsafe { self.f_palette.get_unchecked(v_i as usize) }),
+                (*unsafe { self.f_palette.get_unchecked((v_i - 1) as usize) }),
+            );
+            v_i += 1;
+        }
+        while v_i < 256 {
+            (*unsafe { self.f_palette.get_unchecked_mut(v_i as usize) }) = 0;
+            v_i += 1;
+        }
+    }
+}
+
+// -------- func decoder.decode_frame_image
+
+impl Decoder {
+    fn decode_frame_image(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) -> wuffs_base::Status {
+        let mut v_cache_bits: u32 = 0;
+        let mut v_num_groups: u32 = 0;
+        let mut v_b: u32 = 0;
+        let mut v_w: u32 = 0;
+        let mut v_h: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_g: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_frame_image.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_cache_bits = self.c_decode_frame_image.v_cache_bits;
+            v_num_groups = self.c_decode_frame_image.v_num_groups;
+            v_b = self.c_decode_frame_image.v_b;
+            v_w = self.c_decode_frame_image.v_w;
+            v_h = self.c_decode_frame_image.v_h;
+            v_i = self.c_decode_frame_image.v_i;
+            v_n = self.c_decode_frame_image.v_n;
+            v_g = self.c_decode_frame_image.v_g;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.read_color_cache_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_cache_bits = self.f_cache_bits;
+                    v_num_groups = 1;
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.fill_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 || (r == 0 && (self.take_bits(1) != 0)) {
+                        if r == 0 {
+                            v_b = ((self.take_bits(3) & 7) + 2);
+                            v_w = self.div_round_up(a_width, v_b);
+                            v_h = self.div_round_up(a_height, v_b);
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self
+                                .decode_sub_image(a_dst, a_src, a_workbuf, WHICH_META, v_w, v_h);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_i = 0;
+                            while v_i < 65536 {
+                                (*unsafe { self.f_group_map.get_unchecked_mut(v_i as usize) }) = 0;
+                                v_i += 1;
+                            }
+                            v_n = (v_w * v_h);
+                            v_i = 0;
+                            while v_i < v_n {
+                                v_g = ((self.load_sub_pixel(a_workbuf, WHICH_META, (v_i as u64))
+                                    >> 8)
+                                    & 65535);
+                                (*unsafe { self.f_group_map.get_unchecked_mut(v_g as usize) }) = 1;
+                                if v_num_groups <= v_g {
+                                    v_num_groups = (v_g + 1);
+                                }
+                                v_i += 1;
+                            }
+                            self.f_meta_bits = v_b;
+                            self.f_meta_width = v_w;
+                        }
+                    }
+                }
+                if r == 0 {
+                    self.f_cache_bits = v_cache_bits;
+                }
+                if r == 0 || r == 4 {
+                    if r == 4 {
+                        r = 0;
+                    }
+                    csp = 4;
+                    status = self.read_huffman_groups(a_src, a_workbuf, v_num_groups);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status =
+                        self.decode_pixels(a_dst, a_src, a_workbuf, WHICH_FRAME, a_width, a_height);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_decode_frame_image.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_frame_image.coro_susp_point = csp;
+            self.c_decode_frame_image.v_cache_bits = v_cache_bits;
+            self.c_decode_frame_image.v_num_groups = v_num_groups;
+            self.c_decode_frame_image.v_b = v_b;
+            self.c_decode_frame_image.v_w = v_w;
+            self.c_decode_frame_image.v_h = v_h;
+            self.c_decode_frame_image.v_i = v_i;
+            self.c_decode_frame_image.v_n = v_n;
+            self.c_decode_frame_image.v_g = v_g;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.decode_sub_image
+
+impl Decoder {
+    fn decode_sub_image(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) -> wuffs_base::Status {
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_sub_image.coro_susp_point;
+        let mut csp: u32 = 0;
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.read_color_cache_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 2 {
+                    if r == 2 {
+                        r = 0;
+                    }
+                    csp = 2;
+                    status = self.read_huffman_groups(a_src, a_workbuf, 1);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status =
+                        self.decode_pixels(a_dst, a_src, a_workbuf, a_which, a_width, a_height);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_decode_sub_image.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_sub_image.coro_susp_point = csp;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.read_color_cache_bits
+
+impl Decoder {
+    fn read_color_cache_bits(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_color_cache_bits.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_read_color_cache_bits.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 || r == 1 {
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = self.fill_bits(a_src);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    self.f_cache_bits = 0;
+                    if self.take_bits(1) != 0 {
+                        v_c = (self.take_bits(4) & 15);
+                        if (v_c < 1) || (11 < v_c) {
+                            status = ERROR_BAD_COLOR_CACHE_SIZE;
+                            break 'exit;
+                        }
+                        self.f_cache_bits = v_c;
+                    }
+                }
+                self.c_read_color_cache_bits.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_color_cache_bits.coro_susp_point = csp;
+            self.c_read_color_cache_bits.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.read_huffman_groups
+
+impl Decoder {
+    fn read_huffman_groups(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_n: u32,
+    ) -> wuffs_base::Status {
+        let mut v_num_green_symbols: u32 = 0;
+        let mut v_g: u32 = 0;
+        let mut v_next: u32 = 0;
+        let mut v_position: u32 = 0;
+        let mut v_offset: u64 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_huffman_groups.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_green_symbols = self.c_read_huffman_groups.v_num_green_symbols;
+            v_g = self.c_read_huffman_groups.v_g;
+            v_next = self.c_read_huffman_groups.v_next;
+            v_position = self.c_read_huffman_groups.v_position;
+            v_offset = self.c_read_huffman_groups.v_offset;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_num_green_symbols = 280;
+                    if self.f_cache_bits > 0 {
+                        v_num_green_symbols += (1u32 << self.f_cache_bits);
+                    }
+                    v_g = 0;
+                    v_next = 0;
+                    v_position = 0;
+                    v_offset = 0;
+                }
+                if r == 0 || (1 <= r && r <= 5) {
+                    while r != 0 || (v_g < a_n) {
+                        if r == 0 {
+                            v_position = 0;
+                            if self.f_meta_bits > 0 {
+                                if (*unsafe { self.f_group_map.get_unchecked(v_g as usize) }) == 0 {
+                                    v_position = self.f_max_groups;
+                                } else if v_next < self.f_max_groups {
+                                    v_position = v_next;
+                                    (*unsafe {
+                                        self.f_group_map.get_unchecked_mut(v_g as usize)
+                                    }) = (v_next as u16);
+                                    v_next += 1;
+                                } else {
+                                    status =
+                                        ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                                    break 'exit;
+                                }
+                            }
+                            v_offset = (self.groups_offset() + ((v_position as u64) * GROUP_SIZE));
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.read_huffman_code(
+                                a_src,
+                                a_workbuf,
+                                v_offset,
+                                v_num_green_symbols,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 2 {
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = self.read_huffman_code(
+                                a_src,
+                                a_workbuf,
+                                (v_offset + TABLE_OFFSET_RED),
+                                256,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 3 {
+                            if r == 3 {
+                                r = 0;
+                            }
+                            csp = 3;
+                            status = self.read_huffman_code(
+                                a_src,
+                                a_workbuf,
+                                (v_offset + TABLE_OFFSET_BLUE),
+                                256,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 4 {
+                            if r == 4 {
+                                r = 0;
+                            }
+                            csp = 4;
+                            status = self.read_huffman_code(
+                                a_src,
+                                a_workbuf,
+                                (v_offset + TABLE_OFFSET_ALPHA),
+                                256,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 || r == 5 {
+                            if r == 5 {
+                                r = 0;
+                            }
+                            csp = 5;
+                            status = self.read_huffman_code(
+                                a_src,
+                                a_workbuf,
+                                (v_offset + TABLE_OFFSET_DISTANCE),
+                                40,
+                            );
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_g += 1;
+                        }
+                    }
+                }
+                self.c_read_huffman_groups.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_huffman_groups.coro_susp_point = csp;
+            self.c_read_huffman_groups.v_num_green_symbols = v_num_green_symbols;
+            self.c_read_huffman_groups.v_g = v_g;
+            self.c_read_huffman_groups.v_next = v_next;
+            self.c_read_huffman_groups.v_position = v_position;
+            self.c_read_huffman_groups.v_offset = v_offset;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.read_huffman_code
+
+impl Decoder {
+    fn read_huffman_code(
+        &mut self,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_t: u64,
+        mut a_n: u32,
+    ) -> wuffs_base::Status {
+        let mut v_i: u32 = 0;
+        let mut v_num_symbols: u32 = 0;
+        let mut v_simple_symbol: u32 = 0;
+        let mut v_num_code_lengths: u32 = 0;
+        let mut v_code_length_t: u64 = 0;
+        let mut v_max_symbol: u32 = 0;
+        let mut v_length_n_bits: u32 = 0;
+        let mut v_prev_code_length: u8 = 0;
+        let mut v_symbol: u32 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_repeat: u32 = 0;
+        let mut v_value: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_read_huffman_code.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_i = self.c_read_huffman_code.v_i;
+            v_num_symbols = self.c_read_huffman_code.v_num_symbols;
+            v_simple_symbol = self.c_read_huffman_code.v_simple_symbol;
+            v_num_code_lengths = self.c_read_huffman_code.v_num_code_lengths;
+            v_code_length_t = self.c_read_huffman_code.v_code_length_t;
+            v_max_symbol = self.c_read_huffman_code.v_max_symbol;
+            v_length_n_bits = self.c_read_huffman_code.v_length_n_bits;
+            v_prev_code_length = self.c_read_huffman_code.v_prev_code_length;
+            v_symbol = self.c_read_huffman_code.v_symbol;
+            v_code = self.c_read_huffman_code.v_code;
+            v_repeat = self.c_read_huffman_code.v_repeat;
+            v_value = self.c_read_huffman_code.v_value;
+        }
+
+        'exit: {
+            'suspend: {
+                'ok: {
+                    if r == 0 {
+                        v_i = 0;
+                        while v_i < a_n {
+                            (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 0;
+                            v_i += 1;
+                        }
+                    }
+                    if r == 0 || r == 1 {
+                        if r == 1 {
+                            r = 0;
+                        }
+                        csp = 1;
+                        status = self.fill_bits(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 2 {
+                        if r == 2 || (r == 0 && (self.take_bits(1) != 0)) {
+                            if r == 0 {
+                                v_num_symbols = ((self.take_bits(1) & 1) + 1);
+                                v_simple_symbol = 0;
+                                if self.take_bits(1) != 0 {
+                                    v_simple_symbol = self.take_bits(8);
+                                } else {
+                                    v_simple_symbol = self.take_bits(1);
+                                }
+                                if v_simple_symbol < a_n {
+                                    (*unsafe {
+                                        self.f_code_lengths
+                                            .get_unchecked_mut(v_simple_symbol as usize)
+                                    }) = 1;
+                                }
+                                if v_num_symbols == 2 {
+                                    v_simple_symbol = self.take_bits(8);
+                                    if v_simple_symbol < a_n {
+                                        (*unsafe {
+                                            self.f_code_lengths
+                                                .get_unchecked_mut(v_simple_symbol as usize)
+                                        }) = 1;
+                                    }
+                                }
+                            }
+                            if r == 0 || r == 2 {
+                                if r == 2 {
+                                    r = 0;
+                                }
+                                csp = 2;
+                                status = self.build_huffman(a_workbuf, a_t, a_n);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                status = wuffs_base::Status::Ok;
+                                break 'ok;
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        v_num_code_lengths = ((self.take_bits(4) & 15) + 4);
+                        v_i = 0;
+                    }
+                    if r == 0 || r == 3 {
+                        while r != 0 || (v_i < v_num_code_lengths) {
+                            if r == 0 || r == 3 {
+                                if r == 3 {
+                                    r = 0;
+                                }
+                                csp = 3;
+                                status = self.fill_bits(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                (*unsafe {
+                                    self.f_code_lengths.get_unchecked_mut(
+                                        (*unsafe {
+                                            CODE_LENGTH_CODE_ORDER.get_unchecked(v_i as usize)
+                                        }) as usize,
+                                    )
+                                }) = ((self.take_bits(3) & 7) as u8);
+                                v_i += 1;
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        while v_i < 19 {
+                            (*unsafe {
+                                self.f_code_lengths.get_unchecked_mut(
+                                    (*unsafe { CODE_LENGTH_CODE_ORDER.get_unchecked(v_i as usize) })
+                                        as usize,
+                                )
+                            }) = 0;
+                            v_i += 1;
+                        }
+                        v_code_length_t = self.code_length_table_offset();
+                    }
+                    if r == 0 || r == 4 {
+                        if r == 4 {
+                            r = 0;
+                        }
+                        csp = 4;
+                        status = self.build_huffman(a_workbuf, v_code_length_t, 19);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 {
+                        v_max_symbol = a_n;
+                    }
+                    if r == 0 || r == 5 {
+                        if r == 5 {
+                            r = 0;
+                        }
+                        csp = 5;
+                        status = self.fill_bits(a_src);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                    if r == 0 || r == 6 {
+                        if r == 6 || (r == 0 && (self.take_bits(1) != 0)) {
+                            if r == 0 {
+                                v_length_n_bits = ((2 * (self.take_bits(3) & 7)) + 2);
+                            }
+                            if r == 0 || r == 6 {
+                                if r == 6 {
+                                    r = 0;
+                                }
+                                csp = 6;
+                                status = self.fill_bits(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                v_max_symbol = (2 + (self.take_bits(v_length_n_bits) & 65535));
+                                if v_max_symbol > a_n {
+                                    status = ERROR_BAD_HUFFMAN_CODE;
+                                    break 'exit;
+                                }
+                            }
+                        }
+                    }
+                    if r == 0 {
+                        v_i = 0;
+                        while v_i < a_n {
+                            (*unsafe { self.f_code_lengths.get_unchecked_mut(v_i as usize) }) = 0;
+                            v_i += 1;
+                        }
+                        v_prev_code_length = 8;
+                        v_symbol = 0;
+                        v_code = 0;
+                        v_repeat = 0;
+                        v_value = 0;
+                    }
+                    if r == 0 || r == 7 {
+                        'label_0: while r != 0 || (v_symbol < a_n) {
+                            if r == 0 {
+                                if v_max_symbol == 0 {
+                                    break 'label_0;
+                                }
+                                v_max_symbol -= 1;
+                            }
+                            if r == 0 || r == 7 {
+                                if r == 7 {
+                                    r = 0;
+                                }
+                                csp = 7;
+                                status = self.fill_bits(a_src);
+                                if !status.is_ok() {
+                                    break 'suspend;
+                                }
+                            }
+                            if r == 0 {
+                                v_code = self.decode_huffman(a_workbuf, v_code_length_t);
+                                if v_code < 16 {
+                                    (*unsafe {
+                                        self.f_code_lengths.get_unchecked_mut(v_symbol as usize)
+                                    }) = (v_code as u8);
+                                    v_symbol += 1;
+                                    if v_code != 0 {
+                                        v_prev_code_length = (v_code as u8);
+                                    }
+                                    continue 'label_0;
+                                }
+                                if v_code == 16 {
+                                    v_repeat = ((self.take_bits(2) & 3) + 3);
+                                    v_value = v_prev_code_length;
+                                } else if v_code == 17 {
+                                    v_repeat = ((self.take_bits(3) & 7) + 3);
+                                    v_value = 0;
+                                } else if v_code == 18 {
+                                    v_repeat = ((self.take_bits(7) & 127) + 11);
+                                    v_value = 0;
+                                } else {
+                                    status = ERROR_BAD_HUFFMAN_CODE;
+                                    break 'exit;
+                                }
+                                while v_repeat > 0 {
+                                    if v_symbol >= a_n {
+                                        status = ERROR_BAD_HUFFMAN_CODE;
+                                        break 'exit;
+                                    }
+                                    (*unsafe {
+                                        self.f_code_lengths.get_unchecked_mut(v_symbol as usize)
+                                    }) = v_value;
+                                    v_symbol += 1;
+                                    v_repeat -= 1;
+                                }
+                            }
+                        }
+                    }
+                    if r == 0 || r == 8 {
+                        if r == 8 {
+                            r = 0;
+                        }
+                        csp = 8;
+                        status = self.build_huffman(a_workbuf, a_t, a_n);
+                        if !status.is_ok() {
+                            break 'suspend;
+                        }
+                    }
+                }
+                self.c_read_huffman_code.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_read_huffman_code.coro_susp_point = csp;
+            self.c_read_huffman_code.v_i = v_i;
+            self.c_read_huffman_code.v_num_symbols = v_num_symbols;
+            self.c_read_huffman_code.v_simple_symbol = v_simple_symbol;
+            self.c_read_huffman_code.v_num_code_lengths = v_num_code_lengths;
+            self.c_read_huffman_code.v_code_length_t = v_code_length_t;
+            self.c_read_huffman_code.v_max_symbol = v_max_symbol;
+            self.c_read_huffman_code.v_length_n_bits = v_length_n_bits;
+            self.c_read_huffman_code.v_prev_code_length = v_prev_code_length;
+            self.c_read_huffman_code.v_symbol = v_symbol;
+            self.c_read_huffman_code.v_code = v_code;
+            self.c_read_huffman_code.v_repeat = v_repeat;
+            self.c_read_huffman_code.v_value = v_value;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.build_huffman
+
+impl Decoder {
+    fn build_huffman(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_t: u64,
+        mut a_n: u32,
+    ) -> wuffs_base::Status {
+        let mut v_counts: [u32; 16] = [0; 16];
+        let mut v_num_symbols: u32 = 0;
+        let mut v_last_symbol: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_remaining: u32 = 0;
+        let mut v_l: u32 = 0;
+        let mut v_next_codes: [u32; 16] = [0; 16];
+        let mut v_deltas: [u32; 16] = [0; 16];
+        let mut v_code: u32 = 0;
+        let mut v_vals_length: u32 = 0;
+        let mut v_c: u32 = 0;
+        let mut v_maxcode: u32 = 0;
+        let mut v_v: u32 = 0;
+        let mut v_reversed: u32 = 0;
+        let mut v_j: u32 = 0;
+        let mut v_unused_bits: u32 = 0;
+        let mut v_key: u32 = 0;
+        let mut v_index: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        'exit: {
+            v_counts = [0; 16];
+            v_num_symbols = 0;
+            v_last_symbol = 0;
+            v_i = 0;
+            v_length = 0;
+            v_i = 0;
+            while v_i < a_n {
+                v_length = ((*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as u32);
+                if v_length != 0 {
+                    if ((*unsafe { v_counts.get_unchecked(v_length as usize) }) >= 2328)
+                        || (v_num_symbols >= 2328)
+                    {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    (*unsafe { v_counts.get_unchecked_mut(v_length as usize) }) += 1;
+                    v_num_symbols += 1;
+                    v_last_symbol = v_i;
+                }
+                v_i += 1;
+            }
+            if v_num_symbols == 0 {
+                status = ERROR_BAD_HUFFMAN_CODE;
+                break 'exit;
+            } else if v_num_symbols == 1 {
+                v_i = 0;
+                while v_i < 256 {
+                    self.set_table_value(v_i, v_last_symbol);
+                    v_i += 1;
+                }
+                self.copy_table(a_workbuf, a_t, 0);
+                status = wuffs_base::Status::Ok;
+                break 'exit;
+            }
+            v_i = 0;
+            while v_i < 256 {
+                self.set_table_value(v_i, 65535);
+                v_i += 1;
+            }
+            v_remaining = 1;
+            v_l = 1;
+            while v_l <= 15 {
+                if v_remaining > 1073741824 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_remaining <<= 1;
+                if v_remaining < (*unsafe { v_counts.get_unchecked(v_l as usize) }) {
+                    status = ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED;
+                    break 'exit;
+                }
+                v_remaining -= (*unsafe { v_counts.get_unchecked(v_l as usize) });
+                v_l += 1;
+            }
+            if v_remaining != 0 {
+                status = ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
+                break 'exit;
+            }
+            v_next_codes = [0; 16];
+            v_deltas = [0; 16];
+            v_code = 0;
+            v_vals_length = 0;
+            v_l = 1;
+            while v_l <= 15 {
+                v_c = ((v_code + (*unsafe { v_counts.get_unchecked((v_l - 1) as usize) })) << 1);
+                if v_c > 32768 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                v_code = v_c;
+                (*unsafe { v_next_codes.get_unchecked_mut(v_l as usize) }) = v_code;
+                v_maxcode = (v_code + (*unsafe { v_counts.get_unchecked(v_l as usize) }));
+                if v_maxcode > 32768 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                self.set_table_value((256 + v_l), v_maxcode);
+                if v_l >= 9 {
+                    (*unsafe { v_deltas.get_unchecked_mut(v_l as usize) }) =
+                        (u32::wrapping_sub(v_vals_length, v_code) & 65535);
+                    self.set_table_value(
+                        (272 + v_l),
+                        (*unsafe { v_deltas.get_unchecked(v_l as usize) }),
+                    );
+                    v_v = (v_vals_length + (*unsafe { v_counts.get_unchecked(v_l as usize) }));
+                    if v_v > a_n {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    v_vals_length = v_v;
+                }
+                v_l += 1;
+            }
+            v_reversed = 0;
+            v_j = 0;
+            v_unused_bits = 0;
+            v_key = 0;
+            v_index = 0;
+            v_i = 0;
+            'label_0: while v_i < a_n {
+                v_length = ((*unsafe { self.f_code_lengths.get_unchecked(v_i as usize) }) as u32);
+                if v_length == 0 {
+                    v_i += 1;
+                    continue 'label_0;
+                }
+                v_code = (*unsafe { v_next_codes.get_unchecked(v_length as usize) });
+                if v_code >= 32768 {
+                    status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                    break 'exit;
+                }
+                (*unsafe { v_next_codes.get_unchecked_mut(v_length as usize) }) = (v_code + 1);
+                if v_length <= 8 {
+                    v_unused_bits = (8 - v_length);
+                    v_reversed = 0;
+                    v_j = 0;
+                    while v_j < 8 {
+                        v_reversed = (((v_reversed << 1) | ((v_code >> v_j) & 1)) & 255);
+                        v_j += 1;
+                    }
+                    v_key = (v_reversed >> v_unused_bits);
+                    while v_key < 256 {
+                        self.set_table_value(v_key, ((v_length << 12) | v_i));
+                        v_key += (1u32 << v_length);
+                    }
+                } else {
+                    v_index = (u32::wrapping_add(
+                        v_code,
+                        (*unsafe { v_deltas.get_unchecked(v_length as usize) }),
+                    ) & 65535);
+                    if v_index >= 2328 {
+                        status = ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
+                        break 'exit;
+                    }
+                    self.set_table_value((288 + v_index), v_i);
+                }
+                v_i += 1;
+            }
+            self.copy_table(a_workbuf, a_t, v_vals_length);
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.set_table_value
+
+impl Decoder {
+    fn set_table_value(&mut self, mut a_i: u32, mut a_v: u32) {
+        (*unsafe { self.f_huff_table.get_unchecked_mut((2 * a_i) as usize) }) = ((a_v & 255) as u8);
+        (*unsafe {
+            self.f_huff_table
+                .get_unchecked_mut(((2 * a_i) + 1) as usize)
+        }) = ((a_v >> 8) as u8);
+    }
+}
+
+// -------- func decoder.copy_table
+
+impl Decoder {
+    fn copy_table(&mut self, mut a_workbuf: wuffs_base::SliceU8, mut a_t: u64, mut a_n: u32) {
+        if a_t <= (a_workbuf.len() as u64) {
+            unsafe { a_workbuf.subslice_i(a_t as usize) }.copy_from_slice(unsafe {
+                wuffs_base::SliceU8::from_array(&mut self.f_huff_table)
+                    .subslice_j((576 + (2 * a_n)) as usize)
+            });
+        }
+    }
+}
+
+// -------- func decoder.load_table_value
+
+impl Decoder {
+    fn load_table_value(&mut self, mut a_workbuf: wuffs_base::SliceU8, mut a_o: u64) -> u32 {
+        let mut v_value: [u8; 2] = [0; 2];
+
+        v_value = [0; 2];
+        if a_o < (a_workbuf.len() as u64) {
+            unsafe { wuffs_base::SliceU8::from_array(&mut v_value) }
+                .copy_from_slice(unsafe { a_workbuf.subslice_i(a_o as usize) });
+        }
+        return (((*unsafe { v_value.get_unchecked(0 as usize) }) as u32)
+            | (((*unsafe { v_value.get_unchecked(1 as usize) }) as u32) << 8));
+    }
+}
+
+// -------- func decoder.fill_bits
+
+impl Decoder {
+    fn fill_bits(&mut self, mut a_src: wuffs_base::IoReader) -> wuffs_base::Status {
+        let mut v_c: u8 = 0;
+        let mut t_0: u8 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_fill_bits.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_c = self.c_fill_bits.v_c;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_c = 0;
+                }
+                if r == 0 || r == 1 {
+                    while r != 0 || (self.f_n_bits < 32) {
+                        if r == 0 {
+                            v_c = 0;
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 || (r == 0 && (self.f_chunk_length > 0)) {
+                                if r == 0 || r == 1 {
+                                    if r == 1 {
+                                        r = 0;
+                                    }
+                                    csp = 1;
+                                    status = a_src.read_u8(&mut t_0);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                    v_c = t_0;
+                                }
+                                if r == 0 {
+                                    self.f_chunk_length =
+                                        u32::saturating_sub(self.f_chunk_length, 1);
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            self.f_bits |= ((v_c as u64) << self.f_n_bits);
+                            self.f_n_bits += 8;
+                        }
+                    }
+                }
+                self.c_fill_bits.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_fill_bits.coro_susp_point = csp;
+            self.c_fill_bits.v_c = v_c;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.take_bits
+
+impl Decoder {
+    fn take_bits(&mut self, mut a_n: u32) -> u32 {
+        let mut v_v: u64 = 0;
+
+        if self.f_n_bits < a_n {
+            return 0;
+        }
+        v_v = (self.f_bits & u64::MAX.checked_shr(64 - (a_n as u32)).unwrap_or(0));
+        self.f_bits >>= a_n;
+        self.f_n_bits -= a_n;
+        return ((v_v & 4294967295) as u32);
+    }
+}
+
+// -------- func decoder.decode_huffman
+
+impl Decoder {
+    fn decode_huffman(&mut self, mut a_workbuf: wuffs_base::SliceU8, mut a_t: u64) -> u32 {
+        let mut v_entry: u32 = 0;
+        let mut v_n: u32 = 0;
+        let mut v_bits: u64 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_index: u32 = 0;
+
+        v_entry = self.load_table_value(a_workbuf, (a_t + ((self.f_bits & 255) * 2)));
+        v_n = 0;
+        if v_entry != 65535 {
+            v_n = (v_entry >> 12);
+            if self.f_n_bits < v_n {
+                return 65535;
+            }
+            self.f_bits >>= v_n;
+            self.f_n_bits -= v_n;
+            return (v_entry & 4095);
+        }
+        v_bits = self.f_bits;
+        v_code = 0;
+        v_index = 0;
+        v_n = 1;
+        while v_n <= 15 {
+            v_code = (((v_code << 1) | ((v_bits & 1) as u32)) & 32767);
+            v_bits >>= 1;
+            if (v_n >= 9)
+                && (v_code < self.load_table_value(a_workbuf, (a_t + 512 + ((v_n * 2) as u64))))
+            {
+                if self.f_n_bits < v_n {
+                    return 65535;
+                }
+                self.f_bits >>= v_n;
+                self.f_n_bits -= v_n;
+                v_index = (u32::wrapping_add(
+                    v_code,
+                    self.load_table_value(a_workbuf, (a_t + 544 + ((v_n * 2) as u64))),
+                ) & 65535);
+                return self.load_table_value(a_workbuf, (a_t + 576 + ((v_index * 2) as u64)));
+            }
+            v_n += 1;
+        }
+        return 65535;
+    }
+}
+
+// -------- func decoder.decode_lz77_value
+
+impl Decoder {
+    fn decode_lz77_value(&mut self, mut a_p: u32) -> u32 {
+        let mut v_extra_bits: u32 = 0;
+        let mut v_offset: u32 = 0;
+        let mut v_extra: u32 = 0;
+
+        if a_p < 4 {
+            return (a_p + 1);
+        }
+        v_extra_bits = ((a_p - 2) >> 1);
+        v_offset = ((2 + (a_p & 1)) << v_extra_bits);
+        v_extra = (self.take_bits(v_extra_bits) & 262143);
+        return (v_offset + v_extra + 1);
+    }
+}
+
+// -------- func decoder.distance
+
+impl Decoder {
+    fn distance(&mut self, mut a_code: u32, mut a_width: u32) -> u32 {
+        let mut v_m: u32 = 0;
+        let mut v_d: u32 = 0;
+
+        if a_code > 120 {
+            return (a_code - 120);
+        } else if a_code < 1 {
+            return 1;
+        }
+        v_m = ((*unsafe { DISTANCE_MAP.get_unchecked((a_code - 1) as usize) }) as u32);
+        v_d = (((v_m >> 4) * a_width) + 8);
+        if v_d <= (v_m & 15) {
+            return 1;
+        }
+        return (v_d - (v_m & 15));
+    }
+}
+
+// -------- func decoder.decode_pixels
+
+impl Decoder {
+    fn decode_pixels(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_src: wuffs_base::IoReader,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) -> wuffs_base::Status {
+        let mut v_num_pixels: u32 = 0;
+        let mut v_p: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_group: u64 = 0;
+        let mut v_code: u32 = 0;
+        let mut v_argb: u32 = 0;
+        let mut v_length: u32 = 0;
+        let mut v_dist_code: u32 = 0;
+        let mut v_dist: u32 = 0;
+        let mut v_q: u32 = 0;
+        let mut v_qx: u32 = 0;
+        let mut v_qy: u32 = 0;
+        let mut v_i: u32 = 0;
+        let mut v_num_cache_codes: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode_pixels.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_num_pixels = self.c_decode_pixels.v_num_pixels;
+            v_p = self.c_decode_pixels.v_p;
+            v_x = self.c_decode_pixels.v_x;
+            v_y = self.c_decode_pixels.v_y;
+            v_group = self.c_decode_pixels.v_group;
+            v_code = self.c_decode_pixels.v_code;
+            v_argb = self.c_decode_pixels.v_argb;
+            v_length = self.c_decode_pixels.v_length;
+            v_dist_code = self.c_decode_pixels.v_dist_code;
+            v_dist = self.c_decode_pixels.v_dist;
+            v_q = self.c_decode_pixels.v_q;
+            v_qx = self.c_decode_pixels.v_qx;
+            v_qy = self.c_decode_pixels.v_qy;
+            v_i = self.c_decode_pixels.v_i;
+            v_num_cache_codes = self.c_decode_pixels.v_num_cache_codes;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    v_num_pixels = (a_width * a_height);
+                    v_p = 0;
+                    v_x = 0;
+                    v_y = 0;
+                    v_group = 0;
+                    v_code = 0;
+                    v_argb = 0;
+                    v_length = 0;
+                    v_dist_code = 0;
+                    v_dist = 0;
+                    v_q = 0;
+                    v_qx = 0;
+                    v_qy = 0;
+                    v_i = 0;
+                    while v_i < 2048 {
+                        (*unsafe { self.f_color_cache.get_unchecked_mut(v_i as usize) }) = 0;
+                        v_i += 1;
+                    }
+                    v_num_cache_codes = 0;
+                    if self.f_cache_bits > 0 {
+                        v_num_cache_codes = (1u32 << self.f_cache_bits);
+                    }
+                }
+                if r == 0 || (1 <= r && r <= 7) {
+                    while r != 0 || (v_p < v_num_pixels) {
+                        if r == 0 {
+                            v_group = self.group_offset(a_workbuf, a_which, v_x, v_y);
+                        }
+                        if r == 0 || r == 1 {
+                            if r == 1 {
+                                r = 0;
+                            }
+                            csp = 1;
+                            status = self.fill_bits(a_src);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                        }
+                        if r == 0 {
+                            v_code = self.decode_huffman(a_workbuf, v_group);
+                        }
+                        if r == 0 || (2 <= r && r <= 7) {
+                            if (2 <= r && r <= 4) || (r == 0 && (v_code < 256)) {
+                                if r == 0 {
+                                    v_argb = (v_code << 8);
+                                }
+                                if r == 0 || r == 2 {
+                                    if r == 2 {
+                                        r = 0;
+                                    }
+                                    csp = 2;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_argb |= ((self
+                                        .decode_huffman(a_workbuf, (v_group + TABLE_OFFSET_RED))
+                                        & 255)
+                                        << 16);
+                                }
+                                if r == 0 || r == 3 {
+                                    if r == 3 {
+                                        r = 0;
+                                    }
+                                    csp = 3;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_argb |= ((self
+                                        .decode_huffman(a_workbuf, (v_group + TABLE_OFFSET_BLUE))
+                                        & 255)
+                                        << 0);
+                                }
+                                if r == 0 || r == 4 {
+                                    if r == 4 {
+                                        r = 0;
+                                    }
+                                    csp = 4;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_argb |= ((self.decode_huffman(
+                                        a_workbuf,
+                                        (v_group + TABLE_OFFSET_ALPHA),
+                                    ) & 255)
+                                        << 24);
+                                    self.put_pixel(
+                                        a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb,
+                                    );
+                                    v_p = u32::saturating_add(v_p, 1);
+                                    v_x = u32::saturating_add(v_x, 1);
+                                    if v_x >= a_width {
+                                        v_x = 0;
+                                        v_y = u32::saturating_add(v_y, 1);
+                                    }
+                                }
+                            } else if (5 <= r && r <= 7) || (r == 0 && (v_code < 280)) {
+                                if r == 0 || r == 5 {
+                                    if r == 5 {
+                                        r = 0;
+                                    }
+                                    csp = 5;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_length = self.decode_lz77_value((v_code - 256));
+                                }
+                                if r == 0 || r == 6 {
+                                    if r == 6 {
+                                        r = 0;
+                                    }
+                                    csp = 6;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_code = self.decode_huffman(
+                                        a_workbuf,
+                                        (v_group + TABLE_OFFSET_DISTANCE),
+                                    );
+                                    if v_code >= 40 {
+                                        status = ERROR_BAD_HUFFMAN_CODE;
+                                        break 'exit;
+                                    }
+                                }
+                                if r == 0 || r == 7 {
+                                    if r == 7 {
+                                        r = 0;
+                                    }
+                                    csp = 7;
+                                    status = self.fill_bits(a_src);
+                                    if !status.is_ok() {
+                                        break 'suspend;
+                                    }
+                                }
+                                if r == 0 {
+                                    v_dist_code = self.decode_lz77_value(v_code);
+                                    v_dist = self.distance(v_dist_code, a_width);
+                                    if (v_dist > v_p)
+                                        || (v_length > u32::saturating_sub(v_num_pixels, v_p))
+                                    {
+                                        status = ERROR_BAD_BACK_REFERENCE;
+                                        break 'exit;
+                                    }
+                                    v_q = u32::saturating_sub(v_p, v_dist);
+                                    v_qx = 0;
+                                    v_qy = 0;
+                                    if a_width > 0 {
+                                        v_qx = (v_q % a_width);
+                                        v_qy = (v_q / a_width);
+                                    }
+                                    while v_length > 0 {
+                                        v_argb = self
+                                            .get_pixel(a_dst, a_workbuf, a_which, v_qx, v_qy, v_q);
+                                        self.put_pixel(
+                                            a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb,
+                                        );
+                                        v_p = u32::saturating_add(v_p, 1);
+                                        v_x = u32::saturating_add(v_x, 1);
+                                        if v_x >= a_width {
+                                            v_x = 0;
+                                            v_y = u32::saturating_add(v_y, 1);
+                                        }
+                                        v_q = u32::saturating_add(v_q, 1);
+                                        v_qx = u32::saturating_add(v_qx, 1);
+                                        if v_qx >= a_width {
+                                            v_qx = 0;
+                                            v_qy = u32::saturating_add(v_qy, 1);
+                                        }
+                                        v_length -= 1;
+                                    }
+                                }
+                            } else if r == 0 && ((v_code - 280) < v_num_cache_codes) {
+                                v_argb = (*unsafe {
+                                    self.f_color_cache
+                                        .get_unchecked(((v_code - 280) & 2047) as usize)
+                                });
+                                self.put_pixel(a_dst, a_workbuf, a_which, v_x, v_y, v_p, v_argb);
+                                v_p = u32::saturating_add(v_p, 1);
+                                v_x = u32::saturating_add(v_x, 1);
+                                if v_x >= a_width {
+                                    v_x = 0;
+                                    v_y = u32::saturating_add(v_y, 1);
+                                }
+                            } else {
+                                status = ERROR_BAD_HUFFMAN_CODE;
+                                break 'exit;
+                            }
+                        }
+                    }
+                }
+                self.c_decode_pixels.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode_pixels.coro_susp_point = csp;
+            self.c_decode_pixels.v_num_pixels = v_num_pixels;
+            self.c_decode_pixels.v_p = v_p;
+            self.c_decode_pixels.v_x = v_x;
+            self.c_decode_pixels.v_y = v_y;
+            self.c_decode_pixels.v_group = v_group;
+            self.c_decode_pixels.v_code = v_code;
+            self.c_decode_pixels.v_argb = v_argb;
+            self.c_decode_pixels.v_length = v_length;
+            self.c_decode_pixels.v_dist_code = v_dist_code;
+            self.c_decode_pixels.v_dist = v_dist;
+            self.c_decode_pixels.v_q = v_q;
+            self.c_decode_pixels.v_qx = v_qx;
+            self.c_decode_pixels.v_qy = v_qy;
+            self.c_decode_pixels.v_i = v_i;
+            self.c_decode_pixels.v_num_cache_codes = v_num_cache_codes;
+        }
+
+        status
+    }
+}
+
+// -------- func decoder.group_offset
+
+impl Decoder {
+    fn group_offset(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_x: u32,
+        mut a_y: u32,
+    ) -> u64 {
+        let mut v_i: u64 = 0;
+        let mut v_g: u32 = 0;
+
+        if (a_which != WHICH_FRAME) || (self.f_meta_bits == 0) {
+            return self.groups_offset();
+        }
+        v_i = ((((a_y >> self.f_meta_bits) as u64) * (self.f_meta_width as u64))
+            + ((a_x >> self.f_meta_bits) as u64));
+        v_g = ((self.load_sub_pixel(a_workbuf, WHICH_META, v_i) >> 8) & 65535);
+        return (self.groups_offset()
+            + (((*unsafe { self.f_group_map.get_unchecked(v_g as usize) }) as u64) * GROUP_SIZE));
+    }
+}
+
+// -------- func decoder.put_pixel
+
+impl Decoder {
+    fn put_pixel(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_x: u32,
+        mut a_y: u32,
+        mut a_p: u32,
+        mut a_argb: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_h: u64 = 0;
+
+        if a_which == WHICH_FRAME {
+            v_tab = a_dst.plane(0);
+            self.store_pixel(
+                v_tab.row(u32::wrapping_add(self.f_frame_y0, a_y)),
+                u32::wrapping_add(self.f_frame_x0, a_x),
+                a_argb,
+            );
+        } else if a_which == WHICH_PREDICTOR {
+            self.store_sub_pixel(a_workbuf, WHICH_PREDICTOR, (a_p as u64), a_argb);
+        } else if a_which == WHICH_COLOR {
+            self.store_sub_pixel(a_workbuf, WHICH_COLOR, (a_p as u64), a_argb);
+        } else if a_which == WHICH_META {
+            self.store_sub_pixel(a_workbuf, WHICH_META, (a_p as u64), a_argb);
+        } else {
+            (*unsafe { self.f_palette.get_unchecked_mut((a_p & 255) as usize) }) = a_argb;
+        }
+        if self.f_cache_bits > 0 {
+            v_h = (((a_argb as u64) * 506832829) & 4294967295);
+            (*unsafe {
+                self.f_color_cache
+                    .get_unchecked_mut(((v_h >> (32 - self.f_cache_bits)) & 2047) as usize)
+            }) = a_argb;
+        }
+    }
+}
+
+// -------- func decoder.get_pixel
+
+impl Decoder {
+    fn get_pixel(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_x: u32,
+        mut a_y: u32,
+        mut a_p: u32,
+    ) -> u32 {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+
+        if a_which == WHICH_FRAME {
+            v_tab = a_dst.plane(0);
+            return self.load_pixel(
+                v_tab.row(u32::wrapping_add(self.f_frame_y0, a_y)),
+                u32::wrapping_add(self.f_frame_x0, a_x),
+            );
+        } else if a_which == WHICH_PREDICTOR {
+            return self.load_sub_pixel(a_workbuf, WHICH_PREDICTOR, (a_p as u64));
+        } else if a_which == WHICH_COLOR {
+            return self.load_sub_pixel(a_workbuf, WHICH_COLOR, (a_p as u64));
+        } else if a_which == WHICH_META {
+            return self.load_sub_pixel(a_workbuf, WHICH_META, (a_p as u64));
+        }
+        return (*unsafe { self.f_palette.get_unchecked((a_p & 255) as usize) });
+    }
+}
+
+// -------- func decoder.load_sub_pixel
+
+impl Decoder {
+    fn load_sub_pixel(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_i: u64,
+    ) -> u32 {
+        let mut v_n: u64 = 0;
+        let mut v_offset: u64 = 0;
+
+        v_n = (self.f_sub_image_max_pixels as u64);
+        if a_i >= v_n {
+            return 0;
+        }
+        v_offset = (((a_which - 1) as u64) * v_n * 4);
+        if v_offset > (a_workbuf.len() as u64) {
+            return 0;
+        }
+        return self.load_pixel(
+            unsafe { a_workbuf.subslice_i(v_offset as usize) },
+            (a_i as u32),
+        );
+    }
+}
+
+// -------- func decoder.store_sub_pixel
+
+impl Decoder {
+    fn store_sub_pixel(
+        &mut self,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_which: u32,
+        mut a_i: u64,
+        mut a_argb: u32,
+    ) {
+        let mut v_n: u64 = 0;
+        let mut v_offset: u64 = 0;
+
+        v_n = (self.f_sub_image_max_pixels as u64);
+        if a_i >= v_n {
+            return;
+        }
+        v_offset = (((a_which - 1) as u64) * v_n * 4);
+        if v_offset > (a_workbuf.len() as u64) {
+            return;
+        }
+        self.store_pixel(
+            unsafe { a_workbuf.subslice_i(v_offset as usize) },
+            (a_i as u32),
+            a_argb,
+        );
+    }
+}
+
+// -------- func decoder.load_pixel
+
+impl Decoder {
+    fn load_pixel(&mut self, mut a_row: wuffs_base::SliceU8, mut a_x: u32) -> u32 {
+        let mut v_x4: u64 = 0;
+        let mut v_pixel: [u8; 4] = [0; 4];
+
+        v_x4 = ((a_x as u64) * 4);
+        v_pixel = [0; 4];
+        if v_x4 < (a_row.len() as u64) {
+            unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) }
+                .copy_from_slice(unsafe { a_row.subslice_i(v_x4 as usize) });
+        }
+        return ((((*unsafe { v_pixel.get_unchecked(0 as usize) }) as u32) << 0)
+            | (((*unsafe { v_pixel.get_unchecked(1 as usize) }) as u32) << 8)
+            | (((*unsafe { v_pixel.get_unchecked(2 as usize) }) as u32) << 16)
+            | (((*unsafe { v_pixel.get_unchecked(3 as usize) }) as u32) << 24));
+    }
+}
+
+// -------- func decoder.store_pixel
+
+impl Decoder {
+    fn store_pixel(&mut self, mut a_row: wuffs_base::SliceU8, mut a_x: u32, mut a_argb: u32) {
+        let mut v_x4: u64 = 0;
+        let mut v_pixel: [u8; 4] = [0; 4];
+
+        v_x4 = ((a_x as u64) * 4);
+        v_pixel = [0; 4];
+        (*unsafe { v_pixel.get_unchecked_mut(0 as usize) }) = (((a_argb >> 0) & 255) as u8);
+        (*unsafe { v_pixel.get_unchecked_mut(1 as usize) }) = (((a_argb >> 8) & 255) as u8);
+        (*unsafe { v_pixel.get_unchecked_mut(2 as usize) }) = (((a_argb >> 16) & 255) as u8);
+        (*unsafe { v_pixel.get_unchecked_mut(3 as usize) }) = (((a_argb >> 24) & 255) as u8);
+        if v_x4 < (a_row.len() as u64) {
+            unsafe { a_row.subslice_i(v_x4 as usize) }
+                .copy_from_slice(unsafe { wuffs_base::SliceU8::from_array(&mut v_pixel) });
+        }
+    }
+}
+
+// -------- func decoder.add_pixels
+
+impl Decoder {
+    fn add_pixels(&mut self, mut a_a: u32, mut a_b: u32) -> u32 {
+        return ((u32::wrapping_add((a_a & 4278255360), (a_b & 4278255360)) & 4278255360)
+            | (u32::wrapping_add((a_a & 16711935), (a_b & 16711935)) & 16711935));
+    }
+}
+
+// -------- func decoder.apply_predictor_transform
+
+impl Decoder {
+    fn apply_predictor_transform(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_curr: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_prev: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x0: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_l: u32 = 0;
+        let mut v_t: u32 = 0;
+        let mut v_tr: u32 = 0;
+        let mut v_tl: u32 = 0;
+        let mut v_prediction: u32 = 0;
+        let mut v_mode: u32 = 0;
+        let mut v_bits: u32 = 0;
+        let mut v_i: u64 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_curr = wuffs_base::SliceU8::default();
+        v_prev = wuffs_base::SliceU8::default();
+        v_x0 = self.f_frame_x0;
+        v_x = 0;
+        v_y = 0;
+        v_l = 0;
+        v_t = 0;
+        v_tr = 0;
+        v_tl = 0;
+        v_prediction = 0;
+        v_mode = 0;
+        v_bits = self.f_predictor_bits;
+        v_i = 0;
+        while v_y < a_height {
+            v_curr = v_tab.row(u32::wrapping_add(self.f_frame_y0, v_y));
+            v_x = 0;
+            while v_x < a_width {
+                if v_y == 0 {
+                    if v_x == 0 {
+                        v_prediction = 4278190080;
+                    } else {
+                        v_prediction = v_l;
+                    }
+                } else if v_x == 0 {
+                    v_prediction = self.load_pixel(v_prev, v_x0);
+                } else {
+                    v_i = ((((v_y >> v_bits) as u64) * (self.f_predictor_width as u64))
+                        + ((v_x >> v_bits) as u64));
+                    v_mode = ((self.load_sub_pixel(a_workbuf, WHICH_PREDICTOR, v_i) >> 8) & 15);
+                    v_t = self.load_pixel(v_prev, u32::wrapping_add(v_x0, v_x));
+                    v_tl = self.load_pixel(v_prev, u32::wrapping_add(v_x0, (v_x - 1)));
+                    if u32::saturating_add(v_x, 1) < a_width {
+                        v_tr = self.load_pixel(
+                            v_prev,
+                            u32::wrapping_add(v_x0, u32::saturating_add(v_x, 1)),
+                        );
+                    } else {
+                        v_tr = self.load_pixel(v_curr, v_x0);
+                    }
+                    v_prediction = self.predict(v_mode, v_l, v_t, v_tr, v_tl);
+                }
+                v_l = self.load_pixel(v_curr, u32::wrapping_add(v_x0, v_x));
+                v_l = self.add_pixels(v_l, v_prediction);
+                self.store_pixel(v_curr, u32::wrapping_add(v_x0, v_x), v_l);
+                v_x = u32::saturating_add(v_x, 1);
+            }
+            v_prev = v_curr;
+            v_y = u32::saturating_add(v_y, 1);
+        }
+    }
+}
+
+// -------- func decoder.predict
+
+impl Decoder {
+    fn predict(
+        &mut self,
+        mut a_mode: u32,
+        mut a_l: u32,
+        mut a_t: u32,
+        mut a_tr: u32,
+        mut a_tl: u32,
+    ) -> u32 {
+        let mut v_p: u32 = 0;
+        let mut v_q: u32 = 0;
+
+        v_p = 0;
+        v_q = 0;
+        if a_mode == 1 {
+            return a_l;
+        } else if a_mode == 2 {
+            return a_t;
+        } else if a_mode == 3 {
+            return a_tr;
+        } else if a_mode == 4 {
+            return a_tl;
+        } else if a_mode == 5 {
+            v_p = self.average2(a_l, a_tr);
+            return self.average2(v_p, a_t);
+        } else if a_mode == 6 {
+            return self.average2(a_l, a_tl);
+        } else if a_mode == 7 {
+            return self.average2(a_l, a_t);
+        } else if a_mode == 8 {
+            return self.average2(a_tl, a_t);
+        } else if a_mode == 9 {
+            return self.average2(a_t, a_tr);
+        } else if a_mode == 10 {
+            v_p = self.average2(a_l, a_tl);
+            v_q = self.average2(a_t, a_tr);
+            return self.average2(v_p, v_q);
+        } else if a_mode == 11 {
+            return self.select_nearer(a_l, a_t, a_tl);
+        } else if a_mode == 12 {
+            return self.clamp_add_subtract_full(a_l, a_t, a_tl);
+        } else if a_mode == 13 {
+            v_p = self.average2(a_l, a_t);
+            return self.clamp_add_subtract_half(v_p, a_tl);
+        }
+        return 4278190080;
+    }
+}
+
+// -------- func decoder.average2
+
+impl Decoder {
+    fn average2(&mut self, mut a_a: u32, mut a_b: u32) -> u32 {
+        return u32::wrapping_add((((a_a ^ a_b) & 4278124286) >> 1), (a_a & a_b));
+    }
+}
+
+// -------- func decoder.select_nearer
+
+impl Decoder {
+    fn select_nearer(&mut self, mut a_l: u32, mut a_t: u32, mut a_tl: u32) -> u32 {
+        let mut v_distance_to_l: u32 = 0;
+        let mut v_distance_to_t: u32 = 0;
+
+        v_distance_to_l = (self.abs_diff(((a_t >> 0) & 255), ((a_tl >> 0) & 255))
+            + self.abs_diff(((a_t >> 8) & 255), ((a_tl >> 8) & 255))
+            + self.abs_diff(((a_t >> 16) & 255), ((a_tl >> 16) & 255))
+            + self.abs_diff(((a_t >> 24) & 255), ((a_tl >> 24) & 255)));
+        v_distance_to_t = (self.abs_diff(((a_l >> 0) & 255), ((a_tl >> 0) & 255))
+            + self.abs_diff(((a_l >> 8) & 255), ((a_tl >> 8) & 255))
+            + self.abs_diff(((a_l >> 16) & 255), ((a_tl >> 16) & 255))
+            + self.abs_diff(((a_l >> 24) & 255), ((a_tl >> 24) & 255)));
+        if v_distance_to_l < v_distance_to_t {
+            return a_l;
+        }
+        return a_t;
+    }
+}
+
+// -------- func decoder.abs_diff
+
+impl Decoder {
+    fn abs_diff(&mut self, mut a_a: u32, mut a_b: u32) -> u32 {
+        if a_a >= a_b {
+            return (a_a - a_b);
+        }
+        return u32::saturating_sub(a_b, a_a);
+    }
+}
+
+// -------- func decoder.clamp_add_subtract_full
+
+impl Decoder {
+    fn clamp_add_subtract_full(&mut self, mut a_a: u32, mut a_b: u32, mut a_c: u32) -> u32 {
+        return ((self.clamp_full(((a_a >> 0) & 255), ((a_b >> 0) & 255), ((a_c >> 0) & 255))
+            << 0)
+            | (self.clamp_full(((a_a >> 8) & 255), ((a_b >> 8) & 255), ((a_c >> 8) & 255)) << 8)
+            | (self.clamp_full(
+                ((a_a >> 16) & 255),
+                ((a_b >> 16) & 255),
+                ((a_c >> 16) & 255),
+            ) << 16)
+            | (self.clamp_full(
+                ((a_a >> 24) & 255),
+                ((a_b >> 24) & 255),
+                ((a_c >> 24) & 255),
+            ) << 24));
+    }
+}
+
+// -------- func decoder.clamp_full
+
+impl Decoder {
+    fn clamp_full(&mut self, mut a_a: u32, mut a_b: u32, mut a_c: u32) -> u32 {
+        let mut v_v: u32 = 0;
+
+        v_v = (a_a + a_b);
+        if v_v <= a_c {
+            return 0;
+        }
+        v_v -= a_c;
+        if v_v > 255 {
+            return 255;
+        }
+        return v_v;
+    }
+}
+
+// -------- func decoder.clamp_add_subtract_half
+
+impl Decoder {
+    fn clamp_add_subtract_half(&mut self, mut a_a: u32, mut a_b: u32) -> u32 {
+        return ((self.clamp_half(((a_a >> 0) & 255), ((a_b >> 0) & 255)) << 0)
+            | (self.clamp_half(((a_a >> 8) & 255), ((a_b >> 8) & 255)) << 8)
+            | (self.clamp_half(((a_a >> 16) & 255), ((a_b >> 16) & 255)) << 16)
+            | (self.clamp_half(((a_a >> 24) & 255), ((a_b >> 24) & 255)) << 24));
+    }
+}
+
+// -------- func decoder.clamp_half
+
+impl Decoder {
+    fn clamp_half(&mut self, mut a_a: u32, mut a_b: u32) -> u32 {
+        let mut v_d: u32 = 0;
+
+        v_d = 0;
+        if a_b >= a_a {
+            v_d = ((a_b - a_a) / 2);
+            if v_d >= a_a {
+                return 0;
+            }
+            return (a_a - v_d);
+        }
+        v_d = (u32::saturating_sub(a_a, a_b) / 2);
+        if v_d > (255 - a_a) {
+            return 255;
+        }
+        return (a_a + v_d);
+    }
+}
+
+// -------- func decoder.apply_color_transform
+
+impl Decoder {
+    fn apply_color_transform(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_workbuf: wuffs_base::SliceU8,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x0: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_bits: u32 = 0;
+        let mut v_i: u64 = 0;
+        let mut v_m: u32 = 0;
+        let mut v_argb: u32 = 0;
+        let mut v_g: u32 = 0;
+        let mut v_r: u32 = 0;
+        let mut v_b: u32 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_row = wuffs_base::SliceU8::default();
+        v_x0 = self.f_frame_x0;
+        v_x = 0;
+        v_y = 0;
+        v_bits = self.f_color_bits;
+        v_i = 0;
+        v_m = 0;
+        v_argb = 0;
+        v_g = 0;
+        v_r = 0;
+        v_b = 0;
+        while v_y < a_height {
+            v_row = v_tab.row(u32::wrapping_add(self.f_frame_y0, v_y));
+            v_x = 0;
+            while v_x < a_width {
+                v_i = ((((v_y >> v_bits) as u64) * (self.f_color_width as u64))
+                    + ((v_x >> v_bits) as u64));
+                v_m = self.load_sub_pixel(a_workbuf, WHICH_COLOR, v_i);
+                v_argb = self.load_pixel(v_row, u32::wrapping_add(v_x0, v_x));
+                v_g = ((v_argb >> 8) & 255);
+                v_r = (u32::wrapping_add(
+                    (v_argb >> 16),
+                    self.color_transform_delta(((v_m >> 0) & 255), v_g),
+                ) & 255);
+                v_b = (u32::wrapping_add(
+                    u32::wrapping_add(v_argb, self.color_transform_delta(((v_m >> 8) & 255), v_g)),
+                    self.color_transform_delta(((v_m >> 16) & 255), v_r),
+                ) & 255);
+                v_argb = ((v_argb & 4278255360) | (v_r << 16) | v_b);
+                self.store_pixel(v_row, u32::wrapping_add(v_x0, v_x), v_argb);
+                v_x = u32::saturating_add(v_x, 1);
+            }
+            v_y = u32::saturating_add(v_y, 1);
+        }
+    }
+}
+
+// -------- func decoder.color_transform_delta
+
+impl Decoder {
+    fn color_transform_delta(&mut self, mut a_t: u32, mut a_c: u32) -> u32 {
+        let mut v_t_magnitude: u32 = 0;
+        let mut v_c_magnitude: u32 = 0;
+        let mut v_negative: bool = false;
+        let mut v_product: u32 = 0;
+
+        v_t_magnitude = 0;
+        v_c_magnitude = 0;
+        v_negative = false;
+        if a_t < 128 {
+            v_t_magnitude = a_t;
+        } else {
+            v_t_magnitude = (256 - a_t);
+            v_negative = !v_negative;
+        }
+        if a_c < 128 {
+            v_c_magnitude = a_c;
+        } else {
+            v_c_magnitude = (256 - a_c);
+            v_negative = !v_negative;
+        }
+        v_product = (v_t_magnitude * v_c_magnitude);
+        if v_negative {
+            return u32::wrapping_sub(0, ((v_product + 31) >> 5));
+        }
+        return (v_product >> 5);
+    }
+}
+
+// -------- func decoder.apply_subtract_green_transform
+
+impl Decoder {
+    fn apply_subtract_green_transform(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x0: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_argb: u32 = 0;
+        let mut v_g: u32 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_row = wuffs_base::SliceU8::default();
+        v_x0 = self.f_frame_x0;
+        v_x = 0;
+        v_y = 0;
+        v_argb = 0;
+        v_g = 0;
+        while v_y < a_height {
+            v_row = v_tab.row(u32::wrapping_add(self.f_frame_y0, v_y));
+            v_x = 0;
+            while v_x < a_width {
+                v_argb = self.load_pixel(v_row, u32::wrapping_add(v_x0, v_x));
+                v_g = ((v_argb >> 8) & 255);
+                v_argb = self.add_pixels(v_argb, ((v_g << 16) | v_g));
+                self.store_pixel(v_row, u32::wrapping_add(v_x0, v_x), v_argb);
+                v_x = u32::saturating_add(v_x, 1);
+            }
+            v_y = u32::saturating_add(v_y, 1);
+        }
+    }
+}
+
+// -------- func decoder.apply_color_indexing_transform
+
+impl Decoder {
+    fn apply_color_indexing_transform(
+        &mut self,
+        mut a_dst: &mut wuffs_base::ImageBuffer,
+        mut a_width: u32,
+        mut a_height: u32,
+    ) {
+        let mut v_tab: wuffs_base::TableU8 = wuffs_base::TableU8::default();
+        let mut v_row: wuffs_base::SliceU8 = wuffs_base::SliceU8::default();
+        let mut v_x0: u32 = 0;
+        let mut v_x: u32 = 0;
+        let mut v_y: u32 = 0;
+        let mut v_width_bits: u32 = 0;
+        let mut v_bits_per_index: u32 = 0;
+        let mut v_index_mask: u32 = 0;
+        let mut v_g: u32 = 0;
+        let mut v_shift: u32 = 0;
+
+        v_tab = a_dst.plane(0);
+        v_row = wuffs_base::SliceU8::default();
+        v_x0 = self.f_frame_x0;
+        v_x = 0;
+        v_y = 0;
+        v_width_bits = self.f_palette_width_bits;
+        v_bits_per_index = (8u32 >> v_width_bits);
+        v_index_mask = ((1u32 << v_bits_per_index) - 1);
+        v_g = 0;
+        v_shift = 0;
+        while v_y < a_height {
+            v_row = v_tab.row(u32::wrapping_add(self.f_frame_y0, v_y));
+            v_x = a_width;
+            while v_x > 0 {
+                v_x -= 1;
+                v_g = ((self.load_pixel(v_row, u32::wrapping_add(v_x0, (v_x >> v_width_bits)))
+                    >> 8)
+                    & 255);
+                v_shift = (v_bits_per_index * (v_x & ((1u32 << v_width_bits) - 1)));
+                self.store_pixel(
+                    v_row,
+                    u32::wrapping_add(v_x0, v_x),
+                    (*unsafe {
+                        self.f_palette
+                            .get_unchecked(((v_g >> (v_shift & 7)) & v_index_mask) as usize)
+                    }),
+                );
+            }
+            v_y = u32::saturating_add(v_y, 1);
+        }
+    }
+}
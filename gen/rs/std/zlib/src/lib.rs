@@ -0,0 +1,498 @@
+// Code generated by wuffs-rs. DO NOT EDIT.
+
+//! Package zlib is the Rust translation of the Wuffs std/zlib package.
+//!
+//! # Safety
+//!
+//! The Wuffs compiler has proved that every array or slice index, and every
+//! fast path I/O call, in this crate is in bounds. The unsafe blocks below
+//! skip the corresponding Rust run time checks, and are justified by those
+//! proofs, not by local reasoning.
+
+#![no_std]
+#![allow(
+    dead_code,
+    non_camel_case_types,
+    non_snake_case,
+    non_upper_case_globals
+)]
+#![allow(unreachable_code, unused_assignments, unused_labels, unused_mut)]
+#![allow(unused_parens, unused_unsafe, unused_variables)]
+
+use wuffs_std_adler32 as adler32;
+use wuffs_std_deflate as deflate;
+
+// ---------------- Status Codes
+
+pub const ERROR_BAD_CHECKSUM: wuffs_base::Status = wuffs_base::Status::Error("zlib: bad checksum");
+pub const ERROR_BAD_COMPRESSION_METHOD: wuffs_base::Status =
+    wuffs_base::Status::Error("zlib: bad compression method");
+pub const ERROR_BAD_COMPRESSION_WINDOW_SIZE: wuffs_base::Status =
+    wuffs_base::Status::Error("zlib: bad compression window size");
+pub const ERROR_BAD_PARITY_CHECK: wuffs_base::Status =
+    wuffs_base::Status::Error("zlib: bad parity check");
+pub const ERROR_INCORRECT_DICTIONARY: wuffs_base::Status =
+    wuffs_base::Status::Error("zlib: incorrect dictionary");
+pub const SUSPENSION_DICTIONARY_REQUIRED: wuffs_base::Status =
+    wuffs_base::Status::Suspension("zlib: dictionary required");
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+/// Decoder holds the state of a zlib.decoder. Its default value is ready to use.
+pub struct Decoder {
+    status: wuffs_base::Status,
+    f_flate: deflate::Decoder,
+    f_checksum: adler32::Hasher,
+    f_ignore_checksum: bool,
+    f_dict_id_hasher: adler32::Hasher,
+    f_dict_id_got: u32,
+    f_dict_id_want: u32,
+    f_waiting_for_dictionary: bool,
+    f_got_dictionary: bool,
+    f_bad_call_sequence: bool,
+    c_decode: DecoderDecodeCoro,
+}
+
+impl Default for Decoder {
+    fn default() -> Self {
+        Decoder {
+            status: wuffs_base::Status::Ok,
+            f_flate: deflate::Decoder::default(),
+            f_checksum: adler32::Hasher::default(),
+            f_ignore_checksum: false,
+            f_dict_id_hasher: adler32::Hasher::default(),
+            f_dict_id_got: 0,
+            f_dict_id_want: 0,
+            f_waiting_for_dictionary: false,
+            f_got_dictionary: false,
+            f_bad_call_sequence: false,
+            c_decode: DecoderDecodeCoro::default(),
+        }
+    }
+}
+
+struct DecoderDecodeCoro {
+    coro_susp_point: u32,
+    v_x: u16,
+    v_checksum_got: u32,
+    v_z: wuffs_base::Status,
+    v_checksum_want: u32,
+    scratch: u64,
+}
+
+impl Default for DecoderDecodeCoro {
+    fn default() -> Self {
+        DecoderDecodeCoro {
+            coro_susp_point: 0,
+            v_x: 0,
+            v_checksum_got: 0,
+            v_z: wuffs_base::Status::Ok,
+            v_checksum_want: 0,
+            scratch: 0,
+        }
+    }
+}
+
+/// Encoder holds the state of a zlib.encoder. Its default value is ready to use.
+pub struct Encoder {
+    status: wuffs_base::Status,
+    f_flate: deflate::Encoder,
+    f_checksum: adler32::Hasher,
+    f_level: u32,
+    f_level_set: bool,
+    c_encode: EncoderEncodeCoro,
+}
+
+impl Default for Encoder {
+    fn default() -> Self {
+        Encoder {
+            status: wuffs_base::Status::Ok,
+            f_flate: deflate::Encoder::default(),
+            f_checksum: adler32::Hasher::default(),
+            f_level: 0,
+            f_level_set: false,
+            c_encode: EncoderEncodeCoro::default(),
+        }
+    }
+}
+
+struct EncoderEncodeCoro {
+    coro_susp_point: u32,
+    v_x: u16,
+    v_checksum: u32,
+    v_z: wuffs_base::Status,
+    scratch: u64,
+}
+
+impl Default for EncoderEncodeCoro {
+    fn default() -> Self {
+        EncoderEncodeCoro {
+            coro_susp_point: 0,
+            v_x: 0,
+            v_checksum: 0,
+            v_z: wuffs_base::Status::Ok,
+            scratch: 0,
+        }
+    }
+}
+
+// ---------------- Private Consts
+
+// ---------------- Function Implementations
+
+// -------- func decoder.set_ignore_checksum
+
+impl Decoder {
+    pub fn set_ignore_checksum(&mut self, mut a_ic: bool) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_ignore_checksum = a_ic;
+    }
+}
+
+// -------- func decoder.dictionary_id
+
+impl Decoder {
+    pub fn dictionary_id(&mut self) -> u32 {
+        if self.status.is_error() {
+            return 0;
+        }
+
+        return self.f_dict_id_want;
+    }
+}
+
+// -------- func decoder.set_dictionary
+
+impl Decoder {
+    pub fn set_dictionary(&mut self, mut a_dict: wuffs_base::SliceU8) {
+        if self.status.is_error() {
+            return;
+        }
+
+        if !self.f_waiting_for_dictionary {
+            self.f_bad_call_sequence = true;
+            return;
+        }
+        self.f_waiting_for_dictionary = false;
+        self.f_got_dictionary = true;
+        self.f_dict_id_got = self.f_dict_id_hasher.update(a_dict);
+        self.f_flate.add_history(a_dict);
+    }
+}
+
+// -------- func decoder.decode
+
+impl Decoder {
+    pub fn decode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_x: u16 = 0;
+        let mut v_checksum_got: u32 = 0;
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut v_checksum_want: u32 = 0;
+        let mut t_0: u16 = 0;
+        let mut t_1: u32 = 0;
+        let mut t_2: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_3: u32 = 0;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_decode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_x = self.c_decode.v_x;
+            v_checksum_got = self.c_decode.v_checksum_got;
+            v_z = self.c_decode.v_z;
+            v_checksum_want = self.c_decode.v_checksum_want;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if self.f_bad_call_sequence {
+                        status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_decode.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_src.read_u16be(&mut t_0, &mut self.c_decode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_x = t_0;
+                }
+                if r == 0 {
+                    if ((v_x >> 8) & 15) != 8 {
+                        status = ERROR_BAD_COMPRESSION_METHOD;
+                        break 'exit;
+                    }
+                    if (v_x >> 12) > 7 {
+                        status = ERROR_BAD_COMPRESSION_WINDOW_SIZE;
+                        break 'exit;
+                    }
+                    if (v_x % 31) != 0 {
+                        status = ERROR_BAD_PARITY_CHECK;
+                        break 'exit;
+                    }
+                }
+                if r == 0 || (2 <= r && r <= 3) {
+                    if (2 <= r && r <= 3) || (r == 0 && ((v_x & 32) != 0)) {
+                        if r == 0 || r == 2 {
+                            if r == 0 {
+                                self.c_decode.scratch = 0;
+                            }
+                            if r == 2 {
+                                r = 0;
+                            }
+                            csp = 2;
+                            status = a_src.read_u32be(&mut t_1, &mut self.c_decode.scratch);
+                            if !status.is_ok() {
+                                break 'suspend;
+                            }
+                            self.f_dict_id_want = t_1;
+                        }
+                        if r == 0 {
+                            self.f_waiting_for_dictionary = true;
+                        }
+                        if r == 0 || r == 3 {
+                            while r != 0 || !self.f_got_dictionary {
+                                if r == 0 || r == 3 {
+                                    status = SUSPENSION_DICTIONARY_REQUIRED;
+                                    if r == 3 {
+                                        r = 0;
+                                        status = wuffs_base::Status::Ok;
+                                    } else {
+                                        csp = 3;
+                                        break 'suspend;
+                                    }
+                                }
+                            }
+                        }
+                        if r == 0 {
+                            if self.f_bad_call_sequence {
+                                status = wuffs_base::ERROR_INVALID_CALL_SEQUENCE;
+                                break 'exit;
+                            } else if self.f_dict_id_got != self.f_dict_id_want {
+                                status = ERROR_INCORRECT_DICTIONARY;
+                                break 'exit;
+                            }
+                        }
+                    }
+                }
+                if r == 0 {
+                    v_checksum_got = 0;
+                }
+                if r == 0 || r == 4 {
+                    'label_0: loop {
+                        if r == 0 {
+                            a_dst.set_mark();
+                            t_2 = self.f_flate.decode(a_dst, a_src);
+                            v_z = t_2;
+                            if !self.f_ignore_checksum {
+                                v_checksum_got = self.f_checksum.update(a_dst.since_mark());
+                            }
+                            if v_z.is_ok() {
+                                break 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 4 {
+                            status = v_z;
+                            if r == 4 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                if status.is_error() {
+                                    break 'exit;
+                                }
+                                if !status.is_ok() {
+                                    csp = 4;
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 5 {
+                    if r == 0 {
+                        self.c_decode.scratch = 0;
+                    }
+                    if r == 5 {
+                        r = 0;
+                    }
+                    csp = 5;
+                    status = a_src.read_u32be(&mut t_3, &mut self.c_decode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                    v_checksum_want = t_3;
+                }
+                if r == 0 {
+                    if !self.f_ignore_checksum && (v_checksum_got != v_checksum_want) {
+                        status = ERROR_BAD_CHECKSUM;
+                        break 'exit;
+                    }
+                }
+                self.c_decode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_decode.coro_susp_point = csp;
+            self.c_decode.v_x = v_x;
+            self.c_decode.v_checksum_got = v_checksum_got;
+            self.c_decode.v_z = v_z;
+            self.c_decode.v_checksum_want = v_checksum_want;
+        }
+
+        self.status = status;
+        status
+    }
+}
+
+// -------- func encoder.set_level
+
+impl Encoder {
+    pub fn set_level(&mut self, mut a_level: u32) {
+        if self.status.is_error() {
+            return;
+        }
+
+        self.f_flate.set_level(a_level);
+        self.f_level = u32::min(a_level, 9);
+        self.f_level_set = true;
+    }
+}
+
+// -------- func encoder.encode
+
+impl Encoder {
+    pub fn encode(
+        &mut self,
+        mut a_dst: wuffs_base::IoWriter,
+        mut a_src: wuffs_base::IoReader,
+    ) -> wuffs_base::Status {
+        if self.status.is_error() {
+            return self.status;
+        }
+        a_dst.derive();
+        a_src.derive();
+
+        let mut v_x: u16 = 0;
+        let mut v_checksum: u32 = 0;
+        let mut v_z: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut t_0: wuffs_base::Status = wuffs_base::Status::Ok;
+        let mut status = wuffs_base::Status::Ok;
+
+        let mut r = self.c_encode.coro_susp_point;
+        let mut csp: u32 = 0;
+        if r != 0 {
+            v_x = self.c_encode.v_x;
+            v_checksum = self.c_encode.v_checksum;
+            v_z = self.c_encode.v_z;
+        }
+
+        'exit: {
+            'suspend: {
+                if r == 0 {
+                    if !self.f_level_set {
+                        self.f_level_set = true;
+                        self.f_level = 6;
+                    }
+                    v_x = 30876;
+                    if self.f_level < 2 {
+                        v_x = 30721;
+                    } else if self.f_level < 6 {
+                        v_x = 30814;
+                    } else if self.f_level > 6 {
+                        v_x = 30938;
+                    }
+                }
+                if r == 0 || r == 1 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 1 {
+                        r = 0;
+                    }
+                    csp = 1;
+                    status = a_dst.write_u16be(v_x, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                if r == 0 {
+                    v_checksum = 0;
+                }
+                if r == 0 || r == 2 {
+                    'label_0: loop {
+                        if r == 0 {
+                            a_src.set_mark();
+                            t_0 = self.f_flate.encode(a_dst, a_src);
+                            v_z = t_0;
+                            v_checksum = self.f_checksum.update(a_src.since_mark());
+                            if v_z.is_ok() {
+                                break 'label_0;
+                            }
+                        }
+                        if r == 0 || r == 2 {
+                            status = v_z;
+                            if r == 2 {
+                                r = 0;
+                                status = wuffs_base::Status::Ok;
+                            } else {
+                                if status.is_error() {
+                                    break 'exit;
+                                }
+                                if !status.is_ok() {
+                                    csp = 2;
+                                    break 'suspend;
+                                }
+                            }
+                        }
+                    }
+                }
+                if r == 0 || r == 3 {
+                    if r == 0 {
+                        self.c_encode.scratch = 0;
+                    }
+                    if r == 3 {
+                        r = 0;
+                    }
+                    csp = 3;
+                    status = a_dst.write_u32be(v_checksum, &mut self.c_encode.scratch);
+                    if !status.is_ok() {
+                        break 'suspend;
+                    }
+                }
+                self.c_encode.coro_susp_point = 0;
+                break 'exit;
+            }
+
+            self.c_encode.coro_susp_point = csp;
+            self.c_encode.v_x = v_x;
+            self.c_encode.v_checksum = v_checksum;
+            self.c_encode.v_z = v_z;
+        }
+
+        self.status = status;
+        status
+    }
+}
@@ -86,6 +86,18 @@ var Types = []string{
 }
 
 var Funcs = []string{
+	"i8.max(x i8)(ret i8)",
+	"i8.min(x i8)(ret i8)",
+
+	"i16.max(x i16)(ret i16)",
+	"i16.min(x i16)(ret i16)",
+
+	"i32.max(x i32)(ret i32)",
+	"i32.min(x i32)(ret i32)",
+
+	"i64.max(x i64)(ret i64)",
+	"i64.min(x i64)(ret i64)",
+
 	"u8.high_bits(n u32[..8])(ret u8)",
 	"u8.low_bits(n u32[..8])(ret u8)",
 	"u8.max(x u8)(ret u8)",
@@ -108,6 +120,11 @@ var Funcs = []string{
 
 	// ---- rect_ie_u32
 
+	"rect_ie_u32.min_inclusive_x()(ret u32)",
+	"rect_ie_u32.min_inclusive_y()(ret u32)",
+	"rect_ie_u32.max_exclusive_x()(ret u32)",
+	"rect_ie_u32.max_exclusive_y()(ret u32)",
+
 	"rect_ie_u32.set_min_inclusive_x!(x u32)()",
 	"rect_ie_u32.set_min_inclusive_y!(y u32)()",
 	"rect_ie_u32.set_max_exclusive_x!(x u32)()",
@@ -122,6 +139,10 @@ var Funcs = []string{
 
 	// ---- image_buffer
 
+	"image_buffer.dirty_rect()(ret rect_ie_u32)",
+	"image_buffer.disposal()(ret u8)",
+	"image_buffer.duration()(ret u64)",
+	"image_buffer.palette()(ret slice u8)",
 	"image_buffer.plane(p u32[..3])(ret table u8)",
 	// Duration's upper bound is the maximum possible i64 value.
 	"image_buffer.update!(dirty_rect rect_ie_u32, duration u64[..0xFFFFFFFFFFFFFFF], " +
@@ -129,6 +150,10 @@ var Funcs = []string{
 
 	// ---- image_config
 
+	"image_config.height()(ret u32)",
+	"image_config.num_loops()(ret u32)",
+	"image_config.width()(ret u32)",
+
 	"image_config.initialize!(pixfmt u32, pixsub u32, width u32, height u32, num_loops u32)()",
 
 	// ---- io_reader
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/google/wuffs/lang/interval"
+
 	a "github.com/google/wuffs/lang/ast"
 	t "github.com/google/wuffs/lang/token"
 )
@@ -754,9 +756,36 @@ func (q *checker) bcheckExpr(n *a.Expr, depth uint32) (*big.Int, *big.Int, error
 	if err := q.optimizeNonSuspendible(n); err != nil {
 		return nil, nil, err
 	}
+	if err := q.dropWrittenFacts(n); err != nil {
+		return nil, nil, err
+	}
 	return nMin, nMax, nil
 }
 
+// dropWrittenFacts drops any facts involving w, if n is a call like
+// "w.copy_from_slice32!(etc)" that writes to an io_writer w. Such a call
+// changes w.available(), so facts like "w.available() >= 258" no longer hold.
+func (q *checker) dropWrittenFacts(n *a.Expr) error {
+	nReceiver, nMethod, _ := splitReceiverMethodArgs(n)
+	if nReceiver == nil {
+		return nil
+	}
+	if typ := nReceiver.MType(); typ == nil || !typ.IsIOType() || typ.QID()[1] != t.IDIOWriter {
+		return nil
+	}
+	switch nMethod {
+	case t.IDCopyFromHistory32, t.IDCopyFromReader32, t.IDCopyFromSlice, t.IDCopyFromSlice32:
+	default:
+		return nil
+	}
+	return q.facts.update(func(x *a.Expr) (*a.Expr, error) {
+		if x.Mentions(nReceiver) {
+			return nil, nil
+		}
+		return x, nil
+	})
+}
+
 func (q *checker) bcheckExpr1(n *a.Expr, depth uint32) (*big.Int, *big.Int, error) {
 	if cv := n.ConstValue(); cv != nil {
 		return cv, cv, nil
@@ -1054,15 +1083,10 @@ func (q *checker) bcheckExprBinaryOp1(op t.ID, lhs *a.Expr, lMin *big.Int, lMax
 		return q.bcheckExprXBinaryMinus(lhs, lMin, lMax, rhs, rMin, rMax)
 
 	case t.IDXBinaryStar:
-		// TODO: handle multiplication by negative numbers. Note that this
-		// might reverse the inequality: if 0 < a < b but c < 0 then a*c > b*c.
-		if lMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: multiply op argument %q is possibly negative", lhs.Str(q.tm))
-		}
-		if rMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: multiply op argument %q is possibly negative", rhs.Str(q.tm))
-		}
-		return big.NewInt(0).Mul(lMin, rMin), big.NewInt(0).Mul(lMax, rMax), nil
+		// Multiplying by a negative number can reverse the inequality: if 0 <
+		// a < b but c < 0 then a*c > b*c. The interval package handles that.
+		z := interval.IntRange{lMin, lMax}.Mul(interval.IntRange{rMin, rMax})
+		return z[0], z[1], nil
 
 	case t.IDXBinarySlash, t.IDXBinaryPercent:
 		// Prohibit division by zero.
@@ -1073,7 +1097,7 @@ func (q *checker) bcheckExprBinaryOp1(op t.ID, lhs *a.Expr, lMin *big.Int, lMax
 			return nil, nil, fmt.Errorf("check: divide/modulus op argument %q is possibly non-positive", rhs.Str(q.tm))
 		}
 		if op == t.IDXBinarySlash {
-			return big.NewInt(0).Mul(lMin, rMax), big.NewInt(0).Mul(lMax, rMin), nil
+			return big.NewInt(0).Quo(lMin, rMax), big.NewInt(0).Quo(lMax, rMin), nil
 		}
 		return zero, big.NewInt(0).Sub(rMax, one), nil
 
@@ -1090,12 +1114,25 @@ func (q *checker) bcheckExprBinaryOp1(op t.ID, lhs *a.Expr, lMin *big.Int, lMax
 		return big.NewInt(0).Lsh(lMin, uint(rMin.Uint64())), big.NewInt(0).Lsh(lMax, uint(rMax.Uint64())), nil
 
 	case t.IDXBinaryShiftR:
-		if lMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: shift op argument %q is possibly negative", lhs.Str(q.tm))
-		}
 		if rMin.Sign() < 0 {
 			return nil, nil, fmt.Errorf("check: shift op argument %q is possibly negative", rhs.Str(q.tm))
 		}
+		if lMin.Sign() < 0 {
+			// Shifting a negative (signed) number right is an arithmetic
+			// shift, rounding towards negative infinity. It is only defined
+			// for shifts less than the number of bits in the type.
+			nBits := maxIntBits
+			if qid := lhs.MType().QID(); qid[0] == t.IDBase && qid[1] < t.ID(len(numTypeBounds)) {
+				if b := numTypeBounds[qid[1]]; b[0] != nil && b[0].Sign() < 0 {
+					nBits = big.NewInt(int64(b[1].BitLen() + 1))
+				}
+			}
+			if rMax.Cmp(nBits) >= 0 {
+				return nil, nil, fmt.Errorf("check: shift %q out of range", rhs.Str(q.tm))
+			}
+			z, _ := interval.IntRange{lMin, lMax}.Rsh(interval.IntRange{rMin, rMax})
+			return z[0], z[1], nil
+		}
 		if rMin.Cmp(maxIntBits) >= 0 {
 			return zero, zero, nil
 		}
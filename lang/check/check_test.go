@@ -315,3 +315,136 @@ func TestBuiltInTypeMap(tt *testing.T) {
 		}
 	}
 }
+
+// checkFunc checks a package whose only func, foo, has the given args and
+// body.
+func checkFunc(args string, body string) error {
+	const filename = "test.wuffs"
+	src := "packageid \"test\"\npri func foo(" + args + ")() {\n" + body + "\n}\n"
+
+	tm := &t.Map{}
+	tokens, _, err := t.Tokenize(tm, filename, []byte(src))
+	if err != nil {
+		return fmt.Errorf("Tokenize: %v", err)
+	}
+	file, err := parse.Parse(tm, filename, tokens, nil)
+	if err != nil {
+		return fmt.Errorf("Parse: %v", err)
+	}
+	_, err = Check(tm, []*a.File{file}, nil)
+	return err
+}
+
+func TestWrittenFacts(tt *testing.T) {
+	const args = "dst base.io_writer, s slice base.u8"
+	testCases := []struct {
+		body string
+		ok   bool
+	}{{
+		"if in.dst.available() >= 8 {\n" +
+			"assert in.dst.available() >= 8\n" +
+			"}",
+		true,
+	}, {
+		"if in.dst.available() >= 8 {\n" +
+			"in.dst.copy_from_slice32!(s:in.s, length:4)\n" +
+			"assert in.dst.available() >= 8\n" +
+			"}",
+		false,
+	}, {
+		"var n base.u32\n" +
+			"if in.dst.available() >= 8 {\n" +
+			"n = in.dst.copy_from_slice32!(s:in.s, length:4)\n" +
+			"assert in.dst.available() >= 8\n" +
+			"}",
+		false,
+	}, {
+		"if in.dst.available() >= 8 {\n" +
+			"in.dst.copy_from_slice32!(s:in.s, length:4)\n" +
+			"if in.dst.available() >= 4 {\n" +
+			"assert in.dst.available() >= 4\n" +
+			"}\n" +
+			"}",
+		true,
+	}}
+
+	for _, tc := range testCases {
+		err := checkFunc(args, tc.body)
+		if tc.ok {
+			if err != nil {
+				tt.Errorf("%q: got %v, want no error", tc.body, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), "cannot prove") {
+			tt.Errorf("%q: got %v, want a \"cannot prove\" error", tc.body, err)
+		}
+	}
+}
+
+func TestSignedBinaryOps(tt *testing.T) {
+	const args = "" +
+		"a base.i32[-8 .. 8], " +
+		"n base.i32[-8 .. -2], " +
+		"m base.i32[-3 .. -1], " +
+		"p base.i32[1 .. 5], " +
+		"b base.i32[-3 .. 5], " +
+		"s base.u32[..2], " +
+		"t base.u32[..64], " +
+		"u base.u32[..40], " +
+		"v base.u32[..31], " +
+		"w base.u32[..32], " +
+		"c base.i8[-4 .. -1], " +
+		"d base.u32[..7]"
+	testCases := []struct {
+		body    string
+		wantErr string
+	}{
+		// Mixed-sign ranges: the extremes are -8 * 5 and 8 * 5.
+		{"var x base.i32[-40 .. 40] = in.a * in.b", ""},
+		{"var x base.i32[-39 .. 40] = in.a * in.b", "not within bounds"},
+		{"var x base.i32[-40 .. 39] = in.a * in.b", "not within bounds"},
+
+		// Two negative ranges: the product is positive.
+		{"var x base.i32[2 .. 24] = in.n * in.m", ""},
+		{"var x base.i32[3 .. 24] = in.n * in.m", "not within bounds"},
+		{"var x base.i32[2 .. 23] = in.n * in.m", "not within bounds"},
+
+		// A negative range times a positive one: the product is negative.
+		{"var x base.i32[-40 .. -2] = in.n * in.p", ""},
+		{"var x base.i32[-40 .. -3] = in.n * in.p", "not within bounds"},
+		{"var x base.i32[-39 .. -2] = in.n * in.p", "not within bounds"},
+
+		// Shifting a negative value right rounds towards negative infinity:
+		// -8 >> 0 is -8 and -2 >> 2 is -1, so the result is still negative.
+		{"var x base.i32[-8 .. -1] = in.n >> in.s", ""},
+		{"var x base.i32[-8 .. -2] = in.n >> in.s", "not within bounds"},
+		{"var x base.i32[-7 .. -1] = in.n >> in.s", "not within bounds"},
+
+		// A mixed-sign range shifted right.
+		{"var x base.i32[-8 .. 8] = in.a >> in.s", ""},
+		{"var x base.i32[-8 .. 7] = in.a >> in.s", "not within bounds"},
+
+		// A possibly negative value cannot be shifted right by the number of
+		// bits in its type (32 for a base.i32) or more, or shifted left at
+		// all.
+		{"var x base.i32 = in.n >> in.t", "out of range"},
+		{"var x base.i32 = in.a >> in.t", "out of range"},
+		{"var x base.i32 = in.n >> in.u", "out of range"},
+		{"var x base.i32 = in.n >> in.w", "out of range"},
+		{"var x base.i32[-8 .. -1] = in.n >> in.v", ""},
+		{"var x base.i8 = in.c >> in.w", "out of range"},
+		{"var x base.i8[-4 .. -1] = in.c >> in.d", ""},
+		{"var x base.i32 = in.n << in.s", "possibly negative"},
+		{"var x base.i32 = in.a << in.s", "possibly negative"},
+	}
+
+	for _, tc := range testCases {
+		err := checkFunc(args, tc.body)
+		if tc.wantErr == "" {
+			if err != nil {
+				tt.Errorf("%q: got %v, want no error", tc.body, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			tt.Errorf("%q: got %v, want an error containing %q", tc.body, err, tc.wantErr)
+		}
+	}
+}
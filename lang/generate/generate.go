@@ -15,6 +15,7 @@
 package generate
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
@@ -127,6 +128,96 @@ func ParseFiles(tm *t.Map, filenames []string, opts *parse.Options) (files []*a.
 	return files, nil
 }
 
+// Declarations returns the declarations-only form of a package's Wuffs
+// source code, as written to the gen/wuffs directory: the public structs,
+// statuses and function signatures, without any function bodies. It is what
+// another package's "use" declaration resolves to.
+func Declarations(tm *t.Map, files []*a.File) ([]byte, error) {
+	pkgIDNode := (*a.PackageID)(nil)
+	for _, f := range files {
+		for _, n := range f.TopLevelDecls() {
+			if n.Kind() == a.KPackageID {
+				pkgIDNode = n.PackageID()
+			}
+		}
+	}
+	if pkgIDNode == nil {
+		return nil, fmt.Errorf("missing packageid declaration")
+	}
+	pkgIDStr, ok := t.Unescape(pkgIDNode.ID().Str(tm))
+	if !ok {
+		return nil, fmt.Errorf("invalid packageid declaration")
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by running \"wuffs gen\". DO NOT EDIT.\n\n")
+	fmt.Fprintf(out, "packageid %q\n\n", pkgIDStr)
+
+	for _, f := range files {
+		for _, n := range f.TopLevelDecls() {
+			switch n.Kind() {
+			case a.KConst:
+				n := n.Const()
+				if !n.Public() {
+					continue
+				}
+				return nil, fmt.Errorf("TODO: Declarations for consts")
+
+			case a.KFunc:
+				n := n.Func()
+				if !n.Public() {
+					continue
+				}
+				effect := ""
+				if n.Suspendible() {
+					effect = "?"
+				} else if n.Impure() {
+					effect = "!"
+				}
+				if n.Receiver().IsZero() {
+					return nil, fmt.Errorf("TODO: Declarations for a free-standing function")
+				}
+				// TODO: look at n.Asserts().
+				fmt.Fprintf(out, "pub func %s.%s%s(", n.Receiver().Str(tm), n.FuncName().Str(tm), effect)
+				for i, param := range [2]*a.Struct{n.In(), n.Out()} {
+					if i > 0 {
+						fmt.Fprintf(out, ")(")
+					}
+					for j, field := range param.Fields() {
+						field := field.Field()
+						if j > 0 {
+							fmt.Fprintf(out, ", ")
+						}
+						// TODO: what happens if the XType is from another
+						// package?
+						fmt.Fprintf(out, "%s %s", field.Name().Str(tm), field.XType().Str(tm))
+					}
+				}
+				fmt.Fprintf(out, ") { }\n")
+
+			case a.KStatus:
+				n := n.Status()
+				if !n.Public() {
+					continue
+				}
+				fmt.Fprintf(out, "pub %s %s\n", n.Keyword().Str(tm), n.QID().Str(tm))
+
+			case a.KStruct:
+				n := n.Struct()
+				if !n.Public() {
+					continue
+				}
+				effect := ""
+				if n.Suspendible() {
+					effect = "?"
+				}
+				fmt.Fprintf(out, "pub struct %s%s()\n", n.QID().Str(tm), effect)
+			}
+		}
+	}
+	return out.Bytes(), nil
+}
+
 func resolveUse(usePath string) ([]byte, error) {
 	wuffsRoot, err := WuffsRoot()
 	if err != nil {
@@ -0,0 +1,333 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/wuffs/lib/go/base"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// evalBuiltinCall evaluates n if it is a call to a built-in method, such as
+// "x.low_bits(n:3)" or "in.src.read_u8?()". It returns false if n is a call
+// to a user-defined method.
+func (f *frame) evalBuiltinCall(n *a.Expr) (value, bool) {
+	if n.Operator() != t.IDOpenParen {
+		return nil, false
+	}
+	method := n.LHS().Expr()
+	recv := method.LHS().Expr()
+	recvTyp := recv.MType()
+	args := n.Args()
+
+	switch recvTyp.Decorator() {
+	case 0:
+		// No-op.
+	case t.IDPtr:
+		if recvTyp.Inner().Decorator() != 0 || recvTyp.Inner().QID()[0] != t.IDBase {
+			return nil, false
+		}
+		return f.evalBuiltinMethod(f.eval(recv), method.Ident(), args), true
+	case t.IDSlice:
+		return f.evalBuiltinSlice(f.eval(recv).([]byte), method.Ident(), args), true
+	case t.IDTable:
+		return f.evalBuiltinTable(f.eval(recv).(base.TableU8), method.Ident(), args), true
+	default:
+		return nil, false
+	}
+
+	qid := recvTyp.QID()
+	if qid[0] != t.IDBase {
+		return nil, false
+	}
+	if qid[1].IsNumType() {
+		return f.evalBuiltinNumType(n, f.eval(recv).(uint64), method.Ident(), args), true
+	}
+	switch qid[1] {
+	case t.IDIOReader:
+		return f.evalBuiltinIOReader(n, recv, method.Ident(), args), true
+	case t.IDIOWriter:
+		return f.evalBuiltinIOWriter(n, recv, method.Ident(), args), true
+	case t.IDStatus:
+		z, _ := f.eval(recv).(error)
+		switch method.Ident() {
+		case t.IDIsError:
+			return base.IsError(z), true
+		case t.IDIsOK:
+			return z == nil, true
+		case t.IDIsSuspension:
+			return base.IsSuspension(z), true
+		}
+		panic(fmt.Errorf("no such built-in %q", n.Str(f.pkg.tm)))
+	}
+	return f.evalBuiltinMethod(f.eval(recv), method.Ident(), args), true
+}
+
+// evalBuiltinMethod calls the lib/go/base method of the same (camel-cased)
+// name, such as "ImageConfig.Initialize" or "RectIEU32.SetMinInclusiveX".
+func (f *frame) evalBuiltinMethod(recv value, method t.ID, args []*a.Node) value {
+	name := goName(method.Str(f.pkg.tm))
+	m := reflect.ValueOf(recv).MethodByName(name)
+	if !m.IsValid() {
+		panic(fmt.Errorf("no such built-in %T.%s", recv, name))
+	}
+	in := make([]reflect.Value, len(args))
+	for i, o := range args {
+		in[i] = toReflect(f.eval(o.Arg().Value()), m.Type().In(i))
+	}
+	out := m.Call(in)
+	if len(out) == 0 {
+		return nil
+	}
+	switch x := out[0]; x.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return x.Uint()
+	default:
+		return x.Interface()
+	}
+}
+
+func toReflect(v value, typ reflect.Type) reflect.Value {
+	x := reflect.ValueOf(v)
+	switch typ.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return x.Convert(typ)
+	case reflect.Struct:
+		// Rect values are held by pointer, so that their setter methods can
+		// modify them in place, but are passed by value.
+		if x.Kind() == reflect.Ptr {
+			return x.Elem()
+		}
+	case reflect.Slice:
+		if v == nil {
+			return reflect.Zero(typ)
+		}
+	}
+	return x
+}
+
+// goName converts a Wuffs name like "set_min_inclusive_x" to a Go name like
+// "SetMinInclusiveX".
+func goName(s string) string {
+	b := strings.Builder{}
+	for _, w := range strings.Split(s, "_") {
+		if w != "" {
+			b.WriteString(strings.ToUpper(w[:1]))
+			b.WriteString(w[1:])
+		}
+	}
+	return b.String()
+}
+
+func (f *frame) evalBuiltinNumType(n *a.Expr, x uint64, method t.ID, args []*a.Node) value {
+	bits := numBits(n.LHS().Expr().LHS().Expr().MType())
+	y := f.eval(args[0].Arg().Value()).(uint64)
+	switch method {
+	case t.IDLowBits:
+		return mask(x, uint32(y))
+	case t.IDHighBits:
+		if y == 0 {
+			return uint64(0)
+		}
+		return x >> (uint64(bits) - y)
+	case t.IDMax:
+		if x > y {
+			return x
+		}
+		return y
+	case t.IDMin:
+		if x < y {
+			return x
+		}
+		return y
+	}
+	panic(fmt.Errorf("no such built-in %q", n.Str(f.pkg.tm)))
+}
+
+func (f *frame) evalBuiltinSlice(s []byte, method t.ID, args []*a.Node) value {
+	switch method {
+	case t.IDCopyFromSlice:
+		return uint64(copy(s, f.eval(args[0].Arg().Value()).([]byte)))
+	case t.IDLength:
+		return uint64(len(s))
+	case t.IDPrefix:
+		return base.SliceU8Prefix(s, f.eval(args[0].Arg().Value()).(uint64))
+	case t.IDSuffix:
+		return base.SliceU8Suffix(s, f.eval(args[0].Arg().Value()).(uint64))
+	}
+	panic(fmt.Errorf("no such built-in slice method %q", method.Str(f.pkg.tm)))
+}
+
+func (f *frame) evalBuiltinTable(tab base.TableU8, method t.ID, args []*a.Node) value {
+	switch method {
+	case t.IDHeight:
+		return uint64(tab.Height)
+	case t.IDStride:
+		return uint64(tab.Stride)
+	case t.IDWidth:
+		return uint64(tab.Width)
+	case t.IDRow:
+		return tab.Row(uint32(f.eval(args[0].Arg().Value()).(uint64)))
+	}
+	panic(fmt.Errorf("no such built-in table method %q", method.Str(f.pkg.tm)))
+}
+
+// check acts on the status of a suspendible built-in call. It returns true if
+// the call succeeded. An error fails the enclosing function call. A
+// suspension suspends the coroutine, and check returns false so that the
+// caller retries the built-in call after resuming.
+func (f *frame) check(status error) bool {
+	if status == nil {
+		return true
+	}
+	if base.IsSuspension(status) {
+		f.suspend(status)
+		return false
+	}
+	panic(exitStatus{status})
+}
+
+func (f *frame) evalBuiltinIOReader(n *a.Expr, recv *a.Expr, method t.ID, args []*a.Node) value {
+	switch method {
+	case t.IDSet:
+		s := f.eval(args[0].Arg().Value()).([]byte)
+		closed := f.eval(args[1].Arg().Value()).(bool)
+		f.eval(recv).(*base.IOReader).Set(&base.IOBuffer{}, s, closed)
+		return nil
+
+	case t.IDUnreadU8:
+		if status := f.eval(recv).(*base.IOReader).UnreadU8(); status != nil {
+			panic(exitStatus{status})
+		}
+		return nil
+
+	case t.IDReadU8:
+		for {
+			x, status := f.eval(recv).(*base.IOReader).ReadU8()
+			if f.check(status) {
+				return uint64(x)
+			}
+		}
+
+	case t.IDReadU16BE, t.IDReadU16LE, t.IDReadU24BE, t.IDReadU24LE,
+		t.IDReadU32BE, t.IDReadU32LE, t.IDReadU40BE, t.IDReadU40LE,
+		t.IDReadU48BE, t.IDReadU48LE, t.IDReadU56BE, t.IDReadU56LE,
+		t.IDReadU64BE, t.IDReadU64LE:
+		// A partially complete read is held in scratch, which lives on the
+		// coroutine's goroutine's stack, so that resuming continues where it
+		// left off.
+		name := goName(method.Str(f.pkg.tm))
+		name = name[:len(name)-2] + strings.ToUpper(name[len(name)-2:])
+		scratch := uint64(0)
+		for {
+			r := reflect.ValueOf(f.eval(recv).(*base.IOReader))
+			out := r.MethodByName(name).Call([]reflect.Value{reflect.ValueOf(&scratch)})
+			status, _ := out[1].Interface().(error)
+			if f.check(status) {
+				return out[0].Uint()
+			}
+		}
+
+	case t.IDSkip32, t.IDSkip64:
+		scratch := f.eval(args[0].Arg().Value()).(uint64)
+		for !f.check(f.eval(recv).(*base.IOReader).Skip(&scratch)) {
+		}
+		return nil
+
+	case t.IDAvailable:
+		return f.eval(recv).(*base.IOReader).Available()
+	case t.IDSetLimit:
+		f.eval(recv).(*base.IOReader).SetLimit(f.eval(args[0].Arg().Value()).(uint64))
+		return nil
+	case t.IDSetMark:
+		f.eval(recv).(*base.IOReader).SetMark()
+		return nil
+	case t.IDSinceMark:
+		return f.eval(recv).(*base.IOReader).SinceMark()
+	}
+	panic(fmt.Errorf("no such built-in %q", n.Str(f.pkg.tm)))
+}
+
+func (f *frame) evalBuiltinIOWriter(n *a.Expr, recv *a.Expr, method t.ID, args []*a.Node) value {
+	switch method {
+	case t.IDSet:
+		s := f.eval(args[0].Arg().Value()).([]byte)
+		f.eval(recv).(*base.IOWriter).Set(&base.IOBuffer{}, s)
+		return nil
+
+	case t.IDWriteU8, t.IDWriteU16BE, t.IDWriteU16LE, t.IDWriteU24BE, t.IDWriteU24LE,
+		t.IDWriteU32BE, t.IDWriteU32LE, t.IDWriteU40BE, t.IDWriteU40LE,
+		t.IDWriteU48BE, t.IDWriteU48LE, t.IDWriteU56BE, t.IDWriteU56LE,
+		t.IDWriteU64BE, t.IDWriteU64LE:
+		x := f.eval(args[0].Arg().Value()).(uint64)
+		size, be := uint64(1), false
+		if method != t.IDWriteU8 {
+			size = 2 + uint64(method-t.IDWriteU16BE)/2
+			be = (method-t.IDWriteU16BE)%2 == 0
+		}
+		// A multi-byte write is all or nothing: it suspends, without writing
+		// anything, until there is room for all of the bytes.
+		for !f.check(writeUXX(f.eval(recv).(*base.IOWriter), x, size, be)) {
+		}
+		return nil
+
+	case t.IDAvailable:
+		return f.eval(recv).(*base.IOWriter).Available()
+	case t.IDSetLimit:
+		f.eval(recv).(*base.IOWriter).SetLimit(f.eval(args[0].Arg().Value()).(uint64))
+		return nil
+	case t.IDSetMark:
+		f.eval(recv).(*base.IOWriter).SetMark()
+		return nil
+	case t.IDSinceMark:
+		return f.eval(recv).(*base.IOWriter).SinceMark()
+
+	case t.IDCopyFromHistory32:
+		distance := f.eval(args[0].Arg().Value()).(uint64)
+		length := f.eval(args[1].Arg().Value()).(uint64)
+		return uint64(f.eval(recv).(*base.IOWriter).CopyFromHistory32(uint32(distance), uint32(length)))
+	case t.IDCopyFromReader32:
+		r := f.eval(args[0].Arg().Value()).(*base.IOReader)
+		length := f.eval(args[1].Arg().Value()).(uint64)
+		return uint64(f.eval(recv).(*base.IOWriter).CopyFromReader32(r, uint32(length)))
+	case t.IDCopyFromSlice:
+		s := f.eval(args[0].Arg().Value()).([]byte)
+		return f.eval(recv).(*base.IOWriter).CopyFromSlice(s)
+	case t.IDCopyFromSlice32:
+		s := f.eval(args[0].Arg().Value()).([]byte)
+		length := f.eval(args[1].Arg().Value()).(uint64)
+		return uint64(f.eval(recv).(*base.IOWriter).CopyFromSlice32(s, uint32(length)))
+	}
+	panic(fmt.Errorf("no such built-in %q", n.Str(f.pkg.tm)))
+}
+
+func writeUXX(w *base.IOWriter, x uint64, size uint64, be bool) error {
+	if w.Available() < size {
+		return base.SuspensionShortWrite
+	}
+	for i := uint64(0); i < size; i++ {
+		shift := 8 * i
+		if be {
+			shift = 8 * (size - 1 - i)
+		}
+		w.WriteU8(uint8(x >> shift))
+	}
+	return nil
+}
@@ -0,0 +1,535 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/google/wuffs/lib/go/base"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// errAbandoned is panicked, and recovered, to unwind the goroutine of a
+// suspended coroutine that will never be resumed.
+var errAbandoned = errors.New("interp: abandoned coroutine")
+
+// exitStatus is panicked, and recovered by the enclosing function call, when
+// a suspendible call (with the "?" effect) fails, as opposed to suspends.
+type exitStatus struct {
+	status error
+}
+
+type structValue struct {
+	typ    *structType
+	fields []value
+
+	// status is the sticky status, for a suspendible struct type. A public
+	// method returns any previous error instead of running again.
+	status error
+
+	// coros are the coroutines in progress, one per suspendible method.
+	coros map[*a.Func]*coroutine
+}
+
+func newStructValue(st *structType) *structValue {
+	fields := st.decl.Fields()
+	v := &structValue{
+		typ:    st,
+		fields: make([]value, len(fields)),
+	}
+	for i, o := range fields {
+		v.fields[i] = st.pkg.zero(o.Field().XType())
+	}
+	return v
+}
+
+// reset sets v to its zero value, abandoning its (and its fields')
+// coroutines.
+func (v *structValue) reset() {
+	for _, co := range v.coros {
+		close(co.resume)
+	}
+	for _, f := range v.fields {
+		if f, ok := f.(*structValue); ok {
+			f.reset()
+		}
+	}
+	*v = *newStructValue(v.typ)
+}
+
+// method returns the method with the given name, an ID in caller's token map.
+func (v *structValue) method(caller *Package, name t.ID) *a.Func {
+	if caller == v.typ.pkg {
+		return v.typ.methods[name]
+	}
+	return v.typ.methodsByName[name.Str(caller.tm)]
+}
+
+// coroutine is a suspendible method call in progress. Its goroutine sends on
+// the yield channel when it suspends or finishes, and resuming it sends the
+// new call's arguments on the resume channel.
+type coroutine struct {
+	resume  chan []value
+	yield   chan result
+	running bool
+}
+
+type result struct {
+	status   error
+	panicked interface{}
+	done     bool
+}
+
+// frame is the state of a function call: its receiver, arguments and local
+// variables.
+type frame struct {
+	pkg    *Package
+	fn     *a.Func
+	recv   *structValue
+	args   []value
+	locals map[t.ID]value
+
+	// co is the coroutine that this (suspendible) function call runs on.
+	co *coroutine
+
+	// jump is the loop targeted by a pending break or continue.
+	jump a.Loop
+
+	// ret is the returned value. For suspendible functions, it is a status.
+	ret value
+}
+
+// call calls fn, a method of recv, with the given arguments. For a
+// suspendible method, the result is the call's status.
+func call(recv *structValue, fn *a.Func, args []value) value {
+	pkg := recv.typ.pkg
+	if fn.Public() {
+		if ret, ok := checkPublicCall(recv, fn, args); !ok {
+			return ret
+		}
+	}
+
+	f := &frame{
+		pkg:  pkg,
+		fn:   fn,
+		recv: recv,
+		args: args,
+	}
+	if !fn.Suspendible() {
+		f.locals = map[t.ID]value{}
+		f.execBlock(fn.Body())
+		return f.ret
+	}
+
+	co := recv.coros[fn]
+	if co == nil {
+		co = &coroutine{
+			resume: make(chan []value),
+			yield:  make(chan result),
+		}
+		if recv.coros == nil {
+			recv.coros = map[*a.Func]*coroutine{}
+		}
+		recv.coros[fn] = co
+		f.locals = map[t.ID]value{}
+		f.co = co
+		co.running = true
+		go f.start()
+	} else if co.running {
+		panic(fmt.Errorf("recursive call to %s", fn.QQID().Str(pkg.tm)))
+	} else {
+		co.running = true
+		co.resume <- args
+	}
+
+	r := <-co.yield
+	co.running = false
+	if r.done {
+		delete(recv.coros, fn)
+	}
+	if r.panicked != nil {
+		panic(r.panicked)
+	}
+	if fn.Public() {
+		recv.status = r.status
+	}
+	return r.status
+}
+
+// checkPublicCall performs the run-time checks on entering a public method.
+// Like the generated C and Go code, it checks for a previous (sticky) error
+// and for out-of-bounds arguments. It also derives the io_reader and
+// io_writer arguments' bounds. If the call should not proceed, it returns
+// false and the result to return instead.
+func checkPublicCall(recv *structValue, fn *a.Func, args []value) (ret value, ok bool) {
+	pkg := recv.typ.pkg
+	zeroRet := value(nil)
+	if !fn.Suspendible() {
+		if out := fn.Out().Fields(); len(out) == 1 {
+			zeroRet = pkg.zero(out[0].Field().XType())
+		}
+	}
+
+	suspendibleRecv := recv.typ.decl.Suspendible()
+	if suspendibleRecv && base.IsError(recv.status) {
+		if fn.Suspendible() {
+			return recv.status, false
+		}
+		return zeroRet, false
+	}
+
+	for i, o := range fn.In().Fields() {
+		typ := o.Field().XType()
+		switch x := args[i].(type) {
+		case uint64:
+			if typ.IsRefined() && !inBounds(x, typ) {
+				if suspendibleRecv {
+					recv.status = base.ErrBadArgument
+				}
+				if fn.Suspendible() {
+					return base.ErrBadArgument, false
+				}
+				return zeroRet, false
+			}
+		case *base.IOReader:
+			x.Derive()
+		case *base.IOWriter:
+			x.Derive()
+		}
+	}
+	return nil, true
+}
+
+func inBounds(x uint64, typ *a.TypeExpr) bool {
+	b := typ.Bounds()
+	if b[0] != nil {
+		if cv := b[0].ConstValue(); cv != nil && cv.Cmp(new(big.Int).SetUint64(x)) > 0 {
+			return false
+		}
+	}
+	if b[1] != nil {
+		if cv := b[1].ConstValue(); cv != nil && cv.Cmp(new(big.Int).SetUint64(x)) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// start runs a suspendible function call on its coroutine's goroutine.
+func (f *frame) start() {
+	co := f.co
+	defer func() {
+		if x := recover(); x != nil {
+			if x == errAbandoned {
+				return
+			}
+			co.yield <- result{panicked: x, done: true}
+		}
+	}()
+
+	status := f.runSuspendible()
+	co.yield <- result{status: status, done: true}
+}
+
+func (f *frame) runSuspendible() (status error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(exitStatus); ok {
+				status = e.status
+				return
+			}
+			panic(x)
+		}
+	}()
+
+	f.execBlock(f.fn.Body())
+	status, _ = f.ret.(error)
+	return status
+}
+
+// suspend suspends the coroutine, returning when it is resumed, with the new
+// call's arguments.
+func (f *frame) suspend(status error) {
+	f.co.yield <- result{status: status}
+	args, ok := <-f.co.resume
+	if !ok {
+		panic(errAbandoned)
+	}
+	f.args = args
+}
+
+// argIndex returns the index of the argument with the given name.
+func (f *frame) argIndex(name t.ID) int {
+	for i, o := range f.fn.In().Fields() {
+		if o.Field().Name() == name {
+			return i
+		}
+	}
+	panic(fmt.Errorf("no argument %q", name.Str(f.pkg.tm)))
+}
+
+type control uint32
+
+const (
+	ctrlNone = control(iota)
+	ctrlBreak
+	ctrlContinue
+	ctrlReturn
+)
+
+func (f *frame) execBlock(block []*a.Node) control {
+	for _, o := range block {
+		if c := f.exec(o); c != ctrlNone {
+			return c
+		}
+	}
+	return ctrlNone
+}
+
+func (f *frame) exec(n *a.Node) control {
+	switch n.Kind() {
+	case a.KAssert:
+		// Assertions only apply at compile-time.
+		return ctrlNone
+	case a.KAssign:
+		f.execAssign(n.Assign())
+		return ctrlNone
+	case a.KExpr:
+		f.eval(n.Expr())
+		return ctrlNone
+	case a.KIOBind:
+		return f.execIOBind(n.IOBind())
+	case a.KIf:
+		return f.execIf(n.If())
+	case a.KIterate:
+		return f.execIterate(n.Iterate())
+	case a.KJump:
+		n := n.Jump()
+		f.jump = n.JumpTarget()
+		if n.Keyword() == t.IDBreak {
+			return ctrlBreak
+		}
+		return ctrlContinue
+	case a.KRet:
+		return f.execRet(n.Ret())
+	case a.KVar:
+		n := n.Var()
+		if v := n.Value(); v != nil {
+			f.locals[n.Name()] = f.eval(v)
+		} else {
+			f.locals[n.Name()] = f.pkg.zero(n.XType())
+		}
+		return ctrlNone
+	case a.KWhile:
+		return f.execWhile(n.While())
+	}
+	panic(fmt.Errorf("unrecognized ast.Kind (%s) for exec", n.Kind()))
+}
+
+func (f *frame) execAssign(n *a.Assign) {
+	op := n.Operator()
+	rhs := f.eval(n.RHS())
+	if op == t.IDEq {
+		f.assign(n.LHS(), rhs)
+		return
+	}
+	lhs := f.eval(n.LHS()).(uint64)
+	bits := numBits(n.LHS().MType())
+	f.assign(n.LHS(), arith(assignOps[op], lhs, rhs.(uint64), bits))
+}
+
+var assignOps = [...]t.ID{
+	t.IDPlusEq:          t.IDXBinaryPlus,
+	t.IDMinusEq:         t.IDXBinaryMinus,
+	t.IDStarEq:          t.IDXBinaryStar,
+	t.IDSlashEq:         t.IDXBinarySlash,
+	t.IDShiftLEq:        t.IDXBinaryShiftL,
+	t.IDShiftREq:        t.IDXBinaryShiftR,
+	t.IDAmpEq:           t.IDXBinaryAmp,
+	t.IDPipeEq:          t.IDXBinaryPipe,
+	t.IDHatEq:           t.IDXBinaryHat,
+	t.IDPercentEq:       t.IDXBinaryPercent,
+	t.IDTildeModPlusEq:  t.IDXBinaryTildeModPlus,
+	t.IDTildeModMinusEq: t.IDXBinaryTildeModMinus,
+	t.IDTildeSatPlusEq:  t.IDXBinaryTildeSatPlus,
+	t.IDTildeSatMinusEq: t.IDXBinaryTildeSatMinus,
+}
+
+// assign sets the variable, field or element denoted by lhs.
+func (f *frame) assign(lhs *a.Expr, v value) {
+	switch lhs.Operator() {
+	case 0:
+		f.locals[lhs.Ident()] = v
+		return
+
+	case t.IDDot:
+		if x := lhs.LHS().Expr(); x.Operator() == 0 && x.Ident() == t.IDIn {
+			f.args[f.argIndex(lhs.Ident())] = v
+			return
+		}
+		s := f.eval(lhs.LHS().Expr()).(*structValue)
+		s.fields[s.typ.fields[lhs.Ident()]] = v
+		return
+
+	case t.IDOpenBracket:
+		i := f.eval(lhs.RHS().Expr()).(uint64)
+		switch x := f.eval(lhs.LHS().Expr()).(type) {
+		case []byte:
+			x[i] = uint8(v.(uint64))
+		case []uint64:
+			x[i] = v.(uint64)
+		case []value:
+			x[i] = v
+		}
+		return
+	}
+	panic(fmt.Errorf("cannot assign to %q", lhs.Str(f.pkg.tm)))
+}
+
+func (f *frame) execIOBind(n *a.IOBind) control {
+	// Save the io_reader and io_writer values, including their bounds, and
+	// restore them afterwards.
+	inFields := n.InFields()
+	saved := make([]value, len(inFields))
+	for i, o := range inFields {
+		switch x := f.eval(o.Expr()).(type) {
+		case *base.IOReader:
+			saved[i] = *x
+		case *base.IOWriter:
+			saved[i] = *x
+		}
+	}
+
+	c := f.execBlock(n.Body())
+
+	for i := len(inFields) - 1; i >= 0; i-- {
+		switch x := f.eval(inFields[i].Expr()).(type) {
+		case *base.IOReader:
+			*x = saved[i].(base.IOReader)
+		case *base.IOWriter:
+			*x = saved[i].(base.IOWriter)
+		}
+	}
+	return c
+}
+
+func (f *frame) execIf(n *a.If) control {
+	for ; n != nil; n = n.ElseIf() {
+		if f.eval(n.Condition()).(bool) {
+			return f.execBlock(n.BodyIfTrue())
+		}
+		if bif := n.BodyIfFalse(); len(bif) > 0 {
+			return f.execBlock(bif)
+		}
+	}
+	return ctrlNone
+}
+
+func (f *frame) execIterate(n *a.Iterate) control {
+	vars := n.Variables()
+	if len(vars) == 0 {
+		return ctrlNone
+	}
+	if len(vars) != 1 {
+		panic(fmt.Errorf("TODO: iterate over more than one variable"))
+	}
+	v := vars[0].Var()
+	s := f.eval(v.Value()).([]byte)
+
+	// Unrolling does not change the semantics. Each round steps through the
+	// slice in chunks of that round's length, until fewer remain.
+	i := 0
+	for o := n; o != nil; o = o.ElseIterate() {
+		length := o.Length().SmallPowerOf2Value()
+		for len(s)-i >= length {
+			f.locals[v.Name()] = s[i : i+length]
+			switch c := f.execBlock(o.Body()); c {
+			case ctrlBreak, ctrlContinue:
+				if f.jump != a.Loop(n) {
+					return c
+				}
+				f.jump = nil
+				if c == ctrlBreak {
+					return ctrlNone
+				}
+			case ctrlReturn:
+				return c
+			}
+			i += length
+		}
+	}
+	return ctrlNone
+}
+
+func (f *frame) execWhile(n *a.While) control {
+	cond := n.Condition()
+	for {
+		if !f.eval(cond).(bool) {
+			return ctrlNone
+		}
+		switch c := f.execBlock(n.Body()); c {
+		case ctrlBreak, ctrlContinue:
+			if f.jump != a.Loop(n) {
+				return c
+			}
+			f.jump = nil
+			if c == ctrlBreak {
+				return ctrlNone
+			}
+		case ctrlReturn:
+			return c
+		}
+	}
+}
+
+func (f *frame) execRet(n *a.Ret) control {
+	retExpr := n.Value()
+	if !f.fn.Suspendible() {
+		if retExpr != nil {
+			f.ret = f.eval(retExpr)
+		}
+		return ctrlReturn
+	}
+
+	status, retKeyword := error(nil), t.IDStatus
+	if retExpr != nil {
+		status, _ = f.eval(retExpr).(error)
+		retKeyword = retExpr.Operator()
+	}
+
+	if n.Keyword() == t.IDYield {
+		// Yielding a suspension suspends the coroutine, and resuming carries
+		// on after the yield statement. Yielding an error is like returning
+		// it. Yielding ok is a no-op.
+		if retKeyword == t.IDError || base.IsError(status) {
+			f.ret = status
+			return ctrlReturn
+		}
+		if status != nil {
+			f.suspend(status)
+		}
+		return ctrlNone
+	}
+
+	if retKeyword != t.IDError && retKeyword != t.IDStatus && base.IsSuspension(status) {
+		status = base.ErrCannotReturnASuspension
+	}
+	f.ret = status
+	return ctrlReturn
+}
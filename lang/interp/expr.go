@@ -0,0 +1,283 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interp
+
+import (
+	"fmt"
+
+	"github.com/google/wuffs/lib/go/base"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+func (f *frame) eval(n *a.Expr) value {
+	if cv := n.ConstValue(); cv != nil {
+		if n.MType().IsBool() {
+			return cv.Sign() != 0
+		}
+		return bigToU64(cv)
+	}
+
+	switch op := n.Operator(); {
+	case op.IsXUnaryOp():
+		return f.evalUnaryOp(n)
+	case op.IsXBinaryOp():
+		return f.evalBinaryOp(n)
+	case op.IsXAssociativeOp():
+		return f.evalAssociativeOp(n)
+	}
+
+	switch n.Operator() {
+	case 0:
+		if id := n.Ident(); id == t.IDThis {
+			return f.recv
+		} else if n.GlobalIdent() {
+			return f.pkg.consts[id]
+		} else {
+			return f.locals[id]
+		}
+
+	case t.IDOpenParen, t.IDTry:
+		// n is a function call.
+		return f.evalCall(n)
+
+	case t.IDOpenBracket:
+		// n is an index.
+		i := f.eval(n.RHS().Expr()).(uint64)
+		switch x := f.eval(n.LHS().Expr()).(type) {
+		case []byte:
+			return uint64(x[i])
+		case []uint64:
+			return x[i]
+		case []value:
+			return x[i]
+		}
+
+	case t.IDColon:
+		// n is a slice.
+		s := f.eval(n.LHS().Expr()).([]byte)
+		lo, hi := uint64(0), uint64(len(s))
+		if mhs := n.MHS().Expr(); mhs != nil {
+			lo = f.eval(mhs).(uint64)
+		}
+		if rhs := n.RHS().Expr(); rhs != nil {
+			hi = f.eval(rhs).(uint64)
+		}
+		return s[lo:hi]
+
+	case t.IDDot:
+		if x := n.LHS().Expr(); x.Operator() == 0 && x.Ident() == t.IDIn {
+			return f.args[f.argIndex(n.Ident())]
+		}
+		s := f.eval(n.LHS().Expr()).(*structValue)
+		return s.fields[s.typ.fields[n.Ident()]]
+
+	case t.IDError, t.IDStatus, t.IDSuspension:
+		return f.evalStatus(n)
+	}
+	panic(fmt.Errorf("cannot evaluate %q", n.Str(f.pkg.tm)))
+}
+
+func (f *frame) evalStatus(n *a.Expr) value {
+	qid := n.StatusQID()
+	msg, _ := t.Unescape(qid[1].Str(f.pkg.tm))
+	p := f.pkg
+	if qid[0] != 0 {
+		p = f.pkg.uses[qid[0]]
+	}
+	if p != nil {
+		if z := p.statuses[msg]; z != nil {
+			return z
+		}
+	}
+	if z := builtinStatuses[msg]; z != nil {
+		return z
+	}
+	panic(fmt.Errorf("no status code for %q", msg))
+}
+
+func (f *frame) evalUnaryOp(n *a.Expr) value {
+	x := f.eval(n.RHS().Expr())
+	switch n.Operator() {
+	case t.IDXUnaryNot:
+		return !x.(bool)
+	case t.IDXUnaryMinus:
+		return mask(-x.(uint64), numBits(n.MType()))
+	case t.IDXUnaryPlus:
+		return x
+	}
+	panic(fmt.Errorf("unrecognized operator %q", n.Operator().AmbiguousForm().Str(f.pkg.tm)))
+}
+
+func (f *frame) evalBinaryOp(n *a.Expr) value {
+	op := n.Operator()
+	switch op {
+	case t.IDXBinaryAnd:
+		return f.eval(n.LHS().Expr()).(bool) && f.eval(n.RHS().Expr()).(bool)
+	case t.IDXBinaryOr:
+		return f.eval(n.LHS().Expr()).(bool) || f.eval(n.RHS().Expr()).(bool)
+	case t.IDXBinaryAs:
+		x := f.eval(n.LHS().Expr())
+		return mask(x.(uint64), numBits(n.RHS().TypeExpr()))
+	}
+
+	x := f.eval(n.LHS().Expr())
+	y := f.eval(n.RHS().Expr())
+	switch op {
+	case t.IDXBinaryEqEq:
+		return x == y
+	case t.IDXBinaryNotEq:
+		return x != y
+	case t.IDXBinaryLessThan:
+		return x.(uint64) < y.(uint64)
+	case t.IDXBinaryLessEq:
+		return x.(uint64) <= y.(uint64)
+	case t.IDXBinaryGreaterEq:
+		return x.(uint64) >= y.(uint64)
+	case t.IDXBinaryGreaterThan:
+		return x.(uint64) > y.(uint64)
+	}
+	return arith(op, x.(uint64), y.(uint64), numBits(n.MType()))
+}
+
+func (f *frame) evalAssociativeOp(n *a.Expr) value {
+	op := n.Operator()
+	args := n.Args()
+	switch op {
+	case t.IDXAssociativeAnd:
+		for _, o := range args {
+			if !f.eval(o.Expr()).(bool) {
+				return false
+			}
+		}
+		return true
+	case t.IDXAssociativeOr:
+		for _, o := range args {
+			if f.eval(o.Expr()).(bool) {
+				return true
+			}
+		}
+		return false
+	}
+
+	bop := associativeOps[op]
+	bits := numBits(n.MType())
+	z := f.eval(args[0].Expr()).(uint64)
+	for _, o := range args[1:] {
+		z = arith(bop, z, f.eval(o.Expr()).(uint64), bits)
+	}
+	return z
+}
+
+var associativeOps = [...]t.ID{
+	t.IDXAssociativePlus: t.IDXBinaryPlus,
+	t.IDXAssociativeStar: t.IDXBinaryStar,
+	t.IDXAssociativeAmp:  t.IDXBinaryAmp,
+	t.IDXAssociativePipe: t.IDXBinaryPipe,
+	t.IDXAssociativeHat:  t.IDXBinaryHat,
+}
+
+// arith applies the numeric binary operator op to x and y, for a bits-wide
+// unsigned integer type.
+func arith(op t.ID, x uint64, y uint64, bits uint32) uint64 {
+	switch op {
+	case t.IDXBinaryPlus, t.IDXBinaryTildeModPlus:
+		return mask(x+y, bits)
+	case t.IDXBinaryMinus, t.IDXBinaryTildeModMinus:
+		return mask(x-y, bits)
+	case t.IDXBinaryStar:
+		return mask(x*y, bits)
+	case t.IDXBinarySlash:
+		return x / y
+	case t.IDXBinaryPercent:
+		return x % y
+	case t.IDXBinaryShiftL:
+		return mask(x<<y, bits)
+	case t.IDXBinaryShiftR:
+		return x >> y
+	case t.IDXBinaryAmp:
+		return x & y
+	case t.IDXBinaryPipe:
+		return x | y
+	case t.IDXBinaryHat:
+		return x ^ y
+	case t.IDXBinaryTildeSatPlus:
+		if z := x + y; z >= x && z == mask(z, bits) {
+			return z
+		}
+		return mask(0xFFFFFFFFFFFFFFFF, bits)
+	case t.IDXBinaryTildeSatMinus:
+		if x > y {
+			return x - y
+		}
+		return 0
+	}
+	panic(fmt.Errorf("unrecognized operator 0x%X", op))
+}
+
+func (f *frame) evalCall(n *a.Expr) value {
+	if v, ok := f.evalBuiltinCall(n); ok {
+		return v
+	}
+
+	method := n.LHS().Expr()
+	recv := f.eval(method.LHS().Expr()).(*structValue)
+	if method.Ident() == t.IDReset {
+		recv.reset()
+		return nil
+	}
+	fn := recv.method(f.pkg, method.Ident())
+	if fn == nil {
+		panic(fmt.Errorf("no method %q", method.Str(f.pkg.tm)))
+	}
+
+	// A "try" call's status is its value, and a call without the "?" effect
+	// cannot suspend. A "?" call's error fails this function call, and its
+	// suspension suspends this coroutine. Resuming re-calls the callee, which
+	// resumes the callee's coroutine.
+	if !fn.Suspendible() || n.Operator() == t.IDTry {
+		return call(recv, fn, f.evalArgs(n.Args()))
+	}
+	for {
+		status, _ := call(recv, fn, f.evalArgs(n.Args())).(error)
+		if status == nil {
+			return nil
+		}
+		if !base.IsSuspension(status) || n.ProvenNotToSuspend() {
+			panic(exitStatus{status})
+		}
+		f.suspend(status)
+	}
+}
+
+// evalArgs evaluates a user-defined function call's arguments. Like C's
+// io_reader and io_writer structs, the Go ones are passed by value.
+func (f *frame) evalArgs(args []*a.Node) []value {
+	vals := make([]value, len(args))
+	for i, o := range args {
+		switch v := f.eval(o.Arg().Value()).(type) {
+		case *base.IOReader:
+			r := *v
+			vals[i] = &r
+		case *base.IOWriter:
+			w := *v
+			vals[i] = &w
+		default:
+			vals[i] = v
+		}
+	}
+	return vals
+}
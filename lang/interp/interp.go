@@ -0,0 +1,493 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interp is an interpreter for Wuffs code. It executes a checked
+// package's abstract syntax tree directly, without first generating (and
+// compiling) C or Go code. It is slow, but it is simple, and it acts as a
+// reference implementation for testing the code generators.
+//
+// The Wuffs values map to Go values, the same as for the lib/go/base package
+// that supports the wuffs-go generated code. Numbers, of any width, are
+// uint64s, bools are bools and statuses are errors: nil or a *base.Status.
+// Slices and arrays of base.u8 are []byte, tables are base.TableU8 and
+// io_reader and io_writer are *base.IOReader and *base.IOWriter. Like the
+// lib/go/base package, the interpreter does not yet support signed integers.
+//
+// Coroutines are goroutines. Each suspendible method call in progress runs on
+// its own goroutine, which blocks while that coroutine is suspended.
+package interp
+
+import (
+	"fmt"
+	"math/big"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+	"github.com/google/wuffs/lib/go/base"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// value is a Wuffs value. See the package documentation for how the Wuffs
+// types map to Go types.
+type value interface{}
+
+// Package is a loaded and checked Wuffs package.
+type Package struct {
+	name  string
+	tm    *t.Map
+	files []*a.File
+
+	// uses maps the base names of used packages, such as "deflate" for `use
+	// "std/deflate"`, to those packages.
+	uses map[t.ID]*Package
+
+	consts   map[t.ID]value
+	statuses map[string]error
+	structs  map[t.ID]*structType
+}
+
+type structType struct {
+	pkg    *Package
+	decl   *a.Struct
+	fields map[t.ID]int
+
+	// methods are keyed by IDs in pkg's token map. methodsByName are keyed by
+	// name, for calls from other packages (that have other token maps).
+	methods       map[t.ID]*a.Func
+	methodsByName map[string]*a.Func
+}
+
+// Load loads the Wuffs package in the given directory, such as "std/gzip",
+// relative to wuffsRoot. Any packages that it uses are also loaded.
+func Load(wuffsRoot string, pkgPath string) (*Package, error) {
+	l := &loader{
+		wuffsRoot: wuffsRoot,
+		pkgs:      map[string]*Package{},
+	}
+	return l.load(pkgPath)
+}
+
+type loader struct {
+	wuffsRoot string
+	pkgs      map[string]*Package
+}
+
+func (l *loader) load(pkgPath string) (*Package, error) {
+	if p := l.pkgs[pkgPath]; p != nil {
+		return p, nil
+	}
+	filenames, err := filepath.Glob(filepath.Join(l.wuffsRoot, filepath.FromSlash(pkgPath), "*.wuffs"))
+	if err != nil {
+		return nil, err
+	}
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("interp: no Wuffs files in %q", pkgPath)
+	}
+
+	tm := &t.Map{}
+	files, err := generate.ParseFiles(tm, filenames, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the used packages first, so that check.Check can resolve their
+	// declarations without consulting the gen/wuffs directory.
+	uses := map[t.ID]*Package{}
+	usePkgs := map[string]*Package{}
+	for _, f := range files {
+		for _, n := range f.TopLevelDecls() {
+			if n.Kind() != a.KUse {
+				continue
+			}
+			usePath, ok := t.Unescape(n.Use().Path().Str(tm))
+			if !ok {
+				return nil, fmt.Errorf("interp: cannot resolve `use %s`", n.Use().Path().Str(tm))
+			}
+			u, err := l.load(usePath)
+			if err != nil {
+				return nil, err
+			}
+			usePkgs[usePath] = u
+			baseName, err := tm.Insert(path.Base(usePath))
+			if err != nil {
+				return nil, err
+			}
+			uses[baseName] = u
+		}
+	}
+	resolveUse := func(usePath string) ([]byte, error) {
+		u := usePkgs[strings.TrimSuffix(usePath, ".wuffs")]
+		if u == nil {
+			return nil, fmt.Errorf("interp: cannot resolve %q", usePath)
+		}
+		return generate.Declarations(u.tm, u.files)
+	}
+	if _, err := check.Check(tm, files, resolveUse); err != nil {
+		return nil, err
+	}
+
+	p := &Package{
+		name:     path.Base(pkgPath),
+		tm:       tm,
+		files:    files,
+		uses:     uses,
+		consts:   map[t.ID]value{},
+		statuses: map[string]error{},
+		structs:  map[t.ID]*structType{},
+	}
+	if err := p.gather(); err != nil {
+		return nil, err
+	}
+	l.pkgs[pkgPath] = p
+	return p, nil
+}
+
+// gather indexes the package's top level declarations. Structs are indexed
+// before funcs, as every func is a method of a struct.
+func (p *Package) gather() error {
+	for _, f := range p.files {
+		for _, n := range f.TopLevelDecls() {
+			switch n.Kind() {
+			case a.KConst:
+				n := n.Const()
+				v, err := p.constValue(n.XType(), n.Value())
+				if err != nil {
+					return err
+				}
+				p.consts[n.QID()[1]] = v
+
+			case a.KStatus:
+				n := n.Status()
+				msg, _ := t.Unescape(n.QID()[1].Str(p.tm))
+				if n.Keyword() == t.IDError {
+					p.statuses[msg] = base.NewError(p.name + ": " + msg)
+				} else {
+					p.statuses[msg] = base.NewSuspension(p.name + ": " + msg)
+				}
+
+			case a.KStruct:
+				n := n.Struct()
+				st := &structType{
+					pkg:           p,
+					decl:          n,
+					fields:        map[t.ID]int{},
+					methods:       map[t.ID]*a.Func{},
+					methodsByName: map[string]*a.Func{},
+				}
+				for i, o := range n.Fields() {
+					st.fields[o.Field().Name()] = i
+				}
+				p.structs[n.QID()[1]] = st
+			}
+		}
+	}
+
+	for _, f := range p.files {
+		for _, n := range f.TopLevelDecls() {
+			if n.Kind() != a.KFunc {
+				continue
+			}
+			n := n.Func()
+			st := p.structs[n.Receiver()[1]]
+			if st == nil || n.Receiver()[0] != 0 {
+				return fmt.Errorf("interp: TODO: free-standing function %s", n.QQID().Str(p.tm))
+			}
+			st.methods[n.FuncName()] = n
+			st.methodsByName[n.FuncName().Str(p.tm)] = n
+		}
+	}
+	return nil
+}
+
+func (p *Package) constValue(typ *a.TypeExpr, n *a.Expr) (value, error) {
+	if n.Operator() != t.IDDollar {
+		cv := n.ConstValue()
+		if cv == nil {
+			return nil, fmt.Errorf("interp: invalid const value %q", n.Str(p.tm))
+		}
+		if typ.IsBool() {
+			return cv.Sign() != 0, nil
+		}
+		return bigToU64(cv), nil
+	}
+
+	args := n.Args()
+	inner := typ.Inner()
+	switch {
+	case isU8(inner):
+		s := make([]byte, len(args))
+		for i, o := range args {
+			s[i] = uint8(bigToU64(o.Expr().ConstValue()))
+		}
+		return s, nil
+	case inner.IsNumType():
+		s := make([]uint64, len(args))
+		for i, o := range args {
+			s[i] = bigToU64(o.Expr().ConstValue())
+		}
+		return s, nil
+	}
+	s := make([]value, len(args))
+	for i, o := range args {
+		v, err := p.constValue(inner, o.Expr())
+		if err != nil {
+			return nil, err
+		}
+		s[i] = v
+	}
+	return s, nil
+}
+
+// structType returns the struct type with the given qualified name, which
+// can be from this package or from a used package.
+func (p *Package) structType(qid t.QID) *structType {
+	if qid[0] == 0 {
+		return p.structs[qid[1]]
+	}
+	u := p.uses[qid[0]]
+	if u == nil {
+		return nil
+	}
+	return u.structs[u.tm.ByName(qid[1].Str(p.tm))]
+}
+
+// zero returns the zero value of the given type.
+func (p *Package) zero(typ *a.TypeExpr) value {
+	switch typ.Decorator() {
+	case t.IDPtr, t.IDNptr:
+		return nil
+	case t.IDSlice:
+		return []byte(nil)
+	case t.IDTable:
+		return base.TableU8{}
+	case t.IDArray:
+		n := typ.ArrayLength().ConstValue().Int64()
+		inner := typ.Inner()
+		switch {
+		case isU8(inner):
+			return make([]byte, n)
+		case inner.IsNumType():
+			return make([]uint64, n)
+		}
+		s := make([]value, n)
+		for i := range s {
+			s[i] = p.zero(inner)
+		}
+		return s
+	}
+
+	qid := typ.QID()
+	if qid[0] == t.IDBase {
+		if qid[1].IsNumType() {
+			return uint64(0)
+		}
+		switch qid[1] {
+		case t.IDBool:
+			return false
+		case t.IDStatus:
+			return nil
+		case t.IDEmptyStruct:
+			return struct{}{}
+		case t.IDRectIEU32:
+			return &base.RectIEU32{}
+		case t.IDRectIIU32:
+			return &base.RectIIU32{}
+		case t.IDImageBuffer:
+			return &base.ImageBuffer{}
+		case t.IDImageConfig:
+			return &base.ImageConfig{}
+		case t.IDIOReader:
+			return &base.IOReader{}
+		case t.IDIOWriter:
+			return &base.IOWriter{}
+		}
+		panic(fmt.Errorf("unsupported type %q", typ.Str(p.tm)))
+	}
+
+	st := p.structType(qid)
+	if st == nil {
+		panic(fmt.Errorf("unknown struct type %q", typ.Str(p.tm)))
+	}
+	return newStructValue(st)
+}
+
+// Name returns the package's name, such as "gzip".
+func (p *Package) Name() string { return p.name }
+
+// Status returns the status with the given message, such as "bad checksum",
+// declared by this package, or by the built-in base package. It returns nil
+// if there is no such status.
+func (p *Package) Status(msg string) error {
+	if z := p.statuses[msg]; z != nil {
+		return z
+	}
+	if z := builtinStatuses[msg]; z != nil {
+		return z
+	}
+	return nil
+}
+
+// NewStruct returns a new, zero-valued instance of the named struct type,
+// such as "decoder".
+func (p *Package) NewStruct(name string) (*Struct, error) {
+	st := p.structs[p.tm.ByName(name)]
+	if st == nil || !st.decl.Public() {
+		return nil, fmt.Errorf("interp: no public struct %s.%s", p.name, name)
+	}
+	return &Struct{v: newStructValue(st)}, nil
+}
+
+// Struct is an instance of a Wuffs struct type.
+type Struct struct {
+	v *structValue
+}
+
+// Call calls the named public method, such as "decode". For a suspendible
+// method, the status result is returned as the error. For other methods, the
+// returned value is the method's result (if any) and the error is nil.
+//
+// Arguments can be Go integers, bools, []byte slices, base.IOReader or
+// base.IOWriter values (or pointers to those, which are copied) and pointers
+// to base.ImageConfig or base.ImageBuffer values.
+//
+// A non-nil error that is not a *base.Status means that the interpreter
+// itself failed, such as for an unsupported Wuffs construct.
+func (s *Struct) Call(method string, args ...interface{}) (ret interface{}, err error) {
+	fn := s.v.typ.methodsByName[method]
+	if fn == nil || !fn.Public() {
+		return nil, fmt.Errorf("interp: no public method %s.%s", s.v.typ.decl.QID().Str(s.v.typ.pkg.tm), method)
+	}
+	params := fn.In().Fields()
+	if len(args) != len(params) {
+		return nil, fmt.Errorf("interp: method %s: got %d arguments, want %d", method, len(args), len(params))
+	}
+	vals := make([]value, len(args))
+	for i, x := range args {
+		v, err := toValue(x)
+		if err != nil {
+			return nil, fmt.Errorf("interp: method %s: argument #%d: %v", method, i, err)
+		}
+		vals[i] = v
+	}
+
+	defer func() {
+		if x := recover(); x != nil {
+			ret, err = nil, fmt.Errorf("interp: %v", x)
+		}
+	}()
+	v := call(s.v, fn, vals)
+	if fn.Suspendible() {
+		status, _ := v.(error)
+		return nil, status
+	}
+	return v, nil
+}
+
+// Reset resets the Struct to its zero value, abandoning any suspended
+// coroutines. It is the Go equivalent of the Wuffs reset method.
+func (s *Struct) Reset() { s.v.reset() }
+
+func toValue(x interface{}) (value, error) {
+	switch x := x.(type) {
+	case bool, []byte, *base.ImageConfig, *base.ImageBuffer, *base.RectIEU32, *base.RectIIU32:
+		return x, nil
+	case int:
+		return uint64(x), nil
+	case int32:
+		return uint64(x), nil
+	case int64:
+		return uint64(x), nil
+	case uint8:
+		return uint64(x), nil
+	case uint16:
+		return uint64(x), nil
+	case uint32:
+		return uint64(x), nil
+	case uint64:
+		return x, nil
+	case base.IOReader:
+		return &x, nil
+	case *base.IOReader:
+		r := *x
+		return &r, nil
+	case base.IOWriter:
+		return &x, nil
+	case *base.IOWriter:
+		w := *x
+		return &w, nil
+	}
+	return nil, fmt.Errorf("unsupported Go type %T", x)
+}
+
+// builtinStatuses maps the lang/builtin package's status messages to the
+// lib/go/base package's statuses.
+var builtinStatuses = map[string]error{}
+
+func init() {
+	for _, z := range []*base.Status{
+		base.ErrBadWuffsVersion,
+		base.ErrBadSizeofReceiver,
+		base.ErrBadReceiver,
+		base.ErrBadArgument,
+		base.ErrCheckWuffsVersionNotCalled,
+		base.ErrCheckWuffsVersionCalledTwice,
+		base.ErrInvalidIOOperation,
+		base.ErrClosedForWrites,
+		base.ErrUnexpectedEOF,
+		base.SuspensionShortRead,
+		base.SuspensionShortWrite,
+		base.ErrCannotReturnASuspension,
+		base.ErrInvalidCallSequence,
+		base.SuspensionEndOfData,
+	} {
+		builtinStatuses[z.Error()] = z
+	}
+}
+
+func isU8(typ *a.TypeExpr) bool {
+	return typ.Decorator() == 0 && typ.QID() == t.QID{t.IDBase, t.IDU8}
+}
+
+func bigToU64(x *big.Int) uint64 {
+	if x.Sign() < 0 {
+		return uint64(x.Int64())
+	}
+	return x.Uint64()
+}
+
+// numBits returns the width of the given numeric type, or 64 for an ideal
+// (arbitrary precision) constant type.
+func numBits(typ *a.TypeExpr) uint32 {
+	switch typ.QID()[1] {
+	case t.IDU8, t.IDI8:
+		return 8
+	case t.IDU16, t.IDI16:
+		return 16
+	case t.IDU32, t.IDI32:
+		return 32
+	}
+	return 64
+}
+
+func mask(x uint64, bits uint32) uint64 {
+	if bits >= 64 {
+		return x
+	}
+	return x & (1<<bits - 1)
+}
@@ -0,0 +1,283 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interp
+
+import (
+	"bytes"
+	"hash/adler32"
+	"hash/crc32"
+	stdgif "image/gif"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/gzip"
+	"github.com/google/wuffs/lang/generate"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+func load(tb testing.TB, pkgPath string) *Package {
+	wuffsRoot, err := generate.WuffsRoot()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	p, err := Load(wuffsRoot, pkgPath)
+	if err != nil {
+		tb.Fatalf("%s: %v", pkgPath, err)
+	}
+	return p
+}
+
+func newStruct(tb testing.TB, p *Package, name string) *Struct {
+	s, err := p.NewStruct(name)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
+
+func readFile(tb testing.TB, filename string) []byte {
+	b, err := ioutil.ReadFile(filepath.Join("..", "..", "test", "data", filename))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return b
+}
+
+// decoder adapts an interpreted decoder struct to the testlib.Decoder
+// interface.
+type decoder struct {
+	s *Struct
+}
+
+func (d decoder) Decode(dst base.IOWriter, src base.IOReader) error {
+	_, err := d.s.Call("decode", dst, src)
+	return err
+}
+
+func TestChecksums(tt *testing.T) {
+	testCases := []struct {
+		pkgPath    string
+		structName string
+		want       func([]byte) uint32
+	}{
+		{"std/adler32", "hasher", adler32.Checksum},
+		{"std/crc32", "ieee_hasher", crc32.ChecksumIEEE},
+	}
+	for _, tc := range testCases {
+		p := load(tt, tc.pkgPath)
+		for _, filename := range []string{"romeo.txt", "midsummer.txt"} {
+			src := readFile(tt, filename)
+			h := newStruct(tt, p, tc.structName)
+			// Split the input, to test updating a running checksum.
+			got := uint64(0)
+			for _, x := range [][]byte{src[:100], src[100:]} {
+				ret, err := h.Call("update", x)
+				if err != nil {
+					tt.Fatalf("%s: %s: %v", tc.pkgPath, filename, err)
+				}
+				got = ret.(uint64)
+			}
+			if want := tc.want(src); got != uint64(want) {
+				tt.Errorf("%s: %s: got 0x%08X, want 0x%08X", tc.pkgPath, filename, got, want)
+			}
+		}
+	}
+}
+
+func testDecode(tt *testing.T, pkgPath string, goldens map[string]string, wlimit int, rlimit int) {
+	p := load(tt, pkgPath)
+	for filename, wantFilename := range goldens {
+		src, want := readFile(tt, filename), readFile(tt, wantFilename)
+		literalWidth := -1
+		if pkgPath == "std/lzw" {
+			literalWidth, src = int(src[0]), src[1:]
+		}
+
+		d := newStruct(tt, p, "decoder")
+		if literalWidth >= 0 {
+			if _, err := d.Call("set_literal_width", literalWidth); err != nil {
+				tt.Fatal(err)
+			}
+		}
+		got, err := testlib.Decode(decoder{d}, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+var (
+	deflateGoldens = map[string]string{
+		"artificial/deflate-backref-crosses-blocks.deflate": "artificial/deflate-backref-crosses-blocks.deflate.decompressed",
+		"artificial/deflate-distance-32768.deflate":         "artificial/deflate-distance-32768.deflate.decompressed",
+		"romeo.txt.deflate":                                 "romeo.txt",
+		"romeo.txt.fixed-huff.deflate":                      "romeo.txt",
+	}
+	gzipGoldens = map[string]string{
+		"midsummer.txt.gz": "midsummer.txt",
+		"romeo.txt.gz":     "romeo.txt",
+	}
+	lzwGoldens = map[string]string{
+		"bricks-dither.indexes.giflzw": "bricks-dither.indexes",
+	}
+	zlibGoldens = map[string]string{
+		"midsummer.txt.zlib": "midsummer.txt",
+		"romeo.txt.zlib":     "romeo.txt",
+	}
+)
+
+func TestDecodeDeflate(tt *testing.T) { testDecode(tt, "std/deflate", deflateGoldens, 0, 0) }
+func TestDecodeGzip(tt *testing.T)    { testDecode(tt, "std/gzip", gzipGoldens, 0, 0) }
+func TestDecodeLZW(tt *testing.T)     { testDecode(tt, "std/lzw", lzwGoldens, 0, 0) }
+func TestDecodeZlib(tt *testing.T)    { testDecode(tt, "std/zlib", zlibGoldens, 0, 0) }
+
+func TestDecodeDeflateManySmallWritesReads(tt *testing.T) {
+	testDecode(tt, "std/deflate", deflateGoldens, 11, 7)
+}
+
+func TestDecodeGzipManyTinyWritesReads(tt *testing.T) {
+	testDecode(tt, "std/gzip", map[string]string{"romeo.txt.gz": "romeo.txt"}, 1, 1)
+}
+
+func TestDecodeLZWManySmallWritesReads(tt *testing.T) {
+	testDecode(tt, "std/lzw", lzwGoldens, 41, 43)
+}
+
+func TestDecodeGzipBadChecksum(tt *testing.T) {
+	p := load(tt, "std/gzip")
+	src := append([]byte(nil), readFile(tt, "romeo.txt.gz")...)
+	src[len(src)-8] ^= 1
+
+	_, err := testlib.Decode(decoder{newStruct(tt, p, "decoder")}, src, 0, 0)
+	if want := p.Status("bad checksum"); err != want {
+		tt.Fatalf("got %v, want %v", err, want)
+	}
+}
+
+// TestDifferentialGzip checks that the interpreter agrees with the wuffs-go
+// generated code, both on the decoded bytes and on the final status, for
+// corrupted input.
+func TestDifferentialGzip(tt *testing.T) {
+	p := load(tt, "std/gzip")
+	original := readFile(tt, "romeo.txt.gz")
+	for i := 0; i < len(original); i += 23 {
+		src := append([]byte(nil), original...)
+		src[i] ^= 0x5A
+
+		got, gotErr := testlib.Decode(decoder{newStruct(tt, p, "decoder")}, src, 0, 0)
+		want, wantErr := testlib.Decode(&gzip.Decoder{}, src, 0, 0)
+		if !bytes.Equal(got, want) {
+			tt.Errorf("byte #%d: got %d bytes, want %d bytes", i, len(got), len(want))
+		}
+		if errString(gotErr) != errString(wantErr) {
+			tt.Errorf("byte #%d: got %v, want %v", i, gotErr, wantErr)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestDecodeGIF(tt *testing.T) {
+	p := load(tt, "std/gif")
+	for _, filename := range []string{"animated-red-blue.gif", "bricks-dither.gif"} {
+		src := readFile(tt, filename)
+		g, err := stdgif.DecodeAll(bytes.NewReader(src))
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+
+		// Feed the decoder 4096 bytes at a time.
+		d := newStruct(tt, p, "decoder")
+		r := base.IOBuffer{Data: src}
+		feed := func() bool {
+			if r.Closed {
+				return false
+			}
+			r.WI += 4096
+			if r.WI >= len(src) {
+				r.WI = len(src)
+				r.Closed = true
+			}
+			return true
+		}
+		feed()
+
+		ic := base.ImageConfig{}
+		for {
+			_, err := d.Call("decode_config", &ic, r.Reader())
+			if err == nil {
+				break
+			} else if err != base.SuspensionShortRead || !feed() {
+				tt.Fatalf("%s: decode_config: %v", filename, err)
+			}
+		}
+
+		ib := base.ImageBuffer{}
+		ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+		numFrames := 0
+		for {
+			_, err := d.Call("decode_frame", &ib, r.Reader())
+			if err == base.SuspensionEndOfData {
+				break
+			} else if err == base.SuspensionShortRead && feed() {
+				continue
+			} else if err != nil {
+				tt.Fatalf("%s: decode_frame: %v", filename, err)
+			}
+			if numFrames >= len(g.Image) {
+				tt.Fatalf("%s: too many frames", filename)
+			}
+
+			m := g.Image[numFrames]
+			b := m.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				got := ib.Plane(0).Row(uint32(y))[b.Min.X:b.Max.X]
+				want := m.Pix[m.PixOffset(b.Min.X, y):][:b.Dx()]
+				if !bytes.Equal(got, want) {
+					tt.Fatalf("%s: frame #%d: row %d: pixels differ", filename, numFrames, y)
+				}
+			}
+			numFrames++
+		}
+		if numFrames != len(g.Image) {
+			tt.Errorf("%s: got %d frames, want %d", filename, numFrames, len(g.Image))
+		}
+	}
+}
+
+func TestBadArgument(tt *testing.T) {
+	p := load(tt, "std/lzw")
+	d := newStruct(tt, p, "decoder")
+	if _, err := d.Call("set_literal_width", 9); err != nil {
+		tt.Fatalf("set_literal_width: %v", err)
+	}
+	// The out-of-bounds argument is a sticky error.
+	buf := base.IOBuffer{}
+	if _, err := d.Call("decode", buf.Writer(), buf.Reader()); err != base.ErrBadArgument {
+		tt.Fatalf("decode: got %v, want %v", err, base.ErrBadArgument)
+	}
+}
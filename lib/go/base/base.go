@@ -0,0 +1,234 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package base is the runtime support library for the Go code generated by
+// wuffs-go. It is the Go equivalent of the base-header.h and base-impl.h code
+// that is copy/pasted into every generated C file.
+//
+// The Wuffs "base.foo" types map to the Go types in this package: io_reader
+// and io_writer map to IOReader and IOWriter, image_config maps to
+// ImageConfig, status maps to the built-in error type, etc.
+package base
+
+// ---------------- Numeric Types
+
+func I8Min(x int8, y int8) int8 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func I8Max(x int8, y int8) int8 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func I16Min(x int16, y int16) int16 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func I16Max(x int16, y int16) int16 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func I32Min(x int32, y int32) int32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func I32Max(x int32, y int32) int32 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func I64Min(x int64, y int64) int64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func I64Max(x int64, y int64) int64 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func U8Min(x uint8, y uint8) uint8 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func U8Max(x uint8, y uint8) uint8 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func U16Min(x uint16, y uint16) uint16 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func U16Max(x uint16, y uint16) uint16 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func U32Min(x uint32, y uint32) uint32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func U32Max(x uint32, y uint32) uint32 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func U64Min(x uint64, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func U64Max(x uint64, y uint64) uint64 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// --------
+
+// The SatAdd and SatSub functions implement the Wuffs "~sat+" and "~sat-"
+// operators: addition and subtraction that saturate (clamp) instead of
+// overflowing.
+
+func U8SatAdd(x uint8, y uint8) uint8 {
+	if z := x + y; z >= x {
+		return z
+	}
+	return 0xFF
+}
+
+func U8SatSub(x uint8, y uint8) uint8 {
+	if x > y {
+		return x - y
+	}
+	return 0
+}
+
+func U16SatAdd(x uint16, y uint16) uint16 {
+	if z := x + y; z >= x {
+		return z
+	}
+	return 0xFFFF
+}
+
+func U16SatSub(x uint16, y uint16) uint16 {
+	if x > y {
+		return x - y
+	}
+	return 0
+}
+
+func U32SatAdd(x uint32, y uint32) uint32 {
+	if z := x + y; z >= x {
+		return z
+	}
+	return 0xFFFFFFFF
+}
+
+func U32SatSub(x uint32, y uint32) uint32 {
+	if x > y {
+		return x - y
+	}
+	return 0
+}
+
+func U64SatAdd(x uint64, y uint64) uint64 {
+	if z := x + y; z >= x {
+		return z
+	}
+	return 0xFFFFFFFFFFFFFFFF
+}
+
+func U64SatSub(x uint64, y uint64) uint64 {
+	if x > y {
+		return x - y
+	}
+	return 0
+}
+
+// ---------------- Slices and Tables
+
+// SliceU8Prefix returns up to the first upTo bytes of s.
+func SliceU8Prefix(s []byte, upTo uint64) []byte {
+	if uint64(len(s)) > upTo {
+		return s[:upTo]
+	}
+	return s
+}
+
+// SliceU8Suffix returns up to the last upTo bytes of s.
+func SliceU8Suffix(s []byte, upTo uint64) []byte {
+	if uint64(len(s)) > upTo {
+		return s[uint64(len(s))-upTo:]
+	}
+	return s
+}
+
+// TableU8 is a 2-dimensional buffer: a slice of rows, each row holding Width
+// bytes, with successive rows being Stride bytes apart.
+type TableU8 struct {
+	Data   []byte
+	Width  int
+	Height int
+	Stride int
+}
+
+// Row returns the y'th row of t, or an empty slice if y is out of bounds.
+func (t TableU8) Row(y uint32) []byte {
+	if int64(y) < int64(t.Height) {
+		i := int(y) * t.Stride
+		return t.Data[i : i+t.Width]
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+// ---------------- Ranges and Rects
+
+// RectIEU32 is a rectangle on the integer grid. The "IE" means that the bounds
+// are inclusive on the low end and exclusive on the high end. It is valid for
+// min >= max, in which case the rectangle is empty.
+type RectIEU32 struct {
+	MinInclusiveX uint32
+	MinInclusiveY uint32
+	MaxExclusiveX uint32
+	MaxExclusiveY uint32
+}
+
+func (r *RectIEU32) SetMinInclusiveX(x uint32) { r.MinInclusiveX = x }
+func (r *RectIEU32) SetMinInclusiveY(y uint32) { r.MinInclusiveY = y }
+func (r *RectIEU32) SetMaxExclusiveX(x uint32) { r.MaxExclusiveX = x }
+func (r *RectIEU32) SetMaxExclusiveY(y uint32) { r.MaxExclusiveY = y }
+
+func (r RectIEU32) IsEmpty() bool {
+	return r.MinInclusiveX >= r.MaxExclusiveX || r.MinInclusiveY >= r.MaxExclusiveY
+}
+
+func (r RectIEU32) Width() uint32  { return U32SatSub(r.MaxExclusiveX, r.MinInclusiveX) }
+func (r RectIEU32) Height() uint32 { return U32SatSub(r.MaxExclusiveY, r.MinInclusiveY) }
+
+// RectIIU32 is a rectangle on the integer grid. The "II" means that the bounds
+// are inclusive on the low end and inclusive on the high end. It is valid for
+// min > max, in which case the rectangle is empty.
+type RectIIU32 struct {
+	MinInclusiveX uint32
+	MinInclusiveY uint32
+	MaxInclusiveX uint32
+	MaxInclusiveY uint32
+}
+
+func (r *RectIIU32) SetMinInclusiveX(x uint32) { r.MinInclusiveX = x }
+func (r *RectIIU32) SetMinInclusiveY(y uint32) { r.MinInclusiveY = y }
+func (r *RectIIU32) SetMaxInclusiveX(x uint32) { r.MaxInclusiveX = x }
+func (r *RectIIU32) SetMaxInclusiveY(y uint32) { r.MaxInclusiveY = y }
+
+func (r RectIIU32) IsEmpty() bool {
+	return r.MinInclusiveX > r.MaxInclusiveX || r.MinInclusiveY > r.MaxInclusiveY
+}
+
+// ---------------- Images
+
+// PixelFormat encodes the format of the bytes that constitute an image
+// frame's pixel data. Its bits are the same as the C wuffs_base__pixel_format
+// type's.
+type PixelFormat uint32
+
+const (
+	PixelFormatInvalid              = PixelFormat(0x00000000)
+	PixelFormatBGRANonpremul        = PixelFormat(0x22008888)
+	PixelFormatBGRANonpremulIndexed = PixelFormat(0x22088888)
+	PixelFormatY                    = PixelFormat(0x10000008)
+	PixelFormatYUV                  = PixelFormat(0x40200888)
+
+	PixelFormatNumPlanesMax = 4
+)
+
+// The PixelSubsampling constants encode the mapping of pixel space
+// coordinates to each plane's pixel buffer indices. Their bits are the same as
+// the C wuffs_base__pixel_subsampling type's.
+const (
+	PixelSubsamplingNone = 0x000000
+	PixelSubsampling444  = 0x000000
+	PixelSubsampling440  = 0x010100
+	PixelSubsampling422  = 0x101000
+	PixelSubsampling420  = 0x111100
+	PixelSubsampling411  = 0x202000
+	PixelSubsampling410  = 0x212100
+)
+
+var pixelFormatDepths = [16]uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64}
+
+// BitsPerPixel returns the number of bits per pixel for a packed (single
+// plane) pixel format. For indexed formats, this is the number of bits per
+// index value. It returns zero for invalid or planar pixel formats.
+func (f PixelFormat) BitsPerPixel() uint32 {
+	if f == 0 || (f>>20)&0x03 != 0 {
+		return 0
+	}
+	if (f>>16)&0x0F != 0 {
+		return pixelFormatDepths[(f>>16)&0x0F]
+	}
+	return pixelFormatDepths[(f>>0)&0x0F] + pixelFormatDepths[(f>>4)&0x0F] +
+		pixelFormatDepths[(f>>8)&0x0F] + pixelFormatDepths[(f>>12)&0x0F]
+}
+
+// NumPlanes returns the number of planes, or zero for an invalid pixel
+// format.
+func (f PixelFormat) NumPlanes() uint32 {
+	if f == 0 {
+		return 0
+	}
+	return uint32((f>>20)&0x03) + 1
+}
+
+// PlaneBitsPerPixel returns the number of bits per pixel in the p'th plane.
+// For packed pixel formats, this is the same as BitsPerPixel for p == 0. For
+// planar pixel formats, each plane holds one channel, so this is that
+// channel's depth. It returns zero if there is no p'th plane.
+func (f PixelFormat) PlaneBitsPerPixel(p uint32) uint32 {
+	n := f.NumPlanes()
+	if p >= n {
+		return 0
+	} else if n == 1 {
+		return f.BitsPerPixel()
+	}
+	return pixelFormatDepths[(f>>(4*p))&0x0F]
+}
+
+// The AnimationDisposal constants are an ImageBuffer's Disposal values: how to
+// dispose of an animated image's frame after displaying it.
+const (
+	AnimationDisposalNone              = 0
+	AnimationDisposalRestoreBackground = 1
+	AnimationDisposalRestorePrevious   = 2
+)
+
+// FlicksPerSecond is the number of flicks (the unit of an ImageBuffer's
+// Duration) per second.
+const FlicksPerSecond = 705600000
+
+// ImageConfig holds an image's overall configuration: its pixel format and
+// dimensions. The zero value is an invalid configuration.
+type ImageConfig struct {
+	pixfmt   PixelFormat
+	pixsub   uint32
+	width    uint32
+	height   uint32
+	numLoops uint32
+}
+
+// Initialize sets c's fields, or invalidates c if pixfmt is invalid.
+func (c *ImageConfig) Initialize(pixfmt uint32, pixsub uint32, width uint32, height uint32, numLoops uint32) {
+	f := PixelFormat(pixfmt)
+	n := f.NumPlanes()
+	if n == 0 {
+		*c = ImageConfig{}
+		return
+	}
+	for p := uint32(0); p < n; p++ {
+		if f.PlaneBitsPerPixel(p) == 0 {
+			*c = ImageConfig{}
+			return
+		}
+	}
+	*c = ImageConfig{
+		pixfmt:   f,
+		pixsub:   pixsub,
+		width:    width,
+		height:   height,
+		numLoops: numLoops,
+	}
+}
+
+func (c *ImageConfig) IsValid() bool            { return c.pixfmt != 0 }
+func (c *ImageConfig) PixelFormat() PixelFormat { return c.pixfmt }
+func (c *ImageConfig) PixelSubsampling() uint32 { return c.pixsub }
+func (c *ImageConfig) Width() uint32            { return c.width }
+func (c *ImageConfig) Height() uint32           { return c.height }
+func (c *ImageConfig) NumLoops() uint32         { return c.numLoops }
+func (c *ImageConfig) Invalidate()              { *c = ImageConfig{} }
+
+// PlaneRowLength returns the number of bytes per row in the p'th plane. For
+// planar pixel formats, the pixel subsampling applies. It returns zero if
+// there is no p'th plane.
+func (c *ImageConfig) PlaneRowLength(p uint32) uint64 {
+	width := uint64(c.width)
+	if width > 0 && c.pixfmt.NumPlanes() > 1 {
+		shift := (p&0x03)*8 + 4
+		biasX := uint64(c.pixsub>>(shift+2)) & 0x03
+		shiftX := uint64(c.pixsub>>shift) & 0x03
+		width = ((width - 1 + biasX) >> shiftX) + 1
+	}
+	return (width*uint64(c.pixfmt.PlaneBitsPerPixel(p)) + 7) / 8
+}
+
+// PlaneHeight returns the number of rows in the p'th plane. For planar pixel
+// formats, the pixel subsampling applies. It returns zero if there is no p'th
+// plane.
+func (c *ImageConfig) PlaneHeight(p uint32) uint32 {
+	if p >= c.pixfmt.NumPlanes() {
+		return 0
+	}
+	height := uint64(c.height)
+	if height > 0 && c.pixfmt.NumPlanes() > 1 {
+		shift := (p & 0x03) * 8
+		biasY := uint64(c.pixsub>>(shift+2)) & 0x03
+		shiftY := uint64(c.pixsub>>shift) & 0x03
+		height = ((height - 1 + biasY) >> shiftY) + 1
+	}
+	return uint32(height)
+}
+
+// PixbufSize returns the number of bytes needed to hold the image's pixel
+// buffer: the sum of every plane's size, laid out consecutively.
+func (c *ImageConfig) PixbufSize() uint64 {
+	total := uint64(0)
+	for p := uint32(0); p < c.pixfmt.NumPlanes(); p++ {
+		total += c.PlaneRowLength(p) * uint64(c.PlaneHeight(p))
+	}
+	return total
+}
+
+// ImageBuffer is an image's pixel buffer, plus per-frame metadata.
+type ImageBuffer struct {
+	config         ImageConfig
+	loopCount      uint32
+	planes         [PixelFormatNumPlanesMax]TableU8
+	dirtyRect      RectIEU32
+	duration       uint64
+	blend          bool
+	disposal       uint8
+	paletteChanged bool
+	palette        [1024]byte
+}
+
+// SetFromSlice sets b to use mem as its pixel buffer. For planar pixel
+// formats, the planes are laid out consecutively, as per PixbufSize. It leaves
+// b with an invalid configuration if mem is too short.
+func (b *ImageBuffer) SetFromSlice(config ImageConfig, mem []byte) {
+	*b = ImageBuffer{}
+	if !config.IsValid() || config.PixbufSize() > uint64(len(mem)) {
+		return
+	}
+	b.config = config
+	for p := uint32(0); p < config.pixfmt.NumPlanes(); p++ {
+		rowLength := int(config.PlaneRowLength(p))
+		height := int(config.PlaneHeight(p))
+		n := rowLength * height
+		b.planes[p] = TableU8{
+			Data:   mem[:n],
+			Width:  rowLength,
+			Height: height,
+			Stride: rowLength,
+		}
+		mem = mem[n:]
+	}
+}
+
+// Update sets the per-frame metadata. The palette argument is ignored unless
+// its length is exactly 1024.
+func (b *ImageBuffer) Update(dirtyRect RectIEU32, duration uint64, blend bool, disposal uint8, palette []byte) {
+	// Clip the dirty rectangle to the image bounds.
+	dirtyRect.MaxExclusiveX = U32Min(dirtyRect.MaxExclusiveX, b.config.width)
+	dirtyRect.MaxExclusiveY = U32Min(dirtyRect.MaxExclusiveY, b.config.height)
+	b.dirtyRect = dirtyRect
+
+	b.duration = duration
+	b.blend = blend
+	b.disposal = disposal
+	b.paletteChanged = len(palette) == 1024
+	if b.paletteChanged {
+		copy(b.palette[:], palette)
+	}
+}
+
+// Loop returns whether the image decoder should loop back to the beginning of
+// the animation, assuming that we've reached the end of the encoded stream.
+// If so, it increments b's count of the animation loops played so far.
+func (b *ImageBuffer) Loop() bool {
+	n := b.config.numLoops
+	if n == 0 {
+		return true
+	}
+	if b.loopCount < n-1 {
+		b.loopCount++
+		return true
+	}
+	return false
+}
+
+// Plane returns the p'th plane of the pixel buffer.
+func (b *ImageBuffer) Plane(p uint32) TableU8 {
+	if p < PixelFormatNumPlanesMax {
+		return b.planes[p]
+	}
+	return TableU8{}
+}
+
+func (b *ImageBuffer) ImageConfig() *ImageConfig { return &b.config }
+func (b *ImageBuffer) DirtyRect() RectIEU32      { return b.dirtyRect }
+func (b *ImageBuffer) Duration() uint64          { return b.duration }
+func (b *ImageBuffer) Blend() bool               { return b.blend }
+func (b *ImageBuffer) Disposal() uint8           { return b.disposal }
+func (b *ImageBuffer) PaletteChanged() bool      { return b.paletteChanged }
+func (b *ImageBuffer) Palette() []byte           { return b.palette[:] }
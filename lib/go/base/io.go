@@ -0,0 +1,479 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+// IOBuffer is a 1-dimensional buffer (a slice), plus additional indexes into
+// that buffer, plus an opened / closed flag.
+//
+// The bytes in Data[RI:WI] are available to read. The bytes in Data[WI:] are
+// available to write. A zero value is a valid, empty buffer.
+type IOBuffer struct {
+	Data   []byte
+	WI     int  // Write index. Invariant: WI <= len(Data).
+	RI     int  // Read  index. Invariant: RI <= WI.
+	Closed bool // No further writes are expected.
+}
+
+// Reader returns an IOReader that reads from b.
+func (b *IOBuffer) Reader() IOReader { return IOReader{buf: b} }
+
+// Writer returns an IOWriter that writes to b.
+func (b *IOBuffer) Writer() IOWriter { return IOWriter{buf: b} }
+
+// Compact moves any written but unread bytes to the start of the buffer.
+func (b *IOBuffer) Compact() {
+	if b.RI == 0 {
+		return
+	}
+	n := copy(b.Data, b.Data[b.RI:b.WI])
+	b.WI = n
+	b.RI = 0
+}
+
+// IOReader is a read-only view of an IOBuffer.
+//
+// Like the C io_reader type, it is passed by value. Its mark and limit are the
+// bounds (as indexes into the IOBuffer's Data) that the generated code can
+// read within. A zero mark and limit, when not yet bound, means that the
+// callee substitutes the implicit bounds derived from the IOBuffer.
+type IOReader struct {
+	buf   *IOBuffer
+	mark  int
+	limit int
+	bound bool
+}
+
+// Derive sets r's bounds, if they are not already set, to the IOBuffer's
+// readable bytes. The generated code calls it on entry to every function that
+// takes an io_reader argument.
+func (r *IOReader) Derive() {
+	if r.buf != nil && !r.bound {
+		r.mark = r.buf.RI
+		r.limit = r.buf.WI
+		r.bound = true
+	}
+}
+
+// Available returns the number of bytes that can be read.
+func (r *IOReader) Available() uint64 {
+	if r.buf == nil {
+		return 0
+	}
+	return uint64(r.limit - r.buf.RI)
+}
+
+func (r *IOReader) shortRead() error {
+	if r.buf != nil && r.buf.Closed && r.buf.WI == r.limit {
+		return ErrUnexpectedEOF
+	}
+	return SuspensionShortRead
+}
+
+// ReadU8 reads a byte. It returns SuspensionShortRead, or ErrUnexpectedEOF if
+// the IOBuffer is closed, if no byte is available.
+func (r *IOReader) ReadU8() (uint8, error) {
+	if r.buf == nil || r.buf.RI >= r.limit {
+		return 0, r.shortRead()
+	}
+	x := r.buf.Data[r.buf.RI]
+	r.buf.RI++
+	return x, nil
+}
+
+// ReadU8Fast reads a byte, when the caller has proven that one is available.
+func (r *IOReader) ReadU8Fast() uint8 {
+	x := r.buf.Data[r.buf.RI]
+	r.buf.RI++
+	return x
+}
+
+// readUXX reads a size-bit big-endian or little-endian number. If fewer than
+// size/8 bytes are available, it reads the bytes that are available and
+// returns a suspension. The partial result is accumulated in scratch, so that
+// calling readUXX again (with the same scratch) resumes the read.
+//
+// The scratch encoding is the same as the C code's: the number of bits read
+// so far is held in the low (big-endian) or high (little-endian) byte.
+func (r *IOReader) readUXX(scratch *uint64, size uint32, be bool) (uint64, error) {
+	n := int(size / 8)
+	if *scratch == 0 && r.buf != nil && r.limit-r.buf.RI >= n {
+		p := r.buf.Data[r.buf.RI : r.buf.RI+n]
+		r.buf.RI += n
+		x := uint64(0)
+		if be {
+			for _, c := range p {
+				x = x<<8 | uint64(c)
+			}
+		} else {
+			for i := n - 1; i >= 0; i-- {
+				x = x<<8 | uint64(p[i])
+			}
+		}
+		return x, nil
+	}
+
+	for {
+		if r.buf == nil || r.buf.RI >= r.limit {
+			return 0, r.shortRead()
+		}
+		c := uint64(r.buf.Data[r.buf.RI])
+		r.buf.RI++
+		if be {
+			t := uint32(*scratch & 0xFF)
+			*scratch >>= 8
+			*scratch <<= 8
+			*scratch |= c << (56 - t)
+			if t == size-8 {
+				return *scratch >> (64 - size), nil
+			}
+			*scratch |= uint64(t + 8)
+		} else {
+			t := uint32(*scratch >> 56)
+			*scratch <<= 8
+			*scratch >>= 8
+			*scratch |= c << t
+			if t == size-8 {
+				return *scratch, nil
+			}
+			*scratch |= uint64(t+8) << 56
+		}
+	}
+}
+
+func (r *IOReader) ReadU16BE(scratch *uint64) (uint16, error) {
+	x, err := r.readUXX(scratch, 16, true)
+	return uint16(x), err
+}
+
+func (r *IOReader) ReadU16LE(scratch *uint64) (uint16, error) {
+	x, err := r.readUXX(scratch, 16, false)
+	return uint16(x), err
+}
+
+func (r *IOReader) ReadU24BE(scratch *uint64) (uint32, error) {
+	x, err := r.readUXX(scratch, 24, true)
+	return uint32(x), err
+}
+
+func (r *IOReader) ReadU24LE(scratch *uint64) (uint32, error) {
+	x, err := r.readUXX(scratch, 24, false)
+	return uint32(x), err
+}
+
+func (r *IOReader) ReadU32BE(scratch *uint64) (uint32, error) {
+	x, err := r.readUXX(scratch, 32, true)
+	return uint32(x), err
+}
+
+func (r *IOReader) ReadU32LE(scratch *uint64) (uint32, error) {
+	x, err := r.readUXX(scratch, 32, false)
+	return uint32(x), err
+}
+
+func (r *IOReader) ReadU40BE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 40, true) }
+func (r *IOReader) ReadU40LE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 40, false) }
+func (r *IOReader) ReadU48BE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 48, true) }
+func (r *IOReader) ReadU48LE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 48, false) }
+func (r *IOReader) ReadU56BE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 56, true) }
+func (r *IOReader) ReadU56LE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 56, false) }
+func (r *IOReader) ReadU64BE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 64, true) }
+func (r *IOReader) ReadU64LE(scratch *uint64) (uint64, error) { return r.readUXX(scratch, 64, false) }
+
+// Skip skips the number of bytes held in scratch. If fewer bytes are
+// available, it skips those and returns a suspension, with scratch holding
+// the number of bytes left to skip.
+func (r *IOReader) Skip(scratch *uint64) error {
+	n := r.Available()
+	if *scratch > n {
+		*scratch -= n
+		if r.buf != nil {
+			r.buf.RI = r.limit
+		}
+		return r.shortRead()
+	}
+	r.buf.RI += int(*scratch)
+	return nil
+}
+
+// UnreadU8 rewinds the read index by one byte. It is an error to rewind past
+// the mark.
+func (r *IOReader) UnreadU8() error {
+	if r.buf == nil || r.buf.RI <= r.mark {
+		return ErrInvalidIOOperation
+	}
+	r.buf.RI--
+	return nil
+}
+
+// Set makes r read from s, using b as the backing IOBuffer.
+func (r *IOReader) Set(b *IOBuffer, s []byte, closed bool) {
+	*b = IOBuffer{Data: s, WI: len(s), Closed: closed}
+	*r = IOReader{buf: b, limit: len(s), bound: true}
+}
+
+// SetLimit shrinks r's bounds, if necessary, so that no more than l bytes can
+// be read.
+func (r *IOReader) SetLimit(l uint64) {
+	if r.buf != nil && uint64(r.limit-r.buf.RI) > l {
+		r.limit = r.buf.RI + int(l)
+	}
+}
+
+// SetMark sets r's mark to the current read position.
+func (r *IOReader) SetMark() {
+	if r.buf != nil {
+		r.mark = r.buf.RI
+	}
+}
+
+// SinceMark returns the bytes read since the mark.
+func (r *IOReader) SinceMark() []byte {
+	if r.buf == nil {
+		return nil
+	}
+	return r.buf.Data[r.mark:r.buf.RI]
+}
+
+// IOWriter is a write-only view of an IOBuffer. See IOReader for a discussion
+// of its bounds.
+type IOWriter struct {
+	buf   *IOBuffer
+	mark  int
+	limit int
+	bound bool
+}
+
+// Derive sets w's bounds, if they are not already set, to the IOBuffer's
+// writable bytes. The generated code calls it on entry to every function that
+// takes an io_writer argument.
+func (w *IOWriter) Derive() {
+	if w.buf == nil {
+		return
+	}
+	if !w.bound {
+		w.mark = w.buf.WI
+		w.limit = len(w.buf.Data)
+		w.bound = true
+	}
+	if w.buf.Closed {
+		w.limit = w.buf.WI
+	}
+}
+
+// Available returns the number of bytes that can be written.
+func (w *IOWriter) Available() uint64 {
+	if w.buf == nil {
+		return 0
+	}
+	return uint64(w.limit - w.buf.WI)
+}
+
+// WriteU8 writes a byte. It returns SuspensionShortWrite if there is no room.
+func (w *IOWriter) WriteU8(x uint8) error {
+	if w.buf == nil || w.buf.WI >= w.limit {
+		return SuspensionShortWrite
+	}
+	w.buf.Data[w.buf.WI] = x
+	w.buf.WI++
+	return nil
+}
+
+// WriteU8Fast writes a byte, when the caller has proven that there is room.
+func (w *IOWriter) WriteU8Fast(x uint8) {
+	w.buf.Data[w.buf.WI] = x
+	w.buf.WI++
+}
+
+// writeUXX writes x as a size-bit big-endian or little-endian number. If
+// fewer than size/8 bytes of room are available, it writes what fits and
+// returns a suspension. The number of bytes written so far is held in
+// scratch, so that calling writeUXX again (with the same scratch and x)
+// resumes the write.
+func (w *IOWriter) writeUXX(scratch *uint64, x uint64, size uint32, be bool) error {
+	n := uint64(size / 8)
+	for *scratch < n {
+		if w.buf == nil || w.buf.WI >= w.limit {
+			return SuspensionShortWrite
+		}
+		shift := 8 * *scratch
+		if be {
+			shift = uint64(size) - 8 - shift
+		}
+		w.buf.Data[w.buf.WI] = uint8(x >> shift)
+		w.buf.WI++
+		*scratch++
+	}
+	return nil
+}
+
+func (w *IOWriter) WriteU16BE(scratch *uint64, x uint16) error {
+	return w.writeUXX(scratch, uint64(x), 16, true)
+}
+
+func (w *IOWriter) WriteU16LE(scratch *uint64, x uint16) error {
+	return w.writeUXX(scratch, uint64(x), 16, false)
+}
+
+func (w *IOWriter) WriteU24BE(scratch *uint64, x uint32) error {
+	return w.writeUXX(scratch, uint64(x), 24, true)
+}
+
+func (w *IOWriter) WriteU24LE(scratch *uint64, x uint32) error {
+	return w.writeUXX(scratch, uint64(x), 24, false)
+}
+
+func (w *IOWriter) WriteU32BE(scratch *uint64, x uint32) error {
+	return w.writeUXX(scratch, uint64(x), 32, true)
+}
+
+func (w *IOWriter) WriteU32LE(scratch *uint64, x uint32) error {
+	return w.writeUXX(scratch, uint64(x), 32, false)
+}
+
+func (w *IOWriter) WriteU40BE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 40, true)
+}
+
+func (w *IOWriter) WriteU40LE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 40, false)
+}
+
+func (w *IOWriter) WriteU48BE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 48, true)
+}
+
+func (w *IOWriter) WriteU48LE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 48, false)
+}
+
+func (w *IOWriter) WriteU56BE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 56, true)
+}
+
+func (w *IOWriter) WriteU56LE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 56, false)
+}
+
+func (w *IOWriter) WriteU64BE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 64, true)
+}
+
+func (w *IOWriter) WriteU64LE(scratch *uint64, x uint64) error {
+	return w.writeUXX(scratch, x, 64, false)
+}
+
+// CopyFromHistory32 copies up to length bytes, starting distance bytes before
+// the write position, to the write position. The source and destination can
+// overlap. It copies nothing if distance is zero or reaches before the mark.
+// It returns the number of bytes copied.
+func (w *IOWriter) CopyFromHistory32(distance uint32, length uint32) uint32 {
+	if distance == 0 || w.buf == nil {
+		return 0
+	}
+	p := w.buf.WI
+	if p-w.mark < int(distance) {
+		return 0
+	}
+	if n := w.limit - p; int(length) > n {
+		length = uint32(n)
+	}
+	return w.CopyFromHistory32Fast(distance, length)
+}
+
+// CopyFromHistory32Fast is like CopyFromHistory32, when the caller has proven
+// that distance is positive, that distance does not reach before the mark and
+// that there is room for length bytes.
+func (w *IOWriter) CopyFromHistory32Fast(distance uint32, length uint32) uint32 {
+	d := w.buf.Data
+	p := w.buf.WI
+	q := p - int(distance)
+	for n := int(length); n > 0; n-- {
+		d[p] = d[q]
+		p++
+		q++
+	}
+	w.buf.WI = p
+	return length
+}
+
+// CopyFromReader32 copies up to length bytes from r to w. It returns the
+// number of bytes copied.
+func (w *IOWriter) CopyFromReader32(r *IOReader, length uint32) uint32 {
+	n := uint64(length)
+	if a := w.Available(); n > a {
+		n = a
+	}
+	if a := r.Available(); n > a {
+		n = a
+	}
+	if n > 0 {
+		copy(w.buf.Data[w.buf.WI:], r.buf.Data[r.buf.RI:r.buf.RI+int(n)])
+		w.buf.WI += int(n)
+		r.buf.RI += int(n)
+	}
+	return uint32(n)
+}
+
+// CopyFromSlice copies as much of s as there is room for. It returns the
+// number of bytes copied.
+func (w *IOWriter) CopyFromSlice(s []byte) uint64 {
+	n := uint64(len(s))
+	if a := w.Available(); n > a {
+		n = a
+	}
+	if n > 0 {
+		copy(w.buf.Data[w.buf.WI:], s[:n])
+		w.buf.WI += int(n)
+	}
+	return n
+}
+
+// CopyFromSlice32 copies up to length bytes of s. It returns the number of
+// bytes copied.
+func (w *IOWriter) CopyFromSlice32(s []byte, length uint32) uint32 {
+	if uint64(len(s)) > uint64(length) {
+		s = s[:length]
+	}
+	return uint32(w.CopyFromSlice(s))
+}
+
+// Set makes w write to s, using b as the backing IOBuffer.
+func (w *IOWriter) Set(b *IOBuffer, s []byte) {
+	*b = IOBuffer{Data: s}
+	*w = IOWriter{buf: b, limit: len(s), bound: true}
+}
+
+// SetLimit shrinks w's bounds, if necessary, so that no more than l bytes can
+// be written.
+func (w *IOWriter) SetLimit(l uint64) {
+	if w.buf != nil && uint64(w.limit-w.buf.WI) > l {
+		w.limit = w.buf.WI + int(l)
+	}
+}
+
+// SetMark sets w's mark to the current write position.
+func (w *IOWriter) SetMark() {
+	if w.buf != nil {
+		w.mark = w.buf.WI
+	}
+}
+
+// SinceMark returns the bytes written since the mark.
+func (w *IOWriter) SinceMark() []byte {
+	if w.buf == nil {
+		return nil
+	}
+	return w.buf.Data[w.mark:w.buf.WI]
+}
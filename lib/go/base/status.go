@@ -0,0 +1,78 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+// Status is a non-ok Wuffs status: either an error or a suspension. The ok
+// status is represented by a nil error.
+//
+// Statuses are compared by pointer identity. Each Wuffs package declares its
+// statuses as package-level variables, the same as the built-in statuses
+// below.
+type Status struct {
+	msg        string
+	suspension bool
+}
+
+// NewError returns a new error status with the given message.
+func NewError(msg string) *Status { return &Status{msg: msg} }
+
+// NewSuspension returns a new suspension status with the given message.
+//
+// A suspension is not an unrecoverable error. It means that a coroutine
+// cannot make further progress until e.g. more input becomes available or
+// more output space is made available, and that the coroutine can be resumed
+// by calling the same method again.
+func NewSuspension(msg string) *Status { return &Status{msg: msg, suspension: true} }
+
+// Error implements the error interface.
+func (z *Status) Error() string { return z.msg }
+
+// IsSuspension returns whether z is a suspension, not an error.
+func (z *Status) IsSuspension() bool { return z.suspension }
+
+// IsError returns whether err is an error, as opposed to ok (nil) or a
+// suspension. Any non-nil error that is not a *Status is also an error.
+func IsError(err error) bool {
+	if err == nil {
+		return false
+	}
+	z, ok := err.(*Status)
+	return !ok || !z.suspension
+}
+
+// IsSuspension returns whether err is a suspension.
+func IsSuspension(err error) bool {
+	z, ok := err.(*Status)
+	return ok && z != nil && z.suspension
+}
+
+// The built-in statuses. Their order matches that of the lang/builtin
+// package's StatusList.
+var (
+	ErrBadWuffsVersion              = NewError("bad wuffs version")
+	ErrBadSizeofReceiver            = NewError("bad sizeof receiver")
+	ErrBadReceiver                  = NewError("bad receiver")
+	ErrBadArgument                  = NewError("bad argument")
+	ErrCheckWuffsVersionNotCalled   = NewError("check_wuffs_version not called")
+	ErrCheckWuffsVersionCalledTwice = NewError("check_wuffs_version called twice")
+	ErrInvalidIOOperation           = NewError("invalid I/O operation")
+	ErrClosedForWrites              = NewError("closed for writes")
+	ErrUnexpectedEOF                = NewError("unexpected EOF")
+	SuspensionShortRead             = NewSuspension("short read")
+	SuspensionShortWrite            = NewSuspension("short write")
+	ErrCannotReturnASuspension      = NewError("cannot return a suspension")
+	ErrInvalidCallSequence          = NewError("invalid call sequence")
+	SuspensionEndOfData             = NewSuspension("end of data")
+)
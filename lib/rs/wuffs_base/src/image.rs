@@ -0,0 +1,407 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use crate::slice::{SliceU8, TableU8};
+
+// ---------------- Ranges and Rects
+
+/// RectIeU32 is a rectangle on the integer grid. The "Ie" means that the
+/// bounds are inclusive on the low end and exclusive on the high end. It is
+/// valid for min >= max, in which case the rectangle is empty.
+#[derive(Clone, Copy, Debug, Default, PartialEq, Eq)]
+pub struct RectIeU32 {
+    pub min_inclusive_x: u32,
+    pub min_inclusive_y: u32,
+    pub max_exclusive_x: u32,
+    pub max_exclusive_y: u32,
+}
+
+impl RectIeU32 {
+    pub fn set_min_inclusive_x(&mut self, x: u32) {
+        self.min_inclusive_x = x;
+    }
+
+    pub fn set_min_inclusive_y(&mut self, y: u32) {
+        self.min_inclusive_y = y;
+    }
+
+    pub fn set_max_exclusive_x(&mut self, x: u32) {
+        self.max_exclusive_x = x;
+    }
+
+    pub fn set_max_exclusive_y(&mut self, y: u32) {
+        self.max_exclusive_y = y;
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.min_inclusive_x >= self.max_exclusive_x || self.min_inclusive_y >= self.max_exclusive_y
+    }
+
+    pub fn width(&self) -> u32 {
+        self.max_exclusive_x.saturating_sub(self.min_inclusive_x)
+    }
+
+    pub fn height(&self) -> u32 {
+        self.max_exclusive_y.saturating_sub(self.min_inclusive_y)
+    }
+}
+
+/// RectIiU32 is a rectangle on the integer grid. The "Ii" means that the
+/// bounds are inclusive on the low end and inclusive on the high end. It is
+/// valid for min > max, in which case the rectangle is empty.
+#[derive(Clone, Copy, Debug, Default, PartialEq, Eq)]
+pub struct RectIiU32 {
+    pub min_inclusive_x: u32,
+    pub min_inclusive_y: u32,
+    pub max_inclusive_x: u32,
+    pub max_inclusive_y: u32,
+}
+
+impl RectIiU32 {
+    pub fn set_min_inclusive_x(&mut self, x: u32) {
+        self.min_inclusive_x = x;
+    }
+
+    pub fn set_min_inclusive_y(&mut self, y: u32) {
+        self.min_inclusive_y = y;
+    }
+
+    pub fn set_max_inclusive_x(&mut self, x: u32) {
+        self.max_inclusive_x = x;
+    }
+
+    pub fn set_max_inclusive_y(&mut self, y: u32) {
+        self.max_inclusive_y = y;
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.min_inclusive_x > self.max_inclusive_x || self.min_inclusive_y > self.max_inclusive_y
+    }
+}
+
+// ---------------- Images
+
+// The PIXEL_FORMAT constants encode the format of the bytes that constitute
+// an image frame's pixel data. Their bits are the same as the C
+// wuffs_base__pixel_format type's.
+pub const PIXEL_FORMAT_INVALID: u32 = 0x00000000;
+pub const PIXEL_FORMAT_BGRA_NONPREMUL: u32 = 0x22008888;
+pub const PIXEL_FORMAT_BGRA_NONPREMUL_INDEXED: u32 = 0x22088888;
+pub const PIXEL_FORMAT_Y: u32 = 0x10000008;
+pub const PIXEL_FORMAT_YUV: u32 = 0x40200888;
+
+pub const PIXEL_FORMAT_NUM_PLANES_MAX: usize = 4;
+
+const PIXEL_FORMAT_DEPTHS: [u32; 16] = [0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 16, 24, 32, 48, 64];
+
+/// pixel_format_bits_per_pixel returns the number of bits per pixel for a
+/// packed (single plane) pixel format. For indexed formats, this is the number
+/// of bits per index value. It returns zero for invalid or planar pixel
+/// formats.
+pub fn pixel_format_bits_per_pixel(f: u32) -> u32 {
+    if f == PIXEL_FORMAT_INVALID || (f >> 20) & 0x03 != 0 {
+        return 0;
+    }
+    if (f >> 16) & 0x0F != 0 {
+        return PIXEL_FORMAT_DEPTHS[((f >> 16) & 0x0F) as usize];
+    }
+    (0..4)
+        .map(|i| PIXEL_FORMAT_DEPTHS[((f >> (4 * i)) & 0x0F) as usize])
+        .sum()
+}
+
+/// pixel_format_num_planes returns the number of planes, or zero for an
+/// invalid pixel format.
+pub fn pixel_format_num_planes(f: u32) -> u32 {
+    if f == PIXEL_FORMAT_INVALID {
+        return 0;
+    }
+    ((f >> 20) & 0x03) + 1
+}
+
+/// pixel_format_plane_bits_per_pixel returns the number of bits per pixel in
+/// the p'th plane. For packed pixel formats, this is the same as
+/// pixel_format_bits_per_pixel for p == 0. For planar pixel formats, each
+/// plane holds one channel, so this is that channel's depth. It returns zero
+/// if there is no p'th plane.
+pub fn pixel_format_plane_bits_per_pixel(f: u32, p: u32) -> u32 {
+    let n = pixel_format_num_planes(f);
+    if p >= n {
+        0
+    } else if n == 1 {
+        pixel_format_bits_per_pixel(f)
+    } else {
+        PIXEL_FORMAT_DEPTHS[((f >> (4 * p)) & 0x0F) as usize]
+    }
+}
+
+// The PIXEL_SUBSAMPLING constants encode the mapping of pixel space
+// coordinates to each plane's pixel buffer indices. Their bits are the same as
+// the C wuffs_base__pixel_subsampling type's.
+pub const PIXEL_SUBSAMPLING_NONE: u32 = 0x000000;
+pub const PIXEL_SUBSAMPLING_444: u32 = 0x000000;
+pub const PIXEL_SUBSAMPLING_440: u32 = 0x010100;
+pub const PIXEL_SUBSAMPLING_422: u32 = 0x101000;
+pub const PIXEL_SUBSAMPLING_420: u32 = 0x111100;
+pub const PIXEL_SUBSAMPLING_411: u32 = 0x202000;
+pub const PIXEL_SUBSAMPLING_410: u32 = 0x212100;
+
+/// The ANIMATION_DISPOSAL constants are an ImageBuffer's disposal values: how
+/// to dispose of an animated image's frame after displaying it.
+pub const ANIMATION_DISPOSAL_NONE: u8 = 0;
+pub const ANIMATION_DISPOSAL_RESTORE_BACKGROUND: u8 = 1;
+pub const ANIMATION_DISPOSAL_RESTORE_PREVIOUS: u8 = 2;
+
+/// FLICKS_PER_SECOND is the number of flicks (the unit of an ImageBuffer's
+/// duration) per second.
+pub const FLICKS_PER_SECOND: u64 = 705600000;
+
+/// ImageConfig holds an image's overall configuration: its pixel format and
+/// dimensions. The default value is an invalid configuration.
+#[derive(Clone, Copy, Debug, Default, PartialEq, Eq)]
+pub struct ImageConfig {
+    pixfmt: u32,
+    pixsub: u32,
+    width: u32,
+    height: u32,
+    num_loops: u32,
+}
+
+impl ImageConfig {
+    /// initialize sets the fields, or invalidates the configuration if pixfmt
+    /// is invalid.
+    pub fn initialize(
+        &mut self,
+        pixfmt: u32,
+        pixsub: u32,
+        width: u32,
+        height: u32,
+        num_loops: u32,
+    ) {
+        let n = pixel_format_num_planes(pixfmt);
+        if n == 0 || (0..n).any(|p| pixel_format_plane_bits_per_pixel(pixfmt, p) == 0) {
+            *self = ImageConfig::default();
+            return;
+        }
+        *self = ImageConfig {
+            pixfmt,
+            pixsub,
+            width,
+            height,
+            num_loops,
+        };
+    }
+
+    pub fn is_valid(&self) -> bool {
+        self.pixfmt != PIXEL_FORMAT_INVALID
+    }
+
+    pub fn pixel_format(&self) -> u32 {
+        self.pixfmt
+    }
+
+    pub fn pixel_subsampling(&self) -> u32 {
+        self.pixsub
+    }
+
+    pub fn width(&self) -> u32 {
+        self.width
+    }
+
+    pub fn height(&self) -> u32 {
+        self.height
+    }
+
+    pub fn num_loops(&self) -> u32 {
+        self.num_loops
+    }
+
+    /// plane_row_length returns the number of bytes per row in the p'th
+    /// plane. For planar pixel formats, the pixel subsampling applies. It
+    /// returns zero if there is no p'th plane.
+    pub fn plane_row_length(&self, p: u32) -> u64 {
+        let mut width = self.width as u64;
+        if width > 0 && pixel_format_num_planes(self.pixfmt) > 1 {
+            let shift = ((p & 0x03) * 8) + 4;
+            let bias_x = ((self.pixsub >> (shift + 2)) & 0x03) as u64;
+            let shift_x = ((self.pixsub >> shift) & 0x03) as u64;
+            width = ((width - 1 + bias_x) >> shift_x) + 1;
+        }
+        (width * (pixel_format_plane_bits_per_pixel(self.pixfmt, p) as u64) + 7) / 8
+    }
+
+    /// plane_height returns the number of rows in the p'th plane. For planar
+    /// pixel formats, the pixel subsampling applies. It returns zero if there
+    /// is no p'th plane.
+    pub fn plane_height(&self, p: u32) -> u32 {
+        if p >= pixel_format_num_planes(self.pixfmt) {
+            return 0;
+        }
+        let mut height = self.height as u64;
+        if height > 0 && pixel_format_num_planes(self.pixfmt) > 1 {
+            let shift = (p & 0x03) * 8;
+            let bias_y = ((self.pixsub >> (shift + 2)) & 0x03) as u64;
+            let shift_y = ((self.pixsub >> shift) & 0x03) as u64;
+            height = ((height - 1 + bias_y) >> shift_y) + 1;
+        }
+        height as u32
+    }
+
+    /// pixbuf_size returns the number of bytes needed to hold the image's
+    /// pixel buffer: the sum of every plane's size, laid out consecutively.
+    pub fn pixbuf_size(&self) -> u64 {
+        (0..pixel_format_num_planes(self.pixfmt))
+            .map(|p| self.plane_row_length(p) * (self.plane_height(p) as u64))
+            .sum()
+    }
+
+    pub fn invalidate(&mut self) {
+        *self = ImageConfig::default();
+    }
+}
+
+/// ImageBuffer is an image's pixel buffer, plus per-frame metadata.
+#[derive(Clone, Debug)]
+pub struct ImageBuffer<'a> {
+    config: ImageConfig,
+    loop_count: u32,
+    planes: [TableU8<'a>; PIXEL_FORMAT_NUM_PLANES_MAX],
+    dirty_rect: RectIeU32,
+    duration: u64,
+    blend: bool,
+    disposal: u8,
+    palette_changed: bool,
+    palette: [u8; 1024],
+}
+
+impl<'a> Default for ImageBuffer<'a> {
+    fn default() -> Self {
+        ImageBuffer {
+            config: ImageConfig::default(),
+            loop_count: 0,
+            planes: [TableU8::default(); PIXEL_FORMAT_NUM_PLANES_MAX],
+            dirty_rect: RectIeU32::default(),
+            duration: 0,
+            blend: false,
+            disposal: 0,
+            palette_changed: false,
+            palette: [0; 1024],
+        }
+    }
+}
+
+impl<'a> ImageBuffer<'a> {
+    /// set_from_slice sets the buffer to use mem as its pixel buffer. For
+    /// planar pixel formats, the planes are laid out consecutively, as per
+    /// pixbuf_size. It leaves the buffer with an invalid configuration if mem
+    /// is too short.
+    pub fn set_from_slice(&mut self, config: ImageConfig, mem: &'a mut [u8]) {
+        *self = ImageBuffer::default();
+        if !config.is_valid() || config.pixbuf_size() > (mem.len() as u64) {
+            return;
+        }
+        self.config = config;
+        let mut mem = mem;
+        for p in 0..pixel_format_num_planes(config.pixfmt) {
+            let w = config.plane_row_length(p) as usize;
+            let h = config.plane_height(p) as usize;
+            let (plane, rest) = mem.split_at_mut(w * h);
+            self.planes[p as usize] = TableU8::from_slice(SliceU8::from(plane), w, h, w);
+            mem = rest;
+        }
+    }
+
+    /// update sets the per-frame metadata. The palette argument is ignored
+    /// unless its length is exactly 1024.
+    pub fn update(
+        &mut self,
+        dirty_rect: RectIeU32,
+        duration: u64,
+        blend: bool,
+        disposal: u8,
+        palette: SliceU8,
+    ) {
+        // Clip the dirty rectangle to the image bounds.
+        let mut dirty_rect = dirty_rect;
+        dirty_rect.max_exclusive_x = dirty_rect.max_exclusive_x.min(self.config.width);
+        dirty_rect.max_exclusive_y = dirty_rect.max_exclusive_y.min(self.config.height);
+        self.dirty_rect = dirty_rect;
+
+        self.duration = duration;
+        self.blend = blend;
+        self.disposal = disposal;
+        self.palette_changed = palette.len() == 1024;
+        if self.palette_changed {
+            self.palette.copy_from_slice(palette.as_slice());
+        }
+    }
+
+    /// loop_ returns whether the image decoder should loop back to the
+    /// beginning of the animation, assuming that we've reached the end of the
+    /// encoded stream. If so, it increments the count of the animation loops
+    /// played so far.
+    pub fn loop_(&mut self) -> bool {
+        let n = self.config.num_loops;
+        if n == 0 {
+            return true;
+        }
+        if self.loop_count < n - 1 {
+            self.loop_count += 1;
+            return true;
+        }
+        false
+    }
+
+    /// plane returns the p'th plane of the pixel buffer.
+    pub fn plane(&self, p: u32) -> TableU8<'a> {
+        match self.planes.get(p as usize) {
+            Some(t) => *t,
+            None => TableU8::default(),
+        }
+    }
+
+    pub fn image_config(&mut self) -> &mut ImageConfig {
+        &mut self.config
+    }
+
+    pub fn dirty_rect(&self) -> RectIeU32 {
+        self.dirty_rect
+    }
+
+    pub fn duration(&self) -> u64 {
+        self.duration
+    }
+
+    pub fn blend(&self) -> bool {
+        self.blend
+    }
+
+    pub fn disposal(&self) -> u8 {
+        self.disposal
+    }
+
+    pub fn palette_changed(&self) -> bool {
+        self.palette_changed
+    }
+
+    pub fn palette(&self) -> &[u8] {
+        &self.palette
+    }
+
+    /// palette_slice is like palette but returns a SliceU8, for the generated
+    /// code.
+    pub fn palette_slice(&mut self) -> SliceU8<'_> {
+        SliceU8::from(&mut self.palette[..])
+    }
+}
@@ -0,0 +1,622 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use core::marker::PhantomData;
+use core::ptr;
+
+use crate::slice::SliceU8;
+use crate::status::*;
+
+/// IoBuffer is a 1-dimensional buffer (a slice), plus additional indexes into
+/// that buffer, plus an opened / closed flag.
+///
+/// The bytes in data[ri .. wi] are available to read. The bytes in data[wi ..]
+/// are available to write.
+#[derive(Debug, Default)]
+pub struct IoBuffer<'a> {
+    pub data: SliceU8<'a>,
+    pub wi: usize,    // Write index. Invariant: wi <= data.len().
+    pub ri: usize,    // Read  index. Invariant: ri <= wi.
+    pub closed: bool, // No further writes are expected.
+}
+
+impl<'a> IoBuffer<'a> {
+    /// new returns an empty buffer, backed by data, that is ready to write to.
+    pub fn new(data: &'a mut [u8]) -> Self {
+        IoBuffer {
+            data: SliceU8::from(data),
+            wi: 0,
+            ri: 0,
+            closed: false,
+        }
+    }
+
+    /// new_reader returns a buffer, backed by data, whose first wi bytes are
+    /// ready to read.
+    pub fn new_reader(data: &'a mut [u8], wi: usize, closed: bool) -> Self {
+        let wi = if wi < data.len() { wi } else { data.len() };
+        IoBuffer {
+            data: SliceU8::from(data),
+            wi,
+            ri: 0,
+            closed,
+        }
+    }
+
+    /// reader returns an IoReader that reads from the buffer.
+    pub fn reader(&mut self) -> IoReader<'_> {
+        IoReader {
+            buf: self as *mut IoBuffer as *mut IoBuffer<'static>,
+            mark: 0,
+            limit: 0,
+            bound: false,
+            _marker: PhantomData,
+        }
+    }
+
+    /// writer returns an IoWriter that writes to the buffer.
+    pub fn writer(&mut self) -> IoWriter<'_> {
+        IoWriter {
+            buf: self as *mut IoBuffer as *mut IoBuffer<'static>,
+            mark: 0,
+            limit: 0,
+            bound: false,
+            _marker: PhantomData,
+        }
+    }
+
+    /// readable returns the bytes that are available to read.
+    pub fn readable(&self) -> &[u8] {
+        &self.data.as_slice()[self.ri..self.wi]
+    }
+
+    /// compact moves any written but unread bytes to the start of the buffer.
+    pub fn compact(&mut self) {
+        if self.ri == 0 {
+            return;
+        }
+        let n = self.wi - self.ri;
+        unsafe { ptr::copy(self.data.as_ptr().add(self.ri), self.data.as_ptr(), n) };
+        self.wi = n;
+        self.ri = 0;
+    }
+}
+
+/// IoReader is a read-only view of an IoBuffer.
+///
+/// Like the C io_reader type, it is passed by value. Its mark and limit are
+/// the bounds (as indexes into the IoBuffer's data) that the generated code
+/// can read within. When not yet bound, the callee substitutes the implicit
+/// bounds derived from the IoBuffer.
+#[derive(Clone, Copy, Debug)]
+pub struct IoReader<'a> {
+    buf: *mut IoBuffer<'static>,
+    mark: usize,
+    limit: usize,
+    bound: bool,
+    _marker: PhantomData<&'a mut IoBuffer<'a>>,
+}
+
+impl<'a> Default for IoReader<'a> {
+    fn default() -> Self {
+        IoReader {
+            buf: ptr::null_mut(),
+            mark: 0,
+            limit: 0,
+            bound: false,
+            _marker: PhantomData,
+        }
+    }
+}
+
+impl<'a> IoReader<'a> {
+    // buf returns the IoBuffer. Its lifetime is not tied to &self, so that the
+    // caller can update the reader or writer while holding it.
+    fn buf<'b>(&self) -> Option<&'b mut IoBuffer<'static>> {
+        unsafe { self.buf.as_mut() }
+    }
+
+    /// derive sets the bounds, if they are not already set, to the IoBuffer's
+    /// readable bytes. The generated code calls it on entry to every function
+    /// that takes an io_reader argument.
+    pub fn derive(&mut self) {
+        if let Some(b) = self.buf() {
+            if !self.bound {
+                self.mark = b.ri;
+                self.limit = b.wi;
+                self.bound = true;
+            }
+        }
+    }
+
+    /// available returns the number of bytes that can be read.
+    pub fn available(&self) -> u64 {
+        match self.buf() {
+            Some(b) => (self.limit - b.ri) as u64,
+            None => 0,
+        }
+    }
+
+    fn short_read(&self) -> Status {
+        match self.buf() {
+            Some(b) if b.closed && b.wi == self.limit => ERROR_UNEXPECTED_EOF,
+            _ => SUSPENSION_SHORT_READ,
+        }
+    }
+
+    /// read_u8 reads a byte into out. It returns SUSPENSION_SHORT_READ, or
+    /// ERROR_UNEXPECTED_EOF if the IoBuffer is closed, if no byte is
+    /// available.
+    pub fn read_u8(&mut self, out: &mut u8) -> Status {
+        match self.buf() {
+            Some(b) if b.ri < self.limit => {
+                *out = unsafe { *b.data.get_unchecked(b.ri) };
+                b.ri += 1;
+                Status::Ok
+            }
+            _ => self.short_read(),
+        }
+    }
+
+    /// read_u8_fast reads a byte.
+    ///
+    /// # Safety
+    ///
+    /// The caller must have proven that a byte is available.
+    pub unsafe fn read_u8_fast(&mut self) -> u8 {
+        let b = &mut *self.buf;
+        let x = *b.data.get_unchecked(b.ri);
+        b.ri += 1;
+        x
+    }
+
+    /// read_uxx reads a size-bit big-endian or little-endian number. If fewer
+    /// than size/8 bytes are available, it reads the bytes that are available
+    /// and returns a suspension. The partial result is accumulated in
+    /// scratch, so that calling read_uxx again (with the same scratch) resumes
+    /// the read.
+    ///
+    /// The scratch encoding is the same as the C code's: the number of bits
+    /// read so far is held in the low (big-endian) or high (little-endian)
+    /// byte.
+    fn read_uxx(&mut self, out: &mut u64, scratch: &mut u64, size: u32, be: bool) -> Status {
+        let limit = self.limit;
+        let b = match self.buf() {
+            Some(b) => b,
+            None => return self.short_read(),
+        };
+        let n = (size / 8) as usize;
+        if *scratch == 0 && limit - b.ri >= n {
+            let mut x = 0u64;
+            for i in 0..n {
+                let j = if be { i } else { n - 1 - i };
+                x = (x << 8) | (unsafe { *b.data.get_unchecked(b.ri + j) } as u64);
+            }
+            b.ri += n;
+            *out = x;
+            return Status::Ok;
+        }
+
+        loop {
+            if b.ri >= limit {
+                return self.short_read();
+            }
+            let c = unsafe { *b.data.get_unchecked(b.ri) } as u64;
+            b.ri += 1;
+            if be {
+                let t = (*scratch & 0xFF) as u32;
+                *scratch >>= 8;
+                *scratch <<= 8;
+                *scratch |= c << (56 - t);
+                if t == size - 8 {
+                    *out = *scratch >> (64 - size);
+                    return Status::Ok;
+                }
+                *scratch |= (t + 8) as u64;
+            } else {
+                let t = (*scratch >> 56) as u32;
+                *scratch <<= 8;
+                *scratch >>= 8;
+                *scratch |= c << t;
+                if t == size - 8 {
+                    *out = *scratch;
+                    return Status::Ok;
+                }
+                *scratch |= ((t + 8) as u64) << 56;
+            }
+        }
+    }
+}
+
+macro_rules! read_uxx {
+    ($($name:ident, $t:ty, $size:expr, $be:expr;)*) => {
+        impl<'a> IoReader<'a> {
+            $(
+                pub fn $name(&mut self, out: &mut $t, scratch: &mut u64) -> Status {
+                    let mut x = 0u64;
+                    let status = self.read_uxx(&mut x, scratch, $size, $be);
+                    *out = x as $t;
+                    status
+                }
+            )*
+        }
+    };
+}
+
+read_uxx! {
+    read_u16be, u16, 16, true;
+    read_u16le, u16, 16, false;
+    read_u24be, u32, 24, true;
+    read_u24le, u32, 24, false;
+    read_u32be, u32, 32, true;
+    read_u32le, u32, 32, false;
+    read_u40be, u64, 40, true;
+    read_u40le, u64, 40, false;
+    read_u48be, u64, 48, true;
+    read_u48le, u64, 48, false;
+    read_u56be, u64, 56, true;
+    read_u56le, u64, 56, false;
+    read_u64be, u64, 64, true;
+    read_u64le, u64, 64, false;
+}
+
+macro_rules! write_uxx {
+    ($($name:ident, $t:ty, $size:expr, $be:expr;)*) => {
+        impl<'a> IoWriter<'a> {
+            $(
+                pub fn $name(&mut self, x: $t, scratch: &mut u64) -> Status {
+                    self.write_uxx(x as u64, scratch, $size, $be)
+                }
+            )*
+        }
+    };
+}
+
+write_uxx! {
+    write_u16be, u16, 16, true;
+    write_u16le, u16, 16, false;
+    write_u24be, u32, 24, true;
+    write_u24le, u32, 24, false;
+    write_u32be, u32, 32, true;
+    write_u32le, u32, 32, false;
+    write_u40be, u64, 40, true;
+    write_u40le, u64, 40, false;
+    write_u48be, u64, 48, true;
+    write_u48le, u64, 48, false;
+    write_u56be, u64, 56, true;
+    write_u56le, u64, 56, false;
+    write_u64be, u64, 64, true;
+    write_u64le, u64, 64, false;
+}
+
+impl<'a> IoReader<'a> {
+    /// skip skips the number of bytes held in scratch. If fewer bytes are
+    /// available, it skips those and returns a suspension, with scratch
+    /// holding the number of bytes left to skip.
+    pub fn skip(&mut self, scratch: &mut u64) -> Status {
+        let n = self.available();
+        if *scratch > n {
+            *scratch -= n;
+            let limit = self.limit;
+            if let Some(b) = self.buf() {
+                b.ri = limit;
+            }
+            return self.short_read();
+        }
+        if let Some(b) = self.buf() {
+            b.ri += *scratch as usize;
+        }
+        Status::Ok
+    }
+
+    /// unread_u8 rewinds the read index by one byte. It is an error to rewind
+    /// past the mark.
+    pub fn unread_u8(&mut self) -> Status {
+        let mark = self.mark;
+        match self.buf() {
+            Some(b) if b.ri > mark => {
+                b.ri -= 1;
+                Status::Ok
+            }
+            _ => ERROR_INVALID_I_O_OPERATION,
+        }
+    }
+
+    /// set makes the reader read from s, using b as the backing IoBuffer.
+    ///
+    /// # Safety
+    ///
+    /// The reader must not outlive b.
+    pub unsafe fn set(&mut self, b: &mut IoBuffer, s: SliceU8, closed: bool) {
+        *b = IoBuffer {
+            data: SliceU8::from_raw_parts(s.as_ptr(), s.len()),
+            wi: s.len(),
+            ri: 0,
+            closed,
+        };
+        *self = IoReader {
+            buf: b as *mut IoBuffer as *mut IoBuffer<'static>,
+            mark: 0,
+            limit: s.len(),
+            bound: true,
+            _marker: PhantomData,
+        };
+    }
+
+    /// set_limit shrinks the bounds, if necessary, so that no more than l
+    /// bytes can be read.
+    pub fn set_limit(&mut self, l: u64) {
+        let limit = self.limit;
+        if let Some(b) = self.buf() {
+            if ((limit - b.ri) as u64) > l {
+                self.limit = b.ri + (l as usize);
+            }
+        }
+    }
+
+    /// set_mark sets the mark to the current read position.
+    pub fn set_mark(&mut self) {
+        if let Some(b) = self.buf() {
+            self.mark = b.ri;
+        }
+    }
+
+    /// since_mark returns the bytes read since the mark.
+    pub fn since_mark(&self) -> SliceU8<'a> {
+        match self.buf() {
+            Some(b) => unsafe { b.data.subslice_ij(self.mark, b.ri) },
+            None => SliceU8::default(),
+        }
+    }
+}
+
+/// IoWriter is a write-only view of an IoBuffer. See IoReader for a
+/// discussion of its bounds.
+#[derive(Clone, Copy, Debug)]
+pub struct IoWriter<'a> {
+    buf: *mut IoBuffer<'static>,
+    mark: usize,
+    limit: usize,
+    bound: bool,
+    _marker: PhantomData<&'a mut IoBuffer<'a>>,
+}
+
+impl<'a> Default for IoWriter<'a> {
+    fn default() -> Self {
+        IoWriter {
+            buf: ptr::null_mut(),
+            mark: 0,
+            limit: 0,
+            bound: false,
+            _marker: PhantomData,
+        }
+    }
+}
+
+impl<'a> IoWriter<'a> {
+    // buf returns the IoBuffer. Its lifetime is not tied to &self, so that the
+    // caller can update the reader or writer while holding it.
+    fn buf<'b>(&self) -> Option<&'b mut IoBuffer<'static>> {
+        unsafe { self.buf.as_mut() }
+    }
+
+    /// derive sets the bounds, if they are not already set, to the IoBuffer's
+    /// writable bytes. The generated code calls it on entry to every function
+    /// that takes an io_writer argument.
+    pub fn derive(&mut self) {
+        if let Some(b) = self.buf() {
+            if !self.bound {
+                self.mark = b.wi;
+                self.limit = b.data.len();
+                self.bound = true;
+            }
+            if b.closed {
+                self.limit = b.wi;
+            }
+        }
+    }
+
+    /// available returns the number of bytes that can be written.
+    pub fn available(&self) -> u64 {
+        match self.buf() {
+            Some(b) => (self.limit - b.wi) as u64,
+            None => 0,
+        }
+    }
+
+    /// write_u8 writes a byte. It returns SUSPENSION_SHORT_WRITE if there is
+    /// no room.
+    pub fn write_u8(&mut self, x: u8) -> Status {
+        match self.buf() {
+            Some(b) if b.wi < self.limit => {
+                unsafe { *b.data.get_unchecked_mut(b.wi) = x };
+                b.wi += 1;
+                Status::Ok
+            }
+            _ => SUSPENSION_SHORT_WRITE,
+        }
+    }
+
+    /// write_u8_fast writes a byte.
+    ///
+    /// # Safety
+    ///
+    /// The caller must have proven that there is room for a byte.
+    pub unsafe fn write_u8_fast(&mut self, x: u8) {
+        let b = &mut *self.buf;
+        *b.data.get_unchecked_mut(b.wi) = x;
+        b.wi += 1;
+    }
+
+    /// write_uxx writes x as a size-bit big-endian or little-endian number. If
+    /// fewer than size/8 bytes of room are available, it writes what fits
+    /// and returns a suspension. The number of bytes written so far is held
+    /// in scratch, so that calling write_uxx again (with the same scratch and
+    /// x) resumes the write.
+    fn write_uxx(&mut self, x: u64, scratch: &mut u64, size: u32, be: bool) -> Status {
+        let limit = self.limit;
+        let b = match self.buf() {
+            Some(b) => b,
+            None => return SUSPENSION_SHORT_WRITE,
+        };
+        let n = (size / 8) as u64;
+        while *scratch < n {
+            if b.wi >= limit {
+                return SUSPENSION_SHORT_WRITE;
+            }
+            let mut shift = 8 * *scratch;
+            if be {
+                shift = (size as u64) - 8 - shift;
+            }
+            unsafe { *b.data.get_unchecked_mut(b.wi) = (x >> shift) as u8 };
+            b.wi += 1;
+            *scratch += 1;
+        }
+        Status::Ok
+    }
+
+    /// copy_from_history32 copies up to length bytes, starting distance bytes
+    /// before the write position, to the write position. The source and
+    /// destination can overlap. It copies nothing if distance is zero or
+    /// reaches before the mark. It returns the number of bytes copied.
+    pub fn copy_from_history32(&mut self, distance: u32, length: u32) -> u32 {
+        let (mark, limit) = (self.mark, self.limit);
+        let b = match self.buf() {
+            Some(b) => b,
+            None => return 0,
+        };
+        if distance == 0 || b.wi - mark < (distance as usize) {
+            return 0;
+        }
+        let n = limit - b.wi;
+        let length = if (length as usize) > n {
+            n as u32
+        } else {
+            length
+        };
+        unsafe { self.copy_from_history32_fast(distance, length) }
+    }
+
+    /// copy_from_history32_fast is like copy_from_history32.
+    ///
+    /// # Safety
+    ///
+    /// The caller must have proven that distance is positive, that distance
+    /// does not reach before the mark and that there is room for length
+    /// bytes.
+    pub unsafe fn copy_from_history32_fast(&mut self, distance: u32, length: u32) -> u32 {
+        let b = &mut *self.buf;
+        let d = b.data.as_ptr();
+        let mut p = b.wi;
+        let mut q = p - (distance as usize);
+        for _ in 0..length {
+            *d.add(p) = *d.add(q);
+            p += 1;
+            q += 1;
+        }
+        b.wi = p;
+        length
+    }
+
+    /// copy_from_reader32 copies up to length bytes from r. It returns the
+    /// number of bytes copied.
+    pub fn copy_from_reader32(&mut self, r: &mut IoReader, length: u32) -> u32 {
+        let mut n = length as u64;
+        let wa = self.available();
+        if n > wa {
+            n = wa;
+        }
+        let ra = r.available();
+        if n > ra {
+            n = ra;
+        }
+        if n > 0 {
+            let n = n as usize;
+            let (w, r) = (self.buf().unwrap(), r.buf().unwrap());
+            unsafe { ptr::copy(r.data.as_ptr().add(r.ri), w.data.as_ptr().add(w.wi), n) };
+            w.wi += n;
+            r.ri += n;
+        }
+        n as u32
+    }
+
+    /// copy_from_slice copies as much of s as there is room for. It returns
+    /// the number of bytes copied.
+    pub fn copy_from_slice(&mut self, s: SliceU8) -> u64 {
+        let mut n = s.len() as u64;
+        let a = self.available();
+        if n > a {
+            n = a;
+        }
+        if n > 0 {
+            let w = self.buf().unwrap();
+            unsafe { ptr::copy(s.as_ptr(), w.data.as_ptr().add(w.wi), n as usize) };
+            w.wi += n as usize;
+        }
+        n
+    }
+
+    /// copy_from_slice32 copies up to length bytes of s. It returns the number
+    /// of bytes copied.
+    pub fn copy_from_slice32(&mut self, s: SliceU8, length: u32) -> u32 {
+        self.copy_from_slice(s.prefix(length as u64)) as u32
+    }
+
+    /// set makes the writer write to s, using b as the backing IoBuffer.
+    ///
+    /// # Safety
+    ///
+    /// The writer must not outlive b.
+    pub unsafe fn set(&mut self, b: &mut IoBuffer, s: SliceU8) {
+        *b = IoBuffer {
+            data: SliceU8::from_raw_parts(s.as_ptr(), s.len()),
+            wi: 0,
+            ri: 0,
+            closed: false,
+        };
+        *self = IoWriter {
+            buf: b as *mut IoBuffer as *mut IoBuffer<'static>,
+            mark: 0,
+            limit: s.len(),
+            bound: true,
+            _marker: PhantomData,
+        };
+    }
+
+    /// set_limit shrinks the bounds, if necessary, so that no more than l
+    /// bytes can be written.
+    pub fn set_limit(&mut self, l: u64) {
+        let limit = self.limit;
+        if let Some(b) = self.buf() {
+            if ((limit - b.wi) as u64) > l {
+                self.limit = b.wi + (l as usize);
+            }
+        }
+    }
+
+    /// set_mark sets the mark to the current write position.
+    pub fn set_mark(&mut self) {
+        if let Some(b) = self.buf() {
+            self.mark = b.wi;
+        }
+    }
+
+    /// since_mark returns the bytes written since the mark.
+    pub fn since_mark(&self) -> SliceU8<'a> {
+        match self.buf() {
+            Some(b) => unsafe { b.data.subslice_ij(self.mark, b.wi) },
+            None => SliceU8::default(),
+        }
+    }
+}
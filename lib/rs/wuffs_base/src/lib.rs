@@ -0,0 +1,40 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//! wuffs_base is the runtime support library for the Rust code generated by
+//! wuffs-rs. It is the Rust equivalent of the base-header.h and base-impl.h
+//! code that is copy/pasted into every generated C file.
+//!
+//! The Wuffs "base.foo" types map to the Rust types in this crate: io_reader
+//! and io_writer map to IoReader and IoWriter, slice u8 maps to SliceU8,
+//! status maps to Status, etc.
+//!
+//! Like the C code, slices, tables and I/O buffers are raw pointers plus
+//! lengths (with a lifetime parameter, so that they cannot outlive what they
+//! point to). The Wuffs compiler proves that the generated code's accesses
+//! are within bounds, so that those accesses can skip the run time checks.
+//! Such accesses are unsafe methods, which the generated code calls inside
+//! unsafe blocks.
+
+#![no_std]
+
+mod image;
+mod io;
+mod slice;
+mod status;
+
+pub use crate::image::*;
+pub use crate::io::*;
+pub use crate::slice::*;
+pub use crate::status::*;
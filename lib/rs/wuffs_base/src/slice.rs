@@ -0,0 +1,219 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use core::marker::PhantomData;
+use core::ptr::{self, NonNull};
+use core::slice;
+
+/// SliceU8 is a Wuffs "slice u8": a pointer and length, like a `&mut [u8]`
+/// but without Rust's exclusive borrow, so that it can be copied. It is the
+/// Rust equivalent of the C wuffs_base__slice_u8 type.
+#[derive(Clone, Copy, Debug)]
+pub struct SliceU8<'a> {
+    ptr: NonNull<u8>,
+    len: usize,
+    _marker: PhantomData<&'a mut [u8]>,
+}
+
+impl<'a> Default for SliceU8<'a> {
+    fn default() -> Self {
+        SliceU8 {
+            ptr: NonNull::dangling(),
+            len: 0,
+            _marker: PhantomData,
+        }
+    }
+}
+
+impl<'a> From<&'a mut [u8]> for SliceU8<'a> {
+    fn from(s: &'a mut [u8]) -> Self {
+        SliceU8 {
+            // A slice's pointer is never null, even if its length is zero.
+            ptr: NonNull::new(s.as_mut_ptr()).unwrap_or(NonNull::dangling()),
+            len: s.len(),
+            _marker: PhantomData,
+        }
+    }
+}
+
+impl<'a> SliceU8<'a> {
+    /// from_raw_parts returns a slice of the len bytes starting at ptr.
+    ///
+    /// # Safety
+    ///
+    /// Those bytes must be valid for reads and writes for the lifetime 'a.
+    pub unsafe fn from_raw_parts(ptr: *mut u8, len: usize) -> Self {
+        SliceU8 {
+            ptr: NonNull::new(ptr).unwrap_or(NonNull::dangling()),
+            len,
+            _marker: PhantomData,
+        }
+    }
+
+    /// from_array returns a slice of all of a's elements. The generated code
+    /// uses it for slicing arrays, such as "this.history[..]".
+    ///
+    /// # Safety
+    ///
+    /// The returned slice must not outlive a.
+    pub unsafe fn from_array<const N: usize>(a: &mut [u8; N]) -> Self {
+        SliceU8::from_raw_parts(a.as_mut_ptr(), N)
+    }
+
+    pub fn len(&self) -> usize {
+        self.len
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.len == 0
+    }
+
+    pub fn as_ptr(&self) -> *mut u8 {
+        self.ptr.as_ptr()
+    }
+
+    /// as_slice returns the slice's bytes as a Rust slice.
+    pub fn as_slice(&self) -> &[u8] {
+        unsafe { slice::from_raw_parts(self.ptr.as_ptr(), self.len) }
+    }
+
+    /// get_unchecked returns a reference to the i'th byte.
+    ///
+    /// # Safety
+    ///
+    /// i must be less than the slice's length.
+    pub unsafe fn get_unchecked(&self, i: usize) -> &u8 {
+        &*self.ptr.as_ptr().add(i)
+    }
+
+    /// get_unchecked_mut returns a mutable reference to the i'th byte. Like
+    /// the C code, a SliceU8 does not own its bytes, so this takes &self.
+    ///
+    /// # Safety
+    ///
+    /// i must be less than the slice's length, and the caller must not hold
+    /// any other reference to that byte.
+    #[allow(clippy::mut_from_ref)]
+    pub unsafe fn get_unchecked_mut(&self, i: usize) -> &mut u8 {
+        &mut *self.ptr.as_ptr().add(i)
+    }
+
+    /// subslice_ij returns the Wuffs "s[i .. j]".
+    ///
+    /// # Safety
+    ///
+    /// i must be at most j, which must be at most the slice's length.
+    pub unsafe fn subslice_ij(&self, i: usize, j: usize) -> SliceU8<'a> {
+        SliceU8::from_raw_parts(self.ptr.as_ptr().add(i), j - i)
+    }
+
+    /// subslice_i returns the Wuffs "s[i ..]".
+    ///
+    /// # Safety
+    ///
+    /// i must be at most the slice's length.
+    pub unsafe fn subslice_i(&self, i: usize) -> SliceU8<'a> {
+        SliceU8::from_raw_parts(self.ptr.as_ptr().add(i), self.len - i)
+    }
+
+    /// subslice_j returns the Wuffs "s[.. j]".
+    ///
+    /// # Safety
+    ///
+    /// j must be at most the slice's length.
+    pub unsafe fn subslice_j(&self, j: usize) -> SliceU8<'a> {
+        SliceU8::from_raw_parts(self.ptr.as_ptr(), j)
+    }
+
+    /// prefix returns up to the first up_to bytes of the slice.
+    pub fn prefix(&self, up_to: u64) -> SliceU8<'a> {
+        if (self.len as u64) > up_to {
+            return unsafe { self.subslice_j(up_to as usize) };
+        }
+        *self
+    }
+
+    /// suffix returns up to the last up_to bytes of the slice.
+    pub fn suffix(&self, up_to: u64) -> SliceU8<'a> {
+        if (self.len as u64) > up_to {
+            return unsafe { self.subslice_i(self.len - (up_to as usize)) };
+        }
+        *self
+    }
+
+    /// copy_from_slice copies as many bytes from src as will fit, returning
+    /// the number of bytes copied. The two slices may overlap.
+    pub fn copy_from_slice(&self, src: SliceU8) -> u64 {
+        let n = if self.len < src.len {
+            self.len
+        } else {
+            src.len
+        };
+        if n > 0 {
+            unsafe { ptr::copy(src.ptr.as_ptr(), self.ptr.as_ptr(), n) };
+        }
+        n as u64
+    }
+}
+
+/// TableU8 is a Wuffs "table u8": a 2-dimensional buffer of height rows, each
+/// row holding width bytes, with successive rows being stride bytes apart.
+#[derive(Clone, Copy, Debug)]
+pub struct TableU8<'a> {
+    ptr: NonNull<u8>,
+    pub width: usize,
+    pub height: usize,
+    pub stride: usize,
+    _marker: PhantomData<&'a mut [u8]>,
+}
+
+impl<'a> Default for TableU8<'a> {
+    fn default() -> Self {
+        TableU8 {
+            ptr: NonNull::dangling(),
+            width: 0,
+            height: 0,
+            stride: 0,
+            _marker: PhantomData,
+        }
+    }
+}
+
+impl<'a> TableU8<'a> {
+    /// from_slice returns a table backed by s, or an empty table if s is too
+    /// short.
+    pub fn from_slice(s: SliceU8<'a>, width: usize, height: usize, stride: usize) -> Self {
+        if width > stride || (height > 0 && (height - 1) * stride + width > s.len()) {
+            return TableU8::default();
+        }
+        TableU8 {
+            ptr: s.ptr,
+            width,
+            height,
+            stride,
+            _marker: PhantomData,
+        }
+    }
+
+    /// row returns the y'th row, or an empty slice if y is out of bounds.
+    pub fn row(&self, y: u32) -> SliceU8<'a> {
+        let y = y as usize;
+        if y < self.height {
+            return unsafe {
+                SliceU8::from_raw_parts(self.ptr.as_ptr().add(y * self.stride), self.width)
+            };
+        }
+        SliceU8::default()
+    }
+}
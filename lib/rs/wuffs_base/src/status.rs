@@ -0,0 +1,81 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use core::fmt;
+
+/// Status is the result of a Wuffs function call: ok, an error or a
+/// suspension.
+///
+/// A suspension is not an unrecoverable error. It means that a coroutine
+/// cannot make further progress until e.g. more input becomes available or
+/// more output space is made available, and that the coroutine can be resumed
+/// by calling the same method again.
+///
+/// Statuses are compared by their messages, which each Wuffs package prefixes
+/// with its package name, such as "gzip: bad checksum".
+#[derive(Clone, Copy, Debug, Default, PartialEq, Eq)]
+pub enum Status {
+    #[default]
+    Ok,
+    Error(&'static str),
+    Suspension(&'static str),
+}
+
+impl Status {
+    pub fn is_ok(self) -> bool {
+        self == Status::Ok
+    }
+
+    pub fn is_error(self) -> bool {
+        matches!(self, Status::Error(_))
+    }
+
+    pub fn is_suspension(self) -> bool {
+        matches!(self, Status::Suspension(_))
+    }
+
+    /// message returns the status' message, or "ok" for the ok status.
+    pub fn message(self) -> &'static str {
+        match self {
+            Status::Ok => "ok",
+            Status::Error(msg) | Status::Suspension(msg) => msg,
+        }
+    }
+}
+
+impl fmt::Display for Status {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        f.write_str(self.message())
+    }
+}
+
+// The built-in statuses. Their order matches that of the lang/builtin
+// package's StatusList.
+
+pub const ERROR_BAD_WUFFS_VERSION: Status = Status::Error("bad wuffs version");
+pub const ERROR_BAD_SIZEOF_RECEIVER: Status = Status::Error("bad sizeof receiver");
+pub const ERROR_BAD_RECEIVER: Status = Status::Error("bad receiver");
+pub const ERROR_BAD_ARGUMENT: Status = Status::Error("bad argument");
+pub const ERROR_CHECK_WUFFS_VERSION_NOT_CALLED: Status =
+    Status::Error("check_wuffs_version not called");
+pub const ERROR_CHECK_WUFFS_VERSION_CALLED_TWICE: Status =
+    Status::Error("check_wuffs_version called twice");
+pub const ERROR_INVALID_I_O_OPERATION: Status = Status::Error("invalid I/O operation");
+pub const ERROR_CLOSED_FOR_WRITES: Status = Status::Error("closed for writes");
+pub const ERROR_UNEXPECTED_EOF: Status = Status::Error("unexpected EOF");
+pub const SUSPENSION_SHORT_READ: Status = Status::Suspension("short read");
+pub const SUSPENSION_SHORT_WRITE: Status = Status::Suspension("short write");
+pub const ERROR_CANNOT_RETURN_A_SUSPENSION: Status = Status::Error("cannot return a suspension");
+pub const ERROR_INVALID_CALL_SEQUENCE: Status = Status::Error("invalid call sequence");
+pub const SUSPENSION_END_OF_DATA: Status = Status::Suspension("end of data");
@@ -20,6 +20,8 @@ go install github.com/google/wuffs/cmd/...
 go test    github.com/google/wuffs/...
 wuffs genlib
 wuffs test -skipgen -mimic
+wuffs test -skipgen -cover
+wuffs test -skipgen -debug_asserts
 
 for f in example/*; do
   echo Building $f
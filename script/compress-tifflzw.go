@@ -0,0 +1,127 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// compress-tifflzw.go applies TIFF's LZW-compression: codes are packed Most
+// Significant Bits first and the code width grows one code earlier than for
+// GIF. Unlike *.giflzw files, there is no initial literal width byte, as
+// TIFF's literal width is always 8.
+//
+// Go's compress/lzw package does not implement that "early change", so this
+// program has its own encoder, which follows libtiff's.
+//
+// Usage: go run compress-tifflzw.go < pi.txt > pi.txt.tifflzw
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func main1() error {
+	src, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(compress(src))
+	return err
+}
+
+const (
+	clearCode = 256
+	eoiCode   = 257
+	firstCode = 258
+	maxCode   = 4095
+)
+
+func compress(src []byte) []byte {
+	w := &bitWriter{width: 9}
+	w.write(clearCode)
+	if len(src) == 0 {
+		w.write(eoiCode)
+		return w.flush()
+	}
+
+	// table maps a (prefix code, suffix byte) pair to its code.
+	table := map[uint32]uint32{}
+	next := uint32(firstCode)
+	prefix := uint32(src[0])
+	for _, c := range src[1:] {
+		key := (prefix << 8) | uint32(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+		w.write(prefix)
+		table[key] = next
+		next++
+		if next == maxCode {
+			// The table is full. Start again.
+			w.write(clearCode)
+			w.width = 9
+			table = map[uint32]uint32{}
+			next = firstCode
+		} else if next > (1<<w.width)-1 {
+			w.width++
+		}
+		prefix = uint32(c)
+	}
+
+	// The decoder will add one more table entry after the final code, which
+	// can widen the EOI code.
+	w.write(prefix)
+	next++
+	if next == maxCode {
+		w.write(clearCode)
+		w.width = 9
+	} else if next > (1<<w.width)-1 {
+		w.width++
+	}
+	w.write(eoiCode)
+	return w.flush()
+}
+
+// bitWriter writes codes in Most Significant Bits first order.
+type bitWriter struct {
+	buf   []byte
+	bits  uint32
+	nBits uint32
+	width uint32
+}
+
+func (w *bitWriter) write(code uint32) {
+	w.bits = (w.bits << w.width) | code
+	w.nBits += w.width
+	for w.nBits >= 8 {
+		w.nBits -= 8
+		w.buf = append(w.buf, uint8(w.bits>>w.nBits))
+	}
+	w.bits &= (1 << w.nBits) - 1
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, uint8(w.bits<<(8-w.nBits)))
+	}
+	return w.buf
+}
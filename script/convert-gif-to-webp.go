@@ -0,0 +1,275 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// convert-gif-to-webp.go converts an animated GIF image to an animated WebP
+// image, with each GIF frame becoming an ANMF chunk holding a VP8L (lossless)
+// bitstream.
+//
+// The VP8L encoding is deliberately simple: there are no transforms, no color
+// cache and no backward references. Each channel is coded either with a
+// simple (1 or 2 symbol) Huffman code or with a flat code of 8 bits per
+// symbol. WebP frame offsets must be even, so a GIF frame at an odd offset is
+// extended up and left by transparent pixels.
+//
+// Usage: go run convert-gif-to-webp.go < foo.gif > foo.webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"sort"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func main1() error {
+	g, err := gif.DecodeAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(convert(g))
+	return err
+}
+
+func convert(g *gif.GIF) []byte {
+	// The VP8X flags: 0x10 means alpha and 0x02 means animation.
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x12
+	putU24(vp8x[4:], uint32(g.Config.Width-1))
+	putU24(vp8x[7:], uint32(g.Config.Height-1))
+
+	// The GIF loop count is the number of repetitions after the first play,
+	// with 0 meaning to loop forever. The WebP loop count is the total number
+	// of plays, also with 0 meaning to loop forever.
+	anim := make([]byte, 6)
+	if g.LoopCount > 0 {
+		binary.LittleEndian.PutUint16(anim[4:], uint16(g.LoopCount+1))
+	} else if g.LoopCount < 0 {
+		binary.LittleEndian.PutUint16(anim[4:], 1)
+	}
+
+	payload := []byte("WEBP")
+	payload = appendChunk(payload, "VP8X", vp8x)
+	payload = appendChunk(payload, "ANIM", anim)
+	for i, m := range g.Image {
+		payload = appendChunk(payload, "ANMF", encodeFrame(g, i, m))
+	}
+	return appendChunk(nil, "RIFF", payload)
+}
+
+func appendChunk(dst []byte, fourCC string, payload []byte) []byte {
+	dst = append(dst, fourCC...)
+	dst = append(dst, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(dst[len(dst)-4:], uint32(len(payload)))
+	dst = append(dst, payload...)
+	if len(payload)&1 != 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+func putU24(dst []byte, v uint32) {
+	dst[0] = uint8(v >> 0)
+	dst[1] = uint8(v >> 8)
+	dst[2] = uint8(v >> 16)
+}
+
+func encodeFrame(g *gif.GIF, i int, m *image.Paletted) []byte {
+	b := m.Bounds()
+	r := image.Rect(b.Min.X&^1, b.Min.Y&^1, b.Max.X, b.Max.Y)
+
+	// Every frame is alpha-blended, as GIF frames are. The disposal flag
+	// (bit 0) means to dispose to the background color.
+	flags := uint8(0)
+	if g.Disposal != nil && g.Disposal[i] == gif.DisposalBackground {
+		flags |= 0x01
+	}
+
+	anmf := make([]byte, 16)
+	putU24(anmf[0:], uint32(r.Min.X/2))
+	putU24(anmf[3:], uint32(r.Min.Y/2))
+	putU24(anmf[6:], uint32(r.Dx()-1))
+	putU24(anmf[9:], uint32(r.Dy()-1))
+	putU24(anmf[12:], uint32(10*g.Delay[i]))
+	anmf[15] = flags
+	return appendChunk(anmf, "VP8L", encodeVP8L(m, r))
+}
+
+// bitWriter writes bits in Least Significant Bits first order.
+type bitWriter struct {
+	buf   bytes.Buffer
+	bits  uint64
+	nBits uint32
+}
+
+func (w *bitWriter) write(v uint32, n uint32) {
+	w.bits |= uint64(v) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf.WriteByte(uint8(w.bits))
+		w.bits >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.nBits > 0 {
+		w.buf.WriteByte(uint8(w.bits))
+	}
+	return w.buf.Bytes()
+}
+
+// channelCode is a Huffman code for one of the green, red, blue or alpha
+// channels. If symbols is non-empty, it is a simple code for those (sorted)
+// symbols. Otherwise, every symbol's code is 8 bits long.
+type channelCode struct {
+	symbols []uint8
+}
+
+func newChannelCode(values []uint8) channelCode {
+	seen := [256]bool{}
+	symbols := []uint8(nil)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			symbols = append(symbols, v)
+			if len(symbols) > 2 {
+				return channelCode{}
+			}
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+	return channelCode{symbols: symbols}
+}
+
+func (c channelCode) writeCode(w *bitWriter, alphabetSize int) {
+	if len(c.symbols) > 0 {
+		// A simple code, with 8-bit symbols.
+		w.write(1, 1)
+		w.write(uint32(len(c.symbols)-1), 1)
+		w.write(1, 1)
+		for _, s := range c.symbols {
+			w.write(uint32(s), 8)
+		}
+		return
+	}
+
+	// A normal code. The code length code has two 1-bit codes, for code
+	// lengths 0 and 8. In the code length code order (17, 18, 0, 1, 2, 3,
+	// 4, 5, 16, 6, 7, 8, etc), code length 8 is the 12th.
+	w.write(0, 1)
+	w.write(12-4, 4)
+	for i := 0; i < 12; i++ {
+		if (i == 2) || (i == 11) {
+			w.write(1, 3)
+		} else {
+			w.write(0, 3)
+		}
+	}
+	// There is no max_symbol.
+	w.write(0, 1)
+	for s := 0; s < alphabetSize; s++ {
+		if s < 256 {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+}
+
+func (c channelCode) writeSymbol(w *bitWriter, v uint8) {
+	switch len(c.symbols) {
+	case 0:
+		// Huffman codes are written Most Significant Bits first.
+		for i := 7; i >= 0; i-- {
+			w.write(uint32(v>>uint(i))&1, 1)
+		}
+	case 1:
+		// No-op. A single symbol code is zero bits long.
+	case 2:
+		if v == c.symbols[1] {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+}
+
+func encodeVP8L(m *image.Paletted, r image.Rectangle) []byte {
+	n := r.Dx() * r.Dy()
+	channels := [4][]uint8{}
+	for i := range channels {
+		channels[i] = make([]uint8, 0, n)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.NRGBA{}
+			if (image.Point{x, y}).In(m.Bounds()) {
+				c = color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			}
+			channels[0] = append(channels[0], c.G)
+			channels[1] = append(channels[1], c.R)
+			channels[2] = append(channels[2], c.B)
+			channels[3] = append(channels[3], c.A)
+		}
+	}
+
+	w := &bitWriter{}
+	w.write(0x2F, 8)
+	w.write(uint32(r.Dx()-1), 14)
+	w.write(uint32(r.Dy()-1), 14)
+	// The alpha_is_used bit and a 3-bit version number.
+	w.write(1, 1)
+	w.write(0, 3)
+
+	// There are no transforms, no color cache and no meta prefix codes.
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	codes := [4]channelCode{}
+	for i := range codes {
+		codes[i] = newChannelCode(channels[i])
+		if i == 0 {
+			// The green alphabet also has 24 length prefix codes.
+			codes[i].writeCode(w, 256+24)
+		} else {
+			codes[i].writeCode(w, 256)
+		}
+	}
+	// The distance code is a simple code with one 1-bit symbol, unused.
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	for p := 0; p < n; p++ {
+		for i := range codes {
+			codes[i].writeSymbol(w, channels[i][p])
+		}
+	}
+	return w.flush()
+}
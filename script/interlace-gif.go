@@ -0,0 +1,196 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// interlace-gif.go converts a GIF image's frames to GIF's interlaced row
+// order. Everything other than the frames' pixel data, such as the palettes
+// and extensions, is copied unchanged.
+//
+// Usage: go run interlace-gif.go < foo.gif > foo.interlaced.gif
+
+import (
+	"bytes"
+	"compress/lzw"
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+const (
+	fColorTable         = 0x80
+	fColorTableBitsMask = 0x07
+	fInterlace          = 0x40
+
+	sExtension       = 0x21
+	sImageDescriptor = 0x2C
+	sTrailer         = 0x3B
+)
+
+var errNotAGIF = errors.New("not a GIF")
+
+func main1() error {
+	src, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	dst, err := interlace(src)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(dst)
+	return err
+}
+
+func interlace(src []byte) (dst []byte, err error) {
+	// Copy the header (6 bytes), screen descriptor (7 bytes) and any Global
+	// Color Table.
+	if len(src) < 6+7 {
+		return nil, errNotAGIF
+	}
+	switch string(src[:6]) {
+	case "GIF87a", "GIF89a":
+		// No-op.
+	default:
+		return nil, errNotAGIF
+	}
+	n := 13
+	if src[10]&fColorTable != 0 {
+		n += 3 * (1 << (1 + src[10]&fColorTableBitsMask))
+	}
+	if len(src) < n {
+		return nil, errNotAGIF
+	}
+	dst, src = append(dst, src[:n]...), src[n:]
+
+	for len(src) > 0 {
+		switch src[0] {
+		case sExtension:
+			if len(src) < 2 {
+				return nil, errNotAGIF
+			}
+			n, err := blocksLength(src[2:])
+			if err != nil {
+				return nil, err
+			}
+			dst, src = append(dst, src[:2+n]...), src[2+n:]
+
+		case sImageDescriptor:
+			if dst, src, err = interlaceFrame(dst, src); err != nil {
+				return nil, err
+			}
+
+		case sTrailer:
+			return append(dst, src...), nil
+
+		default:
+			return nil, errNotAGIF
+		}
+	}
+	return nil, errNotAGIF
+}
+
+// blocksLength returns the length of a sequence of data sub-blocks, including
+// the zero-length block terminator.
+func blocksLength(src []byte) (int, error) {
+	for n := 0; n < len(src); {
+		if src[n] == 0 {
+			return n + 1, nil
+		}
+		n += 1 + int(src[n])
+	}
+	return 0, errNotAGIF
+}
+
+func interlaceFrame(dst []byte, src []byte) ([]byte, []byte, error) {
+	// Copy the image descriptor (10 bytes), with the interlace bit set, and
+	// any Local Color Table.
+	if len(src) < 10 {
+		return nil, nil, errNotAGIF
+	}
+	if src[9]&fInterlace != 0 {
+		return nil, nil, errors.New("frame is already interlaced")
+	}
+	width := int(src[5]) | int(src[6])<<8
+	height := int(src[7]) | int(src[8])<<8
+	n := 10
+	if src[9]&fColorTable != 0 {
+		n += 3 * (1 << (1 + src[9]&fColorTableBitsMask))
+	}
+	if len(src) < n+1 {
+		return nil, nil, errNotAGIF
+	}
+	dst = append(dst, src[:n]...)
+	dst[len(dst)-n+9] |= fInterlace
+	src = src[n:]
+
+	// Decompress the pixel data.
+	litWidth := int(src[0])
+	n, err := blocksLength(src[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	compressed := []byte(nil)
+	for b := src[1 : 1+n]; b[0] != 0; b = b[1+int(b[0]):] {
+		compressed = append(compressed, b[1:1+int(b[0])]...)
+	}
+	src = src[1+n:]
+	r := lzw.NewReader(bytes.NewReader(compressed), lzw.LSB, litWidth)
+	pixels, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pixels) < width*height {
+		return nil, nil, errors.New("not enough pixel data")
+	}
+
+	// Re-order the rows and re-compress the pixel data.
+	interlaced := make([]byte, 0, width*height)
+	for _, pass := range []struct{ start, delta int }{{0, 8}, {4, 8}, {2, 4}, {1, 2}} {
+		for y := pass.start; y < height; y += pass.delta {
+			interlaced = append(interlaced, pixels[y*width:(y+1)*width]...)
+		}
+	}
+	buf := &bytes.Buffer{}
+	w := lzw.NewWriter(buf, lzw.LSB, litWidth)
+	if _, err := w.Write(interlaced); err != nil {
+		return nil, nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	dst = append(dst, uint8(litWidth))
+	for b := buf.Bytes(); len(b) > 0; {
+		m := len(b)
+		if m > 0xFF {
+			m = 0xFF
+		}
+		dst = append(dst, uint8(m))
+		dst = append(dst, b[:m]...)
+		b = b[m:]
+	}
+	dst = append(dst, 0x00)
+	return dst, src, nil
+}
@@ -497,6 +497,9 @@ var gifGlobals struct {
 	frameWidth  uint32
 	frameHeight uint32
 
+	// frameInterlaced applies only to the next frame.
+	frameInterlaced bool
+
 	globalPalette [][4]uint8
 }
 
@@ -616,7 +619,12 @@ func stateGifFrame(line string) (stateFunc, error) {
 		out = appendU16LE(out, uint16(g.frameTop))
 		out = appendU16LE(out, uint16(g.frameWidth))
 		out = appendU16LE(out, uint16(g.frameHeight))
-		out = append(out, 0x00) // TODO: flags.
+		flags := uint8(0x00) // TODO: local palettes.
+		if g.frameInterlaced {
+			flags |= 0x40
+		}
+		out = append(out, flags)
+		g.frameInterlaced = false
 		return stateGif, nil
 	}
 
@@ -624,6 +632,10 @@ func stateGifFrame(line string) (stateFunc, error) {
 		cmdFLTWH = "frameLeftTopWidthHeight "
 	)
 	switch {
+	case line == "interlaced":
+		g.frameInterlaced = true
+		return stateGifFrame, nil
+
 	case strings.HasPrefix(line, cmdFLTWH):
 		s := line[len(cmdFLTWH):]
 		if l, s, ok := parseNum(s); ok {
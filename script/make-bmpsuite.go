@@ -0,0 +1,670 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// make-bmpsuite.go generates a corpus of BMP, ICO and CUR images from a
+// source image. Each "foo.bmp", "foo.ico" or "foo.cur" file comes with a
+// "foo.png" file that holds the pixels that it should decode to.
+//
+// The BMP images cover the BITMAPINFOHEADER (v3), BITMAPV2INFOHEADER,
+// BITMAPV4HEADER and BITMAPV5HEADER versions, 1, 4, 8, 16, 24 and 32 bits
+// per pixel, RLE4 and RLE8 compression (with and without delta escapes),
+// bitfields (with channels narrower and wider than 8 bits) and top-down
+// images. The source image is cropped to an odd width, so that rows of pixel
+// data are padded. Bits outside of the bitfields' masks are filled with a
+// pattern, so that a decoder's treatment of those bits is also tested.
+//
+// The ICO and CUR images hold multiple BMP and PNG sub-images, of which a
+// decoder should pick the largest (and then the deepest).
+//
+// Usage: go run make-bmpsuite.go < hippopotamus.png
+//
+// The output files are written to the current directory.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/png"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+const (
+	compressionRGB            = 0
+	compressionRLE8           = 1
+	compressionRLE4           = 2
+	compressionBitfields      = 3
+	compressionAlphaBitfields = 6
+)
+
+// The masks are in RGBA order.
+var (
+	masks555  = [4]uint32{0x7C00, 0x03E0, 0x001F, 0x0000}
+	masks565  = [4]uint32{0xF800, 0x07E0, 0x001F, 0x0000}
+	masks4444 = [4]uint32{0x0F00, 0x00F0, 0x000F, 0xF000}
+	masks888  = [4]uint32{0x00FF0000, 0x0000FF00, 0x000000FF, 0x00000000}
+	masks8888 = [4]uint32{0x00FF0000, 0x0000FF00, 0x000000FF, 0xFF000000}
+	masksABGR = [4]uint32{0x000000FF, 0x0000FF00, 0x00FF0000, 0xFF000000}
+	masks1010 = [4]uint32{0x3FF00000, 0x000FFC00, 0x000003FF, 0x00000000}
+)
+
+var src image.Image
+
+func main1() error {
+	var err error
+	src, err = png.Decode(os.Stdin)
+	if err != nil {
+		return err
+	}
+	b := src.Bounds()
+	w, h := b.Dx()-1, b.Dy()
+	small := image.Rect(10, 6, 26, 22)
+
+	for _, c := range []config{
+		{name: "rgb24", headerSize: 40, bitsPerPixel: 24},
+		{name: "rgb24-topdown", headerSize: 40, bitsPerPixel: 24, topDown: true},
+		{name: "rgb24-gap", headerSize: 40, bitsPerPixel: 24, gap: 6},
+		{name: "rgb16", headerSize: 40, bitsPerPixel: 16, masks: masks555},
+		{name: "rgb32", headerSize: 40, bitsPerPixel: 32, masks: masks888},
+		{name: "bitfields16-565", headerSize: 40, bitsPerPixel: 16,
+			compression: compressionBitfields, masks: masks565},
+		{name: "bitfields16-565-v2", headerSize: 52, bitsPerPixel: 16,
+			compression: compressionBitfields, masks: masks565},
+		{name: "bitfields16-4444-v5", headerSize: 124, bitsPerPixel: 16,
+			compression: compressionBitfields, masks: masks4444},
+		{name: "bitfields32-1010", headerSize: 40, bitsPerPixel: 32,
+			compression: compressionBitfields, masks: masks1010},
+		{name: "bitfields32-v4", headerSize: 108, bitsPerPixel: 32,
+			compression: compressionBitfields, masks: masks8888},
+		{name: "bitfields32-v5-topdown", headerSize: 124, bitsPerPixel: 32,
+			compression: compressionBitfields, masks: masksABGR, topDown: true},
+		{name: "alphabitfields32", headerSize: 40, bitsPerPixel: 32,
+			compression: compressionAlphaBitfields, masks: masks8888},
+		{name: "pal1", headerSize: 40, bitsPerPixel: 1},
+		{name: "pal4", headerSize: 40, bitsPerPixel: 4},
+		{name: "pal8", headerSize: 40, bitsPerPixel: 8},
+		{name: "pal8-v5", headerSize: 124, bitsPerPixel: 8},
+		{name: "rle4", headerSize: 40, bitsPerPixel: 4, compression: compressionRLE4},
+		{name: "rle4-delta", headerSize: 40, bitsPerPixel: 4, compression: compressionRLE4, delta: true},
+		{name: "rle8", headerSize: 40, bitsPerPixel: 8, compression: compressionRLE8},
+		{name: "rle8-delta", headerSize: 40, bitsPerPixel: 8, compression: compressionRLE8, delta: true},
+	} {
+		c.bounds = image.Rect(0, 0, w, h)
+		e := newEncoder(c)
+		data := append(e.fileHeader(), e.encode()...)
+		binary.LittleEndian.PutUint32(data[2:], uint32(len(data)))
+		if err := writeFiles(c.name+".bmp", data, e.want); err != nil {
+			return err
+		}
+	}
+
+	// icon-bmp.ico's largest sub-images are two w × h images, of which the
+	// 24 bits per pixel one is deeper. Its AND mask makes the corners
+	// transparent.
+	best := newEncoder(config{headerSize: 40, bitsPerPixel: 24,
+		bounds: image.Rect(0, 0, w, h), ico: true})
+	if err := writeICO("icon-bmp.ico", 1, best, []icoEntry{
+		{encoder: newEncoder(config{headerSize: 40, bitsPerPixel: 32,
+			masks: masks8888, bounds: small, ico: true})},
+		{encoder: newEncoder(config{headerSize: 40, bitsPerPixel: 4,
+			bounds: image.Rect(0, 0, w, h), ico: true})},
+		{encoder: best},
+		{encoder: newEncoder(config{headerSize: 40, bitsPerPixel: 8,
+			bounds: small, ico: true})},
+	}); err != nil {
+		return err
+	}
+
+	// icon-32bpp.ico's sub-image has an alpha channel. Its AND mask is all
+	// ones, which should be ignored.
+	best = newEncoder(config{headerSize: 40, bitsPerPixel: 32,
+		masks: masks8888, bounds: image.Rect(0, 0, w, h), ico: true, allOnesMask: true})
+	if err := writeICO("icon-32bpp.ico", 1, best, []icoEntry{
+		{encoder: best},
+	}); err != nil {
+		return err
+	}
+
+	// icon-png.ico's largest sub-image is a PNG image.
+	pngBounds := image.Rect(0, 0, w, h)
+	pngData, pngWant, err := encodePNG(pngBounds)
+	if err != nil {
+		return err
+	}
+	if err := writeICO("icon-png.ico", 1, nil, []icoEntry{
+		{encoder: newEncoder(config{headerSize: 40, bitsPerPixel: 8,
+			bounds: small, ico: true})},
+		{bounds: pngBounds, data: pngData, want: pngWant},
+	}); err != nil {
+		return err
+	}
+
+	// cursor.cur's largest sub-image's hotspot is (17, 14).
+	best = newEncoder(config{headerSize: 40, bitsPerPixel: 8,
+		bounds: image.Rect(0, 0, w, h), ico: true})
+	return writeICO("cursor.cur", 2, best, []icoEntry{
+		{encoder: newEncoder(config{headerSize: 40, bitsPerPixel: 1,
+			bounds: small, ico: true}), hotspotX: 1, hotspotY: 2},
+		{encoder: best, hotspotX: 17, hotspotY: 14},
+	})
+}
+
+type config struct {
+	name         string
+	headerSize   int
+	bitsPerPixel int
+	compression  int
+	// masks are the channel masks, in RGBA order, for 16 and 32 bits per
+	// pixel. They are written to the header for the bitfields compression
+	// methods and otherwise implied.
+	masks   [4]uint32
+	topDown bool
+	// gap is the number of bytes between the palette and the pixel data.
+	gap int
+	// delta is whether RLE compressed pixel data uses delta and early end of
+	// row and end of image escapes, skipping over some pixels.
+	delta  bool
+	bounds image.Rectangle
+	// ico is whether to encode an ICO sub-image, with an AND mask.
+	ico         bool
+	allOnesMask bool
+}
+
+type encoder struct {
+	config
+	palette color.Palette
+	// want is the image that the encoded image should decode to.
+	want *image.NRGBA
+	// written is whether the (x, y) pixel was written, for RLE compressed
+	// pixel data.
+	written [][]bool
+}
+
+func newEncoder(c config) *encoder {
+	e := &encoder{config: c}
+	if c.bitsPerPixel <= 8 {
+		e.makePalette()
+	}
+	e.want = image.NewNRGBA(image.Rect(0, 0, c.bounds.Dx(), c.bounds.Dy()))
+	e.written = make([][]bool, c.bounds.Dy())
+	for y := range e.written {
+		e.written[y] = make([]bool, c.bounds.Dx())
+	}
+	return e
+}
+
+func (e *encoder) width() int  { return e.bounds.Dx() }
+func (e *encoder) height() int { return e.bounds.Dy() }
+
+func (e *encoder) makePalette() {
+	switch e.bitsPerPixel {
+	case 1:
+		e.palette = color.Palette{palette.Plan9[0], palette.Plan9[255]}
+	case 4:
+		e.palette = make(color.Palette, 16)
+		for i := range e.palette {
+			e.palette[i] = palette.Plan9[(i*255)/15]
+		}
+	default:
+		// WebSafe has fewer than 256 colors, so that the header's number of
+		// colors field is non-zero.
+		e.palette = palette.WebSafe
+	}
+}
+
+// fileHeader returns the 14 byte BITMAPFILEHEADER.
+func (e *encoder) fileHeader() []byte {
+	n := 14 + e.headerSize + 4*len(e.palette) + e.gap
+	if (e.headerSize == 40) && (e.compression == compressionBitfields) {
+		n += 12
+	} else if (e.headerSize == 40) && (e.compression == compressionAlphaBitfields) {
+		n += 16
+	}
+	b := make([]byte, 14)
+	b[0], b[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(b[10:], uint32(n))
+	return b
+}
+
+// encode returns the info header, masks, palette and pixel data (and, for ICO
+// sub-images, the AND mask).
+func (e *encoder) encode() []byte {
+	pixels := e.pixelData()
+	e.finishRLE()
+
+	b := make([]byte, 40)
+	binary.LittleEndian.PutUint32(b[0:], uint32(e.headerSize))
+	binary.LittleEndian.PutUint32(b[4:], uint32(e.width()))
+	height := int32(e.height())
+	if e.ico {
+		height *= 2
+	}
+	if e.topDown {
+		height = -height
+	}
+	binary.LittleEndian.PutUint32(b[8:], uint32(height))
+	binary.LittleEndian.PutUint16(b[12:], 1)
+	binary.LittleEndian.PutUint16(b[14:], uint16(e.bitsPerPixel))
+	binary.LittleEndian.PutUint32(b[16:], uint32(e.compression))
+	binary.LittleEndian.PutUint32(b[20:], uint32(len(pixels)))
+	binary.LittleEndian.PutUint32(b[24:], 2835)
+	binary.LittleEndian.PutUint32(b[28:], 2835)
+	if n := len(e.palette); n < (1 << uint(e.bitsPerPixel)) {
+		binary.LittleEndian.PutUint32(b[32:], uint32(n))
+	}
+
+	numMasks := 0
+	if e.headerSize >= 56 {
+		numMasks = 4
+	} else if e.headerSize >= 52 {
+		numMasks = 3
+	} else if e.compression == compressionBitfields {
+		numMasks = 3
+	} else if e.compression == compressionAlphaBitfields {
+		numMasks = 4
+	}
+	for i := 0; i < numMasks; i++ {
+		m := uint32(0)
+		if (e.compression == compressionBitfields) || (e.compression == compressionAlphaBitfields) {
+			m = e.masks[i]
+		}
+		b = append(b, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(b[len(b)-4:], m)
+	}
+	if e.headerSize >= 108 {
+		// The color space type is LCS_sRGB, so that the endpoints and gammas
+		// that follow are ignored.
+		b = append(b, "BGRs"...)
+		b = append(b, make([]byte, 48)...)
+	}
+	if e.headerSize >= 124 {
+		// The rendering intent is LCS_GM_IMAGES.
+		b = append(b, 4, 0, 0, 0)
+		b = append(b, make([]byte, 12)...)
+	}
+
+	for _, p := range e.palette {
+		r, g, bb, _ := p.RGBA()
+		b = append(b, uint8(bb>>8), uint8(g>>8), uint8(r>>8), 0)
+	}
+	b = append(b, make([]byte, e.gap)...)
+	b = append(b, pixels...)
+	if e.ico {
+		b = append(b, e.andMask()...)
+	}
+	return b
+}
+
+// fileRowToY converts a row index, counting in file order, to a y coordinate.
+func (e *encoder) fileRowToY(r int) int {
+	if e.topDown {
+		return r
+	}
+	return e.height() - 1 - r
+}
+
+func (e *encoder) pixelData() []byte {
+	if (e.compression == compressionRLE4) || (e.compression == compressionRLE8) {
+		return e.rleData()
+	}
+	b := []byte(nil)
+	for r := 0; r < e.height(); r++ {
+		y := e.fileRowToY(r)
+		row := []byte(nil)
+		acc, nAcc := uint32(0), 0
+		for x := 0; x < e.width(); x++ {
+			switch e.bitsPerPixel {
+			case 1, 4, 8:
+				acc = acc<<uint(e.bitsPerPixel) | uint32(e.index(x, y))
+				nAcc += e.bitsPerPixel
+				if nAcc == 8 {
+					row = append(row, uint8(acc))
+					acc, nAcc = 0, 0
+				}
+			case 24:
+				c := e.sourceColor(x, y)
+				row = append(row, c.B, c.G, c.R)
+				e.want.SetNRGBA(x, y, color.NRGBA{c.R, c.G, c.B, 0xFF})
+			case 16:
+				v := e.bitfields(x, y)
+				row = append(row, uint8(v), uint8(v>>8))
+			case 32:
+				v := e.bitfields(x, y)
+				row = append(row, uint8(v), uint8(v>>8), uint8(v>>16), uint8(v>>24))
+			}
+		}
+		if nAcc > 0 {
+			row = append(row, uint8(acc<<uint(8-nAcc)))
+		}
+		b = append(b, pad(row)...)
+	}
+	return b
+}
+
+// pad pads a row to a multiple of 4 bytes.
+func pad(row []byte) []byte {
+	for len(row)%4 != 0 {
+		row = append(row, 0)
+	}
+	return row
+}
+
+func (e *encoder) sourceColor(x int, y int) color.NRGBA {
+	return color.NRGBAModel.Convert(src.At(e.bounds.Min.X+x, e.bounds.Min.Y+y)).(color.NRGBA)
+}
+
+// index returns the palette index of the (x, y) pixel, and sets the
+// corresponding want pixel.
+func (e *encoder) index(x int, y int) uint8 {
+	i := e.palette.Index(e.sourceColor(x, y))
+	e.want.Set(x, y, e.palette[i])
+	return uint8(i)
+}
+
+// bitfields returns the (x, y) pixel as a 16 or 32 bit value, and sets the
+// corresponding want pixel.
+func (e *encoder) bitfields(x int, y int) uint32 {
+	c := e.sourceColor(x, y)
+
+	// The alpha channel is synthesized, as a diagonal gradient.
+	a := uint8(((x + y) * 0xFF) / (e.width() + e.height()))
+
+	// Bits outside of the masks, and the low bits of channels wider than 8
+	// bits, are filled with a pattern.
+	pattern := uint32(x*7+y*13) * 0x01010101
+
+	v, unmasked := uint32(0), ^uint32(0)
+	want := [4]uint8{}
+	for i, s := range [4]uint8{c.R, c.G, c.B, a} {
+		m := e.masks[i]
+		unmasked &^= m
+		if m == 0 {
+			want[i] = 0
+			if i == 3 {
+				want[i] = 0xFF
+			}
+			continue
+		}
+		shift, numBits := uint(0), uint(0)
+		for (m>>shift)&1 == 0 {
+			shift++
+		}
+		for (m>>(shift+numBits))&1 != 0 {
+			numBits++
+		}
+		if numBits >= 8 {
+			q := uint32(s)<<(numBits-8) | (pattern & ((1 << (numBits - 8)) - 1))
+			v |= q << shift
+			want[i] = s
+		} else {
+			q := uint32(s) >> (8 - numBits)
+			v |= q << shift
+			want[i] = replicate(q, numBits)
+		}
+	}
+	if e.bitsPerPixel == 16 {
+		unmasked &= 0xFFFF
+	}
+	v |= pattern & unmasked
+	e.want.SetNRGBA(x, y, color.NRGBA{want[0], want[1], want[2], want[3]})
+	return v
+}
+
+// replicate scales an n bit value up to 8 bits by replicating its bits.
+func replicate(q uint32, n uint) uint8 {
+	y, m := uint32(0), uint(0)
+	for m < 8 {
+		y = y<<n | q
+		m += n
+	}
+	return uint8(y >> (m - 8))
+}
+
+// rleData returns RLE4 or RLE8 compressed pixel data. With the delta option,
+// some rows are partially skipped by delta escapes, some rows end early and
+// the final rows are skipped by an early end of image escape.
+func (e *encoder) rleData() []byte {
+	b := []byte(nil)
+	w, h := e.width(), e.height()
+	for r := 0; r < h; r++ {
+		switch {
+		case e.delta && (8 <= r) && (r < 12):
+			b = e.rleRun(b, r, 0, 10)
+			b = append(b, 0, 2, 8, 0)
+			b = e.rleRun(b, r, 18, w)
+		case e.delta && (r == 14):
+			b = e.rleRun(b, r, 0, 20)
+		case e.delta && (r == 16):
+			b = e.rleRun(b, r, 0, 5)
+			b = append(b, 0, 2, 0, 2)
+			r = 18
+			b = e.rleRun(b, r, 5, w)
+		case e.delta && (r == h-2):
+			return append(b, 0, 1)
+		default:
+			b = e.rleRun(b, r, 0, w)
+		}
+		b = append(b, 0, 0)
+	}
+	return append(b, 0, 1)
+}
+
+// rleRun appends the x0 ≤ x < x1 pixels of the r'th row, counting in file
+// order, as RLE4 or RLE8 codes.
+func (e *encoder) rleRun(b []byte, r int, x0 int, x1 int) []byte {
+	y := e.fileRowToY(r)
+	indexes := []uint8(nil)
+	for x := x0; x < x1; x++ {
+		indexes = append(indexes, e.index(x, y))
+	}
+	for x := 0; x < x1; x++ {
+		e.written[y][x] = (x >= x0) || e.written[y][x]
+	}
+	rle4 := e.compression == compressionRLE4
+
+	// runLength returns how many indexes, starting at i, can be encoded as
+	// one run. For RLE4, a run can alternate between two indexes.
+	runLength := func(i int) int {
+		n := 1
+		for ; (i+n < len(indexes)) && (n < 255); n++ {
+			j := i
+			if rle4 {
+				j += n & 1
+			}
+			if indexes[i+n] != indexes[j] {
+				break
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < len(indexes); {
+		if n := runLength(i); (n >= 3) || (len(indexes)-i < 3) {
+			c := indexes[i]
+			if rle4 {
+				c <<= 4
+				if n > 1 {
+					c |= indexes[i+1]
+				}
+			}
+			b = append(b, uint8(n), c)
+			i += n
+			continue
+		}
+
+		// Emit literal indexes, up to the next run of 3 or more.
+		j := i + 1
+		for (j < len(indexes)) && (j-i < 254) && (runLength(j) < 3) {
+			j++
+		}
+		if j-i < 3 {
+			for ; i < j; i++ {
+				c := indexes[i]
+				if rle4 {
+					c <<= 4
+				}
+				b = append(b, 1, c)
+			}
+			continue
+		}
+		b = append(b, 0, uint8(j-i))
+		lit := []byte(nil)
+		for k := i; k < j; k++ {
+			if !rle4 {
+				lit = append(lit, indexes[k])
+			} else if (k-i)&1 == 0 {
+				lit = append(lit, indexes[k]<<4)
+			} else {
+				lit[len(lit)-1] |= indexes[k]
+			}
+		}
+		if len(lit)&1 != 0 {
+			lit = append(lit, 0)
+		}
+		b = append(b, lit...)
+		i = j
+	}
+	return b
+}
+
+// andMask returns an ICO sub-image's AND mask, 1 bit per pixel, and makes
+// the want pixels whose bit is set transparent black. The bits are set for
+// pixels outside of an ellipse, unless allOnesMask is set.
+func (e *encoder) andMask() []byte {
+	w, h := e.width(), e.height()
+	b := []byte(nil)
+	for r := 0; r < h; r++ {
+		y := e.fileRowToY(r)
+		row := make([]byte, (w+7)/8)
+		for x := 0; x < w; x++ {
+			dx := float64(2*x+1-w) / float64(w)
+			dy := float64(2*y+1-h) / float64(h)
+			if e.allOnesMask || (dx*dx+dy*dy > 1) {
+				row[x/8] |= 0x80 >> uint(x%8)
+				if e.bitsPerPixel < 32 {
+					e.want.SetNRGBA(x, y, color.NRGBA{})
+				}
+			}
+		}
+		b = append(b, pad(row)...)
+	}
+	return b
+}
+
+// finishRLE makes the want pixels that were skipped over by RLE escapes
+// transparent black.
+func (e *encoder) finishRLE() {
+	if (e.compression != compressionRLE4) && (e.compression != compressionRLE8) {
+		return
+	}
+	for y, row := range e.written {
+		for x, written := range row {
+			if !written {
+				e.want.SetNRGBA(x, y, color.NRGBA{})
+			}
+		}
+	}
+}
+
+func encodePNG(bounds image.Rectangle) (data []byte, want *image.NRGBA, err error) {
+	want = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			want.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, want); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), want, nil
+}
+
+// icoEntry is an ICO or CUR file's sub-image: either a BMP image's encoder or
+// a PNG image's data.
+type icoEntry struct {
+	encoder  *encoder
+	bounds   image.Rectangle
+	data     []byte
+	want     *image.NRGBA
+	hotspotX int
+	hotspotY int
+}
+
+// writeICO writes an ICO (fileType 1) or CUR (fileType 2) file. Its expected
+// pixels are those of the best sub-image, which is the PNG sub-image if best
+// is nil.
+func writeICO(filename string, fileType int, best *encoder, entries []icoEntry) error {
+	b := make([]byte, 6)
+	binary.LittleEndian.PutUint16(b[2:], uint16(fileType))
+	binary.LittleEndian.PutUint16(b[4:], uint16(len(entries)))
+
+	var want *image.NRGBA
+	offset := 6 + 16*len(entries)
+	data := []byte(nil)
+	for _, entry := range entries {
+		bitsPerPixel := 32
+		if e := entry.encoder; e != nil {
+			entry.bounds = e.bounds
+			entry.data = e.encode()
+			entry.want = e.want
+			bitsPerPixel = e.bitsPerPixel
+		}
+		if ((best == nil) && (entry.encoder == nil)) || ((best != nil) && (entry.encoder == best)) {
+			want = entry.want
+		}
+
+		d := make([]byte, 16)
+		d[0] = uint8(entry.bounds.Dx())
+		d[1] = uint8(entry.bounds.Dy())
+		if fileType == 1 {
+			binary.LittleEndian.PutUint16(d[4:], 1)
+			binary.LittleEndian.PutUint16(d[6:], uint16(bitsPerPixel))
+		} else {
+			binary.LittleEndian.PutUint16(d[4:], uint16(entry.hotspotX))
+			binary.LittleEndian.PutUint16(d[6:], uint16(entry.hotspotY))
+		}
+		binary.LittleEndian.PutUint32(d[8:], uint32(len(entry.data)))
+		binary.LittleEndian.PutUint32(d[12:], uint32(offset+len(data)))
+		b = append(b, d...)
+		data = append(data, entry.data...)
+	}
+	return writeFiles(filename, append(b, data...), want)
+}
+
+// writeFiles writes data to filename and the expected pixels to the
+// corresponding ".png" file.
+func writeFiles(filename string, data []byte, want *image.NRGBA) error {
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, want); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename[:len(filename)-4]+".png", buf.Bytes(), 0644)
+}
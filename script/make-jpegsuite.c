@@ -0,0 +1,216 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// make-jpegsuite.c generates a corpus of JPEG images from a source JPEG
+// image, using libjpeg (or libjpeg-turbo). The corpus covers baseline and
+// progressive Huffman coding, 4:4:4, 4:4:0, 4:2:2 and 4:2:0 chroma
+// subsampling, grayscale, restart markers, optimized (non-default) Huffman
+// tables and non-interleaved scans. There is also an arithmetic-coded image,
+// which Wuffs does not support.
+//
+// The source image is cropped by 3 pixels in each dimension, so that the
+// output images' dimensions are not a multiple of the MCU (Minimum Coded Unit)
+// size. "Tiled" variants repeat the cropped image 2×2 times, for a progressive
+// JPEG with more blocks than a small, fixed size coefficient buffer would hold.
+//
+// File names are "MODE-SUBSAMPLING[-VARIANT].jpeg". For example,
+// "progressive-420-restart.jpeg" is a progressive JPEG with 4:2:0 chroma
+// subsampling and restart markers.
+//
+// Usage: gcc make-jpegsuite.c -ljpeg && ./a.out < bricks-color.jpeg
+//
+// The output files are written to the current directory.
+
+#include <stdbool.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include <jpeglib.h>
+
+#define CROP 3
+
+// pixels holds the decoded (and cropped) source image, as RGB.
+static uint8_t* pixels = NULL;
+static JDIMENSION width = 0;
+static JDIMENSION height = 0;
+
+static bool read_source(FILE* f) {
+  struct jpeg_decompress_struct d;
+  struct jpeg_error_mgr e;
+  d.err = jpeg_std_error(&e);
+  jpeg_create_decompress(&d);
+  jpeg_stdio_src(&d, f);
+  if (jpeg_read_header(&d, TRUE) != JPEG_HEADER_OK) {
+    jpeg_destroy_decompress(&d);
+    return false;
+  }
+  d.out_color_space = JCS_RGB;
+  jpeg_start_decompress(&d);
+  if ((d.output_width <= CROP) || (d.output_height <= CROP)) {
+    jpeg_destroy_decompress(&d);
+    return false;
+  }
+  width = d.output_width - CROP;
+  height = d.output_height - CROP;
+  size_t src_stride = 3 * (size_t)d.output_width;
+  uint8_t* row = malloc(src_stride);
+  pixels = malloc(3 * (size_t)width * (size_t)height);
+  if (!row || !pixels) {
+    jpeg_destroy_decompress(&d);
+    return false;
+  }
+  while (d.output_scanline < d.output_height) {
+    JDIMENSION y = d.output_scanline;
+    jpeg_read_scanlines(&d, &row, 1);
+    if (y < height) {
+      memcpy(pixels + (3 * (size_t)width * y), row, 3 * (size_t)width);
+    }
+  }
+  jpeg_finish_decompress(&d);
+  jpeg_destroy_decompress(&d);
+  free(row);
+  return true;
+}
+
+typedef struct {
+  const char* filename;
+  bool gray;
+  // h and v are the luma component's sampling factors. The chroma
+  // components' sampling factors are always 1.
+  int h;
+  int v;
+  bool progressive;
+  bool arithmetic;
+  bool optimize;
+  bool noninterleaved;
+  unsigned int restart_interval;
+  // tiles is the number of times the source image is repeated in each
+  // dimension.
+  unsigned int tiles;
+} variant;
+
+static const variant variants[] = {
+    {"baseline-gray.jpeg", true, 1, 1, false, false, false, false, 0, 1},
+    {"baseline-444.jpeg", false, 1, 1, false, false, false, false, 0, 1},
+    {"baseline-440.jpeg", false, 1, 2, false, false, false, false, 0, 1},
+    {"baseline-422.jpeg", false, 2, 1, false, false, false, false, 0, 1},
+    {"baseline-420.jpeg", false, 2, 2, false, false, false, false, 0, 1},
+    {"baseline-420-noninterleaved.jpeg", false, 2, 2, false, false, false,
+     true, 0, 1},
+    {"baseline-420-optimized.jpeg", false, 2, 2, false, false, true, false, 0,
+     1},
+    {"baseline-420-restart.jpeg", false, 2, 2, false, false, false, false, 3,
+     1},
+    {"progressive-gray.jpeg", true, 1, 1, true, false, false, false, 0, 1},
+    {"progressive-444.jpeg", false, 1, 1, true, false, false, false, 0, 1},
+    {"progressive-440.jpeg", false, 1, 2, true, false, false, false, 0, 1},
+    {"progressive-422.jpeg", false, 2, 1, true, false, false, false, 0, 1},
+    {"progressive-420.jpeg", false, 2, 2, true, false, false, false, 0, 1},
+    {"progressive-420-restart.jpeg", false, 2, 2, true, false, false, false,
+     5, 1},
+    {"progressive-444-tiled.jpeg", false, 1, 1, true, false, false, false, 0,
+     2},
+    {"arithmetic-420.jpeg", false, 2, 2, false, true, false, false, 0, 1},
+};
+
+static bool write_variant(const variant* v) {
+  FILE* f = fopen(v->filename, "wb");
+  if (!f) {
+    return false;
+  }
+  struct jpeg_compress_struct c;
+  struct jpeg_error_mgr e;
+  c.err = jpeg_std_error(&e);
+  jpeg_create_compress(&c);
+  jpeg_stdio_dest(&c, f);
+  c.image_width = width * v->tiles;
+  c.image_height = height * v->tiles;
+  c.input_components = 3;
+  c.in_color_space = JCS_RGB;
+  jpeg_set_defaults(&c);
+  jpeg_set_quality(&c, 85, TRUE);
+  if (v->gray) {
+    jpeg_set_colorspace(&c, JCS_GRAYSCALE);
+  } else {
+    c.comp_info[0].h_samp_factor = v->h;
+    c.comp_info[0].v_samp_factor = v->v;
+    c.comp_info[1].h_samp_factor = 1;
+    c.comp_info[1].v_samp_factor = 1;
+    c.comp_info[2].h_samp_factor = 1;
+    c.comp_info[2].v_samp_factor = 1;
+  }
+  c.arith_code = v->arithmetic;
+  c.optimize_coding = v->optimize;
+  c.restart_interval = v->restart_interval;
+  if (v->progressive) {
+    jpeg_simple_progression(&c);
+  }
+
+  // A sequential JPEG with one scan per component.
+  jpeg_scan_info scans[3];
+  if (v->noninterleaved) {
+    int i;
+    for (i = 0; i < 3; i++) {
+      scans[i].comps_in_scan = 1;
+      scans[i].component_index[0] = i;
+      scans[i].Ss = 0;
+      scans[i].Se = 63;
+      scans[i].Ah = 0;
+      scans[i].Al = 0;
+    }
+    c.scan_info = scans;
+    c.num_scans = 3;
+  }
+
+  size_t src_stride = 3 * (size_t)width;
+  uint8_t* row = malloc(src_stride * v->tiles);
+  if (!row) {
+    jpeg_destroy_compress(&c);
+    fclose(f);
+    return false;
+  }
+  jpeg_start_compress(&c, TRUE);
+  while (c.next_scanline < c.image_height) {
+    const uint8_t* src = pixels + (src_stride * (c.next_scanline % height));
+    unsigned int t;
+    for (t = 0; t < v->tiles; t++) {
+      memcpy(row + (src_stride * t), src, src_stride);
+    }
+    JSAMPROW rows[1] = {row};
+    jpeg_write_scanlines(&c, rows, 1);
+  }
+  free(row);
+  jpeg_finish_compress(&c);
+  jpeg_destroy_compress(&c);
+  return fclose(f) == 0;
+}
+
+int main(int argc, char** argv) {
+  if (!read_source(stdin)) {
+    fprintf(stderr, "make-jpegsuite: could not read the source image\n");
+    return 1;
+  }
+  size_t i;
+  for (i = 0; i < sizeof(variants) / sizeof(variants[0]); i++) {
+    if (!write_variant(&variants[i])) {
+      fprintf(stderr, "make-jpegsuite: could not write %s\n",
+              variants[i].filename);
+      return 1;
+    }
+  }
+  free(pixels);
+  return 0;
+}
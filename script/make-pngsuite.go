@@ -0,0 +1,409 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// make-pngsuite.go generates a corpus of PNG images, in the style of
+// Willem van Schaik's PngSuite, from a source image. The corpus covers every
+// valid combination of color type and bit depth, with and without Adam7
+// interlacing, with and without tRNS transparency, and at small image sizes
+// (where some of the interlacing passes are empty). The rows cycle through
+// all five filter types, and the pixel data is spread over multiple IDAT
+// chunks.
+//
+// File names follow PngSuite's conventions. For example, "basi3p04.png" is a
+// basic (b), interlaced (i) image with color type 3 (palette) and a bit depth
+// of 4. Similarly, "trn" files have a tRNS chunk, "sNN" files are NN × NN
+// pixels and "xcs" files have a bad (corrupted) IDAT checksum.
+//
+// Usage: go run make-pngsuite.go < hippopotamus.png
+//
+// The output files are written to the current directory.
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/png"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+const (
+	ctGray      = 0
+	ctRGB       = 2
+	ctPalette   = 3
+	ctGrayAlpha = 4
+	ctRGBAlpha  = 6
+)
+
+// maxIDATLength is deliberately small, so that the zlib-compressed pixel data
+// is spread over multiple IDAT chunks.
+const maxIDATLength = 256
+
+var src image.Image
+
+func main1() error {
+	var err error
+	src, err = png.Decode(os.Stdin)
+	if err != nil {
+		return err
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	types := []struct {
+		colorType int
+		depths    []int
+	}{
+		{ctGray, []int{1, 2, 4, 8, 16}},
+		{ctRGB, []int{8, 16}},
+		{ctPalette, []int{1, 2, 4, 8}},
+		{ctGrayAlpha, []int{8, 16}},
+		{ctRGBAlpha, []int{8, 16}},
+	}
+	for _, interlaced := range []bool{false, true} {
+		for _, t := range types {
+			for _, depth := range t.depths {
+				c := config{w, h, t.colorType, depth, interlaced, false, false}
+				if err := write("bas", c); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, t := range []struct {
+			colorType int
+			depth     int
+		}{
+			{ctGray, 4},
+			{ctGray, 8},
+			{ctGray, 16},
+			{ctRGB, 8},
+			{ctRGB, 16},
+			{ctPalette, 4},
+			{ctPalette, 8},
+		} {
+			c := config{w, h, t.colorType, t.depth, interlaced, true, false}
+			if err := write("trn", c); err != nil {
+				return err
+			}
+		}
+
+		for _, size := range []int{1, 2, 3, 5, 7, 9} {
+			c := config{size, size, ctPalette, 4, interlaced, false, false}
+			if err := write(fmt.Sprintf("s%02d", size), c); err != nil {
+				return err
+			}
+		}
+	}
+	return write("xcs", config{w, h, ctRGB, 8, false, false, true})
+}
+
+type config struct {
+	width      int
+	height     int
+	colorType  int
+	depth      int
+	interlaced bool
+	trns       bool
+	badCRC     bool
+}
+
+func write(prefix string, c config) error {
+	il := byte('n')
+	if c.interlaced {
+		il = 'i'
+	}
+	filename := fmt.Sprintf("%s%c%d%c%02d.png",
+		prefix, il, c.colorType, "g?cpa?a"[c.colorType], c.depth)
+	data, err := encode(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func encode(c config) ([]byte, error) {
+	e := &encoder{config: c}
+	e.buf = append(e.buf, "\x89PNG\r\n\x1a\n"...)
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(c.width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(c.height))
+	ihdr[8] = uint8(c.depth)
+	ihdr[9] = uint8(c.colorType)
+	if c.interlaced {
+		ihdr[12] = 1
+	}
+	e.writeChunk("IHDR", ihdr)
+
+	if c.colorType == ctPalette {
+		e.makePalette()
+		plte := []byte(nil)
+		for _, p := range e.palette {
+			r, g, b, _ := p.RGBA()
+			plte = append(plte, uint8(r>>8), uint8(g>>8), uint8(b>>8))
+		}
+		e.writeChunk("PLTE", plte)
+	}
+
+	if c.trns {
+		e.writeChunk("tRNS", e.makeTRNS())
+	}
+
+	pixels, err := e.makePixelData()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; len(pixels) > 0; i++ {
+		n := len(pixels)
+		if n > maxIDATLength {
+			n = maxIDATLength
+		}
+		e.writeChunk("IDAT", pixels[:n])
+		if c.badCRC && (i == 0) {
+			e.buf[len(e.buf)-1] ^= 0x01
+		}
+		pixels = pixels[n:]
+	}
+
+	e.writeChunk("IEND", nil)
+	return e.buf, nil
+}
+
+type encoder struct {
+	config
+	buf     []byte
+	palette color.Palette
+}
+
+func (e *encoder) writeChunk(chunkType string, data []byte) {
+	n := len(e.buf)
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(e.buf[n:], uint32(len(data)))
+	e.buf = append(e.buf, chunkType...)
+	e.buf = append(e.buf, data...)
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(e.buf[len(e.buf)-4:], crc32.ChecksumIEEE(e.buf[n+4:len(e.buf)-4]))
+}
+
+func (e *encoder) makePalette() {
+	n := 1 << uint(e.depth)
+	if n >= len(palette.WebSafe) {
+		e.palette = palette.WebSafe
+		return
+	}
+	e.palette = make(color.Palette, n)
+	for i := range e.palette {
+		e.palette[i] = palette.Plan9[(i*255)/(n-1)]
+	}
+}
+
+// makeTRNS returns a tRNS chunk's payload. For gray and RGB images, the
+// transparent color is that of the center pixel.
+func (e *encoder) makeTRNS() []byte {
+	if e.colorType == ctPalette {
+		alphas := []byte(nil)
+		for i := 0; (i < len(e.palette)) && (i < 8); i++ {
+			alphas = append(alphas, uint8(i*0x20))
+		}
+		return alphas
+	}
+	samples := e.samples(e.width/2, e.height/2)
+	trns := []byte(nil)
+	for _, s := range samples {
+		trns = append(trns, uint8(s>>8), uint8(s))
+	}
+	return trns
+}
+
+// samples returns the (x, y) pixel's samples, in the PNG file's color type
+// and bit depth.
+func (e *encoder) samples(x int, y int) []uint16 {
+	b := src.Bounds()
+	c := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+
+	// The alpha channel is synthesized, as a diagonal gradient.
+	a := uint8(((x + y) * 0xFF) / (e.width + e.height))
+
+	// For 16 bit depths, the low byte is filled with a pattern, so that a
+	// decoder's treatment of the low byte is also tested.
+	wide := func(v uint8) uint16 {
+		return uint16(v)<<8 | uint16((x*7+y*13)&0xFF)
+	}
+
+	switch e.colorType {
+	case ctGray, ctGrayAlpha:
+		g := uint8((299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B) + 500) / 1000)
+		if e.depth == 16 {
+			if e.colorType == ctGrayAlpha {
+				return []uint16{wide(g), wide(a)}
+			}
+			return []uint16{wide(g)}
+		}
+		if e.colorType == ctGrayAlpha {
+			return []uint16{uint16(g), uint16(a)}
+		}
+		return []uint16{uint16(g >> uint(8-e.depth))}
+
+	case ctRGB, ctRGBAlpha:
+		s := []uint16{uint16(c.R), uint16(c.G), uint16(c.B)}
+		if e.colorType == ctRGBAlpha {
+			s = append(s, uint16(a))
+		}
+		if e.depth == 16 {
+			for i := range s {
+				s[i] = wide(uint8(s[i]))
+			}
+		}
+		return s
+
+	case ctPalette:
+		return []uint16{uint16(e.palette.Index(c))}
+	}
+	return nil
+}
+
+// Adam7 interlacing passes. The zero'th pass is for non-interlaced images.
+var passes = [8]struct {
+	x0, y0, dx, dy int
+}{
+	{0, 0, 1, 1},
+	{0, 0, 8, 8},
+	{4, 0, 8, 8},
+	{0, 4, 4, 8},
+	{2, 0, 4, 4},
+	{0, 2, 2, 4},
+	{1, 0, 2, 2},
+	{0, 1, 1, 2},
+}
+
+func (e *encoder) makePixelData() ([]byte, error) {
+	numSamples := len(e.samples(0, 0))
+	bitsPerPixel := numSamples * e.depth
+	filterDistance := (bitsPerPixel + 7) / 8
+
+	raw := []byte(nil)
+	for pass := 0; pass < 8; pass++ {
+		if (pass == 0) == e.interlaced {
+			continue
+		}
+		p := passes[pass]
+		if (e.width <= p.x0) || (e.height <= p.y0) {
+			continue
+		}
+		passWidth := (e.width - p.x0 + p.dx - 1) / p.dx
+		rowLength := (passWidth*bitsPerPixel + 7) / 8
+		prev := make([]byte, rowLength)
+
+		for row, y := 0, p.y0; y < e.height; row, y = row+1, y+p.dy {
+			curr := make([]byte, 0, rowLength)
+			acc, nAcc := uint32(0), 0
+			for x := p.x0; x < e.width; x += p.dx {
+				for _, s := range e.samples(x, y) {
+					switch e.depth {
+					case 16:
+						curr = append(curr, uint8(s>>8), uint8(s))
+					case 8:
+						curr = append(curr, uint8(s))
+					default:
+						acc = acc<<uint(e.depth) | uint32(s)
+						nAcc += e.depth
+						if nAcc == 8 {
+							curr = append(curr, uint8(acc))
+							acc, nAcc = 0, 0
+						}
+					}
+				}
+			}
+			if nAcc > 0 {
+				curr = append(curr, uint8(acc<<uint(8-nAcc)))
+			}
+
+			filter := row % 5
+			raw = append(raw, uint8(filter))
+			raw = append(raw, applyFilter(filter, filterDistance, curr, prev)...)
+			prev = curr
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := zlib.NewWriterLevel(buf, zlib.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func applyFilter(filter int, d int, curr []byte, prev []byte) []byte {
+	dst := make([]byte, len(curr))
+	for i := range curr {
+		a, b, c := 0, int(prev[i]), 0
+		if i >= d {
+			a, c = int(curr[i-d]), int(prev[i-d])
+		}
+		predictor := 0
+		switch filter {
+		case 1:
+			predictor = a
+		case 2:
+			predictor = b
+		case 3:
+			predictor = (a + b) / 2
+		case 4:
+			predictor = paeth(a, b, c)
+		}
+		dst[i] = curr[i] - uint8(predictor)
+	}
+	return dst
+}
+
+func paeth(a int, b int, c int) int {
+	p := a + b - c
+	pa, pb, pc := abs(p-a), abs(p-b), abs(p-c)
+	if (pa <= pb) && (pa <= pc) {
+		return a
+	} else if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,605 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// make-tiffsuite.go generates a corpus of TIFF images from a source image.
+// Each "foo.tiff" file comes with a "foo.png" file that holds the pixels that
+// it should decode to.
+//
+// The TIFF images cover little- and big-endian byte orders, strips and tiles,
+// no compression, PackBits, LZW and Deflate (both the Adobe and the older,
+// obsolete, compression numbers), the horizontal differencing predictor,
+// RGB, RGBA (with associated and unassociated alpha), gray (both
+// BlackIsZero and WhiteIsZero), gray with alpha and palette images, and bit
+// depths less than 8. Some images place their IFD (Image File Directory)
+// before the pixel data and some place it after, as libtiff does. The source
+// image is cropped to an odd width for the bit depths less than 8, so that
+// rows of pixel data are padded.
+//
+// Usage: go run make-tiffsuite.go < hippopotamus.png
+//
+// The output files are written to the current directory.
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+const (
+	photometricWhiteIsZero = 0
+	photometricBlackIsZero = 1
+	photometricRGB         = 2
+	photometricPalette     = 3
+
+	compressionNone       = 1
+	compressionLZW        = 5
+	compressionDeflate    = 8
+	compressionPackBits   = 32773
+	compressionOldDeflate = 32946
+
+	extraSamplesNone         = 0
+	extraSamplesAssociated   = 1
+	extraSamplesUnassociated = 2
+
+	typeShort = 3
+	typeLong  = 4
+)
+
+var src image.Image
+
+type config struct {
+	name          string
+	bigEndian     bool
+	photometric   int
+	bitsPerSample int
+	extraSamples  int
+	compression   int
+	predictor     int
+	// rowsPerStrip is the number of rows per strip. Zero means one strip for
+	// the whole image and no RowsPerStrip tag.
+	rowsPerStrip int
+	// tileSize, if non-zero, means to use square tiles instead of strips.
+	tileSize int
+	// ifdFirst means to place the IFD before the pixel data.
+	ifdFirst bool
+	// shortByteCounts means to use SHORT instead of LONG byte counts.
+	shortByteCounts bool
+}
+
+func main1() error {
+	var err error
+	src, err = png.Decode(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []config{
+		{name: "rgb-none", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionNone, rowsPerStrip: 8},
+		{name: "rgb-none-be", bigEndian: true, photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionNone, rowsPerStrip: 8, shortByteCounts: true},
+		{name: "rgb-packbits", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionPackBits, rowsPerStrip: 5, ifdFirst: true},
+		{name: "rgb-lzw", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionLZW, rowsPerStrip: 8},
+		{name: "rgb-lzw-predictor", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionLZW, predictor: 2, rowsPerStrip: 8},
+		{name: "rgb-lzw-predictor-be", bigEndian: true, photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionLZW, predictor: 2, rowsPerStrip: 8, ifdFirst: true},
+		{name: "rgb-deflate", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionDeflate, predictor: 2, ifdFirst: true},
+		{name: "rgb-old-deflate", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionOldDeflate, rowsPerStrip: 16},
+		{name: "rgb-tiles-lzw", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionLZW, predictor: 2, tileSize: 16},
+		{name: "rgb-tiles-deflate-be", bigEndian: true, photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionDeflate, tileSize: 16, ifdFirst: true},
+		{name: "rgb-tiles-packbits", photometric: photometricRGB, bitsPerSample: 8,
+			compression: compressionPackBits, tileSize: 16, shortByteCounts: true},
+		{name: "rgba", photometric: photometricRGB, bitsPerSample: 8, extraSamples: extraSamplesUnassociated,
+			compression: compressionLZW, predictor: 2, rowsPerStrip: 8},
+		{name: "rgba-associated", photometric: photometricRGB, bitsPerSample: 8, extraSamples: extraSamplesAssociated,
+			compression: compressionDeflate, rowsPerStrip: 8},
+		{name: "gray", photometric: photometricBlackIsZero, bitsPerSample: 8,
+			compression: compressionDeflate, predictor: 2},
+		{name: "gray-white-is-zero", photometric: photometricWhiteIsZero, bitsPerSample: 8,
+			compression: compressionPackBits, rowsPerStrip: 10},
+		{name: "gray-alpha", photometric: photometricBlackIsZero, bitsPerSample: 8, extraSamples: extraSamplesUnassociated,
+			compression: compressionLZW, rowsPerStrip: 8, ifdFirst: true},
+		{name: "gray1-white-is-zero", photometric: photometricWhiteIsZero, bitsPerSample: 1,
+			compression: compressionPackBits, rowsPerStrip: 8},
+		{name: "gray2-be", bigEndian: true, photometric: photometricBlackIsZero, bitsPerSample: 2,
+			compression: compressionNone, rowsPerStrip: 8},
+		{name: "gray4", photometric: photometricBlackIsZero, bitsPerSample: 4,
+			compression: compressionLZW, rowsPerStrip: 8},
+		{name: "palette4", photometric: photometricPalette, bitsPerSample: 4,
+			compression: compressionNone, rowsPerStrip: 8},
+		{name: "palette8", photometric: photometricPalette, bitsPerSample: 8,
+			compression: compressionLZW, rowsPerStrip: 8},
+		{name: "palette8-be", bigEndian: true, photometric: photometricPalette, bitsPerSample: 8,
+			compression: compressionDeflate, tileSize: 16},
+	} {
+		e := newEncoder(c)
+		if err := writeFiles(c.name+".tiff", e.encode(), e.want); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFiles(filename string, data []byte, want *image.NRGBA) error {
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, want); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename[:len(filename)-5]+".png", buf.Bytes(), 0644)
+}
+
+type encoder struct {
+	config
+	order         binary.ByteOrder
+	width, height int
+	palette       color.Palette
+	want          *image.NRGBA
+}
+
+func newEncoder(c config) *encoder {
+	e := &encoder{config: c, order: binary.LittleEndian}
+	if c.bigEndian {
+		e.order = binary.BigEndian
+	}
+	b := src.Bounds()
+	e.width, e.height = b.Dx(), b.Dy()
+	if c.bitsPerSample < 8 {
+		e.width--
+	}
+	switch c.photometric {
+	case photometricPalette:
+		e.palette = make(color.Palette, 1<<uint(c.bitsPerSample))
+		for i := range e.palette {
+			e.palette[i] = color.Black
+		}
+		if c.bitsPerSample == 8 {
+			copy(e.palette, palette.WebSafe)
+		} else {
+			for i := range e.palette {
+				e.palette[i] = palette.Plan9[(i*255)/(len(e.palette)-1)]
+			}
+		}
+	}
+	e.want = image.NewNRGBA(image.Rect(0, 0, e.width, e.height))
+	return e
+}
+
+func (e *encoder) samplesPerPixel() int {
+	n := 1
+	if e.photometric == photometricRGB {
+		n = 3
+	}
+	if e.extraSamples != extraSamplesNone {
+		n++
+	}
+	return n
+}
+
+// sourceColor returns the source image's color at (x, y). If there is an
+// alpha channel, the alpha values form a horizontal gradient, from fully
+// transparent to fully opaque.
+func (e *encoder) sourceColor(x int, y int) color.NRGBA {
+	b := src.Bounds()
+	c := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+	c.A = 0xFF
+	if e.extraSamples != extraSamplesNone {
+		c.A = uint8((x * 0xFF) / (e.width - 1))
+	}
+	return c
+}
+
+// pixel returns the samples for the pixel at (x, y), and records the color
+// that it should decode to in e.want.
+func (e *encoder) pixel(x int, y int) []uint8 {
+	c := e.sourceColor(x, y)
+	switch e.photometric {
+	case photometricPalette:
+		i := e.palette.Index(c)
+		e.want.Set(x, y, e.palette[i])
+		return []uint8{uint8(i)}
+
+	case photometricRGB:
+		if e.extraSamples == extraSamplesNone {
+			e.want.SetNRGBA(x, y, c)
+			return []uint8{c.R, c.G, c.B}
+		} else if e.extraSamples == extraSamplesUnassociated {
+			e.want.SetNRGBA(x, y, c)
+			return []uint8{c.R, c.G, c.B, c.A}
+		}
+		r, g, b := premul(c.R, c.A), premul(c.G, c.A), premul(c.B, c.A)
+		e.want.SetNRGBA(x, y, color.NRGBA{
+			unpremul(r, c.A), unpremul(g, c.A), unpremul(b, c.A), c.A,
+		})
+		return []uint8{r, g, b, c.A}
+	}
+
+	// The remaining photometric interpretations are gray.
+	max := uint8((1 << uint(e.bitsPerSample)) - 1)
+	v := color.GrayModel.Convert(c).(color.Gray).Y >> uint(8-e.bitsPerSample)
+	gray := uint8((uint32(v) * 0xFF) / uint32(max))
+	e.want.SetNRGBA(x, y, color.NRGBA{gray, gray, gray, c.A})
+	if e.photometric == photometricWhiteIsZero {
+		v = max - v
+	}
+	if e.extraSamples != extraSamplesNone {
+		return []uint8{v, c.A}
+	}
+	return []uint8{v}
+}
+
+func premul(v uint8, a uint8) uint8 {
+	return uint8(((uint32(v) * uint32(a)) + 127) / 255)
+}
+
+// unpremul matches how the Wuffs decoder converts from associated alpha.
+func unpremul(v uint8, a uint8) uint8 {
+	if a == 0 {
+		return 0
+	}
+	x := ((uint32(v) * 255) + (uint32(a) / 2)) / uint32(a)
+	if x > 255 {
+		x = 255
+	}
+	return uint8(x)
+}
+
+// row returns the packed samples for a w pixel wide row at (x0, y). Pixels
+// outside of the image are zero.
+func (e *encoder) row(x0 int, y int, w int) []byte {
+	spp := e.samplesPerPixel()
+	bps := e.bitsPerSample
+	dst := make([]byte, ((w*spp*bps)+7)/8)
+	bitPos := 0
+	for x := x0; x < x0+w; x++ {
+		var samples []uint8
+		if (x < e.width) && (y < e.height) {
+			samples = e.pixel(x, y)
+		} else {
+			samples = make([]uint8, spp)
+		}
+		for _, s := range samples {
+			dst[bitPos/8] |= s << uint(8-bps-(bitPos%8))
+			bitPos += bps
+		}
+	}
+	if e.predictor == 2 {
+		for i := len(dst) - 1; i >= spp; i-- {
+			dst[i] -= dst[i-spp]
+		}
+	}
+	return dst
+}
+
+// chunks returns the strips or tiles, uncompressed, as rows.
+func (e *encoder) chunks() (ret [][][]byte) {
+	if e.tileSize > 0 {
+		for ty := 0; ty < e.height; ty += e.tileSize {
+			for tx := 0; tx < e.width; tx += e.tileSize {
+				rows := [][]byte(nil)
+				for y := ty; y < ty+e.tileSize; y++ {
+					rows = append(rows, e.row(tx, y, e.tileSize))
+				}
+				ret = append(ret, rows)
+			}
+		}
+		return ret
+	}
+
+	rps := e.rowsPerStrip
+	if rps == 0 {
+		rps = e.height
+	}
+	for sy := 0; sy < e.height; sy += rps {
+		rows := [][]byte(nil)
+		for y := sy; (y < sy+rps) && (y < e.height); y++ {
+			rows = append(rows, e.row(0, y, e.width))
+		}
+		ret = append(ret, rows)
+	}
+	return ret
+}
+
+func (e *encoder) compress(rows [][]byte) []byte {
+	switch e.compression {
+	case compressionPackBits:
+		// PackBits runs do not cross rows.
+		buf := []byte(nil)
+		for _, r := range rows {
+			buf = append(buf, packBits(r)...)
+		}
+		return buf
+	case compressionLZW:
+		return compressLZW(bytes.Join(rows, nil))
+	case compressionDeflate, compressionOldDeflate:
+		buf := &bytes.Buffer{}
+		w := zlib.NewWriter(buf)
+		w.Write(bytes.Join(rows, nil))
+		w.Close()
+		return buf.Bytes()
+	}
+	return bytes.Join(rows, nil)
+}
+
+type entry struct {
+	tag    uint16
+	typ    uint16
+	values []uint32
+}
+
+func (x *entry) size() int {
+	if x.typ == typeShort {
+		return 2 * len(x.values)
+	}
+	return 4 * len(x.values)
+}
+
+func (e *encoder) encode() []byte {
+	chunks := [][]byte(nil)
+	for _, c := range e.chunks() {
+		chunks = append(chunks, e.compress(c))
+	}
+	offsets := make([]uint32, len(chunks))
+	byteCounts := make([]uint32, len(chunks))
+	for i, c := range chunks {
+		byteCounts[i] = uint32(len(c))
+	}
+
+	spp := e.samplesPerPixel()
+	bitsPerSample := make([]uint32, spp)
+	for i := range bitsPerSample {
+		bitsPerSample[i] = uint32(e.bitsPerSample)
+	}
+	byteCountsType := uint16(typeLong)
+	if e.shortByteCounts {
+		byteCountsType = typeShort
+	}
+
+	entries := []*entry{
+		{256, typeLong, []uint32{uint32(e.width)}},
+		{257, typeShort, []uint32{uint32(e.height)}},
+		{258, typeShort, bitsPerSample},
+		{259, typeShort, []uint32{uint32(e.compression)}},
+		{262, typeShort, []uint32{uint32(e.photometric)}},
+		{277, typeShort, []uint32{uint32(spp)}},
+		{284, typeShort, []uint32{1}},
+	}
+	if e.tileSize > 0 {
+		entries = append(entries,
+			&entry{322, typeShort, []uint32{uint32(e.tileSize)}},
+			&entry{323, typeLong, []uint32{uint32(e.tileSize)}},
+			&entry{324, typeLong, offsets},
+			&entry{325, byteCountsType, byteCounts},
+		)
+	} else {
+		entries = append(entries,
+			&entry{273, typeLong, offsets},
+			&entry{279, byteCountsType, byteCounts},
+		)
+		if e.rowsPerStrip > 0 {
+			entries = append(entries, &entry{278, typeShort, []uint32{uint32(e.rowsPerStrip)}})
+		}
+	}
+	if e.predictor != 0 {
+		entries = append(entries, &entry{317, typeShort, []uint32{uint32(e.predictor)}})
+	}
+	if e.palette != nil {
+		colorMap := make([]uint32, 3*len(e.palette))
+		for i, c := range e.palette {
+			r, g, b, _ := c.RGBA()
+			colorMap[(0*len(e.palette))+i] = r
+			colorMap[(1*len(e.palette))+i] = g
+			colorMap[(2*len(e.palette))+i] = b
+		}
+		entries = append(entries, &entry{320, typeShort, colorMap})
+	}
+	if e.extraSamples != extraSamplesNone {
+		entries = append(entries, &entry{338, typeShort, []uint32{uint32(e.extraSamples)}})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+
+	// Lay out the file: the header, then either the IFD, the out-of-line
+	// values and the pixel data, or the pixel data, the out-of-line values
+	// and the IFD. Everything starts at an even offset.
+	ifdSize := 2 + (12 * len(entries)) + 4
+	valuesSize := 0
+	for _, x := range entries {
+		if n := x.size(); n > 4 {
+			valuesSize += (n + 1) &^ 1
+		}
+	}
+	chunksSize := 0
+	for _, c := range chunks {
+		chunksSize += (len(c) + 1) &^ 1
+	}
+	ifdOffset, valuesOffset, chunksOffset := 0, 0, 0
+	if e.ifdFirst {
+		ifdOffset = 8
+		valuesOffset = ifdOffset + ifdSize
+		chunksOffset = valuesOffset + valuesSize
+	} else {
+		chunksOffset = 8
+		valuesOffset = chunksOffset + chunksSize
+		ifdOffset = valuesOffset + valuesSize
+	}
+
+	buf := make([]byte, 8+ifdSize+valuesSize+chunksSize)
+	if e.bigEndian {
+		copy(buf, "MM\x00\x2A")
+	} else {
+		copy(buf, "II\x2A\x00")
+	}
+	e.order.PutUint32(buf[4:], uint32(ifdOffset))
+
+	o := chunksOffset
+	for i, c := range chunks {
+		offsets[i] = uint32(o)
+		copy(buf[o:], c)
+		o += (len(c) + 1) &^ 1
+	}
+
+	e.order.PutUint16(buf[ifdOffset:], uint16(len(entries)))
+	p := buf[ifdOffset+2:]
+	o = valuesOffset
+	for _, x := range entries {
+		e.order.PutUint16(p[0:], x.tag)
+		e.order.PutUint16(p[2:], x.typ)
+		e.order.PutUint32(p[4:], uint32(len(x.values)))
+		v := p[8:12]
+		if n := x.size(); n > 4 {
+			e.order.PutUint32(v, uint32(o))
+			v = buf[o : o+n]
+			o += (n + 1) &^ 1
+		}
+		for i, value := range x.values {
+			if x.typ == typeShort {
+				e.order.PutUint16(v[2*i:], uint16(value))
+			} else {
+				e.order.PutUint32(v[4*i:], value)
+			}
+		}
+		p = p[12:]
+	}
+	// The next IFD offset, zero, is already in place.
+	return buf
+}
+
+func packBits(src []byte) (dst []byte) {
+	for len(src) > 0 {
+		// Look for a run of at least 2 identical bytes.
+		n := 1
+		for (n < len(src)) && (n < 128) && (src[n] == src[0]) {
+			n++
+		}
+		if n >= 2 {
+			dst = append(dst, uint8(1-n), src[0])
+			src = src[n:]
+			continue
+		}
+
+		// Otherwise, emit literals up until the next run.
+		n = 1
+		for (n < len(src)) && (n < 128) &&
+			((n+1 >= len(src)) || (src[n] != src[n+1])) {
+			n++
+		}
+		dst = append(dst, uint8(n-1))
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
+
+// compressLZW is the same as script/compress-tifflzw.go's compress function.
+func compressLZW(src []byte) []byte {
+	const (
+		clearCode = 256
+		eoiCode   = 257
+		firstCode = 258
+		maxCode   = 4095
+	)
+
+	w := &bitWriter{width: 9}
+	w.write(clearCode)
+	if len(src) == 0 {
+		w.write(eoiCode)
+		return w.flush()
+	}
+
+	table := map[uint32]uint32{}
+	next := uint32(firstCode)
+	prefix := uint32(src[0])
+	for _, c := range src[1:] {
+		key := (prefix << 8) | uint32(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+		w.write(prefix)
+		table[key] = next
+		next++
+		if next == maxCode {
+			w.write(clearCode)
+			w.width = 9
+			table = map[uint32]uint32{}
+			next = firstCode
+		} else if next > (1<<w.width)-1 {
+			w.width++
+		}
+		prefix = uint32(c)
+	}
+
+	w.write(prefix)
+	next++
+	if next == maxCode {
+		w.write(clearCode)
+		w.width = 9
+	} else if next > (1<<w.width)-1 {
+		w.width++
+	}
+	w.write(eoiCode)
+	return w.flush()
+}
+
+// bitWriter writes codes in Most Significant Bits first order.
+type bitWriter struct {
+	buf   []byte
+	bits  uint32
+	nBits uint32
+	width uint32
+}
+
+func (w *bitWriter) write(code uint32) {
+	w.bits = (w.bits << w.width) | code
+	w.nBits += w.width
+	for w.nBits >= 8 {
+		w.nBits -= 8
+		w.buf = append(w.buf, uint8(w.bits>>w.nBits))
+	}
+	w.bits &= (1 << w.nBits) - 1
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, uint8(w.bits<<(8-w.nBits)))
+	}
+	return w.buf
+}
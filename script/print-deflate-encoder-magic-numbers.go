@@ -0,0 +1,106 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// print-deflate-encoder-magic-numbers.go prints the std/deflate length_codes
+// and distance_codes values based on the tables in RFC 1951 secion 3.2.5.
+// They are the inverses of the lcode_magic_numbers and dcode_magic_numbers
+// tables printed by print-deflate-magic-numbers.go.
+//
+// length_codes maps a length minus 3, in the range [0, 255], to its lcode
+// minus 257, in the range [0, 28].
+//
+// distance_codes maps a distance minus 1, d, to its dcode, in the range [0,
+// 29]. For d < 256, the index is d. Otherwise, the index is 256 + (d >> 7).
+// This is the same layout as zlib's _dist_code table.
+//
+// Usage: go run print-deflate-encoder-magic-numbers.go
+
+import (
+	"fmt"
+)
+
+func main() {
+	lengthCodes := [256]uint8{}
+	for code := 0; code < 29; code++ {
+		for i := 0; i < 1<<lengthExtraBits[code]; i++ {
+			if n := lengthBases[code] + i - 3; n < 256 {
+				lengthCodes[n] = uint8(code)
+			}
+		}
+	}
+	// Length 258 could also be coded as 227 + 31 (lcode 284), but RFC 1951
+	// gives it its own lcode, 285.
+	lengthCodes[255] = 28
+	printTable(lengthCodes[:])
+
+	fmt.Println()
+
+	distanceCodes := [512]uint8{}
+	for code := 0; code < 30; code++ {
+		for i := 0; i < 1<<distanceExtraBits[code]; i++ {
+			d := distanceBases[code] + i - 1
+			if d < 256 {
+				distanceCodes[d] = uint8(code)
+			} else {
+				distanceCodes[256+(d>>7)] = uint8(code)
+			}
+		}
+	}
+	printTable(distanceCodes[:])
+}
+
+func printTable(b []uint8) {
+	for i, x := range b {
+		fmt.Printf("%d,", x)
+		if i&15 == 15 {
+			fmt.Println()
+		} else {
+			fmt.Print(" ")
+		}
+	}
+}
+
+var (
+	lengthBases = [29]int{
+		3, 4, 5, 6, 7, 8, 9, 10,
+		11, 13, 15, 17, 19, 23, 27, 31,
+		35, 43, 51, 59, 67, 83, 99, 115,
+		131, 163, 195, 227, 258,
+	}
+
+	lengthExtraBits = [29]uint{
+		0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 2, 2,
+		3, 3, 3, 3, 4, 4, 4, 4,
+		5, 5, 5, 5, 0,
+	}
+
+	distanceBases = [30]int{
+		1, 2, 3, 4, 5, 7, 9, 13,
+		17, 25, 33, 49, 65, 97, 129, 193,
+		257, 385, 513, 769, 1025, 1537, 2049, 3073,
+		4097, 6145, 8193, 12289, 16385, 24577,
+	}
+
+	distanceExtraBits = [30]uint{
+		0, 0, 0, 0, 1, 1, 2, 2,
+		3, 3, 4, 4, 5, 5, 6, 6,
+		7, 7, 8, 8, 9, 9, 10, 10,
+		11, 11, 12, 12, 13, 13,
+	}
+)
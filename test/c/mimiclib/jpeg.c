@@ -0,0 +1,125 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <setjmp.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+#include "jpeglib.h"
+
+#define MIMIC_JPEG_MAX_COMPONENTS 3
+
+struct mimic_jpeg_error_mgr {
+  struct jpeg_error_mgr pub;
+  jmp_buf jmpbuf;
+};
+
+void mimic_jpeg_error_exit(j_common_ptr c) {
+  struct mimic_jpeg_error_mgr* e = (struct mimic_jpeg_error_mgr*)(c->err);
+  longjmp(e->jmpbuf, 1);
+}
+
+// mimic_jpeg_decode decodes to planar, unconverted Y or YCbCr samples, without
+// upsampling the chroma planes. This is the pixel format that Wuffs' std/jpeg
+// decoder produces. The planes are written to dst one after another, with no
+// padding between rows.
+const char* mimic_jpeg_decode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src) {
+  // volatile, as they are modified between setjmp and longjmp.
+  const char* volatile ret = NULL;
+  uint8_t* volatile planes[MIMIC_JPEG_MAX_COMPONENTS] = {NULL};
+
+  struct jpeg_decompress_struct d;
+  struct mimic_jpeg_error_mgr e;
+  d.err = jpeg_std_error(&e.pub);
+  e.pub.error_exit = mimic_jpeg_error_exit;
+  if (setjmp(e.jmpbuf)) {
+    ret = "libjpeg failed";
+    goto cleanup;
+  }
+  jpeg_create_decompress(&d);
+  jpeg_mem_src(&d, src->ptr + src->ri, src->wi - src->ri);
+  jpeg_read_header(&d, TRUE);
+  if (d.num_components > MIMIC_JPEG_MAX_COMPONENTS) {
+    ret = "unsupported number of components";
+    goto cleanup;
+  }
+  d.raw_data_out = TRUE;
+  d.do_block_smoothing = FALSE;
+  d.dct_method = JDCT_ISLOW;
+  d.out_color_space = d.jpeg_color_space;
+  jpeg_start_decompress(&d);
+
+  // jpeg_read_raw_data writes whole iMCU (interleaved Minimum Coded Unit)
+  // rows, so each plane is padded out to a whole number of MCUs.
+  size_t strides[MIMIC_JPEG_MAX_COMPONENTS];
+  JSAMPROW rows[MIMIC_JPEG_MAX_COMPONENTS][4 * DCTSIZE];
+  JSAMPARRAY arrays[MIMIC_JPEG_MAX_COMPONENTS];
+  int c;
+  for (c = 0; c < d.num_components; c++) {
+    jpeg_component_info* ci = &d.comp_info[c];
+    size_t w = (size_t)(d.MCUs_per_row) * ci->h_samp_factor;
+    if (w < ci->width_in_blocks) {
+      w = ci->width_in_blocks;
+    }
+    strides[c] = w * DCTSIZE;
+    planes[c] = malloc(strides[c] * d.total_iMCU_rows * ci->v_samp_factor *
+                       DCTSIZE);
+    if (!planes[c]) {
+      ret = "out of memory";
+      goto cleanup;
+    }
+    arrays[c] = rows[c];
+  }
+
+  JDIMENSION imcu_height = d.max_v_samp_factor * DCTSIZE;
+  JDIMENSION i;
+  for (i = 0; i < d.total_iMCU_rows; i++) {
+    for (c = 0; c < d.num_components; c++) {
+      int n = d.comp_info[c].v_samp_factor * DCTSIZE;
+      int j;
+      for (j = 0; j < n; j++) {
+        rows[c][j] = planes[c] + (strides[c] * ((i * n) + j));
+      }
+    }
+    if (jpeg_read_raw_data(&d, arrays, imcu_height) != imcu_height) {
+      ret = "jpeg_read_raw_data failed";
+      goto cleanup;
+    }
+  }
+
+  for (c = 0; c < d.num_components; c++) {
+    jpeg_component_info* ci = &d.comp_info[c];
+    size_t w = ci->downsampled_width;
+    JDIMENSION h = ci->downsampled_height;
+    JDIMENSION y;
+    for (y = 0; y < h; y++) {
+      if (w > (dst->len - dst->wi)) {
+        ret = "JPEG image's pixel data won't fit in the dst buffer";
+        goto cleanup;
+      }
+      memmove(dst->ptr + dst->wi, planes[c] + (strides[c] * y), w);
+      dst->wi += w;
+    }
+  }
+
+  jpeg_finish_decompress(&d);
+
+cleanup:;
+  jpeg_destroy_decompress(&d);
+  for (c = 0; c < MIMIC_JPEG_MAX_COMPONENTS; c++) {
+    free(planes[c]);
+  }
+  return ret;
+}
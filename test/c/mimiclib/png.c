@@ -0,0 +1,102 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include "png.h"
+
+void mimic_png_read_func(png_structp p, png_bytep ptr, png_size_t len) {
+  wuffs_base__io_buffer* src = (wuffs_base__io_buffer*)(png_get_io_ptr(p));
+  size_t num_src = src->wi - src->ri;
+  if (len > num_src) {
+    png_error(p, "unexpected EOF");
+  }
+  memmove(ptr, src->ptr + src->ri, len);
+  src->ri += len;
+}
+
+// mimic_png_decode decodes to 8 bits per channel, non-premultiplied BGRA,
+// which is the pixel format that Wuffs' std/png decoder produces. As with
+// libpng's png_set_strip_16, 16 bit samples are reduced to their high byte.
+const char* mimic_png_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  // volatile, as it is modified between setjmp and longjmp.
+  const char* volatile ret = NULL;
+
+  png_structp p =
+      png_create_read_struct(PNG_LIBPNG_VER_STRING, NULL, NULL, NULL);
+  if (!p) {
+    ret = "png_create_read_struct failed";
+    goto cleanup0;
+  }
+  png_infop info = png_create_info_struct(p);
+  if (!info) {
+    ret = "png_create_info_struct failed";
+    goto cleanup1;
+  }
+  if (setjmp(png_jmpbuf(p))) {
+    ret = "libpng failed";
+    goto cleanup1;
+  }
+
+  png_set_read_fn(p, src, mimic_png_read_func);
+  png_read_info(p, info);
+
+  png_byte color_type = png_get_color_type(p, info);
+  if (color_type == PNG_COLOR_TYPE_PALETTE) {
+    png_set_palette_to_rgb(p);
+  } else if ((color_type == PNG_COLOR_TYPE_GRAY) &&
+             (png_get_bit_depth(p, info) < 8)) {
+    png_set_expand_gray_1_2_4_to_8(p);
+  }
+  if (png_get_valid(p, info, PNG_INFO_tRNS)) {
+    png_set_tRNS_to_alpha(p);
+  }
+  png_set_strip_16(p);
+  if (!(color_type & PNG_COLOR_MASK_COLOR)) {
+    png_set_gray_to_rgb(p);
+  }
+  png_set_add_alpha(p, 0xFF, PNG_FILLER_AFTER);
+  png_set_bgr(p);
+  int num_passes = png_set_interlace_handling(p);
+  png_read_update_info(p, info);
+
+  png_uint_32 width = png_get_image_width(p, info);
+  png_uint_32 height = png_get_image_height(p, info);
+  size_t row_length = png_get_rowbytes(p, info);
+  if (row_length != ((size_t)(width)) * 4) {
+    ret = "unexpected row length";
+    goto cleanup1;
+  }
+  if ((height > 0) && (row_length > ((dst->len - dst->wi) / height))) {
+    ret = "PNG image's pixel data won't fit in the dst buffer";
+    goto cleanup1;
+  }
+
+  // png_read_image needs an array of row pointers. Rather than malloc'ing
+  // that array, read the rows one at a time, once per interlacing pass.
+  int pass;
+  for (pass = 0; pass < num_passes; pass++) {
+    png_uint_32 y;
+    for (y = 0; y < height; y++) {
+      png_read_row(p, dst->ptr + dst->wi + (y * row_length), NULL);
+    }
+  }
+  dst->wi += height * row_length;
+
+  png_read_end(p, NULL);
+
+cleanup1:;
+  png_destroy_read_struct(&p, info ? &info : NULL, NULL);
+cleanup0:;
+  return ret;
+}
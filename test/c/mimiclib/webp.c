@@ -0,0 +1,42 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include "webp/decode.h"
+
+// mimic_webp_decode decodes to 8 bits per channel, non-premultiplied BGRA,
+// which is the pixel format that Wuffs' std/webp decoder produces. Only still
+// images are supported: libwebp's simple decoding API rejects animations.
+const char* mimic_webp_decode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src) {
+  const uint8_t* data = src->ptr + src->ri;
+  size_t data_size = src->wi - src->ri;
+  int width = 0;
+  int height = 0;
+  if (!WebPGetInfo(data, data_size, &width, &height)) {
+    return "WebPGetInfo failed";
+  }
+
+  size_t row_length = ((size_t)(width)) * 4;
+  size_t n = row_length * ((size_t)(height));
+  if (n > (dst->len - dst->wi)) {
+    return "WebP image's pixel data won't fit in the dst buffer";
+  }
+  if (!WebPDecodeBGRAInto(data, data_size, dst->ptr + dst->wi, n,
+                          (int)(row_length))) {
+    return "WebPDecodeBGRAInto failed";
+  }
+  dst->wi += n;
+  src->ri = src->wi;
+  return NULL;
+}
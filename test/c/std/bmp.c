@@ -0,0 +1,474 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror bmp.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/adler32.c"
+#include "../../../gen/c/std/bmp.c"
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../testlib/testlib.c"
+
+// The bmpsuite_filenames are the test/data/bmpsuite BMP files, generated by
+// script/make-bmpsuite.go. Each "foo.bmp" file should decode to the same
+// pixels as the "foo.png" file.
+const char* bmpsuite_filenames[] = {
+    "rgb24",                   //
+    "rgb24-topdown",           //
+    "rgb24-gap",               //
+    "rgb16",                   //
+    "rgb32",                   //
+    "bitfields16-565",         //
+    "bitfields16-565-v2",      //
+    "bitfields16-4444-v5",     //
+    "bitfields32-1010",        //
+    "bitfields32-v4",          //
+    "bitfields32-v5-topdown",  //
+    "alphabitfields32",        //
+    "pal1",                    //
+    "pal4",                    //
+    "pal8",                    //
+    "pal8-v5",                 //
+    "rle4",                    //
+    "rle4-delta",              //
+    "rle8",                    //
+    "rle8-delta",              //
+    NULL,
+};
+
+// The real_world_filenames are the test/data BMP files, generated by
+// ImageMagick. Each "foo.bmp" file should decode to the same pixels as the
+// "foo.png" file.
+const char* real_world_filenames[] = {
+    "bricks-color",     //
+    "bricks-dither",    //
+    "bricks-gray",      //
+    "bricks-nodither",  //
+    "harvesters",       //
+    "hat",              //
+    "hibiscus",         //
+    "hippopotamus",     //
+    "pjw-thumbnail",    //
+    NULL,
+};
+
+// ---------------- BMP Tests
+
+// wuffs_bmp_decode_with_options decodes the BMP image in src into dst, as
+// BGRA_NONPREMUL pixels.
+const char* wuffs_bmp_decode_with_options(wuffs_base__io_buffer* dst,
+                                          wuffs_base__io_buffer* src,
+                                          uint64_t rlimit) {
+  wuffs_bmp__decoder dec = ((wuffs_bmp__decoder){});
+  wuffs_bmp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_bmp__status s = WUFFS_BMP__STATUS_OK;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_BMP__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_bmp__status__string(s);
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    return "unexpected pixel format";
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_bmp__decoder__decode_frame(&dec, &ib, src_reader);
+    if (s != WUFFS_BMP__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_bmp__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+const char* wuffs_bmp_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  return wuffs_bmp_decode_with_options(dst, src, 0);
+}
+
+// wuffs_png_decode decodes the PNG image in src into dst, as BGRA_NONPREMUL
+// pixels.
+const char* wuffs_png_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  s = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+// do_test_wuffs_bmp_decode_equal decodes the got_filename BMP image, with a
+// read limit, and the want_filename PNG image and checks that they have the
+// same pixels.
+bool do_test_wuffs_bmp_decode_equal(const char* got_filename,
+                                    const char* want_filename,
+                                    uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, got_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_bmp_decode_with_options(&got, &src, rlimit);
+  if (got_msg) {
+    FAIL("%s: %s", got_filename, got_msg);
+    return false;
+  }
+
+  src = ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, want_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = wuffs_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", want_filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+// do_test_wuffs_bmp_decode_filenames calls do_test_wuffs_bmp_decode_equal on
+// each "prefix/foo.bmp" and "prefix/foo.png" pair.
+bool do_test_wuffs_bmp_decode_filenames(const char* prefix,
+                                        const char** filenames) {
+  const char** f;
+  for (f = filenames; *f; f++) {
+    char got_filename[64];
+    char want_filename[64];
+    snprintf(got_filename, sizeof(got_filename), "%s%s.bmp", prefix, *f);
+    snprintf(want_filename, sizeof(want_filename), "%s%s.png", prefix, *f);
+    if (!do_test_wuffs_bmp_decode_equal(got_filename, want_filename, 0)) {
+      return false;
+    }
+  }
+  return true;
+}
+
+void test_wuffs_bmp_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.bmp")) {
+    return;
+  }
+
+  wuffs_bmp__decoder dec = ((wuffs_bmp__decoder){});
+  wuffs_bmp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_frame(&dec, &ib, src_reader);
+  if (status != WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status),
+         WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_bmp__status__string(WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_bmp_decode_bmpsuite() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_filenames("../../data/bmpsuite/",
+                                     bmpsuite_filenames);
+}
+
+void test_wuffs_bmp_decode_input_is_a_bmp() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.bmp")) {
+    return;
+  }
+
+  wuffs_bmp__decoder dec = ((wuffs_bmp__decoder){});
+  wuffs_bmp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_BMP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status));
+    return;
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    FAIL("pixel_format: got 0x%08" PRIX32 ", want 0x%08" PRIX32,
+         wuffs_base__image_config__pixel_format(&ic),
+         WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL);
+    return;
+  }
+  // bricks-color.bmp is a 160 × 120 image.
+  if (wuffs_base__image_config__width(&ic) != 160) {
+    FAIL("width: got %" PRIu32 ", want 160",
+         wuffs_base__image_config__width(&ic));
+    return;
+  }
+  if (wuffs_base__image_config__height(&ic) != 120) {
+    FAIL("height: got %" PRIu32 ", want 120",
+         wuffs_base__image_config__height(&ic));
+    return;
+  }
+  uint64_t want_pixbuf_size = 4 * 160 * 120;
+  if (wuffs_base__image_config__pixbuf_size(&ic) != want_pixbuf_size) {
+    FAIL("pixbuf_size: got %" PRIu64 ", want %" PRIu64,
+         wuffs_base__image_config__pixbuf_size(&ic), want_pixbuf_size);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_bmp__decoder__decode_frame(&dec, &ib, src_reader);
+  if (status != WUFFS_BMP__STATUS_OK) {
+    FAIL("decode_frame #0: got %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status));
+    return;
+  }
+  if (src.ri != src.wi) {
+    FAIL("decode_frame returned \"ok\" but src was not exhausted");
+    return;
+  }
+  status = wuffs_bmp__decoder__decode_frame(&dec, &ib, src_reader);
+  if (status != WUFFS_BMP__SUSPENSION_END_OF_DATA) {
+    FAIL("decode_frame #1: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_bmp__status__string(status),
+         WUFFS_BMP__SUSPENSION_END_OF_DATA,
+         wuffs_bmp__status__string(WUFFS_BMP__SUSPENSION_END_OF_DATA));
+    return;
+  }
+}
+
+void test_wuffs_bmp_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_bmp__decoder dec = ((wuffs_bmp__decoder){});
+  wuffs_bmp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_BMP__ERROR_BAD_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status), WUFFS_BMP__ERROR_BAD_HEADER,
+         wuffs_bmp__status__string(WUFFS_BMP__ERROR_BAD_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_bmp_decode_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_equal("../../data/bricks-color.bmp",
+                                 "../../data/bricks-color.png", 11);
+}
+
+void test_wuffs_bmp_decode_real_world() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_filenames("../../data/", real_world_filenames);
+}
+
+void test_wuffs_bmp_decode_rle_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  if (!do_test_wuffs_bmp_decode_equal("../../data/bmpsuite/rle4-delta.bmp",
+                                      "../../data/bmpsuite/rle4-delta.png",
+                                      3)) {
+    return;
+  }
+  do_test_wuffs_bmp_decode_equal("../../data/bmpsuite/rle8-delta.bmp",
+                                 "../../data/bmpsuite/rle8-delta.png", 3);
+}
+
+// ---------------- BMP Benches
+
+bool do_bench_bmp_decode(const char* (*decode_func)(wuffs_base__io_buffer*,
+                                                    wuffs_base__io_buffer*),
+                         const char* filename,
+                         uint64_t iters_unscaled) {
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  uint64_t iters = iters_unscaled * iterscale;
+  for (i = 0; i < iters; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(iters, n_bytes);
+  return true;
+}
+
+void bench_wuffs_bmp_decode_1k_color() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/hippopotamus.bmp", 1000);
+}
+
+void bench_wuffs_bmp_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/hat.bmp", 100);
+}
+
+void bench_wuffs_bmp_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/hibiscus.bmp", 10);
+}
+
+void bench_wuffs_bmp_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/harvesters.bmp", 1);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_bmp_call_sequence,                //
+    test_wuffs_bmp_decode_bmpsuite,              //
+    test_wuffs_bmp_decode_input_is_a_bmp,        //
+    test_wuffs_bmp_decode_input_is_a_png,        //
+    test_wuffs_bmp_decode_many_small_reads,      //
+    test_wuffs_bmp_decode_real_world,            //
+    test_wuffs_bmp_decode_rle_many_small_reads,  //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_bmp_decode_1k_color,  //
+    bench_wuffs_bmp_decode_10k,       //
+    bench_wuffs_bmp_decode_100k,      //
+    bench_wuffs_bmp_decode_1000k,     //
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_package_name = "std/bmp";
+  return test_main(argc, argv, tests, benches);
+}
@@ -70,6 +70,15 @@ golden_test deflate_deflate_backref_crosses_blocks_gt = {
         "deflate-backref-crosses-blocks.deflate",
 };
 
+golden_test deflate_deflate_degenerate_huffman_code_gt = {
+    .want_filename =
+        "../../data/artificial/"
+        "deflate-degenerate-huffman-code.deflate.decompressed",
+    .src_filename =
+        "../../data/artificial/"
+        "deflate-degenerate-huffman-code.deflate",
+};
+
 golden_test deflate_deflate_distance_32768_gt = {
     .want_filename =
         "../../data/artificial/"
@@ -148,6 +157,12 @@ void test_wuffs_deflate_decode_deflate_backref_crosses_blocks() {
                      &deflate_deflate_backref_crosses_blocks_gt, 0, 0);
 }
 
+void test_wuffs_deflate_decode_deflate_degenerate_huffman_code() {
+  CHECK_FOCUS(__func__);
+  do_test_io_buffers(wuffs_deflate_decode,
+                     &deflate_deflate_degenerate_huffman_code_gt, 0, 0);
+}
+
 void test_wuffs_deflate_decode_deflate_distance_32768() {
   CHECK_FOCUS(__func__);
   do_test_io_buffers(wuffs_deflate_decode, &deflate_deflate_distance_32768_gt,
@@ -544,6 +559,142 @@ void test_wuffs_deflate_table_redirect() {
   }
 }
 
+// ---------------- Deflate Encoder Tests
+
+const char* wuffs_deflate_encode(wuffs_base__io_buffer* dst,
+                                 wuffs_base__io_buffer* src,
+                                 uint32_t level,
+                                 uint64_t wlimit,
+                                 uint64_t rlimit) {
+  wuffs_deflate__encoder enc = ((wuffs_deflate__encoder){});
+  wuffs_deflate__encoder__check_wuffs_version(&enc, sizeof enc, WUFFS_VERSION);
+  wuffs_deflate__encoder__set_level(&enc, level);
+  while (true) {
+    wuffs_base__io_writer dst_writer = wuffs_base__io_buffer__writer(dst);
+    if (wlimit) {
+      set_writer_limit(&dst_writer, wlimit);
+    }
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+
+    wuffs_deflate__status s =
+        wuffs_deflate__encoder__encode(&enc, dst_writer, src_reader);
+
+    if (s == WUFFS_DEFLATE__STATUS_OK) {
+      return NULL;
+    }
+    if ((wlimit && (s == WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (s == WUFFS_DEFLATE__SUSPENSION_SHORT_READ))) {
+      continue;
+    }
+    return wuffs_deflate__status__string(s);
+  }
+}
+
+// do_test_deflate_round_trip encodes the golden test's want file and then
+// decodes the result with decode_func, which should give back the original.
+void do_test_deflate_round_trip(
+    const char* (*decode_func)(wuffs_base__io_buffer*,
+                               wuffs_base__io_buffer*,
+                               uint64_t,
+                               uint64_t),
+    golden_test* gt,
+    uint32_t level,
+    uint64_t wlimit,
+    uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&want, gt->want_filename)) {
+    return;
+  }
+  const char* status = wuffs_deflate_encode(&src, &want, level, wlimit, rlimit);
+  if (status) {
+    FAIL("level=%" PRIu32 ": encode: %s", level, status);
+    return;
+  }
+  if (want.ri != want.wi) {
+    FAIL("level=%" PRIu32 ": encode: src was not exhausted", level);
+    return;
+  }
+  src.closed = true;
+  want.ri = 0;
+
+  status = decode_func(&got, &src, 0, 0);
+  if (status) {
+    FAIL("level=%" PRIu32 ": decode: %s", level, status);
+    return;
+  }
+  char prefix[64];
+  snprintf(prefix, 64, "level=%" PRIu32 ": ", level);
+  io_buffers_equal(prefix, &got, &want);
+}
+
+void test_wuffs_deflate_encode_empty() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .closed = true});
+
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    dst.wi = 0;
+    const char* status = wuffs_deflate_encode(&dst, &src, level, 0, 0);
+    if (status) {
+      FAIL("level=%" PRIu32 ": %s", level, status);
+      return;
+    }
+    // A stored block is 5 bytes. A fixed Huffman block with only an
+    // end-of-block code is 10 bits, or 2 bytes.
+    uint64_t want = (level == 0) ? 5 : 2;
+    if (dst.wi != want) {
+      FAIL("level=%" PRIu32 ": dst.wi: got %zu, want %zu", level,
+           (size_t)(dst.wi), (size_t)(want));
+      return;
+    }
+  }
+}
+
+void test_wuffs_deflate_encode_levels() {
+  CHECK_FOCUS(__func__);
+
+  golden_test* gts[] = {
+      &deflate_256_bytes_gt,
+      &deflate_midsummer_gt,
+      &deflate_pi_gt,
+      &deflate_romeo_gt,
+  };
+  int i;
+  for (i = 0; i < WUFFS_TESTLIB_ARRAY_SIZE(gts); i++) {
+    uint32_t level;
+    for (level = 0; level <= 9; level++) {
+      do_test_deflate_round_trip(wuffs_deflate_decode, gts[i], level, 0, 0);
+      if (fail_msg[0]) {
+        return;
+      }
+    }
+  }
+}
+
+void test_wuffs_deflate_encode_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_deflate_round_trip(wuffs_deflate_decode, &deflate_pi_gt, 6, 59, 61);
+}
+
+void test_wuffs_deflate_encode_stored_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_deflate_round_trip(wuffs_deflate_decode, &deflate_midsummer_gt, 0, 7,
+                             11);
+}
+
   // ---------------- Mimic Tests
 
 #ifdef WUFFS_MIMIC
@@ -559,6 +710,12 @@ void test_mimic_deflate_decode_deflate_backref_crosses_blocks() {
                      &deflate_deflate_backref_crosses_blocks_gt, 0, 0);
 }
 
+void test_mimic_deflate_decode_deflate_degenerate_huffman_code() {
+  CHECK_FOCUS(__func__);
+  do_test_io_buffers(mimic_deflate_decode,
+                     &deflate_deflate_degenerate_huffman_code_gt, 0, 0);
+}
+
 void test_mimic_deflate_decode_deflate_distance_32768() {
   CHECK_FOCUS(__func__);
   do_test_io_buffers(mimic_deflate_decode, &deflate_deflate_distance_32768_gt,
@@ -585,6 +742,18 @@ void test_mimic_deflate_decode_romeo_fixed() {
   do_test_io_buffers(mimic_deflate_decode, &deflate_romeo_fixed_gt, 0, 0);
 }
 
+void test_mimic_deflate_decode_wuffs_encoded() {
+  CHECK_FOCUS(__func__);
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    do_test_deflate_round_trip(mimic_deflate_decode, &deflate_pi_gt, level, 0,
+                               0);
+    if (fail_msg[0]) {
+      return;
+    }
+  }
+}
+
 #endif  // WUFFS_MIMIC
 
 // ---------------- Deflate Benches
@@ -634,30 +803,37 @@ void bench_mimic_deflate_decode_100k() {
 // The empty comments forces clang-format to place one element per line.
 proc tests[] = {
 
-    test_wuffs_deflate_decode_256_bytes,                       //
-    test_wuffs_deflate_decode_deflate_backref_crosses_blocks,  //
-    test_wuffs_deflate_decode_deflate_distance_32768,          //
-    test_wuffs_deflate_decode_midsummer,                       //
-    test_wuffs_deflate_decode_pi,                              //
-    test_wuffs_deflate_decode_pi_many_big_reads,               //
-    test_wuffs_deflate_decode_pi_many_medium_reads,            //
-    test_wuffs_deflate_decode_pi_many_small_writes_reads,      //
-    test_wuffs_deflate_decode_romeo,                           //
-    test_wuffs_deflate_decode_romeo_fixed,                     //
-    test_wuffs_deflate_decode_split_src,                       //
-    test_wuffs_deflate_history_full,                           //
-    test_wuffs_deflate_history_partial,                        //
-    test_wuffs_deflate_table_redirect,                         //
+    test_wuffs_deflate_decode_256_bytes,                        //
+    test_wuffs_deflate_decode_deflate_backref_crosses_blocks,   //
+    test_wuffs_deflate_decode_deflate_degenerate_huffman_code,  //
+    test_wuffs_deflate_decode_deflate_distance_32768,           //
+    test_wuffs_deflate_decode_midsummer,                        //
+    test_wuffs_deflate_decode_pi,                               //
+    test_wuffs_deflate_decode_pi_many_big_reads,                //
+    test_wuffs_deflate_decode_pi_many_medium_reads,             //
+    test_wuffs_deflate_decode_pi_many_small_writes_reads,       //
+    test_wuffs_deflate_decode_romeo,                            //
+    test_wuffs_deflate_decode_romeo_fixed,                      //
+    test_wuffs_deflate_decode_split_src,                        //
+    test_wuffs_deflate_encode_empty,                            //
+    test_wuffs_deflate_encode_levels,                           //
+    test_wuffs_deflate_encode_many_small_writes_reads,          //
+    test_wuffs_deflate_encode_stored_many_small_writes_reads,   //
+    test_wuffs_deflate_history_full,                            //
+    test_wuffs_deflate_history_partial,                         //
+    test_wuffs_deflate_table_redirect,                          //
 
 #ifdef WUFFS_MIMIC
 
-    test_mimic_deflate_decode_256_bytes,                       //
-    test_mimic_deflate_decode_deflate_backref_crosses_blocks,  //
-    test_mimic_deflate_decode_deflate_distance_32768,          //
-    test_mimic_deflate_decode_midsummer,                       //
-    test_mimic_deflate_decode_pi,                              //
-    test_mimic_deflate_decode_romeo,                           //
-    test_mimic_deflate_decode_romeo_fixed,                     //
+    test_mimic_deflate_decode_256_bytes,                        //
+    test_mimic_deflate_decode_deflate_backref_crosses_blocks,   //
+    test_mimic_deflate_decode_deflate_degenerate_huffman_code,  //
+    test_mimic_deflate_decode_deflate_distance_32768,           //
+    test_mimic_deflate_decode_midsummer,                        //
+    test_mimic_deflate_decode_pi,                               //
+    test_mimic_deflate_decode_romeo,                            //
+    test_mimic_deflate_decode_romeo_fixed,                      //
+    test_mimic_deflate_decode_wuffs_encoded,                    //
 
 #endif  // WUFFS_MIMIC
 
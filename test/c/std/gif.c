@@ -225,10 +225,16 @@ const char* wuffs_gif_decode(wuffs_base__io_buffer* dst,
   return NULL;
 }
 
+uint8_t global_gif_state_buffer[WUFFS_GIF__DECODER__STATE_LENGTH];
+
+// do_test_wuffs_gif_decode decodes the first frame of filename. If
+// save_restore_state is true, then after every suspension, it saves the
+// decoder's state and restores it into a freshly initialized decoder.
 bool do_test_wuffs_gif_decode(const char* filename,
                               const char* palette_filename,
                               const char* indexes_filename,
-                              uint64_t rlimit) {
+                              uint64_t rlimit,
+                              bool save_restore_state) {
   wuffs_base__io_buffer got =
       ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
   wuffs_base__io_buffer src =
@@ -320,6 +326,27 @@ bool do_test_wuffs_gif_decode(const char* filename,
       FAIL("no progress was made");
       return false;
     }
+
+    if (save_restore_state) {
+      wuffs_base__slice_u8 state = ((wuffs_base__slice_u8){
+          .ptr = global_gif_state_buffer,
+          .len = WUFFS_GIF__DECODER__STATE_LENGTH,
+      });
+      status = wuffs_gif__decoder__save_state(&dec, state);
+      if (status) {
+        FAIL("save_state: got %" PRIi32 " (%s)", status,
+             wuffs_gif__status__string(status));
+        return false;
+      }
+      dec = ((wuffs_gif__decoder){});
+      wuffs_gif__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+      status = wuffs_gif__decoder__restore_state(&dec, state);
+      if (status) {
+        FAIL("restore_state: got %" PRIi32 " (%s)", status,
+             wuffs_gif__status__string(status));
+        return false;
+      }
+    }
   }
 
   if (rlimit) {
@@ -546,25 +573,80 @@ void test_wuffs_gif_decode_frame_out_of_bounds() {
   }
 }
 
+void test_wuffs_gif_decode_frame_interlaced() {
+  CHECK_FOCUS(__func__);
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, "../../data/artificial/gif-frame-interlaced.gif")) {
+    return;
+  }
+
+  wuffs_gif__decoder dec = ((wuffs_gif__decoder){});
+  wuffs_gif__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+  wuffs_gif__status s =
+      wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    FAIL("decode_config: %s", wuffs_gif__status__string(s));
+    return;
+  }
+
+  // The 3×7 image has one interlaced frame, with bounds (0, 2) - (3, 7). See
+  // test/data/artificial/gif-frame-interlaced.gif.make-artificial.txt for
+  // more discussion.
+  const size_t n = 3 * 7;
+  memset(global_pixel_buffer, 0xFF, n);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic, ((wuffs_base__slice_u8){.ptr = global_pixel_buffer, .len = n}));
+  s = wuffs_gif__decoder__decode_frame(&dec, &ib, src_reader);
+  if (s) {
+    FAIL("decode_frame: %s", wuffs_gif__status__string(s));
+    return;
+  }
+
+  const uint8_t want[3 * 7] = {
+      0xFF, 0xFF, 0xFF,  //
+      0xFF, 0xFF, 0xFF,  //
+      0x00, 0x00, 0x00,  //
+      0x01, 0x01, 0x01,  //
+      0x02, 0x02, 0x02,  //
+      0x03, 0x03, 0x03,  //
+      0x04, 0x04, 0x04,  //
+  };
+  size_t i;
+  for (i = 0; i < n; i++) {
+    if (global_pixel_buffer[i] != want[i]) {
+      FAIL("pixel #%zu (x=%zu, y=%zu): got 0x%02X, want 0x%02X", i, i % 3,
+           i / 3, global_pixel_buffer[i], want[i]);
+      return;
+    }
+  }
+}
+
 void test_wuffs_gif_decode_input_is_a_gif() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 0);
+                           "../../data/bricks-dither.indexes", 0,
+                           false);
 }
 
 void test_wuffs_gif_decode_input_is_a_gif_many_big_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 4096);
+                           "../../data/bricks-dither.indexes", 4096,
+                           false);
 }
 
 void test_wuffs_gif_decode_input_is_a_gif_many_medium_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 787);
+                           "../../data/bricks-dither.indexes", 787,
+                           false);
   // The magic 787 tickles being in the middle of a decode_extension skip32
   // call.
   //
@@ -575,7 +657,36 @@ void test_wuffs_gif_decode_input_is_a_gif_many_small_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 13);
+                           "../../data/bricks-dither.indexes", 13,
+                           false);
+}
+
+void test_wuffs_gif_decode_input_is_a_gif_save_restore_state() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13, true);
+}
+
+void test_wuffs_gif_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.interlaced.gif",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 0, false);
+}
+
+void test_wuffs_gif_decode_interlaced_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.interlaced.gif",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13, false);
+}
+
+void test_wuffs_gif_decode_interlaced_save_restore_state() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.interlaced.gif",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13, true);
 }
 
 void test_wuffs_gif_decode_input_is_a_png() {
@@ -603,6 +714,314 @@ void test_wuffs_gif_decode_input_is_a_png() {
   }
 }
 
+// ---------------- GIF Encoder Tests
+
+// do_test_wuffs_gif_encode_round_trip decodes filename, re-encodes each of its
+// frames and then checks that decoding that re-encoding gives the same frames
+// as decoding the original. If wlimit is non-zero, the encoder's writes are
+// limited to at most wlimit bytes at a time.
+bool do_test_wuffs_gif_encode_round_trip(const char* filename,
+                                         uint32_t want_num_frames,
+                                         uint64_t wlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer enc =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  // Decode the original and encode each frame.
+  {
+    wuffs_gif__decoder dec = ((wuffs_gif__decoder){});
+    wuffs_gif__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+    wuffs_gif__encoder encoder = ((wuffs_gif__encoder){});
+    wuffs_gif__encoder__check_wuffs_version(&encoder, sizeof encoder,
+                                            WUFFS_VERSION);
+    wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+    wuffs_base__image_config ic = ((wuffs_base__image_config){});
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+    wuffs_base__io_writer enc_writer = wuffs_base__io_buffer__writer(&enc);
+
+    wuffs_gif__status status =
+        wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+    if (status) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_gif__status__string(status));
+      return false;
+    }
+    wuffs_base__image_buffer__set_from_slice(
+        &ib, ic,
+        ((wuffs_base__slice_u8){
+            .ptr = global_pixel_buffer,
+            .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+        }));
+
+    status = wuffs_gif__encoder__encode_config(&encoder, enc_writer, &ic);
+    if (status) {
+      FAIL("encode_config: got %" PRIi32 " (%s)", status,
+           wuffs_gif__status__string(status));
+      return false;
+    }
+
+    while (true) {
+      status = wuffs_gif__decoder__decode_frame(&dec, &ib, src_reader);
+      if (status == WUFFS_GIF__SUSPENSION_END_OF_DATA) {
+        break;
+      } else if (status) {
+        FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+             wuffs_gif__status__string(status));
+        return false;
+      }
+
+      while (true) {
+        enc_writer = wuffs_base__io_buffer__writer(&enc);
+        if (wlimit) {
+          set_writer_limit(&enc_writer, wlimit);
+        }
+        status = wuffs_gif__encoder__encode_frame(&encoder, enc_writer, &ib);
+        if (status != WUFFS_GIF__SUSPENSION_SHORT_WRITE) {
+          break;
+        }
+      }
+      if (status) {
+        FAIL("encode_frame: got %" PRIi32 " (%s)", status,
+             wuffs_gif__status__string(status));
+        return false;
+      }
+    }
+
+    enc_writer = wuffs_base__io_buffer__writer(&enc);
+    status = wuffs_gif__encoder__encode_trailer(&encoder, enc_writer);
+    if (status) {
+      FAIL("encode_trailer: got %" PRIi32 " (%s)", status,
+           wuffs_gif__status__string(status));
+      return false;
+    }
+  }
+
+  // Decode the original and the re-encoding in lockstep.
+  src.ri = 0;
+  wuffs_gif__decoder decs[2] = {((wuffs_gif__decoder){}),
+                                ((wuffs_gif__decoder){})};
+  wuffs_base__io_reader readers[2] = {wuffs_base__io_buffer__reader(&src),
+                                      wuffs_base__io_buffer__reader(&enc)};
+  uint8_t* pixels[2] = {global_pixel_buffer, global_want_buffer};
+  wuffs_base__image_buffer ibs[2] = {((wuffs_base__image_buffer){}),
+                                     ((wuffs_base__image_buffer){})};
+  wuffs_base__image_config ics[2] = {((wuffs_base__image_config){}),
+                                     ((wuffs_base__image_config){})};
+  int i;
+  for (i = 0; i < 2; i++) {
+    wuffs_gif__decoder__check_wuffs_version(&decs[i], sizeof decs[i],
+                                            WUFFS_VERSION);
+    wuffs_gif__status status =
+        wuffs_gif__decoder__decode_config(&decs[i], &ics[i], readers[i]);
+    if (status) {
+      FAIL("decode_config #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return false;
+    }
+    memset(pixels[i], 0, wuffs_base__image_config__pixbuf_size(&ics[i]));
+    wuffs_base__image_buffer__set_from_slice(
+        &ibs[i], ics[i],
+        ((wuffs_base__slice_u8){
+            .ptr = pixels[i],
+            .len = BUFFER_SIZE,
+        }));
+  }
+
+  if ((wuffs_base__image_config__width(&ics[0]) !=
+       wuffs_base__image_config__width(&ics[1])) ||
+      (wuffs_base__image_config__height(&ics[0]) !=
+       wuffs_base__image_config__height(&ics[1]))) {
+    FAIL("dimensions: got %" PRIu32 " × %" PRIu32 ", want %" PRIu32
+         " × %" PRIu32,
+         wuffs_base__image_config__width(&ics[1]),
+         wuffs_base__image_config__height(&ics[1]),
+         wuffs_base__image_config__width(&ics[0]),
+         wuffs_base__image_config__height(&ics[0]));
+    return false;
+  }
+  if (wuffs_base__image_config__num_loops(&ics[0]) !=
+      wuffs_base__image_config__num_loops(&ics[1])) {
+    FAIL("num_loops: got %" PRIu32 ", want %" PRIu32,
+         wuffs_base__image_config__num_loops(&ics[1]),
+         wuffs_base__image_config__num_loops(&ics[0]));
+    return false;
+  }
+  size_t n = wuffs_base__image_config__pixbuf_size(&ics[0]);
+
+  uint32_t num_frames = 0;
+  while (true) {
+    wuffs_gif__status statuses[2];
+    for (i = 0; i < 2; i++) {
+      statuses[i] =
+          wuffs_gif__decoder__decode_frame(&decs[i], &ibs[i], readers[i]);
+    }
+    if (statuses[0] != statuses[1]) {
+      FAIL("decode_frame #%" PRIu32 ": got %" PRIi32 " (%s), want %" PRIi32
+           " (%s)",
+           num_frames, statuses[1], wuffs_gif__status__string(statuses[1]),
+           statuses[0], wuffs_gif__status__string(statuses[0]));
+      return false;
+    }
+    if (statuses[0] == WUFFS_GIF__SUSPENSION_END_OF_DATA) {
+      break;
+    } else if (statuses[0]) {
+      FAIL("decode_frame #%" PRIu32 ": got %" PRIi32 " (%s)", num_frames,
+           statuses[0], wuffs_gif__status__string(statuses[0]));
+      return false;
+    }
+
+    wuffs_base__rect_ie_u32 r = wuffs_base__image_buffer__dirty_rect(&ibs[0]);
+    if (!wuffs_base__rect_ie_u32__equals(
+            r, wuffs_base__image_buffer__dirty_rect(&ibs[1]))) {
+      FAIL("decode_frame #%" PRIu32 ": dirty_rects differ", num_frames);
+      return false;
+    }
+    if (wuffs_base__image_buffer__duration(&ibs[0]) !=
+        wuffs_base__image_buffer__duration(&ibs[1])) {
+      FAIL("decode_frame #%" PRIu32 ": durations differ", num_frames);
+      return false;
+    }
+    if (wuffs_base__image_buffer__disposal(&ibs[0]) !=
+        wuffs_base__image_buffer__disposal(&ibs[1])) {
+      FAIL("decode_frame #%" PRIu32 ": disposals differ", num_frames);
+      return false;
+    }
+    if (memcmp(pixels[0], pixels[1], n)) {
+      FAIL("decode_frame #%" PRIu32 ": pixels differ", num_frames);
+      return false;
+    }
+
+    // The palettes' sizes may differ, but the colors of every pixel in the
+    // dirty_rect should match.
+    wuffs_base__slice_u8 palettes[2] = {
+        wuffs_base__image_buffer__palette(&ibs[0]),
+        wuffs_base__image_buffer__palette(&ibs[1]),
+    };
+    wuffs_base__table_u8 tab = wuffs_base__image_buffer__plane(&ibs[0], 0);
+    uint32_t x, y;
+    for (y = r.min_inclusive_y; y < r.max_exclusive_y; y++) {
+      for (x = r.min_inclusive_x; x < r.max_exclusive_x; x++) {
+        uint8_t index = tab.ptr[(y * tab.stride) + x];
+        if (memcmp(palettes[0].ptr + (4 * index),
+                   palettes[1].ptr + (4 * index), 3)) {
+          FAIL("decode_frame #%" PRIu32 ": palettes differ at index %" PRIu8,
+               num_frames, index);
+          return false;
+        }
+      }
+    }
+
+    num_frames++;
+  }
+
+  if (num_frames != want_num_frames) {
+    FAIL("num_frames: got %" PRIu32 ", want %" PRIu32, num_frames,
+         want_num_frames);
+    return false;
+  }
+  return true;
+}
+
+void test_wuffs_gif_encode_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer enc =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_gif__encoder encoder = ((wuffs_gif__encoder){});
+  wuffs_gif__encoder__check_wuffs_version(&encoder, sizeof encoder,
+                                          WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+
+  wuffs_gif__status status = wuffs_gif__encoder__encode_frame(
+      &encoder, wuffs_base__io_buffer__writer(&enc), &ib);
+  if (status != WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("encode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status),
+         WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_gif__status__string(WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_gif_encode_empty() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer enc =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_gif__encoder encoder = ((wuffs_gif__encoder){});
+  wuffs_gif__encoder__check_wuffs_version(&encoder, sizeof encoder,
+                                          WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__image_config__initialize(
+      &ic, WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL_INDEXED, 0, 3, 2, 1);
+
+  wuffs_gif__status status = wuffs_gif__encoder__encode_config(
+      &encoder, wuffs_base__io_buffer__writer(&enc), &ic);
+  if (status) {
+    FAIL("encode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  status = wuffs_gif__encoder__encode_trailer(
+      &encoder, wuffs_base__io_buffer__writer(&enc));
+  if (status) {
+    FAIL("encode_trailer: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+
+  uint8_t want_array[14] = {
+      0x47, 0x49, 0x46, 0x38, 0x39, 0x61,  // "GIF89a".
+      0x03, 0x00, 0x02, 0x00,              // 3 × 2.
+      0x00, 0x00, 0x00,                    // No Global Color Table.
+      0x3B,                                // Trailer.
+  };
+  wuffs_base__io_buffer want = ((wuffs_base__io_buffer){
+      .ptr = want_array,
+      .len = WUFFS_TESTLIB_ARRAY_SIZE(want_array),
+      .wi = WUFFS_TESTLIB_ARRAY_SIZE(want_array),
+  });
+  io_buffers_equal("", &enc, &want);
+}
+
+void test_wuffs_gif_encode_round_trip_animated_big() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip("../../data/gifplayer-muybridge.gif",
+                                      380, 0);
+}
+
+void test_wuffs_gif_encode_round_trip_animated_medium() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip("../../data/muybridge.gif", 15, 0);
+}
+
+void test_wuffs_gif_encode_round_trip_animated_small() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip("../../data/animated-red-blue.gif", 4,
+                                      0);
+}
+
+void test_wuffs_gif_encode_round_trip_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip("../../data/bricks-dither.gif", 1, 0);
+}
+
+void test_wuffs_gif_encode_round_trip_many_small_writes() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip("../../data/bricks-dither.gif", 1, 11);
+}
+
+void test_wuffs_gif_encode_round_trip_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_encode_round_trip(
+      "../../data/bricks-dither.interlaced.gif", 1, 0);
+}
+
   // ---------------- Mimic Tests
 
 #ifdef WUFFS_MIMIC
@@ -651,6 +1070,11 @@ void test_mimic_gif_decode_bricks_dither() {
   do_test_mimic_gif_decode("../../data/bricks-dither.gif");
 }
 
+void test_mimic_gif_decode_bricks_dither_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_gif_decode("../../data/bricks-dither.interlaced.gif");
+}
+
 void test_mimic_gif_decode_bricks_gray() {
   CHECK_FOCUS(__func__);
   do_test_mimic_gif_decode("../../data/bricks-gray.gif");
@@ -799,29 +1223,43 @@ proc tests[] = {
     test_basic_status_used_package,             //
     test_basic_sub_struct_initializer,          //
 
-    test_wuffs_gif_call_sequence,                            //
-    test_wuffs_gif_decode_animated_big,                      //
-    test_wuffs_gif_decode_animated_medium,                   //
-    test_wuffs_gif_decode_animated_small,                    //
-    test_wuffs_gif_decode_frame_out_of_bounds,               //
-    test_wuffs_gif_decode_input_is_a_gif,                    //
-    test_wuffs_gif_decode_input_is_a_gif_many_big_reads,     //
-    test_wuffs_gif_decode_input_is_a_gif_many_medium_reads,  //
-    test_wuffs_gif_decode_input_is_a_gif_many_small_reads,   //
-    test_wuffs_gif_decode_input_is_a_png,                    //
+    test_wuffs_gif_call_sequence,                             //
+    test_wuffs_gif_decode_animated_big,                       //
+    test_wuffs_gif_decode_animated_medium,                    //
+    test_wuffs_gif_decode_animated_small,                     //
+    test_wuffs_gif_decode_frame_interlaced,                   //
+    test_wuffs_gif_decode_frame_out_of_bounds,                //
+    test_wuffs_gif_decode_input_is_a_gif,                     //
+    test_wuffs_gif_decode_input_is_a_gif_many_big_reads,      //
+    test_wuffs_gif_decode_input_is_a_gif_many_medium_reads,   //
+    test_wuffs_gif_decode_input_is_a_gif_many_small_reads,    //
+    test_wuffs_gif_decode_input_is_a_gif_save_restore_state,  //
+    test_wuffs_gif_decode_input_is_a_png,                     //
+    test_wuffs_gif_decode_interlaced,                         //
+    test_wuffs_gif_decode_interlaced_many_small_reads,        //
+    test_wuffs_gif_decode_interlaced_save_restore_state,      //
+    test_wuffs_gif_encode_call_sequence,                      //
+    test_wuffs_gif_encode_empty,                              //
+    test_wuffs_gif_encode_round_trip_animated_big,            //
+    test_wuffs_gif_encode_round_trip_animated_medium,         //
+    test_wuffs_gif_encode_round_trip_animated_small,          //
+    test_wuffs_gif_encode_round_trip_bricks_dither,           //
+    test_wuffs_gif_encode_round_trip_interlaced,              //
+    test_wuffs_gif_encode_round_trip_many_small_writes,       //
 
 #ifdef WUFFS_MIMIC
 
-    test_mimic_gif_decode_animated_small,       //
-    test_mimic_gif_decode_bricks_dither,        //
-    test_mimic_gif_decode_bricks_gray,          //
-    test_mimic_gif_decode_bricks_nodither,      //
-    test_mimic_gif_decode_gifplayer_muybridge,  //
-    test_mimic_gif_decode_harvesters,           //
-    test_mimic_gif_decode_hat,                  //
-    test_mimic_gif_decode_hibiscus,             //
-    test_mimic_gif_decode_muybridge,            //
-    test_mimic_gif_decode_pjw_thumbnail,        //
+    test_mimic_gif_decode_animated_small,            //
+    test_mimic_gif_decode_bricks_dither,             //
+    test_mimic_gif_decode_bricks_dither_interlaced,  //
+    test_mimic_gif_decode_bricks_gray,               //
+    test_mimic_gif_decode_bricks_nodither,           //
+    test_mimic_gif_decode_gifplayer_muybridge,       //
+    test_mimic_gif_decode_harvesters,                //
+    test_mimic_gif_decode_hat,                       //
+    test_mimic_gif_decode_hibiscus,                  //
+    test_mimic_gif_decode_muybridge,                 //
+    test_mimic_gif_decode_pjw_thumbnail,             //
 
 #endif  // WUFFS_MIMIC
 
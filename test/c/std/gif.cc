@@ -0,0 +1,248 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program checks the C++ wrappers in the generated gif header. It is
+typically run indirectly, by the "wuffs test" command, which links it against
+the C implementations of the gen/h/std headers that it includes.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  cxx=${cc/clang/clang++}; cxx=${cxx/gcc/g++}
+  for pkg in lzw gif; do
+    $cc -std=c99 -Wall -Werror -c ../../../gen/c/std/$pkg.c
+  done
+  $cxx -std=c++11 -Wall -Werror gif.cc lzw.o gif.o && ./a.out
+  rm -f a.out *.o
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+*/
+
+#include <stdio.h>
+#include <string.h>
+
+#include <string>
+#include <vector>
+
+#include "../../../gen/h/std/gif.h"
+#include "../../../gen/h/std/lzw.h"
+
+#if defined(__clang__)
+const char* cxx = "clang++";
+#elif defined(__GNUC__)
+const char* cxx = "g++";
+#else
+const char* cxx = "c++";
+#endif
+
+std::string focus;
+std::string fail_msg;
+
+static bool read_file(std::vector<uint8_t>* dst, const char* path) {
+  FILE* f = fopen(path, "rb");
+  if (!f) {
+    fail_msg = std::string("could not open ") + path;
+    return false;
+  }
+  uint8_t buf[4096];
+  while (true) {
+    size_t n = fread(buf, 1, sizeof buf, f);
+    dst->insert(dst->end(), buf, buf + n);
+    if (n < sizeof buf) {
+      break;
+    }
+  }
+  bool ok = !ferror(f);
+  fclose(f);
+  if (!ok) {
+    fail_msg = std::string("could not read ") + path;
+  }
+  return ok;
+}
+
+// decode_config decodes src's image configuration, feeding the decoder at most
+// rlimit bytes at a time, to exercise the short read suspensions.
+static wuffs::gif::status decode_config(wuffs::gif::decoder* dec,
+                                        wuffs_base__image_config* ic,
+                                        wuffs::base::io_buffer* src,
+                                        size_t rlimit) {
+  size_t n = src->wi;
+  src->wi = 0;
+  src->closed = false;
+  while (true) {
+    src->wi = (n - src->wi) > rlimit ? (src->wi + rlimit) : n;
+    src->closed = src->wi == n;
+    wuffs::gif::status s = dec->decode_config(ic, src->reader());
+    if ((s != wuffs::gif::suspension_short_read) || src->closed) {
+      src->wi = n;
+      src->closed = true;
+      return s;
+    }
+  }
+}
+
+// ---------------- GIF Tests
+
+static void test_wuffs_gif_cplusplus_decode_bricks_dither() {
+  std::vector<uint8_t> src;
+  std::vector<uint8_t> want_palette;
+  std::vector<uint8_t> want_indexes;
+  if (!read_file(&src, "../../data/bricks-dither.gif") ||
+      !read_file(&want_palette, "../../data/bricks-dither.palette") ||
+      !read_file(&want_indexes, "../../data/bricks-dither.indexes")) {
+    return;
+  }
+
+  for (size_t rlimit : {size_t(13), src.size()}) {
+    wuffs::gif::decoder dec;
+    wuffs::base::io_buffer src_buf(src, true, true);
+    wuffs_base__image_config ic = {};
+    wuffs::gif::status s = decode_config(&dec, &ic, &src_buf, rlimit);
+    if (!s.is_ok()) {
+      fail_msg = std::string("decode_config: ") + s.message();
+      return;
+    }
+    if ((wuffs_base__image_config__width(&ic) != 160) ||
+        (wuffs_base__image_config__height(&ic) != 120)) {
+      fail_msg = "decode_config: bad width or height";
+      return;
+    }
+
+    std::vector<uint8_t> pixbuf(wuffs_base__image_config__pixbuf_size(&ic));
+    wuffs_base__image_buffer ib = {};
+    wuffs_base__image_buffer__set_from_slice(
+        &ib, ic, wuffs_base__slice_u8{pixbuf.data(), pixbuf.size()});
+    s = dec.decode_frame(&ib, src_buf.reader());
+    if (!s.is_ok()) {
+      fail_msg = std::string("decode_frame: ") + s.message();
+      return;
+    }
+
+    wuffs_base__slice_u8 palette = wuffs_base__image_buffer__palette(&ib);
+    if (std::vector<uint8_t>(palette.ptr, palette.ptr + palette.len) !=
+        want_palette) {
+      fail_msg = "decode_frame: got and want palettes differ";
+      return;
+    }
+    std::vector<uint8_t> got_indexes;
+    wuffs_base__table_u8 tab = wuffs_base__image_buffer__plane(&ib, 0);
+    for (size_t y = 0; y < tab.height; y++) {
+      uint8_t* row = tab.ptr + (y * tab.stride);
+      got_indexes.insert(got_indexes.end(), row, row + tab.width);
+    }
+    if (got_indexes != want_indexes) {
+      fail_msg = "decode_frame: got and want indexes differ";
+      return;
+    }
+
+    s = dec.decode_frame(&ib, src_buf.reader());
+    if (s != wuffs::gif::suspension_end_of_data) {
+      fail_msg = std::string("final decode_frame: got \"") + s.message() +
+                 "\", want \"" + wuffs::gif::suspension_end_of_data.message() +
+                 "\"";
+      return;
+    }
+  }
+}
+
+static void test_wuffs_gif_cplusplus_decode_input_is_a_png() {
+  std::vector<uint8_t> src;
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return;
+  }
+
+  wuffs::gif::decoder dec;
+  wuffs::base::io_buffer src_buf(src, true, true);
+  wuffs_base__image_config ic = {};
+  wuffs::gif::status s = dec.decode_config(&ic, src_buf.reader());
+  if (s != wuffs::gif::error_bad_header) {
+    fail_msg = std::string("got \"") + s.message() + "\", want \"" +
+               wuffs::gif::error_bad_header.message() + "\"";
+  }
+}
+
+static void test_wuffs_gif_cplusplus_decode_frame_before_config() {
+  std::vector<uint8_t> src;
+  if (!read_file(&src, "../../data/bricks-dither.gif")) {
+    return;
+  }
+
+  wuffs::gif::decoder dec;
+  wuffs::base::io_buffer src_buf(src, true, true);
+  wuffs_base__image_buffer ib = {};
+  wuffs::gif::status s = dec.decode_frame(&ib, src_buf.reader());
+  if (s != wuffs::gif::error_invalid_call_sequence) {
+    fail_msg = std::string("got \"") + s.message() + "\", want \"" +
+               wuffs::gif::error_invalid_call_sequence.message() + "\"";
+  }
+}
+
+// ---------------- Manifest
+
+struct {
+  const char* name;
+  void (*func)();
+} tests[] = {
+    {"test_wuffs_gif_cplusplus_decode_bricks_dither",
+     test_wuffs_gif_cplusplus_decode_bricks_dither},
+    {"test_wuffs_gif_cplusplus_decode_frame_before_config",
+     test_wuffs_gif_cplusplus_decode_frame_before_config},
+    {"test_wuffs_gif_cplusplus_decode_input_is_a_png",
+     test_wuffs_gif_cplusplus_decode_input_is_a_png},
+};
+
+int main(int argc, char** argv) {
+  for (int i = 1; i < argc; i++) {
+    if (!strncmp(argv[i], "-focus=", 7)) {
+      focus = argv[i] + 7;
+    } else {
+      fprintf(stderr, "unknown flag \"%s\"\n", argv[i]);
+      return 1;
+    }
+  }
+
+  int tests_run = 0;
+  for (const auto& t : tests) {
+    // As for the C test programs, the focus is a comma-separated list of name
+    // prefixes, with or without the "test_".
+    std::string name(t.name);
+    std::string unprefixed = name.substr(5);
+    bool in_focus = focus.empty();
+    for (size_t i = 0; !in_focus && (i <= focus.size());) {
+      size_t j = focus.find(',', i);
+      if (j == std::string::npos) {
+        j = focus.size();
+      }
+      std::string f = focus.substr(i, j - i);
+      in_focus = !f.empty() && (!name.compare(0, f.size(), f) ||
+                                !unprefixed.compare(0, f.size(), f));
+      i = j + 1;
+    }
+    if (!in_focus) {
+      continue;
+    }
+
+    t.func();
+    if (!fail_msg.empty()) {
+      printf("%-16s%-8sFAIL %s: %s\n", "std/gif", cxx, t.name,
+             fail_msg.c_str());
+      return 1;
+    }
+    tests_run++;
+  }
+  printf("%-16s%-8sPASS (%d tests)\n", "std/gif", cxx, tests_run);
+  return 0;
+}
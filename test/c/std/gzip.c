@@ -186,6 +186,458 @@ void test_wuffs_gzip_decode_pi() {
   do_test_io_buffers(wuffs_gzip_decode, &gzip_pi_gt, 0, 0);
 }
 
+// ---------------- Gzip Header Tests
+
+// gzip_header_src is an empty gzip member whose header has FEXTRA, FNAME,
+// FCOMMENT and FHCRC fields. The name and comment are ISO 8859-1 encoded:
+// "caf\xE9.txt" and "\xA9 2018 \xC0 la carte".
+uint8_t gzip_header_src[] = {
+    0x1F, 0x8B, 0x08, 0x1E, 0xD2, 0x02, 0x96, 0x49, 0x00, 0x03, 0x02, 0x00,
+    0xAB, 0xCD, 0x63, 0x61, 0x66, 0xE9, 0x2E, 0x74, 0x78, 0x74, 0x00, 0xA9,
+    0x20, 0x32, 0x30, 0x31, 0x38, 0x20, 0xC0, 0x20, 0x6C, 0x61, 0x20, 0x63,
+    0x61, 0x72, 0x74, 0x65, 0x00, 0x0D, 0x61, 0x03, 0x00, 0x00, 0x00, 0x00,
+    0x00, 0x00, 0x00, 0x00, 0x00,
+};
+
+bool check_slice_u8(const char* prefix,
+                    wuffs_base__slice_u8 got,
+                    const char* want) {
+  size_t n = strlen(want);
+  if ((got.len != n) || (n && memcmp(got.ptr, want, n))) {
+    FAIL("%s: got \"%.*s\", want \"%s\"", prefix, (int)(got.len), got.ptr,
+         want);
+    return false;
+  }
+  return true;
+}
+
+bool do_test_wuffs_gzip_header(wuffs_base__io_buffer* src,
+                               wuffs_gzip__status want_status,
+                               uint32_t want_mtime,
+                               uint8_t want_os,
+                               const char* want_file_name,
+                               const char* want_comment) {
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+
+  // Decode the src data in one go and then in many small chunks.
+  int rlimit;
+  for (rlimit = 0; rlimit < 2; rlimit++) {
+    wuffs_gzip__decoder dec = ((wuffs_gzip__decoder){});
+    wuffs_gzip__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+    got.wi = 0;
+    src->ri = 0;
+
+    wuffs_gzip__status status;
+    while (true) {
+      wuffs_base__io_writer got_writer = wuffs_base__io_buffer__writer(&got);
+      wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+      if (rlimit) {
+        set_reader_limit(&src_reader, 3);
+      }
+      status = wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+      if (status != WUFFS_GZIP__SUSPENSION_SHORT_READ) {
+        break;
+      }
+    }
+    if (status != want_status) {
+      FAIL("rlimit=%d: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", rlimit,
+           status, wuffs_gzip__status__string(status), want_status,
+           wuffs_gzip__status__string(want_status));
+      return false;
+    }
+    if (status) {
+      continue;
+    }
+
+    uint32_t mtime = wuffs_gzip__decoder__modification_time(&dec);
+    if (mtime != want_mtime) {
+      FAIL("rlimit=%d: mtime: got 0x%08" PRIX32 ", want 0x%08" PRIX32, rlimit,
+           mtime, want_mtime);
+      return false;
+    }
+    uint8_t os = wuffs_gzip__decoder__operating_system(&dec);
+    if (os != want_os) {
+      FAIL("rlimit=%d: os: got %d, want %d", rlimit, os, want_os);
+      return false;
+    }
+    if (!check_slice_u8("file_name", wuffs_gzip__decoder__file_name(&dec),
+                        want_file_name) ||
+        !check_slice_u8("comment", wuffs_gzip__decoder__comment(&dec),
+                        want_comment)) {
+      return false;
+    }
+  }
+  return true;
+}
+
+void test_wuffs_gzip_header_fields() {
+  CHECK_FOCUS(__func__);
+  wuffs_base__io_buffer src = ((wuffs_base__io_buffer){
+      .ptr = gzip_header_src,
+      .len = sizeof gzip_header_src,
+      .wi = sizeof gzip_header_src,
+      .closed = true,
+  });
+  do_test_wuffs_gzip_header(&src, WUFFS_GZIP__STATUS_OK, 1234567890, 3,
+                            "caf\xC3\xA9.txt",
+                            "\xC2\xA9 2018 \xC3\x80 la carte");
+}
+
+void test_wuffs_gzip_header_fields_bad_hcrc() {
+  CHECK_FOCUS(__func__);
+  // Corrupt the MTIME field, which is covered by the FHCRC checksum.
+  uint8_t data[sizeof gzip_header_src];
+  memcpy(data, gzip_header_src, sizeof gzip_header_src);
+  data[4] ^= 1;
+  wuffs_base__io_buffer src = ((wuffs_base__io_buffer){
+      .ptr = data,
+      .len = sizeof data,
+      .wi = sizeof data,
+      .closed = true,
+  });
+  do_test_wuffs_gzip_header(&src, WUFFS_GZIP__ERROR_BAD_HEADER_CHECKSUM, 0, 0,
+                            "", "");
+}
+
+void test_wuffs_gzip_header_fields_midsummer() {
+  CHECK_FOCUS(__func__);
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, gzip_midsummer_gt.src_filename)) {
+    return;
+  }
+  do_test_wuffs_gzip_header(&src, WUFFS_GZIP__STATUS_OK, 0x595DD7F7, 3,
+                            "midsummer.txt", "");
+}
+
+void test_wuffs_gzip_header_fields_truncated_name() {
+  CHECK_FOCUS(__func__);
+  // The FNAME field is 300 bytes of ISO 8859-1, each 0xE9, which is two bytes
+  // (0xC3 0xA9) in UTF-8. That is truncated to 127 characters: 254 bytes.
+  uint8_t data[400];
+  size_t n = 0;
+  memcpy(data + n, "\x1F\x8B\x08\x08\x00\x00\x00\x00\x00\xFF", 10);
+  n += 10;
+  memset(data + n, 0xE9, 300);
+  n += 300;
+  memcpy(data + n, "\x00\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00", 11);
+  n += 11;
+  wuffs_base__io_buffer src = ((wuffs_base__io_buffer){
+      .ptr = data,
+      .len = n,
+      .wi = n,
+      .closed = true,
+  });
+
+  char want[255];
+  int i;
+  for (i = 0; i < 127; i++) {
+    want[2 * i + 0] = '\xC3';
+    want[2 * i + 1] = '\xA9';
+  }
+  want[254] = 0;
+  do_test_wuffs_gzip_header(&src, WUFFS_GZIP__STATUS_OK, 0, 0xFF, want, "");
+}
+
+// ---------------- Gzip Multi-Member Tests
+
+bool do_test_wuffs_gzip_multi_member(bool multi_member, uint64_t rlimit) {
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&want, gzip_midsummer_gt.want_filename) ||
+      !read_file(&src, gzip_midsummer_gt.src_filename)) {
+    return false;
+  }
+
+  // Make the src data two concatenated copies of midsummer.txt.gz. The want
+  // data is, if multi_member, two copies of midsummer.txt.
+  size_t src_n = src.wi;
+  size_t want_n = want.wi;
+  if ((2 * src_n > src.len) || (2 * want_n > want.len)) {
+    FAIL("buffers were too short");
+    return false;
+  }
+  memcpy(src.ptr + src_n, src.ptr, src_n);
+  src.wi = 2 * src_n;
+  if (multi_member) {
+    memcpy(want.ptr + want_n, want.ptr, want_n);
+    want.wi = 2 * want_n;
+  }
+
+  wuffs_gzip__decoder dec = ((wuffs_gzip__decoder){});
+  wuffs_gzip__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_gzip__decoder__set_multi_member(&dec, multi_member);
+  while (true) {
+    wuffs_base__io_writer got_writer = wuffs_base__io_buffer__writer(&got);
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    wuffs_gzip__status status =
+        wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+    if (status == WUFFS_GZIP__STATUS_OK) {
+      break;
+    } else if (status != WUFFS_GZIP__SUSPENSION_SHORT_READ) {
+      FAIL("rlimit=%" PRIu64 ": got %" PRIi32 " (%s)", rlimit, status,
+           wuffs_gzip__status__string(status));
+      return false;
+    }
+  }
+
+  size_t want_ri = multi_member ? (2 * src_n) : src_n;
+  if (src.ri != want_ri) {
+    FAIL("rlimit=%" PRIu64 ": src.ri: got %zu, want %zu", rlimit, src.ri,
+         want_ri);
+    return false;
+  }
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_gzip_multi_member_off() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_multi_member(false, 0);
+}
+
+void test_wuffs_gzip_multi_member_on() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_multi_member(true, 0);
+}
+
+void test_wuffs_gzip_multi_member_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_multi_member(true, 1);
+}
+
+// ---------------- Gzip Saved State Tests
+
+uint8_t global_gzip_state_buffer[WUFFS_GZIP__DECODER__STATE_LENGTH];
+
+void test_wuffs_gzip_save_restore_state() {
+  CHECK_FOCUS(__func__);
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__slice_u8 state = ((wuffs_base__slice_u8){
+      .ptr = global_gzip_state_buffer,
+      .len = WUFFS_GZIP__DECODER__STATE_LENGTH,
+  });
+
+  if (!read_file(&want, gzip_midsummer_gt.want_filename) ||
+      !read_file(&src, gzip_midsummer_gt.src_filename)) {
+    return;
+  }
+
+  // Feed the src data in small chunks. After each suspension, save the state
+  // and restore it into the other of two decoders, which carries on.
+  wuffs_gzip__decoder decs[2];
+  int i;
+  for (i = 0; i < 2; i++) {
+    decs[i] = ((wuffs_gzip__decoder){});
+    wuffs_gzip__decoder__check_wuffs_version(&decs[i], sizeof decs[i],
+                                             WUFFS_VERSION);
+  }
+
+  int num_restores = 0;
+  while (true) {
+    wuffs_gzip__decoder* dec = &decs[num_restores & 1];
+    wuffs_base__io_writer got_writer = wuffs_base__io_buffer__writer(&got);
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+    set_reader_limit(&src_reader, 47);
+
+    wuffs_gzip__status s =
+        wuffs_gzip__decoder__decode(dec, got_writer, src_reader);
+    if (s == WUFFS_GZIP__STATUS_OK) {
+      break;
+    } else if (s != WUFFS_GZIP__SUSPENSION_SHORT_READ) {
+      FAIL("decode: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+      return;
+    }
+
+    s = wuffs_gzip__decoder__save_state(dec, state);
+    if (s) {
+      FAIL("save_state: got %" PRIi32 " (%s)", s,
+           wuffs_gzip__status__string(s));
+      return;
+    }
+    num_restores++;
+    s = wuffs_gzip__decoder__restore_state(&decs[num_restores & 1], state);
+    if (s) {
+      FAIL("restore_state: got %" PRIi32 " (%s)", s,
+           wuffs_gzip__status__string(s));
+      return;
+    }
+  }
+
+  if (num_restores < 10) {
+    FAIL("num_restores: got %d, want >= 10", num_restores);
+    return;
+  }
+  io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_gzip_restore_bad_state() {
+  CHECK_FOCUS(__func__);
+  wuffs_base__slice_u8 state = ((wuffs_base__slice_u8){
+      .ptr = global_gzip_state_buffer,
+      .len = WUFFS_GZIP__DECODER__STATE_LENGTH,
+  });
+
+  wuffs_gzip__decoder dec = ((wuffs_gzip__decoder){});
+  wuffs_gzip__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_gzip__status s = wuffs_gzip__decoder__save_state(&dec, state);
+  if (s) {
+    FAIL("save_state: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+    return;
+  }
+
+  // A truncated or corrupted saved state is rejected, leaving dec unchanged.
+  wuffs_base__slice_u8 truncated = state;
+  truncated.len--;
+  s = wuffs_gzip__decoder__restore_state(&dec, truncated);
+  if (s != WUFFS_GZIP__ERROR_BAD_ARGUMENT) {
+    FAIL("truncated: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+    return;
+  }
+  state.ptr[state.len - 1] ^= 1;
+  s = wuffs_gzip__decoder__restore_state(&dec, state);
+  if (s != WUFFS_GZIP__ERROR_BAD_ARGUMENT) {
+    FAIL("corrupted: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+    return;
+  }
+  state.ptr[state.len - 1] ^= 1;
+  s = wuffs_gzip__decoder__restore_state(&dec, state);
+  if (s) {
+    FAIL("restored: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+    return;
+  }
+
+  // A saved state with a valid checksum but an out of range value, a bool
+  // f_ignore_checksum of 2, is rejected, leaving dec with a sticky error.
+  size_t i = WUFFS_BASE__SAVED_STATE__HEADER_LENGTH + 4 +
+             WUFFS_DEFLATE__DECODER__STATE_LENGTH +
+             WUFFS_CRC32__IEEE_HASHER__STATE_LENGTH;
+  if (state.ptr[i] != 0) {
+    FAIL("f_ignore_checksum: got %d, want 0", state.ptr[i]);
+    return;
+  }
+  state.ptr[i] = 2;
+  wuffs_base__store_u32le(
+      state.ptr + 12, wuffs_base__saved_state__checksum(
+                          state.ptr + WUFFS_BASE__SAVED_STATE__HEADER_LENGTH,
+                          state.len - WUFFS_BASE__SAVED_STATE__HEADER_LENGTH));
+  s = wuffs_gzip__decoder__restore_state(&dec, state);
+  if (s != WUFFS_GZIP__ERROR_BAD_ARGUMENT) {
+    FAIL("out of range: got %" PRIi32 " (%s)", s,
+         wuffs_gzip__status__string(s));
+    return;
+  }
+  s = wuffs_gzip__decoder__decode(&dec, ((wuffs_base__io_writer){}),
+                                  ((wuffs_base__io_reader){}));
+  if (s != WUFFS_GZIP__ERROR_BAD_ARGUMENT) {
+    FAIL("decode: got %" PRIi32 " (%s)", s, wuffs_gzip__status__string(s));
+    return;
+  }
+}
+
+// ---------------- Gzip Encoder Tests
+
+const char* wuffs_gzip_encode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src,
+                              uint32_t level,
+                              uint64_t wlimit,
+                              uint64_t rlimit) {
+  wuffs_gzip__encoder enc = ((wuffs_gzip__encoder){});
+  wuffs_gzip__encoder__check_wuffs_version(&enc, sizeof enc, WUFFS_VERSION);
+  wuffs_gzip__encoder__set_level(&enc, level);
+  while (true) {
+    wuffs_base__io_writer dst_writer = wuffs_base__io_buffer__writer(dst);
+    if (wlimit) {
+      set_writer_limit(&dst_writer, wlimit);
+    }
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+
+    wuffs_gzip__status s =
+        wuffs_gzip__encoder__encode(&enc, dst_writer, src_reader);
+
+    if (s == WUFFS_GZIP__STATUS_OK) {
+      return NULL;
+    }
+    if ((wlimit && (s == WUFFS_GZIP__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (s == WUFFS_GZIP__SUSPENSION_SHORT_READ))) {
+      continue;
+    }
+    return wuffs_gzip__status__string(s);
+  }
+}
+
+// do_test_gzip_round_trip encodes the golden test's want file and then
+// decodes the result with decode_func, which should give back the original.
+void do_test_gzip_round_trip(
+    const char* (*decode_func)(wuffs_base__io_buffer*,
+                               wuffs_base__io_buffer*,
+                               uint64_t,
+                               uint64_t),
+    golden_test* gt,
+    uint32_t level,
+    uint64_t wlimit,
+    uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&want, gt->want_filename)) {
+    return;
+  }
+  const char* status = wuffs_gzip_encode(&src, &want, level, wlimit, rlimit);
+  if (status) {
+    FAIL("level=%" PRIu32 ": encode: %s", level, status);
+    return;
+  }
+  src.closed = true;
+  want.ri = 0;
+
+  status = decode_func(&got, &src, 0, 0);
+  if (status) {
+    FAIL("level=%" PRIu32 ": decode: %s", level, status);
+    return;
+  }
+  char prefix[64];
+  snprintf(prefix, 64, "level=%" PRIu32 ": ", level);
+  io_buffers_equal(prefix, &got, &want);
+}
+
+void test_wuffs_gzip_encode_levels() {
+  CHECK_FOCUS(__func__);
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    do_test_gzip_round_trip(wuffs_gzip_decode, &gzip_midsummer_gt, level, 0,
+                            0);
+    if (fail_msg[0]) {
+      return;
+    }
+  }
+}
+
+void test_wuffs_gzip_encode_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_gzip_round_trip(wuffs_gzip_decode, &gzip_pi_gt, 6, 59, 61);
+}
+
   // ---------------- Mimic Tests
 
 #ifdef WUFFS_MIMIC
@@ -200,6 +652,17 @@ void test_mimic_gzip_decode_pi() {
   do_test_io_buffers(mimic_gzip_decode, &gzip_pi_gt, 0, 0);
 }
 
+void test_mimic_gzip_decode_wuffs_encoded() {
+  CHECK_FOCUS(__func__);
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    do_test_gzip_round_trip(mimic_gzip_decode, &gzip_pi_gt, level, 0, 0);
+    if (fail_msg[0]) {
+      return;
+    }
+  }
+}
+
 #endif  // WUFFS_MIMIC
 
 // ---------------- Gzip Benches
@@ -238,17 +701,29 @@ void bench_mimic_gzip_decode_100k() {
 // The empty comments forces clang-format to place one element per line.
 proc tests[] = {
 
-    test_wuffs_gzip_checksum_ignore,       //
-    test_wuffs_gzip_checksum_verify_bad1,  //
-    test_wuffs_gzip_checksum_verify_bad7,  //
-    test_wuffs_gzip_checksum_verify_good,  //
-    test_wuffs_gzip_decode_midsummer,      //
-    test_wuffs_gzip_decode_pi,             //
+    test_wuffs_gzip_checksum_ignore,                 //
+    test_wuffs_gzip_checksum_verify_bad1,            //
+    test_wuffs_gzip_checksum_verify_bad7,            //
+    test_wuffs_gzip_checksum_verify_good,            //
+    test_wuffs_gzip_decode_midsummer,                //
+    test_wuffs_gzip_decode_pi,                       //
+    test_wuffs_gzip_encode_levels,                   //
+    test_wuffs_gzip_encode_many_small_writes_reads,  //
+    test_wuffs_gzip_header_fields,                   //
+    test_wuffs_gzip_header_fields_bad_hcrc,          //
+    test_wuffs_gzip_header_fields_midsummer,         //
+    test_wuffs_gzip_header_fields_truncated_name,    //
+    test_wuffs_gzip_multi_member_off,                //
+    test_wuffs_gzip_multi_member_on,                 //
+    test_wuffs_gzip_multi_member_small_reads,        //
+    test_wuffs_gzip_restore_bad_state,               //
+    test_wuffs_gzip_save_restore_state,              //
 
 #ifdef WUFFS_MIMIC
 
-    test_mimic_gzip_decode_midsummer,  //
-    test_mimic_gzip_decode_pi,         //
+    test_mimic_gzip_decode_midsummer,      //
+    test_mimic_gzip_decode_pi,             //
+    test_mimic_gzip_decode_wuffs_encoded,  //
 
 #endif  // WUFFS_MIMIC
 
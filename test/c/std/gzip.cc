@@ -0,0 +1,222 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program checks the C++ wrappers in the generated gzip header. It is
+typically run indirectly, by the "wuffs test" command, which links it against
+the C implementations of the gen/h/std headers that it includes.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  cxx=${cc/clang/clang++}; cxx=${cxx/gcc/g++}
+  for pkg in crc32 deflate gzip; do
+    $cc -std=c99 -Wall -Werror -c ../../../gen/c/std/$pkg.c
+  done
+  $cxx -std=c++11 -Wall -Werror gzip.cc crc32.o deflate.o gzip.o && ./a.out
+  rm -f a.out *.o
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+*/
+
+#include <stdio.h>
+#include <string.h>
+
+#include <string>
+#include <vector>
+
+#include "../../../gen/h/std/crc32.h"
+#include "../../../gen/h/std/deflate.h"
+#include "../../../gen/h/std/gzip.h"
+
+#if defined(__clang__)
+const char* cxx = "clang++";
+#elif defined(__GNUC__)
+const char* cxx = "g++";
+#else
+const char* cxx = "c++";
+#endif
+
+std::string focus;
+std::string fail_msg;
+
+static bool read_file(std::vector<uint8_t>* dst, const char* path) {
+  FILE* f = fopen(path, "rb");
+  if (!f) {
+    fail_msg = std::string("could not open ") + path;
+    return false;
+  }
+  uint8_t buf[4096];
+  while (true) {
+    size_t n = fread(buf, 1, sizeof buf, f);
+    dst->insert(dst->end(), buf, buf + n);
+    if (n < sizeof buf) {
+      break;
+    }
+  }
+  bool ok = !ferror(f);
+  fclose(f);
+  if (!ok) {
+    fail_msg = std::string("could not read ") + path;
+  }
+  return ok;
+}
+
+// decode decodes src (using a small dst buffer, to exercise the short write
+// suspensions) and appends the result to got.
+static wuffs::gzip::status decode(wuffs::gzip::decoder* dec,
+                                  std::vector<uint8_t>* got,
+                                  std::vector<uint8_t>* src) {
+  wuffs::base::io_buffer src_buf(*src, true, true);
+  while (true) {
+    uint8_t dst_array[100];
+    wuffs::base::io_buffer dst_buf(dst_array);
+    wuffs::gzip::status s = dec->decode(dst_buf.writer(), src_buf.reader());
+    got->insert(got->end(), dst_buf.reader_data(),
+                dst_buf.reader_data() + dst_buf.reader_size());
+    if (s != wuffs::gzip::suspension_short_write) {
+      return s;
+    }
+  }
+}
+
+// ---------------- Gzip Tests
+
+static void test_wuffs_gzip_cplusplus_decode_midsummer() {
+  std::vector<uint8_t> src;
+  std::vector<uint8_t> want;
+  if (!read_file(&src, "../../data/midsummer.txt.gz") ||
+      !read_file(&want, "../../data/midsummer.txt")) {
+    return;
+  }
+
+  wuffs::gzip::decoder dec;
+  std::vector<uint8_t> got;
+  wuffs::gzip::status s = decode(&dec, &got, &src);
+  if (!s.is_ok()) {
+    fail_msg = std::string("decode: ") + s.message();
+    return;
+  }
+  if (got != want) {
+    fail_msg = "decode: got and want contents differ";
+  }
+}
+
+static void test_wuffs_gzip_cplusplus_decode_bad_checksum() {
+  std::vector<uint8_t> src;
+  if (!read_file(&src, "../../data/midsummer.txt.gz")) {
+    return;
+  }
+  if (src.size() < 8) {
+    fail_msg = "source file was too short";
+    return;
+  }
+  // Flip a bit in the gzip checksum, which is in the last 8 bytes of the file.
+  src[src.size() - 8] ^= 1;
+
+  for (int ignore_checksum = 0; ignore_checksum < 2; ignore_checksum++) {
+    wuffs::gzip::decoder dec;
+    dec.set_ignore_checksum(ignore_checksum);
+    std::vector<uint8_t> got;
+    wuffs::gzip::status s = decode(&dec, &got, &src);
+    wuffs::gzip::status want = ignore_checksum
+                                   ? wuffs::gzip::status_ok
+                                   : wuffs::gzip::error_bad_checksum;
+    if (s != want) {
+      fail_msg = std::string("ignore_checksum=") +
+                 (ignore_checksum ? "true" : "false") + ": got \"" +
+                 s.message() + "\", want \"" + want.message() + "\"";
+      return;
+    }
+  }
+}
+
+static void test_wuffs_gzip_cplusplus_status() {
+  if (!wuffs::gzip::status().is_ok()) {
+    fail_msg = "default status: is_ok was false";
+    return;
+  }
+  wuffs::gzip::status s = wuffs::gzip::error_bad_header;
+  if (!s.is_error() || s.is_suspension() ||
+      (s.repr() != WUFFS_GZIP__ERROR_BAD_HEADER)) {
+    fail_msg = "error_bad_header: bad is_error, is_suspension or repr";
+    return;
+  }
+  if (strcmp(s.message(), "gzip: bad header")) {
+    fail_msg = std::string("error_bad_header: bad message \"") + s.message() +
+               "\"";
+    return;
+  }
+  if (!wuffs::gzip::suspension_short_read.is_suspension()) {
+    fail_msg = "suspension_short_read: is_suspension was false";
+    return;
+  }
+}
+
+// ---------------- Manifest
+
+struct {
+  const char* name;
+  void (*func)();
+} tests[] = {
+    {"test_wuffs_gzip_cplusplus_decode_bad_checksum",
+     test_wuffs_gzip_cplusplus_decode_bad_checksum},
+    {"test_wuffs_gzip_cplusplus_decode_midsummer",
+     test_wuffs_gzip_cplusplus_decode_midsummer},
+    {"test_wuffs_gzip_cplusplus_status", test_wuffs_gzip_cplusplus_status},
+};
+
+int main(int argc, char** argv) {
+  for (int i = 1; i < argc; i++) {
+    if (!strncmp(argv[i], "-focus=", 7)) {
+      focus = argv[i] + 7;
+    } else {
+      fprintf(stderr, "unknown flag \"%s\"\n", argv[i]);
+      return 1;
+    }
+  }
+
+  int tests_run = 0;
+  for (const auto& t : tests) {
+    // As for the C test programs, the focus is a comma-separated list of name
+    // prefixes, with or without the "test_".
+    std::string name(t.name);
+    std::string unprefixed = name.substr(5);
+    bool in_focus = focus.empty();
+    for (size_t i = 0; !in_focus && (i <= focus.size());) {
+      size_t j = focus.find(',', i);
+      if (j == std::string::npos) {
+        j = focus.size();
+      }
+      std::string f = focus.substr(i, j - i);
+      in_focus = !f.empty() && (!name.compare(0, f.size(), f) ||
+                                !unprefixed.compare(0, f.size(), f));
+      i = j + 1;
+    }
+    if (!in_focus) {
+      continue;
+    }
+
+    t.func();
+    if (!fail_msg.empty()) {
+      printf("%-16s%-8sFAIL %s: %s\n", "std/gzip", cxx, t.name,
+             fail_msg.c_str());
+      return 1;
+    }
+    tests_run++;
+  }
+  printf("%-16s%-8sPASS (%d tests)\n", "std/gzip", cxx, tests_run);
+  return 0;
+}
@@ -0,0 +1,329 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror ico.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/adler32.c"
+#include "../../../gen/c/std/bmp.c"
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/ico.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../testlib/testlib.c"
+
+// ---------------- ICO Tests
+
+// wuffs_ico_decode_with_options decodes the ICO or CUR image in src into dst,
+// as BGRA_NONPREMUL pixels.
+const char* wuffs_ico_decode_with_options(wuffs_base__io_buffer* dst,
+                                          wuffs_base__io_buffer* src,
+                                          uint64_t rlimit) {
+  wuffs_ico__decoder dec = ((wuffs_ico__decoder){});
+  wuffs_ico__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_ico__status s = WUFFS_ICO__STATUS_OK;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_ico__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_ICO__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_ico__status__string(s);
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    return "unexpected pixel format";
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_ico__decoder__decode_frame(
+        &dec, &ib, src_reader,
+        ((wuffs_base__slice_u8){
+            .ptr = global_work_buffer,
+            .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+        }));
+    if (s != WUFFS_ICO__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_ico__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+// wuffs_png_decode decodes the PNG image in src into dst, as BGRA_NONPREMUL
+// pixels.
+const char* wuffs_png_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  s = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+// do_test_wuffs_ico_decode_equal decodes the got_filename ICO or CUR image,
+// with a read limit, and the want_filename PNG image and checks that they have
+// the same pixels.
+bool do_test_wuffs_ico_decode_equal(const char* got_filename,
+                                    const char* want_filename,
+                                    uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, got_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_ico_decode_with_options(&got, &src, rlimit);
+  if (got_msg) {
+    FAIL("%s: %s", got_filename, got_msg);
+    return false;
+  }
+
+  src = ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, want_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = wuffs_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", want_filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_ico_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bmpsuite/icon-bmp.ico")) {
+    return;
+  }
+
+  wuffs_ico__decoder dec = ((wuffs_ico__decoder){});
+  wuffs_ico__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_ico__status status = wuffs_ico__decoder__decode_frame(
+      &dec, &ib, src_reader, ((wuffs_base__slice_u8){}));
+  if (status != WUFFS_ICO__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_ico__status__string(status),
+         WUFFS_ICO__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_ico__status__string(WUFFS_ICO__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_ico_decode_32bpp() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_ico_decode_equal("../../data/bmpsuite/icon-32bpp.ico",
+                                 "../../data/bmpsuite/icon-32bpp.png", 0);
+}
+
+void test_wuffs_ico_decode_bmp_sub_image() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_ico_decode_equal("../../data/bmpsuite/icon-bmp.ico",
+                                 "../../data/bmpsuite/icon-bmp.png", 0);
+}
+
+void test_wuffs_ico_decode_cursor() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bmpsuite/cursor.cur")) {
+    return;
+  }
+
+  wuffs_ico__decoder dec = ((wuffs_ico__decoder){});
+  wuffs_ico__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_ico__status status =
+      wuffs_ico__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_ICO__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_ico__status__string(status));
+    return;
+  }
+  if (!wuffs_ico__decoder__is_cursor(&dec)) {
+    FAIL("is_cursor: got false, want true");
+    return;
+  }
+  if (wuffs_ico__decoder__num_images(&dec) != 2) {
+    FAIL("num_images: got %" PRIu32 ", want 2",
+         wuffs_ico__decoder__num_images(&dec));
+    return;
+  }
+  // The largest sub-image's hotspot is (17, 14).
+  if (wuffs_ico__decoder__hotspot_x(&dec) != 17) {
+    FAIL("hotspot_x: got %" PRIu32 ", want 17",
+         wuffs_ico__decoder__hotspot_x(&dec));
+    return;
+  }
+  if (wuffs_ico__decoder__hotspot_y(&dec) != 14) {
+    FAIL("hotspot_y: got %" PRIu32 ", want 14",
+         wuffs_ico__decoder__hotspot_y(&dec));
+    return;
+  }
+
+  do_test_wuffs_ico_decode_equal("../../data/bmpsuite/cursor.cur",
+                                 "../../data/bmpsuite/cursor.png", 0);
+}
+
+void test_wuffs_ico_decode_input_is_a_bmp() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.bmp")) {
+    return;
+  }
+
+  wuffs_ico__decoder dec = ((wuffs_ico__decoder){});
+  wuffs_ico__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_ico__status status =
+      wuffs_ico__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_ICO__ERROR_BAD_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_ico__status__string(status), WUFFS_ICO__ERROR_BAD_HEADER,
+         wuffs_ico__status__string(WUFFS_ICO__ERROR_BAD_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_ico_decode_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  if (!do_test_wuffs_ico_decode_equal("../../data/bmpsuite/icon-bmp.ico",
+                                      "../../data/bmpsuite/icon-bmp.png", 3)) {
+    return;
+  }
+  do_test_wuffs_ico_decode_equal("../../data/bmpsuite/icon-png.ico",
+                                 "../../data/bmpsuite/icon-png.png", 3);
+}
+
+void test_wuffs_ico_decode_png_sub_image() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_ico_decode_equal("../../data/bmpsuite/icon-png.ico",
+                                 "../../data/bmpsuite/icon-png.png", 0);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_ico_call_sequence,            //
+    test_wuffs_ico_decode_32bpp,             //
+    test_wuffs_ico_decode_bmp_sub_image,     //
+    test_wuffs_ico_decode_cursor,            //
+    test_wuffs_ico_decode_input_is_a_bmp,    //
+    test_wuffs_ico_decode_many_small_reads,  //
+    test_wuffs_ico_decode_png_sub_image,     //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_package_name = "std/ico";
+  return test_main(argc, argv, tests, benches);
+}
@@ -0,0 +1,688 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libjpeg for JPEG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror jpeg.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC -ljpeg
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/jpeg.c"
+#include "../testlib/testlib.c"
+#ifdef WUFFS_MIMIC
+#include "../mimiclib/jpeg.c"
+#endif
+
+// The jpegsuite_filenames are the test/data/jpegsuite files, generated by
+// script/make-jpegsuite.c, other than those that Wuffs does not support.
+const char* jpegsuite_filenames[] = {
+    "baseline-gray",                                                      //
+    "baseline-444",                                                       //
+    "baseline-440",                                                       //
+    "baseline-422",                                                       //
+    "baseline-420",                                                       //
+    "baseline-420-noninterleaved",                                        //
+    "baseline-420-optimized",                                             //
+    "baseline-420-restart",                                               //
+    "progressive-gray",                                                   //
+    "progressive-444",                                                    //
+    "progressive-440",                                                    //
+    "progressive-422",                                                    //
+    "progressive-420",                                                    //
+    "progressive-420-restart",                                            //
+    "progressive-444-tiled",                                              //
+    NULL,
+};
+
+// ---------------- JPEG Tests
+
+// wuffs_jpeg_decode_with_options decodes the JPEG image in src into dst, as
+// planar Y or YUV pixels, one plane after another.
+const char* wuffs_jpeg_decode_with_options(wuffs_base__io_buffer* dst,
+                                           wuffs_base__io_buffer* src,
+                                           uint64_t rlimit) {
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_jpeg__status s = WUFFS_JPEG__STATUS_OK;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_JPEG__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_jpeg__status__string(s);
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  // Pass a work buffer that is exactly as long as it needs to be.
+  uint64_t workbuf_len = wuffs_jpeg__decoder__workbuf_len(&dec);
+  if (workbuf_len > WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer)) {
+    return "work buffer is too small";
+  }
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_jpeg__decoder__decode_frame(
+        &dec, &ib, src_reader,
+        ((wuffs_base__slice_u8){
+            .ptr = global_work_buffer,
+            .len = workbuf_len,
+        }));
+    if (s != WUFFS_JPEG__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_jpeg__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+const char* wuffs_jpeg_decode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src) {
+  return wuffs_jpeg_decode_with_options(dst, src, 0);
+}
+
+// do_test_wuffs_jpeg_decode_config decodes filename's image config and checks
+// its pixel format and subsampling.
+bool do_test_wuffs_jpeg_decode_config(const char* filename,
+                                      wuffs_base__pixel_format want_pixfmt,
+                                      wuffs_base__pixel_subsampling want_pixsub,
+                                      uint32_t want_width,
+                                      uint32_t want_height) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_JPEG__STATUS_OK) {
+    FAIL("%s: decode_config: got %" PRIi32 " (%s)", filename, status,
+         wuffs_jpeg__status__string(status));
+    return false;
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) != want_pixfmt) {
+    FAIL("%s: pixel_format: got 0x%08" PRIX32 ", want 0x%08" PRIX32, filename,
+         wuffs_base__image_config__pixel_format(&ic), want_pixfmt);
+    return false;
+  }
+  if (wuffs_base__image_config__pixel_subsampling(&ic) != want_pixsub) {
+    FAIL("%s: pixel_subsampling: got 0x%08" PRIX32 ", want 0x%08" PRIX32,
+         filename, wuffs_base__image_config__pixel_subsampling(&ic),
+         want_pixsub);
+    return false;
+  }
+  if (wuffs_base__image_config__width(&ic) != want_width) {
+    FAIL("%s: width: got %" PRIu32 ", want %" PRIu32, filename,
+         wuffs_base__image_config__width(&ic), want_width);
+    return false;
+  }
+  if (wuffs_base__image_config__height(&ic) != want_height) {
+    FAIL("%s: height: got %" PRIu32 ", want %" PRIu32, filename,
+         wuffs_base__image_config__height(&ic), want_height);
+    return false;
+  }
+  return true;
+}
+
+// do_test_wuffs_jpeg_decode_equal decodes the got_filename and want_filename
+// JPEG images, the former with a read limit, and checks that they have the
+// same pixels.
+bool do_test_wuffs_jpeg_decode_equal(const char* got_filename,
+                                     const char* want_filename,
+                                     uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, got_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_jpeg_decode_with_options(&got, &src, rlimit);
+  if (got_msg) {
+    FAIL("%s: %s", got_filename, got_msg);
+    return false;
+  }
+
+  src = ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, want_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = wuffs_jpeg_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", want_filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_jpeg_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.jpeg")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_jpeg__status status = wuffs_jpeg__decoder__decode_frame(
+      &dec, &ib, src_reader, ((wuffs_base__slice_u8){}));
+  if (status != WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status),
+         WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_arithmetic_coding() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/jpegsuite/arithmetic-420.jpeg")) {
+    return;
+  }
+
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_jpeg_decode(&got, &src);
+  const char* want_msg = wuffs_jpeg__status__string(
+      WUFFS_JPEG__ERROR_TODO_UNSUPPORTED_ARITHMETIC_CODING);
+  if (got_msg != want_msg) {
+    FAIL("got \"%s\", want \"%s\"", got_msg ? got_msg : "(null)", want_msg);
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_bad_workbuf_length() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/jpegsuite/progressive-444-tiled.jpeg")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_JPEG__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status));
+    return;
+  }
+
+  // progressive-444-tiled.jpeg is 314 × 234 pixels, or 40 × 30 blocks, for
+  // each of its 3 components. Each block's coefficients take 128 bytes.
+  uint64_t workbuf_len = wuffs_jpeg__decoder__workbuf_len(&dec);
+  if (workbuf_len != 40 * 30 * 3 * 128) {
+    FAIL("workbuf_len: got %" PRIu64 ", want %d", workbuf_len,
+         40 * 30 * 3 * 128);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_jpeg__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = workbuf_len - 1,
+      }));
+  if (status != WUFFS_JPEG__ERROR_BAD_WORKBUF_LENGTH) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status),
+         WUFFS_JPEG__ERROR_BAD_WORKBUF_LENGTH,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_BAD_WORKBUF_LENGTH));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_config() {
+  CHECK_FOCUS(__func__);
+  // The jpegsuite images are 157 × 117 pixels: bricks-color.jpeg (160 × 120)
+  // cropped by 3 pixels in each dimension.
+  if (!do_test_wuffs_jpeg_decode_config(
+          "../../data/jpegsuite/baseline-gray.jpeg",
+          WUFFS_BASE__PIXEL_FORMAT__Y, WUFFS_BASE__PIXEL_SUBSAMPLING__NONE, 157,
+          117)) {
+    return;
+  }
+  if (!do_test_wuffs_jpeg_decode_config(
+          "../../data/jpegsuite/baseline-444.jpeg",
+          WUFFS_BASE__PIXEL_FORMAT__YUV, WUFFS_BASE__PIXEL_SUBSAMPLING__444,
+          157, 117)) {
+    return;
+  }
+  if (!do_test_wuffs_jpeg_decode_config(
+          "../../data/jpegsuite/baseline-440.jpeg",
+          WUFFS_BASE__PIXEL_FORMAT__YUV, WUFFS_BASE__PIXEL_SUBSAMPLING__440,
+          157, 117)) {
+    return;
+  }
+  if (!do_test_wuffs_jpeg_decode_config(
+          "../../data/jpegsuite/progressive-422.jpeg",
+          WUFFS_BASE__PIXEL_FORMAT__YUV, WUFFS_BASE__PIXEL_SUBSAMPLING__422,
+          157, 117)) {
+    return;
+  }
+  if (!do_test_wuffs_jpeg_decode_config(
+          "../../data/jpegsuite/progressive-420.jpeg",
+          WUFFS_BASE__PIXEL_FORMAT__YUV, WUFFS_BASE__PIXEL_SUBSAMPLING__420,
+          157, 117)) {
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_input_is_a_jpeg() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.jpeg")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_JPEG__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status));
+    return;
+  }
+  // bricks-color.jpeg is a 160 × 120 image, with 4:2:0 chroma subsampling.
+  // The Y plane is 160 × 120 and the Cb and Cr planes are 80 × 60.
+  uint64_t want_pixbuf_size = (160 * 120) + (2 * 80 * 60);
+  if (wuffs_base__image_config__pixbuf_size(&ic) != want_pixbuf_size) {
+    FAIL("pixbuf_size: got %" PRIu64 ", want %" PRIu64,
+         wuffs_base__image_config__pixbuf_size(&ic), want_pixbuf_size);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_jpeg__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_JPEG__STATUS_OK) {
+    FAIL("decode_frame #0: got %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status));
+    return;
+  }
+  if (src.ri != src.wi) {
+    FAIL("decode_frame returned \"ok\" but src was not exhausted");
+    return;
+  }
+  status = wuffs_jpeg__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_JPEG__SUSPENSION_END_OF_DATA) {
+    FAIL("decode_frame #1: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_jpeg__status__string(status),
+         WUFFS_JPEG__SUSPENSION_END_OF_DATA,
+         wuffs_jpeg__status__string(WUFFS_JPEG__SUSPENSION_END_OF_DATA));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec = ((wuffs_jpeg__decoder){});
+  wuffs_jpeg__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_JPEG__ERROR_BAD_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status), WUFFS_JPEG__ERROR_BAD_HEADER,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_BAD_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode_equal("../../data/bricks-color.jpeg",
+                                  "../../data/bricks-color.jpeg", 11);
+}
+
+void test_wuffs_jpeg_decode_progressive_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode_equal(
+      "../../data/jpegsuite/progressive-420-restart.jpeg",
+      "../../data/jpegsuite/progressive-420-restart.jpeg", 11);
+}
+
+void test_wuffs_jpeg_decode_progressive_tiled() {
+  CHECK_FOCUS(__func__);
+  // This image has 3600 blocks. The decoder's coefficients used to be held in
+  // a fixed size buffer of 0x800 blocks.
+  do_test_wuffs_jpeg_decode_equal(
+      "../../data/jpegsuite/progressive-444-tiled.jpeg",
+      "../../data/jpegsuite/progressive-444-tiled.jpeg", 101);
+}
+
+  // ---------------- Mimic Tests
+
+#ifdef WUFFS_MIMIC
+
+bool do_test_mimic_jpeg_decode(const char* filename) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_jpeg_decode(&got, &src);
+  if (got_msg) {
+    FAIL("%s: %s", filename, got_msg);
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = mimic_jpeg_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_mimic_jpeg_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-color.jpeg");
+}
+
+void test_mimic_jpeg_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-gray.jpeg");
+}
+
+void test_mimic_jpeg_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/harvesters.jpeg");
+}
+
+void test_mimic_jpeg_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hat.jpeg");
+}
+
+void test_mimic_jpeg_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hibiscus.jpeg");
+}
+
+void test_mimic_jpeg_decode_hippopotamus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hippopotamus.jpeg");
+}
+
+void test_mimic_jpeg_decode_jpegsuite() {
+  CHECK_FOCUS(__func__);
+  const char** f;
+  for (f = jpegsuite_filenames; *f; f++) {
+    char filename[64];
+    snprintf(filename, sizeof(filename), "../../data/jpegsuite/%s.jpeg", *f);
+    if (!do_test_mimic_jpeg_decode(filename)) {
+      return;
+    }
+  }
+}
+
+void test_mimic_jpeg_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/pjw-thumbnail.jpeg");
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- JPEG Benches
+
+bool do_bench_jpeg_decode(const char* (*decode_func)(wuffs_base__io_buffer*,
+                                                     wuffs_base__io_buffer*),
+                          const char* filename,
+                          uint64_t iters_unscaled) {
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  uint64_t iters = iters_unscaled * iterscale;
+  for (i = 0; i < iters; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(iters, n_bytes);
+  return true;
+}
+
+void bench_wuffs_jpeg_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/hippopotamus.jpeg",
+                       1000);
+}
+
+void bench_wuffs_jpeg_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/hat.jpeg", 100);
+}
+
+void bench_wuffs_jpeg_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/hibiscus.jpeg", 10);
+}
+
+void bench_wuffs_jpeg_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/harvesters.jpeg", 1);
+}
+
+  // ---------------- Mimic Benches
+
+#ifdef WUFFS_MIMIC
+
+void bench_mimic_jpeg_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/hippopotamus.jpeg",
+                       1000);
+}
+
+void bench_mimic_jpeg_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/hat.jpeg", 100);
+}
+
+void bench_mimic_jpeg_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/hibiscus.jpeg", 10);
+}
+
+void bench_mimic_jpeg_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/harvesters.jpeg", 1);
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_jpeg_call_sequence,                        //
+    test_wuffs_jpeg_decode_arithmetic_coding,             //
+    test_wuffs_jpeg_decode_bad_workbuf_length,            //
+    test_wuffs_jpeg_decode_config,                        //
+    test_wuffs_jpeg_decode_input_is_a_jpeg,               //
+    test_wuffs_jpeg_decode_input_is_a_png,                //
+    test_wuffs_jpeg_decode_many_small_reads,              //
+    test_wuffs_jpeg_decode_progressive_many_small_reads,  //
+    test_wuffs_jpeg_decode_progressive_tiled,             //
+
+#ifdef WUFFS_MIMIC
+
+    test_mimic_jpeg_decode_bricks_color,   //
+    test_mimic_jpeg_decode_bricks_gray,    //
+    test_mimic_jpeg_decode_harvesters,     //
+    test_mimic_jpeg_decode_hat,            //
+    test_mimic_jpeg_decode_hibiscus,       //
+    test_mimic_jpeg_decode_hippopotamus,   //
+    test_mimic_jpeg_decode_jpegsuite,      //
+    test_mimic_jpeg_decode_pjw_thumbnail,  //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_jpeg_decode_1k,     //
+    bench_wuffs_jpeg_decode_10k,    //
+    bench_wuffs_jpeg_decode_100k,   //
+    bench_wuffs_jpeg_decode_1000k,  //
+
+#ifdef WUFFS_MIMIC
+
+    bench_mimic_jpeg_decode_1k,     //
+    bench_mimic_jpeg_decode_10k,    //
+    bench_mimic_jpeg_decode_100k,   //
+    bench_mimic_jpeg_decode_1000k,  //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_package_name = "std/jpeg";
+  return test_main(argc, argv, tests, benches);
+}
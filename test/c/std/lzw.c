@@ -45,12 +45,16 @@ the first "./a.out" with "./a.out -bench". Combine these changes with the
 
 // ---------------- LZW Tests
 
+// do_test_wuffs_lzw_decode decodes GIF flavored LZW, whose first byte is the
+// LZW literal width, or, if tiff_mode is set, TIFF flavored LZW, which has no
+// such byte.
 bool do_test_wuffs_lzw_decode(const char* src_filename,
                               uint64_t src_size,
                               const char* want_filename,
                               uint64_t want_size,
                               uint64_t wlimit,
-                              uint64_t rlimit) {
+                              uint64_t rlimit,
+                              bool tiff_mode) {
   wuffs_base__io_buffer got =
       ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
   wuffs_base__io_buffer want =
@@ -65,13 +69,17 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
     FAIL("src size: got %d, want %d", (int)(src.wi), (int)(src_size));
     return false;
   }
-  // The first byte in that file, the LZW literal width, should be 0x08.
-  uint8_t literal_width = src.ptr[0];
-  if (literal_width != 0x08) {
-    FAIL("LZW literal width: got %d, want %d", (int)(src.ptr[0]), 0x08);
-    return false;
+  // The first byte in a GIF flavored file, the LZW literal width, should be
+  // 0x08.
+  uint8_t literal_width = 0x08;
+  if (!tiff_mode) {
+    literal_width = src.ptr[0];
+    if (literal_width != 0x08) {
+      FAIL("LZW literal width: got %d, want %d", (int)(src.ptr[0]), 0x08);
+      return false;
+    }
+    src.ri++;
   }
-  src.ri++;
 
   if (!read_file(&want, want_filename)) {
     return false;
@@ -84,6 +92,7 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
   wuffs_lzw__decoder dec = ((wuffs_lzw__decoder){});
   wuffs_lzw__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
   wuffs_lzw__decoder__set_literal_width(&dec, literal_width);
+  wuffs_lzw__decoder__set_tiff_mode(&dec, tiff_mode);
   int num_iters = 0;
   while (true) {
     num_iters++;
@@ -151,31 +160,190 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
 void test_wuffs_lzw_decode_many_big_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-gray.indexes.giflzw", 14731,
-                           "../../data/bricks-gray.indexes", 19200, 0, 4096);
+                           "../../data/bricks-gray.indexes", 19200, 0, 4096, false);
 }
 
 void test_wuffs_lzw_decode_many_small_writes_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-gray.indexes.giflzw", 14731,
-                           "../../data/bricks-gray.indexes", 19200, 41, 43);
+                           "../../data/bricks-gray.indexes", 19200, 41, 43, false);
 }
 
 void test_wuffs_lzw_decode_bricks_dither() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-dither.indexes.giflzw", 14923,
-                           "../../data/bricks-dither.indexes", 19200, 0, 0);
+                           "../../data/bricks-dither.indexes", 19200, 0, 0, false);
 }
 
 void test_wuffs_lzw_decode_bricks_nodither() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-nodither.indexes.giflzw", 13382,
-                           "../../data/bricks-nodither.indexes", 19200, 0, 0);
+                           "../../data/bricks-nodither.indexes", 19200, 0, 0, false);
 }
 
 void test_wuffs_lzw_decode_pi() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/pi.txt.giflzw", 50550,
-                           "../../data/pi.txt", 100003, 0, 0);
+                           "../../data/pi.txt", 100003, 0, 0, false);
+}
+
+void test_wuffs_lzw_decode_tiff_pi() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_lzw_decode("../../data/pi.txt.tifflzw", 50542,
+                           "../../data/pi.txt", 100003, 0, 0, true);
+}
+
+void test_wuffs_lzw_decode_tiff_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_lzw_decode("../../data/pi.txt.tifflzw", 50542,
+                           "../../data/pi.txt", 100003, 41, 43, true);
+}
+
+// ---------------- LZW Encoder Tests
+
+const char* wuffs_lzw_encode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src,
+                             uint32_t literal_width,
+                             uint64_t wlimit,
+                             uint64_t rlimit) {
+  wuffs_lzw__encoder enc = ((wuffs_lzw__encoder){});
+  wuffs_lzw__encoder__check_wuffs_version(&enc, sizeof enc, WUFFS_VERSION);
+  wuffs_lzw__encoder__set_literal_width(&enc, literal_width);
+  while (true) {
+    wuffs_base__io_writer dst_writer = wuffs_base__io_buffer__writer(dst);
+    if (wlimit) {
+      set_writer_limit(&dst_writer, wlimit);
+    }
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+
+    wuffs_lzw__status s =
+        wuffs_lzw__encoder__encode(&enc, dst_writer, src_reader);
+
+    if (s == WUFFS_LZW__STATUS_OK) {
+      return NULL;
+    }
+    if ((wlimit && (s == WUFFS_LZW__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (s == WUFFS_LZW__SUSPENSION_SHORT_READ))) {
+      continue;
+    }
+    return wuffs_lzw__status__string(s);
+  }
+}
+
+const char* wuffs_lzw_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src,
+                             uint32_t literal_width) {
+  wuffs_lzw__decoder dec = ((wuffs_lzw__decoder){});
+  wuffs_lzw__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_lzw__decoder__set_literal_width(&dec, literal_width);
+  wuffs_lzw__status s = wuffs_lzw__decoder__decode(
+      &dec, wuffs_base__io_buffer__writer(dst),
+      wuffs_base__io_buffer__reader(src));
+  return s ? wuffs_lzw__status__string(s) : NULL;
+}
+
+// do_test_wuffs_lzw_round_trip encodes the want file, after masking each byte
+// to the literal width, and then decodes the result, which should give back
+// the (masked) original.
+bool do_test_wuffs_lzw_round_trip(const char* want_filename,
+                                  uint32_t literal_width,
+                                  uint64_t wlimit,
+                                  uint64_t rlimit) {
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&want, want_filename)) {
+    return false;
+  }
+  uint8_t mask = (uint8_t)((1 << literal_width) - 1);
+  size_t i;
+  for (i = 0; i < want.wi; i++) {
+    want.ptr[i] &= mask;
+  }
+
+  const char* status =
+      wuffs_lzw_encode(&src, &want, literal_width, wlimit, rlimit);
+  if (status) {
+    FAIL("literal_width=%" PRIu32 ": encode: %s", literal_width, status);
+    return false;
+  }
+  if (want.ri != want.wi) {
+    FAIL("literal_width=%" PRIu32 ": encode: src was not exhausted",
+         literal_width);
+    return false;
+  }
+  src.closed = true;
+  want.ri = 0;
+
+  status = wuffs_lzw_decode(&got, &src, literal_width);
+  if (status) {
+    FAIL("literal_width=%" PRIu32 ": decode: %s", literal_width, status);
+    return false;
+  }
+  char prefix[64];
+  snprintf(prefix, 64, "literal_width=%" PRIu32 ": ", literal_width);
+  return io_buffers_equal(prefix, &got, &want);
+}
+
+void test_wuffs_lzw_encode_empty() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .closed = true});
+
+  const char* status = wuffs_lzw_encode(&dst, &src, 8, 0, 0);
+  if (status) {
+    FAIL("%s", status);
+    return;
+  }
+  // A clear code (0x100) and an end code (0x101), each 9 bits wide.
+  uint8_t want[3] = {0x00, 0x03, 0x02};
+  if ((dst.wi != 3) || memcmp(dst.ptr, want, 3)) {
+    FAIL("dst: got %zu bytes, want {0x00, 0x03, 0x02}", (size_t)(dst.wi));
+    return;
+  }
+}
+
+void test_wuffs_lzw_encode_bad_literal() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  uint8_t src_bytes[3] = {0x01, 0x0F, 0x10};
+  wuffs_base__io_buffer src = ((wuffs_base__io_buffer){
+      .ptr = src_bytes, .len = 3, .wi = 3, .closed = true});
+
+  const char* status = wuffs_lzw_encode(&dst, &src, 4, 0, 0);
+  const char* want = wuffs_lzw__status__string(WUFFS_LZW__ERROR_BAD_LITERAL);
+  if (status != want) {
+    FAIL("got \"%s\", want \"%s\"", status, want);
+    return;
+  }
+}
+
+void test_wuffs_lzw_encode_literal_widths() {
+  CHECK_FOCUS(__func__);
+  uint32_t literal_width;
+  for (literal_width = 2; literal_width <= 8; literal_width++) {
+    if (!do_test_wuffs_lzw_round_trip("../../data/pi.txt", literal_width, 0,
+                                      0)) {
+      return;
+    }
+  }
+}
+
+void test_wuffs_lzw_encode_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_lzw_round_trip("../../data/bricks-gray.indexes", 8, 41, 43);
 }
 
 // ---------------- LZW Benches
@@ -237,11 +405,17 @@ void bench_wuffs_lzw_decode_100k() {
 // The empty comments forces clang-format to place one element per line.
 proc tests[] = {
 
-    test_wuffs_lzw_decode_many_big_reads,           //
-    test_wuffs_lzw_decode_many_small_writes_reads,  //
-    test_wuffs_lzw_decode_bricks_dither,            //
-    test_wuffs_lzw_decode_bricks_nodither,          //
-    test_wuffs_lzw_decode_pi,                       //
+    test_wuffs_lzw_decode_many_big_reads,                //
+    test_wuffs_lzw_decode_many_small_writes_reads,       //
+    test_wuffs_lzw_decode_bricks_dither,                 //
+    test_wuffs_lzw_decode_bricks_nodither,               //
+    test_wuffs_lzw_decode_pi,                            //
+    test_wuffs_lzw_decode_tiff_pi,                       //
+    test_wuffs_lzw_decode_tiff_many_small_writes_reads,  //
+    test_wuffs_lzw_encode_empty,                         //
+    test_wuffs_lzw_encode_bad_literal,                   //
+    test_wuffs_lzw_encode_literal_widths,                //
+    test_wuffs_lzw_encode_many_small_writes_reads,       //
 
     NULL,
 };
@@ -0,0 +1,671 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror png.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC -lpng
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/adler32.c"
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../testlib/testlib.c"
+#ifdef WUFFS_MIMIC
+#include "../mimiclib/png.c"
+#endif
+
+// The pngsuite_filenames are the test/data/pngsuite files, generated by
+// script/make-pngsuite.go, other than those that are deliberately invalid.
+const char* pngsuite_filenames[] = {
+    "basn0g01", "basn0g02", "basn0g04", "basn0g08", "basn0g16",  //
+    "basn2c08", "basn2c16",                                      //
+    "basn3p01", "basn3p02", "basn3p04", "basn3p08",              //
+    "basn4a08", "basn4a16",                                      //
+    "basn6a08", "basn6a16",                                      //
+    "trnn0g04", "trnn0g08", "trnn0g16",                          //
+    "trnn2c08", "trnn2c16",                                      //
+    "trnn3p04", "trnn3p08",                                      //
+    "s01n3p04", "s02n3p04", "s03n3p04",                          //
+    "s05n3p04", "s07n3p04", "s09n3p04",                          //
+    NULL,
+};
+
+// ---------------- PNG Tests
+
+// wuffs_png_decode_with_options decodes the PNG image in src into dst, as
+// BGRA_NONPREMUL pixels.
+const char* wuffs_png_decode_with_options(wuffs_base__io_buffer* dst,
+                                          wuffs_base__io_buffer* src,
+                                          uint64_t rlimit,
+                                          bool ignore_checksum) {
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_png__decoder__set_ignore_checksum(&dec, ignore_checksum);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_png__status s = WUFFS_PNG__STATUS_OK;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_PNG__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    return "unexpected pixel format";
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  // Pass a work buffer that is exactly as long as it needs to be.
+  uint64_t workbuf_len = wuffs_png__decoder__workbuf_len(&dec);
+  if (workbuf_len > WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer)) {
+    return "work buffer is too small";
+  }
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_png__decoder__decode_frame(
+        &dec, &ib, src_reader,
+        ((wuffs_base__slice_u8){
+            .ptr = global_work_buffer,
+            .len = workbuf_len,
+        }));
+    if (s != WUFFS_PNG__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+const char* wuffs_png_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  return wuffs_png_decode_with_options(dst, src, 0, false);
+}
+
+// do_test_wuffs_png_decode_equal decodes the got_filename and want_filename
+// PNG images and checks that they have the same pixels.
+bool do_test_wuffs_png_decode_equal(const char* got_filename,
+                                    const char* want_filename,
+                                    uint64_t rlimit,
+                                    bool ignore_checksum) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, got_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg =
+      wuffs_png_decode_with_options(&got, &src, rlimit, ignore_checksum);
+  if (got_msg) {
+    FAIL("%s: %s", got_filename, got_msg);
+    return false;
+  }
+  if (src.ri != src.wi) {
+    FAIL("%s: src was not exhausted", got_filename);
+    return false;
+  }
+
+  src = ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, want_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = wuffs_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", want_filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_png_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_png__status status = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader, ((wuffs_base__slice_u8){}));
+  if (status != WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status),
+         WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_bad_checksum() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/pngsuite/xcsn2c08.png")) {
+    return;
+  }
+
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_png_decode(&got, &src);
+  const char* want_msg =
+      wuffs_png__status__string(WUFFS_PNG__ERROR_BAD_CHECKSUM);
+  if (got_msg != want_msg) {
+    FAIL("got \"%s\", want \"%s\"", got_msg ? got_msg : "(null)", want_msg);
+    return;
+  }
+}
+
+void test_wuffs_png_decode_bad_workbuf_length() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status));
+    return;
+  }
+
+  // bricks-color.png is 160 pixels wide, with 3 bytes per pixel. Each of the
+  // work buffer's two rows also holds a filter type byte.
+  uint64_t workbuf_len = wuffs_png__decoder__workbuf_len(&dec);
+  if (workbuf_len != 2 * ((160 * 3) + 1)) {
+    FAIL("workbuf_len: got %" PRIu64 ", want %d", workbuf_len,
+         2 * ((160 * 3) + 1));
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = workbuf_len - 1,
+      }));
+  if (status != WUFFS_PNG__ERROR_BAD_WORKBUF_LENGTH) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status), WUFFS_PNG__ERROR_BAD_WORKBUF_LENGTH,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_BAD_WORKBUF_LENGTH));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_ignore_checksum() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_equal("../../data/pngsuite/xcsn2c08.png",
+                                 "../../data/pngsuite/basn2c08.png", 0, true);
+}
+
+void test_wuffs_png_decode_input_is_a_gif() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-dither.gif")) {
+    return;
+  }
+
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__ERROR_BAD_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status), WUFFS_PNG__ERROR_BAD_HEADER,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_BAD_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status));
+    return;
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    FAIL("pixel_format: got 0x%08" PRIX32 ", want 0x%08" PRIX32,
+         wuffs_base__image_config__pixel_format(&ic),
+         WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL);
+    return;
+  }
+  // bricks-color.png is a 160 × 120 image.
+  if (wuffs_base__image_config__width(&ic) != 160) {
+    FAIL("width: got %" PRIu32 ", want 160",
+         wuffs_base__image_config__width(&ic));
+    return;
+  }
+  if (wuffs_base__image_config__height(&ic) != 120) {
+    FAIL("height: got %" PRIu32 ", want 120",
+         wuffs_base__image_config__height(&ic));
+    return;
+  }
+  uint64_t want_pixbuf_size = 4 * 160 * 120;
+  if (wuffs_base__image_config__pixbuf_size(&ic) != want_pixbuf_size) {
+    FAIL("pixbuf_size: got %" PRIu64 ", want %" PRIu64,
+         wuffs_base__image_config__pixbuf_size(&ic), want_pixbuf_size);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_PNG__STATUS_OK) {
+    FAIL("decode_frame #0: got %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status));
+    return;
+  }
+  if (src.ri != src.wi) {
+    FAIL("decode_frame returned \"ok\" but src was not exhausted");
+    return;
+  }
+  status = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_PNG__SUSPENSION_END_OF_DATA) {
+    FAIL("decode_frame #1: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_png__status__string(status),
+         WUFFS_PNG__SUSPENSION_END_OF_DATA,
+         wuffs_png__status__string(WUFFS_PNG__SUSPENSION_END_OF_DATA));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  // Each interlaced ("basi" etc.) file should decode to the same pixels as
+  // its non-interlaced ("basn" etc.) counterpart.
+  const char** f;
+  for (f = pngsuite_filenames; *f; f++) {
+    char got_filename[64];
+    char want_filename[64];
+    snprintf(want_filename, sizeof(want_filename),
+             "../../data/pngsuite/%s.png", *f);
+    snprintf(got_filename, sizeof(got_filename), "../../data/pngsuite/%s.png",
+             *f);
+    got_filename[strlen(got_filename) - 9] = 'i';
+    if (!do_test_wuffs_png_decode_equal(got_filename, want_filename, 0,
+                                        false)) {
+      return;
+    }
+  }
+}
+
+void test_wuffs_png_decode_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_equal("../../data/bricks-color.png",
+                                 "../../data/bricks-color.png", 11, false);
+}
+
+void test_wuffs_png_decode_interlaced_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_equal("../../data/pngsuite/basi6a16.png",
+                                 "../../data/pngsuite/basn6a16.png", 11, false);
+}
+
+  // ---------------- Mimic Tests
+
+#ifdef WUFFS_MIMIC
+
+bool do_test_mimic_png_decode(const char* filename) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_png_decode(&got, &src);
+  if (got_msg) {
+    FAIL("%s: %s", filename, got_msg);
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = mimic_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_mimic_png_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-color.png");
+}
+
+void test_mimic_png_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-dither.png");
+}
+
+void test_mimic_png_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-gray.png");
+}
+
+void test_mimic_png_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-nodither.png");
+}
+
+void test_mimic_png_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/harvesters.png");
+}
+
+void test_mimic_png_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/hat.png");
+}
+
+void test_mimic_png_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/hibiscus.png");
+}
+
+void test_mimic_png_decode_hippopotamus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/hippopotamus.png");
+}
+
+void test_mimic_png_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/pjw-thumbnail.png");
+}
+
+void test_mimic_png_decode_pngsuite() {
+  CHECK_FOCUS(__func__);
+  const char** f;
+  for (f = pngsuite_filenames; *f; f++) {
+    char filename[64];
+    snprintf(filename, sizeof(filename), "../../data/pngsuite/%s.png", *f);
+    if (!do_test_mimic_png_decode(filename)) {
+      return;
+    }
+    // Also check the interlaced version.
+    filename[strlen(filename) - 9] = 'i';
+    if (!do_test_mimic_png_decode(filename)) {
+      return;
+    }
+  }
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- PNG Benches
+
+bool do_bench_png_decode(const char* (*decode_func)(wuffs_base__io_buffer*,
+                                                    wuffs_base__io_buffer*),
+                         const char* filename,
+                         uint64_t iters_unscaled) {
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  uint64_t iters = iters_unscaled * iterscale;
+  for (i = 0; i < iters; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(iters, n_bytes);
+  return true;
+}
+
+void bench_wuffs_png_decode_1k_bw() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/pjw-thumbnail.png", 2000);
+}
+
+void bench_wuffs_png_decode_1k_color() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/hippopotamus.png", 1000);
+}
+
+void bench_wuffs_png_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/hat.png", 100);
+}
+
+void bench_wuffs_png_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/hibiscus.png", 10);
+}
+
+void bench_wuffs_png_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/harvesters.png", 1);
+}
+
+  // ---------------- Mimic Benches
+
+#ifdef WUFFS_MIMIC
+
+void bench_mimic_png_decode_1k_bw() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/pjw-thumbnail.png", 2000);
+}
+
+void bench_mimic_png_decode_1k_color() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/hippopotamus.png", 1000);
+}
+
+void bench_mimic_png_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/hat.png", 100);
+}
+
+void bench_mimic_png_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/hibiscus.png", 10);
+}
+
+void bench_mimic_png_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/harvesters.png", 1);
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_png_call_sequence,                       //
+    test_wuffs_png_decode_bad_checksum,                 //
+    test_wuffs_png_decode_bad_workbuf_length,           //
+    test_wuffs_png_decode_ignore_checksum,              //
+    test_wuffs_png_decode_input_is_a_gif,               //
+    test_wuffs_png_decode_input_is_a_png,               //
+    test_wuffs_png_decode_interlaced,                   //
+    test_wuffs_png_decode_interlaced_many_small_reads,  //
+    test_wuffs_png_decode_many_small_reads,             //
+
+#ifdef WUFFS_MIMIC
+
+    test_mimic_png_decode_bricks_color,     //
+    test_mimic_png_decode_bricks_dither,    //
+    test_mimic_png_decode_bricks_gray,      //
+    test_mimic_png_decode_bricks_nodither,  //
+    test_mimic_png_decode_harvesters,       //
+    test_mimic_png_decode_hat,              //
+    test_mimic_png_decode_hibiscus,         //
+    test_mimic_png_decode_hippopotamus,     //
+    test_mimic_png_decode_pjw_thumbnail,    //
+    test_mimic_png_decode_pngsuite,         //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_png_decode_1k_bw,     //
+    bench_wuffs_png_decode_1k_color,  //
+    bench_wuffs_png_decode_10k,       //
+    bench_wuffs_png_decode_100k,      //
+    bench_wuffs_png_decode_1000k,     //
+
+#ifdef WUFFS_MIMIC
+
+    bench_mimic_png_decode_1k_bw,     //
+    bench_mimic_png_decode_1k_color,  //
+    bench_mimic_png_decode_10k,       //
+    bench_mimic_png_decode_100k,      //
+    bench_mimic_png_decode_1000k,     //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_package_name = "std/png";
+  return test_main(argc, argv, tests, benches);
+}
@@ -0,0 +1,780 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror webp.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC -lwebp
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/adler32.c"
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/webp.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../testlib/testlib.c"
+#ifdef WUFFS_MIMIC
+#include "../mimiclib/webp.c"
+#endif
+
+// The real_world_filenames are the test/data WebP files, generated by cwebp.
+// Each "foo.lossless.webp" file should decode to the same pixels as the
+// "foo.png" file.
+const char* real_world_filenames[] = {
+    "bricks-color",     //
+    "bricks-dither",    //
+    "bricks-gray",      //
+    "bricks-nodither",  //
+    "harvesters",       //
+    "hat",              //
+    "hibiscus",         //
+    "hippopotamus",     //
+    "pjw-thumbnail",    //
+    NULL,
+};
+
+// ---------------- WebP Tests
+
+// wuffs_webp_decode_with_options decodes the first frame of the WebP image in
+// src into dst, as BGRA_NONPREMUL pixels.
+const char* wuffs_webp_decode_with_options(wuffs_base__io_buffer* dst,
+                                           wuffs_base__io_buffer* src,
+                                           uint64_t rlimit) {
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_webp__status s = WUFFS_WEBP__STATUS_OK;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_WEBP__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (s) {
+    return wuffs_webp__status__string(s);
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    return "unexpected pixel format";
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  // Pass a work buffer that is exactly as long as it needs to be. It holds a
+  // Huffman group per 4 × 4 block of the image, which can be more than
+  // global_work_buffer holds.
+  uint64_t workbuf_len = wuffs_webp__decoder__workbuf_len(&dec);
+  if (workbuf_len > SIZE_MAX) {
+    return "work buffer is too large";
+  }
+  uint8_t* workbuf = malloc(workbuf_len);
+  if (!workbuf) {
+    return "out of memory";
+  }
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    s = wuffs_webp__decoder__decode_frame(
+        &dec, &ib, src_reader,
+        ((wuffs_base__slice_u8){
+            .ptr = workbuf,
+            .len = workbuf_len,
+        }));
+    if (s != WUFFS_WEBP__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  free(workbuf);
+  if (s) {
+    return wuffs_webp__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+const char* wuffs_webp_decode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src) {
+  return wuffs_webp_decode_with_options(dst, src, 0);
+}
+
+// wuffs_png_decode decodes the PNG image in src into dst, as BGRA_NONPREMUL
+// pixels.
+const char* wuffs_png_decode(wuffs_base__io_buffer* dst,
+                             wuffs_base__io_buffer* src) {
+  wuffs_png__decoder dec = ((wuffs_png__decoder){});
+  wuffs_png__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  s = wuffs_png__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  return copy_to_io_buffer_from_image_buffer(dst, &ib);
+}
+
+// do_test_wuffs_webp_decode_equal decodes the got_filename WebP image, with a
+// read limit, and the want_filename PNG image and checks that they have the
+// same pixels.
+bool do_test_wuffs_webp_decode_equal(const char* got_filename,
+                                     const char* want_filename,
+                                     uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, got_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_webp_decode_with_options(&got, &src, rlimit);
+  if (got_msg) {
+    FAIL("%s: %s", got_filename, got_msg);
+    return false;
+  }
+
+  src = ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, want_filename)) {
+    return false;
+  }
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = wuffs_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", want_filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_wuffs_webp_decode_bad_workbuf_length() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.lossless.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return;
+  }
+
+  // bricks-color.lossless.webp is 160 × 120 pixels, or 40 × 30 blocks of 4 ×
+  // 4 pixels. Each of the 3 subresolution images has room for one 4 byte pixel
+  // per block. There is room for one 9152 byte Huffman group per block, plus a
+  // scratch group, and then the 576 byte code length code.
+  uint64_t want_workbuf_len =
+      (40 * 30 * 3 * 4) + (((40 * 30) + 1) * 9152) + 576;
+  uint64_t workbuf_len = wuffs_webp__decoder__workbuf_len(&dec);
+  if (workbuf_len != want_workbuf_len) {
+    FAIL("workbuf_len: got %" PRIu64 ", want %" PRIu64, workbuf_len,
+         want_workbuf_len);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_webp__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = workbuf_len - 1,
+      }));
+  if (status != WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH,
+         wuffs_webp__status__string(WUFFS_WEBP__ERROR_BAD_WORKBUF_LENGTH));
+    return;
+  }
+}
+
+void test_wuffs_webp_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.lossless.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status = wuffs_webp__decoder__decode_frame(
+      &dec, &ib, src_reader, ((wuffs_base__slice_u8){}));
+  if (status != WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_webp__status__string(WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_animated() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/animated-red-blue.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return;
+  }
+  // animated-red-blue.webp was converted from animated-red-blue.gif, so its
+  // num_loops should also be 3.
+  if (wuffs_base__image_config__num_loops(&ic) != 3) {
+    FAIL("num_loops: got %" PRIu32 ", want 3",
+         wuffs_base__image_config__num_loops(&ic));
+    return;
+  }
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+
+  // The GIF frames' odd offsets were rounded down to even ones.
+  wuffs_base__rect_ie_u32 want_rects[4] = {
+      make_rect_ie_u32(0, 0, 64, 48),
+      make_rect_ie_u32(14, 30, 52, 40),
+      make_rect_ie_u32(14, 0, 64, 40),
+      make_rect_ie_u32(14, 0, 64, 40),
+  };
+  uint32_t i;
+  for (i = 0; i < WUFFS_TESTLIB_ARRAY_SIZE(want_rects); i++) {
+    status = wuffs_webp__decoder__decode_frame(
+        &dec, &ib, src_reader,
+        ((wuffs_base__slice_u8){
+            .ptr = global_work_buffer,
+            .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+        }));
+    if (status != WUFFS_WEBP__STATUS_OK) {
+      FAIL("decode_frame #%" PRIu32 ": got %" PRIi32 " (%s)", i, status,
+           wuffs_webp__status__string(status));
+      return;
+    }
+
+    wuffs_base__rect_ie_u32 got = wuffs_base__image_buffer__dirty_rect(&ib);
+    wuffs_base__rect_ie_u32 want = want_rects[i];
+    if (!wuffs_base__rect_ie_u32__equals(got, want)) {
+      FAIL("decode_frame #%" PRIu32 ": dirty_rect: got (%" PRIu32 ", %" PRIu32
+           ")-(%" PRIu32 ", %" PRIu32 "), want (%" PRIu32 ", %" PRIu32
+           ")-(%" PRIu32 ", %" PRIu32 ")",
+           i, got.min_inclusive_x, got.min_inclusive_y, got.max_exclusive_x,
+           got.max_exclusive_y, want.min_inclusive_x, want.min_inclusive_y,
+           want.max_exclusive_x, want.max_exclusive_y);
+      return;
+    }
+
+    // The GIF frames' delays are 10, 20, 30 and 40 centiseconds.
+    wuffs_base__flicks want_duration =
+        (i + 1) * 10 * WUFFS_BASE__FLICKS_PER_SECOND / 100;
+    if (wuffs_base__image_buffer__duration(&ib) != want_duration) {
+      FAIL("decode_frame #%" PRIu32 ": duration: got %" PRIi64
+           ", want %" PRIi64,
+           i, wuffs_base__image_buffer__duration(&ib), want_duration);
+      return;
+    }
+    if (!wuffs_base__image_buffer__blend(&ib)) {
+      FAIL("decode_frame #%" PRIu32 ": blend: got false, want true", i);
+      return;
+    }
+    if (wuffs_base__image_buffer__disposal(&ib) != 0) {
+      FAIL("decode_frame #%" PRIu32 ": disposal: got %" PRIu32 ", want 0", i,
+           (uint32_t)(wuffs_base__image_buffer__disposal(&ib)));
+      return;
+    }
+  }
+
+  // There should be no more frames.
+  status = wuffs_webp__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_WEBP__SUSPENSION_END_OF_DATA) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status), WUFFS_WEBP__SUSPENSION_END_OF_DATA,
+         wuffs_webp__status__string(WUFFS_WEBP__SUSPENSION_END_OF_DATA));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__ERROR_BAD_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status), WUFFS_WEBP__ERROR_BAD_HEADER,
+         wuffs_webp__status__string(WUFFS_WEBP__ERROR_BAD_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_input_is_a_webp() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.lossless.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return;
+  }
+  if (wuffs_base__image_config__pixel_format(&ic) !=
+      WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL) {
+    FAIL("pixel_format: got 0x%08" PRIX32 ", want 0x%08" PRIX32,
+         wuffs_base__image_config__pixel_format(&ic),
+         WUFFS_BASE__PIXEL_FORMAT__BGRA_NONPREMUL);
+    return;
+  }
+  // bricks-color.lossless.webp is a 160 × 120 image.
+  if (wuffs_base__image_config__width(&ic) != 160) {
+    FAIL("width: got %" PRIu32 ", want 160",
+         wuffs_base__image_config__width(&ic));
+    return;
+  }
+  if (wuffs_base__image_config__height(&ic) != 120) {
+    FAIL("height: got %" PRIu32 ", want 120",
+         wuffs_base__image_config__height(&ic));
+    return;
+  }
+  uint64_t want_pixbuf_size = 4 * 160 * 120;
+  if (wuffs_base__image_config__pixbuf_size(&ic) != want_pixbuf_size) {
+    FAIL("pixbuf_size: got %" PRIu64 ", want %" PRIu64,
+         wuffs_base__image_config__pixbuf_size(&ic), want_pixbuf_size);
+    return;
+  }
+
+  wuffs_base__image_buffer ib = ((wuffs_base__image_buffer){});
+  // TODO: check wuffs_base__image_buffer__set_from_slice errors?
+  wuffs_base__image_buffer__set_from_slice(
+      &ib, ic,
+      ((wuffs_base__slice_u8){
+          .ptr = global_pixel_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_pixel_buffer),
+      }));
+  status = wuffs_webp__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_frame #0: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return;
+  }
+  if (src.ri != src.wi) {
+    FAIL("decode_frame returned \"ok\" but src was not exhausted");
+    return;
+  }
+  status = wuffs_webp__decoder__decode_frame(
+      &dec, &ib, src_reader,
+      ((wuffs_base__slice_u8){
+          .ptr = global_work_buffer,
+          .len = WUFFS_TESTLIB_ARRAY_SIZE(global_work_buffer),
+      }));
+  if (status != WUFFS_WEBP__SUSPENSION_END_OF_DATA) {
+    FAIL("decode_frame #1: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_webp__status__string(status),
+         WUFFS_WEBP__SUSPENSION_END_OF_DATA,
+         wuffs_webp__status__string(WUFFS_WEBP__SUSPENSION_END_OF_DATA));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_lossy() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/bricks-color.lossy.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec = ((wuffs_webp__decoder){});
+  wuffs_webp__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__image_config ic = ((wuffs_base__image_config){});
+  wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE,
+         wuffs_webp__status__string(
+             WUFFS_WEBP__ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_equal("../../data/bricks-color.lossless.webp",
+                                  "../../data/bricks-color.png", 11);
+}
+
+void test_wuffs_webp_decode_real_world() {
+  CHECK_FOCUS(__func__);
+  const char** f;
+  for (f = real_world_filenames; *f; f++) {
+    char got_filename[64];
+    char want_filename[64];
+    snprintf(got_filename, sizeof(got_filename), "../../data/%s.lossless.webp",
+             *f);
+    snprintf(want_filename, sizeof(want_filename), "../../data/%s.png", *f);
+    if (!do_test_wuffs_webp_decode_equal(got_filename, want_filename, 0)) {
+      return;
+    }
+  }
+}
+
+  // ---------------- Mimic Tests
+
+#ifdef WUFFS_MIMIC
+
+bool do_test_mimic_webp_decode(const char* filename) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  const char* got_msg = wuffs_webp_decode(&got, &src);
+  if (got_msg) {
+    FAIL("%s: %s", filename, got_msg);
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  const char* want_msg = mimic_webp_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s: %s", filename, want_msg);
+    return false;
+  }
+
+  return io_buffers_equal("", &got, &want);
+}
+
+void test_mimic_webp_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/bricks-color.lossless.webp");
+}
+
+void test_mimic_webp_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/bricks-dither.lossless.webp");
+}
+
+void test_mimic_webp_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/bricks-gray.lossless.webp");
+}
+
+void test_mimic_webp_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/bricks-nodither.lossless.webp");
+}
+
+void test_mimic_webp_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/harvesters.lossless.webp");
+}
+
+void test_mimic_webp_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/hat.lossless.webp");
+}
+
+void test_mimic_webp_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/hibiscus.lossless.webp");
+}
+
+void test_mimic_webp_decode_hippopotamus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/hippopotamus.lossless.webp");
+}
+
+void test_mimic_webp_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_webp_decode("../../data/pjw-thumbnail.lossless.webp");
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- WebP Benches
+
+bool do_bench_webp_decode(const char* (*decode_func)(wuffs_base__io_buffer*,
+                                                     wuffs_base__io_buffer*),
+                          const char* filename,
+                          uint64_t iters_unscaled) {
+  wuffs_base__io_buffer dst =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  uint64_t iters = iters_unscaled * iterscale;
+  for (i = 0; i < iters; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(iters, n_bytes);
+  return true;
+}
+
+void bench_wuffs_webp_decode_1k_color() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode,
+                       "../../data/hippopotamus.lossless.webp", 1000);
+}
+
+void bench_wuffs_webp_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode, "../../data/hat.lossless.webp", 100);
+}
+
+void bench_wuffs_webp_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode, "../../data/hibiscus.lossless.webp",
+                       10);
+}
+
+void bench_wuffs_webp_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode,
+                       "../../data/harvesters.lossless.webp", 1);
+}
+
+  // ---------------- Mimic Benches
+
+#ifdef WUFFS_MIMIC
+
+void bench_mimic_webp_decode_1k_color() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(mimic_webp_decode,
+                       "../../data/hippopotamus.lossless.webp", 1000);
+}
+
+void bench_mimic_webp_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(mimic_webp_decode, "../../data/hat.lossless.webp", 100);
+}
+
+void bench_mimic_webp_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(mimic_webp_decode, "../../data/hibiscus.lossless.webp",
+                       10);
+}
+
+void bench_mimic_webp_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(mimic_webp_decode,
+                       "../../data/harvesters.lossless.webp", 1);
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_webp_call_sequence,              //
+    test_wuffs_webp_decode_animated,            //
+    test_wuffs_webp_decode_bad_workbuf_length,  //
+    test_wuffs_webp_decode_input_is_a_png,      //
+    test_wuffs_webp_decode_input_is_a_webp,     //
+    test_wuffs_webp_decode_lossy,               //
+    test_wuffs_webp_decode_many_small_reads,    //
+    test_wuffs_webp_decode_real_world,          //
+
+#ifdef WUFFS_MIMIC
+
+    test_mimic_webp_decode_bricks_color,     //
+    test_mimic_webp_decode_bricks_dither,    //
+    test_mimic_webp_decode_bricks_gray,      //
+    test_mimic_webp_decode_bricks_nodither,  //
+    test_mimic_webp_decode_harvesters,       //
+    test_mimic_webp_decode_hat,              //
+    test_mimic_webp_decode_hibiscus,         //
+    test_mimic_webp_decode_hippopotamus,     //
+    test_mimic_webp_decode_pjw_thumbnail,    //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_webp_decode_1k_color,  //
+    bench_wuffs_webp_decode_10k,       //
+    bench_wuffs_webp_decode_100k,      //
+    bench_wuffs_webp_decode_1000k,     //
+
+#ifdef WUFFS_MIMIC
+
+    bench_mimic_webp_decode_1k_color,  //
+    bench_mimic_webp_decode_10k,       //
+    bench_mimic_webp_decode_100k,      //
+    bench_mimic_webp_decode_1000k,     //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_package_name = "std/webp";
+  return test_main(argc, argv, tests, benches);
+}
@@ -170,6 +170,128 @@ void test_wuffs_zlib_checksum_verify_good() {
   do_test_wuffs_zlib_checksum(false, 0);
 }
 
+uint8_t global_zlib_dictionary_buffer[65536];
+
+// do_test_wuffs_zlib_dictionary decodes midsummer.txt.romeo-dict.zlib,
+// supplying the contents of dict_filename when the decoder asks for a preset
+// dictionary.
+bool do_test_wuffs_zlib_dictionary(const char* dict_filename,
+                                   wuffs_zlib__status want,
+                                   uint64_t rlimit) {
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer dict = ((wuffs_base__io_buffer){
+      .ptr = global_zlib_dictionary_buffer,
+      .len = WUFFS_TESTLIB_ARRAY_SIZE(global_zlib_dictionary_buffer),
+  });
+
+  if (!read_file(&src, "../../data/midsummer.txt.romeo-dict.zlib") ||
+      !read_file(&dict, dict_filename)) {
+    return false;
+  }
+
+  wuffs_zlib__decoder dec = ((wuffs_zlib__decoder){});
+  wuffs_zlib__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_base__io_writer got_writer = wuffs_base__io_buffer__writer(&got);
+
+  int num_dictionary_requests = 0;
+  wuffs_zlib__status status;
+  while (true) {
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(&src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+    status = wuffs_zlib__decoder__decode(&dec, got_writer, src_reader);
+    if (status == WUFFS_ZLIB__SUSPENSION_DICTIONARY_REQUIRED) {
+      num_dictionary_requests++;
+      // 0x57BB3EDE is the adler32 checksum of romeo.txt.
+      uint32_t dict_id = wuffs_zlib__decoder__dictionary_id(&dec);
+      if (dict_id != 0x57BB3EDE) {
+        FAIL("dictionary_id: got 0x%08" PRIX32 ", want 0x57BB3EDE", dict_id);
+        return false;
+      }
+      wuffs_zlib__decoder__set_dictionary(
+          &dec, ((wuffs_base__slice_u8){.ptr = dict.ptr, .len = dict.wi}));
+      continue;
+    }
+    if (rlimit && (status == WUFFS_ZLIB__SUSPENSION_SHORT_READ)) {
+      continue;
+    }
+    break;
+  }
+
+  if (num_dictionary_requests != 1) {
+    FAIL("num_dictionary_requests: got %d, want 1", num_dictionary_requests);
+    return false;
+  }
+  if (status != want) {
+    FAIL("decode: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_zlib__status__string(status), want,
+         wuffs_zlib__status__string(want));
+    return false;
+  }
+  if (status != WUFFS_ZLIB__STATUS_OK) {
+    return true;
+  }
+
+  wuffs_base__io_buffer want_buf =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+  if (!read_file(&want_buf, "../../data/midsummer.txt")) {
+    return false;
+  }
+  return io_buffers_equal("", &got, &want_buf);
+}
+
+void test_wuffs_zlib_dictionary_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&src, "../../data/midsummer.txt.romeo-dict.zlib")) {
+    return;
+  }
+
+  // Calling set_dictionary before decode asks for it is an error.
+  wuffs_zlib__decoder dec = ((wuffs_zlib__decoder){});
+  wuffs_zlib__decoder__check_wuffs_version(&dec, sizeof dec, WUFFS_VERSION);
+  wuffs_zlib__decoder__set_dictionary(
+      &dec, ((wuffs_base__slice_u8){.ptr = global_zlib_dictionary_buffer,
+                                    .len = 1}));
+  wuffs_zlib__status status =
+      wuffs_zlib__decoder__decode(&dec, wuffs_base__io_buffer__writer(&got),
+                                  wuffs_base__io_buffer__reader(&src));
+  if (status != WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_zlib__status__string(status),
+         WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_zlib__status__string(WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_zlib_dictionary_correct() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dictionary("../../data/romeo.txt", WUFFS_ZLIB__STATUS_OK,
+                                0);
+}
+
+void test_wuffs_zlib_dictionary_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dictionary("../../data/romeo.txt", WUFFS_ZLIB__STATUS_OK,
+                                3);
+}
+
+void test_wuffs_zlib_dictionary_incorrect() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dictionary("../../data/midsummer.txt",
+                                WUFFS_ZLIB__ERROR_INCORRECT_DICTIONARY, 0);
+}
+
 void test_wuffs_zlib_decode_midsummer() {
   CHECK_FOCUS(__func__);
   do_test_io_buffers(wuffs_zlib_decode, &zlib_midsummer_gt, 0, 0);
@@ -180,6 +302,96 @@ void test_wuffs_zlib_decode_pi() {
   do_test_io_buffers(wuffs_zlib_decode, &zlib_pi_gt, 0, 0);
 }
 
+// ---------------- Zlib Encoder Tests
+
+const char* wuffs_zlib_encode(wuffs_base__io_buffer* dst,
+                              wuffs_base__io_buffer* src,
+                              uint32_t level,
+                              uint64_t wlimit,
+                              uint64_t rlimit) {
+  wuffs_zlib__encoder enc = ((wuffs_zlib__encoder){});
+  wuffs_zlib__encoder__check_wuffs_version(&enc, sizeof enc, WUFFS_VERSION);
+  wuffs_zlib__encoder__set_level(&enc, level);
+  while (true) {
+    wuffs_base__io_writer dst_writer = wuffs_base__io_buffer__writer(dst);
+    if (wlimit) {
+      set_writer_limit(&dst_writer, wlimit);
+    }
+    wuffs_base__io_reader src_reader = wuffs_base__io_buffer__reader(src);
+    if (rlimit) {
+      set_reader_limit(&src_reader, rlimit);
+    }
+
+    wuffs_zlib__status s =
+        wuffs_zlib__encoder__encode(&enc, dst_writer, src_reader);
+
+    if (s == WUFFS_ZLIB__STATUS_OK) {
+      return NULL;
+    }
+    if ((wlimit && (s == WUFFS_ZLIB__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (s == WUFFS_ZLIB__SUSPENSION_SHORT_READ))) {
+      continue;
+    }
+    return wuffs_zlib__status__string(s);
+  }
+}
+
+// do_test_zlib_round_trip encodes the golden test's want file and then
+// decodes the result with decode_func, which should give back the original.
+void do_test_zlib_round_trip(
+    const char* (*decode_func)(wuffs_base__io_buffer*,
+                               wuffs_base__io_buffer*,
+                               uint64_t,
+                               uint64_t),
+    golden_test* gt,
+    uint32_t level,
+    uint64_t wlimit,
+    uint64_t rlimit) {
+  wuffs_base__io_buffer src =
+      ((wuffs_base__io_buffer){.ptr = global_src_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer got =
+      ((wuffs_base__io_buffer){.ptr = global_got_buffer, .len = BUFFER_SIZE});
+  wuffs_base__io_buffer want =
+      ((wuffs_base__io_buffer){.ptr = global_want_buffer, .len = BUFFER_SIZE});
+
+  if (!read_file(&want, gt->want_filename)) {
+    return;
+  }
+  const char* status = wuffs_zlib_encode(&src, &want, level, wlimit, rlimit);
+  if (status) {
+    FAIL("level=%" PRIu32 ": encode: %s", level, status);
+    return;
+  }
+  src.closed = true;
+  want.ri = 0;
+
+  status = decode_func(&got, &src, 0, 0);
+  if (status) {
+    FAIL("level=%" PRIu32 ": decode: %s", level, status);
+    return;
+  }
+  char prefix[64];
+  snprintf(prefix, 64, "level=%" PRIu32 ": ", level);
+  io_buffers_equal(prefix, &got, &want);
+}
+
+void test_wuffs_zlib_encode_levels() {
+  CHECK_FOCUS(__func__);
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    do_test_zlib_round_trip(wuffs_zlib_decode, &zlib_midsummer_gt, level, 0,
+                            0);
+    if (fail_msg[0]) {
+      return;
+    }
+  }
+}
+
+void test_wuffs_zlib_encode_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_zlib_round_trip(wuffs_zlib_decode, &zlib_pi_gt, 6, 59, 61);
+}
+
   // ---------------- Mimic Tests
 
 #ifdef WUFFS_MIMIC
@@ -194,6 +406,17 @@ void test_mimic_zlib_decode_pi() {
   do_test_io_buffers(mimic_zlib_decode, &zlib_pi_gt, 0, 0);
 }
 
+void test_mimic_zlib_decode_wuffs_encoded() {
+  CHECK_FOCUS(__func__);
+  uint32_t level;
+  for (level = 0; level <= 9; level++) {
+    do_test_zlib_round_trip(mimic_zlib_decode, &zlib_pi_gt, level, 0, 0);
+    if (fail_msg[0]) {
+      return;
+    }
+  }
+}
+
 #endif  // WUFFS_MIMIC
 
 // ---------------- Zlib Benches
@@ -229,16 +452,23 @@ void bench_mimic_zlib_decode_100k() {
 // The empty comments forces clang-format to place one element per line.
 proc tests[] = {
 
-    test_wuffs_zlib_checksum_ignore,       //
-    test_wuffs_zlib_checksum_verify_bad,   //
-    test_wuffs_zlib_checksum_verify_good,  //
-    test_wuffs_zlib_decode_midsummer,      //
-    test_wuffs_zlib_decode_pi,             //
+    test_wuffs_zlib_checksum_ignore,                 //
+    test_wuffs_zlib_checksum_verify_bad,             //
+    test_wuffs_zlib_checksum_verify_good,            //
+    test_wuffs_zlib_dictionary_call_sequence,        //
+    test_wuffs_zlib_dictionary_correct,              //
+    test_wuffs_zlib_dictionary_incorrect,            //
+    test_wuffs_zlib_dictionary_many_small_reads,     //
+    test_wuffs_zlib_decode_midsummer,                //
+    test_wuffs_zlib_decode_pi,                       //
+    test_wuffs_zlib_encode_levels,                   //
+    test_wuffs_zlib_encode_many_small_writes_reads,  //
 
 #ifdef WUFFS_MIMIC
 
-    test_mimic_zlib_decode_midsummer,  //
-    test_mimic_zlib_decode_pi,         //
+    test_mimic_zlib_decode_midsummer,      //
+    test_mimic_zlib_decode_pi,             //
+    test_mimic_zlib_decode_wuffs_encoded,  //
 
 #endif  // WUFFS_MIMIC
 
@@ -28,6 +28,7 @@ uint8_t global_got_buffer[BUFFER_SIZE];
 uint8_t global_want_buffer[BUFFER_SIZE];
 uint8_t global_src_buffer[BUFFER_SIZE];
 uint8_t global_pixel_buffer[BUFFER_SIZE];
+uint8_t global_work_buffer[BUFFER_SIZE];
 uint8_t global_palette_buffer[PALETTE_BUFFER_SIZE];
 
 char fail_msg[65536] = {0};
@@ -331,25 +332,51 @@ void set_writer_limit(wuffs_base__io_writer* o, uint64_t limit) {
   }
 }
 
+// copy_to_io_buffer_from_image_buffer copies src's dirty rectangle to dst,
+// one plane after another. For planar pixel formats, each plane's rectangle is
+// scaled by that plane's pixel subsampling.
 const char* copy_to_io_buffer_from_image_buffer(wuffs_base__io_buffer* dst,
                                                 wuffs_base__image_buffer* src) {
-  // TODO: don't assume 1 plane or WUFFS_BASE__PIXEL_SUBSAMPLING__NONE.
+  wuffs_base__image_config* ic = wuffs_base__image_buffer__image_config(src);
+  wuffs_base__pixel_format pixfmt = wuffs_base__image_config__pixel_format(ic);
+  wuffs_base__pixel_subsampling pixsub =
+      wuffs_base__image_config__pixel_subsampling(ic);
+  uint32_t num_planes = wuffs_base__pixel_format__num_planes(pixfmt);
   uint32_t p;
-  for (p = 0; p < 1; p++) {
+  for (p = 0; p < num_planes; p++) {
+    uint32_t bpp = wuffs_base__pixel_format__plane_bits_per_pixel(pixfmt, p);
+    if ((bpp == 0) || (bpp % 8)) {
+      return "copy_to_io_buffer_from_image_buffer: unsupported pixel format";
+    }
+    size_t bytes_per_pixel = bpp / 8;
     wuffs_base__table_u8 tab = wuffs_base__image_buffer__plane(src, p);
     wuffs_base__rect_ie_u32 r = wuffs_base__image_buffer__dirty_rect(src);
+    if (wuffs_base__rect_ie_u32__is_empty(r)) {
+      continue;
+    }
+    if (num_planes > 1) {
+      uint32_t bias_x = wuffs_base__pixel_subsampling__bias_x(pixsub, p);
+      uint32_t shift_x = wuffs_base__pixel_subsampling__shift_x(pixsub, p);
+      uint32_t bias_y = wuffs_base__pixel_subsampling__bias_y(pixsub, p);
+      uint32_t shift_y = wuffs_base__pixel_subsampling__shift_y(pixsub, p);
+      r.min_inclusive_x = (r.min_inclusive_x + bias_x) >> shift_x;
+      r.max_exclusive_x = ((r.max_exclusive_x - 1 + bias_x) >> shift_x) + 1;
+      r.min_inclusive_y = (r.min_inclusive_y + bias_y) >> shift_y;
+      r.max_exclusive_y = ((r.max_exclusive_y - 1 + bias_y) >> shift_y) + 1;
+    }
     uint32_t y;
     for (y = r.min_inclusive_y; y < r.max_exclusive_y; y++) {
       wuffs_base__slice_u8 row = wuffs_base__table_u8__row(tab, y);
-      if ((r.min_inclusive_x >= r.max_exclusive_x) ||
-          (r.max_exclusive_x > row.len)) {
+      size_t x0 = r.min_inclusive_x * bytes_per_pixel;
+      size_t x1 = r.max_exclusive_x * bytes_per_pixel;
+      if ((x0 >= x1) || (x1 > row.len)) {
         break;
       }
-      uint32_t n = r.max_exclusive_x - r.min_inclusive_x;
+      size_t n = x1 - x0;
       if (n > (dst->len - dst->wi)) {
         return "copy_to_io_buffer_from_image_buffer: dst buffer is too small";
       }
-      memmove(dst->ptr + dst->wi, row.ptr + r.min_inclusive_x, n);
+      memmove(dst->ptr + dst->wi, row.ptr + x0, n);
       dst->wi += n;
     }
   }
@@ -0,0 +1,139 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build genlib
+
+// These tests need the generated cgo package. To run them:
+//
+//	wuffs genlib -ccompilers=gcc -cgo std/gzip
+//	go test -tags=genlib github.com/google/wuffs/test/cgo/...
+
+package gzip_test
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/wuffs/gen/lib/c/cgo/std/gzip"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"midsummer.txt.gz",
+	"pi.txt.gz",
+	"romeo.txt.gz",
+}
+
+func mimicDecode(src []byte) ([]byte, error) {
+	r, err := stdgzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func testDecode(tt *testing.T, wrap func(io.Reader) io.Reader) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := mimicDecode(src)
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+		r := gzip.NewReader(wrap(bytes.NewReader(src)))
+		got, err := ioutil.ReadAll(wrap(r))
+		if err != nil {
+			tt.Errorf("%s: %v", filename, err)
+		} else if !bytes.Equal(got, want) {
+			tt.Errorf("%s: got %d bytes, want %d bytes", filename, len(got), len(want))
+		}
+		if err := r.Close(); err != nil {
+			tt.Errorf("%s: Close: %v", filename, err)
+		}
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func identity(r io.Reader) io.Reader { return r }
+
+func TestDecode(tt *testing.T)              { testDecode(tt, identity) }
+func TestDecodeManyTinyReads(tt *testing.T) { testDecode(tt, iotest.OneByteReader) }
+
+func TestDecodeBadChecksum(tt *testing.T) {
+	src, err := testlib.ReadFile("romeo.txt.gz")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	src = append([]byte(nil), src...)
+	src[len(src)-8] ^= 1
+
+	r := gzip.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	if _, err := ioutil.ReadAll(r); err != gzip.ErrBadChecksum {
+		tt.Fatalf("got %v, want %v", err, gzip.ErrBadChecksum)
+	}
+	if got, want := gzip.ErrBadChecksum.Error(), "gzip: bad checksum"; got != want {
+		tt.Fatalf("Error: got %q, want %q", got, want)
+	}
+
+	r = gzip.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	r.SetIgnoreChecksum(true)
+	if _, err := ioutil.ReadAll(r); err != nil {
+		tt.Fatalf("SetIgnoreChecksum: %v", err)
+	}
+}
+
+func TestDecodeTruncated(tt *testing.T) {
+	src, err := testlib.ReadFile("romeo.txt.gz")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := gzip.NewReader(bytes.NewReader(src[:len(src)/2]))
+	defer r.Close()
+	if _, err := ioutil.ReadAll(r); err != gzip.ErrUnexpectedEOF {
+		tt.Fatalf("got %v, want %v", err, gzip.ErrUnexpectedEOF)
+	}
+}
+
+func TestDecodeUnderlyingError(tt *testing.T) {
+	src, err := testlib.ReadFile("romeo.txt.gz")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	errFoo := errors.New("foo")
+	r := gzip.NewReader(io.MultiReader(bytes.NewReader(src[:len(src)/2]), errReader{errFoo}))
+	defer r.Close()
+	if _, err := ioutil.ReadAll(r); err != errFoo {
+		tt.Fatalf("got %v, want %v", err, errFoo)
+	}
+}
+
+func TestReadAfterClose(tt *testing.T) {
+	r := gzip.NewReader(bytes.NewReader(nil))
+	r.Close()
+	if _, err := r.Read(make([]byte, 1)); err == nil {
+		tt.Fatal("got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adler32_test
+
+import (
+	stdadler32 "hash/adler32"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/adler32"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"hat.bmp",
+	"hat.gif",
+	"hat.jpeg",
+	"hat.lossless.webp",
+	"hat.lossy.webp",
+	"hat.png",
+	"hat.tiff",
+	"midsummer.txt",
+	"pi.txt",
+}
+
+func TestGolden(tt *testing.T) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want := stdadler32.Checksum(src)
+
+		// Split src into two Update calls at various points, as the
+		// checksum should not depend on how its input is chunked.
+		for _, i := range []int{0, 1, 5551, 5552, 5553, len(src) / 2, len(src)} {
+			if i > len(src) {
+				continue
+			}
+			h := &adler32.Hasher{}
+			h.Update(src[:i])
+			if got := h.Update(src[i:]); got != want {
+				tt.Errorf("%s: split at %d: got 0x%08X, want 0x%08X", filename, i, got, want)
+			}
+		}
+	}
+}
+
+func benchAdler32(b *testing.B, size int, mimic bool) {
+	src, err := testlib.ReadFile("pi.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	src = src[:size]
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			stdadler32.Checksum(src)
+		} else {
+			h := &adler32.Hasher{}
+			h.Update(src)
+		}
+	}
+}
+
+func BenchmarkWuffsAdler32_10k(b *testing.B)  { benchAdler32(b, 10000, false) }
+func BenchmarkWuffsAdler32_100k(b *testing.B) { benchAdler32(b, 100000, false) }
+func BenchmarkMimicAdler32_10k(b *testing.B)  { benchAdler32(b, 10000, true) }
+func BenchmarkMimicAdler32_100k(b *testing.B) { benchAdler32(b, 100000, true) }
@@ -0,0 +1,120 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bmp_test
+
+import (
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/bmp"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens are BMP files, without the ".bmp" suffix. Each "foo.bmp" file
+// should decode to the same pixels as the "foo.png" file.
+var goldens = []string{
+	"bricks-color",
+	"bricks-dither",
+	"bricks-gray",
+	"bricks-nodither",
+	"harvesters",
+	"hat",
+	"hibiscus",
+	"hippopotamus",
+	"pjw-thumbnail",
+}
+
+// bmpsuiteGoldens are the test/data/bmpsuite BMP files, generated by
+// script/make-bmpsuite.go.
+var bmpsuiteGoldens = []string{
+	"rgb24", "rgb24-topdown", "rgb24-gap", "rgb16", "rgb32",
+	"bitfields16-565", "bitfields16-565-v2", "bitfields16-4444-v5",
+	"bitfields32-1010", "bitfields32-v4", "bitfields32-v5-topdown",
+	"alphabitfields32",
+	"pal1", "pal4", "pal8", "pal8-v5",
+	"rle4", "rle4-delta", "rle8", "rle8-delta",
+}
+
+func init() {
+	for _, g := range bmpsuiteGoldens {
+		goldens = append(goldens, "bmpsuite/"+g)
+	}
+}
+
+// imageDecoder adapts a bmp.Decoder, which needs no work buffer, to the
+// testlib.ImageDecoder interface.
+type imageDecoder struct {
+	bmp.Decoder
+}
+
+func (d *imageDecoder) WorkbufLen() uint64 { return 0 }
+
+func (d *imageDecoder) DecodeFrame(dst *base.ImageBuffer, src base.IOReader, workbuf []byte) error {
+	return d.Decoder.DecodeFrame(dst, src)
+}
+
+// decode decodes src's BGRA_NONPREMUL pixels, feeding the decoder at most
+// rlimit bytes at a time (or all of src, if rlimit is non-positive).
+func decode(src []byte, rlimit int) ([]byte, error) {
+	return testlib.DecodeImage(&imageDecoder{}, src, rlimit, testlib.ImageDecodeOptions{CheckEndOfData: true})
+}
+
+func TestDecode(tt *testing.T) {
+	gs := []testlib.ImageGolden(nil)
+	for _, g := range goldens {
+		gs = append(gs, testlib.ImageGolden{Filename: g + ".bmp", PNGFilename: g + ".png"})
+	}
+	testlib.CheckImageGoldens(tt, gs, decode)
+}
+
+func TestDecodeInputIsAPNG(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != bmp.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, bmp.ErrBadHeader)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.bmp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &bmp.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader()); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = decode(src, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsBmpDecode_1k_color(b *testing.B) { benchDecode(b, "hippopotamus.bmp") }
+func BenchmarkWuffsBmpDecode_10k(b *testing.B)      { benchDecode(b, "hat.bmp") }
+func BenchmarkWuffsBmpDecode_100k(b *testing.B)     { benchDecode(b, "hibiscus.bmp") }
+func BenchmarkWuffsBmpDecode_1000k(b *testing.B)    { benchDecode(b, "harvesters.bmp") }
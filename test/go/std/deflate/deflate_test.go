@@ -0,0 +1,132 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deflate_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/deflate"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens' keys are the compressed filenames. A ".zlib" file holds a deflate
+// stream after its 2 byte header. The values are the decompressed filenames.
+var goldens = map[string]string{
+	"artificial/deflate-backref-crosses-blocks.deflate":  "artificial/deflate-backref-crosses-blocks.deflate.decompressed",
+	"artificial/deflate-degenerate-huffman-code.deflate": "artificial/deflate-degenerate-huffman-code.deflate.decompressed",
+	"artificial/deflate-distance-32768.deflate":          "artificial/deflate-distance-32768.deflate.decompressed",
+	"midsummer.txt.zlib":                                 "midsummer.txt",
+	"pi.txt.zlib":                                        "pi.txt",
+	"romeo.txt.deflate":                                  "romeo.txt",
+	"romeo.txt.fixed-huff.deflate":                       "romeo.txt",
+}
+
+func readGolden(tb testing.TB, filename string) (src []byte, want []byte) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		tb.Fatalf("%s: %v", filename, err)
+	}
+	if strings.HasSuffix(filename, ".zlib") {
+		src = src[2:]
+	}
+	want, err = testlib.ReadFile(goldens[filename])
+	if err != nil {
+		tb.Fatalf("%s: %v", filename, err)
+	}
+	return src, want
+}
+
+func mimicDecode(src []byte) ([]byte, error) {
+	return ioutil.ReadAll(flate.NewReader(bytes.NewReader(src)))
+}
+
+func testDecode(tt *testing.T, wlimit int, rlimit int) {
+	for filename := range goldens {
+		src, want := readGolden(tt, filename)
+		got, err := testlib.Decode(&deflate.Decoder{}, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                     { testDecode(tt, 0, 0) }
+func TestDecodeManyBigReads(tt *testing.T)         { testDecode(tt, 0, 4096) }
+func TestDecodeManyMediumReads(tt *testing.T)      { testDecode(tt, 0, 599) }
+func TestDecodeManySmallWritesReads(tt *testing.T) { testDecode(tt, 59, 61) }
+func TestDecodeManyTinyWritesReads(tt *testing.T)  { testDecode(tt, 1, 1) }
+
+func TestMimicDecode(tt *testing.T) {
+	for filename := range goldens {
+		src, want := readGolden(tt, filename)
+		got, err := mimicDecode(src)
+		if err != nil {
+			tt.Errorf("%s: %v", filename, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: got %d bytes, want %d bytes", filename, len(got), len(want))
+		}
+	}
+}
+
+// testEncode checks that deflate.Encoder output round-trips through both Wuffs
+// and the standard library.
+func testEncode(tt *testing.T, wlimit int, rlimit int) {
+	testlib.CheckRoundTrips(tt, newEncoder, newDecoder, mimicDecode, wlimit, rlimit)
+}
+
+func newEncoder(level uint32) testlib.Encoder {
+	e := &deflate.Encoder{}
+	e.SetLevel(level)
+	return e
+}
+
+func newDecoder() testlib.Decoder { return &deflate.Decoder{} }
+
+func TestEncode(tt *testing.T)                     { testEncode(tt, 0, 0) }
+func TestEncodeManySmallWritesReads(tt *testing.T) { testEncode(tt, 59, 61) }
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, want := readGolden(b, filename)
+	b.SetBytes(int64(len(want)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if mimic {
+			_, err = mimicDecode(src)
+		} else {
+			_, err = testlib.Decode(&deflate.Decoder{}, src, 0, 0)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsDeflateDecode_1k(b *testing.B)   { benchDecode(b, "romeo.txt.deflate", false) }
+func BenchmarkWuffsDeflateDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.zlib", false) }
+func BenchmarkWuffsDeflateDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.zlib", false) }
+func BenchmarkMimicDeflateDecode_1k(b *testing.B)   { benchDecode(b, "romeo.txt.deflate", true) }
+func BenchmarkMimicDeflateDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.zlib", true) }
+func BenchmarkMimicDeflateDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.zlib", true) }
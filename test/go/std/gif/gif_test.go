@@ -0,0 +1,427 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package gif_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	stdgif "image/gif"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/gif"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"animated-red-blue.gif",
+	"bricks-dither.gif",
+	"bricks-dither.interlaced.gif",
+	"bricks-gray.gif",
+	"bricks-nodither.gif",
+	"gifplayer-muybridge.gif",
+	"harvesters.gif",
+	"hat.gif",
+	"hibiscus.gif",
+	"muybridge.gif",
+	"pjw-thumbnail.gif",
+}
+
+// frame is a decoded frame's dirty rectangle and its palette indexes within
+// that rectangle.
+type frame struct {
+	rect base.RectIEU32
+	pix  []byte
+}
+
+// decode decodes all of src's frames, feeding the decoder at most rlimit
+// bytes at a time (or all of src, if rlimit is non-positive).
+func decode(src []byte, rlimit int) ([]frame, error) {
+	if rlimit <= 0 {
+		rlimit = len(src)
+	}
+	d := &gif.Decoder{}
+	r := base.IOBuffer{Data: src}
+	feed := func() bool {
+		if r.Closed {
+			return false
+		}
+		r.WI += rlimit
+		if r.WI >= len(src) {
+			r.WI = len(src)
+			r.Closed = true
+		}
+		return true
+	}
+	feed()
+
+	ic := base.ImageConfig{}
+	for {
+		status := d.DecodeConfig(&ic, r.Reader())
+		if status == nil {
+			break
+		} else if status != base.SuspensionShortRead || !feed() {
+			return nil, status
+		}
+	}
+
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	frames := []frame(nil)
+	for {
+		status := d.DecodeFrame(&ib, r.Reader())
+		if status == base.SuspensionEndOfData {
+			return frames, nil
+		} else if status == base.SuspensionShortRead && feed() {
+			continue
+		} else if status != nil {
+			return frames, status
+		}
+		rect := ib.DirtyRect()
+		pix := []byte(nil)
+		for y := rect.MinInclusiveY; y < rect.MaxExclusiveY; y++ {
+			pix = append(pix, ib.Plane(0).Row(y)[rect.MinInclusiveX:rect.MaxExclusiveX]...)
+		}
+		frames = append(frames, frame{rect, pix})
+	}
+}
+
+func mimicDecode(src []byte) ([]frame, error) {
+	g, err := stdgif.DecodeAll(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]frame, len(g.Image))
+	for i, m := range g.Image {
+		b := m.Bounds()
+		frames[i].rect = base.RectIEU32{
+			MinInclusiveX: uint32(b.Min.X),
+			MinInclusiveY: uint32(b.Min.Y),
+			MaxExclusiveX: uint32(b.Max.X),
+			MaxExclusiveY: uint32(b.Max.Y),
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i0 := m.PixOffset(b.Min.X, y)
+			frames[i].pix = append(frames[i].pix, m.Pix[i0:i0+b.Dx()]...)
+		}
+	}
+	return frames, nil
+}
+
+func testDecode(tt *testing.T, rlimit int) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := mimicDecode(src)
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+		got, err := decode(src, rlimit)
+		if err != nil {
+			tt.Errorf("%s: rlimit=%d: %v", filename, rlimit, err)
+			continue
+		}
+		if len(got) != len(want) {
+			tt.Errorf("%s: rlimit=%d: got %d frames, want %d", filename, rlimit, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i].rect != want[i].rect {
+				tt.Errorf("%s: rlimit=%d: frame #%d: rect: got %v, want %v",
+					filename, rlimit, i, got[i].rect, want[i].rect)
+				break
+			}
+			if !bytes.Equal(got[i].pix, want[i].pix) {
+				tt.Errorf("%s: rlimit=%d: frame #%d: pixels differ", filename, rlimit, i)
+				break
+			}
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                { testDecode(tt, 0) }
+func TestDecodeManyMediumReads(tt *testing.T) { testDecode(tt, 4096) }
+func TestDecodeManySmallReads(tt *testing.T)  { testDecode(tt, 7) }
+
+func TestDecodeInputIsAPNG(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != gif.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, gif.ErrBadHeader)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-dither.gif")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &gif.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader()); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+// encode decodes all of src's frames and re-encodes them, writing at most
+// wlimit bytes at a time (or without limit, if wlimit is non-positive).
+func encode(src []byte, wlimit int) ([]byte, error) {
+	if wlimit <= 0 {
+		wlimit = 1 << 30
+	}
+	d := &gif.Decoder{}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		return nil, err
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+
+	e := &gif.Encoder{}
+	dst := []byte(nil)
+	wbuf := base.IOBuffer{Data: make([]byte, minInt(wlimit, 1<<20))}
+	run := func(f func(base.IOWriter) error) error {
+		for {
+			wbuf.WI = 0
+			status := f(wbuf.Writer())
+			dst = append(dst, wbuf.Data[:wbuf.WI]...)
+			if status != base.SuspensionShortWrite {
+				return status
+			}
+		}
+	}
+
+	if err := run(func(w base.IOWriter) error { return e.EncodeConfig(w, &ic) }); err != nil {
+		return nil, err
+	}
+	for {
+		status := d.DecodeFrame(&ib, r.Reader())
+		if status == base.SuspensionEndOfData {
+			break
+		} else if status != nil {
+			return nil, status
+		}
+		if err := run(func(w base.IOWriter) error { return e.EncodeFrame(w, &ib) }); err != nil {
+			return nil, err
+		}
+	}
+	if err := run(e.EncodeTrailer); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func minInt(x int, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func testEncode(tt *testing.T, wlimit int) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := decode(src, 0)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		enc, err := encode(src, wlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d: %v", filename, wlimit, err)
+			continue
+		}
+
+		// Round-trip through the Wuffs decoder.
+		got, err := decode(enc, 0)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d: decode: %v", filename, wlimit, err)
+			continue
+		}
+		if len(got) != len(want) {
+			tt.Errorf("%s: wlimit=%d: got %d frames, want %d", filename, wlimit, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i].rect != want[i].rect {
+				tt.Errorf("%s: wlimit=%d: frame #%d: rect: got %v, want %v",
+					filename, wlimit, i, got[i].rect, want[i].rect)
+				break
+			}
+			if !bytes.Equal(got[i].pix, want[i].pix) {
+				tt.Errorf("%s: wlimit=%d: frame #%d: pixels differ", filename, wlimit, i)
+				break
+			}
+		}
+
+		// Check the colors, loop count, delays and disposals with Go's
+		// image/gif package, independently of the Wuffs decoder.
+		if err := compareMimic(src, enc); err != nil {
+			tt.Errorf("%s: wlimit=%d: %v", filename, wlimit, err)
+		}
+	}
+}
+
+func compareMimic(src []byte, enc []byte) error {
+	g0, err := stdgif.DecodeAll(bytes.NewReader(src))
+	if err != nil {
+		return err
+	}
+	g1, err := stdgif.DecodeAll(bytes.NewReader(enc))
+	if err != nil {
+		return err
+	}
+	if g0.LoopCount != g1.LoopCount {
+		return fmt.Errorf("loop count: got %d, want %d", g1.LoopCount, g0.LoopCount)
+	}
+	if len(g0.Image) != len(g1.Image) {
+		return fmt.Errorf("got %d frames, want %d", len(g1.Image), len(g0.Image))
+	}
+	for i := range g0.Image {
+		if g0.Delay[i] != g1.Delay[i] {
+			return fmt.Errorf("frame #%d: delay: got %d, want %d", i, g1.Delay[i], g0.Delay[i])
+		}
+		m0, m1 := g0.Image[i], g1.Image[i]
+		b := m0.Bounds()
+		if b != m1.Bounds() {
+			return fmt.Errorf("frame #%d: bounds: got %v, want %v", i, m1.Bounds(), b)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				// The Wuffs decoder doesn't yet report transparent palette
+				// entries, so the re-encoded frame's corresponding pixels
+				// are opaque.
+				r0, g0, b0, a0 := m0.At(x, y).RGBA()
+				r1, g1, b1, _ := m1.At(x, y).RGBA()
+				if a0 != 0 && (r0 != r1 || g0 != g1 || b0 != b1) {
+					return fmt.Errorf("frame #%d: colors differ at (%d, %d)", i, x, y)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestEncode(tt *testing.T)                { testEncode(tt, 0) }
+func TestEncodeManySmallWrites(tt *testing.T) { testEncode(tt, 11) }
+
+func TestEncodeLoopCountAndGraphicControl(tt *testing.T) {
+	ic := base.ImageConfig{}
+	ic.Initialize(uint32(base.PixelFormatBGRANonpremulIndexed), 0, 4, 3, 5)
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	palette := make([]byte, 4*256)
+	for i := 0; i < 4; i++ {
+		palette[4*i+0] = uint8(0x10 * i)
+		palette[4*i+1] = uint8(0x20 * i)
+		palette[4*i+2] = uint8(0x30 * i)
+		palette[4*i+3] = 0xFF
+	}
+	// Palette entry 3 is transparent.
+	palette[4*3+3] = 0x00
+	for i := range ib.Plane(0).Data {
+		ib.Plane(0).Data[i] = uint8(i & 3)
+	}
+	// 7056000 flicks is one centisecond.
+	ib.Update(base.RectIEU32{MinInclusiveX: 1, MinInclusiveY: 0, MaxExclusiveX: 4, MaxExclusiveY: 2},
+		25*7056000, false, 1, palette)
+
+	e := &gif.Encoder{}
+	w := base.IOBuffer{Data: make([]byte, 1024)}
+	if err := e.EncodeConfig(w.Writer(), &ic); err != nil {
+		tt.Fatalf("EncodeConfig: %v", err)
+	}
+	if err := e.EncodeFrame(w.Writer(), &ib); err != nil {
+		tt.Fatalf("EncodeFrame: %v", err)
+	}
+	if err := e.EncodeTrailer(w.Writer()); err != nil {
+		tt.Fatalf("EncodeTrailer: %v", err)
+	}
+
+	g, err := stdgif.DecodeAll(bytes.NewReader(w.Data[:w.WI]))
+	if err != nil {
+		tt.Fatalf("DecodeAll: %v", err)
+	}
+	// A num_loops of 5 means 4 repeats after the first play.
+	if got, want := g.LoopCount, 4; got != want {
+		tt.Errorf("LoopCount: got %d, want %d", got, want)
+	}
+	if got, want := len(g.Image), 1; got != want {
+		tt.Fatalf("len(Image): got %d, want %d", got, want)
+	}
+	if got, want := g.Delay[0], 25; got != want {
+		tt.Errorf("Delay: got %d, want %d", got, want)
+	}
+	if got, want := g.Disposal[0], uint8(stdgif.DisposalBackground); got != want {
+		tt.Errorf("Disposal: got %d, want %d", got, want)
+	}
+	m := g.Image[0]
+	if got, want := m.Bounds(), image.Rect(1, 0, 4, 2); got != want {
+		tt.Errorf("Bounds: got %v, want %v", got, want)
+	}
+	if _, _, _, a := m.Palette[3].RGBA(); a != 0 {
+		tt.Errorf("palette entry 3: got alpha %d, want 0", a)
+	}
+	for y := 0; y < 2; y++ {
+		for x := 1; x < 4; x++ {
+			if got, want := m.ColorIndexAt(x, y), uint8((4*y+x)&3); got != want {
+				tt.Errorf("ColorIndexAt(%d, %d): got %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodeCallSequence(tt *testing.T) {
+	w := base.IOBuffer{Data: make([]byte, 1024)}
+	e := &gif.Encoder{}
+	if err := e.EncodeFrame(w.Writer(), &base.ImageBuffer{}); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			_, err = stdgif.DecodeAll(bytes.NewReader(src))
+		} else {
+			_, err = decode(src, 0)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsGifDecode_1k_bw(b *testing.B) { benchDecode(b, "pjw-thumbnail.gif", false) }
+func BenchmarkWuffsGifDecode_10k(b *testing.B)   { benchDecode(b, "hat.gif", false) }
+func BenchmarkWuffsGifDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.gif", false) }
+func BenchmarkWuffsGifDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.gif", false) }
+func BenchmarkMimicGifDecode_1k_bw(b *testing.B) { benchDecode(b, "pjw-thumbnail.gif", true) }
+func BenchmarkMimicGifDecode_10k(b *testing.B)   { benchDecode(b, "hat.gif", true) }
+func BenchmarkMimicGifDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.gif", true) }
+func BenchmarkMimicGifDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.gif", true) }
@@ -0,0 +1,275 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gzip_test
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/wuffs/gen/go/std/gzip"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"midsummer.txt.gz",
+	"pi.txt.gz",
+	"romeo.txt.gz",
+}
+
+func mimicDecode(src []byte) ([]byte, error) {
+	r, err := stdgzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func testDecode(tt *testing.T, wlimit int, rlimit int) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := mimicDecode(src)
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+		got, err := testlib.Decode(&gzip.Decoder{}, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                    { testDecode(tt, 0, 0) }
+func TestDecodeManySmallReads(tt *testing.T)      { testDecode(tt, 0, 7) }
+func TestDecodeManySmallWrites(tt *testing.T)     { testDecode(tt, 11, 0) }
+func TestDecodeManyTinyWritesReads(tt *testing.T) { testDecode(tt, 1, 1) }
+
+func TestDecodeBadChecksum(tt *testing.T) {
+	src, err := testlib.ReadFile("romeo.txt.gz")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	src = append([]byte(nil), src...)
+	src[len(src)-8] ^= 1
+
+	if _, err := testlib.Decode(&gzip.Decoder{}, src, 0, 0); err != gzip.ErrBadChecksum {
+		tt.Fatalf("got %v, want %v", err, gzip.ErrBadChecksum)
+	}
+
+	d := &gzip.Decoder{}
+	d.SetIgnoreChecksum(true)
+	if _, err := testlib.Decode(d, src, 0, 0); err != nil {
+		tt.Fatalf("ignoring the checksum: got %v, want nil", err)
+	}
+}
+
+// headerSrc is an empty gzip member whose header has FEXTRA, FNAME, FCOMMENT
+// and FHCRC fields. The standard library's writer doesn't write FHCRC fields,
+// so this is hand-crafted. The name and comment are ISO 8859-1 encoded.
+var headerSrc = []byte{
+	0x1F, 0x8B, 0x08, 0x1E, 0xD2, 0x02, 0x96, 0x49, 0x00, 0x03, 0x02, 0x00,
+	0xAB, 0xCD, 0x63, 0x61, 0x66, 0xE9, 0x2E, 0x74, 0x78, 0x74, 0x00, 0xA9,
+	0x20, 0x32, 0x30, 0x31, 0x38, 0x20, 0xC0, 0x20, 0x6C, 0x61, 0x20, 0x63,
+	0x61, 0x72, 0x74, 0x65, 0x00, 0x0D, 0x61, 0x03, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+func TestDecodeHeaderFields(tt *testing.T) {
+	d := &gzip.Decoder{}
+	if _, err := testlib.Decode(d, headerSrc, 0, 0); err != nil {
+		tt.Fatal(err)
+	}
+	if got, want := d.ModificationTime(), uint32(1234567890); got != want {
+		tt.Errorf("ModificationTime: got %d, want %d", got, want)
+	}
+	if got, want := d.OperatingSystem(), uint8(3); got != want {
+		tt.Errorf("OperatingSystem: got %d, want %d", got, want)
+	}
+	if got, want := string(d.FileName()), "café.txt"; got != want {
+		tt.Errorf("FileName: got %q, want %q", got, want)
+	}
+	if got, want := string(d.Comment()), "© 2018 À la carte"; got != want {
+		tt.Errorf("Comment: got %q, want %q", got, want)
+	}
+
+	// The standard library agrees.
+	r, err := stdgzip.NewReader(bytes.NewReader(headerSrc))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if got, want := string(d.FileName()), r.Name; got != want {
+		tt.Errorf("FileName: got %q, want %q (mimic)", got, want)
+	}
+	if got, want := string(d.Comment()), r.Comment; got != want {
+		tt.Errorf("Comment: got %q, want %q (mimic)", got, want)
+	}
+}
+
+func TestDecodeHeaderFieldsMimic(tt *testing.T) {
+	// The standard library's writer converts the UTF-8 name and comment to
+	// ISO 8859-1, and the Wuffs decoder converts them back.
+	want := stdgzip.Header{
+		Name:    "Ærøskøbing.txt",
+		Comment: "hello",
+		ModTime: time.Unix(1500000000, 0),
+		OS:      11,
+	}
+	buf := &bytes.Buffer{}
+	w := stdgzip.NewWriter(buf)
+	w.Header = want
+	w.Write([]byte("Hello, world.\n"))
+	if err := w.Close(); err != nil {
+		tt.Fatal(err)
+	}
+
+	d := &gzip.Decoder{}
+	if _, err := testlib.Decode(d, buf.Bytes(), 0, 0); err != nil {
+		tt.Fatal(err)
+	}
+	if got := string(d.FileName()); got != want.Name {
+		tt.Errorf("FileName: got %q, want %q", got, want.Name)
+	}
+	if got := string(d.Comment()); got != want.Comment {
+		tt.Errorf("Comment: got %q, want %q", got, want.Comment)
+	}
+	if got := int64(d.ModificationTime()); got != want.ModTime.Unix() {
+		tt.Errorf("ModificationTime: got %d, want %d", got, want.ModTime.Unix())
+	}
+	if got := d.OperatingSystem(); got != want.OS {
+		tt.Errorf("OperatingSystem: got %d, want %d", got, want.OS)
+	}
+}
+
+func TestDecodeBadHeaderChecksum(tt *testing.T) {
+	src := append([]byte(nil), headerSrc...)
+	src[4] ^= 1
+
+	if _, err := testlib.Decode(&gzip.Decoder{}, src, 0, 0); err != gzip.ErrBadHeaderChecksum {
+		tt.Fatalf("got %v, want %v", err, gzip.ErrBadHeaderChecksum)
+	}
+
+	d := &gzip.Decoder{}
+	d.SetIgnoreChecksum(true)
+	if _, err := testlib.Decode(d, src, 0, 0); err != nil {
+		tt.Fatalf("ignoring the checksum: got %v, want nil", err)
+	}
+}
+
+func testDecodeMultiMember(tt *testing.T, wlimit int, rlimit int) {
+	src := []byte(nil)
+	for _, filename := range goldens {
+		s, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		src = append(src, s...)
+	}
+	want, err := mimicDecode(src)
+	if err != nil {
+		tt.Fatalf("mimic: %v", err)
+	}
+
+	d := &gzip.Decoder{}
+	d.SetMultiMember(true)
+	got, err := testlib.Decode(d, src, wlimit, rlimit)
+	if err != nil {
+		tt.Fatalf("wlimit=%d, rlimit=%d: %v", wlimit, rlimit, err)
+	}
+	if !bytes.Equal(got, want) {
+		tt.Fatalf("wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+			wlimit, rlimit, len(got), len(want))
+	}
+	if got, want := string(d.FileName()), "romeo.txt"; got != want {
+		tt.Errorf("FileName: got %q, want the last member's %q", got, want)
+	}
+
+	// Without the multi-member option, decoding stops after the first member.
+	got, err = testlib.Decode(&gzip.Decoder{}, src, wlimit, rlimit)
+	if err != nil {
+		tt.Fatalf("single member: wlimit=%d, rlimit=%d: %v", wlimit, rlimit, err)
+	}
+	if first, err := mimicDecodeFirstMember(src); err != nil {
+		tt.Fatalf("single member: mimic: %v", err)
+	} else if !bytes.Equal(got, first) {
+		tt.Fatalf("single member: got %d bytes, want %d bytes", len(got), len(first))
+	}
+}
+
+func mimicDecodeFirstMember(src []byte) ([]byte, error) {
+	r, err := stdgzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	r.Multistream(false)
+	return ioutil.ReadAll(r)
+}
+
+func TestDecodeMultiMember(tt *testing.T)               { testDecodeMultiMember(tt, 0, 0) }
+func TestDecodeMultiMemberManySmallReads(tt *testing.T) { testDecodeMultiMember(tt, 0, 7) }
+
+// testEncode checks that gzip.Encoder output round-trips through both Wuffs
+// and the standard library.
+func testEncode(tt *testing.T, wlimit int, rlimit int) {
+	testlib.CheckRoundTrips(tt, newEncoder, newDecoder, mimicDecode, wlimit, rlimit)
+}
+
+func newEncoder(level uint32) testlib.Encoder {
+	e := &gzip.Encoder{}
+	e.SetLevel(level)
+	return e
+}
+
+func newDecoder() testlib.Decoder { return &gzip.Decoder{} }
+
+func TestEncode(tt *testing.T)                     { testEncode(tt, 0, 0) }
+func TestEncodeManySmallWritesReads(tt *testing.T) { testEncode(tt, 59, 61) }
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	want, err := mimicDecode(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(want)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			_, err = mimicDecode(src)
+		} else {
+			_, err = testlib.Decode(&gzip.Decoder{}, src, 0, 0)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsGzipDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.gz", false) }
+func BenchmarkWuffsGzipDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.gz", false) }
+func BenchmarkMimicGzipDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.gz", true) }
+func BenchmarkMimicGzipDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.gz", true) }
@@ -0,0 +1,91 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ico_test
+
+import (
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/ico"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens are the test/data/bmpsuite ICO and CUR files, generated by
+// script/make-bmpsuite.go. Each "foo.ico" or "foo.cur" file should decode to
+// the same pixels as the "foo.png" file.
+var goldens = []string{
+	"bmpsuite/cursor.cur",
+	"bmpsuite/icon-32bpp.ico",
+	"bmpsuite/icon-bmp.ico",
+	"bmpsuite/icon-png.ico",
+}
+
+// decode decodes src's BGRA_NONPREMUL pixels, feeding the decoder at most
+// rlimit bytes at a time (or all of src, if rlimit is non-positive).
+func decode(d *ico.Decoder, src []byte, rlimit int) ([]byte, error) {
+	return testlib.DecodeImage(d, src, rlimit, testlib.ImageDecodeOptions{CheckEndOfData: true})
+}
+
+func TestDecode(tt *testing.T) {
+	gs := []testlib.ImageGolden(nil)
+	for _, filename := range goldens {
+		gs = append(gs, testlib.ImageGolden{Filename: filename, PNGFilename: filename[:len(filename)-4] + ".png"})
+	}
+	testlib.CheckImageGoldens(tt, gs, func(src []byte, rlimit int) ([]byte, error) {
+		return decode(&ico.Decoder{}, src, rlimit)
+	})
+}
+
+func TestDecodeCursor(tt *testing.T) {
+	src, err := testlib.ReadFile("bmpsuite/cursor.cur")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	d := &ico.Decoder{}
+	if _, err := decode(d, src, 0); err != nil {
+		tt.Fatal(err)
+	}
+	if !d.IsCursor() {
+		tt.Errorf("IsCursor: got false, want true")
+	}
+	if got := d.NumImages(); got != 2 {
+		tt.Errorf("NumImages: got %d, want 2", got)
+	}
+	// The largest sub-image's hotspot is (17, 14).
+	if x, y := d.HotspotX(), d.HotspotY(); (x != 17) || (y != 14) {
+		tt.Errorf("hotspot: got (%d, %d), want (17, 14)", x, y)
+	}
+}
+
+func TestDecodeInputIsABMP(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.bmp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(&ico.Decoder{}, src, 0); err != ico.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, ico.ErrBadHeader)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bmpsuite/icon-bmp.ico")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &ico.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader(), nil); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jpeg_test
+
+import (
+	"bytes"
+	"image"
+	stdjpeg "image/jpeg"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/jpeg"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"bricks-color.jpeg",
+	"bricks-gray.jpeg",
+	"harvesters.jpeg",
+	"hat.jpeg",
+	"hibiscus.jpeg",
+	"hippopotamus.jpeg",
+	"pjw-thumbnail.jpeg",
+}
+
+// jpegsuiteGoldens are the test/data/jpegsuite files, generated by
+// script/make-jpegsuite.c, other than those that Wuffs does not support.
+var jpegsuiteGoldens = []string{
+	"baseline-gray",
+	"baseline-444",
+	"baseline-440",
+	"baseline-422",
+	"baseline-420",
+	"baseline-420-noninterleaved",
+	"baseline-420-optimized",
+	"baseline-420-restart",
+	"progressive-gray",
+	"progressive-444",
+	"progressive-440",
+	"progressive-422",
+	"progressive-420",
+	"progressive-420-restart",
+	"progressive-444-tiled",
+}
+
+func init() {
+	for _, g := range jpegsuiteGoldens {
+		goldens = append(goldens, "jpegsuite/"+g+".jpeg")
+	}
+}
+
+// mimicUnsupported are the goldens that the standard library's image/jpeg
+// package cannot decode, even though libjpeg can. For progressive JPEGs with
+// restart markers, it reports "missing 0xff00 sequence". For these, testDecode
+// instead compares Wuffs' output against a single-read decoding.
+var mimicUnsupported = map[string]bool{
+	"jpegsuite/progressive-420-restart.jpeg": true,
+}
+
+// decode decodes src's Y or YUV pixels, feeding the decoder at most rlimit
+// bytes at a time (or all of src, if rlimit is non-positive). It returns one
+// slice per plane, each with no padding between rows.
+func decode(src []byte, rlimit int) ([][]byte, error) {
+	if rlimit <= 0 {
+		rlimit = len(src)
+	}
+	d := &jpeg.Decoder{}
+	r := base.IOBuffer{Data: src}
+	feed := func() bool {
+		if r.Closed {
+			return false
+		}
+		r.WI += rlimit
+		if r.WI >= len(src) {
+			r.WI = len(src)
+			r.Closed = true
+		}
+		return true
+	}
+	feed()
+
+	ic := base.ImageConfig{}
+	for {
+		status := d.DecodeConfig(&ic, r.Reader())
+		if status == nil {
+			break
+		} else if status != base.SuspensionShortRead || !feed() {
+			return nil, status
+		}
+	}
+
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen())
+	for {
+		status := d.DecodeFrame(&ib, r.Reader(), workbuf)
+		if status == nil {
+			break
+		} else if status != base.SuspensionShortRead || !feed() {
+			return nil, status
+		}
+	}
+	if r.RI != len(src) {
+		return nil, errSrcNotExhausted
+	}
+
+	planes := [][]byte(nil)
+	for p := uint32(0); p < ic.PixelFormat().NumPlanes(); p++ {
+		planes = append(planes, ib.Plane(p).Data)
+	}
+	return planes, nil
+}
+
+var errSrcNotExhausted = base.NewError("src was not exhausted")
+
+// mimicDecode decodes src via the standard library's image/jpeg package,
+// returning the unconverted Y or YCbCr planes, with no padding between rows.
+func mimicDecode(src []byte) ([][]byte, error) {
+	m, err := stdjpeg.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	switch m := m.(type) {
+	case *image.Gray:
+		b := m.Bounds()
+		return [][]byte{crop(m.Pix, m.Stride, b.Dx(), b.Dy())}, nil
+	case *image.YCbCr:
+		b := m.Bounds()
+		cw, ch := b.Dx(), b.Dy()
+		switch m.SubsampleRatio {
+		case image.YCbCrSubsampleRatio440:
+			ch = (ch + 1) / 2
+		case image.YCbCrSubsampleRatio422:
+			cw = (cw + 1) / 2
+		case image.YCbCrSubsampleRatio420:
+			cw, ch = (cw+1)/2, (ch+1)/2
+		}
+		return [][]byte{
+			crop(m.Y, m.YStride, b.Dx(), b.Dy()),
+			crop(m.Cb, m.CStride, cw, ch),
+			crop(m.Cr, m.CStride, cw, ch),
+		}, nil
+	}
+	return nil, errUnexpectedImageType
+}
+
+var errUnexpectedImageType = base.NewError("unexpected image type")
+
+func crop(pix []byte, stride int, w int, h int) []byte {
+	ret := make([]byte, 0, w*h)
+	for y := 0; y < h; y++ {
+		ret = append(ret, pix[y*stride:y*stride+w]...)
+	}
+	return ret
+}
+
+// tolerance is the maximum per-sample difference between Wuffs and the
+// standard library. Wuffs mimics libjpeg's integer IDCT (Inverse Discrete
+// Cosine Transform) exactly, but image/jpeg uses a different IDCT algorithm,
+// so their outputs can differ slightly.
+const tolerance = 1
+
+func testDecode(tt *testing.T, rlimit int) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := [][]byte(nil), error(nil)
+		if mimicUnsupported[filename] {
+			want, err = decode(src, 0)
+		} else {
+			want, err = mimicDecode(src)
+		}
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+		got, err := decode(src, rlimit)
+		if err != nil {
+			tt.Errorf("%s: rlimit=%d: %v", filename, rlimit, err)
+			continue
+		}
+		if len(got) != len(want) {
+			tt.Errorf("%s: rlimit=%d: number of planes: got %d, want %d",
+				filename, rlimit, len(got), len(want))
+			continue
+		}
+		for p := range got {
+			if len(got[p]) != len(want[p]) {
+				tt.Errorf("%s: rlimit=%d: plane %d: length: got %d, want %d",
+					filename, rlimit, p, len(got[p]), len(want[p]))
+				continue
+			}
+			for i := range got[p] {
+				delta := int(got[p][i]) - int(want[p][i])
+				if (delta < -tolerance) || (tolerance < delta) {
+					tt.Errorf("%s: rlimit=%d: plane %d: sample %d: got %d, want %d",
+						filename, rlimit, p, i, got[p][i], want[p][i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                { testDecode(tt, 0) }
+func TestDecodeManyMediumReads(tt *testing.T) { testDecode(tt, 4096) }
+func TestDecodeManySmallReads(tt *testing.T)  { testDecode(tt, 7) }
+
+func TestDecodeArithmeticCoding(tt *testing.T) {
+	src, err := testlib.ReadFile("jpegsuite/arithmetic-420.jpeg")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != jpeg.ErrTODOUnsupportedArithmeticCoding {
+		tt.Fatalf("got %v, want %v", err, jpeg.ErrTODOUnsupportedArithmeticCoding)
+	}
+}
+
+func TestDecodeBadWorkbufLength(tt *testing.T) {
+	src, err := testlib.ReadFile("jpegsuite/progressive-444-tiled.jpeg")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &jpeg.Decoder{}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatal(err)
+	}
+	// progressive-444-tiled.jpeg is 314 × 234 pixels, or 40 × 30 blocks, for
+	// each of its 3 components. Each block's coefficients take 128 bytes.
+	if got, want := d.WorkbufLen(), uint64(40*30*3*128); got != want {
+		tt.Fatalf("WorkbufLen: got %d, want %d", got, want)
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen()-1)
+	if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != jpeg.ErrBadWorkbufLength {
+		tt.Fatalf("got %v, want %v", err, jpeg.ErrBadWorkbufLength)
+	}
+}
+
+func TestDecodeInputIsAPNG(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != jpeg.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, jpeg.ErrBadHeader)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.jpeg")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &jpeg.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader(), nil); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			_, err = stdjpeg.Decode(bytes.NewReader(src))
+		} else {
+			_, err = decode(src, 0)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsJpegDecode_1k(b *testing.B)    { benchDecode(b, "hippopotamus.jpeg", false) }
+func BenchmarkWuffsJpegDecode_10k(b *testing.B)   { benchDecode(b, "hat.jpeg", false) }
+func BenchmarkWuffsJpegDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.jpeg", false) }
+func BenchmarkWuffsJpegDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.jpeg", false) }
+func BenchmarkMimicJpegDecode_1k(b *testing.B)    { benchDecode(b, "hippopotamus.jpeg", true) }
+func BenchmarkMimicJpegDecode_10k(b *testing.B)   { benchDecode(b, "hat.jpeg", true) }
+func BenchmarkMimicJpegDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.jpeg", true) }
+func BenchmarkMimicJpegDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.jpeg", true) }
@@ -0,0 +1,203 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package lzw_test
+
+import (
+	"bytes"
+	"compress/lzw"
+	"io/ioutil"
+	"testing"
+
+	wuffslzw "github.com/google/wuffs/gen/go/std/lzw"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens' keys are the compressed filenames, whose first byte is the LZW
+// literal width. The values are the decompressed filenames.
+var goldens = map[string]string{
+	"bricks-dither.indexes.giflzw":   "bricks-dither.indexes",
+	"bricks-gray.indexes.giflzw":     "bricks-gray.indexes",
+	"bricks-nodither.indexes.giflzw": "bricks-nodither.indexes",
+	"pi.txt.giflzw":                  "pi.txt",
+}
+
+func readGolden(tb testing.TB, filename string) (literalWidth uint32, src []byte, want []byte) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		tb.Fatalf("%s: %v", filename, err)
+	}
+	if len(src) == 0 {
+		tb.Fatalf("%s: empty file", filename)
+	}
+	want, err = testlib.ReadFile(goldens[filename])
+	if err != nil {
+		tb.Fatalf("%s: %v", filename, err)
+	}
+	return uint32(src[0]), src[1:], want
+}
+
+func testDecode(tt *testing.T, wlimit int, rlimit int) {
+	for filename := range goldens {
+		literalWidth, src, want := readGolden(tt, filename)
+		d := &wuffslzw.Decoder{}
+		d.SetLiteralWidth(literalWidth)
+		got, err := testlib.Decode(d, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                     { testDecode(tt, 0, 0) }
+func TestDecodeManyBigReads(tt *testing.T)         { testDecode(tt, 4096, 4096) }
+func TestDecodeManySmallWritesReads(tt *testing.T) { testDecode(tt, 41, 43) }
+func TestDecodeManyTinyWritesReads(tt *testing.T)  { testDecode(tt, 1, 1) }
+
+// testDecodeTIFF checks the TIFF mode. Unlike *.giflzw files, *.tifflzw files
+// have no initial literal width byte.
+func testDecodeTIFF(tt *testing.T, wlimit int, rlimit int) {
+	src, err := testlib.ReadFile("pi.txt.tifflzw")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	want, err := testlib.ReadFile("pi.txt")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	d := &wuffslzw.Decoder{}
+	d.SetTiffMode(true)
+	got, err := testlib.Decode(d, src, wlimit, rlimit)
+	if err != nil {
+		tt.Fatalf("wlimit=%d, rlimit=%d: %v", wlimit, rlimit, err)
+	}
+	if !bytes.Equal(got, want) {
+		tt.Fatalf("wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+			wlimit, rlimit, len(got), len(want))
+	}
+}
+
+func TestDecodeTIFF(tt *testing.T)                     { testDecodeTIFF(tt, 0, 0) }
+func TestDecodeTIFFManySmallWritesReads(tt *testing.T) { testDecodeTIFF(tt, 41, 43) }
+
+func mimicEncode(src []byte, literalWidth uint32) []byte {
+	buf := &bytes.Buffer{}
+	w := lzw.NewWriter(buf, lzw.LSB, int(literalWidth))
+	w.Write(src)
+	w.Close()
+	return buf.Bytes()
+}
+
+// testEncode checks that encoding each golden's decompressed file gives
+// exactly what Go's compress/lzw package gives, and that decoding that gets
+// back the original.
+func testEncode(tt *testing.T, wlimit int, rlimit int) {
+	for filename := range goldens {
+		literalWidth, _, src := readGolden(tt, filename)
+		e := &wuffslzw.Encoder{}
+		e.SetLiteralWidth(literalWidth)
+		encoded, err := testlib.Encode(e, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: encode: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if want := mimicEncode(src, literalWidth); !bytes.Equal(encoded, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: encode: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(encoded), len(want))
+			continue
+		}
+
+		d := &wuffslzw.Decoder{}
+		d.SetLiteralWidth(literalWidth)
+		got, err := testlib.Decode(d, encoded, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: decode: %v", filename, wlimit, rlimit, err)
+		} else if !bytes.Equal(got, src) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: decode: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(src))
+		}
+	}
+}
+
+func TestEncode(tt *testing.T)                     { testEncode(tt, 0, 0) }
+func TestEncodeManySmallWritesReads(tt *testing.T) { testEncode(tt, 41, 43) }
+
+func TestEncodeLiteralWidths(tt *testing.T) {
+	src, err := testlib.ReadFile("pi.txt")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	for literalWidth := uint32(2); literalWidth <= 8; literalWidth++ {
+		// Mask the input so that every byte is a valid literal.
+		want := make([]byte, len(src))
+		for i, c := range src {
+			want[i] = c & uint8((1<<literalWidth)-1)
+		}
+
+		e := &wuffslzw.Encoder{}
+		e.SetLiteralWidth(literalWidth)
+		encoded, err := testlib.Encode(e, want, 0, 0)
+		if err != nil {
+			tt.Errorf("literalWidth=%d: encode: %v", literalWidth, err)
+			continue
+		}
+
+		d := &wuffslzw.Decoder{}
+		d.SetLiteralWidth(literalWidth)
+		got, err := testlib.Decode(d, encoded, 0, 0)
+		if err != nil {
+			tt.Errorf("literalWidth=%d: decode: %v", literalWidth, err)
+		} else if !bytes.Equal(got, want) {
+			tt.Errorf("literalWidth=%d: decode: got %d bytes, want %d bytes",
+				literalWidth, len(got), len(want))
+		}
+
+		got, err = ioutil.ReadAll(lzw.NewReader(bytes.NewReader(encoded), lzw.LSB, int(literalWidth)))
+		if err != nil {
+			tt.Errorf("literalWidth=%d: mimic: %v", literalWidth, err)
+		} else if !bytes.Equal(got, want) {
+			tt.Errorf("literalWidth=%d: mimic: got %d bytes, want %d bytes",
+				literalWidth, len(got), len(want))
+		}
+	}
+}
+
+func TestEncodeBadLiteral(tt *testing.T) {
+	e := &wuffslzw.Encoder{}
+	e.SetLiteralWidth(4)
+	_, err := testlib.Encode(e, []byte{0x01, 0x0F, 0x10}, 0, 0)
+	if err != wuffslzw.ErrBadLiteral {
+		tt.Fatalf("got %v, want %v", err, wuffslzw.ErrBadLiteral)
+	}
+}
+
+func benchDecode(b *testing.B, filename string) {
+	literalWidth, src, want := readGolden(b, filename)
+	b.SetBytes(int64(len(want)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := &wuffslzw.Decoder{}
+		d.SetLiteralWidth(literalWidth)
+		if _, err := testlib.Decode(d, src, 0, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsLzwDecode_20k(b *testing.B)  { benchDecode(b, "bricks-gray.indexes.giflzw") }
+func BenchmarkWuffsLzwDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.giflzw") }
@@ -0,0 +1,235 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package png_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	stdpng "image/png"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/png"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"bricks-color.png",
+	"bricks-dither.png",
+	"bricks-gray.png",
+	"bricks-nodither.png",
+	"harvesters.png",
+	"hat.png",
+	"hibiscus.png",
+	"hippopotamus.png",
+	"pjw-thumbnail.png",
+}
+
+// pngsuiteGoldens are the test/data/pngsuite files, generated by
+// script/make-pngsuite.go, other than those that are deliberately invalid.
+var pngsuiteGoldens = []string{
+	"basn0g01", "basn0g02", "basn0g04", "basn0g08", "basn0g16",
+	"basn2c08", "basn2c16",
+	"basn3p01", "basn3p02", "basn3p04", "basn3p08",
+	"basn4a08", "basn4a16",
+	"basn6a08", "basn6a16",
+	"trnn0g04", "trnn0g08", "trnn0g16",
+	"trnn2c08", "trnn2c16",
+	"trnn3p04", "trnn3p08",
+	"s01n3p04", "s02n3p04", "s03n3p04",
+	"s05n3p04", "s07n3p04", "s09n3p04",
+}
+
+func init() {
+	for _, g := range pngsuiteGoldens {
+		goldens = append(goldens,
+			"pngsuite/"+g+".png",
+			"pngsuite/"+g[:3]+"i"+g[4:]+".png",
+		)
+	}
+}
+
+// decode decodes src's BGRA_NONPREMUL pixels, feeding the decoder at most
+// rlimit bytes at a time (or all of src, if rlimit is non-positive).
+func decode(src []byte, rlimit int, ignoreChecksum bool) ([]byte, error) {
+	d := &png.Decoder{}
+	d.SetIgnoreChecksum(ignoreChecksum)
+	return testlib.DecodeImage(d, src, rlimit, testlib.ImageDecodeOptions{CheckExhausted: true})
+}
+
+// TestDecode checks that each PNG file decodes to the same pixels as the
+// standard library's image/png package decodes it to.
+func TestDecode(tt *testing.T) {
+	gs := []testlib.ImageGolden(nil)
+	for _, filename := range goldens {
+		gs = append(gs, testlib.ImageGolden{Filename: filename, PNGFilename: filename})
+	}
+	testlib.CheckImageGoldens(tt, gs, func(src []byte, rlimit int) ([]byte, error) {
+		return decode(src, rlimit, false)
+	})
+}
+
+func TestDecodeBadChecksum(tt *testing.T) {
+	src, err := testlib.ReadFile("pngsuite/xcsn2c08.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0, false); err != png.ErrBadChecksum {
+		tt.Fatalf("got %v, want %v", err, png.ErrBadChecksum)
+	}
+
+	got, err := decode(src, 0, true)
+	if err != nil {
+		tt.Fatalf("ignore checksum: %v", err)
+	}
+	src, err = testlib.ReadFile("pngsuite/basn2c08.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	want, err := decode(src, 0, false)
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		tt.Fatalf("ignore checksum: pixels differ")
+	}
+}
+
+func TestDecodeInputIsAGIF(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-dither.gif")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0, false); err != png.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, png.ErrBadHeader)
+	}
+}
+
+// TestDecodeWide checks images whose rows are longer than any fixed size row
+// buffer would be: the decoder's work buffer is sized from the header.
+func TestDecodeWide(tt *testing.T) {
+	testCases := []struct {
+		name string
+		m    image.Image
+	}{
+		{"8192 px RGBA8", image.NewNRGBA(image.Rect(0, 0, 8192, 3))},
+		{"4096 px RGBA16", image.NewNRGBA64(image.Rect(0, 0, 4096, 3))},
+		{"10000 px RGB", image.NewNRGBA(image.Rect(0, 0, 10000, 3))},
+		{"33000 px gray", image.NewGray(image.Rect(0, 0, 33000, 3))},
+	}
+	for _, tc := range testCases {
+		b := tc.m.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				v := uint8((x * x >> 4) + (x * y * 3))
+				switch m := tc.m.(type) {
+				case *image.NRGBA:
+					a := v | 0x80
+					if tc.name == "10000 px RGB" {
+						a = 0xFF
+					}
+					m.SetNRGBA(x, y, color.NRGBA{v, uint8(x), uint8(y), a})
+				case *image.NRGBA64:
+					m.SetNRGBA64(x, y, color.NRGBA64{uint16(v) << 8, uint16(x), 0x1234, 0x8000 | uint16(v)})
+				case *image.Gray:
+					m.SetGray(x, y, color.Gray{v})
+				}
+			}
+		}
+		buf := &bytes.Buffer{}
+		if err := stdpng.Encode(buf, tc.m); err != nil {
+			tt.Fatalf("%s: %v", tc.name, err)
+		}
+		src := buf.Bytes()
+		want, err := testlib.DecodePNG(src)
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", tc.name, err)
+		}
+		for _, rlimit := range []int{0, 4096} {
+			got, err := decode(src, rlimit, false)
+			if err != nil {
+				tt.Errorf("%s: rlimit=%d: %v", tc.name, rlimit, err)
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				tt.Errorf("%s: rlimit=%d: pixels differ", tc.name, rlimit)
+			}
+		}
+	}
+}
+
+func TestDecodeBadWorkbufLength(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &png.Decoder{}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatal(err)
+	}
+	// bricks-color.png is 160 pixels wide, with 3 bytes per pixel. Each of the
+	// work buffer's two rows also holds a filter type byte.
+	if got, want := d.WorkbufLen(), uint64(2*((160*3)+1)); got != want {
+		tt.Fatalf("WorkbufLen: got %d, want %d", got, want)
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen()-1)
+	if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != png.ErrBadWorkbufLength {
+		tt.Fatalf("got %v, want %v", err, png.ErrBadWorkbufLength)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &png.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader(), nil); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			_, err = stdpng.Decode(bytes.NewReader(src))
+		} else {
+			_, err = decode(src, 0, false)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsPngDecode_1k_bw(b *testing.B) { benchDecode(b, "pjw-thumbnail.png", false) }
+func BenchmarkWuffsPngDecode_10k(b *testing.B)   { benchDecode(b, "hat.png", false) }
+func BenchmarkWuffsPngDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.png", false) }
+func BenchmarkWuffsPngDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.png", false) }
+func BenchmarkMimicPngDecode_1k_bw(b *testing.B) { benchDecode(b, "pjw-thumbnail.png", true) }
+func BenchmarkMimicPngDecode_10k(b *testing.B)   { benchDecode(b, "hat.png", true) }
+func BenchmarkMimicPngDecode_100k(b *testing.B)  { benchDecode(b, "hibiscus.png", true) }
+func BenchmarkMimicPngDecode_1000k(b *testing.B) { benchDecode(b, "harvesters.png", true) }
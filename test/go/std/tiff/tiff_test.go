@@ -0,0 +1,286 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tiff_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/tiff"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens are TIFF files, without the ".tiff" suffix. Each "foo.tiff" file
+// should decode to the same pixels as the "foo.png" file. The tiffsuite files
+// were generated by script/make-tiffsuite.go.
+var goldens = []string{
+	"bricks-color",
+	"bricks-gray",
+	"harvesters",
+	"hat",
+	"hibiscus",
+	"hippopotamus",
+	"pjw-thumbnail",
+	"tiffsuite/gray",
+	"tiffsuite/gray-alpha",
+	"tiffsuite/gray-white-is-zero",
+	"tiffsuite/gray1-white-is-zero",
+	"tiffsuite/gray2-be",
+	"tiffsuite/gray4",
+	"tiffsuite/palette4",
+	"tiffsuite/palette8",
+	"tiffsuite/palette8-be",
+	"tiffsuite/rgb-deflate",
+	"tiffsuite/rgb-lzw",
+	"tiffsuite/rgb-lzw-predictor",
+	"tiffsuite/rgb-lzw-predictor-be",
+	"tiffsuite/rgb-none",
+	"tiffsuite/rgb-none-be",
+	"tiffsuite/rgb-old-deflate",
+	"tiffsuite/rgb-packbits",
+	"tiffsuite/rgb-tiles-deflate-be",
+	"tiffsuite/rgb-tiles-lzw",
+	"tiffsuite/rgb-tiles-packbits",
+	"tiffsuite/rgba",
+	"tiffsuite/rgba-associated",
+}
+
+// decode decodes src's BGRA_NONPREMUL pixels, feeding the decoder at most
+// rlimit bytes at a time (or all of src, if rlimit is non-positive). It also
+// moves the reader when the decoder asks it to seek.
+func decode(src []byte, rlimit int) ([]byte, error) {
+	return testlib.DecodeImage(&tiff.Decoder{}, src, rlimit, testlib.ImageDecodeOptions{
+		CheckEndOfData:         true,
+		SuspensionSeekRequired: tiff.SuspensionSeekRequired,
+	})
+}
+
+func TestDecode(tt *testing.T) {
+	gs := []testlib.ImageGolden(nil)
+	for _, g := range goldens {
+		gs = append(gs, testlib.ImageGolden{Filename: g + ".tiff", PNGFilename: g + ".png"})
+	}
+	testlib.CheckImageGoldens(tt, gs, decode)
+}
+
+func TestDecodeConfig(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.tiff")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &tiff.Decoder{}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatal(err)
+	}
+	if got, want := ic.PixelFormat(), base.PixelFormatBGRANonpremul; got != want {
+		tt.Errorf("PixelFormat: got 0x%08X, want 0x%08X", got, want)
+	}
+	if got, want := ic.Width(), uint32(160); got != want {
+		tt.Errorf("Width: got %d, want %d", got, want)
+	}
+	if got, want := ic.Height(), uint32(120); got != want {
+		tt.Errorf("Height: got %d, want %d", got, want)
+	}
+}
+
+// TestDecodeTruncated checks that a TIFF file whose pixel data is cut short
+// is rejected.
+func TestDecodeTruncated(tt *testing.T) {
+	src, err := testlib.ReadFile("tiffsuite/rgb-packbits.tiff")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	// rgb-packbits.tiff's IFD comes before its pixel data.
+	if _, err := decode(src[:len(src)-100], 0); err != base.ErrUnexpectedEOF {
+		tt.Fatalf("got %v, want %v", err, base.ErrUnexpectedEOF)
+	}
+}
+
+// makeGrayTIFF returns an uncompressed, little-endian, 8 bit gray TIFF file,
+// with strips of one row or, if tileSize is non-zero, with square tiles. The
+// IFD, then the strip or tile arrays and then the pixel data follow the
+// header, so that decoding never seeks. It also returns the BGRA_NONPREMUL
+// pixels that it should decode to.
+func makeGrayTIFF(width int, height int, tileSize int) (tiffData []byte, pixels []byte) {
+	gray := func(x int, y int) uint8 { return uint8(x + (3 * y)) }
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g := gray(x, y)
+			pixels = append(pixels, g, g, g, 0xFF)
+		}
+	}
+
+	chunks := [][]byte(nil)
+	if tileSize == 0 {
+		for y := 0; y < height; y++ {
+			row := []byte(nil)
+			for x := 0; x < width; x++ {
+				row = append(row, gray(x, y))
+			}
+			chunks = append(chunks, row)
+		}
+	} else {
+		for ty := 0; ty < height; ty += tileSize {
+			for tx := 0; tx < width; tx += tileSize {
+				tile := []byte(nil)
+				for y := ty; y < ty+tileSize; y++ {
+					for x := tx; x < tx+tileSize; x++ {
+						tile = append(tile, gray(x, y))
+					}
+				}
+				chunks = append(chunks, tile)
+			}
+		}
+	}
+
+	// Each entry is a tag, a LONG field type, a count and a value.
+	type entry struct{ tag, count, value uint32 }
+	entries := []entry{
+		{256, 1, uint32(width)},
+		{257, 1, uint32(height)},
+		{258, 1, 8},
+		{259, 1, 1},
+		{262, 1, 1},
+	}
+	offsetsTag, byteCountsTag := uint32(273), uint32(279)
+	if tileSize == 0 {
+		entries = append(entries, entry{278, 1, 1})
+	} else {
+		entries = append(entries, entry{322, 1, uint32(tileSize)}, entry{323, 1, uint32(tileSize)})
+		offsetsTag, byteCountsTag = 324, 325
+	}
+	arraysPos := uint32(8 + 2 + (12 * (len(entries) + 2)) + 4)
+	n := uint32(len(chunks))
+	entries = append(entries,
+		entry{offsetsTag, n, arraysPos},
+		entry{byteCountsTag, n, arraysPos + (4 * n)})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+
+	le := binary.LittleEndian
+	buf := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	buf = le.AppendUint16(buf, uint16(len(entries)))
+	for _, e := range entries {
+		buf = le.AppendUint16(buf, uint16(e.tag))
+		buf = le.AppendUint16(buf, 4)
+		buf = le.AppendUint32(buf, e.count)
+		buf = le.AppendUint32(buf, e.value)
+	}
+	buf = le.AppendUint32(buf, 0)
+	pos := arraysPos + (8 * n)
+	for _, c := range chunks {
+		buf = le.AppendUint32(buf, pos)
+		pos += uint32(len(c))
+	}
+	for _, c := range chunks {
+		buf = le.AppendUint32(buf, uint32(len(c)))
+	}
+	for _, c := range chunks {
+		buf = append(buf, c...)
+	}
+	return buf, pixels
+}
+
+// TestDecodeManyChunks checks images with more than 4096 strips or tiles.
+func TestDecodeManyChunks(tt *testing.T) {
+	testCases := []struct {
+		name                    string
+		width, height, tileSize int
+	}{
+		{"strips", 3, 5000, 0},
+		{"tiles", 80 * 16, 52 * 16, 16},
+	}
+	for _, tc := range testCases {
+		src, want := makeGrayTIFF(tc.width, tc.height, tc.tileSize)
+		for _, rlimit := range []int{0, 4096} {
+			got, err := decode(src, rlimit)
+			if err != nil {
+				tt.Errorf("%s: rlimit=%d: %v", tc.name, rlimit, err)
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				tt.Errorf("%s: rlimit=%d: pixels differ", tc.name, rlimit)
+			}
+		}
+	}
+}
+
+func TestDecodeBadWorkbufLength(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.tiff")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &tiff.Decoder{}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatal(err)
+	}
+	// bricks-color.tiff has one strip, whose offset and byte count take 8
+	// bytes.
+	if got, want := d.WorkbufLen(), uint64(8); got != want {
+		tt.Fatalf("WorkbufLen: got %d, want %d", got, want)
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen()-1)
+	if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != tiff.ErrBadWorkbufLength {
+		tt.Fatalf("got %v, want %v", err, tiff.ErrBadWorkbufLength)
+	}
+}
+
+func TestDecodeInputIsAPNG(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != tiff.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, tiff.ErrBadHeader)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.tiff")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &tiff.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader(), nil); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = decode(src, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsTiffDecode_1k_color(b *testing.B) { benchDecode(b, "hippopotamus.tiff") }
+func BenchmarkWuffsTiffDecode_10k(b *testing.B)      { benchDecode(b, "hat.tiff") }
+func BenchmarkWuffsTiffDecode_100k(b *testing.B)     { benchDecode(b, "hibiscus.tiff") }
+func BenchmarkWuffsTiffDecode_1000k(b *testing.B)    { benchDecode(b, "harvesters.tiff") }
@@ -0,0 +1,466 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package webp_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	stdgif "image/gif"
+	"math/bits"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/webp"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+// goldens are lossless WebP files, without the ".lossless.webp" suffix. Each
+// "foo.lossless.webp" file should decode to the same pixels as the "foo.png"
+// file.
+var goldens = []string{
+	"bricks-color",
+	"bricks-dither",
+	"bricks-gray",
+	"bricks-nodither",
+	"harvesters",
+	"hat",
+	"hibiscus",
+	"hippopotamus",
+	"pjw-thumbnail",
+}
+
+// decode decodes src's first frame's BGRA_NONPREMUL pixels, feeding the
+// decoder at most rlimit bytes at a time (or all of src, if rlimit is
+// non-positive).
+func decode(src []byte, rlimit int) ([]byte, error) {
+	return testlib.DecodeImage(&webp.Decoder{}, src, rlimit, testlib.ImageDecodeOptions{})
+}
+
+func TestDecode(tt *testing.T) {
+	gs := []testlib.ImageGolden(nil)
+	for _, g := range goldens {
+		gs = append(gs, testlib.ImageGolden{Filename: g + ".lossless.webp", PNGFilename: g + ".png"})
+	}
+	testlib.CheckImageGoldens(tt, gs, decode)
+}
+
+// TestDecodeAnimated checks that each frame of animated-red-blue.webp, which
+// was generated by script/convert-gif-to-webp.go, matches the corresponding
+// frame of animated-red-blue.gif.
+func TestDecodeAnimated(tt *testing.T) {
+	src, err := testlib.ReadFile("animated-red-blue.gif")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	g, err := stdgif.DecodeAll(bytes.NewReader(src))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	src, err = testlib.ReadFile("animated-red-blue.webp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	d := &webp.Decoder{}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatalf("DecodeConfig: %v", err)
+	}
+	if got, want := ic.NumLoops(), uint32(g.LoopCount+1); got != want {
+		tt.Fatalf("NumLoops: got %d, want %d", got, want)
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen())
+
+	for i, m := range g.Image {
+		if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != nil {
+			tt.Fatalf("DecodeFrame #%d: %v", i, err)
+		}
+
+		// WebP frame offsets are even, so odd GIF frame offsets are rounded
+		// down, with the extra pixels being transparent.
+		b := m.Bounds()
+		want := base.RectIEU32{
+			MinInclusiveX: uint32(b.Min.X &^ 1),
+			MinInclusiveY: uint32(b.Min.Y &^ 1),
+			MaxExclusiveX: uint32(b.Max.X),
+			MaxExclusiveY: uint32(b.Max.Y),
+		}
+		if got := ib.DirtyRect(); got != want {
+			tt.Fatalf("DirtyRect #%d: got %v, want %v", i, got, want)
+		}
+		// There are 7056000 flicks per centisecond.
+		if got, want := ib.Duration(), uint64(g.Delay[i])*7056000; got != want {
+			tt.Fatalf("Duration #%d: got %d, want %d", i, got, want)
+		}
+		if !ib.Blend() {
+			tt.Fatalf("Blend #%d: got false, want true", i)
+		}
+
+		tab := ib.Plane(0)
+		for y := want.MinInclusiveY; y < want.MaxExclusiveY; y++ {
+			for x := want.MinInclusiveX; x < want.MaxExclusiveX; x++ {
+				c := color.NRGBA{}
+				if (image.Point{int(x), int(y)}).In(b) {
+					c = color.NRGBAModel.Convert(m.At(int(x), int(y))).(color.NRGBA)
+				}
+				o := (int(y) * tab.Stride) + (4 * int(x))
+				if got := tab.Data[o : o+4]; !bytes.Equal(got, []byte{c.B, c.G, c.R, c.A}) {
+					tt.Fatalf("frame #%d: pixel (%d, %d): got % x, want %v", i, x, y, got, c)
+				}
+			}
+		}
+	}
+
+	if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != base.SuspensionEndOfData {
+		tt.Fatalf("got %v, want %v", err, base.SuspensionEndOfData)
+	}
+}
+
+func TestDecodeLossy(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.lossy.webp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != webp.ErrTODOUnsupportedLossyImage {
+		tt.Fatalf("got %v, want %v", err, webp.ErrTODOUnsupportedLossyImage)
+	}
+}
+
+func TestDecodeInputIsAPNG(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.png")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	if _, err := decode(src, 0); err != webp.ErrBadHeader {
+		tt.Fatalf("got %v, want %v", err, webp.ErrBadHeader)
+	}
+}
+
+// bitWriter writes a VP8L bitstream, Least Significant Bits first.
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint32
+}
+
+func (w *bitWriter) write(v uint32, n uint32) {
+	w.bits |= uint64(v) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, uint8(w.bits))
+		w.bits >>= 8
+		w.nBits -= 8
+	}
+}
+
+// writeSimpleCode writes a simple Huffman code with the one symbol, which is
+// zero bits long.
+func (w *bitWriter) writeSimpleCode(symbol uint32) {
+	w.write(1, 1)
+	w.write(0, 1)
+	if symbol < 2 {
+		w.write(0, 1)
+		w.write(symbol, 1)
+	} else {
+		w.write(1, 1)
+		w.write(symbol, 8)
+	}
+}
+
+// writeGroup writes a Huffman group whose pixels are all argb, and which has
+// no back-references.
+func (w *bitWriter) writeGroup(argb uint32) {
+	w.writeSimpleCode((argb >> 8) & 0xFF)
+	w.writeSimpleCode((argb >> 16) & 0xFF)
+	w.writeSimpleCode((argb >> 0) & 0xFF)
+	w.writeSimpleCode((argb >> 24) & 0xFF)
+	w.writeSimpleCode(0)
+}
+
+// writeSubImage writes a subresolution image whose pixels are all argb. Its
+// pixels take zero bits, whatever its size.
+func (w *bitWriter) writeSubImage(argb uint32) {
+	w.write(0, 1) // No color cache.
+	w.writeGroup(argb)
+}
+
+// makeVP8L returns a WebP file whose VP8L bitstream is width by height pixels
+// and whose bits after the VP8L header are those of w.
+func makeVP8L(width uint32, height uint32, w *bitWriter) []byte {
+	payload := []byte{0x2F, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(payload[1:], (width-1)|((height-1)<<14))
+	payload = append(payload, w.buf...)
+	if w.nBits > 0 {
+		payload = append(payload, uint8(w.bits))
+	}
+	if len(payload)&1 != 0 {
+		payload = append(payload, 0)
+	}
+
+	buf := []byte("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(12+len(payload)))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(len(payload)))
+	return append(buf, payload...)
+}
+
+// TestDecodeLargeSubresolutionImages checks subresolution images of more than
+// 0x4000 pixels. The image is 600 × 500 pixels and each transform or entropy
+// image block is 4 × 4 pixels, so each subresolution image is 150 × 125
+// pixels.
+func TestDecodeLargeSubresolutionImages(tt *testing.T) {
+	const width, height = 600, 500
+	w := &bitWriter{}
+
+	// A predictor transform, whose every block uses mode 1 (L). The mode is
+	// in the green channel.
+	w.write(1, 1)
+	w.write(0, 2)
+	w.write(0, 3)
+	w.writeSubImage(0x00000100)
+
+	// A color transform, whose every block has a green_to_red multiplier of
+	// 0x20, in the blue channel. Red is incremented by (0x20 * green) >> 5.
+	w.write(1, 1)
+	w.write(1, 2)
+	w.write(0, 3)
+	w.writeSubImage(0x00000020)
+
+	// No more transforms, and then the frame's entropy-coded image, with no
+	// color cache. Its entropy image picks Huffman group 1 (in the green
+	// channel) for every block.
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(0, 3)
+	w.writeSubImage(0x00000100)
+
+	// Group 0's residuals are transparent black and group 1's are (A, R, G, B)
+	// = (0, 1, 2, 3). The color transform makes the latter (0, 3, 2, 3) and
+	// the predictor transform then sums them along each row and column.
+	w.writeGroup(0x00000000)
+	w.writeGroup(0x00010203)
+
+	got, err := decode(makeVP8L(width, height, w), 0)
+	if err != nil {
+		tt.Fatal(err)
+	}
+	want := make([]byte, 0, 4*width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			n := uint8(x + y + 1)
+			want = append(want, 3*n, 2*n, 3*n, 0xFF)
+		}
+	}
+	if !bytes.Equal(got, want) {
+		tt.Fatal("pixels differ")
+	}
+}
+
+// writeIndexSubImage writes a subresolution image, n × 1 pixels with n at
+// most 256, whose i'th pixel has red r and green i.
+func (w *bitWriter) writeIndexSubImage(r uint32, n uint32) {
+	w.write(0, 1) // No color cache.
+
+	// The green code is a normal code, whose code length code has only one
+	// symbol, 8, and so is zero bits long. The first 12 code_length_code_order
+	// entries end with 8.
+	w.write(0, 1)
+	w.write(12-4, 4)
+	for i := 0; i < 12; i++ {
+		if i == 11 {
+			w.write(1, 3)
+		} else {
+			w.write(0, 3)
+		}
+	}
+	// Only the first 256 symbols' code lengths are coded: an 8 bit
+	// max_symbol, minus 2. Those symbols' codes are all 8 bits long.
+	w.write(1, 1)
+	w.write(3, 3)
+	w.write(256-2, 8)
+	w.writeSimpleCode(r)
+	w.writeSimpleCode(0)
+	w.writeSimpleCode(0)
+	w.writeSimpleCode(0)
+
+	// Each symbol's code is itself, written Most Significant Bit first.
+	for i := uint32(0); i < n; i++ {
+		w.write(uint32(bits.Reverse8(uint8(i))), 8)
+	}
+}
+
+// makeGroupsVP8L returns a WebP image, numBlocks blocks of 4 × 4 pixels wide
+// and 1 block high, whose i'th block uses Huffman group (firstBlockGroup + i).
+// Groups before firstBlockGroup, a multiple of 256, are written but not used.
+// Each group's green code is made by writeGreenCode, and the image's color
+// cache has 1 << cacheBits entries.
+func makeGroupsVP8L(firstBlockGroup uint32, numBlocks uint32, cacheBits uint32, writeGreenCode func(w *bitWriter, g uint32)) []byte {
+	w := &bitWriter{}
+	w.write(0, 1) // No transforms.
+	if cacheBits > 0 {
+		w.write(1, 1)
+		w.write(cacheBits, 4)
+	} else {
+		w.write(0, 1)
+	}
+	// The entropy image's pixels hold the Huffman group in their red and
+	// green channels.
+	w.write(1, 1)
+	w.write(0, 3)
+	w.writeIndexSubImage(firstBlockGroup>>8, numBlocks)
+	for g := uint32(0); g < firstBlockGroup+numBlocks; g++ {
+		writeGreenCode(w, g)
+		w.writeSimpleCode(0xFF)
+		w.writeSimpleCode(0x00)
+		w.writeSimpleCode(0xFF)
+		w.writeSimpleCode(0)
+	}
+	return makeVP8L(4*numBlocks, 4, w)
+}
+
+// TestDecodeManyHuffmanGroups checks images with many Huffman groups, some of
+// which are unused, up to the 0x10000 that a group index can address. Each
+// group's green code has one symbol, the low byte of the group index.
+func TestDecodeManyHuffmanGroups(tt *testing.T) {
+	testCases := []struct {
+		firstBlockGroup uint32
+		numBlocks       uint32
+	}{
+		{0x0000, 1},
+		{0x0000, 256},
+		{0x0100, 129},
+		{0xFF00, 256},
+	}
+	for _, tc := range testCases {
+		src := makeGroupsVP8L(tc.firstBlockGroup, tc.numBlocks, 0, func(w *bitWriter, g uint32) {
+			w.writeSimpleCode(g & 0xFF)
+		})
+		got, err := decode(src, 0)
+		if err != nil {
+			tt.Errorf("firstBlockGroup=0x%04X, numBlocks=%d: %v", tc.firstBlockGroup, tc.numBlocks, err)
+			continue
+		}
+		want := []byte(nil)
+		for y := 0; y < 4; y++ {
+			for i := uint32(0); i < tc.numBlocks; i++ {
+				want = append(want, bytes.Repeat([]byte{0x00, uint8(i), 0xFF, 0xFF}, 4)...)
+			}
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("firstBlockGroup=0x%04X, numBlocks=%d: pixels differ", tc.firstBlockGroup, tc.numBlocks)
+		}
+	}
+}
+
+// TestDecodeManyLongCodes checks Huffman groups whose codes are longer than 8
+// bits. Each group's green code has 2048 symbols, all 11 bits long, so that
+// 17 groups have more than 0x8000 such symbols.
+func TestDecodeManyLongCodes(tt *testing.T) {
+	writeGreenCode := func(w *bitWriter, g uint32) {
+		// A normal code, whose code length code has only one symbol, 11, and
+		// so is zero bits long. The first 15 code_length_code_order entries
+		// end with 11.
+		w.write(0, 1)
+		w.write(15-4, 4)
+		for i := 0; i < 15; i++ {
+			if i == 14 {
+				w.write(1, 3)
+			} else {
+				w.write(0, 3)
+			}
+		}
+		// Only the first 2048 symbols' code lengths are coded: a 12 bit
+		// max_symbol, minus 2.
+		w.write(1, 1)
+		w.write(5, 3)
+		w.write(2048-2, 12)
+	}
+
+	for _, numBlocks := range []uint32{16, 17, 200} {
+		// The green alphabet has 256 + 24 + 2048 symbols.
+		src := makeGroupsVP8L(0, numBlocks, 11, writeGreenCode)
+		got, err := decode(src, 0)
+		if err != nil {
+			tt.Errorf("numBlocks=%d: %v", numBlocks, err)
+			continue
+		}
+		// The pixels' bits, after the end of the bitstream, are zero, which
+		// is the code for green 0.
+		if want := bytes.Repeat([]byte{0x00, 0x00, 0xFF, 0xFF}, 16*int(numBlocks)); !bytes.Equal(got, want) {
+			tt.Errorf("numBlocks=%d: pixels differ", numBlocks)
+		}
+	}
+}
+
+func TestDecodeBadWorkbufLength(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.lossless.webp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &webp.Decoder{}
+	ic := base.ImageConfig{}
+	if err := d.DecodeConfig(&ic, r.Reader()); err != nil {
+		tt.Fatal(err)
+	}
+	// bricks-color.lossless.webp is 160 × 120 pixels, or 40 × 30 blocks of 4
+	// × 4 pixels. Each of the 3 subresolution images has room for one 4 byte
+	// pixel per block. There is room for one 9152 byte Huffman group per
+	// block, plus a scratch group, and then the 576 byte code length code.
+	if got, want := d.WorkbufLen(), uint64((40*30*3*4)+(((40*30)+1)*9152)+576); got != want {
+		tt.Fatalf("WorkbufLen: got %d, want %d", got, want)
+	}
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen()-1)
+	if err := d.DecodeFrame(&ib, r.Reader(), workbuf); err != webp.ErrBadWorkbufLength {
+		tt.Fatalf("got %v, want %v", err, webp.ErrBadWorkbufLength)
+	}
+}
+
+func TestCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("bricks-color.lossless.webp")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	d := &webp.Decoder{}
+	if err := d.DecodeFrame(&base.ImageBuffer{}, r.Reader(), nil); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+func benchDecode(b *testing.B, filename string) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = decode(src, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsWebpDecode_1k_color(b *testing.B) { benchDecode(b, "hippopotamus.lossless.webp") }
+func BenchmarkWuffsWebpDecode_10k(b *testing.B)      { benchDecode(b, "hat.lossless.webp") }
+func BenchmarkWuffsWebpDecode_100k(b *testing.B)     { benchDecode(b, "hibiscus.lossless.webp") }
+func BenchmarkWuffsWebpDecode_1000k(b *testing.B)    { benchDecode(b, "harvesters.lossless.webp") }
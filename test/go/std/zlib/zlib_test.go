@@ -0,0 +1,246 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package zlib_test
+
+import (
+	"bytes"
+	stdzlib "compress/zlib"
+	"fmt"
+	"hash/adler32"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/wuffs/gen/go/std/zlib"
+	"github.com/google/wuffs/lib/go/base"
+	"github.com/google/wuffs/test/go/testlib"
+)
+
+var goldens = []string{
+	"midsummer.txt.zlib",
+	"pi.txt.zlib",
+	"romeo.txt.zlib",
+}
+
+func mimicDecode(src []byte) ([]byte, error) {
+	r, err := stdzlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func testDecode(tt *testing.T, wlimit int, rlimit int) {
+	for _, filename := range goldens {
+		src, err := testlib.ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		want, err := mimicDecode(src)
+		if err != nil {
+			tt.Fatalf("%s: mimic: %v", filename, err)
+		}
+		got, err := testlib.Decode(&zlib.Decoder{}, src, wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: %v", filename, wlimit, rlimit, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("%s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+func TestDecode(tt *testing.T)                     { testDecode(tt, 0, 0) }
+func TestDecodeManySmallWritesReads(tt *testing.T) { testDecode(tt, 59, 61) }
+func TestDecodeManyTinyWritesReads(tt *testing.T)  { testDecode(tt, 1, 1) }
+
+func TestChecksum(tt *testing.T) {
+	src, err := testlib.ReadFile("midsummer.txt.zlib")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	src = append([]byte(nil), src...)
+	src[len(src)-1] ^= 1
+
+	if _, err := testlib.Decode(&zlib.Decoder{}, src, 0, 0); err != zlib.ErrBadChecksum {
+		tt.Fatalf("verify: got %v, want %v", err, zlib.ErrBadChecksum)
+	}
+
+	d := &zlib.Decoder{}
+	d.SetIgnoreChecksum(true)
+	if _, err := testlib.Decode(d, src, 0, 0); err != nil {
+		tt.Fatalf("ignore: got %v, want nil", err)
+	}
+}
+
+// dictDecoder wraps a zlib.Decoder, supplying dict when asked for a preset
+// dictionary.
+type dictDecoder struct {
+	zlib.Decoder
+	dict []byte
+}
+
+func (d *dictDecoder) Decode(dst base.IOWriter, src base.IOReader) error {
+	for {
+		err := d.Decoder.Decode(dst, src)
+		if err != zlib.SuspensionDictionaryRequired {
+			return err
+		}
+		if got, want := d.DictionaryId(), adler32.Checksum(d.dict); got != want {
+			return fmt.Errorf("DictionaryId: got 0x%08X, want 0x%08X", got, want)
+		}
+		d.SetDictionary(d.dict)
+	}
+}
+
+func testDictionary(tt *testing.T, wlimit int, rlimit int) {
+	testCases := []struct {
+		dictFilename string
+		filename     string
+	}{
+		{"romeo.txt", "midsummer.txt"},
+		{"midsummer.txt", "romeo.txt"},
+		// pi.txt is longer than the 32 KiB history window.
+		{"pi.txt", "pi.txt"},
+	}
+	for _, tc := range testCases {
+		dict, err := testlib.ReadFile(tc.dictFilename)
+		if err != nil {
+			tt.Fatalf("%s: %v", tc.dictFilename, err)
+		}
+		want, err := testlib.ReadFile(tc.filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", tc.filename, err)
+		}
+		buf := &bytes.Buffer{}
+		w, err := stdzlib.NewWriterLevelDict(buf, stdzlib.BestCompression, dict)
+		if err != nil {
+			tt.Fatalf("%s: %v", tc.filename, err)
+		}
+		w.Write(want)
+		w.Close()
+
+		got, err := testlib.Decode(&dictDecoder{dict: dict}, buf.Bytes(), wlimit, rlimit)
+		if err != nil {
+			tt.Errorf("%s, %s: wlimit=%d, rlimit=%d: %v",
+				tc.dictFilename, tc.filename, wlimit, rlimit, err)
+		} else if !bytes.Equal(got, want) {
+			tt.Errorf("%s, %s: wlimit=%d, rlimit=%d: got %d bytes, want %d bytes",
+				tc.dictFilename, tc.filename, wlimit, rlimit, len(got), len(want))
+		}
+	}
+}
+
+func TestDictionary(tt *testing.T)                     { testDictionary(tt, 0, 0) }
+func TestDictionaryManySmallWritesReads(tt *testing.T) { testDictionary(tt, 59, 61) }
+
+func TestDictionaryGolden(tt *testing.T) {
+	src, err := testlib.ReadFile("midsummer.txt.romeo-dict.zlib")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	dict, err := testlib.ReadFile("romeo.txt")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	want, err := testlib.ReadFile("midsummer.txt")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	if _, err := testlib.Decode(&zlib.Decoder{}, src, 0, 0); err != zlib.SuspensionDictionaryRequired {
+		tt.Fatalf("no dictionary: got %v, want %v", err, zlib.SuspensionDictionaryRequired)
+	}
+	d := &dictDecoder{dict: dict}
+	if got, err := testlib.Decode(d, src, 0, 0); err != nil {
+		tt.Fatalf("right dictionary: %v", err)
+	} else if !bytes.Equal(got, want) {
+		tt.Fatalf("right dictionary: got %d bytes, want %d bytes", len(got), len(want))
+	}
+}
+
+func TestDictionaryIncorrect(tt *testing.T) {
+	src, err := testlib.ReadFile("midsummer.txt.romeo-dict.zlib")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	d := &zlib.Decoder{}
+	r := base.IOBuffer{Data: src, WI: len(src), Closed: true}
+	w := base.IOBuffer{Data: make([]byte, 1<<16)}
+	if err := d.Decode(w.Writer(), r.Reader()); err != zlib.SuspensionDictionaryRequired {
+		tt.Fatalf("got %v, want %v", err, zlib.SuspensionDictionaryRequired)
+	}
+	d.SetDictionary([]byte("not romeo.txt"))
+	if err := d.Decode(w.Writer(), r.Reader()); err != zlib.ErrIncorrectDictionary {
+		tt.Fatalf("got %v, want %v", err, zlib.ErrIncorrectDictionary)
+	}
+}
+
+func TestDictionaryCallSequence(tt *testing.T) {
+	src, err := testlib.ReadFile("midsummer.txt.romeo-dict.zlib")
+	if err != nil {
+		tt.Fatal(err)
+	}
+	d := &zlib.Decoder{}
+	d.SetDictionary([]byte("too early"))
+	if _, err := testlib.Decode(d, src, 0, 0); err != base.ErrInvalidCallSequence {
+		tt.Fatalf("got %v, want %v", err, base.ErrInvalidCallSequence)
+	}
+}
+
+// testEncode checks that zlib.Encoder output round-trips through both Wuffs
+// and the standard library.
+func testEncode(tt *testing.T, wlimit int, rlimit int) {
+	testlib.CheckRoundTrips(tt, newEncoder, newDecoder, mimicDecode, wlimit, rlimit)
+}
+
+func newEncoder(level uint32) testlib.Encoder {
+	e := &zlib.Encoder{}
+	e.SetLevel(level)
+	return e
+}
+
+func newDecoder() testlib.Decoder { return &zlib.Decoder{} }
+
+func TestEncode(tt *testing.T)                     { testEncode(tt, 0, 0) }
+func TestEncodeManySmallWritesReads(tt *testing.T) { testEncode(tt, 59, 61) }
+
+func benchDecode(b *testing.B, filename string, mimic bool) {
+	src, err := testlib.ReadFile(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	want, err := mimicDecode(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(want)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mimic {
+			_, err = mimicDecode(src)
+		} else {
+			_, err = testlib.Decode(&zlib.Decoder{}, src, 0, 0)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWuffsZlibDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.zlib", false) }
+func BenchmarkWuffsZlibDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.zlib", false) }
+func BenchmarkMimicZlibDecode_10k(b *testing.B)  { benchDecode(b, "midsummer.txt.zlib", true) }
+func BenchmarkMimicZlibDecode_100k(b *testing.B) { benchDecode(b, "pi.txt.zlib", true) }
@@ -0,0 +1,336 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testlib holds the helpers shared by the Go tests of the generated
+// Wuffs packages, the Go counterpart of test/c/testlib.
+package testlib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/wuffs/lib/go/base"
+)
+
+// DataDir is the test/data directory, relative to the test/go/std/foo
+// directories that "go test" runs in.
+var DataDir = filepath.Join("..", "..", "..", "data")
+
+// ReadFile reads a file from DataDir.
+func ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(DataDir, filepath.FromSlash(filename)))
+}
+
+// DecodePNG decodes src via the standard library's image/png package, and
+// converts the result to BGRA_NONPREMUL pixels. As with Wuffs, 16 bit samples
+// are reduced to their high byte. Image decoders' tests compare their output
+// to this, for the PNG files that hold their golden pixels.
+func DecodePNG(src []byte) ([]byte, error) {
+	m, err := png.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	m64, _ := m.(*image.NRGBA64)
+	b := m.Bounds()
+	pix := make([]byte, 0, 4*b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBA{}
+			if m64 != nil {
+				// Converting via color.NRGBAModel would premultiply and then
+				// un-premultiply, which is lossy.
+				c64 := m64.NRGBA64At(x, y)
+				c = color.NRGBA{
+					uint8(c64.R >> 8), uint8(c64.G >> 8), uint8(c64.B >> 8), uint8(c64.A >> 8),
+				}
+			} else {
+				c = color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			}
+			pix = append(pix, c.B, c.G, c.R, c.A)
+		}
+	}
+	return pix, nil
+}
+
+// ImageDecoder is a generated image decoder, such as a png.Decoder.
+type ImageDecoder interface {
+	DecodeConfig(dst *base.ImageConfig, src base.IOReader) error
+	WorkbufLen() uint64
+	DecodeFrame(dst *base.ImageBuffer, src base.IOReader, workbuf []byte) error
+}
+
+// ImageDecodeOptions are DecodeImage's codec-specific checks.
+type ImageDecodeOptions struct {
+	// CheckExhausted is whether decoding the first frame must read all of the
+	// source.
+	CheckExhausted bool
+
+	// CheckEndOfData is whether decoding a second frame must return
+	// base.SuspensionEndOfData.
+	CheckEndOfData bool
+
+	// SuspensionSeekRequired, if non-nil, is the decoder's status for asking
+	// the caller to move the reader to the decoder's SeekPosition. Such
+	// decoders must implement the Seeker interface.
+	SuspensionSeekRequired error
+}
+
+// Seeker is an ImageDecoder that can ask its caller to seek, such as a
+// tiff.Decoder.
+type Seeker interface {
+	SeekPosition() uint64
+}
+
+// ErrSrcNotExhausted is DecodeImage's error when its CheckExhausted option
+// is set and the first frame does not end at the end of the source.
+var ErrSrcNotExhausted = base.NewError("src was not exhausted")
+
+// DecodeImage decodes src's first frame with d, returning its pixels. The
+// decoder is fed at most rlimit bytes at a time (or all of src, if rlimit is
+// non-positive), and is given a work buffer that is exactly as long as it
+// needs to be. The reader is also moved when the decoder asks it to seek.
+func DecodeImage(d ImageDecoder, src []byte, rlimit int, opts ImageDecodeOptions) ([]byte, error) {
+	if rlimit <= 0 {
+		rlimit = len(src)
+	}
+	r := base.IOBuffer{Data: src}
+	feed := func() bool {
+		if r.Closed {
+			return false
+		}
+		r.WI += rlimit
+		if r.WI >= len(src) {
+			r.WI = len(src)
+			r.Closed = true
+		}
+		return true
+	}
+	feed()
+	seek := func() bool {
+		pos := d.(Seeker).SeekPosition()
+		if pos > uint64(len(src)) {
+			return false
+		}
+		r.RI = int(pos)
+		if r.WI < r.RI {
+			r.WI = r.RI
+		}
+		return true
+	}
+	resume := func(status error) bool {
+		if status == base.SuspensionShortRead {
+			return feed()
+		} else if (opts.SuspensionSeekRequired != nil) && (status == opts.SuspensionSeekRequired) {
+			return seek()
+		}
+		return false
+	}
+
+	ic := base.ImageConfig{}
+	for {
+		status := d.DecodeConfig(&ic, r.Reader())
+		if status == nil {
+			break
+		} else if !resume(status) {
+			return nil, status
+		}
+	}
+
+	ib := base.ImageBuffer{}
+	ib.SetFromSlice(ic, make([]byte, ic.PixbufSize()))
+	workbuf := make([]byte, d.WorkbufLen())
+	for {
+		status := d.DecodeFrame(&ib, r.Reader(), workbuf)
+		if status == nil {
+			break
+		} else if !resume(status) {
+			return nil, status
+		}
+	}
+	if opts.CheckEndOfData {
+		if status := d.DecodeFrame(&ib, r.Reader(), workbuf); status != base.SuspensionEndOfData {
+			return nil, status
+		}
+	}
+	if opts.CheckExhausted && r.RI != len(src) {
+		return nil, ErrSrcNotExhausted
+	}
+	return ib.Plane(0).Data, nil
+}
+
+// ImageGolden is an image file, relative to DataDir, and the PNG file that
+// holds its golden pixels.
+type ImageGolden struct {
+	Filename    string
+	PNGFilename string
+}
+
+// GoldenReadLimits are the rlimits that CheckImageGoldens decodes with: all
+// of the source at once, many medium reads and many small reads.
+var GoldenReadLimits = []int{0, 4096, 7}
+
+// CheckImageGoldens checks, as one subtest per GoldenReadLimits element, that
+// decode yields the same pixels for each golden's image file as DecodePNG
+// does for its PNG file. The decode function is passed the rlimit, as for
+// DecodeImage.
+func CheckImageGoldens(tt *testing.T, goldens []ImageGolden, decode func(src []byte, rlimit int) ([]byte, error)) {
+	for _, rlimit := range GoldenReadLimits {
+		tt.Run(fmt.Sprintf("rlimit=%d", rlimit), func(tt *testing.T) {
+			for _, g := range goldens {
+				src, err := ReadFile(g.PNGFilename)
+				if err != nil {
+					tt.Fatalf("%s: %v", g.PNGFilename, err)
+				}
+				want, err := DecodePNG(src)
+				if err != nil {
+					tt.Fatalf("%s: %v", g.PNGFilename, err)
+				}
+				src, err = ReadFile(g.Filename)
+				if err != nil {
+					tt.Fatalf("%s: %v", g.Filename, err)
+				}
+				got, err := decode(src, rlimit)
+				if err != nil {
+					tt.Errorf("%s: %v", g.Filename, err)
+					continue
+				}
+				if !bytes.Equal(got, want) {
+					tt.Errorf("%s: pixels differ", g.Filename)
+				}
+			}
+		})
+	}
+}
+
+// Decoder is a generated io_writer-and-io_reader decoder, such as a
+// deflate.Decoder or a gzip.Decoder.
+type Decoder interface {
+	Decode(dst base.IOWriter, src base.IOReader) error
+}
+
+// Decode runs d to completion on src, returning the decoded bytes.
+//
+// Each Decode call sees at most wlimit bytes of destination buffer space and
+// rlimit bytes of source, so that small limits exercise the generated code's
+// suspension and resumption. A non-positive limit means no limit.
+func Decode(d Decoder, src []byte, wlimit int, rlimit int) ([]byte, error) {
+	return run(d.Decode, src, wlimit, rlimit)
+}
+
+// Encoder is a generated io_writer-and-io_reader encoder, such as a
+// deflate.Encoder or a gzip.Encoder.
+type Encoder interface {
+	Encode(dst base.IOWriter, src base.IOReader) error
+}
+
+// Encode runs e to completion on src, returning the encoded bytes. The wlimit
+// and rlimit arguments are as for Decode.
+func Encode(e Encoder, src []byte, wlimit int, rlimit int) ([]byte, error) {
+	return run(e.Encode, src, wlimit, rlimit)
+}
+
+// RoundTrips are the uncompressed files that CheckRoundTrips encodes.
+var RoundTrips = []string{
+	"artificial/256.bytes",
+	"midsummer.txt",
+	"pi.txt",
+	"romeo.txt",
+}
+
+// CheckRoundTrips encodes each RoundTrips file at each compression level, from
+// 0 to 9 inclusive, checking that both the decoder from newDecoder and
+// mimicDecode decode the result back to the original. The encoder from
+// newEncoder is run with the wlimit and rlimit arguments, as for Encode.
+func CheckRoundTrips(tt *testing.T, newEncoder func(level uint32) Encoder, newDecoder func() Decoder,
+	mimicDecode func(src []byte) ([]byte, error), wlimit int, rlimit int) {
+
+	for _, filename := range RoundTrips {
+		want, err := ReadFile(filename)
+		if err != nil {
+			tt.Fatalf("%s: %v", filename, err)
+		}
+		for level := uint32(0); level <= 9; level++ {
+			encoded, err := Encode(newEncoder(level), want, wlimit, rlimit)
+			if err != nil {
+				tt.Errorf("%s: level=%d, wlimit=%d, rlimit=%d: encode: %v",
+					filename, level, wlimit, rlimit, err)
+				continue
+			}
+			got, err := Decode(newDecoder(), encoded, 0, 0)
+			if err != nil {
+				tt.Errorf("%s: level=%d: decode: %v", filename, level, err)
+			} else if !bytes.Equal(got, want) {
+				tt.Errorf("%s: level=%d: decode: got %d bytes, want %d bytes",
+					filename, level, len(got), len(want))
+			}
+			got, err = mimicDecode(encoded)
+			if err != nil {
+				tt.Errorf("%s: level=%d: mimic: %v", filename, level, err)
+			} else if !bytes.Equal(got, want) {
+				tt.Errorf("%s: level=%d: mimic: got %d bytes, want %d bytes",
+					filename, level, len(got), len(want))
+			}
+		}
+	}
+}
+
+func run(f func(base.IOWriter, base.IOReader) error, src []byte, wlimit int, rlimit int) ([]byte, error) {
+	if wlimit <= 0 {
+		wlimit = 1 << 30
+	}
+	if rlimit <= 0 {
+		rlimit = 1 << 30
+	}
+	dst := []byte(nil)
+	wbuf := base.IOBuffer{Data: make([]byte, minInt(wlimit, 1<<20))}
+	ri := 0
+	for {
+		n := minInt(rlimit, len(src)-ri)
+		rbuf := base.IOBuffer{
+			Data:   src[ri : ri+n],
+			WI:     n,
+			Closed: ri+n == len(src),
+		}
+		wbuf.WI = 0
+		status := f(wbuf.Writer(), rbuf.Reader())
+		dst = append(dst, wbuf.Data[:wbuf.WI]...)
+		ri += rbuf.RI
+
+		switch status {
+		case nil:
+			return dst, nil
+		case base.SuspensionShortRead:
+			if rbuf.Closed {
+				return dst, fmt.Errorf("testlib: short read from a closed source")
+			}
+		case base.SuspensionShortWrite:
+			// No-op. The next iteration gives f an empty destination buffer.
+		default:
+			return dst, status
+		}
+	}
+}
+
+func minInt(x int, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
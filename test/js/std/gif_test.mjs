@@ -0,0 +1,131 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for the generated wuffs_gif JavaScript module.
+//
+// To run them:
+//
+//   wuffs genlib -target=wasm32 std/gif
+//   node --test test/js/std/gif_test.mjs
+//
+// The WUFFS_WASM environment variable can name a libwuffs.wasm other than the
+// one under gen/lib/c.
+
+import assert from "node:assert/strict";
+import fs from "node:fs";
+import path from "node:path";
+import {before, test} from "node:test";
+import {fileURLToPath} from "node:url";
+
+const root = path.join(path.dirname(fileURLToPath(import.meta.url)), "..", "..", "..");
+const genlib = path.join(root, "gen", "lib", "c");
+
+const gif = await import(path.join(genlib, "js", "wuffs_gif.mjs"));
+
+function readFile(filename) {
+  return new Uint8Array(fs.readFileSync(path.join(root, "test", "data", filename)));
+}
+
+function wasmFilename() {
+  if (process.env.WUFFS_WASM) {
+    return process.env.WUFFS_WASM;
+  }
+  for (const name of fs.readdirSync(genlib).sort()) {
+    const f = path.join(genlib, name, "libwuffs.wasm");
+    if (name.endsWith("-wasm32") && fs.existsSync(f)) {
+      return f;
+    }
+  }
+  throw new Error("could not find libwuffs.wasm; set WUFFS_WASM");
+}
+
+before(async () => {
+  await gif.load(fs.readFileSync(wasmFilename()));
+});
+
+// decodeFirstFrame decodes the first frame of src, feeding it to the decoder
+// chunkSize bytes at a time.
+function decodeFirstFrame(src, chunkSize) {
+  const d = new gif.Decoder();
+  const r = new gif.IOBuffer(src.length);
+  const config = new gif.ImageConfig();
+  let buf = null;
+  try {
+    let i = 0;
+    const call = (f) => {
+      for (;;) {
+        const status = f();
+        if (status !== gif.SUSPENSION_SHORT_READ) {
+          return status;
+        }
+        assert.ok(i < src.length, "unexpected end of input");
+        i += r.write(src.subarray(i, i + chunkSize));
+      }
+    };
+
+    assert.equal(call(() => d.decodeConfig(config, r)), gif.STATUS_OK);
+    buf = new gif.ImageBuffer(config);
+    assert.equal(call(() => d.decodeFrame(buf, r)), gif.STATUS_OK);
+    return {
+      width: config.width,
+      height: config.height,
+      numLoops: config.numLoops,
+      pixels: buf.pixels(),
+      palette: buf.palette(),
+      dirtyRect: buf.dirtyRect,
+    };
+  } finally {
+    if (buf) {
+      buf.free();
+    }
+    config.free();
+    r.free();
+    d.free();
+  }
+}
+
+test("decode bricks-dither.gif", () => {
+  const src = readFile("bricks-dither.gif");
+  for (const chunkSize of [src.length, 4096, 97]) {
+    // bricks-dither.gif is a 160 × 120 still (not animated) GIF.
+    const got = decodeFirstFrame(src, chunkSize);
+    assert.equal(got.width, 160);
+    assert.equal(got.height, 120);
+    assert.equal(got.numLoops, 1);
+    assert.deepEqual(got.palette, readFile("bricks-dither.palette"));
+    assert.deepEqual(got.pixels, readFile("bricks-dither.indexes"));
+    assert.deepEqual(got.dirtyRect, {
+      minInclusiveX: 0,
+      minInclusiveY: 0,
+      maxExclusiveX: 160,
+      maxExclusiveY: 120,
+    });
+  }
+});
+
+test("bad header", () => {
+  const d = new gif.Decoder();
+  const r = new gif.IOBuffer(64);
+  const config = new gif.ImageConfig();
+  try {
+    r.write(new TextEncoder().encode("not a GIF file"));
+    r.close();
+    assert.throws(() => d.decodeConfig(config, r), gif.BadHeaderError);
+    assert.equal(config.isValid, false);
+  } finally {
+    config.free();
+    r.free();
+    d.free();
+  }
+});
@@ -0,0 +1,156 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for the generated wuffs_gzip and wuffs_crc32 JavaScript modules.
+//
+// To run them:
+//
+//   wuffs genlib -target=wasm32 std/gzip
+//   node --test test/js/std/gzip_test.mjs
+//
+// The WUFFS_WASM environment variable can name a libwuffs.wasm other than the
+// one under gen/lib/c.
+
+import assert from "node:assert/strict";
+import fs from "node:fs";
+import path from "node:path";
+import {before, test} from "node:test";
+import {fileURLToPath} from "node:url";
+import zlib from "node:zlib";
+
+const root = path.join(path.dirname(fileURLToPath(import.meta.url)), "..", "..", "..");
+const genlib = path.join(root, "gen", "lib", "c");
+
+const crc32 = await import(path.join(genlib, "js", "wuffs_crc32.mjs"));
+const gzip = await import(path.join(genlib, "js", "wuffs_gzip.mjs"));
+
+// goldens' first elements are the compressed filenames. The second elements
+// are the decompressed filenames.
+const goldens = [
+  ["midsummer.txt.gz", "midsummer.txt"],
+  ["pi.txt.gz", "pi.txt"],
+  ["romeo.txt.gz", "romeo.txt"],
+];
+
+function readFile(filename) {
+  return new Uint8Array(fs.readFileSync(path.join(root, "test", "data", filename)));
+}
+
+function wasmFilename() {
+  if (process.env.WUFFS_WASM) {
+    return process.env.WUFFS_WASM;
+  }
+  for (const name of fs.readdirSync(genlib).sort()) {
+    const f = path.join(genlib, name, "libwuffs.wasm");
+    if (name.endsWith("-wasm32") && fs.existsSync(f)) {
+      return f;
+    }
+  }
+  throw new Error("could not find libwuffs.wasm; set WUFFS_WASM");
+}
+
+before(async () => {
+  const instance = await gzip.load(fs.readFileSync(wasmFilename()));
+  await crc32.load(instance);
+});
+
+test("decode", () => {
+  for (const [filename, wantFilename] of goldens) {
+    const src = readFile(filename);
+    const want = readFile(wantFilename);
+    assert.deepEqual(new Uint8Array(zlib.gunzipSync(src)), want);
+    // Small buffer sizes exercise the suspension loop.
+    for (const bufferSize of [1, 7, 4096, 65536]) {
+      const d = new gzip.Decoder();
+      try {
+        assert.deepEqual(d.decodeAll(src, bufferSize), want, `${filename}, ${bufferSize}`);
+      } finally {
+        d.free();
+      }
+    }
+  }
+});
+
+test("decode suspensions", () => {
+  const src = new gzip.IOBuffer(16);
+  const dst = new gzip.IOBuffer(16);
+  const d = new gzip.Decoder();
+  try {
+    assert.equal(d.decode(dst, src), gzip.SUSPENSION_SHORT_READ);
+    src.write(readFile("romeo.txt.gz"));
+    assert.equal(d.decode(dst, src), gzip.SUSPENSION_SHORT_READ);
+    assert.equal(src.readerLength(), 0);
+    assert.equal(dst.readerLength(), 0);
+  } finally {
+    d.free();
+    dst.free();
+    src.free();
+  }
+});
+
+test("bad checksum", () => {
+  const src = readFile("romeo.txt.gz");
+  src[src.length - 5] ^= 1;
+
+  const d = new gzip.Decoder();
+  try {
+    assert.throws(() => d.decodeAll(src), (e) => {
+      assert.ok(e instanceof gzip.BadChecksumError);
+      assert.equal(e.status, gzip.ERROR_BAD_CHECKSUM);
+      assert.equal(e.message, "gzip: bad checksum");
+      return true;
+    });
+  } finally {
+    d.free();
+  }
+
+  const e = new gzip.Decoder();
+  try {
+    e.setIgnoreChecksum(true);
+    assert.deepEqual(e.decodeAll(src), readFile("romeo.txt"));
+  } finally {
+    e.free();
+  }
+});
+
+test("bad header", () => {
+  const d = new gzip.Decoder();
+  try {
+    assert.throws(() => d.decodeAll(new TextEncoder().encode("not a gzip file")),
+                  gzip.BadHeaderError);
+  } finally {
+    d.free();
+  }
+});
+
+test("truncated input", () => {
+  const d = new gzip.Decoder();
+  try {
+    assert.throws(() => d.decodeAll(readFile("romeo.txt.gz").subarray(0, 100)),
+                  gzip.UnexpectedEofError);
+  } finally {
+    d.free();
+  }
+});
+
+test("crc32 update", () => {
+  const src = readFile("midsummer.txt");
+  const h = new crc32.IeeeHasher();
+  try {
+    h.update(src.subarray(0, 1000));
+    assert.equal(h.update(src.subarray(1000)), zlib.crc32(src));
+  } finally {
+    h.free();
+  }
+});
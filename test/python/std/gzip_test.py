@@ -0,0 +1,116 @@
+# Copyright 2018 The Wuffs Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#    https://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Tests for the generated wuffs_gzip and wuffs_crc32 Python modules.
+
+To run them:
+
+    wuffs genlib std/gzip
+    python3 test/python/std/gzip_test.py
+
+The WUFFS_LIBRARY environment variable can name a libwuffs.so other than
+the one under gen/lib/c.
+"""
+
+import gzip
+import io
+import os
+import sys
+import unittest
+import zlib
+
+root = os.path.join(os.path.dirname(os.path.abspath(__file__)),
+                    os.pardir, os.pardir, os.pardir)
+sys.path.insert(0, os.path.join(root, "gen", "lib", "c", "python"))
+
+import wuffs_crc32
+import wuffs_gzip
+
+# goldens' first elements are the compressed filenames. The second elements
+# are the decompressed filenames.
+goldens = [
+    ("midsummer.txt.gz", "midsummer.txt"),
+    ("pi.txt.gz", "pi.txt"),
+    ("romeo.txt.gz", "romeo.txt"),
+]
+
+
+def read_file(filename):
+    with open(os.path.join(root, "test", "data", filename), "rb") as f:
+        return f.read()
+
+
+class GzipTest(unittest.TestCase):
+
+    def test_decode(self):
+        for filename, want_filename in goldens:
+            src = read_file(filename)
+            want = read_file(want_filename)
+            self.assertEqual(gzip.decompress(src), want)
+            # Small buffer sizes exercise the suspension loop.
+            for buffer_size in (1, 7, 4096, 65536):
+                dst = io.BytesIO()
+                status = wuffs_gzip.Decoder().decode_stream(
+                    dst, io.BytesIO(src), buffer_size)
+                self.assertEqual(status, wuffs_gzip.STATUS_OK)
+                self.assertEqual(dst.getvalue(), want,
+                                 "%s, %d" % (filename, buffer_size))
+
+    def test_decode_suspensions(self):
+        src = wuffs_gzip.IOBuffer(16)
+        dst = wuffs_gzip.IOBuffer(16)
+        d = wuffs_gzip.Decoder()
+        self.assertEqual(d.decode(dst, src), wuffs_gzip.SUSPENSION_SHORT_READ)
+        src.write(read_file("romeo.txt.gz"))
+        self.assertEqual(d.decode(dst, src), wuffs_gzip.SUSPENSION_SHORT_READ)
+        self.assertEqual(src.reader_length(), 0)
+        self.assertEqual(dst.reader_length(), 0)
+
+    def test_bad_checksum(self):
+        src = bytearray(read_file("romeo.txt.gz"))
+        src[-5] ^= 1
+        with self.assertRaises(wuffs_gzip.BadChecksumError) as cm:
+            wuffs_gzip.Decoder().decode_stream(io.BytesIO(), io.BytesIO(src))
+        self.assertEqual(cm.exception.status, wuffs_gzip.ERROR_BAD_CHECKSUM)
+        self.assertEqual(str(cm.exception), "gzip: bad checksum")
+
+        d = wuffs_gzip.Decoder()
+        d.set_ignore_checksum(True)
+        dst = io.BytesIO()
+        self.assertEqual(d.decode_stream(dst, io.BytesIO(src)),
+                         wuffs_gzip.STATUS_OK)
+        self.assertEqual(dst.getvalue(), read_file("romeo.txt"))
+
+    def test_bad_header(self):
+        with self.assertRaises(wuffs_gzip.BadHeaderError):
+            wuffs_gzip.Decoder().decode_stream(
+                io.BytesIO(), io.BytesIO(b"not a gzip file"))
+
+    def test_truncated_input(self):
+        src = read_file("romeo.txt.gz")[:100]
+        with self.assertRaises(wuffs_gzip.UnexpectedEofError):
+            wuffs_gzip.Decoder().decode_stream(io.BytesIO(), io.BytesIO(src))
+
+
+class Crc32Test(unittest.TestCase):
+
+    def test_update(self):
+        src = read_file("midsummer.txt")
+        h = wuffs_crc32.IeeeHasher()
+        h.update(src[:1000])
+        self.assertEqual(h.update(bytearray(src[1000:])), zlib.crc32(src))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,75 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::SliceU8;
+use wuffs_std_adler32::Hasher;
+
+// The want values are determined by script/checksum.go.
+const GOLDENS: &[(&str, u32)] = &[
+    ("hat.bmp", 0x3D26D034),
+    ("hat.gif", 0x2A5EB144),
+    ("hat.jpeg", 0x3A503B1A),
+    ("hat.lossless.webp", 0xD059D427),
+    ("hat.lossy.webp", 0xF1BB258D),
+    ("hat.png", 0xDFC6C9C6),
+    ("hat.tiff", 0xBDC011E9),
+    ("midsummer.txt", 0x7E98F58E),
+    ("pi.txt", 0xA62F1E51),
+];
+
+fn update(h: &mut Hasher, data: &mut [u8]) -> u32 {
+    h.update(SliceU8::from(data))
+}
+
+#[test]
+fn test_golden() {
+    for &(filename, want) in GOLDENS {
+        let mut src = testlib::read_file(filename);
+        let n = src.len();
+
+        // Split src into two update calls at various points, as the checksum
+        // should not depend on how its input is chunked.
+        for i in [0, 1, 5551, 5552, 5553, n / 2, n] {
+            if i > n {
+                continue;
+            }
+            let mut h = Hasher::default();
+            let (x, y) = src.split_at_mut(i);
+            update(&mut h, x);
+            let got = update(&mut h, y);
+            assert_eq!(got, want, "{}: split at {}", filename, i);
+        }
+    }
+}
+
+fn bench_adler32(name: &str, filename: &str, iters_unscaled: u64) {
+    let mut src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut h = Hasher::default();
+        update(&mut h, &mut src);
+        src.len() as u64
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_adler32_10k() {
+    bench_adler32("wuffs_adler32_10k", "midsummer.txt", 1500);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_adler32_100k() {
+    bench_adler32("wuffs_adler32_100k", "pi.txt", 150);
+}
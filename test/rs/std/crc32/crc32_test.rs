@@ -0,0 +1,75 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::SliceU8;
+use wuffs_std_crc32::IeeeHasher;
+
+// The want values are determined by script/checksum.go.
+const GOLDENS: &[(&str, u32)] = &[
+    ("hat.bmp", 0xA95A578B),
+    ("hat.gif", 0xD9743B6A),
+    ("hat.jpeg", 0x7F1A90CD),
+    ("hat.lossless.webp", 0x485AA040),
+    ("hat.lossy.webp", 0x89F53B4E),
+    ("hat.png", 0xD5DA5C2F),
+    ("hat.tiff", 0xBEF54503),
+    ("midsummer.txt", 0x3DB2CDC6),
+    ("pi.txt", 0x519E8B87),
+];
+
+fn update(h: &mut IeeeHasher, data: &mut [u8]) -> u32 {
+    h.update(SliceU8::from(data))
+}
+
+#[test]
+fn test_golden() {
+    for &(filename, want) in GOLDENS {
+        let mut src = testlib::read_file(filename);
+        let n = src.len();
+
+        // Split src into two update calls at various points, as the checksum
+        // should not depend on how its input is chunked.
+        for i in [0, 1, 7, 8, 9, n / 2, n] {
+            if i > n {
+                continue;
+            }
+            let mut h = IeeeHasher::default();
+            let (x, y) = src.split_at_mut(i);
+            update(&mut h, x);
+            let got = update(&mut h, y);
+            assert_eq!(got, want, "{}: split at {}", filename, i);
+        }
+    }
+}
+
+fn bench_crc32_ieee(name: &str, filename: &str, iters_unscaled: u64) {
+    let mut src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut h = IeeeHasher::default();
+        update(&mut h, &mut src);
+        src.len() as u64
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_crc32_ieee_10k() {
+    bench_crc32_ieee("wuffs_crc32_ieee_10k", "midsummer.txt", 1500);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_crc32_ieee_100k() {
+    bench_crc32_ieee("wuffs_crc32_ieee_100k", "pi.txt", 150);
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_std_deflate::{Decoder, Encoder};
+
+// GOLDENS' first elements are the compressed filenames. A ".zlib" file holds a
+// deflate stream after its 2 byte header. The second elements are the
+// decompressed filenames.
+const GOLDENS: &[(&str, &str)] = &[
+    (
+        "artificial/deflate-backref-crosses-blocks.deflate",
+        "artificial/deflate-backref-crosses-blocks.deflate.decompressed",
+    ),
+    (
+        "artificial/deflate-degenerate-huffman-code.deflate",
+        "artificial/deflate-degenerate-huffman-code.deflate.decompressed",
+    ),
+    (
+        "artificial/deflate-distance-32768.deflate",
+        "artificial/deflate-distance-32768.deflate.decompressed",
+    ),
+    ("midsummer.txt.zlib", "midsummer.txt"),
+    ("pi.txt.zlib", "pi.txt"),
+    ("romeo.txt.deflate", "romeo.txt"),
+    ("romeo.txt.fixed-huff.deflate", "romeo.txt"),
+];
+
+fn read_golden(filename: &str, want_filename: &str) -> (Vec<u8>, Vec<u8>) {
+    let mut src = testlib::read_file(filename);
+    if filename.ends_with(".zlib") {
+        src.drain(..2);
+    }
+    (src, testlib::read_file(want_filename))
+}
+
+fn test_decode(wlimit: usize, rlimit: usize) {
+    for &(filename, want_filename) in GOLDENS {
+        let (src, want) = read_golden(filename, want_filename);
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, wlimit, rlimit) {
+            Ok(got) => assert!(
+                got == want,
+                "{}: wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+                filename,
+                wlimit,
+                rlimit,
+                got.len(),
+                want.len()
+            ),
+            Err(status) => panic!(
+                "{}: wlimit={}, rlimit={}: {}",
+                filename, wlimit, rlimit, status
+            ),
+        }
+    }
+}
+
+#[test]
+fn test_decode_all_at_once() {
+    test_decode(0, 0);
+}
+
+#[test]
+fn test_decode_many_big_reads() {
+    test_decode(0, 4096);
+}
+
+#[test]
+fn test_decode_many_medium_reads() {
+    test_decode(0, 599);
+}
+
+#[test]
+fn test_decode_many_small_writes_reads() {
+    test_decode(59, 61);
+}
+
+#[test]
+fn test_decode_many_tiny_writes_reads() {
+    test_decode(1, 1);
+}
+
+/// round_trip encodes filename at the given compression level and then
+/// decodes the result, checking that it gives back the original.
+fn round_trip(filename: &str, level: u32, wlimit: usize, rlimit: usize) {
+    let mut e = Encoder::default();
+    e.set_level(level);
+    let mut d = Decoder::default();
+    testlib::round_trip(
+        filename,
+        level,
+        wlimit,
+        rlimit,
+        |dst, src| e.encode(dst, src),
+        |dst, src| d.decode(dst, src),
+    );
+}
+
+#[test]
+fn test_encode_levels() {
+    for level in 0..=9 {
+        round_trip("midsummer.txt", level, 0, 0);
+    }
+}
+
+#[test]
+fn test_encode_many_small_writes_reads() {
+    // Level 0 writes stored blocks and level 6 writes Huffman-coded blocks.
+    for &level in &[0, 6] {
+        round_trip("pi.txt", level, 59, 61);
+    }
+}
+
+fn bench_decode(name: &str, filename: &str, want_filename: &str, iters_unscaled: u64) {
+    let (src, _) = read_golden(filename, want_filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0) {
+            Ok(got) => got.len() as u64,
+            Err(status) => panic!("{}: {}", filename, status),
+        }
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_deflate_decode_1k() {
+    bench_decode(
+        "wuffs_deflate_decode_1k",
+        "romeo.txt.deflate",
+        "romeo.txt",
+        2000,
+    );
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_deflate_decode_10k() {
+    bench_decode(
+        "wuffs_deflate_decode_10k",
+        "midsummer.txt.zlib",
+        "midsummer.txt",
+        300,
+    );
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_deflate_decode_100k() {
+    bench_decode("wuffs_deflate_decode_100k", "pi.txt.zlib", "pi.txt", 30);
+}
@@ -0,0 +1,329 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{ImageBuffer, ImageConfig, IoBuffer, RectIeU32, Status};
+use wuffs_std_gif::{Decoder, Encoder};
+
+fn rect(x0: u32, y0: u32, x1: u32, y1: u32) -> RectIeU32 {
+    RectIeU32 {
+        min_inclusive_x: x0,
+        min_inclusive_y: y0,
+        max_exclusive_x: x1,
+        max_exclusive_y: y1,
+    }
+}
+
+/// Decoded is a decoded GIF's image config, the dirty rectangle of each frame
+/// and the final pixel buffer and palette.
+struct Decoded {
+    config: ImageConfig,
+    rects: Vec<RectIeU32>,
+    pixels: Vec<u8>,
+    palette: Vec<u8>,
+}
+
+/// decode decodes all of src's frames, feeding the decoder at most rlimit
+/// bytes at a time (or all of src, if rlimit is zero).
+fn decode(src: &[u8], rlimit: usize) -> Result<Decoded, Status> {
+    let rlimit = if rlimit == 0 { src.len() } else { rlimit };
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, rlimit, rlimit >= n);
+    let feed = |r: &mut IoBuffer| -> bool {
+        if r.closed {
+            return false;
+        }
+        r.wi = n.min(r.wi + rlimit);
+        r.closed = r.wi == n;
+        true
+    };
+
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    loop {
+        match d.decode_config(&mut config, r.reader()) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut rects = Vec::new();
+    loop {
+        match d.decode_frame(&mut ib, r.reader()) {
+            Status::Ok => rects.push(ib.dirty_rect()),
+            wuffs_base::SUSPENSION_END_OF_DATA => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+    let palette = ib.palette().to_vec();
+    Ok(Decoded {
+        config,
+        rects,
+        pixels,
+        palette,
+    })
+}
+
+fn test_decode_input_is_a_gif(rlimit: usize) {
+    let src = testlib::read_file("bricks-dither.gif");
+    let got = match decode(&src, rlimit) {
+        Ok(got) => got,
+        Err(status) => panic!("rlimit={}: {}", rlimit, status),
+    };
+
+    // bricks-dither.gif is a 160 × 120 still (not animated) GIF.
+    assert_eq!(
+        got.config.pixel_format(),
+        wuffs_base::PIXEL_FORMAT_BGRA_NONPREMUL_INDEXED
+    );
+    assert_eq!(got.config.width(), 160);
+    assert_eq!(got.config.height(), 120);
+    assert_eq!(got.config.num_loops(), 1);
+    assert_eq!(got.rects, [rect(0, 0, 160, 120)]);
+    assert!(
+        got.palette == testlib::read_file("bricks-dither.palette"),
+        "rlimit={}: palettes differ",
+        rlimit
+    );
+    assert!(
+        got.pixels == testlib::read_file("bricks-dither.indexes"),
+        "rlimit={}: indexes differ",
+        rlimit
+    );
+}
+
+#[test]
+fn test_decode_input_is_a_gif_just_one_read() {
+    test_decode_input_is_a_gif(0);
+}
+
+#[test]
+fn test_decode_input_is_a_gif_many_big_reads() {
+    test_decode_input_is_a_gif(4096);
+}
+
+#[test]
+fn test_decode_input_is_a_gif_many_medium_reads() {
+    test_decode_input_is_a_gif(787);
+}
+
+#[test]
+fn test_decode_input_is_a_gif_many_small_reads() {
+    test_decode_input_is_a_gif(13);
+}
+
+#[test]
+fn test_decode_input_is_a_png() {
+    let src = testlib::read_file("bricks-dither.png");
+    assert_eq!(decode(&src, 0).err(), Some(wuffs_std_gif::ERROR_BAD_HEADER));
+}
+
+#[test]
+fn test_decode_call_sequence() {
+    let mut src = testlib::read_file("bricks-dither.gif");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader()),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
+
+fn test_decode_animated(filename: &str, want_num_loops: u32, want_num_frames: usize) -> Decoded {
+    let src = testlib::read_file(filename);
+    let got = match decode(&src, 0) {
+        Ok(got) => got,
+        Err(status) => panic!("{}: {}", filename, status),
+    };
+    assert_eq!(got.config.num_loops(), want_num_loops, "{}", filename);
+    assert_eq!(got.rects.len(), want_num_frames, "{}", filename);
+    got
+}
+
+#[test]
+fn test_decode_animated_big() {
+    test_decode_animated("gifplayer-muybridge.gif", 0, 380);
+}
+
+#[test]
+fn test_decode_animated_medium() {
+    test_decode_animated("muybridge.gif", 0, 15);
+}
+
+#[test]
+fn test_decode_animated_small() {
+    // animated-red-blue.gif's num_loops should be 3. The value explicitly in
+    // the wire format is 0x0002, but that value means "repeat 2 times after
+    // the first play", so the total number of loops is 3.
+    let got = test_decode_animated("animated-red-blue.gif", 3, 4);
+    assert_eq!(
+        got.rects,
+        [
+            rect(0, 0, 64, 48),
+            rect(15, 31, 52, 40),
+            rect(15, 0, 64, 40),
+            rect(15, 0, 64, 40),
+        ]
+    );
+}
+
+/// encode decodes all of src's frames and re-encodes them, writing at most
+/// wlimit bytes at a time (or without limit, if wlimit is zero).
+fn encode(src: &[u8], wlimit: usize) -> Result<Vec<u8>, Status> {
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    match d.decode_config(&mut config, r.reader()) {
+        Status::Ok => {}
+        status => return Err(status),
+    }
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+
+    let mut e = Encoder::default();
+    let mut dst = Vec::new();
+    let mut wbuf = vec![0u8; if wlimit == 0 { 1 << 20 } else { wlimit }];
+    let mut run = |f: &mut dyn FnMut(&mut IoBuffer) -> Status| -> Result<(), Status> {
+        loop {
+            let mut w = IoBuffer::new(&mut wbuf);
+            let status = f(&mut w);
+            dst.extend_from_slice(w.readable());
+            match status {
+                Status::Ok => return Ok(()),
+                wuffs_base::SUSPENSION_SHORT_WRITE => continue,
+                status => return Err(status),
+            }
+        }
+    };
+
+    run(&mut |w| e.encode_config(w.writer(), &mut config))?;
+    loop {
+        match d.decode_frame(&mut ib, r.reader()) {
+            Status::Ok => run(&mut |w| e.encode_frame(w.writer(), &mut ib))?,
+            wuffs_base::SUSPENSION_END_OF_DATA => break,
+            status => return Err(status),
+        }
+    }
+    run(&mut |w| e.encode_trailer(w.writer()))?;
+    Ok(dst)
+}
+
+fn test_encode_round_trip(filename: &str, wlimit: usize) {
+    let src = testlib::read_file(filename);
+    let want = match decode(&src, 0) {
+        Ok(want) => want,
+        Err(status) => panic!("{}: {}", filename, status),
+    };
+    let encoded = match encode(&src, wlimit) {
+        Ok(encoded) => encoded,
+        Err(status) => panic!("{}: wlimit={}: encode: {}", filename, wlimit, status),
+    };
+    let got = match decode(&encoded, 0) {
+        Ok(got) => got,
+        Err(status) => panic!("{}: wlimit={}: decode: {}", filename, wlimit, status),
+    };
+    assert_eq!(got.config.width(), want.config.width(), "{}", filename);
+    assert_eq!(got.config.height(), want.config.height(), "{}", filename);
+    assert_eq!(
+        got.config.num_loops(),
+        want.config.num_loops(),
+        "{}",
+        filename
+    );
+    assert_eq!(got.rects, want.rects, "{}", filename);
+    assert!(
+        got.pixels == want.pixels,
+        "{}: wlimit={}: indexes differ",
+        filename,
+        wlimit
+    );
+}
+
+#[test]
+fn test_encode_round_trip_animated() {
+    test_encode_round_trip("animated-red-blue.gif", 0);
+    test_encode_round_trip("muybridge.gif", 0);
+}
+
+#[test]
+fn test_encode_round_trip_still() {
+    test_encode_round_trip("bricks-dither.gif", 0);
+    test_encode_round_trip("bricks-dither.interlaced.gif", 0);
+}
+
+#[test]
+fn test_encode_round_trip_many_small_writes() {
+    test_encode_round_trip("animated-red-blue.gif", 11);
+    test_encode_round_trip("bricks-dither.gif", 11);
+}
+
+#[test]
+fn test_encode_call_sequence() {
+    let mut buf = vec![0u8; 1024];
+    let mut w = IoBuffer::new(&mut buf);
+    let mut e = Encoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        e.encode_frame(w.writer(), &mut ib),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || match decode(&src, 0) {
+        Ok(got) => got.pixels.len() as u64,
+        Err(status) => panic!("{}: {}", filename, status),
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gif_decode_1k_bw() {
+    bench_decode("wuffs_gif_decode_1k_bw", "pjw-thumbnail.gif", 2000);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gif_decode_1k_color() {
+    bench_decode("wuffs_gif_decode_1k_color", "hippopotamus.gif", 1000);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gif_decode_10k() {
+    bench_decode("wuffs_gif_decode_10k", "hat.gif", 100);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gif_decode_100k() {
+    bench_decode("wuffs_gif_decode_100k", "hibiscus.gif", 10);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gif_decode_1000k() {
+    bench_decode("wuffs_gif_decode_1000k", "harvesters.gif", 1);
+}
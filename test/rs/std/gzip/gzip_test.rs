@@ -0,0 +1,198 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_std_gzip::{Decoder, Encoder};
+
+// GOLDENS' first elements are the compressed filenames. The second elements
+// are the decompressed filenames.
+const GOLDENS: &[(&str, &str)] = &[
+    ("midsummer.txt.gz", "midsummer.txt"),
+    ("pi.txt.gz", "pi.txt"),
+    ("romeo.txt.gz", "romeo.txt"),
+];
+
+fn test_decode(wlimit: usize, rlimit: usize) {
+    for &(filename, want_filename) in GOLDENS {
+        let src = testlib::read_file(filename);
+        let want = testlib::read_file(want_filename);
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, wlimit, rlimit) {
+            Ok(got) => assert!(
+                got == want,
+                "{}: wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+                filename,
+                wlimit,
+                rlimit,
+                got.len(),
+                want.len()
+            ),
+            Err(status) => panic!(
+                "{}: wlimit={}, rlimit={}: {}",
+                filename, wlimit, rlimit, status
+            ),
+        }
+    }
+}
+
+#[test]
+fn test_decode_all_at_once() {
+    test_decode(0, 0);
+}
+
+#[test]
+fn test_decode_many_small_reads() {
+    test_decode(0, 7);
+}
+
+#[test]
+fn test_decode_many_small_writes() {
+    test_decode(11, 0);
+}
+
+#[test]
+fn test_decode_many_tiny_writes_reads() {
+    test_decode(1, 1);
+}
+
+#[test]
+fn test_decode_bad_checksum() {
+    let mut src = testlib::read_file("romeo.txt.gz");
+    let n = src.len();
+    src[n - 8] ^= 1;
+
+    let mut d = Decoder::default();
+    let got = testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0);
+    assert_eq!(got.err(), Some(wuffs_std_gzip::ERROR_BAD_CHECKSUM));
+
+    let mut d = Decoder::default();
+    d.set_ignore_checksum(true);
+    let got = testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0);
+    assert!(got.is_ok(), "ignoring the checksum: got {:?}", got.err());
+}
+
+// HEADER_SRC is an empty gzip member whose header has FEXTRA, FNAME, FCOMMENT
+// and FHCRC fields. The name and comment are ISO 8859-1 encoded.
+const HEADER_SRC: &[u8] = &[
+    0x1F, 0x8B, 0x08, 0x1E, 0xD2, 0x02, 0x96, 0x49, 0x00, 0x03, 0x02, 0x00, 0xAB, 0xCD, 0x63, 0x61,
+    0x66, 0xE9, 0x2E, 0x74, 0x78, 0x74, 0x00, 0xA9, 0x20, 0x32, 0x30, 0x31, 0x38, 0x20, 0xC0, 0x20,
+    0x6C, 0x61, 0x20, 0x63, 0x61, 0x72, 0x74, 0x65, 0x00, 0x0D, 0x61, 0x03, 0x00, 0x00, 0x00, 0x00,
+    0x00, 0x00, 0x00, 0x00, 0x00,
+];
+
+#[test]
+fn test_decode_header_fields() {
+    let mut d = Decoder::default();
+    let got = testlib::decode(|dst, src| d.decode(dst, src), HEADER_SRC, 0, 0);
+    assert!(got.is_ok(), "got {:?}", got.err());
+    assert_eq!(d.modification_time(), 1234567890);
+    assert_eq!(d.operating_system(), 3);
+    assert_eq!(d.file_name().as_slice(), "café.txt".as_bytes());
+    assert_eq!(d.comment().as_slice(), "© 2018 À la carte".as_bytes());
+}
+
+#[test]
+fn test_decode_bad_header_checksum() {
+    let mut src = HEADER_SRC.to_vec();
+    src[4] ^= 1;
+
+    let mut d = Decoder::default();
+    let got = testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0);
+    assert_eq!(got.err(), Some(wuffs_std_gzip::ERROR_BAD_HEADER_CHECKSUM));
+}
+
+#[test]
+fn test_decode_multi_member() {
+    let mut src = Vec::new();
+    let mut want = Vec::new();
+    for &(filename, want_filename) in GOLDENS {
+        src.extend(testlib::read_file(filename));
+        want.extend(testlib::read_file(want_filename));
+    }
+    let first_len = testlib::read_file(GOLDENS[0].1).len();
+
+    for &multi_member in &[false, true] {
+        let mut d = Decoder::default();
+        d.set_multi_member(multi_member);
+        let got = match testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 7) {
+            Ok(got) => got,
+            Err(status) => panic!("multi_member={}: {}", multi_member, status),
+        };
+        let want = if multi_member {
+            &want[..]
+        } else {
+            &want[..first_len]
+        };
+        assert!(
+            got == want,
+            "multi_member={}: got {} bytes, want {} bytes",
+            multi_member,
+            got.len(),
+            want.len()
+        );
+    }
+}
+
+/// round_trip encodes filename at the given compression level and then
+/// decodes the result, checking that it gives back the original.
+fn round_trip(filename: &str, level: u32, wlimit: usize, rlimit: usize) {
+    let mut e = Encoder::default();
+    e.set_level(level);
+    let mut d = Decoder::default();
+    testlib::round_trip(
+        filename,
+        level,
+        wlimit,
+        rlimit,
+        |dst, src| e.encode(dst, src),
+        |dst, src| d.decode(dst, src),
+    );
+}
+
+#[test]
+fn test_encode_levels() {
+    for level in 0..=9 {
+        round_trip("midsummer.txt", level, 0, 0);
+    }
+}
+
+#[test]
+fn test_encode_many_small_writes_reads() {
+    // Level 0 writes stored blocks and level 6 writes Huffman-coded blocks.
+    for &level in &[0, 6] {
+        round_trip("pi.txt", level, 59, 61);
+    }
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0) {
+            Ok(got) => got.len() as u64,
+            Err(status) => panic!("{}: {}", filename, status),
+        }
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gzip_decode_10k() {
+    bench_decode("wuffs_gzip_decode_10k", "midsummer.txt.gz", 300);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_gzip_decode_100k() {
+    bench_decode("wuffs_gzip_decode_100k", "pi.txt.gz", 30);
+}
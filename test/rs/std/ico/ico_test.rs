@@ -0,0 +1,138 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{ImageBuffer, ImageConfig, IoBuffer, SliceU8, Status};
+use wuffs_std_ico::Decoder;
+
+/// FILENAMES are the test/data/bmpsuite ICO and CUR files, generated by
+/// script/make-bmpsuite.go, without the "bmpsuite/" prefix. Each "foo.ico" or
+/// "foo.cur" file should decode to the same pixels as the "foo.png" file.
+const FILENAMES: &[&str] = &[
+    "cursor.cur",
+    "icon-32bpp.ico",
+    "icon-bmp.ico",
+    "icon-png.ico",
+];
+
+/// decode decodes src's BGRA_NONPREMUL pixels, feeding the decoder at most
+/// rlimit bytes at a time (or all of src, if rlimit is zero).
+fn decode(d: &mut Decoder, src: &[u8], rlimit: usize) -> Result<Vec<u8>, Status> {
+    let rlimit = if rlimit == 0 { src.len() } else { rlimit };
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, rlimit, rlimit >= n);
+    let feed = |r: &mut IoBuffer| -> bool {
+        if r.closed {
+            return false;
+        }
+        r.wi = n.min(r.wi + rlimit);
+        r.closed = r.wi == n;
+        true
+    };
+
+    let mut config = ImageConfig::default();
+    loop {
+        match d.decode_config(&mut config, r.reader()) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    loop {
+        match d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        wuffs_base::SUSPENSION_END_OF_DATA
+    );
+    Ok(pixels)
+}
+
+/// decode_png decodes the PNG file that holds the pixels that an ICO or CUR
+/// file should decode to.
+fn decode_png(filename: &str) -> Vec<u8> {
+    let mut src = testlib::read_file(filename);
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = wuffs_std_png::Decoder::default();
+    let mut config = ImageConfig::default();
+    assert_eq!(d.decode_config(&mut config, r.reader()), Status::Ok);
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        Status::Ok
+    );
+    pixels
+}
+
+#[test]
+fn test_decode() {
+    for name in FILENAMES {
+        let src = testlib::read_file(&format!("bmpsuite/{}", name));
+        let want = decode_png(&format!("bmpsuite/{}.png", &name[..name.len() - 4]));
+        for rlimit in [0, 7] {
+            match decode(&mut Decoder::default(), &src, rlimit) {
+                Ok(got) => assert!(got == want, "{}: rlimit={}: pixels differ", name, rlimit),
+                Err(status) => panic!("{}: rlimit={}: {}", name, rlimit, status),
+            }
+        }
+    }
+}
+
+#[test]
+fn test_decode_cursor() {
+    let src = testlib::read_file("bmpsuite/cursor.cur");
+    let mut d = Decoder::default();
+    if let Err(status) = decode(&mut d, &src, 0) {
+        panic!("{}", status);
+    }
+    assert!(d.is_cursor());
+    assert_eq!(d.num_images(), 2);
+    // The largest sub-image's hotspot is (17, 14).
+    assert_eq!((d.hotspot_x(), d.hotspot_y()), (17, 14));
+}
+
+#[test]
+fn test_decode_input_is_a_bmp() {
+    let src = testlib::read_file("bricks-color.bmp");
+    assert_eq!(
+        decode(&mut Decoder::default(), &src, 0).err(),
+        Some(wuffs_std_ico::ERROR_BAD_HEADER)
+    );
+}
+
+#[test]
+fn test_decode_call_sequence() {
+    let mut src = testlib::read_file("bmpsuite/icon-bmp.ico");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::default()),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{ImageBuffer, ImageConfig, IoBuffer, SliceU8, Status};
+use wuffs_std_jpeg::Decoder;
+
+/// JPEGSUITE_FILENAMES are the test/data/jpegsuite files, generated by
+/// script/make-jpegsuite.c, other than those that Wuffs does not support.
+const JPEGSUITE_FILENAMES: &[&str] = &[
+    "baseline-gray",
+    "baseline-444",
+    "baseline-440",
+    "baseline-422",
+    "baseline-420",
+    "baseline-420-noninterleaved",
+    "baseline-420-optimized",
+    "baseline-420-restart",
+    "progressive-gray",
+    "progressive-444",
+    "progressive-440",
+    "progressive-422",
+    "progressive-420",
+    "progressive-420-restart",
+];
+
+/// Decoded is a decoded JPEG's image config and Y or YUV pixels, with the
+/// planes laid out one after another.
+struct Decoded {
+    config: ImageConfig,
+    pixels: Vec<u8>,
+}
+
+/// decode decodes src, feeding the decoder at most rlimit bytes at a time (or
+/// all of src, if rlimit is zero).
+fn decode(src: &[u8], rlimit: usize) -> Result<Decoded, Status> {
+    let rlimit = if rlimit == 0 { src.len() } else { rlimit };
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, rlimit, rlimit >= n);
+    let feed = |r: &mut IoBuffer| -> bool {
+        if r.closed {
+            return false;
+        }
+        r.wi = n.min(r.wi + rlimit);
+        r.closed = r.wi == n;
+        true
+    };
+
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    loop {
+        match d.decode_config(&mut config, r.reader()) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    loop {
+        match d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        wuffs_base::SUSPENSION_END_OF_DATA
+    );
+    Ok(Decoded { config, pixels })
+}
+
+fn must_decode(filename: &str, rlimit: usize) -> Decoded {
+    let src = testlib::read_file(filename);
+    match decode(&src, rlimit) {
+        Ok(got) => got,
+        Err(status) => panic!("{}: rlimit={}: {}", filename, rlimit, status),
+    }
+}
+
+fn test_decode_input_is_a_jpeg(rlimit: usize) {
+    let got = must_decode("bricks-color.jpeg", rlimit);
+
+    // bricks-color.jpeg is a 160 × 120 image, with 4:2:0 chroma subsampling.
+    // The Y plane is 160 × 120 and the Cb and Cr planes are 80 × 60.
+    assert_eq!(got.config.pixel_format(), wuffs_base::PIXEL_FORMAT_YUV);
+    assert_eq!(
+        got.config.pixel_subsampling(),
+        wuffs_base::PIXEL_SUBSAMPLING_420
+    );
+    assert_eq!(got.config.width(), 160);
+    assert_eq!(got.config.height(), 120);
+    assert_eq!(got.config.pixbuf_size(), (160 * 120) + (2 * 80 * 60));
+    assert!(
+        got.pixels == must_decode("bricks-color.jpeg", 0).pixels,
+        "rlimit={}: pixels differ",
+        rlimit
+    );
+}
+
+#[test]
+fn test_decode_input_is_a_jpeg_just_one_read() {
+    test_decode_input_is_a_jpeg(0);
+}
+
+#[test]
+fn test_decode_input_is_a_jpeg_many_big_reads() {
+    test_decode_input_is_a_jpeg(4096);
+}
+
+#[test]
+fn test_decode_input_is_a_jpeg_many_small_reads() {
+    test_decode_input_is_a_jpeg(13);
+}
+
+#[test]
+fn test_decode_input_is_a_png() {
+    let src = testlib::read_file("bricks-color.png");
+    assert_eq!(
+        decode(&src, 0).err(),
+        Some(wuffs_std_jpeg::ERROR_BAD_HEADER)
+    );
+}
+
+#[test]
+fn test_decode_arithmetic_coding() {
+    let src = testlib::read_file("jpegsuite/arithmetic-420.jpeg");
+    assert_eq!(
+        decode(&src, 0).err(),
+        Some(wuffs_std_jpeg::ERROR_TODO_UNSUPPORTED_ARITHMETIC_CODING)
+    );
+}
+
+#[test]
+fn test_decode_call_sequence() {
+    let mut src = testlib::read_file("bricks-color.jpeg");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::default()),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
+
+#[test]
+fn test_decode_jpegsuite() {
+    // The jpegsuite images are 157 × 117 pixels: bricks-color.jpeg (160 × 120)
+    // cropped by 3 pixels in each dimension. Each should decode the same,
+    // regardless of how the input is chunked.
+    for name in JPEGSUITE_FILENAMES {
+        let filename = format!("jpegsuite/{}.jpeg", name);
+        let want = must_decode(&filename, 0);
+        assert_eq!(want.config.width(), 157, "{}", filename);
+        assert_eq!(want.config.height(), 117, "{}", filename);
+        assert!(
+            must_decode(&filename, 7).pixels == want.pixels,
+            "{}: rlimit=7: pixels differ",
+            filename
+        );
+    }
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || match decode(&src, 0) {
+        Ok(got) => got.pixels.len() as u64,
+        Err(status) => panic!("{}: {}", filename, status),
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_jpeg_decode_1k() {
+    bench_decode("wuffs_jpeg_decode_1k", "hippopotamus.jpeg", 1000);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_jpeg_decode_10k() {
+    bench_decode("wuffs_jpeg_decode_10k", "hat.jpeg", 100);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_jpeg_decode_100k() {
+    bench_decode("wuffs_jpeg_decode_100k", "hibiscus.jpeg", 10);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_jpeg_decode_1000k() {
+    bench_decode("wuffs_jpeg_decode_1000k", "harvesters.jpeg", 1);
+}
+
+#[test]
+fn test_decode_progressive_tiled() {
+    // progressive-444-tiled.jpeg is the 157 × 117 progressive-444 image,
+    // repeated 2 × 2 times. Its 40 × 30 blocks per component need a 460800
+    // byte work buffer, 128 bytes per block.
+    let filename = "jpegsuite/progressive-444-tiled.jpeg";
+    let mut src = testlib::read_file(filename);
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    assert_eq!(d.decode_config(&mut config, r.reader()), Status::Ok);
+    assert_eq!(config.width(), 314);
+    assert_eq!(config.height(), 234);
+    assert_eq!(d.workbuf_len(), 40 * 30 * 3 * 128);
+
+    let want = must_decode(filename, 0);
+    assert!(
+        must_decode(filename, 7).pixels == want.pixels,
+        "rlimit=7: pixels differ"
+    );
+}
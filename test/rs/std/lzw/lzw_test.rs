@@ -0,0 +1,181 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_std_lzw::{Decoder, Encoder};
+
+// GOLDENS' first elements are the compressed filenames, whose first byte is
+// the LZW literal width. The second elements are the decompressed filenames.
+const GOLDENS: &[(&str, &str)] = &[
+    ("bricks-dither.indexes.giflzw", "bricks-dither.indexes"),
+    ("bricks-gray.indexes.giflzw", "bricks-gray.indexes"),
+    ("bricks-nodither.indexes.giflzw", "bricks-nodither.indexes"),
+    ("pi.txt.giflzw", "pi.txt"),
+];
+
+fn read_golden(filename: &str, want_filename: &str) -> (u32, Vec<u8>, Vec<u8>) {
+    let mut src = testlib::read_file(filename);
+    assert!(!src.is_empty(), "{}: empty file", filename);
+    let literal_width = src.remove(0) as u32;
+    (literal_width, src, testlib::read_file(want_filename))
+}
+
+fn test_decode(wlimit: usize, rlimit: usize) {
+    for &(filename, want_filename) in GOLDENS {
+        let (literal_width, src, want) = read_golden(filename, want_filename);
+        let mut d = Decoder::default();
+        d.set_literal_width(literal_width);
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, wlimit, rlimit) {
+            Ok(got) => assert!(
+                got == want,
+                "{}: wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+                filename,
+                wlimit,
+                rlimit,
+                got.len(),
+                want.len()
+            ),
+            Err(status) => panic!(
+                "{}: wlimit={}, rlimit={}: {}",
+                filename, wlimit, rlimit, status
+            ),
+        }
+    }
+}
+
+#[test]
+fn test_decode_all_at_once() {
+    test_decode(0, 0);
+}
+
+#[test]
+fn test_decode_many_big_reads() {
+    test_decode(4096, 4096);
+}
+
+#[test]
+fn test_decode_many_small_writes_reads() {
+    test_decode(41, 43);
+}
+
+#[test]
+fn test_decode_many_tiny_writes_reads() {
+    test_decode(1, 1);
+}
+
+/// test_decode_tiff checks the TIFF mode. Unlike *.giflzw files, *.tifflzw
+/// files have no initial literal width byte.
+fn test_decode_tiff(wlimit: usize, rlimit: usize) {
+    let src = testlib::read_file("pi.txt.tifflzw");
+    let want = testlib::read_file("pi.txt");
+    let mut d = Decoder::default();
+    d.set_tiff_mode(true);
+    match testlib::decode(|dst, src| d.decode(dst, src), &src, wlimit, rlimit) {
+        Ok(got) => assert!(
+            got == want,
+            "wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+            wlimit,
+            rlimit,
+            got.len(),
+            want.len()
+        ),
+        Err(status) => panic!("wlimit={}, rlimit={}: {}", wlimit, rlimit, status),
+    }
+}
+
+#[test]
+fn test_decode_tiff_all_at_once() {
+    test_decode_tiff(0, 0);
+}
+
+#[test]
+fn test_decode_tiff_many_small_writes_reads() {
+    test_decode_tiff(41, 43);
+}
+
+fn round_trip(filename: &str, literal_width: u32, wlimit: usize, rlimit: usize) {
+    // Mask the input so that every byte is a valid literal.
+    let mask = ((1u32 << literal_width) - 1) as u8;
+    let src: Vec<u8> = testlib::read_file(filename)
+        .iter()
+        .map(|&c| c & mask)
+        .collect();
+    let mut e = Encoder::default();
+    e.set_literal_width(literal_width);
+    let encoded = match testlib::decode(|dst, src| e.encode(dst, src), &src, wlimit, rlimit) {
+        Ok(encoded) => encoded,
+        Err(status) => panic!(
+            "{}: literal_width={}, wlimit={}, rlimit={}: encode: {}",
+            filename, literal_width, wlimit, rlimit, status
+        ),
+    };
+    let mut d = Decoder::default();
+    d.set_literal_width(literal_width);
+    match testlib::decode(|dst, src| d.decode(dst, src), &encoded, 0, 0) {
+        Ok(got) => assert!(
+            got == src,
+            "{}: literal_width={}, wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+            filename,
+            literal_width,
+            wlimit,
+            rlimit,
+            got.len(),
+            src.len()
+        ),
+        Err(status) => panic!(
+            "{}: literal_width={}, wlimit={}, rlimit={}: decode: {}",
+            filename, literal_width, wlimit, rlimit, status
+        ),
+    }
+}
+
+#[test]
+fn test_encode_literal_widths() {
+    for literal_width in 2..=8 {
+        round_trip("pi.txt", literal_width, 0, 0);
+    }
+}
+
+#[test]
+fn test_encode_many_small_writes_reads() {
+    round_trip("bricks-gray.indexes", 8, 41, 43);
+}
+
+fn bench_decode(name: &str, filename: &str, want_filename: &str, iters_unscaled: u64) {
+    let (literal_width, src, _) = read_golden(filename, want_filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut d = Decoder::default();
+        d.set_literal_width(literal_width);
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0) {
+            Ok(got) => got.len() as u64,
+            Err(status) => panic!("{}: {}", filename, status),
+        }
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_lzw_decode_20k() {
+    bench_decode(
+        "wuffs_lzw_decode_20k",
+        "bricks-gray.indexes.giflzw",
+        "bricks-gray.indexes",
+        50,
+    );
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_lzw_decode_100k() {
+    bench_decode("wuffs_lzw_decode_100k", "pi.txt.giflzw", "pi.txt", 10);
+}
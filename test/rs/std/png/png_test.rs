@@ -0,0 +1,218 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{ImageBuffer, ImageConfig, IoBuffer, SliceU8, Status};
+use wuffs_std_png::Decoder;
+
+/// PNGSUITE_FILENAMES are the test/data/pngsuite files, generated by
+/// script/make-pngsuite.go, other than those that are deliberately invalid.
+const PNGSUITE_FILENAMES: &[&str] = &[
+    "basn0g01", "basn0g02", "basn0g04", "basn0g08", "basn0g16", //
+    "basn2c08", "basn2c16", //
+    "basn3p01", "basn3p02", "basn3p04", "basn3p08", //
+    "basn4a08", "basn4a16", //
+    "basn6a08", "basn6a16", //
+    "trnn0g04", "trnn0g08", "trnn0g16", //
+    "trnn2c08", "trnn2c16", //
+    "trnn3p04", "trnn3p08", //
+    "s01n3p04", "s02n3p04", "s03n3p04", //
+    "s05n3p04", "s07n3p04", "s09n3p04", //
+];
+
+/// Decoded is a decoded PNG's image config and BGRA_NONPREMUL pixels.
+struct Decoded {
+    config: ImageConfig,
+    pixels: Vec<u8>,
+}
+
+/// decode decodes src, feeding the decoder at most rlimit bytes at a time (or
+/// all of src, if rlimit is zero).
+fn decode(src: &[u8], rlimit: usize, ignore_checksum: bool) -> Result<Decoded, Status> {
+    let rlimit = if rlimit == 0 { src.len() } else { rlimit };
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, rlimit, rlimit >= n);
+    let feed = |r: &mut IoBuffer| -> bool {
+        if r.closed {
+            return false;
+        }
+        r.wi = n.min(r.wi + rlimit);
+        r.closed = r.wi == n;
+        true
+    };
+
+    let mut d = Decoder::default();
+    d.set_ignore_checksum(ignore_checksum);
+    let mut config = ImageConfig::default();
+    loop {
+        match d.decode_config(&mut config, r.reader()) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    loop {
+        match d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        wuffs_base::SUSPENSION_END_OF_DATA
+    );
+    Ok(Decoded { config, pixels })
+}
+
+fn must_decode(filename: &str, rlimit: usize) -> Decoded {
+    let src = testlib::read_file(filename);
+    match decode(&src, rlimit, false) {
+        Ok(got) => got,
+        Err(status) => panic!("{}: rlimit={}: {}", filename, rlimit, status),
+    }
+}
+
+fn test_decode_input_is_a_png(rlimit: usize) {
+    let got = must_decode("bricks-color.png", rlimit);
+
+    // bricks-color.png is a 160 × 120 image.
+    assert_eq!(
+        got.config.pixel_format(),
+        wuffs_base::PIXEL_FORMAT_BGRA_NONPREMUL
+    );
+    assert_eq!(got.config.width(), 160);
+    assert_eq!(got.config.height(), 120);
+    assert_eq!(got.config.pixbuf_size(), 4 * 160 * 120);
+    assert!(
+        got.pixels == must_decode("bricks-color.png", 0).pixels,
+        "rlimit={}: pixels differ",
+        rlimit
+    );
+}
+
+#[test]
+fn test_decode_input_is_a_png_just_one_read() {
+    test_decode_input_is_a_png(0);
+}
+
+#[test]
+fn test_decode_input_is_a_png_many_big_reads() {
+    test_decode_input_is_a_png(4096);
+}
+
+#[test]
+fn test_decode_input_is_a_png_many_small_reads() {
+    test_decode_input_is_a_png(13);
+}
+
+#[test]
+fn test_decode_input_is_a_gif() {
+    let src = testlib::read_file("bricks-dither.gif");
+    assert_eq!(
+        decode(&src, 0, false).err(),
+        Some(wuffs_std_png::ERROR_BAD_HEADER)
+    );
+}
+
+#[test]
+fn test_decode_call_sequence() {
+    let mut src = testlib::read_file("bricks-color.png");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::default()),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
+
+#[test]
+fn test_decode_bad_checksum() {
+    let src = testlib::read_file("pngsuite/xcsn2c08.png");
+    assert_eq!(
+        decode(&src, 0, false).err(),
+        Some(wuffs_std_png::ERROR_BAD_CHECKSUM)
+    );
+    let got = match decode(&src, 0, true) {
+        Ok(got) => got,
+        Err(status) => panic!("ignore checksum: {}", status),
+    };
+    assert!(
+        got.pixels == must_decode("pngsuite/basn2c08.png", 0).pixels,
+        "ignore checksum: pixels differ"
+    );
+}
+
+#[test]
+fn test_decode_interlaced() {
+    // Each interlaced ("basi" etc.) file should decode to the same pixels as
+    // its non-interlaced ("basn" etc.) counterpart.
+    for name in PNGSUITE_FILENAMES {
+        let want = format!("pngsuite/{}.png", name);
+        let got = format!("pngsuite/{}i{}.png", &name[..3], &name[4..]);
+        for rlimit in [0, 7] {
+            assert!(
+                must_decode(&got, rlimit).pixels == must_decode(&want, 0).pixels,
+                "{}: rlimit={}: pixels differ",
+                got,
+                rlimit
+            );
+        }
+    }
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || match decode(&src, 0, false) {
+        Ok(got) => got.pixels.len() as u64,
+        Err(status) => panic!("{}: {}", filename, status),
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_png_decode_1k_bw() {
+    bench_decode("wuffs_png_decode_1k_bw", "pjw-thumbnail.png", 2000);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_png_decode_1k_color() {
+    bench_decode("wuffs_png_decode_1k_color", "hippopotamus.png", 1000);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_png_decode_10k() {
+    bench_decode("wuffs_png_decode_10k", "hat.png", 100);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_png_decode_100k() {
+    bench_decode("wuffs_png_decode_100k", "hibiscus.png", 10);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_png_decode_1000k() {
+    bench_decode("wuffs_png_decode_1000k", "harvesters.png", 1);
+}
@@ -0,0 +1,272 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{ImageBuffer, ImageConfig, IoBuffer, RectIeU32, SliceU8, Status};
+use wuffs_std_webp::Decoder;
+
+/// GOLDENS are lossless WebP files, without the ".lossless.webp" suffix. Each
+/// "foo.lossless.webp" file should decode to the same pixels as the "foo.png"
+/// file.
+const GOLDENS: &[&str] = &[
+    "bricks-color",
+    "bricks-dither",
+    "bricks-gray",
+    "bricks-nodither",
+    "harvesters",
+    "hat",
+    "hibiscus",
+    "hippopotamus",
+    "pjw-thumbnail",
+];
+
+/// Decoded is a decoded WebP's image config and BGRA_NONPREMUL pixels.
+struct Decoded {
+    config: ImageConfig,
+    pixels: Vec<u8>,
+}
+
+/// decode decodes src's first frame, feeding the decoder at most rlimit bytes
+/// at a time (or all of src, if rlimit is zero).
+fn decode(src: &[u8], rlimit: usize) -> Result<Decoded, Status> {
+    let rlimit = if rlimit == 0 { src.len() } else { rlimit };
+    let mut src = src.to_vec();
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, rlimit, rlimit >= n);
+    let feed = |r: &mut IoBuffer| -> bool {
+        if r.closed {
+            return false;
+        }
+        r.wi = n.min(r.wi + rlimit);
+        r.closed = r.wi == n;
+        true
+    };
+
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    loop {
+        match d.decode_config(&mut config, r.reader()) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    loop {
+        match d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])) {
+            Status::Ok => break,
+            wuffs_base::SUSPENSION_SHORT_READ if feed(&mut r) => continue,
+            status => return Err(status),
+        }
+    }
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        wuffs_base::SUSPENSION_END_OF_DATA
+    );
+    Ok(Decoded { config, pixels })
+}
+
+/// decode_png decodes the PNG file that holds the pixels that a WebP file
+/// should decode to.
+fn decode_png(filename: &str) -> Vec<u8> {
+    let mut src = testlib::read_file(filename);
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = wuffs_std_png::Decoder::default();
+    let mut config = ImageConfig::default();
+    assert_eq!(d.decode_config(&mut config, r.reader()), Status::Ok);
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        Status::Ok
+    );
+    pixels
+}
+
+fn must_decode(filename: &str, rlimit: usize) -> Decoded {
+    let src = testlib::read_file(filename);
+    match decode(&src, rlimit) {
+        Ok(got) => got,
+        Err(status) => panic!("{}: rlimit={}: {}", filename, rlimit, status),
+    }
+}
+
+fn test_decode_input_is_a_webp(rlimit: usize) {
+    let got = must_decode("bricks-color.lossless.webp", rlimit);
+
+    // bricks-color.lossless.webp is a 160 × 120 image.
+    assert_eq!(
+        got.config.pixel_format(),
+        wuffs_base::PIXEL_FORMAT_BGRA_NONPREMUL
+    );
+    assert_eq!(got.config.width(), 160);
+    assert_eq!(got.config.height(), 120);
+    assert_eq!(got.config.pixbuf_size(), 4 * 160 * 120);
+    assert!(
+        got.pixels == decode_png("bricks-color.png"),
+        "rlimit={}: pixels differ",
+        rlimit
+    );
+}
+
+#[test]
+fn test_decode_input_is_a_webp_just_one_read() {
+    test_decode_input_is_a_webp(0);
+}
+
+#[test]
+fn test_decode_input_is_a_webp_many_small_reads() {
+    test_decode_input_is_a_webp(13);
+}
+
+#[test]
+fn test_decode_input_is_a_png() {
+    let src = testlib::read_file("bricks-color.png");
+    assert_eq!(
+        decode(&src, 0).err(),
+        Some(wuffs_std_webp::ERROR_BAD_HEADER)
+    );
+}
+
+#[test]
+fn test_decode_lossy() {
+    let src = testlib::read_file("bricks-color.lossy.webp");
+    assert_eq!(
+        decode(&src, 0).err(),
+        Some(wuffs_std_webp::ERROR_TODO_UNSUPPORTED_LOSSY_IMAGE)
+    );
+}
+
+#[test]
+fn test_decode_call_sequence() {
+    let mut src = testlib::read_file("bricks-color.lossless.webp");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut ib = ImageBuffer::default();
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::default()),
+        wuffs_base::ERROR_INVALID_CALL_SEQUENCE
+    );
+}
+
+#[test]
+fn test_decode_goldens() {
+    for name in GOLDENS {
+        let got = format!("{}.lossless.webp", name);
+        let want = format!("{}.png", name);
+        for rlimit in [0, 7] {
+            assert!(
+                must_decode(&got, rlimit).pixels == decode_png(&want),
+                "{}: rlimit={}: pixels differ",
+                got,
+                rlimit
+            );
+        }
+    }
+}
+
+#[test]
+fn test_decode_animated() {
+    let mut src = testlib::read_file("animated-red-blue.webp");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut d = Decoder::default();
+    let mut config = ImageConfig::default();
+    assert_eq!(d.decode_config(&mut config, r.reader()), Status::Ok);
+    assert_eq!(config.width(), 64);
+    assert_eq!(config.height(), 48);
+    assert_eq!(config.num_loops(), 3);
+
+    let mut pixels = vec![0u8; config.pixbuf_size() as usize];
+    let mut ib = ImageBuffer::default();
+    ib.set_from_slice(config, &mut pixels);
+    let mut workbuf = vec![0u8; d.workbuf_len() as usize];
+
+    // The frame rects, as (x0, y0, x1, y1), and durations, in milliseconds.
+    let want: [(u32, u32, u32, u32, u64); 4] = [
+        (0, 0, 64, 48, 100),
+        (14, 30, 52, 40, 200),
+        (14, 0, 64, 40, 300),
+        (14, 0, 64, 40, 400),
+    ];
+    for (i, &(x0, y0, x1, y1, ms)) in want.iter().enumerate() {
+        assert_eq!(
+            d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+            Status::Ok,
+            "#{}",
+            i
+        );
+        assert_eq!(
+            ib.dirty_rect(),
+            RectIeU32 {
+                min_inclusive_x: x0,
+                min_inclusive_y: y0,
+                max_exclusive_x: x1,
+                max_exclusive_y: y1,
+            },
+            "#{}",
+            i
+        );
+        assert_eq!(ib.duration(), ms * 705_600, "#{}", i);
+        assert!(ib.blend(), "#{}", i);
+        assert_eq!(ib.disposal(), 0, "#{}", i);
+    }
+    assert_eq!(
+        d.decode_frame(&mut ib, r.reader(), SliceU8::from(&mut workbuf[..])),
+        wuffs_base::SUSPENSION_END_OF_DATA
+    );
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || match decode(&src, 0) {
+        Ok(got) => got.pixels.len() as u64,
+        Err(status) => panic!("{}: {}", filename, status),
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_webp_decode_1k_color() {
+    bench_decode(
+        "wuffs_webp_decode_1k_color",
+        "hippopotamus.lossless.webp",
+        1000,
+    );
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_webp_decode_10k() {
+    bench_decode("wuffs_webp_decode_10k", "hat.lossless.webp", 100);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_webp_decode_100k() {
+    bench_decode("wuffs_webp_decode_100k", "hibiscus.lossless.webp", 10);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_webp_decode_1000k() {
+    bench_decode("wuffs_webp_decode_1000k", "harvesters.lossless.webp", 1);
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+use wuffs_base::{IoBuffer, SliceU8, Status};
+use wuffs_std_zlib::{Decoder, Encoder};
+
+// GOLDENS' first elements are the compressed filenames. The second elements
+// are the decompressed filenames.
+const GOLDENS: &[(&str, &str)] = &[
+    ("midsummer.txt.zlib", "midsummer.txt"),
+    ("pi.txt.zlib", "pi.txt"),
+    ("romeo.txt.zlib", "romeo.txt"),
+];
+
+fn test_decode(wlimit: usize, rlimit: usize) {
+    for &(filename, want_filename) in GOLDENS {
+        let src = testlib::read_file(filename);
+        let want = testlib::read_file(want_filename);
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, wlimit, rlimit) {
+            Ok(got) => assert!(
+                got == want,
+                "{}: wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+                filename,
+                wlimit,
+                rlimit,
+                got.len(),
+                want.len()
+            ),
+            Err(status) => panic!(
+                "{}: wlimit={}, rlimit={}: {}",
+                filename, wlimit, rlimit, status
+            ),
+        }
+    }
+}
+
+#[test]
+fn test_decode_all_at_once() {
+    test_decode(0, 0);
+}
+
+#[test]
+fn test_decode_many_small_reads() {
+    test_decode(0, 7);
+}
+
+#[test]
+fn test_decode_many_small_writes() {
+    test_decode(11, 0);
+}
+
+#[test]
+fn test_decode_many_tiny_writes_reads() {
+    test_decode(1, 1);
+}
+
+#[test]
+fn test_decode_bad_checksum() {
+    let mut src = testlib::read_file("midsummer.txt.zlib");
+    let n = src.len();
+    src[n - 1] ^= 1;
+
+    let mut d = Decoder::default();
+    let got = testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0);
+    assert_eq!(got.err(), Some(wuffs_std_zlib::ERROR_BAD_CHECKSUM));
+
+    let mut d = Decoder::default();
+    d.set_ignore_checksum(true);
+    let got = testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0);
+    assert!(got.is_ok(), "ignoring the checksum: got {:?}", got.err());
+}
+
+/// round_trip encodes filename at the given compression level and then
+/// decodes the result, checking that it gives back the original.
+fn round_trip(filename: &str, level: u32, wlimit: usize, rlimit: usize) {
+    let mut e = Encoder::default();
+    e.set_level(level);
+    let mut d = Decoder::default();
+    testlib::round_trip(
+        filename,
+        level,
+        wlimit,
+        rlimit,
+        |dst, src| e.encode(dst, src),
+        |dst, src| d.decode(dst, src),
+    );
+}
+
+#[test]
+fn test_encode_levels() {
+    for level in 0..=9 {
+        round_trip("midsummer.txt", level, 0, 0);
+    }
+}
+
+#[test]
+fn test_encode_many_small_writes_reads() {
+    // Level 0 writes stored blocks and level 6 writes Huffman-coded blocks.
+    for &level in &[0, 6] {
+        round_trip("pi.txt", level, 59, 61);
+    }
+}
+
+/// decode_with_dictionary decodes midsummer.txt.romeo-dict.zlib, supplying
+/// dict when the decoder asks for a preset dictionary.
+fn decode_with_dictionary(dict: &mut [u8]) -> Result<Vec<u8>, Status> {
+    let mut src = testlib::read_file("midsummer.txt.romeo-dict.zlib");
+    let n = src.len();
+    let mut r = IoBuffer::new_reader(&mut src, n, true);
+    let mut got = vec![0u8; 1 << 16];
+    let mut w = IoBuffer::new(&mut got);
+    let mut d = Decoder::default();
+    assert_eq!(
+        d.decode(w.writer(), r.reader()),
+        wuffs_std_zlib::SUSPENSION_DICTIONARY_REQUIRED
+    );
+    // 0x57BB3EDE is the adler32 checksum of romeo.txt.
+    assert_eq!(d.dictionary_id(), 0x57BB3EDE);
+    d.set_dictionary(SliceU8::from(dict));
+    let status = d.decode(w.writer(), r.reader());
+    let wi = w.wi;
+    match status {
+        Status::Ok => Ok(got[..wi].to_vec()),
+        status => Err(status),
+    }
+}
+
+#[test]
+fn test_decode_dictionary_correct() {
+    let mut dict = testlib::read_file("romeo.txt");
+    let want = testlib::read_file("midsummer.txt");
+    match decode_with_dictionary(&mut dict) {
+        Ok(got) => assert!(got == want, "got {} bytes, want {}", got.len(), want.len()),
+        Err(status) => panic!("{}", status),
+    }
+}
+
+#[test]
+fn test_decode_dictionary_incorrect() {
+    let mut dict = testlib::read_file("midsummer.txt");
+    assert_eq!(
+        decode_with_dictionary(&mut dict).err(),
+        Some(wuffs_std_zlib::ERROR_INCORRECT_DICTIONARY)
+    );
+}
+
+fn bench_decode(name: &str, filename: &str, iters_unscaled: u64) {
+    let src = testlib::read_file(filename);
+    testlib::bench(name, iters_unscaled, || {
+        let mut d = Decoder::default();
+        match testlib::decode(|dst, src| d.decode(dst, src), &src, 0, 0) {
+            Ok(got) => got.len() as u64,
+            Err(status) => panic!("{}: {}", filename, status),
+        }
+    });
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_zlib_decode_10k() {
+    bench_decode("wuffs_zlib_decode_10k", "midsummer.txt.zlib", 300);
+}
+
+#[test]
+#[ignore]
+fn bench_wuffs_zlib_decode_100k() {
+    bench_decode("wuffs_zlib_decode_100k", "pi.txt.zlib", 30);
+}
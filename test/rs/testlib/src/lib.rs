@@ -0,0 +1,167 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//! testlib holds the helpers shared by the Rust tests of the generated Wuffs
+//! crates, the Rust counterpart of test/go/testlib.
+
+use std::env;
+use std::fs;
+use std::path::Path;
+use std::time::Instant;
+
+use wuffs_base::{IoBuffer, IoReader, IoWriter, Status};
+
+/// read_file reads a file from the test/data directory, panicking on error.
+pub fn read_file(filename: &str) -> Vec<u8> {
+    let path = Path::new(env!("CARGO_MANIFEST_DIR"))
+        .join("../../data")
+        .join(filename);
+    match fs::read(&path) {
+        Ok(data) => data,
+        Err(err) => panic!("{}: {}", filename, err),
+    }
+}
+
+/// decode runs a generated io_writer-and-io_reader decoder, such as a
+/// deflate::Decoder or a gzip::Decoder, to completion on src, returning the
+/// decoded bytes. The decoder is wrapped in the f closure, which typically
+/// calls its decode method.
+///
+/// Each f call sees at most wlimit bytes of destination buffer space and
+/// rlimit bytes of source, so that small limits exercise the generated code's
+/// suspension and resumption. A zero limit means no limit.
+pub fn decode<F>(mut f: F, src: &[u8], wlimit: usize, rlimit: usize) -> Result<Vec<u8>, Status>
+where
+    F: FnMut(IoWriter, IoReader) -> Status,
+{
+    let wlimit = if wlimit == 0 { 1 << 30 } else { wlimit };
+    let rlimit = if rlimit == 0 { 1 << 30 } else { rlimit };
+    let mut src = src.to_vec();
+    let mut dst = Vec::new();
+    let mut wdata = vec![0u8; wlimit.min(1 << 20)];
+    let mut ri = 0;
+    loop {
+        let n = rlimit.min(src.len() - ri);
+        let closed = ri + n == src.len();
+        let mut rbuf = IoBuffer::new_reader(&mut src[ri..ri + n], n, closed);
+        let mut wbuf = IoBuffer::new(&mut wdata);
+        let status = f(wbuf.writer(), rbuf.reader());
+        dst.extend_from_slice(&wbuf.data.as_slice()[..wbuf.wi]);
+        ri += rbuf.ri;
+
+        match status {
+            Status::Ok => return Ok(dst),
+            wuffs_base::SUSPENSION_SHORT_READ => {
+                if closed {
+                    return Err(wuffs_base::ERROR_UNEXPECTED_EOF);
+                }
+            }
+            wuffs_base::SUSPENSION_SHORT_WRITE => {
+                // No-op. The next iteration gives f an empty destination
+                // buffer.
+            }
+            _ => return Err(status),
+        }
+    }
+}
+
+/// round_trip encodes the filename file with the encode closure and then
+/// decodes the result with the decode closure, checking that it gives back
+/// the original. The closures are as for the decode function, with encode run
+/// under the wlimit and rlimit arguments. The level is only used in failure
+/// messages.
+pub fn round_trip<E, D>(
+    filename: &str,
+    level: u32,
+    wlimit: usize,
+    rlimit: usize,
+    encode: E,
+    decode: D,
+) where
+    E: FnMut(IoWriter, IoReader) -> Status,
+    D: FnMut(IoWriter, IoReader) -> Status,
+{
+    let src = read_file(filename);
+    let encoded = match self::decode(encode, &src, wlimit, rlimit) {
+        Ok(encoded) => encoded,
+        Err(status) => panic!(
+            "{}: level={}, wlimit={}, rlimit={}: encode: {}",
+            filename, level, wlimit, rlimit, status
+        ),
+    };
+    match self::decode(decode, &encoded, 0, 0) {
+        Ok(got) => assert!(
+            got == src,
+            "{}: level={}, wlimit={}, rlimit={}: got {} bytes, want {} bytes",
+            filename,
+            level,
+            wlimit,
+            rlimit,
+            got.len(),
+            src.len()
+        ),
+        Err(status) => panic!(
+            "{}: level={}, wlimit={}, rlimit={}: decode: {}",
+            filename, level, wlimit, rlimit, status
+        ),
+    }
+}
+
+fn env_u64(key: &str, default: u64) -> u64 {
+    match env::var(key) {
+        Ok(s) => s.parse().unwrap_or(default),
+        Err(_) => default,
+    }
+}
+
+/// bench times iters_unscaled (times the iterscale) calls to f, which returns
+/// the number of bytes processed, and prints the result in the same format as
+/// the C and Go benchmarks. Like those benchmarks, there is an initial warm up
+/// run followed by reps timed runs.
+///
+/// The iterscale and reps values come from the WUFFS_BENCH_ITERSCALE and
+/// WUFFS_BENCH_REPS environment variables, set by "wuffs-rs bench".
+pub fn bench<F>(name: &str, iters_unscaled: u64, mut f: F)
+where
+    F: FnMut() -> u64,
+{
+    let iters = iters_unscaled * env_u64("WUFFS_BENCH_ITERSCALE", 100);
+    let reps = env_u64("WUFFS_BENCH_REPS", 5);
+    for rep in 0..=reps {
+        let start = Instant::now();
+        let mut n_bytes = 0u64;
+        for _ in 0..iters {
+            n_bytes += f();
+        }
+        let nanos = (start.elapsed().as_nanos() as u64).max(1);
+        if rep == 0 {
+            println!(
+                "# (warm up) {}/rustc\t{:8}.{:06} seconds",
+                name,
+                nanos / 1_000_000_000,
+                (nanos % 1_000_000_000) / 1000
+            );
+        } else {
+            let kb_per_s = n_bytes * 1_000_000 / nanos;
+            println!(
+                "Benchmark{}/rustc\t{:8}\t{:8} ns/op\t{:8}.{:03} MB/s",
+                name,
+                iters,
+                nanos / iters,
+                kb_per_s / 1000,
+                kb_per_s % 1000
+            );
+        }
+    }
+}